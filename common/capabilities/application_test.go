@@ -26,6 +26,17 @@ func TestApplicationV11(t *testing.T) {
 	assert.NoError(t, op.Supported())
 	assert.True(t, op.ForbidDuplicateTXIdInBlock())
 	assert.True(t, op.V1_1Validation())
+	assert.False(t, op.KeyLevelEndorsement())
+}
+
+func TestApplicationV12(t *testing.T) {
+	op := NewApplicationProvider(map[string]*cb.Capability{
+		ApplicationV1_2: {},
+	})
+	assert.NoError(t, op.Supported())
+	assert.True(t, op.ForbidDuplicateTXIdInBlock())
+	assert.True(t, op.V1_1Validation())
+	assert.True(t, op.KeyLevelEndorsement())
 }
 
 func TestApplicationPvtDataExperimental(t *testing.T) {
@@ -80,6 +80,9 @@ type Orderer interface {
 	// ConsensusType returns the configured consensus type
 	ConsensusType() string
 
+	// ConsensusMetadata returns the metadata associated with the consensus type
+	ConsensusMetadata() []byte
+
 	// BatchSize returns the maximum number of messages to include in a block
 	BatchSize() *ab.BatchSize
 
@@ -94,6 +97,10 @@ type Orderer interface {
 	// used for ordering
 	KafkaBrokers() []string
 
+	// RateLimits returns the limits applied to the rate of the messages broadcast
+	// to the channel by each org and by each of its clients
+	RateLimits() []*ab.RateLimit
+
 	// Organizations returns the organizations for the ordering service
 	Organizations() map[string]Org
 
@@ -129,6 +136,10 @@ type ApplicationCapabilities interface {
 	// V1_1Validation returns true is this channel is configured to perform stricter validation
 	// of transactions (as introduced in v1.1).
 	V1_1Validation() bool
+
+	// KeyLevelEndorsement returns true if this channel supports endorsement
+	// policies expressible at a ledger key granularity
+	KeyLevelEndorsement() bool
 }
 
 // OrdererCapabilities defines the capabilities for the orderer portion of a channel
@@ -8,6 +8,7 @@ package channelconfig
 
 import (
 	"fmt"
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
@@ -40,6 +41,9 @@ const (
 
 	// KafkaBrokersKey is the cb.ConfigItem type key name for the KafkaBrokers message
 	KafkaBrokersKey = "KafkaBrokers"
+
+	// RateLimitsKey is the cb.ConfigItem type key name for the RateLimits message
+	RateLimitsKey = "RateLimits"
 )
 
 // OrdererProtos is used as the source of the OrdererConfig
@@ -49,6 +53,7 @@ type OrdererProtos struct {
 	BatchTimeout        *ab.BatchTimeout
 	KafkaBrokers        *ab.KafkaBrokers
 	ChannelRestrictions *ab.ChannelRestrictions
+	RateLimits          *ab.RateLimits
 	Capabilities        *cb.Capabilities
 }
 
@@ -89,6 +94,11 @@ func (oc *OrdererConfig) ConsensusType() string {
 	return oc.protos.ConsensusType.Type
 }
 
+// ConsensusMetadata returns the metadata associated with the consensus type
+func (oc *OrdererConfig) ConsensusMetadata() []byte {
+	return oc.protos.ConsensusType.Metadata
+}
+
 // BatchSize returns the maximum number of messages to include in a block
 func (oc *OrdererConfig) BatchSize() *ab.BatchSize {
 	return oc.protos.BatchSize
@@ -111,6 +121,12 @@ func (oc *OrdererConfig) MaxChannelsCount() uint64 {
 	return oc.protos.ChannelRestrictions.MaxCount
 }
 
+// RateLimits returns the limits applied to the rate of the messages broadcast
+// to the channel by each org and by each of its clients
+func (oc *OrdererConfig) RateLimits() []*ab.RateLimit {
+	return oc.protos.RateLimits.Limits
+}
+
 // Organizations returns a map of the orgs in the channel
 func (oc *OrdererConfig) Organizations() map[string]Org {
 	return oc.orgs
@@ -126,6 +142,7 @@ func (oc *OrdererConfig) Validate() error {
 		oc.validateBatchSize,
 		oc.validateBatchTimeout,
 		oc.validateKafkaBrokers,
+		oc.validateRateLimits,
 	} {
 		if err := validator(); err != nil {
 			return err
@@ -172,6 +189,23 @@ func (oc *OrdererConfig) validateKafkaBrokers() error {
 	return nil
 }
 
+func (oc *OrdererConfig) validateRateLimits() error {
+	mspIDs := make(map[string]struct{})
+	for _, limit := range oc.protos.RateLimits.Limits {
+		if _, ok := mspIDs[limit.MspId]; ok {
+			return fmt.Errorf("Attempted to set more than one rate limit for MSP ID '%s'", limit.MspId)
+		}
+		mspIDs[limit.MspId] = struct{}{}
+		if !(limit.Rate >= 0) || math.IsInf(limit.Rate, 1) {
+			return fmt.Errorf("Attempted to set the rate limit of MSP ID '%s' to an invalid value: %v", limit.MspId, limit.Rate)
+		}
+		if !(limit.ClientRate >= 0) || math.IsInf(limit.ClientRate, 1) {
+			return fmt.Errorf("Attempted to set the client rate limit of MSP ID '%s' to an invalid value: %v", limit.MspId, limit.ClientRate)
+		}
+	}
+	return nil
+}
+
 // This does just a barebones sanity check.
 func brokerEntrySeemsValid(broker string) bool {
 	if !strings.Contains(broker, ":") {
@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package channelconfig
 
 import (
+	"math"
 	"testing"
 
 	ab "github.com/hyperledger/fabric/protos/orderer"
@@ -55,3 +56,21 @@ func TestKafkaBrokers(t *testing.T) {
 	oc = &OrdererConfig{protos: &OrdererProtos{KafkaBrokers: &ab.KafkaBrokers{Brokers: []string{"127.0.0.1", "foo.bar", "127.0.0.1:-1", "localhost:65536", "foo.bar.:9092", ".127.0.0.1:9092", "-foo.bar:9092"}}}}
 	assert.Error(t, oc.validateKafkaBrokers(), "Invalid kafka brokers")
 }
+
+func TestRateLimits(t *testing.T) {
+	oc := &OrdererConfig{protos: &OrdererProtos{RateLimits: &ab.RateLimits{}}}
+	assert.NoError(t, oc.validateRateLimits(), "No rate limits")
+
+	oc = &OrdererConfig{protos: &OrdererProtos{RateLimits: &ab.RateLimits{Limits: []*ab.RateLimit{{Rate: 500, Burst: 1000}, {MspId: "SampleOrg", ClientRate: 10}}}}}
+	assert.NoError(t, oc.validateRateLimits(), "Valid rate limits")
+	assert.Len(t, oc.RateLimits(), 2)
+
+	oc = &OrdererConfig{protos: &OrdererProtos{RateLimits: &ab.RateLimits{Limits: []*ab.RateLimit{{MspId: "SampleOrg", Rate: 1}, {MspId: "SampleOrg", Rate: 2}}}}}
+	assert.Error(t, oc.validateRateLimits(), "Duplicate MSP ID")
+
+	oc = &OrdererConfig{protos: &OrdererProtos{RateLimits: &ab.RateLimits{Limits: []*ab.RateLimit{{Rate: -1}}}}}
+	assert.Error(t, oc.validateRateLimits(), "Negative rate")
+
+	oc = &OrdererConfig{protos: &OrdererProtos{RateLimits: &ab.RateLimits{Limits: []*ab.RateLimit{{ClientRate: math.NaN()}}}}}
+	assert.Error(t, oc.validateRateLimits(), "Invalid client rate")
+}
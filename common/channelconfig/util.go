@@ -102,13 +102,15 @@ func OrdererAddressesValue(addresses []string) *StandardConfigValue {
 	}
 }
 
-// ConsensusTypeValue returns the config definition for the orderer consensus type.
+// ConsensusTypeValue returns the config definition for the orderer consensus type,
+// along with the metadata specific to that consensus type.
 // It is a value for the /Channel/Orderer group.
-func ConsensusTypeValue(consensusType string) *StandardConfigValue {
+func ConsensusTypeValue(consensusType string, consensusMetadata []byte) *StandardConfigValue {
 	return &StandardConfigValue{
 		key: ConsensusTypeKey,
 		value: &ab.ConsensusType{
-			Type: consensusType,
+			Type:     consensusType,
+			Metadata: consensusMetadata,
 		},
 	}
 }
@@ -159,6 +161,18 @@ func KafkaBrokersValue(brokers []string) *StandardConfigValue {
 	}
 }
 
+// RateLimitsValue returns the config definition for the limits of the rate of the messages
+// broadcast by each org and by each of its clients.
+// It is a value for the /Channel/Orderer group.
+func RateLimitsValue(limits []*ab.RateLimit) *StandardConfigValue {
+	return &StandardConfigValue{
+		key: RateLimitsKey,
+		value: &ab.RateLimits{
+			Limits: limits,
+		},
+	}
+}
+
 // MSPValue returns the config definition for an MSP.
 // It is a value for the /Channel/Orderer/*, /Channel/Application/*, and /Channel/Consortiums/*/*/* groups.
 func MSPValue(mspDef *mspprotos.MSPConfig) *StandardConfigValue {
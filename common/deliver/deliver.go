@@ -25,6 +25,7 @@ import (
 	"github.com/hyperledger/fabric/common/crypto"
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/common/ledger/blockledger"
+	"github.com/hyperledger/fabric/common/metrics"
 	"github.com/hyperledger/fabric/common/policies"
 	"github.com/hyperledger/fabric/common/util"
 	"github.com/hyperledger/fabric/core/comm"
@@ -78,6 +79,7 @@ type deliverServer struct {
 	policyChecker    PolicyChecker
 	timeWindow       time.Duration
 	bindingInspector comm.BindingInspector
+	metrics          metrics.Scope
 }
 
 // NewHandlerImpl creates an implementation of the Handler interface
@@ -97,6 +99,7 @@ func NewHandlerImpl(sm SupportManager, policyChecker PolicyChecker, timeWindow t
 		policyChecker:    policyChecker,
 		timeWindow:       timeWindow,
 		bindingInspector: bindingInspector,
+		metrics:          metrics.RootScope.SubScope("deliver"),
 	}
 }
 
@@ -125,6 +128,14 @@ func (ds *deliverServer) Handle(srv ab.AtomicBroadcast_DeliverServer) error {
 }
 
 func (ds *deliverServer) deliverBlocks(srv ab.AtomicBroadcast_DeliverServer, envelope *cb.Envelope) error {
+	startTime := time.Now()
+	// the requests for a channel which is not served are not tagged with the
+	// channel ID of their header, so that the clients cannot create metrics at will
+	channelID := "unknown"
+	recorder := &responseRecorder{AtomicBroadcast_DeliverServer: srv, status: cb.Status_UNKNOWN}
+	srv = recorder
+	defer func() { recorder.record(ds.metrics, channelID, time.Since(startTime)) }()
+
 	addr := util.ExtractRemoteAddress(srv.Context())
 	payload, err := utils.UnmarshalPayload(envelope.Payload)
 	if err != nil {
@@ -156,6 +167,7 @@ func (ds *deliverServer) deliverBlocks(srv ab.AtomicBroadcast_DeliverServer, env
 		logger.Debugf("Rejecting deliver for %s because channel %s not found", addr, chdr.ChannelId)
 		return sendStatusReply(srv, cb.Status_NOT_FOUND)
 	}
+	channelID = chdr.ChannelId
 
 	erroredChan := chain.Errored()
 	select {
@@ -251,6 +263,35 @@ func (ds *deliverServer) deliverBlocks(srv ab.AtomicBroadcast_DeliverServer, env
 
 }
 
+// responseRecorder keeps track of the responses sent to the client
+// in order to record the outcome of a deliver request in the metrics
+type responseRecorder struct {
+	ab.AtomicBroadcast_DeliverServer
+	status     cb.Status
+	blocksSent int64
+}
+
+func (r *responseRecorder) Send(resp *ab.DeliverResponse) error {
+	if err := r.AtomicBroadcast_DeliverServer.Send(resp); err != nil {
+		return err
+	}
+	switch t := resp.Type.(type) {
+	case *ab.DeliverResponse_Status:
+		r.status = t.Status
+	case *ab.DeliverResponse_Block:
+		r.blocksSent++
+	}
+	return nil
+}
+
+func (r *responseRecorder) record(scope metrics.Scope, channelID string, duration time.Duration) {
+	scope = scope.Tagged(map[string]string{"channel": channelID})
+	scope.Counter("blocks_sent").Inc(r.blocksSent)
+	scope = scope.Tagged(map[string]string{"status": r.status.String()})
+	scope.Counter("requests_total").Inc(1)
+	scope.Histogram("request_duration").RecordDuration(duration)
+}
+
 func (ds *deliverServer) validateChannelHeader(srv ab.AtomicBroadcast_DeliverServer, chdr *cb.ChannelHeader) error {
 	if chdr.GetTimestamp() == nil {
 		err := errors.New("channel header in envelope must contain timestamp")
@@ -30,6 +30,7 @@ import (
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/common/ledger/blockledger"
 	"github.com/hyperledger/fabric/common/ledger/blockledger/ram"
+	mockmetrics "github.com/hyperledger/fabric/common/mocks/metrics"
 	mockpolicies "github.com/hyperledger/fabric/common/mocks/policies"
 	"github.com/hyperledger/fabric/common/policies"
 	genesisconfig "github.com/hyperledger/fabric/common/tools/configtxgen/localconfig"
@@ -851,3 +852,33 @@ func TestSeekWithMutualTLS_noTLSCert(t *testing.T) {
 		t.Fatalf("Timed out waiting to get all blocks")
 	}
 }
+
+func TestMetrics(t *testing.T) {
+	scope := mockmetrics.NewScope()
+	m := newMockD()
+	ds := initializeDeliverHandler(nil, !mutualTLS)
+	ds.(*deliverServer).metrics = scope
+	done := make(chan struct{})
+	go func() {
+		ds.Handle(m)
+		close(done)
+	}()
+
+	m.recvChan <- makeSeek(systemChainID, &ab.SeekInfo{Start: seekSpecified(3), Stop: seekSpecified(5), Behavior: ab.SeekInfo_BLOCK_UNTIL_READY})
+	for i := 0; i < 3; i++ {
+		assert.NotNil(t, (<-m.sendChan).GetBlock())
+	}
+	assert.Equal(t, cb.Status_SUCCESS, (<-m.sendChan).GetStatus())
+	m.recvChan <- makeSeek("nonexistent", &ab.SeekInfo{Start: seekNewest, Stop: seekNewest, Behavior: ab.SeekInfo_BLOCK_UNTIL_READY})
+	assert.Equal(t, cb.Status_NOT_FOUND, (<-m.sendChan).GetStatus())
+	close(m.recvChan)
+	<-done
+
+	successTags := map[string]string{"channel": systemChainID, "status": "SUCCESS"}
+	notFoundTags := map[string]string{"channel": "unknown", "status": "NOT_FOUND"}
+	assert.Equal(t, int64(3), scope.CounterValue("blocks_sent", map[string]string{"channel": systemChainID}))
+	assert.Equal(t, int64(1), scope.CounterValue("requests_total", successTags))
+	assert.Equal(t, int64(1), scope.CounterValue("requests_total", notFoundTags))
+	assert.Len(t, scope.HistogramValues("request_duration", successTags), 1)
+	assert.Len(t, scope.HistogramValues("request_duration", notFoundTags), 1)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package deliver
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/msp"
+	cb "github.com/hyperledger/fabric/protos/common"
+	bftpb "github.com/hyperledger/fabric/protos/orderer/bft"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/pkg/errors"
+)
+
+// ConsensusTypeBFT is the consensus type of the channels ordered by the BFT consenter,
+// whose blocks carry the signatures of a quorum of consenters
+const ConsensusTypeBFT = "bft"
+
+// BFTQuorum returns the number of consenters, out of n, which make a quorum of the BFT consenter.
+// Up to f = (n-1)/3 consenters may be faulty, and any two quorums of ceil((n+f+1)/2) consenters
+// intersect in at least f+1 consenters, at least one of which is correct
+func BFTQuorum(n int) int {
+	f := (n - 1) / 3
+	return (n + f + 2) / 2
+}
+
+// VerifyBlockQuorum checks that the block carries the valid signatures of a quorum of the
+// consenters of a BFT channel, so that a single orderer cannot forge a block. The check is
+// skipped for the other consensus types, whose blocks are signed by a single orderer
+func VerifyBlockQuorum(block *cb.Block, ordererConfig channelconfig.Orderer, deserializer msp.IdentityDeserializer) error {
+	if ordererConfig.ConsensusType() != ConsensusTypeBFT {
+		return nil
+	}
+	configMetadata := &bftpb.ConfigMetadata{}
+	if err := proto.Unmarshal(ordererConfig.ConsensusMetadata(), configMetadata); err != nil {
+		return errors.Wrap(err, "error unmarshaling bft config metadata")
+	}
+	var consenters [][]byte
+	for _, consenter := range configMetadata.Consenters {
+		consenters = append(consenters, consenter.Identity)
+	}
+	return VerifyQuorumSignatures(block, consenters, deserializer)
+}
+
+// VerifyQuorumSignatures checks that the SIGNATURES metadata of the block carries valid
+// signatures over the block header by a quorum of the given consenter identities
+func VerifyQuorumSignatures(block *cb.Block, consenters [][]byte, deserializer msp.IdentityDeserializer) error {
+	if block == nil || block.Header == nil || block.Metadata == nil || len(block.Metadata.Metadata) <= int(cb.BlockMetadataIndex_SIGNATURES) {
+		return errors.New("block is missing its header or metadata")
+	}
+	if len(consenters) == 0 {
+		return errors.New("no consenters to verify the block signatures against")
+	}
+	identifiers := make([]*msp.IdentityIdentifier, len(consenters))
+	for i, consenter := range consenters {
+		identity, err := deserializer.DeserializeIdentity(consenter)
+		if err != nil {
+			return errors.WithMessage(err, "failed to deserialize the identity of a consenter")
+		}
+		identifiers[i] = identity.GetIdentifier()
+	}
+	md, err := utils.GetMetadataFromBlock(block, cb.BlockMetadataIndex_SIGNATURES)
+	if err != nil {
+		return errors.Wrap(err, "error unmarshaling the signatures of the block")
+	}
+
+	headerBytes := block.Header.Bytes()
+	signed := make(map[int]bool)
+	for _, signature := range md.Signatures {
+		consenter, err := verifyConsenterSignature(signature, md.Value, headerBytes, identifiers, deserializer)
+		if err != nil {
+			logger.Debugf("Ignoring a signature of block [%d]: %s", block.Header.Number, err)
+			continue
+		}
+		signed[consenter] = true
+	}
+	if quorum := BFTQuorum(len(consenters)); len(signed) < quorum {
+		return errors.Errorf("block [%d] carries valid signatures of %d consenters, while a quorum of %d out of %d is required",
+			block.Header.Number, len(signed), quorum, len(consenters))
+	}
+	return nil
+}
+
+// verifyConsenterSignature returns the index of the consenter which made the given signature
+func verifyConsenterSignature(signature *cb.MetadataSignature, value, headerBytes []byte,
+	identifiers []*msp.IdentityIdentifier, deserializer msp.IdentityDeserializer) (int, error) {
+	shdr, err := utils.GetSignatureHeader(signature.SignatureHeader)
+	if err != nil {
+		return 0, err
+	}
+	identity, err := deserializer.DeserializeIdentity(shdr.Creator)
+	if err != nil {
+		return 0, err
+	}
+	consenter := -1
+	for i, identifier := range identifiers {
+		if *identifier == *identity.GetIdentifier() {
+			consenter = i
+			break
+		}
+	}
+	if consenter < 0 {
+		return 0, errors.Errorf("the signer %s is not a consenter", identity.GetIdentifier().Id)
+	}
+	if err := identity.Validate(); err != nil {
+		return 0, errors.WithMessage(err, "the identity of the signer is invalid")
+	}
+	if err := identity.Verify(util.ConcatenateBytes(value, signature.SignatureHeader, headerBytes), signature.Signature); err != nil {
+		return 0, errors.WithMessage(err, "the signature is invalid")
+	}
+	return consenter, nil
+}
@@ -0,0 +1,76 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package deliver
+
+import (
+	"fmt"
+	"testing"
+
+	mockconfig "github.com/hyperledger/fabric/common/mocks/config"
+	mockmsp "github.com/hyperledger/fabric/common/mocks/msp"
+	"github.com/hyperledger/fabric/common/util"
+	cb "github.com/hyperledger/fabric/protos/common"
+	bftpb "github.com/hyperledger/fabric/protos/orderer/bft"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func signBlock(block *cb.Block, signers ...*mockmsp.SigningIdentity) {
+	md := &cb.Metadata{}
+	for _, signer := range signers {
+		shdr := utils.MarshalOrPanic(utils.NewSignatureHeaderOrPanic(signer))
+		md.Signatures = append(md.Signatures, &cb.MetadataSignature{
+			SignatureHeader: shdr,
+			Signature:       utils.SignOrPanic(signer, util.ConcatenateBytes(shdr, block.Header.Bytes())),
+		})
+	}
+	block.Metadata.Metadata[cb.BlockMetadataIndex_SIGNATURES] = utils.MarshalOrPanic(md)
+}
+
+func TestBFTQuorum(t *testing.T) {
+	for n, quorum := range map[int]int{1: 1, 2: 2, 3: 2, 4: 3, 5: 4, 6: 4, 7: 5, 10: 7} {
+		assert.Equal(t, quorum, BFTQuorum(n), "quorum of %d consenters", n)
+	}
+}
+
+func TestVerifyBlockQuorum(t *testing.T) {
+	signers := mockmsp.NewSigners()
+	var consenters []*mockmsp.SigningIdentity
+	metadata := &bftpb.ConfigMetadata{}
+	for i := 0; i < 4; i++ {
+		consenter := signers.NewIdentity("OrdererOrg", fmt.Sprintf("consenter%d", i))
+		consenters = append(consenters, consenter)
+		metadata.Consenters = append(metadata.Consenters, &bftpb.Consenter{Identity: consenter.SerializeOrPanic()})
+	}
+	outsider := signers.NewIdentity("OrdererOrg", "outsider")
+	ordererConfig := &mockconfig.Orderer{ConsensusTypeVal: ConsensusTypeBFT, ConsensusMetadataVal: utils.MarshalOrPanic(metadata)}
+	block := cb.NewBlock(5, []byte("previous hash"))
+
+	signBlock(block, consenters[0], consenters[2], consenters[3])
+	assert.NoError(t, VerifyBlockQuorum(block, ordererConfig, signers))
+
+	// neither repeated signatures nor the signatures of other identities count towards the quorum
+	signBlock(block, consenters[0], consenters[2], consenters[2], outsider)
+	assert.EqualError(t, VerifyBlockQuorum(block, ordererConfig, signers),
+		"block [5] carries valid signatures of 2 consenters, while a quorum of 3 out of 4 is required")
+
+	// the signatures are made over the header of the block
+	signBlock(block, consenters[0], consenters[1], consenters[2])
+	block.Header.DataHash = []byte("forged data hash")
+	assert.EqualError(t, VerifyBlockQuorum(block, ordererConfig, signers),
+		"block [5] carries valid signatures of 0 consenters, while a quorum of 3 out of 4 is required")
+
+	// the blocks of the other consensus types are not verified
+	assert.NoError(t, VerifyBlockQuorum(block, &mockconfig.Orderer{ConsensusTypeVal: "solo"}, signers))
+
+	assert.EqualError(t, VerifyBlockQuorum(&cb.Block{}, ordererConfig, signers), "block is missing its header or metadata")
+	assert.EqualError(t, VerifyQuorumSignatures(block, nil, signers), "no consenters to verify the block signatures against")
+	assert.EqualError(t, VerifyQuorumSignatures(block, [][]byte{[]byte("garbage")}, signers),
+		"failed to deserialize the identity of a consenter: unknown identity")
+	ordererConfig.ConsensusMetadataVal = []byte("garbage")
+	assert.Contains(t, VerifyBlockQuorum(block, ordererConfig, signers).Error(), "error unmarshaling bft config metadata")
+}
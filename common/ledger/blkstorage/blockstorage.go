@@ -18,6 +18,7 @@ package blkstorage
 
 import (
 	"errors"
+	"time"
 
 	"github.com/hyperledger/fabric/common/ledger"
 	"github.com/hyperledger/fabric/protos/common"
@@ -47,11 +48,107 @@ var (
 	ErrNotFoundInIndex = errors.New("Entry not found in index")
 	// ErrAttrNotIndexed is used to indicate that an attribute is not indexed
 	ErrAttrNotIndexed = errors.New("Attribute not indexed")
+	// ErrPruned is used to indicate that the requested block (or transaction) existed but has been pruned
+	ErrPruned = errors.New("Block has been pruned")
 )
 
+// PruneAction specifies what is done with the block files that are pruned
+type PruneAction int
+
+// constants for the prune actions
+const (
+	// PruneActionArchive moves the pruned block files to the archive directory of the ledger
+	PruneActionArchive PruneAction = iota
+	// PruneActionDelete removes the pruned block files from the file system
+	PruneActionDelete
+)
+
+// RetainLastNBlocks is a prune policy that retains (at least) the latest 'NumBlocks' blocks
+// and prunes the blocks older than these
+type RetainLastNBlocks struct {
+	NumBlocks uint64
+	Action    PruneAction
+}
+
+// RetainBlocksNewerThan is a prune policy that retains the blocks that are created after the 'Timestamp'
+// and prunes the blocks older than these. The creation time of a block is the timestamp present in
+// the channel header of the first transaction in the block
+type RetainBlocksNewerThan struct {
+	Timestamp time.Time
+	Action    PruneAction
+}
+
+// SnapshotInfo contains the blocks from a snapshot of a ledger that are needed for bootstrapping a block store.
+// 'LastBlock' is the last block covered by the snapshot and 'ConfigBlock' is the latest config block as of
+// the 'LastBlock'. A bootstrapped block store expects the next block to be the one following the 'LastBlock'
+type SnapshotInfo struct {
+	LastBlock   *common.Block
+	ConfigBlock *common.Block
+}
+
+// BlockVerifier is invoked by `BlockStore.Verify` for each of the verified blocks in the order of the block numbers.
+// It performs the checks that need a context beyond the block store, such as the verification of the signatures
+// of the orderers against the channel config in force at the height of the block. An error returned by the
+// verifier is reported as an inconsistency of the type `InconsistencyBlockVerificationFailed`
+type BlockVerifier func(block *common.Block) error
+
+// InconsistencyType identifies the check that failed during the verification of a block store
+type InconsistencyType string
+
+// constants for the inconsistency types
+const (
+	// InconsistencyBlockUnreadable indicates that the bytes in the block file cannot be read as a block
+	InconsistencyBlockUnreadable = InconsistencyType("BlockUnreadable")
+	// InconsistencyMissingBlocks indicates that the block files end before the last block recorded in the checkpoint
+	InconsistencyMissingBlocks = InconsistencyType("MissingBlocks")
+	// InconsistencyBlockNumberMismatch indicates that the block is not the one expected at the position in the block files
+	InconsistencyBlockNumberMismatch = InconsistencyType("BlockNumberMismatch")
+	// InconsistencyPreviousHashMismatch indicates that `PreviousHash` in the block header is not the hash of the previous block header
+	InconsistencyPreviousHashMismatch = InconsistencyType("PreviousHashMismatch")
+	// InconsistencyDataHashMismatch indicates that `DataHash` in the block header is not the hash of the block data
+	InconsistencyDataHashMismatch = InconsistencyType("DataHashMismatch")
+	// InconsistencyIndexMismatch indicates that an index entry does not point to the location of the block or the transaction
+	InconsistencyIndexMismatch = InconsistencyType("IndexMismatch")
+	// InconsistencyBlockVerificationFailed indicates that the `BlockVerifier` returned an error for the block
+	InconsistencyBlockVerificationFailed = InconsistencyType("BlockVerificationFailed")
+)
+
+// Inconsistency describes an inconsistency found during the verification of a block store.
+// 'FileNum' and 'Offset' locate the start of the block in the block files
+type Inconsistency struct {
+	Type     InconsistencyType `json:"type"`
+	BlockNum uint64            `json:"blockNum"`
+	FileNum  int               `json:"fileNum"`
+	Offset   int64             `json:"offset"`
+	Message  string            `json:"message"`
+}
+
+// VerificationReport is the outcome of the verification of a block store. The blocks starting from 'FirstBlockNum'
+// (i.e., the blocks that are not pruned) up to the height of the block store are verified. The verification stops
+// at the first inconsistency, which is reported in 'Inconsistency'. A nil 'Inconsistency' means that all the blocks are verified
+type VerificationReport struct {
+	FirstBlockNum     uint64         `json:"firstBlockNum"`
+	Height            uint64         `json:"height"`
+	NumBlocksVerified uint64         `json:"numBlocksVerified"`
+	Inconsistency     *Inconsistency `json:"inconsistency,omitempty"`
+}
+
 // BlockStoreProvider provides an handle to a BlockStore
 type BlockStoreProvider interface {
 	CreateBlockStore(ledgerid string) (BlockStore, error)
+	// BootstrapFromSnapshot initializes an empty block store for the given ledgerid from the snapshot info.
+	// The blocks older than the 'LastBlock' in the snapshot are treated as pruned, except for the 'ConfigBlock'
+	// which (as well as the 'LastBlock') can be retrieved by the block number
+	BootstrapFromSnapshot(ledgerid string, snapshotInfo *SnapshotInfo) error
+	// Rollback removes all the blocks after the block 'lastBlockToRetain' from the block store for the given ledgerid.
+	// This method should be invoked only for a block store that is not opened
+	Rollback(ledgerid string, lastBlockToRetain uint64) error
+	// DropIndex removes the index of the block store for the given ledgerid. The index is rebuilt from the blocks
+	// when the block store is opened next. This method should be invoked only for a block store that is not opened
+	DropIndex(ledgerid string) error
+	// Remove removes the block store for the given ledgerid along with its index. The removal can safely be repeated
+	// after a failure. This method should be invoked only for a block store that is not opened
+	Remove(ledgerid string) error
 	OpenBlockStore(ledgerid string) (BlockStore, error)
 	Exists(ledgerid string) (bool, error)
 	List() ([]string, error)
@@ -71,5 +168,15 @@ type BlockStore interface {
 	RetrieveTxByBlockNumTranNum(blockNum uint64, tranNum uint64) (*common.Envelope, error)
 	RetrieveBlockByTxID(txID string) (*common.Block, error)
 	RetrieveTxValidationCodeByTxID(txID string) (peer.TxValidationCode, error)
+	// Prune removes the blocks that satisfy the given policy. Supported policies are
+	// `RetainLastNBlocks` and `RetainBlocksNewerThan`. An implementation may retain more blocks
+	// than the policy asks for (e.g., when the blocks are removed at the granularity of a file).
+	// A retrieval of a pruned block or transaction returns the error `ErrPruned`
+	Prune(policy ledger.PrunePolicy) error
+	// Verify reads all the available blocks from the storage and checks the chaining of the block hashes, the data
+	// hashes, and the consistency of the index with the locations of the blocks. The verifier (if not nil) is invoked
+	// for each block. The first inconsistency found is returned in the report. An error is returned only if the
+	// verification itself cannot be performed
+	Verify(verifier BlockVerifier) (*VerificationReport, error)
 	Shutdown()
 }
@@ -46,6 +46,7 @@ var (
 
 type blockfileMgr struct {
 	rootDir           string
+	archiveDir        string
 	conf              *Conf
 	db                *leveldbhelper.DBHandle
 	index             index
@@ -53,6 +54,8 @@ type blockfileMgr struct {
 	cpInfoCond        *sync.Cond
 	currentFileWriter *blockfileWriter
 	bcInfo            atomic.Value
+	pruneInfo         atomic.Value
+	pruneLock         sync.Mutex
 }
 
 /*
@@ -105,7 +108,7 @@ func newBlockfileMgr(id string, conf *Conf, indexConfig *blkstorage.IndexConfig,
 		panic(fmt.Sprintf("Error: %s", err))
 	}
 	// Instantiate the manager, i.e. blockFileMgr structure
-	mgr := &blockfileMgr{rootDir: rootDir, conf: conf, db: indexStore}
+	mgr := &blockfileMgr{rootDir: rootDir, archiveDir: conf.getLedgerArchiveDir(id), conf: conf, db: indexStore}
 
 	// cp = checkpointInfo, retrieve from the database the file suffix or number of where blocks were stored.
 	// It also retrieves the current size of that file and the last block number that was written to that file.
@@ -121,6 +124,11 @@ func newBlockfileMgr(id string, conf *Conf, indexConfig *blkstorage.IndexConfig,
 		}
 		logger.Debugf("Info constructed by scanning the blocks dir = %s", spew.Sdump(cpInfo))
 	} else {
+		// If a rollback was interrupted by a crash, complete the truncation of the block files before
+		// syncing the checkpoint info, as the block files may still contain the rolled back blocks
+		if err := mgr.completeRollbackIfPending(cpInfo); err != nil {
+			panic(fmt.Sprintf("Could not complete the rollback of block files: %s", err))
+		}
 		logger.Debug(`Synching block information from block storage (if needed)`)
 		syncCPInfoFromFS(rootDir, cpInfo)
 	}
@@ -144,6 +152,19 @@ func newBlockfileMgr(id string, conf *Conf, indexConfig *blkstorage.IndexConfig,
 	// Create a new KeyValue store database handler for the blocks index in the keyvalue database
 	mgr.index = newBlockIndex(indexConfig, indexStore)
 
+	// pruneInfo tracks the first block that is available (i.e., not pruned) in the block files.
+	// If a prune operation was interrupted by a crash, complete the removal of the pruned index entries and block files
+	pruneInfo, err := mgr.loadPruneInfo()
+	if err != nil {
+		panic(fmt.Sprintf("Could not get prune info from db: %s", err))
+	}
+	mgr.pruneInfo.Store(pruneInfo)
+	if pruneInfo.firstFileNum > 0 {
+		if err := mgr.completePrune(pruneInfo); err != nil {
+			panic(fmt.Sprintf("Could not complete the pruning of block files: %s", err))
+		}
+	}
+
 	// Update the manager with the checkpoint info and the file writer
 	mgr.cpInfo = cpInfo
 	mgr.currentFileWriter = currentFileWriter
@@ -354,6 +375,10 @@ func (mgr *blockfileMgr) syncIndex() error {
 		startingBlockNum = lastBlockIndexed + 1
 	} else {
 		logger.Debugf("No block indexed, Last block present in block files=[%d]", mgr.cpInfo.lastBlockNumber)
+		// start from the first block file that is not pruned
+		pruneInfo := mgr.getPruneInfo()
+		startFileNum = pruneInfo.firstFileNum
+		startingBlockNum = pruneInfo.firstBlockNum
 	}
 
 	logger.Infof("Start building index from block [%d] to last block [%d]", startingBlockNum, mgr.cpInfo.lastBlockNumber)
@@ -457,6 +482,9 @@ func (mgr *blockfileMgr) retrieveBlockByNumber(blockNum uint64) (*common.Block,
 	if blockNum == math.MaxUint64 {
 		blockNum = mgr.getBlockchainInfo().Height - 1
 	}
+	if mgr.isBlockPruned(blockNum) {
+		return mgr.retrieveSnapshotBlock(blockNum)
+	}
 
 	loc, err := mgr.index.getBlockLocByBlockNum(blockNum)
 	if err != nil {
@@ -483,6 +511,13 @@ func (mgr *blockfileMgr) retrieveTxValidationCodeByTxID(txID string) (peer.TxVal
 
 func (mgr *blockfileMgr) retrieveBlockHeaderByNumber(blockNum uint64) (*common.BlockHeader, error) {
 	logger.Debugf("retrieveBlockHeaderByNumber() - blockNum = [%d]", blockNum)
+	if mgr.isBlockPruned(blockNum) {
+		block, err := mgr.retrieveSnapshotBlock(blockNum)
+		if err != nil {
+			return nil, err
+		}
+		return block.Header, nil
+	}
 	loc, err := mgr.index.getBlockLocByBlockNum(blockNum)
 	if err != nil {
 		return nil, err
@@ -513,6 +548,9 @@ func (mgr *blockfileMgr) retrieveTransactionByID(txID string) (*common.Envelope,
 
 func (mgr *blockfileMgr) retrieveTransactionByBlockNumTranNum(blockNum uint64, tranNum uint64) (*common.Envelope, error) {
 	logger.Debugf("retrieveTransactionByBlockNumTranNum() - blockNum = [%d], tranNum = [%d]", blockNum, tranNum)
+	if mgr.isBlockPruned(blockNum) {
+		return nil, blkstorage.ErrPruned
+	}
 	loc, err := mgr.index.getTXLocByBlockNumTranNum(blockNum, tranNum)
 	if err != nil {
 		return nil, err
@@ -544,8 +582,15 @@ func (mgr *blockfileMgr) fetchTransactionEnvelope(lp *fileLocPointer) (*common.E
 }
 
 func (mgr *blockfileMgr) fetchBlockBytes(lp *fileLocPointer) ([]byte, error) {
+	if mgr.isFilePruned(lp.fileSuffixNum) {
+		return nil, blkstorage.ErrPruned
+	}
 	stream, err := newBlockfileStream(mgr.rootDir, lp.fileSuffixNum, int64(lp.offset))
 	if err != nil {
+		// the file may have been pruned after the location was looked up
+		if mgr.isFilePruned(lp.fileSuffixNum) {
+			return nil, blkstorage.ErrPruned
+		}
 		return nil, err
 	}
 	defer stream.close()
@@ -557,9 +602,16 @@ func (mgr *blockfileMgr) fetchBlockBytes(lp *fileLocPointer) ([]byte, error) {
 }
 
 func (mgr *blockfileMgr) fetchRawBytes(lp *fileLocPointer) ([]byte, error) {
+	if mgr.isFilePruned(lp.fileSuffixNum) {
+		return nil, blkstorage.ErrPruned
+	}
 	filePath := deriveBlockfilePath(mgr.rootDir, lp.fileSuffixNum)
 	reader, err := newBlockfileReader(filePath)
 	if err != nil {
+		// the file may have been pruned after the location was looked up
+		if mgr.isFilePruned(lp.fileSuffixNum) {
+			return nil, blkstorage.ErrPruned
+		}
 		return nil, err
 	}
 	defer reader.close()
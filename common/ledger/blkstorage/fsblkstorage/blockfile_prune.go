@@ -0,0 +1,289 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fsblkstorage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/ledger"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/util"
+	putil "github.com/hyperledger/fabric/protos/utils"
+)
+
+var (
+	blkMgrPruneInfoKey = []byte("blkMgrPruneInfo")
+)
+
+// prune removes the block files that contain only the blocks that satisfy the given policy.
+// The blocks are pruned at the granularity of a block file and the block file that is currently
+// being appended is never pruned. Hence, the blocks retained may be more than what the policy asks for.
+// The prune info is persisted before the index entries and the block files are removed so that,
+// in the case of a crash, the removal can be completed during the start-up
+func (mgr *blockfileMgr) prune(policy ledger.PrunePolicy) error {
+	mgr.pruneLock.Lock()
+	defer mgr.pruneLock.Unlock()
+
+	var firstBlockToRetain uint64
+	var action blkstorage.PruneAction
+	var err error
+	currentPruneInfo := mgr.getPruneInfo()
+	bcInfo := mgr.getBlockchainInfo()
+	if bcInfo.Height == 0 {
+		logger.Debugf("Block storage is empty. Nothing to prune")
+		return nil
+	}
+	lastBlockNum := bcInfo.Height - 1
+
+	switch p := policy.(type) {
+	case *blkstorage.RetainLastNBlocks:
+		if p.NumBlocks == 0 {
+			return fmt.Errorf("Invalid prune policy: the number of blocks to retain should be greater than zero")
+		}
+		if p.NumBlocks >= bcInfo.Height {
+			logger.Debugf("Block height [%d] does not exceed the number of blocks to retain [%d]. Nothing to prune",
+				bcInfo.Height, p.NumBlocks)
+			return nil
+		}
+		firstBlockToRetain = bcInfo.Height - p.NumBlocks
+		action = p.Action
+	case *blkstorage.RetainBlocksNewerThan:
+		if firstBlockToRetain, err = mgr.firstBlockNewerThan(p.Timestamp, currentPruneInfo.firstBlockNum, lastBlockNum); err != nil {
+			return err
+		}
+		action = p.Action
+	default:
+		return fmt.Errorf("Unsupported prune policy type [%T]", policy)
+	}
+	if action != blkstorage.PruneActionArchive && action != blkstorage.PruneActionDelete {
+		return fmt.Errorf("Invalid prune policy: unknown prune action [%d]", action)
+	}
+
+	if firstBlockToRetain <= currentPruneInfo.firstBlockNum {
+		logger.Debugf("Block [%d] is already the first available block. Nothing to prune", currentPruneInfo.firstBlockNum)
+		return nil
+	}
+	flp, err := mgr.index.getBlockLocByBlockNum(firstBlockToRetain)
+	if err != nil {
+		return err
+	}
+	if flp.fileSuffixNum <= currentPruneInfo.firstFileNum {
+		logger.Debugf("Block [%d] is present in the first available block file [%d]. Nothing to prune",
+			firstBlockToRetain, currentPruneInfo.firstFileNum)
+		return nil
+	}
+	firstBlockInFile, err := mgr.firstBlockNumInFile(flp.fileSuffixNum)
+	if err != nil {
+		return err
+	}
+	newPruneInfo := &pruneInfo{
+		firstBlockNum: firstBlockInFile,
+		firstFileNum:  flp.fileSuffixNum,
+		action:        action,
+	}
+	logger.Infof("Pruning blocks [%d] to [%d] (block files [%d] to [%d])", currentPruneInfo.firstBlockNum, firstBlockInFile-1,
+		currentPruneInfo.firstFileNum, flp.fileSuffixNum-1)
+	if err = mgr.savePruneInfo(newPruneInfo); err != nil {
+		return err
+	}
+	mgr.pruneInfo.Store(newPruneInfo)
+	return mgr.completePrune(newPruneInfo)
+}
+
+// completePrune removes the index entries and the block files for the blocks that are marked as pruned
+// in the given prune info. This function is idempotent and is invoked during start-up as well in order
+// to finish a prune operation that may have been interrupted by a crash
+func (mgr *blockfileMgr) completePrune(info *pruneInfo) error {
+	if err := mgr.index.pruneIndex(info.firstBlockNum); err != nil {
+		return err
+	}
+	filesInfo, err := ioutil.ReadDir(mgr.rootDir)
+	if err != nil {
+		return err
+	}
+	for _, fileInfo := range filesInfo {
+		name := fileInfo.Name()
+		if fileInfo.IsDir() || !isBlockFileName(name) {
+			continue
+		}
+		fileNum, err := strconv.Atoi(strings.TrimPrefix(name, blockfilePrefix))
+		if err != nil {
+			return err
+		}
+		if fileNum >= info.firstFileNum {
+			continue
+		}
+		if err := mgr.removeBlockfile(name, info.action); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (mgr *blockfileMgr) removeBlockfile(name string, action blkstorage.PruneAction) error {
+	filePath := filepath.Join(mgr.rootDir, name)
+	if action == blkstorage.PruneActionDelete {
+		logger.Debugf("Deleting block file [%s]", filePath)
+		return os.Remove(filePath)
+	}
+	if _, err := util.CreateDirIfMissing(mgr.archiveDir); err != nil {
+		return err
+	}
+	archivePath := filepath.Join(mgr.archiveDir, name)
+	logger.Debugf("Archiving block file [%s] to [%s]", filePath, archivePath)
+	return os.Rename(filePath, archivePath)
+}
+
+// firstBlockNewerThan returns the number of the first block (starting from the block 'startBlockNum') that
+// is created after the given timestamp. The last block is returned if no such block is found because
+// the last block is never pruned
+func (mgr *blockfileMgr) firstBlockNewerThan(timestamp time.Time, startBlockNum, lastBlockNum uint64) (uint64, error) {
+	for blockNum := startBlockNum; blockNum < lastBlockNum; blockNum++ {
+		blockTime, err := mgr.retrieveBlockTimestamp(blockNum)
+		if err != nil {
+			return 0, err
+		}
+		if blockTime.After(timestamp) {
+			return blockNum, nil
+		}
+	}
+	return lastBlockNum, nil
+}
+
+func (mgr *blockfileMgr) retrieveBlockTimestamp(blockNum uint64) (time.Time, error) {
+	block, err := mgr.retrieveBlockByNumber(blockNum)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if block.Data == nil || len(block.Data.Data) == 0 {
+		return time.Time{}, fmt.Errorf("Could not determine the timestamp of block [%d]: block contains no transaction", blockNum)
+	}
+	env, err := putil.ExtractEnvelope(block, 0)
+	if err != nil {
+		return time.Time{}, err
+	}
+	chdr, err := putil.ChannelHeader(env)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if chdr.Timestamp == nil {
+		return time.Time{}, fmt.Errorf("Could not determine the timestamp of block [%d]: timestamp is not set", blockNum)
+	}
+	return time.Unix(chdr.Timestamp.Seconds, int64(chdr.Timestamp.Nanos)).UTC(), nil
+}
+
+func (mgr *blockfileMgr) firstBlockNumInFile(fileNum int) (uint64, error) {
+	stream, err := newBlockfileStream(mgr.rootDir, fileNum, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer stream.close()
+	blockBytes, err := stream.nextBlockBytes()
+	if err != nil {
+		return 0, err
+	}
+	if blockBytes == nil {
+		return 0, fmt.Errorf("No block found in block file [%d]", fileNum)
+	}
+	info, err := extractSerializedBlockInfo(blockBytes)
+	if err != nil {
+		return 0, err
+	}
+	return info.blockHeader.Number, nil
+}
+
+func (mgr *blockfileMgr) getPruneInfo() *pruneInfo {
+	return mgr.pruneInfo.Load().(*pruneInfo)
+}
+
+func (mgr *blockfileMgr) isBlockPruned(blockNum uint64) bool {
+	return blockNum < mgr.getPruneInfo().firstBlockNum
+}
+
+func (mgr *blockfileMgr) isFilePruned(fileNum int) bool {
+	return fileNum < mgr.getPruneInfo().firstFileNum
+}
+
+func (mgr *blockfileMgr) loadPruneInfo() (*pruneInfo, error) {
+	b, err := mgr.db.Get(blkMgrPruneInfoKey)
+	if err != nil {
+		return nil, err
+	}
+	i := &pruneInfo{}
+	if b == nil {
+		return i, nil
+	}
+	if err = i.unmarshal(b); err != nil {
+		return nil, err
+	}
+	logger.Debugf("loaded pruneInfo:%s", i)
+	return i, nil
+}
+
+func (mgr *blockfileMgr) savePruneInfo(i *pruneInfo) error {
+	b, err := i.marshal()
+	if err != nil {
+		return err
+	}
+	return mgr.db.Put(blkMgrPruneInfoKey, b, true)
+}
+
+// pruneInfo tracks the first block (and the block file containing it) that is available in the block storage.
+// All the blocks (and the block files) lower than these are pruned
+type pruneInfo struct {
+	firstBlockNum uint64
+	firstFileNum  int
+	action        blkstorage.PruneAction
+}
+
+func (i *pruneInfo) marshal() ([]byte, error) {
+	buffer := proto.NewBuffer([]byte{})
+	var err error
+	if err = buffer.EncodeVarint(i.firstBlockNum); err != nil {
+		return nil, err
+	}
+	if err = buffer.EncodeVarint(uint64(i.firstFileNum)); err != nil {
+		return nil, err
+	}
+	if err = buffer.EncodeVarint(uint64(i.action)); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (i *pruneInfo) unmarshal(b []byte) error {
+	buffer := proto.NewBuffer(b)
+	var val uint64
+	var err error
+
+	if val, err = buffer.DecodeVarint(); err != nil {
+		return err
+	}
+	i.firstBlockNum = val
+
+	if val, err = buffer.DecodeVarint(); err != nil {
+		return err
+	}
+	i.firstFileNum = int(val)
+
+	if val, err = buffer.DecodeVarint(); err != nil {
+		return err
+	}
+	i.action = blkstorage.PruneAction(val)
+	return nil
+}
+
+func (i *pruneInfo) String() string {
+	return fmt.Sprintf("firstBlockNum=[%d], firstFileNum=[%d], action=[%d]", i.firstBlockNum, i.firstFileNum, i.action)
+}
@@ -0,0 +1,231 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fsblkstorage
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/protos/common"
+	putil "github.com/hyperledger/fabric/protos/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockfileMgrPruneRetainLastNBlocks(t *testing.T) {
+	env := newTestEnv(t, NewConf(testPath(), 16*1024))
+	defer env.Cleanup()
+	ledgerid := "testLedger"
+	blkfileMgrWrapper := newTestBlockfileWrapper(env, ledgerid)
+	blocks := testutil.ConstructTestBlocks(t, 50)
+	blkfileMgrWrapper.addBlocks(blocks)
+	mgr := blkfileMgrWrapper.blockfileMgr
+	assert.True(t, mgr.cpInfo.latestFileChunkSuffixNum > 2, "test expects the blocks to span multiple files")
+
+	assert.NoError(t, mgr.prune(&blkstorage.RetainLastNBlocks{NumBlocks: 10, Action: blkstorage.PruneActionArchive}))
+	pruneInfo := mgr.getPruneInfo()
+	assert.True(t, pruneInfo.firstBlockNum > 0 && pruneInfo.firstBlockNum <= 40)
+	assert.True(t, pruneInfo.firstFileNum > 0)
+	assert.Equal(t, blkstorage.PruneActionArchive, pruneInfo.action)
+	checkPrunedBlocks(t, mgr, blocks[:pruneInfo.firstBlockNum])
+	checkAvailableBlocks(t, mgr, blocks[pruneInfo.firstBlockNum:])
+	checkBlockfiles(t, mgr.rootDir, pruneInfo.firstFileNum, mgr.cpInfo.latestFileChunkSuffixNum)
+	checkBlockfiles(t, env.provider.conf.getLedgerArchiveDir(ledgerid), 0, pruneInfo.firstFileNum-1)
+
+	// the index entries keyed by the block number are removed
+	_, err := mgr.index.getBlockLocByBlockNum(0)
+	assert.Equal(t, blkstorage.ErrNotFoundInIndex, err)
+
+	// the blockchain info remains unaffected by pruning
+	assert.Equal(t, uint64(50), mgr.getBlockchainInfo().Height)
+
+	// prune with a policy that retains more blocks than what are already retained should be a no-op
+	assert.NoError(t, mgr.prune(&blkstorage.RetainLastNBlocks{NumBlocks: 45, Action: blkstorage.PruneActionArchive}))
+	assert.Equal(t, pruneInfo, mgr.getPruneInfo())
+
+	// the prune info should survive a restart and the blockfile manager should accept new blocks
+	blkfileMgrWrapper.close()
+	env.provider.Close()
+	env = newTestEnv(t, env.provider.conf)
+	blkfileMgrWrapper = newTestBlockfileWrapper(env, ledgerid)
+	defer blkfileMgrWrapper.close()
+	mgr = blkfileMgrWrapper.blockfileMgr
+	assert.Equal(t, pruneInfo, mgr.getPruneInfo())
+	checkPrunedBlocks(t, mgr, blocks[:pruneInfo.firstBlockNum])
+	checkAvailableBlocks(t, mgr, blocks[pruneInfo.firstBlockNum:])
+
+	lastBlock := blocks[len(blocks)-1]
+	newBlock := testutil.ConstructTestBlock(t, lastBlock.Header.Number+1, 10, 100)
+	newBlock.Header.PreviousHash = lastBlock.Header.Hash()
+	newBlocks := []*common.Block{newBlock}
+	blkfileMgrWrapper.addBlocks(newBlocks)
+	checkAvailableBlocks(t, mgr, append(blocks[pruneInfo.firstBlockNum:], newBlocks...))
+}
+
+func TestBlockfileMgrPruneRetainBlocksNewerThan(t *testing.T) {
+	env := newTestEnv(t, NewConf(testPath(), 16*1024))
+	defer env.Cleanup()
+	ledgerid := "testLedger"
+	blkfileMgrWrapper := newTestBlockfileWrapper(env, ledgerid)
+	defer blkfileMgrWrapper.close()
+	mgr := blkfileMgrWrapper.blockfileMgr
+
+	bg, gb := testutil.NewBlockGenerator(t, ledgerid, false)
+	olderBlocks := append([]*common.Block{gb}, bg.NextTestBlocks(29)...)
+	time.Sleep(10 * time.Millisecond)
+	timestamp := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	newerBlocks := bg.NextTestBlocks(10)
+	blkfileMgrWrapper.addBlocks(olderBlocks)
+	blkfileMgrWrapper.addBlocks(newerBlocks)
+
+	assert.NoError(t, mgr.prune(&blkstorage.RetainBlocksNewerThan{Timestamp: timestamp, Action: blkstorage.PruneActionDelete}))
+	pruneInfo := mgr.getPruneInfo()
+	assert.True(t, pruneInfo.firstBlockNum > 0 && pruneInfo.firstBlockNum <= 30)
+	allBlocks := append(olderBlocks, newerBlocks...)
+	checkPrunedBlocks(t, mgr, allBlocks[:pruneInfo.firstBlockNum])
+	checkAvailableBlocks(t, mgr, allBlocks[pruneInfo.firstBlockNum:])
+	checkBlockfiles(t, mgr.rootDir, pruneInfo.firstFileNum, mgr.cpInfo.latestFileChunkSuffixNum)
+	_, err := os.Stat(env.provider.conf.getLedgerArchiveDir(ledgerid))
+	assert.True(t, os.IsNotExist(err), "archive dir should not be created when the pruned block files are deleted")
+
+	// a timestamp older than all the blocks should not cause any pruning
+	assert.NoError(t, mgr.prune(&blkstorage.RetainBlocksNewerThan{Timestamp: time.Unix(0, 0), Action: blkstorage.PruneActionDelete}))
+	assert.Equal(t, pruneInfo, mgr.getPruneInfo())
+
+	// a timestamp newer than all the blocks should never cause pruning of the block file that is currently being appended
+	assert.NoError(t, mgr.prune(&blkstorage.RetainBlocksNewerThan{Timestamp: time.Now(), Action: blkstorage.PruneActionDelete}))
+	pruneInfo = mgr.getPruneInfo()
+	assert.Equal(t, mgr.cpInfo.latestFileChunkSuffixNum, pruneInfo.firstFileNum)
+	checkAvailableBlocks(t, mgr, allBlocks[pruneInfo.firstBlockNum:])
+}
+
+func TestBlockfileMgrPruneCompletionAfterCrash(t *testing.T) {
+	env := newTestEnv(t, NewConf(testPath(), 16*1024))
+	defer env.Cleanup()
+	ledgerid := "testLedger"
+	blkfileMgrWrapper := newTestBlockfileWrapper(env, ledgerid)
+	blocks := testutil.ConstructTestBlocks(t, 30)
+	blkfileMgrWrapper.addBlocks(blocks)
+	mgr := blkfileMgrWrapper.blockfileMgr
+
+	// simulate a crash after the prune info is persisted but before the index entries and the block files are removed
+	firstBlockInFile, err := mgr.firstBlockNumInFile(2)
+	assert.NoError(t, err)
+	pruneInfo := &pruneInfo{firstBlockNum: firstBlockInFile, firstFileNum: 2, action: blkstorage.PruneActionDelete}
+	assert.NoError(t, mgr.savePruneInfo(pruneInfo))
+	blkfileMgrWrapper.close()
+	checkBlockfiles(t, mgr.rootDir, 0, mgr.cpInfo.latestFileChunkSuffixNum)
+
+	blkfileMgrWrapper = newTestBlockfileWrapper(env, ledgerid)
+	defer blkfileMgrWrapper.close()
+	mgr = blkfileMgrWrapper.blockfileMgr
+	assert.Equal(t, pruneInfo, mgr.getPruneInfo())
+	checkBlockfiles(t, mgr.rootDir, 2, mgr.cpInfo.latestFileChunkSuffixNum)
+	checkPrunedBlocks(t, mgr, blocks[:firstBlockInFile])
+	checkAvailableBlocks(t, mgr, blocks[firstBlockInFile:])
+}
+
+func TestBlockfileMgrPruneInvalidPolicies(t *testing.T) {
+	env := newTestEnv(t, NewConf(testPath(), 0))
+	defer env.Cleanup()
+	blkfileMgrWrapper := newTestBlockfileWrapper(env, "testLedger")
+	defer blkfileMgrWrapper.close()
+	mgr := blkfileMgrWrapper.blockfileMgr
+
+	// pruning an empty block storage is a no-op
+	assert.NoError(t, mgr.prune(&blkstorage.RetainLastNBlocks{NumBlocks: 1}))
+
+	blkfileMgrWrapper.addBlocks(testutil.ConstructTestBlocks(t, 5))
+	assert.Error(t, mgr.prune("unknown-policy"))
+	assert.Error(t, mgr.prune(&blkstorage.RetainLastNBlocks{NumBlocks: 0}))
+	assert.Error(t, mgr.prune(&blkstorage.RetainLastNBlocks{NumBlocks: 1, Action: blkstorage.PruneAction(5)}))
+	assert.Equal(t, &pruneInfo{}, mgr.getPruneInfo())
+}
+
+func TestPruneInfoSerialization(t *testing.T) {
+	info := &pruneInfo{firstBlockNum: 2000, firstFileNum: 20, action: blkstorage.PruneActionDelete}
+	b, err := info.marshal()
+	assert.NoError(t, err)
+	info1 := &pruneInfo{}
+	assert.NoError(t, info1.unmarshal(b))
+	assert.Equal(t, info, info1)
+}
+
+func checkPrunedBlocks(t *testing.T, mgr *blockfileMgr, blocks []*common.Block) {
+	for _, block := range blocks {
+		_, err := mgr.retrieveBlockByNumber(block.Header.Number)
+		assert.Equal(t, blkstorage.ErrPruned, err)
+		_, err = mgr.retrieveBlockByHash(block.Header.Hash())
+		assert.Equal(t, blkstorage.ErrPruned, err)
+		_, err = mgr.retrieveTransactionByBlockNumTranNum(block.Header.Number, 0)
+		assert.Equal(t, blkstorage.ErrPruned, err)
+		txID := extractTxIDForTest(t, block)
+		_, err = mgr.retrieveTransactionByID(txID)
+		assert.Equal(t, blkstorage.ErrPruned, err)
+		_, err = mgr.retrieveBlockByTxID(txID)
+		assert.Equal(t, blkstorage.ErrPruned, err)
+		// the validation code of a transaction is retained in the index
+		_, err = mgr.retrieveTxValidationCodeByTxID(txID)
+		assert.NoError(t, err)
+		itr, err := mgr.retrieveBlocks(block.Header.Number)
+		assert.NoError(t, err)
+		_, err = itr.Next()
+		assert.Equal(t, blkstorage.ErrPruned, err)
+		itr.Close()
+	}
+}
+
+func checkAvailableBlocks(t *testing.T, mgr *blockfileMgr, blocks []*common.Block) {
+	for _, block := range blocks {
+		b, err := mgr.retrieveBlockByNumber(block.Header.Number)
+		assert.NoError(t, err)
+		assert.Equal(t, block, b)
+		b, err = mgr.retrieveBlockByHash(block.Header.Hash())
+		assert.NoError(t, err)
+		assert.Equal(t, block, b)
+		_, err = mgr.retrieveTransactionByID(extractTxIDForTest(t, block))
+		assert.NoError(t, err)
+	}
+	itr, err := mgr.retrieveBlocks(blocks[0].Header.Number)
+	assert.NoError(t, err)
+	defer itr.Close()
+	for _, block := range blocks {
+		b, err := itr.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, block, b)
+	}
+}
+
+func checkBlockfiles(t *testing.T, dir string, firstFileNum, lastFileNum int) {
+	filesInfo, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	expectedFiles := []string{}
+	for i := firstFileNum; i <= lastFileNum; i++ {
+		expectedFiles = append(expectedFiles, fileNameForTest(i))
+	}
+	actualFiles := []string{}
+	for _, fileInfo := range filesInfo {
+		actualFiles = append(actualFiles, fileInfo.Name())
+	}
+	assert.Equal(t, expectedFiles, actualFiles)
+}
+
+func fileNameForTest(fileNum int) string {
+	return deriveBlockfilePath("", fileNum)[1:]
+}
+
+func extractTxIDForTest(t *testing.T, block *common.Block) string {
+	env, err := putil.ExtractEnvelope(block, 0)
+	assert.NoError(t, err)
+	chdr, err := putil.ChannelHeader(env)
+	assert.NoError(t, err)
+	return chdr.TxId
+}
@@ -0,0 +1,173 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fsblkstorage
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+var (
+	blkMgrRollbackPendingKey = []byte("blkMgrRollbackPending")
+)
+
+// rollback removes all the blocks after the given block number from the block storage.
+// The index entries of the removed blocks are removed first, then the checkpoint info that points to the end of
+// the block 'lastBlockToRetain' is persisted along with a marker, and finally the block files are truncated.
+// If a crash happens before the checkpoint info is persisted, the index entries are rebuilt during the start-up.
+// If a crash happens after that, the truncation of the block files is completed during the start-up
+func (mgr *blockfileMgr) rollback(lastBlockToRetain uint64) error {
+	bcInfo := mgr.getBlockchainInfo()
+	if bcInfo.Height == 0 {
+		return fmt.Errorf("Block storage is empty. Nothing to rollback")
+	}
+	lastBlockNum := bcInfo.Height - 1
+	if lastBlockToRetain > lastBlockNum {
+		return fmt.Errorf("Block [%d] cannot be retained during the rollback as the last block in the block storage is [%d]",
+			lastBlockToRetain, lastBlockNum)
+	}
+	if mgr.isBlockPruned(lastBlockToRetain) {
+		return fmt.Errorf("Block storage cannot be rolled back to block [%d] as the blocks lower than [%d] are pruned",
+			lastBlockToRetain, mgr.getPruneInfo().firstBlockNum)
+	}
+	if lastBlockToRetain == lastBlockNum {
+		logger.Debugf("Block [%d] is already the last block. Nothing to rollback", lastBlockNum)
+		return nil
+	}
+
+	flp, err := mgr.index.getBlockLocByBlockNum(lastBlockToRetain + 1)
+	if err != nil {
+		return err
+	}
+	logger.Infof("Rolling back blocks [%d] to [%d] (block files [%d] to [%d])", lastBlockToRetain+1, lastBlockNum,
+		flp.fileSuffixNum, mgr.cpInfo.latestFileChunkSuffixNum)
+	blocksToRemove, err := mgr.blocksIdxInfoFrom(flp)
+	if err != nil {
+		return err
+	}
+	if err := mgr.index.rollbackIndex(lastBlockToRetain, blocksToRemove); err != nil {
+		return err
+	}
+
+	cpInfo := &checkpointInfo{
+		latestFileChunkSuffixNum: flp.fileSuffixNum,
+		latestFileChunksize:      flp.offset,
+		isChainEmpty:             false,
+		lastBlockNumber:          lastBlockToRetain,
+	}
+	batch := leveldbhelper.NewUpdateBatch()
+	cpInfoBytes, err := cpInfo.marshal()
+	if err != nil {
+		return err
+	}
+	batch.Put(blkMgrInfoKey, cpInfoBytes)
+	batch.Put(blkMgrRollbackPendingKey, []byte{})
+	if err := mgr.db.WriteBatch(batch, true); err != nil {
+		return err
+	}
+	mgr.currentFileWriter.close()
+	if err := mgr.completeRollback(cpInfo); err != nil {
+		return err
+	}
+	if mgr.currentFileWriter, err = newBlockfileWriter(deriveBlockfilePath(mgr.rootDir, cpInfo.latestFileChunkSuffixNum)); err != nil {
+		return err
+	}
+
+	lastBlockHeader, err := mgr.retrieveBlockHeaderByNumber(lastBlockToRetain)
+	if err != nil {
+		return err
+	}
+	mgr.updateCheckpoint(cpInfo)
+	mgr.bcInfo.Store(&common.BlockchainInfo{
+		Height:            lastBlockToRetain + 1,
+		CurrentBlockHash:  lastBlockHeader.Hash(),
+		PreviousBlockHash: lastBlockHeader.PreviousHash,
+	})
+	return nil
+}
+
+// completeRollback truncates the block file that is pointed to by the given checkpoint info and removes the
+// block files after it. This function is idempotent and is invoked during start-up as well in order to finish
+// a rollback that may have been interrupted by a crash
+func (mgr *blockfileMgr) completeRollback(cpInfo *checkpointInfo) error {
+	filesInfo, err := ioutil.ReadDir(mgr.rootDir)
+	if err != nil {
+		return err
+	}
+	for _, fileInfo := range filesInfo {
+		name := fileInfo.Name()
+		if fileInfo.IsDir() || !isBlockFileName(name) {
+			continue
+		}
+		fileNum, err := strconv.Atoi(strings.TrimPrefix(name, blockfilePrefix))
+		if err != nil {
+			return err
+		}
+		if fileNum <= cpInfo.latestFileChunkSuffixNum {
+			continue
+		}
+		filePath := filepath.Join(mgr.rootDir, name)
+		logger.Debugf("Deleting block file [%s]", filePath)
+		if err := os.Remove(filePath); err != nil {
+			return err
+		}
+	}
+	filePath := deriveBlockfilePath(mgr.rootDir, cpInfo.latestFileChunkSuffixNum)
+	if err := os.Truncate(filePath, int64(cpInfo.latestFileChunksize)); err != nil {
+		return err
+	}
+	return mgr.db.Delete(blkMgrRollbackPendingKey, true)
+}
+
+// completeRollbackIfPending completes a rollback that was interrupted by a crash. It must be invoked
+// before the checkpoint info is synced from the block files, as the block files may still contain the rolled back blocks
+func (mgr *blockfileMgr) completeRollbackIfPending(cpInfo *checkpointInfo) error {
+	pending, err := mgr.db.Get(blkMgrRollbackPendingKey)
+	if err != nil || pending == nil {
+		return err
+	}
+	logger.Infof("Completing the interrupted rollback of block files to block [%d]", cpInfo.lastBlockNumber)
+	return mgr.completeRollback(cpInfo)
+}
+
+// blocksIdxInfoFrom returns the index information of all the blocks starting from the given location
+func (mgr *blockfileMgr) blocksIdxInfoFrom(flp *fileLocPointer) ([]*blockIdxInfo, error) {
+	stream, err := newBlockStream(mgr.rootDir, flp.fileSuffixNum, int64(flp.offset), mgr.cpInfo.latestFileChunkSuffixNum)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.close()
+	var blocksIdxInfo []*blockIdxInfo
+	for {
+		blockBytes, placementInfo, err := stream.nextBlockBytesAndPlacementInfo()
+		if err != nil {
+			return nil, err
+		}
+		if blockBytes == nil {
+			return blocksIdxInfo, nil
+		}
+		info, err := extractSerializedBlockInfo(blockBytes)
+		if err != nil {
+			return nil, err
+		}
+		blocksIdxInfo = append(blocksIdxInfo, &blockIdxInfo{
+			blockNum:  info.blockHeader.Number,
+			blockHash: info.blockHeader.Hash(),
+			flp: &fileLocPointer{fileSuffixNum: placementInfo.fileNum,
+				locPointer: locPointer{offset: int(placementInfo.blockStartOffset)}},
+			txOffsets: info.txOffsets,
+			metadata:  info.metadata,
+		})
+	}
+}
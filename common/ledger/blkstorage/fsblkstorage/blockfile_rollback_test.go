@@ -0,0 +1,153 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fsblkstorage
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockfileMgrRollback(t *testing.T) {
+	env := newTestEnv(t, NewConf(testPath(), 16*1024))
+	defer env.Cleanup()
+	ledgerid := "testLedger"
+	blkfileMgrWrapper := newTestBlockfileWrapper(env, ledgerid)
+	blocks := testutil.ConstructTestBlocks(t, 50)
+	blkfileMgrWrapper.addBlocks(blocks)
+	mgr := blkfileMgrWrapper.blockfileMgr
+	assert.True(t, mgr.cpInfo.latestFileChunkSuffixNum > 2, "test expects the blocks to span multiple files")
+
+	assert.NoError(t, mgr.rollback(12))
+	assert.Equal(t, uint64(13), mgr.getBlockchainInfo().Height)
+	assert.Equal(t, blocks[12].Header.Hash(), mgr.getBlockchainInfo().CurrentBlockHash)
+	checkAvailableBlocks(t, mgr, blocks[:13])
+	checkRolledBackBlocks(t, mgr, blocks[13:])
+	checkBlockfiles(t, mgr.rootDir, 0, mgr.cpInfo.latestFileChunkSuffixNum)
+	lastBlockIndexed, err := mgr.index.getLastBlockIndexed()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(12), lastBlockIndexed)
+
+	// rolling back to the last block should be a no-op
+	assert.NoError(t, mgr.rollback(12))
+	assert.Equal(t, uint64(13), mgr.getBlockchainInfo().Height)
+
+	// the blockfile manager should accept the blocks following the retained blocks
+	blkfileMgrWrapper.addBlocks(blocks[13:20])
+	checkAvailableBlocks(t, mgr, blocks[:20])
+
+	// the rollback should survive a restart
+	blkfileMgrWrapper.close()
+	blkfileMgrWrapper = newTestBlockfileWrapper(env, ledgerid)
+	defer blkfileMgrWrapper.close()
+	mgr = blkfileMgrWrapper.blockfileMgr
+	assert.Equal(t, uint64(20), mgr.getBlockchainInfo().Height)
+	checkAvailableBlocks(t, mgr, blocks[:20])
+	checkRolledBackBlocks(t, mgr, blocks[20:])
+}
+
+func TestBlockfileMgrRollbackCompletionAfterCrash(t *testing.T) {
+	env := newTestEnv(t, NewConf(testPath(), 16*1024))
+	defer env.Cleanup()
+	ledgerid := "testLedger"
+	blkfileMgrWrapper := newTestBlockfileWrapper(env, ledgerid)
+	blocks := testutil.ConstructTestBlocks(t, 30)
+	blkfileMgrWrapper.addBlocks(blocks)
+	mgr := blkfileMgrWrapper.blockfileMgr
+
+	// simulate a crash after the checkpoint info is persisted but before the block files are truncated
+	flp, err := mgr.index.getBlockLocByBlockNum(6)
+	assert.NoError(t, err)
+	blocksToRemove, err := mgr.blocksIdxInfoFrom(flp)
+	assert.NoError(t, err)
+	assert.Len(t, blocksToRemove, 24)
+	assert.NoError(t, mgr.index.rollbackIndex(5, blocksToRemove))
+	cpInfo := &checkpointInfo{latestFileChunkSuffixNum: flp.fileSuffixNum, latestFileChunksize: flp.offset, lastBlockNumber: 5}
+	cpInfoBytes, err := cpInfo.marshal()
+	assert.NoError(t, err)
+	batch := leveldbhelper.NewUpdateBatch()
+	batch.Put(blkMgrInfoKey, cpInfoBytes)
+	batch.Put(blkMgrRollbackPendingKey, []byte{})
+	assert.NoError(t, mgr.db.WriteBatch(batch, true))
+	blkfileMgrWrapper.close()
+	checkBlockfiles(t, mgr.rootDir, 0, mgr.cpInfo.latestFileChunkSuffixNum)
+
+	blkfileMgrWrapper = newTestBlockfileWrapper(env, ledgerid)
+	defer blkfileMgrWrapper.close()
+	mgr = blkfileMgrWrapper.blockfileMgr
+	assert.Equal(t, uint64(6), mgr.getBlockchainInfo().Height)
+	checkBlockfiles(t, mgr.rootDir, 0, flp.fileSuffixNum)
+	checkAvailableBlocks(t, mgr, blocks[:6])
+	checkRolledBackBlocks(t, mgr, blocks[6:])
+	pending, err := mgr.db.Get(blkMgrRollbackPendingKey)
+	assert.NoError(t, err)
+	assert.Nil(t, pending)
+}
+
+func TestBlockfileMgrRollbackInvalidBlockNum(t *testing.T) {
+	env := newTestEnv(t, NewConf(testPath(), 16*1024))
+	defer env.Cleanup()
+	blkfileMgrWrapper := newTestBlockfileWrapper(env, "testLedger")
+	defer blkfileMgrWrapper.close()
+	mgr := blkfileMgrWrapper.blockfileMgr
+
+	// an empty block storage cannot be rolled back
+	assert.EqualError(t, mgr.rollback(0), "Block storage is empty. Nothing to rollback")
+
+	blocks := testutil.ConstructTestBlocks(t, 30)
+	blkfileMgrWrapper.addBlocks(blocks)
+	assert.EqualError(t, mgr.rollback(30),
+		"Block [30] cannot be retained during the rollback as the last block in the block storage is [29]")
+
+	assert.NoError(t, mgr.prune(&blkstorage.RetainLastNBlocks{NumBlocks: 5, Action: blkstorage.PruneActionDelete}))
+	firstBlockNum := mgr.getPruneInfo().firstBlockNum
+	assert.True(t, firstBlockNum > 0)
+	assert.Error(t, mgr.rollback(firstBlockNum-1))
+	assert.NoError(t, mgr.rollback(firstBlockNum))
+	checkAvailableBlocks(t, mgr, blocks[firstBlockNum:firstBlockNum+1])
+}
+
+func TestFsBlockstoreProviderRollback(t *testing.T) {
+	env := newTestEnv(t, NewConf(testPath(), 0))
+	defer env.Cleanup()
+	provider := env.provider
+	store, err := provider.OpenBlockStore("testLedger")
+	assert.NoError(t, err)
+	blocks := testutil.ConstructTestBlocks(t, 10)
+	for _, block := range blocks {
+		assert.NoError(t, store.AddBlock(block))
+	}
+	store.Shutdown()
+
+	assert.NoError(t, provider.Rollback("testLedger", 3))
+	store, err = provider.OpenBlockStore("testLedger")
+	assert.NoError(t, err)
+	defer store.Shutdown()
+	bcInfo, err := store.GetBlockchainInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), bcInfo.Height)
+	_, err = store.RetrieveBlockByNumber(4)
+	assert.Equal(t, blkstorage.ErrNotFoundInIndex, err)
+}
+
+func checkRolledBackBlocks(t *testing.T, mgr *blockfileMgr, blocks []*common.Block) {
+	for _, block := range blocks {
+		_, err := mgr.retrieveBlockByNumber(block.Header.Number)
+		assert.Equal(t, blkstorage.ErrNotFoundInIndex, err)
+		_, err = mgr.retrieveBlockByHash(block.Header.Hash())
+		assert.Equal(t, blkstorage.ErrNotFoundInIndex, err)
+		txID := extractTxIDForTest(t, block)
+		_, err = mgr.retrieveTransactionByID(txID)
+		assert.Equal(t, blkstorage.ErrNotFoundInIndex, err)
+		_, err = mgr.retrieveTxValidationCodeByTxID(txID)
+		assert.Equal(t, blkstorage.ErrNotFoundInIndex, err)
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fsblkstorage
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+var (
+	blkMgrSnapshotBlockKeyPrefix = []byte("blkMgrSnapshotBlock")
+)
+
+// bootstrapFromSnapshot initializes an empty block storage such that the next block to be added is the one
+// following the last block in the snapshot. All the blocks up to the last block in the snapshot are treated as pruned.
+// However, the last block and the config block from the snapshot are persisted in the db so that these remain
+// retrievable by the block number. The checkpoint info, the prune info, and these blocks are persisted atomically
+func (mgr *blockfileMgr) bootstrapFromSnapshot(snapshotInfo *blkstorage.SnapshotInfo) error {
+	if !mgr.cpInfo.isChainEmpty {
+		return fmt.Errorf("Block storage cannot be bootstrapped from a snapshot as it already contains [%d] blocks",
+			mgr.getBlockchainInfo().Height)
+	}
+	lastBlock := snapshotInfo.LastBlock
+	configBlock := snapshotInfo.ConfigBlock
+	if lastBlock == nil || lastBlock.Header == nil || configBlock == nil || configBlock.Header == nil {
+		return fmt.Errorf("Snapshot info should contain both the last block and the config block")
+	}
+	lastBlockNum := lastBlock.Header.Number
+	if configBlock.Header.Number > lastBlockNum {
+		return fmt.Errorf("Config block [%d] should not be newer than the last block [%d] in the snapshot",
+			configBlock.Header.Number, lastBlockNum)
+	}
+
+	cpInfo := &checkpointInfo{
+		latestFileChunkSuffixNum: mgr.cpInfo.latestFileChunkSuffixNum,
+		latestFileChunksize:      mgr.cpInfo.latestFileChunksize,
+		isChainEmpty:             false,
+		lastBlockNumber:          lastBlockNum,
+	}
+	pruneInfo := &pruneInfo{
+		firstBlockNum: lastBlockNum + 1,
+		firstFileNum:  mgr.cpInfo.latestFileChunkSuffixNum,
+		action:        blkstorage.PruneActionDelete,
+	}
+	batch := leveldbhelper.NewUpdateBatch()
+	for _, block := range []*common.Block{lastBlock, configBlock} {
+		blockBytes, _, err := serializeBlock(block)
+		if err != nil {
+			return err
+		}
+		batch.Put(constructSnapshotBlockKey(block.Header.Number), blockBytes)
+	}
+	cpInfoBytes, err := cpInfo.marshal()
+	if err != nil {
+		return err
+	}
+	batch.Put(blkMgrInfoKey, cpInfoBytes)
+	pruneInfoBytes, err := pruneInfo.marshal()
+	if err != nil {
+		return err
+	}
+	batch.Put(blkMgrPruneInfoKey, pruneInfoBytes)
+	if err := mgr.db.WriteBatch(batch, true); err != nil {
+		return err
+	}
+
+	logger.Infof("Bootstrapped block storage from snapshot. Last block in snapshot = [%d], config block = [%d]",
+		lastBlockNum, configBlock.Header.Number)
+	mgr.pruneInfo.Store(pruneInfo)
+	mgr.updateCheckpoint(cpInfo)
+	mgr.bcInfo.Store(&common.BlockchainInfo{
+		Height:            lastBlockNum + 1,
+		CurrentBlockHash:  lastBlock.Header.Hash(),
+		PreviousBlockHash: lastBlock.Header.PreviousHash,
+	})
+	return nil
+}
+
+// retrieveSnapshotBlock returns the block with the given number if the block storage was bootstrapped from
+// a snapshot that contained this block. Otherwise, the block is considered to be pruned
+func (mgr *blockfileMgr) retrieveSnapshotBlock(blockNum uint64) (*common.Block, error) {
+	blockBytes, err := mgr.db.Get(constructSnapshotBlockKey(blockNum))
+	if err != nil {
+		return nil, err
+	}
+	if blockBytes == nil {
+		return nil, blkstorage.ErrPruned
+	}
+	return deserializeBlock(blockBytes)
+}
+
+func constructSnapshotBlockKey(blockNum uint64) []byte {
+	return append(append([]byte{}, blkMgrSnapshotBlockKeyPrefix...), util.EncodeOrderPreservingVarUint64(blockNum)...)
+}
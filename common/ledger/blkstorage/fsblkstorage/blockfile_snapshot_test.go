@@ -0,0 +1,90 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fsblkstorage
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockfileMgrBootstrapFromSnapshot(t *testing.T) {
+	env := newTestEnv(t, NewConf(testPath(), 0))
+	defer env.Cleanup()
+	ledgerid := "testLedger"
+	blocks := testutil.ConstructTestBlocks(t, 20)
+	lastBlock, configBlock := blocks[14], blocks[5]
+	assert.NoError(t, env.provider.BootstrapFromSnapshot(ledgerid,
+		&blkstorage.SnapshotInfo{LastBlock: lastBlock, ConfigBlock: configBlock}))
+
+	blkfileMgrWrapper := newTestBlockfileWrapper(env, ledgerid)
+	mgr := blkfileMgrWrapper.blockfileMgr
+	checkBootstrappedBlockfileMgr := func(height uint64, currentBlock *common.Block) {
+		assert.Equal(t, &common.BlockchainInfo{
+			Height:            height,
+			CurrentBlockHash:  currentBlock.Header.Hash(),
+			PreviousBlockHash: currentBlock.Header.PreviousHash,
+		}, mgr.getBlockchainInfo())
+
+		// the last block and the config block from the snapshot are retrievable by the block number
+		b, err := mgr.retrieveBlockByNumber(lastBlock.Header.Number)
+		assert.NoError(t, err)
+		assert.Equal(t, lastBlock, b)
+		b, err = mgr.retrieveBlockByNumber(configBlock.Header.Number)
+		assert.NoError(t, err)
+		assert.Equal(t, configBlock, b)
+
+		// other blocks before the snapshot are treated as pruned
+		_, err = mgr.retrieveBlockByNumber(3)
+		assert.Equal(t, blkstorage.ErrPruned, err)
+		itr, err := mgr.retrieveBlocks(0)
+		assert.NoError(t, err)
+		_, err = itr.Next()
+		assert.Equal(t, blkstorage.ErrPruned, err)
+		itr.Close()
+	}
+	checkBootstrappedBlockfileMgr(15, lastBlock)
+
+	// the bootstrapped state should survive a restart
+	blkfileMgrWrapper.close()
+	blkfileMgrWrapper = newTestBlockfileWrapper(env, ledgerid)
+	mgr = blkfileMgrWrapper.blockfileMgr
+	checkBootstrappedBlockfileMgr(15, lastBlock)
+
+	// the block storage should only accept the block following the last block in the snapshot
+	assert.Error(t, mgr.addBlock(blocks[16]))
+	blkfileMgrWrapper.addBlocks(blocks[15:])
+	checkBootstrappedBlockfileMgr(20, blocks[19])
+	checkAvailableBlocks(t, mgr, blocks[15:])
+
+	blkfileMgrWrapper.close()
+	blkfileMgrWrapper = newTestBlockfileWrapper(env, ledgerid)
+	defer blkfileMgrWrapper.close()
+	mgr = blkfileMgrWrapper.blockfileMgr
+	checkBootstrappedBlockfileMgr(20, blocks[19])
+	checkAvailableBlocks(t, mgr, blocks[15:])
+}
+
+func TestBlockfileMgrBootstrapFromSnapshotErrors(t *testing.T) {
+	env := newTestEnv(t, NewConf(testPath(), 0))
+	defer env.Cleanup()
+	blocks := testutil.ConstructTestBlocks(t, 5)
+
+	assert.Error(t, env.provider.BootstrapFromSnapshot("ledger-missing-config-block",
+		&blkstorage.SnapshotInfo{LastBlock: blocks[4]}))
+	assert.Error(t, env.provider.BootstrapFromSnapshot("ledger-newer-config-block",
+		&blkstorage.SnapshotInfo{LastBlock: blocks[2], ConfigBlock: blocks[3]}))
+
+	blkfileMgrWrapper := newTestBlockfileWrapper(env, "ledger-non-empty")
+	blkfileMgrWrapper.addBlocks(blocks)
+	blkfileMgrWrapper.close()
+	assert.Error(t, env.provider.BootstrapFromSnapshot("ledger-non-empty",
+		&blkstorage.SnapshotInfo{LastBlock: blocks[4], ConfigBlock: blocks[0]}))
+}
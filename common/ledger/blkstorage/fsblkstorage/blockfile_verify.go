@@ -0,0 +1,201 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fsblkstorage
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// verify reads the blocks from the block files, starting from the first block that is not pruned up to the last block
+// recorded in the checkpoint info, and checks each block for the block number, the previous hash, the data hash, and
+// the index entries by the block number, the block hash and the transaction ids. The verification stops at the first
+// inconsistency found, which is returned in the report. The blocks added during the verification are not verified
+func (mgr *blockfileMgr) verify(verifier blkstorage.BlockVerifier) (*blkstorage.VerificationReport, error) {
+	mgr.cpInfoCond.L.Lock()
+	cpInfo := mgr.cpInfo
+	mgr.cpInfoCond.L.Unlock()
+	pruneInfo := mgr.getPruneInfo()
+
+	report := &blkstorage.VerificationReport{FirstBlockNum: pruneInfo.firstBlockNum}
+	if cpInfo.isChainEmpty || cpInfo.lastBlockNumber < pruneInfo.firstBlockNum {
+		// either the block storage is empty or it is bootstrapped from a snapshot and no block is added afterwards
+		if !cpInfo.isChainEmpty {
+			report.Height = cpInfo.lastBlockNumber + 1
+		}
+		return report, nil
+	}
+	report.Height = cpInfo.lastBlockNumber + 1
+
+	// the previous hash of the first available block can be checked only if the previous
+	// block is retained from a snapshot, as the blocks before the first available block are pruned
+	var previousHeader *common.BlockHeader
+	if pruneInfo.firstBlockNum > 0 {
+		previousBlock, err := mgr.retrieveSnapshotBlock(pruneInfo.firstBlockNum - 1)
+		if err != nil && err != blkstorage.ErrPruned {
+			return nil, err
+		}
+		if previousBlock != nil {
+			previousHeader = previousBlock.Header
+		}
+	}
+
+	stream, err := newBlockStream(mgr.rootDir, pruneInfo.firstFileNum, 0, cpInfo.latestFileChunkSuffixNum)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.close()
+
+	expectedFileNum, expectedOffset := pruneInfo.firstFileNum, int64(0)
+	for blockNum := pruneInfo.firstBlockNum; blockNum <= cpInfo.lastBlockNumber; blockNum++ {
+		inconsistency := &blkstorage.Inconsistency{BlockNum: blockNum, FileNum: expectedFileNum, Offset: expectedOffset}
+		blockBytes, placementInfo, err := stream.nextBlockBytesAndPlacementInfo()
+		if err != nil {
+			inconsistency.Type = blkstorage.InconsistencyBlockUnreadable
+			inconsistency.Message = fmt.Sprintf("Error while reading the block from the block files: %s", err)
+			report.Inconsistency = inconsistency
+			return report, nil
+		}
+		if blockBytes == nil {
+			inconsistency.Type = blkstorage.InconsistencyMissingBlocks
+			inconsistency.Message = fmt.Sprintf("The block files end before block [%d] while the last block is [%d]",
+				blockNum, cpInfo.lastBlockNumber)
+			report.Inconsistency = inconsistency
+			return report, nil
+		}
+		inconsistency.FileNum, inconsistency.Offset = placementInfo.fileNum, placementInfo.blockStartOffset
+		expectedFileNum = placementInfo.fileNum
+		expectedOffset = placementInfo.blockBytesOffset + int64(len(blockBytes))
+
+		block, err := deserializeBlock(blockBytes)
+		if err != nil {
+			inconsistency.Type = blkstorage.InconsistencyBlockUnreadable
+			inconsistency.Message = fmt.Sprintf("Error while deserializing the block: %s", err)
+			report.Inconsistency = inconsistency
+			return report, nil
+		}
+		inconsistencyType, msg, err := mgr.verifyBlock(block, blockBytes, placementInfo, blockNum, previousHeader)
+		if err != nil {
+			return nil, err
+		}
+		if inconsistencyType == "" && verifier != nil {
+			if err := verifier(block); err != nil {
+				inconsistencyType, msg = blkstorage.InconsistencyBlockVerificationFailed, err.Error()
+			}
+		}
+		if inconsistencyType != "" {
+			inconsistency.Type, inconsistency.Message = inconsistencyType, msg
+			report.Inconsistency = inconsistency
+			return report, nil
+		}
+		previousHeader = block.Header
+		report.NumBlocksVerified++
+		if blockNum%10000 == 0 {
+			logger.Infof("Verified block number [%d]", blockNum)
+		}
+	}
+	logger.Infof("Finished verifying [%d] blocks", report.NumBlocksVerified)
+	return report, nil
+}
+
+// verifyBlock checks the given block that is read from the block files at the given placement. An inconsistency
+// is returned as its type and a message, whereas an error is returned if the checks cannot be performed
+func (mgr *blockfileMgr) verifyBlock(block *common.Block, blockBytes []byte, placementInfo *blockPlacementInfo,
+	expectedBlockNum uint64, previousHeader *common.BlockHeader) (blkstorage.InconsistencyType, string, error) {
+	if block.Header.Number != expectedBlockNum {
+		return blkstorage.InconsistencyBlockNumberMismatch,
+			fmt.Sprintf("Expected block [%d] but found block [%d]", expectedBlockNum, block.Header.Number), nil
+	}
+	if previousHeader != nil && !bytes.Equal(block.Header.PreviousHash, previousHeader.Hash()) {
+		return blkstorage.InconsistencyPreviousHashMismatch,
+			fmt.Sprintf("PreviousHash [%x] is not the hash [%x] of the header of block [%d]",
+				block.Header.PreviousHash, previousHeader.Hash(), previousHeader.Number), nil
+	}
+	if !bytes.Equal(block.Data.Hash(), block.Header.DataHash) {
+		return blkstorage.InconsistencyDataHashMismatch,
+			fmt.Sprintf("DataHash [%x] is not the hash [%x] of the block data", block.Header.DataHash, block.Data.Hash()), nil
+	}
+
+	blockFLP := &fileLocPointer{fileSuffixNum: placementInfo.fileNum,
+		locPointer: locPointer{offset: int(placementInfo.blockStartOffset)}}
+	blockHash := block.Header.Hash()
+	for _, lookup := range []struct {
+		attr string
+		get  func() (*fileLocPointer, error)
+	}{
+		{"block number", func() (*fileLocPointer, error) { return mgr.index.getBlockLocByBlockNum(block.Header.Number) }},
+		{"block hash", func() (*fileLocPointer, error) { return mgr.index.getBlockLocByHash(blockHash) }},
+	} {
+		indexedFLP, err := lookup.get()
+		if err == blkstorage.ErrAttrNotIndexed {
+			continue
+		}
+		if err != nil && err != blkstorage.ErrNotFoundInIndex {
+			return "", "", err
+		}
+		if indexedFLP == nil || indexedFLP.fileSuffixNum != blockFLP.fileSuffixNum || indexedFLP.offset != blockFLP.offset {
+			return blkstorage.InconsistencyIndexMismatch,
+				fmt.Sprintf("The index entry for the %s points to [%s] instead of [%s]", lookup.attr, indexedFLP, blockFLP), nil
+		}
+	}
+
+	info, err := extractSerializedBlockInfo(blockBytes)
+	if err != nil {
+		return "", "", err
+	}
+	// the tx offsets are relative to the block bytes, which are preceded by the length of the block bytes in the file
+	numBytesToShift := int(placementInfo.blockBytesOffset - placementInfo.blockStartOffset)
+	for _, txOffset := range info.txOffsets {
+		if txOffset.txID == "" {
+			continue
+		}
+		txFLP := newFileLocationPointer(blockFLP.fileSuffixNum, blockFLP.offset+numBytesToShift, txOffset.loc)
+		indexedFLP, err := mgr.index.getTxLoc(txOffset.txID)
+		if err == blkstorage.ErrAttrNotIndexed {
+			break
+		}
+		if err != nil && err != blkstorage.ErrNotFoundInIndex {
+			return "", "", err
+		}
+		if indexedFLP != nil && *indexedFLP == *txFLP {
+			continue
+		}
+		// the index entry for a transaction id that appears more than once points to the last occurrence
+		overwritten, err := mgr.isIndexedByLaterTx(txOffset.txID, indexedFLP, txFLP)
+		if err != nil {
+			return "", "", err
+		}
+		if !overwritten {
+			return blkstorage.InconsistencyIndexMismatch,
+				fmt.Sprintf("The index entry for the transaction id [%s] points to [%s] instead of [%s]",
+					txOffset.txID, indexedFLP, txFLP), nil
+		}
+	}
+	return "", "", nil
+}
+
+// isIndexedByLaterTx returns true if the indexed location is after the location of the transaction
+// and a transaction with the same transaction id is present at the indexed location
+func (mgr *blockfileMgr) isIndexedByLaterTx(txID string, indexedFLP, txFLP *fileLocPointer) (bool, error) {
+	if indexedFLP == nil || indexedFLP.fileSuffixNum < txFLP.fileSuffixNum ||
+		(indexedFLP.fileSuffixNum == txFLP.fileSuffixNum && indexedFLP.offset <= txFLP.offset) {
+		return false, nil
+	}
+	txEnvelope, err := mgr.fetchTransactionEnvelope(indexedFLP)
+	if err != nil {
+		return false, nil
+	}
+	chdr, err := utils.ChannelHeader(txEnvelope)
+	if err != nil {
+		return false, nil
+	}
+	return chdr.TxId == txID, nil
+}
@@ -0,0 +1,136 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fsblkstorage
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockfileMgrVerify(t *testing.T) {
+	env := newTestEnv(t, NewConf(testPath(), 16*1024))
+	defer env.Cleanup()
+	blkfileMgrWrapper := newTestBlockfileWrapper(env, "testLedger")
+	defer blkfileMgrWrapper.close()
+	mgr := blkfileMgrWrapper.blockfileMgr
+
+	// an empty block storage has nothing to verify
+	report, err := mgr.verify(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &blkstorage.VerificationReport{}, report)
+
+	blocks := testutil.ConstructTestBlocks(t, 50)
+	blkfileMgrWrapper.addBlocks(blocks)
+	assert.True(t, mgr.cpInfo.latestFileChunkSuffixNum > 2, "test expects the blocks to span multiple files")
+
+	var verifiedBlocks []uint64
+	report, err = mgr.verify(func(block *common.Block) error {
+		verifiedBlocks = append(verifiedBlocks, block.Header.Number)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &blkstorage.VerificationReport{FirstBlockNum: 0, Height: 50, NumBlocksVerified: 50}, report)
+	assert.Len(t, verifiedBlocks, 50)
+	assert.Equal(t, uint64(49), verifiedBlocks[49])
+
+	// an error from the verifier is reported as an inconsistency and the verification stops
+	report, err = mgr.verify(func(block *common.Block) error {
+		if block.Header.Number == 20 {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(20), report.NumBlocksVerified)
+	assert.Equal(t, blkstorage.InconsistencyBlockVerificationFailed, report.Inconsistency.Type)
+	assert.Equal(t, uint64(20), report.Inconsistency.BlockNum)
+	assert.Equal(t, "invalid signature", report.Inconsistency.Message)
+	flp, err := mgr.index.getBlockLocByBlockNum(20)
+	assert.NoError(t, err)
+	assert.Equal(t, flp.fileSuffixNum, report.Inconsistency.FileNum)
+	assert.Equal(t, int64(flp.offset), report.Inconsistency.Offset)
+
+	// only the blocks that are not pruned are verified
+	assert.NoError(t, mgr.prune(&blkstorage.RetainLastNBlocks{NumBlocks: 10, Action: blkstorage.PruneActionDelete}))
+	firstBlockNum := mgr.getPruneInfo().firstBlockNum
+	report, err = mgr.verify(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &blkstorage.VerificationReport{FirstBlockNum: firstBlockNum, Height: 50, NumBlocksVerified: 50 - firstBlockNum}, report)
+}
+
+func TestBlockfileMgrVerifyTamperedBlocks(t *testing.T) {
+	testcases := []struct {
+		name                  string
+		tamper                func(blocks []*common.Block)
+		expectedInconsistency blkstorage.InconsistencyType
+	}{
+		{
+			name: "data",
+			tamper: func(blocks []*common.Block) {
+				blocks[10].Data.Data[0] = blocks[11].Data.Data[0]
+			},
+			expectedInconsistency: blkstorage.InconsistencyDataHashMismatch,
+		},
+		{
+			name: "previousHash",
+			tamper: func(blocks []*common.Block) {
+				blocks[10].Header.PreviousHash = blocks[8].Header.Hash()
+			},
+			expectedInconsistency: blkstorage.InconsistencyPreviousHashMismatch,
+		},
+	}
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			env := newTestEnv(t, NewConf(testPath(), 0))
+			defer env.Cleanup()
+			blkfileMgrWrapper := newTestBlockfileWrapper(env, "testLedger")
+			defer blkfileMgrWrapper.close()
+			blocks := testutil.ConstructTestBlocks(t, 20)
+			testcase.tamper(blocks)
+			blkfileMgrWrapper.addBlocks(blocks)
+
+			report, err := blkfileMgrWrapper.blockfileMgr.verify(nil)
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(10), report.NumBlocksVerified)
+			assert.Equal(t, testcase.expectedInconsistency, report.Inconsistency.Type)
+			assert.Equal(t, uint64(10), report.Inconsistency.BlockNum)
+		})
+	}
+}
+
+func TestBlockfileMgrVerifyTamperedIndex(t *testing.T) {
+	env := newTestEnv(t, NewConf(testPath(), 0))
+	defer env.Cleanup()
+	blkfileMgrWrapper := newTestBlockfileWrapper(env, "testLedger")
+	defer blkfileMgrWrapper.close()
+	blocks := testutil.ConstructTestBlocks(t, 20)
+	blkfileMgrWrapper.addBlocks(blocks)
+	mgr := blkfileMgrWrapper.blockfileMgr
+
+	// point the index entry of the transaction in block 7 to the transaction in block 8
+	txFLPBytes, err := mgr.db.Get(constructTxIDKey(extractTxIDForTest(t, blocks[8])))
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.db.Put(constructTxIDKey(extractTxIDForTest(t, blocks[7])), txFLPBytes, true))
+	report, err := mgr.verify(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), report.NumBlocksVerified)
+	assert.Equal(t, blkstorage.InconsistencyIndexMismatch, report.Inconsistency.Type)
+	assert.Equal(t, uint64(7), report.Inconsistency.BlockNum)
+
+	// remove the index entry of block 5 by the block number
+	assert.NoError(t, mgr.db.Delete(constructBlockNumKey(5), true))
+	report, err = mgr.verify(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), report.NumBlocksVerified)
+	assert.Equal(t, blkstorage.InconsistencyIndexMismatch, report.Inconsistency.Type)
+	assert.Equal(t, uint64(5), report.Inconsistency.BlockNum)
+}
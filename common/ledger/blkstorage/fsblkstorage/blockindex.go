@@ -38,9 +38,13 @@ const (
 	blockTxIDIdxKeyPrefix          = 'b'
 	txValidationResultIdxKeyPrefix = 'v'
 	indexCheckpointKeyStr          = "indexCheckpointKey"
+	maxIndexEntriesInDropBatch     = 1000
 )
 
 var indexCheckpointKey = []byte(indexCheckpointKeyStr)
+
+// blkMgrKeyPrefix is the common prefix of the keys that are maintained by the block file manager in the index db
+var blkMgrKeyPrefix = []byte("blkMgr")
 var errIndexEmpty = errors.New("NoBlockIndexed")
 
 type index interface {
@@ -52,6 +56,8 @@ type index interface {
 	getTXLocByBlockNumTranNum(blockNum uint64, tranNum uint64) (*fileLocPointer, error)
 	getBlockLocByTxID(txID string) (*fileLocPointer, error)
 	getTxValidationCodeByTxID(txID string) (peer.TxValidationCode, error)
+	pruneIndex(firstBlockToRetain uint64) error
+	rollbackIndex(lastBlockToRetain uint64, blocksToRemove []*blockIdxInfo) error
 }
 
 type blockIdxInfo struct {
@@ -263,6 +269,88 @@ func (index *blockIndex) getTxValidationCodeByTxID(txID string) (peer.TxValidati
 	return result, nil
 }
 
+// pruneIndex removes the index entries that are keyed by the block number (i.e., the entries for the indexes
+// 'IndexableAttrBlockNum' and 'IndexableAttrBlockNumTranNum') for the blocks lower than the 'firstBlockToRetain'.
+// The entries that are keyed by the block hash or by the transaction id are retained. This allows the lookups
+// by these attributes to report a pruned block as pruned (as opposed to not found) and also keeps the
+// transaction ids in the pruned blocks visible to the duplicate transaction id check
+func (index *blockIndex) pruneIndex(firstBlockToRetain uint64) error {
+	batch := leveldbhelper.NewUpdateBatch()
+	firstBlockToRetainBytes := util.EncodeOrderPreservingVarUint64(firstBlockToRetain)
+	for _, keyPrefix := range []byte{blockNumIdxKeyPrefix, blockNumTranNumIdxKeyPrefix} {
+		itr := index.db.GetIterator([]byte{keyPrefix}, append([]byte{keyPrefix}, firstBlockToRetainBytes...))
+		for itr.Next() {
+			batch.Delete(itr.Key())
+		}
+		err := itr.Error()
+		itr.Release()
+		if err != nil {
+			return err
+		}
+	}
+	if len(batch.KVs) == 0 {
+		return nil
+	}
+	logger.Debugf("Removing [%d] index entries for the blocks lower than block number [%d]", len(batch.KVs), firstBlockToRetain)
+	return index.db.WriteBatch(batch, true)
+}
+
+// rollbackIndex removes the index entries for the given blocks and moves the index checkpoint back to the
+// 'lastBlockToRetain'. The removal is idempotent, as the entries that are not present are simply ignored
+func (index *blockIndex) rollbackIndex(lastBlockToRetain uint64, blocksToRemove []*blockIdxInfo) error {
+	batch := leveldbhelper.NewUpdateBatch()
+	for _, blockIdxInfo := range blocksToRemove {
+		batch.Delete(constructBlockHashKey(blockIdxInfo.blockHash))
+		batch.Delete(constructBlockNumKey(blockIdxInfo.blockNum))
+		for txNum, txOffset := range blockIdxInfo.txOffsets {
+			batch.Delete(constructTxIDKey(txOffset.txID))
+			batch.Delete(constructBlockNumTranNumKey(blockIdxInfo.blockNum, uint64(txNum)))
+			batch.Delete(constructBlockTxIDKey(txOffset.txID))
+			batch.Delete(constructTxValidationCodeIDKey(txOffset.txID))
+		}
+	}
+	batch.Put(indexCheckpointKey, encodeBlockNum(lastBlockToRetain))
+	logger.Debugf("Removing the index entries for [%d] blocks after block number [%d]", len(blocksToRemove), lastBlockToRetain)
+	return index.db.WriteBatch(batch, true)
+}
+
+// dropIndex removes all the index entries so that the index is rebuilt from the block files when the block store
+// is opened next. The keys maintained by the block file manager (e.g., the checkpoint info and the prune info) share
+// the db with the index and are retained. The index checkpoint is removed first and the entries are removed
+// in multiple batches afterwards, hence the removal can safely be repeated after a crash
+func (index *blockIndex) dropIndex() error {
+	if err := index.db.Delete(indexCheckpointKey, true); err != nil {
+		return err
+	}
+	itr := index.db.GetIterator(nil, nil)
+	defer itr.Release()
+	batch := leveldbhelper.NewUpdateBatch()
+	numEntries := 0
+	for itr.Next() {
+		key := itr.Key()
+		if bytes.HasPrefix(key, blkMgrKeyPrefix) {
+			continue
+		}
+		batch.Delete(key)
+		numEntries++
+		if len(batch.KVs) < maxIndexEntriesInDropBatch {
+			continue
+		}
+		if err := index.db.WriteBatch(batch, true); err != nil {
+			return err
+		}
+		batch = leveldbhelper.NewUpdateBatch()
+	}
+	if err := itr.Error(); err != nil {
+		return err
+	}
+	logger.Debugf("Removing [%d] index entries", numEntries)
+	if len(batch.KVs) == 0 {
+		return nil
+	}
+	return index.db.WriteBatch(batch, true)
+}
+
 func constructBlockNumKey(blockNum uint64) []byte {
 	blkNumBytes := util.EncodeOrderPreservingVarUint64(blockNum)
 	return append([]byte{blockNumIdxKeyPrefix}, blkNumBytes...)
@@ -58,6 +58,14 @@ func (i *noopIndex) getTxValidationCodeByTxID(txID string) (peer.TxValidationCod
 	return peer.TxValidationCode(-1), nil
 }
 
+func (i *noopIndex) pruneIndex(firstBlockToRetain uint64) error {
+	return nil
+}
+
+func (i *noopIndex) rollbackIndex(lastBlockToRetain uint64, blocksToRemove []*blockIdxInfo) error {
+	return nil
+}
+
 func TestBlockIndexSync(t *testing.T) {
 	testBlockIndexSync(t, 10, 5, false)
 	testBlockIndexSync(t, 10, 5, true)
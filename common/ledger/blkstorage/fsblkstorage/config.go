@@ -22,7 +22,9 @@ const (
 	// ChainsDir is the name of the directory containing the channel ledgers.
 	ChainsDir = "chains"
 	// IndexDir is the name of the directory containing all block indexes across ledgers.
-	IndexDir                = "index"
+	IndexDir = "index"
+	// ArchiveDir is the name of the directory containing the pruned block files (if archived) across ledgers.
+	ArchiveDir              = "archive"
 	defaultMaxBlockfileSize = 64 * 1024 * 1024 // bytes
 )
 
@@ -52,3 +54,11 @@ func (conf *Conf) getChainsDir() string {
 func (conf *Conf) getLedgerBlockDir(ledgerid string) string {
 	return filepath.Join(conf.getChainsDir(), ledgerid)
 }
+
+func (conf *Conf) getArchiveDir() string {
+	return filepath.Join(conf.blockStorageDir, ArchiveDir)
+}
+
+func (conf *Conf) getLedgerArchiveDir(ledgerid string) string {
+	return filepath.Join(conf.getArchiveDir(), ledgerid)
+}
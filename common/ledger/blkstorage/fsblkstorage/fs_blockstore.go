@@ -87,6 +87,16 @@ func (store *fsBlockStore) RetrieveTxValidationCodeByTxID(txID string) (peer.TxV
 }
 
 // Shutdown shuts down the block store
+// Prune removes the blocks that satisfy the given prune policy
+func (store *fsBlockStore) Prune(policy ledger.PrunePolicy) error {
+	return store.fileMgr.prune(policy)
+}
+
+// Verify verifies the blocks in the block files and the index
+func (store *fsBlockStore) Verify(verifier blkstorage.BlockVerifier) (*blkstorage.VerificationReport, error) {
+	return store.fileMgr.verify(verifier)
+}
+
 func (store *fsBlockStore) Shutdown() {
 	logger.Debugf("closing fs blockStore:%s", store.id)
 	store.fileMgr.close()
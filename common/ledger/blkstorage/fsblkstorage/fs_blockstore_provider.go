@@ -17,6 +17,8 @@ limitations under the License.
 package fsblkstorage
 
 import (
+	"os"
+
 	"github.com/hyperledger/fabric/common/ledger/blkstorage"
 	"github.com/hyperledger/fabric/common/ledger/util"
 	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
@@ -48,6 +50,42 @@ func (p *FsBlockstoreProvider) OpenBlockStore(ledgerid string) (blkstorage.Block
 	return newFsBlockStore(ledgerid, p.conf, p.indexConfig, indexStoreHandle), nil
 }
 
+// BootstrapFromSnapshot initializes the block store for the given ledgerid from the snapshot info.
+// This method should be invoked only for a block store that is empty and not opened
+func (p *FsBlockstoreProvider) BootstrapFromSnapshot(ledgerid string, snapshotInfo *blkstorage.SnapshotInfo) error {
+	indexStoreHandle := p.leveldbProvider.GetDBHandle(ledgerid)
+	mgr := newBlockfileMgr(ledgerid, p.conf, p.indexConfig, indexStoreHandle)
+	defer mgr.close()
+	return mgr.bootstrapFromSnapshot(snapshotInfo)
+}
+
+// Rollback removes all the blocks after the block 'lastBlockToRetain' from the block store for the given ledgerid.
+// This method should be invoked only for a block store that is not opened
+func (p *FsBlockstoreProvider) Rollback(ledgerid string, lastBlockToRetain uint64) error {
+	indexStoreHandle := p.leveldbProvider.GetDBHandle(ledgerid)
+	mgr := newBlockfileMgr(ledgerid, p.conf, p.indexConfig, indexStoreHandle)
+	defer mgr.close()
+	return mgr.rollback(lastBlockToRetain)
+}
+
+// DropIndex removes the index of the block store for the given ledgerid. The index is rebuilt from the block files
+// when the block store is opened next. This method should be invoked only for a block store that is not opened
+func (p *FsBlockstoreProvider) DropIndex(ledgerid string) error {
+	indexStoreHandle := p.leveldbProvider.GetDBHandle(ledgerid)
+	return newBlockIndex(p.indexConfig, indexStoreHandle).dropIndex()
+}
+
+// Remove removes the block store for the given ledgerid along with its index. The index entries (including the
+// checkpoint of the block files) are removed before the block files so that a repeated removal after a failure, or
+// a block store later created with the same ledgerid, does not see stale entries.
+// This method should be invoked only for a block store that is not opened
+func (p *FsBlockstoreProvider) Remove(ledgerid string) error {
+	if err := p.leveldbProvider.GetDBHandle(ledgerid).DeleteAll(); err != nil {
+		return err
+	}
+	return os.RemoveAll(p.conf.getLedgerBlockDir(ledgerid))
+}
+
 // Exists tells whether the BlockStore with given id exists
 func (p *FsBlockstoreProvider) Exists(ledgerid string) (bool, error) {
 	exists, _, err := util.FileExists(p.conf.getLedgerBlockDir(ledgerid))
@@ -27,6 +27,7 @@ import (
 	"github.com/hyperledger/fabric/protos/common"
 	"github.com/hyperledger/fabric/protos/peer"
 	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestMultipleBlockStores(t *testing.T) {
@@ -150,3 +151,43 @@ func TestBlockStoreProvider(t *testing.T) {
 func constructLedgerid(id int) string {
 	return fmt.Sprintf("ledger_%d", id)
 }
+
+func TestDropIndex(t *testing.T) {
+	env := newTestEnv(t, NewConf(testPath(), 0))
+	defer env.Cleanup()
+	provider := env.provider
+	store, err := provider.OpenBlockStore("testLedger")
+	assert.NoError(t, err)
+	blocks := testutil.ConstructTestBlocks(t, 10)
+	for _, block := range blocks {
+		assert.NoError(t, store.AddBlock(block))
+	}
+	store.Shutdown()
+
+	assert.NoError(t, provider.DropIndex("testLedger"))
+	index := newBlockIndex(provider.indexConfig, provider.leveldbProvider.GetDBHandle("testLedger"))
+	_, err = index.getLastBlockIndexed()
+	assert.Equal(t, errIndexEmpty, err)
+	_, err = index.getBlockLocByBlockNum(5)
+	assert.Equal(t, blkstorage.ErrNotFoundInIndex, err)
+	// dropping the index again should be a no-op
+	assert.NoError(t, provider.DropIndex("testLedger"))
+
+	// the index should be rebuilt when the block store is opened
+	store, err = provider.OpenBlockStore("testLedger")
+	assert.NoError(t, err)
+	defer store.Shutdown()
+	bcInfo, err := store.GetBlockchainInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), bcInfo.Height)
+	for _, block := range blocks {
+		b, err := store.RetrieveBlockByNumber(block.Header.Number)
+		assert.NoError(t, err)
+		assert.Equal(t, block, b)
+		b, err = store.RetrieveBlockByHash(block.Header.Hash())
+		assert.NoError(t, err)
+		assert.Equal(t, block, b)
+		_, err = store.RetrieveTxByID(extractTxIDForTest(t, block))
+		assert.NoError(t, err)
+	}
+}
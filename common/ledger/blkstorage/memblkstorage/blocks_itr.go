@@ -0,0 +1,73 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package memblkstorage
+
+import (
+	"sync"
+
+	"github.com/hyperledger/fabric/common/ledger"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+)
+
+// blocksItr - an iterator for iterating over a sequence of blocks
+type blocksItr struct {
+	store              *memBlockStore
+	blockNumToRetrieve uint64
+	closeMarker        bool
+	closeMarkerLock    *sync.Mutex
+}
+
+func newBlockItr(store *memBlockStore, startBlockNum uint64) *blocksItr {
+	return &blocksItr{store, startBlockNum, false, &sync.Mutex{}}
+}
+
+// waitForBlock waits till the given block is added to the block store or the iterator is closed
+func (itr *blocksItr) waitForBlock(blockNum uint64) {
+	itr.store.infoCond.L.Lock()
+	defer itr.store.infoCond.L.Unlock()
+	for itr.store.info.height <= blockNum && !itr.shouldClose() {
+		logger.Debugf("Going to wait for newer blocks. height=[%d], waitForBlockNum=[%d]",
+			itr.store.info.height, blockNum)
+		itr.store.infoCond.Wait()
+		logger.Debugf("Came out of wait. height=[%d]", itr.store.info.height)
+	}
+}
+
+func (itr *blocksItr) shouldClose() bool {
+	itr.closeMarkerLock.Lock()
+	defer itr.closeMarkerLock.Unlock()
+	return itr.closeMarker
+}
+
+// Next moves the cursor to next block and returns true iff the iterator is not exhausted
+func (itr *blocksItr) Next() (ledger.QueryResult, error) {
+	itr.waitForBlock(itr.blockNumToRetrieve)
+	itr.closeMarkerLock.Lock()
+	defer itr.closeMarkerLock.Unlock()
+	if itr.closeMarker {
+		return nil, nil
+	}
+	if itr.store.isBlockPruned(itr.blockNumToRetrieve) {
+		return nil, blkstorage.ErrPruned
+	}
+	block, err := itr.store.fetchBlock(itr.blockNumToRetrieve)
+	if err != nil {
+		return nil, err
+	}
+	itr.blockNumToRetrieve++
+	return block, nil
+}
+
+// Close releases any resources held by the iterator
+func (itr *blocksItr) Close() {
+	itr.closeMarkerLock.Lock()
+	defer itr.closeMarkerLock.Unlock()
+	itr.closeMarker = true
+	itr.store.infoCond.L.Lock()
+	defer itr.store.infoCond.L.Unlock()
+	itr.store.infoCond.Broadcast()
+}
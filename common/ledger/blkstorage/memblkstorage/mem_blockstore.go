@@ -0,0 +1,480 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package memblkstorage
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/ledger"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	ledgerUtil "github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	putil "github.com/hyperledger/fabric/protos/utils"
+)
+
+const (
+	blockKeyPrefix         = byte('b')
+	snapshotBlockKeyPrefix = byte('s')
+	blockHashIdxKeyPrefix  = byte('h')
+	txIDIdxKeyPrefix       = byte('t')
+)
+
+var (
+	chainInfoKey       = []byte{'i'}
+	indexCheckpointKey = []byte{'x'}
+)
+
+// memBlockStore keeps the serialized blocks of a ledger in an in-memory db, keyed by the block numbers.
+// The index entries (by the block hash and by the transaction id) and the chain info are kept in the same db
+// and are updated along with the block in a single batch
+type memBlockStore struct {
+	id         string
+	db         *leveldbhelper.DBHandle
+	indexItems map[blkstorage.IndexableAttr]bool
+	writeLock  sync.Mutex // serializes the updates of the blocks and the chain info
+
+	infoCond *sync.Cond // guards 'info' and is broadcast when the chain info changes
+	info     *chainInfo
+	bcInfo   atomic.Value
+}
+
+func newMemBlockStore(id string, indexConfig *blkstorage.IndexConfig, db *leveldbhelper.DBHandle) (*memBlockStore, error) {
+	indexItems := make(map[blkstorage.IndexableAttr]bool)
+	for _, attr := range indexConfig.AttrsToIndex {
+		indexItems[attr] = true
+	}
+	store := &memBlockStore{
+		id:         id,
+		db:         db,
+		indexItems: indexItems,
+		infoCond:   sync.NewCond(&sync.Mutex{}),
+	}
+	info, err := store.loadChainInfo()
+	if err != nil {
+		return nil, err
+	}
+	store.info = info
+	if err := store.syncIndex(); err != nil {
+		return nil, err
+	}
+	bcInfo := &common.BlockchainInfo{}
+	if info.height > 0 {
+		lastBlock, err := store.retrieveBlockByNumber(info.height - 1)
+		if err != nil {
+			return nil, err
+		}
+		bcInfo = &common.BlockchainInfo{
+			Height:            info.height,
+			CurrentBlockHash:  lastBlock.Header.Hash(),
+			PreviousBlockHash: lastBlock.Header.PreviousHash,
+		}
+	}
+	store.bcInfo.Store(bcInfo)
+	return store, nil
+}
+
+// AddBlock adds a new block
+func (store *memBlockStore) AddBlock(block *common.Block) error {
+	store.writeLock.Lock()
+	defer store.writeLock.Unlock()
+	info := store.getChainInfo()
+	if block.Header.Number != info.height {
+		return fmt.Errorf("Block number should have been %d but was %d", info.height, block.Header.Number)
+	}
+	blockBytes, err := proto.Marshal(block)
+	if err != nil {
+		return fmt.Errorf("Error while serializing block: %s", err)
+	}
+	batch := leveldbhelper.NewUpdateBatch()
+	batch.Put(constructBlockKey(block.Header.Number), blockBytes)
+	if err := store.addIndexEntries(batch, block); err != nil {
+		return err
+	}
+	newInfo := &chainInfo{height: info.height + 1, firstBlockNum: info.firstBlockNum}
+	batch.Put(chainInfoKey, newInfo.marshal())
+	batch.Put(indexCheckpointKey, newInfo.marshalHeight())
+	if err := store.db.WriteBatch(batch, true); err != nil {
+		return fmt.Errorf("Error while saving block to db: %s", err)
+	}
+	store.updateChainInfo(newInfo, block.Header)
+	return nil
+}
+
+// GetBlockchainInfo returns the current info about blockchain
+func (store *memBlockStore) GetBlockchainInfo() (*common.BlockchainInfo, error) {
+	return store.bcInfo.Load().(*common.BlockchainInfo), nil
+}
+
+// RetrieveBlocks returns an iterator that can be used for iterating over a range of blocks
+func (store *memBlockStore) RetrieveBlocks(startNum uint64) (ledger.ResultsIterator, error) {
+	return newBlockItr(store, startNum), nil
+}
+
+// RetrieveBlockByHash returns the block for given block-hash
+func (store *memBlockStore) RetrieveBlockByHash(blockHash []byte) (*common.Block, error) {
+	if !store.indexItems[blkstorage.IndexableAttrBlockHash] {
+		return nil, blkstorage.ErrAttrNotIndexed
+	}
+	b, err := store.db.Get(constructBlockHashKey(blockHash))
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, blkstorage.ErrNotFoundInIndex
+	}
+	blockNum, _ := util.DecodeOrderPreservingVarUint64(b)
+	if store.isBlockPruned(blockNum) {
+		return nil, blkstorage.ErrPruned
+	}
+	return store.fetchBlock(blockNum)
+}
+
+// RetrieveBlockByNumber returns the block at a given blockchain height
+func (store *memBlockStore) RetrieveBlockByNumber(blockNum uint64) (*common.Block, error) {
+	if !store.indexItems[blkstorage.IndexableAttrBlockNum] {
+		return nil, blkstorage.ErrAttrNotIndexed
+	}
+	// interpret math.MaxUint64 as a request for last block
+	if blockNum == math.MaxUint64 {
+		blockNum = store.getChainInfo().height - 1
+	}
+	return store.retrieveBlockByNumber(blockNum)
+}
+
+// RetrieveTxByID returns a transaction for given transaction id
+func (store *memBlockStore) RetrieveTxByID(txID string) (*common.Envelope, error) {
+	loc, err := store.getTxLoc(txID, blkstorage.IndexableAttrTxID)
+	if err != nil {
+		return nil, err
+	}
+	return store.fetchTransactionEnvelope(loc.blockNum, loc.txNum)
+}
+
+// RetrieveTxByBlockNumTranNum returns a transaction for given block number and transaction number
+func (store *memBlockStore) RetrieveTxByBlockNumTranNum(blockNum uint64, tranNum uint64) (*common.Envelope, error) {
+	if !store.indexItems[blkstorage.IndexableAttrBlockNumTranNum] {
+		return nil, blkstorage.ErrAttrNotIndexed
+	}
+	if store.isBlockPruned(blockNum) {
+		return nil, blkstorage.ErrPruned
+	}
+	return store.fetchTransactionEnvelope(blockNum, tranNum)
+}
+
+// RetrieveBlockByTxID returns a block for a given transaction id
+func (store *memBlockStore) RetrieveBlockByTxID(txID string) (*common.Block, error) {
+	loc, err := store.getTxLoc(txID, blkstorage.IndexableAttrBlockTxID)
+	if err != nil {
+		return nil, err
+	}
+	return store.fetchBlock(loc.blockNum)
+}
+
+// RetrieveTxValidationCodeByTxID returns a TX validation code for a given transaction id.
+// The validation code is kept in the index and hence, it remains available after the block is pruned
+func (store *memBlockStore) RetrieveTxValidationCodeByTxID(txID string) (peer.TxValidationCode, error) {
+	if !store.indexItems[blkstorage.IndexableAttrTxValidationCode] {
+		return peer.TxValidationCode(-1), blkstorage.ErrAttrNotIndexed
+	}
+	loc, err := store.getTxLocEntry(txID)
+	if err != nil {
+		return peer.TxValidationCode(-1), err
+	}
+	return loc.validationCode, nil
+}
+
+// Prune removes the blocks that satisfy the given policy
+func (store *memBlockStore) Prune(policy ledger.PrunePolicy) error {
+	return store.prune(policy)
+}
+
+// Verify checks the chaining of the blocks and the consistency of the index with the blocks
+func (store *memBlockStore) Verify(verifier blkstorage.BlockVerifier) (*blkstorage.VerificationReport, error) {
+	return store.verify(verifier)
+}
+
+// Shutdown shuts down the block store
+func (store *memBlockStore) Shutdown() {
+	logger.Debugf("closing mem blockStore:%s", store.id)
+}
+
+func (store *memBlockStore) getChainInfo() *chainInfo {
+	store.infoCond.L.Lock()
+	defer store.infoCond.L.Unlock()
+	return store.info
+}
+
+// updateChainInfo sets the chain info and the blockchain info that is derived from the header of the last block
+func (store *memBlockStore) updateChainInfo(info *chainInfo, lastBlockHeader *common.BlockHeader) {
+	bcInfo := &common.BlockchainInfo{Height: info.height}
+	if lastBlockHeader != nil {
+		bcInfo.CurrentBlockHash = lastBlockHeader.Hash()
+		bcInfo.PreviousBlockHash = lastBlockHeader.PreviousHash
+	}
+	store.infoCond.L.Lock()
+	defer store.infoCond.L.Unlock()
+	store.info = info
+	store.bcInfo.Store(bcInfo)
+	logger.Debugf("Broadcasting about update chainInfo: %s", info)
+	store.infoCond.Broadcast()
+}
+
+func (store *memBlockStore) isBlockPruned(blockNum uint64) bool {
+	return blockNum < store.getChainInfo().firstBlockNum
+}
+
+func (store *memBlockStore) loadChainInfo() (*chainInfo, error) {
+	b, err := store.db.Get(chainInfoKey)
+	if err != nil {
+		return nil, err
+	}
+	info := &chainInfo{}
+	if b == nil {
+		return info, nil
+	}
+	if err := info.unmarshal(b); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// retrieveBlockByNumber returns the given block from the blocks or, if the block is pruned, from the snapshot blocks
+func (store *memBlockStore) retrieveBlockByNumber(blockNum uint64) (*common.Block, error) {
+	if store.isBlockPruned(blockNum) {
+		return store.retrieveSnapshotBlock(blockNum)
+	}
+	return store.fetchBlock(blockNum)
+}
+
+func (store *memBlockStore) fetchBlock(blockNum uint64) (*common.Block, error) {
+	blockBytes, err := store.db.Get(constructBlockKey(blockNum))
+	if err != nil {
+		return nil, err
+	}
+	if blockBytes == nil {
+		return nil, blkstorage.ErrNotFoundInIndex
+	}
+	block := &common.Block{}
+	if err := proto.Unmarshal(blockBytes, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+func (store *memBlockStore) fetchTransactionEnvelope(blockNum, txNum uint64) (*common.Envelope, error) {
+	block, err := store.fetchBlock(blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if block.Data == nil || txNum >= uint64(len(block.Data.Data)) {
+		return nil, blkstorage.ErrNotFoundInIndex
+	}
+	return putil.ExtractEnvelope(block, int(txNum))
+}
+
+// getTxLoc returns the location of the transaction if the given attribute is indexed and the block is not pruned
+func (store *memBlockStore) getTxLoc(txID string, attr blkstorage.IndexableAttr) (*txLoc, error) {
+	if !store.indexItems[attr] {
+		return nil, blkstorage.ErrAttrNotIndexed
+	}
+	loc, err := store.getTxLocEntry(txID)
+	if err != nil {
+		return nil, err
+	}
+	if store.isBlockPruned(loc.blockNum) {
+		return nil, blkstorage.ErrPruned
+	}
+	return loc, nil
+}
+
+func (store *memBlockStore) getTxLocEntry(txID string) (*txLoc, error) {
+	b, err := store.db.Get(constructTxIDKey(txID))
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, blkstorage.ErrNotFoundInIndex
+	}
+	loc := &txLoc{}
+	if err := loc.unmarshal(b); err != nil {
+		return nil, err
+	}
+	return loc, nil
+}
+
+func (store *memBlockStore) isTxIndexed() bool {
+	return store.indexItems[blkstorage.IndexableAttrTxID] || store.indexItems[blkstorage.IndexableAttrBlockTxID] ||
+		store.indexItems[blkstorage.IndexableAttrTxValidationCode]
+}
+
+// addIndexEntries adds the index entries for the given block to the batch. The entry for a transaction id
+// that appears more than once points to the last occurrence
+func (store *memBlockStore) addIndexEntries(batch *leveldbhelper.UpdateBatch, block *common.Block) error {
+	blockNum := block.Header.Number
+	if store.indexItems[blkstorage.IndexableAttrBlockHash] {
+		batch.Put(constructBlockHashKey(block.Header.Hash()), util.EncodeOrderPreservingVarUint64(blockNum))
+	}
+	if !store.isTxIndexed() {
+		return nil
+	}
+	txIDs, err := extractTxIDs(block)
+	if err != nil {
+		return err
+	}
+	txsfltr := ledgerUtil.TxValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+	for txNum, txID := range txIDs {
+		if txID == "" {
+			continue
+		}
+		loc := &txLoc{blockNum: blockNum, txNum: uint64(txNum), validationCode: txsfltr.Flag(txNum)}
+		locBytes, err := loc.marshal()
+		if err != nil {
+			return err
+		}
+		batch.Put(constructTxIDKey(txID), locBytes)
+	}
+	return nil
+}
+
+// syncIndex adds the index entries for the blocks that are added after the last indexed block. This is needed
+// only after the index is dropped, as the index entries are otherwise added atomically with the blocks
+func (store *memBlockStore) syncIndex() error {
+	b, err := store.db.Get(indexCheckpointKey)
+	if err != nil {
+		return err
+	}
+	indexedHeight := uint64(0)
+	if b != nil {
+		indexedHeight, _ = util.DecodeOrderPreservingVarUint64(b)
+	}
+	info := store.info
+	if indexedHeight >= info.height {
+		return nil
+	}
+	startBlockNum := indexedHeight
+	if startBlockNum < info.firstBlockNum {
+		startBlockNum = info.firstBlockNum
+	}
+	logger.Infof("Start building index from block [%d] to last block [%d]", startBlockNum, info.height-1)
+	batch := leveldbhelper.NewUpdateBatch()
+	for blockNum := startBlockNum; blockNum < info.height; blockNum++ {
+		block, err := store.fetchBlock(blockNum)
+		if err != nil {
+			return err
+		}
+		if err := store.addIndexEntries(batch, block); err != nil {
+			return err
+		}
+	}
+	batch.Put(indexCheckpointKey, info.marshalHeight())
+	return store.db.WriteBatch(batch, true)
+}
+
+func extractTxIDs(block *common.Block) ([]string, error) {
+	if block.Data == nil {
+		return nil, nil
+	}
+	txIDs := make([]string, len(block.Data.Data))
+	for i, envBytes := range block.Data.Data {
+		env, err := putil.GetEnvelopeFromBlock(envBytes)
+		if err != nil {
+			return nil, err
+		}
+		payload, err := putil.GetPayload(env)
+		if err != nil {
+			continue
+		}
+		chdr, err := putil.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+		if err != nil {
+			return nil, err
+		}
+		txIDs[i] = chdr.TxId
+	}
+	return txIDs, nil
+}
+
+func constructBlockKey(blockNum uint64) []byte {
+	return append([]byte{blockKeyPrefix}, util.EncodeOrderPreservingVarUint64(blockNum)...)
+}
+
+func constructSnapshotBlockKey(blockNum uint64) []byte {
+	return append([]byte{snapshotBlockKeyPrefix}, util.EncodeOrderPreservingVarUint64(blockNum)...)
+}
+
+func constructBlockHashKey(blockHash []byte) []byte {
+	return append([]byte{blockHashIdxKeyPrefix}, blockHash...)
+}
+
+func constructTxIDKey(txID string) []byte {
+	return append([]byte{txIDIdxKeyPrefix}, []byte(txID)...)
+}
+
+// chainInfo tracks the height of the block store and the first block that is not pruned
+type chainInfo struct {
+	height        uint64
+	firstBlockNum uint64
+}
+
+func (i *chainInfo) marshal() []byte {
+	return append(util.EncodeOrderPreservingVarUint64(i.height), util.EncodeOrderPreservingVarUint64(i.firstBlockNum)...)
+}
+
+func (i *chainInfo) marshalHeight() []byte {
+	return util.EncodeOrderPreservingVarUint64(i.height)
+}
+
+func (i *chainInfo) unmarshal(b []byte) error {
+	var n int
+	i.height, n = util.DecodeOrderPreservingVarUint64(b)
+	if n >= len(b) {
+		return fmt.Errorf("Invalid chain info bytes [%x]", b)
+	}
+	i.firstBlockNum, _ = util.DecodeOrderPreservingVarUint64(b[n:])
+	return nil
+}
+
+func (i *chainInfo) String() string {
+	return fmt.Sprintf("height=[%d], firstBlockNum=[%d]", i.height, i.firstBlockNum)
+}
+
+// txLoc is the index entry for a transaction id
+type txLoc struct {
+	blockNum       uint64
+	txNum          uint64
+	validationCode peer.TxValidationCode
+}
+
+func (l *txLoc) marshal() ([]byte, error) {
+	buffer := proto.NewBuffer([]byte{})
+	for _, val := range []uint64{l.blockNum, l.txNum, uint64(l.validationCode)} {
+		if err := buffer.EncodeVarint(val); err != nil {
+			return nil, err
+		}
+	}
+	return buffer.Bytes(), nil
+}
+
+func (l *txLoc) unmarshal(b []byte) error {
+	buffer := proto.NewBuffer(b)
+	var vals [3]uint64
+	for i := range vals {
+		val, err := buffer.DecodeVarint()
+		if err != nil {
+			return err
+		}
+		vals[i] = val
+	}
+	l.blockNum, l.txNum, l.validationCode = vals[0], vals[1], peer.TxValidationCode(int32(vals[2]))
+	return nil
+}
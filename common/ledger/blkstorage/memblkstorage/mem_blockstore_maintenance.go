@@ -0,0 +1,294 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package memblkstorage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/ledger"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/protos/common"
+	putil "github.com/hyperledger/fabric/protos/utils"
+)
+
+// maxIndexEntriesInDropBatch is the maximum number of index entries removed in a single batch by 'dropIndex'
+const maxIndexEntriesInDropBatch = 1000
+
+// prune removes the blocks that satisfy the given policy. As opposed to the file based block storage,
+// the blocks are pruned at the granularity of a block and the prune action is not relevant, as there are
+// no block files to archive. Like in the file based block storage, the last block is never pruned and the
+// index entries by the block hash and by the transaction id are retained so that a lookup of a pruned
+// block is reported as pruned (as opposed to not found)
+func (store *memBlockStore) prune(policy ledger.PrunePolicy) error {
+	store.writeLock.Lock()
+	defer store.writeLock.Unlock()
+
+	var firstBlockToRetain uint64
+	var action blkstorage.PruneAction
+	var err error
+	info := store.getChainInfo()
+	if info.height == 0 {
+		logger.Debugf("Block storage is empty. Nothing to prune")
+		return nil
+	}
+	lastBlockNum := info.height - 1
+
+	switch p := policy.(type) {
+	case *blkstorage.RetainLastNBlocks:
+		if p.NumBlocks == 0 {
+			return fmt.Errorf("Invalid prune policy: the number of blocks to retain should be greater than zero")
+		}
+		if p.NumBlocks >= info.height {
+			logger.Debugf("Block height [%d] does not exceed the number of blocks to retain [%d]. Nothing to prune",
+				info.height, p.NumBlocks)
+			return nil
+		}
+		firstBlockToRetain = info.height - p.NumBlocks
+		action = p.Action
+	case *blkstorage.RetainBlocksNewerThan:
+		if firstBlockToRetain, err = store.firstBlockNewerThan(p.Timestamp, info.firstBlockNum, lastBlockNum); err != nil {
+			return err
+		}
+		action = p.Action
+	default:
+		return fmt.Errorf("Unsupported prune policy type [%T]", policy)
+	}
+	if action != blkstorage.PruneActionArchive && action != blkstorage.PruneActionDelete {
+		return fmt.Errorf("Invalid prune policy: unknown prune action [%d]", action)
+	}
+
+	if firstBlockToRetain <= info.firstBlockNum {
+		logger.Debugf("Block [%d] is already the first available block. Nothing to prune", info.firstBlockNum)
+		return nil
+	}
+	logger.Infof("Pruning blocks [%d] to [%d]", info.firstBlockNum, firstBlockToRetain-1)
+	batch := leveldbhelper.NewUpdateBatch()
+	for blockNum := info.firstBlockNum; blockNum < firstBlockToRetain; blockNum++ {
+		batch.Delete(constructBlockKey(blockNum))
+	}
+	newInfo := &chainInfo{height: info.height, firstBlockNum: firstBlockToRetain}
+	batch.Put(chainInfoKey, newInfo.marshal())
+	if err := store.db.WriteBatch(batch, true); err != nil {
+		return err
+	}
+	store.infoCond.L.Lock()
+	defer store.infoCond.L.Unlock()
+	store.info = newInfo
+	return nil
+}
+
+// firstBlockNewerThan returns the number of the first block (starting from the block 'startBlockNum') that
+// is created after the given timestamp. The last block is returned if no such block is found because
+// the last block is never pruned
+func (store *memBlockStore) firstBlockNewerThan(timestamp time.Time, startBlockNum, lastBlockNum uint64) (uint64, error) {
+	for blockNum := startBlockNum; blockNum < lastBlockNum; blockNum++ {
+		blockTime, err := store.retrieveBlockTimestamp(blockNum)
+		if err != nil {
+			return 0, err
+		}
+		if blockTime.After(timestamp) {
+			return blockNum, nil
+		}
+	}
+	return lastBlockNum, nil
+}
+
+func (store *memBlockStore) retrieveBlockTimestamp(blockNum uint64) (time.Time, error) {
+	block, err := store.fetchBlock(blockNum)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if block.Data == nil || len(block.Data.Data) == 0 {
+		return time.Time{}, fmt.Errorf("Could not determine the timestamp of block [%d]: block contains no transaction", blockNum)
+	}
+	env, err := putil.ExtractEnvelope(block, 0)
+	if err != nil {
+		return time.Time{}, err
+	}
+	chdr, err := putil.ChannelHeader(env)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if chdr.Timestamp == nil {
+		return time.Time{}, fmt.Errorf("Could not determine the timestamp of block [%d]: timestamp is not set", blockNum)
+	}
+	return time.Unix(chdr.Timestamp.Seconds, int64(chdr.Timestamp.Nanos)).UTC(), nil
+}
+
+// rollback removes all the blocks after the given block number from the block storage along with their
+// index entries. The removal and the update of the chain info are performed in a single batch
+func (store *memBlockStore) rollback(lastBlockToRetain uint64) error {
+	store.writeLock.Lock()
+	defer store.writeLock.Unlock()
+	info := store.getChainInfo()
+	if info.height == 0 {
+		return fmt.Errorf("Block storage is empty. Nothing to rollback")
+	}
+	lastBlockNum := info.height - 1
+	if lastBlockToRetain > lastBlockNum {
+		return fmt.Errorf("Block [%d] cannot be retained during the rollback as the last block in the block storage is [%d]",
+			lastBlockToRetain, lastBlockNum)
+	}
+	if store.isBlockPruned(lastBlockToRetain) {
+		return fmt.Errorf("Block storage cannot be rolled back to block [%d] as the blocks lower than [%d] are pruned",
+			lastBlockToRetain, info.firstBlockNum)
+	}
+	if lastBlockToRetain == lastBlockNum {
+		logger.Debugf("Block [%d] is already the last block. Nothing to rollback", lastBlockNum)
+		return nil
+	}
+
+	logger.Infof("Rolling back blocks [%d] to [%d]", lastBlockToRetain+1, lastBlockNum)
+	batch := leveldbhelper.NewUpdateBatch()
+	for blockNum := lastBlockToRetain + 1; blockNum <= lastBlockNum; blockNum++ {
+		block, err := store.fetchBlock(blockNum)
+		if err != nil {
+			return err
+		}
+		if err := store.removeIndexEntries(batch, block); err != nil {
+			return err
+		}
+		batch.Delete(constructBlockKey(blockNum))
+	}
+	newInfo := &chainInfo{height: lastBlockToRetain + 1, firstBlockNum: info.firstBlockNum}
+	batch.Put(chainInfoKey, newInfo.marshal())
+	batch.Put(indexCheckpointKey, newInfo.marshalHeight())
+	if err := store.db.WriteBatch(batch, true); err != nil {
+		return err
+	}
+	lastBlock, err := store.fetchBlock(lastBlockToRetain)
+	if err != nil {
+		return err
+	}
+	store.updateChainInfo(newInfo, lastBlock.Header)
+	return nil
+}
+
+// removeIndexEntries adds to the batch the removal of the index entries that point to the given block
+func (store *memBlockStore) removeIndexEntries(batch *leveldbhelper.UpdateBatch, block *common.Block) error {
+	batch.Delete(constructBlockHashKey(block.Header.Hash()))
+	txIDs, err := extractTxIDs(block)
+	if err != nil {
+		return err
+	}
+	for _, txID := range txIDs {
+		if txID == "" {
+			continue
+		}
+		loc, err := store.getTxLocEntry(txID)
+		if err == blkstorage.ErrNotFoundInIndex {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if loc.blockNum == block.Header.Number {
+			batch.Delete(constructTxIDKey(txID))
+		}
+	}
+	return nil
+}
+
+// bootstrapFromSnapshot initializes an empty block storage such that the next block to be added is the one
+// following the last block in the snapshot. All the blocks up to the last block in the snapshot are treated as pruned.
+// However, the last block and the config block from the snapshot are retained so that these remain retrievable
+// by the block number
+func (store *memBlockStore) bootstrapFromSnapshot(snapshotInfo *blkstorage.SnapshotInfo) error {
+	store.writeLock.Lock()
+	defer store.writeLock.Unlock()
+	info := store.getChainInfo()
+	if info.height != 0 {
+		return fmt.Errorf("Block storage cannot be bootstrapped from a snapshot as it already contains [%d] blocks", info.height)
+	}
+	lastBlock := snapshotInfo.LastBlock
+	configBlock := snapshotInfo.ConfigBlock
+	if lastBlock == nil || lastBlock.Header == nil || configBlock == nil || configBlock.Header == nil {
+		return fmt.Errorf("Snapshot info should contain both the last block and the config block")
+	}
+	lastBlockNum := lastBlock.Header.Number
+	if configBlock.Header.Number > lastBlockNum {
+		return fmt.Errorf("Config block [%d] should not be newer than the last block [%d] in the snapshot",
+			configBlock.Header.Number, lastBlockNum)
+	}
+
+	batch := leveldbhelper.NewUpdateBatch()
+	for _, block := range []*common.Block{lastBlock, configBlock} {
+		blockBytes, err := proto.Marshal(block)
+		if err != nil {
+			return err
+		}
+		batch.Put(constructSnapshotBlockKey(block.Header.Number), blockBytes)
+	}
+	newInfo := &chainInfo{height: lastBlockNum + 1, firstBlockNum: lastBlockNum + 1}
+	batch.Put(chainInfoKey, newInfo.marshal())
+	batch.Put(indexCheckpointKey, newInfo.marshalHeight())
+	if err := store.db.WriteBatch(batch, true); err != nil {
+		return err
+	}
+	logger.Infof("Bootstrapped block storage from snapshot. Last block in snapshot = [%d], config block = [%d]",
+		lastBlockNum, configBlock.Header.Number)
+	store.updateChainInfo(newInfo, lastBlock.Header)
+	return nil
+}
+
+// retrieveSnapshotBlock returns the block with the given number if the block storage was bootstrapped from
+// a snapshot that contained this block. Otherwise, the block is considered to be pruned
+func (store *memBlockStore) retrieveSnapshotBlock(blockNum uint64) (*common.Block, error) {
+	blockBytes, err := store.db.Get(constructSnapshotBlockKey(blockNum))
+	if err != nil {
+		return nil, err
+	}
+	if blockBytes == nil {
+		return nil, blkstorage.ErrPruned
+	}
+	block := &common.Block{}
+	if err := proto.Unmarshal(blockBytes, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// dropIndex removes all the index entries so that the index is rebuilt from the blocks when the block store
+// is opened next. The index checkpoint is removed first and hence, the removal can safely be repeated
+func dropIndex(db *leveldbhelper.DBHandle) error {
+	if err := db.Delete(indexCheckpointKey, true); err != nil {
+		return err
+	}
+	numEntries := 0
+	for _, keyPrefix := range []byte{blockHashIdxKeyPrefix, txIDIdxKeyPrefix} {
+		itr := db.GetIterator([]byte{keyPrefix}, []byte{keyPrefix + 1})
+		batch := leveldbhelper.NewUpdateBatch()
+		for itr.Next() {
+			batch.Delete(itr.Key())
+			numEntries++
+			if len(batch.KVs) < maxIndexEntriesInDropBatch {
+				continue
+			}
+			if err := db.WriteBatch(batch, true); err != nil {
+				itr.Release()
+				return err
+			}
+			batch = leveldbhelper.NewUpdateBatch()
+		}
+		err := itr.Error()
+		itr.Release()
+		if err != nil {
+			return err
+		}
+		if len(batch.KVs) == 0 {
+			continue
+		}
+		if err := db.WriteBatch(batch, true); err != nil {
+			return err
+		}
+	}
+	logger.Debugf("Removed [%d] index entries", numEntries)
+	return nil
+}
@@ -0,0 +1,126 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package memblkstorage
+
+import (
+	"path/filepath"
+	"sort"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+)
+
+var logger = flogging.MustGetLogger("memblkstorage")
+
+const (
+	// ChainsDir is the name under the block storage dir that identifies the in-memory db containing the blocks of all the ledgers
+	ChainsDir = "chains"
+	// LedgerIDsDir is the name under the block storage dir that identifies the in-memory db containing the ids of the ledgers
+	LedgerIDsDir = "ledgerids"
+
+	ledgerIDsDBName = "ledgerids"
+)
+
+// MemBlockstoreProvider provides handle to block storage - this is not thread-safe
+type MemBlockstoreProvider struct {
+	indexConfig     *blkstorage.IndexConfig
+	leveldbProvider *leveldbhelper.Provider
+	ledgerIDsDB     *leveldbhelper.Provider
+}
+
+// NewProvider constructs a block store provider that keeps the blocks and the indexes in the memory.
+// The 'blockStorageDir' is not created. It only identifies the in-memory dbs so that the blocks survive a close
+// and a re-open of the provider within the same process. The memory is released by `leveldbhelper.RemoveInMemoryDBs`
+func NewProvider(blockStorageDir string, indexConfig *blkstorage.IndexConfig) blkstorage.BlockStoreProvider {
+	p := leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: filepath.Join(blockStorageDir, ChainsDir), InMemory: true})
+	idsDB := leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: filepath.Join(blockStorageDir, LedgerIDsDir), InMemory: true})
+	return &MemBlockstoreProvider{indexConfig, p, idsDB}
+}
+
+// CreateBlockStore simply calls OpenBlockStore
+func (p *MemBlockstoreProvider) CreateBlockStore(ledgerid string) (blkstorage.BlockStore, error) {
+	return p.OpenBlockStore(ledgerid)
+}
+
+// OpenBlockStore opens a block store for given ledgerid.
+// If a blockstore is not existing, this method creates one
+// This method should be invoked only once for a particular ledgerid
+func (p *MemBlockstoreProvider) OpenBlockStore(ledgerid string) (blkstorage.BlockStore, error) {
+	if err := p.recordLedgerID(ledgerid); err != nil {
+		return nil, err
+	}
+	return newMemBlockStore(ledgerid, p.indexConfig, p.leveldbProvider.GetDBHandle(ledgerid))
+}
+
+// BootstrapFromSnapshot initializes an empty block store for the given ledgerid from the snapshot info
+func (p *MemBlockstoreProvider) BootstrapFromSnapshot(ledgerid string, snapshotInfo *blkstorage.SnapshotInfo) error {
+	if err := p.recordLedgerID(ledgerid); err != nil {
+		return err
+	}
+	store, err := newMemBlockStore(ledgerid, p.indexConfig, p.leveldbProvider.GetDBHandle(ledgerid))
+	if err != nil {
+		return err
+	}
+	defer store.Shutdown()
+	return store.bootstrapFromSnapshot(snapshotInfo)
+}
+
+// Rollback removes all the blocks after the block 'lastBlockToRetain' from the block store for the given ledgerid
+func (p *MemBlockstoreProvider) Rollback(ledgerid string, lastBlockToRetain uint64) error {
+	store, err := newMemBlockStore(ledgerid, p.indexConfig, p.leveldbProvider.GetDBHandle(ledgerid))
+	if err != nil {
+		return err
+	}
+	defer store.Shutdown()
+	return store.rollback(lastBlockToRetain)
+}
+
+// DropIndex removes the index of the block store for the given ledgerid
+func (p *MemBlockstoreProvider) DropIndex(ledgerid string) error {
+	return dropIndex(p.leveldbProvider.GetDBHandle(ledgerid))
+}
+
+// Remove removes the block store for the given ledgerid along with its index.
+// This method should be invoked only for a block store that is not opened
+func (p *MemBlockstoreProvider) Remove(ledgerid string) error {
+	if err := p.leveldbProvider.GetDBHandle(ledgerid).DeleteAll(); err != nil {
+		return err
+	}
+	return p.ledgerIDsDB.GetDBHandle(ledgerIDsDBName).Delete([]byte(ledgerid), true)
+}
+
+// Exists tells whether the BlockStore with given id exists
+func (p *MemBlockstoreProvider) Exists(ledgerid string) (bool, error) {
+	val, err := p.ledgerIDsDB.GetDBHandle(ledgerIDsDBName).Get([]byte(ledgerid))
+	return val != nil, err
+}
+
+// List lists the ids of the existing ledgers
+func (p *MemBlockstoreProvider) List() ([]string, error) {
+	itr := p.ledgerIDsDB.GetDBHandle(ledgerIDsDBName).GetIterator(nil, nil)
+	defer itr.Release()
+	var ids []string
+	for itr.Next() {
+		ids = append(ids, string(itr.Key()))
+	}
+	if err := itr.Error(); err != nil {
+		return nil, err
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// Close closes the MemBlockstoreProvider
+func (p *MemBlockstoreProvider) Close() {
+	p.leveldbProvider.Close()
+	p.ledgerIDsDB.Close()
+}
+
+func (p *MemBlockstoreProvider) recordLedgerID(ledgerid string) error {
+	return p.ledgerIDsDB.GetDBHandle(ledgerIDsDBName).Put([]byte(ledgerid), []byte(ledgerid), true)
+}
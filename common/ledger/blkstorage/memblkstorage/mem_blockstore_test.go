@@ -0,0 +1,448 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package memblkstorage
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/peer"
+	putil "github.com/hyperledger/fabric/protos/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockStoreReadWrite(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	store := env.openStore("testLedger")
+	defer store.Shutdown()
+
+	bcInfo, err := store.GetBlockchainInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, &common.BlockchainInfo{}, bcInfo)
+
+	blocks := testutil.ConstructTestBlocks(t, 10)
+	addBlocks(t, store, blocks)
+	assert.EqualError(t, store.AddBlock(blocks[5]), "Block number should have been 10 but was 5")
+	checkAvailableBlocks(t, store, blocks)
+
+	bcInfo, err = store.GetBlockchainInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, &common.BlockchainInfo{
+		Height:            10,
+		CurrentBlockHash:  blocks[9].Header.Hash(),
+		PreviousBlockHash: blocks[9].Header.PreviousHash,
+	}, bcInfo)
+	lastBlock, err := store.RetrieveBlockByNumber(math.MaxUint64)
+	assert.NoError(t, err)
+	assert.Equal(t, blocks[9], lastBlock)
+
+	code, err := store.RetrieveTxValidationCodeByTxID(extractTxIDForTest(t, blocks[3], 0))
+	assert.NoError(t, err)
+	assert.Equal(t, peer.TxValidationCode_VALID, code)
+
+	_, err = store.RetrieveBlockByNumber(10)
+	assert.Equal(t, blkstorage.ErrNotFoundInIndex, err)
+	_, err = store.RetrieveBlockByHash([]byte("non-existing-hash"))
+	assert.Equal(t, blkstorage.ErrNotFoundInIndex, err)
+	_, err = store.RetrieveTxByID("non-existing-txid")
+	assert.Equal(t, blkstorage.ErrNotFoundInIndex, err)
+	_, err = store.RetrieveTxByBlockNumTranNum(2, 100)
+	assert.Equal(t, blkstorage.ErrNotFoundInIndex, err)
+
+	// nothing should be written to the file system
+	_, err = os.Stat(testBlockStorageDir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestBlockStoreRestart(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	store := env.openStore("testLedger")
+	blocks := testutil.ConstructTestBlocks(t, 10)
+	addBlocks(t, store, blocks[:5])
+	store.Shutdown()
+
+	// the blocks should survive a re-open of the provider within the process
+	env.reopen()
+	store = env.openStore("testLedger")
+	defer store.Shutdown()
+	bcInfo, err := store.GetBlockchainInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), bcInfo.Height)
+	addBlocks(t, store, blocks[5:])
+	checkAvailableBlocks(t, store, blocks)
+}
+
+func TestBlockStoreProvider(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	provider := env.provider
+
+	for _, ledgerid := range []string{"ledger2", "ledger1"} {
+		store, err := provider.OpenBlockStore(ledgerid)
+		assert.NoError(t, err)
+		defer store.Shutdown()
+	}
+	ids, err := provider.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ledger1", "ledger2"}, ids)
+	exists, err := provider.Exists("ledger1")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = provider.Exists("ledger3")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// the ledger ids of a different block storage dir should not be visible
+	otherProvider := NewProvider(filepath.Join(testBlockStorageDir, "other"), provider.indexConfig)
+	defer otherProvider.Close()
+	ids, err = otherProvider.List()
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestBlockStoreRemove(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	provider := env.provider
+
+	store := env.openStore("ledger1")
+	addBlocks(t, store, testutil.ConstructTestBlocks(t, 3))
+	store.Shutdown()
+	other := env.openStore("ledger2")
+	defer other.Shutdown()
+
+	assert.NoError(t, provider.Remove("ledger1"))
+	// removing again should be harmless
+	assert.NoError(t, provider.Remove("ledger1"))
+	ids, err := provider.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ledger2"}, ids)
+	exists, err := provider.Exists("ledger1")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	store = env.openStore("ledger1")
+	defer store.Shutdown()
+	bcInfo, err := store.GetBlockchainInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), bcInfo.Height)
+}
+
+func TestBlockStoreSelectiveIndexing(t *testing.T) {
+	env := newTestEnvSelectiveIndexing(t, []blkstorage.IndexableAttr{blkstorage.IndexableAttrBlockNum})
+	defer env.Cleanup()
+	store := env.openStore("testLedger")
+	defer store.Shutdown()
+	blocks := testutil.ConstructTestBlocks(t, 3)
+	addBlocks(t, store, blocks)
+
+	b, err := store.RetrieveBlockByNumber(1)
+	assert.NoError(t, err)
+	assert.Equal(t, blocks[1], b)
+	txID := extractTxIDForTest(t, blocks[1], 0)
+	_, err = store.RetrieveBlockByHash(blocks[1].Header.Hash())
+	assert.Equal(t, blkstorage.ErrAttrNotIndexed, err)
+	_, err = store.RetrieveTxByID(txID)
+	assert.Equal(t, blkstorage.ErrAttrNotIndexed, err)
+	_, err = store.RetrieveBlockByTxID(txID)
+	assert.Equal(t, blkstorage.ErrAttrNotIndexed, err)
+	_, err = store.RetrieveTxByBlockNumTranNum(1, 0)
+	assert.Equal(t, blkstorage.ErrAttrNotIndexed, err)
+	_, err = store.RetrieveTxValidationCodeByTxID(txID)
+	assert.Equal(t, blkstorage.ErrAttrNotIndexed, err)
+}
+
+func TestBlocksItrBlockingNext(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	store := env.openStore("testLedger")
+	defer store.Shutdown()
+	blocks := testutil.ConstructTestBlocks(t, 10)
+	addBlocks(t, store, blocks[:5])
+
+	itr, err := store.RetrieveBlocks(3)
+	assert.NoError(t, err)
+	defer itr.Close()
+	doneChan := make(chan bool)
+	go func() {
+		for i := 3; i < 10; i++ {
+			b, err := itr.Next()
+			assert.NoError(t, err)
+			assert.Equal(t, blocks[i], b)
+		}
+		close(doneChan)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	addBlocks(t, store, blocks[5:])
+	<-doneChan
+
+	// a closed iterator should return nil instead of waiting for the next block
+	waitingItr, err := store.RetrieveBlocks(10)
+	assert.NoError(t, err)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		waitingItr.Close()
+	}()
+	b, err := waitingItr.Next()
+	assert.NoError(t, err)
+	assert.Nil(t, b)
+}
+
+func TestBlockStorePrune(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	ledgerid := "testLedger"
+	store := env.openStore(ledgerid)
+
+	bg, gb := testutil.NewBlockGenerator(t, ledgerid, false)
+	olderBlocks := append([]*common.Block{gb}, bg.NextTestBlocks(19)...)
+	time.Sleep(10 * time.Millisecond)
+	timestamp := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	newerBlocks := bg.NextTestBlocks(10)
+	allBlocks := append(olderBlocks, newerBlocks...)
+	addBlocks(t, store, allBlocks)
+
+	assert.NoError(t, store.Prune(&blkstorage.RetainLastNBlocks{NumBlocks: 25, Action: blkstorage.PruneActionArchive}))
+	checkPrunedBlocks(t, store, allBlocks[:5])
+	checkAvailableBlocks(t, store, allBlocks[5:])
+
+	assert.NoError(t, store.Prune(&blkstorage.RetainBlocksNewerThan{Timestamp: timestamp, Action: blkstorage.PruneActionDelete}))
+	checkPrunedBlocks(t, store, olderBlocks)
+	checkAvailableBlocks(t, store, newerBlocks)
+
+	// the validation codes remain available after the pruning
+	code, err := store.RetrieveTxValidationCodeByTxID(extractTxIDForTest(t, olderBlocks[1], 0))
+	assert.NoError(t, err)
+	assert.Equal(t, peer.TxValidationCode_VALID, code)
+
+	// the last block is never pruned
+	assert.NoError(t, store.Prune(&blkstorage.RetainBlocksNewerThan{Timestamp: time.Now(), Action: blkstorage.PruneActionDelete}))
+	checkPrunedBlocks(t, store, allBlocks[:29])
+	checkAvailableBlocks(t, store, allBlocks[29:])
+
+	// the prune should survive a re-open and the store should accept new blocks
+	store.Shutdown()
+	env.reopen()
+	store = env.openStore(ledgerid)
+	defer store.Shutdown()
+	checkPrunedBlocks(t, store, allBlocks[:29])
+	newBlocks := bg.NextTestBlocks(2)
+	addBlocks(t, store, newBlocks)
+	checkAvailableBlocks(t, store, append(allBlocks[29:], newBlocks...))
+
+	report, err := store.Verify(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &blkstorage.VerificationReport{FirstBlockNum: 29, Height: 32, NumBlocksVerified: 3}, report)
+}
+
+func TestBlockStorePruneInvalidPolicies(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	store := env.openStore("testLedger")
+	defer store.Shutdown()
+	addBlocks(t, store, testutil.ConstructTestBlocks(t, 5))
+
+	assert.EqualError(t, store.Prune(&blkstorage.RetainLastNBlocks{NumBlocks: 0}),
+		"Invalid prune policy: the number of blocks to retain should be greater than zero")
+	assert.EqualError(t, store.Prune(&blkstorage.RetainLastNBlocks{NumBlocks: 2, Action: blkstorage.PruneAction(5)}),
+		"Invalid prune policy: unknown prune action [5]")
+	assert.EqualError(t, store.Prune(nil), "Unsupported prune policy type [<nil>]")
+}
+
+func TestBlockStoreRollback(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	ledgerid := "testLedger"
+	store := env.openStore(ledgerid)
+	blocks := testutil.ConstructTestBlocks(t, 10)
+	addBlocks(t, store, blocks)
+	store.Shutdown()
+
+	assert.EqualError(t, env.provider.Rollback(ledgerid, 10),
+		"Block [10] cannot be retained during the rollback as the last block in the block storage is [9]")
+	assert.NoError(t, env.provider.Rollback(ledgerid, 5))
+
+	store = env.openStore(ledgerid)
+	defer store.Shutdown()
+	bcInfo, err := store.GetBlockchainInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6), bcInfo.Height)
+	assert.Equal(t, blocks[5].Header.Hash(), bcInfo.CurrentBlockHash)
+	checkAvailableBlocks(t, store, blocks[:6])
+	_, err = store.RetrieveBlockByHash(blocks[7].Header.Hash())
+	assert.Equal(t, blkstorage.ErrNotFoundInIndex, err)
+	_, err = store.RetrieveTxByID(extractTxIDForTest(t, blocks[7], 0))
+	assert.Equal(t, blkstorage.ErrNotFoundInIndex, err)
+
+	// the rolled back blocks can be added again
+	addBlocks(t, store, blocks[6:])
+	checkAvailableBlocks(t, store, blocks)
+}
+
+func TestBlockStoreBootstrapFromSnapshot(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	ledgerid := "testLedger"
+	blocks := testutil.ConstructTestBlocks(t, 20)
+	lastBlock, configBlock := blocks[14], blocks[5]
+	assert.EqualError(t, env.provider.BootstrapFromSnapshot(ledgerid, &blkstorage.SnapshotInfo{LastBlock: lastBlock}),
+		"Snapshot info should contain both the last block and the config block")
+	assert.NoError(t, env.provider.BootstrapFromSnapshot(ledgerid,
+		&blkstorage.SnapshotInfo{LastBlock: lastBlock, ConfigBlock: configBlock}))
+	assert.Error(t, env.provider.BootstrapFromSnapshot(ledgerid,
+		&blkstorage.SnapshotInfo{LastBlock: lastBlock, ConfigBlock: configBlock}))
+
+	store := env.openStore(ledgerid)
+	defer store.Shutdown()
+	bcInfo, err := store.GetBlockchainInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, &common.BlockchainInfo{
+		Height:            15,
+		CurrentBlockHash:  lastBlock.Header.Hash(),
+		PreviousBlockHash: lastBlock.Header.PreviousHash,
+	}, bcInfo)
+	for _, block := range []*common.Block{lastBlock, configBlock} {
+		b, err := store.RetrieveBlockByNumber(block.Header.Number)
+		assert.NoError(t, err)
+		assert.Equal(t, block, b)
+	}
+	_, err = store.RetrieveBlockByNumber(3)
+	assert.Equal(t, blkstorage.ErrPruned, err)
+
+	assert.Error(t, store.AddBlock(blocks[16]))
+	addBlocks(t, store, blocks[15:])
+	checkAvailableBlocks(t, store, blocks[15:])
+	report, err := store.Verify(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &blkstorage.VerificationReport{FirstBlockNum: 15, Height: 20, NumBlocksVerified: 5}, report)
+}
+
+func TestBlockStoreDropIndex(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	provider := env.provider
+	store := env.openStore("testLedger")
+	blocks := testutil.ConstructTestBlocks(t, 10)
+	addBlocks(t, store, blocks)
+	store.Shutdown()
+
+	assert.NoError(t, provider.DropIndex("testLedger"))
+	db := provider.leveldbProvider.GetDBHandle("testLedger")
+	val, err := db.Get(constructBlockHashKey(blocks[3].Header.Hash()))
+	assert.NoError(t, err)
+	assert.Nil(t, val)
+	// dropping the index again should be a no-op
+	assert.NoError(t, provider.DropIndex("testLedger"))
+
+	// the index should be rebuilt when the block store is opened
+	store = env.openStore("testLedger")
+	defer store.Shutdown()
+	checkAvailableBlocks(t, store, blocks)
+}
+
+func TestBlockStoreVerify(t *testing.T) {
+	env := newTestEnv(t)
+	defer env.Cleanup()
+	store := env.openStore("testLedger")
+	defer store.Shutdown()
+
+	report, err := store.Verify(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &blkstorage.VerificationReport{}, report)
+
+	blocks := testutil.ConstructTestBlocks(t, 20)
+	blocks[10].Data.Data[0] = blocks[11].Data.Data[0]
+	addBlocks(t, store, blocks)
+	report, err = store.Verify(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), report.NumBlocksVerified)
+	assert.Equal(t, blkstorage.InconsistencyDataHashMismatch, report.Inconsistency.Type)
+	assert.Equal(t, uint64(10), report.Inconsistency.BlockNum)
+
+	report, err = store.Verify(func(block *common.Block) error {
+		if block.Header.Number == 4 {
+			return errors.New("invalid signature")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), report.NumBlocksVerified)
+	assert.Equal(t, &blkstorage.Inconsistency{
+		Type:     blkstorage.InconsistencyBlockVerificationFailed,
+		BlockNum: 4,
+		Message:  "invalid signature",
+	}, report.Inconsistency)
+
+	// a tampered index entry should be reported
+	assert.NoError(t, store.db.Put(constructBlockHashKey(blocks[2].Header.Hash()), util.EncodeOrderPreservingVarUint64(3), true))
+	report, err = store.Verify(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, blkstorage.InconsistencyIndexMismatch, report.Inconsistency.Type)
+	assert.Equal(t, uint64(2), report.Inconsistency.BlockNum)
+}
+
+func checkAvailableBlocks(t *testing.T, store blkstorage.BlockStore, blocks []*common.Block) {
+	for _, block := range blocks {
+		b, err := store.RetrieveBlockByNumber(block.Header.Number)
+		assert.NoError(t, err)
+		assert.Equal(t, block, b)
+		b, err = store.RetrieveBlockByHash(block.Header.Hash())
+		assert.NoError(t, err)
+		assert.Equal(t, block, b)
+		for txNum := range block.Data.Data {
+			txID := extractTxIDForTest(t, block, txNum)
+			expectedEnv, err := putil.ExtractEnvelope(block, txNum)
+			assert.NoError(t, err)
+			env, err := store.RetrieveTxByID(txID)
+			assert.NoError(t, err)
+			assert.Equal(t, expectedEnv, env)
+			env, err = store.RetrieveTxByBlockNumTranNum(block.Header.Number, uint64(txNum))
+			assert.NoError(t, err)
+			assert.Equal(t, expectedEnv, env)
+			b, err = store.RetrieveBlockByTxID(txID)
+			assert.NoError(t, err)
+			assert.Equal(t, block, b)
+		}
+	}
+	itr, err := store.RetrieveBlocks(blocks[0].Header.Number)
+	assert.NoError(t, err)
+	defer itr.Close()
+	for _, block := range blocks {
+		b, err := itr.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, block, b)
+	}
+}
+
+func checkPrunedBlocks(t *testing.T, store blkstorage.BlockStore, blocks []*common.Block) {
+	for _, block := range blocks {
+		_, err := store.RetrieveBlockByNumber(block.Header.Number)
+		assert.Equal(t, blkstorage.ErrPruned, err)
+		_, err = store.RetrieveBlockByHash(block.Header.Hash())
+		assert.Equal(t, blkstorage.ErrPruned, err)
+		_, err = store.RetrieveTxByID(extractTxIDForTest(t, block, 0))
+		assert.Equal(t, blkstorage.ErrPruned, err)
+		_, err = store.RetrieveTxByBlockNumTranNum(block.Header.Number, 0)
+		assert.Equal(t, blkstorage.ErrPruned, err)
+	}
+	itr, err := store.RetrieveBlocks(blocks[0].Header.Number)
+	assert.NoError(t, err)
+	defer itr.Close()
+	_, err = itr.Next()
+	assert.Equal(t, blkstorage.ErrPruned, err)
+}
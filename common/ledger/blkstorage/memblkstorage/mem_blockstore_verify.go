@@ -0,0 +1,143 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package memblkstorage
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// verify reads all the available blocks and checks the chaining of the block hashes, the data hashes, and the
+// consistency of the index with the blocks. As there are no block files, the 'FileNum' and the 'Offset' of a
+// reported inconsistency are always zero
+func (store *memBlockStore) verify(verifier blkstorage.BlockVerifier) (*blkstorage.VerificationReport, error) {
+	info := store.getChainInfo()
+	report := &blkstorage.VerificationReport{FirstBlockNum: info.firstBlockNum, Height: info.height}
+	if info.height == 0 || info.height <= info.firstBlockNum {
+		// either the block storage is empty or it is bootstrapped from a snapshot and no block is added afterwards
+		return report, nil
+	}
+
+	// the previous hash of the first available block can be checked only if the previous
+	// block is retained from a snapshot, as the blocks before the first available block are pruned
+	var previousHeader *common.BlockHeader
+	if info.firstBlockNum > 0 {
+		previousBlock, err := store.retrieveSnapshotBlock(info.firstBlockNum - 1)
+		if err != nil && err != blkstorage.ErrPruned {
+			return nil, err
+		}
+		if previousBlock != nil {
+			previousHeader = previousBlock.Header
+		}
+	}
+
+	for blockNum := info.firstBlockNum; blockNum < info.height; blockNum++ {
+		inconsistency := &blkstorage.Inconsistency{BlockNum: blockNum}
+		blockBytes, err := store.db.Get(constructBlockKey(blockNum))
+		if err != nil {
+			return nil, err
+		}
+		if blockBytes == nil {
+			inconsistency.Type = blkstorage.InconsistencyMissingBlocks
+			inconsistency.Message = fmt.Sprintf("Block [%d] is missing while the last block is [%d]", blockNum, info.height-1)
+			report.Inconsistency = inconsistency
+			return report, nil
+		}
+		block := &common.Block{}
+		if err := proto.Unmarshal(blockBytes, block); err != nil || block.Header == nil {
+			inconsistency.Type = blkstorage.InconsistencyBlockUnreadable
+			inconsistency.Message = fmt.Sprintf("Error while deserializing the block: %v", err)
+			report.Inconsistency = inconsistency
+			return report, nil
+		}
+		inconsistencyType, msg, err := store.verifyBlock(block, blockNum, previousHeader)
+		if err != nil {
+			return nil, err
+		}
+		if inconsistencyType == "" && verifier != nil {
+			if err := verifier(block); err != nil {
+				inconsistencyType, msg = blkstorage.InconsistencyBlockVerificationFailed, err.Error()
+			}
+		}
+		if inconsistencyType != "" {
+			inconsistency.Type, inconsistency.Message = inconsistencyType, msg
+			report.Inconsistency = inconsistency
+			return report, nil
+		}
+		previousHeader = block.Header
+		report.NumBlocksVerified++
+	}
+	logger.Infof("Finished verifying [%d] blocks", report.NumBlocksVerified)
+	return report, nil
+}
+
+// verifyBlock checks the given block. An inconsistency is returned as its type and a message,
+// whereas an error is returned if the checks cannot be performed
+func (store *memBlockStore) verifyBlock(block *common.Block, expectedBlockNum uint64,
+	previousHeader *common.BlockHeader) (blkstorage.InconsistencyType, string, error) {
+	if block.Header.Number != expectedBlockNum {
+		return blkstorage.InconsistencyBlockNumberMismatch,
+			fmt.Sprintf("Expected block [%d] but found block [%d]", expectedBlockNum, block.Header.Number), nil
+	}
+	if previousHeader != nil && !bytes.Equal(block.Header.PreviousHash, previousHeader.Hash()) {
+		return blkstorage.InconsistencyPreviousHashMismatch,
+			fmt.Sprintf("PreviousHash [%x] is not the hash [%x] of the header of block [%d]",
+				block.Header.PreviousHash, previousHeader.Hash(), previousHeader.Number), nil
+	}
+	if !bytes.Equal(block.Data.Hash(), block.Header.DataHash) {
+		return blkstorage.InconsistencyDataHashMismatch,
+			fmt.Sprintf("DataHash [%x] is not the hash [%x] of the block data", block.Header.DataHash, block.Data.Hash()), nil
+	}
+
+	if store.indexItems[blkstorage.IndexableAttrBlockHash] {
+		b, err := store.db.Get(constructBlockHashKey(block.Header.Hash()))
+		if err != nil {
+			return "", "", err
+		}
+		if b == nil {
+			return blkstorage.InconsistencyIndexMismatch,
+				fmt.Sprintf("The index entry for the block hash of block [%d] is missing", expectedBlockNum), nil
+		}
+		if indexedBlockNum, _ := util.DecodeOrderPreservingVarUint64(b); indexedBlockNum != expectedBlockNum {
+			return blkstorage.InconsistencyIndexMismatch,
+				fmt.Sprintf("The index entry for the block hash points to block [%d] instead of [%d]", indexedBlockNum, expectedBlockNum), nil
+		}
+	}
+
+	if !store.isTxIndexed() {
+		return "", "", nil
+	}
+	txIDs, err := extractTxIDs(block)
+	if err != nil {
+		return "", "", err
+	}
+	for txNum, txID := range txIDs {
+		if txID == "" {
+			continue
+		}
+		loc, err := store.getTxLocEntry(txID)
+		if err != nil && err != blkstorage.ErrNotFoundInIndex {
+			return "", "", err
+		}
+		if loc != nil && loc.blockNum == expectedBlockNum && loc.txNum == uint64(txNum) {
+			continue
+		}
+		// the index entry for a transaction id that appears more than once points to the last occurrence
+		if loc != nil && (loc.blockNum > expectedBlockNum || (loc.blockNum == expectedBlockNum && loc.txNum > uint64(txNum))) {
+			continue
+		}
+		return blkstorage.InconsistencyIndexMismatch,
+			fmt.Sprintf("The index entry for the transaction id [%s] does not point to transaction [%d] in block [%d]",
+				txID, txNum, expectedBlockNum), nil
+	}
+	return "", "", nil
+}
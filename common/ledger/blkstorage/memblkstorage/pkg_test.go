@@ -0,0 +1,81 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package memblkstorage
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	putil "github.com/hyperledger/fabric/protos/utils"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+const testBlockStorageDir = "/tmp/fabric/ledgertests/memblkstorage"
+
+func TestMain(m *testing.M) {
+	flogging.SetModuleLevel("memblkstorage", "debug")
+	os.Exit(m.Run())
+}
+
+type testEnv struct {
+	t        testing.TB
+	provider *MemBlockstoreProvider
+}
+
+func newTestEnv(t testing.TB) *testEnv {
+	attrsToIndex := []blkstorage.IndexableAttr{
+		blkstorage.IndexableAttrBlockHash,
+		blkstorage.IndexableAttrBlockNum,
+		blkstorage.IndexableAttrTxID,
+		blkstorage.IndexableAttrBlockNumTranNum,
+		blkstorage.IndexableAttrBlockTxID,
+		blkstorage.IndexableAttrTxValidationCode,
+	}
+	return newTestEnvSelectiveIndexing(t, attrsToIndex)
+}
+
+func newTestEnvSelectiveIndexing(t testing.TB, attrsToIndex []blkstorage.IndexableAttr) *testEnv {
+	leveldbhelper.RemoveInMemoryDBs(testBlockStorageDir)
+	indexConfig := &blkstorage.IndexConfig{AttrsToIndex: attrsToIndex}
+	return &testEnv{t, NewProvider(testBlockStorageDir, indexConfig).(*MemBlockstoreProvider)}
+}
+
+// reopen closes the provider and opens a new one on the same in-memory dbs
+func (env *testEnv) reopen() {
+	env.provider.Close()
+	env.provider = NewProvider(testBlockStorageDir, env.provider.indexConfig).(*MemBlockstoreProvider)
+}
+
+func (env *testEnv) Cleanup() {
+	env.provider.Close()
+	leveldbhelper.RemoveInMemoryDBs(testBlockStorageDir)
+}
+
+func (env *testEnv) openStore(ledgerid string) *memBlockStore {
+	store, err := env.provider.OpenBlockStore(ledgerid)
+	assert.NoError(env.t, err)
+	return store.(*memBlockStore)
+}
+
+func addBlocks(t testing.TB, store blkstorage.BlockStore, blocks []*common.Block) {
+	for _, block := range blocks {
+		assert.NoError(t, store.AddBlock(block))
+	}
+}
+
+func extractTxIDForTest(t testing.TB, block *common.Block, txNum int) string {
+	env, err := putil.ExtractEnvelope(block, txNum)
+	assert.NoError(t, err)
+	chdr, err := putil.ChannelHeader(env)
+	assert.NoError(t, err)
+	return chdr.TxId
+}
@@ -60,6 +60,20 @@ func (flf *fileLedgerFactory) ChainIDs() []string {
 	return chainIDs
 }
 
+// Remove shuts down the ledger of the given chainID (if it is open) and removes its block store
+func (flf *fileLedgerFactory) Remove(chainID string) error {
+	flf.mutex.Lock()
+	defer flf.mutex.Unlock()
+
+	if ledger, ok := flf.ledgers[chainID]; ok {
+		if store, ok := ledger.(*FileLedger).blockStore.(blkstorage.BlockStore); ok {
+			store.Shutdown()
+		}
+		delete(flf.ledgers, chainID)
+	}
+	return flf.blkstorageProvider.Remove(chainID)
+}
+
 // Close releases all resources acquired by the factory
 func (flf *fileLedgerFactory) Close() {
 	flf.blkstorageProvider.Close()
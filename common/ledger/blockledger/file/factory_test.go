@@ -19,6 +19,7 @@ package fileledger
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"testing"
 
 	"github.com/hyperledger/fabric/common/ledger/blkstorage"
@@ -42,6 +43,22 @@ func (mbsp *mockBlockStoreProvider) OpenBlockStore(ledgerid string) (blkstorage.
 	return mbsp.blockstore, mbsp.error
 }
 
+func (mbsp *mockBlockStoreProvider) BootstrapFromSnapshot(ledgerid string, snapshotInfo *blkstorage.SnapshotInfo) error {
+	return mbsp.error
+}
+
+func (mbsp *mockBlockStoreProvider) Rollback(ledgerid string, lastBlockToRetain uint64) error {
+	return mbsp.error
+}
+
+func (mbsp *mockBlockStoreProvider) DropIndex(ledgerid string) error {
+	return mbsp.error
+}
+
+func (mbsp *mockBlockStoreProvider) Remove(ledgerid string) error {
+	return mbsp.error
+}
+
 func (mbsp *mockBlockStoreProvider) Exists(ledgerid string) (bool, error) {
 	return mbsp.exists, mbsp.error
 }
@@ -90,3 +107,26 @@ func TestMultiReinitialization(t *testing.T) {
 	assert.Equal(t, 3, len(flf.ChainIDs()), "Expected chain to be recovered")
 	flf.Close()
 }
+
+func TestRemove(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hyperledger_fabric")
+	assert.NoError(t, err, "Error creating temp dir: %s", err)
+	defer os.RemoveAll(dir)
+
+	flf := New(dir)
+	fl, err := flf.GetOrCreate("foo")
+	assert.NoError(t, err, "Error creating chain")
+	assert.NoError(t, fl.Append(genesisBlock), "Error appending genesis block")
+	_, err = flf.GetOrCreate("bar")
+	assert.NoError(t, err, "Error creating chain")
+	assert.NoError(t, flf.Remove("foo"), "Error removing chain")
+	assert.Equal(t, []string{"bar"}, flf.ChainIDs(), "Expected removed chain to be gone")
+	flf.Close()
+
+	flf = New(dir)
+	defer flf.Close()
+	assert.Equal(t, []string{"bar"}, flf.ChainIDs(), "Expected removed chain not to be recovered")
+	fl, err = flf.GetOrCreate("foo")
+	assert.NoError(t, err, "Error re-creating chain")
+	assert.Equal(t, uint64(0), fl.Height(), "Expected re-created chain to be empty")
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/hyperledger/fabric/common/flogging"
 	cl "github.com/hyperledger/fabric/common/ledger"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
 	"github.com/hyperledger/fabric/common/ledger/blockledger"
 	genesisconfig "github.com/hyperledger/fabric/common/tools/configtxgen/localconfig"
 	cb "github.com/hyperledger/fabric/protos/common"
@@ -117,6 +118,14 @@ func (mbs *mockBlockStore) RetrieveTxValidationCodeByTxID(txID string) (peer.TxV
 	return mbs.txValidationCode, mbs.defaultError
 }
 
+func (mbs *mockBlockStore) Prune(policy cl.PrunePolicy) error {
+	return mbs.defaultError
+}
+
+func (mbs *mockBlockStore) Verify(verifier blkstorage.BlockVerifier) (*blkstorage.VerificationReport, error) {
+	return nil, mbs.defaultError
+}
+
 func (*mockBlockStore) Shutdown() {
 }
 
@@ -119,3 +119,16 @@ func TestClose(t *testing.T) {
 	jlf := New(name)
 	assert.NotPanics(t, func() { jlf.Close() }, "Noop should not pannic")
 }
+
+func TestRemove(t *testing.T) {
+	name, err := ioutil.TempDir("", "hyperledger_fabric")
+	assert.Nil(t, err, "Error creating temp dir: %s", err)
+	defer os.RemoveAll(name)
+
+	jlf := New(name)
+	_, err = jlf.GetOrCreate("foo")
+	assert.NoError(t, err, "Error creating chain")
+	assert.NoError(t, jlf.Remove("foo"), "Error removing chain")
+	assert.Empty(t, jlf.ChainIDs(), "Expected removed chain to be gone")
+	assert.Empty(t, New(name).ChainIDs(), "Expected removed chain not to be recovered")
+}
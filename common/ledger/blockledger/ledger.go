@@ -27,6 +27,10 @@ type Factory interface {
 	// ChainIDs returns the chain IDs the Factory is aware of
 	ChainIDs() []string
 
+	// Remove removes the ledger of the given chainID. The ledger
+	// should no longer be used by the caller
+	Remove(chainID string) error
+
 	// Close releases all resources acquired by the factory
 	Close()
 }
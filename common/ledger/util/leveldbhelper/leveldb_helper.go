@@ -18,6 +18,8 @@ package leveldbhelper
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/hyperledger/fabric/common/flogging"
@@ -25,6 +27,7 @@ import (
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/storage"
 	goleveldbutil "github.com/syndtr/goleveldb/leveldb/util"
 )
 
@@ -40,6 +43,46 @@ const (
 // Conf configuration for `DB`
 type Conf struct {
 	DBPath string
+	// InMemory keeps the db in the memory instead of the 'DBPath' directory. The 'DBPath' still identifies the db
+	// so that the contents survive a close and a re-open of the db within the same process (see `RemoveInMemoryDBs`)
+	InMemory bool
+}
+
+// memDBs holds the in-memory dbs across the opens and the closes of the `DB`s, keyed by the db path
+var memDBs = struct {
+	sync.Mutex
+	m map[string]*leveldb.DB
+}{m: make(map[string]*leveldb.DB)}
+
+func getMemDB(dbPath string) (*leveldb.DB, error) {
+	memDBs.Lock()
+	defer memDBs.Unlock()
+	dbPath = filepath.Clean(dbPath)
+	if db, ok := memDBs.m[dbPath]; ok {
+		return db, nil
+	}
+	db, err := leveldb.Open(storage.NewMemStorage(), &opt.Options{})
+	if err != nil {
+		return nil, err
+	}
+	memDBs.m[dbPath] = db
+	return db, nil
+}
+
+// RemoveInMemoryDBs discards the contents of the in-memory dbs whose paths are the given directory
+// or are under the given directory. The dbs should not be open at the time of this call
+func RemoveInMemoryDBs(dir string) {
+	memDBs.Lock()
+	defer memDBs.Unlock()
+	dir = filepath.Clean(dir)
+	for dbPath, db := range memDBs.m {
+		if dbPath == dir || strings.HasPrefix(dbPath, dir+string(filepath.Separator)) {
+			if err := db.Close(); err != nil {
+				logger.Errorf("Error while closing in-memory DB: %s", err)
+			}
+			delete(memDBs.m, dbPath)
+		}
+	}
 }
 
 // DB - a wrapper on an actual store
@@ -80,6 +123,13 @@ func (dbInst *DB) Open() {
 	dbPath := dbInst.conf.DBPath
 	var err error
 	var dirEmpty bool
+	if dbInst.conf.InMemory {
+		if dbInst.db, err = getMemDB(dbPath); err != nil {
+			panic(fmt.Sprintf("Error while trying to open in-memory DB: %s", err))
+		}
+		dbInst.dbState = opened
+		return
+	}
 	if dirEmpty, err = util.CreateDirIfMissing(dbPath); err != nil {
 		panic(fmt.Sprintf("Error while trying to create dir if missing: %s", err))
 	}
@@ -97,6 +147,11 @@ func (dbInst *DB) Close() {
 	if dbInst.dbState == closed {
 		return
 	}
+	if dbInst.conf.InMemory {
+		// the in-memory db is retained for the next open and is closed only by 'RemoveInMemoryDBs'
+		dbInst.dbState = closed
+		return
+	}
 	if err := dbInst.db.Close(); err != nil {
 		logger.Errorf("Error while closing DB: %s", err)
 	}
@@ -112,7 +112,7 @@ func TestLevelDBHelper(t *testing.T) {
 func TestCreateDBInEmptyDir(t *testing.T) {
 	testutil.AssertNoError(t, os.RemoveAll(testDBPath), "")
 	testutil.AssertNoError(t, os.MkdirAll(testDBPath, 0775), "")
-	db := CreateDB(&Conf{testDBPath})
+	db := CreateDB(&Conf{DBPath: testDBPath})
 	defer db.Close()
 	defer func() {
 		if r := recover(); r != nil {
@@ -128,7 +128,7 @@ func TestCreateDBInNonEmptyDir(t *testing.T) {
 	file, err := os.Create(filepath.Join(testDBPath, "dummyfile.txt"))
 	testutil.AssertNoError(t, err, "")
 	file.Close()
-	db := CreateDB(&Conf{testDBPath})
+	db := CreateDB(&Conf{DBPath: testDBPath})
 	defer db.Close()
 	defer func() {
 		if r := recover(); r == nil {
@@ -137,3 +137,34 @@ func TestCreateDBInNonEmptyDir(t *testing.T) {
 	}()
 	db.Open()
 }
+
+func TestInMemoryDB(t *testing.T) {
+	testutil.AssertNoError(t, os.RemoveAll(testDBPath), "")
+	dbPath := filepath.Join(testDBPath, "inmemory")
+	defer RemoveInMemoryDBs(testDBPath)
+
+	db := CreateDB(&Conf{DBPath: dbPath, InMemory: true})
+	db.Open()
+	testutil.AssertNoError(t, db.Put([]byte("key1"), []byte("value1"), true), "")
+	db.Close()
+	// nothing should be written to the file system
+	_, err := os.Stat(dbPath)
+	testutil.AssertEquals(t, os.IsNotExist(err), true)
+
+	// the contents should be available when the db is re-opened
+	db = CreateDB(&Conf{DBPath: dbPath, InMemory: true})
+	db.Open()
+	val, err := db.Get([]byte("key1"))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, string(val), "value1")
+	db.Close()
+
+	// the contents should be discarded after the removal of the in-memory dbs under the parent dir
+	RemoveInMemoryDBs(testDBPath)
+	db = CreateDB(&Conf{DBPath: dbPath, InMemory: true})
+	db.Open()
+	defer db.Close()
+	val, err = db.Get([]byte("key1"))
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, val)
+}
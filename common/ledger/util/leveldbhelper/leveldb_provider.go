@@ -27,6 +27,9 @@ import (
 var dbNameKeySep = []byte{0x00}
 var lastKeyIndicator = byte(0x01)
 
+// maxDeleteBatchSize is the maximum number of keys deleted in a single batch by 'DeleteAll'
+const maxDeleteBatchSize = 1000
+
 // Provider enables to use a single leveldb as multiple logical leveldbs
 type Provider struct {
 	db        *DB
@@ -110,6 +113,31 @@ func (h *DBHandle) GetIterator(startKey []byte, endKey []byte) *Iterator {
 	return &Iterator{h.db.GetIterator(sKey, eKey)}
 }
 
+// DeleteAll deletes all the keys that are present in the db. The keys are deleted in multiple batches
+// and hence, the deletion is not atomic. However, the deletion can safely be repeated after a failure
+func (h *DBHandle) DeleteAll() error {
+	itr := h.GetIterator(nil, nil)
+	defer itr.Release()
+	batch := NewUpdateBatch()
+	for itr.Next() {
+		batch.Delete(itr.Key())
+		if len(batch.KVs) < maxDeleteBatchSize {
+			continue
+		}
+		if err := h.WriteBatch(batch, true); err != nil {
+			return err
+		}
+		batch = NewUpdateBatch()
+	}
+	if err := itr.Error(); err != nil {
+		return err
+	}
+	if len(batch.KVs) == 0 {
+		return nil
+	}
+	return h.WriteBatch(batch, true)
+}
+
 // UpdateBatch encloses the details of multiple `updates`
 type UpdateBatch struct {
 	KVs map[string][]byte
@@ -90,6 +90,39 @@ func TestBatchedUpdates(t *testing.T) {
 	}
 }
 
+func TestDeleteAll(t *testing.T) {
+	env := newTestProviderEnv(t, testDBPath)
+	defer env.cleanup()
+	p := env.provider
+
+	db1 := p.GetDBHandle("db1")
+	db2 := p.GetDBHandle("db2")
+	for _, db := range []*DBHandle{db1, db2} {
+		batch := NewUpdateBatch()
+		for i := 0; i < maxDeleteBatchSize+10; i++ {
+			batch.Put([]byte(createTestKey(i)), []byte(createTestValue("db", i)))
+		}
+		db.WriteBatch(batch, true)
+	}
+
+	testutil.AssertNoError(t, db1.DeleteAll(), "")
+	itr := db1.GetIterator(nil, nil)
+	testutil.AssertEquals(t, itr.Next(), false)
+	itr.Release()
+
+	// the other dbs remain unaffected
+	itr = db2.GetIterator(nil, nil)
+	count := 0
+	for itr.Next() {
+		count++
+	}
+	itr.Release()
+	testutil.AssertEquals(t, count, maxDeleteBatchSize+10)
+
+	// deleting all the keys of an empty db is a no-op
+	testutil.AssertNoError(t, db1.DeleteAll(), "")
+}
+
 func testDBBasicWriteAndReads(t *testing.T, dbNames ...string) {
 	env := newTestProviderEnv(t, testDBPath)
 	defer env.cleanup()
@@ -14,6 +14,7 @@ import (
 
 	"github.com/spf13/viper"
 	"github.com/uber-go/tally"
+	promreporter "github.com/uber-go/tally/prometheus"
 )
 
 const (
@@ -29,7 +30,9 @@ const (
 	defaultStatsdReporterFlushBytes    = 1432
 )
 
-var RootScope Scope
+// RootScope is the root of all metrics scopes. It discards all metrics until Init
+// is called, so that components can be instrumented regardless of the configuration
+var RootScope = newNoOpScope()
 var once sync.Once
 var started uint32
 
@@ -94,7 +97,7 @@ func Start() error {
 func Shutdown() error {
 	if atomic.CompareAndSwapUint32(&started, 1, 0) {
 		err := RootScope.Close()
-		RootScope = nil
+		RootScope = newNoOpScope()
 		return err
 	}
 
@@ -133,9 +136,17 @@ func (g *noOpGauge) Update(v float64) {
 
 }
 
+type noOpHistogram struct {
+}
+
+func (h *noOpHistogram) RecordDuration(d time.Duration) {
+
+}
+
 type noOpScope struct {
-	counter *noOpCounter
-	gauge   *noOpGauge
+	counter   *noOpCounter
+	gauge     *noOpGauge
+	histogram *noOpHistogram
 }
 
 func (s *noOpScope) Counter(name string) Counter {
@@ -146,6 +157,10 @@ func (s *noOpScope) Gauge(name string) Gauge {
 	return s.gauge
 }
 
+func (s *noOpScope) Histogram(name string) Histogram {
+	return s.histogram
+}
+
 func (s *noOpScope) Tagged(tags map[string]string) Scope {
 	return s
 }
@@ -164,8 +179,9 @@ func (s *noOpScope) Start() error {
 
 func newNoOpScope() Scope {
 	return &noOpScope{
-		counter: &noOpCounter{},
-		gauge:   &noOpGauge{},
+		counter:   &noOpCounter{},
+		gauge:     &noOpGauge{},
+		histogram: &noOpHistogram{},
 	}
 }
 
@@ -186,12 +202,15 @@ func create(opts Opts) (rootScope Scope, e error) {
 
 		var reporter tally.StatsReporter
 		var cachedReporter tally.CachedStatsReporter
+		separator := tally.DefaultSeparator
 		if opts.Reporter == statsdReporterType {
 			reporter, e = newStatsdReporter(opts.StatsdReporterOpts)
 		}
 
 		if opts.Reporter == promReporterType {
+			// prometheus does not allow dots in metric names
 			cachedReporter, e = newPromReporter(opts.PromReporterOpts)
+			separator = promreporter.DefaultSeparator
 		}
 
 		if e != nil {
@@ -201,6 +220,7 @@ func create(opts Opts) (rootScope Scope, e error) {
 		rootScope = newRootScope(
 			tally.ScopeOptions{
 				Prefix:         namespace,
+				Separator:      separator,
 				Reporter:       reporter,
 				CachedReporter: cachedReporter,
 			}, opts.Interval)
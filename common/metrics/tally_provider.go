@@ -29,6 +29,24 @@ var logger = logging.MustGetLogger("common/metrics/tally")
 
 var scopeRegistryKey = tally.KeyForPrefixedStringMap
 
+// defaultDurationBuckets are the buckets of the histograms, ranging
+// from the duration of local operations to slow network requests
+var defaultDurationBuckets = tally.DurationBuckets{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+}
+
 type counter struct {
 	tallyCounter tally.Counter
 }
@@ -53,6 +71,18 @@ func (g *gauge) Update(v float64) {
 	g.tallyGauge.Update(v)
 }
 
+type histogram struct {
+	tallyHistogram tally.Histogram
+}
+
+func newHistogram(tallyHistogram tally.Histogram) *histogram {
+	return &histogram{tallyHistogram: tallyHistogram}
+}
+
+func (h *histogram) RecordDuration(d time.Duration) {
+	h.tallyHistogram.RecordDuration(d)
+}
+
 type scopeRegistry struct {
 	sync.RWMutex
 	subScopes map[string]*scope
@@ -68,9 +98,11 @@ type scope struct {
 
 	cm sync.RWMutex
 	gm sync.RWMutex
+	hm sync.RWMutex
 
-	counters map[string]*counter
-	gauges   map[string]*gauge
+	counters   map[string]*counter
+	gauges     map[string]*gauge
+	histograms map[string]*histogram
 }
 
 func newRootScope(opts tally.ScopeOptions, interval time.Duration) Scope {
@@ -92,7 +124,8 @@ func newRootScope(opts tally.ScopeOptions, interval time.Duration) Scope {
 		},
 		baseReporter: baseReporter,
 		counters:     make(map[string]*counter),
-		gauges:       make(map[string]*gauge)}
+		gauges:       make(map[string]*gauge),
+		histograms:   make(map[string]*histogram)}
 }
 
 func newStatsdReporter(statsdReporterOpts StatsdReporterOpts) (tally.StatsReporter, error) {
@@ -171,6 +204,23 @@ func (s *scope) Gauge(name string) Gauge {
 	return val
 }
 
+func (s *scope) Histogram(name string) Histogram {
+	s.hm.RLock()
+	val, ok := s.histograms[name]
+	s.hm.RUnlock()
+	if !ok {
+		s.hm.Lock()
+		val, ok = s.histograms[name]
+		if !ok {
+			histogram := s.tallyScope.Histogram(name, defaultDurationBuckets)
+			val = newHistogram(histogram)
+			s.histograms[name] = val
+		}
+		s.hm.Unlock()
+	}
+	return val
+}
+
 func (s *scope) Tagged(tags map[string]string) Scope {
 	originTags := tags
 	tags = mergeRightTags(s.tags, tags)
@@ -201,8 +251,9 @@ func (s *scope) Tagged(tags map[string]string) Scope {
 		tallyScope: s.tallyScope.Tagged(originTags),
 		registry:   s.registry,
 
-		counters: make(map[string]*counter),
-		gauges:   make(map[string]*gauge),
+		counters:   make(map[string]*counter),
+		gauges:     make(map[string]*gauge),
+		histograms: make(map[string]*histogram),
 	}
 
 	s.registry.subScopes[key] = subScope
@@ -237,8 +288,9 @@ func (s *scope) SubScope(prefix string) Scope {
 		tallyScope: s.tallyScope.SubScope(prefix),
 		registry:   s.registry,
 
-		counters: make(map[string]*counter),
-		gauges:   make(map[string]*gauge),
+		counters:   make(map[string]*counter),
+		gauges:     make(map[string]*gauge),
+		histograms: make(map[string]*histogram),
 	}
 
 	s.registry.subScopes[key] = subScope
@@ -53,11 +53,13 @@ func (m *testFloatValue) ReportGauge(value float64) {
 type testStatsReporter struct {
 	cg sync.WaitGroup
 	gg sync.WaitGroup
+	hg sync.WaitGroup
 
 	scope Scope
 
-	counters map[string]*testIntValue
-	gauges   map[string]*testFloatValue
+	counters   map[string]*testIntValue
+	gauges     map[string]*testFloatValue
+	histograms map[string]map[time.Duration]int64
 
 	flushes int32
 }
@@ -65,8 +67,9 @@ type testStatsReporter struct {
 // newTestStatsReporter returns a new TestStatsReporter
 func newTestStatsReporter() *testStatsReporter {
 	return &testStatsReporter{
-		counters: make(map[string]*testIntValue),
-		gauges:   make(map[string]*testFloatValue)}
+		counters:   make(map[string]*testIntValue),
+		gauges:     make(map[string]*testFloatValue),
+		histograms: make(map[string]map[time.Duration]int64)}
 }
 
 func (r *testStatsReporter) WaitAll() {
@@ -151,7 +154,11 @@ func (r *testStatsReporter) ReportHistogramDurationSamples(
 	bucketUpperBound time.Duration,
 	samples int64,
 ) {
-
+	if r.histograms[name] == nil {
+		r.histograms[name] = make(map[time.Duration]int64)
+	}
+	r.histograms[name][bucketUpperBound] += samples
+	r.hg.Done()
 }
 
 func (r *testStatsReporter) Capabilities() tally.Capabilities {
@@ -245,6 +252,29 @@ func TestMultiGaugeReport(t *testing.T) {
 	assert.Equal(t, float64(3.33), r.gauges[namespace+".foo"].val)
 }
 
+func TestHistogram(t *testing.T) {
+	t.Parallel()
+	r := newTestStatsReporter()
+	opts := tally.ScopeOptions{
+		Prefix:    namespace,
+		Separator: tally.DefaultSeparator,
+		Reporter:  r}
+
+	s := newRootScope(opts, 1*time.Second)
+	go s.Start()
+	defer s.Close()
+	r.hg.Add(2)
+	s.Histogram("foo").RecordDuration(3 * time.Millisecond)
+	s.Histogram("foo").RecordDuration(4 * time.Millisecond)
+	s.Histogram("foo").RecordDuration(2 * time.Second)
+	r.hg.Wait()
+
+	assert.Equal(t, map[time.Duration]int64{
+		5 * time.Millisecond:    2,
+		2500 * time.Millisecond: 1,
+	}, r.histograms[namespace+".foo"])
+}
+
 func TestSubScope(t *testing.T) {
 	t.Parallel()
 	r := newTestStatsReporter()
@@ -26,6 +26,7 @@ type MockApplicationCapabilities struct {
 	ResourcesTreeRv              bool
 	PrivateChannelDataRv         bool
 	V1_1ValidationRv             bool
+	KeyLevelEndorsementRv        bool
 }
 
 func (mac *MockApplicationCapabilities) Supported() error {
@@ -47,3 +48,7 @@ func (mac *MockApplicationCapabilities) PrivateChannelData() bool {
 func (mac *MockApplicationCapabilities) V1_1Validation() bool {
 	return mac.V1_1ValidationRv
 }
+
+func (mac *MockApplicationCapabilities) KeyLevelEndorsement() bool {
+	return mac.KeyLevelEndorsementRv
+}
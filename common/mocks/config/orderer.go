@@ -17,12 +17,16 @@ import (
 type Orderer struct {
 	// ConsensusTypeVal is returned as the result of ConsensusType()
 	ConsensusTypeVal string
+	// ConsensusMetadataVal is returned as the result of ConsensusMetadata()
+	ConsensusMetadataVal []byte
 	// BatchSizeVal is returned as the result of BatchSize()
 	BatchSizeVal *ab.BatchSize
 	// BatchTimeoutVal is returned as the result of BatchTimeout()
 	BatchTimeoutVal time.Duration
 	// KafkaBrokersVal is returned as the result of KafkaBrokers()
 	KafkaBrokersVal []string
+	// RateLimitsVal is returned as the result of RateLimits()
+	RateLimitsVal []*ab.RateLimit
 	// MaxChannelsCountVal is returns as the result of MaxChannelsCount()
 	MaxChannelsCountVal uint64
 	// OrganizationsVal is returned as the result of Organizations()
@@ -36,6 +40,11 @@ func (scm *Orderer) ConsensusType() string {
 	return scm.ConsensusTypeVal
 }
 
+// ConsensusMetadata returns the ConsensusMetadataVal
+func (scm *Orderer) ConsensusMetadata() []byte {
+	return scm.ConsensusMetadataVal
+}
+
 // BatchSize returns the BatchSizeVal
 func (scm *Orderer) BatchSize() *ab.BatchSize {
 	return scm.BatchSizeVal
@@ -51,6 +60,11 @@ func (scm *Orderer) KafkaBrokers() []string {
 	return scm.KafkaBrokersVal
 }
 
+// RateLimits returns the RateLimitsVal
+func (scm *Orderer) RateLimits() []*ab.RateLimit {
+	return scm.RateLimitsVal
+}
+
 // MaxChannelsCount returns the MaxChannelsCountVal
 func (scm *Orderer) MaxChannelsCount() uint64 {
 	return scm.MaxChannelsCountVal
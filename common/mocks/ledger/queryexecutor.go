@@ -20,11 +20,14 @@ import (
 	"fmt"
 
 	"github.com/hyperledger/fabric/common/ledger"
+	coreledger "github.com/hyperledger/fabric/core/ledger"
 )
 
 type MockQueryExecutor struct {
 	// State keeps all namespaces
 	State map[string]map[string][]byte
+	// StateMetadata keeps the metadata of the keys in all namespaces
+	StateMetadata map[string]map[string]map[string][]byte
 }
 
 func NewMockQueryExecutor(state map[string]map[string][]byte) *MockQueryExecutor {
@@ -42,6 +45,10 @@ func (m *MockQueryExecutor) GetState(namespace string, key string) ([]byte, erro
 	return ns[key], nil
 }
 
+func (m *MockQueryExecutor) GetStateMetadata(namespace, key string) (map[string][]byte, error) {
+	return m.StateMetadata[namespace][key], nil
+}
+
 func (m *MockQueryExecutor) GetStateMultipleKeys(namespace string, keys []string) ([][]byte, error) {
 	return nil, nil
 
@@ -52,10 +59,18 @@ func (m *MockQueryExecutor) GetStateRangeScanIterator(namespace string, startKey
 
 }
 
+func (m *MockQueryExecutor) GetStateRangeScanIteratorWithPagination(namespace string, startKey string, endKey string, pageSize int32) (coreledger.QueryResultsIterator, error) {
+	return nil, nil
+}
+
 func (m *MockQueryExecutor) ExecuteQuery(namespace, query string) (ledger.ResultsIterator, error) {
 	return nil, nil
 }
 
+func (m *MockQueryExecutor) ExecuteQueryWithPagination(namespace, query, bookmark string, pageSize int32) (coreledger.QueryResultsIterator, error) {
+	return nil, nil
+}
+
 func (m *MockQueryExecutor) GetPrivateData(namespace, collection, key string) ([]byte, error) {
 	return nil, nil
 }
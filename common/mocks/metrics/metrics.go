@@ -0,0 +1,154 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/common/metrics"
+)
+
+// Scope is a mock implementation of metrics.Scope which records the emitted
+// values in memory, so that tests can assert on them
+type Scope struct {
+	prefix string
+	tags   map[string]string
+	values *values
+}
+
+type values struct {
+	sync.Mutex
+	counters   map[string]int64
+	gauges     map[string]float64
+	histograms map[string][]time.Duration
+}
+
+// NewScope creates a new mock root scope
+func NewScope() *Scope {
+	return &Scope{
+		values: &values{
+			counters:   make(map[string]int64),
+			gauges:     make(map[string]float64),
+			histograms: make(map[string][]time.Duration),
+		},
+	}
+}
+
+// Counter returns the Counter with the given name
+func (s *Scope) Counter(name string) metrics.Counter {
+	return &counter{key: s.key(name), values: s.values}
+}
+
+// Gauge returns the Gauge with the given name
+func (s *Scope) Gauge(name string) metrics.Gauge {
+	return &gauge{key: s.key(name), values: s.values}
+}
+
+// Histogram returns the Histogram with the given name
+func (s *Scope) Histogram(name string) metrics.Histogram {
+	return &histogram{key: s.key(name), values: s.values}
+}
+
+// Tagged returns a child scope with the given tags added to the tags of this scope
+func (s *Scope) Tagged(tags map[string]string) metrics.Scope {
+	merged := make(map[string]string)
+	for k, v := range s.tags {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+	return &Scope{prefix: s.prefix, tags: merged, values: s.values}
+}
+
+// SubScope returns a child scope with the given name appended to the prefix of this scope
+func (s *Scope) SubScope(name string) metrics.Scope {
+	return &Scope{prefix: s.prefix + name + ".", tags: s.tags, values: s.values}
+}
+
+// Start does nothing
+func (s *Scope) Start() error {
+	return nil
+}
+
+// Close does nothing
+func (s *Scope) Close() error {
+	return nil
+}
+
+// CounterValue returns the value of the counter with the given fully qualified name and tags
+func (s *Scope) CounterValue(name string, tags map[string]string) int64 {
+	s.values.Lock()
+	defer s.values.Unlock()
+	return s.values.counters[key(name, tags)]
+}
+
+// GaugeValue returns the value of the gauge with the given fully qualified name and tags
+func (s *Scope) GaugeValue(name string, tags map[string]string) float64 {
+	s.values.Lock()
+	defer s.values.Unlock()
+	return s.values.gauges[key(name, tags)]
+}
+
+// HistogramValues returns the durations recorded into the histogram with the given
+// fully qualified name and tags
+func (s *Scope) HistogramValues(name string, tags map[string]string) []time.Duration {
+	s.values.Lock()
+	defer s.values.Unlock()
+	return append([]time.Duration(nil), s.values.histograms[key(name, tags)]...)
+}
+
+func (s *Scope) key(name string) string {
+	return key(s.prefix+name, s.tags)
+}
+
+// key formats the name and the tags of a metric like name{k1=v1,k2=v2}, with sorted tag keys
+func key(name string, tags map[string]string) string {
+	var pairs []string
+	for k, v := range tags {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(pairs)
+	return fmt.Sprintf("%s{%s}", name, strings.Join(pairs, ","))
+}
+
+type counter struct {
+	key    string
+	values *values
+}
+
+func (c *counter) Inc(delta int64) {
+	c.values.Lock()
+	defer c.values.Unlock()
+	c.values.counters[c.key] += delta
+}
+
+type gauge struct {
+	key    string
+	values *values
+}
+
+func (g *gauge) Update(value float64) {
+	g.values.Lock()
+	defer g.values.Unlock()
+	g.values.gauges[g.key] = value
+}
+
+type histogram struct {
+	key    string
+	values *values
+}
+
+func (h *histogram) RecordDuration(d time.Duration) {
+	h.values.Lock()
+	defer h.values.Unlock()
+	h.values.histograms[h.key] = append(h.values.histograms[h.key], d)
+}
@@ -0,0 +1,174 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package msp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/crypto"
+	m "github.com/hyperledger/fabric/msp"
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/msp"
+	"github.com/pkg/errors"
+)
+
+// Signers is a mock msp.MSPManager that deserializes the identities it has created. Each identity
+// is backed by its own ECDSA key, so that, unlike with the noop MSP, the signature of an identity
+// does not verify against another identity
+type Signers struct {
+	lock       sync.RWMutex
+	identities map[string]*SigningIdentity
+}
+
+// NewSigners returns a Signers without identities
+func NewSigners() *Signers {
+	return &Signers{identities: make(map[string]*SigningIdentity)}
+}
+
+// NewIdentity creates a signing identity with the given name in the given MSP
+func (s *Signers) NewIdentity(mspID, name string) *SigningIdentity {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	id := &SigningIdentity{mspID: mspID, name: name, key: key}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.identities[string(id.SerializeOrPanic())] = id
+	return id
+}
+
+// Setup does nothing
+func (s *Signers) Setup(msps []m.MSP) error {
+	return nil
+}
+
+// GetMSPs returns no MSPs
+func (s *Signers) GetMSPs() (map[string]m.MSP, error) {
+	return nil, nil
+}
+
+// DeserializeIdentity returns the identity created by NewIdentity which serializes to the given bytes
+func (s *Signers) DeserializeIdentity(serializedIdentity []byte) (m.Identity, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	id, ok := s.identities[string(serializedIdentity)]
+	if !ok {
+		return nil, errors.New("unknown identity")
+	}
+	return id, nil
+}
+
+// IsWellFormed returns nil
+func (s *Signers) IsWellFormed(identity *msp.SerializedIdentity) error {
+	return nil
+}
+
+// SigningIdentity is an identity created by Signers. Besides msp.SigningIdentity,
+// it implements crypto.LocalSigner
+type SigningIdentity struct {
+	mspID string
+	name  string
+	key   *ecdsa.PrivateKey
+}
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// SatisfiesPrincipal returns nil
+func (id *SigningIdentity) SatisfiesPrincipal(*msp.MSPPrincipal) error {
+	return nil
+}
+
+// ExpiresAt returns the zero time, as the identity never expires
+func (id *SigningIdentity) ExpiresAt() time.Time {
+	return time.Time{}
+}
+
+// GetIdentifier returns the MSP and the name of the identity
+func (id *SigningIdentity) GetIdentifier() *m.IdentityIdentifier {
+	return &m.IdentityIdentifier{Mspid: id.mspID, Id: id.name}
+}
+
+// GetMSPIdentifier returns the MSP of the identity
+func (id *SigningIdentity) GetMSPIdentifier() string {
+	return id.mspID
+}
+
+// Validate returns nil
+func (id *SigningIdentity) Validate() error {
+	return nil
+}
+
+// GetOrganizationalUnits returns no organizational units
+func (id *SigningIdentity) GetOrganizationalUnits() []*m.OUIdentifier {
+	return nil
+}
+
+// Verify checks that the signature was made by the key of the identity
+func (id *SigningIdentity) Verify(msg []byte, sig []byte) error {
+	signature := &ecdsaSignature{}
+	if _, err := asn1.Unmarshal(sig, signature); err != nil {
+		return errors.Wrap(err, "failed to unmarshal the signature")
+	}
+	digest := sha256.Sum256(msg)
+	if !ecdsa.Verify(&id.key.PublicKey, digest[:], signature.R, signature.S) {
+		return errors.New("the signature is invalid")
+	}
+	return nil
+}
+
+// Serialize returns the serialized identity, which carries the name of the identity as its bytes
+func (id *SigningIdentity) Serialize() ([]byte, error) {
+	return proto.Marshal(&msp.SerializedIdentity{Mspid: id.mspID, IdBytes: []byte(id.name)})
+}
+
+// SerializeOrPanic serializes the identity, and panics on failure
+func (id *SigningIdentity) SerializeOrPanic() []byte {
+	serialized, err := id.Serialize()
+	if err != nil {
+		panic(err)
+	}
+	return serialized
+}
+
+// Sign signs the message with the key of the identity
+func (id *SigningIdentity) Sign(msg []byte) ([]byte, error) {
+	digest := sha256.Sum256(msg)
+	r, s, err := ecdsa.Sign(rand.Reader, id.key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(ecdsaSignature{R: r, S: s})
+}
+
+// GetPublicVersion returns the identity itself
+func (id *SigningIdentity) GetPublicVersion() m.Identity {
+	return id
+}
+
+// NewSignatureHeader creates a signature header whose creator is the identity
+func (id *SigningIdentity) NewSignatureHeader() (*cb.SignatureHeader, error) {
+	creator, err := id.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := crypto.GetRandomNonce()
+	if err != nil {
+		return nil, err
+	}
+	return &cb.SignatureHeader{Creator: creator, Nonce: nonce}, nil
+}
@@ -0,0 +1,44 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package msp
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/common/crypto"
+	m "github.com/hyperledger/fabric/msp"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	_ m.MSPManager       = &Signers{}
+	_ m.SigningIdentity  = &SigningIdentity{}
+	_ crypto.LocalSigner = &SigningIdentity{}
+)
+
+func TestSigners(t *testing.T) {
+	signers := NewSigners()
+	alice := signers.NewIdentity("SampleOrg", "alice")
+	bob := signers.NewIdentity("SampleOrg", "bob")
+
+	id, err := signers.DeserializeIdentity(alice.SerializeOrPanic())
+	assert.NoError(t, err)
+	assert.Equal(t, &m.IdentityIdentifier{Mspid: "SampleOrg", Id: "alice"}, id.GetIdentifier())
+	_, err = signers.DeserializeIdentity([]byte("carol"))
+	assert.EqualError(t, err, "unknown identity")
+
+	sig, err := alice.Sign([]byte("message"))
+	assert.NoError(t, err)
+	assert.NoError(t, id.Verify([]byte("message"), sig))
+	assert.EqualError(t, id.Verify([]byte("another message"), sig), "the signature is invalid")
+	assert.EqualError(t, bob.Verify([]byte("message"), sig), "the signature is invalid")
+
+	shdr, err := bob.NewSignatureHeader()
+	assert.NoError(t, err)
+	assert.Equal(t, bob.SerializeOrPanic(), shdr.Creator)
+	assert.NotEmpty(t, shdr.Nonce)
+}
@@ -7,6 +7,9 @@ SPDX-License-Identifier: Apache-2.0
 package encoder
 
 import (
+	"io/ioutil"
+	"time"
+
 	"github.com/hyperledger/fabric/common/cauthdsl"
 	"github.com/hyperledger/fabric/common/channelconfig"
 	"github.com/hyperledger/fabric/common/crypto"
@@ -19,6 +22,10 @@ import (
 	"github.com/hyperledger/fabric/common/util"
 	"github.com/hyperledger/fabric/msp"
 	cb "github.com/hyperledger/fabric/protos/common"
+	mspprotos "github.com/hyperledger/fabric/protos/msp"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	bftpb "github.com/hyperledger/fabric/protos/orderer/bft"
+	raftpb "github.com/hyperledger/fabric/protos/orderer/raft"
 	pb "github.com/hyperledger/fabric/protos/peer"
 	"github.com/hyperledger/fabric/protos/utils"
 
@@ -45,6 +52,10 @@ const (
 	ConsensusTypeSolo = "solo"
 	// ConsensusTypeKafka identifies the Kafka-based consensus implementation.
 	ConsensusTypeKafka = "kafka"
+	// ConsensusTypeRaft identifies the Raft-based consensus implementation.
+	ConsensusTypeRaft = "raft"
+	// ConsensusTypeBFT identifies the BFT-based consensus implementation.
+	ConsensusTypeBFT = "bft"
 
 	// BlockValidationPolicyKey TODO
 	BlockValidationPolicyKey = "BlockValidation"
@@ -145,7 +156,6 @@ func NewOrdererGroup(conf *genesisconfig.Orderer) (*cb.ConfigGroup, error) {
 		Policy:    policies.ImplicitMetaAnyPolicy(channelconfig.WritersPolicyKey).Value(),
 		ModPolicy: channelconfig.AdminsPolicyKey,
 	}
-	addValue(ordererGroup, channelconfig.ConsensusTypeValue(conf.OrdererType), channelconfig.AdminsPolicyKey)
 	addValue(ordererGroup, channelconfig.BatchSizeValue(
 		conf.BatchSize.MaxMessageCount,
 		conf.BatchSize.AbsoluteMaxBytes,
@@ -154,17 +164,43 @@ func NewOrdererGroup(conf *genesisconfig.Orderer) (*cb.ConfigGroup, error) {
 	addValue(ordererGroup, channelconfig.BatchTimeoutValue(conf.BatchTimeout.String()), channelconfig.AdminsPolicyKey)
 	addValue(ordererGroup, channelconfig.ChannelRestrictionsValue(conf.MaxChannels), channelconfig.AdminsPolicyKey)
 
+	if len(conf.RateLimits) > 0 {
+		var limits []*ab.RateLimit
+		for _, limit := range conf.RateLimits {
+			limits = append(limits, &ab.RateLimit{
+				MspId:       limit.MSPID,
+				Rate:        limit.Rate,
+				Burst:       limit.Burst,
+				ClientRate:  limit.ClientRate,
+				ClientBurst: limit.ClientBurst,
+			})
+		}
+		addValue(ordererGroup, channelconfig.RateLimitsValue(limits), channelconfig.AdminsPolicyKey)
+	}
+
 	if len(conf.Capabilities) > 0 {
 		addValue(ordererGroup, channelconfig.CapabilitiesValue(conf.Capabilities), channelconfig.AdminsPolicyKey)
 	}
 
+	var consensusMetadata []byte
 	switch conf.OrdererType {
 	case ConsensusTypeSolo:
 	case ConsensusTypeKafka:
 		addValue(ordererGroup, channelconfig.KafkaBrokersValue(conf.Kafka.Brokers), channelconfig.AdminsPolicyKey)
+	case ConsensusTypeRaft:
+		var err error
+		if consensusMetadata, err = raftConfigMetadata(&conf.Raft); err != nil {
+			return nil, errors.WithMessage(err, "cannot encode raft config metadata")
+		}
+	case ConsensusTypeBFT:
+		var err error
+		if consensusMetadata, err = bftConfigMetadata(&conf.BFT); err != nil {
+			return nil, errors.WithMessage(err, "cannot encode bft config metadata")
+		}
 	default:
 		return nil, errors.Errorf("unknown orderer type: %s", conf.OrdererType)
 	}
+	addValue(ordererGroup, channelconfig.ConsensusTypeValue(conf.OrdererType, consensusMetadata), channelconfig.AdminsPolicyKey)
 
 	for _, org := range conf.Organizations {
 		var err error
@@ -178,6 +214,64 @@ func NewOrdererGroup(conf *genesisconfig.Orderer) (*cb.ConfigGroup, error) {
 	return ordererGroup, nil
 }
 
+// raftConfigMetadata encodes the set of consenters and the protocol options of the Raft-based orderer
+func raftConfigMetadata(conf *genesisconfig.Raft) ([]byte, error) {
+	if len(conf.Consenters) == 0 {
+		return nil, errors.New("at least one consenter is required")
+	}
+	metadata := &raftpb.ConfigMetadata{
+		Options: &raftpb.Options{
+			TickInterval:     uint64(conf.Options.TickInterval / time.Millisecond),
+			ElectionTick:     conf.Options.ElectionTick,
+			HeartbeatTick:    conf.Options.HeartbeatTick,
+			MaxSizePerMsg:    conf.Options.MaxSizePerMsg,
+			SnapshotInterval: conf.Options.SnapshotInterval,
+		},
+	}
+	for _, consenter := range conf.Consenters {
+		cert, err := ioutil.ReadFile(consenter.ClientTLSCert)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read the client TLS certificate of consenter %s:%d", consenter.Host, consenter.Port)
+		}
+		metadata.Consenters = append(metadata.Consenters, &raftpb.Consenter{
+			Host:          consenter.Host,
+			Port:          consenter.Port,
+			ClientTlsCert: cert,
+		})
+	}
+	return proto.Marshal(metadata)
+}
+
+// bftConfigMetadata encodes the set of consenters, along with their serialized identities,
+// and the protocol options of the BFT-based orderer
+func bftConfigMetadata(conf *genesisconfig.BFT) ([]byte, error) {
+	if len(conf.Consenters) == 0 {
+		return nil, errors.New("at least one consenter is required")
+	}
+	metadata := &bftpb.ConfigMetadata{
+		Options: &bftpb.Options{
+			RequestTimeout:    uint64(conf.Options.RequestTimeout / time.Millisecond),
+			ViewChangeTimeout: uint64(conf.Options.ViewChangeTimeout / time.Millisecond),
+		},
+	}
+	for _, consenter := range conf.Consenters {
+		cert, err := ioutil.ReadFile(consenter.Identity)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cannot read the identity of consenter %s:%d", consenter.Host, consenter.Port)
+		}
+		identity, err := proto.Marshal(&mspprotos.SerializedIdentity{Mspid: consenter.MSPID, IdBytes: cert})
+		if err != nil {
+			return nil, err
+		}
+		metadata.Consenters = append(metadata.Consenters, &bftpb.Consenter{
+			Host:     consenter.Host,
+			Port:     consenter.Port,
+			Identity: identity,
+		})
+	}
+	return proto.Marshal(metadata)
+}
+
 // NewOrdererOrgGroup returns an orderer org component of the channel configuration.  It defines the crypto material for the
 // organization (its MSP).  It sets the mod_policy of all elements to "Admins".
 func NewOrdererOrgGroup(conf *genesisconfig.Organization) (*cb.ConfigGroup, error) {
@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package encoder
 
 import (
+	"io/ioutil"
 	"testing"
 
 	"github.com/hyperledger/fabric/common/capabilities"
@@ -17,6 +18,10 @@ import (
 	genesisconfig "github.com/hyperledger/fabric/common/tools/configtxgen/localconfig"
 	mspmgmt "github.com/hyperledger/fabric/msp/mgmt"
 	cb "github.com/hyperledger/fabric/protos/common"
+	mspprotos "github.com/hyperledger/fabric/protos/msp"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	bftpb "github.com/hyperledger/fabric/protos/orderer/bft"
+	raftpb "github.com/hyperledger/fabric/protos/orderer/raft"
 	pb "github.com/hyperledger/fabric/protos/peer"
 	"github.com/hyperledger/fabric/protos/utils"
 
@@ -54,6 +59,8 @@ func TestConfigParsing(t *testing.T) {
 		genesisconfig.SampleSingleMSPKafkaProfile,
 		genesisconfig.SampleSingleMSPKafkaV11Profile,
 		genesisconfig.SampleDevModeKafkaProfile,
+		genesisconfig.SampleSingleMSPRaftProfile,
+		genesisconfig.SampleSingleMSPBFTProfile,
 	} {
 		t.Run(profile, func(t *testing.T) {
 			config := genesisconfig.Load(profile)
@@ -264,6 +271,96 @@ func TestNewOrdererGroup(t *testing.T) {
 		assert.Error(t, err)
 		assert.Nil(t, group)
 	})
+
+	t.Run("Rate limits", func(t *testing.T) {
+		config := genesisconfig.Load(genesisconfig.SampleDevModeSoloProfile)
+		group, err := NewOrdererGroup(config.Orderer)
+		assert.NoError(t, err)
+		assert.NotContains(t, group.Values, channelconfig.RateLimitsKey)
+
+		config.Orderer.RateLimits = []*genesisconfig.RateLimit{
+			{Rate: 500, Burst: 1000},
+			{MSPID: "SampleOrg", ClientRate: 10, ClientBurst: 20},
+		}
+		group, err = NewOrdererGroup(config.Orderer)
+		assert.NoError(t, err)
+		rateLimits := &ab.RateLimits{}
+		assert.NoError(t, proto.Unmarshal(group.Values[channelconfig.RateLimitsKey].Value, rateLimits))
+		assert.Equal(t, &ab.RateLimits{Limits: []*ab.RateLimit{
+			{Rate: 500, Burst: 1000},
+			{MspId: "SampleOrg", ClientRate: 10, ClientBurst: 20},
+		}}, rateLimits)
+	})
+
+	t.Run("Raft consenters", func(t *testing.T) {
+		config := genesisconfig.Load(genesisconfig.SampleSingleMSPRaftProfile)
+		group, err := NewOrdererGroup(config.Orderer)
+		assert.NoError(t, err)
+		consensusType := &ab.ConsensusType{}
+		assert.NoError(t, proto.Unmarshal(group.Values[channelconfig.ConsensusTypeKey].Value, consensusType))
+		assert.Equal(t, ConsensusTypeRaft, consensusType.Type)
+		metadata := &raftpb.ConfigMetadata{}
+		assert.NoError(t, proto.Unmarshal(consensusType.Metadata, metadata))
+		cert, err := ioutil.ReadFile(config.Orderer.Raft.Consenters[0].ClientTLSCert)
+		assert.NoError(t, err)
+		assert.Equal(t, &raftpb.ConfigMetadata{
+			Consenters: []*raftpb.Consenter{
+				{Host: "raft0.example.com", Port: 7050, ClientTlsCert: cert},
+				{Host: "raft1.example.com", Port: 7050, ClientTlsCert: cert},
+				{Host: "raft2.example.com", Port: 7050, ClientTlsCert: cert},
+			},
+			Options: &raftpb.Options{TickInterval: 100, ElectionTick: 10, HeartbeatTick: 1, MaxSizePerMsg: 1048576, SnapshotInterval: 100},
+		}, metadata)
+
+		config.Orderer.Raft.Consenters[1].ClientTLSCert = "/nonexistent.pem"
+		group, err = NewOrdererGroup(config.Orderer)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "cannot encode raft config metadata: cannot read the client TLS certificate of consenter raft1.example.com:7050")
+		assert.Nil(t, group)
+
+		config.Orderer.Raft.Consenters = nil
+		group, err = NewOrdererGroup(config.Orderer)
+		assert.EqualError(t, err, "cannot encode raft config metadata: at least one consenter is required")
+		assert.Nil(t, group)
+	})
+
+	t.Run("BFT consenters", func(t *testing.T) {
+		config := genesisconfig.Load(genesisconfig.SampleSingleMSPBFTProfile)
+		group, err := NewOrdererGroup(config.Orderer)
+		assert.NoError(t, err)
+		consensusType := &ab.ConsensusType{}
+		assert.NoError(t, proto.Unmarshal(group.Values[channelconfig.ConsensusTypeKey].Value, consensusType))
+		assert.Equal(t, ConsensusTypeBFT, consensusType.Type)
+		metadata := &bftpb.ConfigMetadata{}
+		assert.NoError(t, proto.Unmarshal(consensusType.Metadata, metadata))
+		assert.Len(t, metadata.Consenters, 1)
+		assert.Equal(t, "127.0.0.1", metadata.Consenters[0].Host)
+		assert.Equal(t, uint32(7050), metadata.Consenters[0].Port)
+		assert.Equal(t, &bftpb.Options{RequestTimeout: 5000, ViewChangeTimeout: 10000}, metadata.Options)
+
+		// the identity carries the certificate the sample MSP signs with
+		assert.NoError(t, mspmgmt.LoadDevMsp())
+		self, err := mspmgmt.GetLocalMSP().GetDefaultSigningIdentity()
+		assert.NoError(t, err)
+		serialized, err := self.Serialize()
+		assert.NoError(t, err)
+		selfIdentity, consenterIdentity := &mspprotos.SerializedIdentity{}, &mspprotos.SerializedIdentity{}
+		assert.NoError(t, proto.Unmarshal(serialized, selfIdentity))
+		assert.NoError(t, proto.Unmarshal(metadata.Consenters[0].Identity, consenterIdentity))
+		assert.Equal(t, "SampleOrg", consenterIdentity.Mspid)
+		assert.Equal(t, selfIdentity.IdBytes, consenterIdentity.IdBytes)
+
+		config.Orderer.BFT.Consenters[0].Identity = "/nonexistent.pem"
+		group, err = NewOrdererGroup(config.Orderer)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "cannot encode bft config metadata: cannot read the identity of consenter 127.0.0.1:7050")
+		assert.Nil(t, group)
+
+		config.Orderer.BFT.Consenters = nil
+		group, err = NewOrdererGroup(config.Orderer)
+		assert.EqualError(t, err, "cannot encode bft config metadata: at least one consenter is required")
+		assert.Nil(t, group)
+	})
 }
 
 func TestBootstrapper(t *testing.T) {
@@ -71,6 +71,12 @@ const (
 	// SampleSingleMSPKafkaV11Profile references the sample profile which includes only the sample MSP with v1.1 capabilities defined and uses Kafka for ordering.
 	SampleSingleMSPKafkaV11Profile = "SampleSingleMSPKafkaV1_1"
 
+	// SampleSingleMSPRaftProfile references the sample profile which includes only the sample MSP and uses Raft for ordering.
+	SampleSingleMSPRaftProfile = "SampleSingleMSPRaft"
+
+	// SampleSingleMSPBFTProfile references the sample profile which includes only the sample MSP and uses BFT for ordering.
+	SampleSingleMSPBFTProfile = "SampleSingleMSPBFT"
+
 	// SampleSingleMSPChannelProfile references the sample profile which includes only the sample MSP and is used to create a channel
 	SampleSingleMSPChannelProfile = "SampleSingleMSPChannel"
 	// SampleSingleMSPChannelV11Profile references the sample profile which includes only the sample MSP with v1.1 capabilities and is used to create a channel
@@ -151,8 +157,11 @@ type Orderer struct {
 	BatchTimeout  time.Duration   `yaml:"BatchTimeout"`
 	BatchSize     BatchSize       `yaml:"BatchSize"`
 	Kafka         Kafka           `yaml:"Kafka"`
+	Raft          Raft            `yaml:"Raft"`
+	BFT           BFT             `yaml:"BFT"`
 	Organizations []*Organization `yaml:"Organizations"`
 	MaxChannels   uint64          `yaml:"MaxChannels"`
+	RateLimits    []*RateLimit    `yaml:"RateLimits"`
 	Capabilities  map[string]bool `yaml:"Capabilities"`
 }
 
@@ -163,11 +172,68 @@ type BatchSize struct {
 	PreferredMaxBytes uint32 `yaml:"PreferredMaxBytes"`
 }
 
+// RateLimit contains the token bucket settings applied to the messages
+// broadcast by an org, and to the messages of each of its clients. An empty
+// MSPID matches the orgs without a limit of their own.
+type RateLimit struct {
+	MSPID       string  `yaml:"MSPID"`
+	Rate        float64 `yaml:"Rate"`
+	Burst       uint32  `yaml:"Burst"`
+	ClientRate  float64 `yaml:"ClientRate"`
+	ClientBurst uint32  `yaml:"ClientBurst"`
+}
+
 // Kafka contains configuration for the Kafka-based orderer.
 type Kafka struct {
 	Brokers []string `yaml:"Brokers"`
 }
 
+// Raft contains configuration for the Raft-based orderer.
+type Raft struct {
+	Consenters []*RaftConsenter `yaml:"Consenters"`
+	Options    RaftOptions      `yaml:"Options"`
+}
+
+// RaftConsenter identifies an orderer that participates in the Raft protocol,
+// along with the TLS certificate with which it connects to the other consenters.
+type RaftConsenter struct {
+	Host          string `yaml:"Host"`
+	Port          uint32 `yaml:"Port"`
+	ClientTLSCert string `yaml:"ClientTLSCert"`
+}
+
+// RaftOptions contains the Raft protocol options. Options left unset take the
+// default values of the Raft-based orderer.
+type RaftOptions struct {
+	TickInterval     time.Duration `yaml:"TickInterval"`
+	ElectionTick     uint32        `yaml:"ElectionTick"`
+	HeartbeatTick    uint32        `yaml:"HeartbeatTick"`
+	MaxSizePerMsg    uint32        `yaml:"MaxSizePerMsg"`
+	SnapshotInterval uint32        `yaml:"SnapshotInterval"`
+}
+
+// BFT contains configuration for the BFT-based orderer.
+type BFT struct {
+	Consenters []*BFTConsenter `yaml:"Consenters"`
+	Options    BFTOptions      `yaml:"Options"`
+}
+
+// BFTConsenter identifies an orderer that participates in the BFT protocol,
+// along with the certificate with which it signs protocol messages and blocks.
+type BFTConsenter struct {
+	Host     string `yaml:"Host"`
+	Port     uint32 `yaml:"Port"`
+	MSPID    string `yaml:"MSPID"`
+	Identity string `yaml:"Identity"`
+}
+
+// BFTOptions contains the BFT protocol options. Options left unset take the
+// default values of the BFT-based orderer.
+type BFTOptions struct {
+	RequestTimeout    time.Duration `yaml:"RequestTimeout"`
+	ViewChangeTimeout time.Duration `yaml:"ViewChangeTimeout"`
+}
+
 var genesisDefaults = TopLevel{
 	Orderer: &Orderer{
 		OrdererType:  "solo",
@@ -262,6 +328,7 @@ func (t *TopLevel) completeInitialization(configDir string) {
 
 	if t.Orderer != nil {
 		t.Orderer.completeInitialization()
+		t.Orderer.translatePaths(configDir)
 	}
 }
 
@@ -292,6 +359,7 @@ func (p *Profile) completeInitialization(configDir string) {
 	// Some profiles will not define orderer parameters
 	if p.Orderer != nil {
 		p.Orderer.completeInitialization()
+		p.Orderer.translatePaths(configDir)
 	}
 }
 
@@ -348,6 +416,15 @@ func (oc *Orderer) completeInitialization() {
 	}
 }
 
+func (oc *Orderer) translatePaths(configDir string) {
+	for _, consenter := range oc.Raft.Consenters {
+		cf.TranslatePathInPlace(configDir, &consenter.ClientTLSCert)
+	}
+	for _, consenter := range oc.BFT.Consenters {
+		cf.TranslatePathInPlace(configDir, &consenter.Identity)
+	}
+}
+
 func translatePaths(configDir string, org *Organization) {
 	cf.TranslatePathInPlace(configDir, &org.MSPDir)
 }
@@ -15,6 +15,7 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/idemix"
 	m "github.com/hyperledger/fabric/msp"
+	mspprotos "github.com/hyperledger/fabric/protos/msp"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
@@ -32,27 +33,46 @@ func TestIdemixCa(t *testing.T) {
 	err = proto.Unmarshal(ipkBytes, ipk)
 	assert.NoError(t, err)
 
-	writeVerifierToFile(ipkBytes)
+	revocationSkBytes, revocationPkBytes, err := GenerateRevocationKey()
+	assert.NoError(t, err)
+	revocationKey, err := ParseRevocationKey(revocationSkBytes)
+	assert.NoError(t, err)
+
+	writeVerifierToFile(ipkBytes, revocationPkBytes)
 
 	key := &idemix.IssuerKey{isk, ipk}
 
-	conf, err := GenerateSignerConfig(false, "OU1", key)
+	conf, err := GenerateSignerConfig(false, "OU1", 1, 0, key, revocationKey)
 	assert.NoError(t, err)
 	cleanupSigner()
 	assert.NoError(t, writeSignerToFile(conf))
 	assert.NoError(t, setupMSP())
 
-	conf, err = GenerateSignerConfig(true, "OU1", key)
+	conf, err = GenerateSignerConfig(true, "OU1", 2, 0, key, revocationKey)
 	assert.NoError(t, err)
 	cleanupSigner()
 	assert.NoError(t, writeSignerToFile(conf))
 	assert.NoError(t, setupMSP())
 
+	// A signer with the revocation information of another epoch than the one
+	// of the MSP cannot be set up
+	conf, err = GenerateSignerCRI(conf, 1, revocationKey)
+	assert.NoError(t, err)
+	mspConfig, err := getSignerConfigWithEpoch(conf, 0)
+	assert.NoError(t, err)
+	assert.Error(t, setupMSPWithConfig(mspConfig))
+
+	_, err = GenerateSignerCRI([]byte{1, 2, 3}, 1, revocationKey)
+	assert.Error(t, err)
+
+	_, err = ParseRevocationKey([]byte("not a key"))
+	assert.EqualError(t, err, "failed to decode revocation secret key")
+
 	// Without the verifier dir present, setup should give an error
 	cleanupVerifier()
 	assert.Error(t, setupMSP())
 
-	_, err = GenerateSignerConfig(true, "", key)
+	_, err = GenerateSignerConfig(true, "", 1, 0, key, revocationKey)
 	assert.EqualError(t, err, "the OU attribute value is empty")
 }
 
@@ -73,12 +93,16 @@ func cleanupVerifier() {
 	os.RemoveAll(filepath.Join(testDir, m.IdemixConfigDirMsp))
 }
 
-func writeVerifierToFile(ipkBytes []byte) error {
+func writeVerifierToFile(ipkBytes, revocationPkBytes []byte) error {
 	err := os.Mkdir(filepath.Join(testDir, m.IdemixConfigDirMsp), os.ModePerm)
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(filepath.Join(testDir, m.IdemixConfigDirMsp, m.IdemixConfigFileIssuerPublicKey), ipkBytes, 0644)
+	err = ioutil.WriteFile(filepath.Join(testDir, m.IdemixConfigDirMsp, m.IdemixConfigFileIssuerPublicKey), ipkBytes, 0644)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(testDir, m.IdemixConfigDirMsp, m.IdemixConfigFileRevocationPublicKey), revocationPkBytes, 0644)
 }
 
 func writeSignerToFile(signerBytes []byte) error {
@@ -98,6 +122,41 @@ func setupMSP() error {
 		return errors.Wrap(err, "Getting MSP failed")
 	}
 	mspConfig, err := m.GetIdemixMspConfig(testDir, "TestName")
+	if err != nil {
+		return err
+	}
+
+	return msp.Setup(mspConfig)
+}
 
+// setupMSPWithConfig sets up an idemix msp with the given config
+func setupMSPWithConfig(mspConfig *mspprotos.MSPConfig) error {
+	msp, err := m.New(&m.IdemixNewOpts{NewBaseOpts: m.NewBaseOpts{Version: m.MSPv1_1}})
+	if err != nil {
+		return errors.Wrap(err, "Getting MSP failed")
+	}
 	return msp.Setup(mspConfig)
 }
+
+// getSignerConfigWithEpoch returns the msp config of the test directory
+// with the given signer config and epoch
+func getSignerConfigWithEpoch(signerBytes []byte, epoch int64) (*mspprotos.MSPConfig, error) {
+	mspConfig, err := m.GetIdemixMspConfig(testDir, "TestName")
+	if err != nil {
+		return nil, err
+	}
+	idemixConfig := &mspprotos.IdemixMSPConfig{}
+	err = proto.Unmarshal(mspConfig.Config, idemixConfig)
+	if err != nil {
+		return nil, err
+	}
+	signer := &mspprotos.IdemixMSPSignerConfig{}
+	err = proto.Unmarshal(signerBytes, signer)
+	if err != nil {
+		return nil, err
+	}
+	idemixConfig.Signer = signer
+	idemixConfig.Epoch = epoch
+	mspConfig.Config, err = proto.Marshal(idemixConfig)
+	return mspConfig, err
+}
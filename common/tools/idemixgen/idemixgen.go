@@ -12,6 +12,7 @@ package main
 // the Identity Mixer MSP
 
 import (
+	"crypto/ecdsa"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -27,8 +28,9 @@ import (
 )
 
 const (
-	IdemixDirIssuer             = "ca"
-	IdemixConfigIssuerSecretKey = "IssuerSecretKey"
+	IdemixDirIssuer                 = "ca"
+	IdemixConfigIssuerSecretKey     = "IssuerSecretKey"
+	IdemixConfigRevocationSecretKey = "RevocationKey"
 )
 
 // command line flags
@@ -39,6 +41,10 @@ var (
 	genSignerConfig = app.Command("signerconfig", "Generate a default signer for this Idemix MSP")
 	genCredOU       = genSignerConfig.Flag("org-unit", "The Organizational Unit of the default signer").Short('u').String()
 	genCredIsAdmin  = genSignerConfig.Flag("admin", "Make the default signer admin").Short('a').Bool()
+	genCredRH       = genSignerConfig.Flag("revocation-handle", "The handle used to revoke the default signer, which should be unique for each signer").Short('r').Int()
+	genCredEpoch    = genSignerConfig.Flag("epoch", "The epoch of the credential revocation information of the default signer").Short('e').Int()
+	genCRI          = app.Command("cri", "Generate the credential revocation information of the default signer for a new epoch")
+	genCRIEpoch     = genCRI.Flag("epoch", "The epoch of the credential revocation information").Short('e').Required().Int()
 
 	version = app.Command("version", "Show version information")
 )
@@ -51,6 +57,8 @@ func main() {
 	case genIssuerKey.FullCommand():
 		isk, ipk, err := idemixca.GenerateIssuerKey()
 		handleError(err)
+		revocationSk, revocationPk, err := idemixca.GenerateRevocationKey()
+		handleError(err)
 
 		// Prevent overwriting the existing key
 		path := filepath.Join(IdemixDirIssuer)
@@ -65,9 +73,11 @@ func main() {
 		writeFile(filepath.Join(IdemixDirIssuer, IdemixConfigIssuerSecretKey), isk)
 		writeFile(filepath.Join(IdemixDirIssuer, msp.IdemixConfigFileIssuerPublicKey), ipk)
 		writeFile(filepath.Join(msp.IdemixConfigDirMsp, msp.IdemixConfigFileIssuerPublicKey), ipk)
+		writeFile(filepath.Join(IdemixDirIssuer, IdemixConfigRevocationSecretKey), revocationSk)
+		writeFile(filepath.Join(msp.IdemixConfigDirMsp, msp.IdemixConfigFileRevocationPublicKey), revocationPk)
 
 	case genSignerConfig.FullCommand():
-		config, err := idemixca.GenerateSignerConfig(*genCredIsAdmin, *genCredOU, readIssuerKey())
+		config, err := idemixca.GenerateSignerConfig(*genCredIsAdmin, *genCredOU, *genCredRH, *genCredEpoch, readIssuerKey(), readRevocationKey())
 		handleError(err)
 
 		path := msp.IdemixConfigDirUser
@@ -77,6 +87,16 @@ func main() {
 		handleError(os.Mkdir(msp.IdemixConfigDirUser, 0770))
 		writeFile(filepath.Join(msp.IdemixConfigDirUser, msp.IdemixConfigFileSigner), config)
 
+	case genCRI.FullCommand():
+		path := filepath.Join(msp.IdemixConfigDirUser, msp.IdemixConfigFileSigner)
+		signerConfig, err := ioutil.ReadFile(path)
+		if err != nil {
+			handleError(errors.Wrapf(err, "failed to open signer config file: %s", path))
+		}
+		config, err := idemixca.GenerateSignerCRI(signerConfig, *genCRIEpoch, readRevocationKey())
+		handleError(err)
+		writeFile(path, config)
+
 	case version.FullCommand():
 		printVersion()
 	}
@@ -110,6 +130,19 @@ func readIssuerKey() *idemix.IssuerKey {
 	return key
 }
 
+// readRevocationKey reads the revocation secret key from the current directory
+func readRevocationKey() *ecdsa.PrivateKey {
+	path := filepath.Join(IdemixDirIssuer, IdemixConfigRevocationSecretKey)
+	keyBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		handleError(errors.Wrapf(err, "failed to open revocation secret key file: %s", path))
+	}
+	key, err := idemixca.ParseRevocationKey(keyBytes)
+	handleError(err)
+
+	return key
+}
+
 // checkDirectoryNotExists checks whether a directory with the given path already exists and exits if this is the case
 func checkDirectoryNotExists(path string, errorMessage string) {
 	_, err := os.Stat(path)
@@ -0,0 +1,52 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/hyperledger/fabric/common/tools/ledgerutil/verify"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// command line flags
+var (
+	app = kingpin.New("ledgerutil", "Utility for inspecting the ledger of a peer that is not running")
+
+	verifyCmd = app.Command("verify", "Verify the integrity of the block store of a channel and print the report as JSON. "+
+		"The exit code is 1 if an inconsistency is found")
+	blockStorePath = verifyCmd.Flag("blockStorePath", "The path of the block storage of the peer, i.e., "+
+		"'<peer.fileSystemPath>/ledgersData/chains'").Required().String()
+	channelID        = verifyCmd.Flag("channelID", "The channel whose block store is verified").Required().String()
+	verifySignatures = verifyCmd.Flag("verifySignatures", "Verify the signatures of the orderers on the blocks "+
+		"against the channel config").Default("true").Bool()
+)
+
+func main() {
+	kingpin.Version("0.0.1")
+	switch kingpin.MustParse(app.Parse(os.Args[1:])) {
+
+	// "verify" command
+	case verifyCmd.FullCommand():
+		report, err := verify.BlockStore(*blockStorePath, *channelID, *verifySignatures)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error while verifying the block store: %s\n", err)
+			os.Exit(2)
+		}
+		reportJSON, err := json.MarshalIndent(report, "", "\t")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error while marshaling the report: %s\n", err)
+			os.Exit(2)
+		}
+		fmt.Println(string(reportJSON))
+		if report.Inconsistency != nil {
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verify
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/common/channelconfig"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage/fsblkstorage"
+	"github.com/hyperledger/fabric/common/policies"
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// attrsToIndex are the attributes indexed by the block store of a peer
+var attrsToIndex = []blkstorage.IndexableAttr{
+	blkstorage.IndexableAttrBlockHash,
+	blkstorage.IndexableAttrBlockNum,
+	blkstorage.IndexableAttrTxID,
+	blkstorage.IndexableAttrBlockNumTranNum,
+	blkstorage.IndexableAttrBlockTxID,
+	blkstorage.IndexableAttrTxValidationCode,
+}
+
+// Report is the outcome of the verification of the block store of a channel
+type Report struct {
+	ChannelID string `json:"channelID"`
+	*blkstorage.VerificationReport
+}
+
+// BlockStore opens the block store of the given channel from the block storage at the given path and verifies it.
+// If 'verifySignatures' is true, the signatures of the orderers on the blocks are verified against the block
+// validation policy of the channel config in force at the height of each block. The block store should not be
+// in use by a peer during the verification
+func BlockStore(blockStorePath, channelID string, verifySignatures bool) (*Report, error) {
+	provider := fsblkstorage.NewProvider(fsblkstorage.NewConf(blockStorePath, 0),
+		&blkstorage.IndexConfig{AttrsToIndex: attrsToIndex})
+	defer provider.Close()
+	exists, err := provider.Exists(channelID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("Block store for channel [%s] does not exist at [%s]", channelID, blockStorePath)
+	}
+	store, err := provider.OpenBlockStore(channelID)
+	if err != nil {
+		return nil, err
+	}
+	defer store.Shutdown()
+
+	var verifier blkstorage.BlockVerifier
+	if verifySignatures {
+		verifier = newSignatureVerifier(store, policyManagerFromConfigBlock).verify
+	}
+	verificationReport, err := store.Verify(verifier)
+	if err != nil {
+		return nil, err
+	}
+	return &Report{ChannelID: channelID, VerificationReport: verificationReport}, nil
+}
+
+// signatureVerifier verifies the signatures of the orderers on the blocks, which are supplied in the order of the block
+// numbers, against the block validation policy of the channel config in force at the height of each block.
+// The config in force is updated after each config block is verified
+type signatureVerifier struct {
+	store            blkstorage.BlockStore
+	policyManager    policies.Manager
+	newPolicyManager func(configBlock *common.Block) (policies.Manager, error)
+}
+
+func newSignatureVerifier(store blkstorage.BlockStore,
+	newPolicyManager func(configBlock *common.Block) (policies.Manager, error)) *signatureVerifier {
+	return &signatureVerifier{store: store, newPolicyManager: newPolicyManager}
+}
+
+func (v *signatureVerifier) verify(block *common.Block) error {
+	blockNum := block.Header.Number
+	if v.policyManager == nil {
+		if blockNum == 0 {
+			// the genesis block is not signed by the orderers and establishes the config of the channel
+			return v.updatePolicyManager(block)
+		}
+		if err := v.initPolicyManager(block); err != nil {
+			return fmt.Errorf("Cannot determine the channel config in force at block [%d]: %s", blockNum, err)
+		}
+	}
+	if err := verifyBlockSignatures(block, v.policyManager); err != nil {
+		return err
+	}
+	if utils.IsConfigBlock(block) {
+		return v.updatePolicyManager(block)
+	}
+	return nil
+}
+
+// initPolicyManager loads the channel config in force at the given block, which is the first block being verified,
+// from the last config block before the given block, as recorded in the metadata of the blocks
+func (v *signatureVerifier) initPolicyManager(block *common.Block) error {
+	lastConfigBlockNum, err := utils.GetLastConfigIndexFromBlock(block)
+	if err != nil {
+		return err
+	}
+	if lastConfigBlockNum == block.Header.Number {
+		// the block is a config block, hence the config in force is the one before this block
+		previousBlock, err := v.store.RetrieveBlockByNumber(block.Header.Number - 1)
+		if err != nil {
+			return err
+		}
+		if lastConfigBlockNum, err = utils.GetLastConfigIndexFromBlock(previousBlock); err != nil {
+			return err
+		}
+	}
+	configBlock, err := v.store.RetrieveBlockByNumber(lastConfigBlockNum)
+	if err != nil {
+		return err
+	}
+	return v.updatePolicyManager(configBlock)
+}
+
+func (v *signatureVerifier) updatePolicyManager(configBlock *common.Block) error {
+	policyManager, err := v.newPolicyManager(configBlock)
+	if err != nil {
+		return fmt.Errorf("Error while loading the channel config from block [%d]: %s", configBlock.Header.Number, err)
+	}
+	v.policyManager = policyManager
+	return nil
+}
+
+// verifyBlockSignatures evaluates the block validation policy for the signatures in the metadata of the block,
+// in the same manner as the peer does for a block received from the ordering service
+func verifyBlockSignatures(block *common.Block, policyManager policies.Manager) error {
+	metadata, err := utils.GetMetadataFromBlock(block, common.BlockMetadataIndex_SIGNATURES)
+	if err != nil {
+		return fmt.Errorf("Failed unmarshalling the metadata for signatures: %s", err)
+	}
+	policy, ok := policyManager.GetPolicy(policies.BlockValidation)
+	if !ok {
+		return fmt.Errorf("Block validation policy is not found in the channel config")
+	}
+	signatureSet := []*common.SignedData{}
+	for _, metadataSignature := range metadata.Signatures {
+		shdr, err := utils.GetSignatureHeader(metadataSignature.SignatureHeader)
+		if err != nil {
+			return fmt.Errorf("Failed unmarshalling the signature header: %s", err)
+		}
+		signatureSet = append(signatureSet, &common.SignedData{
+			Identity:  shdr.Creator,
+			Data:      util.ConcatenateBytes(metadata.Value, metadataSignature.SignatureHeader, block.Header.Bytes()),
+			Signature: metadataSignature.Signature,
+		})
+	}
+	if err := policy.Evaluate(signatureSet); err != nil {
+		return fmt.Errorf("Block validation policy is not satisfied by the signatures on the block: %s", err)
+	}
+	return nil
+}
+
+func policyManagerFromConfigBlock(configBlock *common.Block) (policies.Manager, error) {
+	envelope, err := utils.ExtractEnvelope(configBlock, 0)
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := channelconfig.NewBundleFromEnvelope(envelope)
+	if err != nil {
+		return nil, err
+	}
+	return bundle.PolicyManager(), nil
+}
@@ -0,0 +1,110 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package verify
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage/fsblkstorage"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	mockpolicies "github.com/hyperledger/fabric/common/mocks/policies"
+	"github.com/hyperledger/fabric/common/policies"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockStore(t *testing.T) {
+	blockStorePath, err := ioutil.TempDir("", "ledgerutil")
+	assert.NoError(t, err)
+	defer os.RemoveAll(blockStorePath)
+
+	_, err = BlockStore(blockStorePath, "testchannel", false)
+	assert.EqualError(t, err, fmt.Sprintf("Block store for channel [testchannel] does not exist at [%s]", blockStorePath))
+
+	provider := fsblkstorage.NewProvider(fsblkstorage.NewConf(blockStorePath, 0),
+		&blkstorage.IndexConfig{AttrsToIndex: attrsToIndex})
+	store, err := provider.OpenBlockStore("testchannel")
+	assert.NoError(t, err)
+	for _, block := range testutil.ConstructTestBlocks(t, 10) {
+		assert.NoError(t, store.AddBlock(block))
+	}
+	store.Shutdown()
+	provider.Close()
+
+	report, err := BlockStore(blockStorePath, "testchannel", false)
+	assert.NoError(t, err)
+	assert.Equal(t, &Report{
+		ChannelID:          "testchannel",
+		VerificationReport: &blkstorage.VerificationReport{FirstBlockNum: 0, Height: 10, NumBlocksVerified: 10},
+	}, report)
+
+	// the test blocks following the genesis block are not signed by the orderers
+	report, err = BlockStore(blockStorePath, "testchannel", true)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), report.NumBlocksVerified)
+	assert.Equal(t, blkstorage.InconsistencyBlockVerificationFailed, report.Inconsistency.Type)
+	assert.Equal(t, uint64(1), report.Inconsistency.BlockNum)
+}
+
+func TestSignatureVerifier(t *testing.T) {
+	blocks := testutil.ConstructTestBlocks(t, 5)
+	policy := &mockpolicies.Policy{}
+	var configBlocks []uint64
+	verifier := newSignatureVerifier(nil, func(configBlock *common.Block) (policies.Manager, error) {
+		configBlocks = append(configBlocks, configBlock.Header.Number)
+		return &mockpolicies.Manager{Policy: policy}, nil
+	})
+
+	// the config is loaded from the genesis block
+	assert.NoError(t, verifier.verify(blocks[0]))
+	assert.Equal(t, []uint64{0}, configBlocks)
+	assert.NoError(t, verifier.verify(blocks[1]))
+	assert.Equal(t, []uint64{0}, configBlocks)
+
+	policy.Err = fmt.Errorf("signature set did not satisfy policy")
+	assert.EqualError(t, verifier.verify(blocks[2]),
+		"Block validation policy is not satisfied by the signatures on the block: signature set did not satisfy policy")
+
+	// a config block updates the config in force for the subsequent blocks
+	policy.Err = nil
+	configBlock := testutil.ConstructTestBlocks(t, 1)[0]
+	configBlock.Header.Number = 3
+	assert.NoError(t, verifier.verify(configBlock))
+	assert.Equal(t, []uint64{0, 3}, configBlocks)
+}
+
+func TestSignatureVerifierFromLastConfigBlock(t *testing.T) {
+	blocks := testutil.ConstructTestBlocks(t, 5)
+	store := &mockBlockStore{blocks: blocks}
+	var configBlocks []uint64
+	verifier := newSignatureVerifier(store, func(configBlock *common.Block) (policies.Manager, error) {
+		configBlocks = append(configBlocks, configBlock.Header.Number)
+		return &mockpolicies.Manager{Policy: &mockpolicies.Policy{}}, nil
+	})
+	// the last config block as per the metadata of the test blocks is the genesis block
+	assert.NoError(t, verifier.verify(blocks[3]))
+	assert.Equal(t, []uint64{0}, configBlocks)
+
+	verifier = newSignatureVerifier(&mockBlockStore{}, nil)
+	assert.EqualError(t, verifier.verify(blocks[3]), "Cannot determine the channel config in force at block [3]: Block not found")
+}
+
+type mockBlockStore struct {
+	blkstorage.BlockStore
+	blocks []*common.Block
+}
+
+func (m *mockBlockStore) RetrieveBlockByNumber(blockNum uint64) (*common.Block, error) {
+	if blockNum >= uint64(len(m.blocks)) {
+		return nil, fmt.Errorf("Block not found")
+	}
+	return m.blocks[blockNum], nil
+}
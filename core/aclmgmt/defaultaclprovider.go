@@ -74,6 +74,7 @@ func (d *defaultACLProvider) initialize() {
 	d.cResourcePolicyMap[resources.QSCC_GetBlockByHash] = CHANNELREADERS
 	d.cResourcePolicyMap[resources.QSCC_GetTransactionByID] = CHANNELREADERS
 	d.cResourcePolicyMap[resources.QSCC_GetBlockByTxID] = CHANNELREADERS
+	d.cResourcePolicyMap[resources.QSCC_GetCommitHash] = CHANNELREADERS
 
 	//--------------- CSCC resources -----------
 	//p resources (implemented by the chaincode currently)
@@ -28,6 +28,7 @@ const (
 	QSCC_GetBlockByHash     = "QSCC.GetBlockByHash"
 	QSCC_GetTransactionByID = "QSCC.GetTransactionByID"
 	QSCC_GetBlockByTxID     = "QSCC.GetBlockByTxID"
+	QSCC_GetCommitHash      = "QSCC.GetCommitHash"
 
 	//CSCC resources
 	CSCC_JoinChain                = "CSCC.JoinChain"
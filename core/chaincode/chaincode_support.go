@@ -28,6 +28,7 @@ import (
 
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/metrics"
 	"github.com/hyperledger/fabric/core/chaincode/accesscontrol"
 	"github.com/hyperledger/fabric/core/chaincode/platforms"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
@@ -147,6 +148,7 @@ func NewChaincodeSupport(ccEndpoint string, userrunsCC bool, ccstartuptimeout ti
 			chaincodeMap:  make(map[string]*chaincodeRTEnv),
 			launchStarted: make(map[string]bool),
 		}, peerNetworkID: pnid, peerID: pid,
+		metrics: metrics.RootScope.SubScope("chaincode"),
 	}
 
 	theChaincodeSupport.auth = accesscontrol.NewAuthenticator(theChaincodeSupport, ca)
@@ -229,6 +231,7 @@ type ChaincodeSupport struct {
 	executetimeout    time.Duration
 	userRunsCC        bool
 	peerTLS           bool
+	metrics           metrics.Scope
 }
 
 // DuplicateChaincodeHandlerError returned if attempt to register same chaincodeID while a stream already exists.
@@ -726,7 +729,9 @@ func (chaincodeSupport *ChaincodeSupport) Launch(context context.Context, cccid
 
 		builder := func() (io.Reader, error) { return platforms.GenerateDockerBuild(cds) }
 
+		launchStartTime := time.Now()
 		err = chaincodeSupport.launchAndWaitForRegister(context, cccid, cds, &ccLauncherImpl{context, chaincodeSupport, cccid, cds, builder})
+		chaincodeSupport.recordLaunch(cID.Name, time.Since(launchStartTime), err)
 		if err != nil {
 			chaincodeLogger.Errorf("launchAndWaitForRegister failed: %+v", err)
 			return cID, cMsg, err
@@ -752,6 +757,16 @@ func (chaincodeSupport *ChaincodeSupport) Launch(context context.Context, cccid
 	return cID, cMsg, err
 }
 
+// recordLaunch records the duration and the outcome of the launch of a chaincode container
+func (chaincodeSupport *ChaincodeSupport) recordLaunch(ccName string, duration time.Duration, err error) {
+	scope := chaincodeSupport.metrics.Tagged(map[string]string{
+		"chaincode": ccName,
+		"success":   strconv.FormatBool(err == nil),
+	})
+	scope.Counter("launches_total").Inc(1)
+	scope.Histogram("launch_duration").RecordDuration(duration)
+}
+
 //getVMType - just returns a string for now. Another possibility is to use a factory method to
 //return a VM executor
 func (chaincodeSupport *ChaincodeSupport) getVMType(cds *pb.ChaincodeDeploymentSpec) (string, error) {
@@ -15,15 +15,18 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/hyperledger/fabric/common/flogging"
 	commonledger "github.com/hyperledger/fabric/common/ledger"
 	mc "github.com/hyperledger/fabric/common/mocks/config"
 	mocklgr "github.com/hyperledger/fabric/common/mocks/ledger"
+	mockmetrics "github.com/hyperledger/fabric/common/mocks/metrics"
 	mockpeer "github.com/hyperledger/fabric/common/mocks/peer"
 	"github.com/hyperledger/fabric/common/util"
 	"github.com/hyperledger/fabric/core/aclmgmt"
@@ -35,6 +38,7 @@ import (
 	"github.com/hyperledger/fabric/core/config"
 	"github.com/hyperledger/fabric/core/container"
 	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
 	"github.com/hyperledger/fabric/core/ledger/ledgermgmt"
 	cmp "github.com/hyperledger/fabric/core/mocks/peer"
 	"github.com/hyperledger/fabric/core/peer"
@@ -44,6 +48,7 @@ import (
 	plgr "github.com/hyperledger/fabric/protos/ledger/queryresult"
 	pb "github.com/hyperledger/fabric/protos/peer"
 	putils "github.com/hyperledger/fabric/protos/utils"
+	"github.com/spf13/viper"
 	"golang.org/x/net/context"
 )
 
@@ -584,6 +589,166 @@ func getQueryStateByRange(t *testing.T, collection, chainID, ccname string, ccSi
 	return nil
 }
 
+func getQueryStateByRangeWithPagination(t *testing.T, chainID, ccname string, ccSide *mockpeer.MockCCComm) error {
+	done := setuperror()
+
+	errorFunc := func(ind int, err error) {
+		done <- err
+	}
+
+	chaincodeID := &pb.ChaincodeID{Name: ccname, Version: "0"}
+	ci := &pb.ChaincodeInput{Args: [][]byte{[]byte("invoke"), []byte("A"), []byte("B"), []byte("10")}, Decorations: nil}
+	cis := &pb.ChaincodeInvocationSpec{ChaincodeSpec: &pb.ChaincodeSpec{Type: pb.ChaincodeSpec_Type(pb.ChaincodeSpec_Type_value["GOLANG"]), ChaincodeId: chaincodeID, Input: ci}}
+	txid := util.GenerateUUID()
+	ctxt, txsim, sprop, prop := startTx(t, chainID, cis, txid)
+
+	//setup CheckACL calls
+	mockAclProvider.Reset()
+	mockAclProvider.On("CheckACL", resources.LSCC_GETDEPSPEC, chainID, sprop).Return(nil)
+	mockAclProvider.On("CheckACL", resources.LSCC_GETCCDATA, chainID, sprop).Return(nil)
+	mockAclProvider.On("CheckACL", resources.PROPOSE, chainID, sprop).Return(nil)
+
+	//the whole page is returned in the response, along with the bookmark of the next page
+	checkPageFunc := func(reqMsg *pb.ChaincodeMessage) *pb.ChaincodeMessage {
+		qr := &pb.QueryResponse{}
+		proto.Unmarshal(reqMsg.Payload, qr)
+		qrm := &pb.QueryResponseMetadata{}
+		proto.Unmarshal(qr.Metadata, qrm)
+		if qr.HasMore || len(qr.Results) != 1 || qrm.FetchedRecordsCount != 1 || qrm.Bookmark != "B" {
+			return &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_COMPLETED, Payload: putils.MarshalOrPanic(&pb.Response{Status: shim.ERROR, Message: "unexpected page"}), Txid: txid, ChannelId: chainID}
+		}
+		return &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_COMPLETED, Payload: putils.MarshalOrPanic(&pb.Response{Status: shim.OK, Payload: []byte("OK")}), Txid: txid, ChannelId: chainID}
+	}
+
+	queryMetadata := putils.MarshalOrPanic(&pb.QueryMetadata{PageSize: 1})
+	respSet := &mockpeer.MockResponseSet{errorFunc, nil, []*mockpeer.MockResponse{
+		{&pb.ChaincodeMessage{Type: pb.ChaincodeMessage_TRANSACTION}, &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_GET_STATE_BY_RANGE, Payload: putils.MarshalOrPanic(&pb.GetStateByRange{StartKey: "A", EndKey: "C", Metadata: queryMetadata}), Txid: txid, ChannelId: chainID}},
+		{&pb.ChaincodeMessage{Type: pb.ChaincodeMessage_RESPONSE}, checkPageFunc}}}
+
+	cccid := ccprovider.NewCCContext(chainID, ccname, "0", txid, false, sprop, prop)
+	execCC(t, ctxt, ccSide, cccid, false, false, done, cis, respSet)
+
+	endTx(t, cccid, txsim, cis)
+
+	return nil
+}
+
+func TestGetQueryMetadata(t *testing.T) {
+	queryMetadata, err := getQueryMetadata(nil)
+	if queryMetadata != nil || err != nil {
+		t.Fatalf("expected no query metadata for a query which is not paginated, got %v, %v", queryMetadata, err)
+	}
+
+	queryMetadata, err = getQueryMetadata(putils.MarshalOrPanic(&pb.QueryMetadata{PageSize: 10, Bookmark: "key1"}))
+	if err != nil || queryMetadata.PageSize != 10 || queryMetadata.Bookmark != "key1" {
+		t.Fatalf("unexpected query metadata %v, %v", queryMetadata, err)
+	}
+
+	if _, err = getQueryMetadata(putils.MarshalOrPanic(&pb.QueryMetadata{Bookmark: "key1"})); err == nil {
+		t.Fatalf("expected an error for a page size of zero")
+	}
+
+	if _, err = getQueryMetadata([]byte("garbage")); err == nil {
+		t.Fatalf("expected an error for malformed query metadata")
+	}
+
+	// an oversized page is capped at the query limit
+	defer viper.Set("ledger.state.couchDBConfig.queryLimit", ledgerconfig.GetQueryLimit())
+	viper.Set("ledger.state.couchDBConfig.queryLimit", 50)
+	queryMetadata, err = getQueryMetadata(putils.MarshalOrPanic(&pb.QueryMetadata{PageSize: 1000000, Bookmark: "key1"}))
+	if err != nil || queryMetadata.PageSize != 50 || queryMetadata.Bookmark != "key1" {
+		t.Fatalf("expected the page size to be capped at the query limit, got %v, %v", queryMetadata, err)
+	}
+}
+
+// mockPaginatedIterator returns the given number of results, followed by the bookmark of the next page
+type mockPaginatedIterator struct {
+	remaining int
+	closed    bool
+}
+
+func (itr *mockPaginatedIterator) Next() (commonledger.QueryResult, error) {
+	if itr.remaining == 0 {
+		return nil, nil
+	}
+	itr.remaining--
+	return &plgr.KV{Key: fmt.Sprintf("key%d", itr.remaining)}, nil
+}
+
+func (itr *mockPaginatedIterator) Close() {
+	itr.closed = true
+}
+
+func (itr *mockPaginatedIterator) GetBookmarkAndClose() string {
+	itr.Close()
+	return "nextkey"
+}
+
+func TestGetPaginatedQueryResponse(t *testing.T) {
+	handler := &Handler{}
+	txContext := &transactionContext{queryIteratorMap: map[string]commonledger.ResultsIterator{}}
+	iter := &mockPaginatedIterator{remaining: 2*maxResultLimit + 1}
+	handler.putQueryIterator(txContext, "iterID", iter)
+
+	// the first response carries the metadata of the whole page, but only the first batch of its results
+	response, err := getQueryResponse(handler, txContext, iter, "iterID", true)
+	if err != nil {
+		t.Fatalf("failed to get the query response: %s", err)
+	}
+	responseMetadata := &pb.QueryResponseMetadata{}
+	if err = proto.Unmarshal(response.Metadata, responseMetadata); err != nil {
+		t.Fatalf("failed to unmarshal the query response metadata: %s", err)
+	}
+	if len(response.Results) != maxResultLimit || !response.HasMore || !iter.closed ||
+		responseMetadata.FetchedRecordsCount != 2*maxResultLimit+1 || responseMetadata.Bookmark != "nextkey" {
+		t.Fatalf("unexpected first response %v with metadata %v", response, responseMetadata)
+	}
+
+	// the rest of the page is returned in batches through QUERY_STATE_NEXT
+	var results int
+	for response.HasMore {
+		response, err = getQueryResponse(handler, txContext, handler.getQueryIterator(txContext, "iterID"), "iterID", false)
+		if err != nil || len(response.Results) > maxResultLimit {
+			t.Fatalf("unexpected next response %v, %v", response, err)
+		}
+		results += len(response.Results)
+	}
+	if results != maxResultLimit+1 || handler.getQueryIterator(txContext, "iterID") != nil {
+		t.Fatalf("expected the remaining %d results of the page and the iterator to be released, got %d results", maxResultLimit+1, results)
+	}
+}
+
+func TestGetHistoryQueryOptions(t *testing.T) {
+	historyOptions, err := getHistoryQueryOptions(nil)
+	if historyOptions != nil || err != nil {
+		t.Fatalf("expected no history query options for a query of the complete history, got %v, %v", historyOptions, err)
+	}
+
+	startTime := &timestamp.Timestamp{Seconds: 10}
+	historyOptions, err = getHistoryQueryOptions(putils.MarshalOrPanic(&pb.HistoryQueryMetadata{StartBlock: 2, EndBlock: 5,
+		StartTime: startTime, Descending: true, PageSize: 10, Bookmark: "4:0"}))
+	expectedOptions := &ledger.HistoryQueryOptions{StartBlock: 2, EndBlock: 5, StartTime: startTime, Descending: true, PageSize: 10, Bookmark: "4:0"}
+	if err != nil || !reflect.DeepEqual(historyOptions, expectedOptions) {
+		t.Fatalf("unexpected history query options %v, %v", historyOptions, err)
+	}
+
+	if _, err = getHistoryQueryOptions(putils.MarshalOrPanic(&pb.HistoryQueryMetadata{PageSize: -1})); err == nil {
+		t.Fatalf("expected an error for a negative page size")
+	}
+
+	if _, err = getHistoryQueryOptions([]byte("garbage")); err == nil {
+		t.Fatalf("expected an error for malformed history query metadata")
+	}
+
+	// an oversized page is capped at the query limit
+	defer viper.Set("ledger.state.couchDBConfig.queryLimit", ledgerconfig.GetQueryLimit())
+	viper.Set("ledger.state.couchDBConfig.queryLimit", 50)
+	historyOptions, err = getHistoryQueryOptions(putils.MarshalOrPanic(&pb.HistoryQueryMetadata{PageSize: 1000000}))
+	if err != nil || historyOptions.PageSize != 50 {
+		t.Fatalf("expected the page size to be capped at the query limit, got %v, %v", historyOptions, err)
+	}
+}
+
 func cc2cc(t *testing.T, chainID, chainID2, ccname string, ccSide *mockpeer.MockCCComm) error {
 	calledCC := "calledCC"
 	//starts and registers the CC
@@ -879,6 +1044,23 @@ func TestLaunchAndWaitSuccess(t *testing.T) {
 	}
 }
 
+func TestRecordLaunch(t *testing.T) {
+	scope := mockmetrics.NewScope()
+	newCCSupport := &ChaincodeSupport{metrics: scope}
+	newCCSupport.recordLaunch("testcc", time.Second, nil)
+	newCCSupport.recordLaunch("testcc", time.Second, errors.New("timeout expired"))
+
+	for _, success := range []string{"true", "false"} {
+		tags := map[string]string{"chaincode": "testcc", "success": success}
+		if count := scope.CounterValue("launches_total", tags); count != 1 {
+			t.Fatalf("expected 1 launch with success=%s but got %d", success, count)
+		}
+		if durations := scope.HistogramValues("launch_duration", tags); len(durations) != 1 || durations[0] != time.Second {
+			t.Fatalf("expected a launch duration of 1s with success=%s but got %v", success, durations)
+		}
+	}
+}
+
 //test timeout error
 func TestLaunchAndWaitTimeout(t *testing.T) {
 	newCCSupport := &ChaincodeSupport{peerTLS: false, chaincodeLogLevel: "debug", shimLogLevel: "info", ccStartupTimeout: time.Duration(500) * time.Millisecond, runningChaincodes: &runningChaincodes{chaincodeMap: make(map[string]*chaincodeRTEnv), launchStarted: make(map[string]bool)}, peerNetworkID: "networkID", peerID: "peerID"}
@@ -1154,6 +1336,9 @@ func TestCCFramework(t *testing.T) {
 	//call's query state range
 	getQueryStateByRange(t, "", chainID, ccname, ccSide)
 
+	//call's paginated query state range
+	getQueryStateByRangeWithPagination(t, chainID, ccname, ccSide)
+
 	//call's cc2cc on the same chaincode only call to chainID2 should succeed
 	cc2SameCC(t, chainID, chainID2, ccname, ccSide)
 
@@ -10,6 +10,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"sort"
 	"sync"
 	"time"
 
@@ -23,6 +24,7 @@ import (
 	"github.com/hyperledger/fabric/core/common/sysccprovider"
 	"github.com/hyperledger/fabric/core/container/ccintf"
 	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
 	"github.com/hyperledger/fabric/core/peer"
 	pb "github.com/hyperledger/fabric/protos/peer"
 	"github.com/looplab/fsm"
@@ -408,9 +410,11 @@ func newChaincodeSupportHandler(chaincodeSupport *ChaincodeSupport, peerChatStre
 			{Name: pb.ChaincodeMessage_READY.String(), Src: []string{establishedstate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_PUT_STATE.String(), Src: []string{readystate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_DEL_STATE.String(), Src: []string{readystate}, Dst: readystate},
+			{Name: pb.ChaincodeMessage_PUT_STATE_METADATA.String(), Src: []string{readystate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_INVOKE_CHAINCODE.String(), Src: []string{readystate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_COMPLETED.String(), Src: []string{readystate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_GET_STATE.String(), Src: []string{readystate}, Dst: readystate},
+			{Name: pb.ChaincodeMessage_GET_STATE_METADATA.String(), Src: []string{readystate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_GET_STATE_BY_RANGE.String(), Src: []string{readystate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_GET_QUERY_RESULT.String(), Src: []string{readystate}, Dst: readystate},
 			{Name: pb.ChaincodeMessage_GET_HISTORY_FOR_KEY.String(), Src: []string{readystate}, Dst: readystate},
@@ -425,6 +429,7 @@ func newChaincodeSupportHandler(chaincodeSupport *ChaincodeSupport, peerChatStre
 			"before_" + pb.ChaincodeMessage_REGISTER.String():           func(e *fsm.Event) { v.beforeRegisterEvent(e, v.FSM.Current()) },
 			"before_" + pb.ChaincodeMessage_COMPLETED.String():          func(e *fsm.Event) { v.beforeCompletedEvent(e, v.FSM.Current()) },
 			"after_" + pb.ChaincodeMessage_GET_STATE.String():           func(e *fsm.Event) { v.afterGetState(e, v.FSM.Current()) },
+			"after_" + pb.ChaincodeMessage_GET_STATE_METADATA.String():  func(e *fsm.Event) { v.afterGetStateMetadata(e, v.FSM.Current()) },
 			"after_" + pb.ChaincodeMessage_GET_STATE_BY_RANGE.String():  func(e *fsm.Event) { v.afterGetStateByRange(e, v.FSM.Current()) },
 			"after_" + pb.ChaincodeMessage_GET_QUERY_RESULT.String():    func(e *fsm.Event) { v.afterGetQueryResult(e, v.FSM.Current()) },
 			"after_" + pb.ChaincodeMessage_GET_HISTORY_FOR_KEY.String(): func(e *fsm.Event) { v.afterGetHistoryForKey(e, v.FSM.Current()) },
@@ -432,6 +437,7 @@ func newChaincodeSupportHandler(chaincodeSupport *ChaincodeSupport, peerChatStre
 			"after_" + pb.ChaincodeMessage_QUERY_STATE_CLOSE.String():   func(e *fsm.Event) { v.afterQueryStateClose(e, v.FSM.Current()) },
 			"after_" + pb.ChaincodeMessage_PUT_STATE.String():           func(e *fsm.Event) { v.enterBusyState(e, v.FSM.Current()) },
 			"after_" + pb.ChaincodeMessage_DEL_STATE.String():           func(e *fsm.Event) { v.enterBusyState(e, v.FSM.Current()) },
+			"after_" + pb.ChaincodeMessage_PUT_STATE_METADATA.String():  func(e *fsm.Event) { v.enterBusyState(e, v.FSM.Current()) },
 			"after_" + pb.ChaincodeMessage_INVOKE_CHAINCODE.String():    func(e *fsm.Event) { v.enterBusyState(e, v.FSM.Current()) },
 			"enter_" + establishedstate:                                 func(e *fsm.Event) { v.enterEstablishedState(e, v.FSM.Current()) },
 			"enter_" + readystate:                                       func(e *fsm.Event) { v.enterReadyState(e, v.FSM.Current()) },
@@ -653,6 +659,112 @@ func (handler *Handler) handleGetState(msg *pb.ChaincodeMessage) {
 	}()
 }
 
+// afterGetStateMetadata handles a GET_STATE_METADATA request from the chaincode.
+func (handler *Handler) afterGetStateMetadata(e *fsm.Event, state string) {
+	msg, ok := e.Args[0].(*pb.ChaincodeMessage)
+	if !ok {
+		e.Cancel(errors.New("received unexpected message type"))
+		return
+	}
+	chaincodeLogger.Debugf("[%s]Received %s, invoking get state metadata from ledger", shorttxid(msg.Txid), pb.ChaincodeMessage_GET_STATE_METADATA)
+
+	// Query ledger for state metadata
+	handler.handleGetStateMetadata(msg)
+}
+
+// Handles query to ledger to get the metadata of a key
+func (handler *Handler) handleGetStateMetadata(msg *pb.ChaincodeMessage) {
+	go func() {
+		// Check if this is the unique state request from this chaincode txid
+		uniqueReq := handler.createTXIDEntry(msg.ChannelId, msg.Txid)
+		if !uniqueReq {
+			// Drop this request
+			chaincodeLogger.Error("Another state request pending for this Txid. Cannot process.")
+			return
+		}
+
+		var serialSendMsg *pb.ChaincodeMessage
+		var txContext *transactionContext
+		txContext, serialSendMsg = handler.isValidTxSim(msg.ChannelId, msg.Txid,
+			"[%s]No ledger context for GetStateMetadata. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_ERROR)
+
+		defer func() {
+			handler.deleteTXIDEntry(msg.ChannelId, msg.Txid)
+			chaincodeLogger.Debugf("[%s]handleGetStateMetadata serial send %s",
+				shorttxid(serialSendMsg.Txid), serialSendMsg.Type)
+			handler.serialSendAsync(serialSendMsg, nil)
+		}()
+
+		if txContext == nil {
+			return
+		}
+
+		errHandler := func(err error, errFmt string, errArgs ...interface{}) {
+			chaincodeLogger.Errorf(errFmt, errArgs...)
+			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: []byte(err.Error()), Txid: msg.Txid, ChannelId: msg.ChannelId}
+		}
+
+		if err := handler.checkKeyLevelEndorsement(txContext.chainID); err != nil {
+			errHandler(err, "[%s]Key level metadata not supported. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_ERROR)
+			return
+		}
+
+		getStateMetadata := &pb.GetStateMetadata{}
+		if err := proto.Unmarshal(msg.Payload, getStateMetadata); err != nil {
+			errHandler(err, "[%s]Unable to decipher payload. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_ERROR)
+			return
+		}
+		if isCollectionSet(getStateMetadata.Collection) {
+			errHandler(errors.New("metadata of private data is not supported"),
+				"[%s]Failed to get state metadata. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_ERROR)
+			return
+		}
+		chaincodeID := handler.getCCRootName()
+		chaincodeLogger.Debugf("[%s] getting state metadata for chaincode %s, key %s, channel %s",
+			shorttxid(msg.Txid), chaincodeID, getStateMetadata.Key, txContext.chainID)
+
+		metadata, err := txContext.txsimulator.GetStateMetadata(chaincodeID, getStateMetadata.Key)
+		if err != nil {
+			errHandler(err, "[%s]Failed to get state metadata(%s). Sending %s", shorttxid(msg.Txid), err, pb.ChaincodeMessage_ERROR)
+			return
+		}
+
+		var metadataResult pb.StateMetadataResult
+		for _, metakey := range sortedMetadataKeys(metadata) {
+			metadataResult.Entries = append(metadataResult.Entries, &pb.StateMetadata{Metakey: metakey, Value: metadata[metakey]})
+		}
+		res, err := proto.Marshal(&metadataResult)
+		if err != nil {
+			errHandler(err, "[%s]Failed to marshal state metadata(%s). Sending %s", shorttxid(msg.Txid), err, pb.ChaincodeMessage_ERROR)
+			return
+		}
+		chaincodeLogger.Debugf("[%s]Got state metadata. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_RESPONSE)
+		serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_RESPONSE, Payload: res, Txid: msg.Txid, ChannelId: msg.ChannelId}
+	}()
+}
+
+// checkKeyLevelEndorsement returns an error if the channel does not support the metadata of the keys,
+// which is used for setting key level endorsement policies
+func (handler *Handler) checkKeyLevelEndorsement(chainID string) error {
+	ac, exists := sysccprovider.GetSystemChaincodeProvider().GetApplicationConfig(chainID)
+	if !exists {
+		return errors.Errorf("application config for channel %s not found", chainID)
+	}
+	if !ac.Capabilities().KeyLevelEndorsement() {
+		return errors.Errorf("key level endorsement is not enabled on channel %s", chainID)
+	}
+	return nil
+}
+
+func sortedMetadataKeys(metadata map[string][]byte) []string {
+	var keys []string
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // afterGetStateByRange handles a GET_STATE_BY_RANGE request from the chaincode.
 func (handler *Handler) afterGetStateByRange(e *fsm.Event, state string) {
 	msg, ok := e.Args[0].(*pb.ChaincodeMessage)
@@ -717,12 +829,29 @@ func (handler *Handler) handleGetStateByRange(msg *pb.ChaincodeMessage) {
 			chaincodeLogger.Errorf(errFmt, errArgs...)
 			serialSendMsg = &pb.ChaincodeMessage{Type: pb.ChaincodeMessage_ERROR, Payload: payload, Txid: msg.Txid, ChannelId: msg.ChannelId}
 		}
+		queryMetadata, err := getQueryMetadata(getStateByRange.Metadata)
+		if err != nil {
+			errHandler(err, nil, "Failed to get query metadata. Sending %s", pb.ChaincodeMessage_ERROR)
+			return
+		}
+
 		var rangeIter commonledger.ResultsIterator
-		var err error
 
-		if isCollectionSet(getStateByRange.Collection) {
+		switch {
+		case isCollectionSet(getStateByRange.Collection):
+			if queryMetadata != nil {
+				errHandler(errors.New("pagination is not supported for queries on private data"), nil, "Failed to get ledger scan iterator. Sending %s", pb.ChaincodeMessage_ERROR)
+				return
+			}
 			rangeIter, err = txContext.txsimulator.GetPrivateDataRangeScanIterator(chaincodeID, getStateByRange.Collection, getStateByRange.StartKey, getStateByRange.EndKey)
-		} else {
+		case queryMetadata != nil:
+			// the bookmark of a page is the start key of the next page
+			startKey := getStateByRange.StartKey
+			if queryMetadata.Bookmark != "" {
+				startKey = queryMetadata.Bookmark
+			}
+			rangeIter, err = txContext.txsimulator.GetStateRangeScanIteratorWithPagination(chaincodeID, startKey, getStateByRange.EndKey, queryMetadata.PageSize)
+		default:
 			rangeIter, err = txContext.txsimulator.GetStateRangeScanIterator(chaincodeID, getStateByRange.StartKey, getStateByRange.EndKey)
 		}
 		if err != nil {
@@ -732,7 +861,7 @@ func (handler *Handler) handleGetStateByRange(msg *pb.ChaincodeMessage) {
 
 		handler.putQueryIterator(txContext, iterID, rangeIter)
 		var payload *pb.QueryResponse
-		payload, err = getQueryResponse(handler, txContext, rangeIter, iterID)
+		payload, err = getQueryResponse(handler, txContext, rangeIter, iterID, queryMetadata != nil)
 		if err != nil {
 			errHandler(err, rangeIter, "Failed to get query result. Sending %s", pb.ChaincodeMessage_ERROR)
 			return
@@ -753,12 +882,39 @@ func (handler *Handler) handleGetStateByRange(msg *pb.ChaincodeMessage) {
 const maxResultLimit = 100
 
 //getQueryResponse takes an iterator and fetch state to construct QueryResponse
+//The results of a paginated query are read up to the page size, which is limited by the
+//query limit, so that the first response carries the QueryResponseMetadata with the bookmark
+//of the next page. They are then returned in batches of maxResultLimit, like those of any query
 func getQueryResponse(handler *Handler, txContext *transactionContext, iter commonledger.ResultsIterator,
-	iterID string) (*pb.QueryResponse, error) {
+	iterID string, isPaginated bool) (*pb.QueryResponse, error) {
 
 	var err error
 	var queryResult commonledger.QueryResult
 	var queryResultsBytes []*pb.QueryResultBytes
+	var responseMetadataBytes []byte
+
+	if isPaginated {
+		var page []commonledger.QueryResult
+		for {
+			if queryResult, err = iter.Next(); err != nil {
+				chaincodeLogger.Errorf("Failed to get query result from iterator")
+				handler.deleteQueryIterator(txContext, iterID)
+				return nil, err
+			}
+			if queryResult == nil {
+				break
+			}
+			page = append(page, queryResult)
+		}
+		bookmark := iter.(ledger.QueryResultsIterator).GetBookmarkAndClose()
+		responseMetadata := &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(page)), Bookmark: bookmark}
+		if responseMetadataBytes, err = proto.Marshal(responseMetadata); err != nil {
+			handler.deleteQueryIterator(txContext, iterID)
+			return nil, err
+		}
+		iter = &pageIterator{results: page}
+		handler.putQueryIterator(txContext, iterID, iter)
+	}
 
 	for i := 0; i < maxResultLimit; i++ {
 		queryResult, err = iter.Next()
@@ -787,7 +943,53 @@ func getQueryResponse(handler *Handler, txContext *transactionContext, iter comm
 			return nil, err
 		}
 	}
-	return &pb.QueryResponse{Results: queryResultsBytes, HasMore: queryResult != nil, Id: iterID}, nil
+	return &pb.QueryResponse{Results: queryResultsBytes, HasMore: queryResult != nil, Id: iterID, Metadata: responseMetadataBytes}, nil
+}
+
+// pageIterator iterates over the results of a page of a paginated query which have already
+// been read from the ledger, so that they can be returned through QUERY_STATE_NEXT requests
+type pageIterator struct {
+	results []commonledger.QueryResult
+}
+
+func (pi *pageIterator) Next() (commonledger.QueryResult, error) {
+	if len(pi.results) == 0 {
+		return nil, nil
+	}
+	queryResult := pi.results[0]
+	pi.results = pi.results[1:]
+	return queryResult, nil
+}
+
+func (pi *pageIterator) Close() {
+	pi.results = nil
+}
+
+// getQueryMetadata unmarshals the QueryMetadata of a paginated query. It returns
+// nil if the metadata is not set, that is, if the query is not paginated. The page
+// size is capped at the query limit, since a page is read from the ledger at once
+func getQueryMetadata(metadataBytes []byte) (*pb.QueryMetadata, error) {
+	if len(metadataBytes) == 0 {
+		return nil, nil
+	}
+	queryMetadata := &pb.QueryMetadata{}
+	if err := proto.Unmarshal(metadataBytes, queryMetadata); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal query metadata")
+	}
+	if queryMetadata.PageSize <= 0 {
+		return nil, errors.Errorf("invalid page size [%d], it must be greater than zero", queryMetadata.PageSize)
+	}
+	queryMetadata.PageSize = capPageSize(queryMetadata.PageSize)
+	return queryMetadata, nil
+}
+
+// capPageSize returns the given page size, or the query limit if it is greater
+func capPageSize(pageSize int32) int32 {
+	if queryLimit := int32(ledgerconfig.GetQueryLimit()); pageSize > queryLimit {
+		chaincodeLogger.Warningf("The page size [%d] exceeds the query limit, capping it at [%d]", pageSize, queryLimit)
+		return queryLimit
+	}
+	return pageSize
 }
 
 // afterQueryStateNext handles a QUERY_STATE_NEXT request from the chaincode.
@@ -859,7 +1061,7 @@ func (handler *Handler) handleQueryStateNext(msg *pb.ChaincodeMessage) {
 			return
 		}
 
-		payload, err := getQueryResponse(handler, txContext, queryIter, queryStateNext.Id)
+		payload, err := getQueryResponse(handler, txContext, queryIter, queryStateNext.Id, false)
 		if err != nil {
 			errHandler([]byte(err.Error()), queryIter, "Failed to get query result. Sending %s", pb.ChaincodeMessage_ERROR)
 			return
@@ -1012,11 +1214,23 @@ func (handler *Handler) handleGetQueryResult(msg *pb.ChaincodeMessage) {
 
 		chaincodeID := handler.getCCRootName()
 
-		var err error
+		queryMetadata, err := getQueryMetadata(getQueryResult.Metadata)
+		if err != nil {
+			errHandler([]byte(err.Error()), nil, "Failed to get query metadata. Sending %s", pb.ChaincodeMessage_ERROR)
+			return
+		}
+
 		var executeIter commonledger.ResultsIterator
-		if isCollectionSet(getQueryResult.Collection) {
+		switch {
+		case isCollectionSet(getQueryResult.Collection):
+			if queryMetadata != nil {
+				errHandler([]byte("pagination is not supported for queries on private data"), nil, "Failed to get ledger query iterator. Sending %s", pb.ChaincodeMessage_ERROR)
+				return
+			}
 			executeIter, err = txContext.txsimulator.ExecuteQueryOnPrivateData(chaincodeID, getQueryResult.Collection, getQueryResult.Query)
-		} else {
+		case queryMetadata != nil:
+			executeIter, err = txContext.txsimulator.ExecuteQueryWithPagination(chaincodeID, getQueryResult.Query, queryMetadata.Bookmark, queryMetadata.PageSize)
+		default:
 			executeIter, err = txContext.txsimulator.ExecuteQuery(chaincodeID, getQueryResult.Query)
 		}
 
@@ -1027,7 +1241,7 @@ func (handler *Handler) handleGetQueryResult(msg *pb.ChaincodeMessage) {
 
 		handler.putQueryIterator(txContext, iterID, executeIter)
 		var payload *pb.QueryResponse
-		payload, err = getQueryResponse(handler, txContext, executeIter, iterID)
+		payload, err = getQueryResponse(handler, txContext, executeIter, iterID, queryMetadata != nil)
 		if err != nil {
 			errHandler([]byte(err.Error()), executeIter, "Failed to get query result. Sending %s", pb.ChaincodeMessage_ERROR)
 			return
@@ -1109,7 +1323,18 @@ func (handler *Handler) handleGetHistoryForKey(msg *pb.ChaincodeMessage) {
 		}
 		chaincodeID := handler.getCCRootName()
 
-		historyIter, err := txContext.historyQueryExecutor.GetHistoryForKey(chaincodeID, getHistoryForKey.Key)
+		historyOptions, err := getHistoryQueryOptions(getHistoryForKey.Metadata)
+		if err != nil {
+			errHandler([]byte(err.Error()), nil, "Failed to get history query metadata. Sending %s", pb.ChaincodeMessage_ERROR)
+			return
+		}
+
+		var historyIter commonledger.ResultsIterator
+		if historyOptions != nil {
+			historyIter, err = txContext.historyQueryExecutor.GetHistoryForKeyWithOptions(chaincodeID, getHistoryForKey.Key, historyOptions)
+		} else {
+			historyIter, err = txContext.historyQueryExecutor.GetHistoryForKey(chaincodeID, getHistoryForKey.Key)
+		}
 		if err != nil {
 			errHandler([]byte(err.Error()), nil, "Failed to get ledger history iterator. Sending %s", pb.ChaincodeMessage_ERROR)
 			return
@@ -1118,7 +1343,7 @@ func (handler *Handler) handleGetHistoryForKey(msg *pb.ChaincodeMessage) {
 		handler.putQueryIterator(txContext, iterID, historyIter)
 
 		var payload *pb.QueryResponse
-		payload, err = getQueryResponse(handler, txContext, historyIter, iterID)
+		payload, err = getQueryResponse(handler, txContext, historyIter, iterID, historyOptions != nil && historyOptions.PageSize > 0)
 
 		if err != nil {
 			errHandler([]byte(err.Error()), historyIter, "Failed to get query result. Sending %s", pb.ChaincodeMessage_ERROR)
@@ -1138,6 +1363,33 @@ func (handler *Handler) handleGetHistoryForKey(msg *pb.ChaincodeMessage) {
 	}()
 }
 
+// getHistoryQueryOptions unmarshals the HistoryQueryMetadata of a history query. It
+// returns nil if the metadata is not set, that is, if the complete history is requested
+func getHistoryQueryOptions(metadataBytes []byte) (*ledger.HistoryQueryOptions, error) {
+	if len(metadataBytes) == 0 {
+		return nil, nil
+	}
+	historyMetadata := &pb.HistoryQueryMetadata{}
+	if err := proto.Unmarshal(metadataBytes, historyMetadata); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal history query metadata")
+	}
+	if historyMetadata.PageSize < 0 {
+		return nil, errors.Errorf("invalid page size [%d], it must not be negative", historyMetadata.PageSize)
+	}
+	if historyMetadata.PageSize > 0 {
+		historyMetadata.PageSize = capPageSize(historyMetadata.PageSize)
+	}
+	return &ledger.HistoryQueryOptions{
+		StartBlock: historyMetadata.StartBlock,
+		EndBlock:   historyMetadata.EndBlock,
+		StartTime:  historyMetadata.StartTime,
+		EndTime:    historyMetadata.EndTime,
+		Descending: historyMetadata.Descending,
+		PageSize:   historyMetadata.PageSize,
+		Bookmark:   historyMetadata.Bookmark,
+	}, nil
+}
+
 func isCollectionSet(collection string) bool {
 	if collection == "" {
 		return false
@@ -1258,6 +1510,39 @@ func (handler *Handler) enterBusyState(e *fsm.Event, state string) {
 			} else {
 				err = txContext.txsimulator.DeleteState(chaincodeID, delState.Key)
 			}
+		} else if msg.Type.String() == pb.ChaincodeMessage_PUT_STATE_METADATA.String() {
+			if err := handler.checkKeyLevelEndorsement(txContext.chainID); err != nil {
+				errHandler([]byte(err.Error()), "[%s]Key level metadata not supported. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_ERROR)
+				return
+			}
+
+			putStateMetadata := &pb.PutStateMetadata{}
+			unmarshalErr := proto.Unmarshal(msg.Payload, putStateMetadata)
+			if unmarshalErr != nil {
+				errHandler([]byte(unmarshalErr.Error()), "[%s]Unable to decipher payload. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_ERROR)
+				return
+			}
+			if putStateMetadata.Metadata == nil {
+				errHandler([]byte("no metadata provided"), "[%s]No metadata provided. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_ERROR)
+				return
+			}
+			if isCollectionSet(putStateMetadata.Collection) {
+				errHandler([]byte("metadata of private data is not supported"), "[%s]Metadata of private data is not supported. Sending %s", shorttxid(msg.Txid), pb.ChaincodeMessage_ERROR)
+				return
+			}
+
+			// the metadata write replaces only the given entry and retains the other entries of the key
+			var metadata map[string][]byte
+			metadata, err = txContext.txsimulator.GetStateMetadata(chaincodeID, putStateMetadata.Key)
+			if err != nil {
+				errHandler([]byte(err.Error()), "[%s]Failed to get state metadata(%s). Sending %s", shorttxid(msg.Txid), err, pb.ChaincodeMessage_ERROR)
+				return
+			}
+			if metadata == nil {
+				metadata = make(map[string][]byte)
+			}
+			metadata[putStateMetadata.Metadata.Metakey] = putStateMetadata.Metadata.Value
+			err = txContext.txsimulator.SetStateMetadata(chaincodeID, putStateMetadata.Key, metadata)
 		} else if msg.Type.String() == pb.ChaincodeMessage_INVOKE_CHAINCODE.String() {
 			chaincodeLogger.Debugf("[%s] C-call-C", shorttxid(msg.Txid))
 			chaincodeSpec := &pb.ChaincodeSpec{}
@@ -19,6 +19,8 @@ package golang
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"sort"
 	"strings"
 
 	"os"
@@ -26,6 +28,7 @@ import (
 
 	"github.com/hyperledger/fabric/common/flogging"
 	ccutil "github.com/hyperledger/fabric/core/chaincode/platforms/util"
+	"github.com/hyperledger/fabric/core/common/ccprovider/ccmetadata"
 	pb "github.com/hyperledger/fabric/protos/peer"
 )
 
@@ -148,3 +151,48 @@ func findSource(gopath, pkg string) (SourceMap, error) {
 
 	return sources, nil
 }
+
+// findMetadata returns the metadata files found in the META-INF directory of the given package,
+// named relative to the package directory, e.g. META-INF/statedb/couchdb/indexes/indexOwner.json
+func findMetadata(gopath, pkg string) (Sources, error) {
+	metadata := make(Sources, 0)
+	tld := filepath.Join(gopath, "src", pkg)
+	metadataDir := filepath.Join(tld, "META-INF")
+	if _, err := os.Stat(metadataDir); os.IsNotExist(err) {
+		return metadata, nil
+	}
+	walkFn := func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// hidden files, e.g. those created by editors, are not metadata
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+
+		name, err := filepath.Rel(tld, path)
+		if err != nil {
+			return fmt.Errorf("error obtaining relative path for %s: %s", path, err)
+		}
+		name = filepath.ToSlash(name)
+
+		fileBytes, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading metadata file %s: %s", path, err)
+		}
+		if err := ccmetadata.ValidateMetadataFile(name, fileBytes); err != nil {
+			return err
+		}
+
+		metadata = append(metadata, SourceDescriptor{Name: name, Path: path, Info: info})
+		return nil
+	}
+
+	if err := filepath.Walk(metadataDir, walkFn); err != nil {
+		return nil, fmt.Errorf("Error walking metadata directory: %s", err)
+	}
+	sort.Sort(metadata)
+
+	return metadata, nil
+}
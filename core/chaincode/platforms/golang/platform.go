@@ -22,6 +22,7 @@ import (
 	"compress/gzip"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -32,6 +33,7 @@ import (
 
 	"github.com/hyperledger/fabric/common/metadata"
 	"github.com/hyperledger/fabric/core/chaincode/platforms/util"
+	"github.com/hyperledger/fabric/core/common/ccprovider/ccmetadata"
 	cutil "github.com/hyperledger/fabric/core/container/util"
 	pb "github.com/hyperledger/fabric/protos/peer"
 	"github.com/spf13/viper"
@@ -142,6 +144,7 @@ func (goPlatform *Platform) ValidateDeploymentSpec(cds *pb.ChaincodeDeploymentSp
 	// resilient in enforcing constraints. However, we should still do our best to keep as much
 	// garbage out of the system as possible.
 	re := regexp.MustCompile(`(/)?src/.*`)
+	metadataRe := regexp.MustCompile(`^META-INF/.*`)
 	is := bytes.NewReader(cds.CodePackage)
 	gr, err := gzip.NewReader(is)
 	if err != nil {
@@ -159,7 +162,17 @@ func (goPlatform *Platform) ValidateDeploymentSpec(cds *pb.ChaincodeDeploymentSp
 		// --------------------------------------------------------------------------------------
 		// Check name for conforming path
 		// --------------------------------------------------------------------------------------
-		if !re.MatchString(header.Name) {
+		if metadataRe.MatchString(header.Name) {
+			// chaincode metadata, such as the statedb index definitions, is only accepted
+			// if it is supported and well formed
+			fileBytes, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return fmt.Errorf("failure reading metadata file %s: %s", header.Name, err)
+			}
+			if err := ccmetadata.ValidateMetadataFile(header.Name, fileBytes); err != nil {
+				return err
+			}
+		} else if !re.MatchString(header.Name) {
 			return fmt.Errorf("illegal file detected in payload: \"%s\"", header.Name)
 		}
 
@@ -418,6 +431,16 @@ func (goPlatform *Platform) GetDeploymentPayload(spec *pb.ChaincodeSpec) ([]byte
 	// --------------------------------------------------------------------------------------
 	sort.Sort(files)
 
+	// --------------------------------------------------------------------------------------
+	// Append the metadata of our first-order code package, e.g. the statedb index definitions.
+	// These are kept in META-INF at the root of the tarball rather than under src
+	// --------------------------------------------------------------------------------------
+	metadata, err := findMetadata(code.Gopath, code.Pkg)
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, metadata...)
+
 	// --------------------------------------------------------------------------------------
 	// Write out our tar package
 	// --------------------------------------------------------------------------------------
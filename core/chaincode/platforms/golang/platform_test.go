@@ -13,6 +13,7 @@ import (
 	"bytes"
 	"compress/gzip"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -85,6 +86,8 @@ func TestValidateCDS(t *testing.T) {
 	specs = append(specs, spec{CCName: "NoCode", Path: "path/to/nowhere", File: "/bin/warez", Mode: 0100400, SuccessExpected: false})
 	specs = append(specs, spec{CCName: "NoCode", Path: "path/to/somewhere", File: "/src/path/to/somewhere/main.go", Mode: 0100400, SuccessExpected: true})
 	specs = append(specs, spec{CCName: "NoCode", Path: "path/to/somewhere", File: "/src/path/to/somewhere/warez", Mode: 0100555, SuccessExpected: false})
+	specs = append(specs, spec{CCName: "NoCode", Path: "path/to/somewhere", File: "META-INF/statedb/couchdb/indexes/indexOwner.json", Mode: 0100400, SuccessExpected: false})
+	specs = append(specs, spec{CCName: "NoCode", Path: "path/to/somewhere", File: "META-INF/warez", Mode: 0100400, SuccessExpected: false})
 
 	for _, s := range specs {
 		cds, err := generateFakeCDS(s.CCName, s.Path, s.File, s.Mode)
@@ -165,6 +168,58 @@ func Test_DeploymentPayload(t *testing.T) {
 	}
 }
 
+func Test_DeploymentPayloadWithMetadata(t *testing.T) {
+	platform := &Platform{}
+	spec := &pb.ChaincodeSpec{
+		ChaincodeId: &pb.ChaincodeID{
+			Path: "github.com/hyperledger/fabric/examples/chaincode/go/marbles02",
+		},
+	}
+
+	payload, err := platform.GetDeploymentPayload(spec)
+	if err != nil {
+		t.Fatalf("failed to get deployment payload: %s", err)
+	}
+	assert.NoError(t, platform.ValidateDeploymentSpec(&pb.ChaincodeDeploymentSpec{ChaincodeSpec: spec, CodePackage: payload}))
+
+	gr, err := gzip.NewReader(bytes.NewReader(payload))
+	assert.NoError(t, err)
+	tr := tar.NewReader(gr)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, header.Name)
+	}
+	assert.Contains(t, names, "src/github.com/hyperledger/fabric/examples/chaincode/go/marbles02/marbles_chaincode.go")
+	assert.Contains(t, names, "META-INF/statedb/couchdb/indexes/indexOwner.json")
+}
+
+func Test_findMetadata(t *testing.T) {
+	gopath, err := ioutil.TempDir("", "metadata")
+	assert.NoError(t, err)
+	defer os.RemoveAll(gopath)
+
+	metadata, err := findMetadata(gopath, "example.com/cc")
+	assert.NoError(t, err)
+	assert.Empty(t, metadata)
+
+	indexesDir := filepath.Join(gopath, "src", "example.com", "cc", "META-INF", "statedb", "couchdb", "indexes")
+	assert.NoError(t, os.MkdirAll(indexesDir, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(indexesDir, "indexSize.json"), []byte(`{"index":{"fields":["size"]}}`), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(indexesDir, ".indexSize.json.swp"), []byte("garbage"), 0644))
+	metadata, err = findMetadata(gopath, "example.com/cc")
+	assert.NoError(t, err)
+	assert.Len(t, metadata, 1)
+	assert.Equal(t, "META-INF/statedb/couchdb/indexes/indexSize.json", metadata[0].Name)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(indexesDir, "indexColor.json"), []byte(`{"index":{}}`), 0644))
+	_, err = findMetadata(gopath, "example.com/cc")
+	assert.Contains(t, err.Error(), "invalid metadata file META-INF/statedb/couchdb/indexes/indexColor.json")
+}
+
 func Test_decodeUrl(t *testing.T) {
 	cs := &pb.ChaincodeSpec{
 		ChaincodeId: &pb.ChaincodeID{
@@ -12,6 +12,7 @@ import (
 	"compress/gzip"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -20,6 +21,7 @@ import (
 
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/core/chaincode/platforms/util"
+	"github.com/hyperledger/fabric/core/common/ccprovider/ccmetadata"
 	cutil "github.com/hyperledger/fabric/core/container/util"
 	pb "github.com/hyperledger/fabric/protos/peer"
 )
@@ -82,6 +84,7 @@ func (nodePlatform *Platform) ValidateDeploymentSpec(cds *pb.ChaincodeDeployment
 	// resilient in enforcing constraints. However, we should still do our best to keep as much
 	// garbage out of the system as possible.
 	re := regexp.MustCompile(`(/)?src/.*`)
+	metadataRe := regexp.MustCompile(`^META-INF/.*`)
 	is := bytes.NewReader(cds.CodePackage)
 	gr, err := gzip.NewReader(is)
 	if err != nil {
@@ -100,7 +103,17 @@ func (nodePlatform *Platform) ValidateDeploymentSpec(cds *pb.ChaincodeDeployment
 		// --------------------------------------------------------------------------------------
 		// Check name for conforming path
 		// --------------------------------------------------------------------------------------
-		if !re.MatchString(header.Name) {
+		if metadataRe.MatchString(header.Name) {
+			// chaincode metadata, such as the statedb index definitions, is only accepted
+			// if it is supported and well formed
+			fileBytes, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return fmt.Errorf("failure reading metadata file %s: %s", header.Name, err)
+			}
+			if err := ccmetadata.ValidateMetadataFile(header.Name, fileBytes); err != nil {
+				return err
+			}
+		} else if !re.MatchString(header.Name) {
 			return fmt.Errorf("illegal file detected in payload: \"%s\"", header.Name)
 		}
 		if header.Name == "src/package.json" {
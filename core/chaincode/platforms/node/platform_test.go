@@ -128,6 +128,19 @@ func TestValidateDeploymentSpec(t *testing.T) {
 	if err != nil {
 		t.Fatalf("should have returned no errors, but got '%s'", err)
 	}
+
+	cp, err = writeCodePackage(tmpfile.Name(), "META-INF/statedb/couchdb/indexes/indexOwner.json", 0100666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cds.CodePackage = cp
+	err = platform.ValidateDeploymentSpec(cds)
+	if err == nil {
+		t.Fatal("should have failed to validate because the index definition in the archive is not valid JSON")
+	} else if !strings.HasPrefix(err.Error(), "invalid metadata file META-INF/statedb/couchdb/indexes/indexOwner.json") {
+		t.Fatalf("should have returned error about invalid metadata file, but got '%s'", err)
+	}
 }
 
 func TestGetDeploymentPayload(t *testing.T) {
@@ -433,17 +433,54 @@ func (stub *ChaincodeStub) PutState(key string, value []byte) error {
 	return stub.handler.handlePutState(collection, key, value, stub.ChannelId, stub.TxID)
 }
 
+// SetStateValidationParameter documentation can be found in interfaces.go
+func (stub *ChaincodeStub) SetStateValidationParameter(key string, ep []byte) error {
+	if key == "" {
+		return errors.New("key must not be an empty string")
+	}
+	// Access public data by setting the collection to empty string
+	collection := ""
+	return stub.handler.handlePutStateMetadataEntry(collection, key, pb.MetaDataKeys_VALIDATION_PARAMETER.String(), ep, stub.ChannelId, stub.TxID)
+}
+
+// GetStateValidationParameter documentation can be found in interfaces.go
+func (stub *ChaincodeStub) GetStateValidationParameter(key string) ([]byte, error) {
+	// Access public data by setting the collection to empty string
+	collection := ""
+	md, err := stub.handler.handleGetStateMetadata(collection, key, stub.ChannelId, stub.TxID)
+	if err != nil {
+		return nil, err
+	}
+	return md[pb.MetaDataKeys_VALIDATION_PARAMETER.String()], nil
+}
+
 // GetQueryResult documentation can be found in interfaces.go
 func (stub *ChaincodeStub) GetQueryResult(query string) (StateQueryIteratorInterface, error) {
 	// Access public data by setting the collection to empty string
 	collection := ""
-	response, err := stub.handler.handleGetQueryResult(collection, query, stub.ChannelId, stub.TxID)
+	response, err := stub.handler.handleGetQueryResult(collection, query, nil, stub.ChannelId, stub.TxID)
 	if err != nil {
 		return nil, err
 	}
 	return &StateQueryIterator{CommonIterator: &CommonIterator{stub.handler, stub.ChannelId, stub.TxID, response, 0}}, nil
 }
 
+// GetQueryResultWithPagination documentation can be found in interfaces.go
+func (stub *ChaincodeStub) GetQueryResultWithPagination(query string, pageSize int32,
+	bookmark string) (StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	// Access public data by setting the collection to empty string
+	collection := ""
+	metadata, err := createQueryMetadata(pageSize, bookmark)
+	if err != nil {
+		return nil, nil, err
+	}
+	response, err := stub.handler.handleGetQueryResult(collection, query, metadata, stub.ChannelId, stub.TxID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return stub.createPaginatedQueryIterator(response)
+}
+
 // DelState documentation can be found in interfaces.go
 func (stub *ChaincodeStub) DelState(key string) error {
 	// Access public data by setting the collection to empty string
@@ -477,8 +514,8 @@ const (
 	HISTORY_QUERY_RESULT
 )
 
-func (stub *ChaincodeStub) handleGetStateByRange(collection, startKey, endKey string) (StateQueryIteratorInterface, error) {
-	response, err := stub.handler.handleGetStateByRange(collection, startKey, endKey, stub.ChannelId, stub.TxID)
+func (stub *ChaincodeStub) handleGetStateByRange(collection, startKey, endKey string, metadata []byte) (StateQueryIteratorInterface, error) {
+	response, err := stub.handler.handleGetStateByRange(collection, startKey, endKey, metadata, stub.ChannelId, stub.TxID)
 	if err != nil {
 		return nil, err
 	}
@@ -494,18 +531,114 @@ func (stub *ChaincodeStub) GetStateByRange(startKey, endKey string) (StateQueryI
 		return nil, err
 	}
 	collection := ""
-	return stub.handleGetStateByRange(collection, startKey, endKey)
+	return stub.handleGetStateByRange(collection, startKey, endKey, nil)
+}
+
+// GetStateByRangeWithPagination documentation can be found in interfaces.go
+func (stub *ChaincodeStub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32,
+	bookmark string) (StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if startKey == "" {
+		startKey = emptyKeySubstitute
+	}
+	if err := validateSimpleKeys(startKey, endKey); err != nil {
+		return nil, nil, err
+	}
+	metadata, err := createQueryMetadata(pageSize, bookmark)
+	if err != nil {
+		return nil, nil, err
+	}
+	collection := ""
+	response, err := stub.handler.handleGetStateByRange(collection, startKey, endKey, metadata, stub.ChannelId, stub.TxID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return stub.createPaginatedQueryIterator(response)
+}
+
+// createQueryMetadata marshals the page size and the bookmark of a paginated query
+func createQueryMetadata(pageSize int32, bookmark string) ([]byte, error) {
+	if pageSize <= 0 {
+		return nil, errors.Errorf("invalid page size [%d], it must be greater than zero", pageSize)
+	}
+	return proto.Marshal(&pb.QueryMetadata{PageSize: pageSize, Bookmark: bookmark})
+}
+
+// createPaginatedQueryIterator returns an iterator over the results of the page held by the
+// response, along with the metadata of the response which carries the bookmark of the next page
+func (stub *ChaincodeStub) createPaginatedQueryIterator(response *pb.QueryResponse) (StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	responseMetadata := &pb.QueryResponseMetadata{}
+	if err := proto.Unmarshal(response.Metadata, responseMetadata); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to unmarshal query response metadata")
+	}
+	iterator := &StateQueryIterator{CommonIterator: &CommonIterator{stub.handler, stub.ChannelId, stub.TxID, response, 0}}
+	return iterator, responseMetadata, nil
 }
 
 // GetHistoryForKey documentation can be found in interfaces.go
 func (stub *ChaincodeStub) GetHistoryForKey(key string) (HistoryQueryIteratorInterface, error) {
-	response, err := stub.handler.handleGetHistoryForKey(key, stub.ChannelId, stub.TxID)
+	response, err := stub.handler.handleGetHistoryForKey(key, nil, stub.ChannelId, stub.TxID)
 	if err != nil {
 		return nil, err
 	}
 	return &HistoryQueryIterator{CommonIterator: &CommonIterator{stub.handler, stub.ChannelId, stub.TxID, response, 0}}, nil
 }
 
+// HistoryQueryOptions restricts, orders and paginates the history of a key returned
+// by GetHistoryForKeyWithOptions. The history is restricted to the modifications
+// committed in the blocks [StartBlock, EndBlock) by the transactions whose timestamps
+// are in [StartTime, EndTime); an EndBlock of zero and nil timestamps impose no bound.
+// If Descending is set, the most recent modification is returned first. If the
+// PageSize is greater than zero, at most PageSize modifications are returned, starting
+// from the Bookmark returned in the QueryResponseMetadata of the previous page. A history
+// bounded in time must be paginated, and as the peer limits the number of modifications
+// it skips for a page, a page may hold fewer than PageSize modifications even if the
+// Bookmark of the next page is not empty
+type HistoryQueryOptions struct {
+	StartBlock uint64
+	EndBlock   uint64
+	StartTime  *timestamp.Timestamp
+	EndTime    *timestamp.Timestamp
+	Descending bool
+	PageSize   int32
+	Bookmark   string
+}
+
+// GetHistoryForKeyWithOptions documentation can be found in interfaces.go
+func (stub *ChaincodeStub) GetHistoryForKeyWithOptions(key string,
+	options *HistoryQueryOptions) (HistoryQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if options.PageSize < 0 {
+		return nil, nil, errors.Errorf("invalid page size [%d], it must not be negative", options.PageSize)
+	}
+	if (options.StartTime != nil || options.EndTime != nil) && options.PageSize == 0 {
+		return nil, nil, errors.New("a history query bounded in time must be paginated")
+	}
+	metadata, err := proto.Marshal(&pb.HistoryQueryMetadata{
+		StartBlock: options.StartBlock,
+		EndBlock:   options.EndBlock,
+		StartTime:  options.StartTime,
+		EndTime:    options.EndTime,
+		Descending: options.Descending,
+		PageSize:   options.PageSize,
+		Bookmark:   options.Bookmark,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	response, err := stub.handler.handleGetHistoryForKey(key, metadata, stub.ChannelId, stub.TxID)
+	if err != nil {
+		return nil, nil, err
+	}
+	iterator := &HistoryQueryIterator{CommonIterator: &CommonIterator{stub.handler, stub.ChannelId, stub.TxID, response, 0}}
+	if options.PageSize == 0 {
+		return iterator, nil, nil
+	}
+	responseMetadata := &pb.QueryResponseMetadata{}
+	if err := proto.Unmarshal(response.Metadata, responseMetadata); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to unmarshal query response metadata")
+	}
+	return iterator, responseMetadata, nil
+}
+
 //CreateCompositeKey documentation can be found in interfaces.go
 func (stub *ChaincodeStub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
 	return createCompositeKey(objectType, attributes)
@@ -577,7 +710,7 @@ func validateSimpleKeys(simpleKeys ...string) error {
 func (stub *ChaincodeStub) GetStateByPartialCompositeKey(objectType string, attributes []string) (StateQueryIteratorInterface, error) {
 	collection := ""
 	if partialCompositeKey, err := stub.CreateCompositeKey(objectType, attributes); err == nil {
-		return stub.handleGetStateByRange(collection, partialCompositeKey, partialCompositeKey+string(maxUnicodeRuneValue))
+		return stub.handleGetStateByRange(collection, partialCompositeKey, partialCompositeKey+string(maxUnicodeRuneValue), nil)
 	} else {
 		return nil, err
 	}
@@ -52,7 +52,7 @@ func (stub *ChaincodeStub) GetPrivateDataByRange(collection, startKey, endKey st
 	if err := validateSimpleKeys(startKey, endKey); err != nil {
 		return nil, err
 	}
-	return stub.handleGetStateByRange(collection, startKey, endKey)
+	return stub.handleGetStateByRange(collection, startKey, endKey, nil)
 }
 
 // GetPrivateDataByPartialCompositeKey documentation can be found in interfaces.go
@@ -61,7 +61,7 @@ func (stub *ChaincodeStub) GetPrivateDataByPartialCompositeKey(collection, objec
 		return nil, fmt.Errorf("collection must not be an empty string")
 	}
 	if partialCompositeKey, err := stub.CreateCompositeKey(objectType, attributes); err == nil {
-		return stub.handleGetStateByRange(collection, partialCompositeKey, partialCompositeKey+string(maxUnicodeRuneValue))
+		return stub.handleGetStateByRange(collection, partialCompositeKey, partialCompositeKey+string(maxUnicodeRuneValue), nil)
 	} else {
 		return nil, err
 	}
@@ -72,7 +72,7 @@ func (stub *ChaincodeStub) GetPrivateDataQueryResult(collection, query string) (
 	if collection == "" {
 		return nil, fmt.Errorf("collection must not be an empty string")
 	}
-	response, err := stub.handler.handleGetQueryResult(collection, query, stub.ChannelId, stub.TxID)
+	response, err := stub.handler.handleGetQueryResult(collection, query, nil, stub.ChannelId, stub.TxID)
 	if err != nil {
 		return nil, err
 	}
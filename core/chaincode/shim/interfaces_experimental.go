@@ -98,6 +98,21 @@ type ChaincodeStubInterface interface {
 	// the ledger when the transaction is validated and successfully committed.
 	DelState(key string) error
 
+	// SetStateValidationParameter sets the key-level endorsement policy for `key`.
+	// The policy is stored as the metadata of `key` and is evaluated, in place of
+	// the endorsement policy of the chaincode, for the transactions that update
+	// `key` or its key-level endorsement policy. The `ep` is a serialized
+	// SignaturePolicyEnvelope. A nil `ep` removes the key-level endorsement policy.
+	// The policy takes effect when the transaction is validated and successfully
+	// committed, and only if `key` exists at that point.
+	SetStateValidationParameter(key string, ep []byte) error
+
+	// GetStateValidationParameter retrieves the key-level endorsement policy
+	// for `key`. Note that this will introduce a read dependency on `key` in
+	// the transaction's readset. If no key-level endorsement policy has been
+	// set for `key`, (nil, nil) is returned.
+	GetStateValidationParameter(key string) ([]byte, error)
+
 	// GetStateByRange returns a range iterator over a set of keys in the
 	// ledger. The iterator can be used to iterate over all keys
 	// between the startKey (inclusive) and endKey (exclusive).
@@ -109,6 +124,17 @@ type ChaincodeStubInterface interface {
 	// has not changed since transaction endorsement (phantom reads detected).
 	GetStateByRange(startKey, endKey string) (StateQueryIteratorInterface, error)
 
+	// GetStateByRangeWithPagination returns a range iterator over a set of keys in the
+	// ledger, like GetStateByRange, limited to at most `pageSize` results. The
+	// `bookmark` is empty for the first page and, for each following page, is the
+	// bookmark returned in the QueryResponseMetadata of the previous page. An empty
+	// bookmark in the returned metadata means that there are no more pages.
+	// Call Close() on the returned StateQueryIteratorInterface object when done.
+	// Paginated queries are only supported in read-only transactions, they must
+	// not be used in transactions that update the ledger.
+	GetStateByRangeWithPagination(startKey, endKey string, pageSize int32,
+		bookmark string) (StateQueryIteratorInterface, *pb.QueryResponseMetadata, error)
+
 	// GetStateByPartialCompositeKey queries the state in the ledger based on
 	// a given partial composite key. This function returns an iterator
 	// which can be used to iterate over all composite keys whose prefix matches
@@ -147,6 +173,17 @@ type ChaincodeStubInterface interface {
 	// ledger, and should limit use to read-only chaincode operations.
 	GetQueryResult(query string) (StateQueryIteratorInterface, error)
 
+	// GetQueryResultWithPagination performs a "rich" query against a state database,
+	// like GetQueryResult, limited to at most `pageSize` results. The `bookmark` is
+	// empty for the first page and, for each following page, is the bookmark returned
+	// in the QueryResponseMetadata of the previous page. An empty bookmark in the
+	// returned metadata means that there are no more pages.
+	// Call Close() on the returned StateQueryIteratorInterface object when done.
+	// Paginated queries are only supported in read-only transactions, they must
+	// not be used in transactions that update the ledger.
+	GetQueryResultWithPagination(query string, pageSize int32,
+		bookmark string) (StateQueryIteratorInterface, *pb.QueryResponseMetadata, error)
+
 	// GetHistoryForKey returns a history of key values across time.
 	// For each historic key update, the historic value and associated
 	// transaction id and timestamp are returned. The timestamp is the
@@ -161,6 +198,21 @@ type ChaincodeStubInterface interface {
 	// update ledger, and should limit use to read-only chaincode operations.
 	GetHistoryForKey(key string) (HistoryQueryIteratorInterface, error)
 
+	// GetHistoryForKeyWithOptions returns the history of key values across time,
+	// like GetHistoryForKey, restricted to a range of blocks and of transaction
+	// timestamps, in ascending or descending order, as per the given `options`.
+	// If the page size in the `options` is greater than zero, at most that many
+	// modifications are returned, along with the QueryResponseMetadata whose
+	// bookmark is to be set in the `options` for retrieving the next page. An
+	// empty bookmark in the returned metadata means that there are no more pages.
+	// The returned metadata is nil if the history is not paginated. A history
+	// restricted to a range of timestamps must be paginated, and its pages may
+	// hold fewer modifications than the page size before the last one.
+	// Call Close() on the returned HistoryQueryIteratorInterface object when done.
+	// Like GetHistoryForKey, it should be limited to read-only chaincode operations.
+	GetHistoryForKeyWithOptions(key string, options *HistoryQueryOptions) (HistoryQueryIteratorInterface,
+		*pb.QueryResponseMetadata, error)
+
 	// GetPrivateData returns the value of the specified `key` from the specified
 	// `collection`. Note that GetPrivateData doesn't read data from the
 	// private writeset, which has not been committed to the `collection`. In
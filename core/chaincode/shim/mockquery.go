@@ -0,0 +1,395 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package shim
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+	"github.com/pkg/errors"
+)
+
+// mockQuery is a CouchDB (Mango) query as evaluated by the MockStub. Only a
+// subset of the Mango syntax is supported: implicit and explicit ($eq, $ne)
+// field equality, the comparison operators $gt, $gte, $lt and $lte, the
+// combination operators $and, $or and $not, dotted and nested field names,
+// sort, skip and limit
+type mockQuery struct {
+	Selector map[string]interface{} `json:"selector"`
+	Sort     []interface{}          `json:"sort"`
+	Skip     int                    `json:"skip"`
+	Limit    int                    `json:"limit"`
+}
+
+// sortField is a field the results of a query are sorted by
+type sortField struct {
+	name string
+	desc bool
+}
+
+// parseMockQuery parses a query string in the Mango syntax
+func parseMockQuery(query string) (*mockQuery, error) {
+	q := &mockQuery{}
+	decoder := json.NewDecoder(strings.NewReader(query))
+	decoder.UseNumber()
+	if err := decoder.Decode(q); err != nil {
+		return nil, errors.Wrap(err, "invalid query")
+	}
+	if q.Selector == nil {
+		return nil, errors.New("invalid query: selector is missing")
+	}
+	if q.Skip < 0 || q.Limit < 0 {
+		return nil, errors.New("invalid query: skip and limit must not be negative")
+	}
+	// validate the selector up front, so that an invalid selector is
+	// reported even if there are no documents to match it against
+	if err := validateSelector(q.Selector); err != nil {
+		return nil, err
+	}
+	if _, err := q.sortFields(); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// sortFields returns the fields of the sort clause of the query. A field is
+// given either by its name (ascending order) or as {"name": "asc"|"desc"}
+func (q *mockQuery) sortFields() ([]sortField, error) {
+	var fields []sortField
+	for _, s := range q.Sort {
+		switch s := s.(type) {
+		case string:
+			fields = append(fields, sortField{name: s})
+		case map[string]interface{}:
+			if len(s) != 1 {
+				return nil, errors.Errorf("invalid sort field %v", s)
+			}
+			for name, dir := range s {
+				switch dir {
+				case "asc":
+					fields = append(fields, sortField{name: name})
+				case "desc":
+					fields = append(fields, sortField{name: name, desc: true})
+				default:
+					return nil, errors.Errorf("invalid sort direction %v for field %s", dir, name)
+				}
+			}
+		default:
+			return nil, errors.Errorf("invalid sort field %v", s)
+		}
+	}
+	return fields, nil
+}
+
+// execute evaluates the query against the supplied key/values, which are
+// expected in lexical order of the keys. Values which are not JSON objects
+// never match a query. The results are sorted as per the sort clause of the
+// query (keeping the key order for ties); skip and limit are applied after
+// sorting
+func (q *mockQuery) execute(kvs []*queryresult.KV) ([]*queryresult.KV, error) {
+	type match struct {
+		kv  *queryresult.KV
+		doc map[string]interface{}
+	}
+	var matches []match
+	for _, kv := range kvs {
+		doc, ok := unmarshalJSONObject(kv.Value)
+		if !ok {
+			continue
+		}
+		matched, err := matchSelector(q.Selector, doc)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			matches = append(matches, match{kv: kv, doc: doc})
+		}
+	}
+
+	fields, err := q.sortFields()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		for _, f := range fields {
+			vi, _ := lookupField(matches[i].doc, f.name)
+			vj, _ := lookupField(matches[j].doc, f.name)
+			c := collate(vi, vj)
+			if c == 0 {
+				continue
+			}
+			if f.desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+
+	var results []*queryresult.KV
+	for i := q.Skip; i < len(matches); i++ {
+		if q.Limit > 0 && len(results) == q.Limit {
+			break
+		}
+		results = append(results, matches[i].kv)
+	}
+	return results, nil
+}
+
+// unmarshalJSONObject returns the JSON object encoded in value, if any
+func unmarshalJSONObject(value []byte) (map[string]interface{}, bool) {
+	var doc map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil || doc == nil {
+		return nil, false
+	}
+	return doc, true
+}
+
+// validateSelector checks that the selector only uses the supported operators
+func validateSelector(selector map[string]interface{}) error {
+	for field, cond := range selector {
+		switch field {
+		case "$and", "$or":
+			selectors, ok := cond.([]interface{})
+			if !ok {
+				return errors.Errorf("invalid query: %s expects an array", field)
+			}
+			for _, s := range selectors {
+				sub, ok := s.(map[string]interface{})
+				if !ok {
+					return errors.Errorf("invalid query: %s expects an array of objects", field)
+				}
+				if err := validateSelector(sub); err != nil {
+					return err
+				}
+			}
+		case "$not":
+			sub, ok := cond.(map[string]interface{})
+			if !ok {
+				return errors.Errorf("invalid query: %s expects an object", field)
+			}
+			if err := validateSelector(sub); err != nil {
+				return err
+			}
+		default:
+			if strings.HasPrefix(field, "$") {
+				return errors.Errorf("invalid query: unsupported operator %s", field)
+			}
+			ops, ok := cond.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if !hasOperators(ops) {
+				if err := validateSelector(ops); err != nil {
+					return err
+				}
+				continue
+			}
+			for op := range ops {
+				switch op {
+				case "$eq", "$ne", "$gt", "$gte", "$lt", "$lte":
+				default:
+					return errors.Errorf("invalid query: unsupported operator %s for field %s", op, field)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// matchSelector returns true if the document satisfies all the conditions of the selector.
+// The selector is expected to have been checked by validateSelector
+func matchSelector(selector map[string]interface{}, doc map[string]interface{}) (bool, error) {
+	for field, cond := range selector {
+		var matched bool
+		var err error
+		switch field {
+		case "$and", "$or":
+			matched, err = matchCombination(field, cond, doc)
+		case "$not":
+			matched, err = matchSelector(cond.(map[string]interface{}), doc)
+			matched = !matched
+		default:
+			value, exists := lookupField(doc, field)
+			matched, err = matchCondition(field, cond, value, exists)
+		}
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// matchCombination evaluates the $and and $or operators over a list of selectors
+func matchCombination(op string, cond interface{}, doc map[string]interface{}) (bool, error) {
+	for _, s := range cond.([]interface{}) {
+		matched, err := matchSelector(s.(map[string]interface{}), doc)
+		if err != nil {
+			return false, err
+		}
+		if op == "$and" && !matched {
+			return false, nil
+		}
+		if op == "$or" && matched {
+			return true, nil
+		}
+	}
+	return op == "$and", nil
+}
+
+// matchCondition evaluates the condition on a field of the document. A condition is
+// either a value the field is to be equal to, an object of operators or an object of
+// conditions on the sub fields of the field
+func matchCondition(field string, cond interface{}, value interface{}, exists bool) (bool, error) {
+	ops, ok := cond.(map[string]interface{})
+	if !ok {
+		return exists && collate(value, cond) == 0, nil
+	}
+	if !hasOperators(ops) {
+		// conditions on the sub fields of the field
+		sub, isObject := value.(map[string]interface{})
+		if !isObject {
+			sub = map[string]interface{}{}
+		}
+		return matchSelector(ops, sub)
+	}
+	for op, operand := range ops {
+		var matched bool
+		switch op {
+		case "$eq":
+			matched = exists && collate(value, operand) == 0
+		case "$ne":
+			matched = !exists || collate(value, operand) != 0
+		case "$gt":
+			matched = exists && collate(value, operand) > 0
+		case "$gte":
+			matched = exists && collate(value, operand) >= 0
+		case "$lt":
+			matched = exists && collate(value, operand) < 0
+		case "$lte":
+			matched = exists && collate(value, operand) <= 0
+		default:
+			return false, errors.Errorf("invalid query: unsupported operator %s for field %s", op, field)
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// hasOperators returns true if any of the keys of the object is an operator
+func hasOperators(obj map[string]interface{}) bool {
+	for k := range obj {
+		if strings.HasPrefix(k, "$") {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupField returns the value of a (possibly dotted) field of the document
+func lookupField(doc map[string]interface{}, field string) (interface{}, bool) {
+	var current interface{} = doc
+	for _, name := range strings.Split(field, ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = obj[name]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// collate compares two JSON values following the CouchDB collation order:
+// null < false < true < numbers < strings < arrays < objects
+func collate(a, b interface{}) int {
+	ra, rb := collationRank(a), collationRank(b)
+	if ra != rb {
+		return ra - rb
+	}
+	switch a := a.(type) {
+	case bool:
+		if a == b.(bool) {
+			return 0
+		}
+		if !a {
+			return -1
+		}
+		return 1
+	case json.Number:
+		fa, _ := a.Float64()
+		fb, _ := b.(json.Number).Float64()
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(a, b.(string))
+	case []interface{}:
+		b := b.([]interface{})
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if c := collate(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+		return len(a) - len(b)
+	case map[string]interface{}:
+		b := b.(map[string]interface{})
+		// objects are compared by their sorted fields and, then, by the values of the fields
+		ka, kb := sortedFieldNames(a), sortedFieldNames(b)
+		for i := 0; i < len(ka) && i < len(kb); i++ {
+			if c := strings.Compare(ka[i], kb[i]); c != 0 {
+				return c
+			}
+			if c := collate(a[ka[i]], b[kb[i]]); c != 0 {
+				return c
+			}
+		}
+		return len(ka) - len(kb)
+	}
+	return 0
+}
+
+func collationRank(v interface{}) int {
+	switch v := v.(type) {
+	case nil:
+		return 0
+	case bool:
+		if v {
+			return 2
+		}
+		return 1
+	case json.Number:
+		return 3
+	case string:
+		return 4
+	case []interface{}:
+		return 5
+	default:
+		return 6
+	}
+}
+
+func sortedFieldNames(obj map[string]interface{}) []string {
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -11,6 +11,8 @@ package shim
 import (
 	"container/list"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/golang/protobuf/ptypes/timestamp"
@@ -43,6 +45,20 @@ type MockStub struct {
 	// Keys stores the list of mapped values in lexical order
 	Keys *list.List
 
+	// EndorsementPolicies keeps the key-level endorsement policies of the keys in State
+	EndorsementPolicies map[string][]byte
+
+	// PvtState keeps name value pairs of the private data collections, by collection
+	PvtState map[string]map[string][]byte
+
+	// History keeps the modifications of the keys in State, in the order of the
+	// transactions that modified them
+	History map[string][]*queryresult.KeyModification
+
+	// pendingHistory keeps the latest modification of the keys by the current
+	// transaction, which is added to History when the transaction ends
+	pendingHistory map[string]*queryresult.KeyModification
+
 	// registered list of other MockStub chaincodes that can be called from this MockStub
 	Invokables map[string]*MockStub
 
@@ -100,12 +116,30 @@ func (stub *MockStub) MockTransactionStart(txid string) {
 	stub.setTxTimestamp(util.CreateUtcTimestamp())
 }
 
-// End a mocked transaction, clearing the UUID.
+// End a mocked transaction, clearing the UUID. The modifications of the keys
+// by the transaction are added to the history of the keys.
 func (stub *MockStub) MockTransactionEnd(uuid string) {
+	for key, modification := range stub.pendingHistory {
+		stub.History[key] = append(stub.History[key], modification)
+	}
+	stub.pendingHistory = make(map[string]*queryresult.KeyModification)
 	stub.signedProposal = nil
 	stub.TxID = ""
 }
 
+// recordModification records the modification of a key by the current transaction
+func (stub *MockStub) recordModification(key string, value []byte, isDelete bool) {
+	if stub.TxID == "" {
+		return
+	}
+	stub.pendingHistory[key] = &queryresult.KeyModification{
+		TxId:      stub.TxID,
+		Value:     value,
+		Timestamp: stub.TxTimestamp,
+		IsDelete:  isDelete,
+	}
+}
+
 // Register a peer chaincode with this MockStub
 // invokableChaincodeName is the name or hash of the peer
 // otherStub is a MockStub of the peer, already intialised
@@ -145,31 +179,126 @@ func (stub *MockStub) MockInvokeWithSignedProposal(uuid string, args [][]byte, s
 	return res
 }
 
+// GetPrivateData returns the value of `key` in `collection`. Unlike the peer,
+// the MockStub returns the writes of the current transaction as well
 func (stub *MockStub) GetPrivateData(collection string, key string) ([]byte, error) {
-	return nil, errors.New("Not Implemented")
+	if collection == "" {
+		return nil, errors.New("collection must not be an empty string")
+	}
+	value := stub.PvtState[collection][key]
+	mockLogger.Debug("MockStub", stub.Name, "Getting", collection, key, value)
+	return value, nil
 }
 
+// PutPrivateData writes `key` and `value` into `collection`
 func (stub *MockStub) PutPrivateData(collection string, key string, value []byte) error {
-	return errors.New("Not Implemented")
+	if stub.TxID == "" {
+		err := errors.New("cannot PutPrivateData without a transactions - call stub.MockTransactionStart()?")
+		mockLogger.Errorf("%+v", err)
+		return err
+	}
+	if collection == "" {
+		return errors.New("collection must not be an empty string")
+	}
+	if key == "" {
+		return errors.New("key must not be an empty string")
+	}
+	if value == nil {
+		return errors.New("value must not be nil")
+	}
+
+	mockLogger.Debug("MockStub", stub.Name, "Putting", collection, key, value)
+	if _, ok := stub.PvtState[collection]; !ok {
+		stub.PvtState[collection] = make(map[string][]byte)
+	}
+	stub.PvtState[collection][key] = value
+	return nil
 }
 
+// DelPrivateData removes `key` and its value from `collection`
 func (stub *MockStub) DelPrivateData(collection string, key string) error {
-	return errors.New("Not Implemented")
+	if stub.TxID == "" {
+		err := errors.New("cannot DelPrivateData without a transactions - call stub.MockTransactionStart()?")
+		mockLogger.Errorf("%+v", err)
+		return err
+	}
+	if collection == "" {
+		return errors.New("collection must not be an empty string")
+	}
+
+	mockLogger.Debug("MockStub", stub.Name, "Deleting", collection, key)
+	delete(stub.PvtState[collection], key)
+	return nil
 }
 
+// GetPrivateDataByRange returns an iterator over the keys of `collection` between
+// startKey (inclusive) and endKey (exclusive), in lexical order
 func (stub *MockStub) GetPrivateDataByRange(collection, startKey, endKey string) (StateQueryIteratorInterface, error) {
-	return nil, errors.New("Not Implemented")
+	if collection == "" {
+		return nil, errors.New("collection must not be an empty string")
+	}
+	if err := validateSimpleKeys(startKey, endKey); err != nil {
+		return nil, err
+	}
+	return &mockPageQueryIterator{results: stub.getPrivateDataKVs(collection, startKey, endKey)}, nil
 }
 
+// GetPrivateDataByPartialCompositeKey returns an iterator over the composite keys of
+// `collection` whose prefix matches the given partial composite key
 func (stub *MockStub) GetPrivateDataByPartialCompositeKey(collection, objectType string, attributes []string) (StateQueryIteratorInterface, error) {
-	return nil, errors.New("Not Implemented")
+	if collection == "" {
+		return nil, errors.New("collection must not be an empty string")
+	}
+	partialCompositeKey, err := stub.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, err
+	}
+	kvs := stub.getPrivateDataKVs(collection, partialCompositeKey, partialCompositeKey+string(maxUnicodeRuneValue))
+	return &mockPageQueryIterator{results: kvs}, nil
 }
 
+// GetPrivateDataQueryResult evaluates a CouchDB (Mango) query against the JSON values
+// of `collection`. See GetQueryResult for the supported subset of the query syntax
 func (stub *MockStub) GetPrivateDataQueryResult(collection, query string) (StateQueryIteratorInterface, error) {
-	// Not implemented since the mock engine does not have a query engine.
-	// However, a very simple query engine that supports string matching
-	// could be implemented to test that the framework supports queries
-	return nil, errors.New("Not Implemented")
+	if collection == "" {
+		return nil, errors.New("collection must not be an empty string")
+	}
+	q, err := parseMockQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	results, err := q.execute(stub.getPrivateDataKVs(collection, "", ""))
+	if err != nil {
+		return nil, err
+	}
+	return &mockPageQueryIterator{results: results}, nil
+}
+
+// getPrivateDataKVs returns the key/values of `collection` between startKey (inclusive)
+// and endKey (exclusive), in lexical order. Empty keys imply an unbounded range
+func (stub *MockStub) getPrivateDataKVs(collection, startKey, endKey string) []*queryresult.KV {
+	var keys []string
+	for key := range stub.PvtState[collection] {
+		if key >= startKey && (endKey == "" || key < endKey) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	var kvs []*queryresult.KV
+	for _, key := range keys {
+		kvs = append(kvs, &queryresult.KV{Key: key, Value: stub.PvtState[collection][key]})
+	}
+	return kvs
+}
+
+// getStateKVs returns all the key/values of State in lexical order
+func (stub *MockStub) getStateKVs() []*queryresult.KV {
+	var kvs []*queryresult.KV
+	for elem := stub.Keys.Front(); elem != nil; elem = elem.Next() {
+		key := elem.Value.(string)
+		kvs = append(kvs, &queryresult.KV{Key: key, Value: stub.State[key]})
+	}
+	return kvs
 }
 
 // GetState retrieves the value for a given key from the ledger
@@ -189,6 +318,7 @@ func (stub *MockStub) PutState(key string, value []byte) error {
 
 	mockLogger.Debug("MockStub", stub.Name, "Putting", key, value)
 	stub.State[key] = value
+	stub.recordModification(key, value, false)
 
 	// insert key into ordered list of keys
 	for elem := stub.Keys.Front(); elem != nil; elem = elem.Next() {
@@ -227,6 +357,8 @@ func (stub *MockStub) PutState(key string, value []byte) error {
 func (stub *MockStub) DelState(key string) error {
 	mockLogger.Debug("MockStub", stub.Name, "Deleting", key, stub.State[key])
 	delete(stub.State, key)
+	delete(stub.EndorsementPolicies, key)
+	stub.recordModification(key, nil, true)
 
 	for elem := stub.Keys.Front(); elem != nil; elem = elem.Next() {
 		if strings.Compare(key, elem.Value.(string)) == 0 {
@@ -237,6 +369,30 @@ func (stub *MockStub) DelState(key string) error {
 	return nil
 }
 
+// SetStateValidationParameter sets the key-level endorsement policy of an existing `key`.
+// A nil `ep` removes the key-level endorsement policy
+func (stub *MockStub) SetStateValidationParameter(key string, ep []byte) error {
+	if stub.TxID == "" {
+		err := errors.New("cannot SetStateValidationParameter without a transactions - call stub.MockTransactionStart()?")
+		mockLogger.Errorf("%+v", err)
+		return err
+	}
+	if _, ok := stub.State[key]; !ok {
+		return errors.Errorf("cannot set the validation parameter of the non-existing key %s", key)
+	}
+	if ep == nil {
+		delete(stub.EndorsementPolicies, key)
+		return nil
+	}
+	stub.EndorsementPolicies[key] = ep
+	return nil
+}
+
+// GetStateValidationParameter returns the key-level endorsement policy of `key`
+func (stub *MockStub) GetStateValidationParameter(key string) ([]byte, error) {
+	return stub.EndorsementPolicies[key], nil
+}
+
 func (stub *MockStub) GetStateByRange(startKey, endKey string) (StateQueryIteratorInterface, error) {
 	if err := validateSimpleKeys(startKey, endKey); err != nil {
 		return nil, err
@@ -244,22 +400,167 @@ func (stub *MockStub) GetStateByRange(startKey, endKey string) (StateQueryIterat
 	return NewMockStateRangeQueryIterator(stub, startKey, endKey), nil
 }
 
-// GetQueryResult function can be invoked by a chaincode to perform a
-// rich query against state database.  Only supported by state database implementations
-// that support rich query.  The query string is in the syntax of the underlying
-// state database. An iterator is returned which can be used to iterate (next) over
-// the query result set
+// GetStateByRangeWithPagination returns at most `pageSize` keys of the range, starting
+// from the `bookmark` if it is not empty, along with the bookmark of the next page
+func (stub *MockStub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32,
+	bookmark string) (StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if err := validateSimpleKeys(startKey, endKey); err != nil {
+		return nil, nil, err
+	}
+	if pageSize <= 0 {
+		return nil, nil, errors.Errorf("invalid page size [%d], it must be greater than zero", pageSize)
+	}
+	if bookmark != "" {
+		startKey = bookmark
+	}
+	rangeIter := NewMockStateRangeQueryIterator(stub, startKey, endKey)
+	defer rangeIter.Close()
+	pageIter := &mockPageQueryIterator{}
+	for int32(len(pageIter.results)) < pageSize && rangeIter.HasNext() {
+		kv, err := rangeIter.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		pageIter.results = append(pageIter.results, kv)
+	}
+	responseMetadata := &pb.QueryResponseMetadata{FetchedRecordsCount: int32(len(pageIter.results))}
+	if rangeIter.HasNext() {
+		next, err := rangeIter.Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		responseMetadata.Bookmark = next.Key
+	}
+	return pageIter, responseMetadata, nil
+}
+
+// GetQueryResult evaluates a CouchDB (Mango) query against the JSON values in
+// State. Only a subset of the query syntax is supported: field equality (either
+// implicit or with $eq and $ne), the comparison operators $gt, $gte, $lt and $lte,
+// the combination operators $and, $or and $not, dotted and nested field names,
+// sort, skip and limit. Values that are not JSON objects are never returned
 func (stub *MockStub) GetQueryResult(query string) (StateQueryIteratorInterface, error) {
-	// Not implemented since the mock engine does not have a query engine.
-	// However, a very simple query engine that supports string matching
-	// could be implemented to test that the framework supports queries
-	return nil, errors.New("not implemented")
+	q, err := parseMockQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	results, err := q.execute(stub.getStateKVs())
+	if err != nil {
+		return nil, err
+	}
+	return &mockPageQueryIterator{results: results}, nil
+}
+
+// GetQueryResultWithPagination returns at most `pageSize` results of the query,
+// as evaluated by GetQueryResult, starting from the `bookmark` if it is not empty,
+// along with the bookmark of the next page
+func (stub *MockStub) GetQueryResultWithPagination(query string, pageSize int32,
+	bookmark string) (StateQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if pageSize <= 0 {
+		return nil, nil, errors.Errorf("invalid page size [%d], it must be greater than zero", pageSize)
+	}
+	q, err := parseMockQuery(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	results, err := q.execute(stub.getStateKVs())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the bookmark of the mock is the position of the first result of the page
+	start := 0
+	if bookmark != "" {
+		if start, err = strconv.Atoi(bookmark); err != nil || start < 0 {
+			return nil, nil, errors.Errorf("invalid bookmark [%s]", bookmark)
+		}
+	}
+	if start > len(results) {
+		start = len(results)
+	}
+	end := start + int(pageSize)
+	if end > len(results) {
+		end = len(results)
+	}
+
+	pageIter := &mockPageQueryIterator{results: results[start:end]}
+	responseMetadata := &pb.QueryResponseMetadata{FetchedRecordsCount: int32(end - start)}
+	if end < len(results) {
+		responseMetadata.Bookmark = strconv.Itoa(end)
+	}
+	return pageIter, responseMetadata, nil
 }
 
-// GetHistoryForKey function can be invoked by a chaincode to return a history of
-// key values across time. GetHistoryForKey is intended to be used for read-only queries.
+// GetHistoryForKey returns an iterator over the modifications of `key` by the
+// transactions that have ended, in the order of the transactions
 func (stub *MockStub) GetHistoryForKey(key string) (HistoryQueryIteratorInterface, error) {
-	return nil, errors.New("not implemented")
+	history := make([]*queryresult.KeyModification, len(stub.History[key]))
+	copy(history, stub.History[key])
+	return &mockHistoryQueryIterator{results: history}, nil
+}
+
+// GetHistoryForKeyWithOptions returns the modifications of `key` like GetHistoryForKey,
+// restricted to the time range, ordered and paginated as per the `options`. The MockStub
+// does not commit blocks, hence a block range is not supported
+func (stub *MockStub) GetHistoryForKeyWithOptions(key string,
+	options *HistoryQueryOptions) (HistoryQueryIteratorInterface, *pb.QueryResponseMetadata, error) {
+	if options.StartBlock != 0 || options.EndBlock != 0 {
+		return nil, nil, errors.New("block ranges are not supported by the MockStub")
+	}
+	if options.PageSize < 0 {
+		return nil, nil, errors.Errorf("invalid page size [%d], it must not be negative", options.PageSize)
+	}
+	if (options.StartTime != nil || options.EndTime != nil) && options.PageSize == 0 {
+		return nil, nil, errors.New("a history query bounded in time must be paginated")
+	}
+	var history []*queryresult.KeyModification
+	for _, modification := range stub.History[key] {
+		if options.StartTime != nil && timestampBefore(modification.Timestamp, options.StartTime) {
+			continue
+		}
+		if options.EndTime != nil && !timestampBefore(modification.Timestamp, options.EndTime) {
+			continue
+		}
+		history = append(history, modification)
+	}
+	if options.Descending {
+		for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
+			history[i], history[j] = history[j], history[i]
+		}
+	}
+	if options.PageSize == 0 {
+		return &mockHistoryQueryIterator{results: history}, nil, nil
+	}
+
+	// the bookmark of the mock is the position of the first modification of the page
+	start := 0
+	if options.Bookmark != "" {
+		var err error
+		if start, err = strconv.Atoi(options.Bookmark); err != nil || start < 0 {
+			return nil, nil, errors.Errorf("invalid bookmark [%s]", options.Bookmark)
+		}
+	}
+	if start > len(history) {
+		start = len(history)
+	}
+	end := start + int(options.PageSize)
+	if end > len(history) {
+		end = len(history)
+	}
+
+	responseMetadata := &pb.QueryResponseMetadata{FetchedRecordsCount: int32(end - start)}
+	if end < len(history) {
+		responseMetadata.Bookmark = strconv.Itoa(end)
+	}
+	return &mockHistoryQueryIterator{results: history[start:end]}, responseMetadata, nil
+}
+
+// timestampBefore tells whether the timestamp t1 is before the timestamp t2
+func timestampBefore(t1, t2 *timestamp.Timestamp) bool {
+	if t1.GetSeconds() != t2.GetSeconds() {
+		return t1.GetSeconds() < t2.GetSeconds()
+	}
+	return t1.GetNanos() < t2.GetNanos()
 }
 
 //GetStateByPartialCompositeKey function can be invoked by a chaincode to query the
@@ -360,6 +661,10 @@ func NewMockStub(name string, cc Chaincode) *MockStub {
 	s.State = make(map[string][]byte)
 	s.Invokables = make(map[string]*MockStub)
 	s.Keys = list.New()
+	s.EndorsementPolicies = make(map[string][]byte)
+	s.PvtState = make(map[string]map[string][]byte)
+	s.History = make(map[string][]*queryresult.KeyModification)
+	s.pendingHistory = make(map[string]*queryresult.KeyModification)
 
 	return s
 }
@@ -505,3 +810,58 @@ func getFuncArgs(bytes [][]byte) (string, []string) {
 	}
 	return function, args
 }
+
+// mockPageQueryIterator iterates over a precomputed list of results, such as a
+// page of a paginated query or the results of a rich query
+type mockPageQueryIterator struct {
+	results []*queryresult.KV
+	closed  bool
+}
+
+// HasNext returns true if the page contains additional keys and values
+func (iter *mockPageQueryIterator) HasNext() bool {
+	return !iter.closed && len(iter.results) > 0
+}
+
+// Next returns the next key and value of the page
+func (iter *mockPageQueryIterator) Next() (*queryresult.KV, error) {
+	if !iter.HasNext() {
+		return nil, errors.New("mockPageQueryIterator.Next() called when it does not HaveNext()")
+	}
+	kv := iter.results[0]
+	iter.results = iter.results[1:]
+	return kv, nil
+}
+
+// Close closes the iterator
+func (iter *mockPageQueryIterator) Close() error {
+	iter.closed = true
+	return nil
+}
+
+// mockHistoryQueryIterator iterates over the history of a key
+type mockHistoryQueryIterator struct {
+	results []*queryresult.KeyModification
+	closed  bool
+}
+
+// HasNext returns true if the history contains additional modifications
+func (iter *mockHistoryQueryIterator) HasNext() bool {
+	return !iter.closed && len(iter.results) > 0
+}
+
+// Next returns the next modification of the key
+func (iter *mockHistoryQueryIterator) Next() (*queryresult.KeyModification, error) {
+	if !iter.HasNext() {
+		return nil, errors.New("mockHistoryQueryIterator.Next() called when it does not HaveNext()")
+	}
+	modification := iter.results[0]
+	iter.results = iter.results[1:]
+	return modification, nil
+}
+
+// Close closes the iterator
+func (iter *mockHistoryQueryIterator) Close() error {
+	iter.closed = true
+	return nil
+}
@@ -22,8 +22,11 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/hyperledger/fabric/common/flogging"
+	pb "github.com/hyperledger/fabric/protos/peer"
 	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestMockStateRangeQueryIterator(t *testing.T) {
@@ -82,6 +85,43 @@ func TestMockStateRangeQueryIterator_openEnded(t *testing.T) {
 	}
 }
 
+func TestMockStateRangeQueryWithPagination(t *testing.T) {
+	stub := NewMockStub("rangeTest", nil)
+	stub.MockTransactionStart("init")
+	for _, key := range []string{"1", "0", "5", "3", "4", "6"} {
+		stub.PutState(key, []byte(key))
+	}
+	stub.MockTransactionEnd("init")
+
+	var keys []string
+	var pages int
+	bookmark := ""
+	for {
+		iter, metadata, err := stub.GetStateByRangeWithPagination("1", "5", 2, bookmark)
+		assert.NoError(t, err)
+		pages++
+		for iter.HasNext() {
+			kv, err := iter.Next()
+			assert.NoError(t, err)
+			keys = append(keys, kv.Key)
+		}
+		iter.Close()
+		if metadata.Bookmark == "" {
+			break
+		}
+		assert.Equal(t, int32(2), metadata.FetchedRecordsCount)
+		bookmark = metadata.Bookmark
+	}
+	// the end key of the ranges of the mock stub is inclusive
+	assert.Equal(t, []string{"1", "3", "4", "5"}, keys)
+	assert.Equal(t, 2, pages)
+
+	_, _, err := stub.GetStateByRangeWithPagination("1", "5", 0, "")
+	assert.Error(t, err)
+	_, _, err = stub.GetQueryResultWithPagination("q", 2, "")
+	assert.Error(t, err)
+}
+
 // TestSetupChaincodeLogging uses the utlity function defined in chaincode.go to
 // set the chaincodeLogger's logging format and level
 func TestSetupChaincodeLogging_blankLevel(t *testing.T) {
@@ -138,6 +178,38 @@ func jsonBytesEqual(expected []byte, actual []byte) bool {
 	return reflect.DeepEqual(infActual, infExpected)
 }
 
+func TestMockStateValidationParameter(t *testing.T) {
+	stub := NewMockStub("validationParameterTest", nil)
+	err := stub.SetStateValidationParameter("key", []byte("ep"))
+	assert.Error(t, err, "setting the validation parameter outside of a transaction should fail")
+
+	stub.MockTransactionStart("init")
+	err = stub.SetStateValidationParameter("key", []byte("ep"))
+	assert.Error(t, err, "setting the validation parameter of a non-existing key should fail")
+	stub.PutState("key", []byte("value"))
+	err = stub.SetStateValidationParameter("key", []byte("ep"))
+	assert.NoError(t, err)
+	stub.MockTransactionEnd("init")
+
+	ep, err := stub.GetStateValidationParameter("key")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("ep"), ep)
+
+	stub.MockTransactionStart("update")
+	err = stub.SetStateValidationParameter("key", nil)
+	assert.NoError(t, err)
+	ep, err = stub.GetStateValidationParameter("key")
+	assert.NoError(t, err)
+	assert.Nil(t, ep)
+
+	stub.SetStateValidationParameter("key", []byte("ep"))
+	stub.DelState("key")
+	ep, err = stub.GetStateValidationParameter("key")
+	assert.NoError(t, err)
+	assert.Nil(t, ep)
+	stub.MockTransactionEnd("update")
+}
+
 func TestGetStateByPartialCompositeKey(t *testing.T) {
 	stub := NewMockStub("GetStateByPartialCompositeKeyTest", nil)
 	stub.MockTransactionStart("init")
@@ -271,3 +343,283 @@ func TestMockMock(t *testing.T) {
 	getBytes("f", []string{"a", "b"})
 	getFuncArgs([][]byte{[]byte("a")})
 }
+
+func TestMockPrivateData(t *testing.T) {
+	stub := NewMockStub("PvtDataStub", nil)
+
+	err := stub.PutPrivateData("coll1", "key1", []byte("value1"))
+	assert.Error(t, err, "PutPrivateData should require a transaction")
+
+	stub.MockTransactionStart("init")
+	assert.NoError(t, stub.PutPrivateData("coll1", "key1", []byte("value1")))
+	assert.NoError(t, stub.PutPrivateData("coll1", "key2", []byte("value2")))
+	assert.NoError(t, stub.PutPrivateData("coll1", "key3", []byte("value3")))
+	assert.NoError(t, stub.PutPrivateData("coll2", "key1", []byte("other")))
+	assert.Error(t, stub.PutPrivateData("", "key1", []byte("value1")))
+	assert.Error(t, stub.PutPrivateData("coll1", "", []byte("value1")))
+
+	value, err := stub.GetPrivateData("coll1", "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value1"), value)
+	value, err = stub.GetPrivateData("coll2", "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("other"), value)
+	value, err = stub.GetPrivateData("coll3", "key1")
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+	_, state := stub.State["key1"]
+	assert.False(t, state, "private data should not be written to the state")
+
+	iter, err := stub.GetPrivateDataByRange("coll1", "key1", "key3")
+	assert.NoError(t, err)
+	var keys []string
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		assert.NoError(t, err)
+		keys = append(keys, kv.Key)
+	}
+	assert.Equal(t, []string{"key1", "key2"}, keys)
+
+	assert.NoError(t, stub.DelPrivateData("coll1", "key1"))
+	value, err = stub.GetPrivateData("coll1", "key1")
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+	stub.MockTransactionEnd("init")
+}
+
+func TestMockPrivateDataByPartialCompositeKey(t *testing.T) {
+	stub := NewMockStub("PvtDataStub", nil)
+	stub.MockTransactionStart("init")
+	for _, attrs := range [][]string{{"blue", "1"}, {"blue", "2"}, {"red", "1"}} {
+		key, err := stub.CreateCompositeKey("marble", attrs)
+		assert.NoError(t, err)
+		assert.NoError(t, stub.PutPrivateData("coll", key, []byte(attrs[1])))
+	}
+	stub.MockTransactionEnd("init")
+
+	iter, err := stub.GetPrivateDataByPartialCompositeKey("coll", "marble", []string{"blue"})
+	assert.NoError(t, err)
+	count := 0
+	for iter.HasNext() {
+		kv, err := iter.Next()
+		assert.NoError(t, err)
+		_, attrs, err := stub.SplitCompositeKey(kv.Key)
+		assert.NoError(t, err)
+		assert.Equal(t, "blue", attrs[0])
+		count++
+	}
+	assert.Equal(t, 2, count)
+}
+
+func TestMockGetHistoryForKey(t *testing.T) {
+	stub := NewMockStub("HistoryStub", nil)
+	stub.MockTransactionStart("tx1")
+	stub.PutState("key", []byte("value1"))
+	stub.PutState("key", []byte("value2"))
+	stub.MockTransactionEnd("tx1")
+
+	stub.MockTransactionStart("tx2")
+	stub.DelState("key")
+	iter, err := stub.GetHistoryForKey("key")
+	assert.NoError(t, err)
+	var modifications []string
+	for iter.HasNext() {
+		km, err := iter.Next()
+		assert.NoError(t, err)
+		modifications = append(modifications, km.TxId)
+	}
+	assert.Equal(t, []string{"tx1"}, modifications, "the history should only contain the ended transactions")
+	stub.MockTransactionEnd("tx2")
+
+	stub.MockTransactionStart("tx3")
+	stub.PutState("key", []byte("value3"))
+	stub.MockTransactionEnd("tx3")
+
+	iter, err = stub.GetHistoryForKey("key")
+	assert.NoError(t, err)
+	expected := []struct {
+		txID     string
+		value    string
+		isDelete bool
+	}{
+		{"tx1", "value2", false},
+		{"tx2", "", true},
+		{"tx3", "value3", false},
+	}
+	for _, e := range expected {
+		assert.True(t, iter.HasNext())
+		km, err := iter.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, e.txID, km.TxId)
+		assert.Equal(t, e.value, string(km.Value))
+		assert.Equal(t, e.isDelete, km.IsDelete)
+	}
+	assert.False(t, iter.HasNext())
+	_, err = iter.Next()
+	assert.Error(t, err)
+	assert.NoError(t, iter.Close())
+}
+
+func TestMockGetHistoryForKeyWithOptions(t *testing.T) {
+	stub := NewMockStub("HistoryStub", nil)
+	for i := 1; i <= 5; i++ {
+		txID := fmt.Sprintf("tx%d", i)
+		stub.MockTransactionStart(txID)
+		stub.setTxTimestamp(&timestamp.Timestamp{Seconds: int64(i * 10)})
+		stub.PutState("key", []byte(fmt.Sprintf("value%d", i)))
+		stub.MockTransactionEnd(txID)
+	}
+
+	retrieve := func(options *HistoryQueryOptions) ([]string, *pb.QueryResponseMetadata) {
+		iter, metadata, err := stub.GetHistoryForKeyWithOptions("key", options)
+		assert.NoError(t, err)
+		defer iter.Close()
+		var txIDs []string
+		for iter.HasNext() {
+			km, err := iter.Next()
+			assert.NoError(t, err)
+			txIDs = append(txIDs, km.TxId)
+		}
+		return txIDs, metadata
+	}
+
+	txIDs, metadata := retrieve(&HistoryQueryOptions{Descending: true})
+	assert.Equal(t, []string{"tx5", "tx4", "tx3", "tx2", "tx1"}, txIDs)
+	assert.Nil(t, metadata, "the metadata should not be returned for a history which is not paginated")
+
+	txIDs, _ = retrieve(&HistoryQueryOptions{StartTime: &timestamp.Timestamp{Seconds: 20}, EndTime: &timestamp.Timestamp{Seconds: 40}, PageSize: 10})
+	assert.Equal(t, []string{"tx2", "tx3"}, txIDs)
+
+	options := &HistoryQueryOptions{StartTime: &timestamp.Timestamp{Seconds: 15}, Descending: true, PageSize: 3}
+	txIDs, metadata = retrieve(options)
+	assert.Equal(t, []string{"tx5", "tx4", "tx3"}, txIDs)
+	assert.Equal(t, int32(3), metadata.FetchedRecordsCount)
+	assert.NotEmpty(t, metadata.Bookmark)
+	options.Bookmark = metadata.Bookmark
+	txIDs, metadata = retrieve(options)
+	assert.Equal(t, []string{"tx2"}, txIDs)
+	assert.Empty(t, metadata.Bookmark)
+
+	_, _, err := stub.GetHistoryForKeyWithOptions("key", &HistoryQueryOptions{StartBlock: 1})
+	assert.Error(t, err, "block ranges are not supported by the MockStub")
+	_, _, err = stub.GetHistoryForKeyWithOptions("key", &HistoryQueryOptions{PageSize: -1})
+	assert.Error(t, err)
+	_, _, err = stub.GetHistoryForKeyWithOptions("key", &HistoryQueryOptions{EndTime: &timestamp.Timestamp{Seconds: 40}})
+	assert.Error(t, err, "a history query bounded in time must be paginated")
+	_, _, err = stub.GetHistoryForKeyWithOptions("key", &HistoryQueryOptions{PageSize: 2, Bookmark: "bad bookmark"})
+	assert.Error(t, err)
+}
+
+func TestMockGetQueryResult(t *testing.T) {
+	stub := NewMockStub("QueryStub", nil)
+	stub.MockTransactionStart("init")
+	marbles := map[string]string{
+		"marble1": `{"color":"blue","size":10,"owner":{"name":"tom"}}`,
+		"marble2": `{"color":"red","size":50,"owner":{"name":"jerry"}}`,
+		"marble3": `{"color":"blue","size":35,"owner":{"name":"jerry"}}`,
+		"marble4": `{"color":"green","size":20,"owner":{"name":"tom"}}`,
+		"other":   `not json`,
+	}
+	for key, value := range marbles {
+		stub.PutState(key, []byte(value))
+	}
+	stub.MockTransactionEnd("init")
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"implicit equality", `{"selector":{"color":"blue"}}`, []string{"marble1", "marble3"}},
+		{"explicit equality", `{"selector":{"color":{"$eq":"red"}}}`, []string{"marble2"}},
+		{"not equal", `{"selector":{"color":{"$ne":"blue"}}}`, []string{"marble2", "marble4"}},
+		{"range", `{"selector":{"size":{"$gte":20,"$lt":50}}}`, []string{"marble3", "marble4"}},
+		{"dotted field", `{"selector":{"owner.name":"tom"}}`, []string{"marble1", "marble4"}},
+		{"nested field", `{"selector":{"owner":{"name":"jerry"}}}`, []string{"marble2", "marble3"}},
+		{"and", `{"selector":{"$and":[{"color":"blue"},{"size":{"$gt":20}}]}}`, []string{"marble3"}},
+		{"or", `{"selector":{"$or":[{"color":"red"},{"color":"green"}]}}`, []string{"marble2", "marble4"}},
+		{"not", `{"selector":{"$not":{"color":"blue"}}}`, []string{"marble2", "marble4"}},
+		{"missing field", `{"selector":{"weight":{"$gt":0}}}`, nil},
+		{"sort", `{"selector":{"size":{"$gt":0}},"sort":[{"size":"desc"}]}`, []string{"marble2", "marble3", "marble4", "marble1"}},
+		{"skip and limit", `{"selector":{"size":{"$gt":0}},"sort":["size"],"skip":1,"limit":2}`, []string{"marble4", "marble3"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			iter, err := stub.GetQueryResult(test.query)
+			assert.NoError(t, err)
+			var keys []string
+			for iter.HasNext() {
+				kv, err := iter.Next()
+				assert.NoError(t, err)
+				keys = append(keys, kv.Key)
+			}
+			assert.Equal(t, test.expected, keys)
+		})
+	}
+
+	for _, query := range []string{
+		`not a query`,
+		`{"fields":["color"]}`,
+		`{"selector":{"color":{"$regex":"^b"}}}`,
+		`{"selector":{"$nor":[{"color":"blue"}]}}`,
+		`{"selector":{"$or":{"color":"blue"}}}`,
+		`{"selector":{"color":"blue"},"sort":[{"size":"up"}]}`,
+	} {
+		_, err := stub.GetQueryResult(query)
+		assert.Error(t, err, "query %s should be rejected", query)
+	}
+}
+
+func TestMockGetQueryResultWithPagination(t *testing.T) {
+	stub := NewMockStub("QueryStub", nil)
+	stub.MockTransactionStart("init")
+	for i := 1; i <= 5; i++ {
+		stub.PutState(fmt.Sprintf("marble%d", i), []byte(fmt.Sprintf(`{"docType":"marble","size":%d}`, i)))
+	}
+	stub.MockTransactionEnd("init")
+
+	query := `{"selector":{"docType":"marble"}}`
+	var keys []string
+	bookmark := ""
+	pages := 0
+	for {
+		iter, metadata, err := stub.GetQueryResultWithPagination(query, 2, bookmark)
+		assert.NoError(t, err)
+		pages++
+		for iter.HasNext() {
+			kv, err := iter.Next()
+			assert.NoError(t, err)
+			keys = append(keys, kv.Key)
+		}
+		if metadata.Bookmark == "" {
+			assert.Equal(t, int32(1), metadata.FetchedRecordsCount)
+			break
+		}
+		assert.Equal(t, int32(2), metadata.FetchedRecordsCount)
+		bookmark = metadata.Bookmark
+	}
+	assert.Equal(t, 3, pages)
+	assert.Equal(t, []string{"marble1", "marble2", "marble3", "marble4", "marble5"}, keys)
+
+	_, _, err := stub.GetQueryResultWithPagination(query, 0, "")
+	assert.Error(t, err)
+	_, _, err = stub.GetQueryResultWithPagination(query, 2, "bad bookmark")
+	assert.Error(t, err)
+}
+
+func TestMockGetPrivateDataQueryResult(t *testing.T) {
+	stub := NewMockStub("PvtDataStub", nil)
+	stub.MockTransactionStart("init")
+	stub.PutPrivateData("coll", "marble1", []byte(`{"color":"blue"}`))
+	stub.PutPrivateData("coll", "marble2", []byte(`{"color":"red"}`))
+	stub.PutState("marble3", []byte(`{"color":"blue"}`))
+	stub.MockTransactionEnd("init")
+
+	iter, err := stub.GetPrivateDataQueryResult("coll", `{"selector":{"color":"blue"}}`)
+	assert.NoError(t, err)
+	assert.True(t, iter.HasNext())
+	kv, err := iter.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "marble1", kv.Key)
+	assert.False(t, iter.HasNext())
+}
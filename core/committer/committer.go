@@ -42,6 +42,18 @@ type Committer interface {
 	// collections and namespaces of private data to retrieve
 	GetPvtDataByNum(blockNum uint64, filter ledger.PvtNsCollFilter) ([]*ledger.TxPvtData, error)
 
+	// GetMissingPvtDataInfoForMostRecentBlocks returns the info about the private data
+	// missing in the most recent blocks, up to the given number of blocks
+	GetMissingPvtDataInfoForMostRecentBlocks(maxBlocks int) (ledger.MissingPvtDataInfo, error)
+
+	// GetMissingPvtDataInfoForBlocksBelow returns the info about the private data
+	// missing in the most recent blocks before the given block, up to the given number of blocks
+	GetMissingPvtDataInfoForBlocksBelow(blockNum uint64, maxBlocks int) (ledger.MissingPvtDataInfo, error)
+
+	// CommitPvtDataOfOldBlocks commits the private data of already committed blocks
+	// and returns the collections whose private data does not match the hashes in the blocks
+	CommitPvtDataOfOldBlocks(blocksPvtData []*ledger.BlockPvtData) ([]*ledger.PvtdataHashMismatch, error)
+
 	// Get recent block sequence number
 	LedgerHeight() (uint64, error)
 
@@ -18,8 +18,10 @@ package committer
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/metrics"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/events/producer"
 	"github.com/hyperledger/fabric/protos/common"
@@ -44,6 +46,7 @@ func init() {
 type LedgerCommitter struct {
 	ledger.PeerLedger
 	eventer ConfigBlockEventer
+	metrics metrics.Scope
 }
 
 // ConfigBlockEventer callback function proto type to define action
@@ -60,7 +63,7 @@ func NewLedgerCommitter(ledger ledger.PeerLedger) *LedgerCommitter {
 // same as way as NewLedgerCommitter, while also provides an option to specify callback to
 // be called upon new configuration block arrival and commit event
 func NewLedgerCommitterReactive(ledger ledger.PeerLedger, eventer ConfigBlockEventer) *LedgerCommitter {
-	return &LedgerCommitter{PeerLedger: ledger, eventer: eventer}
+	return &LedgerCommitter{PeerLedger: ledger, eventer: eventer, metrics: metrics.RootScope.SubScope("committer")}
 }
 
 // preCommit takes care to validate the block and update based on its
@@ -85,9 +88,11 @@ func (lc *LedgerCommitter) CommitWithPvtData(blockAndPvtData *ledger.BlockAndPvt
 	}
 
 	// Committing new block
+	startTime := time.Now()
 	if err := lc.PeerLedger.CommitWithPvtData(blockAndPvtData); err != nil {
 		return err
 	}
+	lc.recordMetrics(blockAndPvtData.Block, time.Since(startTime))
 
 	// post commit actions, such as event publishing
 	lc.postCommit(blockAndPvtData.Block)
@@ -95,6 +100,19 @@ func (lc *LedgerCommitter) CommitWithPvtData(blockAndPvtData *ledger.BlockAndPvt
 	return nil
 }
 
+// recordMetrics records the duration of the commit of the block and the resulting height of the ledger
+func (lc *LedgerCommitter) recordMetrics(block *common.Block, duration time.Duration) {
+	chainID, err := utils.GetChainIDFromBlock(block)
+	if err != nil {
+		logger.Debugf("Could not determine the channel of block [%d] for metrics: %s", block.Header.Number, err)
+		return
+	}
+	scope := lc.metrics.Tagged(map[string]string{"channel": chainID})
+	scope.Histogram("block_commit_duration").RecordDuration(duration)
+	scope.Counter("blocks_committed").Inc(1)
+	scope.Gauge("ledger_height").Update(float64(block.Header.Number + 1))
+}
+
 // GetPvtDataAndBlockByNum retrieves private data and block for given sequence number
 func (lc *LedgerCommitter) GetPvtDataAndBlockByNum(seqNum uint64) (*ledger.BlockAndPvtData, error) {
 	return lc.PeerLedger.GetPvtDataAndBlockByNum(seqNum, nil)
@@ -22,12 +22,14 @@ import (
 
 	"github.com/hyperledger/fabric/common/configtx/test"
 	"github.com/hyperledger/fabric/common/ledger/testutil"
+	mockmetrics "github.com/hyperledger/fabric/common/mocks/metrics"
 	"github.com/hyperledger/fabric/common/tools/configtxgen/encoder"
 	"github.com/hyperledger/fabric/common/tools/configtxgen/localconfig"
 	"github.com/hyperledger/fabric/common/util"
 	ledger2 "github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/ledgermgmt"
 	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
@@ -43,6 +45,8 @@ func TestKVLedgerBlockStorage(t *testing.T) {
 	defer ledger.Close()
 
 	committer := NewLedgerCommitter(ledger)
+	scope := mockmetrics.NewScope()
+	committer.metrics = scope
 	height, err := committer.LedgerHeight()
 	assert.Equal(t, uint64(1), height)
 	assert.NoError(t, err)
@@ -71,6 +75,13 @@ func TestKVLedgerBlockStorage(t *testing.T) {
 	assert.Equal(t, uint64(2), height)
 	assert.NoError(t, err)
 
+	chainID, err := utils.GetChainIDFromBlock(block1)
+	assert.NoError(t, err)
+	tags := map[string]string{"channel": chainID}
+	assert.Len(t, scope.HistogramValues("block_commit_duration", tags), 1)
+	assert.Equal(t, int64(1), scope.CounterValue("blocks_committed", tags))
+	assert.Equal(t, float64(2), scope.GaugeValue("ledger_height", tags))
+
 	blocks := committer.GetBlocks([]uint64{0})
 	assert.Equal(t, 1, len(blocks))
 	assert.NoError(t, err)
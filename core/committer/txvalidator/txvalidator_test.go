@@ -22,10 +22,13 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/common/configtx/test"
 	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/common/metrics"
 	"github.com/hyperledger/fabric/common/mocks/config"
+	mockmetrics "github.com/hyperledger/fabric/common/mocks/metrics"
 	util2 "github.com/hyperledger/fabric/common/util"
 	"github.com/hyperledger/fabric/core/common/sysccprovider"
 	ledger2 "github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwsetutil"
 	"github.com/hyperledger/fabric/core/ledger/ledgermgmt"
 	"github.com/hyperledger/fabric/core/ledger/util"
 	ledgerUtil "github.com/hyperledger/fabric/core/ledger/util"
@@ -62,7 +65,7 @@ func testValidationWithNTXes(t *testing.T, ledger ledger2.PeerLedger, gbHash []b
 		*mocktxvalidator.Support
 		*semaphore.Weighted
 	}{&mocktxvalidator.Support{LedgerVal: ledger, ACVal: &config.MockApplicationCapabilities{}}, semaphore.NewWeighted(10)}
-	tValidator := &txValidator{vcs, mockVsccValidator}
+	tValidator := &txValidator{vcs, mockVsccValidator, metrics.RootScope}
 
 	bcInfo, _ := ledger.GetBlockchainInfo()
 	testutil.AssertEquals(t, bcInfo, &common.BlockchainInfo{
@@ -91,7 +94,7 @@ func testValidationWithNTXes(t *testing.T, ledger ledger2.PeerLedger, gbHash []b
 			CIns:     upgradeChaincodeIns,
 			RespPayl: prespPaylBytes,
 		}
-		newTxValidator := &txValidator{&mocktxvalidator.Support{LedgerVal: ledger}, newMockVsccValidator}
+		newTxValidator := &txValidator{&mocktxvalidator.Support{LedgerVal: ledger}, newMockVsccValidator, metrics.RootScope}
 
 		// generate new block
 		newBlock := testutil.ConstructBlock(t, 2, block.Header.Hash(), [][]byte{simRes}, true) // contains one tx with chaincode version v1
@@ -158,7 +161,8 @@ func TestBlockValidationDuplicateTXId(t *testing.T) {
 		*mocktxvalidator.Support
 		*semaphore.Weighted
 	}{&mocktxvalidator.Support{LedgerVal: ledger, ACVal: acv}, semaphore.NewWeighted(10)}
-	tValidator := &txValidator{vcs, mockVsccValidator}
+	scope := mockmetrics.NewScope()
+	tValidator := &txValidator{vcs, mockVsccValidator, scope}
 
 	bcInfo, _ := ledger.GetBlockchainInfo()
 	testutil.AssertEquals(t, bcInfo, &common.BlockchainInfo{
@@ -185,6 +189,12 @@ func TestBlockValidationDuplicateTXId(t *testing.T) {
 
 	assert.True(t, txsfltr.IsSetTo(0, peer.TxValidationCode_VALID))
 	assert.True(t, txsfltr.IsSetTo(1, peer.TxValidationCode_DUPLICATE_TXID))
+
+	chainID, err := utils.GetChainIDFromBlock(block)
+	assert.NoError(t, err)
+	assert.Len(t, scope.HistogramValues("block_validation_duration", map[string]string{"channel": chainID}), 2)
+	assert.Equal(t, int64(3), scope.CounterValue("transactions_total", map[string]string{"channel": chainID, "validation_code": "VALID"}))
+	assert.Equal(t, int64(1), scope.CounterValue("transactions_total", map[string]string{"channel": chainID, "validation_code": "DUPLICATE_TXID"}))
 }
 
 func TestBlockValidation(t *testing.T) {
@@ -245,7 +255,7 @@ func TestNewTxValidator_DuplicateTransactions(t *testing.T) {
 		*mocktxvalidator.Support
 		*semaphore.Weighted
 	}{&mocktxvalidator.Support{LedgerVal: ledger, ACVal: &config.MockApplicationCapabilities{}}, semaphore.NewWeighted(10)}
-	tValidator := &txValidator{vcs, &validator.MockVsccValidator{}}
+	tValidator := &txValidator{vcs, &validator.MockVsccValidator{}, metrics.RootScope}
 
 	// Create simple endorsement transaction
 	payload := &common.Payload{
@@ -416,3 +426,48 @@ func TestInvalidTXsForUpgradeCC(t *testing.T) {
 
 	assert.EqualValues(t, expectTxsFltr, finalfltr)
 }
+
+func TestInvalidTXsForKeyLevelPolicyUpdates(t *testing.T) {
+	simRes := func(writes map[string][]byte, metadataWrites map[string]map[string][]byte) []byte {
+		rwsetBuilder := rwsetutil.NewRWSetBuilder()
+		for k, v := range writes {
+			rwsetBuilder.AddToWriteSet("ns1", k, v)
+		}
+		for k, m := range metadataWrites {
+			rwsetBuilder.AddToMetadataWriteSet("ns1", k, m)
+		}
+		sr, err := rwsetBuilder.GetTxSimulationResults()
+		assert.NoError(t, err)
+		srBytes, err := sr.GetPubSimulationBytes()
+		assert.NoError(t, err)
+		return srBytes
+	}
+	ep := map[string][]byte{peer.MetaDataKeys_VALIDATION_PARAMETER.String(): []byte("ep")}
+
+	block := testutil.ConstructBlock(t, 1, []byte("hash"), [][]byte{
+		simRes(nil, map[string]map[string][]byte{"key1": ep}),             // updates the policy of key1
+		simRes(map[string][]byte{"key1": []byte("value1")}, nil),          // writes key1, should be invalidated
+		simRes(nil, map[string]map[string][]byte{"key2": ep}),             // invalid, should not affect later txs
+		simRes(map[string][]byte{"key2": []byte("value2")}, nil),          // writes key2
+		simRes(nil, map[string]map[string][]byte{"key1": ep, "key3": ep}), // updates the policy of key1, should be invalidated
+		simRes(map[string][]byte{"key3": []byte("value3")}, nil),          // writes key3, not affected by the invalidated tx
+		simRes(map[string][]byte{"key4": []byte("value4")}, nil),          // writes key4
+	}, true)
+
+	txsfltr := ledgerUtil.NewTxValidationFlags(7)
+	for i := 0; i < 7; i++ {
+		txsfltr.SetFlag(i, peer.TxValidationCode_VALID)
+	}
+	txsfltr.SetFlag(2, peer.TxValidationCode_MVCC_READ_CONFLICT)
+
+	tValidator := &txValidator{}
+	tValidator.invalidTXsForKeyLevelPolicyUpdates(block, txsfltr)
+
+	assert.True(t, txsfltr.IsSetTo(0, peer.TxValidationCode_VALID))
+	assert.True(t, txsfltr.IsSetTo(1, peer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE))
+	assert.True(t, txsfltr.IsSetTo(2, peer.TxValidationCode_MVCC_READ_CONFLICT))
+	assert.True(t, txsfltr.IsSetTo(3, peer.TxValidationCode_VALID))
+	assert.True(t, txsfltr.IsSetTo(4, peer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE))
+	assert.True(t, txsfltr.IsSetTo(5, peer.TxValidationCode_VALID))
+	assert.True(t, txsfltr.IsSetTo(6, peer.TxValidationCode_VALID))
+}
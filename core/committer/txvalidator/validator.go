@@ -8,12 +8,15 @@ package txvalidator
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/common/cauthdsl"
 	"github.com/hyperledger/fabric/common/channelconfig"
 	"github.com/hyperledger/fabric/common/configtx"
 	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/metrics"
 	"github.com/hyperledger/fabric/common/resourcesconfig"
 	coreUtil "github.com/hyperledger/fabric/common/util"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
@@ -88,6 +91,7 @@ type vsccValidatorImpl struct {
 type txValidator struct {
 	support Support
 	vscc    vsccValidator
+	metrics metrics.Scope
 }
 
 // VSCCInfoLookupFailureError error to indicate inability
@@ -154,7 +158,8 @@ func NewTxValidator(support Support) Validator {
 		&vsccValidatorImpl{
 			support:     support,
 			ccprovider:  ccprovider.GetChaincodeProvider(),
-			sccprovider: sysccprovider.GetSystemChaincodeProvider()}}
+			sccprovider: sysccprovider.GetSystemChaincodeProvider()},
+		metrics.RootScope.SubScope("validator")}
 }
 
 func (v *txValidator) chainExists(chain string) bool {
@@ -185,6 +190,7 @@ func (v *txValidator) chainExists(chain string) bool {
 func (v *txValidator) Validate(block *common.Block) error {
 	var err error
 	var errPos int
+	startTime := time.Now()
 
 	logger.Debug("START Block Validation")
 	defer logger.Debug("END Block Validation")
@@ -267,6 +273,12 @@ func (v *txValidator) Validate(block *common.Block) error {
 		markTXIdDuplicates(txidArray, txsfltr)
 	}
 
+	// if we operate with this capability, we mark invalid any transaction that writes
+	// to a key whose key-level endorsement policy was updated by a previous tx in this block
+	if v.support.Capabilities().KeyLevelEndorsement() {
+		v.invalidTXsForKeyLevelPolicyUpdates(block, txsfltr)
+	}
+
 	// if we're here, all workers have completed validation and
 	// no error was reported; we set the tx filter and return
 	// success
@@ -278,9 +290,31 @@ func (v *txValidator) Validate(block *common.Block) error {
 
 	block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = txsfltr
 
+	v.recordMetrics(block, txsfltr, time.Since(startTime))
+
 	return nil
 }
 
+// recordMetrics records the duration of the validation of the block and the
+// number of transactions per validation code
+func (v *txValidator) recordMetrics(block *common.Block, txsfltr ledgerUtil.TxValidationFlags, duration time.Duration) {
+	chainID, err := utils.GetChainIDFromBlock(block)
+	if err != nil {
+		logger.Debugf("Could not determine the channel of the block for metrics: %s", err)
+		return
+	}
+	scope := v.metrics.Tagged(map[string]string{"channel": chainID})
+	scope.Histogram("block_validation_duration").RecordDuration(duration)
+
+	counts := make(map[peer.TxValidationCode]int64)
+	for i := range txsfltr {
+		counts[txsfltr.Flag(i)]++
+	}
+	for code, count := range counts {
+		scope.Tagged(map[string]string{"validation_code": code.String()}).Counter("transactions_total").Inc(count)
+	}
+}
+
 func markTXIdDuplicates(txids []string, txsfltr ledgerUtil.TxValidationFlags) {
 	txidMap := make(map[string]struct{})
 
@@ -366,9 +400,9 @@ func validateTx(req *blockValidationRequest, results chan<- *blockValidationResu
 		}
 
 		if common.HeaderType(chdr.Type) == common.HeaderType_ENDORSER_TRANSACTION {
-			// Check duplicate transactions
+			// Check duplicate transactions. A transaction in a pruned block is a duplicate as well
 			txID = chdr.TxId
-			if _, err := v.support.Ledger().GetTransactionByID(txID); err == nil {
+			if _, err := v.support.Ledger().GetTransactionByID(txID); err == nil || err == blkstorage.ErrPruned {
 				logger.Error("Duplicate transaction found, ", txID, ", skipping")
 				results <- &blockValidationResult{
 					tIdx:           tIdx,
@@ -487,6 +521,96 @@ func validateTx(req *blockValidationRequest, results chan<- *blockValidationResu
 	}
 }
 
+// invalidTXsForKeyLevelPolicyUpdates marks invalid all the txs that write to a key (or to its metadata)
+// whose metadata was updated by a previous valid tx in the same block. VSCC evaluates the key-level
+// endorsement policies as found in the ledger, so these txs have been validated against a stale policy
+func (v *txValidator) invalidTXsForKeyLevelPolicyUpdates(block *common.Block, txsfltr ledgerUtil.TxValidationFlags) {
+	// updatedKeys records, for every key whose metadata was updated in this block, the index of the updating tx
+	updatedKeys := make(map[nsKey]int)
+	for tIdx, d := range block.Data.Data {
+		if !txsfltr.IsValid(tIdx) {
+			continue
+		}
+		txRWSet, err := getEndorserTxRWSet(d)
+		if err != nil {
+			logger.Debugf("Skipping key-level endorsement checks for transaction with index %d: %s", tIdx, err)
+			continue
+		}
+		if txRWSet == nil {
+			continue
+		}
+
+		var writtenKeys, metadataKeys []nsKey
+		for _, ns := range txRWSet.NsRwSets {
+			if ns.KvRwSet == nil {
+				continue
+			}
+			for _, write := range ns.KvRwSet.Writes {
+				writtenKeys = append(writtenKeys, nsKey{ns.NameSpace, write.Key})
+			}
+			for _, metadataWrite := range ns.KvRwSet.MetadataWrites {
+				metadataKeys = append(metadataKeys, nsKey{ns.NameSpace, metadataWrite.Key})
+			}
+		}
+
+		invalid := false
+		for _, k := range append(writtenKeys, metadataKeys...) {
+			if updatingIdx, exists := updatedKeys[k]; exists {
+				logger.Infof("Invalid transaction with index %d: endorsement policy of key %s of chaincode %s was updated by tx with index %d",
+					tIdx, k.key, k.ns, updatingIdx)
+				invalid = true
+				break
+			}
+		}
+		if invalid {
+			txsfltr.SetFlag(tIdx, peer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE)
+			continue
+		}
+
+		for _, k := range metadataKeys {
+			updatedKeys[k] = tIdx
+		}
+	}
+}
+
+// nsKey identifies a key in the namespace of a chaincode
+type nsKey struct {
+	ns  string
+	key string
+}
+
+// getEndorserTxRWSet returns the read-write set of an endorser transaction,
+// or nil if the supplied envelope does not carry an endorser transaction
+func getEndorserTxRWSet(envBytes []byte) (*rwsetutil.TxRwSet, error) {
+	env, err := utils.GetEnvelopeFromBlock(envBytes)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := utils.GetPayload(env)
+	if err != nil {
+		return nil, err
+	}
+	if payload.Header == nil {
+		return nil, fmt.Errorf("nil payload header")
+	}
+	chdr, err := utils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return nil, err
+	}
+	if common.HeaderType(chdr.Type) != common.HeaderType_ENDORSER_TRANSACTION {
+		return nil, nil
+	}
+	respPayload, err := utils.GetActionFromEnvelope(envBytes)
+	if err != nil {
+		return nil, err
+	}
+	txRWSet := &rwsetutil.TxRwSet{}
+	if err = txRWSet.FromProtoBytes(respPayload.Results); err != nil {
+		return nil, err
+	}
+	return txRWSet, nil
+}
+
 // generateCCKey generates a unique identifier for chaincode in specific chain
 func (v *txValidator) generateCCKey(ccName, chainID string) string {
 	return fmt.Sprintf("%s/%s", ccName, chainID)
@@ -662,6 +786,11 @@ func (v *vsccValidatorImpl) txWritesToNamespace(ns *rwsetutil.NsRwSet) bool {
 		return true
 	}
 
+	// check for writes to the metadata of the keys if we support key-level endorsement policies
+	if v.support.Capabilities().KeyLevelEndorsement() && ns.KvRwSet != nil && len(ns.KvRwSet.MetadataWrites) > 0 {
+		return true
+	}
+
 	// do not look at collection data if we don't support that capability
 	if !v.support.Capabilities().PrivateChannelData() {
 		return false
@@ -796,7 +925,7 @@ func (v *vsccValidatorImpl) VSCCValidateTx(payload *common.Payload, envBytes []b
 			}
 
 			// do VSCC validation
-			if err = v.VSCCValidateTxForCC(envBytes, chdr.TxId, chdr.ChannelId, vscc.ChaincodeName, vscc.ChaincodeVersion, policy); err != nil {
+			if err = v.VSCCValidateTxForCC(envBytes, chdr.TxId, chdr.ChannelId, vscc.ChaincodeName, vscc.ChaincodeVersion, policy, ns); err != nil {
 				switch err.(type) {
 				case *VSCCEndorsementPolicyError:
 					return err, peer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE
@@ -827,7 +956,7 @@ func (v *vsccValidatorImpl) VSCCValidateTx(payload *common.Payload, envBytes []b
 		// currently, VSCC does custom validation for LSCC only; if an hlf
 		// user creates a new system chaincode which is invokable from the outside
 		// they have to modify VSCC to provide appropriate validation
-		if err = v.VSCCValidateTxForCC(envBytes, chdr.TxId, vscc.ChainID, vscc.ChaincodeName, vscc.ChaincodeVersion, policy); err != nil {
+		if err = v.VSCCValidateTxForCC(envBytes, chdr.TxId, vscc.ChainID, vscc.ChaincodeName, vscc.ChaincodeVersion, policy, ccID); err != nil {
 			switch err.(type) {
 			case *VSCCEndorsementPolicyError:
 				return err, peer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE
@@ -840,7 +969,7 @@ func (v *vsccValidatorImpl) VSCCValidateTx(payload *common.Payload, envBytes []b
 	return nil, peer.TxValidationCode_VALID
 }
 
-func (v *vsccValidatorImpl) VSCCValidateTxForCC(envBytes []byte, txid, chid, vsccName, vsccVer string, policy []byte) error {
+func (v *vsccValidatorImpl) VSCCValidateTxForCC(envBytes []byte, txid, chid, vsccName, vsccVer string, policy []byte, namespace string) error {
 	logger.Debugf("VSCCValidateTxForCC starts for envbytes %p", envBytes)
 	defer logger.Debugf("VSCCValidateTxForCC completes for envbytes %p", envBytes)
 	ctxt, txsim, err := v.ccprovider.GetContext(v.support.Ledger(), txid)
@@ -855,7 +984,8 @@ func (v *vsccValidatorImpl) VSCCValidateTxForCC(envBytes []byte, txid, chid, vsc
 	// args[0] - function name (not used now)
 	// args[1] - serialized Envelope
 	// args[2] - serialized policy
-	args := [][]byte{[]byte(""), envBytes, policy}
+	// args[3] - namespace whose writes are validated
+	args := [][]byte{[]byte(""), envBytes, policy, []byte(namespace)}
 
 	// get context to invoke VSCC
 	vscctxid := coreUtil.GenerateUUID()
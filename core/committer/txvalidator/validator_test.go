@@ -25,6 +25,7 @@ import (
 	"github.com/hyperledger/fabric/common/cauthdsl"
 	ctxt "github.com/hyperledger/fabric/common/configtx/test"
 	ledger2 "github.com/hyperledger/fabric/common/ledger"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
 	"github.com/hyperledger/fabric/common/ledger/testutil"
 	mockconfig "github.com/hyperledger/fabric/common/mocks/config"
 	"github.com/hyperledger/fabric/common/mocks/scc"
@@ -484,6 +485,21 @@ func (m *mockLedger) CommitWithPvtData(pvtDataAndBlock *ledger.BlockAndPvtData)
 	return nil
 }
 
+// GetMissingPvtDataInfoForMostRecentBlocks returns the info about the missing pvt data
+func (m *mockLedger) GetMissingPvtDataInfoForMostRecentBlocks(maxBlocks int) (ledger.MissingPvtDataInfo, error) {
+	return nil, nil
+}
+
+// GetMissingPvtDataInfoForBlocksBelow returns the info about the missing pvt data
+func (m *mockLedger) GetMissingPvtDataInfoForBlocksBelow(blockNum uint64, maxBlocks int) (ledger.MissingPvtDataInfo, error) {
+	return nil, nil
+}
+
+// CommitPvtDataOfOldBlocks commits the pvt data of already committed blocks
+func (m *mockLedger) CommitPvtDataOfOldBlocks(blocksPvtData []*ledger.BlockPvtData) ([]*ledger.PvtdataHashMismatch, error) {
+	return nil, nil
+}
+
 // PurgePrivateData purges the private data
 func (m *mockLedger) PurgePrivateData(maxBlockNumToRetain uint64) error {
 	return nil
@@ -499,6 +515,11 @@ func (m *mockLedger) Prune(policy ledger2.PrunePolicy) error {
 	return nil
 }
 
+// ExportSnapshot exports the snapshot of the ledger
+func (m *mockLedger) ExportSnapshot(snapshotDir string) error {
+	return nil
+}
+
 func (m *mockLedger) GetBlockchainInfo() (*common.BlockchainInfo, error) {
 	args := m.Called()
 	return args.Get(0).(*common.BlockchainInfo), nil
@@ -538,6 +559,11 @@ func (exec *mockQueryExecutor) GetState(namespace string, key string) ([]byte, e
 	return args.Get(0).([]byte), args.Error(1)
 }
 
+func (exec *mockQueryExecutor) GetStateMetadata(namespace, key string) (map[string][]byte, error) {
+	args := exec.Called(namespace, key)
+	return args.Get(0).(map[string][]byte), args.Error(1)
+}
+
 func (exec *mockQueryExecutor) GetStateMultipleKeys(namespace string, keys []string) ([][]byte, error) {
 	args := exec.Called(namespace, keys)
 	return args.Get(0).([][]byte), args.Error(1)
@@ -548,11 +574,21 @@ func (exec *mockQueryExecutor) GetStateRangeScanIterator(namespace string, start
 	return args.Get(0).(ledger2.ResultsIterator), args.Error(1)
 }
 
+func (exec *mockQueryExecutor) GetStateRangeScanIteratorWithPagination(namespace string, startKey string, endKey string, pageSize int32) (ledger.QueryResultsIterator, error) {
+	args := exec.Called(namespace, startKey, endKey, pageSize)
+	return args.Get(0).(ledger.QueryResultsIterator), args.Error(1)
+}
+
 func (exec *mockQueryExecutor) ExecuteQuery(namespace, query string) (ledger2.ResultsIterator, error) {
 	args := exec.Called(namespace)
 	return args.Get(0).(ledger2.ResultsIterator), args.Error(1)
 }
 
+func (exec *mockQueryExecutor) ExecuteQueryWithPagination(namespace, query, bookmark string, pageSize int32) (ledger.QueryResultsIterator, error) {
+	args := exec.Called(namespace, query, bookmark, pageSize)
+	return args.Get(0).(ledger.QueryResultsIterator), args.Error(1)
+}
+
 func (exec *mockQueryExecutor) GetPrivateData(namespace, collection, key string) ([]byte, error) {
 	args := exec.Called(namespace, collection, key)
 	return args.Get(0).([]byte), args.Error(1)
@@ -651,6 +687,27 @@ func TestValidationInvalidEndorsing(t *testing.T) {
 	assertInvalid(b, t, peer.TxValidationCode_ENDORSEMENT_POLICY_FAILURE)
 }
 
+func TestValidationDuplicateTxIDInPrunedBlock(t *testing.T) {
+	theLedger := new(mockLedger)
+	vcs := struct {
+		*mocktxvalidator.Support
+		*semaphore.Weighted
+	}{&mocktxvalidator.Support{LedgerVal: theLedger, ACVal: &mockconfig.MockApplicationCapabilities{}}, semaphore.NewWeighted(10)}
+	validator := NewTxValidator(vcs)
+
+	ccID := "mycc"
+	tx := getEnv(ccID, createRWset(t, ccID), t)
+
+	// the transaction with the same txid was committed in a block that is pruned since then
+	theLedger.On("GetTransactionByID", mock.Anything).Return(&peer.ProcessedTransaction{}, blkstorage.ErrPruned)
+
+	b := &common.Block{Data: &common.BlockData{Data: [][]byte{utils.MarshalOrPanic(tx)}}}
+
+	err := validator.Validate(b)
+	assert.NoError(t, err)
+	assertInvalid(b, t, peer.TxValidationCode_DUPLICATE_TXID)
+}
+
 func TestValidationResourceUpdate(t *testing.T) {
 	theLedger := new(mockLedger)
 	sup := &mocktxvalidator.Support{LedgerVal: theLedger, ACVal: &mockconfig.MockApplicationCapabilities{}}
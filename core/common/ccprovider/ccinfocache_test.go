@@ -267,6 +267,57 @@ func TestSetChaincodesPath(t *testing.T) {
 	// }, "SetChaincodesPath should have paniced if it is not able to create the dir")
 }
 
+func TestExtractStatedbArtifacts(t *testing.T) {
+	codePackageBytes := bytes.NewBuffer(nil)
+	gz := gzip.NewWriter(codePackageBytes)
+	tw := tar.NewWriter(gz)
+	assert.NoError(t, util.WriteBytesToPackage("src/main.go", []byte("package main"), tw))
+	assert.NoError(t, util.WriteBytesToPackage("META-INF/statedb/couchdb/indexes/indexOwner.json", []byte(`{"index":{"fields":["owner"]}}`), tw))
+	assert.NoError(t, util.WriteBytesToPackage("META-INF/statedb/leveldb/indexes/indexOwner.json", []byte("leveldb"), tw))
+	tw.Close()
+	gz.Close()
+	depSpec := &peer.ChaincodeDeploymentSpec{
+		ChaincodeSpec: &peer.ChaincodeSpec{Type: 1, ChaincodeId: &peer.ChaincodeID{Name: "indexcc", Path: "indexcc", Version: "0"}},
+		CodePackage:   codePackageBytes.Bytes(),
+	}
+	ccpack := &CDSPackage{}
+	_, err := ccpack.InitFromBuffer(marshalOrFail(t, depSpec))
+	assert.NoError(t, err)
+
+	statedbArtifactsTar, err := ExtractStatedbArtifactsFromCCPackage(ccpack)
+	assert.NoError(t, err)
+	fileEntries, err := ExtractFileEntries(statedbArtifactsTar, "couchdb")
+	assert.NoError(t, err)
+	assert.Len(t, fileEntries, 1)
+	assert.Equal(t, "couchdb/indexes/indexOwner.json", fileEntries[0].FileHeader.Name)
+	assert.Equal(t, []byte(`{"index":{"fields":["owner"]}}`), fileEntries[0].FileContent)
+	fileEntries, err = ExtractFileEntries(statedbArtifactsTar, "mongodb")
+	assert.NoError(t, err)
+	assert.Empty(t, fileEntries)
+
+	// a chaincode without statedb artifacts results in an empty tar
+	noIndexPack, err := buildPackage("noindexcc", "noindexcc", "0", [][]byte{})
+	assert.NoError(t, err)
+	statedbArtifactsTar, err = ExtractStatedbArtifactsFromCCPackage(noIndexPack)
+	assert.NoError(t, err)
+	fileEntries, err = ExtractFileEntries(statedbArtifactsTar, "couchdb")
+	assert.NoError(t, err)
+	assert.Empty(t, fileEntries)
+
+	depSpec.CodePackage = []byte("garbage")
+	ccpack = &CDSPackage{}
+	_, err = ccpack.InitFromBuffer(marshalOrFail(t, depSpec))
+	assert.NoError(t, err)
+	_, err = ExtractStatedbArtifactsFromCCPackage(ccpack)
+	assert.Contains(t, err.Error(), "failure opening codepackage gzip stream")
+}
+
+func marshalOrFail(t *testing.T, msg proto.Message) []byte {
+	msgBytes, err := proto.Marshal(msg)
+	assert.NoError(t, err)
+	return msgBytes
+}
+
 var ccinfocachetestpath = "/tmp/ccinfocachetest"
 
 func TestMain(m *testing.M) {
@@ -0,0 +1,100 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ccmetadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric/common/flogging"
+)
+
+var logger = flogging.MustGetLogger("metadata")
+
+// CouchDBIndexesDir is the directory of a chaincode package which holds the
+// CouchDB index definitions of the chaincode
+const CouchDBIndexesDir = "META-INF/statedb/couchdb/indexes"
+
+// LevelDBIndexesDir is the directory of a chaincode package which holds the
+// LevelDB index definitions of the chaincode. The definitions have the same
+// format as the CouchDB index definitions
+const LevelDBIndexesDir = "META-INF/statedb/leveldb/indexes"
+
+// fileValidators maps the directories of the chaincode metadata to the validator
+// of the files found in each directory
+var fileValidators = map[string]func(fileName string, fileBytes []byte) error{
+	CouchDBIndexesDir: couchdbIndexFileValidator,
+	LevelDBIndexesDir: couchdbIndexFileValidator,
+}
+
+// InvalidMetadataError is returned when a metadata file of a chaincode package
+// is not supported or is malformed
+type InvalidMetadataError struct {
+	FilePathName string
+	Reason       string
+}
+
+func (e *InvalidMetadataError) Error() string {
+	return fmt.Sprintf("invalid metadata file %s: %s", e.FilePathName, e.Reason)
+}
+
+// ValidateMetadataFile checks that the file with the given path within the chaincode
+// package is a supported metadata file, and that its contents are well formed.
+// The path is expected to start with META-INF, e.g. META-INF/statedb/couchdb/indexes/indexOwner.json
+func ValidateMetadataFile(filePathName string, fileBytes []byte) error {
+	dir, fileName := filepath.Split(filepath.ToSlash(filePathName))
+	dir = strings.TrimSuffix(dir, "/")
+	validator, ok := fileValidators[dir]
+	if !ok {
+		return &InvalidMetadataError{filePathName, fmt.Sprintf("metadata files are only supported in the directories %s", supportedDirs())}
+	}
+	if filepath.Ext(fileName) != ".json" {
+		return &InvalidMetadataError{filePathName, "only .json files are supported"}
+	}
+	if err := validator(fileName, fileBytes); err != nil {
+		return &InvalidMetadataError{filePathName, err.Error()}
+	}
+	logger.Debugf("Validated metadata file %s", filePathName)
+	return nil
+}
+
+// couchdbIndexFileValidator checks that the file contains a CouchDB index definition such as
+// {"index":{"fields":["docType","owner"]},"ddoc":"indexOwnerDoc","name":"indexOwner","type":"json"}
+func couchdbIndexFileValidator(fileName string, fileBytes []byte) error {
+	indexDefinition := make(map[string]interface{})
+	if err := json.Unmarshal(fileBytes, &indexDefinition); err != nil {
+		return fmt.Errorf("index definition is not valid JSON: %s", err)
+	}
+	index, ok := indexDefinition["index"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("index definition must contain an \"index\" object")
+	}
+	fields, ok := index["fields"].([]interface{})
+	if !ok || len(fields) == 0 {
+		return fmt.Errorf("index definition must contain a non-empty \"fields\" array")
+	}
+	for _, key := range []string{"ddoc", "name", "type"} {
+		if value, exists := indexDefinition[key]; exists {
+			if _, ok := value.(string); !ok {
+				return fmt.Errorf("\"%s\" of the index definition must be a string", key)
+			}
+		}
+	}
+	return nil
+}
+
+func supportedDirs() []string {
+	var dirs []string
+	for dir := range fileValidators {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
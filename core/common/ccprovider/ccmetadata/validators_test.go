@@ -0,0 +1,46 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ccmetadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateMetadataFile(t *testing.T) {
+	validIndex := []byte(`{"index":{"fields":["docType","owner"]},"ddoc":"indexOwnerDoc","name":"indexOwner","type":"json"}`)
+	assert.NoError(t, ValidateMetadataFile("META-INF/statedb/couchdb/indexes/indexOwner.json", validIndex))
+	assert.NoError(t, ValidateMetadataFile("META-INF/statedb/couchdb/indexes/indexSize.json", []byte(`{"index":{"fields":["size"]}}`)))
+
+	assert.NoError(t, ValidateMetadataFile("META-INF/statedb/leveldb/indexes/indexOwner.json", validIndex))
+
+	err := ValidateMetadataFile("META-INF/statedb/mongodb/indexes/indexOwner.json", validIndex)
+	assert.IsType(t, &InvalidMetadataError{}, err)
+	assert.Contains(t, err.Error(), "metadata files are only supported in the directories")
+
+	err = ValidateMetadataFile("META-INF/statedb/couchdb/indexes/sub/indexOwner.json", validIndex)
+	assert.Contains(t, err.Error(), "metadata files are only supported in the directories")
+
+	err = ValidateMetadataFile("META-INF/statedb/couchdb/indexes/indexOwner.txt", validIndex)
+	assert.EqualError(t, err, "invalid metadata file META-INF/statedb/couchdb/indexes/indexOwner.txt: only .json files are supported")
+
+	invalidIndexes := map[string]string{
+		`{"index":`:                                "index definition is not valid JSON",
+		`{"fields":["owner"]}`:                     `index definition must contain an "index" object`,
+		`{"index":{"fields":[]}}`:                  `index definition must contain a non-empty "fields" array`,
+		`{"index":{"fields":"owner"}}`:             `index definition must contain a non-empty "fields" array`,
+		`{"index":{"fields":["owner"]},"name":10}`: `"name" of the index definition must be a string`,
+	}
+	for index, reason := range invalidIndexes {
+		err := ValidateMetadataFile("META-INF/statedb/couchdb/indexes/indexOwner.json", []byte(index))
+		assert.Error(t, err, index)
+		assert.Contains(t, err.Error(), reason, index)
+		err = ValidateMetadataFile("META-INF/statedb/leveldb/indexes/indexOwner.json", []byte(index))
+		assert.Contains(t, err.Error(), reason, index)
+	}
+}
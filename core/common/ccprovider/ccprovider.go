@@ -17,9 +17,12 @@ limitations under the License.
 package ccprovider
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -110,6 +113,86 @@ func ChaincodePackageExists(ccname string, ccversion string) (bool, error) {
 	return false, err
 }
 
+// statedbArtifactsPrefix is the directory of a chaincode code package which holds
+// the artifacts for the state database, e.g. META-INF/statedb/couchdb/indexes/indexOwner.json
+const statedbArtifactsPrefix = "META-INF/statedb/"
+
+// TarFileEntry encapsulates a file entry and its contents inside a tar
+type TarFileEntry struct {
+	FileHeader  *tar.Header
+	FileContent []byte
+}
+
+// ExtractStatedbArtifactsFromCCPackage extracts the statedb artifacts from the code package of the
+// given chaincode package into a tar, in which the files are named relative to META-INF/statedb,
+// e.g. couchdb/indexes/indexOwner.json. The tar is empty if the chaincode has no statedb artifacts
+func ExtractStatedbArtifactsFromCCPackage(ccpackage CCPackage) ([]byte, error) {
+	cds := ccpackage.GetDepSpec()
+	statedbTarBuffer := bytes.NewBuffer(nil)
+	tw := tar.NewWriter(statedbTarBuffer)
+	if len(cds.CodePackage) != 0 {
+		gr, err := gzip.NewReader(bytes.NewReader(cds.CodePackage))
+		if err != nil {
+			return nil, fmt.Errorf("failure opening codepackage gzip stream: %s", err)
+		}
+		tr := tar.NewReader(gr)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("error reading codepackage tar: %s", err)
+			}
+			if !strings.HasPrefix(header.Name, statedbArtifactsPrefix) {
+				continue
+			}
+			ccproviderLogger.Debugf("Extracting statedb artifact %s from the package of chaincode %s", header.Name, cds.ChaincodeSpec.ChaincodeId.Name)
+			fileContent, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return nil, fmt.Errorf("error reading %s from codepackage tar: %s", header.Name, err)
+			}
+			header.Name = strings.TrimPrefix(header.Name, statedbArtifactsPrefix)
+			header.Size = int64(len(fileContent))
+			if err := tw.WriteHeader(header); err != nil {
+				return nil, fmt.Errorf("error writing %s to statedb artifacts tar: %s", header.Name, err)
+			}
+			if _, err := tw.Write(fileContent); err != nil {
+				return nil, fmt.Errorf("error writing %s to statedb artifacts tar: %s", header.Name, err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("error closing statedb artifacts tar: %s", err)
+	}
+	return statedbTarBuffer.Bytes(), nil
+}
+
+// ExtractFileEntries extracts the file entries from the given statedb artifacts tar that
+// belong to the given database type, e.g. the entries of couchdb/indexes/ for "couchdb"
+func ExtractFileEntries(tarBytes []byte, databaseType string) ([]*TarFileEntry, error) {
+	var fileEntries []*TarFileEntry
+	tr := tar.NewReader(bytes.NewReader(tarBytes))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading statedb artifacts tar: %s", err)
+		}
+		if !strings.HasPrefix(header.Name, databaseType+"/") {
+			continue
+		}
+		fileContent, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s from statedb artifacts tar: %s", header.Name, err)
+		}
+		fileEntries = append(fileEntries, &TarFileEntry{header, fileContent})
+	}
+	return fileEntries, nil
+}
+
 type CCCacheSupport interface {
 	//GetChaincode is needed by the cache to get chaincode data
 	GetChaincode(ccname string, ccversion string) (CCPackage, error)
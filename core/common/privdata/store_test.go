@@ -38,7 +38,7 @@ func (c *mockStoreSupport) GetIdentityDeserializer(chainID string) msp.IdentityD
 
 func TestCollectionStore(t *testing.T) {
 	wState := make(map[string]map[string][]byte)
-	support := &mockStoreSupport{Qe: &lm.MockQueryExecutor{wState}}
+	support := &mockStoreSupport{Qe: &lm.MockQueryExecutor{State: wState}}
 	cs := NewSimpleCollectionStore(support)
 	assert.NotNil(t, cs)
 
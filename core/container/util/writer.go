@@ -11,12 +11,14 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/core/common/ccprovider/ccmetadata"
 	"github.com/pkg/errors"
 )
 
@@ -59,6 +61,14 @@ func WriteFolderToTarPackage(tw *tar.Writer, srcPath string, excludeDir string,
 		if len(path[rootDirLen:]) == 0 {
 			return nil
 		}
+
+		// metadata files such as the statedb index definitions keep their META-INF
+		// directory, e.g. META-INF/statedb/couchdb/indexes/indexOwner.json, while
+		// the source files are put in the src directory
+		if strings.HasPrefix(path, filepath.Join(rootDirectory, "META-INF")+string(filepath.Separator)) {
+			return writeMetadataFileToPackage(path, path[rootDirLen+1:], tw)
+		}
+
 		ext := filepath.Ext(path)
 
 		if includeFileTypeMap != nil {
@@ -100,6 +110,25 @@ func WriteFolderToTarPackage(tw *tar.Writer, srcPath string, excludeDir string,
 	return nil
 }
 
+// writeMetadataFileToPackage validates the chaincode metadata file and writes it
+// to the package under the given name, which starts with META-INF
+func writeMetadataFileToPackage(localpath, packagepath string, tw *tar.Writer) error {
+	packagepath = filepath.ToSlash(packagepath)
+	// hidden files, e.g. those created by editors, are not metadata
+	if strings.HasPrefix(filepath.Base(packagepath), ".") {
+		return nil
+	}
+	fileBytes, err := ioutil.ReadFile(localpath)
+	if err != nil {
+		return errors.Wrapf(err, "error reading metadata file %s", localpath)
+	}
+	if err := ccmetadata.ValidateMetadataFile(packagepath, fileBytes); err != nil {
+		return err
+	}
+	vmLogger.Debugf("Writing metadata file %s to tar", packagepath)
+	return WriteFileToPackage(localpath, packagepath, tw)
+}
+
 //Package Java project to tar file from the source path
 func WriteJavaProjectToPackage(tw *tar.Writer, srcPath string) error {
 
@@ -180,6 +180,46 @@ func Test_WriteFolderToTarPackage(t *testing.T) {
 	assert.Contains(t, err.Error(), "no source files found")
 }
 
+func Test_WriteFolderToTarPackageWithMetadata(t *testing.T) {
+	srcPath, err := ioutil.TempDir("", "chaincode")
+	assert.NoError(t, err)
+	defer os.RemoveAll(srcPath)
+	indexesDir := filepath.Join(srcPath, "META-INF", "statedb", "couchdb", "indexes")
+	assert.NoError(t, os.MkdirAll(indexesDir, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(srcPath, "chaincode.js"), []byte("chaincode"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(indexesDir, "indexOwner.json"), []byte(`{"index":{"fields":["owner"]}}`), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(indexesDir, ".hidden"), []byte("hidden"), 0644))
+
+	buf := bytes.NewBuffer(nil)
+	tw := tar.NewWriter(buf)
+	err = WriteFolderToTarPackage(tw, srcPath, "", nil, nil)
+	assert.NoError(t, err)
+	tw.Close()
+
+	tr := tar.NewReader(buf)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, header.Name)
+	}
+	assert.Equal(t, []string{"META-INF/statedb/couchdb/indexes/indexOwner.json", "src/chaincode.js"}, names)
+
+	// an invalid index definition is rejected
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(indexesDir, "indexColor.json"), []byte(`{"index":`), 0644))
+	err = WriteFolderToTarPackage(tar.NewWriter(bytes.NewBuffer(nil)), srcPath, "", nil, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid metadata file META-INF/statedb/couchdb/indexes/indexColor.json")
+
+	// a package with only metadata has no source files
+	assert.NoError(t, os.Remove(filepath.Join(indexesDir, "indexColor.json")))
+	assert.NoError(t, os.Remove(filepath.Join(srcPath, "chaincode.js")))
+	err = WriteFolderToTarPackage(tar.NewWriter(bytes.NewBuffer(nil)), srcPath, "", nil, nil)
+	assert.Contains(t, err.Error(), "no source files found")
+}
+
 func Test_WriteJavaProjectToPackage(t *testing.T) {
 	inputbuf := bytes.NewBuffer(nil)
 	gw := gzip.NewWriter(inputbuf)
@@ -0,0 +1,53 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package deliverclient
+
+import (
+	"github.com/hyperledger/fabric/common/channelconfig"
+	"github.com/hyperledger/fabric/common/deliver"
+	"github.com/hyperledger/fabric/gossip/api"
+	"github.com/hyperledger/fabric/gossip/common"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/pkg/errors"
+)
+
+// quorumVerifier is a MessageCryptoService which, in addition to the checks of the
+// MessageCryptoService it wraps, verifies that the blocks of a channel ordered by the
+// BFT consenter carry the signatures of a quorum of its consenters
+type quorumVerifier struct {
+	api.MessageCryptoService
+	channelConfig func() channelconfig.Resources
+}
+
+// NewQuorumVerifier returns a MessageCryptoService which rejects the blocks of a BFT channel
+// that are not signed by a quorum of its consenters, so that a peer cannot be fed a forged
+// block by a single orderer. The config of the channel is retrieved on every verification,
+// as it changes over time
+func NewQuorumVerifier(mcs api.MessageCryptoService, channelConfig func() channelconfig.Resources) api.MessageCryptoService {
+	return &quorumVerifier{MessageCryptoService: mcs, channelConfig: channelConfig}
+}
+
+// VerifyBlock returns nil if the block is properly signed, and carries the signatures
+// of a quorum of consenters if the channel is ordered by the BFT consenter
+func (v *quorumVerifier) VerifyBlock(chainID common.ChainID, seqNum uint64, signedBlock []byte) error {
+	if err := v.MessageCryptoService.VerifyBlock(chainID, seqNum, signedBlock); err != nil {
+		return err
+	}
+	block, err := utils.GetBlockFromBlockBytes(signedBlock)
+	if err != nil {
+		return errors.Wrapf(err, "failed unmarshaling block [%d] of channel %s", seqNum, chainID)
+	}
+	resources := v.channelConfig()
+	if resources == nil {
+		return errors.Errorf("no config for channel %s", chainID)
+	}
+	ordererConfig, ok := resources.OrdererConfig()
+	if !ok {
+		return errors.Errorf("no orderer config for channel %s", chainID)
+	}
+	return deliver.VerifyBlockQuorum(block, ordererConfig, resources.MSPManager())
+}
@@ -0,0 +1,129 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package deliverclient
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/channelconfig"
+	mockconfig "github.com/hyperledger/fabric/common/mocks/config"
+	mockmsp "github.com/hyperledger/fabric/common/mocks/msp"
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/gossip/api"
+	"github.com/hyperledger/fabric/gossip/common"
+	cb "github.com/hyperledger/fabric/protos/common"
+	bftpb "github.com/hyperledger/fabric/protos/orderer/bft"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingMCS struct {
+	mockMCS
+}
+
+func (*failingMCS) VerifyBlock(chainID common.ChainID, seqNum uint64, signedBlock []byte) error {
+	return errors.New("bad block signature")
+}
+
+func TestQuorumVerifier(t *testing.T) {
+	signers := mockmsp.NewSigners()
+	var consenters []*bftpb.Consenter
+	var identities []*mockmsp.SigningIdentity
+	for _, name := range []string{"orderer1", "orderer2", "orderer3", "orderer4"} {
+		id := signers.NewIdentity("OrdererOrg", name)
+		identities = append(identities, id)
+		consenters = append(consenters, &bftpb.Consenter{Host: name, Port: 7050, Identity: id.SerializeOrPanic()})
+	}
+	bftResources := &mockconfig.Resources{
+		OrdererConfigVal: &mockconfig.Orderer{
+			ConsensusTypeVal:     "bft",
+			ConsensusMetadataVal: utils.MarshalOrPanic(&bftpb.ConfigMetadata{Consenters: consenters}),
+		},
+		MSPManagerVal: signers,
+	}
+
+	signedBlock := func(signers ...*mockmsp.SigningIdentity) []byte {
+		block := cb.NewBlock(5, []byte("previous hash"))
+		md := &cb.Metadata{}
+		for _, signer := range signers {
+			shdr := utils.MarshalOrPanic(utils.NewSignatureHeaderOrPanic(signer))
+			md.Signatures = append(md.Signatures, &cb.MetadataSignature{
+				SignatureHeader: shdr,
+				Signature:       utils.SignOrPanic(signer, util.ConcatenateBytes(shdr, block.Header.Bytes())),
+			})
+		}
+		block.Metadata.Metadata[cb.BlockMetadataIndex_SIGNATURES] = utils.MarshalOrPanic(md)
+		return utils.MarshalOrPanic(block)
+	}
+
+	for _, test := range []struct {
+		name      string
+		mcs       api.MessageCryptoService
+		resources channelconfig.Resources
+		block     []byte
+		err       string
+	}{
+		{
+			name:      "quorum of signatures",
+			mcs:       &mockMCS{},
+			resources: bftResources,
+			block:     signedBlock(identities[0], identities[2], identities[3]),
+		},
+		{
+			name:      "single signature",
+			mcs:       &mockMCS{},
+			resources: bftResources,
+			block:     signedBlock(identities[1]),
+			err:       "block [5] carries valid signatures of 1 consenters, while a quorum of 3 out of 4 is required",
+		},
+		{
+			name:      "repeated signatures",
+			mcs:       &mockMCS{},
+			resources: bftResources,
+			block:     signedBlock(identities[1], identities[1], identities[1]),
+			err:       "block [5] carries valid signatures of 1 consenters, while a quorum of 3 out of 4 is required",
+		},
+		{
+			name:      "not a bft channel",
+			mcs:       &mockMCS{},
+			resources: &mockconfig.Resources{OrdererConfigVal: &mockconfig.Orderer{ConsensusTypeVal: "solo"}},
+			block:     signedBlock(),
+		},
+		{
+			name:      "bad block signature",
+			mcs:       &failingMCS{},
+			resources: bftResources,
+			block:     signedBlock(identities...),
+			err:       "bad block signature",
+		},
+		{
+			name:  "no channel config",
+			mcs:   &mockMCS{},
+			block: signedBlock(identities...),
+			err:   "no config for channel testchain",
+		},
+		{
+			name:      "no orderer config",
+			mcs:       &mockMCS{},
+			resources: &mockconfig.Resources{},
+			block:     signedBlock(identities...),
+			err:       "no orderer config for channel testchain",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			resources := test.resources
+			verifier := NewQuorumVerifier(test.mcs, func() channelconfig.Resources { return resources })
+			err := verifier.VerifyBlock(common.ChainID("testchain"), 5, test.block)
+			if test.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
@@ -8,9 +8,12 @@ package endorser
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/hyperledger/fabric/common/channelconfig"
 	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/metrics"
 	"github.com/hyperledger/fabric/common/resourcesconfig"
 	"github.com/hyperledger/fabric/common/util"
 	"github.com/hyperledger/fabric/core/chaincode"
@@ -94,13 +97,15 @@ type Support interface {
 type Endorser struct {
 	distributePrivateData privateDataDistributor
 	s                     Support
+	metrics               metrics.Scope
 }
 
 // NewEndorserServer creates and returns a new Endorser server instance.
 func NewEndorserServer(privDist privateDataDistributor, s Support) pb.EndorserServer {
 	e := &Endorser{
 		distributePrivateData: privDist,
-		s: s,
+		s:                     s,
+		metrics:               metrics.RootScope.SubScope("endorser"),
 	}
 	return e
 }
@@ -213,7 +218,8 @@ func (e *Endorser) disableJavaCCInst(cid *pb.ChaincodeID, cis *pb.ChaincodeInvoc
 	return nil
 }
 
-//simulate the proposal by calling the chaincode
+//simulate the proposal by calling the chaincode. The definition of the chaincode
+//is also returned along with the errors which occur once it has been looked up
 func (e *Endorser) simulateProposal(ctx context.Context, chainID string, txid string, signedProp *pb.SignedProposal, prop *pb.Proposal, cid *pb.ChaincodeID, txsim ledger.TxSimulator) (resourcesconfig.ChaincodeDefinition, *pb.Response, []byte, *pb.ChaincodeEvent, error) {
 	endorserLogger.Debugf("Entry - txid: %s channel id: %s", txid, chainID)
 	defer endorserLogger.Debugf("Exit")
@@ -242,7 +248,7 @@ func (e *Endorser) simulateProposal(ctx context.Context, chainID string, txid st
 
 		err = e.s.CheckInstantiationPolicy(cid.Name, version, cdLedger)
 		if err != nil {
-			return nil, nil, nil, nil, err
+			return cdLedger, nil, nil, nil, err
 		}
 	} else {
 		version = util.GetSysCCVersion()
@@ -256,25 +262,25 @@ func (e *Endorser) simulateProposal(ctx context.Context, chainID string, txid st
 	res, ccevent, err = e.callChaincode(ctx, chainID, version, txid, signedProp, prop, cis, cid, txsim)
 	if err != nil {
 		endorserLogger.Errorf("failed to invoke chaincode %s on transaction %s, error: %+v", cid, txid, err)
-		return nil, nil, nil, nil, err
+		return cdLedger, nil, nil, nil, err
 	}
 
 	if txsim != nil {
 		if simResult, err = txsim.GetTxSimulationResults(); err != nil {
-			return nil, nil, nil, nil, err
+			return cdLedger, nil, nil, nil, err
 		}
 
 		if simResult.PvtSimulationResults != nil {
 			if cid.Name == "lscc" {
 				// TODO: remove once we can store collection configuration outside of LSCC
-				return nil, nil, nil, nil, errors.New("Private data is forbidden to be used in instantiate")
+				return cdLedger, nil, nil, nil, errors.New("Private data is forbidden to be used in instantiate")
 			}
 			if err := e.distributePrivateData(chainID, txid, simResult.PvtSimulationResults); err != nil {
-				return nil, nil, nil, nil, err
+				return cdLedger, nil, nil, nil, err
 			}
 		}
 		if pubSimResBytes, err = simResult.GetPubSimulationBytes(); err != nil {
-			return nil, nil, nil, nil, err
+			return cdLedger, nil, nil, nil, err
 		}
 	}
 	return cdLedger, res, pubSimResBytes, ccevent, nil
@@ -370,8 +376,29 @@ func (e *Endorser) endorseProposal(ctx context.Context, chainID string, txid str
 	return pResp, nil
 }
 
+// unknownTag is the value of the metrics tags which would otherwise be taken
+// from a proposal whose channel or chaincode could not be resolved
+const unknownTag = "unknown"
+
 // ProcessProposal process the Proposal
-func (e *Endorser) ProcessProposal(ctx context.Context, signedProp *pb.SignedProposal) (*pb.ProposalResponse, error) {
+func (e *Endorser) ProcessProposal(ctx context.Context, signedProp *pb.SignedProposal) (resp *pb.ProposalResponse, err error) {
+	startTime := time.Now()
+	// the proposals are tagged with the channels of the peer and the deployed
+	// chaincodes only, so that the clients cannot create metrics at will
+	channelTag, chaincodeTag := unknownTag, unknownTag
+	defer func() {
+		// the outcome of the endorsement is carried by the status of the
+		// response, which may report a failure along with a nil error
+		success := resp != nil && resp.Response != nil && resp.Response.Status < shim.ERRORTHRESHOLD
+		scope := e.metrics.Tagged(map[string]string{
+			"channel":   channelTag,
+			"chaincode": chaincodeTag,
+			"success":   strconv.FormatBool(success),
+		})
+		scope.Counter("proposals_total").Inc(1)
+		scope.Histogram("proposal_duration").RecordDuration(time.Since(startTime))
+	}()
+
 	addr := util.ExtractRemoteAddress(ctx)
 	endorserLogger.Debug("Entering: Got request from", addr)
 	defer endorserLogger.Debugf("Exit: request from", addr)
@@ -380,7 +407,6 @@ func (e *Endorser) ProcessProposal(ctx context.Context, signedProp *pb.SignedPro
 	if err != nil {
 		return &pb.ProposalResponse{Response: &pb.Response{Status: 500, Message: err.Error()}}, err
 	}
-
 	chdr, err := putils.UnmarshalChannelHeader(hdr.ChannelHeader)
 	if err != nil {
 		return &pb.ProposalResponse{Response: &pb.Response{Status: 500, Message: err.Error()}}, err
@@ -450,6 +476,8 @@ func (e *Endorser) ProcessProposal(ctx context.Context, signedProp *pb.SignedPro
 
 		defer txsim.Done()
 	}
+	// the channel has a ledger on this peer, unless the proposal is chainless
+	channelTag = chainID
 	//this could be a request to a chainless SysCC
 
 	// TODO: if the proposal has an extension, it will be of type ChaincodeAction;
@@ -459,6 +487,9 @@ func (e *Endorser) ProcessProposal(ctx context.Context, signedProp *pb.SignedPro
 
 	//1 -- simulate
 	cd, res, simulationResult, ccevent, err := e.simulateProposal(ctx, chainID, txid, signedProp, prop, hdrExt.ChaincodeId, txsim)
+	if cd != nil || e.s.IsSysCC(hdrExt.ChaincodeId.Name) {
+		chaincodeTag = hdrExt.ChaincodeId.Name
+	}
 	if err != nil {
 		return &pb.ProposalResponse{Response: &pb.Response{Status: 500, Message: err.Error()}}, err
 	}
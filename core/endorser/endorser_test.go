@@ -13,6 +13,7 @@ import (
 	"testing"
 
 	mc "github.com/hyperledger/fabric/common/mocks/config"
+	mockmetrics "github.com/hyperledger/fabric/common/mocks/metrics"
 	"github.com/hyperledger/fabric/common/mocks/resourcesconfig"
 	"github.com/hyperledger/fabric/common/util"
 	"github.com/hyperledger/fabric/core/ledger"
@@ -179,6 +180,46 @@ func TestEndorserCCInvocationError(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestEndorserMetrics(t *testing.T) {
+	support := &em.MockSupport{
+		GetApplicationConfigBoolRv: true,
+		GetApplicationConfigRv:     &mc.MockApplication{&mc.MockApplicationCapabilities{}},
+		GetTransactionByIDErr:      errors.New(""),
+		IsSysCCRv:                  true,
+		ChaincodeDefinitionRv:      &resourceconfig.MockChaincodeDefinition{EndorsementStr: "ESCC"},
+		ExecuteResp:                &pb.Response{Status: 200, Payload: utils.MarshalOrPanic(&pb.ProposalResponse{Response: &pb.Response{}})},
+		GetTxSimulatorRv:           &ccprovider.MockTxSim{&ledger.TxSimulationResults{PubSimulationResults: &rwset.TxReadWriteSet{}}},
+	}
+	es := NewEndorserServer(func(channel string, txID string, privateData *rwset.TxPvtReadWriteSet) error {
+		return nil
+	}, support)
+	scope := mockmetrics.NewScope()
+	es.(*Endorser).metrics = scope
+
+	_, err := es.ProcessProposal(context.Background(), getSignedProp("ccid", "0", t))
+	assert.NoError(t, err)
+	support.ExecuteError = errors.New("")
+	_, err = es.ProcessProposal(context.Background(), getSignedProp("ccid", "0", t))
+	assert.Error(t, err)
+	_, err = es.ProcessProposal(context.Background(), nil)
+	assert.Error(t, err)
+	// the chaincode is not tagged unless it is deployed
+	support.IsSysCCRv = false
+	support.ChaincodeDefinitionError = errors.New("not found")
+	_, err = es.ProcessProposal(context.Background(), getSignedProp("notdeployed", "0", t))
+	assert.Error(t, err)
+
+	successTags := map[string]string{"channel": util.GetTestChainID(), "chaincode": "ccid", "success": "true"}
+	failureTags := map[string]string{"channel": util.GetTestChainID(), "chaincode": "ccid", "success": "false"}
+	invalidTags := map[string]string{"channel": "unknown", "chaincode": "unknown", "success": "false"}
+	notDeployedTags := map[string]string{"channel": util.GetTestChainID(), "chaincode": "unknown", "success": "false"}
+	assert.Equal(t, int64(1), scope.CounterValue("proposals_total", successTags))
+	assert.Equal(t, int64(1), scope.CounterValue("proposals_total", failureTags))
+	assert.Equal(t, int64(1), scope.CounterValue("proposals_total", invalidTags))
+	assert.Equal(t, int64(1), scope.CounterValue("proposals_total", notDeployedTags))
+	assert.Len(t, scope.HistogramValues("proposal_duration", successTags), 1)
+}
+
 func TestEndorserLSCCBadType(t *testing.T) {
 	es := NewEndorserServer(func(channel string, txID string, privateData *rwset.TxPvtReadWriteSet) error {
 		return nil
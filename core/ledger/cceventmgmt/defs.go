@@ -0,0 +1,96 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cceventmgmt
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/common/ccprovider"
+	"github.com/hyperledger/fabric/core/common/sysccprovider"
+)
+
+// ChaincodeDefinition captures the info about chaincode
+type ChaincodeDefinition struct {
+	Name    string
+	Hash    []byte
+	Version string
+}
+
+func (cdef *ChaincodeDefinition) String() string {
+	return fmt.Sprintf("Name=%s, Version=%s, Hash=%#v", cdef.Name, cdef.Version, cdef.Hash)
+}
+
+// ChaincodeLifecycleEventListener interface enables ledger components (mainly, intended for statedb)
+// to be able to listen to chaincode lifecycle events. 'dbArtifactsTar' represents db specific artifacts
+// (such as index specs) packaged in a tar
+type ChaincodeLifecycleEventListener interface {
+	// HandleChaincodeDeploy is expected to create all the necessary db specific artifacts (such as indexes).
+	// It is invoked when a chaincode is deployed (instantiated or upgraded) on a channel, and when a
+	// chaincode which is already deployed on the channel is installed on the peer
+	HandleChaincodeDeploy(chaincodeDefinition *ChaincodeDefinition, dbArtifactsTar []byte) error
+}
+
+// ChaincodeInfoProvider interface enables event mgr to retrieve chaincode info for a given chaincode
+type ChaincodeInfoProvider interface {
+	// IsChaincodeDeployed returns true if the given chaincode is deployed on the given channel
+	IsChaincodeDeployed(chainid string, chaincodeDefinition *ChaincodeDefinition) (bool, error)
+	// RetrieveChaincodeArtifacts checks if the given chaincode is installed on the peer and if yes,
+	// it extracts the state db specific artifacts from the chaincode package tarball
+	RetrieveChaincodeArtifacts(chaincodeDefinition *ChaincodeDefinition) (installed bool, dbArtifactsTar []byte, err error)
+}
+
+// chaincodeInfoProviderImpl implements ChaincodeInfoProvider by looking up the chaincode
+// data that lscc maintains in the state of the channel, and the chaincode packages
+// that are installed on the file system of the peer
+type chaincodeInfoProviderImpl struct {
+}
+
+// IsChaincodeDeployed implements function in the interface ChaincodeInfoProvider
+func (p *chaincodeInfoProviderImpl) IsChaincodeDeployed(chainid string, chaincodeDefinition *ChaincodeDefinition) (bool, error) {
+	qe, err := sysccprovider.GetSystemChaincodeProvider().GetQueryExecutorForLedger(chainid)
+	if err != nil {
+		return false, err
+	}
+	defer qe.Done()
+	chaincodeDataBytes, err := qe.GetState(lsccNamespace, chaincodeDefinition.Name)
+	if err != nil || chaincodeDataBytes == nil {
+		return false, err
+	}
+	chaincodeData := &ccprovider.ChaincodeData{}
+	if err := proto.Unmarshal(chaincodeDataBytes, chaincodeData); err != nil {
+		return false, fmt.Errorf("error unmarshalling chaincode data of chaincode %s: %s", chaincodeDefinition.Name, err)
+	}
+	return chaincodeData.Version == chaincodeDefinition.Version && bytes.Equal(chaincodeData.Id, chaincodeDefinition.Hash), nil
+}
+
+// RetrieveChaincodeArtifacts implements function in the interface ChaincodeInfoProvider
+func (p *chaincodeInfoProviderImpl) RetrieveChaincodeArtifacts(chaincodeDefinition *ChaincodeDefinition) (bool, []byte, error) {
+	exists, err := ccprovider.ChaincodePackageExists(chaincodeDefinition.Name, chaincodeDefinition.Version)
+	if err != nil && !os.IsNotExist(err) {
+		return false, nil, err
+	}
+	if !exists {
+		return false, nil, nil
+	}
+	ccpackage, err := ccprovider.GetChaincodeFromFS(chaincodeDefinition.Name, chaincodeDefinition.Version)
+	if err != nil {
+		return false, nil, err
+	}
+	// a package with the same name and version but a different code is not the deployed chaincode
+	if !bytes.Equal(ccpackage.GetId(), chaincodeDefinition.Hash) {
+		logger.Warningf("The chaincode [%s] installed on the peer does not match the deployed one", chaincodeDefinition)
+		return false, nil, nil
+	}
+	dbArtifactsTar, err := ccprovider.ExtractStatedbArtifactsFromCCPackage(ccpackage)
+	if err != nil {
+		return false, nil, err
+	}
+	return true, dbArtifactsTar, nil
+}
@@ -0,0 +1,65 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cceventmgmt
+
+import (
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/common/ccprovider"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/pkg/errors"
+)
+
+const (
+	lsccNamespace = "lscc"
+	// collectionKeySeparator is used by lscc for building the keys that hold the
+	// collection configs of the chaincodes (see privdata.BuildCollectionKVSKey)
+	collectionKeySeparator = "~"
+)
+
+// KVLedgerLSCCStateListener listens for state changes on 'lscc' namespace
+type KVLedgerLSCCStateListener struct {
+}
+
+// InterestedInNamespaces implements function from interface `ledger.StateListener`
+func (listener *KVLedgerLSCCStateListener) InterestedInNamespaces() []string {
+	return []string{lsccNamespace}
+}
+
+// HandleStateUpdates implements function from interface `ledger.StateListener`
+// Each write in the 'lscc' namespace (other than a collection config) represents the
+// deployment (instantiate or upgrade) of a chaincode
+func (listener *KVLedgerLSCCStateListener) HandleStateUpdates(trigger *ledger.StateUpdateTrigger) error {
+	channelName := trigger.LedgerID
+	kvStateUpdates, ok := trigger.StateUpdates[lsccNamespace]
+	if !ok {
+		return nil
+	}
+	logger.Debugf("Channel [%s]: Handling state updates in LSCC namespace - stateUpdates=%#v", channelName, kvStateUpdates.PublicUpdates)
+	var chaincodeDefs []*ChaincodeDefinition
+	for _, kvWrite := range kvStateUpdates.PublicUpdates {
+		if kvWrite.IsDelete || strings.Contains(kvWrite.Key, collectionKeySeparator) {
+			continue
+		}
+		chaincodeData := &ccprovider.ChaincodeData{}
+		if err := proto.Unmarshal(kvWrite.Value, chaincodeData); err != nil {
+			return errors.Wrapf(err, "error unmarshalling chaincode data of key [%s] in lscc namespace", kvWrite.Key)
+		}
+		chaincodeDefs = append(chaincodeDefs,
+			&ChaincodeDefinition{Name: chaincodeData.CCName(), Version: chaincodeData.CCVersion(), Hash: chaincodeData.Hash()})
+	}
+	if len(chaincodeDefs) == 0 {
+		return nil
+	}
+	return GetMgr().HandleChaincodeDeploy(channelName, chaincodeDefs)
+}
+
+// StateCommitDone implements function from interface `ledger.StateListener`
+func (listener *KVLedgerLSCCStateListener) StateCommitDone(channelName string) {
+	// Noop
+}
@@ -0,0 +1,107 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cceventmgmt
+
+import (
+	"sync"
+
+	"github.com/hyperledger/fabric/common/flogging"
+)
+
+var logger = flogging.MustGetLogger("cceventmgmt")
+
+var mgr = newMgr(&chaincodeInfoProviderImpl{})
+
+// GetMgr returns the reference to singleton event manager
+func GetMgr() *Mgr {
+	return mgr
+}
+
+// initialize replaces the singleton event manager with the one that uses the given
+// info provider. This is intended to be used by the tests only
+func initialize(infoProvider ChaincodeInfoProvider) {
+	mgr = newMgr(infoProvider)
+}
+
+// Mgr encapsulate important interactions for events related to the interest of ledger
+type Mgr struct {
+	rwlock               sync.RWMutex
+	infoProvider         ChaincodeInfoProvider
+	ccLifecycleListeners map[string]ChaincodeLifecycleEventListener
+}
+
+func newMgr(chaincodeInfoProvider ChaincodeInfoProvider) *Mgr {
+	return &Mgr{
+		infoProvider:         chaincodeInfoProvider,
+		ccLifecycleListeners: make(map[string]ChaincodeLifecycleEventListener),
+	}
+}
+
+// Register registers a ChaincodeLifecycleEventListener for given ledgerid
+// Since, `Register` is expected to be invoked when creating/opening a ledger instance
+func (m *Mgr) Register(ledgerid string, l ChaincodeLifecycleEventListener) {
+	m.rwlock.Lock()
+	defer m.rwlock.Unlock()
+	m.ccLifecycleListeners[ledgerid] = l
+}
+
+// Deregister removes the ChaincodeLifecycleEventListener registered for the given ledgerid.
+// `Deregister` is expected to be invoked when closing a ledger instance
+func (m *Mgr) Deregister(ledgerid string) {
+	m.rwlock.Lock()
+	defer m.rwlock.Unlock()
+	delete(m.ccLifecycleListeners, ledgerid)
+}
+
+// HandleChaincodeDeploy is expected to be invoked when a chaincode is deployed via a deploy transaction.
+// The chaincodes whose packages are not installed on the peer are skipped; their artifacts are
+// created when they get installed (see function `HandleChaincodeInstall`)
+func (m *Mgr) HandleChaincodeDeploy(chainid string, chaincodeDefinitions []*ChaincodeDefinition) error {
+	m.rwlock.RLock()
+	defer m.rwlock.RUnlock()
+	listener, ok := m.ccLifecycleListeners[chainid]
+	if !ok {
+		return nil
+	}
+	for _, chaincodeDefinition := range chaincodeDefinitions {
+		installed, dbArtifacts, err := m.infoProvider.RetrieveChaincodeArtifacts(chaincodeDefinition)
+		if err != nil {
+			return err
+		}
+		if !installed {
+			logger.Infof("Chaincode [%s] is not installed, hence no need to create chaincode artifacts for endorsement on channel [%s]",
+				chaincodeDefinition, chainid)
+			continue
+		}
+		logger.Debugf("Invoking listener for chaincode [%s] deployed on channel [%s]", chaincodeDefinition, chainid)
+		if err := listener.HandleChaincodeDeploy(chaincodeDefinition, dbArtifacts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleChaincodeInstall is expected to get invoked during installation of a chaincode package.
+// The listeners of the channels on which the chaincode is already deployed are invoked
+func (m *Mgr) HandleChaincodeInstall(chaincodeDefinition *ChaincodeDefinition, dbArtifacts []byte) error {
+	m.rwlock.RLock()
+	defer m.rwlock.RUnlock()
+	for chainid, listener := range m.ccLifecycleListeners {
+		deployed, err := m.infoProvider.IsChaincodeDeployed(chainid, chaincodeDefinition)
+		if err != nil {
+			return err
+		}
+		if !deployed {
+			continue
+		}
+		logger.Debugf("Invoking listener for chaincode [%s] installed after being deployed on channel [%s]", chaincodeDefinition, chainid)
+		if err := listener.HandleChaincodeDeploy(chaincodeDefinition, dbArtifacts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
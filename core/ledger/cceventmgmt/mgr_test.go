@@ -0,0 +1,207 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package cceventmgmt
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/core/common/ccprovider"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/protos/ledger/rwset/kvrwset"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	flogging.SetModuleLevel("cceventmgmt", "DEBUG")
+	os.Exit(m.Run())
+}
+
+func TestCCEventMgmt(t *testing.T) {
+	cc1Def := &ChaincodeDefinition{Name: "cc1", Version: "v1", Hash: []byte("cc1")}
+	cc1DBArtifactsTar := []byte("cc1DBArtifacts")
+
+	cc2Def := &ChaincodeDefinition{Name: "cc2", Version: "v1", Hash: []byte("cc2")}
+	cc2DBArtifactsTar := []byte("cc2DBArtifacts")
+
+	cc3Def := &ChaincodeDefinition{Name: "cc3", Version: "v1", Hash: []byte("cc3")}
+	cc3DBArtifactsTar := []byte("cc3DBArtifacts")
+
+	// cc1 is deployed and installed. cc2 is deployed but not installed. cc3 is not deployed but installed
+	mockProvider := newMockProvider()
+	mockProvider.setChaincodeInstalled(cc1Def, cc1DBArtifactsTar)
+	mockProvider.setChaincodeDeployed("channel1", cc1Def)
+	mockProvider.setChaincodeDeployed("channel1", cc2Def)
+	mockProvider.setChaincodeInstalled(cc3Def, cc3DBArtifactsTar)
+	initialize(mockProvider)
+	defer initialize(&chaincodeInfoProviderImpl{})
+	eventMgr := GetMgr()
+	assert.NotNil(t, eventMgr)
+
+	handler1, handler2 := &mockHandler{}, &mockHandler{}
+	eventMgr.Register("channel1", handler1)
+	eventMgr.Register("channel2", handler2)
+
+	// Deploy cc3 on chain1 - handler1 should receive event because cc3 is being deployed only on chain1
+	eventMgr.HandleChaincodeDeploy("channel1", []*ChaincodeDefinition{cc3Def})
+	assert.Contains(t, handler1.eventsRecieved, &mockEvent{cc3Def, cc3DBArtifactsTar})
+	assert.NotContains(t, handler2.eventsRecieved, &mockEvent{cc3Def, cc3DBArtifactsTar})
+
+	// Deploy cc3 on chain2 as well and this time handler2 should also receive event
+	eventMgr.HandleChaincodeDeploy("channel2", []*ChaincodeDefinition{cc3Def})
+	assert.Contains(t, handler2.eventsRecieved, &mockEvent{cc3Def, cc3DBArtifactsTar})
+
+	// Deploy cc2 on chain1 - no handler should receive event because cc2 is not installed
+	eventMgr.HandleChaincodeDeploy("channel1", []*ChaincodeDefinition{cc2Def})
+	assert.NotContains(t, handler1.eventsRecieved, &mockEvent{cc2Def, cc2DBArtifactsTar})
+	assert.NotContains(t, handler2.eventsRecieved, &mockEvent{cc2Def, cc2DBArtifactsTar})
+
+	// Install cc2 - handler1 should receive event because cc2 is deployed on chain1 and not on chain2
+	eventMgr.HandleChaincodeInstall(cc2Def, cc2DBArtifactsTar)
+	assert.Contains(t, handler1.eventsRecieved, &mockEvent{cc2Def, cc2DBArtifactsTar})
+	assert.NotContains(t, handler2.eventsRecieved, &mockEvent{cc2Def, cc2DBArtifactsTar})
+
+	// A deregistered handler should not receive any more events
+	eventMgr.Deregister("channel1")
+	handler1.eventsRecieved = nil
+	eventMgr.HandleChaincodeDeploy("channel1", []*ChaincodeDefinition{cc1Def})
+	assert.Empty(t, handler1.eventsRecieved)
+}
+
+func TestCCEventMgmtErrors(t *testing.T) {
+	cc1Def := &ChaincodeDefinition{Name: "cc1", Version: "v1", Hash: []byte("cc1")}
+	mockProvider := newMockProvider()
+	mockProvider.setChaincodeInstalled(cc1Def, []byte("cc1DBArtifacts"))
+	mockProvider.setChaincodeDeployed("channel1", cc1Def)
+	initialize(mockProvider)
+	defer initialize(&chaincodeInfoProviderImpl{})
+	eventMgr := GetMgr()
+	eventMgr.Register("channel1", &mockHandler{err: errors.New("handler error")})
+
+	assert.EqualError(t, eventMgr.HandleChaincodeDeploy("channel1", []*ChaincodeDefinition{cc1Def}), "handler error")
+	assert.EqualError(t, eventMgr.HandleChaincodeInstall(cc1Def, nil), "handler error")
+
+	mockProvider.err = errors.New("provider error")
+	assert.EqualError(t, eventMgr.HandleChaincodeDeploy("channel1", []*ChaincodeDefinition{cc1Def}), "provider error")
+	assert.EqualError(t, eventMgr.HandleChaincodeInstall(cc1Def, nil), "provider error")
+}
+
+func TestRetrieveChaincodeArtifactsErrors(t *testing.T) {
+	installPath, err := ioutil.TempDir("", "cceventmgmt")
+	assert.NoError(t, err)
+	defer os.RemoveAll(installPath)
+	ccprovider.SetChaincodesPath(installPath)
+	cc1Def := &ChaincodeDefinition{Name: "cc1", Version: "v1", Hash: []byte("cc1")}
+	provider := &chaincodeInfoProviderImpl{}
+
+	// a chaincode which is not installed has no artifacts
+	installed, _, err := provider.RetrieveChaincodeArtifacts(cc1Def)
+	assert.NoError(t, err)
+	assert.False(t, installed)
+
+	// failing to look up the chaincode package is an error rather than a missing chaincode
+	assert.NoError(t, os.RemoveAll(installPath))
+	assert.NoError(t, ioutil.WriteFile(installPath, nil, 0644))
+	_, _, err = provider.RetrieveChaincodeArtifacts(cc1Def)
+	assert.Error(t, err)
+}
+
+func TestLSCCListener(t *testing.T) {
+	channelName := "testChannel"
+	cc1Def := &ChaincodeDefinition{Name: "testChaincode", Version: "v1", Hash: []byte("hash_testChaincode")}
+	cc1DBArtifactsTar := []byte("cc1DBArtifacts")
+	mockProvider := newMockProvider()
+	mockProvider.setChaincodeInstalled(cc1Def, cc1DBArtifactsTar)
+	initialize(mockProvider)
+	defer initialize(&chaincodeInfoProviderImpl{})
+	handler := &mockHandler{}
+	GetMgr().Register(channelName, handler)
+	defer GetMgr().Deregister(channelName)
+
+	lsccStateListener := &KVLedgerLSCCStateListener{}
+	assert.Equal(t, []string{"lscc"}, lsccStateListener.InterestedInNamespaces())
+
+	// the collection configs and the deletes in lscc namespace are ignored
+	ccData := &ccprovider.ChaincodeData{Name: cc1Def.Name, Version: cc1Def.Version, Id: cc1Def.Hash}
+	ccDataBytes, err := proto.Marshal(ccData)
+	assert.NoError(t, err)
+	trigger := &ledger.StateUpdateTrigger{
+		LedgerID: channelName,
+		StateUpdates: ledger.StateUpdates{
+			lsccNamespace: {
+				PublicUpdates: []*kvrwset.KVWrite{
+					{Key: cc1Def.Name, Value: ccDataBytes},
+					{Key: cc1Def.Name + "~collection", Value: []byte("collection config")},
+					{Key: "deletedChaincode", IsDelete: true},
+				},
+			},
+		},
+	}
+	assert.NoError(t, lsccStateListener.HandleStateUpdates(trigger))
+	assert.Equal(t, []*mockEvent{{cc1Def, cc1DBArtifactsTar}}, handler.eventsRecieved)
+
+	// a malformed chaincode data fails the handling of the updates
+	trigger.StateUpdates = ledger.StateUpdates{
+		lsccNamespace: {PublicUpdates: []*kvrwset.KVWrite{{Key: cc1Def.Name, Value: []byte("malformed chaincode data")}}},
+	}
+	err = lsccStateListener.HandleStateUpdates(trigger)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "error unmarshalling chaincode data of key [testChaincode]")
+}
+
+type mockProvider struct {
+	chaincodesDeployed  map[[3]string]bool
+	chaincodesInstalled map[[3]string][]byte
+	err                 error
+}
+
+type mockHandler struct {
+	eventsRecieved []*mockEvent
+	err            error
+}
+
+type mockEvent struct {
+	chaincodeDefinition *ChaincodeDefinition
+	dbArtifactsTar      []byte
+}
+
+func (l *mockHandler) HandleChaincodeDeploy(chaincodeDefinition *ChaincodeDefinition, dbArtifactsTar []byte) error {
+	if l.err != nil {
+		return l.err
+	}
+	l.eventsRecieved = append(l.eventsRecieved, &mockEvent{chaincodeDefinition, dbArtifactsTar})
+	return nil
+}
+
+func newMockProvider() *mockProvider {
+	return &mockProvider{
+		make(map[[3]string]bool),
+		make(map[[3]string][]byte),
+		nil,
+	}
+}
+
+func (p *mockProvider) setChaincodeDeployed(chainid string, chaincodeDefinition *ChaincodeDefinition) {
+	p.chaincodesDeployed[[3]string{chainid, chaincodeDefinition.Name, chaincodeDefinition.Version}] = true
+}
+
+func (p *mockProvider) setChaincodeInstalled(chaincodeDefinition *ChaincodeDefinition, dbArtifactsTar []byte) {
+	p.chaincodesInstalled[[3]string{chaincodeDefinition.Name, chaincodeDefinition.Version, string(chaincodeDefinition.Hash)}] = dbArtifactsTar
+}
+
+func (p *mockProvider) IsChaincodeDeployed(chainid string, chaincodeDefinition *ChaincodeDefinition) (bool, error) {
+	return p.chaincodesDeployed[[3]string{chainid, chaincodeDefinition.Name, chaincodeDefinition.Version}], p.err
+}
+
+func (p *mockProvider) RetrieveChaincodeArtifacts(chaincodeDefinition *ChaincodeDefinition) (installed bool, dbArtifactsTar []byte, err error) {
+	dbArtifactsTar, ok := p.chaincodesInstalled[[3]string{chaincodeDefinition.Name, chaincodeDefinition.Version, string(chaincodeDefinition.Hash)}]
+	return ok, dbArtifactsTar, p.err
+}
@@ -0,0 +1,41 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bookkeeping
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	flogging.SetModuleLevel("leveldbhelper", "debug")
+	viper.Set("peer.fileSystemPath", "/tmp/fabric/ledgertests/kvledger/bookkeeping")
+	os.Exit(m.Run())
+}
+
+func TestProvider(t *testing.T) {
+	testEnv := NewTestEnv(t)
+	defer testEnv.Cleanup()
+	p := testEnv.TestProvider
+	db1 := p.GetDBHandle("TestLedger1", PvtdataExpiry)
+	db2 := p.GetDBHandle("TestLedger2", PvtdataExpiry)
+
+	assert.NoError(t, db1.Put([]byte("key"), []byte("value1"), true))
+	assert.NoError(t, db2.Put([]byte("key"), []byte("value2"), true))
+
+	val, err := db1.Get([]byte("key"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value1"), val)
+
+	val, err = db2.Get([]byte("key"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value2"), val)
+}
@@ -0,0 +1,41 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bookkeeping
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+)
+
+// TestEnv provides the bookkeeper provider env for testing
+type TestEnv struct {
+	t            testing.TB
+	TestProvider Provider
+}
+
+// NewTestEnv construct a TestEnv for testing
+func NewTestEnv(t testing.TB) *TestEnv {
+	removePath(t)
+	provider := NewProvider()
+	return &TestEnv{t, provider}
+}
+
+// Cleanup cleansup the  store env after testing
+func (env *TestEnv) Cleanup() {
+	env.TestProvider.Close()
+	removePath(env.t)
+}
+
+func removePath(t testing.TB) {
+	dbPath := ledgerconfig.GetInternalBookkeeperPath()
+	if err := os.RemoveAll(dbPath); err != nil {
+		t.Fatalf("Err: %s", err)
+		t.FailNow()
+	}
+}
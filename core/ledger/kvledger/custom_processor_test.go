@@ -41,7 +41,15 @@ func (ctp *customTxProcessor) GenerateSimulationResults(txEnvelop *common.Envelo
 }
 
 func TestCustomProcessor(t *testing.T) {
-	env := newTestEnv(t)
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			testCustomProcessor(t, providerType)
+		})
+	}
+}
+
+func testCustomProcessor(t *testing.T, providerType testProviderType) {
+	env := newTestEnv(t, providerType)
 	defer env.cleanup()
 	provider, _ := NewProvider()
 	defer provider.Close()
@@ -42,9 +42,18 @@ type HistoryDBProvider struct {
 
 // NewHistoryDBProvider instantiates HistoryDBProvider
 func NewHistoryDBProvider() *HistoryDBProvider {
+	return newHistoryDBProvider(false)
+}
+
+// NewInMemoryHistoryDBProvider instantiates HistoryDBProvider that keeps the history in the memory
+func NewInMemoryHistoryDBProvider() *HistoryDBProvider {
+	return newHistoryDBProvider(true)
+}
+
+func newHistoryDBProvider(inMemory bool) *HistoryDBProvider {
 	dbPath := ledgerconfig.GetHistoryLevelDBPath()
-	logger.Debugf("constructing HistoryDBProvider dbPath=%s", dbPath)
-	dbProvider := leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath})
+	logger.Debugf("constructing HistoryDBProvider dbPath=%s, inMemory=%t", dbPath, inMemory)
+	dbProvider := leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath, InMemory: inMemory})
 	return &HistoryDBProvider{dbProvider}
 }
 
@@ -53,6 +62,11 @@ func (provider *HistoryDBProvider) GetDBHandle(dbName string) (historydb.History
 	return newHistoryDB(provider.dbProvider.GetDBHandle(dbName), dbName), nil
 }
 
+// Drop removes all the history of the named db
+func (provider *HistoryDBProvider) Drop(dbName string) error {
+	return provider.dbProvider.GetDBHandle(dbName).DeleteAll()
+}
+
 // Close closes the underlying db
 func (provider *HistoryDBProvider) Close() {
 	provider.dbProvider.Close()
@@ -193,6 +207,14 @@ func (historyDB *historyDB) GetLastSavepoint() (*version.Height, error) {
 	return height, nil
 }
 
+// RecordSavepoint implements method in HistoryDB interface. This is used for setting the savepoint
+// of an empty history db, for instance, when a ledger is created from a snapshot
+func (historyDB *historyDB) RecordSavepoint(height *version.Height) error {
+	dbBatch := leveldbhelper.NewUpdateBatch()
+	dbBatch.Put(savePointKey, height.ToBytes())
+	return historyDB.db.WriteBatch(dbBatch, true)
+}
+
 // ShouldRecover implements method in interface kvledger.Recoverer
 func (historyDB *historyDB) ShouldRecover(lastAvailableBlock uint64) (bool, uint64, error) {
 	if !ledgerconfig.IsHistoryDBEnabled() {
@@ -17,17 +17,24 @@ limitations under the License.
 package historyleveldb
 
 import (
-	"errors"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
 
+	"github.com/golang/protobuf/ptypes/timestamp"
 	commonledger "github.com/hyperledger/fabric/common/ledger"
 	"github.com/hyperledger/fabric/common/ledger/blkstorage"
 	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/history/historydb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwsetutil"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
 	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
 	"github.com/hyperledger/fabric/protos/common"
 	"github.com/hyperledger/fabric/protos/ledger/queryresult"
 	putils "github.com/hyperledger/fabric/protos/utils"
+	"github.com/pkg/errors"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
 )
 
@@ -39,68 +46,224 @@ type LevelHistoryDBQueryExecutor struct {
 
 // GetHistoryForKey implements method in interface `ledger.HistoryQueryExecutor`
 func (q *LevelHistoryDBQueryExecutor) GetHistoryForKey(namespace string, key string) (commonledger.ResultsIterator, error) {
+	return q.GetHistoryForKeyWithOptions(namespace, key, &ledger.HistoryQueryOptions{})
+}
+
+// GetHistoryForKeyWithOptions implements method in interface `ledger.HistoryQueryExecutor`
+func (q *LevelHistoryDBQueryExecutor) GetHistoryForKeyWithOptions(namespace string, key string,
+	options *ledger.HistoryQueryOptions) (ledger.QueryResultsIterator, error) {
 
 	if ledgerconfig.IsHistoryDBEnabled() == false {
 		return nil, errors.New("History tracking not enabled - historyDatabase is false")
 	}
+	if options.PageSize < 0 {
+		return nil, errors.Errorf("invalid page size [%d], it must not be negative", options.PageSize)
+	}
+	if options.EndBlock != 0 && options.EndBlock <= options.StartBlock {
+		return nil, errors.Errorf("invalid block range [%d, %d)", options.StartBlock, options.EndBlock)
+	}
+	if (options.StartTime != nil || options.EndTime != nil) && options.PageSize == 0 {
+		return nil, errors.New("a history query bounded in time must be paginated")
+	}
 
-	var compositeStartKey []byte
-	var compositeEndKey []byte
-	compositeStartKey = historydb.ConstructPartialCompositeHistoryKey(namespace, key, false)
-	compositeEndKey = historydb.ConstructPartialCompositeHistoryKey(namespace, key, true)
+	compositePartialKey := historydb.ConstructPartialCompositeHistoryKey(namespace, key, false)
+
+	// the history records are ordered by the height of the transactions, hence the block range
+	// translates into a range scan of namespace~key~blocknum~trannum
+	compositeStartKey := append(append([]byte{}, compositePartialKey...), util.EncodeOrderPreservingVarUint64(options.StartBlock)...)
+	compositeEndKey := historydb.ConstructPartialCompositeHistoryKey(namespace, key, true)
+	if options.EndBlock != 0 {
+		compositeEndKey = append(append([]byte{}, compositePartialKey...), util.EncodeOrderPreservingVarUint64(options.EndBlock)...)
+	}
+
+	// the bookmark is the height of the first record of the page to be retrieved, which is the
+	// start of the range for an ascending scan and the (inclusive) end of the range for a descending scan
+	if options.Bookmark != "" {
+		height, err := decodeBookmark(options.Bookmark)
+		if err != nil {
+			return nil, err
+		}
+		bookmarkKey := append(append([]byte{}, compositePartialKey...), height.ToBytes()...)
+		if options.Descending {
+			if bytes.Compare(bookmarkKey, compositeEndKey) < 0 {
+				compositeEndKey = append(bookmarkKey, 0x00)
+			}
+		} else if bytes.Compare(bookmarkKey, compositeStartKey) > 0 {
+			compositeStartKey = bookmarkKey
+		}
+	}
 
-	// range scan to find any history records starting with namespace~key
 	dbItr := q.historyDB.db.GetIterator(compositeStartKey, compositeEndKey)
-	return newHistoryScanner(compositeStartKey, namespace, key, dbItr, q.blockStore), nil
+	return newHistoryScanner(compositePartialKey, namespace, key, dbItr, q.blockStore, options), nil
 }
 
-//historyScanner implements ResultsIterator for iterating through history results
+// historyScanner implements ResultsIterator for iterating through history results. The scanner skips
+// the records of the transactions whose timestamps are out of the requested range, and if the pageSize
+// is greater than zero, stops after returning pageSize results. Since the timestamp of each record has to
+// be retrieved from the block store, the scanner also stops after skipping maxSkipped records, in which
+// case the page holds fewer than pageSize results and its bookmark resumes the scan where it stopped
 type historyScanner struct {
 	compositePartialKey []byte //compositePartialKey includes namespace~key
 	namespace           string
 	key                 string
 	dbItr               iterator.Iterator
 	blockStore          blkstorage.BlockStore
+	startTime           *timestamp.Timestamp
+	endTime             *timestamp.Timestamp
+	descending          bool
+	started             bool
+	pageSize            int32
+	fetched             int32
+	maxSkipped          int
+	skipped             int
+	resumeHeight        *version.Height
 }
 
 func newHistoryScanner(compositePartialKey []byte, namespace string, key string,
-	dbItr iterator.Iterator, blockStore blkstorage.BlockStore) *historyScanner {
-	return &historyScanner{compositePartialKey, namespace, key, dbItr, blockStore}
+	dbItr iterator.Iterator, blockStore blkstorage.BlockStore, options *ledger.HistoryQueryOptions) *historyScanner {
+	return &historyScanner{
+		compositePartialKey: compositePartialKey,
+		namespace:           namespace,
+		key:                 key,
+		dbItr:               dbItr,
+		blockStore:          blockStore,
+		startTime:           options.StartTime,
+		endTime:             options.EndTime,
+		descending:          options.Descending,
+		pageSize:            options.PageSize,
+		maxSkipped:          ledgerconfig.GetQueryLimit(),
+	}
 }
 
 func (scanner *historyScanner) Next() (commonledger.QueryResult, error) {
-	if !scanner.dbItr.Next() {
+	if scanner.pageSize > 0 && scanner.fetched >= scanner.pageSize {
 		return nil, nil
 	}
-	historyKey := scanner.dbItr.Key() // history key is in the form namespace~key~blocknum~trannum
+	queryResult, _, err := scanner.nextKeyModification()
+	if queryResult == nil || err != nil {
+		return nil, err
+	}
+	scanner.fetched++
+	return queryResult, nil
+}
 
-	// SplitCompositeKey(namespace~key~blocknum~trannum, namespace~key~) will return the blocknum~trannum in second position
-	_, blockNumTranNumBytes := historydb.SplitCompositeHistoryKey(historyKey, scanner.compositePartialKey)
-	blockNum, bytesConsumed := util.DecodeOrderPreservingVarUint64(blockNumTranNumBytes[0:])
-	tranNum, _ := util.DecodeOrderPreservingVarUint64(blockNumTranNumBytes[bytesConsumed:])
-	logger.Debugf("Found history record for namespace:%s key:%s at blockNumTranNum %v:%v\n",
-		scanner.namespace, scanner.key, blockNum, tranNum)
+// nextKeyModification returns the next modification of the key in the requested order and time range,
+// along with the height of the transaction which made it
+func (scanner *historyScanner) nextKeyModification() (*queryresult.KeyModification, *version.Height, error) {
+	for scanner.resumeHeight == nil && scanner.move() {
+		historyKey := scanner.dbItr.Key() // history key is in the form namespace~key~blocknum~trannum
 
-	// Get the transaction from block storage that is associated with this history record
-	tranEnvelope, err := scanner.blockStore.RetrieveTxByBlockNumTranNum(blockNum, tranNum)
-	if err != nil {
-		return nil, err
+		// SplitCompositeKey(namespace~key~blocknum~trannum, namespace~key~) will return the blocknum~trannum in second position
+		_, blockNumTranNumBytes := historydb.SplitCompositeHistoryKey(historyKey, scanner.compositePartialKey)
+		blockNum, bytesConsumed := util.DecodeOrderPreservingVarUint64(blockNumTranNumBytes[0:])
+		tranNum, _ := util.DecodeOrderPreservingVarUint64(blockNumTranNumBytes[bytesConsumed:])
+		logger.Debugf("Found history record for namespace:%s key:%s at blockNumTranNum %v:%v\n",
+			scanner.namespace, scanner.key, blockNum, tranNum)
+		if scanner.skipped >= scanner.maxSkipped {
+			logger.Debugf("Stopping the scan of the history of namespace:%s key:%s after skipping %d records out of the time range\n",
+				scanner.namespace, scanner.key, scanner.skipped)
+			scanner.resumeHeight = version.NewHeight(blockNum, tranNum)
+			return nil, nil, nil
+		}
+
+		// Get the transaction from block storage that is associated with this history record
+		tranEnvelope, err := scanner.blockStore.RetrieveTxByBlockNumTranNum(blockNum, tranNum)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Get the txid, key write value, timestamp, and delete indicator associated with this transaction
+		queryResult, err := getKeyModificationFromTran(tranEnvelope, scanner.namespace, scanner.key)
+		if err != nil {
+			return nil, nil, err
+		}
+		keyModification := queryResult.(*queryresult.KeyModification)
+		if !scanner.inTimeRange(keyModification.Timestamp) {
+			logger.Debugf("Skipping historic key value for namespace:%s key:%s from transaction %s out of the time range\n",
+				scanner.namespace, scanner.key, keyModification.TxId)
+			scanner.skipped++
+			continue
+		}
+		logger.Debugf("Found historic key value for namespace:%s key:%s from transaction %s\n",
+			scanner.namespace, scanner.key, keyModification.TxId)
+		return keyModification, version.NewHeight(blockNum, tranNum), nil
 	}
+	return nil, nil, nil
+}
 
-	// Get the txid, key write value, timestamp, and delete indicator associated with this transaction
-	queryResult, err := getKeyModificationFromTran(tranEnvelope, scanner.namespace, scanner.key)
-	if err != nil {
-		return nil, err
+// move positions the db iterator on the next history record in the requested order
+func (scanner *historyScanner) move() bool {
+	if !scanner.descending {
+		return scanner.dbItr.Next()
 	}
-	logger.Debugf("Found historic key value for namespace:%s key:%s from transaction %s\n",
-		scanner.namespace, scanner.key, queryResult.(*queryresult.KeyModification).TxId)
-	return queryResult, nil
+	if !scanner.started {
+		scanner.started = true
+		return scanner.dbItr.Last()
+	}
+	return scanner.dbItr.Prev()
+}
+
+// inTimeRange checks whether the given transaction timestamp is in the requested time range
+func (scanner *historyScanner) inTimeRange(ts *timestamp.Timestamp) bool {
+	if scanner.startTime != nil && compareTimestamps(ts, scanner.startTime) < 0 {
+		return false
+	}
+	if scanner.endTime != nil && compareTimestamps(ts, scanner.endTime) >= 0 {
+		return false
+	}
+	return true
 }
 
 func (scanner *historyScanner) Close() {
 	scanner.dbItr.Release()
 }
 
+// GetBookmarkAndClose implements method in QueryResultsIterator interface.
+// The bookmark is the height of the transaction which made the modification
+// that follows the last one returned by the scanner, or of the record where
+// the scanner stopped after skipping maxSkipped records
+func (scanner *historyScanner) GetBookmarkAndClose() string {
+	defer scanner.Close()
+	_, height, err := scanner.nextKeyModification()
+	if err != nil {
+		logger.Errorf("Failed to retrieve the bookmark of the history of namespace:%s key:%s: %s", scanner.namespace, scanner.key, err)
+		return ""
+	}
+	if height == nil {
+		height = scanner.resumeHeight
+	}
+	if height == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", height.BlockNum, height.TxNum)
+}
+
+// decodeBookmark decodes a bookmark returned by historyScanner.GetBookmarkAndClose
+func decodeBookmark(bookmark string) (*version.Height, error) {
+	parts := strings.Split(bookmark, ":")
+	if len(parts) != 2 {
+		return nil, errors.Errorf("invalid bookmark [%s]", bookmark)
+	}
+	blockNum, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid bookmark [%s]", bookmark)
+	}
+	tranNum, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid bookmark [%s]", bookmark)
+	}
+	return version.NewHeight(blockNum, tranNum), nil
+}
+
+// compareTimestamps returns a negative number, zero, or a positive number based on whether
+// the timestamp t1 is before, equal to, or after the timestamp t2. A nil timestamp is the zero time
+func compareTimestamps(t1, t2 *timestamp.Timestamp) int64 {
+	if t1.GetSeconds() != t2.GetSeconds() {
+		return t1.GetSeconds() - t2.GetSeconds()
+	}
+	return int64(t1.GetNanos() - t2.GetNanos())
+}
+
 // getTxIDandKeyWriteValueFromTran inspects a transaction for writes to a given key
 func getKeyModificationFromTran(tranEnvelope *common.Envelope, namespace string, key string) (commonledger.QueryResult, error) {
 	logger.Debugf("Entering getKeyModificationFromTran()\n", namespace, key)
@@ -25,6 +25,8 @@ import (
 	"github.com/hyperledger/fabric/common/ledger/testutil"
 	util2 "github.com/hyperledger/fabric/common/util"
 	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
 	"github.com/hyperledger/fabric/core/ledger/util"
 	"github.com/hyperledger/fabric/protos/common"
 	"github.com/hyperledger/fabric/protos/ledger/queryresult"
@@ -101,6 +103,47 @@ func TestSavepoint(t *testing.T) {
 	testutil.AssertEquals(t, blockNum, uint64(3))
 }
 
+func TestRecordSavepoint(t *testing.T) {
+	env := newTestHistoryEnv(t)
+	defer env.cleanup()
+
+	testutil.AssertNoError(t, env.testHistoryDB.RecordSavepoint(version.NewHeight(10, 5)), "")
+	savepoint, err := env.testHistoryDB.GetLastSavepoint()
+	testutil.AssertNoError(t, err, "Error upon historyDatabase.GetLastSavepoint()")
+	testutil.AssertEquals(t, savepoint, version.NewHeight(10, 5))
+
+	// ShouldRecover should start the recovery from the block next to the recorded savepoint
+	status, blockNum, err := env.testHistoryDB.ShouldRecover(12)
+	testutil.AssertNoError(t, err, "Error upon historyDatabase.ShouldRecover()")
+	testutil.AssertEquals(t, status, true)
+	testutil.AssertEquals(t, blockNum, uint64(11))
+}
+
+func TestDropHistoryDB(t *testing.T) {
+	env := newTestHistoryEnv(t)
+	defer env.cleanup()
+
+	testutil.AssertNoError(t, env.testHistoryDB.RecordSavepoint(version.NewHeight(10, 5)), "")
+	otherHistoryDB, err := env.testHistoryDBProvider.GetDBHandle("OtherHistoryDB")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNoError(t, otherHistoryDB.RecordSavepoint(version.NewHeight(3, 1)), "")
+
+	// dropping a history db removes the savepoint as well, so that the history is recovered from the first block
+	testutil.AssertNoError(t, env.testHistoryDBProvider.Drop("TestHistoryDB"), "")
+	savepoint, err := env.testHistoryDB.GetLastSavepoint()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, savepoint)
+	status, blockNum, err := env.testHistoryDB.ShouldRecover(12)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, status, true)
+	testutil.AssertEquals(t, blockNum, uint64(0))
+
+	// the other history dbs remain unaffected
+	savepoint, err = otherHistoryDB.GetLastSavepoint()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, savepoint, version.NewHeight(3, 1))
+}
+
 func TestHistory(t *testing.T) {
 	env := newTestHistoryEnv(t)
 	defer env.cleanup()
@@ -200,6 +243,131 @@ func TestHistory(t *testing.T) {
 	testutil.AssertEquals(t, count, 4)
 }
 
+func TestHistoryWithOptions(t *testing.T) {
+	env := newTestHistoryEnv(t)
+	defer env.cleanup()
+	provider := env.testBlockStorageEnv.provider
+	ledger1id := "ledger1"
+	store1, err := provider.OpenBlockStore(ledger1id)
+	testutil.AssertNoError(t, err, "Error upon provider.OpenBlockStore()")
+	defer store1.Shutdown()
+
+	bg, gb := testutil.NewBlockGenerator(t, ledger1id, false)
+	testutil.AssertNoError(t, store1.AddBlock(gb), "")
+	testutil.AssertNoError(t, env.testHistoryDB.Commit(gb), "")
+
+	// blocks 1 to 5, block i sets key7 to value<i>
+	for i := 1; i <= 5; i++ {
+		simulator, _ := env.txmgr.NewTxSimulator(util2.GenerateUUID())
+		simulator.SetState("ns1", "key7", []byte("value"+strconv.Itoa(i)))
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		pubSimResBytes, _ := simRes.GetPubSimulationBytes()
+		block := bg.NextBlock([][]byte{pubSimResBytes})
+		testutil.AssertNoError(t, store1.AddBlock(block), "")
+		testutil.AssertNoError(t, env.testHistoryDB.Commit(block), "")
+	}
+
+	qhistory, err := env.testHistoryDB.NewHistoryQueryExecutor(store1)
+	testutil.AssertNoError(t, err, "Error upon NewHistoryQueryExecutor")
+
+	retrieve := func(options *ledger.HistoryQueryOptions) ([]*queryresult.KeyModification, string) {
+		itr, err := qhistory.GetHistoryForKeyWithOptions("ns1", "key7", options)
+		testutil.AssertNoError(t, err, "Error upon GetHistoryForKeyWithOptions()")
+		var kmods []*queryresult.KeyModification
+		for {
+			kmod, err := itr.Next()
+			testutil.AssertNoError(t, err, "")
+			if kmod == nil {
+				break
+			}
+			kmods = append(kmods, kmod.(*queryresult.KeyModification))
+		}
+		return kmods, itr.GetBookmarkAndClose()
+	}
+	values := func(kmods []*queryresult.KeyModification) []string {
+		var vals []string
+		for _, kmod := range kmods {
+			vals = append(vals, string(kmod.Value))
+		}
+		return vals
+	}
+
+	allKmods, bookmark := retrieve(&ledger.HistoryQueryOptions{})
+	testutil.AssertEquals(t, values(allKmods), []string{"value1", "value2", "value3", "value4", "value5"})
+	testutil.AssertEquals(t, bookmark, "")
+
+	// block range
+	kmods, _ := retrieve(&ledger.HistoryQueryOptions{StartBlock: 2, EndBlock: 4})
+	testutil.AssertEquals(t, values(kmods), []string{"value2", "value3"})
+	kmods, _ = retrieve(&ledger.HistoryQueryOptions{StartBlock: 4})
+	testutil.AssertEquals(t, values(kmods), []string{"value4", "value5"})
+
+	// descending order
+	kmods, _ = retrieve(&ledger.HistoryQueryOptions{Descending: true})
+	testutil.AssertEquals(t, values(kmods), []string{"value5", "value4", "value3", "value2", "value1"})
+	kmods, _ = retrieve(&ledger.HistoryQueryOptions{StartBlock: 2, EndBlock: 4, Descending: true})
+	testutil.AssertEquals(t, values(kmods), []string{"value3", "value2"})
+
+	// pagination
+	kmods, bookmark = retrieve(&ledger.HistoryQueryOptions{PageSize: 2})
+	testutil.AssertEquals(t, values(kmods), []string{"value1", "value2"})
+	testutil.AssertEquals(t, bookmark, "3:0")
+	kmods, bookmark = retrieve(&ledger.HistoryQueryOptions{PageSize: 2, Bookmark: bookmark})
+	testutil.AssertEquals(t, values(kmods), []string{"value3", "value4"})
+	kmods, bookmark = retrieve(&ledger.HistoryQueryOptions{PageSize: 2, Bookmark: bookmark})
+	testutil.AssertEquals(t, values(kmods), []string{"value5"})
+	testutil.AssertEquals(t, bookmark, "")
+
+	kmods, bookmark = retrieve(&ledger.HistoryQueryOptions{EndBlock: 5, Descending: true, PageSize: 3})
+	testutil.AssertEquals(t, values(kmods), []string{"value4", "value3", "value2"})
+	testutil.AssertEquals(t, bookmark, "1:0")
+	kmods, bookmark = retrieve(&ledger.HistoryQueryOptions{EndBlock: 5, Descending: true, PageSize: 3, Bookmark: bookmark})
+	testutil.AssertEquals(t, values(kmods), []string{"value1"})
+	testutil.AssertEquals(t, bookmark, "")
+
+	// time range, the transaction timestamps are taken from the retrieved modifications
+	// as the transactions are timestamped at creation
+	startTime, endTime := allKmods[1].Timestamp, allKmods[3].Timestamp
+	var expectedValues []string
+	for _, kmod := range allKmods {
+		if compareTimestamps(kmod.Timestamp, startTime) >= 0 && compareTimestamps(kmod.Timestamp, endTime) < 0 {
+			expectedValues = append(expectedValues, string(kmod.Value))
+		}
+	}
+	kmods, _ = retrieve(&ledger.HistoryQueryOptions{StartTime: startTime, EndTime: endTime, PageSize: 5})
+	testutil.AssertEquals(t, values(kmods), expectedValues)
+
+	// the modifications skipped for a page are limited by the query limit, the bookmark
+	// then resumes the scan of the time range where the page stopped
+	defer viper.Set("ledger.state.couchDBConfig.queryLimit", ledgerconfig.GetQueryLimit())
+	viper.Set("ledger.state.couchDBConfig.queryLimit", 1)
+	var pagedValues []string
+	pages := 0
+	for bookmark = ""; pages == 0 || bookmark != ""; pages++ {
+		kmods, bookmark = retrieve(&ledger.HistoryQueryOptions{StartTime: endTime, PageSize: 5, Bookmark: bookmark})
+		pagedValues = append(pagedValues, values(kmods)...)
+	}
+	expectedValues = nil
+	for _, kmod := range allKmods {
+		if compareTimestamps(kmod.Timestamp, endTime) >= 0 {
+			expectedValues = append(expectedValues, string(kmod.Value))
+		}
+	}
+	testutil.AssertEquals(t, pagedValues, expectedValues)
+	testutil.AssertEquals(t, pages > 1, true)
+
+	// invalid options
+	_, err = qhistory.GetHistoryForKeyWithOptions("ns1", "key7", &ledger.HistoryQueryOptions{PageSize: -1})
+	testutil.AssertError(t, err, "Error should have been returned for a negative page size")
+	_, err = qhistory.GetHistoryForKeyWithOptions("ns1", "key7", &ledger.HistoryQueryOptions{StartBlock: 3, EndBlock: 3})
+	testutil.AssertError(t, err, "Error should have been returned for an empty block range")
+	_, err = qhistory.GetHistoryForKeyWithOptions("ns1", "key7", &ledger.HistoryQueryOptions{PageSize: 2, Bookmark: "invalid"})
+	testutil.AssertError(t, err, "Error should have been returned for an invalid bookmark")
+	_, err = qhistory.GetHistoryForKeyWithOptions("ns1", "key7", &ledger.HistoryQueryOptions{StartTime: startTime})
+	testutil.AssertError(t, err, "Error should have been returned for a time range which is not paginated")
+}
+
 func TestHistoryForInvalidTran(t *testing.T) {
 	env := newTestHistoryEnv(t)
 	defer env.cleanup()
@@ -24,11 +24,13 @@ import (
 	"github.com/hyperledger/fabric/common/ledger/blkstorage"
 	"github.com/hyperledger/fabric/common/ledger/blkstorage/fsblkstorage"
 	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/bookkeeping"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/history/historydb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/txmgr"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/txmgr/lockbasedtxmgr"
 	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	btltestutil "github.com/hyperledger/fabric/core/ledger/pvtdatapolicy/testutil"
 	"github.com/spf13/viper"
 )
 
@@ -38,8 +40,9 @@ type levelDBLockBasedHistoryEnv struct {
 	t                   testing.TB
 	testBlockStorageEnv *testBlockStoreEnv
 
-	testDBEnv privacyenabledstate.TestEnv
-	txmgr     txmgr.TxMgr
+	testDBEnv          privacyenabledstate.TestEnv
+	testBookkeepingEnv *bookkeeping.TestEnv
+	txmgr              txmgr.TxMgr
 
 	testHistoryDBProvider historydb.HistoryDBProvider
 	testHistoryDB         historydb.HistoryDB
@@ -55,19 +58,21 @@ func newTestHistoryEnv(t *testing.T) *levelDBLockBasedHistoryEnv {
 	testDBEnv.Init(t)
 	testDB := testDBEnv.GetDBHandle(testLedgerID)
 
-	txMgr := lockbasedtxmgr.NewLockBasedTxMgr(testDB)
+	testBookkeepingEnv := bookkeeping.NewTestEnv(t)
+	txMgr := lockbasedtxmgr.NewLockBasedTxMgr(testLedgerID, testDB, btltestutil.SampleBTLPolicy(nil), testBookkeepingEnv.TestProvider, nil)
 	testHistoryDBProvider := NewHistoryDBProvider()
 	testHistoryDB, err := testHistoryDBProvider.GetDBHandle("TestHistoryDB")
 	testutil.AssertNoError(t, err, "")
 
 	return &levelDBLockBasedHistoryEnv{t,
-		blockStorageTestEnv, testDBEnv,
+		blockStorageTestEnv, testDBEnv, testBookkeepingEnv,
 		txMgr, testHistoryDBProvider, testHistoryDB}
 }
 
 func (env *levelDBLockBasedHistoryEnv) cleanup() {
 	defer env.txmgr.Shutdown()
 	defer env.testDBEnv.Cleanup()
+	defer env.testBookkeepingEnv.Cleanup()
 	defer env.testBlockStorageEnv.cleanup()
 
 	// clean up history
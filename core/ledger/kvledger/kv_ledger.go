@@ -7,22 +7,33 @@ SPDX-License-Identifier: Apache-2.0
 package kvledger
 
 import (
-	"errors"
+	"bytes"
 	"fmt"
 	"sync"
+	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/common/flogging"
 	commonledger "github.com/hyperledger/fabric/common/ledger"
+	"github.com/hyperledger/fabric/common/metrics"
 	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/core/common/privdata"
 	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/cceventmgmt"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/bookkeeping"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/history/historydb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwsetutil"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/txmgr"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/txmgr/lockbasedtxmgr"
 	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
 	"github.com/hyperledger/fabric/core/ledger/ledgerstorage"
+	"github.com/hyperledger/fabric/core/ledger/pvtdatapolicy"
+	lutil "github.com/hyperledger/fabric/core/ledger/util"
 	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/ledger/rwset"
 	"github.com/hyperledger/fabric/protos/peer"
+	putils "github.com/hyperledger/fabric/protos/utils"
 )
 
 var logger = flogging.MustGetLogger("kvledger")
@@ -33,31 +44,74 @@ type kvLedger struct {
 	ledgerID        string
 	blockStore      *ledgerstorage.Store
 	txtmgmt         txmgr.TxMgr
+	versionedDB     privacyenabledstate.DB
 	historyDB       historydb.HistoryDB
 	blockAPIsRWLock *sync.RWMutex
+	commitLock      *sync.Mutex
+	metrics         metrics.Scope
+	commitHash      []byte
 }
 
 // NewKVLedger constructs new `KVLedger`
 func newKVLedger(ledgerID string, blockStore *ledgerstorage.Store,
-	versionedDB privacyenabledstate.DB, historyDB historydb.HistoryDB) (*kvLedger, error) {
+	versionedDB privacyenabledstate.DB, historyDB historydb.HistoryDB,
+	bookkeeperProvider bookkeeping.Provider, stateListeners []ledger.StateListener) (*kvLedger, error) {
 
 	logger.Debugf("Creating KVLedger ledgerID=%s: ", ledgerID)
 
-	//Initialize transaction manager using state database
-	var txmgmt txmgr.TxMgr
-	txmgmt = lockbasedtxmgr.NewLockBasedTxMgr(versionedDB)
-
 	// Create a kvLedger for this chain/ledger, which encasulates the underlying
 	// id store, blockstore, txmgr (state database), history database
-	l := &kvLedger{ledgerID, blockStore, txmgmt, historyDB, &sync.RWMutex{}}
+	l := &kvLedger{ledgerID: ledgerID, blockStore: blockStore, versionedDB: versionedDB, historyDB: historyDB,
+		blockAPIsRWLock: &sync.RWMutex{},
+		commitLock:      &sync.Mutex{},
+		metrics:         metrics.RootScope.SubScope("ledger").Tagged(map[string]string{"channel": ledgerID})}
+
+	// The btl policy is backed by the collection configurations that are stored in the state by lscc.
+	// Hence, the policy is able to lookup the configurations only after the txmgr is initialized
+	btlPolicy := pvtdatapolicy.NewBTLPolicy(&collectionInfoRetriever{l})
+
+	//Initialize transaction manager using state database
+	stateListeners = append([]ledger.StateListener{&cceventmgmt.KVLedgerLSCCStateListener{}}, stateListeners...)
+	l.txtmgmt = lockbasedtxmgr.NewLockBasedTxMgr(ledgerID, versionedDB, btlPolicy, bookkeeperProvider, stateListeners)
+
+	// Register the statedb for the chaincode lifecycle events, so that it can create the
+	// database artifacts (such as indexes) packaged with the chaincodes deployed on the channel
+	if ccEventListener, ok := versionedDB.(cceventmgmt.ChaincodeLifecycleEventListener); ok {
+		cceventmgmt.GetMgr().Register(ledgerID, ccEventListener)
+	}
+
+	if err := blockStore.Init(btlPolicy); err != nil {
+		return nil, err
+	}
 
 	//Recover both state DB and history DB if they are out of sync with block storage
 	if err := l.recoverDBs(); err != nil {
 		panic(fmt.Errorf(`Error during state DB recovery:%s`, err))
 	}
+	var err error
+	if l.commitHash, err = l.lastPersistedCommitHash(); err != nil {
+		return nil, err
+	}
 	return l, nil
 }
 
+// lastPersistedCommitHash returns the commit hash recorded in the metadata of the last block in the block storage,
+// which is the starting point of the chain of the commit hashes of the subsequent blocks
+func (l *kvLedger) lastPersistedCommitHash() ([]byte, error) {
+	info, err := l.blockStore.GetBlockchainInfo()
+	if err != nil {
+		return nil, err
+	}
+	if info.Height == 0 {
+		return nil, nil
+	}
+	block, err := l.blockStore.RetrieveBlockByNumber(info.Height - 1)
+	if err != nil {
+		return nil, err
+	}
+	return putils.GetCommitHashFromBlock(block)
+}
+
 //Recover the state database and history database (if exist)
 //by recommitting last valid blocks
 func (l *kvLedger) recoverDBs() error {
@@ -107,6 +161,7 @@ func (l *kvLedger) recoverDBs() error {
 //recommitLostBlocks retrieves blocks in specified range and commit the write set to either
 //state DB or history DB or both
 func (l *kvLedger) recommitLostBlocks(firstBlockNum uint64, lastBlockNum uint64, recoverables ...recoverable) error {
+	logger.Infof("Recommitting blocks [%d] to [%d] for ledger [%s]", firstBlockNum, lastBlockNum, l.ledgerID)
 	var err error
 	var blockAndPvtdata *ledger.BlockAndPvtData
 	for blockNumber := firstBlockNum; blockNumber <= lastBlockNum; blockNumber++ {
@@ -118,7 +173,11 @@ func (l *kvLedger) recommitLostBlocks(firstBlockNum uint64, lastBlockNum uint64,
 				return err
 			}
 		}
+		if blockNumber != firstBlockNum && blockNumber%10000 == 0 {
+			logger.Infof("Recommitted block number [%d] of [%d] for ledger [%s]", blockNumber, lastBlockNum, l.ledgerID)
+		}
 	}
+	logger.Infof("Finished recommitting blocks for ledger [%s]. Last block recommitted [%d]", l.ledgerID, lastBlockNum)
 	return nil
 }
 
@@ -190,8 +249,12 @@ func (l *kvLedger) GetTxValidationCodeByTxID(txID string) (peer.TxValidationCode
 }
 
 //Prune prunes the blocks/transactions that satisfy the given policy
+//The supported policies are `blkstorage.RetainLastNBlocks` and `blkstorage.RetainBlocksNewerThan`.
+//A subsequent retrieval of a pruned block or transaction returns the error `blkstorage.ErrPruned`
 func (l *kvLedger) Prune(policy commonledger.PrunePolicy) error {
-	return errors.New("Not yet implemented")
+	l.blockAPIsRWLock.Lock()
+	defer l.blockAPIsRWLock.Unlock()
+	return l.blockStore.Prune(policy)
 }
 
 // NewTxSimulator returns new `ledger.TxSimulator`
@@ -220,25 +283,45 @@ func (l *kvLedger) CommitWithPvtData(pvtdataAndBlock *ledger.BlockAndPvtData) er
 	block := pvtdataAndBlock.Block
 	blockNo := pvtdataAndBlock.Block.Header.Number
 
+	// the commit of a block is serialized with the commit of the pvt data of old blocks,
+	// as both update the state and the bookkeeping of the expiry of the pvt data
+	l.commitLock.Lock()
+	defer l.commitLock.Unlock()
+
+	startTime := time.Now()
 	logger.Debugf("Channel [%s]: Validating state for block [%d]", l.ledgerID, blockNo)
-	err = l.txtmgmt.ValidateAndPrepare(pvtdataAndBlock, true)
+	updateBytes, err := l.txtmgmt.ValidateAndPrepare(pvtdataAndBlock, true)
 	if err != nil {
 		return err
 	}
+	l.metrics.Histogram("state_validation_duration").RecordDuration(time.Since(startTime))
+
+	// the chain of the commit hashes starts at the genesis block, i.e., a ledger that has been created
+	// before the commit hashes were introduced does not carry the commit hashes until it is reset
+	commitHash := l.commitHash
+	if blockNo == 0 || len(l.commitHash) != 0 {
+		logger.Debugf("Channel [%s]: Adding commit hash to block [%d]", l.ledgerID, blockNo)
+		commitHash = l.addBlockCommitHash(block, updateBytes)
+	}
 
 	logger.Debugf("Channel [%s]: Committing block [%d] to storage", l.ledgerID, blockNo)
 
 	l.blockAPIsRWLock.Lock()
 	defer l.blockAPIsRWLock.Unlock()
+	blockstoreStartTime := time.Now()
 	if err = l.blockStore.CommitWithPvtData(pvtdataAndBlock); err != nil {
 		return err
 	}
+	l.commitHash = commitHash
+	l.metrics.Histogram("blockstore_commit_duration").RecordDuration(time.Since(blockstoreStartTime))
 	logger.Infof("Channel [%s]: Committed block [%d] with %d transaction(s)", l.ledgerID, block.Header.Number, len(block.Data.Data))
 
 	logger.Debugf("Channel [%s]: Committing block [%d] transactions to state database", l.ledgerID, blockNo)
+	statedbStartTime := time.Now()
 	if err = l.txtmgmt.Commit(); err != nil {
 		panic(fmt.Errorf(`Error during commit to txmgr:%s`, err))
 	}
+	l.metrics.Histogram("statedb_commit_duration").RecordDuration(time.Since(statedbStartTime))
 
 	// History database could be written in parallel with state and/or async as a future optimization
 	if ledgerconfig.IsHistoryDBEnabled() {
@@ -247,9 +330,28 @@ func (l *kvLedger) CommitWithPvtData(pvtdataAndBlock *ledger.BlockAndPvtData) er
 			panic(fmt.Errorf(`Error during commit to history db:%s`, err))
 		}
 	}
+	l.metrics.Histogram("block_processing_duration").RecordDuration(time.Since(startTime))
 	return nil
 }
 
+// addBlockCommitHash computes the commit hash of the block over the validation flags of the transactions, the
+// updates of the valid transactions and the commit hash of the previous block, and records it in the block metadata
+func (l *kvLedger) addBlockCommitHash(block *common.Block, updateBytes []byte) []byte {
+	txsFilter := block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER]
+	valueBytes := proto.EncodeVarint(uint64(len(txsFilter)))
+	valueBytes = append(valueBytes, txsFilter...)
+	valueBytes = append(valueBytes, updateBytes...)
+	valueBytes = append(valueBytes, l.commitHash...)
+	commitHash := util.ComputeSHA256(valueBytes)
+
+	// the blocks created by the orderers that are not aware of the commit hash lack the corresponding metadata entry
+	for len(block.Metadata.Metadata) <= int(common.BlockMetadataIndex_COMMIT_HASH) {
+		block.Metadata.Metadata = append(block.Metadata.Metadata, []byte{})
+	}
+	block.Metadata.Metadata[common.BlockMetadataIndex_COMMIT_HASH] = putils.MarshalOrPanic(&common.Metadata{Value: commitHash})
+	return commitHash
+}
+
 // GetPvtDataAndBlockByNum returns the block and the corresponding pvt data.
 // The pvt data is filtered by the list of 'collections' supplied
 func (l *kvLedger) GetPvtDataAndBlockByNum(blockNum uint64, filter ledger.PvtNsCollFilter) (*ledger.BlockAndPvtData, error) {
@@ -268,6 +370,136 @@ func (l *kvLedger) GetPvtDataByNum(blockNum uint64, filter ledger.PvtNsCollFilte
 	return pvtdata, err
 }
 
+// GetMissingPvtDataInfoForMostRecentBlocks returns the info about the pvt data that is missing
+// in the most recent blocks, up to the given number of blocks with missing pvt data
+func (l *kvLedger) GetMissingPvtDataInfoForMostRecentBlocks(maxBlocks int) (ledger.MissingPvtDataInfo, error) {
+	return l.blockStore.GetMissingPvtDataInfoForMostRecentBlocks(maxBlocks)
+}
+
+// GetMissingPvtDataInfoForBlocksBelow returns the info about the pvt data that is missing in
+// the most recent blocks before the given block, up to the given number of blocks with missing pvt data
+func (l *kvLedger) GetMissingPvtDataInfoForBlocksBelow(blockNum uint64, maxBlocks int) (ledger.MissingPvtDataInfo, error) {
+	return l.blockStore.GetMissingPvtDataInfoForBlocksBelow(blockNum, maxBlocks)
+}
+
+// CommitPvtDataOfOldBlocks commits the pvt data of already committed blocks. The hash of the write set of
+// each collection is verified against the hash present in the corresponding block; the collections that
+// do not match are not committed and are returned to the caller
+func (l *kvLedger) CommitPvtDataOfOldBlocks(blocksPvtData []*ledger.BlockPvtData) ([]*ledger.PvtdataHashMismatch, error) {
+	l.commitLock.Lock()
+	defer l.commitLock.Unlock()
+
+	validPvtData, hashMismatches, err := l.validatePvtDataOfOldBlocks(blocksPvtData)
+	if err != nil {
+		return nil, err
+	}
+	if len(validPvtData) == 0 {
+		return hashMismatches, nil
+	}
+
+	// The state is updated before the pvt data store. In the case of a crash in between, the pvt data
+	// is still reported as missing by the pvt data store and is committed again, which has no effect
+	// on the already updated state
+	logger.Debugf("Channel [%s]: Committing pvt data of %d old blocks to state database", l.ledgerID, len(validPvtData))
+	if err := l.txtmgmt.RemoveStaleAndCommitPvtDataOfOldBlocks(validPvtData); err != nil {
+		return nil, err
+	}
+
+	logger.Debugf("Channel [%s]: Committing pvt data of %d old blocks to pvt data store", l.ledgerID, len(validPvtData))
+	l.blockAPIsRWLock.Lock()
+	defer l.blockAPIsRWLock.Unlock()
+	if err := l.blockStore.CommitPvtDataOfOldBlocks(validPvtData); err != nil {
+		return nil, err
+	}
+	logger.Infof("Channel [%s]: Committed pvt data of %d old blocks", l.ledgerID, len(validPvtData))
+	return hashMismatches, nil
+}
+
+// validatePvtDataOfOldBlocks verifies the pvt data against the hashes present in the corresponding blocks.
+// It returns the pvt data that matches the hashes and the list of the collections that do not match
+func (l *kvLedger) validatePvtDataOfOldBlocks(blocksPvtData []*ledger.BlockPvtData) ([]*ledger.BlockPvtData, []*ledger.PvtdataHashMismatch, error) {
+	var validPvtData []*ledger.BlockPvtData
+	var hashMismatches []*ledger.PvtdataHashMismatch
+	for _, blockPvtData := range blocksPvtData {
+		block, err := l.blockStore.RetrieveBlockByNumber(blockPvtData.BlockNum)
+		if err != nil {
+			return nil, nil, err
+		}
+		validBlockPvtData := &ledger.BlockPvtData{BlockNum: blockPvtData.BlockNum, WriteSets: make(map[uint64]*ledger.TxPvtData)}
+		txsFilter := lutil.TxValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+		for txNum, txPvtData := range blockPvtData.WriteSets {
+			if txPvtData == nil || txPvtData.WriteSet == nil {
+				continue
+			}
+			if txNum >= uint64(len(block.Data.Data)) || txsFilter.IsInvalid(int(txNum)) {
+				logger.Debugf("Channel [%s]: Skipping the pvt data of the invalid or non existing transaction [%d] in block [%d]",
+					l.ledgerID, txNum, blockPvtData.BlockNum)
+				continue
+			}
+			txRWSet, err := retrieveTxRWSet(block.Data.Data[txNum])
+			if err != nil {
+				return nil, nil, err
+			}
+			validWriteSet := &rwset.TxPvtReadWriteSet{DataModel: txPvtData.WriteSet.DataModel}
+			for _, nsPvtRwset := range txPvtData.WriteSet.NsPvtRwset {
+				validNsPvtRwset := &rwset.NsPvtReadWriteSet{Namespace: nsPvtRwset.Namespace}
+				for _, collPvtRwset := range nsPvtRwset.CollectionPvtRwset {
+					expectedHash := retrievePvtRwSetHash(txRWSet, nsPvtRwset.Namespace, collPvtRwset.CollectionName)
+					if !bytes.Equal(lutil.ComputeHash(collPvtRwset.Rwset), expectedHash) {
+						hashMismatches = append(hashMismatches, &ledger.PvtdataHashMismatch{
+							BlockNum:     blockPvtData.BlockNum,
+							TxNum:        txNum,
+							Namespace:    nsPvtRwset.Namespace,
+							Collection:   collPvtRwset.CollectionName,
+							ExpectedHash: expectedHash,
+						})
+						continue
+					}
+					validNsPvtRwset.CollectionPvtRwset = append(validNsPvtRwset.CollectionPvtRwset, collPvtRwset)
+				}
+				if len(validNsPvtRwset.CollectionPvtRwset) > 0 {
+					validWriteSet.NsPvtRwset = append(validWriteSet.NsPvtRwset, validNsPvtRwset)
+				}
+			}
+			if len(validWriteSet.NsPvtRwset) > 0 {
+				validBlockPvtData.WriteSets[txNum] = &ledger.TxPvtData{SeqInBlock: txNum, WriteSet: validWriteSet}
+			}
+		}
+		if len(validBlockPvtData.WriteSets) > 0 {
+			validPvtData = append(validPvtData, validBlockPvtData)
+		}
+	}
+	return validPvtData, hashMismatches, nil
+}
+
+// retrieveTxRWSet returns the read-write set of the endorser transaction present in the given envelope
+func retrieveTxRWSet(envBytes []byte) (*rwsetutil.TxRwSet, error) {
+	respPayload, err := putils.GetActionFromEnvelope(envBytes)
+	if err != nil {
+		return nil, err
+	}
+	txRWSet := &rwsetutil.TxRwSet{}
+	if err := txRWSet.FromProtoBytes(respPayload.Results); err != nil {
+		return nil, err
+	}
+	return txRWSet, nil
+}
+
+// retrievePvtRwSetHash returns the hash of the pvt write set of the given collection, as present in the read-write set
+func retrievePvtRwSetHash(txRWSet *rwsetutil.TxRwSet, ns, coll string) []byte {
+	for _, nsRwSet := range txRWSet.NsRwSets {
+		if nsRwSet.NameSpace != ns {
+			continue
+		}
+		for _, collHashedRwSet := range nsRwSet.CollHashedRwSets {
+			if collHashedRwSet.CollectionName == coll {
+				return collHashedRwSet.PvtRwSetHash
+			}
+		}
+	}
+	return nil
+}
+
 // Purge removes private read-writes set generated by endorsers at block height lesser than
 // a given maxBlockNumToRetain. In other words, Purge only retains private read-write sets
 // that were generated at block height of maxBlockNumToRetain or higher.
@@ -282,6 +514,7 @@ func (l *kvLedger) PrivateDataMinBlockNum() (uint64, error) {
 
 // Close closes `KVLedger`
 func (l *kvLedger) Close() {
+	cceventmgmt.GetMgr().Deregister(l.ledgerID)
 	l.blockStore.Shutdown()
 	l.txtmgmt.Shutdown()
 }
@@ -304,3 +537,36 @@ func (itr *blocksItr) Next() (commonledger.QueryResult, error) {
 func (itr *blocksItr) Close() {
 	itr.blocksItr.Close()
 }
+
+// collectionInfoRetriever implements interface pvtdatapolicy.CollectionInfoProvider
+// by looking up the collection configurations that are maintained by lscc in the state
+type collectionInfoRetriever struct {
+	l *kvLedger
+}
+
+// CollectionInfo implements the function in the interface pvtdatapolicy.CollectionInfoProvider
+func (r *collectionInfoRetriever) CollectionInfo(chaincodeName, collectionName string) (*common.StaticCollectionConfig, error) {
+	qe, err := r.l.txtmgmt.NewQueryExecutor(util.GenerateUUID())
+	if err != nil {
+		return nil, err
+	}
+	defer qe.Done()
+	collConfigPkgBytes, err := qe.GetState("lscc", privdata.BuildCollectionKVSKey(chaincodeName))
+	if err != nil {
+		return nil, err
+	}
+	if collConfigPkgBytes == nil {
+		return nil, nil
+	}
+	collConfigPkg := &common.CollectionConfigPackage{}
+	if err := proto.Unmarshal(collConfigPkgBytes, collConfigPkg); err != nil {
+		return nil, err
+	}
+	for _, collConfig := range collConfigPkg.Config {
+		staticCollConfig := collConfig.GetStaticCollectionConfig()
+		if staticCollConfig != nil && staticCollConfig.Name == collectionName {
+			return staticCollConfig, nil
+		}
+	}
+	return nil, nil
+}
@@ -7,13 +7,14 @@ SPDX-License-Identifier: Apache-2.0
 package kvledger
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
 	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
 	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/bookkeeping"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/history/historydb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/history/historydb/historyleveldb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate"
@@ -34,6 +35,20 @@ var (
 
 	underConstructionLedgerKey = []byte("underConstructionLedgerKey")
 	ledgerKeyPrefix            = []byte("l")
+	ledgerKeyStop              = []byte{ledgerKeyPrefix[0] + 1}
+	rebuildDBsKeyPrefix        = []byte("r")
+)
+
+// rebuildDBsState is the state of the rebuild of the databases of a ledger, as recorded in the id store
+type rebuildDBsState byte
+
+const (
+	rebuildDBsNotPending rebuildDBsState = iota
+	// rebuildDBsDropPending indicates that the databases are yet to be dropped (some of these may
+	// already be dropped if a crash happened during the drop)
+	rebuildDBsDropPending
+	// rebuildDBsRecommitPending indicates that the databases are dropped and are being rebuilt from the blocks
+	rebuildDBsRecommitPending
 )
 
 // Provider implements interface ledger.PeerLedgerProvider
@@ -42,35 +57,69 @@ type Provider struct {
 	ledgerStoreProvider *ledgerstorage.Provider
 	vdbProvider         privacyenabledstate.DBProvider
 	historydbProvider   historydb.HistoryDBProvider
+	bookkeepingProvider bookkeeping.Provider
+	stateListeners      []ledger.StateListener
 }
 
 // NewProvider instantiates a new Provider.
+// The provider keeps the ledgers in the memory if the ledger configuration says so (see `NewInMemoryProvider`).
 // This is not thread-safe and assumed to be synchronized be the caller
 func NewProvider() (ledger.PeerLedgerProvider, error) {
+	return newProvider(ledgerconfig.IsLedgerInMemory())
+}
 
-	logger.Info("Initializing ledger provider")
+// NewInMemoryProvider instantiates a new Provider that keeps the ledgers (the blocks, the state, the history,
+// the private data, and the bookkeeping) in the memory of the process instead of the file system. The contents
+// survive a close and a re-open of the provider within the process and are discarded by
+// `leveldbhelper.RemoveInMemoryDBs(ledgerconfig.GetRootPath())`. The state is always kept in goleveldb.
+// This is not thread-safe and assumed to be synchronized be the caller
+func NewInMemoryProvider() (ledger.PeerLedgerProvider, error) {
+	return newProvider(true)
+}
 
-	// Initialize the ID store (inventory of chainIds/ledgerIds)
-	idStore := openIDStore(ledgerconfig.GetLedgerProviderPath())
+func newProvider(inMemory bool) (ledger.PeerLedgerProvider, error) {
 
-	ledgerStoreProvider := ledgerstorage.NewProvider()
+	logger.Infof("Initializing ledger provider, inMemory=%t", inMemory)
 
-	// Initialize the versioned database (state database)
-	vdbProvider, err := privacyenabledstate.NewCommonStorageDBProvider()
-	if err != nil {
-		return nil, err
-	}
+	// Initialize the ID store (inventory of chainIds/ledgerIds)
+	idStore := openIDStore(ledgerconfig.GetLedgerProviderPath(), inMemory)
 
-	// Initialize the history database (index for history of values by key)
+	var ledgerStoreProvider *ledgerstorage.Provider
+	var vdbProvider privacyenabledstate.DBProvider
 	var historydbProvider historydb.HistoryDBProvider
-	historydbProvider = historyleveldb.NewHistoryDBProvider()
+	var bookkeepingProvider bookkeeping.Provider
+	if inMemory {
+		ledgerStoreProvider = ledgerstorage.NewInMemoryProvider()
+		vdbProvider = privacyenabledstate.NewInMemoryCommonStorageDBProvider()
+		historydbProvider = historyleveldb.NewInMemoryHistoryDBProvider()
+		bookkeepingProvider = bookkeeping.NewInMemoryProvider()
+	} else {
+		ledgerStoreProvider = ledgerstorage.NewProvider()
+
+		// Initialize the versioned database (state database)
+		var err error
+		if vdbProvider, err = privacyenabledstate.NewCommonStorageDBProvider(); err != nil {
+			return nil, err
+		}
+
+		// Initialize the history database (index for history of values by key)
+		historydbProvider = historyleveldb.NewHistoryDBProvider()
+
+		// Initialize the bookkeeping provider (internal bookkeeping such as the expiry schedule of private data)
+		bookkeepingProvider = bookkeeping.NewProvider()
+	}
 
 	logger.Info("ledger provider Initialized")
-	provider := &Provider{idStore, ledgerStoreProvider, vdbProvider, historydbProvider}
+	provider := &Provider{idStore, ledgerStoreProvider, vdbProvider, historydbProvider, bookkeepingProvider, nil}
 	provider.recoverUnderConstructionLedger()
 	return provider, nil
 }
 
+// Initialize implements the corresponding method from interface ledger.PeerLedgerProvider
+func (provider *Provider) Initialize(stateListeners []ledger.StateListener) {
+	provider.stateListeners = stateListeners
+}
+
 // Create implements the corresponding method from interface ledger.PeerLedgerProvider
 // This functions sets a under construction flag before doing any thing related to ledger creation and
 // upon a successful ledger creation with the committed genesis block, removes the flag and add entry into
@@ -119,7 +168,26 @@ func (provider *Provider) Open(ledgerID string) (ledger.PeerLedger, error) {
 	if !exists {
 		return nil, ErrNonExistingLedgerID
 	}
-	return provider.openInternal(ledgerID)
+	rebuildState, err := provider.idStore.getRebuildDBsState(ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	if rebuildState == rebuildDBsDropPending {
+		if err := provider.dropDBsForRebuild(ledgerID); err != nil {
+			return nil, err
+		}
+	}
+	l, err := provider.openInternal(ledgerID)
+	if err != nil || rebuildState == rebuildDBsNotPending {
+		return l, err
+	}
+	// the databases are brought in sync with the block store while the ledger is opened
+	if err := provider.idStore.unsetRebuildDBsState(ledgerID); err != nil {
+		l.Close()
+		return nil, err
+	}
+	logger.Infof("Finished rebuilding the databases of ledger [%s]", ledgerID)
+	return l, nil
 }
 
 func (provider *Provider) openInternal(ledgerID string) (ledger.PeerLedger, error) {
@@ -143,7 +211,7 @@ func (provider *Provider) openInternal(ledgerID string) (ledger.PeerLedger, erro
 
 	// Create a kvLedger for this chain/ledger, which encasulates the underlying data stores
 	// (id store, blockstore, state database, history database)
-	l, err := newKVLedger(ledgerID, blockStore, vDB, historyDB)
+	l, err := newKVLedger(ledgerID, blockStore, vDB, historyDB, provider.bookkeepingProvider, provider.stateListeners)
 	if err != nil {
 		return nil, err
 	}
@@ -155,6 +223,136 @@ func (provider *Provider) Exists(ledgerID string) (bool, error) {
 	return provider.idStore.ledgerIDExists(ledgerID)
 }
 
+// Rollback implements the corresponding method from interface ledger.PeerLedgerProvider.
+// The state db, the history db and the bookkeeping of the ledger are dropped before the blocks are removed so that,
+// in the case of a crash, these are rebuilt from the blocks when the ledger is opened and the rollback can be repeated
+func (provider *Provider) Rollback(ledgerID string, blockNum uint64) error {
+	exists, err := provider.idStore.ledgerIDExists(ledgerID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNonExistingLedgerID
+	}
+	if err := provider.validateRollback(ledgerID, blockNum); err != nil {
+		return err
+	}
+	logger.Infof("Rolling back ledger [%s] to block [%d]", ledgerID, blockNum)
+	if err := provider.dropDBs(ledgerID); err != nil {
+		return err
+	}
+	if err := provider.ledgerStoreProvider.Rollback(ledgerID, blockNum); err != nil {
+		return err
+	}
+	logger.Infof("Rolled back ledger [%s] to block [%d]. The state will be rebuilt when the ledger is opened", ledgerID, blockNum)
+	return nil
+}
+
+// validateRollback checks that the ledger contains the given block and that the state of the ledger can be
+// rebuilt after the rollback, which is not possible if the blocks are pruned or the ledger is created from a snapshot
+func (provider *Provider) validateRollback(ledgerID string, blockNum uint64) error {
+	store, err := provider.ledgerStoreProvider.Open(ledgerID)
+	if err != nil {
+		return err
+	}
+	defer store.Shutdown()
+	bcInfo, err := store.GetBlockchainInfo()
+	if err != nil {
+		return err
+	}
+	if blockNum >= bcInfo.Height {
+		return fmt.Errorf("Ledger [%s] cannot be rolled back to block [%d] as the last block in the ledger is [%d]",
+			ledgerID, blockNum, bcInfo.Height-1)
+	}
+	pruned, err := hasPrunedBlocks(store)
+	if err != nil {
+		return err
+	}
+	if pruned {
+		return fmt.Errorf("Ledger [%s] cannot be rolled back as its state cannot be rebuilt without the pruned blocks", ledgerID)
+	}
+	return nil
+}
+
+// RebuildDBs implements the corresponding method from interface ledger.PeerLedgerProvider.
+// This function only records in the id store that the databases of the ledger are to be rebuilt. The databases
+// and the block index are dropped, and subsequently rebuilt from the blocks, when the ledger is opened next.
+// If a rebuild was interrupted, the recorded state is retained so that the rebuild resumes from where it stopped
+func (provider *Provider) RebuildDBs(ledgerID string) error {
+	exists, err := provider.idStore.ledgerIDExists(ledgerID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNonExistingLedgerID
+	}
+	rebuildState, err := provider.idStore.getRebuildDBsState(ledgerID)
+	if err != nil {
+		return err
+	}
+	if rebuildState != rebuildDBsNotPending {
+		logger.Infof("Rebuild of the databases of ledger [%s] is already in progress and will be resumed", ledgerID)
+		return nil
+	}
+	store, err := provider.ledgerStoreProvider.Open(ledgerID)
+	if err != nil {
+		return err
+	}
+	pruned, err := hasPrunedBlocks(store)
+	store.Shutdown()
+	if err != nil {
+		return err
+	}
+	if pruned {
+		return fmt.Errorf("The databases of ledger [%s] cannot be rebuilt without the pruned blocks", ledgerID)
+	}
+	return provider.idStore.setRebuildDBsState(ledgerID, rebuildDBsDropPending)
+}
+
+// dropDBsForRebuild drops the databases and the block index of the ledger and records in the id store that
+// these are to be rebuilt from the blocks. If a crash happens in between, the drop is repeated
+func (provider *Provider) dropDBsForRebuild(ledgerID string) error {
+	logger.Infof("Dropping the databases of ledger [%s] for the rebuild", ledgerID)
+	if err := provider.dropDBs(ledgerID); err != nil {
+		return err
+	}
+	if err := provider.ledgerStoreProvider.DropBlockIndex(ledgerID); err != nil {
+		return err
+	}
+	return provider.idStore.setRebuildDBsState(ledgerID, rebuildDBsRecommitPending)
+}
+
+// dropDBs drops the state db, the history db and the bookkeeping of the ledger. These are rebuilt from the blocks
+// when the ledger is opened, as the recovery finds the savepoints of the dropped dbs to be missing
+func (provider *Provider) dropDBs(ledgerID string) error {
+	if err := provider.vdbProvider.Drop(ledgerID); err != nil {
+		return err
+	}
+	if err := provider.historydbProvider.Drop(ledgerID); err != nil {
+		return err
+	}
+	return provider.bookkeepingProvider.GetDBHandle(ledgerID, bookkeeping.PvtdataExpiry).DeleteAll()
+}
+
+// hasPrunedBlocks returns true if the blocks starting from the genesis block are not available in the store.
+// This is the case if the blocks are pruned or the ledger is created from a snapshot. The genesis block may
+// be retrievable by the number even in the latter case, hence the blocks are iterated as in the function
+// 'isBootstrappedFromSnapshot'
+func hasPrunedBlocks(store *ledgerstorage.Store) (bool, error) {
+	itr, err := store.RetrieveBlocks(0)
+	if err != nil {
+		return false, err
+	}
+	defer itr.Close()
+	if _, err := itr.Next(); err != nil {
+		if err == blkstorage.ErrPruned {
+			return true, nil
+		}
+		return false, err
+	}
+	return false, nil
+}
+
 // List implements the corresponding method from interface ledger.PeerLedgerProvider
 func (provider *Provider) List() ([]string, error) {
 	return provider.idStore.getAllLedgerIds()
@@ -166,12 +364,14 @@ func (provider *Provider) Close() {
 	provider.ledgerStoreProvider.Close()
 	provider.vdbProvider.Close()
 	provider.historydbProvider.Close()
+	provider.bookkeepingProvider.Close()
 }
 
 // recoverUnderConstructionLedger checks whether the under construction flag is set - this would be the case
 // if a crash had happened during creation of ledger and the ledger creation could have been left in intermediate
-// state. Recovery checks if the ledger was created and the genesis block was committed successfully then it completes
-// the last step of adding the ledger id to the list of created ledgers. Else, it clears the under construction flag
+// state. Recovery checks if the ledger was created and the genesis block was committed successfully (or the block storage
+// was bootstrapped from a snapshot) then it completes the last step of adding the ledger id to the list of created ledgers.
+// Else, it clears the under construction flag
 func (provider *Provider) recoverUnderConstructionLedger() {
 	logger.Debugf("Recovering under construction ledger")
 	ledgerID, err := provider.idStore.getUnderConstructionFlag()
@@ -198,13 +398,32 @@ func (provider *Provider) recoverUnderConstructionLedger() {
 		panicOnErr(err, "Error while retrieving genesis block from blockchain for ledger [%s]", ledgerID)
 		panicOnErr(provider.idStore.createLedgerID(ledgerID, genesisBlock), "Error while adding ledgerID [%s] to created list", ledgerID)
 	default:
-		panic(fmt.Errorf(
-			"Data inconsistency: under construction flag is set for ledger [%s] while the height of the blockchain is [%d]",
-			ledgerID, bcInfo.Height))
+		// a ledger that is created from a snapshot is considered as created once the block storage is bootstrapped.
+		// Such a block storage treats the blocks before the snapshot as pruned and hence, an iteration from the
+		// genesis block fails (though the config block from the snapshot may still be retrievable by the number)
+		if !isBootstrappedFromSnapshot(ledger) {
+			panic(fmt.Errorf(
+				"Data inconsistency: under construction flag is set for ledger [%s] while the height of the blockchain is [%d]",
+				ledgerID, bcInfo.Height))
+		}
+		logger.Infof("Block storage was bootstrapped from a snapshot. Hence, marking the peer ledger as created")
+		lastBlock, err := ledger.GetBlockByNumber(bcInfo.Height - 1)
+		panicOnErr(err, "Error while retrieving last block from blockchain for ledger [%s]", ledgerID)
+		panicOnErr(provider.idStore.createLedgerID(ledgerID, lastBlock), "Error while adding ledgerID [%s] to created list", ledgerID)
 	}
 	return
 }
 
+func isBootstrappedFromSnapshot(l ledger.PeerLedger) bool {
+	itr, err := l.GetBlocksIterator(0)
+	if err != nil {
+		return false
+	}
+	defer itr.Close()
+	_, err = itr.Next()
+	return err == blkstorage.ErrPruned
+}
+
 // runCleanup cleans up blockstorage, statedb, and historydb for what
 // may have got created during in-complete ledger creation
 func (provider *Provider) runCleanup(ledgerID string) error {
@@ -231,8 +450,8 @@ type idStore struct {
 	db *leveldbhelper.DB
 }
 
-func openIDStore(path string) *idStore {
-	db := leveldbhelper.CreateDB(&leveldbhelper.Conf{DBPath: path})
+func openIDStore(path string, inMemory bool) *idStore {
+	db := leveldbhelper.CreateDB(&leveldbhelper.Conf{DBPath: path, InMemory: inMemory})
 	db.Open()
 	return &idStore{db}
 }
@@ -284,17 +503,29 @@ func (s *idStore) ledgerIDExists(ledgerID string) (bool, error) {
 
 func (s *idStore) getAllLedgerIds() ([]string, error) {
 	var ids []string
-	itr := s.db.GetIterator(nil, nil)
-	itr.First()
-	for itr.Valid() {
-		if bytes.Equal(itr.Key(), underConstructionLedgerKey) {
-			continue
-		}
+	itr := s.db.GetIterator(ledgerKeyPrefix, ledgerKeyStop)
+	defer itr.Release()
+	for itr.Next() {
 		id := string(s.decodeLedgerID(itr.Key()))
 		ids = append(ids, id)
-		itr.Next()
 	}
-	return ids, nil
+	return ids, itr.Error()
+}
+
+func (s *idStore) setRebuildDBsState(ledgerID string, state rebuildDBsState) error {
+	return s.db.Put(s.encodeRebuildDBsKey(ledgerID), []byte{byte(state)}, true)
+}
+
+func (s *idStore) unsetRebuildDBsState(ledgerID string) error {
+	return s.db.Delete(s.encodeRebuildDBsKey(ledgerID), true)
+}
+
+func (s *idStore) getRebuildDBsState(ledgerID string) (rebuildDBsState, error) {
+	val, err := s.db.Get(s.encodeRebuildDBsKey(ledgerID))
+	if err != nil || len(val) == 0 {
+		return rebuildDBsNotPending, err
+	}
+	return rebuildDBsState(val[0]), nil
 }
 
 func (s *idStore) close() {
@@ -305,6 +536,10 @@ func (s *idStore) encodeLedgerKey(ledgerID string) []byte {
 	return append(ledgerKeyPrefix, []byte(ledgerID)...)
 }
 
+func (s *idStore) encodeRebuildDBsKey(ledgerID string) []byte {
+	return append(append([]byte{}, rebuildDBsKeyPrefix...), []byte(ledgerID)...)
+}
+
 func (s *idStore) decodeLedgerID(key []byte) string {
 	return string(key[len(ledgerKeyPrefix):])
 }
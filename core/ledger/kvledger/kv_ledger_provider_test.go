@@ -35,7 +35,15 @@ import (
 )
 
 func TestLedgerProvider(t *testing.T) {
-	env := newTestEnv(t)
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			testLedgerProvider(t, providerType)
+		})
+	}
+}
+
+func testLedgerProvider(t *testing.T, providerType testProviderType) {
+	env := newTestEnv(t, providerType)
 	defer env.cleanup()
 	numLedgers := 10
 	provider, _ := NewProvider()
@@ -82,8 +90,30 @@ func TestLedgerProvider(t *testing.T) {
 	testutil.AssertEquals(t, err, ErrNonExistingLedgerID)
 }
 
+func TestInMemoryLedgerProvider(t *testing.T) {
+	env := newTestEnv(t, inMemoryProvider)
+	defer env.cleanup()
+	provider, err := NewProvider()
+	testutil.AssertNoError(t, err, "")
+	gb, _ := configtxtest.MakeGenesisBlock(constructTestLedgerID(0))
+	_, err = provider.Create(gb)
+	testutil.AssertNoError(t, err, "")
+	provider.Close()
+	// nothing should be written to the file system
+	_, err = os.Stat(ledgerconfig.GetRootPath())
+	testutil.AssertEquals(t, os.IsNotExist(err), true)
+}
+
 func TestRecovery(t *testing.T) {
-	env := newTestEnv(t)
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			testRecovery(t, providerType)
+		})
+	}
+}
+
+func testRecovery(t *testing.T, providerType testProviderType) {
+	env := newTestEnv(t, providerType)
 	defer env.cleanup()
 	provider, _ := NewProvider()
 
@@ -123,8 +153,151 @@ func TestRecovery(t *testing.T) {
 
 }
 
+func TestLedgerRollback(t *testing.T) {
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			testLedgerRollback(t, providerType)
+		})
+	}
+}
+
+func testLedgerRollback(t *testing.T, providerType testProviderType) {
+	env := newTestEnv(t, providerType)
+	defer env.cleanup()
+	viper.Set("ledger.history.enableHistoryDatabase", true)
+	ledgerid := "testledger"
+	provider, _ := NewProvider()
+	bg, gb := testutil.NewBlockGenerator(t, ledgerid, false)
+	ledger, _ := provider.Create(gb)
+	var blocks []*common.Block
+	for i := 1; i <= 3; i++ {
+		simulator, _ := ledger.NewTxSimulator(util.GenerateUUID())
+		simulator.SetState("ns1", "key1", []byte(fmt.Sprintf("value%d", i)))
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		pubSimBytes, _ := simRes.GetPubSimulationBytes()
+		block := bg.NextBlock([][]byte{pubSimBytes})
+		testutil.AssertNoError(t, ledger.CommitWithPvtData(&ledgerproto.BlockAndPvtData{Block: block}), "")
+		blocks = append(blocks, block)
+	}
+	ledger.Close()
+
+	testutil.AssertEquals(t, provider.Rollback("non-existing-ledger", 1), ErrNonExistingLedgerID)
+	testutil.AssertError(t, provider.Rollback(ledgerid, 4), "")
+	testutil.AssertNoError(t, provider.Rollback(ledgerid, 1), "")
+	provider.Close()
+
+	// the state and the history are rebuilt from the retained blocks when the ledger is opened
+	provider, _ = NewProvider()
+	defer provider.Close()
+	ledger, err := provider.Open(ledgerid)
+	testutil.AssertNoError(t, err, "")
+	defer ledger.Close()
+	bcInfo, _ := ledger.GetBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo, &common.BlockchainInfo{
+		Height: 2, CurrentBlockHash: blocks[0].Header.Hash(), PreviousBlockHash: gb.Header.Hash()})
+	qe, _ := ledger.NewQueryExecutor()
+	value, _ := qe.GetState("ns1", "key1")
+	qe.Done()
+	testutil.AssertEquals(t, value, []byte("value1"))
+	hqe, _ := ledger.NewHistoryQueryExecutor()
+	itr, _ := hqe.GetHistoryForKey("ns1", "key1")
+	numHistoryEntries := 0
+	for kmod, _ := itr.Next(); kmod != nil; kmod, _ = itr.Next() {
+		testutil.AssertEquals(t, kmod.(*queryresult.KeyModification).Value, []byte("value1"))
+		numHistoryEntries++
+	}
+	itr.Close()
+	testutil.AssertEquals(t, numHistoryEntries, 1)
+
+	// the rolled back blocks can be committed again
+	testutil.AssertNoError(t, ledger.CommitWithPvtData(&ledgerproto.BlockAndPvtData{Block: blocks[1]}), "")
+	qe, _ = ledger.NewQueryExecutor()
+	value, _ = qe.GetState("ns1", "key1")
+	qe.Done()
+	testutil.AssertEquals(t, value, []byte("value2"))
+}
+
+func TestLedgerRebuildDBs(t *testing.T) {
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			testLedgerRebuildDBs(t, providerType)
+		})
+	}
+}
+
+func testLedgerRebuildDBs(t *testing.T, providerType testProviderType) {
+	env := newTestEnv(t, providerType)
+	defer env.cleanup()
+	viper.Set("ledger.history.enableHistoryDatabase", false)
+	ledgerid := "testledger"
+	provider, _ := NewProvider()
+	bg, gb := testutil.NewBlockGenerator(t, ledgerid, false)
+	ledger, _ := provider.Create(gb)
+	for i := 1; i <= 3; i++ {
+		simulator, _ := ledger.NewTxSimulator(util.GenerateUUID())
+		simulator.SetState("ns1", "key1", []byte(fmt.Sprintf("value%d", i)))
+		simulator.Done()
+		simRes, _ := simulator.GetTxSimulationResults()
+		pubSimBytes, _ := simRes.GetPubSimulationBytes()
+		block := bg.NextBlock([][]byte{pubSimBytes})
+		testutil.AssertNoError(t, ledger.CommitWithPvtData(&ledgerproto.BlockAndPvtData{Block: block}), "")
+	}
+	ledger.Close()
+
+	// enable the history db after the blocks are committed, the history is built during the rebuild
+	viper.Set("ledger.history.enableHistoryDatabase", true)
+	testutil.AssertEquals(t, provider.RebuildDBs("non-existing-ledger"), ErrNonExistingLedgerID)
+	testutil.AssertNoError(t, provider.RebuildDBs(ledgerid), "")
+	p := provider.(*Provider)
+	rebuildState, _ := p.idStore.getRebuildDBsState(ledgerid)
+	testutil.AssertEquals(t, rebuildState, rebuildDBsDropPending)
+	ids, _ := provider.List()
+	testutil.AssertEquals(t, ids, []string{ledgerid})
+
+	// simulate a crash after the dbs are dropped, the rebuild should be resumed rather than restarted
+	testutil.AssertNoError(t, p.dropDBsForRebuild(ledgerid), "")
+	testutil.AssertNoError(t, provider.RebuildDBs(ledgerid), "")
+	rebuildState, _ = p.idStore.getRebuildDBsState(ledgerid)
+	testutil.AssertEquals(t, rebuildState, rebuildDBsRecommitPending)
+	provider.Close()
+
+	provider, _ = NewProvider()
+	defer provider.Close()
+	ledger, err := provider.Open(ledgerid)
+	testutil.AssertNoError(t, err, "")
+	defer ledger.Close()
+	rebuildState, _ = provider.(*Provider).idStore.getRebuildDBsState(ledgerid)
+	testutil.AssertEquals(t, rebuildState, rebuildDBsNotPending)
+	bcInfo, _ := ledger.GetBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo.Height, uint64(4))
+	block, err := ledger.GetBlockByNumber(2)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, block.Header.Number, uint64(2))
+	qe, _ := ledger.NewQueryExecutor()
+	value, _ := qe.GetState("ns1", "key1")
+	qe.Done()
+	testutil.AssertEquals(t, value, []byte("value3"))
+	hqe, _ := ledger.NewHistoryQueryExecutor()
+	itr, _ := hqe.GetHistoryForKey("ns1", "key1")
+	numHistoryEntries := 0
+	for kmod, _ := itr.Next(); kmod != nil; kmod, _ = itr.Next() {
+		numHistoryEntries++
+	}
+	itr.Close()
+	testutil.AssertEquals(t, numHistoryEntries, 3)
+}
+
 func TestMultipleLedgerBasicRW(t *testing.T) {
-	env := newTestEnv(t)
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			testMultipleLedgerBasicRW(t, providerType)
+		})
+	}
+}
+
+func testMultipleLedgerBasicRW(t *testing.T, providerType testProviderType) {
+	env := newTestEnv(t, providerType)
 	defer env.cleanup()
 	numLedgers := 10
 	provider, _ := NewProvider()
@@ -176,7 +349,7 @@ func TestLedgerBackup(t *testing.T) {
 	viper.Set("ledger.history.enableHistoryDatabase", true)
 
 	// create and populate a ledger in the original environment
-	env := createTestEnv(t, originalPath)
+	env := createTestEnv(t, originalPath, fileSystemProvider)
 	provider, _ := NewProvider()
 	bg, gb := testutil.NewBlockGenerator(t, ledgerid, false)
 	gbHash := gb.Header.Hash()
@@ -208,7 +381,7 @@ func TestLedgerBackup(t *testing.T) {
 	provider.Close()
 
 	// Create restore environment
-	env = createTestEnv(t, restorePath)
+	env = createTestEnv(t, restorePath, fileSystemProvider)
 
 	// remove the statedb, historydb, and block indexes (they are supposed to be auto created during opening of an existing ledger)
 	// and rename the originalPath to restorePath
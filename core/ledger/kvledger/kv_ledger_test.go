@@ -21,14 +21,19 @@ import (
 	"os"
 	"testing"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
 	"github.com/hyperledger/fabric/common/ledger/testutil"
+	mockmetrics "github.com/hyperledger/fabric/common/mocks/metrics"
 	"github.com/hyperledger/fabric/common/util"
 	lgr "github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
 	ledgertestutil "github.com/hyperledger/fabric/core/ledger/testutil"
 	"github.com/hyperledger/fabric/protos/common"
 	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+	"github.com/hyperledger/fabric/protos/ledger/rwset"
+	"github.com/hyperledger/fabric/protos/ledger/rwset/kvrwset"
 	"github.com/hyperledger/fabric/protos/peer"
 	putils "github.com/hyperledger/fabric/protos/utils"
 	"github.com/spf13/viper"
@@ -46,7 +51,15 @@ func TestMain(m *testing.M) {
 }
 
 func TestKVLedgerBlockStorage(t *testing.T) {
-	env := newTestEnv(t)
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			testKVLedgerBlockStorage(t, providerType)
+		})
+	}
+}
+
+func testKVLedgerBlockStorage(t *testing.T, providerType testProviderType) {
+	env := newTestEnv(t, providerType)
 	defer env.cleanup()
 	provider, _ := NewProvider()
 	defer provider.Close()
@@ -128,8 +141,16 @@ func TestKVLedgerBlockStorage(t *testing.T) {
 }
 
 func TestKVLedgerBlockStorageWithPvtdata(t *testing.T) {
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			testKVLedgerBlockStorageWithPvtdata(t, providerType)
+		})
+	}
+}
+
+func testKVLedgerBlockStorageWithPvtdata(t *testing.T, providerType testProviderType) {
 	t.Skip()
-	env := newTestEnv(t)
+	env := newTestEnv(t, providerType)
 	defer env.cleanup()
 	provider, _ := NewProvider()
 	defer provider.Close()
@@ -189,8 +210,152 @@ func TestKVLedgerBlockStorageWithPvtdata(t *testing.T) {
 	testutil.AssertNil(t, pvtdataAndBlock.BlockPvtData)
 }
 
+func TestKVLedgerCommitPvtDataOfOldBlocks(t *testing.T) {
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			testKVLedgerCommitPvtDataOfOldBlocks(t, providerType)
+		})
+	}
+}
+
+func testKVLedgerCommitPvtDataOfOldBlocks(t *testing.T, providerType testProviderType) {
+	env := newTestEnv(t, providerType)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	testLedgerid := "testLedger"
+	bg, gb := testutil.NewBlockGenerator(t, testLedgerid, false)
+	ledger, _ := provider.Create(gb)
+	defer ledger.Close()
+
+	// the block is committed while the pvt data of the transaction is missing
+	blockAndPvtdata1 := prepareNextBlockForTest(t, ledger, bg, "SimulateForBlk1",
+		map[string]string{"key1": "value1.1"},
+		map[string]string{"key1": "pvtValue1.1", "key2": "pvtValue2.1"})
+	pvtdata := blockAndPvtdata1.BlockPvtData[0]
+	blockAndPvtdata1.BlockPvtData = nil
+	blockAndPvtdata1.Missing = []lgr.MissingPrivateData{{TxId: "SimulateForBlk1", SeqInBlock: 0, Namespace: "ns", Collection: "coll"}}
+	assert.NoError(t, ledger.CommitWithPvtData(blockAndPvtdata1))
+
+	expectedMissingPvtDataInfo := make(lgr.MissingPvtDataInfo)
+	expectedMissingPvtDataInfo.Add(1, 0, "ns", "coll")
+	missingPvtDataInfo, err := ledger.GetMissingPvtDataInfoForMostRecentBlocks(10)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedMissingPvtDataInfo, missingPvtDataInfo)
+
+	// pvt data that does not match the hash in the block is not committed
+	tamperedPvtdata := proto.Clone(pvtdata.WriteSet).(*rwset.TxPvtReadWriteSet)
+	tamperedPvtdata.NsPvtRwset[0].CollectionPvtRwset[0].Rwset = []byte("tampered-rwset")
+	hashMismatches, err := ledger.CommitPvtDataOfOldBlocks([]*lgr.BlockPvtData{
+		{BlockNum: 1, WriteSets: map[uint64]*lgr.TxPvtData{0: {SeqInBlock: 0, WriteSet: tamperedPvtdata}}},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, hashMismatches, 1)
+	assert.Equal(t, uint64(1), hashMismatches[0].BlockNum)
+	assert.Equal(t, uint64(0), hashMismatches[0].TxNum)
+	assert.Equal(t, "ns", hashMismatches[0].Namespace)
+	assert.Equal(t, "coll", hashMismatches[0].Collection)
+	missingPvtDataInfo, err = ledger.GetMissingPvtDataInfoForMostRecentBlocks(10)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedMissingPvtDataInfo, missingPvtDataInfo)
+
+	// the matching pvt data is committed to both the pvt data store and the state
+	hashMismatches, err = ledger.CommitPvtDataOfOldBlocks([]*lgr.BlockPvtData{
+		{BlockNum: 1, WriteSets: map[uint64]*lgr.TxPvtData{0: pvtdata}},
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, hashMismatches)
+	missingPvtDataInfo, err = ledger.GetMissingPvtDataInfoForMostRecentBlocks(10)
+	assert.NoError(t, err)
+	assert.Empty(t, missingPvtDataInfo)
+
+	retrievedPvtdata, err := ledger.GetPvtDataByNum(1, nil)
+	assert.NoError(t, err)
+	assert.Len(t, retrievedPvtdata, 1)
+	assert.True(t, proto.Equal(pvtdata.WriteSet, retrievedPvtdata[0].WriteSet))
+	checkStateDBForTest(t, ledger, map[string]string{"key1": "value1.1"},
+		map[string]string{"key1": "pvtValue1.1", "key2": "pvtValue2.1"})
+}
+
+func TestKVLedgerPrune(t *testing.T) {
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			testKVLedgerPrune(t, providerType)
+		})
+	}
+}
+
+func testKVLedgerPrune(t *testing.T, providerType testProviderType) {
+	env := newTestEnv(t, providerType)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	ledger, _ := provider.Create(gb)
+	defer ledger.Close()
+	for _, block := range bg.NextTestBlocks(5) {
+		assert.NoError(t, ledger.CommitWithPvtData(&lgr.BlockAndPvtData{Block: block}))
+	}
+
+	// the blocks in the file system are pruned by block file, and all of them fit in the current block
+	// file which is never pruned, while the blocks in the memory are pruned one by one
+	assert.NoError(t, ledger.Prune(&blkstorage.RetainLastNBlocks{NumBlocks: 1, Action: blkstorage.PruneActionDelete}))
+	for i := uint64(0); i < 6; i++ {
+		_, err := ledger.GetBlockByNumber(i)
+		if providerType.inMemory && i < 5 {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+	assert.Error(t, ledger.Prune(&blkstorage.RetainLastNBlocks{NumBlocks: 0}))
+	assert.Error(t, ledger.Prune("unsupported-policy"))
+}
+
+func TestKVLedgerMetrics(t *testing.T) {
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			testKVLedgerMetrics(t, providerType)
+		})
+	}
+}
+
+func testKVLedgerMetrics(t *testing.T, providerType testProviderType) {
+	env := newTestEnv(t, providerType)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	ledger, _ := provider.Create(gb)
+	defer ledger.Close()
+	scope := mockmetrics.NewScope()
+	ledger.(*kvLedger).metrics = scope
+	for _, block := range bg.NextTestBlocks(3) {
+		assert.NoError(t, ledger.CommitWithPvtData(&lgr.BlockAndPvtData{Block: block}))
+	}
+
+	for _, name := range []string{
+		"state_validation_duration",
+		"blockstore_commit_duration",
+		"statedb_commit_duration",
+		"block_processing_duration",
+	} {
+		assert.Len(t, scope.HistogramValues(name, nil), 3, name)
+	}
+}
+
 func TestKVLedgerDBRecovery(t *testing.T) {
-	env := newTestEnv(t)
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			testKVLedgerDBRecovery(t, providerType)
+		})
+	}
+}
+
+func testKVLedgerDBRecovery(t *testing.T, providerType testProviderType) {
+	env := newTestEnv(t, providerType)
 	defer env.cleanup()
 	provider, _ := NewProvider()
 	defer provider.Close()
@@ -226,7 +391,8 @@ func TestKVLedgerDBRecovery(t *testing.T) {
 		map[string]string{"key1": "value1.2", "key2": "value2.2", "key3": "value3.2"},
 		map[string]string{"key1": "pvtValue1.2", "key2": "pvtValue2.2", "key3": "pvtValue3.2"})
 
-	assert.NoError(t, ledger.(*kvLedger).txtmgmt.ValidateAndPrepare(blockAndPvtdata2, true))
+	_, err := ledger.(*kvLedger).txtmgmt.ValidateAndPrepare(blockAndPvtdata2, true)
+	assert.NoError(t, err)
 	assert.NoError(t, ledger.(*kvLedger).blockStore.CommitWithPvtData(blockAndPvtdata2))
 
 	// block storage should be as of block-2 but the state and history db should be as of block-1
@@ -273,7 +439,8 @@ func TestKVLedgerDBRecovery(t *testing.T) {
 		map[string]string{"key1": "value1.3", "key2": "value2.3", "key3": "value3.3"},
 		map[string]string{"key1": "pvtValue1.3", "key2": "pvtValue2.3", "key3": "pvtValue3.3"},
 	)
-	assert.NoError(t, ledger.(*kvLedger).txtmgmt.ValidateAndPrepare(blockAndPvtdata3, true))
+	_, err = ledger.(*kvLedger).txtmgmt.ValidateAndPrepare(blockAndPvtdata3, true)
+	assert.NoError(t, err)
 	assert.NoError(t, ledger.(*kvLedger).blockStore.CommitWithPvtData(blockAndPvtdata3))
 	// committing the transaction to state DB
 	assert.NoError(t, ledger.(*kvLedger).txtmgmt.Commit())
@@ -324,7 +491,8 @@ func TestKVLedgerDBRecovery(t *testing.T) {
 		map[string]string{"key1": "pvtValue1.4", "key2": "pvtValue2.4", "key3": "pvtValue3.4"},
 	)
 
-	assert.NoError(t, ledger.(*kvLedger).txtmgmt.ValidateAndPrepare(blockAndPvtdata4, true))
+	_, err = ledger.(*kvLedger).txtmgmt.ValidateAndPrepare(blockAndPvtdata4, true)
+	assert.NoError(t, err)
 	assert.NoError(t, ledger.(*kvLedger).blockStore.CommitWithPvtData(blockAndPvtdata4))
 	assert.NoError(t, ledger.(*kvLedger).historyDB.Commit(blockAndPvtdata4.Block))
 
@@ -364,6 +532,14 @@ func TestKVLedgerDBRecovery(t *testing.T) {
 }
 
 func TestLedgerWithCouchDbEnabledWithBinaryAndJSONData(t *testing.T) {
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			testLedgerWithCouchDbEnabledWithBinaryAndJSONData(t, providerType)
+		})
+	}
+}
+
+func testLedgerWithCouchDbEnabledWithBinaryAndJSONData(t *testing.T, providerType testProviderType) {
 
 	//call a helper method to load the core.yaml
 	ledgertestutil.SetupCoreYAMLConfig()
@@ -371,7 +547,7 @@ func TestLedgerWithCouchDbEnabledWithBinaryAndJSONData(t *testing.T) {
 	logger.Debugf("TestLedgerWithCouchDbEnabledWithBinaryAndJSONData  IsCouchDBEnabled()value: %v , IsHistoryDBEnabled()value: %v\n",
 		ledgerconfig.IsCouchDBEnabled(), ledgerconfig.IsHistoryDBEnabled())
 
-	env := newTestEnv(t)
+	env := newTestEnv(t, providerType)
 	defer env.cleanup()
 	provider, _ := NewProvider()
 	defer provider.Close()
@@ -478,6 +654,148 @@ func TestLedgerWithCouchDbEnabledWithBinaryAndJSONData(t *testing.T) {
 	}
 }
 
+func TestKVLedgerCommitHash(t *testing.T) {
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			testKVLedgerCommitHash(t, providerType)
+		})
+	}
+}
+
+func testKVLedgerCommitHash(t *testing.T, providerType testProviderType) {
+	env := newTestEnv(t, providerType)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	ledger, _ := provider.Create(gb)
+	blockAndPvtdata1 := prepareNextBlockForTest(t, ledger, bg, "SimulateForBlk1",
+		map[string]string{"key1": "value1.1", "key2": "value2.1"},
+		map[string]string{"key1": "pvtValue1.1"})
+	assert.NoError(t, ledger.CommitWithPvtData(blockAndPvtdata1))
+	blockAndPvtdata2 := prepareNextBlockForTest(t, ledger, bg, "SimulateForBlk2",
+		map[string]string{"key1": "value1.2"},
+		map[string]string{"key1": "pvtValue1.2"})
+	assert.NoError(t, ledger.CommitWithPvtData(blockAndPvtdata2))
+
+	commitHashes := retrieveCommitHashesForTest(t, ledger, 3)
+	for i, commitHash := range commitHashes {
+		assert.Len(t, commitHash, 32, "block [%d] is expected to carry a commit hash", i)
+	}
+	assert.NotEqual(t, commitHashes[0], commitHashes[1])
+	assert.NotEqual(t, commitHashes[1], commitHashes[2])
+	ledger.Close()
+	provider.Close()
+
+	// the chain of the commit hashes is resumed from the last block when the ledger is opened
+	provider, _ = NewProvider()
+	ledger, _ = provider.Open("testLedger")
+	assert.Equal(t, commitHashes[2], ledger.(*kvLedger).commitHash)
+	ledger.Close()
+	provider.Close()
+
+	// another peer that commits the same blocks computes the same commit hashes,
+	// irrespective of the availability of the pvt data at the time of commit
+	env.cleanup()
+	provider, _ = NewProvider()
+	defer provider.Close()
+	ledger, _ = provider.Create(gb)
+	defer ledger.Close()
+	assert.NoError(t, ledger.CommitWithPvtData(&lgr.BlockAndPvtData{Block: blockAndPvtdata1.Block}))
+	assert.NoError(t, ledger.CommitWithPvtData(blockAndPvtdata2))
+	assert.Equal(t, commitHashes, retrieveCommitHashesForTest(t, ledger, 3))
+
+	// the commit hash is not computed if the last block does not carry a commit hash, as is the case
+	// for a ledger that has been created before the introduction of the commit hash
+	ledger.(*kvLedger).commitHash = nil
+	block3 := bg.NextTestBlocks(1)[0]
+	assert.NoError(t, ledger.CommitWithPvtData(&lgr.BlockAndPvtData{Block: block3}))
+	assert.Nil(t, retrieveCommitHashesForTest(t, ledger, 4)[3])
+}
+
+func TestKVLedgerStateListener(t *testing.T) {
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			testKVLedgerStateListener(t, providerType)
+		})
+	}
+}
+
+func testKVLedgerStateListener(t *testing.T, providerType testProviderType) {
+	env := newTestEnv(t, providerType)
+	defer env.cleanup()
+	provider, _ := NewProvider()
+	defer provider.Close()
+	listener := &testStateListener{namespace: "ns"}
+	provider.Initialize([]lgr.StateListener{listener})
+
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	ledger, _ := provider.Create(gb)
+	defer ledger.Close()
+
+	// the listener receives the public and pvt writes of the block and is notified of the commit
+	blockAndPvtdata1 := prepareNextBlockForTest(t, ledger, bg, "SimulateForBlk1",
+		map[string]string{"key1": "value1.1"},
+		map[string]string{"key1": "pvtValue1.1"})
+	assert.NoError(t, ledger.CommitWithPvtData(blockAndPvtdata1))
+	assert.Equal(t, &lgr.StateUpdateTrigger{
+		LedgerID: "testLedger",
+		StateUpdates: lgr.StateUpdates{
+			"ns": {
+				PublicUpdates: []*kvrwset.KVWrite{{Key: "key1", Value: []byte("value1.1")}},
+				PvtUpdates:    map[string][]*kvrwset.KVWrite{"coll": {{Key: "key1", Value: []byte("pvtValue1.1")}}},
+			},
+		},
+		CommittingBlockNum: 1,
+	}, listener.receivedTrigger)
+	assert.Equal(t, []string{"testLedger"}, listener.commitsDone)
+
+	// an error returned by the listener fails the commit of the block
+	listener.err = fmt.Errorf("listener error")
+	blockAndPvtdata2 := prepareNextBlockForTest(t, ledger, bg, "SimulateForBlk2",
+		map[string]string{"key1": "value1.2"}, nil)
+	assert.EqualError(t, ledger.CommitWithPvtData(blockAndPvtdata2), "listener error")
+	assert.Equal(t, []string{"testLedger"}, listener.commitsDone)
+	bcInfo, _ := ledger.GetBlockchainInfo()
+	assert.Equal(t, uint64(2), bcInfo.Height)
+	qe, _ := ledger.NewQueryExecutor()
+	defer qe.Done()
+	value, _ := qe.GetState("ns", "key1")
+	assert.Equal(t, []byte("value1.1"), value)
+}
+
+type testStateListener struct {
+	namespace       string
+	err             error
+	receivedTrigger *lgr.StateUpdateTrigger
+	commitsDone     []string
+}
+
+func (l *testStateListener) InterestedInNamespaces() []string {
+	return []string{l.namespace}
+}
+
+func (l *testStateListener) HandleStateUpdates(trigger *lgr.StateUpdateTrigger) error {
+	l.receivedTrigger = trigger
+	return l.err
+}
+
+func (l *testStateListener) StateCommitDone(ledgerID string) {
+	l.commitsDone = append(l.commitsDone, ledgerID)
+}
+
+func retrieveCommitHashesForTest(t *testing.T, l lgr.PeerLedger, numBlocks uint64) [][]byte {
+	var commitHashes [][]byte
+	for i := uint64(0); i < numBlocks; i++ {
+		block, err := l.GetBlockByNumber(i)
+		assert.NoError(t, err)
+		commitHash, err := putils.GetCommitHashFromBlock(block)
+		assert.NoError(t, err)
+		commitHashes = append(commitHashes, commitHash)
+	}
+	return commitHashes
+}
+
 func prepareNextBlockForTest(t *testing.T, l lgr.PeerLedger, bg *testutil.BlockGenerator,
 	txid string, pubKVs map[string]string, pvtKVs map[string]string) *lgr.BlockAndPvtData {
 	simulator, _ := l.NewTxSimulator(txid)
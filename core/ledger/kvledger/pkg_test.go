@@ -20,20 +20,36 @@ import (
 	"os"
 	"testing"
 
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
 	"github.com/hyperledger/fabric/core/config"
 	"github.com/spf13/viper"
 )
 
+// testProviderType selects the ledger provider that `NewProvider` instantiates in a test env
+type testProviderType struct {
+	name     string
+	inMemory bool
+}
+
+var (
+	fileSystemProvider = testProviderType{name: "fileSystemProvider"}
+	inMemoryProvider   = testProviderType{name: "inMemoryProvider", inMemory: true}
+
+	// testProviderTypes are the ledger providers that the tests run against
+	testProviderTypes = []testProviderType{fileSystemProvider, inMemoryProvider}
+)
+
 type testEnv struct {
 	t testing.TB
 }
 
-func newTestEnv(t testing.TB) *testEnv {
-	return createTestEnv(t, "/tmp/fabric/ledgertests/kvledger")
+func newTestEnv(t testing.TB, providerType testProviderType) *testEnv {
+	return createTestEnv(t, "/tmp/fabric/ledgertests/kvledger", providerType)
 }
 
-func createTestEnv(t testing.TB, path string) *testEnv {
+func createTestEnv(t testing.TB, path string, providerType testProviderType) *testEnv {
 	viper.Set("peer.fileSystemPath", path)
+	viper.Set("ledger.inMemory", providerType.inMemory)
 	env := &testEnv{t}
 	env.cleanup()
 	return env
@@ -42,4 +58,5 @@ func createTestEnv(t testing.TB, path string) *testEnv {
 func (env *testEnv) cleanup() {
 	path := config.GetPath("peer.fileSystemPath")
 	os.RemoveAll(path)
+	leveldbhelper.RemoveInMemoryDBs(path)
 }
@@ -0,0 +1,457 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+const (
+	snapshotMetadataFileName    = "_snapshot_metadata.json"
+	snapshotStateFileName       = "public_and_hashed_state.data"
+	snapshotLastBlockFileName   = "last_block.data"
+	snapshotConfigBlockFileName = "config_block.data"
+
+	// snapshotImportBatchSize is the number of state entries that are applied to the state db in one batch
+	snapshotImportBatchSize = 1000
+)
+
+// snapshotMetadata is persisted in the snapshot directory along with the data files. It contains
+// the SHA-256 hash of each of the data files so that the snapshot can be verified before it is imported
+type snapshotMetadata struct {
+	ChannelName     string            `json:"channel_name"`
+	LastBlockNumber uint64            `json:"last_block_number"`
+	LastBlockHash   string            `json:"last_block_hash"`
+	SavepointTxNum  uint64            `json:"savepoint_tx_num"`
+	FileHashes      map[string]string `json:"file_hashes"`
+}
+
+// ExportSnapshot implements the corresponding method from interface ledger.PeerLedger.
+// The exported snapshot contains the public data and the hashes of the private data from the state db, along with
+// the last committed block and the latest config block. The private data itself is not exported and is expected to be
+// pulled from other peers, if required. The commits are blocked while the savepoint is read and the iterator over the
+// state is created, so that the exported state is consistent with the last block in the snapshot. If the iterator
+// reads a snapshot of the state db, as with leveldb, the commits resume before the state is exported. Otherwise, as
+// with couchdb, the commits are blocked for the whole export, and so are the block APIs called in the meantime
+func (l *kvLedger) ExportSnapshot(snapshotDir string) error {
+	l.blockAPIsRWLock.RLock()
+	locked := true
+	unlock := func() {
+		if locked {
+			l.blockAPIsRWLock.RUnlock()
+			locked = false
+		}
+	}
+	defer unlock()
+
+	savepoint, err := l.txtmgmt.GetLastSavepoint()
+	if err != nil {
+		return err
+	}
+	if savepoint == nil {
+		return fmt.Errorf("Ledger [%s] is empty, nothing to export", l.ledgerID)
+	}
+	stateItr, err := l.versionedDB.GetPubAndHashedStateIterator()
+	if err != nil {
+		return err
+	}
+	defer stateItr.Close()
+	if l.versionedDB.FullScanReadsSnapshot() {
+		unlock()
+	}
+
+	lastBlock, err := l.blockStore.RetrieveBlockByNumber(savepoint.BlockNum)
+	if err != nil {
+		return err
+	}
+	lastConfigBlockNum, err := utils.GetLastConfigIndexFromBlock(lastBlock)
+	if err != nil {
+		return err
+	}
+	configBlock, err := l.blockStore.RetrieveBlockByNumber(lastConfigBlockNum)
+	if err != nil {
+		return err
+	}
+
+	empty, err := util.CreateDirIfMissing(snapshotDir)
+	if err != nil {
+		return err
+	}
+	if !empty {
+		return fmt.Errorf("Snapshot directory [%s] is not empty", snapshotDir)
+	}
+	logger.Infof("Channel [%s]: Exporting snapshot at block [%d] to [%s]", l.ledgerID, savepoint.BlockNum, snapshotDir)
+
+	fileHashes := make(map[string]string)
+	if fileHashes[snapshotStateFileName], err = l.exportState(stateItr, filepath.Join(snapshotDir, snapshotStateFileName)); err != nil {
+		return err
+	}
+	if fileHashes[snapshotLastBlockFileName], err = writeBlockFile(filepath.Join(snapshotDir, snapshotLastBlockFileName), lastBlock); err != nil {
+		return err
+	}
+	if fileHashes[snapshotConfigBlockFileName], err = writeBlockFile(filepath.Join(snapshotDir, snapshotConfigBlockFileName), configBlock); err != nil {
+		return err
+	}
+	metadataBytes, err := json.MarshalIndent(&snapshotMetadata{
+		ChannelName:     l.ledgerID,
+		LastBlockNumber: savepoint.BlockNum,
+		LastBlockHash:   hex.EncodeToString(lastBlock.Header.Hash()),
+		SavepointTxNum:  savepoint.TxNum,
+		FileHashes:      fileHashes,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(snapshotDir, snapshotMetadataFileName), metadataBytes, 0644)
+}
+
+// exportState writes the public data and the hashed data read from the given iterator to the given file and returns
+// the hex encoded hash of the file contents. Each entry is written as a length-prefixed record that contains the
+// namespace, the collection (empty for the public data), the key (the key-hash for the hashed data), the value, and
+// the version of the entry
+func (l *kvLedger) exportState(itr statedb.ResultsIterator, filePath string) (string, error) {
+	w, err := newHashingFileWriter(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer w.close()
+	numEntries := 0
+	for {
+		queryResult, err := itr.Next()
+		if err != nil {
+			return "", err
+		}
+		if queryResult == nil {
+			break
+		}
+		kv := queryResult.(*privacyenabledstate.PubOrHashedKV)
+		record := proto.NewBuffer(nil)
+		if err := encodeStateRecord(record, kv); err != nil {
+			return "", err
+		}
+		framedRecord := proto.NewBuffer(nil)
+		if err := framedRecord.EncodeRawBytes(record.Bytes()); err != nil {
+			return "", err
+		}
+		if err := w.write(framedRecord.Bytes()); err != nil {
+			return "", err
+		}
+		numEntries++
+	}
+	logger.Debugf("Channel [%s]: Exported [%d] state entries", l.ledgerID, numEntries)
+	return w.done()
+}
+
+// CreateFromSnapshot implements the corresponding method from interface ledger.PeerLedgerProvider.
+// This function verifies the hashes of the snapshot files and sets the under construction flag before importing
+// the state. The block storage is bootstrapped in the end so that, if a crash happens in between, the
+// 'recoverUnderConstructionLedger' function treats the ledger as not created. The history db contains no history
+// for the keys in the snapshot and the expiry of the imported hashes of the private data is not tracked
+func (provider *Provider) CreateFromSnapshot(snapshotDir string) (ledger.PeerLedger, error) {
+	metadata, err := readSnapshotMetadata(snapshotDir)
+	if err != nil {
+		return nil, err
+	}
+	lastBlock, err := readBlockFile(filepath.Join(snapshotDir, snapshotLastBlockFileName))
+	if err != nil {
+		return nil, err
+	}
+	configBlock, err := readBlockFile(filepath.Join(snapshotDir, snapshotConfigBlockFileName))
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySnapshotBlocks(metadata, lastBlock, configBlock); err != nil {
+		return nil, err
+	}
+	ledgerID := metadata.ChannelName
+	exists, err := provider.idStore.ledgerIDExists(ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrLedgerIDExists
+	}
+	if err = provider.idStore.setUnderConstructionFlag(ledgerID); err != nil {
+		return nil, err
+	}
+	logger.Infof("Creating ledger [%s] from snapshot [%s] at block [%d]", ledgerID, snapshotDir, metadata.LastBlockNumber)
+	savepoint := version.NewHeight(metadata.LastBlockNumber, metadata.SavepointTxNum)
+	if err := provider.importSnapshot(ledgerID, snapshotDir, savepoint, lastBlock, configBlock); err != nil {
+		logger.Errorf("Error in importing the snapshot. Unsetting under construction flag. Err: %s", err)
+		panicOnErr(provider.runCleanup(ledgerID), "Error while running cleanup for ledger id [%s]", ledgerID)
+		panicOnErr(provider.idStore.unsetUnderConstructionFlag(), "Error while unsetting under construction flag")
+		return nil, err
+	}
+	lgr, err := provider.openInternal(ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	panicOnErr(provider.idStore.createLedgerID(ledgerID, lastBlock), "Error while marking ledger as created")
+	return lgr, nil
+}
+
+func (provider *Provider) importSnapshot(ledgerID, snapshotDir string, savepoint *version.Height,
+	lastBlock, configBlock *common.Block) error {
+	vDB, err := provider.vdbProvider.GetDBHandle(ledgerID)
+	if err != nil {
+		return err
+	}
+	if err := importState(vDB, filepath.Join(snapshotDir, snapshotStateFileName), savepoint); err != nil {
+		return err
+	}
+	historyDB, err := provider.historydbProvider.GetDBHandle(ledgerID)
+	if err != nil {
+		return err
+	}
+	if err := historyDB.RecordSavepoint(savepoint); err != nil {
+		return err
+	}
+	return provider.ledgerStoreProvider.BootstrapFromSnapshot(ledgerID,
+		&blkstorage.SnapshotInfo{LastBlock: lastBlock, ConfigBlock: configBlock})
+}
+
+// importState reads the state entries from the given file and applies them to the state db in batches
+func importState(vDB privacyenabledstate.DB, filePath string, savepoint *version.Height) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	reader := bufio.NewReader(f)
+	batch := privacyenabledstate.NewUpdateBatch()
+	numEntriesInBatch := 0
+	for {
+		kv, err := readStateRecord(reader)
+		if err != nil {
+			return err
+		}
+		if kv == nil {
+			break
+		}
+		if kv.IsHashed() {
+			batch.HashUpdates.Put(kv.Namespace, kv.CollectionName, []byte(kv.Key), kv.Value, kv.Version)
+		} else {
+			batch.PubUpdates.PutValAndMetadata(kv.Namespace, kv.Key, kv.Value, kv.Metadata, kv.Version)
+		}
+		numEntriesInBatch++
+		if numEntriesInBatch == snapshotImportBatchSize {
+			if err := vDB.ApplyPrivacyAwareUpdates(batch, savepoint); err != nil {
+				return err
+			}
+			batch = privacyenabledstate.NewUpdateBatch()
+			numEntriesInBatch = 0
+		}
+	}
+	// the last batch is applied even if empty so that the savepoint is recorded in the state db
+	return vDB.ApplyPrivacyAwareUpdates(batch, savepoint)
+}
+
+func encodeStateRecord(buf *proto.Buffer, kv *privacyenabledstate.PubOrHashedKV) error {
+	for _, s := range []string{kv.Namespace, kv.CollectionName, kv.Key} {
+		if err := buf.EncodeStringBytes(s); err != nil {
+			return err
+		}
+	}
+	if err := buf.EncodeRawBytes(kv.Value); err != nil {
+		return err
+	}
+	if err := buf.EncodeVarint(kv.Version.BlockNum); err != nil {
+		return err
+	}
+	if err := buf.EncodeVarint(kv.Version.TxNum); err != nil {
+		return err
+	}
+	return buf.EncodeRawBytes(kv.Metadata)
+}
+
+// readStateRecord reads the next length-prefixed state record. A nil record is returned at the end of the file
+func readStateRecord(reader *bufio.Reader) (*privacyenabledstate.PubOrHashedKV, error) {
+	recordLen, err := binary.ReadUvarint(reader)
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	recordBytes := make([]byte, recordLen)
+	if _, err := io.ReadFull(reader, recordBytes); err != nil {
+		return nil, err
+	}
+	buf := proto.NewBuffer(recordBytes)
+	var fields [3]string
+	for i := range fields {
+		if fields[i], err = buf.DecodeStringBytes(); err != nil {
+			return nil, err
+		}
+	}
+	value, err := buf.DecodeRawBytes(false)
+	if err != nil {
+		return nil, err
+	}
+	blockNum, err := buf.DecodeVarint()
+	if err != nil {
+		return nil, err
+	}
+	txNum, err := buf.DecodeVarint()
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := buf.DecodeRawBytes(false)
+	if err != nil {
+		return nil, err
+	}
+	if len(metadata) == 0 {
+		metadata = nil
+	}
+	return &privacyenabledstate.PubOrHashedKV{
+		Namespace:      fields[0],
+		CollectionName: fields[1],
+		Key:            fields[2],
+		VersionedValue: &statedb.VersionedValue{Value: value, Metadata: metadata, Version: version.NewHeight(blockNum, txNum)},
+	}, nil
+}
+
+func writeBlockFile(filePath string, block *common.Block) (string, error) {
+	blockBytes, err := proto.Marshal(block)
+	if err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(filePath, blockBytes, 0644); err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(blockBytes)
+	return hex.EncodeToString(h[:]), nil
+}
+
+func readBlockFile(filePath string) (*common.Block, error) {
+	blockBytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	block := &common.Block{}
+	if err := proto.Unmarshal(blockBytes, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// readSnapshotMetadata reads the snapshot metadata and verifies the hash of each of the snapshot files
+func readSnapshotMetadata(snapshotDir string) (*snapshotMetadata, error) {
+	metadataBytes, err := ioutil.ReadFile(filepath.Join(snapshotDir, snapshotMetadataFileName))
+	if err != nil {
+		return nil, err
+	}
+	metadata := &snapshotMetadata{}
+	if err := json.Unmarshal(metadataBytes, metadata); err != nil {
+		return nil, fmt.Errorf("Error while unmarshalling snapshot metadata: %s", err)
+	}
+	for _, fileName := range []string{snapshotStateFileName, snapshotLastBlockFileName, snapshotConfigBlockFileName} {
+		expectedHash, ok := metadata.FileHashes[fileName]
+		if !ok {
+			return nil, fmt.Errorf("Snapshot metadata does not contain the hash for the file [%s]", fileName)
+		}
+		actualHash, err := computeFileHash(filepath.Join(snapshotDir, fileName))
+		if err != nil {
+			return nil, err
+		}
+		if actualHash != expectedHash {
+			return nil, fmt.Errorf("Hash mismatch for the snapshot file [%s]: expected [%s], computed [%s]",
+				fileName, expectedHash, actualHash)
+		}
+	}
+	return metadata, nil
+}
+
+func verifySnapshotBlocks(metadata *snapshotMetadata, lastBlock, configBlock *common.Block) error {
+	if lastBlock.Header == nil || configBlock.Header == nil {
+		return fmt.Errorf("Snapshot blocks should contain the block header")
+	}
+	if lastBlock.Header.Number != metadata.LastBlockNumber ||
+		hex.EncodeToString(lastBlock.Header.Hash()) != metadata.LastBlockHash {
+		return fmt.Errorf("Last block in the snapshot does not match the snapshot metadata")
+	}
+	channelName, err := utils.GetChainIDFromBlock(configBlock)
+	if err != nil {
+		return err
+	}
+	if channelName != metadata.ChannelName {
+		return fmt.Errorf("Channel name [%s] in the config block does not match the channel name [%s] in the snapshot metadata",
+			channelName, metadata.ChannelName)
+	}
+	return nil
+}
+
+func computeFileHash(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashingFileWriter writes to a file via a buffer and computes the hash of the bytes written
+type hashingFileWriter struct {
+	file   *os.File
+	buf    *bufio.Writer
+	hasher hash.Hash
+}
+
+func newHashingFileWriter(filePath string) (*hashingFileWriter, error) {
+	f, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &hashingFileWriter{file: f, buf: bufio.NewWriter(f), hasher: sha256.New()}, nil
+}
+
+func (w *hashingFileWriter) write(b []byte) error {
+	if _, err := w.buf.Write(b); err != nil {
+		return err
+	}
+	_, err := w.hasher.Write(b)
+	return err
+}
+
+// done flushes and syncs the file and returns the hex encoded hash of the bytes written
+func (w *hashingFileWriter) done() (string, error) {
+	if err := w.buf.Flush(); err != nil {
+		return "", err
+	}
+	if err := w.file.Sync(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(w.hasher.Sum(nil)), nil
+}
+
+func (w *hashingFileWriter) close() {
+	w.file.Close()
+}
@@ -0,0 +1,343 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kvledger
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/ledger/blkstorage"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/common/util"
+	lgr "github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotExportAndCreateLedgerFromSnapshot(t *testing.T) {
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			testSnapshotExportAndCreateLedgerFromSnapshot(t, providerType)
+		})
+	}
+}
+
+func testSnapshotExportAndCreateLedgerFromSnapshot(t *testing.T, providerType testProviderType) {
+	snapshotDir := "/tmp/fabric/ledgertests/kvledgersnapshot"
+	os.RemoveAll(snapshotDir)
+	defer os.RemoveAll(snapshotDir)
+
+	// create and populate a ledger in the original environment
+	env := newTestEnv(t, providerType)
+	provider, _ := NewProvider()
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	ledger, err := provider.Create(gb)
+	assert.NoError(t, err)
+	block1 := commitTestBlock(t, ledger, bg, func(s lgr.TxSimulator) {
+		s.SetState("ns1", "key1", []byte("value1"))
+		s.SetState("ns1", "key2", []byte("value2"))
+		s.SetState("ns2", "key1", []byte("value3"))
+		s.SetPrivateData("ns1", "coll1", "key1", []byte("pvtValue1"))
+	})
+	block2 := commitTestBlock(t, ledger, bg, func(s lgr.TxSimulator) {
+		s.SetState("ns1", "key1", []byte("value4"))
+		s.DeleteState("ns1", "key2")
+	})
+	expectedState := retrievePubAndHashedState(t, ledger)
+	assert.NoError(t, ledger.ExportSnapshot(snapshotDir))
+	// exporting to a non-empty directory should fail
+	assert.Error(t, ledger.ExportSnapshot(snapshotDir))
+	ledger.Close()
+	provider.Close()
+	env.cleanup()
+
+	// create a ledger from the snapshot in a fresh environment
+	env = newTestEnv(t, providerType)
+	defer env.cleanup()
+	provider, _ = NewProvider()
+	defer provider.Close()
+	ledger, err = provider.CreateFromSnapshot(snapshotDir)
+	assert.NoError(t, err)
+	defer ledger.Close()
+	_, err = provider.CreateFromSnapshot(snapshotDir)
+	assert.Equal(t, ErrLedgerIDExists, err)
+	ledgerIDs, _ := provider.List()
+	assert.Equal(t, []string{"testLedger"}, ledgerIDs)
+
+	bcInfo, _ := ledger.GetBlockchainInfo()
+	assert.Equal(t, &common.BlockchainInfo{
+		Height: 3, CurrentBlockHash: block2.Header.Hash(), PreviousBlockHash: block1.Header.Hash()}, bcInfo)
+	b, err := ledger.GetBlockByNumber(2)
+	assert.NoError(t, err)
+	assert.Equal(t, block2, b)
+	b, err = ledger.GetBlockByNumber(0)
+	assert.NoError(t, err)
+	assert.Equal(t, gb, b)
+	_, err = ledger.GetBlockByNumber(1)
+	assert.Equal(t, blkstorage.ErrPruned, err)
+	assert.Equal(t, expectedState, retrievePubAndHashedState(t, ledger))
+
+	// the ledger should continue from the block following the last block in the snapshot
+	commitTestBlock(t, ledger, bg, func(s lgr.TxSimulator) {
+		s.SetState("ns1", "key3", []byte("value5"))
+	})
+	qe, _ := ledger.NewQueryExecutor()
+	defer qe.Done()
+	val, _ := qe.GetState("ns1", "key1")
+	assert.Equal(t, []byte("value4"), val)
+	val, _ = qe.GetState("ns1", "key2")
+	assert.Nil(t, val)
+	val, _ = qe.GetState("ns1", "key3")
+	assert.Equal(t, []byte("value5"), val)
+	bcInfo, _ = ledger.GetBlockchainInfo()
+	assert.Equal(t, uint64(4), bcInfo.Height)
+}
+
+func TestCreateLedgerFromTamperedSnapshot(t *testing.T) {
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			testCreateLedgerFromTamperedSnapshot(t, providerType)
+		})
+	}
+}
+
+func testCreateLedgerFromTamperedSnapshot(t *testing.T, providerType testProviderType) {
+	snapshotDir := "/tmp/fabric/ledgertests/kvledgersnapshot"
+	os.RemoveAll(snapshotDir)
+	defer os.RemoveAll(snapshotDir)
+
+	env := newTestEnv(t, providerType)
+	provider, _ := NewProvider()
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	ledger, _ := provider.Create(gb)
+	commitTestBlock(t, ledger, bg, func(s lgr.TxSimulator) {
+		s.SetState("ns1", "key1", []byte("value1"))
+	})
+	assert.NoError(t, ledger.ExportSnapshot(snapshotDir))
+	ledger.Close()
+	provider.Close()
+	env.cleanup()
+
+	env = newTestEnv(t, providerType)
+	defer env.cleanup()
+	provider, _ = NewProvider()
+	defer provider.Close()
+
+	stateFile := filepath.Join(snapshotDir, snapshotStateFileName)
+	stateBytes, err := ioutil.ReadFile(stateFile)
+	assert.NoError(t, err)
+	stateBytes[len(stateBytes)-1]++
+	assert.NoError(t, ioutil.WriteFile(stateFile, stateBytes, 0644))
+	_, err = provider.CreateFromSnapshot(snapshotDir)
+	assert.Contains(t, err.Error(), "Hash mismatch for the snapshot file")
+
+	metadataFile := filepath.Join(snapshotDir, snapshotMetadataFileName)
+	metadataBytes, err := ioutil.ReadFile(metadataFile)
+	assert.NoError(t, err)
+	metadata := &snapshotMetadata{}
+	assert.NoError(t, json.Unmarshal(metadataBytes, metadata))
+	delete(metadata.FileHashes, snapshotStateFileName)
+	metadataBytes, _ = json.Marshal(metadata)
+	assert.NoError(t, ioutil.WriteFile(metadataFile, metadataBytes, 0644))
+	_, err = provider.CreateFromSnapshot(snapshotDir)
+	assert.Contains(t, err.Error(), "does not contain the hash")
+
+	exists, _ := provider.Exists("testLedger")
+	assert.False(t, exists)
+}
+
+func TestRecoveryOfLedgerCreatedFromSnapshot(t *testing.T) {
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			testRecoveryOfLedgerCreatedFromSnapshot(t, providerType)
+		})
+	}
+}
+
+func testRecoveryOfLedgerCreatedFromSnapshot(t *testing.T, providerType testProviderType) {
+	snapshotDir := "/tmp/fabric/ledgertests/kvledgersnapshot"
+	os.RemoveAll(snapshotDir)
+	defer os.RemoveAll(snapshotDir)
+
+	env := newTestEnv(t, providerType)
+	provider, _ := NewProvider()
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	ledger, _ := provider.Create(gb)
+	commitTestBlock(t, ledger, bg, func(s lgr.TxSimulator) {
+		s.SetState("ns1", "key1", []byte("value1"))
+	})
+	assert.NoError(t, ledger.ExportSnapshot(snapshotDir))
+	ledger.Close()
+	provider.Close()
+	env.cleanup()
+
+	// simulate a crash after the snapshot is imported but before the ledger is marked as created
+	env = newTestEnv(t, providerType)
+	defer env.cleanup()
+	provider, _ = NewProvider()
+	ledger, err := provider.CreateFromSnapshot(snapshotDir)
+	assert.NoError(t, err)
+	ledger.Close()
+	p := provider.(*Provider)
+	assert.NoError(t, p.idStore.db.Delete(p.idStore.encodeLedgerKey("testLedger"), true))
+	assert.NoError(t, p.idStore.setUnderConstructionFlag("testLedger"))
+	provider.Close()
+
+	provider, _ = NewProvider()
+	defer provider.Close()
+	flag, err := provider.(*Provider).idStore.getUnderConstructionFlag()
+	assert.NoError(t, err)
+	assert.Equal(t, "", flag)
+	exists, _ := provider.Exists("testLedger")
+	assert.True(t, exists)
+}
+
+func TestSnapshotExportWithConcurrentCommits(t *testing.T) {
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			testSnapshotExportWithConcurrentCommits(t, providerType)
+		})
+	}
+}
+
+func testSnapshotExportWithConcurrentCommits(t *testing.T, providerType testProviderType) {
+	snapshotDir := "/tmp/fabric/ledgertests/kvledgersnapshot"
+	os.RemoveAll(snapshotDir)
+	defer os.RemoveAll(snapshotDir)
+
+	env := newTestEnv(t, providerType)
+	provider, _ := NewProvider()
+	bg, gb := testutil.NewBlockGenerator(t, "testLedger", false)
+	ledger, _ := provider.Create(gb)
+	commitTestBlock(t, ledger, bg, func(s lgr.TxSimulator) {
+		s.SetState("ns1", "key1", []byte("value1"))
+	})
+	expectedState := retrievePubAndHashedState(t, ledger)
+
+	// a block is committed while the state is being exported, which the leveldb
+	// iterator over the state reads a snapshot of
+	kvl := ledger.(*kvLedger)
+	committed := make(chan struct{})
+	kvl.versionedDB = &stateExportHookDB{DB: kvl.versionedDB, readsSnapshot: true, onNext: func() {
+		go func() {
+			commitTestBlock(t, ledger, bg, func(s lgr.TxSimulator) {
+				s.SetState("ns1", "key1", []byte("value2"))
+				s.SetState("ns1", "key2", []byte("value3"))
+			})
+			close(committed)
+		}()
+		select {
+		case <-committed:
+		case <-time.After(10 * time.Second):
+			t.Error("The commits should not be blocked while the state is exported from a snapshot")
+		}
+	}}
+	assert.NoError(t, ledger.ExportSnapshot(snapshotDir))
+	<-committed
+	metadata, err := readSnapshotMetadata(snapshotDir)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), metadata.LastBlockNumber)
+
+	// the commits wait for the export to complete if the iterator over the state does not read a snapshot
+	defer os.RemoveAll(snapshotDir + "-blocking")
+	committed = make(chan struct{})
+	kvl.versionedDB = &stateExportHookDB{DB: kvl.versionedDB.(*stateExportHookDB).DB, onNext: func() {
+		go func() {
+			commitTestBlock(t, ledger, bg, func(s lgr.TxSimulator) {
+				s.SetState("ns1", "key3", []byte("value4"))
+			})
+			close(committed)
+		}()
+		select {
+		case <-committed:
+			t.Error("The commits should be blocked while the state is exported")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}}
+	assert.NoError(t, ledger.ExportSnapshot(snapshotDir+"-blocking"))
+	<-committed
+	ledger.Close()
+	provider.Close()
+	env.cleanup()
+
+	// the snapshot exported from the leveldb snapshot contains the state as of the last block in the snapshot
+	env = newTestEnv(t, providerType)
+	defer env.cleanup()
+	provider, _ = NewProvider()
+	defer provider.Close()
+	ledger, err = provider.CreateFromSnapshot(snapshotDir)
+	assert.NoError(t, err)
+	defer ledger.Close()
+	assert.Equal(t, expectedState, retrievePubAndHashedState(t, ledger))
+}
+
+// stateExportHookDB calls the given function when the state is first read from the
+// iterator over the public and hashed state, to simulate the events happening in the
+// middle of an export
+type stateExportHookDB struct {
+	privacyenabledstate.DB
+	readsSnapshot bool
+	onNext        func()
+}
+
+func (db *stateExportHookDB) FullScanReadsSnapshot() bool {
+	return db.readsSnapshot
+}
+
+func (db *stateExportHookDB) GetPubAndHashedStateIterator() (statedb.ResultsIterator, error) {
+	itr, err := db.DB.GetPubAndHashedStateIterator()
+	if err != nil {
+		return nil, err
+	}
+	return &stateExportHookItr{ResultsIterator: itr, onNext: db.onNext}, nil
+}
+
+type stateExportHookItr struct {
+	statedb.ResultsIterator
+	onNext func()
+}
+
+func (itr *stateExportHookItr) Next() (statedb.QueryResult, error) {
+	if onNext := itr.onNext; onNext != nil {
+		itr.onNext = nil
+		onNext()
+	}
+	return itr.ResultsIterator.Next()
+}
+
+func commitTestBlock(t *testing.T, ledger lgr.PeerLedger, bg *testutil.BlockGenerator,
+	simulate func(s lgr.TxSimulator)) *common.Block {
+	simulator, _ := ledger.NewTxSimulator(util.GenerateUUID())
+	simulate(simulator)
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	pubSimBytes, _ := simRes.GetPubSimulationBytes()
+	block := bg.NextBlock([][]byte{pubSimBytes})
+	assert.NoError(t, ledger.CommitWithPvtData(&lgr.BlockAndPvtData{Block: block}))
+	return block
+}
+
+func retrievePubAndHashedState(t *testing.T, ledger lgr.PeerLedger) []*privacyenabledstate.PubOrHashedKV {
+	itr, err := ledger.(*kvLedger).versionedDB.GetPubAndHashedStateIterator()
+	assert.NoError(t, err)
+	defer itr.Close()
+	var kvs []*privacyenabledstate.PubOrHashedKV
+	for {
+		queryResult, err := itr.Next()
+		assert.NoError(t, err)
+		if queryResult == nil {
+			return kvs
+		}
+		kvs = append(kvs, queryResult.(*privacyenabledstate.PubOrHashedKV))
+	}
+}
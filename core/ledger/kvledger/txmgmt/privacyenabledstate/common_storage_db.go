@@ -9,7 +9,11 @@ package privacyenabledstate
 import (
 	"encoding/base64"
 	"fmt"
+	"strings"
 
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/core/common/ccprovider"
+	"github.com/hyperledger/fabric/core/ledger/cceventmgmt"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/statecouchdb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/stateleveldb"
@@ -17,6 +21,8 @@ import (
 	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
 )
 
+var logger = flogging.MustGetLogger("privacyenabledstate")
+
 const (
 	nsJoiner       = "$"
 	pvtDataPrefix  = "p"
@@ -42,6 +48,12 @@ func NewCommonStorageDBProvider() (DBProvider, error) {
 	return &CommonStorageDBProvider{vdbProvider}, nil
 }
 
+// NewInMemoryCommonStorageDBProvider constructs an instance of DBProvider that keeps the state in the memory.
+// The state is always kept in goleveldb, irrespective of the configured state database
+func NewInMemoryCommonStorageDBProvider() DBProvider {
+	return &CommonStorageDBProvider{stateleveldb.NewInMemoryVersionedDBProvider()}
+}
+
 // GetDBHandle implements function from interface DBProvider
 func (p *CommonStorageDBProvider) GetDBHandle(id string) (DB, error) {
 	vdb, err := p.VersionedDBProvider.GetDBHandle(id)
@@ -51,6 +63,11 @@ func (p *CommonStorageDBProvider) GetDBHandle(id string) (DB, error) {
 	return NewCommonStorageDB(vdb, id)
 }
 
+// Drop implements function from interface DBProvider
+func (p *CommonStorageDBProvider) Drop(id string) error {
+	return p.VersionedDBProvider.Drop(id)
+}
+
 // Close implements function from interface DBProvider
 func (p *CommonStorageDBProvider) Close() {
 	p.VersionedDBProvider.Close()
@@ -60,12 +77,78 @@ func (p *CommonStorageDBProvider) Close() {
 // both the public and private data
 type CommonStorageDB struct {
 	statedb.VersionedDB
+	// cache is nil if the state cache is disabled
+	cache *stateCache
 }
 
 // NewCommonStorageDB wraps a VersionedDB instance. The public data is managed directly by the wrapped versionedDB.
-// For managing the hashed data and private data, this implementation creates separate namespaces in the wrapped db
+// For managing the hashed data and private data, this implementation creates separate namespaces in the wrapped db.
+// The reads are served from a state cache that is sized as per the ledger configuration
 func NewCommonStorageDB(vdb statedb.VersionedDB, ledgerid string) (DB, error) {
-	return &CommonStorageDB{VersionedDB: vdb}, nil
+	cacheSize, nsCacheSizes, err := ledgerconfig.GetStateCacheSizes()
+	if err != nil {
+		return nil, err
+	}
+	return &CommonStorageDB{VersionedDB: vdb, cache: newStateCache(cacheSize, nsCacheSizes)}, nil
+}
+
+// GetState overrides the function in statedb.VersionedDB for serving the reads from the state cache
+func (s *CommonStorageDB) GetState(namespace string, key string) (*statedb.VersionedValue, error) {
+	if s.cache == nil {
+		return s.VersionedDB.GetState(namespace, key)
+	}
+	if vv, ok := s.cache.get(namespace, key); ok {
+		return vv, nil
+	}
+	marker := s.cache.readMarker()
+	vv, err := s.VersionedDB.GetState(namespace, key)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.add(namespace, key, vv, marker)
+	return vv, nil
+}
+
+// GetVersion overrides the function in statedb.VersionedDB for serving the reads from the state cache
+func (s *CommonStorageDB) GetVersion(namespace string, key string) (*version.Height, error) {
+	if s.cache != nil {
+		if vv, ok := s.cache.get(namespace, key); ok {
+			return vv.Version, nil
+		}
+	}
+	return s.VersionedDB.GetVersion(namespace, key)
+}
+
+// GetStateMultipleKeys overrides the function in statedb.VersionedDB for serving the reads from the state cache.
+// Only the keys that are not present in the cache are read from the underlying db
+func (s *CommonStorageDB) GetStateMultipleKeys(namespace string, keys []string) ([]*statedb.VersionedValue, error) {
+	if s.cache == nil {
+		return s.VersionedDB.GetStateMultipleKeys(namespace, keys)
+	}
+	vals := make([]*statedb.VersionedValue, len(keys))
+	var missingKeys []string
+	var missingIndexes []int
+	for i, key := range keys {
+		if vv, ok := s.cache.get(namespace, key); ok {
+			vals[i] = vv
+			continue
+		}
+		missingKeys = append(missingKeys, key)
+		missingIndexes = append(missingIndexes, i)
+	}
+	if len(missingKeys) == 0 {
+		return vals, nil
+	}
+	marker := s.cache.readMarker()
+	missingVals, err := s.VersionedDB.GetStateMultipleKeys(namespace, missingKeys)
+	if err != nil {
+		return nil, err
+	}
+	for i, vv := range missingVals {
+		vals[missingIndexes[i]] = vv
+		s.cache.add(namespace, missingKeys[i], vv, marker)
+	}
+	return vals, nil
 }
 
 // IsBulkOptimizable implements corresponding function in interface DB
@@ -168,6 +251,15 @@ func (s CommonStorageDB) ExecuteQueryOnPrivateData(namespace, collection, query
 	return s.ExecuteQuery(derivePvtDataNs(namespace, collection), query)
 }
 
+// GetPubAndHashedStateIterator implements corresponding function in interface DB
+func (s *CommonStorageDB) GetPubAndHashedStateIterator() (statedb.ResultsIterator, error) {
+	itr, err := s.GetFullScanIterator(isPvtDataNs)
+	if err != nil {
+		return nil, err
+	}
+	return &pubAndHashedStateItr{itr, !s.BytesKeySuppoted()}, nil
+}
+
 // ApplyUpdates overrides the funciton in statedb.VersionedDB and throws appropriate error message
 // Otherwise, somewhere in the code, usage of this function could lead to updating only public data.
 func (s *CommonStorageDB) ApplyUpdates(batch *statedb.UpdateBatch, height *version.Height) error {
@@ -178,7 +270,36 @@ func (s *CommonStorageDB) ApplyUpdates(batch *statedb.UpdateBatch, height *versi
 func (s *CommonStorageDB) ApplyPrivacyAwareUpdates(updates *UpdateBatch, height *version.Height) error {
 	addPvtUpdates(updates.PubUpdates, updates.PvtUpdates)
 	addHashedUpdates(updates.PubUpdates, updates.HashUpdates, !s.BytesKeySuppoted())
-	return s.VersionedDB.ApplyUpdates(updates.PubUpdates.UpdateBatch, height)
+	err := s.VersionedDB.ApplyUpdates(updates.PubUpdates.UpdateBatch, height)
+	if s.cache != nil {
+		s.cache.update(updates.PubUpdates.UpdateBatch, err == nil)
+	}
+	return err
+}
+
+// HandleChaincodeDeploy initializes database artifacts for the database associated with the namespace
+// This function deliberately suppresses the errors that occur during the creation of the indexes on couchdb.
+// This is because, in the present code, we do not differentiate between the errors because of couchdb interaction
+// and the errors because of bad index files - the latter being unfixable by the admin. Note that the error suppression
+// is acceptable since peer can continue in the committing role without the indexes. However, executing chaincode queries
+// may be affected, until a new chaincode with fixed indexes is installed and instantiated
+func (s *CommonStorageDB) HandleChaincodeDeploy(chaincodeDefinition *cceventmgmt.ChaincodeDefinition, dbArtifactsTar []byte) error {
+	indexCapable, ok := s.VersionedDB.(statedb.IndexCapable)
+	if !ok {
+		return nil
+	}
+	if chaincodeDefinition == nil {
+		return fmt.Errorf("chaincode definition not found while creating the indexes")
+	}
+	dbArtifacts, err := ccprovider.ExtractFileEntries(dbArtifactsTar, indexCapable.GetDBType())
+	if err != nil {
+		logger.Errorf("Error during extracting the db artifacts of chaincode [%s]: %s", chaincodeDefinition, err)
+		return nil
+	}
+	if err := indexCapable.ProcessIndexesForChaincodeDeploy(chaincodeDefinition.Name, dbArtifacts); err != nil {
+		logger.Errorf("Error during processing the indexes of chaincode [%s]: %s", chaincodeDefinition, err)
+	}
+	return nil
 }
 
 func derivePvtDataNs(namespace, collection string) string {
@@ -189,6 +310,20 @@ func deriveHashedDataNs(namespace, collection string) string {
 	return namespace + nsJoiner + hashDataPrefix + collection
 }
 
+func isPvtDataNs(ns string) bool {
+	return strings.Contains(ns, nsJoiner+pvtDataPrefix)
+}
+
+// splitHashedDataNs returns the namespace and the collection name that a derived hashed data namespace is composed of.
+// The last return value is false if the given namespace is not a hashed data namespace
+func splitHashedDataNs(ns string) (string, string, bool) {
+	splits := strings.SplitN(ns, nsJoiner+hashDataPrefix, 2)
+	if len(splits) != 2 {
+		return "", "", false
+	}
+	return splits[0], splits[1], true
+}
+
 func addPvtUpdates(pubUpdateBatch *PubUpdateBatch, pvtUpdateBatch *PvtUpdateBatch) {
 	for ns, nsBatch := range pvtUpdateBatch.UpdateMap {
 		for _, coll := range nsBatch.GetCollectionNames() {
@@ -211,3 +346,31 @@ func addHashedUpdates(pubUpdateBatch *PubUpdateBatch, hashedUpdateBatch *HashedU
 		}
 	}
 }
+
+// pubAndHashedStateItr wraps a full scan iterator of the underlying db and translates the results
+// that belong to the derived hashed data namespaces back to the namespace, the collection, and the key-hash
+type pubAndHashedStateItr struct {
+	statedb.ResultsIterator
+	base64Key bool
+}
+
+func (itr *pubAndHashedStateItr) Next() (statedb.QueryResult, error) {
+	queryResult, err := itr.ResultsIterator.Next()
+	if err != nil || queryResult == nil {
+		return nil, err
+	}
+	vkv := queryResult.(*statedb.VersionedKV)
+	ns, coll, isHashed := splitHashedDataNs(vkv.Namespace)
+	if !isHashed {
+		return &PubOrHashedKV{Namespace: vkv.Namespace, Key: vkv.Key, VersionedValue: &vkv.VersionedValue}, nil
+	}
+	keyHash := vkv.Key
+	if itr.base64Key {
+		keyHashBytes, err := base64.StdEncoding.DecodeString(keyHash)
+		if err != nil {
+			return nil, err
+		}
+		keyHash = string(keyHashBytes)
+	}
+	return &PubOrHashedKV{Namespace: ns, CollectionName: coll, Key: keyHash, VersionedValue: &vkv.VersionedValue}, nil
+}
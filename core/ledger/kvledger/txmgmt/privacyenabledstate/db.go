@@ -15,6 +15,8 @@ import (
 type DBProvider interface {
 	// GetDBHandle returns a handle to a PvtVersionedDB
 	GetDBHandle(id string) (DB, error)
+	// Drop removes all the public, hashed and private data of the PvtVersionedDB with the given id
+	Drop(id string) error
 	// Close closes all the PvtVersionedDB instances and releases any resources held by VersionedDBProvider
 	Close()
 }
@@ -33,6 +35,23 @@ type DB interface {
 	GetPrivateDataRangeScanIterator(namespace, collection, startKey, endKey string) (statedb.ResultsIterator, error)
 	ExecuteQueryOnPrivateData(namespace, collection, query string) (statedb.ResultsIterator, error)
 	ApplyPrivacyAwareUpdates(updates *UpdateBatch, height *version.Height) error
+	// GetPubAndHashedStateIterator returns an iterator over the public data and the hashes of the private data.
+	// The private data is not included. The returned ResultsIterator contains results of type *PubOrHashedKV
+	GetPubAndHashedStateIterator() (statedb.ResultsIterator, error)
+}
+
+// PubOrHashedKV encloses either a key-value from the public data (CollectionName is empty) or
+// a key-hash and the corresponding value-hash from the hashed data of a collection
+type PubOrHashedKV struct {
+	Namespace      string
+	CollectionName string
+	Key            string
+	*statedb.VersionedValue
+}
+
+// IsHashed returns true if the key-value belongs to the hashed data of a collection
+func (kv *PubOrHashedKV) IsHashed() bool {
+	return kv.CollectionName != ""
 }
 
 // HashedCompositeKey encloses Namespace, CollectionName and KeyHash components
@@ -7,12 +7,17 @@ SPDX-License-Identifier: Apache-2.0
 package privacyenabledstate
 
 import (
+	"archive/tar"
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 	"testing"
 
 	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/common/ccprovider"
+	"github.com/hyperledger/fabric/core/ledger/cceventmgmt"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
 	"github.com/hyperledger/fabric/core/ledger/util"
@@ -129,6 +134,57 @@ func testDB(t *testing.T, env TestEnv) {
 	assert.Nil(t, vv)
 }
 
+func TestGetPubAndHashedStateIterator(t *testing.T) {
+	for _, env := range testEnvs {
+		t.Run(env.GetName(), func(t *testing.T) {
+			testGetPubAndHashedStateIterator(t, env)
+		})
+	}
+}
+
+func testGetPubAndHashedStateIterator(t *testing.T, env TestEnv) {
+	env.Init(t)
+	defer env.Cleanup()
+	db := env.GetDBHandle("test-get-pub-and-hashed-state-iterator")
+
+	updates := NewUpdateBatch()
+	updates.PubUpdates.Put("ns1", "key1", []byte("value1"), version.NewHeight(1, 1))
+	updates.PubUpdates.Put("ns2", "key2", []byte("value2"), version.NewHeight(1, 2))
+	putPvtUpdates(t, updates, "ns1", "coll1", "key1", []byte("pvt_value1"), version.NewHeight(1, 3))
+	putPvtUpdates(t, updates, "ns2", "coll2", "key2", []byte("pvt_value2"), version.NewHeight(1, 4))
+	assert.NoError(t, db.ApplyPrivacyAwareUpdates(updates, version.NewHeight(1, 4)))
+
+	itr, err := db.GetPubAndHashedStateIterator()
+	assert.NoError(t, err)
+	defer itr.Close()
+	var results []*PubOrHashedKV
+	for {
+		queryResult, err := itr.Next()
+		assert.NoError(t, err)
+		if queryResult == nil {
+			break
+		}
+		results = append(results, queryResult.(*PubOrHashedKV))
+	}
+	expectedResults := []*PubOrHashedKV{
+		{Namespace: "ns1", Key: "key1",
+			VersionedValue: &statedb.VersionedValue{Value: []byte("value1"), Version: version.NewHeight(1, 1)}},
+		{Namespace: "ns2", Key: "key2",
+			VersionedValue: &statedb.VersionedValue{Value: []byte("value2"), Version: version.NewHeight(1, 2)}},
+		{Namespace: "ns1", CollectionName: "coll1", Key: string(util.ComputeStringHash("key1")),
+			VersionedValue: &statedb.VersionedValue{Value: util.ComputeStringHash("pvt_value1"), Version: version.NewHeight(1, 3)}},
+		{Namespace: "ns2", CollectionName: "coll2", Key: string(util.ComputeStringHash("key2")),
+			VersionedValue: &statedb.VersionedValue{Value: util.ComputeStringHash("pvt_value2"), Version: version.NewHeight(1, 4)}},
+	}
+	assert.Len(t, results, len(expectedResults))
+	for _, expectedResult := range expectedResults {
+		assert.Contains(t, results, expectedResult)
+	}
+	for _, result := range results {
+		assert.Equal(t, result.CollectionName != "", result.IsHashed())
+	}
+}
+
 //TODO add tests for functions GetPrivateStateMultipleKeys and GetPrivateStateRangeScanIterator
 
 func TestGetStateMultipleKeys(t *testing.T) {
@@ -231,19 +287,15 @@ func testGetStateRangeScanIterator(t *testing.T, env TestEnv) {
 	testItr(t, pvtItr4, []string{"key5", "key6"})
 }
 
-func TestQueryOnCouchDB(t *testing.T) {
+func TestQuery(t *testing.T) {
 	for _, env := range testEnvs {
-		_, ok := env.(*CouchDBCommonStorageTestEnv)
-		if !ok {
-			continue
-		}
 		t.Run(env.GetName(), func(t *testing.T) {
-			testQueryOnCouchDB(t, env)
+			testQuery(t, env)
 		})
 	}
 }
 
-func testQueryOnCouchDB(t *testing.T, env TestEnv) {
+func testQuery(t *testing.T, env TestEnv) {
 	env.Init(t)
 	defer env.Cleanup()
 	db := env.GetDBHandle("test-ledger-id")
@@ -342,6 +394,64 @@ func testQueryItr(t *testing.T, itr statedb.ResultsIterator, expectedKeys []stri
 	testutil.AssertNil(t, last)
 }
 
+func TestHandleChainCodeDeploy(t *testing.T) {
+	env := &LevelDBCommonStorageTestEnv{}
+	env.Init(t)
+	defer env.Cleanup()
+	db := env.GetDBHandle("test-handle-chaincode-deploy").(*CommonStorageDB)
+	ccDef := &cceventmgmt.ChaincodeDefinition{Name: "ns1", Hash: []byte("hash"), Version: "v1"}
+	dbArtifactsTar := createTar(t, map[string]string{
+		"couchdb/indexes/indexOwner.json": `{"index":{"fields":["owner"]}}`,
+		"otherdb/indexes/indexSize.json":  `{"index":{"fields":["size"]}}`,
+	})
+
+	// the leveldb is not capable of handling indexes, hence the artifacts are ignored
+	assert.NoError(t, db.HandleChaincodeDeploy(ccDef, dbArtifactsTar))
+
+	// the artifacts of the type of the db are passed to a db capable of handling indexes
+	indexCapableDB := &mockIndexCapableDB{VersionedDB: db.VersionedDB}
+	db.VersionedDB = indexCapableDB
+	assert.NoError(t, db.HandleChaincodeDeploy(ccDef, dbArtifactsTar))
+	assert.Equal(t, "ns1", indexCapableDB.namespace)
+	assert.Len(t, indexCapableDB.fileEntries, 1)
+	assert.Equal(t, "couchdb/indexes/indexOwner.json", indexCapableDB.fileEntries[0].FileHeader.Name)
+	assert.Equal(t, `{"index":{"fields":["owner"]}}`, string(indexCapableDB.fileEntries[0].FileContent))
+
+	// the errors during processing of the indexes do not fail the deployment of the chaincode
+	indexCapableDB.err = errors.New("index error")
+	assert.NoError(t, db.HandleChaincodeDeploy(ccDef, dbArtifactsTar))
+	assert.Error(t, db.HandleChaincodeDeploy(nil, dbArtifactsTar))
+}
+
+type mockIndexCapableDB struct {
+	statedb.VersionedDB
+	namespace   string
+	fileEntries []*ccprovider.TarFileEntry
+	err         error
+}
+
+func (db *mockIndexCapableDB) GetDBType() string {
+	return "couchdb"
+}
+
+func (db *mockIndexCapableDB) ProcessIndexesForChaincodeDeploy(namespace string, fileEntries []*ccprovider.TarFileEntry) error {
+	db.namespace = namespace
+	db.fileEntries = fileEntries
+	return db.err
+}
+
+func createTar(t *testing.T, files map[string]string) []byte {
+	buffer := bytes.NewBuffer(nil)
+	tw := tar.NewWriter(buffer)
+	for name, content := range files {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Size: int64(len(content)), Mode: 0600}))
+		_, err := tw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	return buffer.Bytes()
+}
+
 func testKey(i int) string {
 	return fmt.Sprintf("key%d", i)
 }
@@ -355,3 +465,67 @@ func deletePvtUpdates(t *testing.T, updates *UpdateBatch, ns, coll, key string,
 	updates.PvtUpdates.Delete(ns, coll, key, ver)
 	updates.HashUpdates.Delete(ns, coll, util.ComputeStringHash(key), ver)
 }
+
+func TestDBWithStateCache(t *testing.T) {
+	viper.Set("ledger.state.cache.size", 10)
+	defer viper.Set("ledger.state.cache.size", 0)
+	env := &LevelDBCommonStorageTestEnv{}
+	env.Init(t)
+	defer env.Cleanup()
+	db := env.GetDBHandle("test-ledger-id")
+	assert.NotNil(t, db.(*CommonStorageDB).cache)
+
+	updates := NewUpdateBatch()
+	updates.PubUpdates.Put("ns1", "key1", []byte("value1"), version.NewHeight(1, 1))
+	updates.PubUpdates.Put("ns1", "key2", []byte("value2"), version.NewHeight(1, 2))
+	putPvtUpdates(t, updates, "ns1", "coll1", "key1", []byte("pvt_value1"), version.NewHeight(1, 3))
+	assert.NoError(t, db.ApplyPrivacyAwareUpdates(updates, version.NewHeight(1, 3)))
+
+	vv, err := db.GetState("ns1", "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, &statedb.VersionedValue{Value: []byte("value1"), Version: version.NewHeight(1, 1)}, vv)
+	vv, err = db.GetPrivateData("ns1", "coll1", "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, &statedb.VersionedValue{Value: []byte("pvt_value1"), Version: version.NewHeight(1, 3)}, vv)
+	vvs, err := db.GetStateMultipleKeys("ns1", []string{"key1", "key2", "key3"})
+	assert.NoError(t, err)
+	assert.Equal(t, []*statedb.VersionedValue{
+		{Value: []byte("value1"), Version: version.NewHeight(1, 1)},
+		{Value: []byte("value2"), Version: version.NewHeight(1, 2)},
+		nil,
+	}, vvs)
+	_, cached := db.(*CommonStorageDB).cache.get("ns1", "key2")
+	assert.True(t, cached)
+
+	// the cache is kept coherent with the committed updates
+	updates = NewUpdateBatch()
+	updates.PubUpdates.Put("ns1", "key1", []byte("new_value1"), version.NewHeight(2, 1))
+	updates.PubUpdates.Delete("ns1", "key2", version.NewHeight(2, 2))
+	updates.PubUpdates.Put("ns1", "key3", []byte("value3"), version.NewHeight(2, 3))
+	deletePvtUpdates(t, updates, "ns1", "coll1", "key1", version.NewHeight(2, 4))
+	assert.NoError(t, db.ApplyPrivacyAwareUpdates(updates, version.NewHeight(2, 4)))
+
+	vv, err = db.GetState("ns1", "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, &statedb.VersionedValue{Value: []byte("new_value1"), Version: version.NewHeight(2, 1)}, vv)
+	ver, err := db.GetVersion("ns1", "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, version.NewHeight(2, 1), ver)
+	vv, err = db.GetPrivateData("ns1", "coll1", "key1")
+	assert.NoError(t, err)
+	assert.Nil(t, vv)
+	vvs, err = db.GetStateMultipleKeys("ns1", []string{"key1", "key2", "key3"})
+	assert.NoError(t, err)
+	assert.Equal(t, []*statedb.VersionedValue{
+		{Value: []byte("new_value1"), Version: version.NewHeight(2, 1)},
+		nil,
+		{Value: []byte("value3"), Version: version.NewHeight(2, 3)},
+	}, vvs)
+}
+
+func TestNewCommonStorageDBWithMalformedCacheConfig(t *testing.T) {
+	viper.Set("ledger.state.cache.namespaces", "malformed")
+	defer viper.Set("ledger.state.cache.namespaces", nil)
+	_, err := NewCommonStorageDB(nil, "test-ledger-id")
+	assert.Error(t, err)
+}
@@ -0,0 +1,148 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package privacyenabledstate
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/golang/groupcache/lru"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+)
+
+// stateCache is a read cache in front of the state database of a channel. It keeps the most recently
+// read keys of each namespace, up to the size configured for the namespace. The derived namespaces of
+// the private data collections are sized as the namespace of their chaincode. The keys that are updated
+// by a commit are refreshed in the cache, so that the cache remains coherent with the state database
+type stateCache struct {
+	sync.Mutex
+	defaultSize int
+	nsSizes     map[string]int
+	nsCaches    map[string]*lru.Cache
+	// commits is incremented by each commit. A value read from the state database is added to
+	// the cache only if no commit has completed since the read, as the value could be stale otherwise
+	commits uint64
+}
+
+// newStateCache returns a stateCache, or nil if the cache is disabled for all the namespaces
+func newStateCache(defaultSize int, nsSizes map[string]int) *stateCache {
+	enabled := defaultSize > 0
+	for _, size := range nsSizes {
+		enabled = enabled || size > 0
+	}
+	if !enabled {
+		return nil
+	}
+	return &stateCache{
+		defaultSize: defaultSize,
+		nsSizes:     nsSizes,
+		nsCaches:    make(map[string]*lru.Cache),
+	}
+}
+
+// get returns the cached value of the key, if any
+func (c *stateCache) get(ns, key string) (*statedb.VersionedValue, bool) {
+	c.Lock()
+	defer c.Unlock()
+	nsCache := c.nsCache(ns)
+	if nsCache == nil {
+		return nil, false
+	}
+	cached, ok := nsCache.Get(key)
+	if !ok {
+		return nil, false
+	}
+	return copyVersionedValue(cached.(*statedb.VersionedValue)), true
+}
+
+// readMarker returns a marker to be passed to the function add for a value read from the state database
+func (c *stateCache) readMarker() uint64 {
+	c.Lock()
+	defer c.Unlock()
+	return c.commits
+}
+
+// add adds the value of the key read from the state database after the given marker was obtained.
+// The values of the keys that do not exist are not cached
+func (c *stateCache) add(ns, key string, vv *statedb.VersionedValue, marker uint64) {
+	if vv == nil {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	if c.commits != marker {
+		return
+	}
+	if nsCache := c.nsCache(ns); nsCache != nil {
+		nsCache.Add(key, copyVersionedValue(vv))
+	}
+}
+
+// update refreshes the cached keys that are updated by the batch committed to the state database.
+// If the commit failed, the updated keys are removed from the cache, as the commit may have been
+// partially applied
+func (c *stateCache) update(batch *statedb.UpdateBatch, committed bool) {
+	c.Lock()
+	defer c.Unlock()
+	c.commits++
+	for _, ns := range batch.GetUpdatedNamespaces() {
+		nsCache := c.nsCaches[ns]
+		if nsCache == nil {
+			continue
+		}
+		for key, vv := range batch.GetUpdates(ns) {
+			if _, ok := nsCache.Get(key); !ok {
+				continue
+			}
+			if !committed || vv.Value == nil {
+				nsCache.Remove(key)
+				continue
+			}
+			nsCache.Add(key, copyVersionedValue(vv))
+		}
+	}
+}
+
+// nsCache returns the cache of the namespace, or nil if the cache is disabled for the namespace
+func (c *stateCache) nsCache(ns string) *lru.Cache {
+	if nsCache, ok := c.nsCaches[ns]; ok {
+		return nsCache
+	}
+	size := c.sizeOf(ns)
+	var nsCache *lru.Cache
+	if size > 0 {
+		nsCache = lru.New(size)
+	}
+	c.nsCaches[ns] = nsCache
+	return nsCache
+}
+
+// sizeOf returns the configured cache size of the namespace. A derived namespace of a private data
+// collection takes the size of the namespace of its chaincode
+func (c *stateCache) sizeOf(ns string) int {
+	if size, ok := c.nsSizes[ns]; ok {
+		return size
+	}
+	if ccNs := strings.SplitN(ns, nsJoiner, 2)[0]; ccNs != ns {
+		if size, ok := c.nsSizes[ccNs]; ok {
+			return size
+		}
+	}
+	return c.defaultSize
+}
+
+// copyVersionedValue copies the value, so that the cached value cannot be modified by the callers
+func copyVersionedValue(vv *statedb.VersionedValue) *statedb.VersionedValue {
+	vvCopy := &statedb.VersionedValue{Version: vv.Version}
+	if vv.Value != nil {
+		vvCopy.Value = append([]byte{}, vv.Value...)
+	}
+	if vv.Metadata != nil {
+		vvCopy.Metadata = append([]byte{}, vv.Metadata...)
+	}
+	return vvCopy
+}
@@ -0,0 +1,110 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package privacyenabledstate
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStateCache(t *testing.T) {
+	assert.Nil(t, newStateCache(0, nil))
+	assert.Nil(t, newStateCache(0, map[string]int{"ns1": 0}))
+	assert.NotNil(t, newStateCache(0, map[string]int{"ns1": 10}))
+	assert.NotNil(t, newStateCache(10, nil))
+}
+
+func TestStateCacheSizes(t *testing.T) {
+	cache := newStateCache(2, map[string]int{"ns1": 0, "ns2": 3})
+	assert.Equal(t, 0, cache.sizeOf("ns1"))
+	assert.Equal(t, 0, cache.sizeOf(derivePvtDataNs("ns1", "coll1")))
+	assert.Equal(t, 3, cache.sizeOf("ns2"))
+	assert.Equal(t, 3, cache.sizeOf(deriveHashedDataNs("ns2", "coll1")))
+	assert.Equal(t, 2, cache.sizeOf("ns3"))
+
+	for _, ns := range []string{"ns1", "ns2", "ns3"} {
+		for i := 0; i < 5; i++ {
+			cache.add(ns, testKey(i), &statedb.VersionedValue{Value: []byte("value"), Version: version.NewHeight(1, 1)}, 0)
+		}
+	}
+	assert.Nil(t, cache.nsCaches["ns1"])
+	assert.Equal(t, 3, cache.nsCaches["ns2"].Len())
+	assert.Equal(t, 2, cache.nsCaches["ns3"].Len())
+
+	// the least recently used keys are evicted
+	_, ok := cache.get("ns3", testKey(2))
+	assert.False(t, ok)
+	_, ok = cache.get("ns3", testKey(4))
+	assert.True(t, ok)
+}
+
+func TestStateCacheGetAndAdd(t *testing.T) {
+	cache := newStateCache(10, nil)
+	vv := &statedb.VersionedValue{Value: []byte("value1"), Metadata: []byte("metadata1"), Version: version.NewHeight(1, 1)}
+	cache.add("ns1", "key1", vv, cache.readMarker())
+	cache.add("ns1", "key2", nil, cache.readMarker())
+
+	// the cached value is not affected by the modifications of the callers
+	vv.Value[0] = 'V'
+	cachedVV, ok := cache.get("ns1", "key1")
+	assert.True(t, ok)
+	assert.Equal(t, &statedb.VersionedValue{Value: []byte("value1"), Metadata: []byte("metadata1"), Version: version.NewHeight(1, 1)}, cachedVV)
+	cachedVV.Value[0] = 'V'
+	cachedVV, _ = cache.get("ns1", "key1")
+	assert.Equal(t, []byte("value1"), cachedVV.Value)
+
+	// the keys that do not exist are not cached
+	_, ok = cache.get("ns1", "key2")
+	assert.False(t, ok)
+
+	// a value read before a commit is not cached after the commit
+	marker := cache.readMarker()
+	cache.update(statedb.NewUpdateBatch(), true)
+	cache.add("ns1", "key3", &statedb.VersionedValue{Value: []byte("value3"), Version: version.NewHeight(1, 3)}, marker)
+	_, ok = cache.get("ns1", "key3")
+	assert.False(t, ok)
+}
+
+func TestStateCacheUpdate(t *testing.T) {
+	cache := newStateCache(10, nil)
+	for i := 0; i < 3; i++ {
+		cache.add("ns1", testKey(i), &statedb.VersionedValue{Value: []byte(fmt.Sprintf("value%d", i)), Version: version.NewHeight(1, uint64(i))}, 0)
+	}
+
+	batch := statedb.NewUpdateBatch()
+	batch.Put("ns1", testKey(0), []byte("new-value0"), version.NewHeight(2, 0))
+	batch.Delete("ns1", testKey(1), version.NewHeight(2, 1))
+	batch.Put("ns1", testKey(5), []byte("value5"), version.NewHeight(2, 2))
+	batch.Put("ns2", testKey(0), []byte("value0"), version.NewHeight(2, 3))
+	cache.update(batch, true)
+
+	vv, ok := cache.get("ns1", testKey(0))
+	assert.True(t, ok)
+	assert.Equal(t, &statedb.VersionedValue{Value: []byte("new-value0"), Version: version.NewHeight(2, 0)}, vv)
+	_, ok = cache.get("ns1", testKey(1))
+	assert.False(t, ok)
+	_, ok = cache.get("ns1", testKey(2))
+	assert.True(t, ok)
+	// the keys that were not cached before the commit are not added by the commit
+	_, ok = cache.get("ns1", testKey(5))
+	assert.False(t, ok)
+	_, ok = cache.get("ns2", testKey(0))
+	assert.False(t, ok)
+
+	// the keys updated by a failed commit are removed
+	batch = statedb.NewUpdateBatch()
+	batch.Put("ns1", testKey(2), []byte("new-value2"), version.NewHeight(3, 0))
+	cache.update(batch, false)
+	_, ok = cache.get("ns1", testKey(2))
+	assert.False(t, ok)
+	_, ok = cache.get("ns1", testKey(0))
+	assert.True(t, ok)
+}
@@ -0,0 +1,85 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pvtstatepurgemgmt
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/bookkeeping"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpiryKVEncoding(t *testing.T) {
+	pvtdataKeys := newPvtdataKeys()
+	pvtdataKeys.add("ns1", "coll-1", "key-1", []byte("key-1-hash"))
+	expiryInfo := &expiryInfo{&expiryInfoKey{expiryBlk: 10, committingBlk: 2}, pvtdataKeys}
+	k, v, err := encodeKV(expiryInfo)
+	assert.NoError(t, err)
+	expiryInfo1, err := decodeExpiryInfo(k, v)
+	assert.NoError(t, err)
+	assert.Equal(t, expiryInfo.expiryInfoKey, expiryInfo1.expiryInfoKey)
+	assert.True(t, proto.Equal(expiryInfo.pvtdataKeys, expiryInfo1.pvtdataKeys))
+}
+
+func TestExpiryKeeper(t *testing.T) {
+	testenv := bookkeeping.NewTestEnv(t)
+	defer testenv.Cleanup()
+	expiryKeeper := newExpiryKeeper("testledger", testenv.TestProvider)
+
+	expinfo1 := &expiryInfo{&expiryInfoKey{committingBlk: 3, expiryBlk: 13}, buildPvtdataKeysForTest(1, 1)}
+	expinfo2 := &expiryInfo{&expiryInfoKey{committingBlk: 3, expiryBlk: 15}, buildPvtdataKeysForTest(2, 2)}
+	expinfo3 := &expiryInfo{&expiryInfoKey{committingBlk: 4, expiryBlk: 13}, buildPvtdataKeysForTest(3, 3)}
+	expinfo4 := &expiryInfo{&expiryInfoKey{committingBlk: 5, expiryBlk: 17}, buildPvtdataKeysForTest(4, 4)}
+
+	// Insert entries for keys at committingBlk 3
+	assert.NoError(t, expiryKeeper.updateBookkeeping([]*expiryInfo{expinfo1, expinfo2}, nil))
+	// Insert entries for keys at committingBlk 4 and 5
+	assert.NoError(t, expiryKeeper.updateBookkeeping([]*expiryInfo{expinfo3, expinfo4}, nil))
+
+	// Retrieve entries by expiring block 12, 13, 15, and 17
+	listExpinfo, err := expiryKeeper.retrieve(12)
+	assert.NoError(t, err)
+	assert.Len(t, listExpinfo, 0)
+
+	listExpinfo, err = expiryKeeper.retrieve(13)
+	assert.NoError(t, err)
+	assertExpiryInfos(t, []*expiryInfo{expinfo1, expinfo3}, listExpinfo)
+
+	listExpinfo, err = expiryKeeper.retrieve(15)
+	assert.NoError(t, err)
+	assertExpiryInfos(t, []*expiryInfo{expinfo1, expinfo3, expinfo2}, listExpinfo)
+
+	// Clear entries for the expiring block 13 and 15
+	assert.NoError(t, expiryKeeper.updateBookkeeping(nil,
+		[]*expiryInfoKey{expinfo1.expiryInfoKey, expinfo2.expiryInfoKey, expinfo3.expiryInfoKey}))
+
+	listExpinfo, err = expiryKeeper.retrieve(15)
+	assert.NoError(t, err)
+	assert.Len(t, listExpinfo, 0)
+
+	listExpinfo, err = expiryKeeper.retrieve(17)
+	assert.NoError(t, err)
+	assertExpiryInfos(t, []*expiryInfo{expinfo4}, listExpinfo)
+}
+
+func assertExpiryInfos(t *testing.T, expected, actual []*expiryInfo) {
+	assert.Len(t, actual, len(expected))
+	for i := range expected {
+		assert.Equal(t, expected[i].expiryInfoKey, actual[i].expiryInfoKey)
+		assert.True(t, proto.Equal(expected[i].pvtdataKeys, actual[i].pvtdataKeys))
+	}
+}
+
+func buildPvtdataKeysForTest(startingEntry int, numEntries int) *PvtdataKeys {
+	pvtdataKeys := newPvtdataKeys()
+	for i := startingEntry; i <= startingEntry+numEntries; i++ {
+		pvtdataKeys.add(fmt.Sprintf("ns-%d", i), fmt.Sprintf("coll-%d", i), fmt.Sprintf("key-%d", i), []byte(fmt.Sprintf("key-%d-hash", i)))
+	}
+	return pvtdataKeys
+}
@@ -0,0 +1,21 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pvtstatepurgemgmt
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/spf13/viper"
+)
+
+func TestMain(m *testing.M) {
+	flogging.SetModuleLevel("pvtstatepurgemgmt", "debug")
+	viper.Set("peer.fileSystemPath", "/tmp/fabric/ledgertests/kvledger/txmgmt/pvtstatepurgemgmt")
+	os.Exit(m.Run())
+}
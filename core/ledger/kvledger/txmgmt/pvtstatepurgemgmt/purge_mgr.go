@@ -0,0 +1,257 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pvtstatepurgemgmt
+
+import (
+	"bytes"
+	"math"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/bookkeeping"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/core/ledger/pvtdatapolicy"
+	"github.com/hyperledger/fabric/core/ledger/util"
+)
+
+var logger = flogging.MustGetLogger("pvtstatepurgemgmt")
+
+// PurgeMgr manages purging of the expired pvtdata
+type PurgeMgr interface {
+	// DeleteExpiredAndUpdateBookkeeping updates the bookkeeping and modifies the update batch by adding the deletes for the expired pvtdata.
+	// The 'blockNum' is the number of the block that the update batch belongs to
+	DeleteExpiredAndUpdateBookkeeping(blockNum uint64, updates *privacyenabledstate.UpdateBatch) error
+	// BlockCommitDone is a callback to the PurgeMgr when the block is committed to the ledger
+	BlockCommitDone() error
+	// UpdateBookkeepingForPvtDataOfOldBlocks updates the bookkeeping with the keys of the pvtdata that is committed
+	// to the state after the commit of the corresponding block, i.e., the keys for which only the key hashes were known.
+	// The pvtdata that has already expired is removed from the update batch so that it does not get committed to the state
+	UpdateBookkeepingForPvtDataOfOldBlocks(pvtUpdates *privacyenabledstate.PvtUpdateBatch) error
+}
+
+type purgeMgr struct {
+	btlPolicy pvtdatapolicy.BTLPolicy
+	db        privacyenabledstate.DB
+	expKeeper expiryKeeper
+
+	processedExpiryKeys []*expiryInfoKey
+}
+
+// InstantiatePurgeMgr instantiates a PurgeMgr.
+func InstantiatePurgeMgr(ledgerid string, db privacyenabledstate.DB, btlPolicy pvtdatapolicy.BTLPolicy, bookkeepingProvider bookkeeping.Provider) PurgeMgr {
+	return &purgeMgr{
+		btlPolicy: btlPolicy,
+		db:        db,
+		expKeeper: newExpiryKeeper(ledgerid, bookkeepingProvider),
+	}
+}
+
+// DeleteExpiredAndUpdateBookkeeping implements function in the interface 'PurgeMgr'
+func (p *purgeMgr) DeleteExpiredAndUpdateBookkeeping(blockNum uint64, updates *privacyenabledstate.UpdateBatch) error {
+	listExpiryInfo, err := p.expKeeper.retrieve(blockNum)
+	if err != nil {
+		return err
+	}
+	p.processedExpiryKeys = nil
+	deleteVersion := version.NewHeight(blockNum, math.MaxUint64)
+	for _, expiryInfo := range listExpiryInfo {
+		if err := p.addExpiredKeysToBatch(expiryInfo, updates, deleteVersion); err != nil {
+			return err
+		}
+		p.processedExpiryKeys = append(p.processedExpiryKeys, expiryInfo.expiryInfoKey)
+	}
+
+	toTrack, err := buildExpirySchedule(blockNum, p.btlPolicy, updates)
+	if err != nil {
+		return err
+	}
+	// The new entries are persisted before the block is committed to the state. This makes sure that
+	// a crash between the commit of the state and the commit of the bookkeeping does not cause the newly
+	// committed keys to live forever. In the case of a crash, the block is recommitted and the same entries
+	// are produced again
+	return p.expKeeper.updateBookkeeping(toTrack, nil)
+}
+
+// BlockCommitDone implements function in the interface 'PurgeMgr'
+func (p *purgeMgr) BlockCommitDone() error {
+	defer func() { p.processedExpiryKeys = nil }()
+	if len(p.processedExpiryKeys) == 0 {
+		return nil
+	}
+	return p.expKeeper.updateBookkeeping(nil, p.processedExpiryKeys)
+}
+
+// UpdateBookkeepingForPvtDataOfOldBlocks implements function in the interface 'PurgeMgr'
+func (p *purgeMgr) UpdateBookkeepingForPvtDataOfOldBlocks(pvtUpdates *privacyenabledstate.PvtUpdateBatch) error {
+	toTrack := make(map[expiryInfoKey]*expiryInfo)
+	for ns, nsBatch := range pvtUpdates.UpdateMap {
+		for _, coll := range nsBatch.GetCollectionNames() {
+			for key, vv := range nsBatch.GetUpdates(coll) {
+				if vv.Value == nil {
+					continue
+				}
+				committingBlk := vv.Version.BlockNum
+				expiryBlk, err := p.btlPolicy.GetExpiringBlock(ns, coll, committingBlk)
+				if err != nil {
+					return err
+				}
+				if expiryBlk == math.MaxUint64 {
+					continue
+				}
+				expInfoKey := expiryInfoKey{committingBlk: committingBlk, expiryBlk: expiryBlk}
+				expInfo, ok := toTrack[expInfoKey]
+				if !ok {
+					if expInfo, err = p.expKeeper.retrieveByExpiryKey(&expInfoKey); err != nil {
+						return err
+					}
+					if expInfo == nil {
+						// the entry has been removed when the block with the number 'expiryBlk' was committed
+						logger.Debugf("Skipping the key [%s] in ns [%s], coll [%s] as it has already expired", key, ns, coll)
+						delete(nsBatch.GetUpdates(coll), key)
+						continue
+					}
+					toTrack[expInfoKey] = expInfo
+				}
+				expInfo.pvtdataKeys.setKey(ns, coll, key, util.ComputeStringHash(key))
+			}
+		}
+	}
+	var listExpiryInfo []*expiryInfo
+	for _, expInfo := range toTrack {
+		listExpiryInfo = append(listExpiryInfo, expInfo)
+	}
+	return p.expKeeper.updateBookkeeping(listExpiryInfo, nil)
+}
+
+// addExpiredKeysToBatch adds the deletes for the keys in the given expiryInfo. A key is skipped if it has been
+// updated after the block that scheduled its expiry - either in a previous block or in the current update batch
+func (p *purgeMgr) addExpiredKeysToBatch(expInfo *expiryInfo, updates *privacyenabledstate.UpdateBatch, deleteVersion *version.Height) error {
+	committingBlk := expInfo.expiryInfoKey.committingBlk
+	for ns, colls := range expInfo.pvtdataKeys.Map {
+		for coll, keysAndHashes := range colls.Map {
+			for _, keyAndHash := range keysAndHashes.List {
+				if updates.HashUpdates.Contains(ns, coll, keyAndHash.Hash) {
+					logger.Debugf("Skipping the purge of the key hash [%#v] in ns [%s], coll [%s] as it is updated in the current block", keyAndHash.Hash, ns, coll)
+					continue
+				}
+				committedVersion, err := p.db.GetKeyHashVersion(ns, coll, keyAndHash.Hash)
+				if err != nil {
+					return err
+				}
+				if committedVersion == nil || committedVersion.BlockNum != committingBlk {
+					logger.Debugf("Skipping the purge of the key hash [%#v] in ns [%s], coll [%s] as it has been updated or deleted after block [%d]",
+						keyAndHash.Hash, ns, coll, committingBlk)
+					continue
+				}
+				logger.Debugf("Purging the expired key hash [%#v] in ns [%s], coll [%s] committed by block [%d]", keyAndHash.Hash, ns, coll, committingBlk)
+				updates.HashUpdates.Delete(ns, coll, keyAndHash.Hash, deleteVersion)
+				if keyAndHash.Key != "" {
+					updates.PvtUpdates.Delete(ns, coll, keyAndHash.Key, deleteVersion)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// buildExpirySchedule builds the schedule for the expiry of the pvt data keys that are written (and not deleted) by the update batch
+func buildExpirySchedule(blockNum uint64, btlPolicy pvtdatapolicy.BTLPolicy, updates *privacyenabledstate.UpdateBatch) ([]*expiryInfo, error) {
+	schedule := make(map[uint64]*PvtdataKeys)
+	keysFromPvtUpdates := make(map[string]map[string]map[string]string)
+
+	for ns, nsBatch := range updates.PvtUpdates.UpdateMap {
+		for _, coll := range nsBatch.GetCollectionNames() {
+			for key, vv := range nsBatch.GetUpdates(coll) {
+				if vv.Value == nil {
+					continue
+				}
+				addToNestedMap(keysFromPvtUpdates, ns, coll, string(util.ComputeStringHash(key)), key)
+			}
+		}
+	}
+
+	for ns, nsBatch := range updates.HashUpdates.UpdateMap {
+		for _, coll := range nsBatch.GetCollectionNames() {
+			expiryBlk, err := btlPolicy.GetExpiringBlock(ns, coll, blockNum)
+			if err != nil {
+				return nil, err
+			}
+			if expiryBlk == math.MaxUint64 {
+				continue
+			}
+			pvtdataKeys, ok := schedule[expiryBlk]
+			if !ok {
+				pvtdataKeys = newPvtdataKeys()
+				schedule[expiryBlk] = pvtdataKeys
+			}
+			for keyHash, vv := range nsBatch.GetUpdates(coll) {
+				if vv.Value == nil {
+					continue
+				}
+				key := keysFromPvtUpdates[ns][coll][keyHash]
+				pvtdataKeys.add(ns, coll, key, []byte(keyHash))
+			}
+		}
+	}
+
+	var listExpiryInfo []*expiryInfo
+	for expiryBlk, pvtdataKeys := range schedule {
+		if len(pvtdataKeys.Map) == 0 {
+			continue
+		}
+		listExpiryInfo = append(listExpiryInfo,
+			&expiryInfo{
+				expiryInfoKey: &expiryInfoKey{committingBlk: blockNum, expiryBlk: expiryBlk},
+				pvtdataKeys:   pvtdataKeys,
+			},
+		)
+	}
+	return listExpiryInfo, nil
+}
+
+func addToNestedMap(m map[string]map[string]map[string]string, ns, coll, keyHash, key string) {
+	collMap, ok := m[ns]
+	if !ok {
+		collMap = make(map[string]map[string]string)
+		m[ns] = collMap
+	}
+	keyMap, ok := collMap[coll]
+	if !ok {
+		keyMap = make(map[string]string)
+		collMap[coll] = keyMap
+	}
+	keyMap[keyHash] = key
+}
+
+func newPvtdataKeys() *PvtdataKeys {
+	return &PvtdataKeys{Map: make(map[string]*Collections)}
+}
+
+// setKey sets the key for the given key hash, adding the entry if the key hash is not present
+func (pvtdataKeys *PvtdataKeys) setKey(ns string, coll string, key string, keyhash []byte) {
+	for _, keyAndHash := range pvtdataKeys.Map[ns].GetMap()[coll].GetList() {
+		if bytes.Equal(keyAndHash.Hash, keyhash) {
+			keyAndHash.Key = key
+			return
+		}
+	}
+	pvtdataKeys.add(ns, coll, key, keyhash)
+}
+
+func (pvtdataKeys *PvtdataKeys) add(ns string, coll string, key string, keyhash []byte) {
+	colls, ok := pvtdataKeys.Map[ns]
+	if !ok {
+		colls = &Collections{Map: make(map[string]*KeysAndHashes)}
+		pvtdataKeys.Map[ns] = colls
+	}
+	keysAndHashes, ok := colls.Map[coll]
+	if !ok {
+		keysAndHashes = &KeysAndHashes{}
+		colls.Map[coll] = keysAndHashes
+	}
+	keysAndHashes.List = append(keysAndHashes.List, &KeyAndHash{Key: key, Hash: keyhash})
+}
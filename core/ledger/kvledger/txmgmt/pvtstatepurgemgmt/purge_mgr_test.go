@@ -0,0 +1,211 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pvtstatepurgemgmt
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger/bookkeeping"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	btltestutil "github.com/hyperledger/fabric/core/ledger/pvtdatapolicy/testutil"
+	"github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPurgeMgr(t *testing.T) {
+	dbEnv := &privacyenabledstate.LevelDBCommonStorageTestEnv{}
+	dbEnv.Init(t)
+	defer dbEnv.Cleanup()
+	bookkeepingEnv := bookkeeping.NewTestEnv(t)
+	defer bookkeepingEnv.Cleanup()
+
+	ledgerid := "testledger-purge-mgr"
+	btlPolicy := btltestutil.SampleBTLPolicy(
+		map[[2]string]uint64{
+			{"ns1", "coll1"}: 1,
+			{"ns1", "coll2"}: 2,
+			{"ns2", "coll3"}: 4,
+			{"ns2", "coll4"}: 4,
+		},
+	)
+	db := dbEnv.GetDBHandle(ledgerid)
+	purgeMgr := InstantiatePurgeMgr(ledgerid, db, btlPolicy, bookkeepingEnv.TestProvider)
+	helper := &testHelper{t, purgeMgr, db}
+
+	block1Updates := privacyenabledstate.NewUpdateBatch()
+	block1Updates.PubUpdates.Put("ns1", "pubkey1", []byte("pubvalue1-1"), version.NewHeight(1, 1))
+	putPvtAndHashUpdates(block1Updates, "ns1", "coll1", "pvtkey1", []byte("pvtvalue1-1"), version.NewHeight(1, 1))
+	putPvtAndHashUpdates(block1Updates, "ns1", "coll2", "pvtkey2", []byte("pvtvalue2-1"), version.NewHeight(1, 1))
+	putPvtAndHashUpdates(block1Updates, "ns2", "coll3", "pvtkey3", []byte("pvtvalue3-1"), version.NewHeight(1, 1))
+	putPvtAndHashUpdates(block1Updates, "ns2", "coll4", "pvtkey4", []byte("pvtvalue4-1"), version.NewHeight(1, 1))
+	// a collection with no btl configured never expires
+	putPvtAndHashUpdates(block1Updates, "ns3", "coll5", "pvtkey5", []byte("pvtvalue5-1"), version.NewHeight(1, 1))
+	helper.commitBlock(1, block1Updates)
+	helper.checkPvtdataExists("ns1", "coll1", "pvtkey1", []byte("pvtvalue1-1"))
+	helper.checkPvtdataExists("ns1", "coll2", "pvtkey2", []byte("pvtvalue2-1"))
+	helper.checkPvtdataExists("ns2", "coll3", "pvtkey3", []byte("pvtvalue3-1"))
+	helper.checkPvtdataExists("ns2", "coll4", "pvtkey4", []byte("pvtvalue4-1"))
+
+	// update pvtkey3 in block 2, so that it does not get expired with the expiry of the version committed by block 1
+	block2Updates := privacyenabledstate.NewUpdateBatch()
+	putPvtAndHashUpdates(block2Updates, "ns2", "coll3", "pvtkey3", []byte("pvtvalue3-2"), version.NewHeight(2, 1))
+	helper.commitBlock(2, block2Updates)
+	helper.checkPvtdataExists("ns1", "coll1", "pvtkey1", []byte("pvtvalue1-1"))
+	helper.checkPvtdataExists("ns1", "coll2", "pvtkey2", []byte("pvtvalue2-1"))
+
+	// pvtkey1 expires with block 3
+	helper.commitBlock(3, privacyenabledstate.NewUpdateBatch())
+	helper.checkPvtdataDoesNotExist("ns1", "coll1", "pvtkey1")
+	helper.checkPvtdataExists("ns1", "coll2", "pvtkey2", []byte("pvtvalue2-1"))
+	helper.checkPubdataExists("ns1", "pubkey1", []byte("pubvalue1-1"))
+
+	// pvtkey2 expires with block 4
+	helper.commitBlock(4, privacyenabledstate.NewUpdateBatch())
+	helper.checkPvtdataDoesNotExist("ns1", "coll2", "pvtkey2")
+
+	// pvtkey4 expires with block 6. However, pvtkey4 is updated in the block 6 itself and hence should not be purged
+	helper.commitBlock(5, privacyenabledstate.NewUpdateBatch())
+	block6Updates := privacyenabledstate.NewUpdateBatch()
+	putPvtAndHashUpdates(block6Updates, "ns2", "coll4", "pvtkey4", []byte("pvtvalue4-6"), version.NewHeight(6, 1))
+	helper.commitBlock(6, block6Updates)
+	helper.checkPvtdataExists("ns2", "coll3", "pvtkey3", []byte("pvtvalue3-2"))
+	helper.checkPvtdataExists("ns2", "coll4", "pvtkey4", []byte("pvtvalue4-6"))
+
+	// the version of pvtkey3 committed by block 2 expires with block 7
+	helper.commitBlock(7, privacyenabledstate.NewUpdateBatch())
+	helper.checkPvtdataDoesNotExist("ns2", "coll3", "pvtkey3")
+	helper.checkPvtdataExists("ns2", "coll4", "pvtkey4", []byte("pvtvalue4-6"))
+	helper.checkPvtdataExists("ns3", "coll5", "pvtkey5", []byte("pvtvalue5-1"))
+}
+
+func TestPurgeMgrProcessedEntriesAreCleared(t *testing.T) {
+	dbEnv := &privacyenabledstate.LevelDBCommonStorageTestEnv{}
+	dbEnv.Init(t)
+	defer dbEnv.Cleanup()
+	bookkeepingEnv := bookkeeping.NewTestEnv(t)
+	defer bookkeepingEnv.Cleanup()
+
+	ledgerid := "testledger-purge-mgr-bookkeeping"
+	btlPolicy := btltestutil.SampleBTLPolicy(map[[2]string]uint64{{"ns1", "coll1"}: 1})
+	db := dbEnv.GetDBHandle(ledgerid)
+	purgeMgr := InstantiatePurgeMgr(ledgerid, db, btlPolicy, bookkeepingEnv.TestProvider)
+	helper := &testHelper{t, purgeMgr, db}
+	expKeeper := newExpiryKeeper(ledgerid, bookkeepingEnv.TestProvider)
+
+	block1Updates := privacyenabledstate.NewUpdateBatch()
+	putPvtAndHashUpdates(block1Updates, "ns1", "coll1", "pvtkey1", []byte("pvtvalue1-1"), version.NewHeight(1, 1))
+	helper.commitBlock(1, block1Updates)
+	listExpinfo, err := expKeeper.retrieve(3)
+	assert.NoError(t, err)
+	assert.Len(t, listExpinfo, 1)
+	assert.Equal(t, &expiryInfoKey{committingBlk: 1, expiryBlk: 3}, listExpinfo[0].expiryInfoKey)
+
+	helper.commitBlock(2, privacyenabledstate.NewUpdateBatch())
+	helper.commitBlock(3, privacyenabledstate.NewUpdateBatch())
+	listExpinfo, err = expKeeper.retrieve(3)
+	assert.NoError(t, err)
+	assert.Len(t, listExpinfo, 0)
+}
+
+func TestPurgeMgrForPvtDataOfOldBlocks(t *testing.T) {
+	dbEnv := &privacyenabledstate.LevelDBCommonStorageTestEnv{}
+	dbEnv.Init(t)
+	defer dbEnv.Cleanup()
+	bookkeepingEnv := bookkeeping.NewTestEnv(t)
+	defer bookkeepingEnv.Cleanup()
+
+	ledgerid := "testledger-purge-mgr-pvtdata-of-old-blocks"
+	btlPolicy := btltestutil.SampleBTLPolicy(
+		map[[2]string]uint64{
+			{"ns1", "coll1"}: 1,
+			{"ns1", "coll2"}: 2,
+		},
+	)
+	db := dbEnv.GetDBHandle(ledgerid)
+	purgeMgr := InstantiatePurgeMgr(ledgerid, db, btlPolicy, bookkeepingEnv.TestProvider)
+	helper := &testHelper{t, purgeMgr, db}
+
+	// block 1 commits only the hashes of pvtkey1 and pvtkey2, as the pvt data is missing
+	block1Updates := privacyenabledstate.NewUpdateBatch()
+	putHashUpdates(block1Updates, "ns1", "coll1", "pvtkey1", []byte("pvtvalue1-1"), version.NewHeight(1, 1))
+	putHashUpdates(block1Updates, "ns1", "coll2", "pvtkey2", []byte("pvtvalue2-1"), version.NewHeight(1, 1))
+	helper.commitBlock(1, block1Updates)
+
+	// the pvt data of pvtkey1 is committed after block 1
+	oldBlocksUpdates := privacyenabledstate.NewUpdateBatch()
+	oldBlocksUpdates.PvtUpdates.Put("ns1", "coll1", "pvtkey1", []byte("pvtvalue1-1"), version.NewHeight(1, 1))
+	helper.commitPvtDataOfOldBlocks(oldBlocksUpdates)
+	helper.checkPvtdataExists("ns1", "coll1", "pvtkey1", []byte("pvtvalue1-1"))
+
+	// pvtkey1 expires with block 3 and, as the key is now known to the bookkeeping, the pvt data gets purged as well
+	helper.commitBlock(2, privacyenabledstate.NewUpdateBatch())
+	helper.commitBlock(3, privacyenabledstate.NewUpdateBatch())
+	helper.checkPvtdataDoesNotExist("ns1", "coll1", "pvtkey1")
+
+	// pvtkey2 expires with block 4. The pvt data that arrives after its expiry is not committed
+	helper.commitBlock(4, privacyenabledstate.NewUpdateBatch())
+	oldBlocksUpdates = privacyenabledstate.NewUpdateBatch()
+	oldBlocksUpdates.PvtUpdates.Put("ns1", "coll2", "pvtkey2", []byte("pvtvalue2-1"), version.NewHeight(1, 1))
+	helper.commitPvtDataOfOldBlocks(oldBlocksUpdates)
+	helper.checkPvtdataDoesNotExist("ns1", "coll2", "pvtkey2")
+}
+
+type testHelper struct {
+	t        *testing.T
+	purgeMgr PurgeMgr
+	db       privacyenabledstate.DB
+}
+
+func (h *testHelper) commitBlock(blkNum uint64, updates *privacyenabledstate.UpdateBatch) {
+	assert.NoError(h.t, h.purgeMgr.DeleteExpiredAndUpdateBookkeeping(blkNum, updates))
+	assert.NoError(h.t, h.db.ApplyPrivacyAwareUpdates(updates, version.NewHeight(blkNum, 1)))
+	assert.NoError(h.t, h.purgeMgr.BlockCommitDone())
+}
+
+func (h *testHelper) commitPvtDataOfOldBlocks(updates *privacyenabledstate.UpdateBatch) {
+	assert.NoError(h.t, h.purgeMgr.UpdateBookkeepingForPvtDataOfOldBlocks(updates.PvtUpdates))
+	savepoint, err := h.db.GetLatestSavePoint()
+	assert.NoError(h.t, err)
+	assert.NoError(h.t, h.db.ApplyPrivacyAwareUpdates(updates, savepoint))
+}
+
+func (h *testHelper) checkPubdataExists(ns, key string, expectedValue []byte) {
+	vv, err := h.db.GetState(ns, key)
+	assert.NoError(h.t, err)
+	assert.NotNil(h.t, vv)
+	assert.Equal(h.t, expectedValue, vv.Value)
+}
+
+func (h *testHelper) checkPvtdataExists(ns, coll, key string, expectedValue []byte) {
+	vv, err := h.db.GetPrivateData(ns, coll, key)
+	assert.NoError(h.t, err)
+	assert.NotNil(h.t, vv)
+	assert.Equal(h.t, expectedValue, vv.Value)
+	hashVV, err := h.db.GetValueHash(ns, coll, util.ComputeStringHash(key))
+	assert.NoError(h.t, err)
+	assert.NotNil(h.t, hashVV)
+	assert.Equal(h.t, util.ComputeHash(expectedValue), hashVV.Value)
+}
+
+func (h *testHelper) checkPvtdataDoesNotExist(ns, coll, key string) {
+	vv, err := h.db.GetPrivateData(ns, coll, key)
+	assert.NoError(h.t, err)
+	assert.Nil(h.t, vv)
+	hashVV, err := h.db.GetValueHash(ns, coll, util.ComputeStringHash(key))
+	assert.NoError(h.t, err)
+	assert.Nil(h.t, hashVV)
+}
+
+func putPvtAndHashUpdates(updates *privacyenabledstate.UpdateBatch, ns, coll, key string, value []byte, ver *version.Height) {
+	updates.PvtUpdates.Put(ns, coll, key, value, ver)
+	updates.HashUpdates.Put(ns, coll, util.ComputeStringHash(key), util.ComputeHash(value), ver)
+}
+
+func putHashUpdates(updates *privacyenabledstate.UpdateBatch, ns, coll, key string, value []byte, ver *version.Height) {
+	updates.HashUpdates.Put(ns, coll, util.ComputeStringHash(key), util.ComputeHash(value), ver)
+}
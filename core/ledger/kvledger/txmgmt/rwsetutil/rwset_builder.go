@@ -37,6 +37,7 @@ type nsPubRwBuilder struct {
 	namespace         string
 	readMap           map[string]*kvrwset.KVRead //for mvcc validation
 	writeMap          map[string]*kvrwset.KVWrite
+	metadataWriteMap  map[string]*kvrwset.KVMetadataWrite
 	rangeQueriesMap   map[rangeQueryKey]*kvrwset.RangeQueryInfo //for phantom read validation
 	rangeQueriesKeys  []rangeQueryKey
 	collHashRwBuilder map[string]*collHashRwBuilder
@@ -82,6 +83,13 @@ func (b *RWSetBuilder) AddToWriteSet(ns string, key string, value []byte) {
 	nsPubRwBuilder.writeMap[key] = newKVWrite(key, value)
 }
 
+// AddToMetadataWriteSet adds the metadata of a key to the metadata write-set.
+// A nil or empty metadata indicates the deletion of the metadata of the key
+func (b *RWSetBuilder) AddToMetadataWriteSet(ns string, key string, metadata map[string][]byte) {
+	nsPubRwBuilder := b.getOrCreateNsPubRwBuilder(ns)
+	nsPubRwBuilder.metadataWriteMap[key] = newKVMetadataWrite(key, metadata)
+}
+
 // AddToRangeQuerySet adds a range query info for performing phantom read validation
 func (b *RWSetBuilder) AddToRangeQuerySet(ns string, rqi *kvrwset.RangeQueryInfo) {
 	nsPubRwBuilder := b.getOrCreateNsPubRwBuilder(ns)
@@ -183,12 +191,15 @@ func (b *RWSetBuilder) getTxPvtReadWriteSet() *TxPvtRwSet {
 func (b *nsPubRwBuilder) build() *NsRwSet {
 	var readSet []*kvrwset.KVRead
 	var writeSet []*kvrwset.KVWrite
+	var metadataWriteSet []*kvrwset.KVMetadataWrite
 	var rangeQueriesInfo []*kvrwset.RangeQueryInfo
 	var collHashedRwSet []*CollHashedRwSet
 	//add read set
 	util.GetValuesBySortedKeys(&(b.readMap), &readSet)
 	//add write set
 	util.GetValuesBySortedKeys(&(b.writeMap), &writeSet)
+	//add metadata write set
+	util.GetValuesBySortedKeys(&(b.metadataWriteMap), &metadataWriteSet)
 	//add range query info
 	for _, key := range b.rangeQueriesKeys {
 		rangeQueriesInfo = append(rangeQueriesInfo, b.rangeQueriesMap[key])
@@ -201,7 +212,7 @@ func (b *nsPubRwBuilder) build() *NsRwSet {
 	}
 	return &NsRwSet{
 		NameSpace:        b.namespace,
-		KvRwSet:          &kvrwset.KVRWSet{Reads: readSet, Writes: writeSet, RangeQueriesInfo: rangeQueriesInfo},
+		KvRwSet:          &kvrwset.KVRWSet{Reads: readSet, Writes: writeSet, MetadataWrites: metadataWriteSet, RangeQueriesInfo: rangeQueriesInfo},
 		CollHashedRwSets: collHashedRwSet,
 	}
 }
@@ -286,6 +297,7 @@ func newNsPubRwBuilder(namespace string) *nsPubRwBuilder {
 		namespace,
 		make(map[string]*kvrwset.KVRead),
 		make(map[string]*kvrwset.KVWrite),
+		make(map[string]*kvrwset.KVMetadataWrite),
 		make(map[rangeQueryKey]*kvrwset.RangeQueryInfo),
 		nil,
 		make(map[string]*collHashRwBuilder),
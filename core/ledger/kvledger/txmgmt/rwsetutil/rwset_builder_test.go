@@ -91,6 +91,30 @@ func TestTxSimulationResultWithOnlyPubData(t *testing.T) {
 	testutil.AssertNil(t, txSimulationResults.PubSimulationResults.NsRwset[0].CollectionHashedRwset)
 }
 
+func TestTxSimulationResultWithMetadataWrites(t *testing.T) {
+	rwSetBuilder := NewRWSetBuilder()
+	rwSetBuilder.AddToWriteSet("ns1", "key1", []byte("value1"))
+	rwSetBuilder.AddToMetadataWriteSet("ns1", "key2", map[string][]byte{"name2": []byte("value2"), "name1": []byte("value1")})
+	rwSetBuilder.AddToMetadataWriteSet("ns1", "key1", map[string][]byte{"name1": []byte("value1")})
+	rwSetBuilder.AddToMetadataWriteSet("ns1", "key3", nil)
+
+	txSimulationResults, err := rwSetBuilder.GetTxSimulationResults()
+	assert.NoError(t, err)
+
+	ns1KVRWSet := &kvrwset.KVRWSet{
+		Writes: []*kvrwset.KVWrite{newKVWrite("key1", []byte("value1"))},
+		MetadataWrites: []*kvrwset.KVMetadataWrite{
+			{Key: "key1", Entries: []*kvrwset.KVMetadataEntry{{Name: "name1", Value: []byte("value1")}}},
+			{Key: "key2", Entries: []*kvrwset.KVMetadataEntry{{Name: "name1", Value: []byte("value1")}, {Name: "name2", Value: []byte("value2")}}},
+			{Key: "key3"},
+		},
+	}
+	expectedTxRWSet := &rwset.TxReadWriteSet{NsRwset: []*rwset.NsReadWriteSet{
+		{Namespace: "ns1", Rwset: serializeTestProtoMsg(t, ns1KVRWSet)},
+	}}
+	assert.Equal(t, expectedTxRWSet, txSimulationResults.PubSimulationResults)
+}
+
 func TestTxSimulationResultWithPvtData(t *testing.T) {
 	rwSetBuilder := NewRWSetBuilder()
 	// public rws ns1 + ns2
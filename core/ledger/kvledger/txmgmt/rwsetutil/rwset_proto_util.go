@@ -303,6 +303,10 @@ func newKVWrite(key string, value []byte) *kvrwset.KVWrite {
 	return &kvrwset.KVWrite{Key: key, IsDelete: value == nil, Value: value}
 }
 
+func newKVMetadataWrite(key string, metadata map[string][]byte) *kvrwset.KVMetadataWrite {
+	return &kvrwset.KVMetadataWrite{Key: key, Entries: util.MetadataMapToEntries(metadata)}
+}
+
 func newPvtKVReadHash(key string, version *version.Height) (*kvrwset.KVReadHash, error) {
 	return &kvrwset.KVReadHash{KeyHash: util.ComputeStringHash(key), Version: newProtoVersion(version)}, nil
 }
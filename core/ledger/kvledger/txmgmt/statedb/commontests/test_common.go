@@ -17,6 +17,7 @@ limitations under the License.
 package commontests
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 
@@ -132,6 +133,40 @@ func TestMultiDBBasicRW(t *testing.T, dbProvider statedb.VersionedDBProvider) {
 	testutil.AssertEquals(t, sp, savePoint2)
 }
 
+// TestDrop tests that dropping a db removes all its data and leaves the other dbs intact
+func TestDrop(t *testing.T, dbProvider statedb.VersionedDBProvider) {
+	db1, err := dbProvider.GetDBHandle("testdrop")
+	testutil.AssertNoError(t, err, "")
+	db2, err := dbProvider.GetDBHandle("testdrop2")
+	testutil.AssertNoError(t, err, "")
+
+	for _, db := range []statedb.VersionedDB{db1, db2} {
+		batch := statedb.NewUpdateBatch()
+		batch.Put("ns1", "key1", []byte("value1"), version.NewHeight(1, 1))
+		batch.Put("ns2", "key1", []byte("value1"), version.NewHeight(1, 2))
+		db.ApplyUpdates(batch, version.NewHeight(1, 2))
+	}
+
+	testutil.AssertNoError(t, dbProvider.Drop("testdrop"), "")
+	db1, err = dbProvider.GetDBHandle("testdrop")
+	testutil.AssertNoError(t, err, "")
+	for _, ns := range []string{"ns1", "ns2"} {
+		vv, err := db1.GetState(ns, "key1")
+		testutil.AssertNoError(t, err, "")
+		testutil.AssertNil(t, vv)
+	}
+	sp, err := db1.GetLatestSavePoint()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, sp)
+
+	vv, err := db2.GetState("ns2", "key1")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, vv.Value, []byte("value1"))
+	sp, err = db2.GetLatestSavePoint()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, sp, version.NewHeight(1, 2))
+}
+
 // TestDeletes tests deletes
 func TestDeletes(t *testing.T, dbProvider statedb.VersionedDBProvider) {
 	db, err := dbProvider.GetDBHandle("testdeletes")
@@ -197,6 +232,97 @@ func TestIterator(t *testing.T, dbProvider statedb.VersionedDBProvider) {
 	testItr(t, itr4, []string{"key5", "key6"})
 }
 
+// TestPaginatedRangeQuery tests the range queries which return the results in pages
+func TestPaginatedRangeQuery(t *testing.T, dbProvider statedb.VersionedDBProvider) {
+	db, err := dbProvider.GetDBHandle("testpaginatedrangequery")
+	testutil.AssertNoError(t, err, "")
+	db.Open()
+	defer db.Close()
+	batch := statedb.NewUpdateBatch()
+	for i := 1; i <= 5; i++ {
+		batch.Put("ns1", fmt.Sprintf("key%d", i), []byte(fmt.Sprintf("value%d", i)), version.NewHeight(1, uint64(i)))
+	}
+	batch.Put("ns2", "key6", []byte("value6"), version.NewHeight(1, 6))
+	savePoint := version.NewHeight(2, 5)
+	testutil.AssertNoError(t, db.ApplyUpdates(batch, savePoint), "")
+
+	// the bookmark of a page is the start key of the next one
+	itr, err := db.GetStateRangeScanIteratorWithPagination("ns1", "", "", 2)
+	testutil.AssertNoError(t, err, "")
+	testItrWithoutClose(t, itr, []string{"key1", "key2"})
+	testutil.AssertEquals(t, itr.GetBookmarkAndClose(), "key3")
+
+	itr, err = db.GetStateRangeScanIteratorWithPagination("ns1", "key3", "", 2)
+	testutil.AssertNoError(t, err, "")
+	testItrWithoutClose(t, itr, []string{"key3", "key4"})
+	testutil.AssertEquals(t, itr.GetBookmarkAndClose(), "key5")
+
+	// the last page has an empty bookmark
+	itr, err = db.GetStateRangeScanIteratorWithPagination("ns1", "key5", "", 2)
+	testutil.AssertNoError(t, err, "")
+	testItrWithoutClose(t, itr, []string{"key5"})
+	testutil.AssertEquals(t, itr.GetBookmarkAndClose(), "")
+
+	// the end key is honored across the pages
+	itr, err = db.GetStateRangeScanIteratorWithPagination("ns1", "key2", "key4", 2)
+	testutil.AssertNoError(t, err, "")
+	testItrWithoutClose(t, itr, []string{"key2", "key3"})
+	testutil.AssertEquals(t, itr.GetBookmarkAndClose(), "")
+
+	_, err = db.GetStateRangeScanIteratorWithPagination("ns1", "", "", 0)
+	testutil.AssertError(t, err, "Expected error for a zero page size")
+}
+
+// TestFullScanIterator tests the iterator over the entire contents of the db
+func TestFullScanIterator(t *testing.T, dbProvider statedb.VersionedDBProvider) {
+	db, err := dbProvider.GetDBHandle("testfullscaniterator")
+	testutil.AssertNoError(t, err, "")
+	db.Open()
+	defer db.Close()
+	batch := statedb.NewUpdateBatch()
+	batch.Put("ns1", "key1", []byte("value1"), version.NewHeight(1, 1))
+	batch.Put("ns1", "key2", []byte("value2"), version.NewHeight(1, 2))
+	batch.Put("ns2", "key3", []byte("value3"), version.NewHeight(1, 3))
+	batch.Put("ns3", "key4", []byte("value4"), version.NewHeight(1, 4))
+	batch.Put("ns3", "key5", []byte("value5"), version.NewHeight(1, 5))
+	savePoint := version.NewHeight(2, 5)
+	testutil.AssertNoError(t, db.ApplyUpdates(batch, savePoint), "")
+
+	itr, err := db.GetFullScanIterator(func(ns string) bool { return ns == "ns2" })
+	testutil.AssertNoError(t, err, "")
+	defer itr.Close()
+	results := map[statedb.CompositeKey]*statedb.VersionedValue{}
+	for {
+		queryResult, err := itr.Next()
+		testutil.AssertNoError(t, err, "")
+		if queryResult == nil {
+			break
+		}
+		vkv := queryResult.(*statedb.VersionedKV)
+		results[vkv.CompositeKey] = &statedb.VersionedValue{Value: vkv.Value, Version: vkv.Version}
+	}
+	testutil.AssertEquals(t, results, map[statedb.CompositeKey]*statedb.VersionedValue{
+		{Namespace: "ns1", Key: "key1"}: {Value: []byte("value1"), Version: version.NewHeight(1, 1)},
+		{Namespace: "ns1", Key: "key2"}: {Value: []byte("value2"), Version: version.NewHeight(1, 2)},
+		{Namespace: "ns3", Key: "key4"}: {Value: []byte("value4"), Version: version.NewHeight(1, 4)},
+		{Namespace: "ns3", Key: "key5"}: {Value: []byte("value5"), Version: version.NewHeight(1, 5)},
+	})
+}
+
+// testItrWithoutClose verifies the keys returned by the iterator, without closing it,
+// so that the bookmark of a paginated iterator can be retrieved afterwards
+func testItrWithoutClose(t *testing.T, itr statedb.ResultsIterator, expectedKeys []string) {
+	for _, expectedKey := range expectedKeys {
+		queryResult, err := itr.Next()
+		testutil.AssertNoError(t, err, "")
+		testutil.AssertNotNil(t, queryResult)
+		testutil.AssertEquals(t, queryResult.(*statedb.VersionedKV).Key, expectedKey)
+	}
+	last, err := itr.Next()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNil(t, last)
+}
+
 func testItr(t *testing.T, itr statedb.ResultsIterator, expectedKeys []string) {
 	defer itr.Close()
 	for _, expectedKey := range expectedKeys {
@@ -724,3 +850,40 @@ func TestBatchWithIndividualRetry(t *testing.T, dbProvider statedb.VersionedDBPr
 	testutil.AssertNoError(t, err, "")
 
 }
+
+// TestValueAndMetadataWrites tests that the metadata of the keys is stored and retrieved along with the values
+func TestValueAndMetadataWrites(t *testing.T, dbProvider statedb.VersionedDBProvider) {
+	db, err := dbProvider.GetDBHandle("testvalueandmetadata")
+	testutil.AssertNoError(t, err, "")
+	batch := statedb.NewUpdateBatch()
+
+	vv1 := statedb.VersionedValue{Value: []byte("value1"), Metadata: []byte("metadata1"), Version: version.NewHeight(1, 1)}
+	vv2 := statedb.VersionedValue{Value: []byte("value2"), Metadata: []byte("metadata2"), Version: version.NewHeight(1, 2)}
+	vv3 := statedb.VersionedValue{Value: []byte(`{"color":"blue"}`), Metadata: []byte("metadata3"), Version: version.NewHeight(1, 3)}
+	vv4 := statedb.VersionedValue{Value: []byte("value4"), Version: version.NewHeight(1, 4)}
+
+	batch.PutValAndMetadata("ns1", "key1", vv1.Value, vv1.Metadata, vv1.Version)
+	batch.PutValAndMetadata("ns1", "key2", vv2.Value, vv2.Metadata, vv2.Version)
+	batch.PutValAndMetadata("ns1", "key3", vv3.Value, vv3.Metadata, vv3.Version)
+	batch.Put("ns1", "key4", vv4.Value, vv4.Version)
+	testutil.AssertNoError(t, db.ApplyUpdates(batch, version.NewHeight(2, 5)), "")
+
+	vv, err := db.GetState("ns1", "key1")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, vv, &vv1)
+
+	vv, err = db.GetState("ns1", "key3")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, vv, &vv3)
+
+	vv, err = db.GetState("ns1", "key4")
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, vv, &vv4)
+
+	itr, err := db.GetStateRangeScanIterator("ns1", "key2", "key3")
+	testutil.AssertNoError(t, err, "")
+	defer itr.Close()
+	queryResult, err := itr.Next()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, queryResult.(*statedb.VersionedKV).VersionedValue, vv2)
+}
@@ -29,6 +29,8 @@ const jsonQuerySelector = "selector"
 const jsonQueryUseIndex = "use_index"
 const jsonQueryLimit = "limit"
 const jsonQuerySkip = "skip"
+const jsonQueryBookmark = "bookmark"
+const jsonIndex = "index"
 
 var validOperators = []string{"$and", "$or", "$not", "$nor", "$all", "$elemMatch",
 	"$lt", "$lte", "$eq", "$ne", "$gte", "$gt", "$exits", "$type", "$in", "$nin",
@@ -62,6 +64,17 @@ Result Wrapped Query:
 
 */
 func ApplyQueryWrapper(namespace, queryString string, queryLimit, querySkip int) (string, error) {
+	return applyQueryWrapper(namespace, queryString, queryLimit, querySkip, "")
+}
+
+//ApplyQueryWrapperWithBookmark applies the query wrapper like ApplyQueryWrapper and,
+//if the bookmark is not empty, adds the "bookmark" returned by CouchDB for the previous page
+//of the results to the query, so that the query returns the next page of the results
+func ApplyQueryWrapperWithBookmark(namespace, queryString string, queryLimit int, bookmark string) (string, error) {
+	return applyQueryWrapper(namespace, queryString, queryLimit, 0, bookmark)
+}
+
+func applyQueryWrapper(namespace, queryString string, queryLimit, querySkip int, bookmark string) (string, error) {
 
 	//create a generic map for the query json
 	jsonQueryMap := make(map[string]interface{})
@@ -103,6 +116,11 @@ func ApplyQueryWrapper(namespace, queryString string, queryLimit, querySkip int)
 	//Add skip
 	jsonQueryMap[jsonQuerySkip] = querySkip
 
+	//Add bookmark, if any
+	if bookmark != "" {
+		jsonQueryMap[jsonQueryBookmark] = bookmark
+	}
+
 	//Marshal the updated json query
 	editedQuery, _ := json.Marshal(jsonQueryMap)
 
@@ -112,6 +130,66 @@ func ApplyQueryWrapper(namespace, queryString string, queryLimit, querySkip int)
 
 }
 
+/*
+ApplyIndexWrapper parses an index definition packaged with a chaincode and prepends
+the wrapper "data." to the field names of the index, in the same way as
+ApplyQueryWrapper does for the queries of the chaincode
+
+Example:
+
+Source Index:
+{"index":{"fields":["docType",{"size":"desc"}]},"ddoc":"indexSizeDoc","name":"indexSize","type":"json"}
+
+Result Wrapped Index:
+{"index":{"fields":["data.docType",{"data.size":"desc"}]},"ddoc":"indexSizeDoc","name":"indexSize","type":"json"}
+
+*/
+func ApplyIndexWrapper(indexDefinition string) (string, error) {
+
+	//create a generic map for the index json
+	jsonIndexMap := make(map[string]interface{})
+
+	//unmarshal the index json into the generic map
+	decoder := json.NewDecoder(bytes.NewBuffer([]byte(indexDefinition)))
+	decoder.UseNumber()
+	err := decoder.Decode(&jsonIndexMap)
+	if err != nil {
+		return "", err
+	}
+
+	index, ok := jsonIndexMap[jsonIndex].(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("index definition does not contain an \"%s\" object", jsonIndex)
+	}
+	fields, ok := index[jsonQueryFields].([]interface{})
+	if !ok {
+		return "", fmt.Errorf("index definition does not contain a \"%s\" array", jsonQueryFields)
+	}
+
+	//wrap the field names, which are either plain strings or maps of a field name to a sort direction
+	for itemKey, itemValue := range fields {
+		switch itemValueType := itemValue.(type) {
+		case string:
+			fields[itemKey] = fmt.Sprintf("%v.%v", dataWrapper, itemValueType)
+		case map[string]interface{}:
+			wrappedField := make(map[string]interface{})
+			for key, value := range itemValueType {
+				wrappedField[fmt.Sprintf("%v.%v", dataWrapper, key)] = value
+			}
+			fields[itemKey] = wrappedField
+		default:
+			return "", fmt.Errorf("unexpected field %v in the index definition", itemValue)
+		}
+	}
+
+	//Marshal the updated index definition
+	editedIndex, _ := json.Marshal(jsonIndexMap)
+
+	logger.Debugf("Rewritten index definition with data wrapper: %s", editedIndex)
+
+	return string(editedIndex), nil
+}
+
 //setNamespaceInSelector adds an additional hierarchy in the "selector"
 //{"owner": {"$eq": "tom"}}
 //would be mapped as (assuming a namespace of "marble"):
@@ -381,3 +381,49 @@ func TestQueryWithLargeInteger(t *testing.T) {
 	testutil.AssertEquals(t, strings.Count(wrappedQuery, "{\"$eq\":1000007}"), 1)
 
 }
+
+//TestApplyIndexWrapper tests the wrapping of the fields of an index definition
+func TestApplyIndexWrapper(t *testing.T) {
+
+	rawIndex := []byte(`{"index":{"fields":["docType",{"size":"desc"}]},"ddoc":"indexSizeDoc","name":"indexSize","type":"json"}`)
+
+	wrappedIndex, err := ApplyIndexWrapper(string(rawIndex))
+
+	//Make sure the index definition did not throw an exception
+	testutil.AssertNoError(t, err, "Unexpected error thrown when for index JSON")
+
+	//check to make sure the fields are wrapped and the other attributes are preserved
+	testutil.AssertEquals(t, wrappedIndex,
+		`{"ddoc":"indexSizeDoc","index":{"fields":["data.docType",{"data.size":"desc"}]},"name":"indexSize","type":"json"}`)
+
+	_, err = ApplyIndexWrapper(`{"index":`)
+	testutil.AssertError(t, err, "Expected error for invalid index JSON")
+
+	_, err = ApplyIndexWrapper(`{"fields":["docType"]}`)
+	testutil.AssertError(t, err, "Expected error for index JSON without an index object")
+
+	_, err = ApplyIndexWrapper(`{"index":{"fields":[10]}}`)
+	testutil.AssertError(t, err, "Expected error for index JSON with an unexpected field")
+
+}
+
+//TestQueryWithBookmark tests query with the bookmark of the previous page
+func TestQueryWithBookmark(t *testing.T) {
+
+	rawQuery := []byte(`{"selector":{"owner":{"$eq":"jerry"}}}`)
+
+	wrappedQuery, err := ApplyQueryWrapperWithBookmark("ns1", string(rawQuery), 5, "g1AAAABweJzLYWBgYMpgSmHgKy5JLCrJTq2MT8lPzkzJBYqzFhfkZ")
+
+	//Make sure the query did not throw an exception
+	testutil.AssertNoError(t, err, "Unexpected error thrown when for query JSON")
+
+	//check to make sure the bookmark and the page size are added
+	testutil.AssertEquals(t, strings.Count(wrappedQuery, "\"bookmark\":\"g1AAAABweJzLYWBgYMpgSmHgKy5JLCrJTq2MT8lPzkzJBYqzFhfkZ\""), 1)
+	testutil.AssertEquals(t, strings.Count(wrappedQuery, "\"limit\":5"), 1)
+
+	//check to make sure the bookmark is not added if it is empty
+	wrappedQuery, err = ApplyQueryWrapperWithBookmark("ns1", string(rawQuery), 5, "")
+	testutil.AssertNoError(t, err, "Unexpected error thrown when for query JSON")
+	testutil.AssertEquals(t, strings.Count(wrappedQuery, "\"bookmark\""), 0)
+
+}
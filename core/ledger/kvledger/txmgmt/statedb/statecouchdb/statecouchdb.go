@@ -7,15 +7,18 @@ package statecouchdb
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path"
 	"strconv"
 	"strings"
 	"sync"
 	"unicode/utf8"
 
 	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/core/common/ccprovider"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
 	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
@@ -27,6 +30,14 @@ var logger = flogging.MustGetLogger("statecouchdb")
 
 var binaryWrapper = "valueBytes"
 
+// metadataField is the field of the document header which holds the base64 encoded
+// metadata of the key, if the key has any
+const metadataField = "metadata"
+
+// couchdbIndexesDir is the directory of the statedb artifacts of a chaincode package
+// which holds the CouchDB index definitions
+const couchdbIndexesDir = "couchdb/indexes"
+
 // querySkip is implemented for future use by query paging
 // currently defaulted to 0 and is not used
 var querySkip = 0
@@ -85,6 +96,32 @@ func (provider *VersionedDBProvider) GetDBHandle(dbName string) (statedb.Version
 	return vdb, nil
 }
 
+// Drop drops the metadata database and all the namespace databases of the named database
+func (provider *VersionedDBProvider) Drop(dbName string) error {
+	provider.mux.Lock()
+	defer provider.mux.Unlock()
+
+	delete(provider.databases, dbName)
+	// the names of the couch databases are derived from the named database as done in newVersionedDB
+	// and in getNamespaceDBHandle, where the '.' is mapped to '$' by couchdb.CreateCouchDatabase
+	prefix := strings.Replace(dbName+"_", ".", "$", -1)
+	couchDBNames, err := provider.couchInstance.RetrieveDatabaseNames()
+	if err != nil {
+		return err
+	}
+	for _, couchDBName := range couchDBNames {
+		if !strings.HasPrefix(couchDBName, prefix) {
+			continue
+		}
+		logger.Debugf("Dropping couch database [%s]", couchDBName)
+		couchDB := &couchdb.CouchDatabase{CouchInstance: *provider.couchInstance, DBName: couchDBName}
+		if _, err := couchDB.DropDatabase(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Close closes the underlying db instance
 func (provider *VersionedDBProvider) Close() {
 	// No close needed on Couch
@@ -147,6 +184,40 @@ func (vdb *VersionedDB) getNamespaceDBHandle(namespace string) (*couchdb.CouchDa
 	return db, nil
 }
 
+// GetDBType returns the hosted stateDB
+func (vdb *VersionedDB) GetDBType() string {
+	return "couchdb"
+}
+
+// ProcessIndexesForChaincodeDeploy creates the indexes packaged with a chaincode in the
+// database of the namespace of the chaincode. The file entries are expected to be named
+// relative to the statedb artifacts of the package, e.g. couchdb/indexes/indexOwner.json.
+// The failure to create an index is logged and does not prevent the creation of the others
+func (vdb *VersionedDB) ProcessIndexesForChaincodeDeploy(namespace string, fileEntries []*ccprovider.TarFileEntry) error {
+	db, err := vdb.getNamespaceDBHandle(namespace)
+	if err != nil {
+		return err
+	}
+	for _, fileEntry := range fileEntries {
+		filename := fileEntry.FileHeader.Name
+		if path.Dir(filename) != couchdbIndexesDir {
+			logger.Warningf("Skipping the file [%s] of chaincode [%s], since it is not an index definition", filename, namespace)
+			continue
+		}
+		indexDefinition, err := ApplyIndexWrapper(string(fileEntry.FileContent))
+		if err != nil {
+			logger.Errorf("Error during parsing of the index from file=[%s] for chaincode=[%s]. Error=%s", filename, namespace, err)
+			continue
+		}
+		if err := db.CreateIndex(indexDefinition); err != nil {
+			logger.Errorf("Error during creation of index from file=[%s] for chaincode=[%s]. Error=%s", filename, namespace, err)
+			continue
+		}
+		logger.Infof("Created index from file=[%s] for chaincode=[%s] on database [%s]", filename, namespace, db.DBName)
+	}
+	return nil
+}
+
 // Open implements method in VersionedDB interface
 func (vdb *VersionedDB) Open() error {
 	// no need to open db since a shared couch instance is used
@@ -188,9 +259,9 @@ func (vdb *VersionedDB) GetState(namespace string, key string) (*statedb.Version
 	}
 
 	// remove the data wrapper and return the value and version
-	returnValue, returnVersion := removeDataWrapper(couchDoc.JSONValue, couchDoc.Attachments)
+	returnValue, returnMetadata, returnVersion := removeDataWrapper(couchDoc.JSONValue, couchDoc.Attachments)
 
-	return &statedb.VersionedValue{Value: returnValue, Version: returnVersion}, nil
+	return &statedb.VersionedValue{Value: returnValue, Metadata: returnMetadata, Version: returnVersion}, nil
 }
 
 //GetCachedVersion implements method in VersionedDB interface
@@ -248,7 +319,7 @@ func (vdb *VersionedDB) GetVersion(namespace string, key string) (*version.Heigh
 	return returnVersion, nil
 }
 
-func removeDataWrapper(wrappedValue []byte, attachments []*couchdb.AttachmentInfo) ([]byte, *version.Height) {
+func removeDataWrapper(wrappedValue []byte, attachments []*couchdb.AttachmentInfo) ([]byte, []byte, *version.Height) {
 
 	// initialize the return value
 	returnValue := []byte{}
@@ -280,7 +351,13 @@ func removeDataWrapper(wrappedValue []byte, attachments []*couchdb.AttachmentInf
 
 	returnVersion := createVersionHeightFromVersionString(jsonResult["version"].(string))
 
-	return returnValue, returnVersion
+	// the metadata of the key is kept in the header as a base64 encoded string
+	var returnMetadata []byte
+	if encodedMetadata, ok := jsonResult[metadataField].(string); ok {
+		returnMetadata, _ = base64.StdEncoding.DecodeString(encodedMetadata)
+	}
+
+	return returnValue, returnMetadata, returnVersion
 
 }
 
@@ -322,6 +399,56 @@ func (vdb *VersionedDB) GetStateRangeScanIterator(namespace string, startKey str
 
 }
 
+// GetStateRangeScanIteratorWithPagination implements method in VersionedDB interface
+// One more document than the page size is read from CouchDB, in order to determine the
+// bookmark, i.e. the start key of the next page
+func (vdb *VersionedDB) GetStateRangeScanIteratorWithPagination(namespace string, startKey string, endKey string, pageSize int32) (statedb.QueryResultsIterator, error) {
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("invalid page size [%d], it must be greater than zero", pageSize)
+	}
+
+	db, err := vdb.getNamespaceDBHandle(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	queryResult, err := db.ReadDocRange(startKey, endKey, int(pageSize)+1, querySkip)
+	if err != nil {
+		logger.Debugf("Error calling ReadDocRange(): %s\n", err.Error())
+		return nil, err
+	}
+	results := *queryResult
+	bookmark := ""
+	if len(results) > int(pageSize) {
+		bookmark = results[pageSize].ID
+		results = results[:pageSize]
+	}
+	logger.Debugf("Exiting GetStateRangeScanIteratorWithPagination")
+	return &paginatedScanner{newKVScanner(namespace, results), bookmark}, nil
+}
+
+// GetFullScanIterator implements method in VersionedDB interface
+func (vdb *VersionedDB) GetFullScanIterator(skipNamespace func(string) bool) (statedb.ResultsIterator, error) {
+	dbNames, err := vdb.couchInstance.RetrieveDatabaseNames()
+	if err != nil {
+		return nil, err
+	}
+	var namespaceDBNames []string
+	for _, dbName := range dbNames {
+		// the namespace databases of this channel are prefixed with the name of the metadata database of the channel
+		if dbName != vdb.dbName && strings.HasPrefix(dbName, vdb.dbName) {
+			namespaceDBNames = append(namespaceDBNames, dbName)
+		}
+	}
+	logger.Debugf("Channel [%s]: Namespace databases for the full scan = %s", vdb.dbName, namespaceDBNames)
+	return newFullScanner(vdb.couchInstance, namespaceDBNames, skipNamespace, ledgerconfig.GetQueryLimit()), nil
+}
+
+// FullScanReadsSnapshot implements method in VersionedDB interface
+func (vdb *VersionedDB) FullScanReadsSnapshot() bool {
+	return false
+}
+
 // ExecuteQuery implements method in VersionedDB interface
 func (vdb *VersionedDB) ExecuteQuery(namespace, query string) (statedb.ResultsIterator, error) {
 
@@ -349,6 +476,37 @@ func (vdb *VersionedDB) ExecuteQuery(namespace, query string) (statedb.ResultsIt
 	return newQueryScanner(namespace, *queryResult), nil
 }
 
+// ExecuteQueryWithPagination implements method in VersionedDB interface
+// The page size is used as the limit of the query and the bookmark is the one returned by CouchDB
+// for the previous page. The bookmark of the last page, which has less results than the page size, is empty
+func (vdb *VersionedDB) ExecuteQueryWithPagination(namespace, query, bookmark string, pageSize int32) (statedb.QueryResultsIterator, error) {
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("invalid page size [%d], it must be greater than zero", pageSize)
+	}
+
+	queryString, err := ApplyQueryWrapperWithBookmark(namespace, query, int(pageSize), bookmark)
+	if err != nil {
+		logger.Debugf("Error calling ApplyQueryWrapperWithBookmark(): %s\n", err.Error())
+		return nil, err
+	}
+
+	db, err := vdb.getNamespaceDBHandle(namespace)
+	if err != nil {
+		return nil, err
+	}
+	queryResult, nextBookmark, err := db.QueryDocumentsWithBookmark(queryString)
+	if err != nil {
+		logger.Debugf("Error calling QueryDocumentsWithBookmark(): %s\n", err.Error())
+		return nil, err
+	}
+	if len(*queryResult) < int(pageSize) {
+		nextBookmark = ""
+	}
+
+	logger.Debugf("Exiting ExecuteQueryWithPagination")
+	return &paginatedScanner{newQueryScanner(namespace, *queryResult), nextBookmark}, nil
+}
+
 // ApplyUpdates implements method in VersionedDB interface
 func (vdb *VersionedDB) ApplyUpdates(batch *statedb.UpdateBatch, height *version.Height) error {
 
@@ -398,7 +556,7 @@ func (vdb *VersionedDB) ApplyUpdates(batch *statedb.UpdateBatch, height *version
 			if vv.Value == nil {
 				processBatch.Delete(ns, k, vv.Version)
 			} else {
-				processBatch.Put(ns, k, vv.Value, vv.Version)
+				processBatch.PutValAndMetadata(ns, k, vv.Value, vv.Metadata, vv.Version)
 			}
 
 			//Check to see if the process batch exceeds the max batch size
@@ -499,14 +657,14 @@ func (vdb *VersionedDB) processUpdateBatch(updateBatch *statedb.UpdateBatch, mis
 				// this is a deleted record.  Set the _deleted property to true
 				//couchDoc.JSONValue = createCouchdbDocJSON(string(compositeKey), revision, nil, ns, vv.Version, true)
 				//TODO: Remove ns/chaincodeID from json doc
-				couchDoc.JSONValue = createCouchdbDocJSON(key, revision, nil, ns, vv.Version, true)
+				couchDoc.JSONValue = createCouchdbDocJSON(key, revision, nil, nil, ns, vv.Version, true)
 
 			} else {
 
 				if couchdb.IsJSON(string(vv.Value)) {
 					// Handle as json
 					//TODO: Remove ns from json doc
-					couchDoc.JSONValue = createCouchdbDocJSON(key, revision, vv.Value, ns, vv.Version, false)
+					couchDoc.JSONValue = createCouchdbDocJSON(key, revision, vv.Value, vv.Metadata, ns, vv.Version, false)
 
 				} else { // if value is not json, handle as a couchdb attachment
 
@@ -518,7 +676,7 @@ func (vdb *VersionedDB) processUpdateBatch(updateBatch *statedb.UpdateBatch, mis
 
 					couchDoc.Attachments = attachments
 					//TODO: Remove ns from json doc
-					couchDoc.JSONValue = createCouchdbDocJSON(key, revision, nil, ns, vv.Version, false)
+					couchDoc.JSONValue = createCouchdbDocJSON(key, revision, nil, vv.Metadata, ns, vv.Version, false)
 
 				}
 			}
@@ -770,9 +928,10 @@ func (vdb *VersionedDB) ClearCachedVersions() {
 // _deleted - flag using in batch operations for deleting a couchdb document
 // chaincodeID - chain code ID, added to header, used to scope couchdb queries
 // version - version, added to header, used for state validation
+// metadata - metadata of the key (e.g., its validation parameter), added to header if not nil
 // data wrapper - JSON from the chaincode goes here
 // The return value is the CouchDoc.JSONValue with the header fields populated
-func createCouchdbDocJSON(id, revision string, value []byte, chaincodeID string, version *version.Height, deleted bool) []byte {
+func createCouchdbDocJSON(id, revision string, value []byte, metadata []byte, chaincodeID string, version *version.Height, deleted bool) []byte {
 
 	// create a version mapping
 	jsonMap := map[string]interface{}{"version": fmt.Sprintf("%v:%v", version.BlockNum, version.TxNum)}
@@ -795,6 +954,11 @@ func createCouchdbDocJSON(id, revision string, value []byte, chaincodeID string,
 		// add the chaincodeID
 		jsonMap["chaincodeid"] = chaincodeID
 
+		// add the metadata of the key, which is marshalled as a base64 encoded string
+		if metadata != nil {
+			jsonMap[metadataField] = metadata
+		}
+
 		// Add the wrapped data if the value is not null
 		if value != nil {
 
@@ -957,11 +1121,11 @@ func (scanner *kvScanner) Next() (statedb.QueryResult, error) {
 	key := selectedKV.ID
 
 	// remove the data wrapper and return the value and version
-	returnValue, returnVersion := removeDataWrapper(selectedKV.Value, selectedKV.Attachments)
+	returnValue, returnMetadata, returnVersion := removeDataWrapper(selectedKV.Value, selectedKV.Attachments)
 
 	return &statedb.VersionedKV{
 		CompositeKey:   statedb.CompositeKey{Namespace: scanner.namespace, Key: key},
-		VersionedValue: statedb.VersionedValue{Value: returnValue, Version: returnVersion}}, nil
+		VersionedValue: statedb.VersionedValue{Value: returnValue, Metadata: returnMetadata, Version: returnVersion}}, nil
 }
 
 func (scanner *kvScanner) Close() {
@@ -991,13 +1155,111 @@ func (scanner *queryScanner) Next() (statedb.QueryResult, error) {
 	key := selectedResultRecord.ID
 
 	// remove the data wrapper and return the value and version
-	returnValue, returnVersion := removeDataWrapper(selectedResultRecord.Value, selectedResultRecord.Attachments)
+	returnValue, returnMetadata, returnVersion := removeDataWrapper(selectedResultRecord.Value, selectedResultRecord.Attachments)
 
 	return &statedb.VersionedKV{
 		CompositeKey:   statedb.CompositeKey{Namespace: scanner.namespace, Key: key},
-		VersionedValue: statedb.VersionedValue{Value: returnValue, Version: returnVersion}}, nil
+		VersionedValue: statedb.VersionedValue{Value: returnValue, Metadata: returnMetadata, Version: returnVersion}}, nil
 }
 
 func (scanner *queryScanner) Close() {
 	scanner = nil
 }
+
+// paginatedScanner wraps a scanner over a page of results and holds
+// the bookmark for retrieving the next page
+type paginatedScanner struct {
+	statedb.ResultsIterator
+	bookmark string
+}
+
+// GetBookmarkAndClose implements method in QueryResultsIterator interface
+func (scanner *paginatedScanner) GetBookmarkAndClose() string {
+	scanner.Close()
+	return scanner.bookmark
+}
+
+// fullScanner iterates over all the documents in the given namespace databases. The documents are retrieved
+// from a database in pages of size 'pageSize' and the namespace of a document is derived from the field 'chaincodeid'
+type fullScanner struct {
+	couchInstance *couchdb.CouchInstance
+	dbNames       []string
+	skipNamespace func(string) bool
+	pageSize      int
+
+	currentDB     *couchdb.CouchDatabase
+	currentPage   []couchdb.QueryResult
+	cursor        int
+	lastDocID     string
+	lastPageFound bool
+}
+
+func newFullScanner(couchInstance *couchdb.CouchInstance, dbNames []string, skipNamespace func(string) bool, pageSize int) *fullScanner {
+	return &fullScanner{couchInstance: couchInstance, dbNames: dbNames, skipNamespace: skipNamespace, pageSize: pageSize}
+}
+
+func (scanner *fullScanner) Next() (statedb.QueryResult, error) {
+	for {
+		if scanner.cursor < len(scanner.currentPage) {
+			result := scanner.currentPage[scanner.cursor]
+			scanner.cursor++
+			if strings.HasPrefix(result.ID, "_design/") {
+				continue
+			}
+			docHeader := &struct {
+				ChaincodeID string `json:"chaincodeid"`
+			}{}
+			if err := json.Unmarshal(result.Value, docHeader); err != nil {
+				return nil, err
+			}
+			if scanner.skipNamespace != nil && scanner.skipNamespace(docHeader.ChaincodeID) {
+				continue
+			}
+			returnValue, returnMetadata, returnVersion := removeDataWrapper(result.Value, result.Attachments)
+			return &statedb.VersionedKV{
+				CompositeKey:   statedb.CompositeKey{Namespace: docHeader.ChaincodeID, Key: result.ID},
+				VersionedValue: statedb.VersionedValue{Value: returnValue, Metadata: returnMetadata, Version: returnVersion}}, nil
+		}
+		moreResults, err := scanner.fetchNextPage()
+		if err != nil || !moreResults {
+			return nil, err
+		}
+	}
+}
+
+// fetchNextPage loads the next page of the documents either from the current database or, if the
+// current database is exhausted, from the next database. This function returns false if no more documents are left
+func (scanner *fullScanner) fetchNextPage() (bool, error) {
+	for {
+		if scanner.currentDB == nil || scanner.lastPageFound {
+			if len(scanner.dbNames) == 0 {
+				return false, nil
+			}
+			scanner.currentDB = &couchdb.CouchDatabase{CouchInstance: *scanner.couchInstance, DBName: scanner.dbNames[0]}
+			scanner.dbNames = scanner.dbNames[1:]
+			scanner.lastDocID = ""
+			scanner.lastPageFound = false
+		}
+		skip := 0
+		if scanner.lastDocID != "" {
+			// the start key is inclusive and hence, skip the last document of the previous page
+			skip = 1
+		}
+		queryResults, err := scanner.currentDB.ReadDocRange(scanner.lastDocID, "", scanner.pageSize, skip)
+		if err != nil {
+			return false, err
+		}
+		scanner.currentPage = *queryResults
+		scanner.cursor = 0
+		scanner.lastPageFound = len(scanner.currentPage) < scanner.pageSize
+		if len(scanner.currentPage) > 0 {
+			scanner.lastDocID = scanner.currentPage[len(scanner.currentPage)-1].ID
+			return true, nil
+		}
+	}
+}
+
+func (scanner *fullScanner) Close() {
+	scanner.currentPage = nil
+	scanner.dbNames = nil
+}
@@ -17,7 +17,10 @@ limitations under the License.
 package statecouchdb
 
 import (
+	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -26,6 +29,7 @@ import (
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/commontests"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
 	ledgertestutil "github.com/hyperledger/fabric/core/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger/util/couchdb"
 	"github.com/spf13/viper"
 )
 
@@ -74,6 +78,15 @@ func TestMultiDBBasicRW(t *testing.T) {
 
 }
 
+func TestDrop(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	env.Cleanup("testdrop")
+	env.Cleanup("testdrop2")
+	defer env.Cleanup("testdrop")
+	defer env.Cleanup("testdrop2")
+	commontests.TestDrop(t, env.DBProvider)
+}
+
 func TestDeletes(t *testing.T) {
 	env := NewTestVDBEnv(t)
 	env.Cleanup("testdeletes")
@@ -88,6 +101,66 @@ func TestIterator(t *testing.T) {
 	commontests.TestIterator(t, env.DBProvider)
 }
 
+func TestValueAndMetadataWrites(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	env.Cleanup("testvalueandmetadata")
+	defer env.Cleanup("testvalueandmetadata")
+	commontests.TestValueAndMetadataWrites(t, env.DBProvider)
+}
+
+func TestPaginatedRangeQuery(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	env.Cleanup("testpaginatedrangequery")
+	defer env.Cleanup("testpaginatedrangequery")
+	commontests.TestPaginatedRangeQuery(t, env.DBProvider)
+}
+
+func TestPaginatedQuery(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	env.Cleanup("testpaginatedquery")
+	defer env.Cleanup("testpaginatedquery")
+	db, err := env.DBProvider.GetDBHandle("testpaginatedquery")
+	testutil.AssertNoError(t, err, "")
+
+	batch := statedb.NewUpdateBatch()
+	for i := 1; i <= 5; i++ {
+		batch.Put("ns1", fmt.Sprintf("key%d", i), []byte(fmt.Sprintf(`{"asset_name":"marble%d","owner":"tom"}`, i)), version.NewHeight(1, uint64(i)))
+	}
+	batch.Put("ns1", "key6", []byte(`{"asset_name":"marble6","owner":"jerry"}`), version.NewHeight(1, 6))
+	testutil.AssertNoError(t, db.ApplyUpdates(batch, version.NewHeight(1, 6)), "")
+
+	query := `{"selector":{"owner":"tom"}}`
+	var keys []string
+	bookmark := ""
+	for pages := 0; pages < 3; pages++ {
+		itr, err := db.ExecuteQueryWithPagination("ns1", query, bookmark, 2)
+		testutil.AssertNoError(t, err, "")
+		for {
+			queryResult, err := itr.Next()
+			testutil.AssertNoError(t, err, "")
+			if queryResult == nil {
+				break
+			}
+			keys = append(keys, queryResult.(*statedb.VersionedKV).Key)
+		}
+		bookmark = itr.GetBookmarkAndClose()
+	}
+	// the last page has less results than the page size, hence an empty bookmark
+	testutil.AssertEquals(t, bookmark, "")
+	sort.Strings(keys)
+	testutil.AssertEquals(t, keys, []string{"key1", "key2", "key3", "key4", "key5"})
+
+	_, err = db.ExecuteQueryWithPagination("ns1", query, "", 0)
+	testutil.AssertError(t, err, "Expected error for a zero page size")
+}
+
+func TestFullScanIterator(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	env.Cleanup("testfullscaniterator")
+	defer env.Cleanup("testfullscaniterator")
+	commontests.TestFullScanIterator(t, env.DBProvider)
+}
+
 func TestEncodeDecodeValueAndVersion(t *testing.T) {
 	testValueAndVersionEncoding(t, []byte("value1"), version.NewHeight(1, 2))
 	testValueAndVersionEncoding(t, []byte{}, version.NewHeight(50, 50))
@@ -141,6 +214,29 @@ func TestBatchRetry(t *testing.T) {
 }
 
 // TestUtilityFunctions tests utility functions
+func TestMetadataInDocHeader(t *testing.T) {
+	height := version.NewHeight(1, 2)
+
+	// the metadata of a key is kept in the header of both JSON and binary documents
+	jsonDoc := createCouchdbDocJSON("key1", "", []byte(`{"color":"blue"}`), []byte("metadata1"), "ns1", height, false)
+	value, metadata, ver := removeDataWrapper(jsonDoc, nil)
+	testutil.AssertEquals(t, value, []byte(`{"color":"blue"}`))
+	testutil.AssertEquals(t, metadata, []byte("metadata1"))
+	testutil.AssertEquals(t, ver, height)
+
+	binaryDoc := createCouchdbDocJSON("key2", "", nil, []byte("metadata2"), "ns1", height, false)
+	attachments := []*couchdb.AttachmentInfo{{Name: binaryWrapper, AttachmentBytes: []byte("value2")}}
+	value, metadata, _ = removeDataWrapper(binaryDoc, attachments)
+	testutil.AssertEquals(t, value, []byte("value2"))
+	testutil.AssertEquals(t, metadata, []byte("metadata2"))
+
+	// no metadata field is added for a key without metadata
+	docWithoutMetadata := createCouchdbDocJSON("key3", "", []byte(`{"color":"red"}`), nil, "ns1", height, false)
+	testutil.AssertEquals(t, strings.Contains(string(docWithoutMetadata), metadataField), false)
+	_, metadata, _ = removeDataWrapper(docWithoutMetadata, nil)
+	testutil.AssertNil(t, metadata)
+}
+
 func TestUtilityFunctions(t *testing.T) {
 
 	env := NewTestVDBEnv(t)
@@ -8,6 +8,7 @@ package statedb
 import (
 	"sort"
 
+	"github.com/hyperledger/fabric/core/common/ccprovider"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
 	"github.com/hyperledger/fabric/core/ledger/util"
 )
@@ -16,6 +17,8 @@ import (
 type VersionedDBProvider interface {
 	// GetDBHandle returns a handle to a VersionedDB
 	GetDBHandle(id string) (VersionedDB, error)
+	// Drop removes all the data of the VersionedDB with the given id. The VersionedDB should not be in use
+	Drop(id string) error
 	// Close closes all the VersionedDB instances and releases any resources held by VersionedDBProvider
 	Close()
 }
@@ -33,8 +36,26 @@ type VersionedDB interface {
 	// endKey is exclusive
 	// The returned ResultsIterator contains results of type *VersionedKV
 	GetStateRangeScanIterator(namespace string, startKey string, endKey string) (ResultsIterator, error)
+	// GetStateRangeScanIteratorWithPagination returns an iterator that contains at most pageSize key-values
+	// between given key ranges. startKey is inclusive and endKey is exclusive. The bookmark returned by the
+	// iterator is the key to be used as the startKey for retrieving the next page
+	// The returned QueryResultsIterator contains results of type *VersionedKV
+	GetStateRangeScanIteratorWithPagination(namespace string, startKey string, endKey string, pageSize int32) (QueryResultsIterator, error)
 	// ExecuteQuery executes the given query and returns an iterator that contains results of type *VersionedKV.
 	ExecuteQuery(namespace, query string) (ResultsIterator, error)
+	// ExecuteQueryWithPagination executes the given query and returns an iterator that contains at most pageSize
+	// results of type *VersionedKV, starting from the given bookmark. An empty bookmark refers to the first page.
+	// The bookmark returned by the iterator is to be used for retrieving the next page
+	ExecuteQueryWithPagination(namespace, query, bookmark string, pageSize int32) (QueryResultsIterator, error)
+	// GetFullScanIterator returns an iterator that contains all the keys present in the db across all the namespaces,
+	// except the namespaces for which the function 'skipNamespace' returns true. The results are grouped by namespace,
+	// however, the order of the namespaces and the keys within a namespace is specific to the implementation.
+	// The returned ResultsIterator contains results of type *VersionedKV
+	GetFullScanIterator(skipNamespace func(namespace string) bool) (ResultsIterator, error)
+	// FullScanReadsSnapshot returns true if the iterator returned by GetFullScanIterator reads the db as it was
+	// when the iterator was created, regardless of the updates applied afterwards. For instance, a leveldb
+	// iterator reads an implicit snapshot of the db while the couchdb one queries the databases page by page
+	FullScanReadsSnapshot() bool
 	// ApplyUpdates applies the batch to the underlying db.
 	// height is the height of the highest transaction in the Batch that
 	// a state db implementation is expected to ues as a save point
@@ -62,16 +83,25 @@ type BulkOptimizable interface {
 	ClearCachedVersions()
 }
 
+//IndexCapable interface provides additional functions for
+//databases capable of index operations
+type IndexCapable interface {
+	GetDBType() string
+	ProcessIndexesForChaincodeDeploy(namespace string, fileEntries []*ccprovider.TarFileEntry) error
+}
+
 // CompositeKey encloses Namespace and Key components
 type CompositeKey struct {
 	Namespace string
 	Key       string
 }
 
-// VersionedValue encloses value and corresponding version
+// VersionedValue encloses value and corresponding version. Metadata holds the
+// serialized metadata of the key (e.g., its validation parameter), if any
 type VersionedValue struct {
-	Value   []byte
-	Version *version.Height
+	Value    []byte
+	Metadata []byte
+	Version  *version.Height
 }
 
 // VersionedKV encloses key and corresponding VersionedValue
@@ -86,6 +116,14 @@ type ResultsIterator interface {
 	Close()
 }
 
+// QueryResultsIterator adds GetBookmarkAndClose method
+type QueryResultsIterator interface {
+	ResultsIterator
+	// GetBookmarkAndClose returns the bookmark for retrieving the page that follows the results
+	// of this iterator, and closes the iterator. An empty bookmark means that there are no more results
+	GetBookmarkAndClose() string
+}
+
 // QueryResult - a general interface for supporting different types of query results. Actual types differ for different queries
 type QueryResult interface{}
 
@@ -125,12 +163,20 @@ func (batch *UpdateBatch) Put(ns string, key string, value []byte, version *vers
 	if value == nil {
 		panic("Nil value not allowed")
 	}
-	batch.Update(ns, key, &VersionedValue{value, version})
+	batch.Update(ns, key, &VersionedValue{Value: value, Version: version})
+}
+
+// PutValAndMetadata adds a VersionedKV along with the serialized metadata of the key
+func (batch *UpdateBatch) PutValAndMetadata(ns string, key string, value []byte, metadata []byte, version *version.Height) {
+	if value == nil {
+		panic("Nil value not allowed")
+	}
+	batch.Update(ns, key, &VersionedValue{Value: value, Metadata: metadata, Version: version})
 }
 
 // Delete deletes a Key and associated value
 func (batch *UpdateBatch) Delete(ns string, key string, version *version.Height) {
-	batch.Update(ns, key, &VersionedValue{nil, version})
+	batch.Update(ns, key, &VersionedValue{Value: nil, Version: version})
 }
 
 // Exists checks whether the given key exists in the batch
@@ -225,7 +271,7 @@ func (itr *nsIterator) Next() (QueryResult, error) {
 	key := itr.sortedKeys[itr.nextIndex]
 	vv := itr.nsUpdates.m[key]
 	itr.nextIndex++
-	return &VersionedKV{CompositeKey{itr.ns, key}, VersionedValue{vv.Value, vv.Version}}, nil
+	return &VersionedKV{CompositeKey{itr.ns, key}, VersionedValue{Value: vv.Value, Metadata: vv.Metadata, Version: vv.Version}}, nil
 }
 
 // Close implements the method from QueryResult interface
@@ -0,0 +1,297 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package stateleveldb
+
+import (
+	"bytes"
+	"encoding/json"
+	"path"
+	"strings"
+
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/core/common/ccprovider"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/pkg/errors"
+)
+
+// leveldbIndexesDir is the directory of the statedb artifacts of a chaincode which holds the
+// definitions of the leveldb indexes of the chaincode, e.g. leveldb/indexes/indexOwner.json
+const leveldbIndexesDir = "leveldb/indexes"
+
+// The definitions and the entries of the indexes are stored in the same db as the state, under
+// keys that start with the byte 0x00, which does not start any namespace, and that does not
+// collide with the savepoint key
+var indexDefinitionKeyPrefix = []byte{0x00, 0x01}
+var indexEntryKeyPrefix = []byte{0x00, 0x02}
+
+// indexDefinition is a secondary index on the fields of the JSON values of a namespace. An index is
+// declared in the same format as a CouchDB index, e.g.
+// {"index":{"fields":["docType","owner"]},"ddoc":"indexOwnerDoc","name":"indexOwner","type":"json"}
+// The index has an entry for each value that contains all the fields of the index. As the index can be
+// scanned in both directions, the direction of the fields in the definition (e.g. {"size":"desc"}) is ignored
+type indexDefinition struct {
+	Name   string   `json:"name"`
+	Ddoc   string   `json:"ddoc,omitempty"`
+	Fields []string `json:"fields"`
+}
+
+// parseIndexDefinition parses an index definition packaged with a chaincode. The name of the index is taken
+// from the field "name" of the definition, or else from the field "ddoc", or else from the name of the file
+func parseIndexDefinition(filename string, content []byte) (*indexDefinition, error) {
+	jsonIndex := struct {
+		Index struct {
+			Fields []interface{} `json:"fields"`
+		} `json:"index"`
+		Ddoc string `json:"ddoc"`
+		Name string `json:"name"`
+	}{}
+	if err := json.Unmarshal(content, &jsonIndex); err != nil {
+		return nil, errors.Wrap(err, "index definition is not valid JSON")
+	}
+	if len(jsonIndex.Index.Fields) == 0 {
+		return nil, errors.New("index definition must contain a non-empty \"fields\" array")
+	}
+	def := &indexDefinition{Name: jsonIndex.Name, Ddoc: jsonIndex.Ddoc}
+	if def.Name == "" {
+		def.Name = def.Ddoc
+	}
+	if def.Name == "" {
+		def.Name = strings.TrimSuffix(path.Base(filename), path.Ext(filename))
+	}
+	for _, field := range jsonIndex.Index.Fields {
+		switch f := field.(type) {
+		case string:
+			def.Fields = append(def.Fields, f)
+		case map[string]interface{}:
+			if len(f) != 1 {
+				return nil, errors.Errorf("unexpected field %v in the index definition", field)
+			}
+			for name := range f {
+				def.Fields = append(def.Fields, name)
+			}
+		default:
+			return nil, errors.Errorf("unexpected field %v in the index definition", field)
+		}
+	}
+	return def, nil
+}
+
+// equals returns true if the definitions are for the same index
+func (def *indexDefinition) equals(other *indexDefinition) bool {
+	if def.Name != other.Name || def.Ddoc != other.Ddoc || len(def.Fields) != len(other.Fields) {
+		return false
+	}
+	for i, field := range def.Fields {
+		if other.Fields[i] != field {
+			return false
+		}
+	}
+	return true
+}
+
+// entryKey returns the key of the index entry for the given key and value, or nil if the value
+// is not a JSON object that contains all the fields of the index. The index entry key is composed of
+// the encoded values of the fields of the index followed by the key, and the value of the entry is the key
+func (def *indexDefinition) entryKey(ns, key string, doc map[string]interface{}) []byte {
+	if doc == nil {
+		return nil
+	}
+	entryKey := constructIndexEntryKeyPrefix(ns, def.Name)
+	for _, field := range def.Fields {
+		value, found := lookupField(doc, field)
+		if !found {
+			return nil
+		}
+		entryKey = encodeValue(entryKey, value)
+	}
+	return append(entryKey, []byte(key)...)
+}
+
+func constructIndexDefinitionKey(ns, indexName string) []byte {
+	return append(append(append([]byte{}, indexDefinitionKeyPrefix...), constructCompositeKey(ns, "")...), []byte(indexName)...)
+}
+
+func constructIndexEntryKeyPrefix(ns, indexName string) []byte {
+	return append(append(append([]byte{}, indexEntryKeyPrefix...), constructCompositeKey(ns, indexName)...), compositeKeySep...)
+}
+
+// prefixEnd returns the smallest key that is greater than all the keys that start with the given prefix
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte{}, prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+// isIndexKey returns true if the key of the db holds the definition or an entry of an index
+func isIndexKey(dbKey []byte) bool {
+	return bytes.HasPrefix(dbKey, indexDefinitionKeyPrefix) || bytes.HasPrefix(dbKey, indexEntryKeyPrefix)
+}
+
+// unmarshalDoc returns the JSON object held by the value, or nil if the value is not a JSON object
+func unmarshalDoc(value []byte) map[string]interface{} {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(value, &doc); err != nil {
+		return nil
+	}
+	return doc
+}
+
+// GetDBType implements method in IndexCapable interface
+func (vdb *versionedDB) GetDBType() string {
+	return "leveldb"
+}
+
+// ProcessIndexesForChaincodeDeploy creates the indexes packaged with a chaincode for the namespace of the
+// chaincode. The file entries are expected to be named relative to the statedb artifacts of the package,
+// e.g. leveldb/indexes/indexOwner.json. An index is built from the existing values of the namespace, unless
+// the same index already exists. The failure to create an index is logged and does not prevent the creation
+// of the others
+func (vdb *versionedDB) ProcessIndexesForChaincodeDeploy(namespace string, fileEntries []*ccprovider.TarFileEntry) error {
+	vdb.indexLock.Lock()
+	defer vdb.indexLock.Unlock()
+	for _, fileEntry := range fileEntries {
+		filename := fileEntry.FileHeader.Name
+		if path.Dir(filename) != leveldbIndexesDir {
+			logger.Warningf("Skipping the file [%s] of chaincode [%s], since it is not an index definition", filename, namespace)
+			continue
+		}
+		def, err := parseIndexDefinition(filename, fileEntry.FileContent)
+		if err != nil {
+			logger.Errorf("Error during parsing of the index from file=[%s] for chaincode=[%s]. Error=%s", filename, namespace, err)
+			continue
+		}
+		if err := vdb.createIndex(namespace, def); err != nil {
+			logger.Errorf("Error during creation of index from file=[%s] for chaincode=[%s]. Error=%s", filename, namespace, err)
+			continue
+		}
+	}
+	return nil
+}
+
+// createIndex stores the definition of the index and builds the index from the existing values of the
+// namespace. An existing index of the same name with a different definition is replaced
+func (vdb *versionedDB) createIndex(namespace string, def *indexDefinition) error {
+	existingDef, err := vdb.getIndexDefinition(namespace, def.Name)
+	if err != nil {
+		return err
+	}
+	if existingDef != nil && existingDef.equals(def) {
+		logger.Debugf("Channel [%s]: Index [%s] of namespace [%s] already exists", vdb.dbName, def.Name, namespace)
+		return nil
+	}
+	dbBatch := leveldbhelper.NewUpdateBatch()
+	if existingDef != nil {
+		entryKeyPrefix := constructIndexEntryKeyPrefix(namespace, def.Name)
+		itr := vdb.db.GetIterator(entryKeyPrefix, prefixEnd(entryKeyPrefix))
+		for itr.Next() {
+			dbBatch.Delete(append([]byte{}, itr.Key()...))
+		}
+		itr.Release()
+		if err := itr.Error(); err != nil {
+			return errors.Wrapf(err, "error while removing the entries of index [%s]", def.Name)
+		}
+	}
+	scanner := vdb.getStateRangeScanIterator(namespace, "", "", 0)
+	defer scanner.Close()
+	entries := 0
+	for {
+		result, err := scanner.Next()
+		if err != nil {
+			return err
+		}
+		if result == nil {
+			break
+		}
+		kv := result.(*statedb.VersionedKV)
+		if entryKey := def.entryKey(namespace, kv.Key, unmarshalDoc(kv.Value)); entryKey != nil {
+			dbBatch.Put(entryKey, []byte(kv.Key))
+			entries++
+		}
+	}
+	defBytes, err := json.Marshal(def)
+	if err != nil {
+		return errors.Wrapf(err, "error while marshalling the definition of index [%s]", def.Name)
+	}
+	dbBatch.Put(constructIndexDefinitionKey(namespace, def.Name), defBytes)
+	if err := vdb.db.WriteBatch(dbBatch, true); err != nil {
+		return err
+	}
+	logger.Infof("Channel [%s]: Created index [%s] on fields %s of namespace [%s] with %d entries",
+		vdb.dbName, def.Name, def.Fields, namespace, entries)
+	return nil
+}
+
+// getIndexDefinition returns the definition of the named index of the namespace, or nil if there is no such index
+func (vdb *versionedDB) getIndexDefinition(namespace, indexName string) (*indexDefinition, error) {
+	defBytes, err := vdb.db.Get(constructIndexDefinitionKey(namespace, indexName))
+	if err != nil || defBytes == nil {
+		return nil, err
+	}
+	def := &indexDefinition{}
+	if err := json.Unmarshal(defBytes, def); err != nil {
+		return nil, errors.Wrapf(err, "error while unmarshalling the definition of index [%s]", indexName)
+	}
+	return def, nil
+}
+
+// getIndexDefinitions returns the definitions of the indexes of the namespace, ordered by the name of the index
+func (vdb *versionedDB) getIndexDefinitions(namespace string) ([]*indexDefinition, error) {
+	defKeyPrefix := constructIndexDefinitionKey(namespace, "")
+	itr := vdb.db.GetIterator(defKeyPrefix, prefixEnd(defKeyPrefix))
+	defer itr.Release()
+	var defs []*indexDefinition
+	for itr.Next() {
+		def := &indexDefinition{}
+		if err := json.Unmarshal(itr.Value(), def); err != nil {
+			return nil, errors.Wrapf(err, "error while unmarshalling the index definition [%s]", itr.Key())
+		}
+		defs = append(defs, def)
+	}
+	return defs, itr.Error()
+}
+
+// addIndexUpdates adds to the db batch the updates of the entries of the indexes of the namespace, which
+// are caused by the updates of the namespace. The entries for the committed values of the updated keys are
+// removed and the entries for the new values are added
+func (vdb *versionedDB) addIndexUpdates(dbBatch *leveldbhelper.UpdateBatch, namespace string, updates map[string]*statedb.VersionedValue) error {
+	defs, err := vdb.getIndexDefinitions(namespace)
+	if err != nil || len(defs) == 0 {
+		return err
+	}
+	for key, vv := range updates {
+		committedVV, err := vdb.GetState(namespace, key)
+		if err != nil {
+			return err
+		}
+		var committedDoc, doc map[string]interface{}
+		if committedVV != nil {
+			committedDoc = unmarshalDoc(committedVV.Value)
+		}
+		if vv.Value != nil {
+			doc = unmarshalDoc(vv.Value)
+		}
+		for _, def := range defs {
+			committedEntryKey := def.entryKey(namespace, key, committedDoc)
+			entryKey := def.entryKey(namespace, key, doc)
+			if bytes.Equal(committedEntryKey, entryKey) {
+				continue
+			}
+			if committedEntryKey != nil {
+				dbBatch.Delete(committedEntryKey)
+			}
+			if entryKey != nil {
+				dbBatch.Put(entryKey, []byte(key))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,463 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package stateleveldb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// query is a rich query on the JSON values of a namespace. The query is expressed in the subset of the
+// CouchDB Mango query language that is supported by leveldb, e.g.
+// {"selector":{"owner":"tom","size":{"$gt":5}},"fields":["owner","size"],"sort":[{"size":"desc"}],"limit":10}
+type query struct {
+	selector selector
+	fields   []string
+	sort     []sortField
+	limit    int
+	skip     int
+	useIndex []string
+}
+
+// sortField is a field of the "sort" of a query
+type sortField struct {
+	field string
+	desc  bool
+}
+
+// selector is a condition on a JSON document, as expressed by the "selector" of a query
+type selector interface {
+	matches(doc map[string]interface{}) bool
+}
+
+type andSelector []selector
+type orSelector []selector
+type norSelector []selector
+type notSelector struct {
+	selector
+}
+
+// fieldSelector is a condition on a field of a JSON document, e.g. {"size":{"$gt":5}}.
+// The nested fields are referred to with the dot notation, e.g. "address.city"
+type fieldSelector struct {
+	field   string
+	op      string
+	operand interface{}
+	regex   *regexp.Regexp
+}
+
+// parseQuery parses the query string into a query. An error is returned for the malformed
+// queries, as well as for the queries that use an operator that is not supported by leveldb
+func parseQuery(queryString string) (*query, error) {
+	jsonQuery := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(queryString), &jsonQuery); err != nil {
+		return nil, errors.Wrap(err, "query is not valid JSON")
+	}
+	q := &query{selector: andSelector{}}
+	var err error
+	if jsonSelector, ok := jsonQuery["selector"]; ok {
+		selectorMap, ok := jsonSelector.(map[string]interface{})
+		if !ok {
+			return nil, errors.New("\"selector\" of the query must be an object")
+		}
+		if q.selector, err = parseSelector(selectorMap); err != nil {
+			return nil, err
+		}
+	}
+	if jsonFields, ok := jsonQuery["fields"]; ok {
+		if q.fields, err = toStrings(jsonFields); err != nil {
+			return nil, errors.WithMessage(err, "invalid \"fields\" of the query")
+		}
+	}
+	if jsonSort, ok := jsonQuery["sort"]; ok {
+		if q.sort, err = parseSort(jsonSort); err != nil {
+			return nil, err
+		}
+	}
+	if q.limit, err = toNonNegativeInt(jsonQuery, "limit"); err != nil {
+		return nil, err
+	}
+	if q.skip, err = toNonNegativeInt(jsonQuery, "skip"); err != nil {
+		return nil, err
+	}
+	if jsonUseIndex, ok := jsonQuery["use_index"]; ok {
+		if name, ok := jsonUseIndex.(string); ok {
+			q.useIndex = []string{name}
+		} else if q.useIndex, err = toStrings(jsonUseIndex); err != nil || len(q.useIndex) == 0 || len(q.useIndex) > 2 {
+			return nil, errors.New("\"use_index\" of the query must be a design document name or an array of a design document name and an index name")
+		}
+	}
+	return q, nil
+}
+
+func parseSelector(selectorMap map[string]interface{}) (selector, error) {
+	var selectors andSelector
+	for _, key := range sortedKeys(selectorMap) {
+		value := selectorMap[key]
+		switch key {
+		case "$and", "$or", "$nor":
+			subSelectorMaps, ok := value.([]interface{})
+			if !ok {
+				return nil, errors.Errorf("operator %s requires an array of selectors", key)
+			}
+			var subSelectors []selector
+			for _, subSelectorMap := range subSelectorMaps {
+				m, ok := subSelectorMap.(map[string]interface{})
+				if !ok {
+					return nil, errors.Errorf("operator %s requires an array of selectors", key)
+				}
+				subSelector, err := parseSelector(m)
+				if err != nil {
+					return nil, err
+				}
+				subSelectors = append(subSelectors, subSelector)
+			}
+			switch key {
+			case "$and":
+				selectors = append(selectors, andSelector(subSelectors))
+			case "$or":
+				selectors = append(selectors, orSelector(subSelectors))
+			default:
+				selectors = append(selectors, norSelector(subSelectors))
+			}
+		case "$not":
+			m, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, errors.New("operator $not requires a selector")
+			}
+			subSelector, err := parseSelector(m)
+			if err != nil {
+				return nil, err
+			}
+			selectors = append(selectors, notSelector{subSelector})
+		default:
+			if strings.HasPrefix(key, "$") {
+				return nil, errors.Errorf("operator %s is not supported by leveldb", key)
+			}
+			fieldSelectors, err := parseFieldSelectors(key, value)
+			if err != nil {
+				return nil, err
+			}
+			selectors = append(selectors, fieldSelectors...)
+		}
+	}
+	if len(selectors) == 1 {
+		return selectors[0], nil
+	}
+	return selectors, nil
+}
+
+// parseFieldSelectors parses the conditions on a field. The value is either an object of operators,
+// e.g. {"$gt":5,"$lt":8}, an object of the conditions on the nested fields, e.g. {"city":"London"},
+// or a value the field must be equal to
+func parseFieldSelectors(field string, value interface{}) ([]selector, error) {
+	conditions, ok := value.(map[string]interface{})
+	if !ok || len(conditions) == 0 {
+		return []selector{&fieldSelector{field: field, op: "$eq", operand: value}}, nil
+	}
+	var selectors []selector
+	operators := 0
+	for _, key := range sortedKeys(conditions) {
+		if !strings.HasPrefix(key, "$") {
+			nestedSelectors, err := parseFieldSelectors(field+"."+key, conditions[key])
+			if err != nil {
+				return nil, err
+			}
+			selectors = append(selectors, nestedSelectors...)
+			continue
+		}
+		operators++
+		fs := &fieldSelector{field: field, op: key, operand: conditions[key]}
+		switch key {
+		case "$eq", "$ne", "$lt", "$lte", "$gt", "$gte":
+		case "$in", "$nin":
+			if _, ok := fs.operand.([]interface{}); !ok {
+				return nil, errors.Errorf("operator %s on field %s requires an array", key, field)
+			}
+		case "$exists":
+			if _, ok := fs.operand.(bool); !ok {
+				return nil, errors.Errorf("operator $exists on field %s requires a boolean", field)
+			}
+		case "$regex":
+			pattern, ok := fs.operand.(string)
+			if !ok {
+				return nil, errors.Errorf("operator $regex on field %s requires a string", field)
+			}
+			var err error
+			if fs.regex, err = regexp.Compile(pattern); err != nil {
+				return nil, errors.Wrapf(err, "invalid regular expression on field %s", field)
+			}
+		default:
+			return nil, errors.Errorf("operator %s is not supported by leveldb", key)
+		}
+		selectors = append(selectors, fs)
+	}
+	if operators != 0 && operators != len(conditions) {
+		return nil, errors.Errorf("conditions on field %s mix operators and nested fields", field)
+	}
+	return selectors, nil
+}
+
+func parseSort(jsonSort interface{}) ([]sortField, error) {
+	sortItems, ok := jsonSort.([]interface{})
+	if !ok {
+		return nil, errors.New("\"sort\" of the query must be an array")
+	}
+	var sortFields []sortField
+	for _, sortItem := range sortItems {
+		switch item := sortItem.(type) {
+		case string:
+			sortFields = append(sortFields, sortField{field: item})
+		case map[string]interface{}:
+			if len(item) != 1 {
+				return nil, errors.Errorf("invalid sort field %v, it must be a field name or an object of a field name and a direction", item)
+			}
+			for field, direction := range item {
+				if direction != "asc" && direction != "desc" {
+					return nil, errors.Errorf("invalid direction %v of sort field %s, it must be either \"asc\" or \"desc\"", direction, field)
+				}
+				sortFields = append(sortFields, sortField{field: field, desc: direction == "desc"})
+			}
+		default:
+			return nil, errors.Errorf("invalid sort field %v, it must be a field name or an object of a field name and a direction", item)
+		}
+	}
+	return sortFields, nil
+}
+
+func (s andSelector) matches(doc map[string]interface{}) bool {
+	for _, subSelector := range s {
+		if !subSelector.matches(doc) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s orSelector) matches(doc map[string]interface{}) bool {
+	for _, subSelector := range s {
+		if subSelector.matches(doc) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s norSelector) matches(doc map[string]interface{}) bool {
+	return !orSelector(s).matches(doc)
+}
+
+func (s notSelector) matches(doc map[string]interface{}) bool {
+	return !s.selector.matches(doc)
+}
+
+// matches evaluates the condition on the field of the document. As in CouchDB, the values of different
+// types are compared as per their collation, and a missing field only matches {"$exists":false}
+func (s *fieldSelector) matches(doc map[string]interface{}) bool {
+	value, found := lookupField(doc, s.field)
+	if s.op == "$exists" {
+		return found == s.operand.(bool)
+	}
+	if !found {
+		return false
+	}
+	switch s.op {
+	case "$eq":
+		return compareValues(value, s.operand) == 0
+	case "$ne":
+		return compareValues(value, s.operand) != 0
+	case "$lt":
+		return compareValues(value, s.operand) < 0
+	case "$lte":
+		return compareValues(value, s.operand) <= 0
+	case "$gt":
+		return compareValues(value, s.operand) > 0
+	case "$gte":
+		return compareValues(value, s.operand) >= 0
+	case "$in", "$nin":
+		in := false
+		for _, item := range s.operand.([]interface{}) {
+			if compareValues(value, item) == 0 {
+				in = true
+				break
+			}
+		}
+		return in == (s.op == "$in")
+	case "$regex":
+		str, ok := value.(string)
+		return ok && s.regex.MatchString(str)
+	}
+	return false
+}
+
+// fieldConditions returns the conditions on the fields that all the documents matching the selector
+// satisfy, i.e., the field selectors of the implicit or explicit "$and" at the top of the selector
+func fieldConditions(s selector) []*fieldSelector {
+	switch sel := s.(type) {
+	case *fieldSelector:
+		return []*fieldSelector{sel}
+	case andSelector:
+		var conditions []*fieldSelector
+		for _, subSelector := range sel {
+			conditions = append(conditions, fieldConditions(subSelector)...)
+		}
+		return conditions
+	}
+	return nil
+}
+
+// lookupField returns the value of the field of the document, in which the nested fields
+// are referred to with the dot notation
+func lookupField(doc map[string]interface{}, field string) (interface{}, bool) {
+	var value interface{} = doc
+	for _, name := range strings.Split(field, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[name]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// projectFields returns a document that contains only the given fields of the document
+func projectFields(doc map[string]interface{}, fields []string) map[string]interface{} {
+	projection := make(map[string]interface{})
+	for _, field := range fields {
+		value, found := lookupField(doc, field)
+		if !found {
+			continue
+		}
+		names := strings.Split(field, ".")
+		object := projection
+		for _, name := range names[:len(names)-1] {
+			nested, ok := object[name].(map[string]interface{})
+			if !ok {
+				nested = make(map[string]interface{})
+				object[name] = nested
+			}
+			object = nested
+		}
+		object[names[len(names)-1]] = value
+	}
+	return projection
+}
+
+// compareValues compares two JSON values as per the collation of their encoding
+func compareValues(a, b interface{}) int {
+	return bytes.Compare(encodeValue(nil, a), encodeValue(nil, b))
+}
+
+const (
+	nullTag   = byte(0x01)
+	falseTag  = byte(0x02)
+	trueTag   = byte(0x03)
+	numberTag = byte(0x04)
+	stringTag = byte(0x05)
+	arrayTag  = byte(0x06)
+	objectTag = byte(0x07)
+)
+
+// encodeValue appends the encoding of a JSON value to buf. The encoding is such that the byte-wise order
+// of the encoded values follows the CouchDB collation of the JSON types, i.e., null < false < true < numbers
+// < strings < arrays < objects. The strings are ordered by their UTF-8 bytes and the objects by their fields
+// in the order of the field names. No encoded value is a prefix of another, so that the encoded values of
+// the fields of an index can be concatenated in an index key
+func encodeValue(buf []byte, value interface{}) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(buf, nullTag)
+	case bool:
+		if v {
+			return append(buf, trueTag)
+		}
+		return append(buf, falseTag)
+	case float64:
+		bits := math.Float64bits(v + 0) // adding zero maps -0 to 0
+		if v < 0 {
+			bits = ^bits
+		} else {
+			bits |= 1 << 63
+		}
+		buf = append(buf, numberTag)
+		return append(buf, uint64ToBytes(bits)...)
+	case string:
+		buf = append(buf, stringTag)
+		for _, b := range []byte(v) {
+			buf = append(buf, b)
+			if b == 0x00 {
+				buf = append(buf, 0xff)
+			}
+		}
+		return append(buf, 0x00, 0x01)
+	case []interface{}:
+		buf = append(buf, arrayTag)
+		for _, item := range v {
+			buf = encodeValue(buf, item)
+		}
+		return append(buf, 0x00)
+	case map[string]interface{}:
+		buf = append(buf, objectTag)
+		for _, key := range sortedKeys(v) {
+			buf = encodeValue(buf, key)
+			buf = encodeValue(buf, v[key])
+		}
+		return append(buf, 0x00)
+	}
+	// the values decoded by encoding/json are of one of the types above
+	panic(errors.Errorf("unexpected JSON value %#v", value))
+}
+
+func uint64ToBytes(n uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, n)
+	return b
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func toStrings(value interface{}) ([]string, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New("an array of strings is expected")
+	}
+	strs := make([]string, len(items))
+	for i, item := range items {
+		if strs[i], ok = item.(string); !ok {
+			return nil, errors.New("an array of strings is expected")
+		}
+	}
+	return strs, nil
+}
+
+func toNonNegativeInt(jsonQuery map[string]interface{}, key string) (int, error) {
+	value, ok := jsonQuery[key]
+	if !ok {
+		return 0, nil
+	}
+	n, ok := value.(float64)
+	if !ok || n < 0 || n != math.Trunc(n) {
+		return 0, errors.Errorf("\"%s\" of the query must be a non-negative integer", key)
+	}
+	return int(n), nil
+}
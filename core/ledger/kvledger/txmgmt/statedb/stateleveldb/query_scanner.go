@@ -0,0 +1,400 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package stateleveldb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+)
+
+// The bookmark of a page of results is either the position in the scan of the index or the namespace
+// of the first result of the next page, or the offset of the first result of the next page among the
+// sorted results, when the results are sorted in memory
+const (
+	positionBookmarkPrefix = "p"
+	offsetBookmarkPrefix   = "o"
+)
+
+// queryPlan describes how the candidate values of a query are retrieved. The candidates are either
+// the values of the namespace or the values referred to by the entries of an index, in the range
+// [start, end) of the db keys, which is scanned backward if reverse is true
+type queryPlan struct {
+	index   *indexDefinition
+	start   []byte
+	end     []byte
+	reverse bool
+	// sorted is true if the order of the scan satisfies the sort of the query
+	sorted bool
+}
+
+// planQuery chooses the index, if any, to be used for the query. An index can be used only if the
+// documents that are not in the index (because they miss a field of the index) cannot be in the results,
+// i.e., if each field of the index is either constrained by the selector or a field of the sort. Among those
+// indexes, the one specified by "use_index" is preferred, then the ones that limit the range of the scan
+// by a condition on their first field, and then the ones that satisfy the sort of the query
+func (vdb *versionedDB) planQuery(namespace string, q *query) (*queryPlan, error) {
+	defs, err := vdb.getIndexDefinitions(namespace)
+	if err != nil {
+		return nil, err
+	}
+	conditions := make(map[string][]*fieldSelector)
+	for _, condition := range fieldConditions(q.selector) {
+		if condition.op == "$exists" && !condition.operand.(bool) {
+			continue
+		}
+		conditions[condition.field] = append(conditions[condition.field], condition)
+	}
+	sortFields := make(map[string]bool)
+	for _, sf := range q.sort {
+		sortFields[sf.field] = true
+	}
+
+	var usable []*indexDefinition
+	for _, def := range defs {
+		isUsable := true
+		for _, field := range def.Fields {
+			if _, ok := conditions[field]; !ok && !sortFields[field] {
+				isUsable = false
+				break
+			}
+		}
+		if isUsable {
+			usable = append(usable, def)
+		}
+	}
+
+	var chosen *indexDefinition
+	if len(q.useIndex) > 0 {
+		for _, def := range usable {
+			if def.Ddoc == q.useIndex[0] && (len(q.useIndex) == 1 || def.Name == q.useIndex[1]) ||
+				len(q.useIndex) == 1 && def.Name == q.useIndex[0] {
+				chosen = def
+				break
+			}
+		}
+		if chosen == nil {
+			logger.Warningf("Channel [%s]: Index %s of namespace [%s] does not exist or cannot be used for the query, another index is used if any",
+				vdb.dbName, q.useIndex, namespace)
+		}
+	}
+	if chosen == nil {
+		for _, def := range usable {
+			if hasRangeCondition(conditions[def.Fields[0]]) {
+				chosen = def
+				break
+			}
+		}
+	}
+	if chosen == nil {
+		for _, def := range usable {
+			if _, ok := indexSatisfiesSort(def, q.sort); ok {
+				chosen = def
+				break
+			}
+		}
+	}
+
+	if chosen == nil {
+		start := constructCompositeKey(namespace, "")
+		end := constructCompositeKey(namespace, "")
+		end[len(end)-1] = lastKeyIndicator
+		return &queryPlan{start: start, end: end, sorted: len(q.sort) == 0}, nil
+	}
+	plan := &queryPlan{index: chosen}
+	plan.start, plan.end = indexRange(namespace, chosen, conditions[chosen.Fields[0]])
+	plan.reverse, plan.sorted = indexSatisfiesSort(chosen, q.sort)
+	if len(q.sort) == 0 {
+		plan.reverse, plan.sorted = false, true
+	}
+	logger.Debugf("Channel [%s]: Using index [%s] of namespace [%s] for the query", vdb.dbName, chosen.Name, namespace)
+	return plan, nil
+}
+
+func hasRangeCondition(conditions []*fieldSelector) bool {
+	for _, condition := range conditions {
+		switch condition.op {
+		case "$eq", "$lt", "$lte", "$gt", "$gte":
+			return true
+		}
+	}
+	return false
+}
+
+// indexSatisfiesSort returns true if the order of the index satisfies the sort, i.e., if the fields of
+// the sort are the first fields of the index, in the same direction. The first return value is true if
+// the index has to be scanned backward
+func indexSatisfiesSort(def *indexDefinition, sortFields []sortField) (bool, bool) {
+	if len(sortFields) == 0 || len(sortFields) > len(def.Fields) {
+		return false, false
+	}
+	for i, sf := range sortFields {
+		if sf.field != def.Fields[i] || sf.desc != sortFields[0].desc {
+			return false, false
+		}
+	}
+	return sortFields[0].desc, true
+}
+
+// indexRange returns the range of the entry keys of the index that may match the conditions on the first
+// field of the index
+func indexRange(namespace string, def *indexDefinition, conditions []*fieldSelector) ([]byte, []byte) {
+	prefix := constructIndexEntryKeyPrefix(namespace, def.Name)
+	start, end := prefix, prefixEnd(prefix)
+	for _, condition := range conditions {
+		var conditionStart, conditionEnd []byte
+		bound := encodeValue(append([]byte{}, prefix...), condition.operand)
+		switch condition.op {
+		case "$eq":
+			conditionStart, conditionEnd = bound, prefixEnd(bound)
+		case "$gt":
+			conditionStart = prefixEnd(bound)
+		case "$gte":
+			conditionStart = bound
+		case "$lt":
+			conditionEnd = bound
+		case "$lte":
+			conditionEnd = prefixEnd(bound)
+		}
+		if conditionStart != nil && bytes.Compare(conditionStart, start) > 0 {
+			start = conditionStart
+		}
+		if conditionEnd != nil && bytes.Compare(conditionEnd, end) < 0 {
+			end = conditionEnd
+		}
+	}
+	return start, end
+}
+
+// executeQuery executes the query and returns an iterator over at most limit results, after skipping
+// skip results. If the bookmark is not empty, the results start from the position held by the bookmark
+func (vdb *versionedDB) executeQuery(namespace string, q *query, skip, limit int, bookmark string) (*queryScanner, error) {
+	plan, err := vdb.planQuery(namespace, q)
+	if err != nil {
+		return nil, err
+	}
+	scanner := &queryScanner{vdb: vdb, namespace: namespace, query: q, plan: plan, skip: skip, limit: limit}
+	var position []byte
+	if bookmark != "" {
+		if position, err = scanner.applyBookmark(bookmark); err != nil {
+			return nil, err
+		}
+	}
+	start, end := plan.start, plan.end
+	if position != nil {
+		if plan.reverse {
+			end = append(position, 0x00)
+		} else {
+			start = position
+		}
+	}
+	if bytes.Compare(start, end) >= 0 {
+		scanner.dbItr = iterator.NewEmptyIterator(nil)
+	} else {
+		scanner.dbItr = vdb.db.GetIterator(start, end)
+	}
+	if !plan.sorted {
+		if err := scanner.sortResults(); err != nil {
+			scanner.Close()
+			return nil, err
+		}
+	}
+	return scanner, nil
+}
+
+// queryScanner iterates over the results of a query. The candidate values are retrieved as per the query plan
+// and filtered by the selector. If the plan does not satisfy the sort of the query, all the results are first
+// collected and sorted in memory
+type queryScanner struct {
+	vdb       *versionedDB
+	namespace string
+	query     *query
+	plan      *queryPlan
+	dbItr     iterator.Iterator
+	started   bool
+	skip      int
+	limit     int
+	fetched   int
+	// sortedResults holds the results of the query, if sorted in memory, and offset the position of the next
+	// result to be returned
+	sortedResults []*queryResult
+	offset        int
+}
+
+// queryResult is a value that matches the selector of a query
+type queryResult struct {
+	kv       *statedb.VersionedKV
+	doc      map[string]interface{}
+	position []byte
+}
+
+// Next implements method in ResultsIterator interface
+func (scanner *queryScanner) Next() (statedb.QueryResult, error) {
+	if scanner.limit > 0 && scanner.fetched >= scanner.limit {
+		return nil, nil
+	}
+	var result *queryResult
+	if scanner.sortedResults != nil {
+		if scanner.offset < len(scanner.sortedResults) {
+			result = scanner.sortedResults[scanner.offset]
+			scanner.offset++
+		}
+	} else {
+		for ; scanner.skip >= 0; scanner.skip-- {
+			var err error
+			if result, err = scanner.nextMatch(); err != nil || result == nil {
+				return nil, err
+			}
+		}
+		scanner.skip = 0
+	}
+	if result == nil {
+		return nil, nil
+	}
+	scanner.fetched++
+	if len(scanner.query.fields) > 0 {
+		projection, err := json.Marshal(projectFields(result.doc, scanner.query.fields))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error while marshalling the fields of key [%s]", result.kv.Key)
+		}
+		result.kv.Value = projection
+	}
+	return result.kv, nil
+}
+
+// nextMatch returns the next candidate value that matches the selector of the query
+func (scanner *queryScanner) nextMatch() (*queryResult, error) {
+	for scanner.nextCandidate() {
+		position := append([]byte{}, scanner.dbItr.Key()...)
+		var kv *statedb.VersionedKV
+		if scanner.plan.index == nil {
+			_, key := splitCompositeKey(position)
+			value, metadata, version := statedb.DecodeValueAndMetadata(append([]byte{}, scanner.dbItr.Value()...))
+			kv = &statedb.VersionedKV{
+				CompositeKey:   statedb.CompositeKey{Namespace: scanner.namespace, Key: key},
+				VersionedValue: statedb.VersionedValue{Value: value, Metadata: metadata, Version: version}}
+		} else {
+			key := string(scanner.dbItr.Value())
+			vv, err := scanner.vdb.GetState(scanner.namespace, key)
+			if err != nil {
+				return nil, err
+			}
+			if vv == nil {
+				continue
+			}
+			kv = &statedb.VersionedKV{CompositeKey: statedb.CompositeKey{Namespace: scanner.namespace, Key: key}, VersionedValue: *vv}
+		}
+		doc := unmarshalDoc(kv.Value)
+		if doc == nil || !scanner.query.selector.matches(doc) {
+			continue
+		}
+		return &queryResult{kv, doc, position}, nil
+	}
+	return nil, scanner.dbItr.Error()
+}
+
+func (scanner *queryScanner) nextCandidate() bool {
+	if !scanner.plan.reverse {
+		return scanner.dbItr.Next()
+	}
+	if !scanner.started {
+		scanner.started = true
+		return scanner.dbItr.Last()
+	}
+	return scanner.dbItr.Prev()
+}
+
+// sortResults collects all the results of the query and sorts them as per the sort of the query. As the
+// results of an index, the documents that miss a field of the sort are not part of the results
+func (scanner *queryScanner) sortResults() error {
+	results := []*queryResult{}
+	sortKeys := make(map[*queryResult][][]byte)
+	for {
+		result, err := scanner.nextMatch()
+		if err != nil {
+			return err
+		}
+		if result == nil {
+			break
+		}
+		var resultSortKeys [][]byte
+		for _, sf := range scanner.query.sort {
+			value, found := lookupField(result.doc, sf.field)
+			if !found {
+				break
+			}
+			resultSortKeys = append(resultSortKeys, encodeValue(nil, value))
+		}
+		if len(resultSortKeys) != len(scanner.query.sort) {
+			continue
+		}
+		sortKeys[result] = resultSortKeys
+		results = append(results, result)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		for k, sf := range scanner.query.sort {
+			if c := bytes.Compare(sortKeys[results[i]][k], sortKeys[results[j]][k]); c != 0 {
+				return (c < 0) != sf.desc
+			}
+		}
+		return false
+	})
+	scanner.sortedResults = results
+	scanner.offset += scanner.skip
+	return nil
+}
+
+// applyBookmark returns the position of the scan held by the bookmark, if any, or applies the offset held by the bookmark
+func (scanner *queryScanner) applyBookmark(bookmark string) ([]byte, error) {
+	switch {
+	case scanner.plan.sorted && strings.HasPrefix(bookmark, positionBookmarkPrefix):
+		position, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(bookmark, positionBookmarkPrefix))
+		if err == nil && bytes.Compare(position, scanner.plan.start) >= 0 && bytes.Compare(position, scanner.plan.end) < 0 {
+			return position, nil
+		}
+	case !scanner.plan.sorted && strings.HasPrefix(bookmark, offsetBookmarkPrefix):
+		offset, err := strconv.Atoi(strings.TrimPrefix(bookmark, offsetBookmarkPrefix))
+		if err == nil && offset >= 0 {
+			scanner.offset = offset
+			return nil, nil
+		}
+	}
+	return nil, errors.Errorf("invalid bookmark [%s] for the query", bookmark)
+}
+
+// GetBookmarkAndClose implements method in QueryResultsIterator interface.
+// The bookmark is empty if there are no more results
+func (scanner *queryScanner) GetBookmarkAndClose() string {
+	defer scanner.Close()
+	if scanner.sortedResults != nil {
+		if scanner.offset >= len(scanner.sortedResults) {
+			return ""
+		}
+		return offsetBookmarkPrefix + strconv.Itoa(scanner.offset)
+	}
+	result, err := scanner.nextMatch()
+	if err != nil {
+		logger.Errorf("Channel [%s]: Error while looking for the next page of the query on namespace [%s]: %s", scanner.vdb.dbName, scanner.namespace, err)
+		return ""
+	}
+	if result == nil {
+		return ""
+	}
+	return positionBookmarkPrefix + base64.RawURLEncoding.EncodeToString(result.position)
+}
+
+// Close implements method in ResultsIterator interface
+func (scanner *queryScanner) Close() {
+	scanner.dbItr.Release()
+}
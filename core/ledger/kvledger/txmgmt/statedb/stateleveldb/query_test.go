@@ -0,0 +1,148 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package stateleveldb
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuery(t *testing.T) {
+	q, err := parseQuery(`{"selector":{"owner":"tom"},"fields":["owner","size"],"sort":["size",{"color":"desc"}],"limit":10,"skip":2,"use_index":["indexDoc","indexSize"]}`)
+	assert.NoError(t, err)
+	assert.Equal(t, &fieldSelector{field: "owner", op: "$eq", operand: "tom"}, q.selector)
+	assert.Equal(t, []string{"owner", "size"}, q.fields)
+	assert.Equal(t, []sortField{{field: "size"}, {field: "color", desc: true}}, q.sort)
+	assert.Equal(t, 10, q.limit)
+	assert.Equal(t, 2, q.skip)
+	assert.Equal(t, []string{"indexDoc", "indexSize"}, q.useIndex)
+
+	q, err = parseQuery(`{}`)
+	assert.NoError(t, err)
+	assert.True(t, q.selector.matches(map[string]interface{}{}))
+
+	invalidQueries := map[string]string{
+		`this is an invalid query string`:                   "query is not valid JSON",
+		`{"selector":"owner"}`:                              `"selector" of the query must be an object`,
+		`{"selector":{"$elemMatch":{"owner":"tom"}}}`:       "operator $elemMatch is not supported by leveldb",
+		`{"selector":{"owner":{"$size":1}}}`:                "operator $size is not supported by leveldb",
+		`{"selector":{"$and":{"owner":"tom"}}}`:             "operator $and requires an array of selectors",
+		`{"selector":{"$not":["owner"]}}`:                   "operator $not requires a selector",
+		`{"selector":{"owner":{"$in":"tom"}}}`:              "operator $in on field owner requires an array",
+		`{"selector":{"owner":{"$exists":"yes"}}}`:          "operator $exists on field owner requires a boolean",
+		`{"selector":{"owner":{"$regex":"("}}}`:             "invalid regular expression on field owner",
+		`{"selector":{"owner":{"$eq":"tom","name":"tom"}}}`: "conditions on field owner mix operators and nested fields",
+		`{"fields":"owner"}`:                                `invalid "fields" of the query`,
+		`{"sort":"size"}`:                                   `"sort" of the query must be an array`,
+		`{"sort":[{"size":"up"}]}`:                          `invalid direction up of sort field size`,
+		`{"limit":-1}`:                                      `"limit" of the query must be a non-negative integer`,
+		`{"skip":1.5}`:                                      `"skip" of the query must be a non-negative integer`,
+		`{"use_index":["a","b","c"]}`:                       `"use_index" of the query must be a design document name`,
+	}
+	for query, reason := range invalidQueries {
+		_, err := parseQuery(query)
+		assert.Error(t, err, query)
+		assert.Contains(t, err.Error(), reason, query)
+	}
+}
+
+func TestSelectorMatches(t *testing.T) {
+	doc := unmarshalDoc([]byte(`{"owner":"tom","size":5,"color":"blue","tags":["a","b"],"address":{"city":"London","zip":null},"sold":false}`))
+	matchingSelectors := []string{
+		`{}`,
+		`{"owner":"tom"}`,
+		`{"owner":"tom","size":5}`,
+		`{"size":{"$gt":4,"$lt":6}}`,
+		`{"size":{"$gte":5,"$lte":5}}`,
+		`{"size":{"$ne":6}}`,
+		`{"size":{"$lt":"5"}}`,
+		`{"owner":{"$in":["jerry","tom"]}}`,
+		`{"owner":{"$nin":["jerry"]}}`,
+		`{"owner":{"$regex":"^t.m$"}}`,
+		`{"owner":{"$exists":true},"name":{"$exists":false}}`,
+		`{"address.city":"London"}`,
+		`{"address":{"city":"London"}}`,
+		`{"address.zip":null}`,
+		`{"tags":["a","b"]}`,
+		`{"sold":false}`,
+		`{"$or":[{"owner":"jerry"},{"color":"blue"}]}`,
+		`{"$and":[{"owner":"tom"},{"$not":{"size":6}}]}`,
+		`{"$nor":[{"owner":"jerry"},{"color":"red"}]}`,
+	}
+	for _, s := range matchingSelectors {
+		q, err := parseQuery(`{"selector":` + s + `}`)
+		assert.NoError(t, err, s)
+		assert.True(t, q.selector.matches(doc), s)
+	}
+	nonMatchingSelectors := []string{
+		`{"owner":"jerry"}`,
+		`{"owner":"tom","size":6}`,
+		`{"size":{"$gt":5}}`,
+		`{"size":"5"}`,
+		`{"name":{"$ne":"tom"}}`,
+		`{"owner":{"$in":["jerry"]}}`,
+		`{"size":{"$regex":"5"}}`,
+		`{"address.country":"UK"}`,
+		`{"owner.name":"tom"}`,
+		`{"tags":["a"]}`,
+		`{"$or":[{"owner":"jerry"},{"color":"red"}]}`,
+		`{"$not":{"owner":"tom"}}`,
+	}
+	for _, s := range nonMatchingSelectors {
+		q, err := parseQuery(`{"selector":` + s + `}`)
+		assert.NoError(t, err, s)
+		assert.False(t, q.selector.matches(doc), s)
+	}
+}
+
+func TestEncodeValue(t *testing.T) {
+	// values in collation order
+	orderedValues := []string{`null`, `false`, `true`, `-10.5`, `-1`, `0`, `1`, `2.5`, `1000007`,
+		`""`, `"\u0000"`, `"a"`, `"a\u0000"`, `"aa"`, `"b"`, `[]`, `[1]`, `[1,2]`, `["a"]`, `{}`, `{"a":1}`, `{"a":2}`, `{"b":1}`}
+	var previous []byte
+	for _, jsonValue := range orderedValues {
+		var value interface{}
+		assert.NoError(t, json.Unmarshal([]byte(jsonValue), &value))
+		encoded := encodeValue(nil, value)
+		if previous != nil {
+			assert.True(t, bytes.Compare(previous, encoded) < 0, jsonValue)
+			assert.False(t, bytes.HasPrefix(encoded, previous), jsonValue)
+		}
+		previous = encoded
+	}
+	assert.Equal(t, 0, compareValues(0.0, -0.0*1))
+}
+
+func TestProjectFields(t *testing.T) {
+	doc := unmarshalDoc([]byte(`{"owner":"tom","size":5,"address":{"city":"London","zip":"N1"}}`))
+	assert.Equal(t,
+		map[string]interface{}{"owner": "tom", "address": map[string]interface{}{"city": "London"}},
+		projectFields(doc, []string{"owner", "address.city", "color"}))
+}
+
+func TestParseIndexDefinition(t *testing.T) {
+	def, err := parseIndexDefinition("leveldb/indexes/indexSize.json",
+		[]byte(`{"index":{"fields":["docType",{"size":"desc"}]},"ddoc":"indexSizeDoc","name":"indexSize","type":"json"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, &indexDefinition{Name: "indexSize", Ddoc: "indexSizeDoc", Fields: []string{"docType", "size"}}, def)
+
+	def, err = parseIndexDefinition("leveldb/indexes/indexSize.json", []byte(`{"index":{"fields":["size"]},"ddoc":"indexSizeDoc"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "indexSizeDoc", def.Name)
+
+	def, err = parseIndexDefinition("leveldb/indexes/indexSize.json", []byte(`{"index":{"fields":["size"]}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "indexSize", def.Name)
+
+	_, err = parseIndexDefinition("leveldb/indexes/indexSize.json", []byte(`{"index":{"fields":[]}}`))
+	assert.EqualError(t, err, `index definition must contain a non-empty "fields" array`)
+	_, err = parseIndexDefinition("leveldb/indexes/indexSize.json", []byte(`{"index":{"fields":[1]}}`))
+	assert.EqualError(t, err, "unexpected field 1 in the index definition")
+}
@@ -7,7 +7,8 @@ package stateleveldb
 
 import (
 	"bytes"
-	"errors"
+	"fmt"
+	"sync"
 
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
@@ -26,19 +27,46 @@ var savePointKey = []byte{0x00}
 // VersionedDBProvider implements interface VersionedDBProvider
 type VersionedDBProvider struct {
 	dbProvider *leveldbhelper.Provider
+	databases  map[string]*versionedDB
+	mux        sync.Mutex
 }
 
 // NewVersionedDBProvider instantiates VersionedDBProvider
 func NewVersionedDBProvider() *VersionedDBProvider {
+	return newVersionedDBProvider(false)
+}
+
+// NewInMemoryVersionedDBProvider instantiates VersionedDBProvider that keeps the databases in the memory
+func NewInMemoryVersionedDBProvider() *VersionedDBProvider {
+	return newVersionedDBProvider(true)
+}
+
+func newVersionedDBProvider(inMemory bool) *VersionedDBProvider {
 	dbPath := ledgerconfig.GetStateLevelDBPath()
-	logger.Debugf("constructing VersionedDBProvider dbPath=%s", dbPath)
-	dbProvider := leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath})
-	return &VersionedDBProvider{dbProvider}
+	logger.Debugf("constructing VersionedDBProvider dbPath=%s, inMemory=%t", dbPath, inMemory)
+	dbProvider := leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath, InMemory: inMemory})
+	return &VersionedDBProvider{dbProvider: dbProvider, databases: make(map[string]*versionedDB)}
 }
 
-// GetDBHandle gets the handle to a named database
+// GetDBHandle gets the handle to a named database. The same handle is returned for a name, so that
+// the maintenance of the indexes of the db is serialized with the creation of the indexes
 func (provider *VersionedDBProvider) GetDBHandle(dbName string) (statedb.VersionedDB, error) {
-	return newVersionedDB(provider.dbProvider.GetDBHandle(dbName), dbName), nil
+	provider.mux.Lock()
+	defer provider.mux.Unlock()
+	vdb := provider.databases[dbName]
+	if vdb == nil {
+		vdb = newVersionedDB(provider.dbProvider.GetDBHandle(dbName), dbName)
+		provider.databases[dbName] = vdb
+	}
+	return vdb, nil
+}
+
+// Drop removes all the data of the named db, including its indexes
+func (provider *VersionedDBProvider) Drop(dbName string) error {
+	provider.mux.Lock()
+	defer provider.mux.Unlock()
+	delete(provider.databases, dbName)
+	return provider.dbProvider.GetDBHandle(dbName).DeleteAll()
 }
 
 // Close closes the underlying db
@@ -50,11 +78,13 @@ func (provider *VersionedDBProvider) Close() {
 type versionedDB struct {
 	db     *leveldbhelper.DBHandle
 	dbName string
+	// indexLock serializes the creation of the indexes with the updates of their entries
+	indexLock sync.Mutex
 }
 
 // newVersionedDB constructs an instance of VersionedDB
 func newVersionedDB(db *leveldbhelper.DBHandle, dbName string) *versionedDB {
-	return &versionedDB{db, dbName}
+	return &versionedDB{db: db, dbName: dbName}
 }
 
 // Open implements method in VersionedDB interface
@@ -89,8 +119,8 @@ func (vdb *versionedDB) GetState(namespace string, key string) (*statedb.Version
 	if dbVal == nil {
 		return nil, nil
 	}
-	val, ver := statedb.DecodeValue(dbVal)
-	return &statedb.VersionedValue{Value: val, Version: ver}, nil
+	val, metadata, ver := statedb.DecodeValueAndMetadata(dbVal)
+	return &statedb.VersionedValue{Value: val, Metadata: metadata, Version: ver}, nil
 }
 
 // GetVersion implements method in VersionedDB interface
@@ -122,26 +152,81 @@ func (vdb *versionedDB) GetStateMultipleKeys(namespace string, keys []string) ([
 // startKey is inclusive
 // endKey is exclusive
 func (vdb *versionedDB) GetStateRangeScanIterator(namespace string, startKey string, endKey string) (statedb.ResultsIterator, error) {
+	return vdb.getStateRangeScanIterator(namespace, startKey, endKey, 0), nil
+}
+
+// GetStateRangeScanIteratorWithPagination implements method in VersionedDB interface
+func (vdb *versionedDB) GetStateRangeScanIteratorWithPagination(namespace string, startKey string, endKey string, pageSize int32) (statedb.QueryResultsIterator, error) {
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("invalid page size [%d], it must be greater than zero", pageSize)
+	}
+	return vdb.getStateRangeScanIterator(namespace, startKey, endKey, pageSize), nil
+}
+
+func (vdb *versionedDB) getStateRangeScanIterator(namespace string, startKey string, endKey string, pageSize int32) *kvScanner {
 	compositeStartKey := constructCompositeKey(namespace, startKey)
 	compositeEndKey := constructCompositeKey(namespace, endKey)
 	if endKey == "" {
 		compositeEndKey[len(compositeEndKey)-1] = lastKeyIndicator
 	}
 	dbItr := vdb.db.GetIterator(compositeStartKey, compositeEndKey)
-	return newKVScanner(namespace, dbItr), nil
+	return newKVScanner(namespace, dbItr, pageSize)
 }
 
 // ExecuteQuery implements method in VersionedDB interface
+// The number of results is limited by the "limit" of the query, if any, and by the query limit from core.yaml
 func (vdb *versionedDB) ExecuteQuery(namespace, query string) (statedb.ResultsIterator, error) {
-	return nil, errors.New("ExecuteQuery not supported for leveldb")
+	q, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	limit := ledgerconfig.GetQueryLimit()
+	if q.limit > 0 && (limit <= 0 || q.limit < limit) {
+		limit = q.limit
+	}
+	return vdb.executeQuery(namespace, q, q.skip, limit, "")
+}
+
+// ExecuteQueryWithPagination implements method in VersionedDB interface
+// As for CouchDB, the page size is used as the limit of the query. The "skip" of the query applies to the first page only
+func (vdb *versionedDB) ExecuteQueryWithPagination(namespace, query, bookmark string, pageSize int32) (statedb.QueryResultsIterator, error) {
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("invalid page size [%d], it must be greater than zero", pageSize)
+	}
+	q, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	skip := q.skip
+	if bookmark != "" {
+		skip = 0
+	}
+	return vdb.executeQuery(namespace, q, skip, int(pageSize), bookmark)
+}
+
+// GetFullScanIterator implements method in VersionedDB interface
+func (vdb *versionedDB) GetFullScanIterator(skipNamespace func(string) bool) (statedb.ResultsIterator, error) {
+	dbItr := vdb.db.GetIterator(nil, nil)
+	return newFullScanner(dbItr, skipNamespace), nil
+}
+
+// FullScanReadsSnapshot implements method in VersionedDB interface
+func (vdb *versionedDB) FullScanReadsSnapshot() bool {
+	return true
 }
 
 // ApplyUpdates implements method in VersionedDB interface
+// The entries of the indexes of the updated namespaces are updated in the same db batch as the values
 func (vdb *versionedDB) ApplyUpdates(batch *statedb.UpdateBatch, height *version.Height) error {
+	vdb.indexLock.Lock()
+	defer vdb.indexLock.Unlock()
 	dbBatch := leveldbhelper.NewUpdateBatch()
 	namespaces := batch.GetUpdatedNamespaces()
 	for _, ns := range namespaces {
 		updates := batch.GetUpdates(ns)
+		if err := vdb.addIndexUpdates(dbBatch, ns, updates); err != nil {
+			return err
+		}
 		for k, vv := range updates {
 			compositeKey := constructCompositeKey(ns, k)
 			logger.Debugf("Channel [%s]: Applying key(string)=[%s] key(bytes)=[%#v]", vdb.dbName, string(compositeKey), compositeKey)
@@ -149,7 +234,7 @@ func (vdb *versionedDB) ApplyUpdates(batch *statedb.UpdateBatch, height *version
 			if vv.Value == nil {
 				dbBatch.Delete(compositeKey)
 			} else {
-				dbBatch.Put(compositeKey, statedb.EncodeValue(vv.Value, vv.Version))
+				dbBatch.Put(compositeKey, statedb.EncodeValueAndMetadata(vv.Value, vv.Metadata, vv.Version))
 			}
 		}
 	}
@@ -183,30 +268,83 @@ func splitCompositeKey(compositeKey []byte) (string, string) {
 	return string(split[0]), string(split[1])
 }
 
+// kvScanner iterates over the keys of a namespace in a range. If the pageSize is greater
+// than zero, the scanner stops after returning pageSize results
 type kvScanner struct {
 	namespace string
 	dbItr     iterator.Iterator
+	pageSize  int32
+	fetched   int32
 }
 
-func newKVScanner(namespace string, dbItr iterator.Iterator) *kvScanner {
-	return &kvScanner{namespace, dbItr}
+func newKVScanner(namespace string, dbItr iterator.Iterator, pageSize int32) *kvScanner {
+	return &kvScanner{namespace, dbItr, pageSize, 0}
 }
 
 func (scanner *kvScanner) Next() (statedb.QueryResult, error) {
+	if scanner.pageSize > 0 && scanner.fetched >= scanner.pageSize {
+		return nil, nil
+	}
 	if !scanner.dbItr.Next() {
 		return nil, nil
 	}
+	scanner.fetched++
 	dbKey := scanner.dbItr.Key()
 	dbVal := scanner.dbItr.Value()
 	dbValCopy := make([]byte, len(dbVal))
 	copy(dbValCopy, dbVal)
 	_, key := splitCompositeKey(dbKey)
-	value, version := statedb.DecodeValue(dbValCopy)
+	value, metadata, version := statedb.DecodeValueAndMetadata(dbValCopy)
 	return &statedb.VersionedKV{
 		CompositeKey:   statedb.CompositeKey{Namespace: scanner.namespace, Key: key},
-		VersionedValue: statedb.VersionedValue{Value: value, Version: version}}, nil
+		VersionedValue: statedb.VersionedValue{Value: value, Metadata: metadata, Version: version}}, nil
 }
 
 func (scanner *kvScanner) Close() {
 	scanner.dbItr.Release()
 }
+
+// GetBookmarkAndClose implements method in QueryResultsIterator interface.
+// The bookmark is the key that follows the last key returned by the scanner
+func (scanner *kvScanner) GetBookmarkAndClose() string {
+	defer scanner.Close()
+	if !scanner.dbItr.Next() {
+		return ""
+	}
+	_, key := splitCompositeKey(scanner.dbItr.Key())
+	return key
+}
+
+type fullScanner struct {
+	dbItr         iterator.Iterator
+	skipNamespace func(string) bool
+}
+
+func newFullScanner(dbItr iterator.Iterator, skipNamespace func(string) bool) *fullScanner {
+	return &fullScanner{dbItr, skipNamespace}
+}
+
+func (scanner *fullScanner) Next() (statedb.QueryResult, error) {
+	for scanner.dbItr.Next() {
+		dbKey := scanner.dbItr.Key()
+		if bytes.Equal(dbKey, savePointKey) || isIndexKey(dbKey) {
+			continue
+		}
+		ns, key := splitCompositeKey(dbKey)
+		if scanner.skipNamespace != nil && scanner.skipNamespace(ns) {
+			continue
+		}
+		dbVal := scanner.dbItr.Value()
+		dbValCopy := make([]byte, len(dbVal))
+		copy(dbValCopy, dbVal)
+		value, metadata, version := statedb.DecodeValueAndMetadata(dbValCopy)
+		return &statedb.VersionedKV{
+			CompositeKey:   statedb.CompositeKey{Namespace: ns, Key: key},
+			VersionedValue: statedb.VersionedValue{Value: value, Metadata: metadata, Version: version}}, nil
+	}
+	return nil, scanner.dbItr.Error()
+}
+
+func (scanner *fullScanner) Close() {
+	scanner.dbItr.Release()
+}
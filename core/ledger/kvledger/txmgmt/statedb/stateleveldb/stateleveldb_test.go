@@ -17,14 +17,19 @@ limitations under the License.
 package stateleveldb
 
 import (
+	"archive/tar"
+	"encoding/base64"
+	"fmt"
 	"os"
 	"testing"
 
 	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/common/ccprovider"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/commontests"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
 	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestMain(m *testing.M) {
@@ -44,6 +49,12 @@ func TestMultiDBBasicRW(t *testing.T) {
 	commontests.TestMultiDBBasicRW(t, env.DBProvider)
 }
 
+func TestDrop(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	commontests.TestDrop(t, env.DBProvider)
+}
+
 func TestDeletes(t *testing.T) {
 	env := NewTestVDBEnv(t)
 	defer env.Cleanup()
@@ -56,6 +67,24 @@ func TestIterator(t *testing.T) {
 	commontests.TestIterator(t, env.DBProvider)
 }
 
+func TestValueAndMetadataWrites(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	commontests.TestValueAndMetadataWrites(t, env.DBProvider)
+}
+
+func TestPaginatedRangeQuery(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	commontests.TestPaginatedRangeQuery(t, env.DBProvider)
+}
+
+func TestFullScanIterator(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	commontests.TestFullScanIterator(t, env.DBProvider)
+}
+
 func TestEncodeDecodeValueAndVersion(t *testing.T) {
 	testValueAndVersionEncoding(t, []byte("value1"), version.NewHeight(1, 2))
 	testValueAndVersionEncoding(t, []byte{}, version.NewHeight(50, 50))
@@ -81,27 +110,198 @@ func testCompositeKey(t *testing.T, dbName string, ns string, key string) {
 	testutil.AssertEquals(t, key1, key)
 }
 
-// TestQueryOnLevelDB tests queries on levelDB.
-func TestQueryOnLevelDB(t *testing.T) {
+func TestQuery(t *testing.T) {
 	env := NewTestVDBEnv(t)
 	defer env.Cleanup()
-	db, err := env.DBProvider.GetDBHandle("testquery")
-	testutil.AssertNoError(t, err, "")
-	db.Open()
-	defer db.Close()
+	commontests.TestQuery(t, env.DBProvider)
+}
+
+func TestQueryWithIndexes(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	db, err := env.DBProvider.GetDBHandle("testquerywithindexes")
+	assert.NoError(t, err)
+	vdb := db.(*versionedDB)
+
 	batch := statedb.NewUpdateBatch()
-	jsonValue1 := "{\"asset_name\": \"marble1\",\"color\": \"blue\",\"size\": 1,\"owner\": \"tom\"}"
-	batch.Put("ns1", "key1", []byte(jsonValue1), version.NewHeight(1, 1))
-
-	savePoint := version.NewHeight(2, 22)
-	db.ApplyUpdates(batch, savePoint)
-
-	// query for owner=jerry, use namespace "ns1"
-	// As queries are not supported in levelDB, call to ExecuteQuery()
-	// should return a error message
-	itr, err := db.ExecuteQuery("ns1", "{\"selector\":{\"owner\":\"jerry\"}}")
-	testutil.AssertError(t, err, "ExecuteQuery not supported for leveldb")
-	testutil.AssertNil(t, itr)
+	for i, owner := range []string{"tom", "jerry", "fred", "tom", "mary", "fred"} {
+		batch.Put("ns1", fmt.Sprintf("key%d", i), []byte(fmt.Sprintf(`{"owner":"%s","size":%d}`, owner, 10-i)), version.NewHeight(1, uint64(i)))
+	}
+	batch.Put("ns1", "key-nosize", []byte(`{"owner":"tom"}`), version.NewHeight(1, 6))
+	batch.Put("ns1", "key-binary", []byte{0x00, 0x01}, version.NewHeight(1, 7))
+	assert.NoError(t, db.ApplyUpdates(batch, version.NewHeight(1, 7)))
+
+	// the indexes are created on the existing values, and a file that is not an index is skipped
+	indexCapable := db.(statedb.IndexCapable)
+	assert.Equal(t, "leveldb", indexCapable.GetDBType())
+	assert.NoError(t, indexCapable.ProcessIndexesForChaincodeDeploy("ns1", []*ccprovider.TarFileEntry{
+		{FileHeader: &tar.Header{Name: "leveldb/indexes/indexOwner.json"}, FileContent: []byte(`{"index":{"fields":["owner","size"]},"name":"indexOwner"}`)},
+		{FileHeader: &tar.Header{Name: "leveldb/indexes/indexSize.json"}, FileContent: []byte(`{"index":{"fields":[{"size":"desc"}]},"ddoc":"indexSizeDoc"}`)},
+		{FileHeader: &tar.Header{Name: "leveldb/indexes/bad.json"}, FileContent: []byte(`{"index":{"fields":[]}}`)},
+		{FileHeader: &tar.Header{Name: "leveldb/README.md"}, FileContent: []byte(`indexes`)},
+	}))
+	defs, err := vdb.getIndexDefinitions("ns1")
+	assert.NoError(t, err)
+	assert.Equal(t, []*indexDefinition{
+		{Name: "indexOwner", Fields: []string{"owner", "size"}},
+		{Name: "indexSizeDoc", Ddoc: "indexSizeDoc", Fields: []string{"size"}},
+	}, defs)
+	otherDefs, err := vdb.getIndexDefinitions("ns2")
+	assert.NoError(t, err)
+	assert.Nil(t, otherDefs)
+
+	testQueryKeys(t, db, `{"selector":{"owner":"tom"}}`, []string{"key-nosize", "key0", "key3"})
+	testQueryPlan(t, vdb, `{"selector":{"owner":"tom"}}`, nil, true)
+	testQueryKeys(t, db, `{"selector":{"owner":"tom","size":{"$gt":0}}}`, []string{"key3", "key0"})
+	testQueryPlan(t, vdb, `{"selector":{"owner":"tom","size":{"$gt":0}}}`, defs[0], true)
+	testQueryKeys(t, db, `{"selector":{"size":{"$gte":6,"$lt":9}}}`, []string{"key4", "key3", "key2"})
+	testQueryPlan(t, vdb, `{"selector":{"size":{"$gte":6,"$lt":9}}}`, defs[1], true)
+	testQueryKeys(t, db, `{"selector":{"size":{"$gt":5}},"sort":[{"size":"desc"}],"limit":2}`, []string{"key0", "key1"})
+	testQueryPlan(t, vdb, `{"selector":{"size":{"$gt":5}},"sort":[{"size":"desc"}]}`, defs[1], true)
+	testQueryKeys(t, db, `{"selector":{"owner":{"$in":["tom","fred"]}},"sort":["owner","size"]}`, []string{"key5", "key2", "key3", "key0"})
+	testQueryPlan(t, vdb, `{"selector":{"owner":{"$in":["tom","fred"]}},"sort":["owner","size"]}`, defs[0], true)
+	// an index that misses the values without a field of the index is not used even if specified
+	testQueryKeys(t, db, `{"selector":{"owner":"tom"},"use_index":"indexOwner"}`, []string{"key-nosize", "key0", "key3"})
+	testQueryPlan(t, vdb, `{"selector":{"size":{"$gt":5}},"use_index":"indexOwner"}`, defs[1], true)
+	testQueryKeys(t, db, `{"selector":{"size":{"$gt":5}},"use_index":["indexSizeDoc","indexSizeDoc"]}`, []string{"key4", "key3", "key2", "key1", "key0"})
+	testQueryPlan(t, vdb, `{"selector":{"size":{"$gt":5}},"sort":["owner"]}`, defs[1], false)
+	testQueryKeys(t, db, `{"selector":{"size":{"$gt":5}},"sort":["owner"],"skip":1,"fields":["owner"]}`, []string{"key1", "key4", "key3", "key0"})
+
+	// the entries of the indexes are kept up to date with the updates
+	batch = statedb.NewUpdateBatch()
+	batch.Put("ns1", "key0", []byte(`{"owner":"jerry","size":10}`), version.NewHeight(2, 0))
+	batch.Delete("ns1", "key3", version.NewHeight(2, 1))
+	batch.Put("ns1", "key-nosize", []byte(`{"owner":"tom","size":1}`), version.NewHeight(2, 2))
+	batch.Put("ns1", "key4", []byte("not json"), version.NewHeight(2, 3))
+	batch.Put("ns1", "key6", []byte(`{"owner":"tom","size":3}`), version.NewHeight(2, 4))
+	assert.NoError(t, db.ApplyUpdates(batch, version.NewHeight(2, 4)))
+	testQueryKeys(t, db, `{"selector":{"owner":"tom","size":{"$gt":0}}}`, []string{"key-nosize", "key6"})
+	testQueryKeys(t, db, `{"selector":{"size":{"$gte":1}},"sort":["size"]}`, []string{"key-nosize", "key6", "key5", "key2", "key1", "key0"})
+	testQueryKeys(t, db, `{"selector":{"owner":"jerry","size":{"$gte":0}}}`, []string{"key1", "key0"})
+	assertIndexEntries(t, vdb, "ns1", "indexOwner", 6)
+	assertIndexEntries(t, vdb, "ns1", "indexSizeDoc", 6)
+
+	// an index redefined with other fields is rebuilt, while an unchanged index is not
+	assert.NoError(t, indexCapable.ProcessIndexesForChaincodeDeploy("ns1", []*ccprovider.TarFileEntry{
+		{FileHeader: &tar.Header{Name: "leveldb/indexes/indexOwner.json"}, FileContent: []byte(`{"index":{"fields":["owner"]},"name":"indexOwner"}`)},
+		{FileHeader: &tar.Header{Name: "leveldb/indexes/indexSize.json"}, FileContent: []byte(`{"index":{"fields":["size"]},"ddoc":"indexSizeDoc"}`)},
+	}))
+	assertIndexEntries(t, vdb, "ns1", "indexOwner", 6)
+	testQueryKeys(t, db, `{"selector":{"owner":"tom"}}`, []string{"key-nosize", "key6"})
+	testQueryPlan(t, vdb, `{"selector":{"owner":"tom"}}`, &indexDefinition{Name: "indexOwner", Fields: []string{"owner"}}, true)
+
+	// the full scan does not return the indexes
+	itr, err := db.GetFullScanIterator(nil)
+	assert.NoError(t, err)
+	defer itr.Close()
+	for {
+		result, err := itr.Next()
+		assert.NoError(t, err)
+		if result == nil {
+			break
+		}
+		assert.Equal(t, "ns1", result.(*statedb.VersionedKV).Namespace)
+	}
+
+	// the indexes are dropped with the db
+	assert.NoError(t, env.DBProvider.Drop("testquerywithindexes"))
+	db, err = env.DBProvider.GetDBHandle("testquerywithindexes")
+	assert.NoError(t, err)
+	defs, err = db.(*versionedDB).getIndexDefinitions("ns1")
+	assert.NoError(t, err)
+	assert.Nil(t, defs)
+}
+
+func TestPaginatedQuery(t *testing.T) {
+	env := NewTestVDBEnv(t)
+	defer env.Cleanup()
+	db, err := env.DBProvider.GetDBHandle("testpaginatedquery")
+	assert.NoError(t, err)
+	batch := statedb.NewUpdateBatch()
+	for i := 0; i < 10; i++ {
+		batch.Put("ns1", fmt.Sprintf("key%d", i), []byte(fmt.Sprintf(`{"color":"blue","size":%d}`, i%4)), version.NewHeight(1, uint64(i)))
+	}
+	assert.NoError(t, db.ApplyUpdates(batch, version.NewHeight(1, 9)))
+
+	// results from a scan of the namespace
+	query := `{"selector":{"color":"blue"},"skip":1}`
+	testPaginatedQueryKeys(t, db, query, 4, [][]string{{"key1", "key2", "key3", "key4"}, {"key5", "key6", "key7", "key8"}, {"key9"}})
+	// results sorted in memory
+	query = `{"selector":{"color":"blue"},"sort":["size"]}`
+	testPaginatedQueryKeys(t, db, query, 3, [][]string{{"key0", "key4", "key8"}, {"key1", "key5", "key9"}, {"key2", "key6", "key3"}, {"key7"}})
+	// results from a scan of an index
+	assert.NoError(t, db.(statedb.IndexCapable).ProcessIndexesForChaincodeDeploy("ns1", []*ccprovider.TarFileEntry{
+		{FileHeader: &tar.Header{Name: "leveldb/indexes/indexSize.json"}, FileContent: []byte(`{"index":{"fields":["size"]},"name":"indexSize"}`)},
+	}))
+	query = `{"selector":{"size":{"$lt":3}},"sort":[{"size":"desc"}]}`
+	testPaginatedQueryKeys(t, db, query, 4, [][]string{{"key6", "key2", "key9", "key5"}, {"key1", "key8", "key4", "key0"}})
+
+	_, err = db.ExecuteQueryWithPagination("ns1", query, "", 0)
+	assert.EqualError(t, err, "invalid page size [0], it must be greater than zero")
+	_, err = db.ExecuteQueryWithPagination("ns1", query, "o3", 2)
+	assert.EqualError(t, err, "invalid bookmark [o3] for the query")
+	_, err = db.ExecuteQueryWithPagination("ns1", `{"selector":{"color":"blue"}}`, "p"+base64.RawURLEncoding.EncodeToString([]byte("ns2")), 2)
+	assert.Contains(t, err.Error(), "invalid bookmark")
+}
+
+func testQueryKeys(t *testing.T, db statedb.VersionedDB, query string, expectedKeys []string) {
+	itr, err := db.ExecuteQuery("ns1", query)
+	assert.NoError(t, err, query)
+	defer itr.Close()
+	var keys []string
+	for {
+		result, err := itr.Next()
+		assert.NoError(t, err, query)
+		if result == nil {
+			break
+		}
+		keys = append(keys, result.(*statedb.VersionedKV).Key)
+	}
+	assert.Equal(t, expectedKeys, keys, query)
+}
+
+func testQueryPlan(t *testing.T, vdb *versionedDB, query string, expectedIndex *indexDefinition, expectedSorted bool) {
+	q, err := parseQuery(query)
+	assert.NoError(t, err, query)
+	plan, err := vdb.planQuery("ns1", q)
+	assert.NoError(t, err, query)
+	assert.Equal(t, expectedIndex, plan.index, query)
+	assert.Equal(t, expectedSorted, plan.sorted, query)
+}
+
+func testPaginatedQueryKeys(t *testing.T, db statedb.VersionedDB, query string, pageSize int32, expectedPages [][]string) {
+	bookmark := ""
+	for i, expectedKeys := range expectedPages {
+		itr, err := db.ExecuteQueryWithPagination("ns1", query, bookmark, pageSize)
+		assert.NoError(t, err, query)
+		var keys []string
+		for {
+			result, err := itr.Next()
+			assert.NoError(t, err, query)
+			if result == nil {
+				break
+			}
+			keys = append(keys, result.(*statedb.VersionedKV).Key)
+		}
+		assert.Equal(t, expectedKeys, keys, query)
+		bookmark = itr.GetBookmarkAndClose()
+		if i == len(expectedPages)-1 {
+			assert.Empty(t, bookmark, query)
+		} else {
+			assert.NotEmpty(t, bookmark, query)
+		}
+	}
+}
+
+func assertIndexEntries(t *testing.T, vdb *versionedDB, ns, indexName string, expectedEntries int) {
+	prefix := constructIndexEntryKeyPrefix(ns, indexName)
+	itr := vdb.db.GetIterator(prefix, prefixEnd(prefix))
+	defer itr.Release()
+	entries := 0
+	for itr.Next() {
+		entries++
+	}
+	assert.Equal(t, expectedEntries, entries, indexName)
 }
 
 func TestGetStateMultipleKeys(t *testing.T) {
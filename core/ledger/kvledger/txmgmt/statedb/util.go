@@ -16,7 +16,15 @@ limitations under the License.
 
 package statedb
 
-import "github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+)
+
+// valueWithMetadataMarker is the first byte of an encoded value which carries the metadata
+// of the key. The encoding of a version never starts with this byte, which keeps the values
+// encoded by EncodeValue readable by DecodeValueAndMetadata
+const valueWithMetadataMarker = byte(0xff)
 
 //EncodeValue appends the value to the version, allows storage of version and value in binary form
 func EncodeValue(value []byte, version *version.Height) []byte {
@@ -33,3 +41,29 @@ func DecodeValue(encodedValue []byte) ([]byte, *version.Height) {
 	value := encodedValue[n:]
 	return value, height
 }
+
+//EncodeValueAndMetadata is similar to EncodeValue, and additionally prepends the metadata of the key.
+//If the metadata is nil, the encoding is the same as that of EncodeValue
+func EncodeValueAndMetadata(value []byte, metadata []byte, version *version.Height) []byte {
+	if metadata == nil {
+		return EncodeValue(value, version)
+	}
+	encodedValue := []byte{valueWithMetadataMarker}
+	encodedValue = append(encodedValue, proto.EncodeVarint(uint64(len(metadata)))...)
+	encodedValue = append(encodedValue, metadata...)
+	return append(encodedValue, EncodeValue(value, version)...)
+}
+
+//DecodeValueAndMetadata separates the version, the value and the metadata from a binary value
+//encoded by either EncodeValue or EncodeValueAndMetadata
+func DecodeValueAndMetadata(encodedValue []byte) ([]byte, []byte, *version.Height) {
+	if len(encodedValue) == 0 || encodedValue[0] != valueWithMetadataMarker {
+		value, height := DecodeValue(encodedValue)
+		return value, nil, height
+	}
+	metadataLen, n := proto.DecodeVarint(encodedValue[1:])
+	metadataStart := 1 + n
+	metadataEnd := metadataStart + int(metadataLen)
+	value, height := DecodeValue(encodedValue[metadataEnd:])
+	return value, encodedValue[metadataStart:metadataEnd], height
+}
@@ -52,3 +52,23 @@ func TestEncodeDecodeJSON(t *testing.T) {
 	testutil.AssertEquals(t, decodedVersion, version2)
 
 }
+
+// TestEncodeDecodeValueAndMetadata tests encoding and decoding a value along with the metadata of the key
+func TestEncodeDecodeValueAndMetadata(t *testing.T) {
+	value := []byte("value1")
+	metadata := []byte("metadata1")
+	version1 := version.NewHeight(1, 1)
+
+	decodedValue, decodedMetadata, decodedVersion := DecodeValueAndMetadata(EncodeValueAndMetadata(value, metadata, version1))
+	testutil.AssertEquals(t, decodedValue, value)
+	testutil.AssertEquals(t, decodedMetadata, metadata)
+	testutil.AssertEquals(t, decodedVersion, version1)
+
+	// a value without metadata is encoded as by EncodeValue
+	encodedValue := EncodeValueAndMetadata(value, nil, version1)
+	testutil.AssertEquals(t, encodedValue, EncodeValue(value, version1))
+	decodedValue, decodedMetadata, decodedVersion = DecodeValueAndMetadata(encodedValue)
+	testutil.AssertEquals(t, decodedValue, value)
+	testutil.AssertNil(t, decodedMetadata)
+	testutil.AssertEquals(t, decodedVersion, version1)
+}
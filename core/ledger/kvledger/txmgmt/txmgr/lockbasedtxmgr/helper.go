@@ -23,6 +23,7 @@ import (
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/txmgr"
 
 	commonledger "github.com/hyperledger/fabric/common/ledger"
+	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwsetutil"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
@@ -55,6 +56,26 @@ func (h *queryHelper) getState(ns string, key string) ([]byte, error) {
 	return val, nil
 }
 
+func (h *queryHelper) getStateMetadata(ns string, key string) (map[string][]byte, error) {
+	if err := h.checkDone(); err != nil {
+		return nil, err
+	}
+	versionedValue, err := h.txmgr.db.GetState(ns, key)
+	if err != nil {
+		return nil, err
+	}
+	var metadataBytes []byte
+	var ver *version.Height
+	if versionedValue != nil {
+		metadataBytes = versionedValue.Metadata
+		ver = versionedValue.Version
+	}
+	if h.rwsetBuilder != nil {
+		h.rwsetBuilder.AddToReadSet(ns, key, ver)
+	}
+	return util.DeserializeMetadata(metadataBytes)
+}
+
 func (h *queryHelper) getStateMultipleKeys(namespace string, keys []string) ([][]byte, error) {
 	if err := h.checkDone(); err != nil {
 		return nil, err
@@ -78,7 +99,20 @@ func (h *queryHelper) getStateRangeScanIterator(namespace string, startKey strin
 	if err := h.checkDone(); err != nil {
 		return nil, err
 	}
-	itr, err := newResultsItr(namespace, startKey, endKey, h.txmgr.db, h.rwsetBuilder,
+	itr, err := newResultsItr(namespace, startKey, endKey, 0, h.txmgr.db, h.rwsetBuilder,
+		ledgerconfig.IsQueryReadsHashingEnabled(), ledgerconfig.GetMaxDegreeQueryReadsHashing())
+	if err != nil {
+		return nil, err
+	}
+	h.itrs = append(h.itrs, itr)
+	return itr, nil
+}
+
+func (h *queryHelper) getStateRangeScanIteratorWithPagination(namespace string, startKey string, endKey string, pageSize int32) (ledger.QueryResultsIterator, error) {
+	if err := h.checkDone(); err != nil {
+		return nil, err
+	}
+	itr, err := newResultsItr(namespace, startKey, endKey, pageSize, h.txmgr.db, h.rwsetBuilder,
 		ledgerconfig.IsQueryReadsHashingEnabled(), ledgerconfig.GetMaxDegreeQueryReadsHashing())
 	if err != nil {
 		return nil, err
@@ -98,6 +132,17 @@ func (h *queryHelper) executeQuery(namespace, query string) (commonledger.Result
 	return &queryResultsItr{DBItr: dbItr, RWSetBuilder: h.rwsetBuilder}, nil
 }
 
+func (h *queryHelper) executeQueryWithPagination(namespace, query, bookmark string, pageSize int32) (ledger.QueryResultsIterator, error) {
+	if err := h.checkDone(); err != nil {
+		return nil, err
+	}
+	dbItr, err := h.txmgr.db.ExecuteQueryWithPagination(namespace, query, bookmark, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	return &queryResultsItr{DBItr: dbItr, RWSetBuilder: h.rwsetBuilder}, nil
+}
+
 func (h *queryHelper) getPrivateData(ns, coll, key string) ([]byte, error) {
 	if err := h.checkDone(); err != nil {
 		return nil, err
@@ -220,9 +265,17 @@ type resultsItr struct {
 	rangeQueryResultsHelper *rwsetutil.RangeQueryResultsHelper
 }
 
-func newResultsItr(ns string, startKey string, endKey string,
+// newResultsItr constructs a resultsItr over the given range. If the pageSize is greater than zero,
+// the iterator contains at most pageSize results
+func newResultsItr(ns string, startKey string, endKey string, pageSize int32,
 	db statedb.VersionedDB, rwsetBuilder *rwsetutil.RWSetBuilder, enableHashing bool, maxDegree uint32) (*resultsItr, error) {
-	dbItr, err := db.GetStateRangeScanIterator(ns, startKey, endKey)
+	var dbItr statedb.ResultsIterator
+	var err error
+	if pageSize > 0 {
+		dbItr, err = db.GetStateRangeScanIteratorWithPagination(ns, startKey, endKey, pageSize)
+	} else {
+		dbItr, err = db.GetStateRangeScanIterator(ns, startKey, endKey)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -291,6 +344,11 @@ func (itr *resultsItr) Close() {
 	itr.dbItr.Close()
 }
 
+// GetBookmarkAndClose implements method in interface ledger.QueryResultsIterator
+func (itr *resultsItr) GetBookmarkAndClose() string {
+	return getBookmarkAndClose(itr.dbItr)
+}
+
 type queryResultsItr struct {
 	DBItr        statedb.ResultsIterator
 	RWSetBuilder *rwsetutil.RWSetBuilder
@@ -320,6 +378,20 @@ func (itr *queryResultsItr) Close() {
 	itr.DBItr.Close()
 }
 
+// GetBookmarkAndClose implements method in interface ledger.QueryResultsIterator
+func (itr *queryResultsItr) GetBookmarkAndClose() string {
+	return getBookmarkAndClose(itr.DBItr)
+}
+
+// getBookmarkAndClose returns the bookmark of the given db iterator, if it is a paginated one, and closes it
+func getBookmarkAndClose(dbItr statedb.ResultsIterator) string {
+	if queryResultsItr, ok := dbItr.(statedb.QueryResultsIterator); ok {
+		return queryResultsItr.GetBookmarkAndClose()
+	}
+	dbItr.Close()
+	return ""
+}
+
 func decomposeVersionedValue(versionedValue *statedb.VersionedValue) ([]byte, *version.Height) {
 	var value []byte
 	var ver *version.Height
@@ -29,7 +29,7 @@ import (
 
 func TestPvtdataResultsItr(t *testing.T) {
 	testEnv := testEnvs[0]
-	testEnv.init(t, "test-pvtdata-range-queries")
+	testEnv.init(t, "test-pvtdata-range-queries", nil)
 	defer testEnv.cleanup()
 
 	txMgr := testEnv.getTxMgr().(*LockBasedTxMgr)
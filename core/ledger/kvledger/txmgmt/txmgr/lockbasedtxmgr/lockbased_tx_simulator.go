@@ -29,16 +29,17 @@ import (
 // LockBasedTxSimulator is a transaction simulator used in `LockBasedTxMgr`
 type lockBasedTxSimulator struct {
 	lockBasedQueryExecutor
-	rwsetBuilder            *rwsetutil.RWSetBuilder
-	writePerformed          bool
-	pvtdataQueriesPerformed bool
+	rwsetBuilder              *rwsetutil.RWSetBuilder
+	writePerformed            bool
+	pvtdataQueriesPerformed   bool
+	paginatedQueriesPerformed bool
 }
 
 func newLockBasedTxSimulator(txmgr *LockBasedTxMgr, txid string) (*lockBasedTxSimulator, error) {
 	rwsetBuilder := rwsetutil.NewRWSetBuilder()
 	helper := &queryHelper{txmgr: txmgr, rwsetBuilder: rwsetBuilder}
 	logger.Debugf("constructing new tx simulator txid = [%s]", txid)
-	return &lockBasedTxSimulator{lockBasedQueryExecutor{helper, txid}, rwsetBuilder, false, false}, nil
+	return &lockBasedTxSimulator{lockBasedQueryExecutor{helper, txid}, rwsetBuilder, false, false, false}, nil
 }
 
 // GetState implements method in interface `ledger.TxSimulator`
@@ -66,6 +67,26 @@ func (s *lockBasedTxSimulator) DeleteState(ns string, key string) error {
 	return s.SetState(ns, key, nil)
 }
 
+// SetStateMetadata implements method in interface `ledger.TxSimulator`
+func (s *lockBasedTxSimulator) SetStateMetadata(namespace, key string, metadata map[string][]byte) error {
+	if err := s.helper.checkDone(); err != nil {
+		return err
+	}
+	if err := s.checkBeforeWrite(); err != nil {
+		return err
+	}
+	if err := s.helper.txmgr.db.ValidateKey(key); err != nil {
+		return err
+	}
+	s.rwsetBuilder.AddToMetadataWriteSet(namespace, key, metadata)
+	return nil
+}
+
+// DeleteStateMetadata implements method in interface `ledger.TxSimulator`
+func (s *lockBasedTxSimulator) DeleteStateMetadata(namespace, key string) error {
+	return s.SetStateMetadata(namespace, key, nil)
+}
+
 // SetStateMultipleKeys implements method in interface `ledger.TxSimulator`
 func (s *lockBasedTxSimulator) SetStateMultipleKeys(namespace string, kvs map[string][]byte) error {
 	for k, v := range kvs {
@@ -122,6 +143,22 @@ func (s *lockBasedTxSimulator) ExecuteQueryOnPrivateData(namespace, collection,
 	return s.lockBasedQueryExecutor.ExecuteQueryOnPrivateData(namespace, collection, query)
 }
 
+// GetStateRangeScanIteratorWithPagination implements method in interface `ledger.TxSimulator`
+func (s *lockBasedTxSimulator) GetStateRangeScanIteratorWithPagination(namespace string, startKey string, endKey string, pageSize int32) (ledger.QueryResultsIterator, error) {
+	if err := s.checkBeforePaginatedQueries(); err != nil {
+		return nil, err
+	}
+	return s.lockBasedQueryExecutor.GetStateRangeScanIteratorWithPagination(namespace, startKey, endKey, pageSize)
+}
+
+// ExecuteQueryWithPagination implements method in interface `ledger.TxSimulator`
+func (s *lockBasedTxSimulator) ExecuteQueryWithPagination(namespace, query, bookmark string, pageSize int32) (ledger.QueryResultsIterator, error) {
+	if err := s.checkBeforePaginatedQueries(); err != nil {
+		return nil, err
+	}
+	return s.lockBasedQueryExecutor.ExecuteQueryWithPagination(namespace, query, bookmark, pageSize)
+}
+
 // GetTxSimulationResults implements method in interface `ledger.TxSimulator`
 func (s *lockBasedTxSimulator) GetTxSimulationResults() (*ledger.TxSimulationResults, error) {
 	logger.Debugf("Simulation completed, getting simulation results")
@@ -143,6 +180,11 @@ func (s *lockBasedTxSimulator) checkBeforeWrite() error {
 			Msg: fmt.Sprintf("Tx [%s]: Transaction has already performed queries on pvt data. Writes are not allowed", s.txid),
 		}
 	}
+	if s.paginatedQueriesPerformed {
+		return &txmgr.ErrUnsupportedTransaction{
+			Msg: fmt.Sprintf("Tx [%s]: Transaction has already performed a paginated query. Writes are not allowed", s.txid),
+		}
+	}
 	s.writePerformed = true
 	return nil
 }
@@ -156,3 +198,13 @@ func (s *lockBasedTxSimulator) checkBeforePvtdataQueries() error {
 	s.pvtdataQueriesPerformed = true
 	return nil
 }
+
+func (s *lockBasedTxSimulator) checkBeforePaginatedQueries() error {
+	if s.writePerformed {
+		return &txmgr.ErrUnsupportedTransaction{
+			Msg: fmt.Sprintf("Tx [%s]: Paginated queries are supported only in a read-only transaction", s.txid),
+		}
+	}
+	s.paginatedQueriesPerformed = true
+	return nil
+}
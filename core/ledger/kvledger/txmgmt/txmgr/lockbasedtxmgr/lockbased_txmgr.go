@@ -6,15 +6,23 @@ SPDX-License-Identifier: Apache-2.0
 package lockbasedtxmgr
 
 import (
+	"sort"
 	"sync"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/bookkeeping"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/pvtstatepurgemgmt"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/validator"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/validator/valimpl"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/core/ledger/pvtdatapolicy"
+	"github.com/hyperledger/fabric/core/ledger/util"
 	"github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/ledger/rwset/kvrwset"
 )
 
 var logger = flogging.MustGetLogger("lockbasedtxmgr")
@@ -22,17 +30,24 @@ var logger = flogging.MustGetLogger("lockbasedtxmgr")
 // LockBasedTxMgr a simple implementation of interface `txmgmt.TxMgr`.
 // This implementation uses a read-write lock to prevent conflicts between transaction simulation and committing
 type LockBasedTxMgr struct {
-	db           privacyenabledstate.DB
-	validator    validator.Validator
-	batch        *privacyenabledstate.UpdateBatch
-	currentBlock *common.Block
-	commitRWLock sync.RWMutex
+	ledgerid        string
+	db              privacyenabledstate.DB
+	pvtdataPurgeMgr pvtstatepurgemgmt.PurgeMgr
+	validator       validator.Validator
+	batch           *privacyenabledstate.UpdateBatch
+	currentBlock    *common.Block
+	stateListeners  []ledger.StateListener
+	// invokedListeners are the state listeners that have been passed the updates of the current block
+	invokedListeners []ledger.StateListener
+	commitRWLock     sync.RWMutex
 }
 
 // NewLockBasedTxMgr constructs a new instance of NewLockBasedTxMgr
-func NewLockBasedTxMgr(db privacyenabledstate.DB) *LockBasedTxMgr {
+func NewLockBasedTxMgr(ledgerid string, db privacyenabledstate.DB, btlPolicy pvtdatapolicy.BTLPolicy,
+	bookkeepingProvider bookkeeping.Provider, stateListeners []ledger.StateListener) *LockBasedTxMgr {
 	db.Open()
-	txmgr := &LockBasedTxMgr{db: db}
+	txmgr := &LockBasedTxMgr{ledgerid: ledgerid, db: db, stateListeners: stateListeners}
+	txmgr.pvtdataPurgeMgr = pvtstatepurgemgmt.InstantiatePurgeMgr(ledgerid, db, btlPolicy, bookkeepingProvider)
 	txmgr.validator = valimpl.NewStatebasedValidator(txmgr, db)
 	return txmgr
 }
@@ -62,17 +77,89 @@ func (txmgr *LockBasedTxMgr) NewTxSimulator(txid string) (ledger.TxSimulator, er
 }
 
 // ValidateAndPrepare implements method in interface `txmgmt.TxMgr`
-func (txmgr *LockBasedTxMgr) ValidateAndPrepare(blockAndPvtdata *ledger.BlockAndPvtData, doMVCCValidation bool) error {
+func (txmgr *LockBasedTxMgr) ValidateAndPrepare(blockAndPvtdata *ledger.BlockAndPvtData, doMVCCValidation bool) ([]byte, error) {
 	block := blockAndPvtdata.Block
 	logger.Debugf("Validating new block with num trans = [%d]", len(block.Data.Data))
 	batch, err := txmgr.validator.ValidateAndPrepareBatch(blockAndPvtdata, doMVCCValidation)
 	if err != nil {
 		txmgr.clearCache()
-		return err
+		return nil, err
+	}
+	if err = txmgr.pvtdataPurgeMgr.DeleteExpiredAndUpdateBookkeeping(block.Header.Number, batch); err != nil {
+		txmgr.clearCache()
+		return nil, err
+	}
+	if err = txmgr.invokeNamespaceListeners(block.Header.Number, batch); err != nil {
+		txmgr.clearCache()
+		return nil, err
 	}
 	txmgr.currentBlock = block
 	txmgr.batch = batch
-	return err
+	return deterministicBytesForPubAndHashUpdates(batch), nil
+}
+
+// invokeNamespaceListeners passes the public and pvt updates of the block to the state listeners
+// which are interested in the updated namespaces
+func (txmgr *LockBasedTxMgr) invokeNamespaceListeners(blockNum uint64, batch *privacyenabledstate.UpdateBatch) error {
+	txmgr.invokedListeners = nil
+	for _, listener := range txmgr.stateListeners {
+		stateUpdates := ledger.StateUpdates{}
+		for _, ns := range listener.InterestedInNamespaces() {
+			kvStateUpdates := &ledger.KVStateUpdates{PublicUpdates: sortedKVWrites(batch.PubUpdates.GetUpdates(ns))}
+			if nsPvtBatch, ok := batch.PvtUpdates.UpdateMap[ns]; ok {
+				for _, coll := range nsPvtBatch.GetCollectionNames() {
+					if kvWrites := sortedKVWrites(nsPvtBatch.GetUpdates(coll)); len(kvWrites) > 0 {
+						if kvStateUpdates.PvtUpdates == nil {
+							kvStateUpdates.PvtUpdates = make(map[string][]*kvrwset.KVWrite)
+						}
+						kvStateUpdates.PvtUpdates[coll] = kvWrites
+					}
+				}
+			}
+			if len(kvStateUpdates.PublicUpdates) == 0 && len(kvStateUpdates.PvtUpdates) == 0 {
+				continue
+			}
+			stateUpdates[ns] = kvStateUpdates
+		}
+		if len(stateUpdates) == 0 {
+			continue
+		}
+		trigger := &ledger.StateUpdateTrigger{LedgerID: txmgr.ledgerid, StateUpdates: stateUpdates, CommittingBlockNum: blockNum}
+		if err := listener.HandleStateUpdates(trigger); err != nil {
+			txmgr.invokedListeners = nil
+			return err
+		}
+		txmgr.invokedListeners = append(txmgr.invokedListeners, listener)
+		logger.Debugf("Invoked listener for state changes in namespaces %v", listener.InterestedInNamespaces())
+	}
+	return nil
+}
+
+// sortedKVWrites converts the updates of a namespace (or a collection) into writes sorted by key
+func sortedKVWrites(updates map[string]*statedb.VersionedValue) []*kvrwset.KVWrite {
+	if len(updates) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(updates))
+	for key := range updates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	kvWrites := make([]*kvrwset.KVWrite, 0, len(keys))
+	for _, key := range keys {
+		vv := updates[key]
+		kvWrites = append(kvWrites, &kvrwset.KVWrite{Key: key, IsDelete: vv.Value == nil, Value: vv.Value})
+	}
+	return kvWrites
+}
+
+// notifyCommitDone informs the state listeners that were passed the updates of the block that the
+// updates have been committed to the state
+func (txmgr *LockBasedTxMgr) notifyCommitDone() {
+	for _, listener := range txmgr.invokedListeners {
+		listener.StateCommitDone(txmgr.ledgerid)
+	}
+	txmgr.invokedListeners = nil
 }
 
 // Shutdown implements method in interface `txmgmt.TxMgr`
@@ -87,6 +174,15 @@ func (txmgr *LockBasedTxMgr) Commit() error {
 	// be cleared.
 	defer txmgr.clearCache()
 
+	if err := txmgr.commit(); err != nil {
+		return err
+	}
+	// the state listeners are notified once the write lock is released, so that these can query the committed state
+	txmgr.notifyCommitDone()
+	return nil
+}
+
+func (txmgr *LockBasedTxMgr) commit() error {
 	logger.Debugf("Committing updates to state database")
 	txmgr.commitRWLock.Lock()
 	defer txmgr.commitRWLock.Unlock()
@@ -99,14 +195,96 @@ func (txmgr *LockBasedTxMgr) Commit() error {
 		version.NewHeight(txmgr.currentBlock.Header.Number, uint64(len(txmgr.currentBlock.Data.Data)-1))); err != nil {
 		return err
 	}
+	// only while the write lock is held, the expired keys are removed from the bookkeeping so that a new
+	// simulation does not get a chance to observe the purged data as present in the state
+	if err := txmgr.pvtdataPurgeMgr.BlockCommitDone(); err != nil {
+		return err
+	}
 	logger.Debugf("Updates committed to state database")
+	return nil
+}
 
+// RemoveStaleAndCommitPvtDataOfOldBlocks implements method in interface `txmgmt.TxMgr`
+// The pvt data of old blocks is committed to the state only if the corresponding key hashes
+// are still at the version written by the transaction that produced the pvt data, i.e., the
+// writes that have been overwritten by later transactions are treated as stale and ignored.
+// The caller is expected to not invoke this function concurrently with the commit of a block
+func (txmgr *LockBasedTxMgr) RemoveStaleAndCommitPvtDataOfOldBlocks(blocksPvtData []*ledger.BlockPvtData) error {
+	logger.Debugf("Committing pvt data of %d old blocks to state database", len(blocksPvtData))
+	batch := privacyenabledstate.NewUpdateBatch()
+	for _, blockPvtData := range blocksPvtData {
+		for _, txPvtData := range blockPvtData.WriteSets {
+			if err := txmgr.addNonStalePvtWrites(batch.PvtUpdates, blockPvtData.BlockNum, txPvtData); err != nil {
+				return err
+			}
+		}
+	}
+	if batch.PvtUpdates.IsEmpty() {
+		logger.Debugf("No non-stale pvt data of old blocks to commit")
+		return nil
+	}
+	if err := txmgr.pvtdataPurgeMgr.UpdateBookkeepingForPvtDataOfOldBlocks(batch.PvtUpdates); err != nil {
+		return err
+	}
+	// the savepoint of the state database remains unchanged as no new block is committed
+	savepoint, err := txmgr.GetLastSavepoint()
+	if err != nil {
+		return err
+	}
+	// the write lock is acquired only for updating the state, as the btl policy used by
+	// the purge manager above looks up the collection configurations via a query executor
+	txmgr.commitRWLock.Lock()
+	defer txmgr.commitRWLock.Unlock()
+	if err := txmgr.db.ApplyPrivacyAwareUpdates(batch, savepoint); err != nil {
+		return err
+	}
+	logger.Debugf("Pvt data of old blocks committed to state database")
+	return nil
+}
+
+// addNonStalePvtWrites adds to the batch the pvt writes of a transaction for which the
+// committed key hashes are still at the version of the transaction
+func (txmgr *LockBasedTxMgr) addNonStalePvtWrites(pvtUpdates *privacyenabledstate.PvtUpdateBatch, blockNum uint64, txPvtData *ledger.TxPvtData) error {
+	if txPvtData.WriteSet == nil {
+		return nil
+	}
+	ver := version.NewHeight(blockNum, txPvtData.SeqInBlock)
+	for _, nsPvtRwset := range txPvtData.WriteSet.NsPvtRwset {
+		ns := nsPvtRwset.Namespace
+		for _, collPvtRwset := range nsPvtRwset.CollectionPvtRwset {
+			coll := collPvtRwset.CollectionName
+			kvRWSet := &kvrwset.KVRWSet{}
+			if err := proto.Unmarshal(collPvtRwset.Rwset, kvRWSet); err != nil {
+				return err
+			}
+			for _, write := range kvRWSet.Writes {
+				committedVersion, err := txmgr.db.GetKeyHashVersion(ns, coll, util.ComputeStringHash(write.Key))
+				if err != nil {
+					return err
+				}
+				switch {
+				case version.AreSame(committedVersion, ver):
+					if write.IsDelete {
+						pvtUpdates.Delete(ns, coll, write.Key, ver)
+					} else {
+						pvtUpdates.Put(ns, coll, write.Key, write.Value, ver)
+					}
+				case committedVersion == nil && write.IsDelete:
+					// the key hash has been deleted; remove the pvt key that may have been left behind
+					pvtUpdates.Delete(ns, coll, write.Key, ver)
+				default:
+					logger.Debugf("Skipping the stale write of key [%s] in ns [%s], coll [%s] at version %v", write.Key, ns, coll, ver)
+				}
+			}
+		}
+	}
 	return nil
 }
 
 // Rollback implements method in interface `txmgmt.TxMgr`
 func (txmgr *LockBasedTxMgr) Rollback() {
 	txmgr.batch = nil
+	txmgr.invokedListeners = nil
 	// If statedb implementation needed bulk read optimization, cache might have been populated by
 	// ValidateAndPrepareBatch(). As the block commit is rollbacked, populated cache needs to
 	// be cleared now.
@@ -136,7 +314,7 @@ func (txmgr *LockBasedTxMgr) ShouldRecover(lastAvailableBlock uint64) (bool, uin
 func (txmgr *LockBasedTxMgr) CommitLostBlock(blockAndPvtdata *ledger.BlockAndPvtData) error {
 	block := blockAndPvtdata.Block
 	logger.Debugf("Constructing updateSet for the block %d", block.Header.Number)
-	if err := txmgr.ValidateAndPrepare(blockAndPvtdata, false); err != nil {
+	if _, err := txmgr.ValidateAndPrepare(blockAndPvtdata, false); err != nil {
 		return err
 	}
 	logger.Debugf("Committing block %d to state database", block.Header.Number)
@@ -0,0 +1,142 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package lockbasedtxmgr
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/bookkeeping"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate"
+	btltestutil "github.com/hyperledger/fabric/core/ledger/pvtdatapolicy/testutil"
+	"github.com/hyperledger/fabric/protos/ledger/rwset/kvrwset"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateListener(t *testing.T) {
+	testLedgerID := "teststatelistener"
+	ml1 := &mockStateListener{namespace: "ns1"}
+	ml2 := &mockStateListener{namespace: "ns2"}
+	ml3 := &mockStateListener{namespace: "ns3"}
+
+	testDBEnv := &privacyenabledstate.LevelDBCommonStorageTestEnv{}
+	testDBEnv.Init(t)
+	defer testDBEnv.Cleanup()
+	testBookkeepingEnv := bookkeeping.NewTestEnv(t)
+	defer testBookkeepingEnv.Cleanup()
+	txMgr := NewLockBasedTxMgr(testLedgerID, testDBEnv.GetDBHandle(testLedgerID), btltestutil.SampleBTLPolicy(nil),
+		testBookkeepingEnv.TestProvider, []ledger.StateListener{ml1, ml2, ml3})
+	defer txMgr.Shutdown()
+	txMgrHelper := newTxMgrTestHelper(t, txMgr)
+
+	// the listeners receive the sorted public and pvt writes of the namespaces they are interested in
+	s1, _ := txMgr.NewTxSimulator("test_tx1")
+	s1.SetState("ns1", "key2", []byte("value2"))
+	s1.SetState("ns1", "key1", []byte("value1"))
+	s1.SetPrivateData("ns1", "coll1", "key5", []byte("value5"))
+	s1.SetState("ns2", "key3", []byte("value3"))
+	s1.SetState("ns4", "key4", []byte("value4"))
+	s1.Done()
+	txRWSet1, _ := s1.GetTxSimulationResults()
+	rwSetBytes, _ := proto.Marshal(txRWSet1.PubSimulationResults)
+	block := txMgrHelper.bg.NextBlock([][]byte{rwSetBytes})
+	blockAndPvtData := &ledger.BlockAndPvtData{
+		Block:        block,
+		BlockPvtData: map[uint64]*ledger.TxPvtData{0: {SeqInBlock: 0, WriteSet: txRWSet1.PvtSimulationResults}},
+	}
+	_, err := txMgr.ValidateAndPrepare(blockAndPvtData, true)
+	assert.NoError(t, err)
+	assertReceivedUpdates(t, ml1, testLedgerID, block.Header.Number, &ledger.KVStateUpdates{
+		PublicUpdates: []*kvrwset.KVWrite{
+			{Key: "key1", Value: []byte("value1")},
+			{Key: "key2", Value: []byte("value2")},
+		},
+		PvtUpdates: map[string][]*kvrwset.KVWrite{
+			"coll1": {{Key: "key5", Value: []byte("value5")}},
+		},
+	})
+	assertReceivedUpdates(t, ml2, testLedgerID, block.Header.Number, &ledger.KVStateUpdates{
+		PublicUpdates: []*kvrwset.KVWrite{{Key: "key3", Value: []byte("value3")}},
+	})
+	assert.False(t, ml3.invoked)
+	// the listeners are notified once the updates are committed
+	assert.False(t, ml1.commitDone)
+	assert.NoError(t, txMgr.Commit())
+	assert.True(t, ml1.commitDone)
+	assert.True(t, ml2.commitDone)
+	assert.False(t, ml3.commitDone)
+
+	// deletes are passed as such, and the listeners are not invoked if their namespaces are not updated
+	ml1.reset()
+	ml2.reset()
+	s2, _ := txMgr.NewTxSimulator("test_tx2")
+	s2.DeleteState("ns1", "key1")
+	s2.Done()
+	txRWSet2, _ := s2.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet2.PubSimulationResults)
+	assertReceivedUpdates(t, ml1, testLedgerID, block.Header.Number+1, &ledger.KVStateUpdates{
+		PublicUpdates: []*kvrwset.KVWrite{{Key: "key1", IsDelete: true}},
+	})
+	assert.True(t, ml1.commitDone)
+	assert.False(t, ml2.invoked)
+	assert.False(t, ml2.commitDone)
+	assert.False(t, ml3.invoked)
+
+	// an error returned by a listener fails the block, and no listener is notified of the commit
+	ml1.reset()
+	ml1.err = errors.New("listener error")
+	s3, _ := txMgr.NewTxSimulator("test_tx3")
+	s3.SetState("ns1", "key1", []byte("value1"))
+	s3.Done()
+	txRWSet3, _ := s3.GetTxSimulationResults()
+	rwSetBytes, _ = proto.Marshal(txRWSet3.PubSimulationResults)
+	block = txMgrHelper.bg.NextBlock([][]byte{rwSetBytes})
+	_, err = txMgr.ValidateAndPrepare(&ledger.BlockAndPvtData{Block: block}, true)
+	testutil.AssertError(t, err, "listener error")
+	txMgr.Rollback()
+	assert.False(t, ml1.commitDone)
+}
+
+func assertReceivedUpdates(t *testing.T, l *mockStateListener, ledgerID string, blockNum uint64, expectedUpdates *ledger.KVStateUpdates) {
+	assert.True(t, l.invoked)
+	assert.Equal(t, &ledger.StateUpdateTrigger{
+		LedgerID:           ledgerID,
+		StateUpdates:       ledger.StateUpdates{l.namespace: expectedUpdates},
+		CommittingBlockNum: blockNum,
+	}, l.receivedTrigger)
+}
+
+type mockStateListener struct {
+	namespace       string
+	err             error
+	invoked         bool
+	receivedTrigger *ledger.StateUpdateTrigger
+	commitDone      bool
+}
+
+func (l *mockStateListener) InterestedInNamespaces() []string {
+	return []string{l.namespace}
+}
+
+func (l *mockStateListener) HandleStateUpdates(trigger *ledger.StateUpdateTrigger) error {
+	l.invoked = true
+	l.receivedTrigger = trigger
+	return l.err
+}
+
+func (l *mockStateListener) StateCommitDone(ledgerID string) {
+	l.commitDone = true
+}
+
+func (l *mockStateListener) reset() {
+	l.invoked = false
+	l.receivedTrigger = nil
+	l.commitDone = false
+}
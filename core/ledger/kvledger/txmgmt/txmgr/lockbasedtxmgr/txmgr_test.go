@@ -12,14 +12,18 @@ import (
 
 	"os"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/common/ledger/testutil"
+	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/txmgr"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
 	ledgertestutil "github.com/hyperledger/fabric/core/ledger/testutil"
 	"github.com/hyperledger/fabric/core/ledger/util"
 	"github.com/hyperledger/fabric/protos/ledger/queryresult"
+	"github.com/hyperledger/fabric/protos/ledger/rwset"
+	"github.com/hyperledger/fabric/protos/ledger/rwset/kvrwset"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
@@ -40,7 +44,7 @@ func TestTxSimulatorWithNoExistingData(t *testing.T) {
 	for _, testEnv := range testEnvs {
 		t.Logf("Running test for TestEnv = %s", testEnv.getName())
 		testLedgerID := "testtxsimulatorwithnoexistingdata"
-		testEnv.init(t, testLedgerID)
+		testEnv.init(t, testLedgerID, nil)
 		testTxSimulatorWithNoExistingData(t, testEnv)
 		testEnv.cleanup()
 	}
@@ -70,7 +74,7 @@ func TestTxSimulatorWithExistingData(t *testing.T) {
 	for _, testEnv := range testEnvs {
 		t.Run(testEnv.getName(), func(t *testing.T) {
 			testLedgerID := "testtxsimulatorwithexistingdata"
-			testEnv.init(t, testLedgerID)
+			testEnv.init(t, testLedgerID, nil)
 			testTxSimulatorWithExistingData(t, testEnv)
 			testEnv.cleanup()
 		})
@@ -123,7 +127,7 @@ func TestTxValidation(t *testing.T) {
 	for _, testEnv := range testEnvs {
 		t.Logf("Running test for TestEnv = %s", testEnv.getName())
 		testLedgerID := "testtxvalidation"
-		testEnv.init(t, testLedgerID)
+		testEnv.init(t, testLedgerID, nil)
 		testTxValidation(t, testEnv)
 		testEnv.cleanup()
 	}
@@ -210,7 +214,7 @@ func TestTxPhantomValidation(t *testing.T) {
 	for _, testEnv := range testEnvs {
 		t.Logf("Running test for TestEnv = %s", testEnv.getName())
 		testLedgerID := "testtxphantomvalidation"
-		testEnv.init(t, testLedgerID)
+		testEnv.init(t, testLedgerID, nil)
 		testTxPhantomValidation(t, testEnv)
 		testEnv.cleanup()
 	}
@@ -281,27 +285,27 @@ func TestIterator(t *testing.T) {
 		t.Logf("Running test for TestEnv = %s", testEnv.getName())
 
 		testLedgerID := "testiterator.1"
-		testEnv.init(t, testLedgerID)
+		testEnv.init(t, testLedgerID, nil)
 		testIterator(t, testEnv, 10, 2, 7)
 		testEnv.cleanup()
 
 		testLedgerID = "testiterator.2"
-		testEnv.init(t, testLedgerID)
+		testEnv.init(t, testLedgerID, nil)
 		testIterator(t, testEnv, 10, 1, 11)
 		testEnv.cleanup()
 
 		testLedgerID = "testiterator.3"
-		testEnv.init(t, testLedgerID)
+		testEnv.init(t, testLedgerID, nil)
 		testIterator(t, testEnv, 10, 0, 0)
 		testEnv.cleanup()
 
 		testLedgerID = "testiterator.4"
-		testEnv.init(t, testLedgerID)
+		testEnv.init(t, testLedgerID, nil)
 		testIterator(t, testEnv, 10, 5, 0)
 		testEnv.cleanup()
 
 		testLedgerID = "testiterator.5"
-		testEnv.init(t, testLedgerID)
+		testEnv.init(t, testLedgerID, nil)
 		testIterator(t, testEnv, 10, 0, 5)
 		testEnv.cleanup()
 	}
@@ -369,7 +373,7 @@ func TestIteratorWithDeletes(t *testing.T) {
 	for _, testEnv := range testEnvs {
 		t.Logf("Running test for TestEnv = %s", testEnv.getName())
 		testLedgerID := "testiteratorwithdeletes"
-		testEnv.init(t, testLedgerID)
+		testEnv.init(t, testLedgerID, nil)
 		testIteratorWithDeletes(t, testEnv)
 		testEnv.cleanup()
 	}
@@ -411,7 +415,7 @@ func TestTxValidationWithItr(t *testing.T) {
 	for _, testEnv := range testEnvs {
 		t.Logf("Running test for TestEnv = %s", testEnv.getName())
 		testLedgerID := "testtxvalidationwithitr"
-		testEnv.init(t, testLedgerID)
+		testEnv.init(t, testLedgerID, nil)
 		testTxValidationWithItr(t, testEnv)
 		testEnv.cleanup()
 	}
@@ -476,7 +480,7 @@ func TestGetSetMultipeKeys(t *testing.T) {
 	for _, testEnv := range testEnvs {
 		t.Logf("Running test for TestEnv = %s", testEnv.getName())
 		testLedgerID := "testgetsetmultipekeys"
-		testEnv.init(t, testLedgerID)
+		testEnv.init(t, testLedgerID, nil)
 		testGetSetMultipeKeys(t, testEnv)
 		testEnv.cleanup()
 	}
@@ -539,7 +543,7 @@ func TestExecuteQuery(t *testing.T) {
 		if testEnv.getName() == couchDBtestEnvName {
 			t.Logf("Running test for TestEnv = %s", testEnv.getName())
 			testLedgerID := "testexecutequery"
-			testEnv.init(t, testLedgerID)
+			testEnv.init(t, testLedgerID, nil)
 			testExecuteQuery(t, testEnv)
 			testEnv.cleanup()
 		}
@@ -611,7 +615,7 @@ func TestValidateKey(t *testing.T) {
 	dummyValue := []byte("dummyValue")
 	for _, testEnv := range testEnvs {
 		testLedgerID := "test.validate.key"
-		testEnv.init(t, testLedgerID)
+		testEnv.init(t, testLedgerID, nil)
 		txSimulator, _ := testEnv.getTxMgr().NewTxSimulator("test_tx1")
 		err := txSimulator.SetState("ns1", nonUTF8Key, dummyValue)
 		if testEnv.getName() == levelDBtestEnvName {
@@ -628,7 +632,7 @@ func TestValidateKey(t *testing.T) {
 // is perfromed - queries on private data are supported in a read-only tran
 func TestTxSimulatorUnsupportedTx(t *testing.T) {
 	testEnv := testEnvs[0]
-	testEnv.init(t, "TestTxSimulatorUnsupportedTxQueries")
+	testEnv.init(t, "TestTxSimulatorUnsupportedTxQueries", nil)
 	defer testEnv.cleanup()
 	txMgr := testEnv.getTxMgr()
 
@@ -647,9 +651,165 @@ func TestTxSimulatorUnsupportedTx(t *testing.T) {
 	testutil.AssertEquals(t, ok, true)
 }
 
+// TestTxSimulatorUnsupportedPaginatedQueries verifies that the paginated queries are supported only in a read-only transaction
+func TestTxSimulatorUnsupportedPaginatedQueries(t *testing.T) {
+	testEnv := testEnvs[0]
+	testEnv.init(t, "TestTxSimulatorUnsupportedPaginatedQueries", nil)
+	defer testEnv.cleanup()
+	txMgr := testEnv.getTxMgr()
+
+	simulator, _ := txMgr.NewTxSimulator("txid1")
+	err := simulator.SetState("ns", "key", []byte("value"))
+	testutil.AssertNoError(t, err, "")
+	_, err = simulator.GetStateRangeScanIteratorWithPagination("ns", "startKey", "endKey", 2)
+	_, ok := err.(*txmgr.ErrUnsupportedTransaction)
+	testutil.AssertEquals(t, ok, true)
+	_, err = simulator.ExecuteQueryWithPagination("ns", `{"selector":{"owner":"tom"}}`, "", 2)
+	_, ok = err.(*txmgr.ErrUnsupportedTransaction)
+	testutil.AssertEquals(t, ok, true)
+
+	simulator, _ = txMgr.NewTxSimulator("txid2")
+	itr, err := simulator.GetStateRangeScanIteratorWithPagination("ns", "startKey", "endKey", 2)
+	testutil.AssertNoError(t, err, "")
+	itr.Close()
+	err = simulator.SetState("ns", "key", []byte("value"))
+	_, ok = err.(*txmgr.ErrUnsupportedTransaction)
+	testutil.AssertEquals(t, ok, true)
+	simulator.Done()
+}
+
+// TestPaginatedRangeQuery verifies that a range can be iterated over in pages by
+// using the bookmark of each page as the start key of the next one
+func TestPaginatedRangeQuery(t *testing.T) {
+	for _, testEnv := range testEnvs {
+		t.Logf("Running test for TestEnv = %s", testEnv.getName())
+		testLedgerID := "testpaginatedrangequery"
+		testEnv.init(t, testLedgerID, nil)
+		testPaginatedRangeQuery(t, testEnv)
+		testEnv.cleanup()
+	}
+}
+
+func testPaginatedRangeQuery(t *testing.T, env testEnv) {
+	cID := "cid"
+	txMgr := env.getTxMgr()
+	txMgrHelper := newTxMgrTestHelper(t, txMgr)
+	s, _ := txMgr.NewTxSimulator("test_tx1")
+	for i := 1; i <= 5; i++ {
+		s.SetState(cID, createTestKey(i), createTestValue(i))
+	}
+	s.Done()
+	txRWSet, _ := s.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet.PubSimulationResults)
+
+	queryExecuter, _ := txMgr.NewQueryExecutor("test_tx2")
+	defer queryExecuter.Done()
+	var pages [][]string
+	startKey := ""
+	for {
+		itr, err := queryExecuter.GetStateRangeScanIteratorWithPagination(cID, startKey, "", 2)
+		testutil.AssertNoError(t, err, "")
+		var keys []string
+		for {
+			kv, err := itr.Next()
+			testutil.AssertNoError(t, err, "")
+			if kv == nil {
+				break
+			}
+			keys = append(keys, kv.(*queryresult.KV).Key)
+		}
+		pages = append(pages, keys)
+		if startKey = itr.GetBookmarkAndClose(); startKey == "" {
+			break
+		}
+	}
+	testutil.AssertEquals(t, pages, [][]string{
+		{createTestKey(1), createTestKey(2)},
+		{createTestKey(3), createTestKey(4)},
+		{createTestKey(5)},
+	})
+}
+
+// TestStateMetadata verifies that the metadata of a key is retained across the updates to the value of the key
+// and that the value of a key is retained across the updates to the metadata of the key
+func TestStateMetadata(t *testing.T) {
+	for _, testEnv := range testEnvs {
+		t.Logf("Running test for TestEnv = %s", testEnv.getName())
+		testLedgerID := "teststatemetadata"
+		testEnv.init(t, testLedgerID, nil)
+		testStateMetadata(t, testEnv)
+		testEnv.cleanup()
+	}
+}
+
+func testStateMetadata(t *testing.T, env testEnv) {
+	cID := "cid"
+	txMgr := env.getTxMgr()
+	txMgrHelper := newTxMgrTestHelper(t, txMgr)
+	metadata1 := map[string][]byte{"entry1": []byte("metadata1")}
+	metadata2 := map[string][]byte{"entry1": []byte("metadata2"), "entry2": []byte("metadata2")}
+
+	checkState := func(key string, expectedValue []byte, expectedMetadata map[string][]byte) {
+		qe, _ := txMgr.NewQueryExecutor("query_tx")
+		defer qe.Done()
+		value, err := qe.GetState(cID, key)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedValue, value)
+		metadata, err := qe.GetStateMetadata(cID, key)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedMetadata, metadata)
+	}
+
+	// metadata written along with the value and metadata written for a non-existing key
+	s, _ := txMgr.NewTxSimulator("test_tx1")
+	s.SetState(cID, "key1", []byte("value1"))
+	s.SetState(cID, "key2", []byte("value2"))
+	s.SetStateMetadata(cID, "key2", metadata1)
+	s.SetStateMetadata(cID, "key3", metadata1)
+	txRWSet, _ := s.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet.PubSimulationResults)
+	checkState("key1", []byte("value1"), nil)
+	checkState("key2", []byte("value2"), metadata1)
+	checkState("key3", nil, nil)
+
+	// update to the value retains the metadata and update to the metadata retains the value
+	s, _ = txMgr.NewTxSimulator("test_tx2")
+	s.SetState(cID, "key2", []byte("value2_1"))
+	s.SetStateMetadata(cID, "key1", metadata2)
+	txRWSet, _ = s.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet.PubSimulationResults)
+	checkState("key1", []byte("value1"), metadata2)
+	checkState("key2", []byte("value2_1"), metadata1)
+
+	// a subsequent transaction in the same block sees the updates by the preceding one
+	s, _ = txMgr.NewTxSimulator("test_tx3")
+	s.SetState(cID, "key1", []byte("value1_1"))
+	s.DeleteStateMetadata(cID, "key2")
+	txRWSet1, _ := s.GetTxSimulationResults()
+	s, _ = txMgr.NewTxSimulator("test_tx4")
+	s.SetStateMetadata(cID, "key1", metadata1)
+	s.SetState(cID, "key2", []byte("value2_2"))
+	txRWSet2, _ := s.GetTxSimulationResults()
+	rwSetBytes1, _ := proto.Marshal(txRWSet1.PubSimulationResults)
+	rwSetBytes2, _ := proto.Marshal(txRWSet2.PubSimulationResults)
+	block := txMgrHelper.bg.NextBlock([][]byte{rwSetBytes1, rwSetBytes2})
+	_, err := txMgr.ValidateAndPrepare(&ledger.BlockAndPvtData{Block: block}, true)
+	assert.NoError(t, err)
+	assert.NoError(t, txMgr.Commit())
+	checkState("key1", []byte("value1_1"), metadata1)
+	checkState("key2", []byte("value2_2"), nil)
+
+	// delete of the key removes the metadata as well
+	s, _ = txMgr.NewTxSimulator("test_tx5")
+	s.DeleteState(cID, "key1")
+	txRWSet, _ = s.GetTxSimulationResults()
+	txMgrHelper.validateAndCommitRWSet(txRWSet.PubSimulationResults)
+	checkState("key1", nil, nil)
+}
+
 func TestTxSimulatorMissingPvtdata(t *testing.T) {
 	testEnv := testEnvs[0]
-	testEnv.init(t, "TestTxSimulatorUnsupportedTxQueries")
+	testEnv.init(t, "TestTxSimulatorUnsupportedTxQueries", nil)
 	defer testEnv.cleanup()
 
 	db := testEnv.getVDB()
@@ -689,10 +849,87 @@ func TestTxSimulatorMissingPvtdata(t *testing.T) {
 	testutil.AssertNil(t, val)
 }
 
+func TestRemoveStaleAndCommitPvtDataOfOldBlocks(t *testing.T) {
+	testEnv := testEnvs[0]
+	testEnv.init(t, "TestRemoveStaleAndCommitPvtDataOfOldBlocks", nil)
+	defer testEnv.cleanup()
+
+	// block 1 commits only the hashes of the pvt data of tx 1, except for key4 which is deleted by tx 1.
+	// key3 is overwritten by block 2
+	db := testEnv.getVDB()
+	updateBatch := privacyenabledstate.NewUpdateBatch()
+	updateBatch.PvtUpdates.Put("ns1", "coll1", "key4", []byte("value4"), version.NewHeight(1, 0))
+	updateBatch.HashUpdates.Put("ns1", "coll1", util.ComputeStringHash("key1"), util.ComputeHash([]byte("value1")), version.NewHeight(1, 1))
+	updateBatch.HashUpdates.Put("ns1", "coll1", util.ComputeStringHash("key2"), util.ComputeHash([]byte("value2")), version.NewHeight(1, 1))
+	db.ApplyPrivacyAwareUpdates(updateBatch, version.NewHeight(1, 1))
+	updateBatch = privacyenabledstate.NewUpdateBatch()
+	putPvtUpdates(t, updateBatch, "ns1", "coll1", "key3", []byte("value3-2"), version.NewHeight(2, 0))
+	db.ApplyPrivacyAwareUpdates(updateBatch, version.NewHeight(2, 0))
+
+	kvRWSet := &kvrwset.KVRWSet{
+		Writes: []*kvrwset.KVWrite{
+			{Key: "key1", Value: []byte("value1")},
+			{Key: "key3", Value: []byte("value3-1")},
+			{Key: "key4", IsDelete: true},
+		},
+	}
+	kvRWSetBytes, err := proto.Marshal(kvRWSet)
+	assert.NoError(t, err)
+	blocksPvtData := []*ledger.BlockPvtData{
+		{
+			BlockNum: 1,
+			WriteSets: map[uint64]*ledger.TxPvtData{
+				1: {
+					SeqInBlock: 1,
+					WriteSet: &rwset.TxPvtReadWriteSet{
+						DataModel: rwset.TxReadWriteSet_KV,
+						NsPvtRwset: []*rwset.NsPvtReadWriteSet{
+							{
+								Namespace: "ns1",
+								CollectionPvtRwset: []*rwset.CollectionPvtReadWriteSet{
+									{CollectionName: "coll1", Rwset: kvRWSetBytes},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	txMgr := testEnv.getTxMgr()
+	assert.NoError(t, txMgr.RemoveStaleAndCommitPvtDataOfOldBlocks(blocksPvtData))
+
+	// key1 is committed, the stale write of key3 is ignored and the left behind key4 is removed
+	vv, err := db.GetPrivateData("ns1", "coll1", "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value1"), vv.Value)
+	assert.Equal(t, version.NewHeight(1, 1), vv.Version)
+	vv, err = db.GetPrivateData("ns1", "coll1", "key2")
+	assert.NoError(t, err)
+	assert.Nil(t, vv)
+	vv, err = db.GetPrivateData("ns1", "coll1", "key3")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value3-2"), vv.Value)
+	vv, err = db.GetPrivateData("ns1", "coll1", "key4")
+	assert.NoError(t, err)
+	assert.Nil(t, vv)
+
+	// the savepoint is not changed
+	savepoint, err := txMgr.GetLastSavepoint()
+	assert.NoError(t, err)
+	assert.Equal(t, version.NewHeight(2, 0), savepoint)
+
+	simulator, _ := txMgr.NewTxSimulator("testTxid")
+	defer simulator.Done()
+	val, err := simulator.GetPrivateData("ns1", "coll1", "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value1"), val)
+}
+
 func TestDeleteOnCursor(t *testing.T) {
 	cID := "cid"
 	env := testEnvs[0]
-	env.init(t, "TestDeleteOnCursor")
+	env.init(t, "TestDeleteOnCursor", nil)
 	defer env.cleanup()
 
 	txMgr := env.getTxMgr()
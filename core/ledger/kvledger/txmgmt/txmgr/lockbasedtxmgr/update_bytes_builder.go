@@ -0,0 +1,70 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package lockbasedtxmgr
+
+import (
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
+)
+
+// deterministicBytesForPubAndHashUpdates serializes the public and the hashed updates in the given batch
+// in the sorted order of the namespaces, the collections and the keys, so that the peers that apply the same
+// block to the same state produce the same bytes. The private updates are not included, as the availability
+// of the private data at the time of commit may vary across the peers
+func deterministicBytesForPubAndHashUpdates(batch *privacyenabledstate.UpdateBatch) []byte {
+	buf := proto.NewBuffer(nil)
+	pubUpdates := batch.PubUpdates
+	pubNamespaces := pubUpdates.GetUpdatedNamespaces()
+	buf.EncodeVarint(uint64(len(pubNamespaces)))
+	for _, ns := range sortedStrings(pubNamespaces) {
+		buf.EncodeStringBytes(ns)
+		encodeUpdates(buf, pubUpdates.GetUpdates(ns))
+	}
+	hashUpdates := batch.HashUpdates.UpdateMap
+	hashedNamespaces := make([]string, 0, len(hashUpdates))
+	for ns := range hashUpdates {
+		hashedNamespaces = append(hashedNamespaces, ns)
+	}
+	buf.EncodeVarint(uint64(len(hashedNamespaces)))
+	for _, ns := range sortedStrings(hashedNamespaces) {
+		buf.EncodeStringBytes(ns)
+		collUpdates := hashUpdates[ns]
+		collNames := collUpdates.GetCollectionNames()
+		buf.EncodeVarint(uint64(len(collNames)))
+		for _, coll := range sortedStrings(collNames) {
+			buf.EncodeStringBytes(coll)
+			encodeUpdates(buf, collUpdates.GetUpdates(coll))
+		}
+	}
+	return buf.Bytes()
+}
+
+// encodeUpdates writes the key, the value, the metadata and the version of each update, in the sorted order of the keys.
+// A delete is encoded with an empty value and metadata
+func encodeUpdates(buf *proto.Buffer, updates map[string]*statedb.VersionedValue) {
+	keys := make([]string, 0, len(updates))
+	for key := range updates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	buf.EncodeVarint(uint64(len(keys)))
+	for _, key := range keys {
+		vv := updates[key]
+		buf.EncodeStringBytes(key)
+		buf.EncodeRawBytes(vv.Value)
+		buf.EncodeRawBytes(vv.Metadata)
+		buf.EncodeRawBytes(vv.Version.ToBytes())
+	}
+}
+
+func sortedStrings(s []string) []string {
+	sort.Strings(s)
+	return s
+}
@@ -0,0 +1,48 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package lockbasedtxmgr
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeterministicBytesForPubAndHashUpdates(t *testing.T) {
+	batch1 := privacyenabledstate.NewUpdateBatch()
+	batch1.PubUpdates.Put("ns1", "key1", []byte("value1"), version.NewHeight(1, 1))
+	batch1.PubUpdates.Put("ns2", "key2", []byte("value2"), version.NewHeight(1, 2))
+	batch1.PubUpdates.Delete("ns2", "key3", version.NewHeight(1, 3))
+	batch1.HashUpdates.Put("ns1", "coll1", []byte("keyHash1"), []byte("valueHash1"), version.NewHeight(1, 1))
+	batch1.HashUpdates.Put("ns1", "coll2", []byte("keyHash2"), []byte("valueHash2"), version.NewHeight(1, 2))
+
+	// the same updates added in a different order
+	batch2 := privacyenabledstate.NewUpdateBatch()
+	batch2.HashUpdates.Put("ns1", "coll2", []byte("keyHash2"), []byte("valueHash2"), version.NewHeight(1, 2))
+	batch2.HashUpdates.Put("ns1", "coll1", []byte("keyHash1"), []byte("valueHash1"), version.NewHeight(1, 1))
+	batch2.PubUpdates.Delete("ns2", "key3", version.NewHeight(1, 3))
+	batch2.PubUpdates.Put("ns2", "key2", []byte("value2"), version.NewHeight(1, 2))
+	batch2.PubUpdates.Put("ns1", "key1", []byte("value1"), version.NewHeight(1, 1))
+	// the pvt updates are not included
+	batch2.PvtUpdates.Put("ns1", "coll1", "key1", []byte("pvtValue1"), version.NewHeight(1, 1))
+
+	bytes1 := deterministicBytesForPubAndHashUpdates(batch1)
+	assert.NotEmpty(t, bytes1)
+	assert.Equal(t, bytes1, deterministicBytesForPubAndHashUpdates(batch2))
+
+	batch2.PubUpdates.Put("ns1", "key1", []byte("value1"), version.NewHeight(1, 4))
+	assert.NotEqual(t, bytes1, deterministicBytesForPubAndHashUpdates(batch2))
+
+	// the public and the hashed updates are encoded in separate sections
+	batch3 := privacyenabledstate.NewUpdateBatch()
+	batch3.PubUpdates.Put("ns1", "coll1", nil, version.NewHeight(1, 1))
+	batch4 := privacyenabledstate.NewUpdateBatch()
+	batch4.HashUpdates.Put("ns1", "coll1", []byte{}, nil, version.NewHeight(1, 1))
+	assert.NotEqual(t, deterministicBytesForPubAndHashUpdates(batch3), deterministicBytesForPubAndHashUpdates(batch4))
+}
@@ -25,10 +25,13 @@ import (
 type TxMgr interface {
 	NewQueryExecutor(txid string) (ledger.QueryExecutor, error)
 	NewTxSimulator(txid string) (ledger.TxSimulator, error)
-	ValidateAndPrepare(blockAndPvtdata *ledger.BlockAndPvtData, doMVCCValidation bool) error
+	// ValidateAndPrepare validates the transactions in the block and prepares the updates of the valid transactions
+	// for the commit. It returns the deterministic bytes of the public and hashed updates of the block
+	ValidateAndPrepare(blockAndPvtdata *ledger.BlockAndPvtData, doMVCCValidation bool) ([]byte, error)
 	GetLastSavepoint() (*version.Height, error)
 	ShouldRecover(lastAvailableBlock uint64) (bool, uint64, error)
 	CommitLostBlock(blockAndPvtdata *ledger.BlockAndPvtData) error
+	RemoveStaleAndCommitPvtDataOfOldBlocks(blocksPvtData []*ledger.BlockPvtData) error
 	Commit() error
 	Rollback()
 	Shutdown()
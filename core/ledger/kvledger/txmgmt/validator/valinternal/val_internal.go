@@ -7,12 +7,17 @@ SPDX-License-Identifier: Apache-2.0
 package valinternal
 
 import (
+	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/privacyenabledstate"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwsetutil"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb"
 	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/version"
+	"github.com/hyperledger/fabric/core/ledger/util"
 	"github.com/hyperledger/fabric/protos/peer"
 )
 
+var logger = flogging.MustGetLogger("valinternal")
+
 // InternalValidator is supposed to validate the transactions based on public data and hashes present in a block
 // and returns a batch that should be used to update the state
 type InternalValidator interface {
@@ -78,16 +83,57 @@ func (t *Transaction) RetrieveHash(ns string, coll string) []byte {
 	return nil
 }
 
-// ApplyWriteSet adds (or deletes) the key/values present in the write set to the PubAndHashUpdates
-func (u *PubAndHashUpdates) ApplyWriteSet(txRWSet *rwsetutil.TxRwSet, txHeight *version.Height) {
+// ApplyWriteSet adds (or deletes) the key/values present in the write set to the PubAndHashUpdates.
+// The metadata writes present in the write set are applied along with the value of the corresponding key.
+// A write to the value of a key retains the existing metadata of the key (if any) and a write to the metadata
+// of a key retains the latest value of the key. The latest value or metadata is looked up first in the updates
+// (for the updates by the preceding transactions in the block) and then in the db
+func (u *PubAndHashUpdates) ApplyWriteSet(txRWSet *rwsetutil.TxRwSet, txHeight *version.Height, db privacyenabledstate.DB) error {
 	for _, nsRWSet := range txRWSet.NsRwSets {
 		ns := nsRWSet.NameSpace
+		metadataWrites := make(map[string][]byte)
+		for _, metadataWrite := range nsRWSet.KvRwSet.MetadataWrites {
+			metadataBytes, err := util.SerializeMetadata(metadataWrite.Entries)
+			if err != nil {
+				return err
+			}
+			metadataWrites[metadataWrite.Key] = metadataBytes
+		}
+
+		valueWrites := make(map[string]bool)
 		for _, kvWrite := range nsRWSet.KvRwSet.Writes {
+			valueWrites[kvWrite.Key] = true
 			if kvWrite.IsDelete {
 				u.PubUpdates.Delete(ns, kvWrite.Key, txHeight)
-			} else {
-				u.PubUpdates.Put(ns, kvWrite.Key, kvWrite.Value, txHeight)
+				continue
 			}
+			metadataBytes, ok := metadataWrites[kvWrite.Key]
+			if !ok {
+				latestVal, err := u.retrieveLatestState(ns, kvWrite.Key, db)
+				if err != nil {
+					return err
+				}
+				if latestVal != nil {
+					metadataBytes = latestVal.Metadata
+				}
+			}
+			u.PubUpdates.PutValAndMetadata(ns, kvWrite.Key, kvWrite.Value, metadataBytes, txHeight)
+		}
+
+		for _, metadataWrite := range nsRWSet.KvRwSet.MetadataWrites {
+			key := metadataWrite.Key
+			if valueWrites[key] {
+				continue
+			}
+			latestVal, err := u.retrieveLatestState(ns, key, db)
+			if err != nil {
+				return err
+			}
+			if latestVal == nil {
+				logger.Debugf("Ignoring the metadata write for the non-existing key [%s] in namespace [%s]", key, ns)
+				continue
+			}
+			u.PubUpdates.PutValAndMetadata(ns, key, latestVal.Value, metadataWrites[key], txHeight)
 		}
 
 		for _, collHashRWset := range nsRWSet.CollHashedRwSets {
@@ -101,4 +147,18 @@ func (u *PubAndHashUpdates) ApplyWriteSet(txRWSet *rwsetutil.TxRwSet, txHeight *
 			}
 		}
 	}
+	return nil
+}
+
+// retrieveLatestState returns the latest value (along with the metadata) of the key, taking into account the updates
+// by the preceding transactions in the block. A nil is returned if the key does not exist or has been deleted
+func (u *PubAndHashUpdates) retrieveLatestState(ns, key string, db privacyenabledstate.DB) (*statedb.VersionedValue, error) {
+	if u.PubUpdates.Exists(ns, key) {
+		vv := u.PubUpdates.Get(ns, key)
+		if vv.Value == nil {
+			return nil, nil
+		}
+		return vv, nil
+	}
+	return db.GetState(ns, key)
 }
@@ -18,22 +18,39 @@ package ledger
 
 import (
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	commonledger "github.com/hyperledger/fabric/common/ledger"
 	"github.com/hyperledger/fabric/protos/common"
 	"github.com/hyperledger/fabric/protos/ledger/rwset"
+	"github.com/hyperledger/fabric/protos/ledger/rwset/kvrwset"
 	"github.com/hyperledger/fabric/protos/peer"
 )
 
 // PeerLedgerProvider provides handle to ledger instances
 type PeerLedgerProvider interface {
+	// Initialize provides the state listeners to the provider. The listeners are registered with
+	// the ledgers that are created or opened afterwards
+	Initialize(stateListeners []StateListener)
 	// Create creates a new ledger with the given genesis block.
 	// This function guarantees that the creation of ledger and committing the genesis block would an atomic action
 	// The chain id retrieved from the genesis block is treated as a ledger id
 	Create(genesisBlock *common.Block) (PeerLedger, error)
+	// CreateFromSnapshot creates a new ledger from the snapshot present in the given directory.
+	// The snapshot is expected to have been produced by the function `PeerLedger.ExportSnapshot`.
+	// The created ledger is expected to continue from the block following the last block in the snapshot
+	CreateFromSnapshot(snapshotDir string) (PeerLedger, error)
 	// Open opens an already created ledger
 	Open(ledgerID string) (PeerLedger, error)
 	// Exists tells whether the ledger with given id exists
 	Exists(ledgerID string) (bool, error)
+	// Rollback removes all the blocks after the given block number from the ledger with the given id.
+	// The state and the history of the ledger are rebuilt from the genesis block when the ledger is opened next.
+	// The ledger should not be opened while it is being rolled back
+	Rollback(ledgerID string, blockNum uint64) error
+	// RebuildDBs drops the state database, the history database and the block index of the ledger with the given id
+	// and rebuilds these from the blocks when the ledger is opened next. An interrupted rebuild is resumed when
+	// the ledger is opened. The ledger should not be opened while this function is invoked
+	RebuildDBs(ledgerID string) error
 	// List lists the ids of the existing ledgers
 	List() ([]string, error)
 	// Close closes the PeerLedgerProvider
@@ -72,6 +89,21 @@ type PeerLedger interface {
 	// The pvt data is filtered by the list of 'ns/collections' supplied in the filter
 	// A nil filter does not filter any results and causes retrieving all the pvt data for the given blockNum
 	GetPvtDataByNum(blockNum uint64, filter PvtNsCollFilter) ([]*TxPvtData, error)
+	// GetMissingPvtDataInfoForMostRecentBlocks returns the information about the pvt data that is missing
+	// from the committed blocks, for at most `maxBlocks` of the most recent blocks that miss some pvt data.
+	// The pvt data that has expired is not included
+	GetMissingPvtDataInfoForMostRecentBlocks(maxBlocks int) (MissingPvtDataInfo, error)
+	// GetMissingPvtDataInfoForBlocksBelow returns the information about the pvt data that is missing from
+	// the committed blocks, for at most `maxBlocks` of the most recent blocks before `blockNum` that miss
+	// some pvt data, so that the missing pvt data of older blocks can be reached past the most recent ones.
+	// The pvt data that has expired is not included
+	GetMissingPvtDataInfoForBlocksBelow(blockNum uint64, maxBlocks int) (MissingPvtDataInfo, error)
+	// CommitPvtDataOfOldBlocks commits the pvt data that was missing from already committed blocks.
+	// The pvt data of a collection is committed only if it is missing from the transaction, has not
+	// expired and its hash matches the corresponding hash present in the block; the collections whose
+	// hash does not match are returned. The pvt data is also applied to the state if the keys have
+	// not been updated by a later transaction
+	CommitPvtDataOfOldBlocks(blocksPvtData []*BlockPvtData) ([]*PvtdataHashMismatch, error)
 	// CommitWithPvtData commits the block and the corresponding pvt data in an atomic operation
 	CommitWithPvtData(blockAndPvtdata *BlockAndPvtData) error
 	// Purge removes private read-writes set generated by endorsers at block height lesser than
@@ -82,6 +114,10 @@ type PeerLedger interface {
 	PrivateDataMinBlockNum() (uint64, error)
 	//Prune prunes the blocks/transactions that satisfy the given policy
 	Prune(policy commonledger.PrunePolicy) error
+	// ExportSnapshot exports the current state of the ledger (i.e., the contents of the state database, the last
+	// committed block, and the latest config block) to the given directory. The exported files can be used for
+	// creating a new ledger via the function `PeerLedgerProvider.CreateFromSnapshot`
+	ExportSnapshot(snapshotDir string) error
 }
 
 // ValidatedLedger represents the 'final ledger' after filtering out invalid transactions from PeerLedger.
@@ -98,6 +134,8 @@ type ValidatedLedger interface {
 type QueryExecutor interface {
 	// GetState gets the value for given namespace and key. For a chaincode, the namespace corresponds to the chaincodeId
 	GetState(namespace string, key string) ([]byte, error)
+	// GetStateMetadata returns the metadata for given namespace and key
+	GetStateMetadata(namespace, key string) (map[string][]byte, error)
 	// GetStateMultipleKeys gets the values for multiple keys in a single call
 	GetStateMultipleKeys(namespace string, keys []string) ([][]byte, error)
 	// GetStateRangeScanIterator returns an iterator that contains all the key-values between given key ranges.
@@ -106,11 +144,22 @@ type QueryExecutor interface {
 	// can be supplied as empty strings. However, a full scan should be used judiciously for performance reasons.
 	// The returned ResultsIterator contains results of type *KV which is defined in protos/ledger/queryresult.
 	GetStateRangeScanIterator(namespace string, startKey string, endKey string) (commonledger.ResultsIterator, error)
+	// GetStateRangeScanIteratorWithPagination returns an iterator that contains at most pageSize key-values between
+	// given key ranges. startKey is included in the results and endKey is excluded. The bookmark returned by the
+	// iterator is to be used as the startKey for retrieving the next page, and is empty if there are no more results.
+	// The returned QueryResultsIterator contains results of type *KV which is defined in protos/ledger/queryresult.
+	GetStateRangeScanIteratorWithPagination(namespace string, startKey string, endKey string, pageSize int32) (QueryResultsIterator, error)
 	// ExecuteQuery executes the given query and returns an iterator that contains results of type specific to the underlying data store.
 	// Only used for state databases that support query
 	// For a chaincode, the namespace corresponds to the chaincodeId
 	// The returned ResultsIterator contains results of type *KV which is defined in protos/ledger/queryresult.
 	ExecuteQuery(namespace, query string) (commonledger.ResultsIterator, error)
+	// ExecuteQueryWithPagination executes the given query and returns an iterator that contains at most pageSize
+	// results, starting from the given bookmark. An empty bookmark refers to the first page. The bookmark returned
+	// by the iterator is to be used for retrieving the next page, and is empty if there are no more results.
+	// Only used for state databases that support query
+	// The returned QueryResultsIterator contains results of type *KV which is defined in protos/ledger/queryresult.
+	ExecuteQueryWithPagination(namespace, query, bookmark string, pageSize int32) (QueryResultsIterator, error)
 	// GetPrivateData gets the value of a private data item identified by a tuple <namespace, collection, key>
 	GetPrivateData(namespace, collection, key string) ([]byte, error)
 	// GetPrivateDataMultipleKeys gets the values for the multiple private data items in a single call
@@ -130,11 +179,43 @@ type QueryExecutor interface {
 	Done()
 }
 
+// QueryResultsIterator - an iterator for query result set
+type QueryResultsIterator interface {
+	commonledger.ResultsIterator
+	// GetBookmarkAndClose returns a bookmark for retrieving the page that follows the results
+	// of this iterator, and closes the iterator
+	GetBookmarkAndClose() string
+}
+
 // HistoryQueryExecutor executes the history queries
 type HistoryQueryExecutor interface {
 	// GetHistoryForKey retrieves the history of values for a key.
 	// The returned ResultsIterator contains results of type *KeyModification which is defined in protos/ledger/queryresult.
 	GetHistoryForKey(namespace string, key string) (commonledger.ResultsIterator, error)
+	// GetHistoryForKeyWithOptions retrieves the history of values for a key, like GetHistoryForKey, restricted,
+	// ordered and paginated as per the given options. The bookmark returned by the iterator is to be used
+	// for retrieving the next page, and is empty if there are no more results.
+	// The returned QueryResultsIterator contains results of type *KeyModification which is defined in protos/ledger/queryresult.
+	GetHistoryForKeyWithOptions(namespace string, key string, options *HistoryQueryOptions) (QueryResultsIterator, error)
+}
+
+// HistoryQueryOptions restricts, orders and paginates the results of a history query.
+// The modifications are restricted to the ones committed in the blocks [StartBlock, EndBlock)
+// by the transactions whose timestamps are in [StartTime, EndTime). An EndBlock of zero refers
+// to the last committed block and a nil timestamp imposes no bound. Note that the block range
+// narrows down the scan of the history, while the timestamps are checked for each modification.
+// If Descending is set, the most recent modification is returned first. If the PageSize is greater
+// than zero, at most PageSize results are returned, starting from the Bookmark of the previous page.
+// A query bounded in time must be paginated, and as the number of modifications skipped for a page
+// is limited by the query limit, a page may hold fewer than PageSize results even if it is not the last
+type HistoryQueryOptions struct {
+	StartBlock uint64
+	EndBlock   uint64
+	StartTime  *timestamp.Timestamp
+	EndTime    *timestamp.Timestamp
+	Descending bool
+	PageSize   int32
+	Bookmark   string
 }
 
 // TxSimulator simulates a transaction on a consistent snapshot of the 'as recent state as possible'
@@ -145,6 +226,10 @@ type TxSimulator interface {
 	SetState(namespace string, key string, value []byte) error
 	// DeleteState deletes the given namespace and key
 	DeleteState(namespace string, key string) error
+	// SetStateMetadata sets the metadata associated with an existing key-tuple <namespace, key>
+	SetStateMetadata(namespace, key string, metadata map[string][]byte) error
+	// DeleteStateMetadata deletes the metadata (if any) associated with an existing key-tuple <namespace, key>
+	DeleteStateMetadata(namespace, key string) error
 	// SetMultipleKeys sets the values for multiple keys in a single call
 	SetStateMultipleKeys(namespace string, kvs map[string][]byte) error
 	// ExecuteUpdate for supporting rich data model (see comments on QueryExecutor above)
@@ -168,6 +253,39 @@ type TxSimulator interface {
 	GetTxSimulationResults() (*TxSimulationResults, error)
 }
 
+// StateListener allows a custom code for performing additional stuff upon state change
+// for a particular namespace against which the listener is registered.
+// This helps to perform custom tasks other than the state updates.
+// A ledger implementation is expected to invoke the function `HandleStateUpdates` once per block
+// and the `trigger` parameter passed to the function captures the state changes caused
+// by the valid transactions in the block for the namespaces of interest.
+// The function is invoked before the block is committed and, if it returns an error, the ledger
+// implementation is expected to halt the block commit. The function `StateCommitDone` is invoked
+// once the state changes passed to the listener have been committed to the state
+type StateListener interface {
+	InterestedInNamespaces() []string
+	HandleStateUpdates(trigger *StateUpdateTrigger) error
+	StateCommitDone(ledgerID string)
+}
+
+// StateUpdateTrigger encapsulates the information passed to a state listener for a block
+type StateUpdateTrigger struct {
+	LedgerID           string
+	StateUpdates       StateUpdates
+	CommittingBlockNum uint64
+}
+
+// StateUpdates captures the writes of a block, grouped by namespace
+type StateUpdates map[string]*KVStateUpdates
+
+// KVStateUpdates captures the public writes and the private writes (grouped by collection) of a namespace,
+// sorted by key. The private writes are limited to the pvt data that is available to the peer at the commit
+// of the block, i.e., the pvt data that is missing from the block and is reconciled later is not included
+type KVStateUpdates struct {
+	PublicUpdates []*kvrwset.KVWrite
+	PvtUpdates    map[string][]*kvrwset.KVWrite
+}
+
 // TxPvtData encapsulates the transaction number and pvt write-set for a transaction
 type TxPvtData struct {
 	SeqInBlock uint64
@@ -192,6 +310,46 @@ type BlockAndPvtData struct {
 	Missing      []MissingPrivateData
 }
 
+// BlockPvtData encapsulates the pvt data of an already committed block, as a map of the tuples <seqInBlock, *TxPvtData>
+type BlockPvtData struct {
+	BlockNum  uint64
+	WriteSets map[uint64]*TxPvtData
+}
+
+// PvtdataHashMismatch is used when the hash of the pvt data of a collection
+// does not match with the corresponding hash present in the block
+type PvtdataHashMismatch struct {
+	BlockNum     uint64
+	TxNum        uint64
+	Namespace    string
+	Collection   string
+	ExpectedHash []byte
+}
+
+// MissingPvtDataInfo is a map of block number to the MissingBlockPvtdataInfo of the block
+type MissingPvtDataInfo map[uint64]MissingBlockPvtdataInfo
+
+// MissingBlockPvtdataInfo is a map of the sequence of the transactions in the block
+// to the collections whose pvt data is missing from the transaction
+type MissingBlockPvtdataInfo map[uint64][]*MissingCollectionPvtDataInfo
+
+// MissingCollectionPvtDataInfo identifies a collection whose pvt data is missing
+type MissingCollectionPvtDataInfo struct {
+	Namespace  string
+	Collection string
+}
+
+// Add adds the missing pvt data of a collection of a transaction
+func (missingPvtDataInfo MissingPvtDataInfo) Add(blkNum, txNum uint64, ns, coll string) {
+	missingBlockPvtDataInfo, ok := missingPvtDataInfo[blkNum]
+	if !ok {
+		missingBlockPvtDataInfo = make(MissingBlockPvtdataInfo)
+		missingPvtDataInfo[blkNum] = missingBlockPvtDataInfo
+	}
+	missingBlockPvtDataInfo[txNum] = append(missingBlockPvtDataInfo[txNum],
+		&MissingCollectionPvtDataInfo{Namespace: ns, Collection: coll})
+}
+
 // PvtCollFilter represents the set of the collection names (as keys of the map with value 'true')
 type PvtCollFilter map[string]bool
 
@@ -20,6 +20,7 @@ import (
 	"path/filepath"
 
 	"github.com/hyperledger/fabric/core/config"
+	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 )
 
@@ -32,6 +33,12 @@ func IsCouchDBEnabled() bool {
 	return false
 }
 
+// IsLedgerInMemory returns true if the ledgers are to be kept only in the memory of the process.
+// The state database is always goleveldb for the in-memory ledgers
+func IsLedgerInMemory() bool {
+	return viper.GetBool("ledger.inMemory")
+}
+
 // GetRootPath returns the filesystem path.
 // All ledger related contents are expected to be stored under this path
 func GetRootPath() string {
@@ -69,6 +76,11 @@ func GetPvtdataStorePath() string {
 	return filepath.Join(GetRootPath(), "pvtdataStore")
 }
 
+// GetInternalBookkeeperPath returns the filesystem path that is used for bookkeeping the internal stuff by by KVledger (such as expiration time for pvt)
+func GetInternalBookkeeperPath() string {
+	return filepath.Join(GetRootPath(), "bookkeeper")
+}
+
 // GetMaxBlockfileSize returns maximum size of the block file
 func GetMaxBlockfileSize() int {
 	return 64 * 1024 * 1024
@@ -94,6 +106,44 @@ func GetMaxBatchUpdateSize() int {
 	return maxBatchUpdateSize
 }
 
+// GetPvtdataStorePurgeInterval returns the interval in the terms of number of blocks
+// when the purge for the expired data would be performed
+func GetPvtdataStorePurgeInterval() uint64 {
+	purgeInterval := viper.GetInt("ledger.pvtdataStore.purgeInterval")
+	if purgeInterval <= 0 {
+		purgeInterval = 100
+	}
+	return uint64(purgeInterval)
+}
+
+// StateCacheNamespaceSize overrides the size of the state read cache for a namespace
+type StateCacheNamespaceSize struct {
+	Name string
+	Size int
+}
+
+// GetStateCacheSizes returns the maximum number of keys per namespace that are kept in the read
+// cache of the state database of a channel, along with the sizes configured for specific namespaces.
+// A size of zero disables the cache for all the namespaces, or for the specific namespace
+func GetStateCacheSizes() (int, map[string]int, error) {
+	defaultSize := viper.GetInt("ledger.state.cache.size")
+	if defaultSize < 0 {
+		defaultSize = 0
+	}
+	var nsSizes []StateCacheNamespaceSize
+	if err := viper.UnmarshalKey("ledger.state.cache.namespaces", &nsSizes); err != nil {
+		return 0, nil, errors.Wrap(err, "malformed configuration of ledger.state.cache.namespaces")
+	}
+	sizes := make(map[string]int)
+	for _, nsSize := range nsSizes {
+		if nsSize.Size < 0 {
+			nsSize.Size = 0
+		}
+		sizes[nsSize.Name] = nsSize.Size
+	}
+	return defaultSize, sizes, nil
+}
+
 //IsHistoryDBEnabled exposes the historyDatabase variable
 func IsHistoryDBEnabled() bool {
 	return viper.GetBool("ledger.history.enableHistoryDatabase")
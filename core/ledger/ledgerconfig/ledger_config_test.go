@@ -63,6 +63,9 @@ func TestLedgerConfigPathDefault(t *testing.T) {
 	testutil.AssertEquals(t,
 		GetBlockStorePath(),
 		"/var/hyperledger/production/ledgersData/chains")
+	testutil.AssertEquals(t,
+		GetInternalBookkeeperPath(),
+		"/var/hyperledger/production/ledgersData/bookkeeper")
 }
 
 func TestLedgerConfigPath(t *testing.T) {
@@ -84,6 +87,9 @@ func TestLedgerConfigPath(t *testing.T) {
 	testutil.AssertEquals(t,
 		GetBlockStorePath(),
 		"/tmp/hyperledger/production/ledgersData/chains")
+	testutil.AssertEquals(t,
+		GetInternalBookkeeperPath(),
+		"/tmp/hyperledger/production/ledgersData/bookkeeper")
 }
 
 func TestGetQueryLimitDefault(t *testing.T) {
@@ -106,6 +112,16 @@ func TestGetQueryLimit(t *testing.T) {
 	testutil.AssertEquals(t, updatedValue, 5000) //test config returns 5000
 }
 
+func TestGetPvtdataStorePurgeInterval(t *testing.T) {
+	setUpCoreYAMLConfig()
+	defer ledgertestutil.ResetConfigToDefaultValues()
+	testutil.AssertEquals(t, GetPvtdataStorePurgeInterval(), uint64(100))
+	viper.Set("ledger.pvtdataStore.purgeInterval", 5)
+	testutil.AssertEquals(t, GetPvtdataStorePurgeInterval(), uint64(5))
+	viper.Set("ledger.pvtdataStore.purgeInterval", 0)
+	testutil.AssertEquals(t, GetPvtdataStorePurgeInterval(), uint64(100))
+}
+
 func TestIsHistoryDBEnabledDefault(t *testing.T) {
 	setUpCoreYAMLConfig()
 	defaultValue := IsHistoryDBEnabled()
@@ -128,6 +144,37 @@ func TestIsHistoryDBEnabledFalse(t *testing.T) {
 	testutil.AssertEquals(t, updatedValue, false) //test config returns false
 }
 
+func TestIsLedgerInMemory(t *testing.T) {
+	setUpCoreYAMLConfig()
+	defer ledgertestutil.ResetConfigToDefaultValues()
+	testutil.AssertEquals(t, IsLedgerInMemory(), false)
+	viper.Set("ledger.inMemory", true)
+	testutil.AssertEquals(t, IsLedgerInMemory(), true)
+}
+
+func TestGetStateCacheSizes(t *testing.T) {
+	setUpCoreYAMLConfig()
+	defer ledgertestutil.ResetConfigToDefaultValues()
+	defaultSize, nsSizes, err := GetStateCacheSizes()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, defaultSize, 1000)
+	testutil.AssertEquals(t, nsSizes, map[string]int{})
+
+	viper.Set("ledger.state.cache.size", 0)
+	viper.Set("ledger.state.cache.namespaces", []map[string]interface{}{
+		{"name": "myCC", "size": 5000},
+		{"name": "otherCC", "size": -1},
+	})
+	defaultSize, nsSizes, err = GetStateCacheSizes()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, defaultSize, 0)
+	testutil.AssertEquals(t, nsSizes, map[string]int{"myCC": 5000, "otherCC": 0})
+
+	viper.Set("ledger.state.cache.namespaces", "malformed")
+	_, _, err = GetStateCacheSizes()
+	testutil.AssertError(t, err, "Error should have been returned for a malformed configuration")
+}
+
 func setUpCoreYAMLConfig() {
 	//call a helper method to load the core.yaml
 	ledgertestutil.SetupCoreYAMLConfig()
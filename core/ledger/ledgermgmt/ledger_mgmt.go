@@ -44,24 +44,43 @@ var lock sync.Mutex
 var initialized bool
 var once sync.Once
 
+// Initializer encapsulates the dependencies of ledgermgmt
+type Initializer struct {
+	// CustomTxProcessors process the custom transactions (such as config transactions) of the blocks
+	CustomTxProcessors customtx.Processors
+	// StateListeners are passed the state updates of each block committed to the ledgers, for the
+	// namespaces they are interested in, before the commit of the block completes
+	StateListeners []ledger.StateListener
+	// LedgerProvider, if not nil, is used for the ledgers instead of the provider from kvledger, which keeps the
+	// ledgers either in the file system or, if 'ledger.inMemory' is set in the configuration, in the memory
+	LedgerProvider ledger.PeerLedgerProvider
+}
+
 // Initialize initializes ledgermgmt
-func Initialize(customTxProcessors customtx.Processors) {
+func Initialize(initializer *Initializer) {
 	once.Do(func() {
-		initialize(customTxProcessors)
+		initialize(initializer)
 	})
 }
 
-func initialize(customTxProcessors customtx.Processors) {
+func initialize(initializer *Initializer) {
 	logger.Info("Initializing ledger mgmt")
 	lock.Lock()
 	defer lock.Unlock()
+	if initializer == nil {
+		initializer = &Initializer{}
+	}
 	initialized = true
 	openedLedgers = make(map[string]ledger.PeerLedger)
-	customtx.Initialize(customTxProcessors)
-	provider, err := kvledger.NewProvider()
-	if err != nil {
-		panic(fmt.Errorf("Error in instantiating ledger provider: %s", err))
+	customtx.Initialize(initializer.CustomTxProcessors)
+	provider := initializer.LedgerProvider
+	if provider == nil {
+		var err error
+		if provider, err = kvledger.NewProvider(); err != nil {
+			panic(fmt.Errorf("Error in instantiating ledger provider: %s", err))
+		}
 	}
+	provider.Initialize(initializer.StateListeners)
 	ledgerProvider = provider
 	logger.Info("ledger mgmt initialized")
 }
@@ -91,6 +110,50 @@ func CreateLedger(genesisBlock *common.Block) (ledger.PeerLedger, error) {
 	return l, nil
 }
 
+// CreateLedgerFromSnapshot creates a new ledger from the snapshot present in the given directory.
+// The ledger id is the channel name recorded in the config block of the snapshot
+func CreateLedgerFromSnapshot(snapshotDir string) (ledger.PeerLedger, error) {
+	lock.Lock()
+	defer lock.Unlock()
+	if !initialized {
+		return nil, ErrLedgerMgmtNotInitialized
+	}
+	logger.Infof("Creating ledger from snapshot [%s]", snapshotDir)
+	l, err := ledgerProvider.CreateFromSnapshot(snapshotDir)
+	if err != nil {
+		return nil, err
+	}
+	id, err := getLedgerIDFromConfigBlock(l)
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+	l = wrapLedger(id, l)
+	openedLedgers[id] = l
+	logger.Infof("Created ledger [%s] from snapshot", id)
+	return l, nil
+}
+
+func getLedgerIDFromConfigBlock(l ledger.PeerLedger) (string, error) {
+	bcInfo, err := l.GetBlockchainInfo()
+	if err != nil {
+		return "", err
+	}
+	lastBlock, err := l.GetBlockByNumber(bcInfo.Height - 1)
+	if err != nil {
+		return "", err
+	}
+	configBlockNum, err := utils.GetLastConfigIndexFromBlock(lastBlock)
+	if err != nil {
+		return "", err
+	}
+	configBlock, err := l.GetBlockByNumber(configBlockNum)
+	if err != nil {
+		return "", err
+	}
+	return utils.GetChainIDFromBlock(configBlock)
+}
+
 // OpenLedger returns a ledger for the given id
 func OpenLedger(id string) (ledger.PeerLedger, error) {
 	logger.Infof("Opening ledger with id = %s", id)
@@ -123,6 +186,78 @@ func GetLedgerIDs() ([]string, error) {
 	return ledgerProvider.List()
 }
 
+// RollbackLedger removes all the blocks after the given block number from the ledger with the given id.
+// The state of the ledger is rebuilt from the retained blocks when the ledger is opened next
+func RollbackLedger(id string, blockNum uint64) error {
+	lock.Lock()
+	defer lock.Unlock()
+	if !initialized {
+		return ErrLedgerMgmtNotInitialized
+	}
+	return rollbackLedger(id, blockNum)
+}
+
+// ResetLedgers rolls back all the ledgers to their genesis block. None of the ledgers should be opened
+func ResetLedgers() error {
+	lock.Lock()
+	defer lock.Unlock()
+	if !initialized {
+		return ErrLedgerMgmtNotInitialized
+	}
+	if len(openedLedgers) > 0 {
+		return ErrLedgerAlreadyOpened
+	}
+	ids, err := ledgerProvider.List()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := rollbackLedger(id, 0); err != nil {
+			return fmt.Errorf("Error while resetting ledger [%s]: %s", id, err)
+		}
+	}
+	return nil
+}
+
+// RebuildLedgers drops the state database, the history database and the block index of all the ledgers and
+// rebuilds these from the blocks. If the rebuild is interrupted, invoking this function again (or opening the
+// ledgers) resumes the rebuild. None of the ledgers should be opened
+func RebuildLedgers() error {
+	lock.Lock()
+	defer lock.Unlock()
+	if !initialized {
+		return ErrLedgerMgmtNotInitialized
+	}
+	if len(openedLedgers) > 0 {
+		return ErrLedgerAlreadyOpened
+	}
+	ids, err := ledgerProvider.List()
+	if err != nil {
+		return err
+	}
+	for i, id := range ids {
+		logger.Infof("Rebuilding the databases of ledger [%s] (%d of %d)", id, i+1, len(ids))
+		if err := ledgerProvider.RebuildDBs(id); err != nil {
+			return fmt.Errorf("Error while rebuilding the databases of ledger [%s]: %s", id, err)
+		}
+		// the databases are rebuilt while the ledger is opened
+		l, err := ledgerProvider.Open(id)
+		if err != nil {
+			return fmt.Errorf("Error while rebuilding the databases of ledger [%s]: %s", id, err)
+		}
+		l.Close()
+	}
+	return nil
+}
+
+func rollbackLedger(id string, blockNum uint64) error {
+	if _, ok := openedLedgers[id]; ok {
+		return ErrLedgerAlreadyOpened
+	}
+	logger.Infof("Rolling back ledger [%s] to block [%d]", id, blockNum)
+	return ledgerProvider.Rollback(id, blockNum)
+}
+
 // Close closes all the opened ledgers and any resources held for ledger management
 func Close() {
 	logger.Infof("Closing ledger mgmt")
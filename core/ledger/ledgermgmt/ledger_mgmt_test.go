@@ -25,9 +25,21 @@ import (
 	"github.com/hyperledger/fabric/common/configtx/test"
 	"github.com/hyperledger/fabric/common/ledger/testutil"
 	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger"
+	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/hyperledger/fabric/protos/ledger/rwset/kvrwset"
 	"github.com/spf13/viper"
 )
 
+// testProviderTypes are the ledger providers that the tests run against, as selected by 'ledger.inMemory'
+var testProviderTypes = []struct {
+	name     string
+	inMemory bool
+}{
+	{name: "fileSystemProvider"},
+	{name: "inMemoryProvider", inMemory: true},
+}
+
 func TestMain(m *testing.M) {
 	viper.Set("peer.fileSystemPath", "/tmp/fabric/ledgertests/ledgermgmt")
 	os.Exit(m.Run())
@@ -51,6 +63,16 @@ func TestLedgerMgmt(t *testing.T) {
 
 	Close()
 
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			viper.Set("ledger.inMemory", providerType.inMemory)
+			defer viper.Set("ledger.inMemory", false)
+			testLedgerMgmt(t)
+		})
+	}
+}
+
+func testLedgerMgmt(t *testing.T) {
 	InitializeTestEnv()
 	defer CleanupTestEnv()
 
@@ -62,18 +84,18 @@ func TestLedgerMgmt(t *testing.T) {
 		ledgers[i] = l
 	}
 
-	ids, _ = GetLedgerIDs()
+	ids, _ := GetLedgerIDs()
 	testutil.AssertEquals(t, len(ids), numLedgers)
 	for i := 0; i < numLedgers; i++ {
 		testutil.AssertEquals(t, ids[i], constructTestLedgerID(i))
 	}
 
-	ledgerID = constructTestLedgerID(2)
+	ledgerID := constructTestLedgerID(2)
 	t.Logf("Ledger selected for test = %s", ledgerID)
-	_, err = OpenLedger(ledgerID)
+	_, err := OpenLedger(ledgerID)
 	testutil.AssertEquals(t, err, ErrLedgerAlreadyOpened)
 
-	l = ledgers[2]
+	l := ledgers[2]
 	l.Close()
 	l, err = OpenLedger(ledgerID)
 	testutil.AssertNoError(t, err, "")
@@ -84,13 +106,226 @@ func TestLedgerMgmt(t *testing.T) {
 	// close all opened ledgers and ledger mgmt
 	Close()
 
-	// Restart ledger mgmt with existing ledgers
-	Initialize(nil)
+	// Restart ledger mgmt with existing ledgers (Initialize only initializes once in a process)
+	initialize(nil)
 	l, err = OpenLedger(ledgerID)
 	testutil.AssertNoError(t, err, "")
 	Close()
 }
 
+func TestCreateLedgerFromSnapshot(t *testing.T) {
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			viper.Set("ledger.inMemory", providerType.inMemory)
+			defer viper.Set("ledger.inMemory", false)
+			testCreateLedgerFromSnapshot(t)
+		})
+	}
+}
+
+func testCreateLedgerFromSnapshot(t *testing.T) {
+	snapshotDir := "/tmp/fabric/ledgertests/ledgermgmtsnapshot"
+	os.RemoveAll(snapshotDir)
+	defer os.RemoveAll(snapshotDir)
+
+	InitializeTestEnv()
+	ledgerID := constructTestLedgerID(0)
+	gb, _ := test.MakeGenesisBlock(ledgerID)
+	l, err := CreateLedger(gb)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNoError(t, l.ExportSnapshot(snapshotDir), "")
+	CleanupTestEnv()
+
+	InitializeTestEnv()
+	defer CleanupTestEnv()
+	l, err = CreateLedgerFromSnapshot(snapshotDir)
+	testutil.AssertNoError(t, err, "")
+	bcInfo, _ := l.GetBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo.Height, uint64(1))
+	_, err = OpenLedger(ledgerID)
+	testutil.AssertEquals(t, err, ErrLedgerAlreadyOpened)
+	l.Close()
+	_, err = OpenLedger(ledgerID)
+	testutil.AssertNoError(t, err, "")
+}
+
+func TestRollbackAndResetLedgers(t *testing.T) {
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			viper.Set("ledger.inMemory", providerType.inMemory)
+			defer viper.Set("ledger.inMemory", false)
+			testRollbackAndResetLedgers(t)
+		})
+	}
+}
+
+func testRollbackAndResetLedgers(t *testing.T) {
+	InitializeTestEnv()
+	defer CleanupTestEnv()
+	for i := 0; i < 2; i++ {
+		bg, gb := testutil.NewBlockGenerator(t, constructTestLedgerID(i), false)
+		l, err := CreateLedger(gb)
+		testutil.AssertNoError(t, err, "")
+		for _, block := range bg.NextTestBlocks(3) {
+			testutil.AssertNoError(t, l.CommitWithPvtData(&ledger.BlockAndPvtData{Block: block}), "")
+		}
+		if i == 0 {
+			// an opened ledger cannot be rolled back
+			testutil.AssertEquals(t, RollbackLedger(constructTestLedgerID(i), 1), ErrLedgerAlreadyOpened)
+			testutil.AssertEquals(t, ResetLedgers(), ErrLedgerAlreadyOpened)
+		}
+		l.Close()
+	}
+
+	testutil.AssertNoError(t, RollbackLedger(constructTestLedgerID(0), 1), "")
+	l, err := OpenLedger(constructTestLedgerID(0))
+	testutil.AssertNoError(t, err, "")
+	bcInfo, _ := l.GetBlockchainInfo()
+	testutil.AssertEquals(t, bcInfo.Height, uint64(2))
+	l.Close()
+
+	testutil.AssertNoError(t, ResetLedgers(), "")
+	for i := 0; i < 2; i++ {
+		l, err := OpenLedger(constructTestLedgerID(i))
+		testutil.AssertNoError(t, err, "")
+		bcInfo, _ := l.GetBlockchainInfo()
+		testutil.AssertEquals(t, bcInfo.Height, uint64(1))
+		l.Close()
+	}
+}
+
+func TestRebuildLedgers(t *testing.T) {
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			viper.Set("ledger.inMemory", providerType.inMemory)
+			defer viper.Set("ledger.inMemory", false)
+			testRebuildLedgers(t)
+		})
+	}
+}
+
+func testRebuildLedgers(t *testing.T) {
+	InitializeTestEnv()
+	defer CleanupTestEnv()
+	for i := 0; i < 2; i++ {
+		bg, gb := testutil.NewBlockGenerator(t, constructTestLedgerID(i), false)
+		l, err := CreateLedger(gb)
+		testutil.AssertNoError(t, err, "")
+		for _, block := range bg.NextTestBlocks(3) {
+			testutil.AssertNoError(t, l.CommitWithPvtData(&ledger.BlockAndPvtData{Block: block}), "")
+		}
+		if i == 0 {
+			// the ledgers cannot be rebuilt while a ledger is opened
+			testutil.AssertEquals(t, RebuildLedgers(), ErrLedgerAlreadyOpened)
+		}
+		l.Close()
+	}
+
+	testutil.AssertNoError(t, RebuildLedgers(), "")
+	for i := 0; i < 2; i++ {
+		l, err := OpenLedger(constructTestLedgerID(i))
+		testutil.AssertNoError(t, err, "")
+		bcInfo, _ := l.GetBlockchainInfo()
+		testutil.AssertEquals(t, bcInfo.Height, uint64(4))
+		block, err := l.GetBlockByNumber(3)
+		testutil.AssertNoError(t, err, "")
+		testutil.AssertEquals(t, block.Header.Number, uint64(3))
+		l.Close()
+	}
+}
+
+func TestStateListenerRegistration(t *testing.T) {
+	for _, providerType := range testProviderTypes {
+		t.Run(providerType.name, func(t *testing.T) {
+			viper.Set("ledger.inMemory", providerType.inMemory)
+			defer viper.Set("ledger.inMemory", false)
+			testStateListenerRegistration(t)
+		})
+	}
+}
+
+func testStateListenerRegistration(t *testing.T) {
+	listener := &testStateListener{}
+	InitializeTestEnvWithInitializer(&Initializer{StateListeners: []ledger.StateListener{listener}})
+	defer CleanupTestEnv()
+	ledgerID := constructTestLedgerID(0)
+	bg, gb := testutil.NewBlockGenerator(t, ledgerID, false)
+	l, err := CreateLedger(gb)
+	testutil.AssertNoError(t, err, "")
+	defer l.Close()
+
+	simulator, _ := l.NewTxSimulator("txid")
+	simulator.SetState("ns", "key", []byte("value"))
+	simulator.Done()
+	simRes, _ := simulator.GetTxSimulationResults()
+	pubSimBytes, _ := simRes.GetPubSimulationBytes()
+	block := bg.NextBlock([][]byte{pubSimBytes})
+	testutil.AssertNoError(t, l.CommitWithPvtData(&ledger.BlockAndPvtData{Block: block}), "")
+	testutil.AssertEquals(t, listener.receivedTriggers, []*ledger.StateUpdateTrigger{
+		{
+			LedgerID:           ledgerID,
+			StateUpdates:       ledger.StateUpdates{"ns": {PublicUpdates: []*kvrwset.KVWrite{{Key: "key", Value: []byte("value")}}}},
+			CommittingBlockNum: 1,
+		},
+	})
+}
+
+func TestInMemoryLedgers(t *testing.T) {
+	viper.Set("ledger.inMemory", true)
+	defer viper.Set("ledger.inMemory", false)
+	InitializeTestEnv()
+	defer CleanupTestEnv()
+	ledgerID := constructTestLedgerID(0)
+	bg, gb := testutil.NewBlockGenerator(t, ledgerID, false)
+	l, err := CreateLedger(gb)
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertNoError(t, l.CommitWithPvtData(&ledger.BlockAndPvtData{Block: bg.NextBlock([][]byte{})}), "")
+	Close()
+
+	// nothing should be written to the file system
+	_, err = os.Stat(ledgerconfig.GetRootPath())
+	testutil.AssertEquals(t, os.IsNotExist(err), true)
+
+	// the ledgers should be available after a restart of ledger mgmt within the process
+	initialize(nil)
+	l, err = OpenLedger(ledgerID)
+	testutil.AssertNoError(t, err, "")
+	bcInfo, err := l.GetBlockchainInfo()
+	testutil.AssertNoError(t, err, "")
+	testutil.AssertEquals(t, bcInfo.Height, uint64(2))
+}
+
+func TestLedgerProviderInjection(t *testing.T) {
+	remove()
+	provider, err := kvledger.NewInMemoryProvider()
+	testutil.AssertNoError(t, err, "")
+	initialize(&Initializer{LedgerProvider: provider})
+	defer CleanupTestEnv()
+	testutil.AssertEquals(t, ledgerProvider == provider, true)
+
+	gb, _ := test.MakeGenesisBlock(constructTestLedgerID(0))
+	_, err = CreateLedger(gb)
+	testutil.AssertNoError(t, err, "")
+	_, err = os.Stat(ledgerconfig.GetRootPath())
+	testutil.AssertEquals(t, os.IsNotExist(err), true)
+}
+
+type testStateListener struct {
+	receivedTriggers []*ledger.StateUpdateTrigger
+}
+
+func (l *testStateListener) InterestedInNamespaces() []string {
+	return []string{"ns"}
+}
+
+func (l *testStateListener) HandleStateUpdates(trigger *ledger.StateUpdateTrigger) error {
+	l.receivedTriggers = append(l.receivedTriggers, trigger)
+	return nil
+}
+
+func (l *testStateListener) StateCommitDone(ledgerID string) {
+}
+
 func constructTestLedgerID(i int) string {
 	return fmt.Sprintf("ledger_%06d", i)
 }
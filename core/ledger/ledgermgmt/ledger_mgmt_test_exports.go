@@ -19,6 +19,7 @@ package ledgermgmt
 import (
 	"os"
 
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
 	"github.com/hyperledger/fabric/core/ledger/customtx"
 
 	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
@@ -34,12 +35,17 @@ func InitializeTestEnv() {
 
 // InitializeTestEnvWithCustomProcessors initializes ledgermgmt for tests with the supplied custom tx processors
 func InitializeTestEnvWithCustomProcessors(customTxProcessors customtx.Processors) {
+	InitializeTestEnvWithInitializer(&Initializer{CustomTxProcessors: customTxProcessors})
+}
+
+// InitializeTestEnvWithInitializer initializes ledgermgmt for tests with the supplied initializer
+func InitializeTestEnvWithInitializer(initializer *Initializer) {
 	remove()
-	customtx.InitializeTestEnv(customTxProcessors)
-	initialize(customTxProcessors)
+	customtx.InitializeTestEnv(initializer.CustomTxProcessors)
+	initialize(initializer)
 }
 
-// CleanupTestEnv closes the ledgermagmt and removes the store directory
+// CleanupTestEnv closes the ledgermagmt and removes the store directory (and the in-memory ledgers, if any)
 func CleanupTestEnv() {
 	Close()
 	remove()
@@ -52,4 +58,5 @@ func remove() {
 	if err != nil {
 		logger.Errorf("Error: %s", err)
 	}
+	leveldbhelper.RemoveInMemoryDBs(path)
 }
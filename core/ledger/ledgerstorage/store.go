@@ -22,9 +22,12 @@ import (
 
 	"github.com/hyperledger/fabric/common/ledger/blkstorage"
 	"github.com/hyperledger/fabric/common/ledger/blkstorage/fsblkstorage"
+	"github.com/hyperledger/fabric/common/ledger/blkstorage/memblkstorage"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/hyperledger/fabric/core/ledger/pvtdatapolicy"
 	"github.com/hyperledger/fabric/core/ledger/pvtdatastorage"
+	"github.com/hyperledger/fabric/core/ledger/util"
 	"github.com/hyperledger/fabric/protos/common"
 )
 
@@ -44,6 +47,22 @@ type Store struct {
 // NewProvider returns the handle to the provider
 func NewProvider() *Provider {
 	// Initialize the block storage
+	blockStoreProvider := fsblkstorage.NewProvider(
+		fsblkstorage.NewConf(ledgerconfig.GetBlockStorePath(), ledgerconfig.GetMaxBlockfileSize()),
+		indexConfig())
+
+	pvtStoreProvider := pvtdatastorage.NewProvider()
+	return &Provider{blockStoreProvider, pvtStoreProvider}
+}
+
+// NewInMemoryProvider returns the handle to a provider that keeps the blocks and the private data in the memory
+func NewInMemoryProvider() *Provider {
+	blockStoreProvider := memblkstorage.NewProvider(ledgerconfig.GetBlockStorePath(), indexConfig())
+	pvtStoreProvider := pvtdatastorage.NewInMemoryProvider()
+	return &Provider{blockStoreProvider, pvtStoreProvider}
+}
+
+func indexConfig() *blkstorage.IndexConfig {
 	attrsToIndex := []blkstorage.IndexableAttr{
 		blkstorage.IndexableAttrBlockHash,
 		blkstorage.IndexableAttrBlockNum,
@@ -52,16 +71,11 @@ func NewProvider() *Provider {
 		blkstorage.IndexableAttrBlockTxID,
 		blkstorage.IndexableAttrTxValidationCode,
 	}
-	indexConfig := &blkstorage.IndexConfig{AttrsToIndex: attrsToIndex}
-	blockStoreProvider := fsblkstorage.NewProvider(
-		fsblkstorage.NewConf(ledgerconfig.GetBlockStorePath(), ledgerconfig.GetMaxBlockfileSize()),
-		indexConfig)
-
-	pvtStoreProvider := pvtdatastorage.NewProvider()
-	return &Provider{blockStoreProvider, pvtStoreProvider}
+	return &blkstorage.IndexConfig{AttrsToIndex: attrsToIndex}
 }
 
-// Open opens the store
+// Open opens the store. The returned store is expected to be initialized
+// by invoking the function `Init` before using it
 func (p *Provider) Open(ledgerid string) (*Store, error) {
 	var blockStore blkstorage.BlockStore
 	var pvtdataStore pvtdatastorage.Store
@@ -73,11 +87,35 @@ func (p *Provider) Open(ledgerid string) (*Store, error) {
 	if pvtdataStore, err = p.pvtdataStoreProvider.OpenStore(ledgerid); err != nil {
 		return nil, err
 	}
-	store := &Store{blockStore, pvtdataStore, &sync.RWMutex{}}
-	if err := store.init(); err != nil {
-		return nil, err
+	return &Store{blockStore, pvtdataStore, &sync.RWMutex{}}, nil
+}
+
+// BootstrapFromSnapshot initializes the block store for the given ledger from the snapshot info such that
+// the next block to be committed is the one following the last block in the snapshot. The pvt data store
+// is brought in sync with the block store when the store is opened and initialized subsequently
+func (p *Provider) BootstrapFromSnapshot(ledgerid string, snapshotInfo *blkstorage.SnapshotInfo) error {
+	return p.blkStoreProvider.BootstrapFromSnapshot(ledgerid, snapshotInfo)
+}
+
+// Rollback removes all the blocks, and the corresponding pvt data, after the block 'lastBlockToRetain' for
+// the given ledger. The block store is rolled back before the pvt data store, so if a crash happens in between,
+// the rollback can be completed by simply invoking this function again. The store should not be opened
+func (p *Provider) Rollback(ledgerid string, lastBlockToRetain uint64) error {
+	if err := p.blkStoreProvider.Rollback(ledgerid, lastBlockToRetain); err != nil {
+		return err
 	}
-	return store, nil
+	pvtdataStore, err := p.pvtdataStoreProvider.OpenStore(ledgerid)
+	if err != nil {
+		return err
+	}
+	defer pvtdataStore.Shutdown()
+	return pvtdataStore.RollbackToBlock(lastBlockToRetain)
+}
+
+// DropBlockIndex removes the index of the block store for the given ledger. The index is rebuilt from
+// the blocks when the store is opened next. The store should not be opened
+func (p *Provider) DropBlockIndex(ledgerid string) error {
+	return p.blkStoreProvider.DropIndex(ledgerid)
 }
 
 // Close closes the provider
@@ -86,7 +124,15 @@ func (p *Provider) Close() {
 	p.pvtdataStoreProvider.Close()
 }
 
-// CommitWithPvtData commits the block and the corresponding pvt data in an atomic operation
+// Init initializes the store with the BlockToLive policy of the pvt data and brings
+// the pvt data store in sync with the block store
+func (s *Store) Init(btlPolicy pvtdatapolicy.BTLPolicy) error {
+	s.pvtdataStore.Init(btlPolicy)
+	return s.init()
+}
+
+// CommitWithPvtData commits the block and the corresponding pvt data in an atomic operation.
+// The pvt data that is missing from the valid transactions of the block is recorded in the pvt data store
 func (s *Store) CommitWithPvtData(blockAndPvtdata *ledger.BlockAndPvtData) error {
 	s.rwlock.Lock()
 	defer s.rwlock.Unlock()
@@ -94,7 +140,8 @@ func (s *Store) CommitWithPvtData(blockAndPvtdata *ledger.BlockAndPvtData) error
 	for _, v := range blockAndPvtdata.BlockPvtData {
 		pvtdata = append(pvtdata, v)
 	}
-	if err := s.pvtdataStore.Prepare(blockAndPvtdata.Block.Header.Number, pvtdata); err != nil {
+	missingPvtData := missingPvtDataOfValidTxs(blockAndPvtdata)
+	if err := s.pvtdataStore.Prepare(blockAndPvtdata.Block.Header.Number, pvtdata, missingPvtData); err != nil {
 		return err
 	}
 	if err := s.AddBlock(blockAndPvtdata.Block); err != nil {
@@ -104,6 +151,35 @@ func (s *Store) CommitWithPvtData(blockAndPvtdata *ledger.BlockAndPvtData) error
 	return s.pvtdataStore.Commit()
 }
 
+// CommitPvtDataOfOldBlocks commits the pvt data that was missing from already committed blocks
+func (s *Store) CommitPvtDataOfOldBlocks(blocksPvtData []*ledger.BlockPvtData) error {
+	s.rwlock.Lock()
+	defer s.rwlock.Unlock()
+	pvtdata := make(map[uint64][]*ledger.TxPvtData)
+	for _, blockPvtData := range blocksPvtData {
+		for _, txPvtData := range blockPvtData.WriteSets {
+			pvtdata[blockPvtData.BlockNum] = append(pvtdata[blockPvtData.BlockNum], txPvtData)
+		}
+	}
+	return s.pvtdataStore.CommitPvtDataOfOldBlocks(pvtdata)
+}
+
+// GetMissingPvtDataInfoForMostRecentBlocks returns the missing pvt data information for
+// at most `maxBlock` of the most recent blocks that miss some pvt data
+func (s *Store) GetMissingPvtDataInfoForMostRecentBlocks(maxBlock int) (ledger.MissingPvtDataInfo, error) {
+	s.rwlock.RLock()
+	defer s.rwlock.RUnlock()
+	return s.pvtdataStore.GetMissingPvtDataInfoForMostRecentBlocks(maxBlock)
+}
+
+// GetMissingPvtDataInfoForBlocksBelow returns the missing pvt data information for
+// at most `maxBlock` of the most recent blocks before `blockNum` that miss some pvt data
+func (s *Store) GetMissingPvtDataInfoForBlocksBelow(blockNum uint64, maxBlock int) (ledger.MissingPvtDataInfo, error) {
+	s.rwlock.RLock()
+	defer s.rwlock.RUnlock()
+	return s.pvtdataStore.GetMissingPvtDataInfoForBlocksBelow(blockNum, maxBlock)
+}
+
 // GetPvtDataAndBlockByNum returns the block and the corresponding pvt data.
 // The pvt data is filtered by the list of 'collections' supplied
 func (s *Store) GetPvtDataAndBlockByNum(blockNum uint64, filter ledger.PvtNsCollFilter) (*ledger.BlockAndPvtData, error) {
@@ -221,6 +297,26 @@ func (s *Store) syncPvtdataStoreWithBlockStore() error {
 	return fmt.Errorf("This is not expected. blockStoreHeight=%d, pvtdataStoreHeight=%d", bcInfo.Height, pvtdataStoreHt)
 }
 
+// missingPvtDataOfValidTxs returns the missing pvt data of the transactions that are marked as valid in the block.
+// The pvt data of the invalid transactions is never going to be needed, hence it is not tracked as missing
+func missingPvtDataOfValidTxs(blockAndPvtdata *ledger.BlockAndPvtData) []ledger.MissingPrivateData {
+	if len(blockAndPvtdata.Missing) == 0 {
+		return nil
+	}
+	var txsFilter util.TxValidationFlags
+	if metadata := blockAndPvtdata.Block.Metadata; metadata != nil && len(metadata.Metadata) > int(common.BlockMetadataIndex_TRANSACTIONS_FILTER) {
+		txsFilter = util.TxValidationFlags(metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+	}
+	var missingPvtData []ledger.MissingPrivateData
+	for _, missing := range blockAndPvtdata.Missing {
+		if missing.SeqInBlock < len(txsFilter) && txsFilter.IsInvalid(missing.SeqInBlock) {
+			continue
+		}
+		missingPvtData = append(missingPvtData, missing)
+	}
+	return missingPvtData
+}
+
 func constructPvtdataMap(pvtdata []*ledger.TxPvtData) map[uint64]*ledger.TxPvtData {
 	if pvtdata == nil {
 		return nil
@@ -26,7 +26,11 @@ import (
 	"github.com/hyperledger/fabric/common/ledger/testutil"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	btltestutil "github.com/hyperledger/fabric/core/ledger/pvtdatapolicy/testutil"
+	"github.com/hyperledger/fabric/core/ledger/util"
+	"github.com/hyperledger/fabric/protos/common"
 	"github.com/hyperledger/fabric/protos/ledger/rwset"
+	"github.com/hyperledger/fabric/protos/peer"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
@@ -47,6 +51,7 @@ func TestStore(t *testing.T) {
 	defer store.Shutdown()
 
 	assert.NoError(t, err)
+	assert.NoError(t, store.Init(btltestutil.SampleBTLPolicy(nil)))
 	sampleData := sampleData(t)
 	for _, sampleDatum := range sampleData {
 		assert.NoError(t, store.CommitWithPvtData(sampleDatum))
@@ -99,6 +104,50 @@ func TestStore(t *testing.T) {
 	assert.Nil(t, blockAndPvtdata.BlockPvtData[2])
 }
 
+func TestStoreRollback(t *testing.T) {
+	testEnv := newTestEnv(t)
+	defer testEnv.cleanup()
+	provider := NewProvider()
+	defer provider.Close()
+	store, err := provider.Open("testLedger")
+	assert.NoError(t, err)
+	assert.NoError(t, store.Init(btltestutil.SampleBTLPolicy(nil)))
+	sampleData := sampleData(t)
+	for _, sampleDatum := range sampleData {
+		assert.NoError(t, store.CommitWithPvtData(sampleDatum))
+	}
+	store.Shutdown()
+
+	assert.NoError(t, provider.Rollback("testLedger", 2))
+	store, err = provider.Open("testLedger")
+	assert.NoError(t, err)
+	defer store.Shutdown()
+	assert.NoError(t, store.Init(btltestutil.SampleBTLPolicy(nil)))
+	bcInfo, err := store.GetBlockchainInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), bcInfo.Height)
+	pvtdataBlockHt, err := store.pvtdataStore.LastCommittedBlockHeight()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), pvtdataBlockHt)
+
+	// the retained blocks and pvt data remain intact
+	blockAndPvtdata, err := store.GetPvtDataAndBlockByNum(2, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, sampleData[2], blockAndPvtdata)
+
+	// the rolled back blocks and pvt data can be committed again
+	for _, sampleDatum := range sampleData[3:] {
+		assert.NoError(t, store.CommitWithPvtData(sampleDatum))
+	}
+	blockAndPvtdata, err = store.GetPvtDataAndBlockByNum(3, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, sampleData[3], blockAndPvtdata)
+
+	// a ledger cannot be rolled back to a block that it does not have
+	store.Shutdown()
+	assert.Error(t, provider.Rollback("testLedger", 10))
+}
+
 func TestStoreWithExistingBlockchain(t *testing.T) {
 	testLedgerid := "test-ledger"
 	testEnv := newTestEnv(t)
@@ -137,6 +186,8 @@ func TestStoreWithExistingBlockchain(t *testing.T) {
 	defer provider.Close()
 	store, err := provider.Open(testLedgerid)
 	defer store.Shutdown()
+	assert.NoError(t, err)
+	assert.NoError(t, store.Init(btltestutil.SampleBTLPolicy(nil)))
 
 	// test that pvtdata store is updated with info from existing block storage
 	pvtdataBlockHt, err := store.pvtdataStore.LastCommittedBlockHeight()
@@ -151,6 +202,52 @@ func TestStoreWithExistingBlockchain(t *testing.T) {
 	assert.Equal(t, uint64(10), pvtdataBlockHt)
 }
 
+func TestStoreWithMissingPvtData(t *testing.T) {
+	testEnv := newTestEnv(t)
+	defer testEnv.cleanup()
+	provider := NewProvider()
+	defer provider.Close()
+	store, err := provider.Open("testLedger")
+	defer store.Shutdown()
+	assert.NoError(t, err)
+	assert.NoError(t, store.Init(btltestutil.SampleBTLPolicy(nil)))
+
+	blocks := testutil.ConstructTestBlocks(t, 3)
+	// tx 1 in block 1 is invalid - its missing pvt data is not tracked
+	txsFilter := util.NewTxValidationFlags(len(blocks[1].Data.Data))
+	txsFilter.SetFlag(1, peer.TxValidationCode_MVCC_READ_CONFLICT)
+	blocks[1].Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = txsFilter
+
+	assert.NoError(t, store.CommitWithPvtData(&ledger.BlockAndPvtData{Block: blocks[0]}))
+	assert.NoError(t, store.CommitWithPvtData(&ledger.BlockAndPvtData{
+		Block: blocks[1],
+		Missing: []ledger.MissingPrivateData{
+			{SeqInBlock: 0, Namespace: "ns-1", Collection: "coll-1"},
+			{SeqInBlock: 1, Namespace: "ns-1", Collection: "coll-1"},
+		},
+	}))
+	assert.NoError(t, store.CommitWithPvtData(&ledger.BlockAndPvtData{Block: blocks[2]}))
+
+	expectedMissingPvtDataInfo := make(ledger.MissingPvtDataInfo)
+	expectedMissingPvtDataInfo.Add(1, 0, "ns-1", "coll-1")
+	missingPvtDataInfo, err := store.GetMissingPvtDataInfoForMostRecentBlocks(10)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedMissingPvtDataInfo, missingPvtDataInfo)
+
+	pvtdata := samplePvtData(t, []uint64{0})
+	assert.NoError(t, store.CommitPvtDataOfOldBlocks([]*ledger.BlockPvtData{{BlockNum: 1, WriteSets: pvtdata}}))
+	missingPvtDataInfo, err = store.GetMissingPvtDataInfoForMostRecentBlocks(10)
+	assert.NoError(t, err)
+	assert.Empty(t, missingPvtDataInfo)
+
+	// only the pvt data of the missing collection is committed
+	retrievedPvtdata, err := store.GetPvtDataByNum(1, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(retrievedPvtdata))
+	assert.True(t, retrievedPvtdata[0].Has("ns-1", "coll-1"))
+	assert.False(t, retrievedPvtdata[0].Has("ns-1", "coll-2"))
+}
+
 func sampleData(t *testing.T) []*ledger.BlockAndPvtData {
 	var blockAndpvtdata []*ledger.BlockAndPvtData
 	blocks := testutil.ConstructTestBlocks(t, 10)
@@ -0,0 +1,109 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pvtdatapolicy
+
+import (
+	"math"
+	"sync"
+
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+var logger = flogging.MustGetLogger("pvtdatapolicy")
+
+var defaultBTL uint64 = math.MaxUint64
+
+// BTLPolicy BlockToLive policy for the pvt data
+type BTLPolicy interface {
+	// GetBTL returns BlockToLive for a given namespace and collection
+	GetBTL(ns string, coll string) (uint64, error)
+	// GetExpiringBlock returns the block number by which the pvtdata for given namespace,collection, and committingBlock should expire
+	GetExpiringBlock(namesapce string, collection string, committingBlock uint64) (uint64, error)
+}
+
+// CollectionInfoProvider provides the configuration of a collection
+type CollectionInfoProvider interface {
+	// CollectionInfo returns the configuration of the collection `collectionName` defined by the chaincode `chaincodeName`.
+	// A nil config and a nil error are expected to be returned if the collection is not (yet) defined
+	CollectionInfo(chaincodeName, collectionName string) (*common.StaticCollectionConfig, error)
+}
+
+// LSCCBasedBTLPolicy implements interface BTLPolicy.
+// This implementation loads the BTL policy from lscc namespace which is populated
+// with the collection configuration during chaincode initialization
+type LSCCBasedBTLPolicy struct {
+	collInfoProvider CollectionInfoProvider
+	cache            map[btlkey]uint64
+	lock             sync.RWMutex
+}
+
+type btlkey struct {
+	ns   string
+	coll string
+}
+
+// NewBTLPolicy constructs an instance of LSCCBasedBTLPolicy
+func NewBTLPolicy(collInfoProvider CollectionInfoProvider) BTLPolicy {
+	return &LSCCBasedBTLPolicy{
+		collInfoProvider: collInfoProvider,
+		cache:            make(map[btlkey]uint64),
+	}
+}
+
+// GetBTL implements corresponding function in interface `BTLPolicy`
+func (p *LSCCBasedBTLPolicy) GetBTL(namesapce string, collection string) (uint64, error) {
+	var btl uint64
+	var found bool
+	key := btlkey{namesapce, collection}
+	p.lock.RLock()
+	btl, found = p.cache[key]
+	p.lock.RUnlock()
+	if found {
+		return btl, nil
+	}
+
+	collConfig, err := p.collInfoProvider.CollectionInfo(namesapce, collection)
+	if err != nil {
+		return 0, err
+	}
+	if collConfig == nil {
+		// the collection is not (yet) known. The data is retained forever and the result is not cached
+		// so that a collection that gets defined later is picked up
+		logger.Debugf("No collection config found for namespace [%s], collection [%s]. Treating BTL as infinite", namesapce, collection)
+		return defaultBTL, nil
+	}
+	btlConfigured := collConfig.GetBlockToLive()
+	if btlConfigured > 0 {
+		btl = btlConfigured
+	} else {
+		btl = defaultBTL
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.cache[key] = btl
+	return btl, nil
+}
+
+// GetExpiringBlock implements function from the interface `BTLPolicy`
+func (p *LSCCBasedBTLPolicy) GetExpiringBlock(namesapce string, collection string, committingBlock uint64) (uint64, error) {
+	btl, err := p.GetBTL(namesapce, collection)
+	if err != nil {
+		return 0, err
+	}
+	return ComputeExpiringBlock(btl, committingBlock), nil
+}
+
+// ComputeExpiringBlock returns the block number at which the data committed at `committingBlock`
+// expires under the given `btl`. A return value of math.MaxUint64 indicates that the data never expires
+func ComputeExpiringBlock(btl uint64, committingBlock uint64) uint64 {
+	expiryBlk := committingBlock + btl + uint64(1)
+	if expiryBlk <= committingBlock { // committingBlk + btl overflows uint64-max
+		expiryBlk = math.MaxUint64
+	}
+	return expiryBlk
+}
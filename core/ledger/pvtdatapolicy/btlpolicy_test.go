@@ -0,0 +1,95 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pvtdatapolicy
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/hyperledger/fabric/protos/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBTLPolicy(t *testing.T) {
+	collInfoProvider := &mockCollectionInfoProvider{
+		configs: map[[2]string]*common.StaticCollectionConfig{
+			{"ns1", "coll1"}: {Name: "coll1", BlockToLive: 100},
+			{"ns1", "coll2"}: {Name: "coll2", BlockToLive: 0},
+		},
+	}
+	btlPolicy := NewBTLPolicy(collInfoProvider)
+
+	btl1, err := btlPolicy.GetBTL("ns1", "coll1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100), btl1)
+
+	btl2, err := btlPolicy.GetBTL("ns1", "coll2")
+	assert.NoError(t, err)
+	assert.Equal(t, defaultBTL, btl2)
+
+	btl3, err := btlPolicy.GetBTL("ns1", "coll3")
+	assert.NoError(t, err)
+	assert.Equal(t, defaultBTL, btl3)
+
+	expiringBlk, err := btlPolicy.GetExpiringBlock("ns1", "coll1", 50)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(151), expiringBlk)
+
+	expiringBlk, err = btlPolicy.GetExpiringBlock("ns1", "coll2", 50)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(math.MaxUint64), expiringBlk)
+
+	expiringBlk, err = btlPolicy.GetExpiringBlock("ns1", "coll3", 50)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(math.MaxUint64), expiringBlk)
+}
+
+func TestBTLPolicyCache(t *testing.T) {
+	collInfoProvider := &mockCollectionInfoProvider{
+		configs: map[[2]string]*common.StaticCollectionConfig{
+			{"ns1", "coll1"}: {Name: "coll1", BlockToLive: 100},
+		},
+	}
+	btlPolicy := NewBTLPolicy(collInfoProvider)
+
+	// an undefined collection is looked up again
+	btlPolicy.GetBTL("ns1", "coll2")
+	collInfoProvider.configs[[2]string{"ns1", "coll2"}] = &common.StaticCollectionConfig{Name: "coll2", BlockToLive: 10}
+	btl, err := btlPolicy.GetBTL("ns1", "coll2")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), btl)
+
+	// a defined collection is served from the cache
+	btlPolicy.GetBTL("ns1", "coll1")
+	collInfoProvider.err = errors.New("collection info should not be looked up again")
+	btl, err = btlPolicy.GetBTL("ns1", "coll1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100), btl)
+
+	_, err = btlPolicy.GetBTL("ns1", "coll3")
+	assert.EqualError(t, err, "collection info should not be looked up again")
+}
+
+func TestComputeExpiringBlock(t *testing.T) {
+	assert.Equal(t, uint64(11), ComputeExpiringBlock(10, 0))
+	assert.Equal(t, uint64(111), ComputeExpiringBlock(10, 100))
+	assert.Equal(t, uint64(math.MaxUint64), ComputeExpiringBlock(math.MaxUint64, 100))
+	assert.Equal(t, uint64(math.MaxUint64), ComputeExpiringBlock(math.MaxUint64-100, 100))
+}
+
+type mockCollectionInfoProvider struct {
+	configs map[[2]string]*common.StaticCollectionConfig
+	err     error
+}
+
+func (p *mockCollectionInfoProvider) CollectionInfo(chaincodeName, collectionName string) (*common.StaticCollectionConfig, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.configs[[2]string{chaincodeName, collectionName}], nil
+}
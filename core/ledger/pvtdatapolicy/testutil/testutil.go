@@ -0,0 +1,30 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package testutil
+
+import (
+	"github.com/hyperledger/fabric/core/ledger/pvtdatapolicy"
+	"github.com/hyperledger/fabric/protos/common"
+)
+
+// SampleBTLPolicy constructs a BTLPolicy for the given map of <[ns, coll], btl>.
+// A collection that is not present in the map is treated as not defined
+func SampleBTLPolicy(m map[[2]string]uint64) pvtdatapolicy.BTLPolicy {
+	return pvtdatapolicy.NewBTLPolicy(&mockCollectionInfoProvider{m})
+}
+
+type mockCollectionInfoProvider struct {
+	btls map[[2]string]uint64
+}
+
+func (p *mockCollectionInfoProvider) CollectionInfo(chaincodeName, collectionName string) (*common.StaticCollectionConfig, error) {
+	btl, ok := p.btls[[2]string{chaincodeName, collectionName}]
+	if !ok {
+		return nil, nil
+	}
+	return &common.StaticCollectionConfig{Name: collectionName, BlockToLive: btl}, nil
+}
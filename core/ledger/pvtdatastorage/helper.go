@@ -0,0 +1,185 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pvtdatastorage
+
+import (
+	"math"
+
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/pvtdatapolicy"
+	"github.com/hyperledger/fabric/protos/ledger/rwset"
+)
+
+// missingDataKey identifies an entry in the missing data index, i.e., the pvt data
+// of a collection that is missing from a committed transaction
+type missingDataKey struct {
+	blkNum uint64
+	txNum  uint64
+	ns     string
+	coll   string
+}
+
+// prepareExpiryEntries returns the expiry data for the pvt data of a block, grouped by the expiring block number.
+// The expiry data covers both the pvt data that is present and the pvt data that is missing from the block.
+// The data that never expires is not included
+func prepareExpiryEntries(committingBlk uint64, pvtData []*ledger.TxPvtData, missingPvtData []ledger.MissingPrivateData,
+	btlPolicy pvtdatapolicy.BTLPolicy) (map[uint64]*ExpiryData, error) {
+	expiryEntries := make(map[uint64]*ExpiryData)
+	getExpiryData := func(ns, coll string) (*ExpiryData, error) {
+		expiringBlk, err := btlPolicy.GetExpiringBlock(ns, coll, committingBlk)
+		if err != nil || expiringBlk == math.MaxUint64 {
+			return nil, err
+		}
+		expiryData, ok := expiryEntries[expiringBlk]
+		if !ok {
+			expiryData = newExpiryData()
+			expiryEntries[expiringBlk] = expiryData
+		}
+		return expiryData, nil
+	}
+
+	for _, txPvtData := range pvtData {
+		if txPvtData.WriteSet == nil {
+			continue
+		}
+		for _, nsPvtRwset := range txPvtData.WriteSet.NsPvtRwset {
+			for _, collPvtRwset := range nsPvtRwset.CollectionPvtRwset {
+				expiryData, err := getExpiryData(nsPvtRwset.Namespace, collPvtRwset.CollectionName)
+				if err != nil {
+					return nil, err
+				}
+				if expiryData != nil {
+					expiryData.add(nsPvtRwset.Namespace, collPvtRwset.CollectionName, txPvtData.SeqInBlock)
+				}
+			}
+		}
+	}
+	for _, missing := range missingPvtData {
+		expiryData, err := getExpiryData(missing.Namespace, missing.Collection)
+		if err != nil {
+			return nil, err
+		}
+		if expiryData != nil {
+			expiryData.addMissing(missing.Namespace, missing.Collection, uint64(missing.SeqInBlock))
+		}
+	}
+	return expiryEntries, nil
+}
+
+func newExpiryData() *ExpiryData {
+	return &ExpiryData{Map: make(map[string]*Collections), MissingDataMap: make(map[string]*Collections)}
+}
+
+func (e *ExpiryData) add(ns, coll string, txNum uint64) {
+	if e.Map == nil {
+		e.Map = make(map[string]*Collections)
+	}
+	addTxNum(e.Map, ns, coll, txNum)
+}
+
+func (e *ExpiryData) addMissing(ns, coll string, txNum uint64) {
+	if e.MissingDataMap == nil {
+		e.MissingDataMap = make(map[string]*Collections)
+	}
+	addTxNum(e.MissingDataMap, ns, coll, txNum)
+}
+
+// missingDataCommitted moves the entry of the pvt data of a collection from the missing data to the committed data
+func (e *ExpiryData) missingDataCommitted(ns, coll string, txNum uint64) {
+	if txNums := e.MissingDataMap[ns].GetMap()[coll]; txNums != nil {
+		var retained []uint64
+		for _, t := range txNums.List {
+			if t != txNum {
+				retained = append(retained, t)
+			}
+		}
+		txNums.List = retained
+	}
+	e.add(ns, coll, txNum)
+}
+
+func addTxNum(m map[string]*Collections, ns, coll string, txNum uint64) {
+	collections, ok := m[ns]
+	if !ok {
+		collections = &Collections{Map: make(map[string]*TxNums)}
+		m[ns] = collections
+	}
+	txNums, ok := collections.Map[coll]
+	if !ok {
+		txNums = &TxNums{}
+		collections.Map[coll] = txNums
+	}
+	txNums.List = append(txNums.List, txNum)
+}
+
+// expiredTxColls captures, for a single transaction, the collections whose data has expired
+type expiredTxColls map[uint64]ledger.PvtNsCollFilter
+
+func (e expiredTxColls) add(expiryData *ExpiryData) {
+	for ns, collections := range expiryData.Map {
+		for coll, txNums := range collections.Map {
+			for _, txNum := range txNums.List {
+				filter, ok := e[txNum]
+				if !ok {
+					filter = ledger.NewPvtNsCollFilter()
+					e[txNum] = filter
+				}
+				filter.Add(ns, coll)
+			}
+		}
+	}
+}
+
+// addCollsToPvtWSet returns a `TxPvtReadWriteSet` that contains the collections of both the write sets.
+// The write sets are expected not to share any collection
+func addCollsToPvtWSet(pvtWSet *rwset.TxPvtReadWriteSet, toAdd *rwset.TxPvtReadWriteSet) *rwset.TxPvtReadWriteSet {
+	if pvtWSet == nil {
+		return toAdd
+	}
+	merged := &rwset.TxPvtReadWriteSet{DataModel: pvtWSet.GetDataModel()}
+	nsIndex := make(map[string]*rwset.NsPvtReadWriteSet)
+	for _, wset := range []*rwset.TxPvtReadWriteSet{pvtWSet, toAdd} {
+		for _, ns := range wset.NsPvtRwset {
+			nsRwSet, ok := nsIndex[ns.Namespace]
+			if !ok {
+				nsRwSet = &rwset.NsPvtReadWriteSet{Namespace: ns.Namespace}
+				nsIndex[ns.Namespace] = nsRwSet
+				merged.NsPvtRwset = append(merged.NsPvtRwset, nsRwSet)
+			}
+			nsRwSet.CollectionPvtRwset = append(nsRwSet.CollectionPvtRwset, ns.CollectionPvtRwset...)
+		}
+	}
+	return merged
+}
+
+// removeColls returns a `TxPvtReadWriteSet` that retains all but the list of 'ns/collections' supplied in the filter
+func removeColls(pvtWSet *rwset.TxPvtReadWriteSet, filter ledger.PvtNsCollFilter) *rwset.TxPvtReadWriteSet {
+	var retainedNsRwSet []*rwset.NsPvtReadWriteSet
+	for _, ns := range pvtWSet.NsPvtRwset {
+		var retainedCollRwSet []*rwset.CollectionPvtReadWriteSet
+		for _, coll := range ns.CollectionPvtRwset {
+			if !filter.Has(ns.Namespace, coll.CollectionName) {
+				retainedCollRwSet = append(retainedCollRwSet, coll)
+			}
+		}
+		if retainedCollRwSet != nil {
+			retainedNsRwSet = append(retainedNsRwSet,
+				&rwset.NsPvtReadWriteSet{
+					Namespace:          ns.Namespace,
+					CollectionPvtRwset: retainedCollRwSet,
+				},
+			)
+		}
+	}
+	if retainedNsRwSet == nil {
+		return nil
+	}
+	return &rwset.TxPvtReadWriteSet{
+		DataModel:  pvtWSet.GetDataModel(),
+		NsPvtRwset: retainedNsRwSet,
+	}
+}
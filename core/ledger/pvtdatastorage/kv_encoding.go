@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package pvtdatastorage
 
 import (
+	"bytes"
 	"math"
 
 	"github.com/golang/protobuf/proto"
@@ -15,10 +16,13 @@ import (
 )
 
 var (
-	pendingCommitKey    = []byte{0}
-	lastCommittedBlkkey = []byte{1}
-	pvtDataKeyPrefix    = []byte{2}
+	pendingCommitKey     = []byte{0}
+	lastCommittedBlkkey  = []byte{1}
+	pvtDataKeyPrefix     = []byte{2}
+	expiryKeyPrefix      = []byte{3}
+	missingDataKeyPrefix = []byte{4}
 
+	nilByte    = byte(0)
 	emptyValue = []byte{}
 )
 
@@ -37,6 +41,90 @@ func getKeysForRangeScanByBlockNum(blockNum uint64) (startKey []byte, endKey []b
 	return
 }
 
+func encodeExpiryKey(expiringBlk uint64, committingBlk uint64) []byte {
+	return append(expiryKeyPrefix, version.NewHeight(expiringBlk, committingBlk).ToBytes()...)
+}
+
+func decodeExpiryKey(key []byte) (expiringBlk uint64, committingBlk uint64) {
+	height, _ := version.NewHeightFromBytes(key[1:])
+	return height.BlockNum, height.TxNum
+}
+
+// getExpiryKeysForRangeScan returns the range that covers the expiry entries for the data
+// that expires at or before the block `maxExpiringBlk`
+func getExpiryKeysForRangeScan(maxExpiringBlk uint64) (startKey []byte, endKey []byte) {
+	startKey = encodeExpiryKey(0, 0)
+	endKey = encodeExpiryKey(maxExpiringBlk+1, 0)
+	return
+}
+
+// getExpiryKeysForRangeScanOfAllBlocks returns the range that covers all the expiry entries
+func getExpiryKeysForRangeScanOfAllBlocks() (startKey []byte, endKey []byte) {
+	startKey = expiryKeyPrefix
+	endKey = []byte{expiryKeyPrefix[0] + 1}
+	return
+}
+
+// encodeMissingDataKey encodes the key of an entry in the missing data index. The block number is
+// encoded in the reverse order so that the entries of the most recent blocks come first in a range scan
+func encodeMissingDataKey(key *missingDataKey) []byte {
+	encKey := append(missingDataKeyPrefix, version.NewHeight(math.MaxUint64-key.blkNum, key.txNum).ToBytes()...)
+	encKey = append(encKey, []byte(key.ns)...)
+	encKey = append(encKey, nilByte)
+	return append(encKey, []byte(key.coll)...)
+}
+
+func decodeMissingDataKey(encKey []byte) *missingDataKey {
+	height, n := version.NewHeightFromBytes(encKey[1:])
+	nsAndColl := bytes.SplitN(encKey[n+1:], []byte{nilByte}, 2)
+	return &missingDataKey{
+		blkNum: math.MaxUint64 - height.BlockNum,
+		txNum:  height.TxNum,
+		ns:     string(nsAndColl[0]),
+		coll:   string(nsAndColl[1]),
+	}
+}
+
+// getMissingDataKeysForRangeScanByBlockNum returns the range that covers the entries of the missing data index for a block
+func getMissingDataKeysForRangeScanByBlockNum(blockNum uint64) (startKey []byte, endKey []byte) {
+	startKey = append(missingDataKeyPrefix, version.NewHeight(math.MaxUint64-blockNum, 0).ToBytes()...)
+	endKey = append(missingDataKeyPrefix, version.NewHeight(math.MaxUint64-blockNum, math.MaxUint64).ToBytes()...)
+	return
+}
+
+// getMissingDataKeysForRangeScan returns the range that covers all the entries of the missing data index,
+// starting from the most recent block
+func getMissingDataKeysForRangeScan() (startKey []byte, endKey []byte) {
+	startKey = missingDataKeyPrefix
+	endKey = []byte{missingDataKeyPrefix[0] + 1}
+	return
+}
+
+// getMissingDataKeysForRangeScanAfterBlockNum returns the range that covers the entries of the missing data index
+// for all the blocks after the given block
+func getMissingDataKeysForRangeScanAfterBlockNum(blockNum uint64) (startKey []byte, endKey []byte) {
+	startKey = missingDataKeyPrefix
+	endKey = append(missingDataKeyPrefix, version.NewHeight(math.MaxUint64-blockNum, 0).ToBytes()...)
+	return
+}
+
+// getMissingDataKeysForRangeScanBeforeBlockNum returns the range that covers the entries of the missing data index
+// for all the blocks before the given block, starting from the most recent one
+func getMissingDataKeysForRangeScanBeforeBlockNum(blockNum uint64) (startKey []byte, endKey []byte) {
+	startKey = append(missingDataKeyPrefix, version.NewHeight(math.MaxUint64-blockNum+1, 0).ToBytes()...)
+	endKey = []byte{missingDataKeyPrefix[0] + 1}
+	return
+}
+
+func encodeExpiryData(expiryData *ExpiryData) ([]byte, error) {
+	return proto.Marshal(expiryData)
+}
+
+func decodeExpiryData(encodedBytes []byte) (*ExpiryData, error) {
+	expiryData := &ExpiryData{}
+	return expiryData, proto.Unmarshal(encodedBytes, expiryData)
+}
+
 func encodePvtRwSet(txPvtRwSet *rwset.TxPvtReadWriteSet) ([]byte, error) {
 	return proto.Marshal(txPvtRwSet)
 }
@@ -0,0 +1,123 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: persistent_msgs.proto
+
+/*
+Package pvtdatastorage is a generated protocol buffer package.
+
+It is generated from these files:
+	persistent_msgs.proto
+
+It has these top-level messages:
+	ExpiryData
+	Collections
+	TxNums
+*/
+package pvtdatastorage
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+// ExpiryData captures the tuples <namespace, collection, txNums> of the private
+// data that is committed with a block and expires at a particular block.
+// The tuples of the private data that is missing from the block are captured
+// separately, so that the corresponding entries of the missing data index are
+// purged when the missing data expires
+type ExpiryData struct {
+	Map            map[string]*Collections `protobuf:"bytes,1,rep,name=map" json:"map,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	MissingDataMap map[string]*Collections `protobuf:"bytes,2,rep,name=missing_data_map,json=missingDataMap" json:"missing_data_map,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *ExpiryData) Reset()                    { *m = ExpiryData{} }
+func (m *ExpiryData) String() string            { return proto.CompactTextString(m) }
+func (*ExpiryData) ProtoMessage()               {}
+func (*ExpiryData) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+func (m *ExpiryData) GetMap() map[string]*Collections {
+	if m != nil {
+		return m.Map
+	}
+	return nil
+}
+
+func (m *ExpiryData) GetMissingDataMap() map[string]*Collections {
+	if m != nil {
+		return m.MissingDataMap
+	}
+	return nil
+}
+
+type Collections struct {
+	Map map[string]*TxNums `protobuf:"bytes,1,rep,name=map" json:"map,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *Collections) Reset()                    { *m = Collections{} }
+func (m *Collections) String() string            { return proto.CompactTextString(m) }
+func (*Collections) ProtoMessage()               {}
+func (*Collections) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *Collections) GetMap() map[string]*TxNums {
+	if m != nil {
+		return m.Map
+	}
+	return nil
+}
+
+type TxNums struct {
+	List []uint64 `protobuf:"varint,1,rep,packed,name=list" json:"list,omitempty"`
+}
+
+func (m *TxNums) Reset()                    { *m = TxNums{} }
+func (m *TxNums) String() string            { return proto.CompactTextString(m) }
+func (*TxNums) ProtoMessage()               {}
+func (*TxNums) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *TxNums) GetList() []uint64 {
+	if m != nil {
+		return m.List
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*ExpiryData)(nil), "pvtdatastorage.ExpiryData")
+	proto.RegisterType((*Collections)(nil), "pvtdatastorage.Collections")
+	proto.RegisterType((*TxNums)(nil), "pvtdatastorage.TxNums")
+}
+
+func init() { proto.RegisterFile("persistent_msgs.proto", fileDescriptor0) }
+
+var fileDescriptor0 = []byte{
+	// 311 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x92, 0xcf, 0x4a, 0xf3, 0x40,
+	0x14, 0xc5, 0x99, 0xb4, 0x5f, 0xf9, 0xbc, 0x85, 0x52, 0x46, 0x94, 0x52, 0x5d, 0x94, 0xea, 0xa2,
+	0x0b, 0x99, 0x60, 0x45, 0x29, 0x5d, 0xaa, 0x5d, 0xda, 0x45, 0x74, 0x21, 0x2e, 0x2c, 0x93, 0x74,
+	0x4c, 0x07, 0x33, 0x99, 0x61, 0x66, 0x52, 0x9a, 0x07, 0xf1, 0xc5, 0x7c, 0x22, 0x49, 0xe2, 0x9f,
+	0x4e, 0x91, 0xac, 0xdc, 0x5d, 0xce, 0x3d, 0xf9, 0x9d, 0x93, 0xcb, 0xc0, 0x81, 0x62, 0xda, 0x70,
+	0x63, 0x59, 0x6a, 0x17, 0xc2, 0xc4, 0x86, 0x28, 0x2d, 0xad, 0xc4, 0x1d, 0xb5, 0xb6, 0x4b, 0x6a,
+	0xa9, 0xb1, 0x52, 0xd3, 0x98, 0x0d, 0xdf, 0x3d, 0x80, 0xd9, 0x46, 0x71, 0x9d, 0xdf, 0x52, 0x4b,
+	0xf1, 0x25, 0x34, 0x04, 0x55, 0x3d, 0x34, 0x68, 0x8c, 0xda, 0xe3, 0x13, 0xe2, 0x9a, 0xc9, 0x8f,
+	0x91, 0xdc, 0x51, 0x35, 0x4b, 0xad, 0xce, 0x83, 0xc2, 0x8f, 0x1f, 0xa1, 0x2b, 0xb8, 0x31, 0x3c,
+	0x8d, 0x17, 0x85, 0x7f, 0x51, 0x30, 0xbc, 0x92, 0x41, 0xea, 0x18, 0xd5, 0x27, 0xc5, 0xfc, 0x8d,
+	0xeb, 0x08, 0x47, 0xec, 0xdf, 0xc3, 0xff, 0xaf, 0x1d, 0xee, 0x42, 0xe3, 0x95, 0xe5, 0x3d, 0x34,
+	0x40, 0xa3, 0xbd, 0xa0, 0x18, 0xf1, 0x39, 0xfc, 0x5b, 0xd3, 0x24, 0x63, 0x3d, 0x6f, 0x80, 0x46,
+	0xed, 0xf1, 0xd1, 0x6e, 0xd8, 0x8d, 0x4c, 0x12, 0x16, 0x59, 0x2e, 0x53, 0x13, 0x54, 0xce, 0xa9,
+	0x37, 0x41, 0xfd, 0x67, 0xd8, 0xff, 0x25, 0xfb, 0xcf, 0xf8, 0xc3, 0x37, 0x04, 0xed, 0xad, 0x15,
+	0xbe, 0xda, 0xbe, 0xea, 0x69, 0x0d, 0xc4, 0x3d, 0x6b, 0x7f, 0x5e, 0xfb, 0xf3, 0x67, 0x6e, 0xb9,
+	0xc3, 0x5d, 0xee, 0xc3, 0x66, 0x9e, 0x09, 0xa7, 0xd7, 0x31, 0xb4, 0x2a, 0x11, 0x63, 0x68, 0x26,
+	0xdc, 0xd8, 0xb2, 0x52, 0x33, 0x28, 0xe7, 0xeb, 0xe9, 0xd3, 0x24, 0xe6, 0x76, 0x95, 0x85, 0x24,
+	0x92, 0xc2, 0x5f, 0xe5, 0x8a, 0xe9, 0x84, 0x2d, 0x63, 0xa6, 0xfd, 0x17, 0x1a, 0x6a, 0x1e, 0xf9,
+	0x91, 0xd4, 0xcc, 0xff, 0x94, 0xdc, 0xac, 0xb0, 0x55, 0xbe, 0xae, 0x8b, 0x8f, 0x01, 0x00, 0xcf,
+	0x7d, 0xcc, 0xd3, 0x76, 0x02, 0x00, 0x00,
+}
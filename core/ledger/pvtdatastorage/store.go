@@ -8,6 +8,7 @@ package pvtdatastorage
 
 import (
 	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/pvtdatapolicy"
 )
 
 // Provider provides handle to specific 'Store' that in turn manages
@@ -26,8 +27,14 @@ type Provider interface {
 // this store (via `Prepare` funtion) and then the block is appended to the block storage.
 // Finally, one of the functions `Commit` or `Rollback` is invoked on this store based
 // on whether the block was written successfully or not. The store implementation
-// is expected to survive a server crash between the call to `Prepare` and `Commit`/`Rollback`
+// is expected to survive a server crash between the call to `Prepare` and `Commit`/`Rollback`.
+// The store also keeps an index of the pvt data that is missing from the committed blocks, so
+// that the missing data can be fetched from other peers and committed later via the function
+// `CommitPvtDataOfOldBlocks`
 type Store interface {
+	// Init initializes the store. This function is expected to be invoked before using the store
+	// The `btlPolicy` is used for computing the block at which the pvt data of a collection expires
+	Init(btlPolicy pvtdatapolicy.BTLPolicy)
 	// InitLastCommittedBlockHeight sets the last commited block height into the pvt data store
 	// This function is used in a special case where the peer is started up with the blockchain
 	// from an earlier version of a peer when the pvt data feature (and hence this store) was not
@@ -38,18 +45,34 @@ type Store interface {
 	InitLastCommittedBlock(blockNum uint64) error
 	// GetPvtDataByBlockNum returns only the pvt data  corresponding to the given block number
 	// The pvt data is filtered by the list of 'ns/collections' supplied in the filter
-	// A nil filter does not filter any results
+	// A nil filter does not filter any results. The pvt data that has expired is not returned
 	GetPvtDataByBlockNum(blockNum uint64, filter ledger.PvtNsCollFilter) ([]*ledger.TxPvtData, error)
+	// GetMissingPvtDataInfoForMostRecentBlocks returns the missing pvt data information for at most
+	// `maxBlock` of the most recent blocks that miss some pvt data. The pvt data that has expired is not included
+	GetMissingPvtDataInfoForMostRecentBlocks(maxBlock int) (ledger.MissingPvtDataInfo, error)
+	// GetMissingPvtDataInfoForBlocksBelow returns the missing pvt data information for at most
+	// `maxBlock` of the most recent blocks before `blockNum` that miss some pvt data
+	GetMissingPvtDataInfoForBlocksBelow(blockNum uint64, maxBlock int) (ledger.MissingPvtDataInfo, error)
 	// Prepare prepares the Store for commiting the pvt data. This call does not commit the pvt data.
+	// The `missingPvtData` is the pvt data that is missing from the block and is added to the missing data index
 	// Subsequently, the caller is expected to call either `Commit` or `Rollback` function.
 	// Return from this should ensure that enough preparation is done such that `Commit` function invoked afterwards
 	// can commit the data and the store is capable of surviving a crash between this function call and the next
 	// invoke to the `Commit`
-	Prepare(blockNum uint64, pvtData []*ledger.TxPvtData) error
-	// Commit commits the pvt data passed in the previous invoke to the `Prepare` function
+	Prepare(blockNum uint64, pvtData []*ledger.TxPvtData, missingPvtData []ledger.MissingPrivateData) error
+	// Commit commits the pvt data passed in the previous invoke to the `Prepare` function.
+	// Periodically, the commit also purges the pvt data that has expired as per the `btlPolicy`
 	Commit() error
 	// Rollback rolls back the pvt data passed in the previous invoke to the `Prepare` function
 	Rollback() error
+	// CommitPvtDataOfOldBlocks commits the pvt data of already committed blocks, as a map of block number
+	// to the pvt data of the block. Only the pvt data of the collections that is present in the missing data
+	// index, and has not expired, is committed. The committed data is removed from the missing data index
+	CommitPvtDataOfOldBlocks(blocksPvtData map[uint64][]*ledger.TxPvtData) error
+	// RollbackToBlock removes the pvt data, the missing data index entries and the expiry entries of all the
+	// blocks after the given block number, including the pending batch (if any), and makes the given block the
+	// last committed block. Unlike `Rollback`, this function is meant to be invoked only when the ledger is not in use
+	RollbackToBlock(blockNum uint64) error
 	// IsEmpty returns true if the store does not have any block committed yet
 	IsEmpty() (bool, error)
 	// LastCommittedBlockHeight returns the height of the last committed block
@@ -8,11 +8,13 @@ package pvtdatastorage
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
 	"github.com/hyperledger/fabric/core/ledger"
 	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/hyperledger/fabric/core/ledger/pvtdatapolicy"
 	"github.com/hyperledger/fabric/protos/ledger/rwset"
 )
 
@@ -25,6 +27,8 @@ type provider struct {
 type store struct {
 	db                 *leveldbhelper.DBHandle
 	ledgerid           string
+	btlPolicy          pvtdatapolicy.BTLPolicy
+	purgeInterval      uint64
 	isEmpty            bool
 	lastCommittedBlock uint64
 	batchPending       bool
@@ -34,15 +38,24 @@ type blkTranNumKey []byte
 
 // NewProvider instantiates a StoreProvider
 func NewProvider() Provider {
+	return newProvider(false)
+}
+
+// NewInMemoryProvider instantiates a StoreProvider that keeps the private data in the memory
+func NewInMemoryProvider() Provider {
+	return newProvider(true)
+}
+
+func newProvider(inMemory bool) Provider {
 	dbPath := ledgerconfig.GetPvtdataStorePath()
-	dbProvider := leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath})
+	dbProvider := leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dbPath, InMemory: inMemory})
 	return &provider{dbProvider: dbProvider}
 }
 
 // OpenStore returns a handle to a store
 func (p *provider) OpenStore(ledgerid string) (Store, error) {
 	dbHandle := p.dbProvider.GetDBHandle(ledgerid)
-	s := &store{db: dbHandle, ledgerid: ledgerid}
+	s := &store{db: dbHandle, ledgerid: ledgerid, purgeInterval: ledgerconfig.GetPvtdataStorePurgeInterval()}
 	if err := s.initState(); err != nil {
 		return nil, err
 	}
@@ -65,8 +78,13 @@ func (s *store) initState() error {
 	return nil
 }
 
+// Init implements the function in the interface `Store`
+func (s *store) Init(btlPolicy pvtdatapolicy.BTLPolicy) {
+	s.btlPolicy = btlPolicy
+}
+
 // Prepare implements the function in the interface `Store`
-func (s *store) Prepare(blockNum uint64, pvtData []*ledger.TxPvtData) error {
+func (s *store) Prepare(blockNum uint64, pvtData []*ledger.TxPvtData, missingPvtData []ledger.MissingPrivateData) error {
 	if s.batchPending {
 		return &ErrIllegalCall{`A pending batch exists as as result of last invoke to "Prepare" call.
 			 Invoke "Commit" or "Rollback" on the pending batch before invoking "Prepare" function`}
@@ -87,12 +105,34 @@ func (s *store) Prepare(blockNum uint64, pvtData []*ledger.TxPvtData) error {
 		logger.Debugf("Adding private data to LevelDB batch for block [%d], tran [%d]", blockNum, txPvtData.SeqInBlock)
 		batch.Put(key, value)
 	}
+	for _, missing := range missingPvtData {
+		logger.Debugf("Adding missing private data entry to LevelDB batch for block [%d], tran [%d], ns [%s], coll [%s]",
+			blockNum, missing.SeqInBlock, missing.Namespace, missing.Collection)
+		batch.Put(encodeMissingDataKey(&missingDataKey{
+			blkNum: blockNum,
+			txNum:  uint64(missing.SeqInBlock),
+			ns:     missing.Namespace,
+			coll:   missing.Collection,
+		}), emptyValue)
+	}
+	expiryEntries, err := prepareExpiryEntries(blockNum, pvtData, missingPvtData, s.btlPolicy)
+	if err != nil {
+		return err
+	}
+	for expiringBlk, expiryData := range expiryEntries {
+		if value, err = encodeExpiryData(expiryData); err != nil {
+			return err
+		}
+		logger.Debugf("Adding expiry entry to LevelDB batch for block [%d], expiring block [%d]", blockNum, expiringBlk)
+		batch.Put(encodeExpiryKey(expiringBlk, blockNum), value)
+	}
 	batch.Put(pendingCommitKey, emptyValue)
 	if err := s.db.WriteBatch(batch, true); err != nil {
 		return err
 	}
 	s.batchPending = true
-	logger.Debugf("Saved %d private data write sets for block [%d]", len(pvtData), blockNum)
+	logger.Debugf("Saved %d private data write sets and %d missing private data entries for block [%d]",
+		len(pvtData), len(missingPvtData), blockNum)
 	return nil
 }
 
@@ -106,6 +146,11 @@ func (s *store) Commit() error {
 	batch := leveldbhelper.NewUpdateBatch()
 	batch.Delete(pendingCommitKey)
 	batch.Put(lastCommittedBlkkey, encodeBlockNum(committingBlockNum))
+	if committingBlockNum%s.purgeInterval == 0 {
+		if err := s.addPurgeOperations(committingBlockNum, batch); err != nil {
+			return err
+		}
+	}
 	if err := s.db.WriteBatch(batch, true); err != nil {
 		return err
 	}
@@ -126,7 +171,7 @@ func (s *store) Rollback() error {
 	rollingbackBlockNum := s.nextBlockNum()
 	logger.Debugf("Rolling back private data for block [%d]", rollingbackBlockNum)
 
-	if pendingBatchKeys, err = s.retrievePendingBatchKeys(); err != nil {
+	if pendingBatchKeys, err = s.retrievePendingBatchKeys(rollingbackBlockNum); err != nil {
 		return err
 	}
 	batch := leveldbhelper.NewUpdateBatch()
@@ -168,12 +213,202 @@ func (s *store) GetPvtDataByBlockNum(blockNum uint64, filter ledger.PvtNsCollFil
 			return nil, err
 		}
 		logger.Debugf("Retrieved private data write set for block [%d] tran [%d]", bNum, tNum)
+		if pvtWSet, err = s.removeExpiredColls(bNum, pvtWSet); err != nil {
+			return nil, err
+		}
+		if pvtWSet == nil {
+			continue
+		}
 		filteredWSet := TrimPvtWSet(pvtWSet, filter)
 		pvtData = append(pvtData, &ledger.TxPvtData{SeqInBlock: tNum, WriteSet: filteredWSet})
 	}
 	return pvtData, nil
 }
 
+// GetMissingPvtDataInfoForMostRecentBlocks implements the function in the interface `Store`
+func (s *store) GetMissingPvtDataInfoForMostRecentBlocks(maxBlock int) (ledger.MissingPvtDataInfo, error) {
+	startKey, endKey := getMissingDataKeysForRangeScan()
+	return s.getMissingPvtDataInfo(startKey, endKey, maxBlock)
+}
+
+// GetMissingPvtDataInfoForBlocksBelow implements the function in the interface `Store`
+func (s *store) GetMissingPvtDataInfoForBlocksBelow(blockNum uint64, maxBlock int) (ledger.MissingPvtDataInfo, error) {
+	if blockNum == 0 {
+		return nil, nil
+	}
+	startKey, endKey := getMissingDataKeysForRangeScanBeforeBlockNum(blockNum)
+	return s.getMissingPvtDataInfo(startKey, endKey, maxBlock)
+}
+
+// getMissingPvtDataInfo returns the missing pvt data information for at most `maxBlock` of the most
+// recent committed blocks that miss some pvt data within the given range of the missing data index
+func (s *store) getMissingPvtDataInfo(startKey, endKey []byte, maxBlock int) (ledger.MissingPvtDataInfo, error) {
+	if maxBlock < 1 || s.isEmpty {
+		return nil, nil
+	}
+	missingPvtDataInfo := make(ledger.MissingPvtDataInfo)
+	itr := s.db.GetIterator(startKey, endKey)
+	defer itr.Release()
+	for itr.Next() {
+		key := decodeMissingDataKey(itr.Key())
+		if key.blkNum > s.lastCommittedBlock {
+			// the entry belongs to a pending batch
+			continue
+		}
+		if _, ok := missingPvtDataInfo[key.blkNum]; !ok && len(missingPvtDataInfo) == maxBlock {
+			break
+		}
+		expired, err := s.isExpired(key.ns, key.coll, key.blkNum)
+		if err != nil {
+			return nil, err
+		}
+		if expired {
+			continue
+		}
+		missingPvtDataInfo.Add(key.blkNum, key.txNum, key.ns, key.coll)
+	}
+	return missingPvtDataInfo, nil
+}
+
+// CommitPvtDataOfOldBlocks implements the function in the interface `Store`
+func (s *store) CommitPvtDataOfOldBlocks(blocksPvtData map[uint64][]*ledger.TxPvtData) error {
+	if s.batchPending {
+		return &ErrIllegalCall{`A pending batch exists as as result of last invoke to "Prepare" call.
+			 Invoke "Commit" or "Rollback" on the pending batch before invoking "CommitPvtDataOfOldBlocks" function`}
+	}
+	batch := leveldbhelper.NewUpdateBatch()
+	expiryEntries := make(map[expiryEntryKey]*ExpiryData)
+	for blkNum, pvtData := range blocksPvtData {
+		if s.isEmpty || blkNum > s.lastCommittedBlock {
+			return &ErrIllegalArgs{fmt.Sprintf("Last committed block=%d, block received=%d", s.lastCommittedBlock, blkNum)}
+		}
+		for _, txPvtData := range pvtData {
+			if err := s.addOldPvtDataToBatch(blkNum, txPvtData, expiryEntries, batch); err != nil {
+				return err
+			}
+		}
+	}
+	for key, expiryData := range expiryEntries {
+		value, err := encodeExpiryData(expiryData)
+		if err != nil {
+			return err
+		}
+		batch.Put(encodeExpiryKey(key.expiringBlk, key.committingBlk), value)
+	}
+	if err := s.db.WriteBatch(batch, true); err != nil {
+		return err
+	}
+	logger.Debugf("Committed private data of %d old blocks", len(blocksPvtData))
+	return nil
+}
+
+// expiryEntryKey identifies an expiry entry in the store
+type expiryEntryKey struct {
+	expiringBlk   uint64
+	committingBlk uint64
+}
+
+// addOldPvtDataToBatch adds to the batch the pvt data of the collections that is missing from a
+// transaction of an already committed block. The corresponding entries are removed from the missing
+// data index and the corresponding expiry entries, which are collected in the `expiryEntries` map, are
+// updated to track the data as committed
+func (s *store) addOldPvtDataToBatch(blkNum uint64, txPvtData *ledger.TxPvtData,
+	expiryEntries map[expiryEntryKey]*ExpiryData, batch *leveldbhelper.UpdateBatch) error {
+	if txPvtData.WriteSet == nil {
+		return nil
+	}
+	var toCommit *rwset.TxPvtReadWriteSet
+	for _, ns := range txPvtData.WriteSet.NsPvtRwset {
+		for _, coll := range ns.CollectionPvtRwset {
+			missingKey := encodeMissingDataKey(&missingDataKey{blkNum: blkNum, txNum: txPvtData.SeqInBlock, ns: ns.Namespace, coll: coll.CollectionName})
+			v, err := s.db.Get(missingKey)
+			if err != nil {
+				return err
+			}
+			if v == nil {
+				logger.Debugf("Ignoring private data of block [%d], tran [%d], ns [%s], coll [%s] as it is not missing",
+					blkNum, txPvtData.SeqInBlock, ns.Namespace, coll.CollectionName)
+				continue
+			}
+			expiringBlk, err := s.btlPolicy.GetExpiringBlock(ns.Namespace, coll.CollectionName, blkNum)
+			if err != nil {
+				return err
+			}
+			if expiringBlk <= s.lastCommittedBlock {
+				logger.Debugf("Ignoring private data of block [%d], tran [%d], ns [%s], coll [%s] as it has expired",
+					blkNum, txPvtData.SeqInBlock, ns.Namespace, coll.CollectionName)
+				continue
+			}
+			if expiringBlk != math.MaxUint64 {
+				expiryData, err := s.getExpiryEntry(expiryEntryKey{expiringBlk: expiringBlk, committingBlk: blkNum}, expiryEntries)
+				if err != nil {
+					return err
+				}
+				expiryData.missingDataCommitted(ns.Namespace, coll.CollectionName, txPvtData.SeqInBlock)
+			}
+			batch.Delete(missingKey)
+			toCommit = addCollsToPvtWSet(toCommit, &rwset.TxPvtReadWriteSet{
+				DataModel: txPvtData.WriteSet.GetDataModel(),
+				NsPvtRwset: []*rwset.NsPvtReadWriteSet{
+					{Namespace: ns.Namespace, CollectionPvtRwset: []*rwset.CollectionPvtReadWriteSet{coll}},
+				},
+			})
+		}
+	}
+	if toCommit == nil {
+		return nil
+	}
+
+	key := encodePK(blkNum, txPvtData.SeqInBlock)
+	existing, err := s.db.Get(key)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		existingWSet, err := decodePvtRwSet(existing)
+		if err != nil {
+			return err
+		}
+		toCommit = addCollsToPvtWSet(existingWSet, toCommit)
+	}
+	value, err := encodePvtRwSet(toCommit)
+	if err != nil {
+		return err
+	}
+	logger.Debugf("Adding missing private data to LevelDB batch for block [%d], tran [%d]", blkNum, txPvtData.SeqInBlock)
+	batch.Put(key, value)
+	return nil
+}
+
+// getExpiryEntry returns the expiry entry from the `expiryEntries` map, loading it from the store if not yet present
+func (s *store) getExpiryEntry(key expiryEntryKey, expiryEntries map[expiryEntryKey]*ExpiryData) (*ExpiryData, error) {
+	if expiryData, ok := expiryEntries[key]; ok {
+		return expiryData, nil
+	}
+	v, err := s.db.Get(encodeExpiryKey(key.expiringBlk, key.committingBlk))
+	if err != nil {
+		return nil, err
+	}
+	expiryData := newExpiryData()
+	if v != nil {
+		if expiryData, err = decodeExpiryData(v); err != nil {
+			return nil, err
+		}
+	}
+	expiryEntries[key] = expiryData
+	return expiryData, nil
+}
+
+// isExpired returns true if the pvt data of the collection committed with the given block
+// has expired with respect to the last committed block
+func (s *store) isExpired(ns, coll string, committingBlk uint64) (bool, error) {
+	expiringBlk, err := s.btlPolicy.GetExpiringBlock(ns, coll, committingBlk)
+	if err != nil {
+		return false, err
+	}
+	return expiringBlk <= s.lastCommittedBlock, nil
+}
+
 // InitLastCommittedBlock implements the function in the interface `Store`
 func (s *store) InitLastCommittedBlock(blockNum uint64) error {
 	if !(s.isEmpty && !s.batchPending) {
@@ -190,6 +425,54 @@ func (s *store) InitLastCommittedBlock(blockNum uint64) error {
 	return nil
 }
 
+// RollbackToBlock implements the function in the interface `Store`
+func (s *store) RollbackToBlock(blockNum uint64) error {
+	if s.isEmpty {
+		return &ErrIllegalCall{"The private data store is empty. RollbackToBlock() function call is not allowed"}
+	}
+	if blockNum > s.lastCommittedBlock {
+		return &ErrIllegalArgs{fmt.Sprintf("Cannot rollback to block number=%d, last committed block number=%d",
+			blockNum, s.lastCommittedBlock)}
+	}
+	batch := leveldbhelper.NewUpdateBatch()
+	dataStartKey, _ := getKeysForRangeScanByBlockNum(blockNum + 1)
+	dataEndKey := []byte{pvtDataKeyPrefix[0] + 1}
+	missingDataStartKey, missingDataEndKey := getMissingDataKeysForRangeScanAfterBlockNum(blockNum)
+	for _, keyRange := range [][2][]byte{{dataStartKey, dataEndKey}, {missingDataStartKey, missingDataEndKey}} {
+		itr := s.db.GetIterator(keyRange[0], keyRange[1])
+		for itr.Next() {
+			batch.Delete(itr.Key())
+		}
+		err := itr.Error()
+		itr.Release()
+		if err != nil {
+			return err
+		}
+	}
+	// the expiry entries are keyed by the expiring block first and hence, these need a full scan
+	startKey, endKey := getExpiryKeysForRangeScanOfAllBlocks()
+	itr := s.db.GetIterator(startKey, endKey)
+	for itr.Next() {
+		if _, committingBlk := decodeExpiryKey(itr.Key()); committingBlk > blockNum {
+			batch.Delete(itr.Key())
+		}
+	}
+	err := itr.Error()
+	itr.Release()
+	if err != nil {
+		return err
+	}
+	batch.Delete(pendingCommitKey)
+	batch.Put(lastCommittedBlkkey, encodeBlockNum(blockNum))
+	if err := s.db.WriteBatch(batch, true); err != nil {
+		return err
+	}
+	s.batchPending = false
+	s.lastCommittedBlock = blockNum
+	logger.Infof("Rolled back private data store to block [%d]", blockNum)
+	return nil
+}
+
 // LastCommittedBlockHeight implements the function in the interface `Store`
 func (s *store) LastCommittedBlockHeight() (uint64, error) {
 	if s.isEmpty {
@@ -220,15 +503,129 @@ func (s *store) nextBlockNum() uint64 {
 	return s.lastCommittedBlock + 1
 }
 
-func (s *store) retrievePendingBatchKeys() ([]blkTranNumKey, error) {
+// retrievePendingBatchKeys returns the data keys, the missing data keys and the expiry keys that were added by the
+// call to the function `Prepare` for the given block
+func (s *store) retrievePendingBatchKeys(blockNum uint64) ([]blkTranNumKey, error) {
 	var pendingBatchKeys []blkTranNumKey
-	itr := s.db.GetIterator(encodePK(s.nextBlockNum(), 0), nil)
+	var pendingPvtData []*ledger.TxPvtData
+	var pendingMissingPvtData []ledger.MissingPrivateData
+	startKey, endKey := getKeysForRangeScanByBlockNum(blockNum)
+	itr := s.db.GetIterator(startKey, endKey)
+	defer itr.Release()
 	for itr.Next() {
-		pendingBatchKeys = append(pendingBatchKeys, itr.Key())
+		_, tNum := decodePK(itr.Key())
+		pvtWSet, err := decodePvtRwSet(itr.Value())
+		if err != nil {
+			return nil, err
+		}
+		// copy the key as the underlying buffer is reused by the iterator
+		key := append([]byte{}, itr.Key()...)
+		pendingBatchKeys = append(pendingBatchKeys, key)
+		pendingPvtData = append(pendingPvtData, &ledger.TxPvtData{SeqInBlock: tNum, WriteSet: pvtWSet})
+	}
+	startKey, endKey = getMissingDataKeysForRangeScanByBlockNum(blockNum)
+	missingItr := s.db.GetIterator(startKey, endKey)
+	defer missingItr.Release()
+	for missingItr.Next() {
+		missing := decodeMissingDataKey(missingItr.Key())
+		pendingBatchKeys = append(pendingBatchKeys, append([]byte{}, missingItr.Key()...))
+		pendingMissingPvtData = append(pendingMissingPvtData,
+			ledger.MissingPrivateData{SeqInBlock: int(missing.txNum), Namespace: missing.ns, Collection: missing.coll})
+	}
+	expiryEntries, err := prepareExpiryEntries(blockNum, pendingPvtData, pendingMissingPvtData, s.btlPolicy)
+	if err != nil {
+		return nil, err
+	}
+	for expiringBlk := range expiryEntries {
+		pendingBatchKeys = append(pendingBatchKeys, encodeExpiryKey(expiringBlk, blockNum))
 	}
 	return pendingBatchKeys, nil
 }
 
+// addPurgeOperations adds to the batch the operations for removing the pvt data that has expired
+// at or before the block `maxExpiringBlk`. The expiry entries are ordered by the expiring block and an
+// entry is removed once processed, so a purge only scans the entries that became eligible since the last purge
+func (s *store) addPurgeOperations(maxExpiringBlk uint64, batch *leveldbhelper.UpdateBatch) error {
+	expiredData := make(map[uint64]expiredTxColls)
+	startKey, endKey := getExpiryKeysForRangeScan(maxExpiringBlk)
+	itr := s.db.GetIterator(startKey, endKey)
+	defer itr.Release()
+	for itr.Next() {
+		expiringBlk, committingBlk := decodeExpiryKey(itr.Key())
+		expiryData, err := decodeExpiryData(itr.Value())
+		if err != nil {
+			return err
+		}
+		logger.Debugf("Purging private data committed with block [%d] that expired at block [%d]", committingBlk, expiringBlk)
+		expiredTxs, ok := expiredData[committingBlk]
+		if !ok {
+			expiredTxs = make(expiredTxColls)
+			expiredData[committingBlk] = expiredTxs
+		}
+		expiredTxs.add(expiryData)
+		for ns, collections := range expiryData.MissingDataMap {
+			for coll, txNums := range collections.Map {
+				for _, txNum := range txNums.List {
+					batch.Delete(encodeMissingDataKey(&missingDataKey{blkNum: committingBlk, txNum: txNum, ns: ns, coll: coll}))
+				}
+			}
+		}
+		batch.Delete(encodeExpiryKey(expiringBlk, committingBlk))
+	}
+
+	for committingBlk, expiredTxs := range expiredData {
+		for txNum, expiredColls := range expiredTxs {
+			key := encodePK(committingBlk, txNum)
+			v, err := s.db.Get(key)
+			if err != nil {
+				return err
+			}
+			if v == nil {
+				continue
+			}
+			pvtWSet, err := decodePvtRwSet(v)
+			if err != nil {
+				return err
+			}
+			retainedWSet := removeColls(pvtWSet, expiredColls)
+			if retainedWSet == nil {
+				batch.Delete(key)
+				continue
+			}
+			if v, err = encodePvtRwSet(retainedWSet); err != nil {
+				return err
+			}
+			batch.Put(key, v)
+		}
+	}
+	return nil
+}
+
+// removeExpiredColls removes from the pvt write set the collections whose data has expired with respect to the last
+// committed block. This covers the expired data that has not yet been purged from the store because of the purge interval
+func (s *store) removeExpiredColls(committingBlk uint64, pvtWSet *rwset.TxPvtReadWriteSet) (*rwset.TxPvtReadWriteSet, error) {
+	var expiredColls ledger.PvtNsCollFilter
+	for _, ns := range pvtWSet.NsPvtRwset {
+		for _, coll := range ns.CollectionPvtRwset {
+			expiringBlk, err := s.btlPolicy.GetExpiringBlock(ns.Namespace, coll.CollectionName, committingBlk)
+			if err != nil {
+				return nil, err
+			}
+			if expiringBlk > s.lastCommittedBlock {
+				continue
+			}
+			if expiredColls == nil {
+				expiredColls = ledger.NewPvtNsCollFilter()
+			}
+			expiredColls.Add(ns.Namespace, coll.CollectionName)
+		}
+	}
+	if expiredColls == nil {
+		return pvtWSet, nil
+	}
+	return removeColls(pvtWSet, expiredColls), nil
+}
+
 func (s *store) hasPendingCommit() (bool, error) {
 	var v []byte
 	var err error
@@ -7,11 +7,13 @@ SPDX-License-Identifier: Apache-2.0
 package pvtdatastorage
 
 import (
+	"fmt"
 	"os"
 	"testing"
 
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/core/ledger"
+	btltestutil "github.com/hyperledger/fabric/core/ledger/pvtdatapolicy/testutil"
 	"github.com/hyperledger/fabric/protos/ledger/rwset"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -24,7 +26,7 @@ func TestMain(m *testing.M) {
 }
 
 func TestEmptyStore(t *testing.T) {
-	env := NewTestStoreEnv(t)
+	env := NewTestStoreEnv(t, btltestutil.SampleBTLPolicy(nil))
 	defer env.Cleanup()
 	assert := assert.New(t)
 	store := env.TestStore
@@ -33,22 +35,22 @@ func TestEmptyStore(t *testing.T) {
 }
 
 func TestStoreBasicCommitAndRetrieval(t *testing.T) {
-	env := NewTestStoreEnv(t)
+	env := NewTestStoreEnv(t, btltestutil.SampleBTLPolicy(nil))
 	defer env.Cleanup()
 	assert := assert.New(t)
 	store := env.TestStore
 	testData := samplePvtData(t, []uint64{2, 4})
 
 	// no pvt data with block 0
-	assert.NoError(store.Prepare(0, nil))
+	assert.NoError(store.Prepare(0, nil, nil))
 	assert.NoError(store.Commit())
 
 	// pvt data with block 1 - commit
-	assert.NoError(store.Prepare(1, testData))
+	assert.NoError(store.Prepare(1, testData, nil))
 	assert.NoError(store.Commit())
 
 	// pvt data with block 2 - rollback
-	assert.NoError(store.Prepare(2, testData))
+	assert.NoError(store.Prepare(2, testData, nil))
 	assert.NoError(store.Rollback())
 
 	// pvt data retrieval for block 0 should return nil
@@ -80,25 +82,25 @@ func TestStoreBasicCommitAndRetrieval(t *testing.T) {
 }
 
 func TestStoreState(t *testing.T) {
-	env := NewTestStoreEnv(t)
+	env := NewTestStoreEnv(t, btltestutil.SampleBTLPolicy(nil))
 	defer env.Cleanup()
 	assert := assert.New(t)
 	store := env.TestStore
 	testData := samplePvtData(t, []uint64{0})
 
-	_, ok := store.Prepare(1, testData).(*ErrIllegalArgs)
+	_, ok := store.Prepare(1, testData, nil).(*ErrIllegalArgs)
 	assert.True(ok)
 
-	assert.Nil(store.Prepare(0, testData))
+	assert.Nil(store.Prepare(0, testData, nil))
 	assert.NoError(store.Commit())
 
-	assert.Nil(store.Prepare(1, testData))
-	_, ok = store.Prepare(2, testData).(*ErrIllegalCall)
+	assert.Nil(store.Prepare(1, testData, nil))
+	_, ok = store.Prepare(2, testData, nil).(*ErrIllegalCall)
 	assert.True(ok)
 }
 
 func TestInitLastCommittedBlock(t *testing.T) {
-	env := NewTestStoreEnv(t)
+	env := NewTestStoreEnv(t, btltestutil.SampleBTLPolicy(nil))
 	defer env.Cleanup()
 	assert := assert.New(t)
 	store := env.TestStore
@@ -119,8 +121,368 @@ func TestInitLastCommittedBlock(t *testing.T) {
 	assert.True(ok)
 }
 
+func TestStoreExpiry(t *testing.T) {
+	btlPolicy := btltestutil.SampleBTLPolicy(
+		map[[2]string]uint64{
+			{"ns-1", "coll-1"}: 1,
+			{"ns-1", "coll-2"}: 2,
+		},
+	)
+	env := NewTestStoreEnv(t, btlPolicy)
+	defer env.Cleanup()
+	assert := assert.New(t)
+	store := env.TestStore
+	testData := samplePvtData(t, []uint64{2, 4})
+
+	// no pvt data with block 0
+	assert.NoError(store.Prepare(0, nil, nil))
+	assert.NoError(store.Commit())
+
+	// write pvt data for block 1
+	assert.NoError(store.Prepare(1, testData, nil))
+	assert.NoError(store.Commit())
+
+	// write pvt data for block 2
+	assert.NoError(store.Prepare(2, nil, nil))
+	assert.NoError(store.Commit())
+	// data should not have expired
+	retrievedData, err := store.GetPvtDataByBlockNum(1, nil)
+	assert.NoError(err)
+	assert.Equal(testData, retrievedData)
+
+	// write pvt data for block 3
+	assert.NoError(store.Prepare(3, nil, nil))
+	assert.NoError(store.Commit())
+	// data for ns-1:coll-1 should have expired
+	retrievedData, err = store.GetPvtDataByBlockNum(1, nil)
+	assert.NoError(err)
+	assert.Equal(2, len(retrievedData))
+	for _, txPvtData := range retrievedData {
+		assert.False(txPvtData.Has("ns-1", "coll-1"))
+		assert.True(txPvtData.Has("ns-1", "coll-2"))
+		assert.True(txPvtData.Has("ns-2", "coll-1"))
+		assert.True(txPvtData.Has("ns-2", "coll-2"))
+	}
+
+	// write pvt data for block 4
+	assert.NoError(store.Prepare(4, nil, nil))
+	assert.NoError(store.Commit())
+	// data for ns-1:coll-2 should have expired as well
+	retrievedData, err = store.GetPvtDataByBlockNum(1, nil)
+	assert.NoError(err)
+	for _, txPvtData := range retrievedData {
+		assert.False(txPvtData.Has("ns-1", "coll-1"))
+		assert.False(txPvtData.Has("ns-1", "coll-2"))
+		assert.True(txPvtData.Has("ns-2", "coll-1"))
+		assert.True(txPvtData.Has("ns-2", "coll-2"))
+	}
+}
+
+func TestStorePurge(t *testing.T) {
+	viper.Set("ledger.pvtdataStore.purgeInterval", 2)
+	defer viper.Set("ledger.pvtdataStore.purgeInterval", 100)
+	btlPolicy := btltestutil.SampleBTLPolicy(
+		map[[2]string]uint64{
+			{"ns-1", "coll-1"}: 1,
+			{"ns-1", "coll-2"}: 1,
+			{"ns-2", "coll-1"}: 1,
+			{"ns-2", "coll-2"}: 3,
+		},
+	)
+	env := NewTestStoreEnv(t, btlPolicy)
+	defer env.Cleanup()
+	assert := assert.New(t)
+	s := env.TestStore.(*store)
+	testData := samplePvtData(t, []uint64{2, 4})
+
+	assert.NoError(s.Prepare(0, nil, nil))
+	assert.NoError(s.Commit())
+	assert.NoError(s.Prepare(1, testData, nil))
+	assert.NoError(s.Commit())
+	testDataKeyExists(t, s, 1, 2, true)
+	testExpiryKeyExists(t, s, 3, 1, true)
+	testExpiryKeyExists(t, s, 5, 1, true)
+
+	// block 3 is not a purge interval - the expired data is only filtered out
+	for blk := uint64(2); blk <= 3; blk++ {
+		assert.NoError(s.Prepare(blk, nil, nil))
+		assert.NoError(s.Commit())
+	}
+	testExpiryKeyExists(t, s, 3, 1, true)
+
+	// block 4 - the data that expired at block 3 gets purged
+	assert.NoError(s.Prepare(4, nil, nil))
+	assert.NoError(s.Commit())
+	testExpiryKeyExists(t, s, 3, 1, false)
+	testExpiryKeyExists(t, s, 5, 1, true)
+	retrievedData, err := s.GetPvtDataByBlockNum(1, nil)
+	assert.NoError(err)
+	assert.Equal(2, len(retrievedData))
+	for _, txPvtData := range retrievedData {
+		assert.Equal(1, len(txPvtData.WriteSet.NsPvtRwset))
+		assert.True(txPvtData.Has("ns-2", "coll-2"))
+	}
+	testDataKeyExists(t, s, 1, 2, true)
+
+	// block 6 - the remaining data gets purged
+	for blk := uint64(5); blk <= 6; blk++ {
+		assert.NoError(s.Prepare(blk, nil, nil))
+		assert.NoError(s.Commit())
+	}
+	testExpiryKeyExists(t, s, 5, 1, false)
+	testDataKeyExists(t, s, 1, 2, false)
+	testDataKeyExists(t, s, 1, 4, false)
+	retrievedData, err = s.GetPvtDataByBlockNum(1, nil)
+	assert.NoError(err)
+	assert.Nil(retrievedData)
+}
+
+func TestStoreRollbackWithExpiryEntries(t *testing.T) {
+	btlPolicy := btltestutil.SampleBTLPolicy(
+		map[[2]string]uint64{
+			{"ns-1", "coll-1"}: 1,
+		},
+	)
+	env := NewTestStoreEnv(t, btlPolicy)
+	defer env.Cleanup()
+	assert := assert.New(t)
+	s := env.TestStore.(*store)
+	testData := samplePvtData(t, []uint64{2, 4})
+
+	assert.NoError(s.Prepare(0, nil, nil))
+	assert.NoError(s.Commit())
+	assert.NoError(s.Prepare(1, testData, nil))
+	testDataKeyExists(t, s, 1, 2, true)
+	testExpiryKeyExists(t, s, 3, 1, true)
+
+	assert.NoError(s.Rollback())
+	testDataKeyExists(t, s, 1, 2, false)
+	testDataKeyExists(t, s, 1, 4, false)
+	testExpiryKeyExists(t, s, 3, 1, false)
+}
+
+func TestStoreMissingPvtData(t *testing.T) {
+	viper.Set("ledger.pvtdataStore.purgeInterval", 2)
+	defer viper.Set("ledger.pvtdataStore.purgeInterval", 100)
+	btlPolicy := btltestutil.SampleBTLPolicy(
+		map[[2]string]uint64{
+			{"ns-1", "coll-1"}: 1,
+		},
+	)
+	env := NewTestStoreEnv(t, btlPolicy)
+	defer env.Cleanup()
+	assert := assert.New(t)
+	s := env.TestStore.(*store)
+
+	// no missing pvt data in an empty store
+	missingPvtDataInfo, err := s.GetMissingPvtDataInfoForMostRecentBlocks(10)
+	assert.NoError(err)
+	assert.Nil(missingPvtDataInfo)
+
+	assert.NoError(s.Prepare(0, nil, nil))
+	assert.NoError(s.Commit())
+
+	// block 1 - pvt data of tx 2 is available, some collections of tx 4 and tx 6 are missing
+	block1PvtData := []*ledger.TxPvtData{samplePvtDataOfColls(2, [2]string{"ns-1", "coll-1"}, [2]string{"ns-2", "coll-1"})}
+	block1MissingData := []ledger.MissingPrivateData{
+		{TxId: "tx4", SeqInBlock: 4, Namespace: "ns-1", Collection: "coll-1"},
+		{TxId: "tx4", SeqInBlock: 4, Namespace: "ns-2", Collection: "coll-2"},
+		{TxId: "tx6", SeqInBlock: 6, Namespace: "ns-1", Collection: "coll-1"},
+		{TxId: "tx6", SeqInBlock: 6, Namespace: "ns-2", Collection: "coll-1"},
+	}
+	assert.NoError(s.Prepare(1, block1PvtData, block1MissingData))
+	assert.NoError(s.Commit())
+
+	// block 2 - collection of tx 1 is missing. The missing data of a pending batch is not reported
+	block2MissingData := []ledger.MissingPrivateData{
+		{TxId: "tx1", SeqInBlock: 1, Namespace: "ns-2", Collection: "coll-1"},
+	}
+	assert.NoError(s.Prepare(2, nil, block2MissingData))
+	missingPvtDataInfo, err = s.GetMissingPvtDataInfoForMostRecentBlocks(10)
+	assert.NoError(err)
+	assert.Len(missingPvtDataInfo, 1)
+	assert.NoError(s.Commit())
+
+	expectedMissingPvtDataInfo := make(ledger.MissingPvtDataInfo)
+	expectedMissingPvtDataInfo.Add(1, 4, "ns-1", "coll-1")
+	expectedMissingPvtDataInfo.Add(1, 4, "ns-2", "coll-2")
+	expectedMissingPvtDataInfo.Add(1, 6, "ns-1", "coll-1")
+	expectedMissingPvtDataInfo.Add(1, 6, "ns-2", "coll-1")
+	expectedMissingPvtDataInfo.Add(2, 1, "ns-2", "coll-1")
+	missingPvtDataInfo, err = s.GetMissingPvtDataInfoForMostRecentBlocks(10)
+	assert.NoError(err)
+	assert.Equal(expectedMissingPvtDataInfo, missingPvtDataInfo)
+
+	// only the most recent block is returned
+	missingPvtDataInfo, err = s.GetMissingPvtDataInfoForMostRecentBlocks(1)
+	assert.NoError(err)
+	assert.Len(missingPvtDataInfo, 1)
+	assert.Contains(missingPvtDataInfo, uint64(2))
+
+	// only the blocks before the given block are returned
+	missingPvtDataInfo, err = s.GetMissingPvtDataInfoForBlocksBelow(2, 10)
+	assert.NoError(err)
+	assert.Len(missingPvtDataInfo, 1)
+	assert.Contains(missingPvtDataInfo, uint64(1))
+	missingPvtDataInfo, err = s.GetMissingPvtDataInfoForBlocksBelow(1, 10)
+	assert.NoError(err)
+	assert.Empty(missingPvtDataInfo)
+	missingPvtDataInfo, err = s.GetMissingPvtDataInfoForBlocksBelow(0, 10)
+	assert.NoError(err)
+	assert.Empty(missingPvtDataInfo)
+
+	// the pvt data of the collections of tx 4 that are not missing is ignored
+	oldBlocksPvtData := map[uint64][]*ledger.TxPvtData{
+		1: {
+			samplePvtDataOfColls(4, [2]string{"ns-1", "coll-1"}, [2]string{"ns-1", "coll-2"}, [2]string{"ns-2", "coll-2"}),
+			samplePvtDataOfColls(2, [2]string{"ns-2", "coll-2"}),
+		},
+	}
+	assert.NoError(s.CommitPvtDataOfOldBlocks(oldBlocksPvtData))
+	retrievedData, err := s.GetPvtDataByBlockNum(1, nil)
+	assert.NoError(err)
+	assert.Equal(2, len(retrievedData))
+	assert.Equal(block1PvtData[0], retrievedData[0])
+	assert.Equal(samplePvtDataOfColls(4, [2]string{"ns-1", "coll-1"}, [2]string{"ns-2", "coll-2"}), retrievedData[1])
+
+	expectedMissingPvtDataInfo = make(ledger.MissingPvtDataInfo)
+	expectedMissingPvtDataInfo.Add(1, 6, "ns-1", "coll-1")
+	expectedMissingPvtDataInfo.Add(1, 6, "ns-2", "coll-1")
+	expectedMissingPvtDataInfo.Add(2, 1, "ns-2", "coll-1")
+	missingPvtDataInfo, err = s.GetMissingPvtDataInfoForMostRecentBlocks(10)
+	assert.NoError(err)
+	assert.Equal(expectedMissingPvtDataInfo, missingPvtDataInfo)
+
+	// the missing data is still reported after reopening the store
+	env.CloseAndReopen()
+	s = env.TestStore.(*store)
+	missingPvtDataInfo, err = s.GetMissingPvtDataInfoForMostRecentBlocks(10)
+	assert.NoError(err)
+	assert.Equal(expectedMissingPvtDataInfo, missingPvtDataInfo)
+
+	// pvt data of blocks that are not yet committed is not accepted
+	_, ok := s.CommitPvtDataOfOldBlocks(map[uint64][]*ledger.TxPvtData{3: {samplePvtDataOfColls(1, [2]string{"ns-2", "coll-1"})}}).(*ErrIllegalArgs)
+	assert.True(ok)
+
+	// pvt data of old blocks is not accepted while a batch is pending
+	assert.NoError(s.Prepare(3, nil, nil))
+	_, ok = s.CommitPvtDataOfOldBlocks(oldBlocksPvtData).(*ErrIllegalCall)
+	assert.True(ok)
+	assert.NoError(s.Commit())
+
+	// the missing data of ns-1:coll-1 of block 1 has expired at block 3 and is neither reported nor committed anymore
+	expectedMissingPvtDataInfo = make(ledger.MissingPvtDataInfo)
+	expectedMissingPvtDataInfo.Add(1, 6, "ns-2", "coll-1")
+	expectedMissingPvtDataInfo.Add(2, 1, "ns-2", "coll-1")
+	missingPvtDataInfo, err = s.GetMissingPvtDataInfoForMostRecentBlocks(10)
+	assert.NoError(err)
+	assert.Equal(expectedMissingPvtDataInfo, missingPvtDataInfo)
+
+	assert.NoError(s.CommitPvtDataOfOldBlocks(map[uint64][]*ledger.TxPvtData{
+		1: {samplePvtDataOfColls(6, [2]string{"ns-1", "coll-1"}, [2]string{"ns-2", "coll-1"})},
+	}))
+	retrievedData, err = s.GetPvtDataByBlockNum(1, nil)
+	assert.NoError(err)
+	assert.Equal(3, len(retrievedData))
+	assert.Equal(samplePvtDataOfColls(6, [2]string{"ns-2", "coll-1"}), retrievedData[2])
+
+	// block 4 - the expired missing data entry gets purged
+	testMissingDataKeyExists(t, s, 1, 6, "ns-1", "coll-1", true)
+	assert.NoError(s.Prepare(4, nil, nil))
+	assert.NoError(s.Commit())
+	testMissingDataKeyExists(t, s, 1, 6, "ns-1", "coll-1", false)
+	testMissingDataKeyExists(t, s, 2, 1, "ns-2", "coll-1", true)
+}
+
+func TestStoreRollbackWithMissingData(t *testing.T) {
+	env := NewTestStoreEnv(t, btltestutil.SampleBTLPolicy(nil))
+	defer env.Cleanup()
+	assert := assert.New(t)
+	s := env.TestStore.(*store)
+
+	assert.NoError(s.Prepare(0, nil, nil))
+	assert.NoError(s.Commit())
+	missingData := []ledger.MissingPrivateData{
+		{TxId: "tx1", SeqInBlock: 1, Namespace: "ns-1", Collection: "coll-1"},
+	}
+	assert.NoError(s.Prepare(1, nil, missingData))
+	testMissingDataKeyExists(t, s, 1, 1, "ns-1", "coll-1", true)
+
+	assert.NoError(s.Rollback())
+	testMissingDataKeyExists(t, s, 1, 1, "ns-1", "coll-1", false)
+}
+
+func TestStoreRollbackToBlock(t *testing.T) {
+	btlPolicy := btltestutil.SampleBTLPolicy(
+		map[[2]string]uint64{
+			{"ns-1", "coll-1"}: 5,
+		},
+	)
+	env := NewTestStoreEnv(t, btlPolicy)
+	defer env.Cleanup()
+	assert := assert.New(t)
+	s := env.TestStore.(*store)
+
+	// rolling back an empty store is not allowed
+	_, ok := s.RollbackToBlock(0).(*ErrIllegalCall)
+	assert.True(ok)
+
+	assert.NoError(s.Prepare(0, nil, nil))
+	assert.NoError(s.Commit())
+	for blkNum := uint64(1); blkNum <= 3; blkNum++ {
+		missingData := []ledger.MissingPrivateData{
+			{TxId: "tx1", SeqInBlock: 1, Namespace: "ns-1", Collection: "coll-1"},
+		}
+		assert.NoError(s.Prepare(blkNum, samplePvtData(t, []uint64{2}), missingData))
+		assert.NoError(s.Commit())
+	}
+	// the pending batch of block 4 is also removed by the rollback
+	assert.NoError(s.Prepare(4, samplePvtData(t, []uint64{2}), nil))
+
+	_, ok = s.RollbackToBlock(4).(*ErrIllegalArgs)
+	assert.True(ok)
+	assert.NoError(s.RollbackToBlock(1))
+	testLastCommittedBlockHeight(2, assert, s)
+	testPendingBatch(false, assert, s)
+	testDataKeyExists(t, s, 1, 2, true)
+	testExpiryKeyExists(t, s, 7, 1, true)
+	testMissingDataKeyExists(t, s, 1, 1, "ns-1", "coll-1", true)
+	for blkNum := uint64(2); blkNum <= 4; blkNum++ {
+		testDataKeyExists(t, s, blkNum, 2, false)
+		testExpiryKeyExists(t, s, blkNum+6, blkNum, false)
+		testMissingDataKeyExists(t, s, blkNum, 1, "ns-1", "coll-1", false)
+	}
+
+	// the rollback should survive a restart and the store should accept the block following the retained blocks
+	env.CloseAndReopen()
+	s = env.TestStore.(*store)
+	testLastCommittedBlockHeight(2, assert, s)
+	testPendingBatch(false, assert, s)
+	assert.NoError(s.Prepare(2, samplePvtData(t, []uint64{2}), nil))
+	assert.NoError(s.Commit())
+	testLastCommittedBlockHeight(3, assert, s)
+}
+
 // TODO Add tests for simulating a crash between calls `Prepare` and `Commit`/`Rollback`
 
+func testDataKeyExists(t *testing.T, s *store, blkNum, txNum uint64, expectedExists bool) {
+	v, err := s.db.Get(encodePK(blkNum, txNum))
+	assert.NoError(t, err)
+	assert.Equal(t, expectedExists, v != nil)
+}
+
+func testExpiryKeyExists(t *testing.T, s *store, expiringBlk, committingBlk uint64, expectedExists bool) {
+	v, err := s.db.Get(encodeExpiryKey(expiringBlk, committingBlk))
+	assert.NoError(t, err)
+	assert.Equal(t, expectedExists, v != nil)
+}
+
+func testMissingDataKeyExists(t *testing.T, s *store, blkNum, txNum uint64, ns, coll string, expectedExists bool) {
+	v, err := s.db.Get(encodeMissingDataKey(&missingDataKey{blkNum: blkNum, txNum: txNum, ns: ns, coll: coll}))
+	assert.NoError(t, err)
+	assert.Equal(t, expectedExists, v != nil)
+}
+
 func testEmpty(expectedEmpty bool, assert *assert.Assertions, store Store) {
 	isEmpty, err := store.IsEmpty()
 	assert.NoError(err)
@@ -176,3 +538,25 @@ func samplePvtData(t *testing.T, txNums []uint64) []*ledger.TxPvtData {
 	}
 	return pvtData
 }
+
+func samplePvtDataOfColls(txNum uint64, nsColls ...[2]string) *ledger.TxPvtData {
+	pvtWriteSet := &rwset.TxPvtReadWriteSet{DataModel: rwset.TxReadWriteSet_KV}
+	for _, nsColl := range nsColls {
+		collPvtRwset := &rwset.CollectionPvtReadWriteSet{
+			CollectionName: nsColl[1],
+			Rwset:          []byte(fmt.Sprintf("RandomBytes-PvtRWSet-%s-%s", nsColl[0], nsColl[1])),
+		}
+		var nsPvtRwset *rwset.NsPvtReadWriteSet
+		for _, ns := range pvtWriteSet.NsPvtRwset {
+			if ns.Namespace == nsColl[0] {
+				nsPvtRwset = ns
+			}
+		}
+		if nsPvtRwset == nil {
+			nsPvtRwset = &rwset.NsPvtReadWriteSet{Namespace: nsColl[0]}
+			pvtWriteSet.NsPvtRwset = append(pvtWriteSet.NsPvtRwset, nsPvtRwset)
+		}
+		nsPvtRwset.CollectionPvtRwset = append(nsPvtRwset.CollectionPvtRwset, collPvtRwset)
+	}
+	return &ledger.TxPvtData{SeqInBlock: txNum, WriteSet: pvtWriteSet}
+}
@@ -11,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/hyperledger/fabric/core/ledger/ledgerconfig"
+	"github.com/hyperledger/fabric/core/ledger/pvtdatapolicy"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -21,16 +22,18 @@ type StoreEnv struct {
 	t                 testing.TB
 	TestStoreProvider Provider
 	TestStore         Store
+	btlPolicy         pvtdatapolicy.BTLPolicy
 }
 
 // NewTestStoreEnv construct a StoreEnv for testing
-func NewTestStoreEnv(t *testing.T) *StoreEnv {
+func NewTestStoreEnv(t *testing.T, btlPolicy pvtdatapolicy.BTLPolicy) *StoreEnv {
 	removeStorePath(t)
 	assert := assert.New(t)
 	testStoreProvider := NewProvider()
 	testStore, err := testStoreProvider.OpenStore(testStoreid)
 	assert.NoError(err)
-	return &StoreEnv{t, testStoreProvider, testStore}
+	testStore.Init(btlPolicy)
+	return &StoreEnv{t, testStoreProvider, testStore, btlPolicy}
 }
 
 // CloseAndReopen closes and opens the store provider
@@ -40,6 +43,7 @@ func (env *StoreEnv) CloseAndReopen() {
 	env.TestStoreProvider = NewProvider()
 	env.TestStore, err = env.TestStoreProvider.OpenStore(testStoreid)
 	assert.NoError(env.t, err)
+	env.TestStore.Init(env.btlPolicy)
 }
 
 // Cleanup cleansup the  store env after testing
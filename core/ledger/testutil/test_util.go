@@ -92,6 +92,10 @@ func ResetConfigToDefaultValues() {
 	viper.Set("ledger.state.couchDBConfig.queryLimit", 10000)
 	viper.Set("ledger.state.stateDatabase", "goleveldb")
 	viper.Set("ledger.history.enableHistoryDatabase", false)
+	viper.Set("ledger.pvtdataStore.purgeInterval", 100)
+	viper.Set("ledger.state.cache.size", 1000)
+	viper.Set("ledger.state.cache.namespaces", nil)
+	viper.Set("ledger.inMemory", false)
 	viper.Set("peer.fileSystemPath", "/var/hyperledger/production")
 }
 
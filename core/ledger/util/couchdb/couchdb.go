@@ -100,8 +100,9 @@ type RangeQueryResponse struct {
 
 //QueryResponse is used for processing REST query responses from CouchDB
 type QueryResponse struct {
-	Warning string            `json:"warning"`
-	Docs    []json.RawMessage `json:"docs"`
+	Warning  string            `json:"warning"`
+	Docs     []json.RawMessage `json:"docs"`
+	Bookmark string            `json:"bookmark"`
 }
 
 // DocMetadata is used for capturing CouchDB document header info,
@@ -397,6 +398,37 @@ func (couchInstance *CouchInstance) VerifyCouchConfig() (*ConnectionInfo, *DBRet
 	return dbResponse, couchDBReturn, nil
 }
 
+//RetrieveDatabaseNames method provides function to retrieve the names of all the databases in the couch instance
+func (couchInstance *CouchInstance) RetrieveDatabaseNames() ([]string, error) {
+
+	logger.Debugf("Entering RetrieveDatabaseNames()")
+	defer logger.Debugf("Exiting RetrieveDatabaseNames()")
+
+	connectURL, err := url.Parse(couchInstance.conf.URL)
+	if err != nil {
+		logger.Errorf("URL parse error: %s", err.Error())
+		return nil, err
+	}
+	connectURL.Path = "/_all_dbs"
+
+	//get the number of retries
+	maxRetries := couchInstance.conf.MaxRetries
+
+	resp, _, err := couchInstance.handleRequest(http.MethodGet, connectURL.String(), nil,
+		couchInstance.conf.Username, couchInstance.conf.Password, maxRetries, true)
+	if err != nil {
+		return nil, err
+	}
+	defer closeResponseBody(resp)
+
+	var dbNames []string
+	if err = json.NewDecoder(resp.Body).Decode(&dbNames); err != nil {
+		return nil, err
+	}
+	logger.Debugf("Retrieved database names: %s", dbNames)
+	return dbNames, nil
+}
+
 //DropDatabase provides method to drop an existing database
 func (dbclient *CouchDatabase) DropDatabase() (*DBOperationResponse, error) {
 
@@ -951,15 +983,22 @@ func (dbclient *CouchDatabase) DeleteDoc(id, rev string) error {
 
 //QueryDocuments method provides function for processing a query
 func (dbclient *CouchDatabase) QueryDocuments(query string) (*[]QueryResult, error) {
+	results, _, err := dbclient.QueryDocumentsWithBookmark(query)
+	return results, err
+}
 
-	logger.Debugf("Entering QueryDocuments()  query=%s", query)
+//QueryDocumentsWithBookmark method provides function for processing a query, and returns
+//the bookmark of CouchDB which can be added to the query for retrieving the next page of results
+func (dbclient *CouchDatabase) QueryDocumentsWithBookmark(query string) (*[]QueryResult, string, error) {
+
+	logger.Debugf("Entering QueryDocumentsWithBookmark()  query=%s", query)
 
 	var results []QueryResult
 
 	queryURL, err := url.Parse(dbclient.CouchInstance.conf.URL)
 	if err != nil {
 		logger.Errorf("URL parse error: %s", err.Error())
-		return nil, err
+		return nil, "", err
 	}
 
 	queryURL.Path = dbclient.DBName + "/_find"
@@ -969,7 +1008,7 @@ func (dbclient *CouchDatabase) QueryDocuments(query string) (*[]QueryResult, err
 
 	resp, _, err := dbclient.CouchInstance.handleRequest(http.MethodPost, queryURL.String(), []byte(query), "", "", maxRetries, true)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	defer closeResponseBody(resp)
 
@@ -984,14 +1023,14 @@ func (dbclient *CouchDatabase) QueryDocuments(query string) (*[]QueryResult, err
 	//handle as JSON document
 	jsonResponseRaw, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	var jsonResponse = &QueryResponse{}
 
 	err2 := json.Unmarshal(jsonResponseRaw, &jsonResponse)
 	if err2 != nil {
-		return nil, err2
+		return nil, "", err2
 	}
 
 	for _, row := range jsonResponse.Docs {
@@ -999,7 +1038,7 @@ func (dbclient *CouchDatabase) QueryDocuments(query string) (*[]QueryResult, err
 		var docMetadata = &DocMetadata{}
 		err3 := json.Unmarshal(row, &docMetadata)
 		if err3 != nil {
-			return nil, err3
+			return nil, "", err3
 		}
 
 		if docMetadata.AttachmentsInfo != nil {
@@ -1008,7 +1047,7 @@ func (dbclient *CouchDatabase) QueryDocuments(query string) (*[]QueryResult, err
 
 			couchDoc, _, err := dbclient.ReadDoc(docMetadata.ID)
 			if err != nil {
-				return nil, err
+				return nil, "", err
 			}
 			var addDocument = &QueryResult{ID: docMetadata.ID, Value: couchDoc.JSONValue, Attachments: couchDoc.Attachments}
 			results = append(results, *addDocument)
@@ -1021,9 +1060,9 @@ func (dbclient *CouchDatabase) QueryDocuments(query string) (*[]QueryResult, err
 
 		}
 	}
-	logger.Debugf("Exiting QueryDocuments()")
+	logger.Debugf("Exiting QueryDocumentsWithBookmark()")
 
-	return &results, nil
+	return &results, jsonResponse.Bookmark, nil
 
 }
 
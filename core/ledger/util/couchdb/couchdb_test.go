@@ -252,6 +252,31 @@ func TestDBCreateSaveWithoutRevision(t *testing.T) {
 	}
 }
 
+func TestRetrieveDatabaseNames(t *testing.T) {
+
+	if ledgerconfig.IsCouchDBEnabled() {
+
+		database := "testretrievedatabasenames"
+		err := cleanup(database)
+		testutil.AssertNoError(t, err, fmt.Sprintf("Error when trying to cleanup  Error: %s", err))
+		defer cleanup(database)
+
+		//create a new instance and database object
+		couchInstance, err := CreateCouchInstance(couchDBDef.URL, couchDBDef.Username, couchDBDef.Password,
+			couchDBDef.MaxRetries, couchDBDef.MaxRetriesOnStartup, couchDBDef.RequestTimeout)
+		testutil.AssertNoError(t, err, fmt.Sprintf("Error when trying to create couch instance"))
+		db := CouchDatabase{CouchInstance: *couchInstance, DBName: database}
+
+		//create a new database
+		_, errdb := db.CreateDatabaseIfNotExist()
+		testutil.AssertNoError(t, errdb, fmt.Sprintf("Error when trying to create database"))
+
+		dbNames, err := couchInstance.RetrieveDatabaseNames()
+		testutil.AssertNoError(t, err, fmt.Sprintf("Error when trying to retrieve database names"))
+		testutil.AssertContains(t, dbNames, database)
+	}
+}
+
 func TestDBCreateEnsureFullCommit(t *testing.T) {
 
 	if ledgerconfig.IsCouchDBEnabled() {
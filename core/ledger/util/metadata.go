@@ -0,0 +1,56 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package util
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/protos/ledger/rwset/kvrwset"
+)
+
+// MetadataMapToEntries converts the metadata of a key from a map to a list of entries sorted by name.
+// A nil is returned for a nil or empty map
+func MetadataMapToEntries(metadata map[string][]byte) []*kvrwset.KVMetadataEntry {
+	var entries []*kvrwset.KVMetadataEntry
+	for _, name := range GetSortedKeys(metadata) {
+		entries = append(entries, &kvrwset.KVMetadataEntry{Name: name, Value: metadata[name]})
+	}
+	return entries
+}
+
+// MetadataEntriesToMap converts the metadata of a key from a list of entries to a map.
+// A nil is returned for an empty list
+func MetadataEntriesToMap(entries []*kvrwset.KVMetadataEntry) map[string][]byte {
+	if len(entries) == 0 {
+		return nil
+	}
+	metadata := make(map[string][]byte)
+	for _, entry := range entries {
+		metadata[entry.Name] = entry.Value
+	}
+	return metadata
+}
+
+// SerializeMetadata serializes the metadata entries of a key for storing in the statedb.
+// A nil is returned for an empty list so that no metadata is stored for the key
+func SerializeMetadata(entries []*kvrwset.KVMetadataEntry) ([]byte, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	return proto.Marshal(&kvrwset.KVMetadataWrite{Entries: entries})
+}
+
+// DeserializeMetadata deserializes the metadata of a key, as stored in the statedb, to a map
+func DeserializeMetadata(metadataBytes []byte) (map[string][]byte, error) {
+	if metadataBytes == nil {
+		return nil, nil
+	}
+	metadata := &kvrwset.KVMetadataWrite{}
+	if err := proto.Unmarshal(metadataBytes, metadata); err != nil {
+		return nil, err
+	}
+	return MetadataEntriesToMap(metadata.Entries), nil
+}
@@ -0,0 +1,46 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package util
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/protos/ledger/rwset/kvrwset"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataMapToEntries(t *testing.T) {
+	assert.Nil(t, MetadataMapToEntries(nil))
+	assert.Nil(t, MetadataMapToEntries(map[string][]byte{}))
+
+	entries := MetadataMapToEntries(map[string][]byte{"name2": []byte("value2"), "name1": []byte("value1")})
+	assert.Equal(t, []*kvrwset.KVMetadataEntry{
+		{Name: "name1", Value: []byte("value1")},
+		{Name: "name2", Value: []byte("value2")},
+	}, entries)
+	assert.Equal(t, map[string][]byte{"name1": []byte("value1"), "name2": []byte("value2")}, MetadataEntriesToMap(entries))
+	assert.Nil(t, MetadataEntriesToMap(nil))
+}
+
+func TestSerializeDeserializeMetadata(t *testing.T) {
+	metadataBytes, err := SerializeMetadata(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, metadataBytes)
+	metadata, err := DeserializeMetadata(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, metadata)
+
+	metadataBytes, err = SerializeMetadata(MetadataMapToEntries(map[string][]byte{"name1": []byte("value1")}))
+	assert.NoError(t, err)
+	assert.NotNil(t, metadataBytes)
+	metadata, err = DeserializeMetadata(metadataBytes)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"name1": []byte("value1")}, metadata)
+
+	_, err = DeserializeMetadata([]byte("junk"))
+	assert.Error(t, err)
+}
@@ -58,6 +58,10 @@ func (m *MockTxSim) GetState(namespace string, key string) ([]byte, error) {
 	return nil, nil
 }
 
+func (m *MockTxSim) GetStateMetadata(namespace, key string) (map[string][]byte, error) {
+	return nil, nil
+}
+
 func (m *MockTxSim) GetStateMultipleKeys(namespace string, keys []string) ([][]byte, error) {
 	return nil, nil
 }
@@ -66,10 +70,18 @@ func (m *MockTxSim) GetStateRangeScanIterator(namespace string, startKey string,
 	return nil, nil
 }
 
+func (m *MockTxSim) GetStateRangeScanIteratorWithPagination(namespace string, startKey string, endKey string, pageSize int32) (ledger.QueryResultsIterator, error) {
+	return nil, nil
+}
+
 func (m *MockTxSim) ExecuteQuery(namespace, query string) (commonledger.ResultsIterator, error) {
 	return nil, nil
 }
 
+func (m *MockTxSim) ExecuteQueryWithPagination(namespace, query, bookmark string, pageSize int32) (ledger.QueryResultsIterator, error) {
+	return nil, nil
+}
+
 func (m *MockTxSim) Done() {
 }
 
@@ -81,6 +93,14 @@ func (m *MockTxSim) DeleteState(namespace string, key string) error {
 	return nil
 }
 
+func (m *MockTxSim) SetStateMetadata(namespace, key string, metadata map[string][]byte) error {
+	return nil
+}
+
+func (m *MockTxSim) DeleteStateMetadata(namespace, key string) error {
+	return nil
+}
+
 func (m *MockTxSim) SetStateMultipleKeys(namespace string, kvs map[string][]byte) error {
 	return nil
 }
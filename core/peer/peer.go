@@ -202,7 +202,7 @@ func Initialize(init func(string)) {
 
 	var cb *common.Block
 	var ledger ledger.PeerLedger
-	ledgermgmt.Initialize(ConfigTxProcessors)
+	ledgermgmt.Initialize(&ledgermgmt.Initializer{CustomTxProcessors: ConfigTxProcessors})
 	ledgerIds, err := ledgermgmt.GetLedgerIDs()
 	if err != nil {
 		panic(fmt.Errorf("Error in initializing ledgermgmt: %s", err))
@@ -378,10 +378,11 @@ func createChain(cid string, ledger ledger.PeerLedger, cb *common.Block) error {
 		PeerLedger: ledger,
 	})
 	service.GetGossipService().InitializeChannel(bundle.ConfigtxValidator().ChainID(), ordererAddresses, service.Support{
-		Validator: validator,
-		Committer: c,
-		Store:     store,
-		Cs:        simpleCollectionStore,
+		Validator:     validator,
+		Committer:     c,
+		Store:         store,
+		Cs:            simpleCollectionStore,
+		ChannelConfig: cs.bundleSource.ChannelConfig,
 	})
 
 	chains.Lock()
@@ -29,6 +29,7 @@ import (
 	"github.com/hyperledger/fabric/core/common/ccprovider"
 	"github.com/hyperledger/fabric/core/common/privdata"
 	"github.com/hyperledger/fabric/core/common/sysccprovider"
+	"github.com/hyperledger/fabric/core/ledger/cceventmgmt"
 	"github.com/hyperledger/fabric/core/peer"
 	"github.com/hyperledger/fabric/core/policy"
 	"github.com/hyperledger/fabric/core/policyprovider"
@@ -395,10 +396,27 @@ func (lscc *lifeCycleSysCC) executeInstall(stub shim.ChaincodeStubInterface, ccb
 		return err
 	}
 
+	// Get any statedb artifacts from the chaincode package, e.g. couchdb index definitions
+	statedbArtifactsTar, err := ccprovider.ExtractStatedbArtifactsFromCCPackage(ccpack)
+	if err != nil {
+		return err
+	}
+
 	if err = lscc.support.PutChaincodeToLocalStorage(ccpack); err != nil {
 		return err
 	}
 
+	// HandleChaincodeInstall will apply any statedb artifacts (e.g. couchdb indexes) to
+	// the statedb of the channels on which the chaincode is already instantiated
+	chaincodeDefinition := &cceventmgmt.ChaincodeDefinition{
+		Name:    cds.ChaincodeSpec.ChaincodeId.Name,
+		Version: cds.ChaincodeSpec.ChaincodeId.Version,
+		Hash:    ccpack.GetId(),
+	}
+	if err = cceventmgmt.GetMgr().HandleChaincodeInstall(chaincodeDefinition, statedbArtifactsTar); err != nil {
+		return err
+	}
+
 	return nil
 }
 
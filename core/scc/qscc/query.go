@@ -47,6 +47,7 @@ const (
 	GetBlockByHash     string = "GetBlockByHash"
 	GetTransactionByID string = "GetTransactionByID"
 	GetBlockByTxID     string = "GetBlockByTxID"
+	GetCommitHash      string = "GetCommitHash"
 )
 
 // Init is called once per chain when the chain is created.
@@ -65,6 +66,7 @@ func (e *LedgerQuerier) Init(stub shim.ChaincodeStubInterface) pb.Response {
 // # GetBlockByNumber: Return the block specified by block number in args[2]
 // # GetBlockByHash: Return the block specified by block hash in args[2]
 // # GetTransactionByID: Return the transaction specified by ID in args[2]
+// # GetCommitHash: Return the commit hash of the block specified by block number in args[2]
 func (e *LedgerQuerier) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 	args := stub.GetArgs()
 
@@ -109,6 +111,8 @@ func (e *LedgerQuerier) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 		return getChainInfo(targetLedger)
 	case GetBlockByTxID:
 		return getBlockByTxID(targetLedger, args[2])
+	case GetCommitHash:
+		return getCommitHash(targetLedger, args[2])
 	}
 
 	return shim.Error(fmt.Sprintf("Requested function %s not found.", fname))
@@ -208,6 +212,29 @@ func getBlockByTxID(vledger ledger.PeerLedger, rawTxID []byte) pb.Response {
 	return shim.Success(bytes)
 }
 
+func getCommitHash(vledger ledger.PeerLedger, number []byte) pb.Response {
+	if number == nil {
+		return shim.Error("Block number must not be nil.")
+	}
+	bnum, err := strconv.ParseUint(string(number), 10, 64)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to parse block number with error %s", err))
+	}
+	block, err := vledger.GetBlockByNumber(bnum)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to get block number %d, error %s", bnum, err))
+	}
+	commitHash, err := utils.GetCommitHashFromBlock(block)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("Failed to get the commit hash of block number %d, error %s", bnum, err))
+	}
+	if commitHash == nil {
+		return shim.Error(fmt.Sprintf("Block number %d does not carry a commit hash", bnum))
+	}
+
+	return shim.Success(commitHash)
+}
+
 func getACLResource(fname string) string {
 	return "QSCC." + fname
 }
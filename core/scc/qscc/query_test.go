@@ -165,6 +165,33 @@ func TestQueryGetBlockByTxID(t *testing.T) {
 	assert.Equal(t, int32(shim.ERROR), res.Status, "GetBlockByTxID should have failed with blank txId.")
 }
 
+func TestQueryGetCommitHash(t *testing.T) {
+	chainid := "mytestchainid9"
+	path := "/var/hyperledger/test9/"
+	stub, err := setupTestLedger(chainid, path)
+	defer os.RemoveAll(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the commit hash is computed starting from the genesis block
+	args := [][]byte{[]byte(GetCommitHash), []byte(chainid), []byte("0")}
+	prop := resetProvider(resources.QSCC_GetCommitHash, chainid, &peer2.SignedProposal{}, nil)
+	res := stub.MockInvokeWithSignedProposal("1", args, prop)
+	assert.Equal(t, int32(shim.OK), res.Status, "GetCommitHash should have succeeded for block number: 0")
+	assert.Len(t, res.Payload, 32, "GetCommitHash should have returned the commit hash of block number: 0")
+
+	// block number 1 should not be present in the ledger
+	args = [][]byte{[]byte(GetCommitHash), []byte(chainid), []byte("1")}
+	res = stub.MockInvoke("2", args)
+	assert.Equal(t, int32(shim.ERROR), res.Status, "GetCommitHash should have failed with invalid number: 1")
+
+	// block number cannot be nil
+	args = [][]byte{[]byte(GetCommitHash), []byte(chainid), []byte(nil)}
+	res = stub.MockInvoke("3", args)
+	assert.Equal(t, int32(shim.ERROR), res.Status, "GetCommitHash should have failed with nil block number")
+}
+
 func TestFailingAccessControl(t *testing.T) {
 	chainid := "mytestchainid6"
 	path := "/var/hyperledger/test6/"
@@ -185,6 +185,18 @@ func (*mockStub) GetStateByRange(startKey, endKey string) (shim.StateQueryIterat
 	panic("implement me")
 }
 
+func (*mockStub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	panic("implement me")
+}
+
+func (*mockStub) SetStateValidationParameter(key string, ep []byte) error {
+	panic("implement me")
+}
+
+func (*mockStub) GetStateValidationParameter(key string) ([]byte, error) {
+	panic("implement me")
+}
+
 func (*mockStub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
 	panic("implement me")
 }
@@ -201,10 +213,18 @@ func (*mockStub) GetQueryResult(query string) (shim.StateQueryIteratorInterface,
 	panic("implement me")
 }
 
+func (*mockStub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	panic("implement me")
+}
+
 func (*mockStub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
 	panic("implement me")
 }
 
+func (*mockStub) GetHistoryForKeyWithOptions(key string, options *shim.HistoryQueryOptions) (shim.HistoryQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	panic("implement me")
+}
+
 func (*mockStub) GetCreator() ([]byte, error) {
 	panic("implement me")
 }
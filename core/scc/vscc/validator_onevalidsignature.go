@@ -24,6 +24,7 @@ import (
 	"github.com/hyperledger/fabric/common/cauthdsl"
 	"github.com/hyperledger/fabric/common/channelconfig"
 	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/policies"
 	"github.com/hyperledger/fabric/core/chaincode/shim"
 	"github.com/hyperledger/fabric/core/common/ccprovider"
 	"github.com/hyperledger/fabric/core/common/privdata"
@@ -88,13 +89,15 @@ func (vscc *ValidatorOneValidSignature) Init(stub shim.ChaincodeStubInterface) p
 // from entities) that comply with the supplied endorsement policy.
 // @return a successful Response (code 200) in case of success, or
 // an error otherwise
-// Note that Peer calls this function with 3 arguments, where args[0] is the
-// function name, args[1] is the Envelope and args[2] is the validation policy
+// Note that Peer calls this function with 4 arguments, where args[0] is the
+// function name, args[1] is the Envelope, args[2] is the validation policy
+// and args[3] is the namespace whose writes are validated
 func (vscc *ValidatorOneValidSignature) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 	// TODO: document the argument in some white paper or design document
 	// args[0] - function name (not used now)
 	// args[1] - serialized Envelope
 	// args[2] - serialized policy
+	// args[3] - namespace (optional, defaults to the invoked chaincode)
 	args := stub.GetArgs()
 	if len(args) < 3 {
 		return shim.Error("Incorrect number of arguments")
@@ -171,8 +174,24 @@ func (vscc *ValidatorOneValidSignature) Invoke(stub shim.ChaincodeStubInterface)
 			return shim.Error(err.Error())
 		}
 
-		// evaluate the signature set against the policy
-		err = policy.Evaluate(signatureSet)
+		hdrExt, err := utils.GetChaincodeHeaderExtension(payl.Header)
+		if err != nil {
+			logger.Errorf("VSCC error: GetChaincodeHeaderExtension failed, err %s", err)
+			return shim.Error(err.Error())
+		}
+
+		// evaluate the signature set against the policy; if the channel supports
+		// key-level endorsement policies, the policies of the written keys are
+		// evaluated as well
+		if ac.Capabilities().KeyLevelEndorsement() && hdrExt.ChaincodeId.Name != "lscc" {
+			namespace := hdrExt.ChaincodeId.Name
+			if len(args) > 3 && len(args[3]) > 0 {
+				namespace = string(args[3])
+			}
+			err = vscc.evaluateKeyLevelPolicies(chdr.ChannelId, namespace, cap, signatureSet, policy, pProvider)
+		} else {
+			err = policy.Evaluate(signatureSet)
+		}
 		if err != nil {
 			logger.Warningf("Endorsement policy failure for transaction txid=%s, err: %s", chdr.GetTxId(), err.Error())
 			if len(signatureSet) < len(cap.Action.Endorsements) {
@@ -182,12 +201,6 @@ func (vscc *ValidatorOneValidSignature) Invoke(stub shim.ChaincodeStubInterface)
 			return shim.Error(fmt.Sprintf("VSCC error: policy evaluation failed, err %s", err))
 		}
 
-		hdrExt, err := utils.GetChaincodeHeaderExtension(payl.Header)
-		if err != nil {
-			logger.Errorf("VSCC error: GetChaincodeHeaderExtension failed, err %s", err)
-			return shim.Error(err.Error())
-		}
-
 		// do some extra validation that is specific to lscc
 		if hdrExt.ChaincodeId.Name == "lscc" {
 			logger.Debugf("VSCC info: doing special validation for LSCC")
@@ -205,6 +218,104 @@ func (vscc *ValidatorOneValidSignature) Invoke(stub shim.ChaincodeStubInterface)
 	return shim.Success(nil)
 }
 
+// evaluateKeyLevelPolicies evaluates the signature set against the key-level endorsement
+// policies of the keys written (or whose metadata is written) in the supplied namespace.
+// The policies are taken from the ledger, so a write to the validation parameter of a key
+// has to satisfy the policy that is being replaced. The endorsement policy of the chaincode
+// is evaluated if any written key has no key-level policy, if private data is written or
+// if the transaction writes nothing to the namespace
+func (vscc *ValidatorOneValidSignature) evaluateKeyLevelPolicies(chid, namespace string, cap *pb.ChaincodeActionPayload,
+	signatureSet []*common.SignedData, ccPolicy policies.Policy, pProvider policies.Provider) error {
+	txRWSet, err := getTxRWSet(cap)
+	if err != nil {
+		return err
+	}
+
+	var keys []string
+	writesPvtData := false
+	for _, ns := range txRWSet.NsRwSets {
+		if ns.NameSpace != namespace {
+			continue
+		}
+		if ns.KvRwSet != nil {
+			for _, write := range ns.KvRwSet.Writes {
+				keys = append(keys, write.Key)
+			}
+			for _, metadataWrite := range ns.KvRwSet.MetadataWrites {
+				keys = append(keys, metadataWrite.Key)
+			}
+		}
+		for _, coll := range ns.CollHashedRwSets {
+			if coll.HashedRwSet != nil && len(coll.HashedRwSet.HashedWrites) > 0 {
+				writesPvtData = true
+			}
+		}
+	}
+	evaluateCCPolicy := len(keys) == 0 || writesPvtData
+
+	if len(keys) > 0 {
+		qe, err := vscc.sccprovider.GetQueryExecutorForLedger(chid)
+		if err != nil {
+			return fmt.Errorf("could not retrieve QueryExecutor for channel %s, error %s", chid, err)
+		}
+		defer qe.Done()
+
+		evaluated := make(map[string]struct{})
+		for _, key := range keys {
+			if _, ok := evaluated[key]; ok {
+				continue
+			}
+			evaluated[key] = struct{}{}
+
+			metadata, err := qe.GetStateMetadata(namespace, key)
+			if err != nil {
+				return fmt.Errorf("could not retrieve metadata for key %s of chaincode %s, error %s", key, namespace, err)
+			}
+			vp := metadata[pb.MetaDataKeys_VALIDATION_PARAMETER.String()]
+			if len(vp) == 0 {
+				evaluateCCPolicy = true
+				continue
+			}
+
+			keyPolicy, _, err := pProvider.NewPolicy(vp)
+			if err != nil {
+				return fmt.Errorf("invalid validation parameter for key %s of chaincode %s, error %s", key, namespace, err)
+			}
+			if err = keyPolicy.Evaluate(signatureSet); err != nil {
+				return fmt.Errorf("key-level endorsement policy for key %s of chaincode %s not satisfied, error %s", key, namespace, err)
+			}
+		}
+	}
+
+	if evaluateCCPolicy {
+		return ccPolicy.Evaluate(signatureSet)
+	}
+	return nil
+}
+
+// getTxRWSet extracts the read-write set from a chaincode action payload
+func getTxRWSet(cap *pb.ChaincodeActionPayload) (*rwsetutil.TxRwSet, error) {
+	if cap.Action == nil || cap.Action.ProposalResponsePayload == nil {
+		return nil, fmt.Errorf("nil proposal response payload")
+	}
+	pRespPayload, err := utils.GetProposalResponsePayload(cap.Action.ProposalResponsePayload)
+	if err != nil {
+		return nil, fmt.Errorf("GetProposalResponsePayload error %s", err)
+	}
+	if pRespPayload.Extension == nil {
+		return nil, fmt.Errorf("nil pRespPayload.Extension")
+	}
+	respPayload, err := utils.GetChaincodeAction(pRespPayload.Extension)
+	if err != nil {
+		return nil, fmt.Errorf("GetChaincodeAction error %s", err)
+	}
+	txRWSet := &rwsetutil.TxRwSet{}
+	if err = txRWSet.FromProtoBytes(respPayload.Results); err != nil {
+		return nil, fmt.Errorf("txRWSet.FromProtoBytes error %s", err)
+	}
+	return txRWSet, nil
+}
+
 // checkInstantiationPolicy evaluates an instantiation policy against a signed proposal
 func (vscc *ValidatorOneValidSignature) checkInstantiationPolicy(chainName string, env *common.Envelope, instantiationPolicy []byte, payl *common.Payload) error {
 	// create a policy object from the policy bytes
@@ -377,6 +377,87 @@ func TestInvoke(t *testing.T) {
 	}
 }
 
+func createTxWithResults(res []byte) (*common.Envelope, error) {
+	ccid := &peer.ChaincodeID{Name: "foo", Version: "v1"}
+	cis := &peer.ChaincodeInvocationSpec{ChaincodeSpec: &peer.ChaincodeSpec{ChaincodeId: ccid}}
+
+	prop, _, err := utils.CreateProposalFromCIS(common.HeaderType_ENDORSER_TRANSACTION, util.GetTestChainID(), cis, sid)
+	if err != nil {
+		return nil, err
+	}
+
+	presp, err := utils.CreateProposalResponse(prop.Header, prop.Payload, &peer.Response{Status: 200}, res, nil, ccid, nil, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return utils.CreateSignedTx(prop, id, presp)
+}
+
+func TestKeyLevelEndorsementPolicies(t *testing.T) {
+	qe := lm.NewMockQueryExecutor(make(map[string]map[string][]byte))
+	qe.StateMetadata = make(map[string]map[string]map[string][]byte)
+	sysccprovider.RegisterSystemChaincodeProviderFactory(&scc.MocksccProviderFactory{
+		Qe:                    qe,
+		ApplicationConfigBool: true,
+		ApplicationConfigRv:   &mc.MockApplication{CapabilitiesRv: &mc.MockApplicationCapabilities{KeyLevelEndorsementRv: true}},
+	})
+
+	v := new(ValidatorOneValidSignature)
+	stub := shim.NewMockStub("validatoronevalidsignature", v)
+	if res := stub.MockInit("1", nil); res.Status != shim.OK {
+		t.Fatalf("vscc init failed with %s", res.Message)
+	}
+
+	goodPolicy, err := getSignedByMSPMemberPolicy(mspid)
+	assert.NoError(t, err)
+	badPolicy, err := getSignedByMSPMemberPolicy("barf")
+	assert.NoError(t, err)
+
+	rwsetBuilder := rwsetutil.NewRWSetBuilder()
+	rwsetBuilder.AddToWriteSet("foo", "key1", []byte("value1"))
+	rwsetBuilder.AddToMetadataWriteSet("foo", "key2", map[string][]byte{peer.MetaDataKeys_VALIDATION_PARAMETER.String(): badPolicy})
+	sr, err := rwsetBuilder.GetTxSimulationResults()
+	assert.NoError(t, err)
+	res, err := sr.GetPubSimulationBytes()
+	assert.NoError(t, err)
+	tx, err := createTxWithResults(res)
+	assert.NoError(t, err)
+	envBytes, err := utils.GetBytesEnvelope(tx)
+	assert.NoError(t, err)
+
+	// no key-level policies: the chaincode policy applies
+	res1 := stub.MockInvoke("1", [][]byte{[]byte("dv"), envBytes, goodPolicy, []byte("foo")})
+	assert.Equal(t, int32(shim.OK), res1.Status, res1.Message)
+	res1 = stub.MockInvoke("1", [][]byte{[]byte("dv"), envBytes, badPolicy, []byte("foo")})
+	assert.NotEqual(t, int32(shim.OK), res1.Status)
+
+	// key-level policies for all the written keys: the chaincode policy is not evaluated
+	qe.StateMetadata["foo"] = map[string]map[string][]byte{
+		"key1": {peer.MetaDataKeys_VALIDATION_PARAMETER.String(): goodPolicy},
+		"key2": {peer.MetaDataKeys_VALIDATION_PARAMETER.String(): goodPolicy},
+	}
+	res1 = stub.MockInvoke("1", [][]byte{[]byte("dv"), envBytes, badPolicy, []byte("foo")})
+	assert.Equal(t, int32(shim.OK), res1.Status, res1.Message)
+
+	// the policy of a key whose metadata is written is the one in the ledger
+	qe.StateMetadata["foo"]["key2"] = map[string][]byte{peer.MetaDataKeys_VALIDATION_PARAMETER.String(): badPolicy}
+	res1 = stub.MockInvoke("1", [][]byte{[]byte("dv"), envBytes, goodPolicy, []byte("foo")})
+	assert.NotEqual(t, int32(shim.OK), res1.Status)
+
+	// a key without a key-level policy requires the chaincode policy
+	delete(qe.StateMetadata["foo"], "key2")
+	res1 = stub.MockInvoke("1", [][]byte{[]byte("dv"), envBytes, badPolicy, []byte("foo")})
+	assert.NotEqual(t, int32(shim.OK), res1.Status)
+	res1 = stub.MockInvoke("1", [][]byte{[]byte("dv"), envBytes, goodPolicy, []byte("foo")})
+	assert.Equal(t, int32(shim.OK), res1.Status, res1.Message)
+
+	// an invalid validation parameter fails the validation
+	qe.StateMetadata["foo"]["key1"] = map[string][]byte{peer.MetaDataKeys_VALIDATION_PARAMETER.String(): []byte("barf")}
+	res1 = stub.MockInvoke("1", [][]byte{[]byte("dv"), envBytes, goodPolicy, []byte("foo")})
+	assert.NotEqual(t, int32(shim.OK), res1.Status)
+}
+
 func TestInvalidFunction(t *testing.T) {
 	v := new(ValidatorOneValidSignature)
 	stub := shim.NewMockStub("validatoronevalidsignature", v)
@@ -36,7 +36,13 @@ under the License.
 //   peer chaincode query -C myc1 -n marbles -c '{"Args":["queryMarblesByOwner","tom"]}'
 //   peer chaincode query -C myc1 -n marbles -c '{"Args":["queryMarbles","{\"selector\":{\"owner\":\"tom\"}}"]}'
 
-//The following examples demonstrate creating indexes on CouchDB
+//Indexes packaged with the chaincode in META-INF/statedb/couchdb/indexes, such as
+//indexOwner.json, are created by the peer in the CouchDB state database when the chaincode
+//is instantiated or upgraded on a channel. Their fields are named as in the chaincode data,
+//like the fields of the rich queries, e.g.
+// {"index":{"fields":["docType","owner"]},"ddoc":"indexOwnerDoc", "name":"indexOwner","type":"json"}
+
+//The following examples demonstrate creating indexes on CouchDB manually
 //Example hostname:port configurations
 //
 //Docker or vagrant environments:
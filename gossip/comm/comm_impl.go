@@ -13,10 +13,12 @@ import (
 	"fmt"
 	"net"
 	"reflect"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/hyperledger/fabric/common/metrics"
 	"github.com/hyperledger/fabric/gossip/api"
 	"github.com/hyperledger/fabric/gossip/common"
 	"github.com/hyperledger/fabric/gossip/identity"
@@ -82,6 +84,7 @@ func NewCommInstanceWithServer(port int, idMapper identity.Mapper, peerIdentity
 		subscriptions:  make([]chan proto.ReceivedMessage, 0),
 		dialTimeout:    util.GetDurationOrDefault("peer.gossip.dialTimeout", defDialTimeout),
 		tlsCerts:       certs,
+		metrics:        metrics.RootScope.SubScope("gossip"),
 	}
 	commInst.connStore = newConnStore(commInst, commInst.logger)
 
@@ -135,6 +138,7 @@ type commImpl struct {
 	port           int
 	stopping       int32
 	dialTimeout    time.Duration
+	metrics        metrics.Scope
 }
 
 func (c *commImpl) createConnection(endpoint string, expectedPKIID common.PKIidType) (*connection, error) {
@@ -189,6 +193,7 @@ func (c *commImpl) createConnection(endpoint string, expectedPKIID common.PKIidT
 
 			h := func(m *proto.SignedGossipMessage) {
 				c.logger.Debug("Got message:", m)
+				c.recordMessage("messages_received", m)
 				c.msgPublisher.DeMultiplex(&ReceivedMessageImpl{
 					conn:                conn,
 					lock:                conn,
@@ -233,12 +238,23 @@ func (c *commImpl) sendToEndpoint(peer *RemotePeer, msg *proto.SignedGossipMessa
 			c.disconnect(peer.PKIID)
 		}
 		conn.send(msg, disConnectOnErr, shouldBlock)
+		c.recordMessage("messages_sent", msg)
 		return
 	}
 	c.logger.Warningf("Failed obtaining connection for %v reason: %v", peer, err)
 	c.disconnect(peer.PKIID)
 }
 
+// recordMessage increments the given counter of messages of the type of the given message,
+// messages without content being counted as of the unknown type
+func (c *commImpl) recordMessage(counter string, m *proto.SignedGossipMessage) {
+	msgType := "unknown"
+	if m != nil && m.GossipMessage != nil && m.Content != nil {
+		msgType = strings.TrimPrefix(reflect.TypeOf(m.Content).Elem().Name(), "GossipMessage_")
+	}
+	c.metrics.Tagged(map[string]string{"type": msgType}).Counter(counter).Inc(1)
+}
+
 func (c *commImpl) isStopping() bool {
 	return atomic.LoadInt32(&c.stopping) == int32(1)
 }
@@ -563,6 +579,7 @@ func (c *commImpl) GossipStream(stream proto.Gossip_GossipStreamServer) error {
 	}
 
 	h := func(m *proto.SignedGossipMessage) {
+		c.recordMessage("messages_received", m)
 		c.msgPublisher.DeMultiplex(&ReceivedMessageImpl{
 			conn:                conn,
 			lock:                conn,
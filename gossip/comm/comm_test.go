@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/hyperledger/fabric/bccsp/factory"
+	mockmetrics "github.com/hyperledger/fabric/common/mocks/metrics"
 	"github.com/hyperledger/fabric/core/config"
 	"github.com/hyperledger/fabric/gossip/api"
 	"github.com/hyperledger/fabric/gossip/common"
@@ -348,6 +349,37 @@ func TestBasic(t *testing.T) {
 	waitForMessages(t, out, 2, "Didn't receive 2 messages")
 }
 
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+	comm1, _ := newCommInstance(2100, naiveSec)
+	comm2, _ := newCommInstance(3100, naiveSec)
+	defer comm1.Stop()
+	defer comm2.Stop()
+	scope1, scope2 := mockmetrics.NewScope(), mockmetrics.NewScope()
+	comm1.(*commImpl).metrics = scope1
+	comm2.(*commImpl).metrics = scope2
+	m2 := comm2.Accept(acceptAll)
+
+	for i := 0; i < 3; i++ {
+		comm1.Send(createGossipMsg(), remotePeer(3100))
+		select {
+		case <-m2:
+		case <-time.After(time.Second * 5):
+			t.Fatal("Didn't receive a message in time")
+		}
+	}
+
+	tags := map[string]string{"type": "DataMsg"}
+	assert.Equal(t, int64(3), scope1.CounterValue("messages_sent", tags))
+	assert.Equal(t, int64(3), scope2.CounterValue("messages_received", tags))
+
+	// Messages without content are counted as of the unknown type
+	comm1.(*commImpl).recordMessage("messages_received", nil)
+	comm1.(*commImpl).recordMessage("messages_received", &proto.SignedGossipMessage{})
+	comm1.(*commImpl).recordMessage("messages_received", &proto.SignedGossipMessage{GossipMessage: &proto.GossipMessage{}})
+	assert.Equal(t, int64(3), scope1.CounterValue("messages_received", map[string]string{"type": "unknown"}))
+}
+
 func TestProdConstructor(t *testing.T) {
 	t.Parallel()
 	srv, lsnr, dialOpts, certs := createGRPCLayer(20000)
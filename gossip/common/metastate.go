@@ -19,11 +19,14 @@ type NodeMetastate struct {
 
 	// Actual ledger height
 	LedgerHeight uint64
+
+	// Commit hash of the block at the ledger height, if the block carries one
+	CommitHash []byte
 }
 
 // NewNodeMetastate creates new meta data with given ledger height
 func NewNodeMetastate(height uint64) *NodeMetastate {
-	return &NodeMetastate{height}
+	return &NodeMetastate{LedgerHeight: height}
 }
 
 // Bytes decodes meta state into byte array for serialization
@@ -32,10 +35,13 @@ func (n *NodeMetastate) Bytes() ([]byte, error) {
 	// Explicitly specify byte order for write into the buffer
 	// to provide cross platform support, note the it consistent
 	// with FromBytes function
-	err := binary.Write(buffer, binary.BigEndian, *n)
+	err := binary.Write(buffer, binary.BigEndian, n.LedgerHeight)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
+	// The commit hash follows the ledger height, so that the peers
+	// that are not aware of the commit hash read only the height
+	buffer.Write(n.CommitHash)
 	return buffer.Bytes(), nil
 }
 
@@ -56,9 +62,12 @@ func FromBytes(buf []byte) (*NodeMetastate, error) {
 	// As bytes are written in the big endian to keep supporting
 	// cross platforming and for consistency reasons read also
 	// done using same order
-	err := binary.Read(reader, binary.BigEndian, &state)
+	err := binary.Read(reader, binary.BigEndian, &state.LedgerHeight)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
+	if reader.Len() > 0 {
+		state.CommitHash = buf[len(buf)-reader.Len():]
+	}
 	return &state, nil
 }
@@ -63,3 +63,23 @@ func TestNodeMetastate_FromBytes(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, updatedState.Height(), uint64(17))
 }
+
+func TestNodeMetastate_CommitHash(t *testing.T) {
+	metastate := NewNodeMetastate(17)
+	metastate.CommitHash = []byte("commit hash")
+	bytes, err := metastate.Bytes()
+	assert.NoError(t, err)
+
+	state, err := FromBytes(bytes)
+	assert.NoError(t, err)
+	assert.Equal(t, metastate, state)
+
+	// the meta state of a peer that is not aware of the commit hash carries only the height
+	state, err = FromBytes(bytes[:8])
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(17), state.Height())
+	assert.Nil(t, state.CommitHash)
+
+	_, err = FromBytes(bytes[:7])
+	assert.Error(t, err)
+}
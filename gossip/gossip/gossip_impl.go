@@ -1170,6 +1170,7 @@ func (g *gossipServiceImpl) createStateInfoMsg(metadata []byte, chainID common.C
 		},
 		Properties: &proto.Properties{
 			LedgerHeight: metaState.LedgerHeight,
+			CommitHash:   metaState.CommitHash,
 		},
 	}
 	if leftChannel {
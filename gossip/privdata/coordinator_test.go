@@ -147,6 +147,30 @@ func (mock *committerMock) GetPvtDataByNum(blockNum uint64, filter ledger.PvtNsC
 	return args.Get(0).([]*ledger.TxPvtData), args.Error(1)
 }
 
+func (mock *committerMock) GetMissingPvtDataInfoForMostRecentBlocks(maxBlocks int) (ledger.MissingPvtDataInfo, error) {
+	args := mock.Called(maxBlocks)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(ledger.MissingPvtDataInfo), args.Error(1)
+}
+
+func (mock *committerMock) GetMissingPvtDataInfoForBlocksBelow(blockNum uint64, maxBlocks int) (ledger.MissingPvtDataInfo, error) {
+	args := mock.Called(blockNum, maxBlocks)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(ledger.MissingPvtDataInfo), args.Error(1)
+}
+
+func (mock *committerMock) CommitPvtDataOfOldBlocks(blocksPvtData []*ledger.BlockPvtData) ([]*ledger.PvtdataHashMismatch, error) {
+	args := mock.Called(blocksPvtData)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*ledger.PvtdataHashMismatch), args.Error(1)
+}
+
 func (mock *committerMock) CommitWithPvtData(blockAndPvtData *ledger.BlockAndPvtData) error {
 	args := mock.Called(blockAndPvtData)
 	return args.Error(0)
@@ -103,6 +103,9 @@ func (dr *dataRetriever) CollectionRWSet(dig *gossip2.PvtDataDigest) []util.Priv
 				"txID", dig.TxId, "block sequence number", dig.BlockSeq, "due to", err)
 		}
 		for _, data := range pvtData {
+			if data.SeqInBlock != dig.SeqInBlock {
+				continue
+			}
 			if data.WriteSet == nil {
 				logger.Warning("Received nil write set for collection", dig.Collection, "namespace", dig.Namespace)
 				continue
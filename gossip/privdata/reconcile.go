@@ -0,0 +1,356 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package privdata
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+
+	util2 "github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/core/committer"
+	"github.com/hyperledger/fabric/core/common/privdata"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/rwsetutil"
+	"github.com/hyperledger/fabric/protos/common"
+	gossip2 "github.com/hyperledger/fabric/protos/gossip"
+	"github.com/hyperledger/fabric/protos/ledger/rwset"
+	"github.com/hyperledger/fabric/protos/peer"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+)
+
+const (
+	reconcileSleepIntervalConfigKey = "peer.gossip.pvtData.reconcileSleepInterval"
+	reconcileSleepIntervalDefault   = time.Minute
+	reconcileBatchSizeConfigKey     = "peer.gossip.pvtData.reconcileBatchSize"
+	reconcileBatchSizeDefault       = 10
+	reconciliationEnabledConfigKey  = "peer.gossip.pvtData.reconciliationEnabled"
+)
+
+// PvtDataReconciler completes, in the background, the private data of the blocks
+// that were committed while some of their private data was missing
+type PvtDataReconciler interface {
+	// Start starts the reconciliation of the missing private data
+	Start()
+	// Stop stops the reconciliation of the missing private data
+	Stop()
+}
+
+// ReconcilerConfig holds the configuration of the reconciler
+type ReconcilerConfig struct {
+	// SleepInterval is the time to wait between two reconciliation attempts
+	SleepInterval time.Duration
+	// BatchSize is the maximum number of blocks with missing private data that are reconciled in a single attempt
+	BatchSize int
+	// IsEnabled tells whether the reconciliation is enabled
+	IsEnabled bool
+}
+
+// GetReconcilerConfig returns the configuration of the reconciler, using the
+// default values for the configuration keys that are not set
+func GetReconcilerConfig() *ReconcilerConfig {
+	sleepInterval := viper.GetDuration(reconcileSleepIntervalConfigKey)
+	if sleepInterval == 0 {
+		logger.Warning("Configuration key", reconcileSleepIntervalConfigKey, "isn't set, defaulting to", reconcileSleepIntervalDefault)
+		sleepInterval = reconcileSleepIntervalDefault
+	}
+	batchSize := viper.GetInt(reconcileBatchSizeConfigKey)
+	if batchSize == 0 {
+		logger.Warning("Configuration key", reconcileBatchSizeConfigKey, "isn't set, defaulting to", reconcileBatchSizeDefault)
+		batchSize = reconcileBatchSizeDefault
+	}
+	isEnabled := true
+	if viper.IsSet(reconciliationEnabledConfigKey) {
+		isEnabled = viper.GetBool(reconciliationEnabledConfigKey)
+	}
+	return &ReconcilerConfig{SleepInterval: sleepInterval, BatchSize: batchSize, IsEnabled: isEnabled}
+}
+
+// NoOpReconciler is the reconciler used when the reconciliation is disabled
+type NoOpReconciler struct{}
+
+// Start does nothing
+func (*NoOpReconciler) Start() {
+	logger.Debug("Private data reconciliation is disabled")
+}
+
+// Stop does nothing
+func (*NoOpReconciler) Stop() {
+}
+
+type reconciler struct {
+	channel string
+	config  *ReconcilerConfig
+	committer.Committer
+	Fetcher
+	privdata.CollectionStore
+	stopChan  chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+	// cursor is the block before which the next attempt looks for missing private data,
+	// or 0 if it looks at the most recent blocks
+	cursor uint64
+}
+
+// NewReconciler creates a new instance of the reconciler, which periodically pulls from the
+// eligible peers the private data that is missing in the ledger and commits it to the ledger
+func NewReconciler(channel string, c committer.Committer, fetcher Fetcher, cs privdata.CollectionStore, config *ReconcilerConfig) PvtDataReconciler {
+	return &reconciler{
+		channel:         channel,
+		config:          config,
+		Committer:       c,
+		Fetcher:         fetcher,
+		CollectionStore: cs,
+		stopChan:        make(chan struct{}),
+	}
+}
+
+// Start starts the reconciliation of the missing private data in the background
+func (r *reconciler) Start() {
+	r.startOnce.Do(func() {
+		go r.run()
+	})
+}
+
+// Stop stops the reconciliation of the missing private data
+func (r *reconciler) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopChan)
+	})
+}
+
+func (r *reconciler) run() {
+	for {
+		select {
+		case <-r.stopChan:
+			return
+		case <-time.After(r.config.SleepInterval):
+			logger.Debug("Start reconcile missing private info")
+			if err := r.reconcile(); err != nil {
+				logger.Error("Failed to reconcile missing private info, error:", err)
+			}
+		}
+	}
+}
+
+// reconcile fetches the missing private data of a batch of blocks and commits it to the ledger.
+// The batches go from the most recent blocks to the oldest ones and then start over, so that
+// the private data that cannot be reconciled does not keep the older blocks from being reconciled
+func (r *reconciler) reconcile() error {
+	missingPvtDataInfo, err := r.getMissingPvtDataInfo()
+	if err != nil {
+		return errors.WithMessage(err, "failed obtaining missing private data information from the ledger")
+	}
+	if len(missingPvtDataInfo) == 0 {
+		logger.Debug("No missing private data to reconcile in channel", r.channel)
+		return nil
+	}
+
+	dig2src, expectedHashes, err := r.getDig2Sources(missingPvtDataInfo)
+	if err != nil {
+		return err
+	}
+	if len(dig2src) == 0 {
+		logger.Debug("None of the missing private data can be reconciled in channel", r.channel)
+		return nil
+	}
+
+	fetchedData, err := r.fetch(dig2src)
+	if err != nil {
+		return errors.WithMessage(err, "failed fetching missing private data from peers")
+	}
+
+	blocksPvtData := preparePvtDataToCommit(fetchedData, expectedHashes)
+	if len(blocksPvtData) == 0 {
+		logger.Debug("No missing private data was fetched from peers in channel", r.channel)
+		return nil
+	}
+
+	mismatches, err := r.CommitPvtDataOfOldBlocks(blocksPvtData)
+	if err != nil {
+		return errors.WithMessage(err, "failed committing missing private data to the ledger")
+	}
+	for _, mismatch := range mismatches {
+		logger.Warningf("Private data of namespace [%s], collection [%s] of transaction [%d] in block [%d] does not match the hash in the block",
+			mismatch.Namespace, mismatch.Collection, mismatch.TxNum, mismatch.BlockNum)
+	}
+	logger.Infof("Reconciled missing private data of %d block(s) in channel [%s]", len(blocksPvtData), r.channel)
+	return nil
+}
+
+// getMissingPvtDataInfo returns the missing private data of the blocks before the ones of the
+// previous attempt, or of the most recent blocks once no older block misses private data, and
+// moves the cursor to the oldest block returned
+func (r *reconciler) getMissingPvtDataInfo() (ledger.MissingPvtDataInfo, error) {
+	var missingPvtDataInfo ledger.MissingPvtDataInfo
+	var err error
+	if r.cursor > 0 {
+		missingPvtDataInfo, err = r.GetMissingPvtDataInfoForBlocksBelow(r.cursor, r.config.BatchSize)
+	}
+	if err == nil && len(missingPvtDataInfo) == 0 {
+		missingPvtDataInfo, err = r.GetMissingPvtDataInfoForMostRecentBlocks(r.config.BatchSize)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.cursor = 0
+	for blockNum := range missingPvtDataInfo {
+		if r.cursor == 0 || blockNum < r.cursor {
+			r.cursor = blockNum
+		}
+	}
+	return missingPvtDataInfo, nil
+}
+
+// getDig2Sources builds the digests of the missing private data along with the endorsers
+// to fetch them from, and the hashes the fetched private data is expected to match
+func (r *reconciler) getDig2Sources(missingPvtDataInfo ledger.MissingPvtDataInfo) (dig2sources, map[gossip2.PvtDataDigest][]byte, error) {
+	var blockNums []uint64
+	for blockNum := range missingPvtDataInfo {
+		blockNums = append(blockNums, blockNum)
+	}
+	sort.Slice(blockNums, func(i, j int) bool { return blockNums[i] < blockNums[j] })
+
+	dig2src := make(dig2sources)
+	expectedHashes := make(map[gossip2.PvtDataDigest][]byte)
+	for _, block := range r.GetBlocks(blockNums) {
+		if block == nil || block.Header == nil || block.Data == nil {
+			return nil, nil, errors.New("failed obtaining the blocks with missing private data from the ledger")
+		}
+		if block.Metadata == nil || len(block.Metadata.Metadata) <= int(common.BlockMetadataIndex_TRANSACTIONS_FILTER) {
+			return nil, nil, errors.Errorf("block [%d] lacks a Tx filter bitmap", block.Header.Number)
+		}
+		txsFilter := txValidationFlags(block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER])
+		if len(txsFilter) != len(block.Data.Data) {
+			return nil, nil, errors.Errorf("block [%d] data size(%d) is different from Tx filter size(%d)",
+				block.Header.Number, len(block.Data.Data), len(txsFilter))
+		}
+
+		blockNum := block.Header.Number
+		missingInBlock := missingPvtDataInfo[blockNum]
+		_, err := blockData(block.Data.Data).forEachTxn(txsFilter, func(seqInBlock uint64, chdr *common.ChannelHeader, txRWSet *rwsetutil.TxRwSet, endorsers []*peer.Endorsement) {
+			for _, missing := range missingInBlock[seqInBlock] {
+				hashedRWSet := findCollHashedRwSet(txRWSet, missing.Namespace, missing.Collection)
+				if hashedRWSet == nil {
+					logger.Warning("Missing private data of namespace", missing.Namespace, "collection", missing.Collection,
+						"isn't in transaction", chdr.TxId, "of block", blockNum)
+					continue
+				}
+				dig := &gossip2.PvtDataDigest{
+					TxId:       chdr.TxId,
+					Namespace:  missing.Namespace,
+					Collection: missing.Collection,
+					BlockSeq:   blockNum,
+					SeqInBlock: seqInBlock,
+				}
+				dig2src[dig] = r.sources(chdr, missing.Namespace, missing.Collection, endorsers)
+				expectedHashes[*dig] = hashedRWSet.PvtRwSetHash
+			}
+		})
+		if err != nil {
+			return nil, nil, errors.WithStack(err)
+		}
+	}
+	return dig2src, expectedHashes, nil
+}
+
+// sources returns the endorsers of the transaction that belong to the member orgs of the collection.
+// If there is none, any peer eligible for the collection is asked for the private data
+func (r *reconciler) sources(chdr *common.ChannelHeader, namespace string, col string, endorsers []*peer.Endorsement) []*peer.Endorsement {
+	policy, err := r.RetrieveCollectionAccessPolicy(common.CollectionCriteria{
+		Channel:    chdr.ChannelId,
+		Namespace:  namespace,
+		Collection: col,
+		TxId:       chdr.TxId,
+	})
+	if err != nil {
+		logger.Debug("Failed obtaining policy for namespace", namespace, "collection", col, ":", err)
+		return nil
+	}
+	return endorsersFromOrgs(namespace, col, endorsers, policy.MemberOrgs())
+}
+
+func findCollHashedRwSet(txRWSet *rwsetutil.TxRwSet, namespace string, col string) *rwsetutil.CollHashedRwSet {
+	for _, ns := range txRWSet.NsRwSets {
+		if ns.NameSpace != namespace {
+			continue
+		}
+		for _, hashed := range ns.CollHashedRwSets {
+			if hashed.CollectionName == col {
+				return hashed
+			}
+		}
+	}
+	return nil
+}
+
+// preparePvtDataToCommit groups the fetched private data by block and transaction. Only the
+// private write sets that match the hashes of the corresponding blocks are taken into account
+func preparePvtDataToCommit(fetchedData []*gossip2.PvtDataElement, expectedHashes map[gossip2.PvtDataDigest][]byte) []*ledger.BlockPvtData {
+	blocksPvtData := make(map[uint64]*ledger.BlockPvtData)
+	for _, element := range fetchedData {
+		dig := element.Digest
+		expectedHash, exists := expectedHashes[*dig]
+		if !exists {
+			logger.Debug("Ignoring", dig, "because it wasn't requested")
+			continue
+		}
+		var matchingRWSet []byte
+		for _, rws := range element.Payload {
+			if bytes.Equal(util2.ComputeSHA256(rws), expectedHash) {
+				matchingRWSet = rws
+				break
+			}
+		}
+		if matchingRWSet == nil {
+			logger.Warning("None of the private write sets fetched for", dig, "matches the hash in the block")
+			continue
+		}
+		// a digest is requested only once, hence a collection is fetched at most once
+		delete(expectedHashes, *dig)
+
+		blockPvtData, exists := blocksPvtData[dig.BlockSeq]
+		if !exists {
+			blockPvtData = &ledger.BlockPvtData{BlockNum: dig.BlockSeq, WriteSets: make(map[uint64]*ledger.TxPvtData)}
+			blocksPvtData[dig.BlockSeq] = blockPvtData
+		}
+		txPvtData, exists := blockPvtData.WriteSets[dig.SeqInBlock]
+		if !exists {
+			txPvtData = &ledger.TxPvtData{
+				SeqInBlock: dig.SeqInBlock,
+				WriteSet:   &rwset.TxPvtReadWriteSet{DataModel: rwset.TxReadWriteSet_KV},
+			}
+			blockPvtData.WriteSets[dig.SeqInBlock] = txPvtData
+		}
+		addCollPvtRwSet(txPvtData.WriteSet, dig.Namespace, &rwset.CollectionPvtReadWriteSet{
+			CollectionName: dig.Collection,
+			Rwset:          matchingRWSet,
+		})
+	}
+
+	var res []*ledger.BlockPvtData
+	for _, blockPvtData := range blocksPvtData {
+		res = append(res, blockPvtData)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].BlockNum < res[j].BlockNum })
+	return res
+}
+
+func addCollPvtRwSet(txPvtRWSet *rwset.TxPvtReadWriteSet, namespace string, collPvtRWSet *rwset.CollectionPvtReadWriteSet) {
+	for _, nsPvtRWSet := range txPvtRWSet.NsPvtRwset {
+		if nsPvtRWSet.Namespace == namespace {
+			nsPvtRWSet.CollectionPvtRwset = append(nsPvtRWSet.CollectionPvtRwset, collPvtRWSet)
+			return
+		}
+	}
+	txPvtRWSet.NsPvtRwset = append(txPvtRWSet.NsPvtRwset, &rwset.NsPvtReadWriteSet{
+		Namespace:          namespace,
+		CollectionPvtRwset: []*rwset.CollectionPvtReadWriteSet{collPvtRWSet},
+	})
+}
@@ -0,0 +1,230 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package privdata
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	util2 "github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/core/ledger"
+	"github.com/hyperledger/fabric/protos/common"
+	proto "github.com/hyperledger/fabric/protos/gossip"
+	"github.com/hyperledger/fabric/protos/ledger/rwset"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetReconcilerConfig(t *testing.T) {
+	defer viper.Reset()
+
+	// default values are used when the configuration keys are not set
+	config := GetReconcilerConfig()
+	assert.Equal(t, time.Minute, config.SleepInterval)
+	assert.Equal(t, 10, config.BatchSize)
+	assert.True(t, config.IsEnabled)
+
+	viper.Set("peer.gossip.pvtData.reconcileSleepInterval", "5s")
+	viper.Set("peer.gossip.pvtData.reconcileBatchSize", 3)
+	viper.Set("peer.gossip.pvtData.reconciliationEnabled", false)
+	config = GetReconcilerConfig()
+	assert.Equal(t, 5*time.Second, config.SleepInterval)
+	assert.Equal(t, 3, config.BatchSize)
+	assert.False(t, config.IsEnabled)
+}
+
+func TestNoItemsToReconcile(t *testing.T) {
+	// Scenario: there is no missing private data in the ledger,
+	// hence nothing is fetched from the peers nor committed
+	committer := &committerMock{}
+	fetcher := &fetcherMock{t: t}
+	committer.On("GetMissingPvtDataInfoForMostRecentBlocks", 10).Return(ledger.MissingPvtDataInfo{}, nil)
+
+	r := NewReconciler("test", committer, fetcher, createcollectionStore(common.SignedData{}).thatAcceptsAll(),
+		&ReconcilerConfig{SleepInterval: time.Minute, BatchSize: 10, IsEnabled: true}).(*reconciler)
+	assert.NoError(t, r.reconcile())
+	fetcher.AssertNotCalled(t, "fetch", mock.Anything)
+	committer.AssertNotCalled(t, "CommitPvtDataOfOldBlocks", mock.Anything)
+}
+
+func TestReconciliationHappyPath(t *testing.T) {
+	// Scenario: the private data of two transactions of a block is missing. The private data
+	// fetched for the first transaction matches the hash in the block and is committed,
+	// while the private data fetched for the second transaction doesn't and is ignored
+	committer := &committerMock{}
+	fetcher := &fetcherMock{t: t}
+
+	missingPvtDataInfo := make(ledger.MissingPvtDataInfo)
+	missingPvtDataInfo.Add(1, 0, "ns1", "c1")
+	missingPvtDataInfo.Add(1, 1, "ns1", "c2")
+	committer.On("GetMissingPvtDataInfoForMostRecentBlocks", 10).Return(missingPvtDataInfo, nil)
+
+	bf := &blockFactory{
+		channelID: "test",
+	}
+	block := bf.AddTxnWithEndorsement("tx1", "ns1", util2.ComputeSHA256([]byte("rws-original")), "org1", "c1").
+		AddTxnWithEndorsement("tx2", "ns1", util2.ComputeSHA256([]byte("rws-original2")), "org0", "c2").create()
+	committer.On("GetBlocks", []uint64{1}).Return([]*common.Block{block})
+
+	fetcher.On("fetch", mock.Anything).expectingDigests([]*proto.PvtDataDigest{
+		{TxId: "tx1", Namespace: "ns1", Collection: "c1", BlockSeq: 1, SeqInBlock: 0},
+		{TxId: "tx2", Namespace: "ns1", Collection: "c2", BlockSeq: 1, SeqInBlock: 1},
+	}).expectingEndorsers("org1", "org0").Return([]*proto.PvtDataElement{
+		{
+			Digest:  &proto.PvtDataDigest{TxId: "tx1", Namespace: "ns1", Collection: "c1", BlockSeq: 1, SeqInBlock: 0},
+			Payload: [][]byte{[]byte("rws-original")},
+		},
+		{
+			Digest:  &proto.PvtDataDigest{TxId: "tx2", Namespace: "ns1", Collection: "c2", BlockSeq: 1, SeqInBlock: 1},
+			Payload: [][]byte{[]byte("rws-tampered")},
+		},
+	}, nil)
+
+	expectedBlocksPvtData := []*ledger.BlockPvtData{
+		{
+			BlockNum: 1,
+			WriteSets: map[uint64]*ledger.TxPvtData{
+				0: {
+					SeqInBlock: 0,
+					WriteSet: &rwset.TxPvtReadWriteSet{
+						DataModel: rwset.TxReadWriteSet_KV,
+						NsPvtRwset: []*rwset.NsPvtReadWriteSet{
+							{
+								Namespace: "ns1",
+								CollectionPvtRwset: []*rwset.CollectionPvtReadWriteSet{
+									{
+										CollectionName: "c1",
+										Rwset:          []byte("rws-original"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	var commitHappened bool
+	committer.On("CommitPvtDataOfOldBlocks", mock.Anything).Run(func(args mock.Arguments) {
+		assert.Equal(t, expectedBlocksPvtData, args.Get(0).([]*ledger.BlockPvtData))
+		commitHappened = true
+	}).Return(nil, nil)
+
+	r := NewReconciler("test", committer, fetcher, createcollectionStore(common.SignedData{}).thatAcceptsAll(),
+		&ReconcilerConfig{SleepInterval: time.Minute, BatchSize: 10, IsEnabled: true}).(*reconciler)
+	assert.NoError(t, r.reconcile())
+	assert.True(t, commitHappened)
+}
+
+func TestReconciliationFailures(t *testing.T) {
+	config := &ReconcilerConfig{SleepInterval: time.Minute, BatchSize: 10, IsEnabled: true}
+	cs := createcollectionStore(common.SignedData{}).thatAcceptsAll()
+	missingPvtDataInfo := make(ledger.MissingPvtDataInfo)
+	missingPvtDataInfo.Add(1, 0, "ns1", "c1")
+	hash := util2.ComputeSHA256([]byte("rws-original"))
+	bf := &blockFactory{
+		channelID: "test",
+	}
+
+	// Scenario I: the missing private data information can't be retrieved from the ledger
+	committer := &committerMock{}
+	committer.On("GetMissingPvtDataInfoForMostRecentBlocks", 10).Return(nil, errors.New("failed accessing ledger"))
+	r := NewReconciler("test", committer, &fetcherMock{t: t}, cs, config).(*reconciler)
+	err := r.reconcile()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed accessing ledger")
+
+	// Scenario II: the block with the missing private data lacks a tx filter
+	committer = &committerMock{}
+	committer.On("GetMissingPvtDataInfoForMostRecentBlocks", 10).Return(missingPvtDataInfo, nil)
+	committer.On("GetBlocks", []uint64{1}).Return([]*common.Block{bf.AddTxn("tx1", "ns1", hash, "c1").withoutMetadata().create()})
+	r = NewReconciler("test", committer, &fetcherMock{t: t}, cs, config).(*reconciler)
+	err = r.reconcile()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "lacks a Tx filter bitmap")
+
+	// Scenario III: the private data can't be fetched from the peers
+	committer = &committerMock{}
+	fetcher := &fetcherMock{t: t}
+	committer.On("GetMissingPvtDataInfoForMostRecentBlocks", 10).Return(missingPvtDataInfo, nil)
+	committer.On("GetBlocks", []uint64{1}).Return([]*common.Block{bf.AddTxnWithEndorsement("tx1", "ns1", hash, "org1", "c1").create()})
+	fetcher.On("fetch", mock.Anything).expectingDigests([]*proto.PvtDataDigest{
+		{TxId: "tx1", Namespace: "ns1", Collection: "c1", BlockSeq: 1, SeqInBlock: 0},
+	}).expectingEndorsers("org1").Return(nil, errors.New("failed fetching"))
+	r = NewReconciler("test", committer, fetcher, cs, config).(*reconciler)
+	err = r.reconcile()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed fetching")
+	committer.AssertNotCalled(t, "CommitPvtDataOfOldBlocks", mock.Anything)
+}
+
+func TestReconciliationCursor(t *testing.T) {
+	// Scenario: the private data missing in blocks 5 and 3 cannot be reconciled, as the collections
+	// are not in the transactions. The attempts go through the older blocks with missing private data
+	// rather than always looking at the most recent ones, and start over once none is left
+	committer := &committerMock{}
+	bf := &blockFactory{
+		channelID: "test",
+	}
+	hash := util2.ComputeSHA256([]byte("rws-original"))
+	for _, blockNum := range []uint64{5, 3} {
+		missingPvtDataInfo := make(ledger.MissingPvtDataInfo)
+		missingPvtDataInfo.Add(blockNum, 0, "ns1", "c1")
+		block := bf.AddTxnWithEndorsement("tx1", "ns1", hash, "org1", "c2").create()
+		block.Header.Number = blockNum
+		committer.On("GetBlocks", []uint64{blockNum}).Return([]*common.Block{block})
+		if blockNum == 5 {
+			committer.On("GetMissingPvtDataInfoForMostRecentBlocks", 1).Return(missingPvtDataInfo, nil)
+		} else {
+			committer.On("GetMissingPvtDataInfoForBlocksBelow", uint64(5), 1).Return(missingPvtDataInfo, nil)
+		}
+	}
+	committer.On("GetMissingPvtDataInfoForBlocksBelow", uint64(3), 1).Return(ledger.MissingPvtDataInfo{}, nil)
+
+	fetcher := &fetcherMock{t: t}
+	r := NewReconciler("test", committer, fetcher, createcollectionStore(common.SignedData{}).thatAcceptsAll(),
+		&ReconcilerConfig{SleepInterval: time.Minute, BatchSize: 1, IsEnabled: true}).(*reconciler)
+	for _, expectedCursor := range []uint64{5, 3, 5} {
+		assert.NoError(t, r.reconcile())
+		assert.Equal(t, expectedCursor, r.cursor)
+	}
+	committer.AssertNumberOfCalls(t, "GetMissingPvtDataInfoForMostRecentBlocks", 2)
+	committer.AssertNumberOfCalls(t, "GetMissingPvtDataInfoForBlocksBelow", 2)
+	fetcher.AssertNotCalled(t, "fetch", mock.Anything)
+
+	// the cursor is kept if the missing private data information can't be retrieved from the ledger
+	committer = &committerMock{}
+	committer.On("GetMissingPvtDataInfoForBlocksBelow", uint64(5), 1).Return(nil, errors.New("failed accessing ledger"))
+	r.Committer = committer
+	assert.Error(t, r.reconcile())
+	assert.Equal(t, uint64(5), r.cursor)
+}
+
+func TestReconcilerStartStop(t *testing.T) {
+	// Scenario: the reconciler periodically looks for missing private data until it is stopped
+	committer := &committerMock{}
+	invoked := make(chan struct{}, 10)
+	committer.On("GetMissingPvtDataInfoForMostRecentBlocks", 10).Run(func(_ mock.Arguments) {
+		invoked <- struct{}{}
+	}).Return(ledger.MissingPvtDataInfo{}, nil)
+
+	r := NewReconciler("test", committer, &fetcherMock{t: t}, createcollectionStore(common.SignedData{}).thatAcceptsAll(),
+		&ReconcilerConfig{SleepInterval: 10 * time.Millisecond, BatchSize: 10, IsEnabled: true})
+	r.Start()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-invoked:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Reconciliation wasn't attempted in a timely manner")
+		}
+	}
+	r.Stop()
+	// stopping the reconciler twice is harmless
+	r.Stop()
+}
@@ -9,6 +9,7 @@ package service
 import (
 	"sync"
 
+	"github.com/hyperledger/fabric/common/channelconfig"
 	"github.com/hyperledger/fabric/core/committer"
 	"github.com/hyperledger/fabric/core/committer/txvalidator"
 	"github.com/hyperledger/fabric/core/common/privdata"
@@ -76,10 +77,12 @@ type privateHandler struct {
 	support     Support
 	coordinator privdata2.Coordinator
 	distributor privdata2.PvtDataDistributor
+	reconciler  privdata2.PvtDataReconciler
 }
 
 func (p privateHandler) close() {
 	p.coordinator.Close()
+	p.reconciler.Stop()
 }
 
 type gossipServiceImpl struct {
@@ -204,6 +207,10 @@ type Support struct {
 	Committer committer.Committer
 	Store     privdata2.TransientStore
 	Cs        privdata.CollectionStore
+	// ChannelConfig returns the current config of the channel. If set, the blocks of
+	// the channel are also verified to carry the signatures of a quorum of the orderers,
+	// when the channel is ordered by the BFT consenter
+	ChannelConfig func() channelconfig.Resources
 }
 
 // DataStoreSupport aggregates interfaces capable
@@ -219,7 +226,11 @@ func (g *gossipServiceImpl) InitializeChannel(chainID string, endpoints []string
 	defer g.lock.Unlock()
 	// Initialize new state provider for given committer
 	logger.Debug("Creating state provider for chainID", chainID)
-	servicesAdapter := &state.ServicesMediator{GossipAdapter: g, MCSAdapter: g.mcs}
+	mcs := g.mcs
+	if support.ChannelConfig != nil {
+		mcs = deliverclient.NewQuorumVerifier(mcs, support.ChannelConfig)
+	}
+	servicesAdapter := &state.ServicesMediator{GossipAdapter: g, MCSAdapter: mcs}
 
 	// Embed transient store and committer APIs to fulfill
 	// DataStore interface to capture ability of retrieving
@@ -240,15 +251,24 @@ func (g *gossipServiceImpl) InitializeChannel(chainID string, endpoints []string
 		Fetcher:         fetcher,
 	}, g.createSelfSignedData())
 
+	var reconciler privdata2.PvtDataReconciler
+	if reconcilerConfig := privdata2.GetReconcilerConfig(); reconcilerConfig.IsEnabled {
+		reconciler = privdata2.NewReconciler(chainID, support.Committer, fetcher, support.Cs, reconcilerConfig)
+	} else {
+		reconciler = &privdata2.NoOpReconciler{}
+	}
+	reconciler.Start()
+
 	g.privateHandlers[chainID] = privateHandler{
 		support:     support,
 		coordinator: coordinator,
 		distributor: privdata2.NewDistributor(chainID, g),
+		reconciler:  reconciler,
 	}
 	g.chains[chainID] = state.NewGossipStateProvider(chainID, servicesAdapter, coordinator)
 	if g.deliveryService[chainID] == nil {
 		var err error
-		g.deliveryService[chainID], err = g.deliveryFactory.Service(g, endpoints, g.mcs)
+		g.deliveryService[chainID], err = g.deliveryFactory.Service(g, endpoints, mcs)
 		if err != nil {
 			logger.Warningf("Cannot create delivery client, due to %+v", errors.WithStack(err))
 		}
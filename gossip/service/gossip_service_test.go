@@ -338,6 +338,18 @@ func (li *mockLedgerInfo) GetPvtDataByNum(blockNum uint64, filter ledger.PvtNsCo
 	panic("implement me")
 }
 
+func (li *mockLedgerInfo) GetMissingPvtDataInfoForMostRecentBlocks(maxBlocks int) (ledger.MissingPvtDataInfo, error) {
+	return nil, nil
+}
+
+func (li *mockLedgerInfo) GetMissingPvtDataInfoForBlocksBelow(blockNum uint64, maxBlocks int) (ledger.MissingPvtDataInfo, error) {
+	return nil, nil
+}
+
+func (li *mockLedgerInfo) CommitPvtDataOfOldBlocks(blocksPvtData []*ledger.BlockPvtData) ([]*ledger.PvtdataHashMismatch, error) {
+	panic("implement me")
+}
+
 func (li *mockLedgerInfo) CommitWithPvtData(blockAndPvtData *ledger.BlockAndPvtData) error {
 	panic("implement me")
 }
@@ -0,0 +1,85 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package state
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/hyperledger/fabric/gossip/discovery"
+)
+
+// defCommitHashesToRetain is the number of the most recent blocks for which
+// the commit hashes are retained for the comparison with the other peers
+const defCommitHashesToRetain = 100
+
+// commitHashTracker keeps the commit hashes of the most recent blocks committed by the peer
+// and compares them with the commit hashes advertised by the other peers of the channel.
+// A mismatch of the commit hashes at the same height indicates that the state of the peers
+// has diverged, which is reported once per peer and block
+type commitHashTracker struct {
+	sync.Mutex
+	chainID         string
+	maxBlocks       uint64
+	commitHashes    map[uint64][]byte
+	reportedForks   map[string]uint64
+	lastBlockNumber uint64
+}
+
+func newCommitHashTracker(chainID string, maxBlocks uint64) *commitHashTracker {
+	return &commitHashTracker{
+		chainID:       chainID,
+		maxBlocks:     maxBlocks,
+		commitHashes:  make(map[uint64][]byte),
+		reportedForks: make(map[string]uint64),
+	}
+}
+
+// add records the commit hash of a block committed by the peer
+func (t *commitHashTracker) add(blockNum uint64, commitHash []byte) {
+	if len(commitHash) == 0 {
+		return
+	}
+	t.Lock()
+	defer t.Unlock()
+	t.commitHashes[blockNum] = commitHash
+	if blockNum > t.lastBlockNumber {
+		t.lastBlockNumber = blockNum
+	}
+	for num := range t.commitHashes {
+		if num+t.maxBlocks <= t.lastBlockNumber {
+			delete(t.commitHashes, num)
+		}
+	}
+}
+
+// check compares the commit hashes advertised by the given peers with the commit hashes of the same blocks committed
+// by the peer, and returns the peers whose commit hashes do not match and have not been reported before
+func (t *commitHashTracker) check(peers []discovery.NetworkMember) []discovery.NetworkMember {
+	t.Lock()
+	defer t.Unlock()
+	var forkedPeers []discovery.NetworkMember
+	for _, peer := range peers {
+		if peer.Properties == nil || len(peer.Properties.CommitHash) == 0 {
+			continue
+		}
+		blockNum := peer.Properties.LedgerHeight
+		commitHash, exists := t.commitHashes[blockNum]
+		if !exists || bytes.Equal(commitHash, peer.Properties.CommitHash) {
+			continue
+		}
+		if reportedBlockNum, reported := t.reportedForks[string(peer.PKIid)]; reported && reportedBlockNum == blockNum {
+			continue
+		}
+		t.reportedForks[string(peer.PKIid)] = blockNum
+		logger.Errorf("Channel [%s]: Commit hash [%x] of block [%d] advertised by peer [%s] differs from the commit hash [%x] "+
+			"of the block committed by this peer. The state of the peers has diverged",
+			t.chainID, peer.Properties.CommitHash, blockNum, peer.Endpoint, commitHash)
+		forkedPeers = append(forkedPeers, peer)
+	}
+	return forkedPeers
+}
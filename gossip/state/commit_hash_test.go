@@ -0,0 +1,57 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package state
+
+import (
+	"testing"
+
+	"github.com/hyperledger/fabric/gossip/common"
+	"github.com/hyperledger/fabric/gossip/discovery"
+	proto "github.com/hyperledger/fabric/protos/gossip"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommitHashTracker(t *testing.T) {
+	tracker := newCommitHashTracker("testchainid", 3)
+	for blockNum := uint64(0); blockNum < 5; blockNum++ {
+		tracker.add(blockNum, []byte{byte(blockNum)})
+	}
+	// a block without a commit hash is not tracked
+	tracker.add(5, nil)
+
+	// only the commit hashes of the most recent blocks are retained
+	assert.Len(t, tracker.commitHashes, 3)
+	assert.Equal(t, []byte{4}, tracker.commitHashes[4])
+
+	member := func(id string, height uint64, commitHash []byte) discovery.NetworkMember {
+		return discovery.NetworkMember{
+			PKIid:      common.PKIidType(id),
+			Endpoint:   id,
+			Properties: &proto.Properties{LedgerHeight: height, CommitHash: commitHash},
+		}
+	}
+	peers := []discovery.NetworkMember{
+		member("p1", 4, []byte{4}),
+		member("p2", 3, []byte{0}),
+		// the commit hashes of the blocks that are not retained or not yet committed cannot be compared
+		member("p3", 0, []byte{1}),
+		member("p4", 6, []byte{1}),
+		// a peer that does not advertise a commit hash
+		member("p5", 4, nil),
+		{PKIid: common.PKIidType("p6"), Endpoint: "p6"},
+	}
+	forkedPeers := tracker.check(peers)
+	assert.Len(t, forkedPeers, 1)
+	assert.Equal(t, "p2", forkedPeers[0].Endpoint)
+
+	// a fork is reported once per peer and block
+	assert.Empty(t, tracker.check(peers))
+	peers[1] = member("p2", 4, []byte{0})
+	forkedPeers = tracker.check(peers)
+	assert.Len(t, forkedPeers, 1)
+	assert.Equal(t, "p2", forkedPeers[0].Endpoint)
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/hyperledger/fabric/protos/common"
 	proto "github.com/hyperledger/fabric/protos/gossip"
 	"github.com/hyperledger/fabric/protos/ledger/rwset"
+	"github.com/hyperledger/fabric/protos/utils"
 	"github.com/op/go-logging"
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
@@ -146,6 +147,9 @@ type GossipStateProviderImpl struct {
 	once sync.Once
 
 	stateTransferActive int32
+
+	// Commit hashes of the recent blocks, compared with the ones advertised by the other peers
+	commitHashes *commitHashTracker
 }
 
 var logger *logging.Logger // package-level logger
@@ -229,6 +233,8 @@ func NewGossipStateProvider(chainID string, services *ServicesMediator, ledger l
 		stateTransferActive: 0,
 
 		once: sync.Once{},
+
+		commitHashes: newCommitHashTracker(chainID, defCommitHashesToRetain),
 	}
 
 	nodeMetastate := common2.NewNodeMetastate(height - 1)
@@ -580,6 +586,7 @@ func (s *GossipStateProviderImpl) antiEntropy() {
 			s.stopCh <- struct{}{}
 			return
 		case <-time.After(defAntiEntropyInterval):
+			s.commitHashes.check(s.mediator.PeersOfChannel(common2.ChainID(s.chainID)))
 			current, err := s.ledger.LedgerHeight()
 			if err != nil {
 				// Unable to read from ledger continue to the next round
@@ -781,6 +788,13 @@ func (s *GossipStateProviderImpl) commitBlock(block *common.Block, pvtData util.
 
 	// Update ledger level within node metadata
 	nodeMetastate := common2.NewNodeMetastate(block.Header.Number)
+	// Advertise the commit hash computed by the ledger, so that the state can be compared across peers
+	if commitHash, err := utils.GetCommitHashFromBlock(block); err != nil {
+		logger.Warningf("Failed extracting the commit hash of block [%d]: %+v", block.Header.Number, errors.WithStack(err))
+	} else {
+		nodeMetastate.CommitHash = commitHash
+		s.commitHashes.add(block.Header.Number, commitHash)
+	}
 	// Decode nodeMetastate to byte array
 	b, err := nodeMetastate.Bytes()
 	if err == nil {
@@ -15,7 +15,12 @@ It has these top-level messages:
 	Credential
 	CredRequest
 	Signature
+	NonRevocationProof
 	NymSignature
+	CredentialRevocationInformation
+	WBBRevocationData
+	WBBHandleSignature
+	WBBNonRevocationProof
 */
 package idemix
 
@@ -334,20 +339,27 @@ func (m *CredRequest) GetProofS2() []byte {
 // Nym - a fresh pseudonym (a commitment to to the user secert)
 // ProofSRNym - a zero-knowledge proof of knowledge of the
 // user secret inside Nym
+// RevocationEpochPk, RevocationPkSig, Epoch - the epoch public key of the revocation
+// authority, its signature under the long term revocation key and the epoch it is valid in
+// NonRevocationProof - a proof that the credential is not revoked in the epoch
 type Signature struct {
-	APrime       *ECP     `protobuf:"bytes,1,opt,name=APrime" json:"APrime,omitempty"`
-	ABar         *ECP     `protobuf:"bytes,2,opt,name=ABar" json:"ABar,omitempty"`
-	BPrime       *ECP     `protobuf:"bytes,3,opt,name=BPrime" json:"BPrime,omitempty"`
-	ProofC       []byte   `protobuf:"bytes,4,opt,name=ProofC,proto3" json:"ProofC,omitempty"`
-	ProofSSk     []byte   `protobuf:"bytes,5,opt,name=ProofSSk,proto3" json:"ProofSSk,omitempty"`
-	ProofSE      []byte   `protobuf:"bytes,6,opt,name=ProofSE,proto3" json:"ProofSE,omitempty"`
-	ProofSR2     []byte   `protobuf:"bytes,7,opt,name=ProofSR2,proto3" json:"ProofSR2,omitempty"`
-	ProofSR3     []byte   `protobuf:"bytes,8,opt,name=ProofSR3,proto3" json:"ProofSR3,omitempty"`
-	ProofSSPrime []byte   `protobuf:"bytes,9,opt,name=ProofSSPrime,proto3" json:"ProofSSPrime,omitempty"`
-	ProofSAttrs  [][]byte `protobuf:"bytes,10,rep,name=ProofSAttrs,proto3" json:"ProofSAttrs,omitempty"`
-	Nonce        []byte   `protobuf:"bytes,11,opt,name=Nonce,proto3" json:"Nonce,omitempty"`
-	Nym          *ECP     `protobuf:"bytes,12,opt,name=Nym" json:"Nym,omitempty"`
-	ProofSRNym   []byte   `protobuf:"bytes,13,opt,name=ProofSRNym,proto3" json:"ProofSRNym,omitempty"`
+	APrime             *ECP                `protobuf:"bytes,1,opt,name=APrime" json:"APrime,omitempty"`
+	ABar               *ECP                `protobuf:"bytes,2,opt,name=ABar" json:"ABar,omitempty"`
+	BPrime             *ECP                `protobuf:"bytes,3,opt,name=BPrime" json:"BPrime,omitempty"`
+	ProofC             []byte              `protobuf:"bytes,4,opt,name=ProofC,proto3" json:"ProofC,omitempty"`
+	ProofSSk           []byte              `protobuf:"bytes,5,opt,name=ProofSSk,proto3" json:"ProofSSk,omitempty"`
+	ProofSE            []byte              `protobuf:"bytes,6,opt,name=ProofSE,proto3" json:"ProofSE,omitempty"`
+	ProofSR2           []byte              `protobuf:"bytes,7,opt,name=ProofSR2,proto3" json:"ProofSR2,omitempty"`
+	ProofSR3           []byte              `protobuf:"bytes,8,opt,name=ProofSR3,proto3" json:"ProofSR3,omitempty"`
+	ProofSSPrime       []byte              `protobuf:"bytes,9,opt,name=ProofSSPrime,proto3" json:"ProofSSPrime,omitempty"`
+	ProofSAttrs        [][]byte            `protobuf:"bytes,10,rep,name=ProofSAttrs,proto3" json:"ProofSAttrs,omitempty"`
+	Nonce              []byte              `protobuf:"bytes,11,opt,name=Nonce,proto3" json:"Nonce,omitempty"`
+	Nym                *ECP                `protobuf:"bytes,12,opt,name=Nym" json:"Nym,omitempty"`
+	ProofSRNym         []byte              `protobuf:"bytes,13,opt,name=ProofSRNym,proto3" json:"ProofSRNym,omitempty"`
+	RevocationEpochPk  *ECP2               `protobuf:"bytes,14,opt,name=RevocationEpochPk" json:"RevocationEpochPk,omitempty"`
+	RevocationPkSig    []byte              `protobuf:"bytes,15,opt,name=RevocationPkSig,proto3" json:"RevocationPkSig,omitempty"`
+	Epoch              int64               `protobuf:"varint,16,opt,name=Epoch" json:"Epoch,omitempty"`
+	NonRevocationProof *NonRevocationProof `protobuf:"bytes,17,opt,name=NonRevocationProof" json:"NonRevocationProof,omitempty"`
 }
 
 func (m *Signature) Reset()                    { *m = Signature{} }
@@ -446,6 +458,59 @@ func (m *Signature) GetProofSRNym() []byte {
 	return nil
 }
 
+func (m *Signature) GetRevocationEpochPk() *ECP2 {
+	if m != nil {
+		return m.RevocationEpochPk
+	}
+	return nil
+}
+
+func (m *Signature) GetRevocationPkSig() []byte {
+	if m != nil {
+		return m.RevocationPkSig
+	}
+	return nil
+}
+
+func (m *Signature) GetEpoch() int64 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *Signature) GetNonRevocationProof() *NonRevocationProof {
+	if m != nil {
+		return m.NonRevocationProof
+	}
+	return nil
+}
+
+// NonRevocationProof contains a proof of non-revocation w.r.t. a given revocation algorithm
+type NonRevocationProof struct {
+	RevocationAlg      int32  `protobuf:"varint,1,opt,name=RevocationAlg" json:"RevocationAlg,omitempty"`
+	NonRevocationProof []byte `protobuf:"bytes,2,opt,name=NonRevocationProof,proto3" json:"NonRevocationProof,omitempty"`
+}
+
+func (m *NonRevocationProof) Reset()                    { *m = NonRevocationProof{} }
+func (m *NonRevocationProof) String() string            { return proto.CompactTextString(m) }
+func (*NonRevocationProof) ProtoMessage()               {}
+func (*NonRevocationProof) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *NonRevocationProof) GetRevocationAlg() int32 {
+	if m != nil {
+		return m.RevocationAlg
+	}
+	return 0
+}
+
+func (m *NonRevocationProof) GetNonRevocationProof() []byte {
+	if m != nil {
+		return m.NonRevocationProof
+	}
+	return nil
+}
+
 // NymSignature specifies a signature object that signs a message
 // with respect to a pseudonym. It differs from the standard idemix.signature in the fact that
 // the  standard signature object also proves that the pseudonym is based on a secret certified by
@@ -465,7 +530,7 @@ type NymSignature struct {
 func (m *NymSignature) Reset()                    { *m = NymSignature{} }
 func (m *NymSignature) String() string            { return proto.CompactTextString(m) }
 func (*NymSignature) ProtoMessage()               {}
-func (*NymSignature) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+func (*NymSignature) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
 
 func (m *NymSignature) GetProofC() []byte {
 	if m != nil {
@@ -495,6 +560,144 @@ func (m *NymSignature) GetNonce() []byte {
 	return nil
 }
 
+// CredentialRevocationInformation contains the data necessary for a user
+// to create a non-revocation proof in a given epoch
+type CredentialRevocationInformation struct {
+	// Epoch contains the epoch (time window) in which this CRI is valid
+	Epoch int64 `protobuf:"varint,1,opt,name=Epoch" json:"Epoch,omitempty"`
+	// EpochPk is the public key that is used by the revocation authority in this epoch
+	EpochPk *ECP2 `protobuf:"bytes,2,opt,name=EpochPk" json:"EpochPk,omitempty"`
+	// EpochPkSig is a signature on the EpochPk valid under the revocation authority's long term key
+	EpochPkSig []byte `protobuf:"bytes,3,opt,name=EpochPkSig,proto3" json:"EpochPkSig,omitempty"`
+	// RevocationAlg denotes which revocation algorithm is used
+	RevocationAlg int32 `protobuf:"varint,4,opt,name=RevocationAlg" json:"RevocationAlg,omitempty"`
+	// RevocationData contains data specific to the revocation algorithm used
+	RevocationData []byte `protobuf:"bytes,5,opt,name=RevocationData,proto3" json:"RevocationData,omitempty"`
+}
+
+func (m *CredentialRevocationInformation) Reset()         { *m = CredentialRevocationInformation{} }
+func (m *CredentialRevocationInformation) String() string { return proto.CompactTextString(m) }
+func (*CredentialRevocationInformation) ProtoMessage()    {}
+func (*CredentialRevocationInformation) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{9}
+}
+
+func (m *CredentialRevocationInformation) GetEpoch() int64 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *CredentialRevocationInformation) GetEpochPk() *ECP2 {
+	if m != nil {
+		return m.EpochPk
+	}
+	return nil
+}
+
+func (m *CredentialRevocationInformation) GetEpochPkSig() []byte {
+	if m != nil {
+		return m.EpochPkSig
+	}
+	return nil
+}
+
+func (m *CredentialRevocationInformation) GetRevocationAlg() int32 {
+	if m != nil {
+		return m.RevocationAlg
+	}
+	return 0
+}
+
+func (m *CredentialRevocationInformation) GetRevocationData() []byte {
+	if m != nil {
+		return m.RevocationData
+	}
+	return nil
+}
+
+// WBBRevocationData is the revocation data of the ALG_WBB_SIGNATURE revocation algorithm.
+// It consists of a weak Boneh-Boyen signature, under the secret key of the epoch,
+// on each of the revocation handles that are not revoked in the epoch
+type WBBRevocationData struct {
+	Signatures []*WBBHandleSignature `protobuf:"bytes,1,rep,name=Signatures" json:"Signatures,omitempty"`
+}
+
+func (m *WBBRevocationData) Reset()                    { *m = WBBRevocationData{} }
+func (m *WBBRevocationData) String() string            { return proto.CompactTextString(m) }
+func (*WBBRevocationData) ProtoMessage()               {}
+func (*WBBRevocationData) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+func (m *WBBRevocationData) GetSignatures() []*WBBHandleSignature {
+	if m != nil {
+		return m.Signatures
+	}
+	return nil
+}
+
+// WBBHandleSignature is a weak Boneh-Boyen signature Sigma on the revocation handle RH
+type WBBHandleSignature struct {
+	RH    []byte `protobuf:"bytes,1,opt,name=RH,proto3" json:"RH,omitempty"`
+	Sigma *ECP   `protobuf:"bytes,2,opt,name=Sigma" json:"Sigma,omitempty"`
+}
+
+func (m *WBBHandleSignature) Reset()                    { *m = WBBHandleSignature{} }
+func (m *WBBHandleSignature) String() string            { return proto.CompactTextString(m) }
+func (*WBBHandleSignature) ProtoMessage()               {}
+func (*WBBHandleSignature) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+func (m *WBBHandleSignature) GetRH() []byte {
+	if m != nil {
+		return m.RH
+	}
+	return nil
+}
+
+func (m *WBBHandleSignature) GetSigma() *ECP {
+	if m != nil {
+		return m.Sigma
+	}
+	return nil
+}
+
+// WBBNonRevocationProof is a zero-knowledge proof of knowledge of a weak Boneh-Boyen
+// signature on the (hidden) revocation handle of a credential. It consists of
+// SigmaPrime, SigmaBar - the randomized signature and
+// ProofSR - the s-value proving knowledge of the randomness
+// The s-value proving knowledge of the revocation handle is the one of the signature
+type WBBNonRevocationProof struct {
+	SigmaPrime *ECP   `protobuf:"bytes,1,opt,name=SigmaPrime" json:"SigmaPrime,omitempty"`
+	SigmaBar   *ECP   `protobuf:"bytes,2,opt,name=SigmaBar" json:"SigmaBar,omitempty"`
+	ProofSR    []byte `protobuf:"bytes,3,opt,name=ProofSR,proto3" json:"ProofSR,omitempty"`
+}
+
+func (m *WBBNonRevocationProof) Reset()                    { *m = WBBNonRevocationProof{} }
+func (m *WBBNonRevocationProof) String() string            { return proto.CompactTextString(m) }
+func (*WBBNonRevocationProof) ProtoMessage()               {}
+func (*WBBNonRevocationProof) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+func (m *WBBNonRevocationProof) GetSigmaPrime() *ECP {
+	if m != nil {
+		return m.SigmaPrime
+	}
+	return nil
+}
+
+func (m *WBBNonRevocationProof) GetSigmaBar() *ECP {
+	if m != nil {
+		return m.SigmaBar
+	}
+	return nil
+}
+
+func (m *WBBNonRevocationProof) GetProofSR() []byte {
+	if m != nil {
+		return m.ProofSR
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*ECP)(nil), "ECP")
 	proto.RegisterType((*ECP2)(nil), "ECP2")
@@ -503,48 +706,68 @@ func init() {
 	proto.RegisterType((*Credential)(nil), "Credential")
 	proto.RegisterType((*CredRequest)(nil), "CredRequest")
 	proto.RegisterType((*Signature)(nil), "Signature")
+	proto.RegisterType((*NonRevocationProof)(nil), "NonRevocationProof")
 	proto.RegisterType((*NymSignature)(nil), "NymSignature")
+	proto.RegisterType((*CredentialRevocationInformation)(nil), "CredentialRevocationInformation")
+	proto.RegisterType((*WBBRevocationData)(nil), "WBBRevocationData")
+	proto.RegisterType((*WBBHandleSignature)(nil), "WBBHandleSignature")
+	proto.RegisterType((*WBBNonRevocationProof)(nil), "WBBNonRevocationProof")
 }
 
 func init() { proto.RegisterFile("idemix/idemix.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 591 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x54, 0x4d, 0x6b, 0xdb, 0x4c,
-	0x18, 0x64, 0xf5, 0xe1, 0xc4, 0x8f, 0xf5, 0xe6, 0x0d, 0x9b, 0x12, 0x96, 0x50, 0x8a, 0x2b, 0x4a,
-	0xc8, 0xc9, 0x21, 0xca, 0x2f, 0x90, 0x8c, 0x5a, 0x87, 0x82, 0x11, 0xd2, 0x21, 0x76, 0x6f, 0xb2,
-	0xbd, 0xb6, 0x85, 0x2d, 0x2b, 0x5d, 0x49, 0x10, 0xff, 0x8e, 0x5e, 0xfb, 0x4f, 0x7b, 0x29, 0xfb,
-	0xa1, 0x4f, 0xe8, 0xc9, 0x9a, 0x19, 0x3d, 0xfb, 0x8c, 0x67, 0x16, 0xc1, 0x4d, 0xb2, 0xa1, 0x69,
-	0xf2, 0xfe, 0x28, 0x7f, 0x26, 0x6f, 0x2c, 0x2b, 0x32, 0xfb, 0x33, 0xe8, 0xfe, 0x34, 0xc0, 0x16,
-	0xa0, 0x05, 0x41, 0x63, 0xf4, 0x60, 0x85, 0x68, 0xc1, 0xd1, 0x92, 0x68, 0x12, 0x2d, 0xed, 0xaf,
-	0x60, 0xf8, 0xd3, 0xc0, 0xc1, 0x57, 0xa0, 0x2d, 0x5c, 0xf5, 0x92, 0xb6, 0x70, 0x05, 0xf6, 0xd4,
-	0x6b, 0xda, 0xc2, 0xe3, 0x78, 0xe9, 0x12, 0x5d, 0xe2, 0xa5, 0xd0, 0x97, 0x1e, 0x31, 0x14, 0xf6,
-	0xec, 0xdf, 0x1a, 0xfc, 0xff, 0x92, 0xe7, 0x25, 0x65, 0x41, 0xb9, 0x3a, 0x26, 0xeb, 0xef, 0xf4,
-	0x8c, 0xef, 0xe1, 0xca, 0x2d, 0x0a, 0x96, 0xac, 0xca, 0x82, 0xce, 0xe3, 0x94, 0xe6, 0x04, 0x8d,
-	0xf5, 0x87, 0x61, 0xd8, 0x63, 0xf1, 0x2d, 0xe8, 0xb3, 0xe8, 0x20, 0x96, 0x8d, 0x1c, 0x63, 0xe2,
-	0x4f, 0x83, 0x90, 0x13, 0xf8, 0x0e, 0xcc, 0x59, 0x18, 0x9f, 0x36, 0x62, 0x6d, 0xa5, 0x48, 0x0a,
-	0x7f, 0x84, 0xc1, 0x8c, 0x1f, 0x93, 0x13, 0x63, 0xac, 0xd7, 0xa2, 0xe2, 0xf0, 0x0d, 0xa0, 0x57,
-	0x62, 0x8a, 0x29, 0x93, 0x0b, 0x4e, 0x88, 0x5e, 0xf9, 0x71, 0x5e, 0xcc, 0xbe, 0x3d, 0x91, 0x41,
-	0xfb, 0x38, 0x41, 0x55, 0x9a, 0x43, 0x2e, 0xfa, 0x9a, 0x83, 0x6f, 0x61, 0x10, 0xb0, 0x2c, 0xdb,
-	0x4e, 0xc9, 0xa5, 0xf8, 0xbb, 0x0a, 0xd5, 0x7c, 0x44, 0x86, 0x2d, 0x3e, 0xc2, 0x18, 0x8c, 0x59,
-	0x9c, 0xef, 0x09, 0x08, 0x56, 0x3c, 0xdb, 0x2e, 0x0c, 0x65, 0x3a, 0x3c, 0x97, 0x6b, 0xd0, 0x5f,
-	0xa2, 0x83, 0x0a, 0x9b, 0x3f, 0x62, 0x1b, 0xf4, 0x97, 0xa0, 0x4a, 0xe0, 0x7a, 0xd2, 0x0b, 0x32,
-	0xe4, 0xa2, 0xbd, 0x05, 0x98, 0x32, 0xba, 0xa1, 0xa7, 0x22, 0x89, 0x8f, 0x18, 0x03, 0x92, 0x75,
-	0x55, 0x66, 0x91, 0xcb, 0x39, 0xaf, 0x93, 0x22, 0xf2, 0x78, 0xdb, 0xbe, 0xaa, 0x0d, 0xf9, 0x1c,
-	0x45, 0xaa, 0x34, 0x14, 0xe1, 0x0f, 0x60, 0xca, 0x08, 0xcd, 0xb1, 0xfe, 0x60, 0x85, 0x12, 0xd8,
-	0xbf, 0x10, 0x8c, 0xf8, 0xa2, 0x90, 0xfe, 0x2c, 0x69, 0x5e, 0xf0, 0x76, 0xe6, 0xe7, 0xb4, 0xb3,
-	0x8b, 0x13, 0x78, 0x0c, 0x23, 0xe9, 0x73, 0x9e, 0x9d, 0xd6, 0x54, 0x5d, 0x95, 0x36, 0xd5, 0x0a,
-	0x4e, 0xef, 0x04, 0x47, 0xe0, 0x42, 0x46, 0xf5, 0xa4, 0xbc, 0x54, 0xb0, 0x51, 0x1c, 0xd1, 0x5e,
-	0xad, 0x38, 0xf6, 0x1f, 0x0d, 0x86, 0x51, 0xb2, 0x3b, 0xc5, 0x45, 0xc9, 0x28, 0x6f, 0xdf, 0x0d,
-	0x58, 0x92, 0xd2, 0x8e, 0x2d, 0xc5, 0x61, 0x02, 0x86, 0xeb, 0xc5, 0xac, 0x13, 0x85, 0x60, 0xf8,
-	0x9c, 0x27, 0xe7, 0xda, 0x57, 0x4a, 0x71, 0x2d, 0xbf, 0x46, 0xc7, 0xef, 0x1d, 0x5c, 0x4a, 0x1b,
-	0xd1, 0x41, 0xd9, 0xaa, 0x71, 0xe3, 0xd8, 0x17, 0xd7, 0xaa, 0x76, 0xec, 0x37, 0x53, 0xa1, 0xbc,
-	0x55, 0xf5, 0x54, 0xe8, 0xb4, 0xb4, 0x67, 0x75, 0xa9, 0x6a, 0x8c, 0x6d, 0xb0, 0xd4, 0xe9, 0xd2,
-	0xa9, 0xbc, 0x5c, 0x1d, 0x8e, 0x67, 0x2f, 0xb1, 0xec, 0x0f, 0x44, 0x7f, 0x6d, 0x8a, 0x77, 0x2b,
-	0x7b, 0x19, 0x89, 0x71, 0xb3, 0x6a, 0x44, 0x74, 0x69, 0xf5, 0xbb, 0xfc, 0x04, 0xa0, 0xf6, 0x73,
-	0xf9, 0x3f, 0x31, 0xd2, 0x62, 0xec, 0x77, 0xb0, 0xe6, 0xe7, 0xb4, 0xc9, 0xbf, 0x49, 0x0a, 0xfd,
-	0x33, 0x29, 0xad, 0x97, 0x54, 0x77, 0x87, 0xde, 0xdf, 0xd1, 0x38, 0x36, 0x5a, 0x8e, 0xbd, 0xfb,
-	0x1f, 0x5f, 0x76, 0x49, 0xb1, 0x2f, 0x57, 0x93, 0x75, 0x96, 0x3e, 0xee, 0xcf, 0x6f, 0x94, 0x1d,
-	0xe9, 0x66, 0x47, 0xd9, 0xe3, 0x36, 0x5e, 0xb1, 0x64, 0xad, 0x3e, 0x78, 0xab, 0x81, 0xf8, 0xe2,
-	0x3d, 0xff, 0x0d, 0x00, 0x00, 0xff, 0xff, 0x06, 0x88, 0xd9, 0x9e, 0x08, 0x05, 0x00, 0x00,
+	// 823 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x55, 0xdd, 0x6e, 0xe2, 0x46,
+	0x18, 0xd5, 0x60, 0x43, 0x92, 0x2f, 0x6c, 0x7e, 0x26, 0xed, 0x6a, 0xb4, 0xaa, 0xba, 0xd4, 0x5a,
+	0x45, 0x5c, 0x11, 0x2d, 0x79, 0x81, 0x7a, 0x28, 0xad, 0xa3, 0x4a, 0xc8, 0x1a, 0x5f, 0x00, 0xbd,
+	0x33, 0x30, 0x01, 0x17, 0x6c, 0xa7, 0xc6, 0x54, 0xe1, 0x39, 0x7a, 0xdb, 0xd7, 0xe8, 0x2b, 0xf4,
+	0xb9, 0xaa, 0xf9, 0xb1, 0x3d, 0x36, 0xf4, 0x8a, 0x39, 0xe7, 0x9b, 0x9f, 0xc3, 0x39, 0x9f, 0x67,
+	0xe0, 0x21, 0x5a, 0xf1, 0x38, 0x7a, 0x7f, 0x52, 0x3f, 0x83, 0xb7, 0x2c, 0xcd, 0x53, 0xe7, 0x07,
+	0xb0, 0xc6, 0x23, 0x1f, 0x77, 0x01, 0xcd, 0x08, 0xea, 0xa1, 0x7e, 0x97, 0xa1, 0x99, 0x40, 0x73,
+	0xd2, 0x52, 0x68, 0xee, 0xfc, 0x0c, 0xf6, 0x78, 0xe4, 0x0f, 0xf1, 0x0d, 0xb4, 0x66, 0xae, 0x9e,
+	0xd4, 0x9a, 0xb9, 0x12, 0x53, 0x3d, 0xad, 0x35, 0xa3, 0x02, 0xcf, 0x5d, 0x62, 0x29, 0x3c, 0x97,
+	0xf5, 0x39, 0x25, 0xb6, 0xc6, 0xd4, 0xf9, 0xbb, 0x05, 0xb7, 0x2f, 0xfb, 0xfd, 0x81, 0x67, 0xfe,
+	0x61, 0xb1, 0x8b, 0x96, 0xbf, 0xf2, 0x23, 0x7e, 0x84, 0x1b, 0x37, 0xcf, 0xb3, 0x68, 0x71, 0xc8,
+	0xf9, 0x24, 0x8c, 0xf9, 0x9e, 0xa0, 0x9e, 0xd5, 0xbf, 0x62, 0x0d, 0x16, 0x7f, 0x04, 0xcb, 0x0b,
+	0xb6, 0xf2, 0xb0, 0xeb, 0xa1, 0x3d, 0x18, 0x8f, 0x7c, 0x26, 0x08, 0xfc, 0x09, 0xda, 0x1e, 0x0b,
+	0x93, 0x15, 0xb1, 0x8c, 0x8a, 0xa2, 0xf0, 0x77, 0xd0, 0xf1, 0xc4, 0x36, 0x7b, 0x62, 0xf7, 0xac,
+	0xb2, 0xa8, 0x39, 0xfc, 0x00, 0x68, 0x4a, 0xda, 0x72, 0x55, 0x5b, 0x14, 0x86, 0x0c, 0x4d, 0xc5,
+	0x76, 0x34, 0xcc, 0x7e, 0xf9, 0x4a, 0x3a, 0xe6, 0x76, 0x92, 0x2a, 0x6a, 0x43, 0x72, 0xd1, 0xac,
+	0x0d, 0xf1, 0x47, 0xe8, 0xf8, 0x59, 0x9a, 0xbe, 0x8e, 0xc8, 0xa5, 0xfc, 0xbb, 0x1a, 0x95, 0x7c,
+	0x40, 0xae, 0x0c, 0x3e, 0xc0, 0x18, 0x6c, 0x2f, 0xdc, 0x6f, 0x08, 0x48, 0x56, 0x8e, 0x1d, 0x17,
+	0xae, 0x94, 0x3b, 0xc2, 0x97, 0x3b, 0xb0, 0x5e, 0x82, 0xad, 0x36, 0x5b, 0x0c, 0xb1, 0x03, 0xd6,
+	0x8b, 0x5f, 0x38, 0x70, 0x37, 0x68, 0x18, 0xc9, 0x44, 0xd1, 0x79, 0x05, 0x18, 0x65, 0x7c, 0xc5,
+	0x93, 0x3c, 0x0a, 0x77, 0x18, 0x03, 0x52, 0x71, 0x15, 0x62, 0x91, 0x2b, 0x38, 0x5a, 0x73, 0x11,
+	0x51, 0x91, 0xf6, 0x58, 0xc7, 0x86, 0xc6, 0x02, 0x05, 0x3a, 0x34, 0x14, 0xe0, 0x6f, 0xa0, 0xad,
+	0x2c, 0x6c, 0xf7, 0xac, 0x7e, 0x97, 0x29, 0xe0, 0xfc, 0x85, 0xe0, 0x5a, 0x1c, 0xc4, 0xf8, 0x1f,
+	0x07, 0xbe, 0xcf, 0x45, 0x3a, 0x93, 0x63, 0x5c, 0x3b, 0x4b, 0x10, 0xb8, 0x07, 0xd7, 0x4a, 0xe7,
+	0x24, 0x4d, 0x96, 0x5c, 0xb7, 0x8a, 0x49, 0x19, 0xc6, 0x59, 0x35, 0xe3, 0x08, 0x5c, 0xc8, 0x51,
+	0xf0, 0x55, 0x6b, 0x29, 0x60, 0x55, 0x19, 0x92, 0xb6, 0x59, 0x19, 0x3a, 0xff, 0xd8, 0x70, 0x15,
+	0x44, 0xeb, 0x24, 0xcc, 0x0f, 0x19, 0x17, 0xe9, 0xbb, 0x7e, 0x16, 0xc5, 0xbc, 0x26, 0x4b, 0x73,
+	0x98, 0x80, 0xed, 0xd2, 0x30, 0xab, 0x59, 0x21, 0x19, 0xb1, 0x8e, 0xaa, 0x75, 0x66, 0x4b, 0x69,
+	0xce, 0xd0, 0x6b, 0xd7, 0xf4, 0x7e, 0x82, 0x4b, 0x25, 0x23, 0xd8, 0x6a, 0x59, 0x25, 0xae, 0x14,
+	0x8f, 0x49, 0xc7, 0x54, 0x3c, 0xae, 0x56, 0x31, 0xd5, 0x55, 0xe5, 0x2a, 0x36, 0x34, 0x6a, 0xcf,
+	0xba, 0xa9, 0x4a, 0x8c, 0x1d, 0xe8, 0xea, 0xdd, 0x95, 0x52, 0xd5, 0x5c, 0x35, 0x4e, 0x78, 0xaf,
+	0xb0, 0xca, 0x0f, 0x64, 0x7e, 0x26, 0x25, 0xb2, 0x55, 0xb9, 0x5c, 0xcb, 0xe5, 0xed, 0x22, 0x11,
+	0x99, 0x65, 0xb7, 0x99, 0xe5, 0xf7, 0x00, 0xfa, 0x7c, 0x51, 0xfe, 0x20, 0x97, 0x18, 0x0c, 0x7e,
+	0x86, 0x7b, 0xc6, 0xff, 0x4c, 0x97, 0x61, 0x1e, 0xa5, 0xc9, 0xf8, 0x2d, 0x5d, 0x6e, 0xfc, 0x2d,
+	0xb9, 0x31, 0xbf, 0xaf, 0xd3, 0x3a, 0xee, 0xc3, 0x6d, 0x45, 0xfa, 0xdb, 0x20, 0x5a, 0x93, 0x5b,
+	0xb9, 0x73, 0x93, 0x16, 0x62, 0xe5, 0x22, 0x72, 0xd7, 0x43, 0x7d, 0x8b, 0x29, 0x80, 0x47, 0x80,
+	0x27, 0x69, 0x62, 0xcc, 0x15, 0x7a, 0xc8, 0xbd, 0x3c, 0xf5, 0x61, 0x70, 0x5a, 0x62, 0x67, 0xa6,
+	0x3b, 0xbf, 0x9f, 0xdb, 0x04, 0x7f, 0x81, 0x0f, 0x15, 0xe5, 0xee, 0xd6, 0xb2, 0x8d, 0xda, 0xac,
+	0x4e, 0xe2, 0xc1, 0x59, 0x01, 0xaa, 0xd1, 0xcf, 0x9d, 0xf5, 0x0e, 0xdd, 0xc9, 0x31, 0xae, 0xba,
+	0xb4, 0xea, 0x27, 0xf4, 0xbf, 0xfd, 0xd4, 0x6a, 0xf4, 0x53, 0x3d, 0x09, 0xeb, 0x24, 0x89, 0x32,
+	0x57, 0xdb, 0xc8, 0xd5, 0xf9, 0x17, 0xc1, 0xe7, 0xea, 0x72, 0xa8, 0x74, 0xbd, 0x24, 0xaf, 0x69,
+	0x16, 0xcb, 0x61, 0x65, 0x32, 0x32, 0x4d, 0xfe, 0x0c, 0x17, 0x45, 0x9e, 0x2d, 0x33, 0xcf, 0x82,
+	0x15, 0x82, 0xf4, 0x50, 0x04, 0xa8, 0x05, 0x55, 0xcc, 0xa9, 0x95, 0xf6, 0x39, 0x2b, 0x1f, 0xe1,
+	0xa6, 0x22, 0x7e, 0x0a, 0xf3, 0x50, 0x7f, 0x48, 0x0d, 0xd6, 0xf1, 0xe0, 0x7e, 0x4a, 0x69, 0x9d,
+	0xc4, 0xcf, 0x00, 0xa5, 0xa9, 0xea, 0x0d, 0x11, 0x0d, 0x30, 0xa5, 0xd4, 0x0b, 0x93, 0xd5, 0x8e,
+	0x97, 0x35, 0x66, 0x4c, 0x73, 0x7e, 0x04, 0x7c, 0x3a, 0x43, 0x3c, 0x5b, 0xcc, 0x2b, 0x9e, 0x39,
+	0xe6, 0x89, 0x7b, 0x3f, 0x88, 0xd6, 0x71, 0x58, 0xbb, 0x2b, 0x14, 0xe5, 0x1c, 0xe1, 0xdb, 0x29,
+	0xa5, 0x67, 0xbb, 0x07, 0xe4, 0x8c, 0xd3, 0x1b, 0xc8, 0xe0, 0x71, 0x0f, 0x2e, 0x25, 0x6a, 0xde,
+	0x44, 0x25, 0x5b, 0xdd, 0x1d, 0x4c, 0xfb, 0x5a, 0x40, 0xfa, 0xf8, 0xdb, 0x97, 0x75, 0x94, 0x6f,
+	0x0e, 0x8b, 0xc1, 0x32, 0x8d, 0x9f, 0x36, 0xc7, 0x37, 0x9e, 0xed, 0xf8, 0x6a, 0xcd, 0xb3, 0xa7,
+	0xd7, 0x70, 0x91, 0x45, 0x4b, 0xfd, 0xcc, 0x2f, 0x3a, 0xf2, 0x9d, 0x7f, 0xfe, 0x6f, 0x00, 0x1c,
+	0x12, 0xec, 0xba, 0xfe, 0x07, 0x00, 0x00,
 }
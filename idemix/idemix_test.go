@@ -10,6 +10,7 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/milagro-crypto/amcl/version3/go/amcl/FP256BN"
 	"github.com/stretchr/testify/assert"
 )
@@ -95,15 +96,24 @@ func TestIdemix(t *testing.T) {
 	assert.Error(t, cred.Ver(sk, key.IPk), "credential with nil attribute should be invalid")
 	cred.Attrs = attrsBackup
 
+	// Generate a revocation key pair and the CRI of the epoch
+	revocationKey, err := GenerateLongTermRevocationKey()
+	assert.NoError(t, err)
+	epoch := 0
+	rhIndex := 0
+	cri, err := CreateCRI(revocationKey, []*FP256BN.BIG{attrs[rhIndex]}, epoch, ALG_WBB_SIGNATURE)
+	assert.NoError(t, err)
+	assert.NoError(t, VerifyEpochPK(&revocationKey.PublicKey, cri.EpochPk, cri.EpochPkSig, epoch, ALG_WBB_SIGNATURE))
+
 	// Test signing no disclosure
 	Nym, RandNym := MakeNym(sk, key.IPk, rng)
 
 	disclosure := []byte{0, 0, 0, 0, 0}
 	msg := []byte{1, 2, 3, 4, 5}
-	sig, err := NewSignature(cred, sk, Nym, RandNym, key.IPk, disclosure, msg, rng)
+	sig, err := NewSignature(cred, sk, Nym, RandNym, key.IPk, disclosure, msg, rhIndex, cri, rng)
 	assert.NoError(t, err)
 
-	err = sig.Ver(disclosure, key.IPk, msg, nil)
+	err = sig.Ver(disclosure, key.IPk, msg, nil, rhIndex, &revocationKey.PublicKey, epoch)
 	if err != nil {
 		t.Fatalf("Signature should be valid but verification returned error: %s", err)
 		return
@@ -111,10 +121,10 @@ func TestIdemix(t *testing.T) {
 
 	// Test signing selective disclosure
 	disclosure = []byte{0, 1, 1, 1, 1}
-	sig, err = NewSignature(cred, sk, Nym, RandNym, key.IPk, disclosure, msg, rng)
+	sig, err = NewSignature(cred, sk, Nym, RandNym, key.IPk, disclosure, msg, rhIndex, cri, rng)
 	assert.NoError(t, err)
 
-	err = sig.Ver(disclosure, key.IPk, msg, attrs)
+	err = sig.Ver(disclosure, key.IPk, msg, attrs, rhIndex, &revocationKey.PublicKey, epoch)
 	if err != nil {
 		t.Fatalf("Signature should be valid but verification returned error: %s", err)
 		return
@@ -130,3 +140,103 @@ func TestIdemix(t *testing.T) {
 		return
 	}
 }
+
+func TestRevocation(t *testing.T) {
+	rng, err := GetRand()
+	assert.NoError(t, err)
+
+	key, err := NewIssuerKey([]string{"Attr1", "RevocationHandle"}, rng)
+	assert.NoError(t, err)
+
+	// Issue two credentials, with revocation handles 1 and 2
+	rhIndex := 1
+	issue := func(rh int) (*Credential, *FP256BN.BIG) {
+		sk := RandModOrder(rng)
+		randCred := RandModOrder(rng)
+		m := NewCredRequest(sk, randCred, RandModOrder(rng), key.IPk, rng)
+		cred, err := NewCredential(key, m, []*FP256BN.BIG{FP256BN.NewBIGint(7), FP256BN.NewBIGint(rh)}, rng)
+		assert.NoError(t, err)
+		cred.Complete(randCred)
+		return cred, sk
+	}
+	cred1, sk1 := issue(1)
+	cred2, sk2 := issue(2)
+
+	revocationKey, err := GenerateLongTermRevocationKey()
+	assert.NoError(t, err)
+	revPk := &revocationKey.PublicKey
+
+	disclosure := []byte{1, 0}
+	attrs := []*FP256BN.BIG{FP256BN.NewBIGint(7), nil}
+	msg := []byte("message")
+	sign := func(cred *Credential, sk *FP256BN.BIG, cri *CredentialRevocationInformation) (*Signature, error) {
+		Nym, RandNym := MakeNym(sk, key.IPk, rng)
+		return NewSignature(cred, sk, Nym, RandNym, key.IPk, disclosure, msg, rhIndex, cri, rng)
+	}
+
+	// In epoch 0 neither credential is revoked
+	cri0, err := CreateCRI(revocationKey, []*FP256BN.BIG{FP256BN.NewBIGint(1), FP256BN.NewBIGint(2)}, 0, ALG_WBB_SIGNATURE)
+	assert.NoError(t, err)
+	sig1, err := sign(cred1, sk1, cri0)
+	assert.NoError(t, err)
+	assert.NoError(t, sig1.Ver(disclosure, key.IPk, msg, attrs, rhIndex, revPk, 0))
+	sig2, err := sign(cred2, sk2, cri0)
+	assert.NoError(t, err)
+	assert.NoError(t, sig2.Ver(disclosure, key.IPk, msg, attrs, rhIndex, revPk, 0))
+
+	// The CRIs of an epoch share the epoch key
+	cri0bis, err := CreateCRI(revocationKey, []*FP256BN.BIG{FP256BN.NewBIGint(2)}, 0, ALG_WBB_SIGNATURE)
+	assert.NoError(t, err)
+	assert.True(t, proto.Equal(cri0.EpochPk, cri0bis.EpochPk))
+
+	// Signatures of an epoch are not valid in other epochs
+	assert.Error(t, sig1.Ver(disclosure, key.IPk, msg, attrs, rhIndex, revPk, 1))
+
+	// The signatures must verify against the long term key of the revocation authority
+	otherKey, err := GenerateLongTermRevocationKey()
+	assert.NoError(t, err)
+	assert.Error(t, sig1.Ver(disclosure, key.IPk, msg, attrs, rhIndex, &otherKey.PublicKey, 0))
+
+	// The revocation handle must remain hidden
+	Nym, RandNym := MakeNym(sk1, key.IPk, rng)
+	_, err = NewSignature(cred1, sk1, Nym, RandNym, key.IPk, []byte{1, 1}, msg, rhIndex, cri0, rng)
+	assert.Error(t, err)
+	assert.Error(t, sig1.Ver([]byte{1, 1}, key.IPk, msg, attrs, rhIndex, revPk, 0))
+
+	// In epoch 1 the first credential is revoked
+	cri1, err := CreateCRI(revocationKey, []*FP256BN.BIG{FP256BN.NewBIGint(2)}, 1, ALG_WBB_SIGNATURE)
+	assert.NoError(t, err)
+	_, err = sign(cred1, sk1, cri1)
+	assert.EqualError(t, err, "failed to compute non-revoked proof: the credential is revoked in epoch 1")
+	sig2, err = sign(cred2, sk2, cri1)
+	assert.NoError(t, err)
+	assert.NoError(t, sig2.Ver(disclosure, key.IPk, msg, attrs, rhIndex, revPk, 1))
+
+	// The signature on the handle from a previous epoch does not verify under the new epoch key
+	forgedCRI := *cri1
+	forgedCRI.RevocationData = cri0.RevocationData
+	sig1, err = sign(cred1, sk1, &forgedCRI)
+	assert.NoError(t, err)
+	assert.Error(t, sig1.Ver(disclosure, key.IPk, msg, attrs, rhIndex, revPk, 1))
+
+	// Tampering with the non-revocation proof invalidates the signature
+	sig2.NonRevocationProof.RevocationAlg = int32(ALG_NO_REVOCATION)
+	assert.Error(t, sig2.Ver(disclosure, key.IPk, msg, attrs, rhIndex, revPk, 1))
+	sig2.NonRevocationProof = nil
+	assert.Error(t, sig2.Ver(disclosure, key.IPk, msg, attrs, rhIndex, revPk, 1))
+
+	// Without revocation, only the epoch is checked
+	criNoRev, err := CreateCRI(revocationKey, nil, 2, ALG_NO_REVOCATION)
+	assert.NoError(t, err)
+	sig1, err = sign(cred1, sk1, criNoRev)
+	assert.NoError(t, err)
+	assert.NoError(t, sig1.Ver(disclosure, key.IPk, msg, attrs, rhIndex, revPk, 2))
+	assert.Error(t, sig1.Ver(disclosure, key.IPk, msg, attrs, rhIndex, revPk, 1))
+
+	_, err = CreateCRI(revocationKey, nil, 0, RevocationAlgorithm(42))
+	assert.Error(t, err)
+	_, err = CreateCRI(nil, nil, 0, ALG_WBB_SIGNATURE)
+	assert.Error(t, err)
+	assert.Error(t, VerifyEpochPK(revPk, cri1.EpochPk, cri1.EpochPkSig, 0, ALG_WBB_SIGNATURE))
+	assert.Error(t, VerifyEpochPK(revPk, cri1.EpochPk, []byte("garbage"), 1, ALG_WBB_SIGNATURE))
+}
@@ -0,0 +1,180 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package idemix
+
+import (
+	"bytes"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/milagro-crypto/amcl/version3/go/amcl"
+	"github.com/milagro-crypto/amcl/version3/go/amcl/FP256BN"
+	"github.com/pkg/errors"
+)
+
+// A non-revocation proof is part of the zero-knowledge proof of an idemix signature:
+// it proves that the revocation handle inside the credential is not revoked in an epoch.
+// The revocation handle is never disclosed; the proof reuses the randomness and the
+// s-value of the handle from the signature, which links the two proofs.
+
+// nonRevokedProver is the prover of non-revocation of a revocation algorithm
+type nonRevokedProver interface {
+	// getFSContribution returns the contribution of the prover to the
+	// Fiat-Shamir hash of the signature, using rRh as randomness for the handle rh
+	getFSContribution(rh *FP256BN.BIG, rRh *FP256BN.BIG, cri *CredentialRevocationInformation, rng *amcl.RAND) ([]byte, error)
+
+	// getNonRevokedProof completes the proof with the challenge of the signature
+	getNonRevokedProof(chal *FP256BN.BIG) (*NonRevocationProof, error)
+}
+
+// nonRevocationVerifier is the verifier of non-revocation of a revocation algorithm
+type nonRevocationVerifier interface {
+	// recomputeFSContribution recomputes the contribution of the non-revocation proof
+	// to the Fiat-Shamir hash of the signature, given the s-value of the revocation handle
+	recomputeFSContribution(proof *NonRevocationProof, chal *FP256BN.BIG, epochPK *FP256BN.ECP2, proofSRh *FP256BN.BIG) ([]byte, error)
+}
+
+// getNonRevocationProver returns the prover of the given revocation algorithm
+func getNonRevocationProver(alg RevocationAlgorithm) (nonRevokedProver, error) {
+	switch alg {
+	case ALG_NO_REVOCATION:
+		return &nopNonRevokedProver{}, nil
+	case ALG_WBB_SIGNATURE:
+		return &wbbNonRevokedProver{}, nil
+	default:
+		return nil, errors.Errorf("unknown revocation algorithm %d", alg)
+	}
+}
+
+// getNonRevocationVerifier returns the verifier of the given revocation algorithm
+func getNonRevocationVerifier(alg RevocationAlgorithm) (nonRevocationVerifier, error) {
+	switch alg {
+	case ALG_NO_REVOCATION:
+		return &nopNonRevocationVerifier{}, nil
+	case ALG_WBB_SIGNATURE:
+		return &wbbNonRevocationVerifier{}, nil
+	default:
+		return nil, errors.Errorf("unknown revocation algorithm %d", alg)
+	}
+}
+
+// nopNonRevokedProver is the prover of ALG_NO_REVOCATION, which proves nothing
+type nopNonRevokedProver struct{}
+
+func (p *nopNonRevokedProver) getFSContribution(rh *FP256BN.BIG, rRh *FP256BN.BIG, cri *CredentialRevocationInformation, rng *amcl.RAND) ([]byte, error) {
+	return nil, nil
+}
+
+func (p *nopNonRevokedProver) getNonRevokedProof(chal *FP256BN.BIG) (*NonRevocationProof, error) {
+	return &NonRevocationProof{RevocationAlg: int32(ALG_NO_REVOCATION)}, nil
+}
+
+// nopNonRevocationVerifier is the verifier of ALG_NO_REVOCATION, which checks nothing
+type nopNonRevocationVerifier struct{}
+
+func (v *nopNonRevocationVerifier) recomputeFSContribution(proof *NonRevocationProof, chal *FP256BN.BIG, epochPK *FP256BN.ECP2, proofSRh *FP256BN.BIG) ([]byte, error) {
+	return nil, nil
+}
+
+// wbbNonRevokedProver is the prover of ALG_WBB_SIGNATURE. Given the signature
+// sigma = g1^{1/(x+rh)} on the handle rh under the epoch key W = g2^x, the prover
+// randomizes it as sigmaPrime = sigma^r and sigmaBar = sigmaPrime^{-rh} g1^r,
+// such that e(sigmaPrime, W) = e(sigmaBar, g2), and proves knowledge of rh and r
+type wbbNonRevokedProver struct {
+	r          *FP256BN.BIG
+	rR         *FP256BN.BIG
+	sigmaPrime *FP256BN.ECP
+	sigmaBar   *FP256BN.ECP
+}
+
+func (p *wbbNonRevokedProver) getFSContribution(rh *FP256BN.BIG, rRh *FP256BN.BIG, cri *CredentialRevocationInformation, rng *amcl.RAND) ([]byte, error) {
+	data := &WBBRevocationData{}
+	err := proto.Unmarshal(cri.GetRevocationData(), data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal revocation data")
+	}
+
+	rhBytes := BigToBytes(rh)
+	var sigma *FP256BN.ECP
+	for _, s := range data.Signatures {
+		if bytes.Equal(s.GetRH(), rhBytes) && s.GetSigma() != nil {
+			sigma = EcpFromProto(s.GetSigma())
+			break
+		}
+	}
+	if sigma == nil {
+		return nil, errors.Errorf("the credential is revoked in epoch %d", cri.Epoch)
+	}
+
+	p.r = RandModOrder(rng)
+	p.sigmaPrime = FP256BN.G1mul(sigma, p.r)
+	p.sigmaBar = FP256BN.G1mul(GenG1, p.r)
+	p.sigmaBar.Sub(FP256BN.G1mul(p.sigmaPrime, rh))
+
+	p.rR = RandModOrder(rng)
+	t := FP256BN.G1mul(GenG1, p.rR)
+	t.Sub(FP256BN.G1mul(p.sigmaPrime, rRh))
+
+	return wbbFSContribution(p.sigmaPrime, p.sigmaBar, t), nil
+}
+
+func (p *wbbNonRevokedProver) getNonRevokedProof(chal *FP256BN.BIG) (*NonRevocationProof, error) {
+	proofBytes, err := proto.Marshal(&WBBNonRevocationProof{
+		SigmaPrime: EcpToProto(p.sigmaPrime),
+		SigmaBar:   EcpToProto(p.sigmaBar),
+		ProofSR:    BigToBytes(Modadd(p.rR, FP256BN.Modmul(chal, p.r, GroupOrder), GroupOrder)),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal non-revocation proof")
+	}
+	return &NonRevocationProof{RevocationAlg: int32(ALG_WBB_SIGNATURE), NonRevocationProof: proofBytes}, nil
+}
+
+// wbbNonRevocationVerifier is the verifier of ALG_WBB_SIGNATURE
+type wbbNonRevocationVerifier struct{}
+
+func (v *wbbNonRevocationVerifier) recomputeFSContribution(proof *NonRevocationProof, chal *FP256BN.BIG, epochPK *FP256BN.ECP2, proofSRh *FP256BN.BIG) ([]byte, error) {
+	wbbProof := &WBBNonRevocationProof{}
+	err := proto.Unmarshal(proof.GetNonRevocationProof(), wbbProof)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal non-revocation proof")
+	}
+	if wbbProof.SigmaPrime == nil || wbbProof.SigmaBar == nil {
+		return nil, errors.Errorf("non-revocation proof is incomplete")
+	}
+
+	sigmaPrime := EcpFromProto(wbbProof.SigmaPrime)
+	sigmaBar := EcpFromProto(wbbProof.SigmaBar)
+	proofSR := FP256BN.FromBytes(wbbProof.GetProofSR())
+
+	if sigmaPrime.Is_infinity() {
+		return nil, errors.Errorf("non-revocation proof invalid: SigmaPrime = 1")
+	}
+	temp1 := FP256BN.Ate(epochPK, sigmaPrime)
+	temp2 := FP256BN.Ate(GenG2, sigmaBar)
+	temp2.Inverse()
+	temp1.Mul(temp2)
+	if !FP256BN.Fexp(temp1).Isunity() {
+		return nil, errors.Errorf("non-revocation proof invalid: SigmaPrime and SigmaBar don't have the expected structure")
+	}
+
+	t := FP256BN.G1mul(GenG1, proofSR)
+	t.Sub(FP256BN.G1mul(sigmaPrime, proofSRh))
+	t.Sub(FP256BN.G1mul(sigmaBar, chal))
+
+	return wbbFSContribution(sigmaPrime, sigmaBar, t), nil
+}
+
+// wbbFSContribution serializes the elements of a weak Boneh-Boyen non-revocation proof
+// that are hashed into the Fiat-Shamir challenge
+func wbbFSContribution(sigmaPrime, sigmaBar, t *FP256BN.ECP) []byte {
+	data := make([]byte, 3*(2*FieldBytes+1))
+	index := 0
+	index = appendBytesG1(data, index, sigmaPrime)
+	index = appendBytesG1(data, index, sigmaBar)
+	appendBytesG1(data, index, t)
+	return data
+}
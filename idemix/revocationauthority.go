@@ -0,0 +1,164 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package idemix
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/milagro-crypto/amcl/version3/go/amcl/FP256BN"
+	"github.com/pkg/errors"
+)
+
+// The revocation authority (RA) holds a long term ECDSA key pair.
+// Time is divided in epochs: for every epoch, the RA derives an epoch key pair
+// from its long term key and publishes a credential revocation information (CRI).
+// A CRI contains the epoch public key, signed with the long term key, and
+// the data the users need to prove that their credential is not revoked.
+// Verifiers only accept signatures created in the current epoch, so a credential
+// is revoked by leaving its revocation handle out of the CRIs of the next epochs
+
+// RevocationAlgorithm identifies the revocation algorithm of a CRI
+type RevocationAlgorithm int32
+
+const (
+	// ALG_NO_REVOCATION does not revoke credentials: the non-revocation proof is empty
+	// and only the epoch of a signature is checked
+	ALG_NO_REVOCATION RevocationAlgorithm = iota
+	// ALG_WBB_SIGNATURE has the RA issue, in every epoch, a weak Boneh-Boyen signature
+	// on each revocation handle that is not revoked. Users prove in zero-knowledge that
+	// they hold such a signature on the (hidden) revocation handle of their credential
+	ALG_WBB_SIGNATURE
+)
+
+// epochKeyLabel is the label used to derive the epoch secret keys from the long term key
+const epochKeyLabel = "epoch"
+
+// ecdsaSignature is the ASN.1 encoding of an ECDSA signature
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// GenerateLongTermRevocationKey generates a long term signing key that will be used for revocation
+func GenerateLongTermRevocationKey() (*ecdsa.PrivateKey, error) {
+	return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+}
+
+// CreateCRI creates the credential revocation information of an epoch, in which
+// only the credentials with one of the unrevokedHandles are not revoked.
+// All the CRIs of an epoch share the same epoch key, which is derived from the long term key
+func CreateCRI(key *ecdsa.PrivateKey, unrevokedHandles []*FP256BN.BIG, epoch int, alg RevocationAlgorithm) (*CredentialRevocationInformation, error) {
+	if key == nil {
+		return nil, errors.Errorf("CreateCRI received nil input")
+	}
+
+	epochSk := epochSecretKey(key, epoch)
+	cri := &CredentialRevocationInformation{
+		Epoch:         int64(epoch),
+		EpochPk:       Ecp2ToProto(GenG2.Mul(epochSk)),
+		RevocationAlg: int32(alg),
+	}
+
+	switch alg {
+	case ALG_NO_REVOCATION:
+		// no revocation data
+	case ALG_WBB_SIGNATURE:
+		data := &WBBRevocationData{}
+		for _, rh := range unrevokedHandles {
+			sigma, err := wbbSign(epochSk, rh)
+			if err != nil {
+				return nil, err
+			}
+			data.Signatures = append(data.Signatures, &WBBHandleSignature{RH: BigToBytes(rh), Sigma: EcpToProto(sigma)})
+		}
+		revocationData, err := proto.Marshal(data)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal revocation data")
+		}
+		cri.RevocationData = revocationData
+	default:
+		return nil, errors.Errorf("the specified revocation algorithm is not supported")
+	}
+
+	digest, err := epochPkDigest(cri.EpochPk, epoch, alg)
+	if err != nil {
+		return nil, err
+	}
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign the epoch public key")
+	}
+	cri.EpochPkSig, err = asn1.Marshal(ecdsaSignature{r, s})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the epoch public key signature")
+	}
+
+	return cri, nil
+}
+
+// VerifyEpochPK verifies that the revocation PK for a certain epoch is valid,
+// by checking that it was signed with the long term revocation key
+func VerifyEpochPK(pk *ecdsa.PublicKey, epochPK *ECP2, epochPkSig []byte, epoch int, alg RevocationAlgorithm) error {
+	if pk == nil || epochPK == nil {
+		return errors.Errorf("EpochPK invalid: received nil input")
+	}
+	digest, err := epochPkDigest(epochPK, epoch, alg)
+	if err != nil {
+		return err
+	}
+	sig := &ecdsaSignature{}
+	rest, err := asn1.Unmarshal(epochPkSig, sig)
+	if err != nil || len(rest) != 0 || sig.R == nil || sig.S == nil {
+		return errors.Errorf("EpochPKSig invalid: malformed signature")
+	}
+	if !ecdsa.Verify(pk, digest, sig.R, sig.S) {
+		return errors.Errorf("EpochPKSig invalid")
+	}
+	return nil
+}
+
+// epochPkDigest returns the digest signed by the revocation authority for an epoch,
+// which binds the epoch public key to the epoch and the revocation algorithm
+func epochPkDigest(epochPK *ECP2, epoch int, alg RevocationAlgorithm) ([]byte, error) {
+	bytes, err := proto.Marshal(&CredentialRevocationInformation{
+		Epoch:         int64(epoch),
+		EpochPk:       epochPK,
+		RevocationAlg: int32(alg),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the epoch public key")
+	}
+	digest := sha256.Sum256(bytes)
+	return digest[:], nil
+}
+
+// epochSecretKey derives the secret key of an epoch from the long term revocation key
+func epochSecretKey(key *ecdsa.PrivateKey, epoch int) *FP256BN.BIG {
+	epochBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(epochBytes, uint64(epoch))
+
+	data := []byte(epochKeyLabel)
+	data = append(data, epochBytes...)
+	data = append(data, key.D.Bytes()...)
+	return HashModOrder(data)
+}
+
+// wbbSign computes the weak Boneh-Boyen signature g1^{1/(sk+rh)} on the revocation handle rh
+func wbbSign(sk *FP256BN.BIG, rh *FP256BN.BIG) (*FP256BN.ECP, error) {
+	exp := Modadd(sk, rh, GroupOrder)
+	if *exp == *FP256BN.NewBIGint(0) {
+		return nil, errors.Errorf("cannot sign revocation handle")
+	}
+	exp.Invmodp(GroupOrder)
+	return GenG1.Mul(exp), nil
+}
@@ -7,6 +7,9 @@ SPDX-License-Identifier: Apache-2.0
 package idemix
 
 import (
+	"crypto/ecdsa"
+	"sort"
+
 	"github.com/milagro-crypto/amcl/version3/go/amcl"
 	"github.com/milagro-crypto/amcl/version3/go/amcl/FP256BN"
 	"github.com/pkg/errors"
@@ -27,6 +30,9 @@ const signLabel = "sign"
 // the advanced privacy features provided by Identity Mixer (due to zero-knowledge proofs):
 //  - Unlinkability of the signatures produced with the same credential
 //  - Selective attribute disclosure and predicates over attributes
+// A signature also proves that the credential is not revoked in the epoch of the signature,
+// using the credential revocation information (CRI) published by the revocation authority
+// for the epoch and the revocation handle, which is a hidden attribute of the credential
 
 // Make a slice of all the attribute indices that will not be disclosed
 func hiddenIndices(Disclosure []byte) []int {
@@ -39,14 +45,38 @@ func hiddenIndices(Disclosure []byte) []int {
 	return HiddenIndices
 }
 
+// rhHiddenIndex returns the position of the revocation handle attribute among the hidden attributes
+func rhHiddenIndex(Disclosure []byte, rhIndex int) (int, error) {
+	if rhIndex < 0 || rhIndex >= len(Disclosure) {
+		return 0, errors.Errorf("invalid revocation handle index %d", rhIndex)
+	}
+	if Disclosure[rhIndex] != 0 {
+		return 0, errors.Errorf("attribute %d is disclosed but also used as revocation handle attribute, which should remain hidden", rhIndex)
+	}
+	return sort.SearchInts(hiddenIndices(Disclosure), rhIndex), nil
+}
+
 // NewSignature creates a new idemix signature (Schnorr-type signature)
 // The []byte Disclosure steers which attributes are disclosed:
 // if Disclosure[i] == 0 then attribute i remains hidden and otherwise it is disclosed.
+// The attribute rhIndex is the revocation handle of the credential: it must remain hidden,
+// and the signature proves that it is not revoked according to the CRI cri.
 // We use the zero-knowledge proof by http://eprint.iacr.org/2016/663.pdf to prove knowledge of a BBS+ signature
-func NewSignature(cred *Credential, sk *FP256BN.BIG, Nym *FP256BN.ECP, RNym *FP256BN.BIG, ipk *IssuerPublicKey, Disclosure []byte, msg []byte, rng *amcl.RAND) (*Signature, error) {
-	if cred == nil || sk == nil || Nym == nil || RNym == nil || ipk == nil || rng == nil {
+func NewSignature(cred *Credential, sk *FP256BN.BIG, Nym *FP256BN.ECP, RNym *FP256BN.BIG, ipk *IssuerPublicKey, Disclosure []byte, msg []byte, rhIndex int, cri *CredentialRevocationInformation, rng *amcl.RAND) (*Signature, error) {
+	if cred == nil || sk == nil || Nym == nil || RNym == nil || ipk == nil || cri == nil || rng == nil {
 		return nil, errors.Errorf("cannot create idemix signature: received nil input")
 	}
+	if len(Disclosure) != len(cred.Attrs) {
+		return nil, errors.Errorf("cannot create idemix signature: disclosure does not match the attributes of the credential")
+	}
+	rhPosition, err := rhHiddenIndex(Disclosure, rhIndex)
+	if err != nil {
+		return nil, errors.WithMessage(err, "cannot create idemix signature")
+	}
+	prover, err := getNonRevocationProver(RevocationAlgorithm(cri.RevocationAlg))
+	if err != nil {
+		return nil, errors.WithMessage(err, "cannot create idemix signature")
+	}
 
 	HiddenIndices := hiddenIndices(Disclosure)
 
@@ -100,13 +130,20 @@ func NewSignature(cred *Credential, sk *FP256BN.BIG, Nym *FP256BN.ECP, RNym *FP2
 
 	t3 := HSk.Mul2(rSk, HRand, rRNym)
 
+	// the non-revocation proof shares the randomness of the revocation handle
+	nonRevokedProofHashData, err := prover.getFSContribution(FP256BN.FromBytes(cred.Attrs[rhIndex]), rAttrs[rhPosition], cri, rng)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to compute non-revoked proof")
+	}
+
 	// proofData is the data being hashed, it consists of:
 	// the signature label
 	// 7 elements of G1 each taking 2*FieldBytes+1 bytes
+	// the contribution of the non-revocation proof
 	// one bigint (hash of the issuer public key) of length FieldBytes
 	// disclosed attributes
 	// message being signed
-	proofData := make([]byte, len([]byte(signLabel))+7*(2*FieldBytes+1)+FieldBytes+len(Disclosure)+len(msg))
+	proofData := make([]byte, len([]byte(signLabel))+7*(2*FieldBytes+1)+len(nonRevokedProofHashData)+FieldBytes+len(Disclosure)+len(msg))
 	index := 0
 	index = appendBytesString(proofData, index, signLabel)
 	index = appendBytesG1(proofData, index, t1)
@@ -116,6 +153,8 @@ func NewSignature(cred *Credential, sk *FP256BN.BIG, Nym *FP256BN.ECP, RNym *FP2
 	index = appendBytesG1(proofData, index, ABar)
 	index = appendBytesG1(proofData, index, BPrime)
 	index = appendBytesG1(proofData, index, Nym)
+	copy(proofData[index:], nonRevokedProofHashData)
+	index = index + len(nonRevokedProofHashData)
 	copy(proofData[index:], ipk.Hash)
 	index = index + FieldBytes
 	copy(proofData[index:], Disclosure)
@@ -141,27 +180,58 @@ func NewSignature(cred *Credential, sk *FP256BN.BIG, Nym *FP256BN.ECP, RNym *FP2
 		ProofSAttrs[i] = BigToBytes(Modadd(rAttrs[i], FP256BN.Modmul(ProofC, FP256BN.FromBytes(cred.Attrs[j]), GroupOrder), GroupOrder))
 	}
 
+	nonRevokedProof, err := prover.getNonRevokedProof(ProofC)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to compute non-revoked proof")
+	}
+
 	return &Signature{
-			EcpToProto(APrime),
-			EcpToProto(ABar),
-			EcpToProto(BPrime),
-			BigToBytes(ProofC),
-			BigToBytes(ProofSSk),
-			BigToBytes(ProofSE),
-			BigToBytes(ProofSR2),
-			BigToBytes(ProofSR3),
-			BigToBytes(ProofSSPrime),
-			ProofSAttrs,
-			BigToBytes(Nonce),
-			EcpToProto(Nym),
-			BigToBytes(ProofSRNym)},
+			APrime:             EcpToProto(APrime),
+			ABar:               EcpToProto(ABar),
+			BPrime:             EcpToProto(BPrime),
+			ProofC:             BigToBytes(ProofC),
+			ProofSSk:           BigToBytes(ProofSSk),
+			ProofSE:            BigToBytes(ProofSE),
+			ProofSR2:           BigToBytes(ProofSR2),
+			ProofSR3:           BigToBytes(ProofSR3),
+			ProofSSPrime:       BigToBytes(ProofSSPrime),
+			ProofSAttrs:        ProofSAttrs,
+			Nonce:              BigToBytes(Nonce),
+			Nym:                EcpToProto(Nym),
+			ProofSRNym:         BigToBytes(ProofSRNym),
+			RevocationEpochPk:  cri.EpochPk,
+			RevocationPkSig:    cri.EpochPkSig,
+			Epoch:              cri.Epoch,
+			NonRevocationProof: nonRevokedProof},
 		nil
 }
 
 // Ver verifies an idemix signature
 // Disclosure steers which attributes it expects to be disclosed
 // attributeValues[i] contains the desired attribute value for the i-th undisclosed attribute in Disclosure
-func (sig *Signature) Ver(Disclosure []byte, ipk *IssuerPublicKey, msg []byte, attributeValues []*FP256BN.BIG) error {
+// rhIndex is the index of the (hidden) revocation handle attribute, revPk is the long term key
+// of the revocation authority and epoch is the current epoch, which the signature must have been created in
+func (sig *Signature) Ver(Disclosure []byte, ipk *IssuerPublicKey, msg []byte, attributeValues []*FP256BN.BIG, rhIndex int, revPk *ecdsa.PublicKey, epoch int) error {
+	rhPosition, err := rhHiddenIndex(Disclosure, rhIndex)
+	if err != nil {
+		return errors.WithMessage(err, "signature invalid")
+	}
+	if sig.GetNonRevocationProof() == nil || sig.GetRevocationEpochPk() == nil {
+		return errors.Errorf("signature invalid: non-revocation proof is missing")
+	}
+	if sig.GetEpoch() != int64(epoch) {
+		return errors.Errorf("signature invalid: the signature is for epoch %d, but the current epoch is %d", sig.GetEpoch(), epoch)
+	}
+	revocationAlg := RevocationAlgorithm(sig.GetNonRevocationProof().GetRevocationAlg())
+	err = VerifyEpochPK(revPk, sig.GetRevocationEpochPk(), sig.GetRevocationPkSig(), epoch, revocationAlg)
+	if err != nil {
+		return errors.WithMessage(err, "signature invalid")
+	}
+	verifier, err := getNonRevocationVerifier(revocationAlg)
+	if err != nil {
+		return errors.WithMessage(err, "signature invalid")
+	}
+
 	HiddenIndices := hiddenIndices(Disclosure)
 
 	APrime := EcpFromProto(sig.GetAPrime())
@@ -230,13 +300,19 @@ func (sig *Signature) Ver(Disclosure []byte, ipk *IssuerPublicKey, msg []byte, a
 	t3 := HSk.Mul2(ProofSSk, HRand, ProofSRNym)
 	t3.Sub(Nym.Mul(ProofC))
 
+	nonRevokedProofHashData, err := verifier.recomputeFSContribution(sig.NonRevocationProof, ProofC, Ecp2FromProto(sig.RevocationEpochPk), ProofSAttrs[rhPosition])
+	if err != nil {
+		return errors.WithMessage(err, "signature invalid")
+	}
+
 	// proofData is the data being hashed, it consists of:
 	// the signature label
 	// 7 elements of G1 each taking 2*FieldBytes+1 bytes
+	// the contribution of the non-revocation proof
 	// one bigint (hash of the issuer public key) of length FieldBytes
 	// disclosed attributes
 	// message that was signed
-	proofData := make([]byte, len([]byte(signLabel))+7*(2*FieldBytes+1)+FieldBytes+len(Disclosure)+len(msg))
+	proofData := make([]byte, len([]byte(signLabel))+7*(2*FieldBytes+1)+len(nonRevokedProofHashData)+FieldBytes+len(Disclosure)+len(msg))
 	index := 0
 	index = appendBytesString(proofData, index, signLabel)
 	index = appendBytesG1(proofData, index, t1)
@@ -246,6 +322,8 @@ func (sig *Signature) Ver(Disclosure []byte, ipk *IssuerPublicKey, msg []byte, a
 	index = appendBytesG1(proofData, index, ABar)
 	index = appendBytesG1(proofData, index, BPrime)
 	index = appendBytesG1(proofData, index, Nym)
+	copy(proofData[index:], nonRevokedProofHashData)
+	index = index + len(nonRevokedProofHashData)
 	copy(proofData[index:], ipk.Hash)
 	index = index + FieldBytes
 	copy(proofData[index:], Disclosure)
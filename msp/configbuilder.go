@@ -16,6 +16,7 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/bccsp"
 	"github.com/hyperledger/fabric/bccsp/factory"
+	"github.com/hyperledger/fabric/idemix"
 	"github.com/hyperledger/fabric/protos/msp"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
@@ -367,10 +368,11 @@ func getMspConfig(dir string, ID string, sigid *msp.SigningIdentityInfo) (*msp.M
 }
 
 const (
-	IdemixConfigDirMsp              = "msp"
-	IdemixConfigDirUser             = "user"
-	IdemixConfigFileIssuerPublicKey = "IssuerPublicKey"
-	IdemixConfigFileSigner          = "SignerConfig"
+	IdemixConfigDirMsp                  = "msp"
+	IdemixConfigDirUser                 = "user"
+	IdemixConfigFileIssuerPublicKey     = "IssuerPublicKey"
+	IdemixConfigFileRevocationPublicKey = "RevocationPublicKey"
+	IdemixConfigFileSigner              = "SignerConfig"
 )
 
 // GetIdemixMspConfig returns the configuration for the Idemix MSP
@@ -380,9 +382,15 @@ func GetIdemixMspConfig(dir string, ID string) (*msp.MSPConfig, error) {
 		return nil, errors.Wrapf(err, "failed to read issuer public key file")
 	}
 
+	revocationPkBytes, err := readFile(filepath.Join(dir, IdemixConfigDirMsp, IdemixConfigFileRevocationPublicKey))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read revocation public key file")
+	}
+
 	idemixConfig := &msp.IdemixMSPConfig{
-		Name: ID,
-		IPk:  ipkBytes,
+		Name:         ID,
+		IPk:          ipkBytes,
+		RevocationPk: revocationPkBytes,
 	}
 
 	signerBytes, err := readFile(filepath.Join(dir, IdemixConfigDirUser, IdemixConfigFileSigner))
@@ -393,6 +401,15 @@ func GetIdemixMspConfig(dir string, ID string) (*msp.MSPConfig, error) {
 			return nil, err
 		}
 		idemixConfig.Signer = signerConfig
+
+		// A local MSP is in the epoch of the revocation information of its signer,
+		// the epoch of a channel MSP is set and advanced through the channel configuration
+		cri := &idemix.CredentialRevocationInformation{}
+		err = proto.Unmarshal(signerConfig.CredentialRevocationInformation, cri)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal credential revocation information")
+		}
+		idemixConfig.Epoch = cri.Epoch
 	}
 
 	confBytes, err := proto.Marshal(idemixConfig)
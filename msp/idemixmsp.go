@@ -8,7 +8,10 @@ package msp
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/x509"
 	"encoding/hex"
+	"encoding/pem"
 	"time"
 
 	"github.com/golang/protobuf/proto"
@@ -26,17 +29,26 @@ const (
 
 	// AttributeNameRole is the attribute name of the Role attribute
 	AttributeNameRole = "Role"
+
+	// AttributeNameRevocationHandle is the attribute name of the revocation handle attribute
+	AttributeNameRevocationHandle = "RevocationHandle"
 )
 
+// rhIndex is the index of the revocation handle attribute in the credentials
+const rhIndex = 2
+
 // discloseFlags will be passed to the idemix signing and verification routines.
-// It informs idemix to disclose both attributes (OU and Role) when signing.
-var discloseFlags = []byte{1, 1}
+// It informs idemix to disclose the OU and Role attributes when signing,
+// while the revocation handle remains hidden.
+var discloseFlags = []byte{1, 1, 0}
 
 type idemixmsp struct {
-	ipk    *idemix.IssuerPublicKey
-	rng    *amcl.RAND
-	signer *idemixSigningIdentity
-	name   string
+	ipk          *idemix.IssuerPublicKey
+	rng          *amcl.RAND
+	signer       *idemixSigningIdentity
+	name         string
+	revocationPK *ecdsa.PublicKey
+	epoch        int
 }
 
 // newIdemixMsp creates a new instance of idemixmsp
@@ -77,8 +89,11 @@ func (msp *idemixmsp) Setup(conf1 *m.MSPConfig) error {
 		return errors.WithMessage(err, "setting the hash of the issuer public key failed")
 	}
 
-	if len(ipk.AttributeNames) < 2 || ipk.AttributeNames[0] != AttributeNameOU || ipk.AttributeNames[1] != AttributeNameRole {
-		return errors.Errorf("ipk must have have attributes OU and Role")
+	if len(ipk.AttributeNames) < 3 ||
+		ipk.AttributeNames[0] != AttributeNameOU ||
+		ipk.AttributeNames[1] != AttributeNameRole ||
+		ipk.AttributeNames[rhIndex] != AttributeNameRevocationHandle {
+		return errors.Errorf("ipk must have have attributes OU, Role and RevocationHandle")
 	}
 
 	err = ipk.Check()
@@ -87,6 +102,12 @@ func (msp *idemixmsp) Setup(conf1 *m.MSPConfig) error {
 	}
 	msp.ipk = ipk
 
+	msp.revocationPK, err = parseRevocationPublicKey(conf.RevocationPk)
+	if err != nil {
+		return errors.WithMessage(err, "cannot setup idemix msp with invalid revocation public key")
+	}
+	msp.epoch = int(conf.Epoch)
+
 	rng, err := idemix.GetRand()
 	if err != nil {
 		return errors.Wrap(err, "error initializing PRNG for idemix msp")
@@ -124,9 +145,9 @@ func (msp *idemixmsp) Setup(conf1 *m.MSPConfig) error {
 		CertifiersIdentifier:         ipk.Hash,
 	}
 
-	// Check if credential contains the right amount of attribute values (Role and OU)
-	if len(cred.Attrs) != 2 {
-		return errors.Errorf("Credential contains %d attribute values, but expected 2", len(cred.Attrs))
+	// Check if credential contains the right amount of attribute values (OU, Role and RevocationHandle)
+	if len(cred.Attrs) != len(ipk.AttributeNames) {
+		return errors.Errorf("Credential contains %d attribute values, but expected %d", len(cred.Attrs), len(ipk.AttributeNames))
 	}
 
 	// Check if credential contains the correct OU attribute value
@@ -146,8 +167,18 @@ func (msp *idemixmsp) Setup(conf1 *m.MSPConfig) error {
 		return errors.Wrap(err, "Credential is not cryptographically valid")
 	}
 
+	// The credential revocation information allows to prove that the credential is not revoked
+	cri := new(idemix.CredentialRevocationInformation)
+	err = proto.Unmarshal(conf.Signer.CredentialRevocationInformation, cri)
+	if err != nil {
+		return errors.Wrap(err, "Failed to unmarshal credential revocation information from config")
+	}
+	if cri.Epoch != conf.Epoch {
+		return errors.Errorf("Credential revocation information is for epoch %d, but the current epoch is %d", cri.Epoch, conf.Epoch)
+	}
+
 	// Create the cryptographic evidence that this identity is valid
-	proof, err := idemix.NewSignature(cred, sk, Nym, RandNym, ipk, discloseFlags, nil, rng)
+	proof, err := idemix.NewSignature(cred, sk, Nym, RandNym, ipk, discloseFlags, nil, rhIndex, cri, rng)
 	if err != nil {
 		return errors.Wrap(err, "Failed to setup cryptographic proof of identity")
 	}
@@ -158,6 +189,23 @@ func (msp *idemixmsp) Setup(conf1 *m.MSPConfig) error {
 	return nil
 }
 
+// parseRevocationPublicKey parses the PEM encoded long term public key of the revocation authority
+func parseRevocationPublicKey(pemBytes []byte) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.Errorf("failed to decode revocation public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse revocation public key")
+	}
+	pk, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.Errorf("revocation public key is of type %T, but expected an ECDSA key", key)
+	}
+	return pk, nil
+}
+
 // GetVersion returns the version of this MSP
 func (msp *idemixmsp) GetVersion() MSPVersion {
 	return MSPv1_1
@@ -251,9 +299,10 @@ func (msp *idemixmsp) Validate(id Identity) error {
 
 func (id *idemixidentity) verifyProof() error {
 	ouBytes := []byte(id.OU.OrganizationalUnitIdentifier)
-	attributeValues := []*FP256BN.BIG{idemix.HashModOrder(ouBytes), FP256BN.NewBIGint(int(id.Role.Role))}
+	// the revocation handle is not disclosed
+	attributeValues := []*FP256BN.BIG{idemix.HashModOrder(ouBytes), FP256BN.NewBIGint(int(id.Role.Role)), nil}
 
-	return id.associationProof.Ver(discloseFlags, id.msp.ipk, nil, attributeValues)
+	return id.associationProof.Ver(discloseFlags, id.msp.ipk, nil, attributeValues, rhIndex, id.msp.revocationPK, id.msp.epoch)
 }
 
 func (msp *idemixmsp) SatisfiesPrincipal(id Identity, principal *m.MSPPrincipal) error {
@@ -388,8 +437,9 @@ func newIdemixIdentity(msp *idemixmsp, nym *FP256BN.ECP, role *m.MSPRole, ou *m.
 }
 
 func (id *idemixidentity) ExpiresAt() time.Time {
-	// Idemix MSP currently does not use expiration dates or revocation,
+	// Idemix MSP currently does not use expiration dates,
 	// so we return the zero time to indicate this.
+	// Revocation is enforced through the epoch of the identity proof.
 	return time.Time{}
 }
 
@@ -114,6 +114,64 @@ func TestSetupBad(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestSetupBadRevocation(t *testing.T) {
+	msp1, err := newIdemixMsp()
+	assert.NoError(t, err)
+
+	conf, err := GetIdemixMspConfig("testdata/idemix/MSP1OU1", "MSP1OU1")
+	assert.NoError(t, err)
+	idemixconfig := &msp.IdemixMSPConfig{}
+	err = proto.Unmarshal(conf.Config, idemixconfig)
+	assert.NoError(t, err)
+
+	// Create MSP config with an epoch that differs from the one of the signer
+	idemixconfig.Epoch = 1
+	conf.Config, err = proto.Marshal(idemixconfig)
+	assert.NoError(t, err)
+	err = msp1.Setup(conf)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Credential revocation information is for epoch 0, but the current epoch is 1")
+
+	// Create MSP config with bad revocation public key bytes
+	idemixconfig.Epoch = 0
+	idemixconfig.RevocationPk = []byte("barf")
+	conf.Config, err = proto.Marshal(idemixconfig)
+	assert.NoError(t, err)
+	err = msp1.Setup(conf)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid revocation public key")
+}
+
+func TestIdentityRevocation(t *testing.T) {
+	msp1, err := setup("testdata/idemix/MSP1OU1", "MSP1OU1")
+	assert.NoError(t, err)
+	id, err := getDefaultSigner(msp1)
+	assert.NoError(t, err)
+	idBytes, err := id.Serialize()
+	assert.NoError(t, err)
+
+	// A verifier that moved to a later epoch rejects the identities
+	// that were created in the previous epochs
+	verifier, err := newIdemixMsp()
+	assert.NoError(t, err)
+	conf, err := GetIdemixMspConfig("testdata/idemix/MSP1Verifier", "MSP1OU1")
+	assert.NoError(t, err)
+	idemixconfig := &msp.IdemixMSPConfig{}
+	err = proto.Unmarshal(conf.Config, idemixconfig)
+	assert.NoError(t, err)
+	idemixconfig.Epoch = 1
+	conf.Config, err = proto.Marshal(idemixconfig)
+	assert.NoError(t, err)
+	err = verifier.Setup(conf)
+	assert.NoError(t, err)
+
+	verID, err := verifier.DeserializeIdentity(idBytes)
+	assert.NoError(t, err)
+	err = verifier.Validate(verID)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "the signature is for epoch 0, but the current epoch is 1")
+}
+
 func TestSigning(t *testing.T) {
 	msp, err := setup("testdata/idemix/MSP1OU1", "MSP1")
 	assert.NoError(t, err)
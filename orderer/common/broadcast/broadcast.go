@@ -8,8 +8,10 @@ package broadcast
 
 import (
 	"io"
+	"time"
 
 	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/metrics"
 	"github.com/hyperledger/fabric/common/util"
 	"github.com/hyperledger/fabric/orderer/common/msgprocessor"
 	cb "github.com/hyperledger/fabric/protos/common"
@@ -44,6 +46,14 @@ type ChannelSupportRegistrar interface {
 type ChannelSupport interface {
 	msgprocessor.Processor
 	Consenter
+
+	// ChainID returns the ID of the channel which processes the messages, which is the
+	// system channel for the requests to create a channel
+	ChainID() string
+
+	// RateLimiter returns the filter applying the rate limits of the channel to the
+	// messages which passed its other filters
+	RateLimiter() msgprocessor.Rule
 }
 
 // Consenter provides methods to send messages through consensus
@@ -65,13 +75,20 @@ type Consenter interface {
 }
 
 type handlerImpl struct {
-	sm ChannelSupportRegistrar
+	sm        ChannelSupportRegistrar
+	txIDIndex *msgprocessor.TxIDIndex
+	metrics   metrics.Scope
 }
 
-// NewHandlerImpl constructs a new implementation of the Handler interface
-func NewHandlerImpl(sm ChannelSupportRegistrar) Handler {
+// NewHandlerImpl constructs a new implementation of the Handler interface.
+// The messages which pass the filters of their channel are admitted by the
+// rate limiter of the channel, and checked for replays against the given
+// TxIDIndex unless it is nil, before being ordered.
+func NewHandlerImpl(sm ChannelSupportRegistrar, txIDIndex *msgprocessor.TxIDIndex) Handler {
 	return &handlerImpl{
-		sm: sm,
+		sm:        sm,
+		txIDIndex: txIDIndex,
+		metrics:   metrics.RootScope.SubScope("broadcast"),
 	}
 }
 
@@ -90,54 +107,129 @@ func (bh *handlerImpl) Handle(srv ab.AtomicBroadcast_BroadcastServer) error {
 			return err
 		}
 
-		chdr, isConfig, processor, err := bh.sm.BroadcastChannelSupport(msg)
+		resp := bh.processMessage(msg, addr)
+		if resp.Status != cb.Status_SUCCESS {
+			return srv.Send(resp)
+		}
+
+		err = srv.Send(resp)
 		if err != nil {
-			logger.Warningf("[channel: %s] Could not get message processor for serving %s: %s", chdr.ChannelId, addr, err)
-			return srv.Send(&ab.BroadcastResponse{Status: cb.Status_INTERNAL_SERVER_ERROR, Info: err.Error()})
+			logger.Warningf("Error sending to %s: %s", addr, err)
+			return err
 		}
+	}
+}
 
-		if err = processor.WaitReady(); err != nil {
-			logger.Warningf("[channel: %s] Rejecting broadcast of message from %s with SERVICE_UNAVAILABLE: rejected by Consenter: %s", chdr.ChannelId, addr, err)
-			return srv.Send(&ab.BroadcastResponse{Status: cb.Status_SERVICE_UNAVAILABLE, Info: err.Error()})
+// unknownTag is the value of the metrics tags which would otherwise be taken
+// from the unauthenticated header of a message that could not be resolved
+const unknownTag = "unknown"
+
+// processMessage validates and enqueues a single message, and records the
+// outcome and the latency of the request in the broadcast metrics. The
+// requests are tagged with the channel which processes them, so that the
+// clients cannot create metrics for arbitrary channel names or types
+func (bh *handlerImpl) processMessage(msg *cb.Envelope, addr string) (resp *ab.BroadcastResponse) {
+	startTime := time.Now()
+	channelID, msgType := unknownTag, unknownTag
+	defer func() {
+		scope := bh.metrics.Tagged(map[string]string{
+			"channel": channelID,
+			"type":    msgType,
+			"status":  resp.Status.String(),
+		})
+		scope.Counter("requests_total").Inc(1)
+		scope.Histogram("request_duration").RecordDuration(time.Since(startTime))
+	}()
+
+	chdr, isConfig, processor, err := bh.sm.BroadcastChannelSupport(msg)
+	if err != nil {
+		logger.Warningf("[channel: %s] Could not get message processor for serving %s: %s", chdr.GetChannelId(), addr, err)
+		return &ab.BroadcastResponse{Status: cb.Status_INTERNAL_SERVER_ERROR, Info: err.Error()}
+	}
+	channelID = processor.ChainID()
+	if _, ok := cb.HeaderType_name[chdr.Type]; ok {
+		msgType = cb.HeaderType(chdr.Type).String()
+	}
+
+	if err = processor.WaitReady(); err != nil {
+		logger.Warningf("[channel: %s] Rejecting broadcast of message from %s with SERVICE_UNAVAILABLE: rejected by Consenter: %s", chdr.ChannelId, addr, err)
+		return &ab.BroadcastResponse{Status: cb.Status_SERVICE_UNAVAILABLE, Info: err.Error()}
+	}
+
+	if !isConfig {
+		logger.Debugf("[channel: %s] Broadcast is processing normal message from %s with txid '%s' of type %s", chdr.ChannelId, addr, chdr.TxId, cb.HeaderType_name[chdr.Type])
+
+		configSeq, err := processor.ProcessNormalMsg(msg)
+		if err != nil {
+			logger.Warningf("[channel: %s] Rejecting broadcast of normal message from %s because of error: %s", chdr.ChannelId, addr, err)
+			return &ab.BroadcastResponse{Status: ClassifyError(err), Info: err.Error()}
 		}
 
-		if !isConfig {
-			logger.Debugf("[channel: %s] Broadcast is processing normal message from %s with txid '%s' of type %s", chdr.ChannelId, addr, chdr.TxId, cb.HeaderType_name[chdr.Type])
-
-			configSeq, err := processor.ProcessNormalMsg(msg)
-			if err != nil {
-				logger.Warningf("[channel: %s] Rejecting broadcast of normal message from %s because of error: %s", chdr.ChannelId, addr, err)
-				return srv.Send(&ab.BroadcastResponse{Status: ClassifyError(err), Info: err.Error()})
-			}
-
-			err = processor.Order(msg, configSeq)
-			if err != nil {
-				logger.Warningf("[channel: %s] Rejecting broadcast of normal message from %s with SERVICE_UNAVAILABLE: rejected by Order: %s", chdr.ChannelId, addr, err)
-				return srv.Send(&ab.BroadcastResponse{Status: cb.Status_SERVICE_UNAVAILABLE, Info: err.Error()})
-			}
-		} else { // isConfig
-			logger.Debugf("[channel: %s] Broadcast is processing config update message from %s", chdr.ChannelId, addr)
-
-			config, configSeq, err := processor.ProcessConfigUpdateMsg(msg)
-			if err != nil {
-				logger.Warningf("[channel: %s] Rejecting broadcast of config message from %s because of error: %s", chdr.ChannelId, addr, err)
-				return srv.Send(&ab.BroadcastResponse{Status: ClassifyError(err), Info: err.Error()})
-			}
-
-			err = processor.Configure(config, configSeq)
-			if err != nil {
-				logger.Warningf("[channel: %s] Rejecting broadcast of config message from %s with SERVICE_UNAVAILABLE: rejected by Configure: %s", chdr.ChannelId, addr, err)
-				return srv.Send(&ab.BroadcastResponse{Status: cb.Status_SERVICE_UNAVAILABLE, Info: err.Error()})
-			}
+		if resp := bh.admit(processor, chdr, msg, addr); resp != nil {
+			return resp
 		}
 
-		logger.Debugf("[channel: %s] Broadcast has successfully enqueued message of type %s from %s", chdr.ChannelId, cb.HeaderType_name[chdr.Type], addr)
+		err = processor.Order(msg, configSeq)
+		if err != nil {
+			bh.release(chdr)
+			logger.Warningf("[channel: %s] Rejecting broadcast of normal message from %s with SERVICE_UNAVAILABLE: rejected by Order: %s", chdr.ChannelId, addr, err)
+			return &ab.BroadcastResponse{Status: cb.Status_SERVICE_UNAVAILABLE, Info: err.Error()}
+		}
+	} else { // isConfig
+		logger.Debugf("[channel: %s] Broadcast is processing config update message from %s", chdr.ChannelId, addr)
 
-		err = srv.Send(&ab.BroadcastResponse{Status: cb.Status_SUCCESS})
+		config, configSeq, err := processor.ProcessConfigUpdateMsg(msg)
 		if err != nil {
-			logger.Warningf("[channel: %s] Error sending to %s: %s", chdr.ChannelId, addr, err)
-			return err
+			logger.Warningf("[channel: %s] Rejecting broadcast of config message from %s because of error: %s", chdr.ChannelId, addr, err)
+			return &ab.BroadcastResponse{Status: ClassifyError(err), Info: err.Error()}
 		}
+
+		if resp := bh.admit(processor, chdr, msg, addr); resp != nil {
+			return resp
+		}
+
+		err = processor.Configure(config, configSeq)
+		if err != nil {
+			bh.release(chdr)
+			logger.Warningf("[channel: %s] Rejecting broadcast of config message from %s with SERVICE_UNAVAILABLE: rejected by Configure: %s", chdr.ChannelId, addr, err)
+			return &ab.BroadcastResponse{Status: cb.Status_SERVICE_UNAVAILABLE, Info: err.Error()}
+		}
+	}
+
+	logger.Debugf("[channel: %s] Broadcast has successfully enqueued message of type %s from %s", chdr.ChannelId, cb.HeaderType_name[chdr.Type], addr)
+
+	return &ab.BroadcastResponse{Status: cb.Status_SUCCESS}
+}
+
+// admit applies the rate limiter of the channel to the message, and remembers
+// its transaction in the transaction ID index, once the message has passed the
+// other filters of its channel. It returns the response rejecting the message
+// if its sender exceeded its rate or if its transaction was recently received.
+// The other filters are applied again whenever the consenters revalidate the
+// message, which is why neither check is part of them.
+func (bh *handlerImpl) admit(processor ChannelSupport, chdr *cb.ChannelHeader, msg *cb.Envelope, addr string) *ab.BroadcastResponse {
+	if err := processor.RateLimiter().Apply(msg); err != nil {
+		logger.Warningf("[channel: %s] Rejecting broadcast of message from %s because of error: %s", chdr.ChannelId, addr, err)
+		resp := &ab.BroadcastResponse{Status: ClassifyError(err), Info: err.Error()}
+		if rateLimitErr, ok := err.(*msgprocessor.RateLimitError); ok {
+			resp.RetryAfterMs = uint64((rateLimitErr.RetryAfter + time.Millisecond - 1) / time.Millisecond)
+		}
+		return resp
+	}
+	if bh.txIDIndex != nil {
+		if err := bh.txIDIndex.Accept(chdr.ChannelId, chdr.TxId); err != nil {
+			logger.Warningf("[channel: %s] Rejecting broadcast of message from %s because of error: %s", chdr.ChannelId, addr, err)
+			return &ab.BroadcastResponse{Status: ClassifyError(err), Info: err.Error()}
+		}
+	}
+	return nil
+}
+
+// release forgets the transaction of a message admitted but which could not be
+// enqueued, so that it may be broadcast again.
+func (bh *handlerImpl) release(chdr *cb.ChannelHeader) {
+	if bh.txIDIndex != nil {
+		bh.txIDIndex.Release(chdr.ChannelId, chdr.TxId)
 	}
 }
 
@@ -148,6 +240,8 @@ func ClassifyError(err error) cb.Status {
 		return cb.Status_NOT_FOUND
 	case msgprocessor.ErrPermissionDenied:
 		return cb.Status_FORBIDDEN
+	case msgprocessor.ErrRateLimited:
+		return cb.Status_SERVICE_UNAVAILABLE
 	default:
 		return cb.Status_BAD_REQUEST
 	}
@@ -12,10 +12,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hyperledger/fabric/common/channelconfig"
 	"github.com/hyperledger/fabric/common/flogging"
+	mockconfig "github.com/hyperledger/fabric/common/mocks/config"
+	mockmetrics "github.com/hyperledger/fabric/common/mocks/metrics"
 	"github.com/hyperledger/fabric/orderer/common/msgprocessor"
 	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/msp"
 	ab "github.com/hyperledger/fabric/protos/orderer"
+	"github.com/hyperledger/fabric/protos/utils"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/net/context"
@@ -95,19 +100,26 @@ func (m *erroneousSendMockB) Recv() (*cb.Envelope, error) {
 }
 
 type mockSupportManager struct {
+	ChannelHeaderVal     *cb.ChannelHeader
 	MsgProcessorIsConfig bool
 	MsgProcessorVal      *mockSupport
 	MsgProcessorErr      error
 }
 
 func (mm *mockSupportManager) BroadcastChannelSupport(msg *cb.Envelope) (*cb.ChannelHeader, bool, ChannelSupport, error) {
-	return &cb.ChannelHeader{}, mm.MsgProcessorIsConfig, mm.MsgProcessorVal, mm.MsgProcessorErr
+	chdr := mm.ChannelHeaderVal
+	if chdr == nil {
+		chdr = &cb.ChannelHeader{}
+	}
+	return chdr, mm.MsgProcessorIsConfig, mm.MsgProcessorVal, mm.MsgProcessorErr
 }
 
 type mockSupport struct {
+	ChainIDVal       string
 	ProcessConfigEnv *cb.Envelope
 	ProcessConfigSeq uint64
 	ProcessErr       error
+	RateLimiterVal   msgprocessor.Rule
 	rejectEnqueue    bool
 }
 
@@ -115,6 +127,17 @@ func (ms *mockSupport) WaitReady() error {
 	return nil
 }
 
+func (ms *mockSupport) ChainID() string {
+	return ms.ChainIDVal
+}
+
+func (ms *mockSupport) RateLimiter() msgprocessor.Rule {
+	if ms.RateLimiterVal == nil {
+		return msgprocessor.AcceptRule
+	}
+	return ms.RateLimiterVal
+}
+
 // Order sends a message for ordering
 func (ms *mockSupport) Order(env *cb.Envelope, configSeq uint64) error {
 	if ms.rejectEnqueue {
@@ -144,6 +167,14 @@ func (ms *mockSupport) ProcessConfigMsg(msg *cb.Envelope) (*cb.Envelope, uint64,
 	return ms.ProcessConfigEnv, ms.ProcessConfigSeq, ms.ProcessErr
 }
 
+type mockOrdererConfigSupport struct {
+	ordererConfig channelconfig.Orderer
+}
+
+func (m *mockOrdererConfigSupport) OrdererConfig() (channelconfig.Orderer, bool) {
+	return m.ordererConfig, true
+}
+
 func getMockSupportManager() *mockSupportManager {
 	return &mockSupportManager{
 		MsgProcessorVal: &mockSupport{},
@@ -152,7 +183,7 @@ func getMockSupportManager() *mockSupportManager {
 
 func TestEnqueueFailure(t *testing.T) {
 	mm := getMockSupportManager()
-	bh := NewHandlerImpl(mm)
+	bh := NewHandlerImpl(mm, nil)
 	m := newMockB()
 	defer close(m.recvChan)
 	done := make(chan struct{})
@@ -190,6 +221,9 @@ func TestClassifyError(t *testing.T) {
 	t.Run("Forbidden", func(t *testing.T) {
 		assert.Equal(t, cb.Status_FORBIDDEN, ClassifyError(msgprocessor.ErrPermissionDenied))
 	})
+	t.Run("RateLimited", func(t *testing.T) {
+		assert.Equal(t, cb.Status_SERVICE_UNAVAILABLE, ClassifyError(&msgprocessor.RateLimitError{Limit: "org", RetryAfter: time.Second}))
+	})
 	t.Run("WrappedErr", func(t *testing.T) {
 		assert.Equal(t, cb.Status_NOT_FOUND, ClassifyError(errors.Wrap(msgprocessor.ErrChannelDoesNotExist, "A wrapped error")))
 	})
@@ -201,7 +235,7 @@ func TestClassifyError(t *testing.T) {
 func TestBadChannelId(t *testing.T) {
 	mm := getMockSupportManager()
 	mm.MsgProcessorVal = &mockSupport{ProcessErr: msgprocessor.ErrChannelDoesNotExist}
-	bh := NewHandlerImpl(mm)
+	bh := NewHandlerImpl(mm, nil)
 	m := newMockB()
 	defer close(m.recvChan)
 	done := make(chan struct{})
@@ -226,7 +260,7 @@ func TestBadChannelId(t *testing.T) {
 func TestGoodConfigUpdate(t *testing.T) {
 	mm := getMockSupportManager()
 	mm.MsgProcessorIsConfig = true
-	bh := NewHandlerImpl(mm)
+	bh := NewHandlerImpl(mm, nil)
 	m := newMockB()
 	defer close(m.recvChan)
 	go bh.Handle(m)
@@ -240,7 +274,7 @@ func TestBadConfigUpdate(t *testing.T) {
 	mm := getMockSupportManager()
 	mm.MsgProcessorIsConfig = true
 	mm.MsgProcessorVal.ProcessErr = fmt.Errorf("Error")
-	bh := NewHandlerImpl(mm)
+	bh := NewHandlerImpl(mm, nil)
 	m := newMockB()
 	defer close(m.recvChan)
 	go bh.Handle(m)
@@ -250,8 +284,74 @@ func TestBadConfigUpdate(t *testing.T) {
 	assert.NotEqual(t, cb.Status_SUCCESS, reply.Status, "Should have rejected CONFIG_UPDATE")
 }
 
+func TestRateLimited(t *testing.T) {
+	mm := getMockSupportManager()
+	mm.MsgProcessorVal.RateLimiterVal = msgprocessor.NewRateLimiter("foo", &mockOrdererConfigSupport{
+		ordererConfig: &mockconfig.Orderer{RateLimitsVal: []*ab.RateLimit{{Rate: 1, Burst: 1}}},
+	})
+	bh := NewHandlerImpl(mm, nil)
+	env := &cb.Envelope{
+		Payload: utils.MarshalOrPanic(&cb.Payload{
+			Header: &cb.Header{
+				ChannelHeader: utils.MarshalOrPanic(&cb.ChannelHeader{Type: int32(cb.HeaderType_ENDORSER_TRANSACTION)}),
+				SignatureHeader: utils.MarshalOrPanic(&cb.SignatureHeader{
+					Creator: utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: "Org1MSP", IdBytes: []byte("client")}),
+				}),
+			},
+		}),
+	}
+	broadcast := func() *ab.BroadcastResponse {
+		m := newMockB()
+		defer close(m.recvChan)
+		go bh.Handle(m)
+		m.recvChan <- env
+		return <-m.sendChan
+	}
+
+	// Messages rejected by the filters of the channel take no token
+	mm.MsgProcessorVal.ProcessErr = msgprocessor.ErrPermissionDenied
+	assert.Equal(t, cb.Status_FORBIDDEN, broadcast().Status)
+	mm.MsgProcessorVal.ProcessErr = nil
+
+	assert.Equal(t, cb.Status_SUCCESS, broadcast().Status)
+	reply := broadcast()
+	assert.Equal(t, cb.Status_SERVICE_UNAVAILABLE, reply.Status)
+	assert.Contains(t, reply.Info, "org Org1MSP exceeded its rate on channel")
+	assert.True(t, reply.RetryAfterMs > 0 && reply.RetryAfterMs <= 1000, "Unexpected retry hint of %dms", reply.RetryAfterMs)
+}
+
+func TestDuplicateTxID(t *testing.T) {
+	mm := getMockSupportManager()
+	bh := NewHandlerImpl(mm, msgprocessor.NewTxIDIndex(time.Minute, 10))
+	broadcast := func(txID string) *ab.BroadcastResponse {
+		mm.ChannelHeaderVal = &cb.ChannelHeader{ChannelId: "foo", TxId: txID}
+		m := newMockB()
+		defer close(m.recvChan)
+		go bh.Handle(m)
+		m.recvChan <- nil
+		return <-m.sendChan
+	}
+
+	assert.Equal(t, cb.Status_SUCCESS, broadcast("tx1").Status)
+	reply := broadcast("tx1")
+	assert.Equal(t, cb.Status_BAD_REQUEST, reply.Status)
+	assert.Equal(t, "transaction tx1 was already received on channel foo: duplicate transaction ID", reply.Info)
+
+	// A transaction which could not be enqueued may be broadcast again
+	mm.MsgProcessorVal.rejectEnqueue = true
+	assert.Equal(t, cb.Status_SERVICE_UNAVAILABLE, broadcast("tx2").Status)
+	mm.MsgProcessorVal.rejectEnqueue = false
+	assert.Equal(t, cb.Status_SUCCESS, broadcast("tx2").Status)
+
+	// So may a transaction rejected by the filters of its channel
+	mm.MsgProcessorVal.ProcessErr = msgprocessor.ErrPermissionDenied
+	assert.Equal(t, cb.Status_FORBIDDEN, broadcast("tx3").Status)
+	mm.MsgProcessorVal.ProcessErr = nil
+	assert.Equal(t, cb.Status_SUCCESS, broadcast("tx3").Status)
+}
+
 func TestGracefulShutdown(t *testing.T) {
-	bh := NewHandlerImpl(nil)
+	bh := NewHandlerImpl(nil, nil)
 	m := newMockB()
 	close(m.recvChan)
 	assert.NoError(t, bh.Handle(m), "Should exit normally upon EOF")
@@ -261,7 +361,7 @@ func TestRejected(t *testing.T) {
 	mm := &mockSupportManager{
 		MsgProcessorVal: &mockSupport{ProcessErr: fmt.Errorf("Reject")},
 	}
-	bh := NewHandlerImpl(mm)
+	bh := NewHandlerImpl(mm, nil)
 	m := newMockB()
 	defer close(m.recvChan)
 	go bh.Handle(m)
@@ -273,13 +373,49 @@ func TestRejected(t *testing.T) {
 }
 
 func TestBadStreamRecv(t *testing.T) {
-	bh := NewHandlerImpl(nil)
+	bh := NewHandlerImpl(nil, nil)
 	assert.Error(t, bh.Handle(&erroneousRecvMockB{}), "Should catch unexpected stream error")
 }
 
 func TestBadStreamSend(t *testing.T) {
 	mm := getMockSupportManager()
-	bh := NewHandlerImpl(mm)
+	bh := NewHandlerImpl(mm, nil)
 	m := &erroneousSendMockB{recvVal: nil}
 	assert.Error(t, bh.Handle(m), "Should catch unexpected stream error")
 }
+
+func TestMetrics(t *testing.T) {
+	scope := mockmetrics.NewScope()
+	mm := getMockSupportManager()
+	mm.MsgProcessorVal.ChainIDVal = "mychannel"
+	mm.ChannelHeaderVal = &cb.ChannelHeader{ChannelId: "newchannel", Type: int32(cb.HeaderType_MESSAGE)}
+	bh := NewHandlerImpl(mm, nil)
+	bh.(*handlerImpl).metrics = scope
+	m := newMockB()
+	defer close(m.recvChan)
+	go bh.Handle(m)
+
+	for i := 0; i < 2; i++ {
+		m.recvChan <- nil
+		assert.Equal(t, cb.Status_SUCCESS, (<-m.sendChan).Status)
+	}
+	mm.MsgProcessorVal.ProcessErr = msgprocessor.ErrPermissionDenied
+	m.recvChan <- nil
+	assert.Equal(t, cb.Status_FORBIDDEN, (<-m.sendChan).Status)
+
+	// the requests are tagged with the channel which processes them rather than the one in their header
+	successTags := map[string]string{"channel": "mychannel", "type": "MESSAGE", "status": "SUCCESS"}
+	forbiddenTags := map[string]string{"channel": "mychannel", "type": "MESSAGE", "status": "FORBIDDEN"}
+	assert.Equal(t, int64(2), scope.CounterValue("requests_total", successTags))
+	assert.Equal(t, int64(1), scope.CounterValue("requests_total", forbiddenTags))
+	assert.Len(t, scope.HistogramValues("request_duration", successTags), 2)
+	assert.Len(t, scope.HistogramValues("request_duration", forbiddenTags), 1)
+
+	// the unknown types and the channels which could not be resolved are not taken from the header
+	mm.ChannelHeaderVal.Type = 1000
+	assert.Equal(t, cb.Status_FORBIDDEN, bh.(*handlerImpl).processMessage(nil, "").Status)
+	mm.MsgProcessorErr = msgprocessor.ErrChannelDoesNotExist
+	assert.Equal(t, cb.Status_INTERNAL_SERVER_ERROR, bh.(*handlerImpl).processMessage(nil, "").Status)
+	assert.Equal(t, int64(1), scope.CounterValue("requests_total", map[string]string{"channel": "mychannel", "type": "unknown", "status": "FORBIDDEN"}))
+	assert.Equal(t, int64(1), scope.CounterValue("requests_total", map[string]string{"channel": "unknown", "type": "unknown", "status": "INTERNAL_SERVER_ERROR"}))
+}
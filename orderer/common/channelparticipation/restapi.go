@@ -0,0 +1,207 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+// Package channelparticipation serves the channel participation API of the orderer, through which
+// an administrator lists, joins, and removes the channels served by an individual orderer, without
+// a system channel.
+package channelparticipation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/gorilla/mux"
+	"github.com/hyperledger/fabric/common/flogging"
+	localconfig "github.com/hyperledger/fabric/orderer/common/localconfig"
+	"github.com/hyperledger/fabric/orderer/common/multichannel"
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/op/go-logging"
+	"github.com/pkg/errors"
+)
+
+const pkgLogID = "orderer/common/channelparticipation"
+
+const (
+	// URLBaseV1 is the prefix of the paths of version 1 of the API
+	URLBaseV1 = "/participation/v1/"
+	// URLBaseV1Channels is the path of the collection of channels
+	URLBaseV1Channels = URLBaseV1 + "channels"
+
+	channelIDKey = "channelID"
+)
+
+var logger *logging.Logger
+
+func init() {
+	logger = flogging.MustGetLogger(pkgLogID)
+}
+
+// ChannelManagement is implemented by the multichannel.Registrar
+type ChannelManagement interface {
+	// ChannelList returns the channels served by the orderer
+	ChannelList() multichannel.ChannelList
+	// ChannelInfo returns the description of the given channel
+	ChannelInfo(channelID string) (multichannel.ChannelInfo, error)
+	// JoinChannel makes the orderer serve the channel of the given config block
+	JoinChannel(channelID string, configBlock *cb.Block) (multichannel.ChannelInfo, error)
+	// RemoveChannel makes the orderer stop serving the given channel and removes its ledger
+	RemoveChannel(channelID string) error
+}
+
+// ErrorResponse is the body of the responses to failed requests
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// HTTPHandler serves the channel participation API:
+//  - GET    /participation/v1/channels             lists the channels
+//  - POST   /participation/v1/channels             joins the channel of the config block in the body
+//  - GET    /participation/v1/channels/<channelID> describes a channel
+//  - DELETE /participation/v1/channels/<channelID> removes a channel
+type HTTPHandler struct {
+	config    localconfig.ChannelParticipation
+	registrar ChannelManagement
+	router    *mux.Router
+}
+
+// NewHTTPHandler creates the handler of the channel participation API
+func NewHTTPHandler(config localconfig.ChannelParticipation, registrar ChannelManagement) *HTTPHandler {
+	handler := &HTTPHandler{
+		config:    config,
+		registrar: registrar,
+		router:    mux.NewRouter(),
+	}
+
+	handler.router.HandleFunc(URLBaseV1Channels, handler.serveChannels)
+	handler.router.HandleFunc(URLBaseV1Channels+"/{"+channelIDKey+"}", handler.serveChannel)
+	handler.router.NotFoundHandler = http.HandlerFunc(handler.serveNotFound)
+
+	return handler
+}
+
+// ServeHTTP dispatches the request to the handler of its path and method
+func (h *HTTPHandler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
+	h.router.ServeHTTP(resp, req)
+}
+
+func (h *HTTPHandler) serveChannels(resp http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		h.serveListAll(resp, req)
+	case http.MethodPost:
+		h.serveJoin(resp, req)
+	default:
+		h.serveNotAllowed(resp, req, "GET, POST")
+	}
+}
+
+func (h *HTTPHandler) serveChannel(resp http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		h.serveListOne(resp, req)
+	case http.MethodDelete:
+		h.serveRemove(resp, req)
+	default:
+		h.serveNotAllowed(resp, req, "GET, DELETE")
+	}
+}
+
+func (h *HTTPHandler) serveListAll(resp http.ResponseWriter, req *http.Request) {
+	h.sendResponseOK(resp, http.StatusOK, h.registrar.ChannelList())
+}
+
+func (h *HTTPHandler) serveListOne(resp http.ResponseWriter, req *http.Request) {
+	channelID := mux.Vars(req)[channelIDKey]
+	info, err := h.registrar.ChannelInfo(channelID)
+	if err != nil {
+		h.sendResponseError(resp, statusOf(err), err)
+		return
+	}
+	h.sendResponseOK(resp, http.StatusOK, info)
+}
+
+func (h *HTTPHandler) serveJoin(resp http.ResponseWriter, req *http.Request) {
+	// read one byte more than allowed to tell whether the body is too large
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, int64(h.config.MaxRequestBodySize)+1))
+	if err != nil {
+		h.sendResponseError(resp, http.StatusBadRequest, errors.Wrap(err, "failed to read the request body"))
+		return
+	}
+	if len(body) > int(h.config.MaxRequestBodySize) {
+		h.sendResponseError(resp, http.StatusRequestEntityTooLarge,
+			errors.Errorf("the request body exceeds the maximum size of %d bytes", h.config.MaxRequestBodySize))
+		return
+	}
+	block := &cb.Block{}
+	if err := proto.Unmarshal(body, block); err != nil {
+		h.sendResponseError(resp, http.StatusBadRequest, errors.Wrap(err, "failed to unmarshal the config block"))
+		return
+	}
+	channelID, err := utils.GetChainIDFromBlock(block)
+	if err != nil {
+		h.sendResponseError(resp, http.StatusBadRequest, errors.WithMessage(err, "failed to extract the channel ID from the config block"))
+		return
+	}
+
+	info, err := h.registrar.JoinChannel(channelID, block)
+	if err != nil {
+		logger.Warningf("Failed to join channel %s: %s", channelID, err)
+		h.sendResponseError(resp, statusOf(err), err)
+		return
+	}
+	resp.Header().Set("Location", URLBaseV1Channels+"/"+channelID)
+	h.sendResponseOK(resp, http.StatusCreated, info)
+}
+
+func (h *HTTPHandler) serveRemove(resp http.ResponseWriter, req *http.Request) {
+	channelID := mux.Vars(req)[channelIDKey]
+	if err := h.registrar.RemoveChannel(channelID); err != nil {
+		logger.Warningf("Failed to remove channel %s: %s", channelID, err)
+		h.sendResponseError(resp, statusOf(err), err)
+		return
+	}
+	resp.WriteHeader(http.StatusNoContent)
+}
+
+func (h *HTTPHandler) serveNotAllowed(resp http.ResponseWriter, req *http.Request, allow string) {
+	resp.Header().Set("Allow", allow)
+	h.sendResponseError(resp, http.StatusMethodNotAllowed, errors.Errorf("invalid request method: %s", req.Method))
+}
+
+func (h *HTTPHandler) serveNotFound(resp http.ResponseWriter, req *http.Request) {
+	h.sendResponseError(resp, http.StatusNotFound, errors.Errorf("invalid path: %s", req.URL.Path))
+}
+
+func (h *HTTPHandler) sendResponseOK(resp http.ResponseWriter, status int, content interface{}) {
+	resp.Header().Set("Content-Type", "application/json")
+	resp.WriteHeader(status)
+	if err := json.NewEncoder(resp).Encode(content); err != nil {
+		logger.Errorf("Failed to encode the response: %s", err)
+	}
+}
+
+func (h *HTTPHandler) sendResponseError(resp http.ResponseWriter, status int, err error) {
+	h.sendResponseOK(resp, status, &ErrorResponse{Error: fmt.Sprint(err)})
+}
+
+// statusOf maps the errors of the registrar to the HTTP status of the response
+func statusOf(err error) int {
+	switch err {
+	case multichannel.ErrChannelNotExist:
+		return http.StatusNotFound
+	case multichannel.ErrChannelAlreadyExists:
+		return http.StatusConflict
+	case multichannel.ErrSystemChannelExists:
+		return http.StatusMethodNotAllowed
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package channelparticipation
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/tools/configtxgen/encoder"
+	genesisconfig "github.com/hyperledger/fabric/common/tools/configtxgen/localconfig"
+	localconfig "github.com/hyperledger/fabric/orderer/common/localconfig"
+	"github.com/hyperledger/fabric/orderer/common/multichannel"
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockChannelManagement struct {
+	channels map[string]multichannel.ChannelInfo
+	err      error
+	joined   *cb.Block
+}
+
+func (m *mockChannelManagement) ChannelList() multichannel.ChannelList {
+	list := multichannel.ChannelList{Channels: []multichannel.ChannelInfo{}}
+	for _, info := range m.channels {
+		list.Channels = append(list.Channels, info)
+	}
+	return list
+}
+
+func (m *mockChannelManagement) ChannelInfo(channelID string) (multichannel.ChannelInfo, error) {
+	info, ok := m.channels[channelID]
+	if !ok {
+		return multichannel.ChannelInfo{}, multichannel.ErrChannelNotExist
+	}
+	return info, nil
+}
+
+func (m *mockChannelManagement) JoinChannel(channelID string, configBlock *cb.Block) (multichannel.ChannelInfo, error) {
+	if m.err != nil {
+		return multichannel.ChannelInfo{}, m.err
+	}
+	m.joined = configBlock
+	info := multichannel.ChannelInfo{Name: channelID, ConsensusType: "solo", Height: 1}
+	m.channels[channelID] = info
+	return info, nil
+}
+
+func (m *mockChannelManagement) RemoveChannel(channelID string) error {
+	if m.err != nil {
+		return m.err
+	}
+	if _, ok := m.channels[channelID]; !ok {
+		return multichannel.ErrChannelNotExist
+	}
+	delete(m.channels, channelID)
+	return nil
+}
+
+func newTestHandler() (*HTTPHandler, *mockChannelManagement) {
+	registrar := &mockChannelManagement{channels: map[string]multichannel.ChannelInfo{}}
+	return NewHTTPHandler(localconfig.ChannelParticipation{Enabled: true, MaxRequestBodySize: 1024 * 1024}, registrar), registrar
+}
+
+func serve(h http.Handler, method, path string, body []byte) *httptest.ResponseRecorder {
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, httptest.NewRequest(method, path, bytes.NewReader(body)))
+	return resp
+}
+
+func decodeError(t *testing.T, resp *httptest.ResponseRecorder) string {
+	errResp := &ErrorResponse{}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), errResp))
+	return errResp.Error
+}
+
+func TestJoinListRemove(t *testing.T) {
+	h, registrar := newTestHandler()
+	genesisBlock := encoder.New(genesisconfig.Load("SampleNoConsortium")).GenesisBlockForChannel("foo")
+
+	resp := serve(h, http.MethodPost, URLBaseV1Channels, utils.MarshalOrPanic(genesisBlock))
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(t, "/participation/v1/channels/foo", resp.Header().Get("Location"))
+	assert.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+	info := multichannel.ChannelInfo{}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &info))
+	assert.Equal(t, multichannel.ChannelInfo{Name: "foo", ConsensusType: "solo", Height: 1}, info)
+	assert.True(t, bytes.Equal(utils.MarshalOrPanic(genesisBlock), utils.MarshalOrPanic(registrar.joined)))
+
+	resp = serve(h, http.MethodGet, URLBaseV1Channels, nil)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	list := multichannel.ChannelList{}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &list))
+	assert.Equal(t, multichannel.ChannelList{Channels: []multichannel.ChannelInfo{info}}, list)
+
+	resp = serve(h, http.MethodGet, URLBaseV1Channels+"/foo", nil)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"name":"foo","consensusType":"solo","height":1}`, resp.Body.String())
+
+	resp = serve(h, http.MethodDelete, URLBaseV1Channels+"/foo", nil)
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+
+	resp = serve(h, http.MethodGet, URLBaseV1Channels+"/foo", nil)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	assert.Equal(t, "channel does not exist", decodeError(t, resp))
+	resp = serve(h, http.MethodDelete, URLBaseV1Channels+"/foo", nil)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func TestJoinErrors(t *testing.T) {
+	h, registrar := newTestHandler()
+	genesisBlock := encoder.New(genesisconfig.Load("SampleNoConsortium")).GenesisBlockForChannel("foo")
+
+	resp := serve(h, http.MethodPost, URLBaseV1Channels, []byte("garbage"))
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, decodeError(t, resp), "failed to unmarshal the config block")
+
+	resp = serve(h, http.MethodPost, URLBaseV1Channels, utils.MarshalOrPanic(&cb.Block{}))
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, decodeError(t, resp), "failed to extract the channel ID from the config block")
+
+	h.config.MaxRequestBodySize = 10
+	resp = serve(h, http.MethodPost, URLBaseV1Channels, utils.MarshalOrPanic(genesisBlock))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+	assert.Equal(t, "the request body exceeds the maximum size of 10 bytes", decodeError(t, resp))
+	h.config.MaxRequestBodySize = 1024 * 1024
+
+	for err, status := range map[error]int{
+		multichannel.ErrChannelAlreadyExists: http.StatusConflict,
+		multichannel.ErrSystemChannelExists:  http.StatusMethodNotAllowed,
+	} {
+		registrar.err = err
+		resp = serve(h, http.MethodPost, URLBaseV1Channels, utils.MarshalOrPanic(genesisBlock))
+		assert.Equal(t, status, resp.Code)
+		assert.Equal(t, err.Error(), decodeError(t, resp))
+	}
+
+	registrar.err = multichannel.ErrSystemChannelExists
+	resp = serve(h, http.MethodDelete, URLBaseV1Channels+"/foo", nil)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.Code)
+	assert.Equal(t, multichannel.ErrSystemChannelExists.Error(), decodeError(t, resp))
+}
+
+func TestInvalidRequests(t *testing.T) {
+	h, _ := newTestHandler()
+
+	resp := serve(h, http.MethodPut, URLBaseV1Channels, nil)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.Code)
+	assert.Equal(t, "GET, POST", resp.Header().Get("Allow"))
+	assert.Equal(t, "invalid request method: PUT", decodeError(t, resp))
+
+	resp = serve(h, http.MethodPost, URLBaseV1Channels+"/foo", nil)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.Code)
+	assert.Equal(t, "GET, DELETE", resp.Header().Get("Allow"))
+
+	resp = serve(h, http.MethodGet, URLBaseV1+"nodes", nil)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	assert.Equal(t, "invalid path: /participation/v1/nodes", decodeError(t, resp))
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package config
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
@@ -61,11 +62,16 @@ func init() {
 // modify the default mapping, see the "Unmarshal"
 // section of https://github.com/spf13/viper for more info
 type TopLevel struct {
-	General    General
-	FileLedger FileLedger
-	RAMLedger  RAMLedger
-	Kafka      Kafka
-	Debug      Debug
+	General              General
+	FileLedger           FileLedger
+	RAMLedger            RAMLedger
+	Kafka                Kafka
+	Raft                 Raft
+	BFT                  BFT
+	Debug                Debug
+	Metrics              Metrics
+	ChannelParticipation ChannelParticipation
+	TxIDIndex            TxIDIndex
 }
 
 // General contains config which should be common among all orderer types.
@@ -134,6 +140,35 @@ type Kafka struct {
 	Verbose bool
 	Version sarama.KafkaVersion // TODO Move this to global config
 	TLS     TLS
+	SASL    SASL
+	Topic   Topic
+}
+
+// SASL contains configuration for SASL authentication towards the Kafka
+// cluster.
+type SASL struct {
+	Enabled   bool
+	Mechanism string
+	User      string
+	Password  string
+}
+
+// Topic contains the settings of the Kafka topics created for channels.
+type Topic struct {
+	ReplicationFactor int16
+	NumPartitions     int32
+}
+
+// Raft contains configuration for the Raft-based orderer.
+type Raft struct {
+	WALDir   string
+	Endpoint string
+}
+
+// BFT contains configuration for the BFT-based orderer.
+type BFT struct {
+	StateDir string
+	Endpoint string
 }
 
 // Retry contains configuration related to retries and timeouts when the
@@ -179,6 +214,47 @@ type Consumer struct {
 	RetryBackoff time.Duration
 }
 
+// Metrics contains configuration for the metrics emitted by the orderer.
+type Metrics struct {
+	Enabled        bool
+	Reporter       string
+	Interval       time.Duration
+	StatsdReporter StatsdReporter
+	PromReporter   PromReporter
+}
+
+// StatsdReporter contains configuration for pushing metrics to a statsd server.
+type StatsdReporter struct {
+	Address       string
+	FlushInterval time.Duration
+	FlushBytes    int
+}
+
+// PromReporter contains configuration for exposing metrics to be scraped by
+// Prometheus.
+type PromReporter struct {
+	ListenAddress string
+}
+
+// ChannelParticipation contains configuration for the channel participation
+// API, through which the channels served by the orderer are listed, joined,
+// and removed without a system channel.
+type ChannelParticipation struct {
+	Enabled            bool
+	ListenAddress      string
+	MaxRequestBodySize uint32
+	TLS                TLS
+}
+
+// TxIDIndex contains configuration for the index of the transaction IDs
+// recently received or ordered on each channel, against which the replayed
+// transactions are rejected at Broadcast time.
+type TxIDIndex struct {
+	Enabled    bool
+	Window     time.Duration
+	MaxEntries int
+}
+
 // Debug contains configuration for the orderer's debug parameters
 type Debug struct {
 	BroadcastTraceDir string
@@ -242,11 +318,48 @@ var defaults = TopLevel{
 		TLS: TLS{
 			Enabled: false,
 		},
+		SASL: SASL{
+			Enabled:   false,
+			Mechanism: "PLAIN",
+		},
+		Topic: Topic{
+			ReplicationFactor: 3,
+			NumPartitions:     1,
+		},
+	},
+	Raft: Raft{
+		WALDir: "/var/hyperledger/production/orderer/raftwal",
+	},
+	BFT: BFT{
+		StateDir: "/var/hyperledger/production/orderer/bftstate",
 	},
 	Debug: Debug{
 		BroadcastTraceDir: "",
 		DeliverTraceDir:   "",
 	},
+	Metrics: Metrics{
+		Enabled:  false,
+		Reporter: "statsd",
+		Interval: time.Second,
+		StatsdReporter: StatsdReporter{
+			Address:       "0.0.0.0:8125",
+			FlushInterval: 2 * time.Second,
+			FlushBytes:    1432,
+		},
+		PromReporter: PromReporter{
+			ListenAddress: "0.0.0.0:8081",
+		},
+	},
+	ChannelParticipation: ChannelParticipation{
+		Enabled:            false,
+		ListenAddress:      "127.0.0.1:9443",
+		MaxRequestBodySize: 1024 * 1024,
+	},
+	TxIDIndex: TxIDIndex{
+		Enabled:    false,
+		Window:     15 * time.Minute,
+		MaxEntries: 100000,
+	},
 }
 
 // Load parses the orderer.yaml file and environment, producing a struct suitable for config use
@@ -285,6 +398,9 @@ func (c *TopLevel) completeInitialization(configDir string) {
 		cf.TranslatePathInPlace(configDir, &c.General.TLS.Certificate)
 		cf.TranslatePathInPlace(configDir, &c.General.GenesisFile)
 		cf.TranslatePathInPlace(configDir, &c.General.LocalMSPDir)
+		c.ChannelParticipation.TLS.ClientRootCAs = translateCAs(configDir, c.ChannelParticipation.TLS.ClientRootCAs)
+		cf.TranslatePathInPlace(configDir, &c.ChannelParticipation.TLS.PrivateKey)
+		cf.TranslatePathInPlace(configDir, &c.ChannelParticipation.TLS.Certificate)
 	}()
 
 	for {
@@ -322,6 +438,11 @@ func (c *TopLevel) completeInitialization(configDir string) {
 			logger.Panicf("General.Kafka.TLS.PrivateKey must be set if General.Kafka.TLS.Enabled is set to true.")
 		case c.Kafka.TLS.Enabled && c.Kafka.TLS.RootCAs == nil:
 			logger.Panicf("General.Kafka.TLS.CertificatePool must be set if General.Kafka.TLS.Enabled is set to true.")
+		case c.Kafka.SASL.Enabled && (c.Kafka.SASL.User == "" || c.Kafka.SASL.Password == ""):
+			logger.Panicf("Kafka.SASL.User and Kafka.SASL.Password must be set if Kafka.SASL.Enabled is set to true.")
+		case c.Kafka.SASL.Mechanism != "" && c.Kafka.SASL.Mechanism != "PLAIN" &&
+			c.Kafka.SASL.Mechanism != "SCRAM-SHA-256" && c.Kafka.SASL.Mechanism != "SCRAM-SHA-512":
+			logger.Panicf("Kafka.SASL.Mechanism %s is not supported, it must be one of PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512.", c.Kafka.SASL.Mechanism)
 
 		case c.General.Profile.Enabled && c.General.Profile.Address == "":
 			logger.Infof("Profiling enabled and General.Profile.Address unset, setting to %s", defaults.General.Profile.Address)
@@ -334,6 +455,53 @@ func (c *TopLevel) completeInitialization(configDir string) {
 			logger.Infof("General.LocalMSPID unset, setting to %s", defaults.General.LocalMSPID)
 			c.General.LocalMSPID = defaults.General.LocalMSPID
 
+		case c.Raft.WALDir == "":
+			logger.Infof("Raft.WALDir unset, setting to %s", defaults.Raft.WALDir)
+			c.Raft.WALDir = defaults.Raft.WALDir
+		case c.Raft.Endpoint == "":
+			c.Raft.Endpoint = fmt.Sprintf("%s:%d", c.General.ListenAddress, c.General.ListenPort)
+			logger.Infof("Raft.Endpoint unset, setting to %s", c.Raft.Endpoint)
+		case c.BFT.StateDir == "":
+			logger.Infof("BFT.StateDir unset, setting to %s", defaults.BFT.StateDir)
+			c.BFT.StateDir = defaults.BFT.StateDir
+		case c.BFT.Endpoint == "":
+			c.BFT.Endpoint = fmt.Sprintf("%s:%d", c.General.ListenAddress, c.General.ListenPort)
+			logger.Infof("BFT.Endpoint unset, setting to %s", c.BFT.Endpoint)
+
+		case c.Metrics.Enabled && c.Metrics.Reporter == "":
+			logger.Infof("Metrics enabled and Metrics.Reporter unset, setting to %s", defaults.Metrics.Reporter)
+			c.Metrics.Reporter = defaults.Metrics.Reporter
+		case c.Metrics.Enabled && c.Metrics.Interval == 0*time.Second:
+			logger.Infof("Metrics enabled and Metrics.Interval unset, setting to %v", defaults.Metrics.Interval)
+			c.Metrics.Interval = defaults.Metrics.Interval
+		case c.Metrics.Enabled && c.Metrics.StatsdReporter.FlushInterval == 0*time.Second:
+			logger.Infof("Metrics enabled and Metrics.StatsdReporter.FlushInterval unset, setting to %v", defaults.Metrics.StatsdReporter.FlushInterval)
+			c.Metrics.StatsdReporter.FlushInterval = defaults.Metrics.StatsdReporter.FlushInterval
+		case c.Metrics.Enabled && c.Metrics.StatsdReporter.FlushBytes == 0:
+			logger.Infof("Metrics enabled and Metrics.StatsdReporter.FlushBytes unset, setting to %d", defaults.Metrics.StatsdReporter.FlushBytes)
+			c.Metrics.StatsdReporter.FlushBytes = defaults.Metrics.StatsdReporter.FlushBytes
+		case c.Metrics.Enabled && c.Metrics.PromReporter.ListenAddress == "":
+			logger.Infof("Metrics enabled and Metrics.PromReporter.ListenAddress unset, setting to %s", defaults.Metrics.PromReporter.ListenAddress)
+			c.Metrics.PromReporter.ListenAddress = defaults.Metrics.PromReporter.ListenAddress
+
+		case c.ChannelParticipation.Enabled && c.ChannelParticipation.ListenAddress == "":
+			logger.Infof("ChannelParticipation enabled and ChannelParticipation.ListenAddress unset, setting to %s", defaults.ChannelParticipation.ListenAddress)
+			c.ChannelParticipation.ListenAddress = defaults.ChannelParticipation.ListenAddress
+		case c.ChannelParticipation.Enabled && c.ChannelParticipation.MaxRequestBodySize == 0:
+			logger.Infof("ChannelParticipation enabled and ChannelParticipation.MaxRequestBodySize unset, setting to %d", defaults.ChannelParticipation.MaxRequestBodySize)
+			c.ChannelParticipation.MaxRequestBodySize = defaults.ChannelParticipation.MaxRequestBodySize
+		case c.ChannelParticipation.TLS.Enabled && (c.ChannelParticipation.TLS.Certificate == "" || c.ChannelParticipation.TLS.PrivateKey == ""):
+			logger.Panicf("ChannelParticipation.TLS.Certificate and ChannelParticipation.TLS.PrivateKey must be set if ChannelParticipation.TLS.Enabled is set to true.")
+		case c.ChannelParticipation.TLS.ClientAuthEnabled && !c.ChannelParticipation.TLS.Enabled:
+			logger.Panicf("ChannelParticipation.TLS.Enabled must be set to true if ChannelParticipation.TLS.ClientAuthEnabled is set to true.")
+
+		case c.TxIDIndex.Enabled && c.TxIDIndex.Window <= 0:
+			logger.Infof("TxIDIndex enabled and TxIDIndex.Window unset, setting to %v", defaults.TxIDIndex.Window)
+			c.TxIDIndex.Window = defaults.TxIDIndex.Window
+		case c.TxIDIndex.Enabled && c.TxIDIndex.MaxEntries <= 0:
+			logger.Infof("TxIDIndex enabled and TxIDIndex.MaxEntries unset, setting to %d", defaults.TxIDIndex.MaxEntries)
+			c.TxIDIndex.MaxEntries = defaults.TxIDIndex.MaxEntries
+
 		case c.FileLedger.Prefix == "":
 			logger.Infof("FileLedger.Prefix unset, setting to %s", defaults.FileLedger.Prefix)
 			c.FileLedger.Prefix = defaults.FileLedger.Prefix
@@ -383,6 +551,16 @@ func (c *TopLevel) completeInitialization(configDir string) {
 			logger.Infof("Kafka.Version unset, setting to %v", defaults.Kafka.Version)
 			c.Kafka.Version = defaults.Kafka.Version
 
+		case c.Kafka.SASL.Mechanism == "":
+			logger.Infof("Kafka.SASL.Mechanism unset, setting to %s", defaults.Kafka.SASL.Mechanism)
+			c.Kafka.SASL.Mechanism = defaults.Kafka.SASL.Mechanism
+		case c.Kafka.Topic.ReplicationFactor == 0:
+			logger.Infof("Kafka.Topic.ReplicationFactor unset, setting to %v", defaults.Kafka.Topic.ReplicationFactor)
+			c.Kafka.Topic.ReplicationFactor = defaults.Kafka.Topic.ReplicationFactor
+		case c.Kafka.Topic.NumPartitions == 0:
+			logger.Infof("Kafka.Topic.NumPartitions unset, setting to %v", defaults.Kafka.Topic.NumPartitions)
+			c.Kafka.Topic.NumPartitions = defaults.Kafka.Topic.NumPartitions
+
 		default:
 			return
 		}
@@ -117,6 +117,63 @@ func TestKafkaTLSConfig(t *testing.T) {
 	}
 }
 
+func TestKafkaSASLConfig(t *testing.T) {
+	testCases := []struct {
+		name        string
+		sasl        SASL
+		shouldPanic bool
+	}{
+		{"Disabled", SASL{Enabled: false}, false},
+		{"EnabledPlain", SASL{Enabled: true, User: "user", Password: "password"}, false},
+		{"EnabledSCRAM", SASL{Enabled: true, Mechanism: "SCRAM-SHA-512", User: "user", Password: "password"}, false},
+		{"EnabledNoUser", SASL{Enabled: true, Password: "password"}, true},
+		{"EnabledNoPassword", SASL{Enabled: true, User: "user"}, true},
+		{"UnknownMechanism", SASL{Enabled: true, Mechanism: "GSSAPI", User: "user", Password: "password"}, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uconf := &TopLevel{Kafka: Kafka{SASL: tc.sasl}}
+			if tc.shouldPanic {
+				assert.Panics(t, func() { uconf.completeInitialization(DummyPath) }, "should panic")
+			} else {
+				assert.NotPanics(t, func() { uconf.completeInitialization(DummyPath) }, "should not panic")
+			}
+		})
+	}
+}
+
+func TestKafkaTopicConfig(t *testing.T) {
+	uconf := &TopLevel{}
+	uconf.completeInitialization(DummyPath)
+	assert.Equal(t, defaults.Kafka.SASL.Mechanism, uconf.Kafka.SASL.Mechanism, "Expected SASL mechanism to be filled with default value")
+	assert.Equal(t, defaults.Kafka.Topic.ReplicationFactor, uconf.Kafka.Topic.ReplicationFactor, "Expected replication factor to be filled with default value")
+	assert.Equal(t, defaults.Kafka.Topic.NumPartitions, uconf.Kafka.Topic.NumPartitions, "Expected partition count to be filled with default value")
+}
+
+func TestChannelParticipationConfig(t *testing.T) {
+	uconf := &TopLevel{ChannelParticipation: ChannelParticipation{Enabled: true}}
+	uconf.completeInitialization(DummyPath)
+	assert.Equal(t, defaults.ChannelParticipation.ListenAddress, uconf.ChannelParticipation.ListenAddress, "Expected listen address to be filled with default value")
+	assert.Equal(t, defaults.ChannelParticipation.MaxRequestBodySize, uconf.ChannelParticipation.MaxRequestBodySize, "Expected max request body size to be filled with default value")
+
+	uconf = &TopLevel{ChannelParticipation: ChannelParticipation{Enabled: true, TLS: TLS{Enabled: true, Certificate: "public.key"}}}
+	assert.Panics(t, func() { uconf.completeInitialization(DummyPath) }, "should panic without a private key")
+
+	uconf = &TopLevel{ChannelParticipation: ChannelParticipation{Enabled: true, TLS: TLS{ClientAuthEnabled: true}}}
+	assert.Panics(t, func() { uconf.completeInitialization(DummyPath) }, "should panic with client authentication but without TLS")
+}
+
+func TestTxIDIndexConfig(t *testing.T) {
+	uconf := &TopLevel{TxIDIndex: TxIDIndex{Enabled: true}}
+	uconf.completeInitialization(DummyPath)
+	assert.Equal(t, defaults.TxIDIndex.Window, uconf.TxIDIndex.Window, "Expected window to be filled with default value")
+	assert.Equal(t, defaults.TxIDIndex.MaxEntries, uconf.TxIDIndex.MaxEntries, "Expected max entries to be filled with default value")
+
+	uconf = &TopLevel{}
+	uconf.completeInitialization(DummyPath)
+	assert.Zero(t, uconf.TxIDIndex.Window, "Expected window to be left unset when disabled")
+}
+
 func TestSystemChannel(t *testing.T) {
 	conf := Load()
 	assert.Equal(t, genesisconfig.TestChainID, conf.General.SystemChannel, "System channel ID should be '%s' by default", genesisconfig.TestChainID)
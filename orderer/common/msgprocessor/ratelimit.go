@@ -0,0 +1,238 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package msgprocessor
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/metrics"
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/msp"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/pkg/errors"
+)
+
+// ErrRateLimited is the cause of the errors returned by the RateLimiter for
+// messages which exceed the rate allowed to their org or client.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimitError is returned by the RateLimiter for messages which exceed the
+// rate allowed to their org or client.
+type RateLimitError struct {
+	ChannelID string
+	MSPID     string
+	// Limit is the exhausted limit, either "org" or "client"
+	Limit string
+	// RetryAfter is how long it takes for the exhausted limit to admit a message again
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.Limit == "org" {
+		return fmt.Sprintf("org %s exceeded its rate on channel %s, retry after %s: %s", e.MSPID, e.ChannelID, e.RetryAfter, ErrRateLimited)
+	}
+	return fmt.Sprintf("client of org %s exceeded its rate on channel %s, retry after %s: %s", e.MSPID, e.ChannelID, e.RetryAfter, ErrRateLimited)
+}
+
+// Cause returns ErrRateLimited, so that errors.Cause identifies the rate limit errors.
+func (e *RateLimitError) Cause() error {
+	return ErrRateLimited
+}
+
+// maxIdleBuckets is the number of token buckets above which the buckets which
+// have refilled, and thus hold no state worth keeping, are discarded.
+const maxIdleBuckets = 10000
+
+// RateLimiter holds the token buckets of the orgs and clients of a channel,
+// against which the messages broadcast to the channel are admitted. The limits
+// are read from the orderer config of the channel for each message, so that
+// they follow the config updates of the channel.
+type RateLimiter struct {
+	channelID     string
+	filterSupport resources
+	metrics       metrics.Scope
+	now           func() time.Time
+
+	lock    sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// NewRateLimiter creates a RateLimiter enforcing the rate limits of the orderer
+// config of the given channel.
+func NewRateLimiter(channelID string, filterSupport resources) *RateLimiter {
+	return &RateLimiter{
+		channelID:     channelID,
+		filterSupport: filterSupport,
+		metrics:       metrics.RootScope.SubScope("ratelimit"),
+		now:           time.Now,
+		buckets:       make(map[string]*tokenBucket),
+	}
+}
+
+// limit returns the limit of the org, or else the limit without an MSP ID,
+// and whether there is one.
+func (rl *RateLimiter) limit(mspID string) (*ab.RateLimit, bool) {
+	ordererConf, ok := rl.filterSupport.OrdererConfig()
+	if !ok {
+		logger.Panic("Programming error: orderer config not found")
+	}
+	var fallback *ab.RateLimit
+	for _, limit := range ordererConf.RateLimits() {
+		switch limit.MspId {
+		case mspID:
+			return limit, true
+		case "":
+			fallback = limit
+		}
+	}
+	return fallback, fallback != nil
+}
+
+// take takes a token from the bucket of the org and from the bucket of the
+// client, if both hold one. Otherwise, it returns which of the two is exhausted
+// and how long it takes for it to hold a token again.
+func (rl *RateLimiter) take(mspID string, creator []byte) (exhausted string, retryAfter time.Duration) {
+	limit, ok := rl.limit(mspID)
+	if !ok {
+		return "", 0
+	}
+
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+
+	now := rl.now()
+	var org, client *tokenBucket
+	if limit.Rate > 0 {
+		org = rl.bucket(fmt.Sprintf("org\x00%s", mspID), limit.Rate, limit.Burst, now)
+		if wait := org.wait(); wait > 0 {
+			return "org", wait
+		}
+	}
+	if limit.ClientRate > 0 {
+		client = rl.bucket(fmt.Sprintf("client\x00%x", sha256.Sum256(creator)), limit.ClientRate, limit.ClientBurst, now)
+		if wait := client.wait(); wait > 0 {
+			return "client", wait
+		}
+	}
+
+	if org != nil {
+		org.tokens--
+	}
+	if client != nil {
+		client.tokens--
+	}
+	return "", 0
+}
+
+// bucket returns the refilled bucket with the given key, creating it if needed,
+// and adjusted to the given rate and burst, which may have been updated since.
+// Must be called with the lock held.
+func (rl *RateLimiter) bucket(key string, rate float64, burst uint32, now time.Time) *tokenBucket {
+	capacity := float64(burst)
+	if capacity < 1 {
+		capacity = math.Max(1, math.Ceil(rate))
+	}
+	b, ok := rl.buckets[key]
+	if !ok {
+		if len(rl.buckets) >= maxIdleBuckets {
+			rl.discardFullBuckets(now)
+		}
+		b = &tokenBucket{rate: rate, capacity: capacity, tokens: capacity, last: now}
+		rl.buckets[key] = b
+	}
+	b.refill(now)
+	b.rate = rate
+	b.capacity = capacity
+	b.tokens = math.Min(b.tokens, capacity)
+	return b
+}
+
+// discardFullBuckets discards the buckets which have refilled, as they would be
+// recreated in the very same state. Must be called with the lock held.
+func (rl *RateLimiter) discardFullBuckets(now time.Time) {
+	for key, b := range rl.buckets {
+		b.refill(now)
+		if b.tokens >= b.capacity {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+// tokenBucket holds up to capacity tokens, and is refilled with rate tokens per
+// second.
+type tokenBucket struct {
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(b.capacity, b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+}
+
+// wait returns how long it takes for the bucket to hold a token.
+func (b *tokenBucket) wait() time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration(math.Ceil((1 - b.tokens) / b.rate * float64(time.Second)))
+}
+
+// Apply takes a token from the buckets of the org and of the client which
+// signed the message, and returns a *RateLimitError if either of them is
+// exhausted. Unlike the other filters of the channel, it is meant to be applied
+// once per message, after the message has passed the other filters, so that
+// the tokens of an org are not drained by messages which merely claim to come
+// from its members, nor taken again when the consenters revalidate a message.
+// The config messages produced by the orderer itself are not limited.
+func (rl *RateLimiter) Apply(message *cb.Envelope) error {
+	payload, err := utils.UnmarshalPayload(message.Payload)
+	if err != nil {
+		return errors.WithMessage(err, "could not unmarshal payload")
+	}
+	if payload.Header == nil {
+		return errors.New("missing header")
+	}
+	chdr, err := utils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return errors.WithMessage(err, "could not unmarshal channel header")
+	}
+	if chdr.Type == int32(cb.HeaderType_CONFIG) || chdr.Type == int32(cb.HeaderType_ORDERER_TRANSACTION) {
+		return nil
+	}
+	shdr, err := utils.GetSignatureHeader(payload.Header.SignatureHeader)
+	if err != nil {
+		return errors.WithMessage(err, "could not unmarshal signature header")
+	}
+	identity := &msp.SerializedIdentity{}
+	if err := proto.Unmarshal(shdr.Creator, identity); err != nil {
+		return errors.Wrap(err, "could not unmarshal creator")
+	}
+
+	exhausted, retryAfter := rl.take(identity.Mspid, shdr.Creator)
+	if exhausted == "" {
+		return nil
+	}
+
+	rl.metrics.Tagged(map[string]string{
+		"channel": rl.channelID,
+		"msp_id":  identity.Mspid,
+		"limit":   exhausted,
+	}).Counter("rejected_total").Inc(1)
+
+	return &RateLimitError{ChannelID: rl.channelID, MSPID: identity.Mspid, Limit: exhausted, RetryAfter: retryAfter}
+}
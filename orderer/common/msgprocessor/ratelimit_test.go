@@ -0,0 +1,226 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package msgprocessor
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/channelconfig"
+	mockconfig "github.com/hyperledger/fabric/common/mocks/config"
+	mockmetrics "github.com/hyperledger/fabric/common/mocks/metrics"
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/msp"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeSignedEnvelope(headerType cb.HeaderType, mspID, client string) *cb.Envelope {
+	return &cb.Envelope{
+		Payload: utils.MarshalOrPanic(&cb.Payload{
+			Header: &cb.Header{
+				ChannelHeader: utils.MarshalOrPanic(&cb.ChannelHeader{Type: int32(headerType), ChannelId: "foo"}),
+				SignatureHeader: utils.MarshalOrPanic(&cb.SignatureHeader{
+					Creator: utils.MarshalOrPanic(&msp.SerializedIdentity{Mspid: mspID, IdBytes: []byte(client)}),
+				}),
+			},
+		}),
+	}
+}
+
+type rateLimitResources struct {
+	ordererConfig *mockconfig.Orderer
+}
+
+func (r *rateLimitResources) OrdererConfig() (channelconfig.Orderer, bool) {
+	return r.ordererConfig, true
+}
+
+type testRateLimiter struct {
+	*RateLimiter
+	ordererConfig *mockconfig.Orderer
+	clock         time.Time
+	metrics       *mockmetrics.Scope
+}
+
+func newTestRateLimiter(limits ...*ab.RateLimit) *testRateLimiter {
+	ordererConfig := &mockconfig.Orderer{RateLimitsVal: limits}
+	trl := &testRateLimiter{
+		RateLimiter:   NewRateLimiter("foo", &rateLimitResources{ordererConfig: ordererConfig}),
+		ordererConfig: ordererConfig,
+		clock:         time.Unix(1000, 0),
+		metrics:       mockmetrics.NewScope(),
+	}
+	trl.RateLimiter.now = func() time.Time { return trl.clock }
+	trl.RateLimiter.metrics = trl.metrics
+	return trl
+}
+
+func TestRateLimitOrg(t *testing.T) {
+	rl := newTestRateLimiter(&ab.RateLimit{Rate: 2, Burst: 3})
+
+	// The burst is spread over several clients of the org
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, rl.Apply(makeSignedEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, "Org1MSP", fmt.Sprintf("client%d", i))))
+	}
+	err := rl.Apply(makeSignedEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, "Org1MSP", "client3"))
+	assert.Equal(t, ErrRateLimited, errors.Cause(err))
+	assert.Equal(t, &RateLimitError{ChannelID: "foo", MSPID: "Org1MSP", Limit: "org", RetryAfter: 500 * time.Millisecond}, err)
+	assert.EqualError(t, err, "org Org1MSP exceeded its rate on channel foo, retry after 500ms: rate limit exceeded")
+
+	// Other orgs have buckets of their own
+	assert.NoError(t, rl.Apply(makeSignedEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, "Org2MSP", "client0")))
+
+	rl.clock = rl.clock.Add(500 * time.Millisecond)
+	assert.NoError(t, rl.Apply(makeSignedEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, "Org1MSP", "client3")))
+	assert.Error(t, rl.Apply(makeSignedEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, "Org1MSP", "client3")))
+
+	assert.Equal(t, int64(2), rl.metrics.CounterValue("rejected_total", map[string]string{
+		"channel": "foo",
+		"msp_id":  "Org1MSP",
+		"limit":   "org",
+	}))
+}
+
+func TestRateLimitClient(t *testing.T) {
+	rl := newTestRateLimiter(&ab.RateLimit{Rate: 100, ClientRate: 1, ClientBurst: 1})
+
+	assert.NoError(t, rl.Apply(makeSignedEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, "Org1MSP", "client0")))
+	err := rl.Apply(makeSignedEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, "Org1MSP", "client0"))
+	assert.Equal(t, ErrRateLimited, errors.Cause(err))
+	assert.EqualError(t, err, "client of org Org1MSP exceeded its rate on channel foo, retry after 1s: rate limit exceeded")
+	assert.NoError(t, rl.Apply(makeSignedEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, "Org1MSP", "client1")))
+
+	assert.Equal(t, int64(1), rl.metrics.CounterValue("rejected_total", map[string]string{
+		"channel": "foo",
+		"msp_id":  "Org1MSP",
+		"limit":   "client",
+	}))
+}
+
+func TestRateLimitNoTokenTakenOnRejection(t *testing.T) {
+	rl := newTestRateLimiter(&ab.RateLimit{Rate: 1, Burst: 2, ClientRate: 1, ClientBurst: 1})
+
+	assert.NoError(t, rl.Apply(makeSignedEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, "Org1MSP", "client0")))
+	// Rejected by the bucket of the client, which leaves the token of the org
+	assert.Error(t, rl.Apply(makeSignedEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, "Org1MSP", "client0")))
+	assert.NoError(t, rl.Apply(makeSignedEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, "Org1MSP", "client1")))
+}
+
+func TestRateLimitSelection(t *testing.T) {
+	rl := newTestRateLimiter(
+		&ab.RateLimit{Rate: 1},
+		&ab.RateLimit{MspId: "Org1MSP", Rate: 2},
+	)
+
+	limit, ok := rl.limit("Org1MSP")
+	assert.True(t, ok)
+	assert.Equal(t, float64(2), limit.Rate)
+	limit, ok = rl.limit("Org2MSP")
+	assert.True(t, ok)
+	assert.Equal(t, float64(1), limit.Rate)
+
+	_, ok = newTestRateLimiter(&ab.RateLimit{MspId: "Org1MSP", Rate: 1}).limit("Org2MSP")
+	assert.False(t, ok)
+}
+
+func TestRateLimitUnlimited(t *testing.T) {
+	for name, rl := range map[string]*testRateLimiter{
+		"NoLimit":   newTestRateLimiter(),
+		"ZeroRates": newTestRateLimiter(&ab.RateLimit{}),
+		"OtherOrgs": newTestRateLimiter(&ab.RateLimit{MspId: "Org2MSP", Rate: 1, ClientRate: 1}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 10; i++ {
+				assert.NoError(t, rl.Apply(makeSignedEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, "Org1MSP", "client0")))
+			}
+		})
+	}
+}
+
+func TestRateLimitConfigUpdate(t *testing.T) {
+	rl := newTestRateLimiter(&ab.RateLimit{Rate: 1, Burst: 1})
+
+	assert.NoError(t, rl.Apply(makeSignedEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, "Org1MSP", "client0")))
+	assert.Error(t, rl.Apply(makeSignedEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, "Org1MSP", "client0")))
+
+	// The limits of the updated config apply to the existing buckets from then on
+	rl.clock = rl.clock.Add(500 * time.Millisecond)
+	rl.ordererConfig.RateLimitsVal = []*ab.RateLimit{{Rate: 10, Burst: 1}}
+	err := rl.Apply(makeSignedEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, "Org1MSP", "client0"))
+	assert.Equal(t, 50*time.Millisecond, err.(*RateLimitError).RetryAfter)
+	rl.clock = rl.clock.Add(50 * time.Millisecond)
+	assert.NoError(t, rl.Apply(makeSignedEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, "Org1MSP", "client0")))
+
+	rl.ordererConfig.RateLimitsVal = nil
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, rl.Apply(makeSignedEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, "Org1MSP", "client0")))
+	}
+}
+
+func TestRateLimitOrdererConfig(t *testing.T) {
+	rl := newTestRateLimiter(&ab.RateLimit{Rate: 1, Burst: 1})
+
+	assert.NoError(t, rl.Apply(makeSignedEnvelope(cb.HeaderType_CONFIG_UPDATE, "Org1MSP", "client0")))
+	assert.Error(t, rl.Apply(makeSignedEnvelope(cb.HeaderType_CONFIG_UPDATE, "Org1MSP", "client0")))
+	// The config produced by the orderer out of a config update is not limited
+	assert.NoError(t, rl.Apply(makeSignedEnvelope(cb.HeaderType_CONFIG, "Org1MSP", "client0")))
+	assert.NoError(t, rl.Apply(makeSignedEnvelope(cb.HeaderType_ORDERER_TRANSACTION, "Org1MSP", "client0")))
+}
+
+func TestRateLimitDefaultBurst(t *testing.T) {
+	rl := newTestRateLimiter(&ab.RateLimit{Rate: 2.5})
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, rl.Apply(makeSignedEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, "Org1MSP", "client0")))
+	}
+	assert.Error(t, rl.Apply(makeSignedEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, "Org1MSP", "client0")))
+}
+
+func TestRateLimitDiscardsIdleBuckets(t *testing.T) {
+	rl := newTestRateLimiter(&ab.RateLimit{ClientRate: 1, ClientBurst: 1})
+
+	for i := 0; i < maxIdleBuckets; i++ {
+		assert.NoError(t, rl.Apply(makeSignedEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, "Org1MSP", fmt.Sprintf("client%d", i))))
+	}
+	assert.Len(t, rl.buckets, maxIdleBuckets)
+
+	// The buckets have refilled by now, and are discarded when the next one
+	// is created, except for the one of the client which has just sent again
+	rl.clock = rl.clock.Add(time.Second)
+	assert.NoError(t, rl.Apply(makeSignedEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, "Org1MSP", "client0")))
+	assert.NoError(t, rl.Apply(makeSignedEnvelope(cb.HeaderType_ENDORSER_TRANSACTION, "Org1MSP", "newclient")))
+	assert.Len(t, rl.buckets, 2)
+}
+
+func TestRateLimitMalformedMessages(t *testing.T) {
+	rl := newTestRateLimiter(&ab.RateLimit{Rate: 1})
+
+	err := rl.Apply(&cb.Envelope{Payload: []byte("garbage")})
+	assert.Contains(t, err.Error(), "could not unmarshal payload")
+
+	err = rl.Apply(&cb.Envelope{Payload: utils.MarshalOrPanic(&cb.Payload{})})
+	assert.EqualError(t, err, "missing header")
+
+	err = rl.Apply(&cb.Envelope{Payload: utils.MarshalOrPanic(&cb.Payload{Header: &cb.Header{ChannelHeader: []byte("garbage")}})})
+	assert.Contains(t, err.Error(), "could not unmarshal channel header")
+
+	err = rl.Apply(&cb.Envelope{Payload: utils.MarshalOrPanic(&cb.Payload{Header: &cb.Header{
+		ChannelHeader:   utils.MarshalOrPanic(&cb.ChannelHeader{Type: int32(cb.HeaderType_ENDORSER_TRANSACTION)}),
+		SignatureHeader: []byte("garbage"),
+	}})})
+	assert.Contains(t, err.Error(), "could not unmarshal signature header")
+
+	err = rl.Apply(&cb.Envelope{Payload: utils.MarshalOrPanic(&cb.Payload{Header: &cb.Header{
+		ChannelHeader:   utils.MarshalOrPanic(&cb.ChannelHeader{Type: int32(cb.HeaderType_ENDORSER_TRANSACTION)}),
+		SignatureHeader: utils.MarshalOrPanic(&cb.SignatureHeader{Creator: []byte("garbage")}),
+	}})})
+	assert.Contains(t, err.Error(), "could not unmarshal creator")
+}
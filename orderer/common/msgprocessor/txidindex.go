@@ -0,0 +1,245 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package msgprocessor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/common/ledger/blockledger"
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/pkg/errors"
+)
+
+// ErrDuplicateTxID is the cause of the errors returned by the TxIDIndex for
+// messages whose transaction ID was recently received or ordered on their channel.
+var ErrDuplicateTxID = errors.New("duplicate transaction ID")
+
+// TxIDIndex holds the IDs of the transactions recently accepted for ordering
+// through Broadcast, or ordered, on each channel, against which the messages
+// broadcast to the channels are checked for replays. A transaction is
+// remembered for window after the orderer received it, and at most maxEntries
+// transactions are remembered per channel, the least recently received being
+// forgotten first. Older replays are left to the DUPLICATE_TXID check of the
+// peers.
+//
+// The index is not persisted by itself: it is rebuilt from the most recent
+// blocks of the ledger of a channel when the channel is loaded, so that it
+// survives restarts. Like those of the blocks ordered afterwards, the
+// transactions of these blocks are deemed received when they are loaded, by
+// the clock of the orderer, so that a client cannot shorten or lengthen the
+// time its transactions are remembered by setting their timestamp. The
+// timestamps only bound how far back the blocks are read, which leaves a gap:
+// the transactions accepted but not yet ordered when the orderer stopped are
+// forgotten, as are the transactions ordered before the first block, going
+// backwards, whose transactions are all timestamped out of the window.
+type TxIDIndex struct {
+	window     time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	lock     sync.Mutex
+	channels map[string]*channelTxIDs
+}
+
+// NewTxIDIndex creates a TxIDIndex remembering up to maxEntries transactions
+// per channel for window after they were received.
+func NewTxIDIndex(window time.Duration, maxEntries int) *TxIDIndex {
+	return &TxIDIndex{
+		window:     window,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		channels:   make(map[string]*channelTxIDs),
+	}
+}
+
+// Accept remembers the transaction of a message broadcast to the given channel,
+// or returns an error whose cause is ErrDuplicateTxID if the transaction is
+// already remembered. Messages without transaction ID are always accepted.
+func (ti *TxIDIndex) Accept(channelID, txID string) error {
+	if txID == "" {
+		return nil
+	}
+
+	ti.lock.Lock()
+	defer ti.lock.Unlock()
+
+	now := ti.now()
+	if !ti.channel(channelID).add(txIDEntry{txID: txID, received: now}, ti.maxEntries, ti.window, now) {
+		return errors.WithMessage(ErrDuplicateTxID, fmt.Sprintf("transaction %s was already received on channel %s", txID, channelID))
+	}
+	return nil
+}
+
+// Release forgets a transaction accepted on the given channel which could not
+// be enqueued for ordering, so that it may be broadcast again.
+func (ti *TxIDIndex) Release(channelID, txID string) {
+	ti.lock.Lock()
+	defer ti.lock.Unlock()
+
+	if channel, ok := ti.channels[channelID]; ok {
+		delete(channel.received, txID)
+	}
+}
+
+// Load replaces the index of the given channel with the transactions of the
+// most recent blocks of its ledger. It reads the blocks backwards, until one
+// holds no transaction timestamped within the window or the index is full. If
+// a block cannot be read, the index is built from the blocks read so far and
+// an error is returned.
+func (ti *TxIDIndex) Load(channelID string, reader blockledger.Reader) error {
+	now := ti.now()
+	var txIDs []txIDEntry
+	var err error
+	for number := reader.Height(); number > 0 && len(txIDs) < ti.maxEntries; number-- {
+		block := blockledger.GetBlock(reader, number-1)
+		if block == nil {
+			err = errors.Errorf("could not retrieve block %d to load the recent transaction IDs of channel %s", number-1, channelID)
+			break
+		}
+		entries := blockTxIDs(block)
+		recent := false
+		for _, entry := range entries {
+			recent = recent || now.Sub(entry.received) < ti.window
+		}
+		if !recent && len(entries) > 0 {
+			break
+		}
+		for i := len(entries) - 1; i >= 0; i-- {
+			txIDs = append(txIDs, txIDEntry{txID: entries[i].txID, received: now})
+		}
+	}
+
+	ti.lock.Lock()
+	defer ti.lock.Unlock()
+
+	// The transactions are remembered in the order they were ordered, so that
+	// the least recent are forgotten first
+	channel := newChannelTxIDs()
+	for i := len(txIDs) - 1; i >= 0; i-- {
+		channel.add(txIDs[i], ti.maxEntries, ti.window, now)
+	}
+	ti.channels[channelID] = channel
+	logger.Debugf("[channel: %s] Loaded %d recent transaction IDs", channelID, len(channel.received))
+	return err
+}
+
+// AddBlock remembers the transactions of a block ordered on the given channel,
+// which were not necessarily received by this orderer.
+func (ti *TxIDIndex) AddBlock(channelID string, block *cb.Block) {
+	ti.lock.Lock()
+	defer ti.lock.Unlock()
+
+	now := ti.now()
+	channel := ti.channel(channelID)
+	for _, entry := range blockTxIDs(block) {
+		entry.received = now
+		channel.add(entry, ti.maxEntries, ti.window, now)
+	}
+}
+
+// Remove forgets the transactions of the given channel.
+func (ti *TxIDIndex) Remove(channelID string) {
+	ti.lock.Lock()
+	defer ti.lock.Unlock()
+	delete(ti.channels, channelID)
+}
+
+// channel returns the transactions of the given channel, creating them if
+// needed. Must be called with the lock held.
+func (ti *TxIDIndex) channel(channelID string) *channelTxIDs {
+	channel, ok := ti.channels[channelID]
+	if !ok {
+		channel = newChannelTxIDs()
+		ti.channels[channelID] = channel
+	}
+	return channel
+}
+
+type txIDEntry struct {
+	txID     string
+	received time.Time
+}
+
+// channelTxIDs holds the recently received transactions of a channel, both by
+// ID and in the order they were received, so that the least recently received
+// are forgotten first.
+type channelTxIDs struct {
+	received map[string]time.Time
+	order    []txIDEntry
+}
+
+func newChannelTxIDs() *channelTxIDs {
+	return &channelTxIDs{received: make(map[string]time.Time)}
+}
+
+// add remembers a transaction received within the window, after forgetting the
+// transactions which fell out of the window or which exceed maxEntries. The
+// entry must not have been received before the remembered transactions. It
+// returns false if the transaction is already remembered.
+func (c *channelTxIDs) add(entry txIDEntry, maxEntries int, window time.Duration, now time.Time) bool {
+	for len(c.order) > 0 && (len(c.order) >= maxEntries || now.Sub(c.order[0].received) >= window) {
+		// A released transaction may have been accepted again since, and is then kept
+		if received, ok := c.received[c.order[0].txID]; ok && received.Equal(c.order[0].received) {
+			delete(c.received, c.order[0].txID)
+		}
+		c.order = c.order[1:]
+	}
+	if _, ok := c.received[entry.txID]; ok {
+		return false
+	}
+	if now.Sub(entry.received) >= window {
+		return true
+	}
+	c.received[entry.txID] = entry.received
+	c.order = append(c.order, entry)
+	return true
+}
+
+// blockTxIDs returns the IDs of the transactions of a block which have one,
+// along with their timestamp, which is zero if unset.
+func blockTxIDs(block *cb.Block) []txIDEntry {
+	var entries []txIDEntry
+	for i := range block.GetData().GetData() {
+		env, err := utils.ExtractEnvelope(block, i)
+		if err != nil {
+			continue
+		}
+		chdr, err := utils.ChannelHeader(env)
+		if err != nil || chdr.TxId == "" {
+			continue
+		}
+		var timestamp time.Time
+		if chdr.Timestamp != nil {
+			timestamp = time.Unix(chdr.Timestamp.Seconds, int64(chdr.Timestamp.Nanos))
+		}
+		entries = append(entries, txIDEntry{txID: chdr.TxId, received: timestamp})
+	}
+	return entries
+}
+
+// DedupeTxIDs returns the given messages, without those whose transaction ID
+// is the one of an earlier message. The result only depends on the messages,
+// so that the consenters which cut their blocks independently of each other
+// cut the same blocks.
+func DedupeTxIDs(messages []*cb.Envelope) []*cb.Envelope {
+	seen := make(map[string]struct{}, len(messages))
+	deduped := make([]*cb.Envelope, 0, len(messages))
+	for _, msg := range messages {
+		if chdr, err := utils.ChannelHeader(msg); err == nil && chdr.TxId != "" {
+			if _, ok := seen[chdr.TxId]; ok {
+				logger.Warningf("[channel: %s] Dropping duplicate of transaction %s from the batch", chdr.ChannelId, chdr.TxId)
+				continue
+			}
+			seen[chdr.TxId] = struct{}{}
+		}
+		deduped = append(deduped, msg)
+	}
+	return deduped
+}
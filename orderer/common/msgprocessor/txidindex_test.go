@@ -0,0 +1,206 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package msgprocessor
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric/common/ledger/blockledger"
+	ramledger "github.com/hyperledger/fabric/common/ledger/blockledger/ram"
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var testTxIDClock = time.Unix(100000, 0)
+
+func makeTxIDEnvelope(txID string, ts time.Time) *cb.Envelope {
+	return &cb.Envelope{
+		Payload: utils.MarshalOrPanic(&cb.Payload{
+			Header: &cb.Header{
+				ChannelHeader: utils.MarshalOrPanic(&cb.ChannelHeader{
+					Type:      int32(cb.HeaderType_ENDORSER_TRANSACTION),
+					ChannelId: "foo",
+					TxId:      txID,
+					Timestamp: &timestamp.Timestamp{Seconds: ts.Unix(), Nanos: int32(ts.Nanosecond())},
+				}),
+			},
+		}),
+	}
+}
+
+func makeTxIDBlock(number uint64, envs ...*cb.Envelope) *cb.Block {
+	block := cb.NewBlock(number, nil)
+	for _, env := range envs {
+		block.Data.Data = append(block.Data.Data, utils.MarshalOrPanic(env))
+	}
+	return block
+}
+
+func newTestTxIDIndex(window time.Duration, maxEntries int) *TxIDIndex {
+	ti := NewTxIDIndex(window, maxEntries)
+	ti.now = func() time.Time { return testTxIDClock }
+	return ti
+}
+
+func TestTxIDIndexAccept(t *testing.T) {
+	ti := newTestTxIDIndex(time.Minute, 10)
+
+	assert.NoError(t, ti.Accept("foo", "tx1"))
+	err := ti.Accept("foo", "tx1")
+	assert.Equal(t, ErrDuplicateTxID, errors.Cause(err))
+	assert.EqualError(t, err, "transaction tx1 was already received on channel foo: duplicate transaction ID")
+
+	// The transactions of other channels are indexed separately
+	assert.NoError(t, ti.Accept("bar", "tx1"))
+	assert.NoError(t, ti.Accept("foo", "tx2"))
+
+	// Messages without transaction ID are not checked
+	assert.NoError(t, ti.Accept("foo", ""))
+	assert.NoError(t, ti.Accept("foo", ""))
+
+	// Transactions ordered through other orderers are remembered as well
+	ti.AddBlock("foo", makeTxIDBlock(1, makeTxIDEnvelope("tx3", testTxIDClock.Add(-time.Hour))))
+	assert.Error(t, ti.Accept("foo", "tx3"), "Transactions should be remembered from the time they are ordered")
+	ti.AddBlock("foo", makeTxIDBlock(2, makeTxIDEnvelope("tx1", testTxIDClock)))
+	assert.Len(t, ti.channels["foo"].order, 3, "Ordering an accepted transaction should not remember it twice")
+
+	ti.Remove("foo")
+	assert.NoError(t, ti.Accept("foo", "tx1"))
+}
+
+func TestTxIDIndexRelease(t *testing.T) {
+	ti := newTestTxIDIndex(time.Minute, 10)
+
+	assert.NoError(t, ti.Accept("foo", "tx1"))
+	ti.Release("foo", "tx1")
+	ti.Release("bar", "tx1")
+	assert.NoError(t, ti.Accept("foo", "tx1"), "A released transaction should be accepted again")
+
+	// The transaction accepted again is remembered for the window after it
+	// was accepted again, and is not forgotten along with its release
+	ti.Release("foo", "tx1")
+	ti.now = func() time.Time { return testTxIDClock.Add(30 * time.Second) }
+	assert.NoError(t, ti.Accept("foo", "tx1"))
+	ti.now = func() time.Time { return testTxIDClock.Add(70 * time.Second) }
+	assert.Error(t, ti.Accept("foo", "tx1"))
+}
+
+func TestTxIDIndexWindow(t *testing.T) {
+	ti := newTestTxIDIndex(time.Minute, 10)
+
+	assert.NoError(t, ti.Accept("foo", "tx1"))
+	ti.now = func() time.Time { return testTxIDClock.Add(30 * time.Second) }
+	assert.NoError(t, ti.Accept("foo", "tx2"))
+	assert.Error(t, ti.Accept("foo", "tx1"))
+
+	ti.now = func() time.Time { return testTxIDClock.Add(time.Minute) }
+	assert.NoError(t, ti.Accept("foo", "tx1"), "Transactions should be forgotten once out of the window")
+	assert.Error(t, ti.Accept("foo", "tx2"))
+	assert.Equal(t, []string{"tx2", "tx1"}, txIDs(ti.channels["foo"]), "Transactions out of the window should be discarded")
+}
+
+func TestTxIDIndexMaxEntries(t *testing.T) {
+	ti := newTestTxIDIndex(time.Minute, 3)
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, ti.Accept("foo", fmt.Sprintf("tx%d", i)))
+	}
+	assert.Len(t, ti.channels["foo"].received, 3)
+	assert.Equal(t, []string{"tx2", "tx3", "tx4"}, txIDs(ti.channels["foo"]), "The least recently received transactions should have been forgotten")
+	assert.NoError(t, ti.Accept("foo", "tx0"))
+}
+
+func TestTxIDIndexLoad(t *testing.T) {
+	rl, err := ramledger.New(10).GetOrCreate("foo")
+	assert.NoError(t, err)
+	appendBlock := func(envs ...*cb.Envelope) {
+		assert.NoError(t, rl.Append(blockledger.CreateNextBlock(rl, envs)))
+	}
+
+	appendBlock(makeTxIDEnvelope("genesis", testTxIDClock.Add(-time.Hour)))
+	appendBlock(makeTxIDEnvelope("stale", testTxIDClock.Add(-2*time.Minute)))
+	appendBlock(makeTxIDEnvelope("old", testTxIDClock.Add(-2*time.Minute)), makeTxIDEnvelope("tx1", testTxIDClock.Add(-30*time.Second)))
+	appendBlock()
+	appendBlock(makeTxIDEnvelope("tx2", testTxIDClock), makeTxIDEnvelope("future", testTxIDClock.Add(time.Hour)), makeTxIDEnvelope("tx3", testTxIDClock.Add(-40*time.Second)))
+
+	t.Run("Window", func(t *testing.T) {
+		ti := newTestTxIDIndex(time.Minute, 10)
+		assert.NoError(t, ti.Load("foo", rl))
+		for _, txID := range []string{"old", "tx1", "tx2", "tx3", "future"} {
+			assert.Error(t, ti.Accept("foo", txID), "%s should have been loaded", txID)
+		}
+		assert.NoError(t, ti.Accept("foo", "stale"), "Blocks should be read until one holds no transaction timestamped within the window")
+	})
+
+	t.Run("Clock", func(t *testing.T) {
+		ti := newTestTxIDIndex(time.Minute, 10)
+		assert.NoError(t, ti.Load("foo", rl))
+		assert.Equal(t, []string{"old", "tx1", "tx2", "future", "tx3"}, txIDs(ti.channels["foo"]), "Transactions should be indexed in the order they were ordered")
+		for txID, received := range ti.channels["foo"].received {
+			assert.Equal(t, testTxIDClock, received, "%s should be deemed received when loaded", txID)
+		}
+
+		// The timestamps of the transactions neither shorten nor lengthen the
+		// time they are remembered
+		ti.now = func() time.Time { return testTxIDClock.Add(59 * time.Second) }
+		assert.Error(t, ti.Accept("foo", "old"))
+		ti.now = func() time.Time { return testTxIDClock.Add(time.Minute) }
+		assert.NoError(t, ti.Accept("foo", "future"))
+	})
+
+	t.Run("MaxEntries", func(t *testing.T) {
+		ti := newTestTxIDIndex(time.Hour, 2)
+		assert.NoError(t, ti.Load("foo", rl))
+		assert.Equal(t, []string{"future", "tx3"}, txIDs(ti.channels["foo"]), "The most recent transactions should be loaded")
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		ti := newTestTxIDIndex(time.Minute, 10)
+		assert.NoError(t, ti.Accept("foo", "other"))
+		assert.NoError(t, ti.Load("foo", rl))
+		assert.NoError(t, ti.Accept("foo", "other"), "Loading should replace the index of the channel")
+	})
+
+	t.Run("MissingBlock", func(t *testing.T) {
+		// The RAM ledger only keeps the two most recent blocks
+		rl, err := ramledger.New(2).GetOrCreate("foo")
+		assert.NoError(t, err)
+		for _, txID := range []string{"tx1", "tx2", "tx3"} {
+			assert.NoError(t, rl.Append(blockledger.CreateNextBlock(rl, []*cb.Envelope{makeTxIDEnvelope(txID, testTxIDClock)})))
+		}
+
+		ti := newTestTxIDIndex(time.Minute, 10)
+		assert.EqualError(t, ti.Load("foo", rl), "could not retrieve block 0 to load the recent transaction IDs of channel foo")
+		assert.Equal(t, []string{"tx2", "tx3"}, txIDs(ti.channels["foo"]), "The transactions of the blocks read should be loaded")
+	})
+}
+
+func TestDedupeTxIDs(t *testing.T) {
+	tx1, tx2 := makeTxIDEnvelope("tx1", testTxIDClock), makeTxIDEnvelope("tx2", testTxIDClock)
+	tx1Again := makeTxIDEnvelope("tx1", testTxIDClock.Add(time.Second))
+	noTxID := makeTxIDEnvelope("", testTxIDClock)
+	garbage := &cb.Envelope{Payload: []byte("garbage")}
+
+	messages := []*cb.Envelope{tx1, noTxID, tx2, tx1Again, noTxID, garbage}
+	assert.Equal(t, []*cb.Envelope{tx1, noTxID, tx2, noTxID, garbage}, DedupeTxIDs(messages))
+	assert.Equal(t, tx1Again, messages[3], "The messages given should be left untouched")
+}
+
+func txIDs(c *channelTxIDs) []string {
+	var ids []string
+	for _, entry := range c.order {
+		if _, ok := c.received[entry.txID]; ok {
+			ids = append(ids, entry.txID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,132 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package multichannel
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric/common/crypto"
+	"github.com/hyperledger/fabric/core/comm"
+	cb "github.com/hyperledger/fabric/protos/common"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// BlockPuller pulls the blocks of a channel from the orderers which serve it, so that the channel
+// can be joined from a config block other than its genesis block, or that a consenter can catch up
+// with the other consenters of the channel
+type BlockPuller interface {
+	// PullBlocks returns the blocks of the given channel numbered from start up to, but excluding, end,
+	// pulled from the first of the given orderer endpoints which serves all of them
+	PullBlocks(channelID string, endpoints []string, start, end uint64) ([]*cb.Block, error)
+}
+
+const (
+	defaultDialTimeout = 5 * time.Second
+	defaultPullTimeout = 5 * time.Minute
+)
+
+// deliverBlockPuller implements BlockPuller over the Deliver service of the orderers
+type deliverBlockPuller struct {
+	client  comm.GRPCClient
+	signer  crypto.LocalSigner
+	timeout time.Duration
+}
+
+// NewBlockPuller returns a BlockPuller which connects to the Deliver service of the orderers
+// with the given client configuration, and signs its requests with the given signer
+func NewBlockPuller(config comm.ClientConfig, signer crypto.LocalSigner) (BlockPuller, error) {
+	if config.Timeout == 0 {
+		config.Timeout = defaultDialTimeout
+	}
+	client, err := comm.NewGRPCClient(config)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create the block puller client")
+	}
+	return &deliverBlockPuller{client: client, signer: signer, timeout: defaultPullTimeout}, nil
+}
+
+func (p *deliverBlockPuller) PullBlocks(channelID string, endpoints []string, start, end uint64) ([]*cb.Block, error) {
+	if start >= end {
+		return nil, errors.Errorf("cannot pull the empty range of blocks [%d, %d)", start, end)
+	}
+	var failures []string
+	for _, endpoint := range endpoints {
+		blocks, err := p.pullFrom(endpoint, channelID, start, end)
+		if err == nil {
+			return blocks, nil
+		}
+		logger.Warningf("[channel: %s] Failed to pull blocks from %s: %s", channelID, endpoint, err)
+		failures = append(failures, endpoint+": "+err.Error())
+	}
+	return nil, errors.Errorf("failed to pull blocks [%d, %d) of channel %s from the orderers [%s]", start, end, channelID, strings.Join(failures, "; "))
+}
+
+func (p *deliverBlockPuller) pullFrom(endpoint, channelID string, start, end uint64) ([]*cb.Block, error) {
+	conn, err := p.client.NewConnection(endpoint, "")
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to connect")
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	stream, err := ab.NewAtomicBroadcastClient(conn).Deliver(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to open the deliver stream")
+	}
+	seekInfo, err := utils.CreateSignedEnvelope(cb.HeaderType_DELIVER_SEEK_INFO, channelID, p.signer, &ab.SeekInfo{
+		Start:    &ab.SeekPosition{Type: &ab.SeekPosition_Specified{Specified: &ab.SeekSpecified{Number: start}}},
+		Stop:     &ab.SeekPosition{Type: &ab.SeekPosition_Specified{Specified: &ab.SeekSpecified{Number: end - 1}}},
+		Behavior: ab.SeekInfo_FAIL_IF_NOT_READY,
+	}, msgVersion, epoch)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create the seek request")
+	}
+	if err := stream.Send(seekInfo); err != nil {
+		return nil, errors.WithMessage(err, "failed to send the seek request")
+	}
+
+	blocks := make([]*cb.Block, 0, end-start)
+	for uint64(len(blocks)) < end-start {
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to receive blocks")
+		}
+		switch t := resp.Type.(type) {
+		case *ab.DeliverResponse_Block:
+			blocks = append(blocks, t.Block)
+		case *ab.DeliverResponse_Status:
+			return nil, errors.Errorf("received status %s instead of block %d", t.Status, start+uint64(len(blocks)))
+		}
+	}
+	return blocks, nil
+}
+
+// verifyBlockChain checks that the blocks are numbered from 0, and that each of them is chained
+// to the previous one by its previous hash, so that they are all as trusted as the last one
+func verifyBlockChain(blocks []*cb.Block) error {
+	for i, block := range blocks {
+		if block == nil || block.Header == nil || block.Data == nil {
+			return errors.Errorf("block %d is missing its header or data", i)
+		}
+		if block.Header.Number != uint64(i) {
+			return errors.Errorf("expected block %d but got block %d", i, block.Header.Number)
+		}
+		if !bytes.Equal(block.Header.DataHash, block.Data.Hash()) {
+			return errors.Errorf("the data hash of block %d does not match its data", i)
+		}
+		if i > 0 && !bytes.Equal(block.Header.PreviousHash, blocks[i-1].Header.Hash()) {
+			return errors.Errorf("the previous hash of block %d does not match the hash of block %d", i, i-1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package multichannel
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/core/comm"
+	cb "github.com/hyperledger/fabric/protos/common"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockDeliverServer serves the blocks it holds through Deliver, as if it had no block after them
+type mockDeliverServer struct {
+	blocks []*cb.Block
+}
+
+func (mds *mockDeliverServer) Broadcast(srv ab.AtomicBroadcast_BroadcastServer) error {
+	return errors.New("not implemented")
+}
+
+func (mds *mockDeliverServer) Deliver(srv ab.AtomicBroadcast_DeliverServer) error {
+	env, err := srv.Recv()
+	if err != nil {
+		return err
+	}
+	seekInfo := &ab.SeekInfo{}
+	if _, err := utils.UnmarshalEnvelopeOfType(env, cb.HeaderType_DELIVER_SEEK_INFO, seekInfo); err != nil {
+		return err
+	}
+	start, stop := seekInfo.Start.GetSpecified().Number, seekInfo.Stop.GetSpecified().Number
+	if stop >= uint64(len(mds.blocks)) {
+		return srv.Send(&ab.DeliverResponse{Type: &ab.DeliverResponse_Status{Status: cb.Status_NOT_FOUND}})
+	}
+	for _, block := range mds.blocks[start : stop+1] {
+		if err := srv.Send(&ab.DeliverResponse{Type: &ab.DeliverResponse_Block{Block: block}}); err != nil {
+			return err
+		}
+	}
+	return srv.Send(&ab.DeliverResponse{Type: &ab.DeliverResponse_Status{Status: cb.Status_SUCCESS}})
+}
+
+func startDeliverServer(t *testing.T, blocks []*cb.Block) (string, func()) {
+	srv, err := comm.NewGRPCServer("127.0.0.1:0", comm.ServerConfig{})
+	assert.NoError(t, err)
+	ab.RegisterAtomicBroadcastServer(srv.Server(), &mockDeliverServer{blocks: blocks})
+	go srv.Start()
+	return srv.Address(), srv.Stop
+}
+
+// chainedBlocks returns blocks numbered from 0, each chained to the previous one
+func chainedBlocks(n int) []*cb.Block {
+	var blocks []*cb.Block
+	var previousHash []byte
+	for i := 0; i < n; i++ {
+		block := cb.NewBlock(uint64(i), previousHash)
+		block.Data.Data = [][]byte{[]byte(fmt.Sprintf("block %d", i))}
+		block.Header.DataHash = block.Data.Hash()
+		previousHash = block.Header.Hash()
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+func TestBlockPuller(t *testing.T) {
+	blocks := chainedBlocks(3)
+	complete, stopComplete := startDeliverServer(t, blocks)
+	defer stopComplete()
+	lagging, stopLagging := startDeliverServer(t, blocks[:1])
+	defer stopLagging()
+
+	puller, err := NewBlockPuller(comm.ClientConfig{Timeout: time.Second}, mockCrypto())
+	assert.NoError(t, err)
+
+	// The orderers which do not serve all the blocks are skipped
+	pulled, err := puller.PullBlocks("foo", []string{lagging, complete}, 0, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, blocks[:2], pulled)
+	pulled, err = puller.PullBlocks("foo", []string{complete}, 1, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, blocks[1:3], pulled)
+
+	_, err = puller.PullBlocks("foo", []string{lagging}, 0, 2)
+	assert.EqualError(t, err, "failed to pull blocks [0, 2) of channel foo from the orderers ["+lagging+": received status NOT_FOUND instead of block 0]")
+	_, err = puller.PullBlocks("foo", []string{complete}, 2, 2)
+	assert.EqualError(t, err, "cannot pull the empty range of blocks [2, 2)")
+}
+
+func TestVerifyBlockChain(t *testing.T) {
+	blocks := chainedBlocks(3)
+	assert.NoError(t, verifyBlockChain(blocks))
+	assert.EqualError(t, verifyBlockChain(blocks[1:]), "expected block 0 but got block 1")
+	assert.EqualError(t, verifyBlockChain([]*cb.Block{blocks[0], {Header: blocks[1].Header}}), "block 1 is missing its header or data")
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/hyperledger/fabric/common/crypto"
 	"github.com/hyperledger/fabric/common/ledger/blockledger"
 	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/orderer/common/msgprocessor"
 	cb "github.com/hyperledger/fabric/protos/common"
 	"github.com/hyperledger/fabric/protos/utils"
 
@@ -35,6 +36,7 @@ type blockWriterSupport interface {
 type BlockWriter struct {
 	support            blockWriterSupport
 	registrar          *Registrar
+	txIDIndex          *msgprocessor.TxIDIndex
 	lastConfigBlockNum uint64
 	lastConfigSeq      uint64
 	lastBlock          *cb.Block
@@ -47,6 +49,7 @@ func newBlockWriter(lastBlock *cb.Block, r *Registrar, support blockWriterSuppor
 		lastConfigSeq: support.Sequence(),
 		lastBlock:     lastBlock,
 		registrar:     r,
+		txIDIndex:     r.txIDIndex,
 	}
 
 	// If this is the genesis block, the lastconfig field may be empty, and, the last config block is necessarily block 0
@@ -64,9 +67,15 @@ func newBlockWriter(lastBlock *cb.Block, r *Registrar, support blockWriterSuppor
 }
 
 // CreateNextBlock creates a new block with the next block number, and the given contents.
+// When the replays of transactions are rejected, the duplicates of a transaction within
+// the contents are left out, as they may have been broadcast through different orderers.
 func (bw *BlockWriter) CreateNextBlock(messages []*cb.Envelope) *cb.Block {
 	previousBlockHash := bw.lastBlock.Header.Hash()
 
+	if bw.txIDIndex != nil {
+		messages = msgprocessor.DedupeTxIDs(messages)
+	}
+
 	data := &cb.BlockData{
 		Data: make([][]byte, len(messages)),
 	}
@@ -150,6 +159,12 @@ func (bw *BlockWriter) WriteBlock(block *cb.Block, encodedMetadataValue []byte)
 	bw.committingBlock.Lock()
 	bw.lastBlock = block
 
+	// The transactions are remembered as soon as they are ordered, so that their replays
+	// are rejected even before the block is committed
+	if bw.txIDIndex != nil {
+		bw.txIDIndex.AddBlock(bw.support.ChainID(), block)
+	}
+
 	go func() {
 		defer bw.committingBlock.Unlock()
 		bw.commitBlock(encodedMetadataValue)
@@ -174,6 +189,11 @@ func (bw *BlockWriter) commitBlock(encodedMetadataValue []byte) {
 }
 
 func (bw *BlockWriter) addBlockSignature(block *cb.Block) {
+	// The BFT consenter sets the signatures of a quorum of consenters itself, which are kept as they are
+	if md, err := utils.GetMetadataFromBlock(block, cb.BlockMetadataIndex_SIGNATURES); err == nil && len(md.Signatures) > 0 {
+		return
+	}
+
 	blockSignature := &cb.MetadataSignature{
 		SignatureHeader: utils.MarshalOrPanic(utils.NewSignatureHeaderOrPanic(bw.support)),
 	}
@@ -70,6 +70,23 @@ func TestBlockSignature(t *testing.T) {
 	assert.NotNil(t, md.Signatures, "Should have signature")
 }
 
+func TestBlockSignaturesSetByConsenter(t *testing.T) {
+	bw := &BlockWriter{
+		support: &mockBlockWriterSupport{
+			LocalSigner: mockCrypto(),
+		},
+	}
+
+	block := cb.NewBlock(7, []byte("foo"))
+	quorumSignatures := utils.MarshalOrPanic(&cb.Metadata{
+		Signatures: []*cb.MetadataSignature{{Signature: []byte("sig1")}, {Signature: []byte("sig2")}},
+	})
+	block.Metadata.Metadata[cb.BlockMetadataIndex_SIGNATURES] = quorumSignatures
+	bw.addBlockSignature(block)
+
+	assert.Equal(t, quorumSignatures, block.Metadata.Metadata[cb.BlockMetadataIndex_SIGNATURES], "Signatures should be kept")
+}
+
 func TestBlockLastConfig(t *testing.T) {
 	lastConfigSeq := uint64(6)
 	newConfigSeq := lastConfigSeq + 1
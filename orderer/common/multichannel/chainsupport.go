@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package multichannel
 
 import (
+	"fmt"
+
 	"github.com/hyperledger/fabric/common/crypto"
 	"github.com/hyperledger/fabric/common/ledger/blockledger"
 	"github.com/hyperledger/fabric/orderer/common/blockcutter"
@@ -24,7 +26,8 @@ type ChainSupport struct {
 	msgprocessor.Processor
 	*BlockWriter
 	consensus.Chain
-	cutter blockcutter.Receiver
+	cutter      blockcutter.Receiver
+	rateLimiter *msgprocessor.RateLimiter
 	crypto.LocalSigner
 }
 
@@ -33,7 +36,7 @@ func newChainSupport(
 	ledgerResources *ledgerResources,
 	consenters map[string]consensus.Consenter,
 	signer crypto.LocalSigner,
-) *ChainSupport {
+) (*ChainSupport, error) {
 	// Read in the last block and metadata for the channel
 	lastBlock := blockledger.GetBlock(ledgerResources, ledgerResources.Height()-1)
 
@@ -41,7 +44,7 @@ func newChainSupport(
 	// Assuming a block created with cb.NewBlock(), this should not
 	// error even if the orderer metadata is an empty byte slice
 	if err != nil {
-		logger.Fatalf("[channel: %s] Error extracting orderer metadata: %s", ledgerResources.ConfigtxValidator().ChainID(), err)
+		return nil, errors.WithMessage(err, fmt.Sprintf("[channel: %s] error extracting orderer metadata", ledgerResources.ConfigtxValidator().ChainID()))
 	}
 
 	// Construct limited support needed as a parameter for additional support
@@ -51,8 +54,16 @@ func newChainSupport(
 		cutter:          blockcutter.NewReceiverImpl(ledgerResources.SharedConfig()),
 	}
 
+	// Load the recently ordered transaction IDs, which are then kept up to date by the block writer
+	if registrar.txIDIndex != nil {
+		if err := registrar.txIDIndex.Load(cs.ChainID(), ledgerResources); err != nil {
+			logger.Warningf("[channel: %s] Could not load all the recent transaction IDs, replays of earlier transactions may not be rejected: %s", cs.ChainID(), err)
+		}
+	}
+
 	// Set up the msgprocessor
 	cs.Processor = msgprocessor.NewStandardChannel(cs, msgprocessor.CreateStandardChannelFilters(cs))
+	cs.rateLimiter = msgprocessor.NewRateLimiter(cs.ChainID(), cs)
 
 	// Set up the block writer
 	cs.BlockWriter = newBlockWriter(lastBlock, registrar, cs)
@@ -61,16 +72,30 @@ func newChainSupport(
 	consenterType := ledgerResources.SharedConfig().ConsensusType()
 	consenter, ok := consenters[consenterType]
 	if !ok {
-		logger.Panicf("Error retrieving consenter of type: %s", consenterType)
+		return nil, errors.Errorf("[channel: %s] error retrieving consenter of type: %s", cs.ChainID(), consenterType)
 	}
 
 	cs.Chain, err = consenter.HandleChain(cs, metadata)
 	if err != nil {
-		logger.Panicf("[channel: %s] Error creating consenter: %s", cs.ChainID(), err)
+		return nil, errors.WithMessage(err, fmt.Sprintf("[channel: %s] error creating consenter", cs.ChainID()))
 	}
 
 	logger.Debugf("[channel: %s] Done creating channel support resources", cs.ChainID())
 
+	return cs, nil
+}
+
+// newChainSupportOrPanic invokes newChainSupport and panics if an error is returned
+func newChainSupportOrPanic(
+	registrar *Registrar,
+	ledgerResources *ledgerResources,
+	consenters map[string]consensus.Consenter,
+	signer crypto.LocalSigner,
+) *ChainSupport {
+	cs, err := newChainSupport(registrar, ledgerResources, consenters, signer)
+	if err != nil {
+		logger.Panic(err)
+	}
 	return cs
 }
 
@@ -92,6 +117,12 @@ func (cs *ChainSupport) BlockCutter() blockcutter.Receiver {
 	return cs.cutter
 }
 
+// RateLimiter returns the filter applying the rate limits of the channel to the
+// messages broadcast to it.
+func (cs *ChainSupport) RateLimiter() msgprocessor.Rule {
+	return cs.rateLimiter
+}
+
 // Validate passes through to the underlying configtx.Validator
 func (cs *ChainSupport) Validate(configEnv *cb.ConfigEnvelope) error {
 	return cs.ConfigtxValidator().Validate(configEnv)
@@ -126,6 +157,14 @@ func (cs *ChainSupport) ConfigProto() *cb.Config {
 	return cs.ConfigtxValidator().ConfigProto()
 }
 
+// Block returns the block with the given number, or nil if such a block does not exist.
+func (cs *ChainSupport) Block(number uint64) *cb.Block {
+	if number >= cs.Height() {
+		return nil
+	}
+	return blockledger.GetBlock(cs.Reader(), number)
+}
+
 // Sequence passes through to the underlying configtx.Validator
 func (cs *ChainSupport) Sequence() uint64 {
 	return cs.ConfigtxValidator().Sequence()
@@ -0,0 +1,280 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package multichannel
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hyperledger/fabric/common/channelconfig"
+	"github.com/hyperledger/fabric/common/configtx"
+	"github.com/hyperledger/fabric/orderer/consensus"
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrChannelNotExist is returned when the channel is not served by the orderer
+	ErrChannelNotExist = errors.New("channel does not exist")
+	// ErrChannelAlreadyExists is returned when a channel that is already served by the orderer is joined
+	ErrChannelAlreadyExists = errors.New("channel already exists")
+	// ErrSystemChannelExists is returned when a channel is joined or removed through the channel participation
+	// API while the orderer has a system channel, through which the channels are created instead
+	ErrSystemChannelExists = errors.New("the orderer has a system channel, the channels are managed through the system channel")
+)
+
+// ChannelInfo describes a channel served by the orderer
+type ChannelInfo struct {
+	// Name is the ID of the channel
+	Name string `json:"name"`
+	// ConsensusType is the consensus type of the channel, e.g. solo, kafka, or raft
+	ConsensusType string `json:"consensusType"`
+	// Height is the number of blocks in the ledger of the channel on this orderer
+	Height uint64 `json:"height"`
+}
+
+// ChannelList lists the channels served by the orderer. The system channel, if the orderer has one,
+// is listed apart from the other channels
+type ChannelList struct {
+	SystemChannel *ChannelInfo  `json:"systemChannel"`
+	Channels      []ChannelInfo `json:"channels"`
+}
+
+// ChannelList returns the channels served by the orderer, sorted by name
+func (r *Registrar) ChannelList() ChannelList {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	list := ChannelList{Channels: []ChannelInfo{}}
+	for chainID, cs := range r.chains {
+		info := channelInfo(cs)
+		if chainID == r.systemChannelID {
+			list.SystemChannel = &info
+			continue
+		}
+		list.Channels = append(list.Channels, info)
+	}
+	sort.Slice(list.Channels, func(i, j int) bool { return list.Channels[i].Name < list.Channels[j].Name })
+	return list
+}
+
+// ChannelInfo returns the description of the given channel, or ErrChannelNotExist
+func (r *Registrar) ChannelInfo(channelID string) (ChannelInfo, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	cs, ok := r.chains[channelID]
+	if !ok {
+		return ChannelInfo{}, ErrChannelNotExist
+	}
+	return channelInfo(cs), nil
+}
+
+// JoinChannel makes the orderer serve the given channel, starting from the given config block. If the config block
+// is not the genesis block of the channel, the blocks preceding it are pulled from the orderers of the channel and
+// must chain up to it; this requires the registrar to have a BlockPuller. The channel is not created through the
+// system channel and hence, joining channels is allowed only when the orderer has no system channel. The consenter
+// of the channel decides whether this orderer takes part in the channel (e.g., the raft consenter fails if this
+// orderer is not in the consenter set), in which case the ledger created for the channel is removed again
+func (r *Registrar) JoinChannel(channelID string, configBlock *cb.Block) (ChannelInfo, error) {
+	r.lock.RLock()
+	err := r.checkJoinable(channelID)
+	r.lock.RUnlock()
+	if err != nil {
+		return ChannelInfo{}, err
+	}
+	configTx, bundle, err := r.validateJoinBlock(channelID, configBlock)
+	if err != nil {
+		return ChannelInfo{}, err
+	}
+	// Pulling the preceding blocks may take a while, so it is done without holding the lock
+	blocks, err := r.pullBlocks(channelID, configBlock, bundle)
+	if err != nil {
+		return ChannelInfo{}, err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.checkJoinable(channelID); err != nil {
+		return ChannelInfo{}, err
+	}
+	ledger, err := r.ledgerFactory.GetOrCreate(channelID)
+	if err != nil {
+		return ChannelInfo{}, errors.WithMessage(err, fmt.Sprintf("failed to create the ledger of channel %s", channelID))
+	}
+	if ledger.Height() != 0 {
+		return ChannelInfo{}, errors.Errorf("the ledger of channel %s already contains %d blocks", channelID, ledger.Height())
+	}
+	for _, block := range blocks {
+		if err := ledger.Append(block); err != nil {
+			r.removeLedger(channelID)
+			return ChannelInfo{}, errors.WithMessage(err, fmt.Sprintf("failed to append block %d to the ledger of channel %s", block.Header.Number, channelID))
+		}
+	}
+
+	cs, err := newChainSupport(r, r.newLedgerResources(configTx), r.consenters, r.signer)
+	if err != nil {
+		r.removeLedger(channelID)
+		return ChannelInfo{}, err
+	}
+	logger.Infof("Joined channel %s with config block %d of hash %x and orderer type %s",
+		channelID, configBlock.Header.Number, configBlock.Header.Hash(), cs.SharedConfig().ConsensusType())
+	r.chains[channelID] = cs
+	cs.start()
+	return channelInfo(cs), nil
+}
+
+// checkJoinable checks that the given channel may be joined. It must be called with the lock held
+func (r *Registrar) checkJoinable(channelID string) error {
+	if r.systemChannel != nil {
+		return ErrSystemChannelExists
+	}
+	if _, ok := r.chains[channelID]; ok {
+		return ErrChannelAlreadyExists
+	}
+	return nil
+}
+
+// RemoveChannel halts the given channel and removes its ledger from the orderer. Removing channels is allowed
+// only when the orderer has no system channel
+func (r *Registrar) RemoveChannel(channelID string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.systemChannel != nil {
+		return ErrSystemChannelExists
+	}
+	cs, ok := r.chains[channelID]
+	if !ok {
+		return ErrChannelNotExist
+	}
+
+	cs.Halt()
+	delete(r.chains, channelID)
+	if r.txIDIndex != nil {
+		r.txIDIndex.Remove(channelID)
+	}
+	if remover, ok := r.consenters[cs.SharedConfig().ConsensusType()].(consensus.ChainRemover); ok {
+		if err := remover.RemoveChain(channelID); err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("failed to remove the consenter state of channel %s", channelID))
+		}
+	}
+	if err := r.ledgerFactory.Remove(channelID); err != nil {
+		return errors.WithMessage(err, fmt.Sprintf("failed to remove the ledger of channel %s", channelID))
+	}
+	logger.Infof("Removed channel %s", channelID)
+	return nil
+}
+
+// validateJoinBlock checks that the block is a config block of the given application channel
+// and that the channel config is supported by this orderer, and returns the config transaction
+// along with the bundle of the config
+func (r *Registrar) validateJoinBlock(channelID string, configBlock *cb.Block) (*cb.Envelope, *channelconfig.Bundle, error) {
+	if configBlock == nil || configBlock.Header == nil || configBlock.Data == nil {
+		return nil, nil, errors.New("the config block is missing its header or data")
+	}
+	// The ledger starts off the last config of its last block, which only the genesis block may omit
+	if configBlock.Header.Number != 0 {
+		index, err := utils.GetLastConfigIndexFromBlock(configBlock)
+		if err != nil {
+			return nil, nil, errors.WithMessage(err, "failed to extract the last config index of the config block")
+		}
+		if index != configBlock.Header.Number {
+			return nil, nil, errors.Errorf("the block is not a config block, its last config is block %d", index)
+		}
+	}
+	if len(configBlock.Data.Data) != 1 {
+		return nil, nil, errors.Errorf("the config block should contain exactly one transaction, but it contains %d", len(configBlock.Data.Data))
+	}
+	configTx, err := utils.ExtractEnvelope(configBlock, 0)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed to extract the config transaction")
+	}
+	payload, err := utils.UnmarshalPayload(configTx.Payload)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed to unmarshal the payload of the config transaction")
+	}
+	if payload.Header == nil {
+		return nil, nil, errors.New("the config transaction is missing its header")
+	}
+	chdr, err := utils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed to unmarshal the channel header of the config transaction")
+	}
+	if chdr.Type != int32(cb.HeaderType_CONFIG) {
+		return nil, nil, errors.Errorf("the block is not a config block, its transaction is of type %s", cb.HeaderType(chdr.Type))
+	}
+	if chdr.ChannelId != channelID {
+		return nil, nil, errors.Errorf("the config block is of channel %s, not of channel %s", chdr.ChannelId, channelID)
+	}
+	configEnvelope, err := configtx.UnmarshalConfigEnvelope(payload.Data)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed to unmarshal the config envelope")
+	}
+	bundle, err := channelconfig.NewBundle(channelID, configEnvelope.Config)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed to create the channel config bundle")
+	}
+	if err := checkResources(bundle); err != nil {
+		return nil, nil, err
+	}
+	if _, ok := bundle.ConsortiumsConfig(); ok {
+		return nil, nil, errors.New("the config block is of a system channel, which cannot be joined")
+	}
+	// checkResources makes sure the orderer config is present
+	oc, _ := bundle.OrdererConfig()
+	if _, ok := r.consenters[oc.ConsensusType()]; !ok {
+		return nil, nil, errors.Errorf("the consensus type %s of the channel is not supported", oc.ConsensusType())
+	}
+	return configTx, bundle, nil
+}
+
+// pullBlocks returns the blocks the ledger of a channel joined from the given config block starts with: the
+// blocks preceding the config block, pulled from the orderers of the channel and verified to chain up to it,
+// followed by the config block
+func (r *Registrar) pullBlocks(channelID string, configBlock *cb.Block, bundle *channelconfig.Bundle) ([]*cb.Block, error) {
+	number := configBlock.Header.Number
+	if number == 0 {
+		return []*cb.Block{configBlock}, nil
+	}
+	if r.blockPuller == nil {
+		return nil, errors.Errorf("the config block should be the genesis block of the channel, but its number is %d, "+
+			"and this orderer is not configured to pull the preceding blocks from the other orderers", number)
+	}
+	endpoints := bundle.ChannelConfig().OrdererAddresses()
+	if len(endpoints) == 0 {
+		return nil, errors.Errorf("the config block has no orderer addresses to pull the %d preceding blocks from", number)
+	}
+	logger.Infof("[channel: %s] Pulling the %d blocks preceding config block %d from %v", channelID, number, number, endpoints)
+	blocks, err := r.blockPuller.PullBlocks(channelID, endpoints, 0, number)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("failed to pull the blocks preceding config block %d", number))
+	}
+	blocks = append(blocks, configBlock)
+	if err := verifyBlockChain(blocks); err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("the blocks pulled do not chain up to config block %d", number))
+	}
+	return blocks, nil
+}
+
+// removeLedger removes the ledger of a channel that failed to be joined
+func (r *Registrar) removeLedger(channelID string) {
+	if err := r.ledgerFactory.Remove(channelID); err != nil {
+		logger.Errorf("Failed to remove the ledger of channel %s: %s", channelID, err)
+	}
+}
+
+func channelInfo(cs *ChainSupport) ChannelInfo {
+	return ChannelInfo{
+		Name:          cs.ChainID(),
+		ConsensusType: cs.SharedConfig().ConsensusType(),
+		Height:        cs.Height(),
+	}
+}
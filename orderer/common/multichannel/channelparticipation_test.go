@@ -0,0 +1,212 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package multichannel
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/ledger/blockledger"
+	ramledger "github.com/hyperledger/fabric/common/ledger/blockledger/ram"
+	"github.com/hyperledger/fabric/common/tools/configtxgen/encoder"
+	genesisconfig "github.com/hyperledger/fabric/common/tools/configtxgen/localconfig"
+	"github.com/hyperledger/fabric/orderer/consensus"
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingConsenter struct {
+}
+
+func (fc *failingConsenter) HandleChain(support consensus.ConsenterSupport, metadata *cb.Metadata) (consensus.Chain, error) {
+	return nil, errors.New("not a consenter of the channel")
+}
+
+func newApplicationGenesisBlock(channelID string) *cb.Block {
+	return encoder.New(genesisconfig.Load("SampleNoConsortium")).GenesisBlockForChannel(channelID)
+}
+
+// newChannelBlocks returns the blocks of a channel up to a config block other than its genesis block
+func newChannelBlocks(channelID string) []*cb.Block {
+	rl, _ := ramledger.New(10).GetOrCreate(channelID)
+	genesis := newApplicationGenesisBlock(channelID)
+	rl.Append(genesis)
+	rl.Append(blockledger.CreateNextBlock(rl, []*cb.Envelope{makeNormalTx(channelID, 1)}))
+	configBlock := blockledger.CreateNextBlock(rl, []*cb.Envelope{utils.ExtractEnvelopeOrPanic(genesis, 0)})
+	setLastConfig(configBlock, configBlock.Header.Number)
+	return []*cb.Block{genesis, blockledger.GetBlock(rl, 1), configBlock}
+}
+
+func setLastConfig(block *cb.Block, index uint64) {
+	block.Metadata.Metadata[cb.BlockMetadataIndex_LAST_CONFIG] = utils.MarshalOrPanic(&cb.Metadata{
+		Value: utils.MarshalOrPanic(&cb.LastConfig{Index: index}),
+	})
+}
+
+type mockBlockPuller struct {
+	blocks    []*cb.Block
+	err       error
+	endpoints []string
+}
+
+func (mbp *mockBlockPuller) PullBlocks(channelID string, endpoints []string, start, end uint64) ([]*cb.Block, error) {
+	mbp.endpoints = endpoints
+	if mbp.err != nil {
+		return nil, mbp.err
+	}
+	return mbp.blocks[start:end], nil
+}
+
+func TestJoinAndRemoveChannel(t *testing.T) {
+	lf := ramledger.New(10)
+	consenters := map[string]consensus.Consenter{conf.Orderer.OrdererType: &mockConsenter{}}
+	manager := NewRegistrar(Config{LedgerFactory: lf, Consenters: consenters, Signer: mockCrypto()})
+	assert.Equal(t, ChannelList{Channels: []ChannelInfo{}}, manager.ChannelList())
+
+	for _, channelID := range []string{"foo", "bar"} {
+		info, err := manager.JoinChannel(channelID, newApplicationGenesisBlock(channelID))
+		assert.NoError(t, err)
+		assert.Equal(t, ChannelInfo{Name: channelID, ConsensusType: conf.Orderer.OrdererType, Height: 1}, info)
+	}
+	_, err := manager.JoinChannel("foo", newApplicationGenesisBlock("foo"))
+	assert.Equal(t, ErrChannelAlreadyExists, err)
+
+	assert.Equal(t, ChannelList{Channels: []ChannelInfo{
+		{Name: "bar", ConsensusType: conf.Orderer.OrdererType, Height: 1},
+		{Name: "foo", ConsensusType: conf.Orderer.OrdererType, Height: 1},
+	}}, manager.ChannelList())
+	info, err := manager.ChannelInfo("foo")
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", info.Name)
+	_, err = manager.ChannelInfo("baz")
+	assert.Equal(t, ErrChannelNotExist, err)
+	_, ok := manager.GetChain("foo")
+	assert.True(t, ok, "Should have gotten the joined chain")
+
+	// the joined channels are loaded when the orderer restarts
+	manager = NewRegistrar(Config{LedgerFactory: lf, Consenters: consenters, Signer: mockCrypto()})
+	assert.Equal(t, 2, manager.ChannelsCount())
+
+	assert.NoError(t, manager.RemoveChannel("foo"))
+	assert.Equal(t, ErrChannelNotExist, manager.RemoveChannel("foo"))
+	_, ok = manager.GetChain("foo")
+	assert.False(t, ok, "Should not have gotten the removed chain")
+	assert.Equal(t, []string{"bar"}, lf.ChainIDs())
+
+	// a removed channel can be joined again
+	_, err = manager.JoinChannel("foo", newApplicationGenesisBlock("foo"))
+	assert.NoError(t, err)
+}
+
+func TestJoinChannelErrors(t *testing.T) {
+	lf := ramledger.New(10)
+	consenters := map[string]consensus.Consenter{conf.Orderer.OrdererType: &mockConsenter{}}
+	manager := NewRegistrar(Config{LedgerFactory: lf, Consenters: consenters, Signer: mockCrypto()})
+
+	block := newApplicationGenesisBlock("foo")
+	_, err := manager.JoinChannel("bar", block)
+	assert.EqualError(t, err, "the config block is of channel foo, not of channel bar")
+
+	laterBlock := proto.Clone(block).(*cb.Block)
+	laterBlock.Header.Number = 3
+	_, err = manager.JoinChannel("foo", laterBlock)
+	assert.EqualError(t, err, "the block is not a config block, its last config is block 0")
+	laterBlock.Metadata.Metadata[cb.BlockMetadataIndex_LAST_CONFIG] = []byte("garbage")
+	_, err = manager.JoinChannel("foo", laterBlock)
+	assert.Contains(t, err.Error(), "failed to extract the last config index of the config block")
+
+	_, err = manager.JoinChannel("foo", &cb.Block{Header: &cb.BlockHeader{}, Data: &cb.BlockData{Data: [][]byte{}}})
+	assert.EqualError(t, err, "the config block should contain exactly one transaction, but it contains 0")
+
+	_, err = manager.JoinChannel("foo", &cb.Block{Header: &cb.BlockHeader{}, Data: &cb.BlockData{Data: [][]byte{utils.MarshalOrPanic(makeNormalTx("foo", 0))}}})
+	assert.EqualError(t, err, "the block is not a config block, its transaction is of type ENDORSER_TRANSACTION")
+
+	_, err = manager.JoinChannel(genesisconfig.TestChainID, genesisBlock)
+	assert.EqualError(t, err, "the config block is of a system channel, which cannot be joined")
+
+	manager = NewRegistrar(Config{LedgerFactory: lf, Consenters: map[string]consensus.Consenter{"kafka": &mockConsenter{}}, Signer: mockCrypto()})
+	_, err = manager.JoinChannel("foo", block)
+	assert.EqualError(t, err, "the consensus type solo of the channel is not supported")
+
+	// the ledger is removed when the consenter fails to take part in the channel
+	manager = NewRegistrar(Config{LedgerFactory: lf, Consenters: map[string]consensus.Consenter{conf.Orderer.OrdererType: &failingConsenter{}}, Signer: mockCrypto()})
+	_, err = manager.JoinChannel("foo", block)
+	assert.EqualError(t, err, "[channel: foo] error creating consenter: not a consenter of the channel")
+	assert.Empty(t, lf.ChainIDs())
+	assert.Equal(t, 0, manager.ChannelsCount())
+}
+
+func TestJoinChannelFromLaterConfigBlock(t *testing.T) {
+	blocks := newChannelBlocks("foo")
+	configBlock := blocks[2]
+	consenters := map[string]consensus.Consenter{conf.Orderer.OrdererType: &mockConsenter{}}
+
+	t.Run("NoBlockPuller", func(t *testing.T) {
+		lf := ramledger.New(10)
+		manager := NewRegistrar(Config{LedgerFactory: lf, Consenters: consenters, Signer: mockCrypto()})
+		_, err := manager.JoinChannel("foo", configBlock)
+		assert.EqualError(t, err, "the config block should be the genesis block of the channel, but its number is 2, "+
+			"and this orderer is not configured to pull the preceding blocks from the other orderers")
+		assert.Empty(t, lf.ChainIDs())
+	})
+
+	t.Run("PullFailure", func(t *testing.T) {
+		lf := ramledger.New(10)
+		puller := &mockBlockPuller{err: errors.New("unreachable")}
+		manager := NewRegistrar(Config{LedgerFactory: lf, Consenters: consenters, Signer: mockCrypto(), BlockPuller: puller})
+		_, err := manager.JoinChannel("foo", configBlock)
+		assert.EqualError(t, err, "failed to pull the blocks preceding config block 2: unreachable")
+		assert.Empty(t, lf.ChainIDs())
+	})
+
+	t.Run("TamperedBlock", func(t *testing.T) {
+		lf := ramledger.New(10)
+		tampered := proto.Clone(blocks[1]).(*cb.Block)
+		tampered.Data.Data = [][]byte{utils.MarshalOrPanic(makeNormalTx("foo", 2))}
+		puller := &mockBlockPuller{blocks: []*cb.Block{blocks[0], tampered}}
+		manager := NewRegistrar(Config{LedgerFactory: lf, Consenters: consenters, Signer: mockCrypto(), BlockPuller: puller})
+		_, err := manager.JoinChannel("foo", configBlock)
+		assert.EqualError(t, err, "the blocks pulled do not chain up to config block 2: the data hash of block 1 does not match its data")
+
+		tampered.Header.DataHash = tampered.Data.Hash()
+		_, err = manager.JoinChannel("foo", configBlock)
+		assert.EqualError(t, err, "the blocks pulled do not chain up to config block 2: the previous hash of block 2 does not match the hash of block 1")
+		assert.Empty(t, lf.ChainIDs())
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		lf := ramledger.New(10)
+		puller := &mockBlockPuller{blocks: blocks}
+		manager := NewRegistrar(Config{LedgerFactory: lf, Consenters: consenters, Signer: mockCrypto(), BlockPuller: puller})
+		info, err := manager.JoinChannel("foo", configBlock)
+		assert.NoError(t, err)
+		assert.Equal(t, ChannelInfo{Name: "foo", ConsensusType: conf.Orderer.OrdererType, Height: 3}, info)
+		assert.Equal(t, []string{"127.0.0.1:7050"}, puller.endpoints, "The blocks should be pulled from the orderers of the channel")
+
+		// the joined channel is loaded from its config block when the orderer restarts
+		manager = NewRegistrar(Config{LedgerFactory: lf, Consenters: consenters, Signer: mockCrypto()})
+		info, err = manager.ChannelInfo("foo")
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(3), info.Height)
+	})
+}
+
+func TestChannelParticipationWithSystemChannel(t *testing.T) {
+	lf, _ := NewRAMLedgerAndFactory(10)
+	consenters := map[string]consensus.Consenter{conf.Orderer.OrdererType: &mockConsenter{}}
+	manager := NewRegistrar(Config{LedgerFactory: lf, Consenters: consenters, Signer: mockCrypto()})
+
+	_, err := manager.JoinChannel("foo", newApplicationGenesisBlock("foo"))
+	assert.Equal(t, ErrSystemChannelExists, err)
+	assert.Equal(t, ErrSystemChannelExists, manager.RemoveChannel(genesisconfig.TestChainID))
+	assert.Equal(t, ChannelList{
+		SystemChannel: &ChannelInfo{Name: genesisconfig.TestChainID, ConsensusType: conf.Orderer.OrdererType, Height: 1},
+		Channels:      []ChannelInfo{},
+	}, manager.ChannelList())
+}
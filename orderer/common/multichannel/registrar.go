@@ -11,6 +11,7 @@ package multichannel
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/hyperledger/fabric/common/channelconfig"
 	"github.com/hyperledger/fabric/common/configtx"
@@ -96,6 +97,7 @@ type ledgerResources struct {
 
 // Registrar serves as a point of access and control for the individual channel resources.
 type Registrar struct {
+	lock            sync.RWMutex
 	chains          map[string]*ChainSupport
 	consenters      map[string]consensus.Consenter
 	ledgerFactory   blockledger.Factory
@@ -103,6 +105,8 @@ type Registrar struct {
 	systemChannelID string
 	systemChannel   *ChainSupport
 	templator       msgprocessor.ChannelConfigTemplator
+	txIDIndex       *msgprocessor.TxIDIndex
+	blockPuller     BlockPuller
 	callbacks       []func(bundle *channelconfig.Bundle)
 }
 
@@ -120,15 +124,34 @@ func getConfigTx(reader blockledger.Reader) *cb.Envelope {
 	return utils.ExtractEnvelopeOrPanic(configBlock, 0)
 }
 
-// NewRegistrar produces an instance of a *Registrar.
-func NewRegistrar(ledgerFactory blockledger.Factory, consenters map[string]consensus.Consenter,
-	signer crypto.LocalSigner, callbacks ...func(bundle *channelconfig.Bundle)) *Registrar {
+// Config holds the resources a Registrar is created with.
+type Config struct {
+	// LedgerFactory holds the ledgers of the channels
+	LedgerFactory blockledger.Factory
+	// Consenters are the consenters available to the channels, by consensus type
+	Consenters map[string]consensus.Consenter
+	// Signer signs the blocks written on the channels
+	Signer crypto.LocalSigner
+	// TxIDIndex rejects the replays of recently received or ordered transactions, if not nil
+	TxIDIndex *msgprocessor.TxIDIndex
+	// BlockPuller pulls the blocks preceding the config block of a channel joined from a config block
+	// other than its genesis block. If nil, channels can only be joined from their genesis block
+	BlockPuller BlockPuller
+	// Callbacks are invoked with the bundles of the channels whenever their config changes
+	Callbacks []func(bundle *channelconfig.Bundle)
+}
+
+// NewRegistrar produces an instance of a *Registrar out of the given config.
+func NewRegistrar(config Config) *Registrar {
+	ledgerFactory, consenters, signer := config.LedgerFactory, config.Consenters, config.Signer
 	r := &Registrar{
 		chains:        make(map[string]*ChainSupport),
 		ledgerFactory: ledgerFactory,
 		consenters:    consenters,
 		signer:        signer,
-		callbacks:     callbacks,
+		txIDIndex:     config.TxIDIndex,
+		blockPuller:   config.BlockPuller,
+		callbacks:     config.Callbacks,
 	}
 
 	existingChains := ledgerFactory.ChainIDs()
@@ -148,7 +171,7 @@ func NewRegistrar(ledgerFactory blockledger.Factory, consenters map[string]conse
 			if r.systemChannelID != "" {
 				logger.Panicf("There appear to be two system chains %s and %s", r.systemChannelID, chainID)
 			}
-			chain := newChainSupport(
+			chain := newChainSupportOrPanic(
 				r,
 				ledgerResources,
 				consenters,
@@ -175,7 +198,7 @@ func NewRegistrar(ledgerFactory blockledger.Factory, consenters map[string]conse
 			defer chain.start()
 		} else {
 			logger.Debugf("Starting chain: %s", chainID)
-			chain := newChainSupport(
+			chain := newChainSupportOrPanic(
 				r,
 				ledgerResources,
 				consenters,
@@ -187,7 +210,7 @@ func NewRegistrar(ledgerFactory blockledger.Factory, consenters map[string]conse
 	}
 
 	if r.systemChannelID == "" {
-		logger.Panicf("No system chain found.  If bootstrapping, does your system channel contain a consortiums group definition?")
+		logger.Infof("No system channel found, the channels are joined and removed through the channel participation API")
 	}
 
 	return r
@@ -198,6 +221,12 @@ func (r *Registrar) SystemChannelID() string {
 	return r.systemChannelID
 }
 
+// TxIDIndex returns the index of the transactions recently received or ordered
+// on the channels, which is nil if their replays are not rejected.
+func (r *Registrar) TxIDIndex() *msgprocessor.TxIDIndex {
+	return r.txIDIndex
+}
+
 // BroadcastChannelSupport returns the message channel header, whether the message is a config update
 // and the channel resources for a message or an error if the message is not a message which can
 // be processed directly (like CONFIG and ORDERER_TRANSACTION messages)
@@ -207,8 +236,13 @@ func (r *Registrar) BroadcastChannelSupport(msg *cb.Envelope) (*cb.ChannelHeader
 		return nil, false, nil, fmt.Errorf("could not determine channel ID: %s", err)
 	}
 
+	r.lock.RLock()
 	cs, ok := r.chains[chdr.ChannelId]
+	r.lock.RUnlock()
 	if !ok {
+		if r.systemChannel == nil {
+			return nil, false, nil, errors.Errorf("channel %s does not exist and it cannot be created through this orderer, as it has no system channel", chdr.ChannelId)
+		}
 		cs = r.systemChannel
 	}
 
@@ -224,6 +258,8 @@ func (r *Registrar) BroadcastChannelSupport(msg *cb.Envelope) (*cb.ChannelHeader
 
 // GetChain retrieves the chain support for a chain (and whether it exists)
 func (r *Registrar) GetChain(chainID string) (*ChainSupport, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
 	cs, ok := r.chains[chainID]
 	return cs, ok
 }
@@ -272,25 +308,21 @@ func (r *Registrar) newChain(configtx *cb.Envelope) {
 	ledgerResources := r.newLedgerResources(configtx)
 	ledgerResources.Append(blockledger.CreateNextBlock(ledgerResources, []*cb.Envelope{configtx}))
 
-	// Copy the map to allow concurrent reads from broadcast/deliver while the new chainSupport is
-	newChains := make(map[string]*ChainSupport)
-	for key, value := range r.chains {
-		newChains[key] = value
-	}
-
-	cs := newChainSupport(r, ledgerResources, r.consenters, r.signer)
+	cs := newChainSupportOrPanic(r, ledgerResources, r.consenters, r.signer)
 	chainID := ledgerResources.ConfigtxValidator().ChainID()
 
 	logger.Infof("Created and starting new chain %s", chainID)
 
-	newChains[string(chainID)] = cs
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.chains[chainID] = cs
 	cs.start()
-
-	r.chains = newChains
 }
 
 // ChannelsCount returns the count of the current total number of channels.
 func (r *Registrar) ChannelsCount() int {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
 	return len(r.chains)
 }
 
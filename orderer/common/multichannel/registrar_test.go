@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package multichannel
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
@@ -22,6 +23,7 @@ import (
 	"github.com/hyperledger/fabric/common/tools/configtxgen/encoder"
 	genesisconfig "github.com/hyperledger/fabric/common/tools/configtxgen/localconfig"
 	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/orderer/common/msgprocessor"
 	"github.com/hyperledger/fabric/orderer/consensus"
 	cb "github.com/hyperledger/fabric/protos/common"
 	ab "github.com/hyperledger/fabric/protos/orderer"
@@ -103,14 +105,22 @@ func TestGetConfigTxFailure(t *testing.T) {
 	assert.Panics(t, func() { getConfigTx(rl) }, "Should have panicked because of bad last config metadata")
 }
 
-// This test checks to make sure the orderer refuses to come up if it cannot find a system channel
+// This test checks that the orderer comes up without a system channel, but refuses channel creation requests
 func TestNoSystemChain(t *testing.T) {
 	lf := ramledger.New(10)
 
 	consenters := make(map[string]consensus.Consenter)
 	consenters[conf.Orderer.OrdererType] = &mockConsenter{}
 
-	assert.Panics(t, func() { NewRegistrar(lf, consenters, mockCrypto()) }, "Should have panicked when starting without a system chain")
+	var manager *Registrar
+	assert.NotPanics(t, func() {
+		manager = NewRegistrar(Config{LedgerFactory: lf, Consenters: consenters, Signer: mockCrypto()})
+	}, "Should have come up without a system chain")
+	assert.Equal(t, "", manager.SystemChannelID())
+	assert.Equal(t, 0, manager.ChannelsCount())
+
+	_, _, _, err := manager.BroadcastChannelSupport(makeConfigTx("foo", 1))
+	assert.EqualError(t, err, "channel foo does not exist and it cannot be created through this orderer, as it has no system channel")
 }
 
 // This test checks to make sure that the orderer refuses to come up if there are multiple system channels
@@ -128,7 +138,7 @@ func TestMultiSystemChannel(t *testing.T) {
 	consenters := make(map[string]consensus.Consenter)
 	consenters[conf.Orderer.OrdererType] = &mockConsenter{}
 
-	assert.Panics(t, func() { NewRegistrar(lf, consenters, mockCrypto()) }, "Two system channels should have caused panic")
+	assert.Panics(t, func() { NewRegistrar(Config{LedgerFactory: lf, Consenters: consenters, Signer: mockCrypto()}) }, "Two system channels should have caused panic")
 }
 
 // This test essentially brings the entire system up and is ultimately what main.go will replicate
@@ -138,7 +148,7 @@ func TestManagerImpl(t *testing.T) {
 	consenters := make(map[string]consensus.Consenter)
 	consenters[conf.Orderer.OrdererType] = &mockConsenter{}
 
-	manager := NewRegistrar(lf, consenters, mockCrypto())
+	manager := NewRegistrar(Config{LedgerFactory: lf, Consenters: consenters, Signer: mockCrypto()})
 
 	_, ok := manager.GetChain("Fake")
 	assert.False(t, ok, "Should not have found a chain that was not created")
@@ -167,6 +177,52 @@ func TestManagerImpl(t *testing.T) {
 	case <-time.After(time.Second):
 		t.Fatalf("Block 1 not produced after timeout")
 	}
+
+	assert.Equal(t, rl.Height(), chainSupport.Height())
+	assert.Equal(t, uint64(0), chainSupport.Block(0).Header.Number)
+	assert.Nil(t, chainSupport.Block(chainSupport.Height()))
+}
+
+// This test checks that the transactions ordered on a channel are rejected when replayed, including after a restart,
+// and that the duplicates of a transaction are left out of the block
+func TestDuplicateTxIDRejection(t *testing.T) {
+	lf, rl := NewRAMLedgerAndFactory(10)
+
+	consenters := make(map[string]consensus.Consenter)
+	consenters[conf.Orderer.OrdererType] = &mockConsenter{}
+
+	manager := NewRegistrar(Config{LedgerFactory: lf, Consenters: consenters, Signer: mockCrypto(), TxIDIndex: msgprocessor.NewTxIDIndex(time.Minute, 100)})
+	chainSupport, ok := manager.GetChain(genesisconfig.TestChainID)
+	assert.True(t, ok, "Should have gotten chain which was initialized by ramledger")
+
+	// The transactions are ordered as if broadcast through another orderer, with a duplicate of the first one
+	messages := make([]*cb.Envelope, conf.Orderer.BatchSize.MaxMessageCount)
+	for i := range messages[1:] {
+		messages[i] = makeNormalTxWithID(genesisconfig.TestChainID, fmt.Sprintf("tx%d", i))
+	}
+	messages[len(messages)-1] = makeNormalTxWithID(genesisconfig.TestChainID, "tx0")
+	for _, message := range messages {
+		chainSupport.Order(message, 0)
+	}
+
+	it, _ := rl.Iterator(&ab.SeekPosition{Type: &ab.SeekPosition_Specified{Specified: &ab.SeekSpecified{Number: 1}}})
+	defer it.Close()
+	select {
+	case <-it.ReadyChan():
+	case <-time.After(time.Second):
+		t.Fatalf("Block 1 not produced after timeout")
+	}
+	block, status := it.Next()
+	assert.Equal(t, cb.Status_SUCCESS, status)
+	assert.Len(t, block.Data.Data, len(messages)-1, "The duplicate should have been left out of the block")
+
+	err := manager.TxIDIndex().Accept(genesisconfig.TestChainID, "tx0")
+	assert.Equal(t, msgprocessor.ErrDuplicateTxID, errors.Cause(err), "Should have rejected an ordered transaction")
+	assert.NoError(t, manager.TxIDIndex().Accept(genesisconfig.TestChainID, "new"), "Should have accepted a new transaction")
+
+	manager = NewRegistrar(Config{LedgerFactory: lf, Consenters: consenters, Signer: mockCrypto(), TxIDIndex: msgprocessor.NewTxIDIndex(time.Minute, 100)})
+	err = manager.TxIDIndex().Accept(genesisconfig.TestChainID, "tx0")
+	assert.Equal(t, msgprocessor.ErrDuplicateTxID, errors.Cause(err), "Should have rejected an ordered transaction after a restart")
 }
 
 // This test brings up the entire system, with the mock consenter, including the broadcasters etc. and creates a new chain
@@ -180,7 +236,7 @@ func TestNewChain(t *testing.T) {
 	consenters := make(map[string]consensus.Consenter)
 	consenters[conf.Orderer.OrdererType] = &mockConsenter{}
 
-	manager := NewRegistrar(lf, consenters, mockCrypto())
+	manager := NewRegistrar(Config{LedgerFactory: lf, Consenters: consenters, Signer: mockCrypto()})
 	orglessChannelConf := genesisconfig.Load(genesisconfig.SampleSingleMSPChannelProfile)
 	orglessChannelConf.Application.Organizations = nil
 	envConfigUpdate, err := encoder.MakeChannelCreationTransaction(newChainID, mockCrypto(), nil, orglessChannelConf)
@@ -270,7 +326,7 @@ func TestNewChain(t *testing.T) {
 		t.Fatalf("Block 1 not produced after timeout on new chain")
 	}
 
-	rcs := newChainSupport(manager, chainSupport.ledgerResources, consenters, mockCrypto())
+	rcs := newChainSupportOrPanic(manager, chainSupport.ledgerResources, consenters, mockCrypto())
 	assert.Equal(t, expectedLastConfigSeq, rcs.lastConfigSeq, "On restart, incorrect lastConfigSeq")
 }
 
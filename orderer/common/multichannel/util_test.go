@@ -12,6 +12,7 @@ import (
 	"github.com/hyperledger/fabric/common/channelconfig"
 	"github.com/hyperledger/fabric/common/configtx"
 	genesisconfig "github.com/hyperledger/fabric/common/tools/configtxgen/localconfig"
+	"github.com/hyperledger/fabric/common/util"
 	"github.com/hyperledger/fabric/orderer/common/blockcutter"
 	"github.com/hyperledger/fabric/orderer/common/msgprocessor"
 	"github.com/hyperledger/fabric/orderer/consensus"
@@ -158,3 +159,20 @@ func makeNormalTx(chainID string, i int) *cb.Envelope {
 		Payload: utils.MarshalOrPanic(payload),
 	}
 }
+
+func makeNormalTxWithID(chainID string, txID string) *cb.Envelope {
+	payload := &cb.Payload{
+		Header: &cb.Header{
+			ChannelHeader: utils.MarshalOrPanic(&cb.ChannelHeader{
+				Type:      int32(cb.HeaderType_ENDORSER_TRANSACTION),
+				ChannelId: chainID,
+				TxId:      txID,
+				Timestamp: util.CreateUtcTimestamp(),
+			}),
+			SignatureHeader: utils.MarshalOrPanic(&cb.SignatureHeader{}),
+		},
+	}
+	return &cb.Envelope{
+		Payload: utils.MarshalOrPanic(payload),
+	}
+}
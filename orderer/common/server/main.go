@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package server
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io/ioutil"
 	"net"
@@ -19,19 +21,26 @@ import (
 	"github.com/hyperledger/fabric/common/crypto"
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/common/ledger/blockledger"
+	"github.com/hyperledger/fabric/common/metrics"
 	"github.com/hyperledger/fabric/common/tools/configtxgen/encoder"
 	genesisconfig "github.com/hyperledger/fabric/common/tools/configtxgen/localconfig"
 	"github.com/hyperledger/fabric/core/comm"
 	"github.com/hyperledger/fabric/msp"
 	"github.com/hyperledger/fabric/orderer/common/bootstrap/file"
+	"github.com/hyperledger/fabric/orderer/common/channelparticipation"
 	"github.com/hyperledger/fabric/orderer/common/localconfig"
 	"github.com/hyperledger/fabric/orderer/common/metadata"
+	"github.com/hyperledger/fabric/orderer/common/msgprocessor"
 	"github.com/hyperledger/fabric/orderer/common/multichannel"
 	"github.com/hyperledger/fabric/orderer/consensus"
+	"github.com/hyperledger/fabric/orderer/consensus/bft"
 	"github.com/hyperledger/fabric/orderer/consensus/kafka"
+	"github.com/hyperledger/fabric/orderer/consensus/raft"
 	"github.com/hyperledger/fabric/orderer/consensus/solo"
 	cb "github.com/hyperledger/fabric/protos/common"
 	ab "github.com/hyperledger/fabric/protos/orderer"
+	bftpb "github.com/hyperledger/fabric/protos/orderer/bft"
+	raftpb "github.com/hyperledger/fabric/protos/orderer/raft"
 	"github.com/hyperledger/fabric/protos/utils"
 
 	"github.com/hyperledger/fabric/common/localmsp"
@@ -71,6 +80,7 @@ func Main() {
 	conf := config.Load()
 	initializeLoggingLevel(conf)
 	initializeLocalMsp(conf)
+	initializeMetrics(conf)
 
 	Start(fullCmd, conf)
 }
@@ -93,7 +103,14 @@ func Start(cmd string, conf *config.TopLevel) {
 		}
 	}
 
-	manager := initializeMultichannelRegistrar(conf, signer, tlsCallback)
+	clusterClientConfig := initializeClusterClientConfig(serverConfig)
+	blockPuller, err := multichannel.NewBlockPuller(clusterClientConfig, signer)
+	if err != nil {
+		logger.Fatal("Failed to create the block puller:", err)
+	}
+	raftConsenter := raft.New(conf.Raft, clusterClientConfig, blockPuller)
+	bftConsenter := bft.New(conf.BFT, clusterClientConfig)
+	manager := initializeMultichannelRegistrar(conf, signer, raftConsenter, bftConsenter, blockPuller, tlsCallback)
 	mutualTLS := serverConfig.SecOpts.UseTLS && serverConfig.SecOpts.RequireClientCert
 	server := NewServer(manager, signer, &conf.Debug, conf.General.Authentication.TimeWindow, mutualTLS)
 
@@ -101,7 +118,10 @@ func Start(cmd string, conf *config.TopLevel) {
 	case start.FullCommand(): // "start" command
 		logger.Infof("Starting %s", metadata.GetVersionInfo())
 		initializeProfilingService(conf)
+		initializeChannelParticipation(conf, manager)
 		ab.RegisterAtomicBroadcastServer(grpcServer.Server(), server)
+		raftpb.RegisterClusterServer(grpcServer.Server(), raftConsenter)
+		bftpb.RegisterClusterServer(grpcServer.Server(), bftConsenter)
 		logger.Info("Beginning to serve requests")
 		grpcServer.Start()
 	case benchmark.FullCommand(): // "benchmark" command
@@ -129,6 +149,100 @@ func initializeProfilingService(conf *config.TopLevel) {
 	}
 }
 
+// Start the channel participation API if enabled.
+func initializeChannelParticipation(conf *config.TopLevel, registrar *multichannel.Registrar) {
+	cpConf := conf.ChannelParticipation
+	if !cpConf.Enabled {
+		return
+	}
+	// The API lets its clients join and remove channels, so unless they must
+	// present a certificate it may only be reached from the host itself
+	mutualTLS := cpConf.TLS.Enabled && cpConf.TLS.ClientAuthEnabled
+	if !mutualTLS && !isLoopbackAddress(cpConf.ListenAddress) {
+		logger.Panicf("Channel participation API must listen on a loopback address unless ChannelParticipation.TLS.ClientAuthEnabled is set to true, got %s", cpConf.ListenAddress)
+	}
+	server := &http.Server{
+		Addr:    cpConf.ListenAddress,
+		Handler: channelparticipation.NewHTTPHandler(cpConf, registrar),
+	}
+	if mutualTLS {
+		clientRootCAs := x509.NewCertPool()
+		for _, clientRoot := range cpConf.TLS.ClientRootCAs {
+			root, err := ioutil.ReadFile(clientRoot)
+			if err != nil {
+				logger.Fatalf("Failed to load ClientRootCAs file '%s' (%s)", clientRoot, err)
+			}
+			if !clientRootCAs.AppendCertsFromPEM(root) {
+				logger.Fatalf("Failed to parse ClientRootCAs file '%s'", clientRoot)
+			}
+		}
+		server.TLSConfig = &tls.Config{
+			ClientCAs:  clientRootCAs,
+			ClientAuth: tls.RequireAndVerifyClientCert,
+		}
+	}
+	go func() {
+		logger.Info("Starting channel participation API on:", cpConf.ListenAddress)
+		// The ListenAndServe() call does not return unless an error occurs.
+		if cpConf.TLS.Enabled {
+			logger.Panic("Channel participation API failed:", server.ListenAndServeTLS(cpConf.TLS.Certificate, cpConf.TLS.PrivateKey))
+		}
+		logger.Panic("Channel participation API failed:", server.ListenAndServe())
+	}()
+}
+
+// isLoopbackAddress returns whether the host of the given address resolves
+// only to loopback addresses. An empty host listens on all the interfaces.
+func isLoopbackAddress(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.IsLoopback()
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil || len(ips) == 0 {
+		return false
+	}
+	for _, ip := range ips {
+		if !ip.IsLoopback() {
+			return false
+		}
+	}
+	return true
+}
+
+// Initialize the metrics root scope, which must happen before the components
+// create their scopes, and start reporting the metrics if enabled.
+func initializeMetrics(conf *config.TopLevel) {
+	if !conf.Metrics.Enabled {
+		return
+	}
+	err := metrics.Init(metrics.Opts{
+		Enabled:  conf.Metrics.Enabled,
+		Reporter: conf.Metrics.Reporter,
+		Interval: conf.Metrics.Interval,
+		StatsdReporterOpts: metrics.StatsdReporterOpts{
+			Address:       conf.Metrics.StatsdReporter.Address,
+			FlushInterval: conf.Metrics.StatsdReporter.FlushInterval,
+			FlushBytes:    conf.Metrics.StatsdReporter.FlushBytes,
+		},
+		PromReporterOpts: metrics.PromReporterOpts{
+			ListenAddress: conf.Metrics.PromReporter.ListenAddress,
+		},
+	})
+	if err != nil {
+		logger.Fatal("Failed to initialize metrics:", err)
+	}
+	go func() {
+		logger.Infof("Starting %s metrics reporter", conf.Metrics.Reporter)
+		if err := metrics.Start(); err != nil && err != http.ErrServerClosed {
+			logger.Error("Metrics reporter failed:", err)
+		}
+	}()
+}
+
 func initializeServerConfig(conf *config.TopLevel) comm.ServerConfig {
 	// secure server config
 	secureOpts := &comm.SecureOptions{
@@ -187,6 +301,22 @@ func initializeServerConfig(conf *config.TopLevel) comm.ServerConfig {
 	return comm.ServerConfig{SecOpts: secureOpts, KaOpts: kaOpts}
 }
 
+// initializeClusterClientConfig returns the config of the client used by the
+// raft and bft consenters to reach each other, which reuses the TLS material of the server
+func initializeClusterClientConfig(serverConfig comm.ServerConfig) comm.ClientConfig {
+	secOpts := serverConfig.SecOpts
+	return comm.ClientConfig{
+		SecOpts: &comm.SecureOptions{
+			UseTLS:            secOpts.UseTLS,
+			RequireClientCert: secOpts.RequireClientCert,
+			Certificate:       secOpts.Certificate,
+			Key:               secOpts.Key,
+			ServerRootCAs:     secOpts.ServerRootCAs,
+		},
+		KaOpts: comm.DefaultKeepaliveOptions(),
+	}
+}
+
 func initializeBootstrapChannel(conf *config.TopLevel, lf blockledger.Factory) {
 	var genesisBlock *cb.Block
 
@@ -196,6 +326,9 @@ func initializeBootstrapChannel(conf *config.TopLevel, lf blockledger.Factory) {
 		genesisBlock = encoder.New(genesisconfig.Load(conf.General.GenesisProfile)).GenesisBlockForChannel(conf.General.SystemChannel)
 	case "file":
 		genesisBlock = file.New(conf.General.GenesisFile).GenesisBlock()
+	case "none":
+		logger.Info("Not bootstrapping a system channel, the channels are joined through the channel participation API")
+		return
 	default:
 		logger.Panic("Unknown genesis method:", conf.General.GenesisMethod)
 	}
@@ -238,8 +371,8 @@ func initializeLocalMsp(conf *config.TopLevel) {
 	}
 }
 
-func initializeMultichannelRegistrar(conf *config.TopLevel, signer crypto.LocalSigner,
-	callbacks ...func(bundle *channelconfig.Bundle)) *multichannel.Registrar {
+func initializeMultichannelRegistrar(conf *config.TopLevel, signer crypto.LocalSigner, raftConsenter *raft.Consenter,
+	bftConsenter *bft.Consenter, blockPuller multichannel.BlockPuller, callbacks ...func(bundle *channelconfig.Bundle)) *multichannel.Registrar {
 	lf, _ := createLedgerFactory(conf)
 	// Are we bootstrapping?
 	if len(lf.ChainIDs()) == 0 {
@@ -251,8 +384,23 @@ func initializeMultichannelRegistrar(conf *config.TopLevel, signer crypto.LocalS
 	consenters := make(map[string]consensus.Consenter)
 	consenters["solo"] = solo.New()
 	consenters["kafka"] = kafka.New(conf.Kafka)
+	consenters["raft"] = raftConsenter
+	consenters["bft"] = bftConsenter
+
+	var txIDIndex *msgprocessor.TxIDIndex
+	if conf.TxIDIndex.Enabled {
+		logger.Infof("Rejecting replays of the transactions received within the last %s, remembering up to %d per channel", conf.TxIDIndex.Window, conf.TxIDIndex.MaxEntries)
+		txIDIndex = msgprocessor.NewTxIDIndex(conf.TxIDIndex.Window, conf.TxIDIndex.MaxEntries)
+	}
 
-	return multichannel.NewRegistrar(lf, consenters, signer, callbacks...)
+	return multichannel.NewRegistrar(multichannel.Config{
+		LedgerFactory: lf,
+		Consenters:    consenters,
+		Signer:        signer,
+		TxIDIndex:     txIDIndex,
+		BlockPuller:   blockPuller,
+		Callbacks:     callbacks,
+	})
 }
 
 func updateTrustedRoots(srv comm.GRPCServer, rootCASupport *comm.CASupport,
@@ -22,10 +22,13 @@ import (
 	"github.com/hyperledger/fabric/common/channelconfig"
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/common/localmsp"
+	"github.com/hyperledger/fabric/common/metrics"
 	genesisconfig "github.com/hyperledger/fabric/common/tools/configtxgen/localconfig"
 	"github.com/hyperledger/fabric/core/comm"
 	coreconfig "github.com/hyperledger/fabric/core/config"
 	"github.com/hyperledger/fabric/orderer/common/localconfig"
+	"github.com/hyperledger/fabric/orderer/consensus/bft"
+	"github.com/hyperledger/fabric/orderer/consensus/raft"
 	"github.com/op/go-logging"
 	"github.com/stretchr/testify/assert"
 )
@@ -75,6 +78,37 @@ func TestInitializeProfilingService(t *testing.T) {
 	}
 }
 
+func TestInitializeMetrics(t *testing.T) {
+	// get a free random port
+	listenAddr := func() string {
+		l, _ := net.Listen("tcp", "localhost:0")
+		l.Close()
+		return l.Addr().String()
+	}()
+	initializeMetrics(&config.TopLevel{
+		Metrics: config.Metrics{
+			Enabled:      true,
+			Reporter:     "prom",
+			Interval:     time.Second,
+			PromReporter: config.PromReporter{ListenAddress: listenAddr},
+		},
+	})
+	defer metrics.Shutdown()
+
+	metrics.RootScope.SubScope("broadcast").Counter("requests_total").Inc(1)
+	var resp *http.Response
+	var err error
+	for i := 0; i < 10; i++ {
+		if resp, err = http.Get("http://" + listenAddr + "/metrics"); err == nil {
+			break
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	assert.NoError(t, err, "Expected the metrics endpoint to be up")
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
 func TestInitializeServerConfig(t *testing.T) {
 	conf := &config.TopLevel{
 		General: config.General{
@@ -146,6 +180,70 @@ func TestInitializeServerConfig(t *testing.T) {
 	}
 }
 
+func TestInitializeChannelParticipation(t *testing.T) {
+	// get a free random port
+	listenAddr := func() string {
+		l, _ := net.Listen("tcp", "localhost:0")
+		l.Close()
+		return l.Addr().String()
+	}()
+	conf := genesisConfig(t)
+	conf.General.GenesisMethod = "none"
+	conf.ChannelParticipation = config.ChannelParticipation{
+		Enabled:            true,
+		ListenAddress:      listenAddr,
+		MaxRequestBodySize: 1024 * 1024,
+	}
+	initializeLocalMsp(conf)
+	manager := initializeMultichannelRegistrar(conf, localmsp.NewSigner(), raft.New(conf.Raft, comm.ClientConfig{}, nil),
+		bft.New(conf.BFT, comm.ClientConfig{}), nil)
+	assert.Equal(t, "", manager.SystemChannelID())
+	initializeChannelParticipation(conf, manager)
+
+	var resp *http.Response
+	var err error
+	for i := 0; i < 10; i++ {
+		if resp, err = http.Get("http://" + listenAddr + "/participation/v1/channels"); err == nil {
+			break
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	assert.NoError(t, err, "Expected the channel participation API to be up")
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"systemChannel":null,"channels":[]}`, string(body))
+}
+
+func TestInitializeChannelParticipationNonLoopback(t *testing.T) {
+	conf := genesisConfig(t)
+	conf.ChannelParticipation = config.ChannelParticipation{
+		Enabled:            true,
+		ListenAddress:      "0.0.0.0:0",
+		MaxRequestBodySize: 1024 * 1024,
+	}
+	assert.Panics(t, func() { initializeChannelParticipation(conf, nil) }, "should refuse to serve other hosts without mutual TLS")
+
+	conf.ChannelParticipation.TLS = config.TLS{Enabled: true, Certificate: "cert", PrivateKey: "key"}
+	assert.Panics(t, func() { initializeChannelParticipation(conf, nil) }, "should refuse to serve other hosts without client authentication")
+}
+
+func TestIsLoopbackAddress(t *testing.T) {
+	for addr, expected := range map[string]bool{
+		"127.0.0.1:9443": true,
+		"[::1]:9443":     true,
+		"localhost:9443": true,
+		"0.0.0.0:9443":   false,
+		":9443":          false,
+		"192.0.2.1:9443": false,
+		"127.0.0.1":      false,
+		"[::]:9443":      false,
+	} {
+		assert.Equal(t, expected, isLoopbackAddress(addr), addr)
+	}
+}
+
 func TestInitializeBootstrapChannel(t *testing.T) {
 	testCases := []struct {
 		genesisMethod string
@@ -155,6 +253,7 @@ func TestInitializeBootstrapChannel(t *testing.T) {
 		{"provisional", "ram", false},
 		{"provisional", "file", false},
 		{"provisional", "json", false},
+		{"none", "ram", false},
 		{"invalid", "ram", true},
 		{"file", "ram", true},
 	}
@@ -237,7 +336,8 @@ func TestInitializeMultiChainManager(t *testing.T) {
 	conf := genesisConfig(t)
 	assert.NotPanics(t, func() {
 		initializeLocalMsp(conf)
-		initializeMultichannelRegistrar(conf, localmsp.NewSigner())
+		initializeMultichannelRegistrar(conf, localmsp.NewSigner(), raft.New(conf.Raft, comm.ClientConfig{}, nil),
+			bft.New(conf.BFT, comm.ClientConfig{}), nil)
 	})
 }
 
@@ -296,7 +396,8 @@ func TestUpdateTrustedRoots(t *testing.T) {
 			updateTrustedRoots(grpcServer, caSupport, bundle)
 		}
 	}
-	initializeMultichannelRegistrar(genesisConfig(t), localmsp.NewSigner(), callback)
+	initializeMultichannelRegistrar(genesisConfig(t), localmsp.NewSigner(), raft.New(config.Raft{}, comm.ClientConfig{}, nil),
+		bft.New(config.BFT{}, comm.ClientConfig{}), nil, callback)
 	t.Logf("# app CAs: %d", len(caSupport.AppRootCAsByChain[genesisconfig.TestChainID]))
 	t.Logf("# orderer CAs: %d", len(caSupport.OrdererRootCAsByChain[genesisconfig.TestChainID]))
 	// mutual TLS not required so no updates should have occurred
@@ -327,7 +428,8 @@ func TestUpdateTrustedRoots(t *testing.T) {
 			updateTrustedRoots(grpcServer, caSupport, bundle)
 		}
 	}
-	initializeMultichannelRegistrar(genesisConfig(t), localmsp.NewSigner(), callback)
+	initializeMultichannelRegistrar(genesisConfig(t), localmsp.NewSigner(), raft.New(config.Raft{}, comm.ClientConfig{}, nil),
+		bft.New(config.BFT{}, comm.ClientConfig{}), nil, callback)
 	t.Logf("# app CAs: %d", len(caSupport.AppRootCAsByChain[genesisconfig.TestChainID]))
 	t.Logf("# orderer CAs: %d", len(caSupport.OrdererRootCAsByChain[genesisconfig.TestChainID]))
 	// mutual TLS is required so updates should have occurred
@@ -60,7 +60,7 @@ func NewServer(r *multichannel.Registrar, _ crypto.LocalSigner, debug *localconf
 				sf := msgprocessor.NewSigFilter(policies.ChannelReaders, chain)
 				return sf.Apply(env)
 			}, timeWindow, mutualTLS),
-		bh:    broadcast.NewHandlerImpl(broadcastSupport{Registrar: r}),
+		bh:    broadcast.NewHandlerImpl(broadcastSupport{Registrar: r}, r.TxIDIndex()),
 		debug: debug,
 	}
 	return s
@@ -0,0 +1,1084 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bft
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	"github.com/hyperledger/fabric/common/configtx"
+	"github.com/hyperledger/fabric/common/deliver"
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/msp"
+	"github.com/hyperledger/fabric/orderer/common/msgprocessor"
+	"github.com/hyperledger/fabric/orderer/consensus"
+	cb "github.com/hyperledger/fabric/protos/common"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	bftpb "github.com/hyperledger/fabric/protos/orderer/bft"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/pkg/errors"
+)
+
+const (
+	stepBufferSize    = 256
+	sendBufferSize    = 256
+	maxPendingMsgs    = 1024
+	maxSyncBlocks     = 16
+	maxBackoffDoubles = 6
+)
+
+type submission struct {
+	configSeq uint64
+	content   *cb.Envelope
+	isConfig  bool
+}
+
+// verifiedMessage is a protocol message whose signature has been verified against the identity of its sender
+type verifiedMessage struct {
+	*bftpb.Message
+	signed *bftpb.SignedMessage
+}
+
+// pooledRequest is a request which waits in the pool until a block containing it, or its
+// transaction, is committed
+type pooledRequest struct {
+	key     string
+	txID    string
+	request *bftpb.Request
+	size    int
+	arrived time.Time
+}
+
+// commitVote is a COMMIT message, along with whether its block signature has been verified
+type commitVote struct {
+	msg      *verifiedMessage
+	verified bool
+}
+
+// chain implements consensus.Chain for the BFT consenter. A single goroutine runs the protocol for the
+// channel, which goes as follows. The requests submitted to any replica are broadcast to all the replicas,
+// which keep them in a pool until they are ordered. The replicas go through a sequence of views, in each
+// of which one of them is the primary. The primary of the current view cuts the pooled requests into a
+// block and proposes it in a PRE_PREPARE message, one block at a time. The other replicas validate the
+// proposed block and broadcast a PREPARE for it. Once a quorum of replicas prepared the block, they
+// broadcast a COMMIT carrying their signature over the block header. A replica writes the block, along with
+// the signatures of a quorum of COMMITs, to the ledger. If a request is not ordered in time, or the primary
+// proposes an invalid block, the replicas move to the next view, carrying over the block that might have
+// been committed by some replica. A replica that falls behind fetches the blocks it missed from the other
+// replicas, and accepts them only if they are signed by a quorum.
+//
+// The view of a replica, the block it prepared and the block prepared by a quorum at the height of its ledger
+// are persisted before the messages which depend on them are sent. A replica that restarts resumes from them
+// and sends these messages again, so that it never contradicts what it sent before, and all the replicas may
+// restart at once without losing a block that might have been committed
+type chain struct {
+	support      consensus.ConsenterSupport
+	channel      string
+	id           uint64
+	n            int
+	f            int
+	quorum       int
+	endpoints    map[uint64]string
+	identities   []msp.Identity
+	consenters   [][]byte
+	communicator Communicator
+	storage      *replicaStorage
+
+	requestTimeout    time.Duration
+	viewChangeTimeout time.Duration
+
+	// the following fields are only accessed by the goroutine that runs the protocol
+	view         uint64
+	viewChanging bool
+	height       uint64
+	lastBlock    *cb.Block // the last block written, stripped of its data
+	genesis      *cb.BlockHeader
+	lastProgress time.Time
+	pool         []*pooledRequest
+	pooled       map[string]*pooledRequest
+
+	// the state of the block in flight at the current height and view
+	proposal *cb.Block
+	prepares map[uint64]*verifiedMessage
+	commits  map[uint64]*commitVote
+	prepared *bftpb.PreparedCertificate // the highest-view block prepared at the current height
+	endorsed uint64                     // the view in which the last block was endorsed for lagging replicas
+
+	// the state of the view changes
+	viewChanges    map[uint64]map[uint64]*verifiedMessage
+	vcAttempts     uint
+	vcDeadline     time.Time
+	newView        *bftpb.SignedMessage            // the NEW_VIEW that installed the current view
+	newViewSent    map[uint64]*bftpb.SignedMessage // the last NEW_VIEW sent to each replica
+	required       *cb.Block                       // the block the primary must propose at the required height
+	requiredHeight uint64
+
+	pending      []*verifiedMessage // messages for a later height or view
+	ahead        map[uint64]bool    // the replicas seen at a later height or view
+	syncing      bool
+	syncDeadline time.Time
+
+	started  uint32 // accessed atomically
+	submitC  chan *submission
+	stepC    chan *verifiedMessage
+	sendCs   map[uint64]chan *bftpb.SignedMessage
+	haltC    chan struct{}
+	doneC    chan struct{}
+	haltOnce sync.Once
+}
+
+func newChain(support consensus.ConsenterSupport, id uint64, endpoints map[uint64]string, configMetadata *bftpb.ConfigMetadata,
+	identities []msp.Identity, communicator Communicator, storage *replicaStorage, view uint64, state *bftpb.ReplicaState) *chain {
+	sendCs := make(map[uint64]chan *bftpb.SignedMessage)
+	for peer := range endpoints {
+		if peer != id {
+			sendCs[peer] = make(chan *bftpb.SignedMessage, sendBufferSize)
+		}
+	}
+	var consenters [][]byte
+	for _, consenter := range configMetadata.Consenters {
+		consenters = append(consenters, consenter.Identity)
+	}
+	n := len(consenters)
+	height := support.Height()
+	c := &chain{
+		support:           support,
+		channel:           support.ChainID(),
+		id:                id,
+		n:                 n,
+		f:                 (n - 1) / 3,
+		quorum:            deliver.BFTQuorum(n),
+		endpoints:         endpoints,
+		identities:        identities,
+		consenters:        consenters,
+		communicator:      communicator,
+		storage:           storage,
+		requestTimeout:    time.Duration(configMetadata.Options.RequestTimeout) * time.Millisecond,
+		viewChangeTimeout: time.Duration(configMetadata.Options.ViewChangeTimeout) * time.Millisecond,
+		view:              view,
+		height:            height,
+		lastBlock:         stripBlock(support.Block(height - 1)),
+		genesis:           support.Block(0).Header,
+		pooled:            make(map[string]*pooledRequest),
+		prepares:          make(map[uint64]*verifiedMessage),
+		commits:           make(map[uint64]*commitVote),
+		viewChanges:       make(map[uint64]map[uint64]*verifiedMessage),
+		newViewSent:       make(map[uint64]*bftpb.SignedMessage),
+		ahead:             make(map[uint64]bool),
+		submitC:           make(chan *submission),
+		stepC:             make(chan *verifiedMessage, stepBufferSize),
+		sendCs:            sendCs,
+		haltC:             make(chan struct{}),
+		doneC:             make(chan struct{}),
+	}
+	c.restore(state)
+	return c
+}
+
+// restore takes over the protocol state persisted before a restart. The view change in progress, and the
+// NEW_VIEW which installed the view, are only taken over if the replica is still in that view. The block in
+// flight and the prepared block are only taken over if the ledger did not move past their height since
+func (c *chain) restore(state *bftpb.ReplicaState) {
+	if state == nil {
+		return
+	}
+	sameView := state.View == c.view
+	if sameView {
+		c.viewChanging = state.ViewChanging
+		c.newView = state.NewView
+	}
+	if state.Sequence == c.height {
+		c.prepared = state.Prepared
+		if sameView && !state.ViewChanging {
+			c.proposal = state.Proposal
+		}
+	}
+	if c.newView == nil || c.viewChanging {
+		return
+	}
+	nv, err := c.verify(c.newView)
+	if err == nil {
+		var vcs []*verifiedMessage
+		if vcs, err = c.validateNewView(nv); err == nil {
+			c.setRequired(vcs)
+			return
+		}
+	}
+	logger.Warningf("Discarding the persisted new view of replica %d of channel %s: %s", c.id, c.channel, err)
+	c.newView = nil
+}
+
+// resume sends again the messages which depend on the restored protocol state, as the other replicas
+// may have missed them while this replica was down
+func (c *chain) resume() {
+	switch {
+	case c.viewChanging:
+		c.startViewChange(c.view)
+	case c.proposal != nil:
+		if c.primary(c.view) == c.id {
+			c.broadcast(&bftpb.Message{Type: bftpb.MessageType_PRE_PREPARE, View: c.view, Sequence: c.height, Block: c.proposal})
+		}
+		if c.prepared != nil && c.prepared.View == c.view {
+			for _, sm := range c.prepared.Prepares {
+				if prepare, err := c.verify(sm); err == nil {
+					c.prepares[prepare.From] = prepare
+				}
+			}
+		}
+		c.prepare()
+	}
+}
+
+// persist stores the protocol state of the replica, before the messages which depend on it are sent
+func (c *chain) persist() {
+	if err := c.storage.store(&bftpb.ReplicaState{
+		View:         c.view,
+		ViewChanging: c.viewChanging,
+		NewView:      c.newView,
+		Sequence:     c.height,
+		Proposal:     c.proposal,
+		Prepared:     c.prepared,
+	}); err != nil {
+		logger.Panicf("Failed to persist the bft state of channel %s: %s", c.channel, err)
+	}
+}
+
+// Start starts the goroutines that run the protocol and send its messages
+func (c *chain) Start() {
+	atomic.StoreUint32(&c.started, 1)
+	for peer, sendC := range c.sendCs {
+		go c.sendLoop(c.endpoints[peer], sendC)
+	}
+	go c.run()
+}
+
+// Halt stops the replica and waits for its goroutine to exit
+func (c *chain) Halt() {
+	c.haltOnce.Do(func() { close(c.haltC) })
+	if atomic.LoadUint32(&c.started) == 1 {
+		<-c.doneC
+	}
+}
+
+// WaitReady does not block, as the submissions are pooled as they arrive
+func (c *chain) WaitReady() error {
+	return nil
+}
+
+// Errored only closes on exit
+func (c *chain) Errored() <-chan struct{} {
+	return c.haltC
+}
+
+// Order submits normal messages for ordering
+func (c *chain) Order(env *cb.Envelope, configSeq uint64) error {
+	return c.submit(&submission{configSeq: configSeq, content: env})
+}
+
+// Configure submits config messages for ordering
+func (c *chain) Configure(config *cb.Envelope, configSeq uint64) error {
+	if err := c.checkConfig(config); err != nil {
+		return err
+	}
+	return c.submit(&submission{configSeq: configSeq, content: config, isConfig: true})
+}
+
+func (c *chain) submit(s *submission) error {
+	select {
+	case c.submitC <- s:
+		return nil
+	case <-c.haltC:
+		return errors.Errorf("bft replica for channel %s is halted", c.channel)
+	}
+}
+
+// handleStep verifies a protocol message sent by another consenter and passes it to the protocol goroutine
+func (c *chain) handleStep(sm *bftpb.SignedMessage) error {
+	m, err := c.verify(sm)
+	if err != nil {
+		return err
+	}
+	if m.From == c.id {
+		return errors.Errorf("consenter %d received a message from itself", c.id)
+	}
+	select {
+	case c.stepC <- m:
+		return nil
+	case <-c.haltC:
+		return errors.Errorf("bft replica for channel %s is halted", c.channel)
+	}
+}
+
+// verify checks the signature of a protocol message against the identity of the consenter which sent it
+func (c *chain) verify(sm *bftpb.SignedMessage) (*verifiedMessage, error) {
+	if sm == nil {
+		return nil, errors.New("missing message")
+	}
+	m := &bftpb.Message{}
+	if err := proto.Unmarshal(sm.Message, m); err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling bft message")
+	}
+	if m.From == 0 || m.From > uint64(c.n) {
+		return nil, errors.Errorf("message from unknown consenter %d", m.From)
+	}
+	if err := c.identities[m.From-1].Verify(sm.Message, sm.Signature); err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("invalid signature on a message from consenter %d", m.From))
+	}
+	return &verifiedMessage{Message: m, signed: sm}, nil
+}
+
+func (c *chain) run() {
+	defer close(c.doneC)
+	c.lastProgress = time.Now()
+	c.resume()
+	for {
+		var timeout <-chan time.Time
+		if deadline, ok := c.nextDeadline(); ok {
+			timeout = time.After(time.Until(deadline))
+		}
+
+		select {
+		case s := <-c.submitC:
+			c.handleSubmission(s)
+		case m := <-c.stepC:
+			c.handle(m)
+		case <-timeout:
+			c.handleTimeout(time.Now())
+		case <-c.haltC:
+			logger.Infof("Halting bft replica %d of channel %s", c.id, c.channel)
+			return
+		}
+	}
+}
+
+func (c *chain) handle(m *verifiedMessage) {
+	switch m.Type {
+	case bftpb.MessageType_REQUEST:
+		c.handleRequest(m)
+	case bftpb.MessageType_PRE_PREPARE, bftpb.MessageType_PREPARE, bftpb.MessageType_COMMIT:
+		c.handleOrdering(m)
+	case bftpb.MessageType_VIEW_CHANGE:
+		c.handleViewChange(m)
+	case bftpb.MessageType_NEW_VIEW:
+		c.handleNewView(m)
+	case bftpb.MessageType_SYNC_REQUEST:
+		c.handleSyncRequest(m)
+	case bftpb.MessageType_SYNC_RESPONSE:
+		c.handleSyncResponse(m)
+	}
+}
+
+func (c *chain) primary(view uint64) uint64 {
+	return view%uint64(c.n) + 1
+}
+
+// nextDeadline returns the earliest time at which a timer of the protocol expires
+func (c *chain) nextDeadline() (time.Time, bool) {
+	var deadlines []time.Time
+	if c.syncing {
+		deadlines = append(deadlines, c.syncDeadline)
+	}
+	if c.viewChanging {
+		deadlines = append(deadlines, c.vcDeadline)
+	} else if deadline, ok := c.requestDeadline(); ok {
+		deadlines = append(deadlines, deadline)
+		if c.primary(c.view) == c.id && c.proposal == nil {
+			deadlines = append(deadlines, c.pool[0].arrived.Add(c.support.SharedConfig().BatchTimeout()))
+		}
+	}
+	if len(deadlines) == 0 {
+		return time.Time{}, false
+	}
+	earliest := deadlines[0]
+	for _, deadline := range deadlines[1:] {
+		if deadline.Before(earliest) {
+			earliest = deadline
+		}
+	}
+	return earliest, true
+}
+
+// requestDeadline returns the time by which the oldest pending request should be ordered
+func (c *chain) requestDeadline() (time.Time, bool) {
+	if len(c.pool) == 0 && c.proposal == nil {
+		return time.Time{}, false
+	}
+	start := c.lastProgress
+	if len(c.pool) > 0 && c.pool[0].arrived.After(start) {
+		start = c.pool[0].arrived
+	}
+	return start.Add(c.requestTimeout), true
+}
+
+func (c *chain) handleTimeout(now time.Time) {
+	if c.syncing && !now.Before(c.syncDeadline) {
+		c.syncing = false
+	}
+	if c.viewChanging {
+		if !now.Before(c.vcDeadline) {
+			logger.Warningf("View change of channel %s to view %d timed out on replica %d", c.channel, c.view, c.id)
+			c.startViewChange(c.view + 1)
+		}
+		return
+	}
+	if deadline, ok := c.requestDeadline(); ok && !now.Before(deadline) {
+		logger.Warningf("Requests of channel %s were not ordered in time in view %d, replica %d suspects primary %d",
+			c.channel, c.view, c.id, c.primary(c.view))
+		c.startViewChange(c.view + 1)
+		return
+	}
+	c.maybePropose()
+}
+
+// handleSubmission pools a request submitted to this replica and broadcasts it to the other replicas
+func (c *chain) handleSubmission(s *submission) {
+	req := &bftpb.Request{ConfigSeq: s.configSeq, Content: s.content, IsConfig: s.isConfig}
+	if !c.addRequest(req, false) {
+		return
+	}
+	c.broadcast(&bftpb.Message{Type: bftpb.MessageType_REQUEST, Request: req})
+	c.maybePropose()
+}
+
+// handleRequest pools a request broadcast by another replica
+func (c *chain) handleRequest(m *verifiedMessage) {
+	if m.Request == nil || m.Request.Content == nil {
+		logger.Warningf("Discarding an empty request from replica %d of channel %s", m.From, c.channel)
+		return
+	}
+	if c.addRequest(m.Request, true) {
+		c.maybePropose()
+	}
+}
+
+// addRequest adds a request to the pool, unless it is already pooled or invalid.
+// The requests broadcast by other replicas are always revalidated
+func (c *chain) addRequest(req *bftpb.Request, forwarded bool) bool {
+	content := utils.MarshalOrPanic(req.Content)
+	key := requestKey(content)
+	if _, ok := c.pooled[key]; ok {
+		return false
+	}
+	if forwarded || req.ConfigSeq < c.support.Sequence() {
+		if err := c.validateRequest(req); err != nil {
+			logger.Warningf("Discarding bad request on channel %s: %s", c.channel, err)
+			return false
+		}
+	}
+	r := &pooledRequest{key: key, txID: txID(req.Content), request: req, size: len(content), arrived: time.Now()}
+	c.pool = append(c.pool, r)
+	c.pooled[key] = r
+	return true
+}
+
+func (c *chain) validateRequest(req *bftpb.Request) error {
+	if req.IsConfig {
+		if _, _, err := c.support.ProcessConfigMsg(req.Content); err != nil {
+			return err
+		}
+		if err := c.checkConfig(req.Content); err != nil {
+			return err
+		}
+	} else if _, err := c.support.ProcessNormalMsg(req.Content); err != nil {
+		return err
+	}
+	req.ConfigSeq = c.support.Sequence()
+	return nil
+}
+
+// revalidatePool drops the requests which are no longer valid after a config update
+func (c *chain) revalidatePool() {
+	pool := c.pool[:0]
+	for _, r := range c.pool {
+		if err := c.validateRequest(r.request); err != nil {
+			logger.Warningf("Discarding request which became invalid on channel %s: %s", c.channel, err)
+			delete(c.pooled, r.key)
+			continue
+		}
+		pool = append(pool, r)
+	}
+	c.pool = pool
+}
+
+// removeRequests removes the requests of a committed block from the pool, along with the
+// requests of the same transactions, which the block writer may have left out of the block
+// as duplicates
+func (c *chain) removeRequests(data [][]byte) {
+	txIDs := make(map[string]struct{})
+	for _, d := range data {
+		delete(c.pooled, requestKey(d))
+		if env, err := utils.UnmarshalEnvelope(d); err == nil {
+			if id := txID(env); id != "" {
+				txIDs[id] = struct{}{}
+			}
+		}
+	}
+	pool := c.pool[:0]
+	for _, r := range c.pool {
+		if _, ok := txIDs[r.txID]; ok && r.txID != "" {
+			delete(c.pooled, r.key)
+		}
+		if _, ok := c.pooled[r.key]; ok {
+			pool = append(pool, r)
+		}
+	}
+	c.pool = pool
+}
+
+// txID returns the transaction ID of a message, if it has one
+func txID(env *cb.Envelope) string {
+	chdr, err := utils.ChannelHeader(env)
+	if err != nil {
+		return ""
+	}
+	return chdr.TxId
+}
+
+func requestKey(content []byte) string {
+	digest := sha256.Sum256(content)
+	return string(digest[:])
+}
+
+// maybePropose proposes the next block if this replica is the primary of the current view,
+// no block is in flight, and either a full batch of requests is pooled or the batch timer expired
+func (c *chain) maybePropose() {
+	if c.primary(c.view) != c.id || c.viewChanging || c.proposal != nil || c.syncing {
+		return
+	}
+	var block *cb.Block
+	if c.required != nil && c.requiredHeight == c.height {
+		block = c.required
+	} else {
+		batch := c.cutBatch(time.Now())
+		if len(batch) == 0 {
+			return
+		}
+		block = c.support.CreateNextBlock(batch)
+	}
+	if block.Header.Number != c.height {
+		logger.Panicf("Replica %d of channel %s created block [%d] at height %d", c.id, c.channel, block.Header.Number, c.height)
+	}
+	logger.Debugf("Replica %d proposing block [%d] of channel %s in view %d", c.id, block.Header.Number, c.channel, c.view)
+	c.proposal = block
+	c.persist()
+	c.broadcast(&bftpb.Message{Type: bftpb.MessageType_PRE_PREPARE, View: c.view, Sequence: c.height, Block: block})
+	c.prepare()
+}
+
+// cutBatch returns the pooled requests which make the next block, if a batch is ready. A config
+// request is ordered in a block of its own. Unlike the block cutter, it leaves the requests in the
+// pool, so that they can be proposed again by the primary of a later view
+func (c *chain) cutBatch(now time.Time) []*cb.Envelope {
+	batchSize := c.support.SharedConfig().BatchSize()
+	var batch []*cb.Envelope
+	var size uint32
+	full := false
+	for i := 0; i < len(c.pool); i++ {
+		r := c.pool[i]
+		if r.request.ConfigSeq < c.support.Sequence() {
+			if err := c.validateRequest(r.request); err != nil {
+				logger.Warningf("Discarding bad request on channel %s: %s", c.channel, err)
+				delete(c.pooled, r.key)
+				c.pool = append(c.pool[:i], c.pool[i+1:]...)
+				i--
+				continue
+			}
+		}
+		if r.request.IsConfig {
+			if len(batch) == 0 {
+				return []*cb.Envelope{r.request.Content}
+			}
+			full = true
+			break
+		}
+		if len(batch) > 0 && size+uint32(r.size) > batchSize.PreferredMaxBytes {
+			full = true
+			break
+		}
+		batch = append(batch, r.request.Content)
+		size += uint32(r.size)
+		if uint32(len(batch)) >= batchSize.MaxMessageCount {
+			full = true
+			break
+		}
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+	if !full && now.Before(c.pool[0].arrived.Add(c.support.SharedConfig().BatchTimeout())) {
+		return nil
+	}
+	return batch
+}
+
+// handleOrdering dispatches the messages which order the block at the current height in the current view,
+// and defers those which are about a later height or view
+func (c *chain) handleOrdering(m *verifiedMessage) {
+	switch {
+	case m.View < c.view:
+		// the sender missed the installation of the current view
+		c.sendNewView(m.From)
+		if m.Sequence > c.height {
+			c.markAhead(m.From)
+		}
+		return
+	case m.View > c.view || c.viewChanging || m.Sequence > c.height:
+		c.deferMessage(m)
+		return
+	case m.Sequence < c.height:
+		if m.Type == bftpb.MessageType_PRE_PREPARE && m.Sequence+1 == c.height {
+			c.endorseLastBlock(m)
+		}
+		return
+	}
+
+	switch m.Type {
+	case bftpb.MessageType_PRE_PREPARE:
+		c.handlePrePrepare(m)
+	case bftpb.MessageType_PREPARE:
+		if _, ok := c.prepares[m.From]; !ok {
+			c.prepares[m.From] = m
+			c.checkPrepared()
+		}
+	case bftpb.MessageType_COMMIT:
+		if _, ok := c.commits[m.From]; !ok && m.BlockSignature != nil {
+			c.commits[m.From] = &commitVote{msg: m}
+			c.checkCommitted()
+		}
+	}
+}
+
+func (c *chain) handlePrePrepare(m *verifiedMessage) {
+	if m.From != c.primary(c.view) {
+		logger.Warningf("Replica %d of channel %s received a proposal from replica %d, which is not the primary of view %d",
+			c.id, c.channel, m.From, c.view)
+		return
+	}
+	if c.proposal != nil {
+		if m.Block == nil || m.Block.Header == nil || !proto.Equal(m.Block.Header, c.proposal.Header) {
+			logger.Warningf("Primary %d of channel %s proposed conflicting blocks [%d] in view %d", m.From, c.channel, c.height, c.view)
+			c.startViewChange(c.view + 1)
+		}
+		return
+	}
+	if err := c.validateProposal(m.Block); err != nil {
+		logger.Warningf("Primary %d of channel %s proposed an invalid block in view %d: %s", m.From, c.channel, c.view, err)
+		c.startViewChange(c.view + 1)
+		return
+	}
+	c.acceptProposal(m.Block)
+}
+
+// validateProposal checks that the proposed block extends the ledger and that its content is valid
+func (c *chain) validateProposal(block *cb.Block) error {
+	if block == nil || block.Header == nil || block.Data == nil {
+		return errors.New("the block is missing its header or data")
+	}
+	if block.Header.Number != c.height {
+		return errors.Errorf("the block number is %d, while the height of the ledger is %d", block.Header.Number, c.height)
+	}
+	if err := c.checkLink(block); err != nil {
+		return err
+	}
+	if c.required != nil && c.requiredHeight == c.height {
+		if !proto.Equal(block.Header, c.required.Header) {
+			return errors.New("the block is not the one prepared in the previous view")
+		}
+		return nil
+	}
+
+	batchSize := c.support.SharedConfig().BatchSize()
+	if len(block.Data.Data) == 0 || uint32(len(block.Data.Data)) > batchSize.MaxMessageCount {
+		return errors.Errorf("the block contains %d messages, while up to %d are allowed", len(block.Data.Data), batchSize.MaxMessageCount)
+	}
+	for i, data := range block.Data.Data {
+		if uint32(len(data)) > batchSize.AbsoluteMaxBytes {
+			return errors.Errorf("message %d is larger than %d bytes", i, batchSize.AbsoluteMaxBytes)
+		}
+		env, err := utils.UnmarshalEnvelope(data)
+		if err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("bad message %d", i))
+		}
+		chdr, err := utils.ChannelHeader(env)
+		if err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("bad message %d", i))
+		}
+		switch c.support.ClassifyMsg(chdr) {
+		case msgprocessor.NormalMsg:
+			if _, err := c.support.ProcessNormalMsg(env); err != nil {
+				return errors.WithMessage(err, fmt.Sprintf("bad normal message %d", i))
+			}
+		case msgprocessor.ConfigMsg:
+			if len(block.Data.Data) != 1 {
+				return errors.New("a config message must be ordered in a block of its own")
+			}
+			if _, _, err := c.support.ProcessConfigMsg(env); err != nil {
+				return errors.WithMessage(err, "bad config message")
+			}
+			if err := c.checkConfig(env); err != nil {
+				return errors.WithMessage(err, "bad config message")
+			}
+		default:
+			return errors.Errorf("message %d is of an unexpected type", i)
+		}
+	}
+	return nil
+}
+
+// checkLink checks that the block follows the last block in the ledger
+func (c *chain) checkLink(block *cb.Block) error {
+	if !proto.Equal(block.Header, &cb.BlockHeader{
+		Number:       c.lastBlock.Header.Number + 1,
+		PreviousHash: c.lastBlock.Header.Hash(),
+		DataHash:     block.Data.Hash(),
+	}) {
+		return errors.Errorf("block [%d] does not follow block [%d] or its data hash is invalid",
+			block.Header.Number, c.lastBlock.Header.Number)
+	}
+	return nil
+}
+
+// acceptProposal records the block in flight and prepares it
+func (c *chain) acceptProposal(block *cb.Block) {
+	c.proposal = block
+	c.persist()
+	c.prepare()
+}
+
+// prepare broadcasts a PREPARE for the block in flight
+func (c *chain) prepare() {
+	prepare := c.broadcast(&bftpb.Message{
+		Type:     bftpb.MessageType_PREPARE,
+		View:     c.view,
+		Sequence: c.height,
+		Digest:   c.proposal.Header.Hash(),
+	})
+	c.prepares[c.id] = prepare
+	c.checkPrepared()
+	c.checkCommitted()
+}
+
+// checkPrepared broadcasts a COMMIT with a signature over the block in flight, once a quorum of replicas prepared it
+func (c *chain) checkPrepared() {
+	if c.proposal == nil || c.commits[c.id] != nil {
+		return
+	}
+	digest := c.proposal.Header.Hash()
+	var prepares []*bftpb.SignedMessage
+	for _, from := range sortedKeys(c.prepares) {
+		if p := c.prepares[from]; string(p.Digest) == string(digest) {
+			prepares = append(prepares, p.signed)
+		}
+	}
+	if len(prepares) < c.quorum {
+		return
+	}
+	c.prepared = &bftpb.PreparedCertificate{View: c.view, Block: c.proposal, Prepares: prepares}
+	c.persist()
+	commit := c.broadcast(&bftpb.Message{
+		Type:           bftpb.MessageType_COMMIT,
+		View:           c.view,
+		Sequence:       c.height,
+		Digest:         digest,
+		BlockSignature: c.signBlock(c.proposal.Header),
+	})
+	c.commits[c.id] = &commitVote{msg: commit, verified: true}
+	c.checkCommitted()
+}
+
+// checkCommitted writes the block in flight, once a quorum of replicas signed it
+func (c *chain) checkCommitted() {
+	if c.proposal == nil {
+		return
+	}
+	digest := c.proposal.Header.Hash()
+	var signatures []*cb.MetadataSignature
+	for _, from := range sortedKeys(c.commits) {
+		vote := c.commits[from]
+		if string(vote.msg.Digest) != string(digest) {
+			continue
+		}
+		if !vote.verified {
+			if err := c.verifyBlockSignature(from, vote.msg.BlockSignature, c.proposal.Header); err != nil {
+				logger.Warningf("Discarding the commit of replica %d of channel %s: %s", from, c.channel, err)
+				delete(c.commits, from)
+				continue
+			}
+			vote.verified = true
+		}
+		signatures = append(signatures, vote.msg.BlockSignature)
+	}
+	if len(signatures) < c.quorum {
+		return
+	}
+
+	// the proposal may still be sent to other replicas, while the ledger modifies the metadata of the block
+	block := proto.Clone(c.proposal).(*cb.Block)
+	block.Metadata.Metadata[cb.BlockMetadataIndex_SIGNATURES] = utils.MarshalOrPanic(&cb.Metadata{Signatures: signatures})
+	logger.Debugf("Replica %d committed block [%d] of channel %s in view %d", c.id, block.Header.Number, c.channel, c.view)
+	c.writeBlock(block, utils.MarshalOrPanic(&bftpb.BlockMetadata{View: c.view}))
+}
+
+// endorseLastBlock sends a PREPARE and a COMMIT for the last block in the ledger to the replicas which
+// are ordering it again in the current view, as they might not reach a quorum without this replica
+func (c *chain) endorseLastBlock(m *verifiedMessage) {
+	if m.From != c.primary(c.view) || c.endorsed == c.view+1 || m.Block == nil || m.Block.Header == nil ||
+		!proto.Equal(m.Block.Header, c.lastBlock.Header) {
+		return
+	}
+	c.endorsed = c.view + 1
+	digest := c.lastBlock.Header.Hash()
+	c.broadcast(&bftpb.Message{Type: bftpb.MessageType_PREPARE, View: c.view, Sequence: m.Sequence, Digest: digest})
+	c.broadcast(&bftpb.Message{
+		Type:           bftpb.MessageType_COMMIT,
+		View:           c.view,
+		Sequence:       m.Sequence,
+		Digest:         digest,
+		BlockSignature: c.signBlock(c.lastBlock.Header),
+	})
+}
+
+// writeBlock writes a block carrying the signatures of a quorum, and moves on to the next height
+func (c *chain) writeBlock(block *cb.Block, metadata []byte) {
+	isConfig := c.isConfigBlock(block)
+	c.lastBlock = stripBlock(block)
+	if isConfig {
+		c.support.WriteConfigBlock(block, metadata)
+	} else {
+		c.support.WriteBlock(block, metadata)
+	}
+	c.height = block.Header.Number + 1
+	c.lastProgress = time.Now()
+	c.removeRequests(block.Data.Data)
+	if isConfig {
+		c.revalidatePool()
+	}
+	c.resetRound()
+	c.prepared = nil
+	if c.required != nil && c.requiredHeight < c.height {
+		c.required = nil
+	}
+	c.ahead = make(map[uint64]bool)
+	c.replayPending()
+	c.maybePropose()
+}
+
+// resetRound forgets the block in flight, when the height or the view changes
+func (c *chain) resetRound() {
+	c.proposal = nil
+	c.prepares = make(map[uint64]*verifiedMessage)
+	c.commits = make(map[uint64]*commitVote)
+}
+
+func (c *chain) isConfigBlock(block *cb.Block) bool {
+	if len(block.Data.Data) != 1 {
+		return false
+	}
+	env, err := utils.UnmarshalEnvelope(block.Data.Data[0])
+	if err != nil {
+		return false
+	}
+	chdr, err := utils.ChannelHeader(env)
+	if err != nil {
+		return false
+	}
+	return c.support.ClassifyMsg(chdr) == msgprocessor.ConfigMsg
+}
+
+// deferMessage keeps a message about a later height or view until this replica gets there.
+// If f+1 replicas are seen ahead, at least one correct replica is, and this replica catches up
+func (c *chain) deferMessage(m *verifiedMessage) {
+	if len(c.pending) < maxPendingMsgs {
+		c.pending = append(c.pending, m)
+	}
+	if m.View > c.view || m.Sequence > c.height {
+		c.markAhead(m.From)
+	}
+}
+
+func (c *chain) markAhead(from uint64) {
+	c.ahead[from] = true
+	if len(c.ahead) > c.f && !c.syncing {
+		c.requestSync(sortedKeys(c.ahead))
+	}
+}
+
+// replayPending handles again the deferred messages, once the height or the view changes
+func (c *chain) replayPending() {
+	pending := c.pending
+	c.pending = nil
+	for _, m := range pending {
+		if m.View < c.view || (m.View == c.view && m.Sequence < c.height) {
+			continue
+		}
+		c.handleOrdering(m)
+	}
+}
+
+func (c *chain) sign(m *bftpb.Message) *bftpb.SignedMessage {
+	m.From = c.id
+	msgBytes := utils.MarshalOrPanic(m)
+	sig, err := c.support.Sign(msgBytes)
+	if err != nil {
+		logger.Panicf("Failed to sign a bft message of channel %s: %s", c.channel, err)
+	}
+	return &bftpb.SignedMessage{Message: msgBytes, Signature: sig}
+}
+
+// broadcast sends a message to all the other replicas, and returns it as verified for this replica to record
+func (c *chain) broadcast(m *bftpb.Message) *verifiedMessage {
+	sm := c.sign(m)
+	for _, peer := range sortedKeys(c.sendCs) {
+		c.send(peer, sm)
+	}
+	return &verifiedMessage{Message: m, signed: sm}
+}
+
+func (c *chain) send(to uint64, sm *bftpb.SignedMessage) {
+	select {
+	case c.sendCs[to] <- sm:
+	default:
+		logger.Debugf("Dropping a bft message of channel %s to replica %d, as its send buffer is full", c.channel, to)
+	}
+}
+
+func (c *chain) sendLoop(endpoint string, sendC chan *bftpb.SignedMessage) {
+	for {
+		select {
+		case sm := <-sendC:
+			if err := c.communicator.Step(endpoint, &bftpb.StepRequest{Channel: c.channel, Message: sm}); err != nil {
+				logger.Debugf("Failed to send a bft message of channel %s to %s: %s", c.channel, endpoint, err)
+			}
+		case <-c.haltC:
+			return
+		}
+	}
+}
+
+// signBlock signs the block header the same way the orderer signs the blocks it writes
+func (c *chain) signBlock(header *cb.BlockHeader) *cb.MetadataSignature {
+	shdr := utils.MarshalOrPanic(utils.NewSignatureHeaderOrPanic(c.support))
+	return &cb.MetadataSignature{
+		SignatureHeader: shdr,
+		Signature:       utils.SignOrPanic(c.support, util.ConcatenateBytes(shdr, header.Bytes())),
+	}
+}
+
+// verifyBlockSignature checks that the signature over the block header was made by the given consenter
+func (c *chain) verifyBlockSignature(from uint64, signature *cb.MetadataSignature, header *cb.BlockHeader) error {
+	shdr, err := utils.GetSignatureHeader(signature.SignatureHeader)
+	if err != nil {
+		return err
+	}
+	signer, err := c.support.MSPManager().DeserializeIdentity(shdr.Creator)
+	if err != nil {
+		return err
+	}
+	if *signer.GetIdentifier() != *c.identities[from-1].GetIdentifier() {
+		return errors.Errorf("the block signature is not made by consenter %d", from)
+	}
+	return c.identities[from-1].Verify(util.ConcatenateBytes(signature.SignatureHeader, header.Bytes()), signature.Signature)
+}
+
+// checkConfig rejects the config updates that change the consensus type or the set of consenters
+func (c *chain) checkConfig(config *cb.Envelope) error {
+	payload, err := utils.UnmarshalPayload(config.Payload)
+	if err != nil {
+		return err
+	}
+	if payload.Header == nil {
+		return errors.New("config message is missing the payload header")
+	}
+	chdr, err := utils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return err
+	}
+	if chdr.Type != int32(cb.HeaderType_CONFIG) {
+		// e.g. the creation of a new channel on the system channel
+		return nil
+	}
+	configEnv, err := configtx.UnmarshalConfigEnvelope(payload.Data)
+	if err != nil {
+		return err
+	}
+	if configEnv.Config == nil || configEnv.Config.ChannelGroup == nil {
+		return errors.New("config envelope is missing the channel group")
+	}
+	ordererGroup, ok := configEnv.Config.ChannelGroup.Groups[channelconfig.OrdererGroupKey]
+	if !ok {
+		return errors.New("config is missing the orderer group")
+	}
+	consensusTypeValue, ok := ordererGroup.Values[channelconfig.ConsensusTypeKey]
+	if !ok {
+		return errors.New("config is missing the consensus type")
+	}
+	consensusType := &ab.ConsensusType{}
+	if err := proto.Unmarshal(consensusTypeValue.Value, consensusType); err != nil {
+		return errors.Wrap(err, "error unmarshaling consensus type")
+	}
+	if consensusType.Type != consensusTypeBFT {
+		return errors.Errorf("changing the consensus type of channel %s to %s is not supported", c.channel, consensusType.Type)
+	}
+	updated, err := unmarshalConfigMetadata(consensusType.Metadata)
+	if err != nil {
+		return err
+	}
+	current, err := unmarshalConfigMetadata(c.support.SharedConfig().ConsensusMetadata())
+	if err != nil {
+		return err
+	}
+	if len(updated.Consenters) != len(current.Consenters) {
+		return errors.Errorf("updating the consenters of channel %s is not supported", c.channel)
+	}
+	for i := range current.Consenters {
+		if !proto.Equal(updated.Consenters[i], current.Consenters[i]) {
+			return errors.Errorf("updating the consenters of channel %s is not supported", c.channel)
+		}
+	}
+	return nil
+}
+
+// stripBlock returns a copy of the header and the signatures of the block, which prove that the block was committed
+func stripBlock(block *cb.Block) *cb.Block {
+	stripped := cb.NewBlock(block.Header.Number, block.Header.PreviousHash)
+	stripped.Header.DataHash = block.Header.DataHash
+	stripped.Data = nil
+	if block.Metadata != nil && len(block.Metadata.Metadata) > int(cb.BlockMetadataIndex_SIGNATURES) {
+		stripped.Metadata.Metadata[cb.BlockMetadataIndex_SIGNATURES] = block.Metadata.Metadata[cb.BlockMetadataIndex_SIGNATURES]
+	}
+	return stripped
+}
+
+func sortedKeys(m interface{}) []uint64 {
+	var keys []uint64
+	switch m := m.(type) {
+	case map[uint64]*verifiedMessage:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[uint64]*commitVote:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[uint64]chan *bftpb.SignedMessage:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[uint64]bool:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -0,0 +1,720 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bft
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	"github.com/hyperledger/fabric/common/configtx"
+	"github.com/hyperledger/fabric/common/deliver"
+	"github.com/hyperledger/fabric/common/flogging"
+	mockconfig "github.com/hyperledger/fabric/common/mocks/config"
+	mockmsp "github.com/hyperledger/fabric/common/mocks/msp"
+	"github.com/hyperledger/fabric/common/util"
+	localconfig "github.com/hyperledger/fabric/orderer/common/localconfig"
+	"github.com/hyperledger/fabric/orderer/common/msgprocessor"
+	mockmultichannel "github.com/hyperledger/fabric/orderer/mocks/common/multichannel"
+	cb "github.com/hyperledger/fabric/protos/common"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	bftpb "github.com/hyperledger/fabric/protos/orderer/bft"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+const (
+	testChannel = "testchannel"
+	testTimeout = 20 * time.Second
+)
+
+func init() {
+	flogging.SetModuleLevel(pkgLogID, "DEBUG")
+}
+
+func testMessage(i int) *cb.Envelope {
+	return &cb.Envelope{
+		Payload: utils.MarshalOrPanic(&cb.Payload{
+			Header: &cb.Header{ChannelHeader: utils.MarshalOrPanic(&cb.ChannelHeader{ChannelId: testChannel})},
+			Data:   []byte(fmt.Sprintf("TEST_MESSAGE_%d", i)),
+		}),
+	}
+}
+
+func testConfigMessage(metadata []byte) *cb.Envelope {
+	consensusType := utils.MarshalOrPanic(&ab.ConsensusType{Type: consensusTypeBFT, Metadata: metadata})
+	configEnv := &cb.ConfigEnvelope{
+		Config: &cb.Config{
+			ChannelGroup: &cb.ConfigGroup{
+				Groups: map[string]*cb.ConfigGroup{
+					channelconfig.OrdererGroupKey: {
+						Values: map[string]*cb.ConfigValue{
+							channelconfig.ConsensusTypeKey: {Value: consensusType},
+						},
+					},
+				},
+			},
+		},
+	}
+	return &cb.Envelope{
+		Payload: utils.MarshalOrPanic(&cb.Payload{
+			Header: &cb.Header{ChannelHeader: utils.MarshalOrPanic(&cb.ChannelHeader{
+				Type:      int32(cb.HeaderType_CONFIG),
+				ChannelId: testChannel,
+			})},
+			Data: utils.MarshalOrPanic(configEnv),
+		}),
+	}
+}
+
+// testConfigMetadata lists the given identities as consenters, and returns the endpoints of the consenters
+func testConfigMetadata(identities ...*mockmsp.SigningIdentity) ([]byte, []string) {
+	metadata := &bftpb.ConfigMetadata{
+		Options: &bftpb.Options{RequestTimeout: 500, ViewChangeTimeout: 1000},
+	}
+	var endpoints []string
+	for i, identity := range identities {
+		metadata.Consenters = append(metadata.Consenters, &bftpb.Consenter{
+			Host:     "bft.example.com",
+			Port:     uint32(7050 + i),
+			Identity: identity.SerializeOrPanic(),
+		})
+		endpoints = append(endpoints, fmt.Sprintf("bft.example.com:%d", 7050+i))
+	}
+	return utils.MarshalOrPanic(metadata), endpoints
+}
+
+// testLedger is a thread-safe in-memory ledger that outlives the restarts of a consenter
+type testLedger struct {
+	sync.Mutex
+	blocks []*cb.Block
+}
+
+func newTestLedger() *testLedger {
+	genesis := cb.NewBlock(0, nil)
+	genesis.Header.DataHash = genesis.Data.Hash()
+	return &testLedger{blocks: []*cb.Block{genesis}}
+}
+
+func (l *testLedger) height() uint64 {
+	l.Lock()
+	defer l.Unlock()
+	return uint64(len(l.blocks))
+}
+
+func (l *testLedger) block(number uint64) *cb.Block {
+	l.Lock()
+	defer l.Unlock()
+	if number >= uint64(len(l.blocks)) {
+		return nil
+	}
+	return l.blocks[number]
+}
+
+// testSupport implements consensus.ConsenterSupport over a testLedger, signing with the identity of a consenter
+type testSupport struct {
+	*mockmultichannel.ConsenterSupport
+	signer *mockmsp.SigningIdentity
+	ledger *testLedger
+}
+
+func newTestSupport(ledger *testLedger, metadata []byte, signers *mockmsp.Signers, signer *mockmsp.SigningIdentity) *testSupport {
+	sharedConfig := &mockconfig.Orderer{
+		ConsensusTypeVal:     consensusTypeBFT,
+		ConsensusMetadataVal: metadata,
+		BatchTimeoutVal:      100 * time.Millisecond,
+		BatchSizeVal: &ab.BatchSize{
+			MaxMessageCount:   2,
+			AbsoluteMaxBytes:  1024 * 1024,
+			PreferredMaxBytes: 1024 * 1024,
+		},
+	}
+	return &testSupport{
+		ConsenterSupport: &mockmultichannel.ConsenterSupport{
+			SharedConfigVal:     sharedConfig,
+			ChainIDVal:          testChannel,
+			MSPManagerVal:       signers,
+			ProcessConfigMsgVal: testConfigMessage(metadata),
+		},
+		signer: signer,
+		ledger: ledger,
+	}
+}
+
+func (ts *testSupport) Sign(message []byte) ([]byte, error) {
+	return ts.signer.Sign(message)
+}
+
+func (ts *testSupport) NewSignatureHeader() (*cb.SignatureHeader, error) {
+	return ts.signer.NewSignatureHeader()
+}
+
+func (ts *testSupport) ClassifyMsg(chdr *cb.ChannelHeader) msgprocessor.Classification {
+	if chdr.Type == int32(cb.HeaderType_CONFIG) {
+		return msgprocessor.ConfigMsg
+	}
+	return msgprocessor.NormalMsg
+}
+
+func (ts *testSupport) CreateNextBlock(messages []*cb.Envelope) *cb.Block {
+	last := ts.ledger.block(ts.ledger.height() - 1)
+	data := &cb.BlockData{}
+	for _, msg := range messages {
+		data.Data = append(data.Data, utils.MarshalOrPanic(msg))
+	}
+	block := cb.NewBlock(last.Header.Number+1, last.Header.Hash())
+	block.Header.DataHash = data.Hash()
+	block.Data = data
+	return block
+}
+
+func (ts *testSupport) WriteBlock(block *cb.Block, encodedMetadataValue []byte) {
+	block.Metadata.Metadata[cb.BlockMetadataIndex_ORDERER] = utils.MarshalOrPanic(&cb.Metadata{Value: encodedMetadataValue})
+	ts.ledger.Lock()
+	defer ts.ledger.Unlock()
+	last := ts.ledger.blocks[len(ts.ledger.blocks)-1]
+	if block.Header.Number != last.Header.Number+1 || !proto.Equal(block.Header, &cb.BlockHeader{
+		Number: block.Header.Number, PreviousHash: last.Header.Hash(), DataHash: block.Header.DataHash}) {
+		panic(fmt.Sprintf("block [%d] does not follow block [%d]", block.Header.Number, last.Header.Number))
+	}
+	ts.ledger.blocks = append(ts.ledger.blocks, block)
+}
+
+func (ts *testSupport) WriteConfigBlock(block *cb.Block, encodedMetadataValue []byte) {
+	ts.WriteBlock(block, encodedMetadataValue)
+}
+
+func (ts *testSupport) Height() uint64 {
+	return ts.ledger.height()
+}
+
+func (ts *testSupport) Block(number uint64) *cb.Block {
+	return ts.ledger.block(number)
+}
+
+// testNetwork connects the consenters of a test in-process. Its interceptor may drop or
+// alter the messages between consenters, to inject faults
+type testNetwork struct {
+	sync.RWMutex
+	t           *testing.T
+	dir         string
+	signers     *mockmsp.Signers
+	identities  []*mockmsp.SigningIdentity
+	metadata    []byte
+	endpoints   []string
+	connected   map[string]*Consenter
+	nodes       map[string]*testNode
+	interceptor func(from, to string, sm *bftpb.SignedMessage) *bftpb.SignedMessage
+}
+
+type testNode struct {
+	endpoint  string
+	consenter *Consenter
+	support   *testSupport
+	ledger    *testLedger
+	chain     *chain
+}
+
+// testCommunicator delivers the requests of a consenter to the other connected consenters
+type testCommunicator struct {
+	from    string
+	network *testNetwork
+}
+
+func (tc *testCommunicator) Step(endpoint string, req *bftpb.StepRequest) error {
+	c, interceptor, err := tc.network.route(tc.from, endpoint)
+	if err != nil {
+		return err
+	}
+	req = proto.Clone(req).(*bftpb.StepRequest)
+	if interceptor != nil {
+		if req.Message = interceptor(tc.from, endpoint, req.Message); req.Message == nil {
+			return nil
+		}
+	}
+	_, err = c.Step(context.Background(), req)
+	return err
+}
+
+func newTestNetwork(t *testing.T, size int) *testNetwork {
+	signers := mockmsp.NewSigners()
+	var identities []*mockmsp.SigningIdentity
+	for i := 0; i < size; i++ {
+		identities = append(identities, signers.NewIdentity("OrdererOrg", fmt.Sprintf("orderer%d", i+1)))
+	}
+	metadata, endpoints := testConfigMetadata(identities...)
+	dir, err := ioutil.TempDir("", "bft")
+	assert.NoError(t, err)
+	network := &testNetwork{
+		t:          t,
+		dir:        dir,
+		signers:    signers,
+		identities: identities,
+		metadata:   metadata,
+		endpoints:  endpoints,
+		connected:  make(map[string]*Consenter),
+		nodes:      make(map[string]*testNode),
+	}
+	for _, endpoint := range endpoints {
+		network.start(endpoint, newTestLedger())
+	}
+	return network
+}
+
+func (n *testNetwork) route(from, to string) (*Consenter, func(string, string, *bftpb.SignedMessage) *bftpb.SignedMessage, error) {
+	n.RLock()
+	defer n.RUnlock()
+	if _, ok := n.connected[from]; !ok {
+		return nil, nil, errors.Errorf("%s is disconnected", from)
+	}
+	c, ok := n.connected[to]
+	if !ok {
+		return nil, nil, errors.Errorf("%s is unreachable", to)
+	}
+	return c, n.interceptor, nil
+}
+
+func (n *testNetwork) intercept(interceptor func(from, to string, sm *bftpb.SignedMessage) *bftpb.SignedMessage) {
+	n.Lock()
+	defer n.Unlock()
+	n.interceptor = interceptor
+}
+
+// start starts a consenter, which resumes from the state it persisted in the directory of its endpoint,
+// or else from the view recorded in the last block of its ledger
+func (n *testNetwork) start(endpoint string, ledger *testLedger) *testNode {
+	conf := localconfig.BFT{StateDir: filepath.Join(n.dir, endpoint), Endpoint: endpoint}
+	consenter := newConsenter(conf, &testCommunicator{from: endpoint, network: n})
+	support := newTestSupport(ledger, n.metadata, n.signers, n.identity(endpoint))
+	lastBlock := ledger.block(ledger.height() - 1)
+	metadata, err := utils.GetMetadataFromBlock(lastBlock, cb.BlockMetadataIndex_ORDERER)
+	assert.NoError(n.t, err)
+	ch, err := consenter.HandleChain(support, metadata)
+	assert.NoError(n.t, err)
+
+	node := &testNode{endpoint: endpoint, consenter: consenter, support: support, ledger: ledger, chain: ch.(*chain)}
+	n.Lock()
+	n.connected[endpoint] = consenter
+	n.nodes[endpoint] = node
+	n.Unlock()
+	ch.Start()
+	return node
+}
+
+func (n *testNetwork) identity(endpoint string) *mockmsp.SigningIdentity {
+	for i, e := range n.endpoints {
+		if e == endpoint {
+			return n.identities[i]
+		}
+	}
+	return nil
+}
+
+// stop disconnects and halts a consenter, and closes its persisted state
+func (n *testNetwork) stop(endpoint string) *testNode {
+	n.Lock()
+	node := n.nodes[endpoint]
+	delete(n.connected, endpoint)
+	delete(n.nodes, endpoint)
+	n.Unlock()
+	node.chain.Halt()
+	node.consenter.stateProvider.Close()
+	return node
+}
+
+func (n *testNetwork) stopAll() {
+	for _, endpoint := range n.endpoints {
+		if _, ok := n.nodes[endpoint]; ok {
+			n.stop(endpoint)
+		}
+	}
+	os.RemoveAll(n.dir)
+}
+
+func (n *testNetwork) node(i int) *testNode {
+	return n.nodes[n.endpoints[i]]
+}
+
+// waitForHeight waits until the ledgers of all the running consenters reach the given height, and checks
+// that they contain the same blocks, each carrying the signatures of a quorum of consenters
+func (n *testNetwork) waitForHeight(height uint64) {
+	deadline := time.Now().Add(testTimeout)
+	for _, node := range n.nodes {
+		for node.ledger.height() < height {
+			if time.Now().After(deadline) {
+				n.t.Fatalf("Ledger of %s did not reach height %d in time, height is %d",
+					node.endpoint, height, node.ledger.height())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		assert.Equal(n.t, height, node.ledger.height(), "ledger of %s", node.endpoint)
+	}
+	var reference *testNode
+	for _, node := range n.nodes {
+		for i := uint64(1); i < height; i++ {
+			assert.NoError(n.t, deliver.VerifyBlockQuorum(node.ledger.block(i), node.support.SharedConfig(), n.signers),
+				"block [%d] of %s", i, node.endpoint)
+		}
+		if reference == nil {
+			reference = node
+			continue
+		}
+		for i := uint64(0); i < height; i++ {
+			assert.Equal(n.t, reference.ledger.block(i).Header, node.ledger.block(i).Header,
+				"block [%d] of %s and %s", i, reference.endpoint, node.endpoint)
+		}
+	}
+}
+
+// orderedMessages returns the messages in the ledger of the node, in order
+func (node *testNode) orderedMessages() [][]byte {
+	var messages [][]byte
+	for i := uint64(1); i < node.ledger.height(); i++ {
+		messages = append(messages, node.ledger.block(i).Data.Data...)
+	}
+	return messages
+}
+
+func blockView(t *testing.T, block *cb.Block) uint64 {
+	metadata := &bftpb.BlockMetadata{}
+	assert.NoError(t, proto.Unmarshal(utils.GetMetadataFromBlockOrPanic(block, cb.BlockMetadataIndex_ORDERER).Value, metadata))
+	return metadata.View
+}
+
+func TestSingleConsenter(t *testing.T) {
+	network := newTestNetwork(t, 1)
+	defer network.stopAll()
+	node := network.node(0)
+
+	assert.NoError(t, node.chain.Order(testMessage(1), 0))
+	assert.NoError(t, node.chain.Order(testMessage(2), 0))
+	network.waitForHeight(2)
+	block := node.ledger.block(1)
+	assert.Len(t, block.Data.Data, 2)
+	assert.Equal(t, uint64(0), blockView(t, block))
+	signatures := utils.GetMetadataFromBlockOrPanic(block, cb.BlockMetadataIndex_SIGNATURES).Signatures
+	assert.Len(t, signatures, 1)
+
+	// a partial batch is cut by the batch timer
+	assert.NoError(t, node.chain.Order(testMessage(3), 0))
+	network.waitForHeight(3)
+	assert.Len(t, node.ledger.block(2).Data.Data, 1)
+}
+
+func TestAgreementAmongConsenters(t *testing.T) {
+	network := newTestNetwork(t, 4)
+	defer network.stopAll()
+
+	// messages submitted to any consenter are ordered by the primary
+	for i := 0; i < 8; i++ {
+		assert.NoError(t, network.node(i%4).chain.Order(testMessage(i), 0))
+	}
+	network.waitForHeight(5)
+	for _, node := range network.nodes {
+		assert.Len(t, node.orderedMessages(), 8)
+		for i := uint64(1); i < 5; i++ {
+			signatures := utils.GetMetadataFromBlockOrPanic(node.ledger.block(i), cb.BlockMetadataIndex_SIGNATURES).Signatures
+			assert.True(t, len(signatures) >= 3, "block [%d] of %s carries %d signatures", i, node.endpoint, len(signatures))
+		}
+	}
+
+	// a message submitted to several consenters is ordered once
+	for _, node := range network.nodes {
+		assert.NoError(t, node.chain.Order(testMessage(8), 0))
+	}
+	network.waitForHeight(6)
+	assert.Equal(t, [][]byte{utils.MarshalOrPanic(testMessage(8))}, network.node(0).ledger.block(5).Data.Data)
+}
+
+func TestConfigUpdates(t *testing.T) {
+	network := newTestNetwork(t, 4)
+	defer network.stopAll()
+	node := network.node(1)
+
+	// a config message is ordered in a block of its own
+	assert.NoError(t, node.chain.Order(testMessage(1), 0))
+	assert.NoError(t, node.chain.Configure(testConfigMessage(network.metadata), 0))
+	network.waitForHeight(3)
+	assert.Equal(t, [][]byte{utils.MarshalOrPanic(testMessage(1))}, node.ledger.block(1).Data.Data)
+	assert.Equal(t, [][]byte{utils.MarshalOrPanic(testConfigMessage(network.metadata))}, node.ledger.block(2).Data.Data)
+
+	// updating the consenters is not supported
+	metadata, _ := testConfigMetadata(network.identities[:3]...)
+	err := node.chain.Configure(testConfigMessage(metadata), 0)
+	assert.EqualError(t, err, "updating the consenters of channel testchannel is not supported")
+	consensusType := utils.MarshalOrPanic(&ab.ConsensusType{Type: "solo"})
+	configEnv := testConfigMessage(nil)
+	payload := utils.UnmarshalPayloadOrPanic(configEnv.Payload)
+	config := configtx.UnmarshalConfigEnvelopeOrPanic(payload.Data)
+	config.Config.ChannelGroup.Groups[channelconfig.OrdererGroupKey].Values[channelconfig.ConsensusTypeKey].Value = consensusType
+	payload.Data = utils.MarshalOrPanic(config)
+	configEnv.Payload = utils.MarshalOrPanic(payload)
+	err = node.chain.Configure(configEnv, 0)
+	assert.EqualError(t, err, "changing the consensus type of channel testchannel to solo is not supported")
+}
+
+func TestPrimaryFailover(t *testing.T) {
+	network := newTestNetwork(t, 4)
+	defer network.stopAll()
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, network.node(1).chain.Order(testMessage(i), 0))
+	}
+	network.waitForHeight(3)
+
+	// the remaining consenters suspect the crashed primary, and move to a view where they keep ordering
+	stopped := network.stop(network.endpoints[0])
+	for i := 4; i < 8; i++ {
+		assert.NoError(t, network.node(2).chain.Order(testMessage(i), 0))
+	}
+	network.waitForHeight(5)
+	assert.Equal(t, uint64(3), stopped.ledger.height())
+	assert.Equal(t, uint64(1), blockView(t, network.node(1).ledger.block(4)))
+
+	// the restarted consenter catches up with the blocks and the view it missed
+	network.start(stopped.endpoint, stopped.ledger)
+	assert.NoError(t, network.node(3).chain.Order(testMessage(8), 0))
+	network.waitForHeight(6)
+	assert.NoError(t, network.node(0).chain.Order(testMessage(9), 0))
+	network.waitForHeight(7)
+	for _, node := range network.nodes {
+		assert.Len(t, node.orderedMessages(), 10)
+	}
+}
+
+func TestRestartAllConsenters(t *testing.T) {
+	network := newTestNetwork(t, 4)
+	defer network.stopAll()
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, network.node(0).chain.Order(testMessage(i), 0))
+	}
+	network.waitForHeight(2)
+
+	// every consenter prepares the next block, but no COMMIT gets through
+	var lock sync.Mutex
+	committing := make(map[string]bool)
+	network.intercept(func(from, to string, sm *bftpb.SignedMessage) *bftpb.SignedMessage {
+		m := &bftpb.Message{}
+		assert.NoError(t, proto.Unmarshal(sm.Message, m))
+		if m.Type != bftpb.MessageType_COMMIT {
+			return sm
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		committing[from] = true
+		return nil
+	})
+	for i := 2; i < 4; i++ {
+		assert.NoError(t, network.node(1).chain.Order(testMessage(i), 0))
+	}
+	deadline := time.Now().Add(testTimeout)
+	for {
+		lock.Lock()
+		prepared := len(committing)
+		lock.Unlock()
+		if prepared == 4 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Only %d consenters prepared the block in time", prepared)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// all the consenters crash, and resume from their persisted state: the prepared block, which might have
+	// been committed by a consenter, is committed instead of the messages submitted after the restart
+	var stopped []*testNode
+	for _, endpoint := range network.endpoints {
+		stopped = append(stopped, network.stop(endpoint))
+	}
+	network.intercept(nil)
+	for _, node := range stopped {
+		assert.Equal(t, uint64(2), node.ledger.height())
+		network.start(node.endpoint, node.ledger)
+	}
+	assert.NoError(t, network.node(2).chain.Order(testMessage(4), 0))
+	network.waitForHeight(4)
+	for _, node := range network.nodes {
+		assert.Equal(t, [][]byte{utils.MarshalOrPanic(testMessage(2)), utils.MarshalOrPanic(testMessage(3))}, node.ledger.block(2).Data.Data)
+		assert.Len(t, node.orderedMessages(), 5)
+	}
+}
+
+func TestEquivocatingPrimary(t *testing.T) {
+	network := newTestNetwork(t, 4)
+	defer network.stopAll()
+	primary := network.identities[0]
+
+	// the primary of the first view proposes a different block to half of the consenters
+	network.intercept(func(from, to string, sm *bftpb.SignedMessage) *bftpb.SignedMessage {
+		m := &bftpb.Message{}
+		assert.NoError(t, proto.Unmarshal(sm.Message, m))
+		if m.Type != bftpb.MessageType_PRE_PREPARE || m.View != 0 || (to != network.endpoints[2] && to != network.endpoints[3]) {
+			return sm
+		}
+		forged := cb.NewBlock(m.Block.Header.Number, m.Block.Header.PreviousHash)
+		forged.Data.Data = [][]byte{utils.MarshalOrPanic(testMessage(1000))}
+		forged.Header.DataHash = forged.Data.Hash()
+		m.Block = forged
+		msgBytes := utils.MarshalOrPanic(m)
+		sig, err := primary.Sign(msgBytes)
+		assert.NoError(t, err)
+		return &bftpb.SignedMessage{Message: msgBytes, Signature: sig}
+	})
+
+	// no conflicting block is committed, and the next primary orders the messages
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, network.node(1).chain.Order(testMessage(i), 0))
+	}
+	network.waitForHeight(3)
+	for _, node := range network.nodes {
+		assert.Len(t, node.orderedMessages(), 4)
+		assert.NotContains(t, node.orderedMessages(), utils.MarshalOrPanic(testMessage(1000)))
+		assert.Equal(t, uint64(1), blockView(t, node.ledger.block(1)))
+	}
+}
+
+func TestSilentConsenter(t *testing.T) {
+	network := newTestNetwork(t, 4)
+	defer network.stopAll()
+
+	// a consenter which sends no messages does not prevent the others from ordering
+	network.intercept(func(from, to string, sm *bftpb.SignedMessage) *bftpb.SignedMessage {
+		if from == network.endpoints[3] {
+			return nil
+		}
+		return sm
+	})
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, network.node(0).chain.Order(testMessage(i), 0))
+	}
+	network.waitForHeight(3)
+}
+
+func TestForgedMessages(t *testing.T) {
+	network := newTestNetwork(t, 4)
+	defer network.stopAll()
+	ch := network.node(0).chain
+	outsider := network.signers.NewIdentity("OrdererOrg", "outsider")
+
+	sign := func(signer *mockmsp.SigningIdentity, m *bftpb.Message) *bftpb.SignedMessage {
+		msgBytes := utils.MarshalOrPanic(m)
+		sig, err := signer.Sign(msgBytes)
+		assert.NoError(t, err)
+		return &bftpb.SignedMessage{Message: msgBytes, Signature: sig}
+	}
+
+	err := ch.handleStep(&bftpb.SignedMessage{Message: []byte("garbage")})
+	assert.Contains(t, err.Error(), "error unmarshaling bft message")
+	err = ch.handleStep(sign(network.identities[1], &bftpb.Message{Type: bftpb.MessageType_PREPARE}))
+	assert.EqualError(t, err, "message from unknown consenter 0")
+	err = ch.handleStep(sign(network.identities[1], &bftpb.Message{Type: bftpb.MessageType_PREPARE, From: 5}))
+	assert.EqualError(t, err, "message from unknown consenter 5")
+	err = ch.handleStep(sign(outsider, &bftpb.Message{Type: bftpb.MessageType_PREPARE, From: 2}))
+	assert.EqualError(t, err, "invalid signature on a message from consenter 2: the signature is invalid")
+	err = ch.handleStep(sign(network.identities[2], &bftpb.Message{Type: bftpb.MessageType_PREPARE, From: 2}))
+	assert.EqualError(t, err, "invalid signature on a message from consenter 2: the signature is invalid")
+	err = ch.handleStep(sign(network.identities[0], &bftpb.Message{Type: bftpb.MessageType_PREPARE, From: 1}))
+	assert.EqualError(t, err, "consenter 1 received a message from itself")
+	assert.NoError(t, ch.handleStep(sign(network.identities[1], &bftpb.Message{Type: bftpb.MessageType_PREPARE, From: 2})))
+}
+
+func TestSyncRejectsForgedBlocks(t *testing.T) {
+	signers := mockmsp.NewSigners()
+	var identities []*mockmsp.SigningIdentity
+	for i := 0; i < 4; i++ {
+		identities = append(identities, signers.NewIdentity("OrdererOrg", fmt.Sprintf("orderer%d", i+1)))
+	}
+	metadata, endpoints := testConfigMetadata(identities...)
+	ledger := newTestLedger()
+	support := newTestSupport(ledger, metadata, signers, identities[0])
+	dir, err := ioutil.TempDir("", "bft")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	consenter := newConsenter(localconfig.BFT{StateDir: dir, Endpoint: endpoints[0]}, &testCommunicator{network: &testNetwork{}})
+	ch, err := consenter.HandleChain(support, nil)
+	assert.NoError(t, err)
+	defer consenter.stateProvider.Close()
+	c := ch.(*chain)
+
+	syncResponse := func(signers ...*mockmsp.SigningIdentity) *verifiedMessage {
+		block := support.CreateNextBlock([]*cb.Envelope{testMessage(1)})
+		md := &cb.Metadata{}
+		for _, signer := range signers {
+			shdr := utils.MarshalOrPanic(utils.NewSignatureHeaderOrPanic(signer))
+			md.Signatures = append(md.Signatures, &cb.MetadataSignature{
+				SignatureHeader: shdr,
+				Signature:       utils.SignOrPanic(signer, util.ConcatenateBytes(shdr, block.Header.Bytes())),
+			})
+		}
+		block.Metadata.Metadata[cb.BlockMetadataIndex_SIGNATURES] = utils.MarshalOrPanic(md)
+		m := &bftpb.Message{Type: bftpb.MessageType_SYNC_RESPONSE, From: 2, Sequence: 1, Blocks: []*cb.Block{block}}
+		return &verifiedMessage{Message: m}
+	}
+
+	// a block signed by a single consenter, or by consenters short of a quorum, is forged
+	c.handle(syncResponse(identities[1]))
+	assert.Equal(t, uint64(1), ledger.height())
+	c.handle(syncResponse(identities[1], identities[1], identities[2]))
+	assert.Equal(t, uint64(1), ledger.height())
+	c.handle(syncResponse(identities[1], identities[2], signers.NewIdentity("OrdererOrg", "outsider")))
+	assert.Equal(t, uint64(1), ledger.height())
+
+	c.handle(syncResponse(identities[1], identities[2], identities[3]))
+	assert.Equal(t, uint64(2), ledger.height())
+	assert.Equal(t, uint64(2), c.height)
+	assert.NoError(t, deliver.VerifyBlockQuorum(ledger.block(1), support.SharedConfig(), signers))
+}
+
+func TestRemoveRequestsOfCommittedTransactions(t *testing.T) {
+	txMessage := func(txID string, i int) *cb.Envelope {
+		return &cb.Envelope{
+			Payload: utils.MarshalOrPanic(&cb.Payload{
+				Header: &cb.Header{ChannelHeader: utils.MarshalOrPanic(&cb.ChannelHeader{ChannelId: testChannel, TxId: txID})},
+				Data:   []byte(fmt.Sprintf("TEST_MESSAGE_%d", i)),
+			}),
+		}
+	}
+	tx1, tx1Again, tx2, noTxID := txMessage("tx1", 1), txMessage("tx1", 2), txMessage("tx2", 3), testMessage(4)
+
+	c := &chain{pooled: make(map[string]*pooledRequest)}
+	for _, env := range []*cb.Envelope{tx1, tx1Again, tx2, noTxID} {
+		content := utils.MarshalOrPanic(env)
+		r := &pooledRequest{key: requestKey(content), txID: txID(env), request: &bftpb.Request{Content: env}, size: len(content)}
+		c.pool = append(c.pool, r)
+		c.pooled[r.key] = r
+	}
+
+	// The duplicate of a committed transaction, which the block writer left out of the block, is removed as well
+	c.removeRequests([][]byte{utils.MarshalOrPanic(tx1), utils.MarshalOrPanic(noTxID)})
+	assert.Len(t, c.pool, 1)
+	assert.Len(t, c.pooled, 1)
+	assert.Equal(t, tx2, c.pool[0].request.Content)
+}
+
+func TestHaltedChain(t *testing.T) {
+	network := newTestNetwork(t, 1)
+	defer network.stopAll()
+	node := network.node(0)
+
+	node.chain.Halt()
+	select {
+	case <-node.chain.Errored():
+	default:
+		t.Fatal("Errored channel should be closed after halting")
+	}
+	assert.EqualError(t, node.chain.Order(testMessage(1), 0), "bft replica for channel testchannel is halted")
+	assert.EqualError(t, node.chain.Configure(testConfigMessage(network.metadata), 0), "bft replica for channel testchannel is halted")
+	assert.NoError(t, node.chain.WaitReady())
+}
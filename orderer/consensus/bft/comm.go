@@ -0,0 +1,77 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bft
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/core/comm"
+	bftpb "github.com/hyperledger/fabric/protos/orderer/bft"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// Communicator sends requests to the Cluster service of the other consenters
+type Communicator interface {
+	// Step sends a signed protocol message to the consenter at the given endpoint
+	Step(endpoint string, req *bftpb.StepRequest) error
+}
+
+const defaultCommTimeout = 5 * time.Second
+
+// grpcCommunicator implements Communicator over gRPC connections which are
+// established lazily and are shared by all the channels
+type grpcCommunicator struct {
+	sync.Mutex
+	client  comm.GRPCClient
+	timeout time.Duration
+	clients map[string]bftpb.ClusterClient
+}
+
+// NewCommunicator returns a Communicator which connects to the other consenters
+// using the given client configuration
+func NewCommunicator(config comm.ClientConfig) (Communicator, error) {
+	if config.Timeout == 0 {
+		config.Timeout = defaultCommTimeout
+	}
+	client, err := comm.NewGRPCClient(config)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create the cluster client")
+	}
+	return &grpcCommunicator{
+		client:  client,
+		timeout: config.Timeout,
+		clients: make(map[string]bftpb.ClusterClient),
+	}, nil
+}
+
+func (c *grpcCommunicator) Step(endpoint string, req *bftpb.StepRequest) error {
+	client, err := c.clusterClient(endpoint)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	_, err = client.Step(ctx, req)
+	return err
+}
+
+func (c *grpcCommunicator) clusterClient(endpoint string) (bftpb.ClusterClient, error) {
+	c.Lock()
+	defer c.Unlock()
+	if client, ok := c.clients[endpoint]; ok {
+		return client, nil
+	}
+	conn, err := c.client.NewConnection(endpoint, "")
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to connect to consenter at "+endpoint)
+	}
+	client := bftpb.NewClusterClient(conn)
+	c.clients[endpoint] = client
+	return client, nil
+}
@@ -0,0 +1,207 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bft
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/deliver"
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/core/comm"
+	"github.com/hyperledger/fabric/msp"
+	localconfig "github.com/hyperledger/fabric/orderer/common/localconfig"
+	"github.com/hyperledger/fabric/orderer/consensus"
+	cb "github.com/hyperledger/fabric/protos/common"
+	bftpb "github.com/hyperledger/fabric/protos/orderer/bft"
+	"github.com/op/go-logging"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+const pkgLogID = "orderer/consensus/bft"
+
+const consensusTypeBFT = deliver.ConsensusTypeBFT
+
+const (
+	defaultRequestTimeout    = 5000  // milliseconds
+	defaultViewChangeTimeout = 10000 // milliseconds
+)
+
+var logger *logging.Logger
+
+func init() {
+	logger = flogging.MustGetLogger(pkgLogID)
+}
+
+// Consenter implements a Byzantine-fault-tolerant consensus scheme in the spirit of PBFT. The consenters
+// of a channel, which are listed along with their identities in the consensus metadata of the channel config,
+// agree on every block as long as at most f out of 3f+1 of them are faulty, including malicious ones. Each
+// block carries the signatures of a quorum of consenters, which the deliver clients verify, so that a single
+// orderer cannot forge a block. Consenter also serves the Cluster service, over which the consenters
+// communicate with each other
+type Consenter struct {
+	sync.RWMutex
+	stateDir      string
+	endpoint      string
+	communicator  Communicator
+	stateProvider *leveldbhelper.Provider
+	chains        map[string]*chain
+}
+
+// New creates a BFT consenter. Called by orderer's main.go.
+func New(conf localconfig.BFT, clientConfig comm.ClientConfig) *Consenter {
+	communicator, err := NewCommunicator(clientConfig)
+	if err != nil {
+		logger.Panicf("Failed to initialize bft consenter: %s", err)
+	}
+	return newConsenter(conf, communicator)
+}
+
+func newConsenter(conf localconfig.BFT, communicator Communicator) *Consenter {
+	return &Consenter{
+		stateDir:     conf.StateDir,
+		endpoint:     conf.Endpoint,
+		communicator: communicator,
+		chains:       make(map[string]*chain),
+	}
+}
+
+// HandleChain creates a BFT replica for the channel of the given support. The replica resumes
+// from the protocol state it persisted, or in the view recorded in the metadata of the last block
+// in the ledger if that view is later
+func (c *Consenter) HandleChain(support consensus.ConsenterSupport, metadata *cb.Metadata) (consensus.Chain, error) {
+	configMetadata, err := unmarshalConfigMetadata(support.SharedConfig().ConsensusMetadata())
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("invalid consensus metadata for channel %s", support.ChainID()))
+	}
+	var id uint64
+	endpoints := make(map[uint64]string)
+	for i, consenter := range configMetadata.Consenters {
+		endpoint := fmt.Sprintf("%s:%d", consenter.Host, consenter.Port)
+		endpoints[uint64(i+1)] = endpoint
+		if endpoint == c.endpoint {
+			id = uint64(i + 1)
+		}
+	}
+	if id == 0 {
+		return nil, errors.Errorf("this orderer (%s) is not a consenter of channel %s", c.endpoint, support.ChainID())
+	}
+
+	identities, err := deserializeConsenters(support.MSPManager(), configMetadata.Consenters)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("invalid consenters for channel %s", support.ChainID()))
+	}
+	shdr, err := support.NewSignatureHeader()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create a signature header")
+	}
+	self, err := support.MSPManager().DeserializeIdentity(shdr.Creator)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to deserialize the identity of this orderer")
+	}
+	if *self.GetIdentifier() != *identities[id-1].GetIdentifier() {
+		return nil, errors.Errorf("the identity of this orderer does not match the identity of consenter %d of channel %s",
+			id, support.ChainID())
+	}
+
+	var view uint64
+	if metadata != nil && len(metadata.Value) > 0 {
+		blockMetadata := &bftpb.BlockMetadata{}
+		if err := proto.Unmarshal(metadata.Value, blockMetadata); err != nil {
+			return nil, errors.Wrap(err, "error unmarshaling bft block metadata")
+		}
+		view = blockMetadata.View
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	if c.stateProvider == nil {
+		c.stateProvider = leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: c.stateDir})
+	}
+	s := &replicaStorage{db: c.stateProvider.GetDBHandle(support.ChainID())}
+	state, err := s.load()
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("failed to load the bft state of channel %s", support.ChainID()))
+	}
+	if state.GetView() > view {
+		view = state.GetView()
+	}
+	logger.Infof("Creating bft replica %d of channel %s in view %d", id, support.ChainID(), view)
+	ch := newChain(support, id, endpoints, configMetadata, identities, c.communicator, s, view, state)
+	c.chains[support.ChainID()] = ch
+	return ch, nil
+}
+
+// RemoveChain forgets the halted replica of the channel and deletes its protocol state
+func (c *Consenter) RemoveChain(chainID string) error {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.chains, chainID)
+	if c.stateProvider == nil {
+		return nil
+	}
+	return c.stateProvider.GetDBHandle(chainID).DeleteAll()
+}
+
+// Step passes a protocol message sent by another consenter to the replica of the channel,
+// once the signature of the message is verified
+func (c *Consenter) Step(ctx context.Context, req *bftpb.StepRequest) (*bftpb.StepResponse, error) {
+	c.RLock()
+	ch, ok := c.chains[req.Channel]
+	c.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("channel %s is not served by this bft consenter", req.Channel)
+	}
+	if req.Message == nil {
+		return nil, errors.New("step request is missing the message")
+	}
+	if err := ch.handleStep(req.Message); err != nil {
+		return nil, err
+	}
+	return &bftpb.StepResponse{}, nil
+}
+
+func deserializeConsenters(deserializer msp.IdentityDeserializer, consenters []*bftpb.Consenter) ([]msp.Identity, error) {
+	identities := make([]msp.Identity, len(consenters))
+	for i, consenter := range consenters {
+		identity, err := deserializer.DeserializeIdentity(consenter.Identity)
+		if err != nil {
+			return nil, errors.WithMessage(err, fmt.Sprintf("failed to deserialize the identity of consenter %d", i+1))
+		}
+		identities[i] = identity
+	}
+	return identities, nil
+}
+
+func unmarshalConfigMetadata(metadataBytes []byte) (*bftpb.ConfigMetadata, error) {
+	configMetadata := &bftpb.ConfigMetadata{}
+	if err := proto.Unmarshal(metadataBytes, configMetadata); err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling bft config metadata")
+	}
+	if len(configMetadata.Consenters) == 0 {
+		return nil, errors.New("bft config metadata contains no consenters")
+	}
+	for i, consenter := range configMetadata.Consenters {
+		if len(consenter.Identity) == 0 {
+			return nil, errors.Errorf("consenter %d has no identity", i+1)
+		}
+	}
+	if configMetadata.Options == nil {
+		configMetadata.Options = &bftpb.Options{}
+	}
+	opts := configMetadata.Options
+	if opts.RequestTimeout == 0 {
+		opts.RequestTimeout = defaultRequestTimeout
+	}
+	if opts.ViewChangeTimeout == 0 {
+		opts.ViewChangeTimeout = defaultViewChangeTimeout
+	}
+	return configMetadata, nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bft
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	mockmsp "github.com/hyperledger/fabric/common/mocks/msp"
+	localconfig "github.com/hyperledger/fabric/orderer/common/localconfig"
+	cb "github.com/hyperledger/fabric/protos/common"
+	bftpb "github.com/hyperledger/fabric/protos/orderer/bft"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestHandleChainErrors(t *testing.T) {
+	signers := mockmsp.NewSigners()
+	identities := []*mockmsp.SigningIdentity{
+		signers.NewIdentity("OrdererOrg", "orderer1"),
+		signers.NewIdentity("OrdererOrg", "orderer2"),
+	}
+	metadata, endpoints := testConfigMetadata(identities...)
+	dir, err := ioutil.TempDir("", "bft")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	consenter := newConsenter(localconfig.BFT{StateDir: dir, Endpoint: "orderer.example.com:7050"}, nil)
+
+	_, err = consenter.HandleChain(newTestSupport(newTestLedger(), metadata, signers, identities[0]), nil)
+	assert.EqualError(t, err, "this orderer (orderer.example.com:7050) is not a consenter of channel testchannel")
+
+	_, err = consenter.HandleChain(newTestSupport(newTestLedger(), []byte("garbage"), signers, identities[0]), nil)
+	assert.Contains(t, err.Error(), "invalid consensus metadata for channel testchannel")
+
+	consenter.endpoint = endpoints[0]
+	_, err = consenter.HandleChain(newTestSupport(newTestLedger(), metadata, mockmsp.NewSigners(), identities[0]), nil)
+	assert.EqualError(t, err, "invalid consenters for channel testchannel: failed to deserialize the identity of consenter 1: unknown identity")
+
+	_, err = consenter.HandleChain(newTestSupport(newTestLedger(), metadata, signers, identities[1]), nil)
+	assert.EqualError(t, err, "the identity of this orderer does not match the identity of consenter 1 of channel testchannel")
+
+	_, err = consenter.HandleChain(newTestSupport(newTestLedger(), metadata, signers, identities[0]), &cb.Metadata{Value: []byte("garbage")})
+	assert.Contains(t, err.Error(), "error unmarshaling bft block metadata")
+
+	ch, err := consenter.HandleChain(newTestSupport(newTestLedger(), metadata, signers, identities[0]),
+		&cb.Metadata{Value: utils.MarshalOrPanic(&bftpb.BlockMetadata{View: 3})})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), ch.(*chain).view)
+	consenter.stateProvider.Close()
+}
+
+func TestUnmarshalConfigMetadata(t *testing.T) {
+	_, err := unmarshalConfigMetadata(nil)
+	assert.EqualError(t, err, "bft config metadata contains no consenters")
+
+	_, err = unmarshalConfigMetadata(utils.MarshalOrPanic(&bftpb.ConfigMetadata{
+		Consenters: []*bftpb.Consenter{{Host: "orderer.example.com", Port: 7050}},
+	}))
+	assert.EqualError(t, err, "consenter 1 has no identity")
+
+	metadata, err := unmarshalConfigMetadata(utils.MarshalOrPanic(&bftpb.ConfigMetadata{
+		Consenters: []*bftpb.Consenter{{Host: "orderer.example.com", Port: 7050, Identity: []byte("identity")}},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, &bftpb.Options{
+		RequestTimeout:    defaultRequestTimeout,
+		ViewChangeTimeout: defaultViewChangeTimeout,
+	}, metadata.Options)
+}
+
+func TestClusterServiceErrors(t *testing.T) {
+	network := newTestNetwork(t, 4)
+	defer network.stopAll()
+	consenter := network.node(0).consenter
+
+	_, err := consenter.Step(context.Background(), &bftpb.StepRequest{Channel: "foo", Message: &bftpb.SignedMessage{}})
+	assert.EqualError(t, err, "channel foo is not served by this bft consenter")
+	_, err = consenter.Step(context.Background(), &bftpb.StepRequest{Channel: testChannel})
+	assert.EqualError(t, err, "step request is missing the message")
+	_, err = consenter.Step(context.Background(), &bftpb.StepRequest{Channel: testChannel, Message: &bftpb.SignedMessage{}})
+	assert.EqualError(t, err, "message from unknown consenter 0")
+
+	// a request signed by another consenter is accepted and ordered
+	request := utils.MarshalOrPanic(&bftpb.Message{
+		Type:    bftpb.MessageType_REQUEST,
+		From:    2,
+		Request: &bftpb.Request{Content: testMessage(1)},
+	})
+	sig, err := network.identities[1].Sign(request)
+	assert.NoError(t, err)
+	_, err = consenter.Step(context.Background(), &bftpb.StepRequest{
+		Channel: testChannel,
+		Message: &bftpb.SignedMessage{Message: request, Signature: sig},
+	})
+	assert.NoError(t, err)
+	network.waitForHeight(2)
+}
+
+func TestRemoveChain(t *testing.T) {
+	network := newTestNetwork(t, 1)
+	defer network.stopAll()
+	node := network.node(0)
+
+	node.chain.Halt()
+	assert.NoError(t, node.consenter.RemoveChain(testChannel))
+	_, err := node.consenter.Step(context.Background(), &bftpb.StepRequest{Channel: testChannel, Message: &bftpb.SignedMessage{}})
+	assert.EqualError(t, err, "channel testchannel is not served by this bft consenter")
+}
@@ -0,0 +1,48 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bft
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	bftpb "github.com/hyperledger/fabric/protos/orderer/bft"
+	"github.com/pkg/errors"
+)
+
+var stateKey = []byte("state")
+
+// replicaStorage persists the protocol state of the replica of a channel in a leveldb handle that
+// is dedicated to the channel. The writes are synced to the disk before they return, so that the
+// messages sent after a write never reflect an unpersisted state
+type replicaStorage struct {
+	db *leveldbhelper.DBHandle
+}
+
+// load returns the protocol state of the replica, which is nil if the channel is new
+func (s *replicaStorage) load() (*bftpb.ReplicaState, error) {
+	stateBytes, err := s.db.Get(stateKey)
+	if err != nil {
+		return nil, err
+	}
+	if stateBytes == nil {
+		return nil, nil
+	}
+	state := &bftpb.ReplicaState{}
+	if err := proto.Unmarshal(stateBytes, state); err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling bft replica state")
+	}
+	return state, nil
+}
+
+// store replaces the protocol state of the replica
+func (s *replicaStorage) store(state *bftpb.ReplicaState) error {
+	stateBytes, err := proto.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return s.db.Put(stateKey, stateBytes, true)
+}
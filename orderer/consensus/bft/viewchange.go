@@ -0,0 +1,337 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package bft
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/deliver"
+	cb "github.com/hyperledger/fabric/protos/common"
+	bftpb "github.com/hyperledger/fabric/protos/orderer/bft"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/pkg/errors"
+)
+
+// startViewChange moves this replica to the given view, and broadcasts a VIEW_CHANGE carrying the proof
+// of the height of its ledger and the block it prepared at that height, if any. The replica does not take
+// part in ordering until a NEW_VIEW installs the view, and moves on to the next view if that takes too long
+func (c *chain) startViewChange(view uint64) {
+	logger.Infof("Replica %d of channel %s changing to view %d", c.id, c.channel, view)
+	c.view = view
+	c.viewChanging = true
+	c.resetRound()
+	c.vcAttempts++
+	backoff := c.vcAttempts - 1
+	if backoff > maxBackoffDoubles {
+		backoff = maxBackoffDoubles
+	}
+	c.vcDeadline = time.Now().Add(c.viewChangeTimeout << backoff)
+	c.persist()
+
+	var prepared *bftpb.PreparedCertificate
+	if c.prepared != nil && c.prepared.Block.Header.Number == c.height {
+		prepared = c.prepared
+	}
+	vc := c.broadcast(&bftpb.Message{
+		Type:     bftpb.MessageType_VIEW_CHANGE,
+		View:     view,
+		Sequence: c.height,
+		Block:    c.lastBlock,
+		Prepared: prepared,
+	})
+	c.recordViewChange(vc)
+	c.checkNewView()
+}
+
+func (c *chain) recordViewChange(m *verifiedMessage) {
+	vcs, ok := c.viewChanges[m.View]
+	if !ok {
+		vcs = make(map[uint64]*verifiedMessage)
+		c.viewChanges[m.View] = vcs
+	}
+	vcs[m.From] = m
+}
+
+func (c *chain) handleViewChange(m *verifiedMessage) {
+	if m.View < c.view || (m.View == c.view && !c.viewChanging) {
+		// the sender missed the installation of a later view
+		c.sendNewView(m.From)
+		return
+	}
+	if err := c.validateViewChange(m); err != nil {
+		logger.Warningf("Discarding the view change of replica %d of channel %s: %s", m.From, c.channel, err)
+		return
+	}
+	c.recordViewChange(m)
+
+	// f+1 replicas moving to a later view include a correct one, which this replica joins
+	// so that a quorum is eventually reached, in the lowest view that f+1 replicas are at
+	latest := make(map[uint64]uint64)
+	for view, vcs := range c.viewChanges {
+		if view <= c.view {
+			continue
+		}
+		for from := range vcs {
+			if view > latest[from] {
+				latest[from] = view
+			}
+		}
+	}
+	if len(latest) > c.f {
+		var views []uint64
+		for _, view := range latest {
+			views = append(views, view)
+		}
+		sort.Slice(views, func(i, j int) bool { return views[i] > views[j] })
+		c.vcAttempts = 0
+		c.startViewChange(views[c.f])
+		return
+	}
+	c.checkNewView()
+}
+
+// validateViewChange checks the proof of the height of the ledger of the sender, and the
+// certificate of the block it prepared at that height
+func (c *chain) validateViewChange(m *verifiedMessage) error {
+	if m.Sequence == 0 || m.Block == nil || m.Block.Header == nil || m.Block.Header.Number+1 != m.Sequence {
+		return errors.New("the view change does not carry the last block of the sender")
+	}
+	if m.Sequence == 1 {
+		if !proto.Equal(m.Block.Header, c.genesis) {
+			return errors.New("the genesis block of the sender is different")
+		}
+	} else if err := deliver.VerifyQuorumSignatures(m.Block, c.consenters, c.support.MSPManager()); err != nil {
+		return err
+	}
+
+	p := m.Prepared
+	if p == nil {
+		return nil
+	}
+	if p.Block == nil || p.Block.Header == nil || p.Block.Data == nil {
+		return errors.New("the prepared block is missing its header or data")
+	}
+	if p.View >= m.View {
+		return errors.Errorf("the block is prepared in view %d, not before view %d", p.View, m.View)
+	}
+	if !proto.Equal(p.Block.Header, &cb.BlockHeader{
+		Number:       m.Sequence,
+		PreviousHash: m.Block.Header.Hash(),
+		DataHash:     p.Block.Data.Hash(),
+	}) {
+		return errors.New("the prepared block does not follow the last block of the sender or its data hash is invalid")
+	}
+	digest := p.Block.Header.Hash()
+	prepared := make(map[uint64]bool)
+	for _, sm := range p.Prepares {
+		prepare, err := c.verify(sm)
+		if err != nil {
+			return errors.WithMessage(err, "invalid prepare")
+		}
+		if prepare.Type != bftpb.MessageType_PREPARE || prepare.View != p.View || prepare.Sequence != m.Sequence ||
+			string(prepare.Digest) != string(digest) {
+			return errors.Errorf("the prepare of replica %d is not for the prepared block", prepare.From)
+		}
+		prepared[prepare.From] = true
+	}
+	if len(prepared) < c.quorum {
+		return errors.Errorf("the block is prepared by %d replicas, while a quorum of %d is required", len(prepared), c.quorum)
+	}
+	return nil
+}
+
+// checkNewView installs the view this replica is changing to, once it is the primary
+// of the view and has the VIEW_CHANGE messages of a quorum
+func (c *chain) checkNewView() {
+	if !c.viewChanging || c.primary(c.view) != c.id || len(c.viewChanges[c.view]) < c.quorum {
+		return
+	}
+	vcs := c.viewChanges[c.view]
+	var signed []*bftpb.SignedMessage
+	var msgs []*verifiedMessage
+	for _, from := range sortedKeys(vcs) {
+		signed = append(signed, vcs[from].signed)
+		msgs = append(msgs, vcs[from])
+	}
+	nv := c.broadcast(&bftpb.Message{Type: bftpb.MessageType_NEW_VIEW, View: c.view, ViewChanges: signed})
+	c.installView(c.view, msgs, nv.signed)
+}
+
+func (c *chain) handleNewView(m *verifiedMessage) {
+	if m.View < c.view || (m.View == c.view && !c.viewChanging) {
+		return
+	}
+	vcs, err := c.validateNewView(m)
+	if err != nil {
+		logger.Warningf("Discarding the new view of replica %d of channel %s: %s", m.From, c.channel, err)
+		return
+	}
+	c.installView(m.View, vcs, m.signed)
+}
+
+// validateNewView checks that the NEW_VIEW is sent by the primary of the view, and carries
+// valid VIEW_CHANGE messages of a quorum
+func (c *chain) validateNewView(m *verifiedMessage) ([]*verifiedMessage, error) {
+	if m.From != c.primary(m.View) {
+		return nil, errors.Errorf("replica %d is not the primary of view %d", m.From, m.View)
+	}
+	senders := make(map[uint64]bool)
+	var vcs []*verifiedMessage
+	for _, sm := range m.ViewChanges {
+		vc, err := c.verify(sm)
+		if err != nil {
+			return nil, errors.WithMessage(err, "invalid view change")
+		}
+		if vc.Type != bftpb.MessageType_VIEW_CHANGE || vc.View != m.View {
+			return nil, errors.Errorf("the message of replica %d is not a view change to view %d", vc.From, m.View)
+		}
+		if err := c.validateViewChange(vc); err != nil {
+			return nil, errors.WithMessage(err, fmt.Sprintf("invalid view change of replica %d", vc.From))
+		}
+		if !senders[vc.From] {
+			senders[vc.From] = true
+			vcs = append(vcs, vc)
+		}
+	}
+	if len(vcs) < c.quorum {
+		return nil, errors.Errorf("the new view carries the view changes of %d replicas, while a quorum of %d is required",
+			len(vcs), c.quorum)
+	}
+	return vcs, nil
+}
+
+// installView starts ordering in the given view. The block prepared in the highest view at the highest
+// height of the VIEW_CHANGE messages might have been committed by some replica, and is the only block
+// the primary may propose at that height
+func (c *chain) installView(view uint64, vcs []*verifiedMessage, nv *bftpb.SignedMessage) {
+	logger.Infof("Replica %d of channel %s installed view %d with primary %d", c.id, c.channel, view, c.primary(view))
+	c.view = view
+	c.viewChanging = false
+	c.vcAttempts = 0
+	c.newView = nv
+	c.resetRound()
+	height := c.setRequired(vcs)
+	c.persist()
+	for v := range c.viewChanges {
+		if v <= view {
+			delete(c.viewChanges, v)
+		}
+	}
+	c.ahead = make(map[uint64]bool)
+	c.lastProgress = time.Now()
+	if c.height < height {
+		c.requestSync(sortedKeys(c.sendCs))
+	}
+	c.replayPending()
+	c.maybePropose()
+}
+
+// setRequired sets the block that the primary must propose, out of the VIEW_CHANGE messages which
+// installed the view, and returns the highest height of these messages
+func (c *chain) setRequired(vcs []*verifiedMessage) uint64 {
+	var height uint64
+	for _, vc := range vcs {
+		if vc.Sequence > height {
+			height = vc.Sequence
+		}
+	}
+	var required *bftpb.PreparedCertificate
+	for _, vc := range vcs {
+		if vc.Sequence == height && vc.Prepared != nil && (required == nil || vc.Prepared.View > required.View) {
+			required = vc.Prepared
+		}
+	}
+	c.required = nil
+	if required != nil {
+		c.required = required.Block
+		c.requiredHeight = height
+	}
+	return height
+}
+
+// sendNewView sends the NEW_VIEW that installed the current view to a replica in an earlier view, once
+func (c *chain) sendNewView(to uint64) {
+	if c.newView == nil || c.newViewSent[to] == c.newView {
+		return
+	}
+	c.newViewSent[to] = c.newView
+	c.send(to, c.newView)
+}
+
+// requestSync asks the given replicas for the blocks this replica is missing
+func (c *chain) requestSync(peers []uint64) {
+	c.syncing = true
+	c.syncDeadline = time.Now().Add(c.requestTimeout)
+	sm := c.sign(&bftpb.Message{Type: bftpb.MessageType_SYNC_REQUEST, View: c.view, Sequence: c.height})
+	for _, peer := range peers {
+		if peer != c.id {
+			c.send(peer, sm)
+		}
+	}
+}
+
+func (c *chain) handleSyncRequest(m *verifiedMessage) {
+	resp := &bftpb.Message{Type: bftpb.MessageType_SYNC_RESPONSE, View: c.view, Sequence: m.Sequence}
+	for number := m.Sequence; number > 0 && number < c.height && len(resp.Blocks) < maxSyncBlocks; number++ {
+		block := c.support.Block(number)
+		if block == nil {
+			break
+		}
+		resp.Blocks = append(resp.Blocks, block)
+	}
+	if m.View < c.view && c.newView != nil {
+		resp.ViewChanges = []*bftpb.SignedMessage{c.newView}
+	}
+	if len(resp.Blocks) == 0 && len(resp.ViewChanges) == 0 {
+		return
+	}
+	c.send(m.From, c.sign(resp))
+}
+
+// handleSyncResponse writes the blocks which follow the ledger and are signed by a quorum. The metadata
+// of the blocks is rebuilt, keeping only the signatures and the view they were committed in
+func (c *chain) handleSyncResponse(m *verifiedMessage) {
+	if len(m.ViewChanges) > 0 {
+		nv, err := c.verify(m.ViewChanges[0])
+		if err != nil {
+			logger.Warningf("Discarding the new view sent by replica %d of channel %s: %s", m.From, c.channel, err)
+		} else if nv.Type == bftpb.MessageType_NEW_VIEW {
+			c.handleNewView(nv)
+		}
+	}
+
+	written := 0
+	for _, block := range m.Blocks {
+		if block == nil || block.Header == nil || block.Data == nil || block.Header.Number != c.height {
+			continue
+		}
+		if err := c.checkLink(block); err != nil {
+			logger.Warningf("Discarding the blocks sent by replica %d of channel %s: %s", m.From, c.channel, err)
+			break
+		}
+		if err := deliver.VerifyQuorumSignatures(block, c.consenters, c.support.MSPManager()); err != nil {
+			logger.Warningf("Discarding the blocks sent by replica %d of channel %s: %s", m.From, c.channel, err)
+			break
+		}
+		var metadata []byte
+		if md, err := utils.GetMetadataFromBlock(block, cb.BlockMetadataIndex_ORDERER); err == nil {
+			metadata = md.Value
+		}
+		synced := &cb.Block{Header: block.Header, Data: block.Data, Metadata: cb.NewBlock(0, nil).Metadata}
+		synced.Metadata.Metadata[cb.BlockMetadataIndex_SIGNATURES] = block.Metadata.Metadata[cb.BlockMetadataIndex_SIGNATURES]
+		logger.Debugf("Replica %d of channel %s synced block [%d] from replica %d", c.id, c.channel, block.Header.Number, m.From)
+		c.syncing = false
+		c.writeBlock(synced, metadata)
+		written++
+	}
+	if written == maxSyncBlocks {
+		c.requestSync([]uint64{m.From})
+	}
+}
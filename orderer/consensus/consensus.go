@@ -9,6 +9,7 @@ package consensus
 import (
 	"github.com/hyperledger/fabric/common/channelconfig"
 	"github.com/hyperledger/fabric/common/crypto"
+	"github.com/hyperledger/fabric/msp"
 	"github.com/hyperledger/fabric/orderer/common/blockcutter"
 	"github.com/hyperledger/fabric/orderer/common/msgprocessor"
 	cb "github.com/hyperledger/fabric/protos/common"
@@ -25,6 +26,13 @@ type Consenter interface {
 	HandleChain(support ConsenterSupport, metadata *cb.Metadata) (Chain, error)
 }
 
+// ChainRemover is implemented by the consenters which keep state for a chain outside of the ledger of the chain.
+// RemoveChain is invoked when the channel is removed from the orderer, after the Chain has been halted, and
+// should release and delete that state, so that the channel can later be joined afresh
+type ChainRemover interface {
+	RemoveChain(chainID string) error
+}
+
 // Chain defines a way to inject messages for ordering.
 // Note, that in order to allow flexibility in the implementation, it is the responsibility of the implementer
 // to take the ordered messages, send them through the blockcutter.Receiver supplied via HandleChain to cut blocks,
@@ -103,4 +111,10 @@ type ConsenterSupport interface {
 
 	// Height returns the number of blocks in the chain this channel is associated with.
 	Height() uint64
+
+	// Block returns the block with the given number, or nil if such a block does not exist.
+	Block(number uint64) *cb.Block
+
+	// MSPManager returns the MSP manager of the channel, which deserializes the identities of its members.
+	MSPManager() msp.MSPManager
 }
@@ -235,6 +235,12 @@ func (chain *chainImpl) enqueue(kafkaMsg *ab.KafkaMessage) bool {
 func startThread(chain *chainImpl) {
 	var err error
 
+	// Create the topic of the channel, or check the existing one
+	err = setupTopicForChannel(chain.consenter.retryOptions(), chain.haltChan, chain.SharedConfig().KafkaBrokers(), chain.consenter.brokerConfig(), chain.consenter.topicDetail(), chain.channel)
+	if err != nil {
+		logger.Panicf("[channel: %s] Cannot set up topic = %s", chain.channel.topic(), err)
+	}
+
 	// Set up the producer
 	chain.producer, err = setupProducerForChannel(chain.consenter.retryOptions(), chain.haltChan, chain.SharedConfig().KafkaBrokers(), chain.consenter.brokerConfig(), chain.channel)
 	if err != nil {
@@ -930,3 +936,131 @@ func setupProducerForChannel(retryOptions localconfig.Retry, haltChan chan struc
 
 	return producer, setupProducer.retry()
 }
+
+// Creates the topic of a channel with the given replication factor and number
+// of partitions, or checks that an existing topic matches them, using the
+// given retry options. This is skipped when the topic detail is unset or the
+// brokers predate the CreateTopics API (Kafka 0.10.1.0), in which case the
+// brokers create the topic on first use.
+func setupTopicForChannel(retryOptions localconfig.Retry, haltChan chan struct{}, brokers []string, brokerConfig *sarama.Config, topicDetail *sarama.TopicDetail, channel channel) error {
+	if topicDetail == nil || topicDetail.ReplicationFactor <= 0 || !brokerConfig.Version.IsAtLeast(sarama.V0_10_1_0) {
+		logger.Debugf("[channel: %s] Leaving the creation of the topic to the Kafka cluster", channel.topic())
+		return nil
+	}
+
+	logger.Infof("[channel: %s] Setting up the topic for this channel...", channel.topic())
+
+	// A topic that does not match the configuration will not be fixed by
+	// retrying, so such an error aborts the retry process right away.
+	var mismatchErr error
+	retryMsg := "Creating Kafka topic for channel"
+	setupTopic := newRetryProcess(retryOptions, haltChan, channel, retryMsg, func() error {
+		client, err := sarama.NewClient(brokers, brokerConfig)
+		if err != nil {
+			return err
+		}
+		defer client.Close()
+
+		exists, err := topicExists(client, channel.topic())
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if exists, err = createTopic(client, brokerConfig, topicDetail, channel.topic()); err != nil {
+				return err
+			}
+			if !exists {
+				logger.Infof("[channel: %s] Created topic with %d partitions and replication factor %d",
+					channel.topic(), topicDetail.NumPartitions, topicDetail.ReplicationFactor)
+				return nil
+			}
+			// Another orderer created the topic in the meantime
+			if err = client.RefreshMetadata(channel.topic()); err != nil {
+				return err
+			}
+		}
+		mismatchErr = validateTopic(client, topicDetail, channel.topic())
+		return nil
+	})
+
+	if err := setupTopic.retry(); err != nil {
+		return err
+	}
+	return mismatchErr
+}
+
+// topicExists returns whether the topic is known to the Kafka cluster.
+func topicExists(client sarama.Client, topic string) (bool, error) {
+	topics, err := client.Topics()
+	if err != nil {
+		return false, err
+	}
+	for _, t := range topics {
+		if t == topic {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// createTopic asks the controller of the Kafka cluster to create the topic,
+// and returns whether the topic turned out to exist already. The metadata
+// requests sent by sarama do not identify the controller, so the brokers are
+// tried in turn until one of them does not refuse the request for not being
+// the controller.
+func createTopic(client sarama.Client, brokerConfig *sarama.Config, topicDetail *sarama.TopicDetail, topic string) (bool, error) {
+	request := &sarama.CreateTopicsRequest{
+		TopicDetails: map[string]*sarama.TopicDetail{topic: topicDetail},
+		Timeout:      brokerConfig.Net.WriteTimeout,
+	}
+
+	for _, broker := range client.Brokers() {
+		if err := broker.Open(brokerConfig); err != nil && err != sarama.ErrAlreadyConnected {
+			return false, err
+		}
+		response, err := broker.CreateTopics(request)
+		if err != nil {
+			return false, err
+		}
+		topicErr, ok := response.TopicErrors[topic]
+		if !ok {
+			return false, fmt.Errorf("broker %s did not report on the creation of topic %s", broker.Addr(), topic)
+		}
+		switch topicErr.Err {
+		case sarama.ErrNoError:
+			return false, nil
+		case sarama.ErrTopicAlreadyExists:
+			return true, nil
+		case sarama.ErrNotController:
+			logger.Debugf("Broker %s is not the controller, cannot create topic %s on it", broker.Addr(), topic)
+		default:
+			return false, fmt.Errorf("cannot create topic %s: %s", topic, topicErr.Error())
+		}
+	}
+
+	return false, fmt.Errorf("cannot create topic %s: no broker accepted the request as the controller of the Kafka cluster", topic)
+}
+
+// validateTopic checks that an existing topic has the configured number of
+// partitions, each replicated to the configured number of brokers.
+func validateTopic(client sarama.Client, topicDetail *sarama.TopicDetail, topic string) error {
+	partitions, err := client.Partitions(topic)
+	if err != nil {
+		return err
+	}
+	if len(partitions) != int(topicDetail.NumPartitions) {
+		return fmt.Errorf("topic %s has %d partitions, while %d are configured (Kafka.Topic.NumPartitions)",
+			topic, len(partitions), topicDetail.NumPartitions)
+	}
+	for _, partition := range partitions {
+		replicas, err := client.Replicas(topic, partition)
+		if err != nil {
+			return err
+		}
+		if len(replicas) != int(topicDetail.ReplicationFactor) {
+			return fmt.Errorf("partition %d of topic %s has %d replicas, while the replication factor is configured to %d (Kafka.Topic.ReplicationFactor)",
+				partition, topic, len(replicas), topicDetail.ReplicationFactor)
+		}
+	}
+	return nil
+}
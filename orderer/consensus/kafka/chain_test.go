@@ -16,6 +16,7 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/common/channelconfig"
 	mockconfig "github.com/hyperledger/fabric/common/mocks/config"
+	"github.com/hyperledger/fabric/msp"
 	"github.com/hyperledger/fabric/orderer/common/blockcutter"
 	"github.com/hyperledger/fabric/orderer/common/msgprocessor"
 	mockblockcutter "github.com/hyperledger/fabric/orderer/mocks/common/blockcutter"
@@ -56,7 +57,7 @@ func TestChain(t *testing.T) {
 			"OffsetRequest": sarama.NewMockOffsetResponse(t).
 				SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetOldest, oldestOffset).
 				SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetNewest, newestOffset),
-			"FetchRequest": sarama.NewMockFetchResponse(t, 1).
+			"FetchRequest": sarama.NewMockFetchResponse(t, 1).SetVersion(1).
 				SetMessage(mockChannel.topic(), mockChannel.partition(), newestOffset, message),
 		})
 		mockSupport = &mockmultichannel.ConsenterSupport{
@@ -195,7 +196,7 @@ func TestChain(t *testing.T) {
 			"OffsetRequest": sarama.NewMockOffsetResponse(t).
 				SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetOldest, oldestOffset).
 				SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetNewest, newestOffset),
-			"FetchRequest": sarama.NewMockFetchResponse(t, 1).
+			"FetchRequest": sarama.NewMockFetchResponse(t, 1).SetVersion(1).
 				SetMessage(mockChannel.topic(), mockChannel.partition(), newestOffset, message),
 		})
 
@@ -219,7 +220,7 @@ func TestChain(t *testing.T) {
 			"OffsetRequest": sarama.NewMockOffsetResponse(t).
 				SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetOldest, oldestOffset).
 				SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetNewest, newestOffset),
-			"FetchRequest": sarama.NewMockFetchResponse(t, 1).
+			"FetchRequest": sarama.NewMockFetchResponse(t, 1).SetVersion(1).
 				SetMessage(mockChannel.topic(), mockChannel.partition(), newestOffset, message),
 		})
 
@@ -248,7 +249,7 @@ func TestChain(t *testing.T) {
 			"OffsetRequest": sarama.NewMockOffsetResponse(t).
 				SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetOldest, oldestOffset).
 				SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetNewest, newestOffset),
-			"FetchRequest": sarama.NewMockFetchResponse(t, 1).
+			"FetchRequest": sarama.NewMockFetchResponse(t, 1).SetVersion(1).
 				SetMessage(mockChannel.topic(), mockChannel.partition(), newestOffset, message),
 		})
 
@@ -269,7 +270,7 @@ func TestChain(t *testing.T) {
 			"OffsetRequest": sarama.NewMockOffsetResponse(t).
 				SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetOldest, oldestOffset).
 				SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetNewest, newestOffset),
-			"FetchRequest": sarama.NewMockFetchResponse(t, 1).
+			"FetchRequest": sarama.NewMockFetchResponse(t, 1).SetVersion(1).
 				SetMessage(mockChannel.topic(), mockChannel.partition(), newestOffset, message),
 		})
 
@@ -302,7 +303,7 @@ func TestChain(t *testing.T) {
 			"OffsetRequest": sarama.NewMockOffsetResponse(t).
 				SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetOldest, oldestOffset).
 				SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetNewest, newestOffset),
-			"FetchRequest": sarama.NewMockFetchResponse(t, 1).
+			"FetchRequest": sarama.NewMockFetchResponse(t, 1).SetVersion(1).
 				SetMessage(mockChannel.topic(), mockChannel.partition(), newestOffset, message),
 		})
 
@@ -336,7 +337,7 @@ func TestChain(t *testing.T) {
 			"OffsetRequest": sarama.NewMockOffsetResponse(t).
 				SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetOldest, oldestOffset).
 				SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetNewest, newestOffset),
-			"FetchRequest": sarama.NewMockFetchResponse(t, 1).
+			"FetchRequest": sarama.NewMockFetchResponse(t, 1).SetVersion(1).
 				SetMessage(mockChannel.topic(), mockChannel.partition(), newestOffset, message),
 		})
 
@@ -383,7 +384,7 @@ func TestChain(t *testing.T) {
 				"OffsetRequest": sarama.NewMockOffsetResponse(t).
 					SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetOldest, oldestOffset).
 					SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetNewest, newestOffset),
-				"FetchRequest": sarama.NewMockFetchResponse(t, 1).
+				"FetchRequest": sarama.NewMockFetchResponse(t, 1).SetVersion(1).
 					SetMessage(mockChannel.topic(), mockChannel.partition(), newestOffset, message),
 			})
 
@@ -426,7 +427,7 @@ func TestChain(t *testing.T) {
 				"OffsetRequest": sarama.NewMockOffsetResponse(t).
 					SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetOldest, oldestOffset).
 					SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetNewest, newestOffset),
-				"FetchRequest": sarama.NewMockFetchResponse(t, 1).
+				"FetchRequest": sarama.NewMockFetchResponse(t, 1).SetVersion(1).
 					SetMessage(mockChannel.topic(), mockChannel.partition(), newestOffset, message),
 			})
 
@@ -446,6 +447,104 @@ func TestChain(t *testing.T) {
 	})
 }
 
+func TestSetupTopicForChannel(t *testing.T) {
+	mockChannel := newChannel(channelNameForTest(t), defaultPartition)
+	haltChan := make(chan struct{})
+
+	// CreateTopics is only available from Kafka 0.10.1.0 on
+	brokerConfig := newMockBrokerConfig(mockLocalConfig.General.TLS, mockLocalConfig.Kafka.SASL, mockLocalConfig.Kafka.Retry, sarama.V0_10_1_0, defaultPartition)
+	topicDetail := &sarama.TopicDetail{NumPartitions: 1, ReplicationFactor: 3}
+
+	newMockBroker := func(t *testing.T, replicas []int32, createErr sarama.KError) *sarama.MockBroker {
+		mockBroker := sarama.NewMockBroker(t, 0)
+		// The metadata requests are of version 1 from Kafka 0.10.0.0 on
+		metadataResponse := &sarama.MetadataResponse{Version: 1}
+		metadataResponse.AddBroker(mockBroker.Addr(), mockBroker.BrokerID())
+		if replicas != nil {
+			metadataResponse.AddTopicPartition(mockChannel.topic(), mockChannel.partition(), mockBroker.BrokerID(), replicas, replicas, nil, sarama.ErrNoError)
+		}
+		mockBroker.SetHandlerByMap(map[string]sarama.MockResponse{
+			"MetadataRequest": sarama.NewMockWrapper(metadataResponse),
+			"CreateTopicsRequest": sarama.NewMockWrapper(&sarama.CreateTopicsResponse{
+				TopicErrors: map[string]*sarama.TopicError{mockChannel.topic(): {Err: createErr}},
+			}),
+		})
+		return mockBroker
+	}
+
+	createTopicsRequests := func(mockBroker *sarama.MockBroker) []*sarama.CreateTopicsRequest {
+		var requests []*sarama.CreateTopicsRequest
+		for _, rr := range mockBroker.History() {
+			if request, ok := rr.Request.(*sarama.CreateTopicsRequest); ok {
+				requests = append(requests, request)
+			}
+		}
+		return requests
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		mockBroker := newMockBroker(t, nil, sarama.ErrNoError)
+		defer mockBroker.Close()
+
+		err := setupTopicForChannel(mockRetryOptions, haltChan, []string{mockBroker.Addr()}, brokerConfig, topicDetail, mockChannel)
+		assert.NoError(t, err, "Expected the setupTopicForChannel call to return without errors")
+		requests := createTopicsRequests(mockBroker)
+		assert.Len(t, requests, 1, "Expected the topic to be created")
+		assert.Equal(t, map[string]*sarama.TopicDetail{mockChannel.topic(): topicDetail}, requests[0].TopicDetails)
+	})
+
+	t.Run("Existing", func(t *testing.T) {
+		mockBroker := newMockBroker(t, []int32{0, 1, 2}, sarama.ErrNoError)
+		defer mockBroker.Close()
+
+		err := setupTopicForChannel(mockRetryOptions, haltChan, []string{mockBroker.Addr()}, brokerConfig, topicDetail, mockChannel)
+		assert.NoError(t, err, "Expected the setupTopicForChannel call to return without errors")
+		assert.Empty(t, createTopicsRequests(mockBroker), "Expected the existing topic not to be created again")
+	})
+
+	t.Run("ExistingWithFewerReplicas", func(t *testing.T) {
+		mockBroker := newMockBroker(t, []int32{0}, sarama.ErrNoError)
+		defer mockBroker.Close()
+
+		err := setupTopicForChannel(mockRetryOptions, haltChan, []string{mockBroker.Addr()}, brokerConfig, topicDetail, mockChannel)
+		assert.EqualError(t, err, fmt.Sprintf("partition 0 of topic %s has 1 replicas, while the replication factor is configured to 3 (Kafka.Topic.ReplicationFactor)", mockChannel.topic()))
+	})
+
+	t.Run("ExistingWithOtherPartitions", func(t *testing.T) {
+		mockBroker := newMockBroker(t, []int32{0, 1, 2}, sarama.ErrNoError)
+		defer mockBroker.Close()
+
+		err := setupTopicForChannel(mockRetryOptions, haltChan, []string{mockBroker.Addr()}, brokerConfig, &sarama.TopicDetail{NumPartitions: 2, ReplicationFactor: 3}, mockChannel)
+		assert.EqualError(t, err, fmt.Sprintf("topic %s has 1 partitions, while 2 are configured (Kafka.Topic.NumPartitions)", mockChannel.topic()))
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		mockBroker := newMockBroker(t, nil, sarama.ErrInvalidReplicationFactor)
+		defer mockBroker.Close()
+
+		err := setupTopicForChannel(mockRetryOptions, haltChan, []string{mockBroker.Addr()}, brokerConfig, topicDetail, mockChannel)
+		assert.Error(t, err, "Expected the setupTopicForChannel call to return an error")
+		assert.Contains(t, err.Error(), sarama.ErrInvalidReplicationFactor.Error())
+	})
+
+	t.Run("NoController", func(t *testing.T) {
+		mockBroker := newMockBroker(t, nil, sarama.ErrNotController)
+		defer mockBroker.Close()
+
+		err := setupTopicForChannel(mockRetryOptions, haltChan, []string{mockBroker.Addr()}, brokerConfig, topicDetail, mockChannel)
+		assert.EqualError(t, err, fmt.Sprintf("cannot create topic %s: no broker accepted the request as the controller of the Kafka cluster", mockChannel.topic()))
+	})
+
+	t.Run("OldKafkaVersion", func(t *testing.T) {
+		mockBroker := newMockBroker(t, nil, sarama.ErrNoError)
+		defer mockBroker.Close()
+
+		err := setupTopicForChannel(mockRetryOptions, haltChan, []string{mockBroker.Addr()}, mockBrokerConfig, topicDetail, mockChannel)
+		assert.NoError(t, err, "Expected the setupTopicForChannel call to return without errors")
+		assert.Empty(t, mockBroker.History(), "Expected the topic to be left to the Kafka cluster")
+	})
+}
+
 func TestSetupProducerForChannel(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping test in short mode")
@@ -461,7 +560,7 @@ func TestSetupProducerForChannel(t *testing.T) {
 	t.Run("Proper", func(t *testing.T) {
 		metadataResponse := new(sarama.MetadataResponse)
 		metadataResponse.AddBroker(mockBroker.Addr(), mockBroker.BrokerID())
-		metadataResponse.AddTopicPartition(mockChannel.topic(), mockChannel.partition(), mockBroker.BrokerID(), nil, nil, sarama.ErrNoError)
+		metadataResponse.AddTopicPartition(mockChannel.topic(), mockChannel.partition(), mockBroker.BrokerID(), nil, nil, nil, sarama.ErrNoError)
 		mockBroker.Returns(metadataResponse)
 
 		producer, err := setupProducerForChannel(mockConsenter.retryOptions(), haltChan, []string{mockBroker.Addr()}, mockBrokerConfig, mockChannel)
@@ -494,7 +593,7 @@ func TestSetupConsumerForChannel(t *testing.T) {
 		"OffsetRequest": sarama.NewMockOffsetResponse(t).
 			SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetOldest, oldestOffset).
 			SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetNewest, newestOffset),
-		"FetchRequest": sarama.NewMockFetchResponse(t, 1).
+		"FetchRequest": sarama.NewMockFetchResponse(t, 1).SetVersion(1).
 			SetMessage(mockChannel.topic(), mockChannel.partition(), startFrom, message),
 	})
 
@@ -552,7 +651,7 @@ func TestCloseKafkaObjects(t *testing.T) {
 		"OffsetRequest": sarama.NewMockOffsetResponse(t).
 			SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetOldest, oldestOffset).
 			SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetNewest, newestOffset),
-		"FetchRequest": sarama.NewMockFetchResponse(t, 1).
+		"FetchRequest": sarama.NewMockFetchResponse(t, 1).SetVersion(1).
 			SetMessage(mockChannel.topic(), mockChannel.partition(), startFrom, message),
 	})
 
@@ -576,7 +675,8 @@ func TestCloseKafkaObjects(t *testing.T) {
 
 		assert.Len(t, errs, 0, "Expected zero errors")
 
-		assert.Panics(t, func() {
+		// Closing a partition consumer again is a no-op
+		assert.NotPanics(t, func() {
 			channelConsumer.Close()
 		})
 
@@ -686,7 +786,7 @@ func TestSendConnectMessage(t *testing.T) {
 
 	metadataResponse := new(sarama.MetadataResponse)
 	metadataResponse.AddBroker(mockBroker.Addr(), mockBroker.BrokerID())
-	metadataResponse.AddTopicPartition(mockChannel.topic(), mockChannel.partition(), mockBroker.BrokerID(), nil, nil, sarama.ErrNoError)
+	metadataResponse.AddTopicPartition(mockChannel.topic(), mockChannel.partition(), mockBroker.BrokerID(), nil, nil, nil, sarama.ErrNoError)
 	mockBroker.Returns(metadataResponse)
 
 	producer, _ := sarama.NewSyncProducer([]string{mockBroker.Addr()}, mockBrokerConfig)
@@ -725,7 +825,7 @@ func TestSendTimeToCut(t *testing.T) {
 
 	metadataResponse := new(sarama.MetadataResponse)
 	metadataResponse.AddBroker(mockBroker.Addr(), mockBroker.BrokerID())
-	metadataResponse.AddTopicPartition(mockChannel.topic(), mockChannel.partition(), mockBroker.BrokerID(), nil, nil, sarama.ErrNoError)
+	metadataResponse.AddTopicPartition(mockChannel.topic(), mockChannel.partition(), mockBroker.BrokerID(), nil, nil, nil, sarama.ErrNoError)
 	mockBroker.Returns(metadataResponse)
 
 	producer, err := sarama.NewSyncProducer([]string{mockBroker.Addr()}, mockBrokerConfig)
@@ -770,7 +870,7 @@ func TestProcessMessagesToBlocks(t *testing.T) {
 
 	metadataResponse := new(sarama.MetadataResponse)
 	metadataResponse.AddBroker(mockBroker.Addr(), mockBroker.BrokerID())
-	metadataResponse.AddTopicPartition(mockChannel.topic(), mockChannel.partition(), mockBroker.BrokerID(), nil, nil, sarama.ErrNoError)
+	metadataResponse.AddTopicPartition(mockChannel.topic(), mockChannel.partition(), mockBroker.BrokerID(), nil, nil, nil, sarama.ErrNoError)
 	mockBroker.Returns(metadataResponse)
 
 	producer, _ := sarama.NewSyncProducer([]string{mockBroker.Addr()}, mockBrokerConfig)
@@ -3090,13 +3190,13 @@ func TestDeliverSession(t *testing.T) {
 				SetOffset(topic, partition, sarama.OffsetOldest, 0).
 				SetOffset(topic, partition, sarama.OffsetNewest, nextOffset),
 			// respond to fetch requests with empty response while starting up
-			"FetchRequest": sarama.NewMockFetchResponse(t, 1),
+			"FetchRequest": sarama.NewMockFetchResponse(t, 1).SetVersion(1),
 		})
 
 		// configure broker2 with a default fetch request response
 		broker2.SetHandlerByMap(map[string]sarama.MockResponse{
 			// respond to fetch requests with empty response while starting up
-			"FetchRequest": sarama.NewMockFetchResponse(t, 1),
+			"FetchRequest": sarama.NewMockFetchResponse(t, 1).SetVersion(1),
 		})
 
 		// setup mock blockcutter
@@ -3178,7 +3278,7 @@ func TestDeliverSession(t *testing.T) {
 		})
 
 		// send a few messages from broker1
-		fetchResponse1 := sarama.NewMockFetchResponse(t, 1)
+		fetchResponse1 := sarama.NewMockFetchResponse(t, 1).SetVersion(1)
 		for i := 0; i < 5; i++ {
 			fetchResponse1.SetMessage(env.topic, env.partition, env.nextOffset, env.testMsg)
 			env.nextOffset++
@@ -3197,7 +3297,7 @@ func TestDeliverSession(t *testing.T) {
 		}
 
 		// prepare broker2 to send a few messages
-		fetchResponse2 := sarama.NewMockFetchResponse(t, 1)
+		fetchResponse2 := sarama.NewMockFetchResponse(t, 1).SetVersion(1)
 		for i := 0; i < 5; i++ {
 			fetchResponse2.SetMessage(env.topic, env.partition, env.nextOffset, env.testMsg)
 			env.nextOffset++
@@ -3329,7 +3429,7 @@ func TestDeliverSession(t *testing.T) {
 		})
 
 		metadataResponse := new(sarama.MetadataResponse)
-		metadataResponse.AddTopicPartition(env.topic, env.partition, -1, []int32{}, []int32{}, sarama.ErrBrokerNotAvailable)
+		metadataResponse.AddTopicPartition(env.topic, env.partition, -1, []int32{}, []int32{}, nil, sarama.ErrBrokerNotAvailable)
 
 		// configure seed broker to return error on metadata request, otherwise the
 		// consumer client will keep 'subscribing' successfully to the topic/partition
@@ -3454,3 +3554,13 @@ func (c *mockConsenterSupport) Height() uint64 {
 	args := c.Called()
 	return args.Get(0).(uint64)
 }
+
+func (c *mockConsenterSupport) Block(number uint64) *cb.Block {
+	args := c.Called(number)
+	return args.Get(0).(*cb.Block)
+}
+
+func (c *mockConsenterSupport) MSPManager() msp.MSPManager {
+	args := c.Called()
+	return args.Get(0).(msp.MSPManager)
+}
@@ -14,7 +14,7 @@ import (
 	localconfig "github.com/hyperledger/fabric/orderer/common/localconfig"
 )
 
-func newBrokerConfig(tlsConfig localconfig.TLS, retryOptions localconfig.Retry, kafkaVersion sarama.KafkaVersion, chosenStaticPartition int32) *sarama.Config {
+func newBrokerConfig(tlsConfig localconfig.TLS, saslConfig localconfig.SASL, retryOptions localconfig.Retry, kafkaVersion sarama.KafkaVersion, chosenStaticPartition int32) *sarama.Config {
 	// Max. size for request headers, etc. Set in bytes. Too big on purpose.
 	paddingDelta := 1 * 1024 * 1024
 
@@ -54,6 +54,22 @@ func newBrokerConfig(tlsConfig localconfig.TLS, retryOptions localconfig.Retry,
 		}
 	}
 
+	brokerConfig.Net.SASL.Enable = saslConfig.Enabled
+	if brokerConfig.Net.SASL.Enable {
+		brokerConfig.Net.SASL.User = saslConfig.User
+		brokerConfig.Net.SASL.Password = saslConfig.Password
+		brokerConfig.Net.SASL.Mechanism = sarama.SASLMechanism(saslConfig.Mechanism)
+		switch brokerConfig.Net.SASL.Mechanism {
+		case sarama.SASLTypeSCRAMSHA256:
+			brokerConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return newSCRAMClient(scramSHA256) }
+		case sarama.SASLTypeSCRAMSHA512:
+			brokerConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return newSCRAMClient(scramSHA512) }
+		case "", sarama.SASLTypePlaintext:
+		default:
+			logger.Panicf("Unsupported SASL mechanism (Kafka.SASL.Mechanism): %s", saslConfig.Mechanism)
+		}
+	}
+
 	// Set equivalent of Kafka producer config max.request.bytes to the default
 	// value of a Kafka broker's socket.request.max.bytes property (100 MiB).
 	brokerConfig.Producer.MaxMessageBytes = int(sarama.MaxRequestSize) - paddingDelta
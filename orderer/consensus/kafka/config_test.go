@@ -40,7 +40,7 @@ func TestBrokerConfig(t *testing.T) {
 	})
 
 	t.Run("Partitioner", func(t *testing.T) {
-		mockBrokerConfig2 := newBrokerConfig(mockLocalConfig.General.TLS, mockLocalConfig.Kafka.Retry, mockLocalConfig.Kafka.Version, differentPartition)
+		mockBrokerConfig2 := newBrokerConfig(mockLocalConfig.General.TLS, mockLocalConfig.Kafka.SASL, mockLocalConfig.Kafka.Retry, mockLocalConfig.Kafka.Version, differentPartition)
 		producer, _ := sarama.NewSyncProducer([]string{mockBroker.Addr()}, mockBrokerConfig2)
 		defer func() { producer.Close() }()
 
@@ -84,7 +84,7 @@ func TestBrokerConfigTLSConfigEnabled(t *testing.T) {
 			PrivateKey:  privateKey,
 			Certificate: publicKey,
 			RootCAs:     []string{caPublicKey},
-		}, mockLocalConfig.Kafka.Retry, mockLocalConfig.Kafka.Version, defaultPartition)
+		}, mockLocalConfig.Kafka.SASL, mockLocalConfig.Kafka.Retry, mockLocalConfig.Kafka.Version, defaultPartition)
 
 		assert.True(t, testBrokerConfig.Net.TLS.Enable)
 		assert.NotNil(t, testBrokerConfig.Net.TLS.Config)
@@ -100,7 +100,7 @@ func TestBrokerConfigTLSConfigEnabled(t *testing.T) {
 			PrivateKey:  privateKey,
 			Certificate: publicKey,
 			RootCAs:     []string{caPublicKey},
-		}, mockLocalConfig.Kafka.Retry, mockLocalConfig.Kafka.Version, defaultPartition)
+		}, mockLocalConfig.Kafka.SASL, mockLocalConfig.Kafka.Retry, mockLocalConfig.Kafka.Version, defaultPartition)
 
 		assert.False(t, testBrokerConfig.Net.TLS.Enable)
 		assert.Zero(t, testBrokerConfig.Net.TLS.Config)
@@ -118,7 +118,7 @@ func TestBrokerConfigTLSConfigBadCert(t *testing.T) {
 				PrivateKey:  privateKey,
 				Certificate: "TRASH",
 				RootCAs:     []string{caPublicKey},
-			}, mockLocalConfig.Kafka.Retry, mockLocalConfig.Kafka.Version, defaultPartition)
+			}, mockLocalConfig.Kafka.SASL, mockLocalConfig.Kafka.Retry, mockLocalConfig.Kafka.Version, defaultPartition)
 		})
 	})
 	t.Run("BadPublicKey", func(t *testing.T) {
@@ -128,7 +128,7 @@ func TestBrokerConfigTLSConfigBadCert(t *testing.T) {
 				PrivateKey:  "TRASH",
 				Certificate: publicKey,
 				RootCAs:     []string{caPublicKey},
-			}, mockLocalConfig.Kafka.Retry, mockLocalConfig.Kafka.Version, defaultPartition)
+			}, mockLocalConfig.Kafka.SASL, mockLocalConfig.Kafka.Retry, mockLocalConfig.Kafka.Version, defaultPartition)
 		})
 	})
 	t.Run("BadRootCAs", func(t *testing.T) {
@@ -138,7 +138,94 @@ func TestBrokerConfigTLSConfigBadCert(t *testing.T) {
 				PrivateKey:  privateKey,
 				Certificate: publicKey,
 				RootCAs:     []string{"TRASH"},
+			}, mockLocalConfig.Kafka.SASL, mockLocalConfig.Kafka.Retry, mockLocalConfig.Kafka.Version, defaultPartition)
+		})
+	})
+}
+
+func TestBrokerConfigSASLConfig(t *testing.T) {
+	t.Run("Disabled", func(t *testing.T) {
+		testBrokerConfig := newBrokerConfig(mockLocalConfig.General.TLS, localconfig.SASL{
+			Enabled:  false,
+			User:     "user",
+			Password: "password",
+		}, mockLocalConfig.Kafka.Retry, mockLocalConfig.Kafka.Version, defaultPartition)
+
+		assert.False(t, testBrokerConfig.Net.SASL.Enable)
+		assert.Empty(t, testBrokerConfig.Net.SASL.User)
+		assert.Empty(t, testBrokerConfig.Net.SASL.Password)
+	})
+
+	t.Run("Plain", func(t *testing.T) {
+		testBrokerConfig := newBrokerConfig(mockLocalConfig.General.TLS, localconfig.SASL{
+			Enabled:   true,
+			Mechanism: "PLAIN",
+			User:      "user",
+			Password:  "password",
+		}, mockLocalConfig.Kafka.Retry, mockLocalConfig.Kafka.Version, defaultPartition)
+
+		assert.True(t, testBrokerConfig.Net.SASL.Enable)
+		assert.Equal(t, sarama.SASLMechanism(sarama.SASLTypePlaintext), testBrokerConfig.Net.SASL.Mechanism)
+		assert.Equal(t, "user", testBrokerConfig.Net.SASL.User)
+		assert.Equal(t, "password", testBrokerConfig.Net.SASL.Password)
+		assert.Nil(t, testBrokerConfig.Net.SASL.SCRAMClientGeneratorFunc)
+		assert.NoError(t, testBrokerConfig.Validate())
+	})
+
+	for _, mechanism := range []sarama.SASLMechanism{sarama.SASLTypeSCRAMSHA256, sarama.SASLTypeSCRAMSHA512} {
+		t.Run(string(mechanism), func(t *testing.T) {
+			testBrokerConfig := newBrokerConfig(mockLocalConfig.General.TLS, localconfig.SASL{
+				Enabled:   true,
+				Mechanism: string(mechanism),
+				User:      "user",
+				Password:  "password",
+			}, mockLocalConfig.Kafka.Retry, mockLocalConfig.Kafka.Version, defaultPartition)
+
+			assert.Equal(t, mechanism, testBrokerConfig.Net.SASL.Mechanism)
+			assert.NotNil(t, testBrokerConfig.Net.SASL.SCRAMClientGeneratorFunc)
+			assert.NotNil(t, testBrokerConfig.Net.SASL.SCRAMClientGeneratorFunc())
+			assert.NoError(t, testBrokerConfig.Validate())
+		})
+	}
+
+	t.Run("UnknownMechanism", func(t *testing.T) {
+		assert.Panics(t, func() {
+			newBrokerConfig(mockLocalConfig.General.TLS, localconfig.SASL{
+				Enabled:   true,
+				Mechanism: "GSSAPI",
+				User:      "user",
+				Password:  "password",
 			}, mockLocalConfig.Kafka.Retry, mockLocalConfig.Kafka.Version, defaultPartition)
 		})
 	})
 }
+
+func TestBrokerConfigSASLHandshake(t *testing.T) {
+	mockBroker := sarama.NewMockBroker(t, 0)
+	defer func() { mockBroker.Close() }()
+
+	// The broker only accepts SASL/PLAIN, so the SCRAM handshake of the
+	// orderer is turned down and no connection is established.
+	mockBroker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"SaslHandshakeRequest": sarama.NewMockWrapper(&sarama.SaslHandshakeResponse{
+			Err:               sarama.ErrUnsupportedSASLMechanism,
+			EnabledMechanisms: []string{"PLAIN"},
+		}),
+	})
+
+	testBrokerConfig := newMockBrokerConfig(mockLocalConfig.General.TLS, localconfig.SASL{
+		Enabled:   true,
+		Mechanism: "SCRAM-SHA-512",
+		User:      "user",
+		Password:  "password",
+	}, mockLocalConfig.Kafka.Retry, sarama.V0_10_2_0, defaultPartition)
+
+	_, err := sarama.NewClient([]string{mockBroker.Addr()}, testBrokerConfig)
+	assert.Equal(t, sarama.ErrOutOfBrokers, err)
+
+	history := mockBroker.History()
+	assert.NotEmpty(t, history, "Expected the orderer to perform a SASL handshake")
+	handshake, ok := history[0].Request.(*sarama.SaslHandshakeRequest)
+	assert.True(t, ok, "Expected the first request to be a SASL handshake")
+	assert.Equal(t, "SCRAM-SHA-512", handshake.Mechanism)
+}
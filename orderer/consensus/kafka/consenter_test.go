@@ -50,7 +50,7 @@ var mockRetryOptions = localconfig.Retry{
 
 func init() {
 	mockLocalConfig = newMockLocalConfig(false, mockRetryOptions, false)
-	mockBrokerConfig = newMockBrokerConfig(mockLocalConfig.General.TLS, mockLocalConfig.Kafka.Retry, mockLocalConfig.Kafka.Version, defaultPartition)
+	mockBrokerConfig = newMockBrokerConfig(mockLocalConfig.General.TLS, mockLocalConfig.Kafka.SASL, mockLocalConfig.Kafka.Retry, mockLocalConfig.Kafka.Version, defaultPartition)
 	mockConsenter = newMockConsenter(mockBrokerConfig, mockLocalConfig.General.TLS, mockLocalConfig.Kafka.Retry, mockLocalConfig.Kafka.Version)
 	setupTestLogging("ERROR")
 }
@@ -78,7 +78,7 @@ func TestHandleChain(t *testing.T) {
 		"OffsetRequest": sarama.NewMockOffsetResponse(t).
 			SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetOldest, oldestOffset).
 			SetOffset(mockChannel.topic(), mockChannel.partition(), sarama.OffsetNewest, newestOffset),
-		"FetchRequest": sarama.NewMockFetchResponse(t, 1).
+		"FetchRequest": sarama.NewMockFetchResponse(t, 1).SetVersion(1).
 			SetMessage(mockChannel.topic(), mockChannel.partition(), newestOffset, message),
 	})
 
@@ -109,8 +109,8 @@ func extractEncodedOffset(marshalledOrdererMetadata []byte) int64 {
 	return kmd.LastOffsetPersisted
 }
 
-func newMockBrokerConfig(tlsConfig localconfig.TLS, retryOptions localconfig.Retry, kafkaVersion sarama.KafkaVersion, chosenStaticPartition int32) *sarama.Config {
-	brokerConfig := newBrokerConfig(tlsConfig, retryOptions, kafkaVersion, chosenStaticPartition)
+func newMockBrokerConfig(tlsConfig localconfig.TLS, saslConfig localconfig.SASL, retryOptions localconfig.Retry, kafkaVersion sarama.KafkaVersion, chosenStaticPartition int32) *sarama.Config {
+	brokerConfig := newBrokerConfig(tlsConfig, saslConfig, retryOptions, kafkaVersion, chosenStaticPartition)
 	brokerConfig.ClientID = "test"
 	return brokerConfig
 }
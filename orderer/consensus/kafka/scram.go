@@ -0,0 +1,58 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kafka
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+
+	"github.com/Shopify/sarama"
+	"github.com/xdg/scram"
+)
+
+// The hash functions of the SCRAM mechanisms supported by the brokers.
+var (
+	scramSHA256 scram.HashGeneratorFcn = func() hash.Hash { return sha256.New() }
+	scramSHA512 scram.HashGeneratorFcn = func() hash.Hash { return sha512.New() }
+)
+
+// scramClient adapts the client side of a SASL/SCRAM exchange, as specified in
+// RFC 5802, to the interface by which sarama drives the exchange when
+// connecting to a broker. The credentials are normalized with SASLprep before
+// the exchange.
+type scramClient struct {
+	newHash      scram.HashGeneratorFcn
+	conversation *scram.ClientConversation
+}
+
+func newSCRAMClient(newHash scram.HashGeneratorFcn) sarama.SCRAMClient {
+	return &scramClient{newHash: newHash}
+}
+
+// Begin prepares the client for an exchange with the given credentials.
+func (c *scramClient) Begin(userName, password, authzID string) error {
+	client, err := c.newHash.NewClient(userName, password, authzID)
+	if err != nil {
+		// The error of the SCRAM client quotes the credentials
+		return fmt.Errorf("the SCRAM credentials contain characters prohibited by SASLprep")
+	}
+	c.conversation = client.NewConversation()
+	return nil
+}
+
+// Step returns the next message of the client given the last message of the
+// server.
+func (c *scramClient) Step(challenge string) (string, error) {
+	return c.conversation.Step(challenge)
+}
+
+// Done returns whether the exchange is over.
+func (c *scramClient) Done() bool {
+	return c.conversation.Done()
+}
@@ -0,0 +1,92 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package kafka
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/xdg/scram"
+)
+
+// newTestSCRAMServer returns the server side of an exchange, which knows the
+// password "pencil" for the user "user".
+func newTestSCRAMServer(t *testing.T, newHash scram.HashGeneratorFcn) *scram.ServerConversation {
+	client, err := newHash.NewClient("user", "pencil", "")
+	assert.NoError(t, err)
+	credentials := client.GetStoredCredentials(scram.KeyFactors{Salt: "salt", Iters: 4096})
+	server, err := newHash.NewServer(func(user string) (scram.StoredCredentials, error) {
+		if user != "user" {
+			return scram.StoredCredentials{}, fmt.Errorf("unknown user %s", user)
+		}
+		return credentials, nil
+	})
+	assert.NoError(t, err)
+	return server.NewConversation()
+}
+
+// scramExchange drives an exchange the way sarama does, and returns the error
+// of the client, if any.
+func scramExchange(client sarama.SCRAMClient, server *scram.ServerConversation) error {
+	var challenge string
+	for !client.Done() {
+		msg, err := client.Step(challenge)
+		if err != nil {
+			return err
+		}
+		if client.Done() {
+			break
+		}
+		challenge, _ = server.Step(msg)
+	}
+	return nil
+}
+
+func TestSCRAMClient(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		newHash scram.HashGeneratorFcn
+	}{
+		{"SHA256", scramSHA256},
+		{"SHA512", scramSHA512},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			client := newSCRAMClient(test.newHash)
+			server := newTestSCRAMServer(t, test.newHash)
+			assert.NoError(t, client.Begin("user", "pencil", ""))
+			assert.NoError(t, scramExchange(client, server))
+			assert.True(t, server.Valid())
+			assert.True(t, client.(*scramClient).conversation.Valid())
+		})
+	}
+
+	t.Run("NormalizedPassword", func(t *testing.T) {
+		// the soft hyphen is mapped to nothing by SASLprep
+		client := newSCRAMClient(scramSHA256)
+		server := newTestSCRAMServer(t, scramSHA256)
+		assert.NoError(t, client.Begin("user", "pen\u00adcil", ""))
+		assert.NoError(t, scramExchange(client, server))
+		assert.True(t, server.Valid())
+	})
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		client := newSCRAMClient(scramSHA256)
+		server := newTestSCRAMServer(t, scramSHA256)
+		assert.NoError(t, client.Begin("user", "pen", ""))
+		assert.Error(t, scramExchange(client, server))
+		assert.False(t, server.Valid())
+		assert.False(t, client.(*scramClient).conversation.Valid())
+	})
+
+	t.Run("ProhibitedCharacters", func(t *testing.T) {
+		client := newSCRAMClient(scramSHA256)
+		err := client.Begin("user", "pen\u0007cil", "")
+		assert.EqualError(t, err, "the SCRAM credentials contain characters prohibited by SASLprep")
+	})
+}
@@ -0,0 +1,1039 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package raft
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	etcdraft "github.com/coreos/etcd/raft"
+	etcdraftpb "github.com/coreos/etcd/raft/raftpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	"github.com/hyperledger/fabric/common/configtx"
+	"github.com/hyperledger/fabric/core/comm"
+	"github.com/hyperledger/fabric/orderer/consensus"
+	cb "github.com/hyperledger/fabric/protos/common"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	raftpb "github.com/hyperledger/fabric/protos/orderer/raft"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+const (
+	stepBufferSize   = 256
+	sendBufferSize   = 256
+	reportBufferSize = 256
+	// maxInflightMsgs limits the number of append messages in flight to each follower
+	maxInflightMsgs = 256
+)
+
+// pullRetryInterval is the time waited before pulling the blocks covered by a snapshot again
+var pullRetryInterval = time.Second
+
+type submission struct {
+	configSeq uint64
+	normalMsg *cb.Envelope
+	configMsg *cb.Envelope
+	// forwarded is set for the submissions received from another consenter,
+	// which have been validated against the config of that consenter
+	forwarded bool
+}
+
+// writtenBlock records the raft log entry that carried a block handed over to the ledger, and the
+// membership of the raft cluster as of that entry. A snapshot can be taken at such an entry once
+// the ledger has committed the block
+type writtenBlock struct {
+	index     uint64
+	number    uint64
+	hash      []byte
+	confState etcdraftpb.ConfState
+}
+
+// member is a consenter of the channel, as identified by its raft node ID
+type member struct {
+	endpoint string
+	certHash []byte
+}
+
+// sender sends the raft messages of the channel to another consenter, in order. Once stopped, it still
+// sends the messages queued so far, e.g. the one telling a consenter that its removal is committed
+type sender struct {
+	endpoint string
+	sendC    chan *etcdraftpb.Message
+	stopC    chan struct{}
+}
+
+// peerReport tells the raft node that a message could not be sent to another node, or
+// whether a snapshot was sent
+type peerReport struct {
+	to       uint64
+	snapshot bool
+	failed   bool
+}
+
+// chain implements consensus.Chain for the raft consenter. A single goroutine drives the etcd raft
+// node of the channel. If the node is the leader, the goroutine cuts the submitted messages into
+// blocks and proposes them as raft log entries, one block at a time. Otherwise, the submissions
+// are forwarded to the leader. On every node, the committed entries are written to the ledger.
+// The config blocks which add or remove a consenter are proposed as raft membership changes.
+// Once enough blocks are committed to the ledger, the raft log is compacted into a snapshot that
+// records the last of them; a follower that receives such a snapshot pulls the blocks it lacks
+// from the other consenters
+type chain struct {
+	support          consensus.ConsenterSupport
+	channel          string
+	id               uint64
+	communicator     Communicator
+	puller           BlockPuller
+	storage          *raftStorage
+	node             *etcdraft.RawNode
+	tickInterval     time.Duration
+	snapshotInterval uint64
+
+	// members maps the node IDs of the consenters of the channel to their endpoints and certificates,
+	// and is updated along with the membership of the raft cluster
+	membersLock sync.RWMutex
+	members     map[uint64]*member
+
+	// the following fields are only accessed by the goroutine that drives the node
+	applied           uint64
+	confState         etcdraftpb.ConfState
+	blockMetadata     *raftpb.BlockMetadata
+	lastBlockNumber   uint64
+	lastBlockHash     []byte
+	lastSnapshotBlock uint64
+	written           []writtenBlock
+	pendingBatches    [][]*cb.Envelope
+	pendingConfig     *cb.Envelope
+	senders           map[uint64]*sender
+
+	leader   uint64 // accessed atomically
+	started  uint32 // accessed atomically
+	submitC  chan *submission
+	stepC    chan *etcdraftpb.Message
+	reportC  chan peerReport
+	haltC    chan struct{}
+	doneC    chan struct{}
+	haltOnce sync.Once
+}
+
+// newChain creates the raft node of a channel. The node bootstraps the raft cluster of a new channel, whose ledger
+// only holds the genesis block, with the consenters of the genesis block. Otherwise, it resumes from its raft log,
+// or, if the log is empty because this consenter was added to the channel, waits for the leader to bring it up to date
+func newChain(support consensus.ConsenterSupport, id uint64, blockMetadata *raftpb.BlockMetadata, members map[uint64]*member,
+	communicator Communicator, puller BlockPuller, s *raftStorage, opts *raftpb.Options) (*chain, error) {
+	var peers []etcdraft.Peer
+	if s.isEmpty() && support.Height() == 1 {
+		for _, peer := range blockMetadata.ConsenterIds {
+			peers = append(peers, etcdraft.Peer{ID: peer})
+		}
+	}
+	node, err := etcdraft.NewRawNode(&etcdraft.Config{
+		ID:              id,
+		ElectionTick:    int(opts.ElectionTick),
+		HeartbeatTick:   int(opts.HeartbeatTick),
+		Storage:         s.ram,
+		MaxSizePerMsg:   uint64(opts.MaxSizePerMsg),
+		MaxInflightMsgs: maxInflightMsgs,
+		CheckQuorum:     true,
+		PreVote:         true,
+		Logger:          logger,
+	}, peers)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating the raft node")
+	}
+
+	lastBlock := support.Block(support.Height() - 1)
+	if lastBlock == nil {
+		return nil, errors.Errorf("block [%d] is missing from the ledger", support.Height()-1)
+	}
+	c := &chain{
+		support:          support,
+		channel:          support.ChainID(),
+		id:               id,
+		communicator:     communicator,
+		puller:           puller,
+		storage:          s,
+		node:             node,
+		tickInterval:     time.Duration(opts.TickInterval) * time.Millisecond,
+		snapshotInterval: uint64(opts.SnapshotInterval),
+		members:          members,
+		blockMetadata:    blockMetadata,
+		lastBlockNumber:  lastBlock.Header.Number,
+		lastBlockHash:    lastBlock.Header.Hash(),
+		senders:          make(map[uint64]*sender),
+		submitC:          make(chan *submission),
+		stepC:            make(chan *etcdraftpb.Message, stepBufferSize),
+		reportC:          make(chan peerReport, reportBufferSize),
+		haltC:            make(chan struct{}),
+		doneC:            make(chan struct{}),
+	}
+
+	// the entries following the snapshot are applied again, skipping the blocks already in the ledger
+	if snapshot := s.snapshot(); !etcdraft.IsEmptySnap(snapshot) {
+		data := &raftpb.SnapshotData{}
+		if err := proto.Unmarshal(snapshot.Data, data); err != nil {
+			return nil, errors.Wrap(err, "error unmarshaling raft snapshot data")
+		}
+		c.applied = snapshot.Metadata.Index
+		c.confState = snapshot.Metadata.ConfState
+		c.lastSnapshotBlock = data.BlockNumber
+	}
+	return c, nil
+}
+
+// Start starts the goroutines that drive the raft node and send its messages
+func (c *chain) Start() {
+	atomic.StoreUint32(&c.started, 1)
+	c.updateSenders()
+	go c.run()
+}
+
+// Halt stops the raft node and waits for its goroutine to exit
+func (c *chain) Halt() {
+	c.haltOnce.Do(func() { close(c.haltC) })
+	if atomic.LoadUint32(&c.started) == 1 {
+		<-c.doneC
+	}
+}
+
+// WaitReady does not block, as the submissions are queued or forwarded as they arrive
+func (c *chain) WaitReady() error {
+	return nil
+}
+
+// Errored only closes on exit
+func (c *chain) Errored() <-chan struct{} {
+	return c.haltC
+}
+
+// Order submits normal messages for ordering, forwarding them to the leader if required
+func (c *chain) Order(env *cb.Envelope, configSeq uint64) error {
+	return c.submit(&submission{configSeq: configSeq, normalMsg: env})
+}
+
+// Configure submits config messages for ordering, forwarding them to the leader if required
+func (c *chain) Configure(config *cb.Envelope, configSeq uint64) error {
+	if err := c.checkConfig(config); err != nil {
+		return err
+	}
+	return c.submit(&submission{configSeq: configSeq, configMsg: config})
+}
+
+func (c *chain) submit(s *submission) error {
+	select {
+	case <-c.haltC:
+		return errors.Errorf("raft node for channel %s is halted", c.channel)
+	default:
+	}
+	switch lead := atomic.LoadUint64(&c.leader); lead {
+	case 0:
+		return errors.Errorf("no raft leader is elected for channel %s", c.channel)
+	case c.id:
+		return c.enqueue(s)
+	default:
+		endpoint, ok := c.endpoint(lead)
+		if !ok {
+			return errors.Errorf("raft leader %d of channel %s is not a known consenter", lead, c.channel)
+		}
+		return c.communicator.Submit(endpoint, c.submitRequest(s))
+	}
+}
+
+// handleSubmit accepts a submission forwarded by another consenter
+func (c *chain) handleSubmit(req *raftpb.SubmitRequest) error {
+	if atomic.LoadUint64(&c.leader) != c.id {
+		return errors.Errorf("raft node %d is not the leader of channel %s", c.id, c.channel)
+	}
+	s := &submission{configSeq: req.ConfigSeq, forwarded: true}
+	if req.IsConfig {
+		s.configMsg = req.Content
+	} else {
+		s.normalMsg = req.Content
+	}
+	return c.enqueue(s)
+}
+
+// handleStep accepts a raft protocol message sent by another consenter
+func (c *chain) handleStep(m *etcdraftpb.Message) error {
+	select {
+	case c.stepC <- m:
+		return nil
+	case <-c.haltC:
+		return errors.Errorf("raft node for channel %s is halted", c.channel)
+	}
+}
+
+func (c *chain) enqueue(s *submission) error {
+	select {
+	case c.submitC <- s:
+		return nil
+	case <-c.haltC:
+		return errors.Errorf("raft node for channel %s is halted", c.channel)
+	}
+}
+
+func (c *chain) submitRequest(s *submission) *raftpb.SubmitRequest {
+	req := &raftpb.SubmitRequest{Channel: c.channel, ConfigSeq: s.configSeq, Content: s.normalMsg}
+	if s.configMsg != nil {
+		req.Content = s.configMsg
+		req.IsConfig = true
+	}
+	return req
+}
+
+// callerIDs returns the node IDs of the consenters which the caller is authenticated as by its client
+// TLS certificate. Several consenters may share a certificate, e.g. in test networks
+func (c *chain) callerIDs(ctx context.Context) (map[uint64]struct{}, error) {
+	callerHash := comm.ExtractCertificateHashFromContext(ctx)
+	if len(callerHash) == 0 {
+		return nil, errors.New("the caller did not present a TLS certificate")
+	}
+	c.membersLock.RLock()
+	defer c.membersLock.RUnlock()
+	ids := make(map[uint64]struct{})
+	for id, m := range c.members {
+		if bytes.Equal(m.certHash, callerHash) {
+			ids[id] = struct{}{}
+		}
+	}
+	if len(ids) == 0 {
+		return nil, errors.Errorf("the caller is not a consenter of channel %s", c.channel)
+	}
+	return ids, nil
+}
+
+func (c *chain) endpoint(id uint64) (string, bool) {
+	c.membersLock.RLock()
+	defer c.membersLock.RUnlock()
+	m, ok := c.members[id]
+	if !ok {
+		return "", false
+	}
+	return m.endpoint, true
+}
+
+func (c *chain) run() {
+	defer close(c.doneC)
+	ticker := time.NewTicker(c.tickInterval)
+	defer ticker.Stop()
+	var timer <-chan time.Time
+
+	// the ledger may not have committed the blocks of a snapshot received before a restart
+	if snapshot := c.storage.snapshot(); !etcdraft.IsEmptySnap(snapshot) && !c.catchUp(snapshot) {
+		return
+	}
+	// apply the entries that were committed but not written to the ledger before a restart
+	if !c.ready(&timer) {
+		return
+	}
+	for {
+		// stop accepting submissions until the blocks which are cut so far are proposed
+		submitC := c.submitC
+		if c.pendingConfig != nil || len(c.pendingBatches) > 0 {
+			submitC = nil
+		}
+
+		select {
+		case s := <-submitC:
+			c.process(s, &timer)
+		case m := <-c.stepC:
+			if err := c.node.Step(*m); err != nil {
+				logger.Debugf("Raft node %d of channel %s failed to step a message from node %d: %s", c.id, c.channel, m.From, err)
+			}
+		case r := <-c.reportC:
+			switch {
+			case r.snapshot && r.failed:
+				c.node.ReportSnapshot(r.to, etcdraft.SnapshotFailure)
+			case r.snapshot:
+				c.node.ReportSnapshot(r.to, etcdraft.SnapshotFinish)
+			default:
+				c.node.ReportUnreachable(r.to)
+			}
+		case <-ticker.C:
+			c.node.Tick()
+			c.maybeSnapshot()
+		case <-timer:
+			timer = nil
+			batch := c.support.BlockCutter().Cut()
+			if len(batch) == 0 {
+				logger.Warningf("Batch timer expired with no pending requests, this might indicate a bug")
+				continue
+			}
+			logger.Debugf("Batch timer expired, creating block")
+			c.pendingBatches = append(c.pendingBatches, batch)
+		case <-c.haltC:
+			logger.Infof("Halting raft node %d of channel %s", c.id, c.channel)
+			return
+		}
+		if !c.ready(&timer) {
+			return
+		}
+	}
+}
+
+// process cuts the submitted messages into batches if this node is the leader,
+// and forwards them to the leader otherwise
+func (c *chain) process(s *submission, timer *<-chan time.Time) {
+	if atomic.LoadUint64(&c.leader) != c.id {
+		c.forward(s)
+		return
+	}
+
+	seq := c.support.Sequence()
+	if s.configMsg == nil {
+		if s.forwarded || s.configSeq < seq {
+			if _, err := c.support.ProcessNormalMsg(s.normalMsg); err != nil {
+				logger.Warningf("Discarding bad normal message: %s", err)
+				return
+			}
+		}
+		batches, pending := c.support.BlockCutter().Ordered(s.normalMsg)
+		c.pendingBatches = append(c.pendingBatches, batches...)
+		if len(batches) > 0 {
+			*timer = nil
+		}
+		if pending && *timer == nil {
+			*timer = time.After(c.support.SharedConfig().BatchTimeout())
+		}
+		return
+	}
+
+	config := s.configMsg
+	if s.forwarded || s.configSeq < seq {
+		var err error
+		if config, _, err = c.support.ProcessConfigMsg(s.configMsg); err != nil {
+			logger.Warningf("Discarding bad config message: %s", err)
+			return
+		}
+	}
+	if err := c.checkConfig(config); err != nil {
+		logger.Warningf("Discarding bad config message: %s", err)
+		return
+	}
+	if batch := c.support.BlockCutter().Cut(); batch != nil {
+		c.pendingBatches = append(c.pendingBatches, batch)
+	}
+	c.pendingConfig = config
+	*timer = nil
+}
+
+func (c *chain) forward(s *submission) {
+	lead := atomic.LoadUint64(&c.leader)
+	endpoint, ok := c.endpoint(lead)
+	if !ok {
+		logger.Warningf("Discarding a message on channel %s as no raft leader is elected", c.channel)
+		return
+	}
+	go func() {
+		if err := c.communicator.Submit(endpoint, c.submitRequest(s)); err != nil {
+			logger.Warningf("Failed to forward a message on channel %s to raft node %d: %s", c.channel, lead, err)
+		}
+	}()
+}
+
+// ready processes the Readys of the raft node: it tracks the leadership, persists the raft state,
+// catches up with the snapshots received from the leader, sends out the messages, writes the committed
+// blocks to the ledger, and finally proposes the next block if this node is the leader. It returns false
+// if the chain is halted, e.g. as this node was removed from the channel
+func (c *chain) ready(timer *<-chan time.Time) bool {
+	for {
+		for c.node.HasReady() {
+			rd := c.node.Ready()
+			if rd.SoftState != nil {
+				lead := rd.SoftState.Lead
+				// a node campaigning for the leadership keeps the leader it lost contact with
+				if rd.SoftState.RaftState == etcdraft.StatePreCandidate || rd.SoftState.RaftState == etcdraft.StateCandidate {
+					lead = 0
+				}
+				c.updateLeader(lead, timer)
+			}
+			if err := c.storage.store(rd.Snapshot, rd.Entries, rd.HardState); err != nil {
+				logger.Panicf("Failed to persist the raft state of channel %s: %s", c.channel, err)
+			}
+			if !etcdraft.IsEmptySnap(rd.Snapshot) && !c.catchUp(rd.Snapshot) {
+				return false
+			}
+			for i := range rd.Messages {
+				c.send(&rd.Messages[i])
+			}
+			removed := c.apply(rd.CommittedEntries)
+			c.node.Advance(rd)
+			if removed {
+				logger.Infof("Raft node %d was removed from channel %s", c.id, c.channel)
+				c.haltOnce.Do(func() { close(c.haltC) })
+				return false
+			}
+		}
+		if !c.proposeNext() {
+			return true
+		}
+	}
+}
+
+func (c *chain) updateLeader(lead uint64, timer *<-chan time.Time) {
+	if lead == atomic.LoadUint64(&c.leader) {
+		return
+	}
+	if atomic.LoadUint64(&c.leader) == c.id {
+		c.abandonPending(timer)
+	}
+	logger.Infof("Raft leader of channel %s changed to node %d on node %d", c.channel, lead, c.id)
+	atomic.StoreUint64(&c.leader, lead)
+}
+
+// abandonPending drops the messages that were received while this node was the leader
+// but have not been proposed yet. The clients are expected to resubmit such messages
+func (c *chain) abandonPending(timer *<-chan time.Time) {
+	dropped := len(c.pendingBatches)
+	if batch := c.support.BlockCutter().Cut(); batch != nil {
+		dropped++
+	}
+	if c.pendingConfig != nil {
+		dropped++
+	}
+	if dropped > 0 {
+		logger.Warningf("Raft node %d lost the leadership of channel %s, dropping %d pending blocks",
+			c.id, c.channel, dropped)
+	}
+	c.pendingBatches = nil
+	c.pendingConfig = nil
+	*timer = nil
+}
+
+// proposeNext proposes the next block if this node is the leader, and all the entries in its log have
+// been written to the ledger. This keeps at most one block in flight, as required by CreateNextBlock.
+// A config block which adds or removes a consenter is proposed as a raft membership change
+func (c *chain) proposeNext() bool {
+	if atomic.LoadUint64(&c.leader) != c.id || c.applied != c.storage.lastIndex() {
+		return false
+	}
+	var block *cb.Block
+	var err error
+	switch {
+	case len(c.pendingBatches) > 0:
+		block = c.support.CreateNextBlock(c.pendingBatches[0])
+		c.pendingBatches = c.pendingBatches[1:]
+		err = c.node.Propose(utils.MarshalOrPanic(block))
+	case c.pendingConfig != nil:
+		block = c.support.CreateNextBlock([]*cb.Envelope{c.pendingConfig})
+		var cc *etcdraftpb.ConfChange
+		cc, err = c.confChange(c.pendingConfig)
+		c.pendingConfig = nil
+		if err != nil {
+			break
+		}
+		if cc == nil {
+			err = c.node.Propose(utils.MarshalOrPanic(block))
+			break
+		}
+		cc.Context = utils.MarshalOrPanic(block)
+		err = c.node.ProposeConfChange(*cc)
+	default:
+		return false
+	}
+	if err != nil {
+		logger.Warningf("Raft node %d failed to propose block [%d] of channel %s: %s", c.id, block.Header.Number, c.channel, err)
+		return false
+	}
+	logger.Debugf("Raft node %d proposing block [%d] of channel %s", c.id, block.Header.Number, c.channel)
+	return true
+}
+
+// apply writes the blocks carried by the newly committed entries to the ledger, and applies the
+// membership changes. It returns true if this node was removed from the raft cluster
+func (c *chain) apply(entries []etcdraftpb.Entry) bool {
+	for i := range entries {
+		e := &entries[i]
+		if e.Index <= c.applied {
+			continue
+		}
+		var block *cb.Block
+		removed := false
+		switch e.Type {
+		case etcdraftpb.EntryNormal:
+			// the leader appends an empty entry when it is elected
+			if len(e.Data) > 0 {
+				block = unmarshalBlock(e.Data, e.Index, c.channel)
+				c.writeBlock(block, e, nil)
+			}
+		case etcdraftpb.EntryConfChange:
+			cc := etcdraftpb.ConfChange{}
+			if err := cc.Unmarshal(e.Data); err != nil {
+				logger.Panicf("Failed to unmarshal the membership change of raft entry [%d] of channel %s: %s", e.Index, c.channel, err)
+			}
+			// the membership changes which bootstrap the raft cluster carry no block
+			if len(cc.Context) > 0 {
+				block = unmarshalBlock(cc.Context, e.Index, c.channel)
+				c.writeBlock(block, e, &cc)
+			}
+			c.confState = *c.node.ApplyConfChange(cc)
+			removed = cc.Type == etcdraftpb.ConfChangeRemoveNode && cc.NodeID == c.id
+		}
+		c.applied = e.Index
+		if block != nil {
+			c.written = append(c.written, writtenBlock{index: e.Index, number: block.Header.Number, hash: block.Header.Hash(), confState: c.confState})
+		}
+		if removed {
+			return true
+		}
+	}
+	return false
+}
+
+func unmarshalBlock(data []byte, index uint64, channel string) *cb.Block {
+	block := &cb.Block{}
+	if err := proto.Unmarshal(data, block); err != nil || block.Header == nil {
+		logger.Panicf("Failed to unmarshal the block of raft entry [%d] of channel %s: %v", index, channel, err)
+	}
+	return block
+}
+
+// writeBlock writes the block carried by a committed entry to the ledger, unless the block was written before a
+// restart. The block of a membership change is a config block, along with which the node IDs of the consenters
+// are updated
+func (c *chain) writeBlock(block *cb.Block, e *etcdraftpb.Entry, cc *etcdraftpb.ConfChange) {
+	number := block.Header.Number
+	if number <= c.lastBlockNumber {
+		logger.Debugf("Skipping block [%d] of channel %s, which is already in the ledger", number, c.channel)
+		return
+	}
+	if number != c.lastBlockNumber+1 {
+		logger.Panicf("Raft entry [%d] of channel %s carries block [%d], while the next block expected is [%d]",
+			e.Index, c.channel, number, c.lastBlockNumber+1)
+	}
+
+	if cc != nil {
+		blockMetadata, err := c.updatedBlockMetadata(block, cc)
+		if err != nil {
+			logger.Panicf("Failed to apply the membership change of block [%d] of channel %s: %s", number, c.channel, err)
+		}
+		c.blockMetadata = blockMetadata
+	}
+	metadata := utils.MarshalOrPanic(&raftpb.BlockMetadata{
+		Term:            e.Term,
+		Index:           e.Index,
+		ConsenterIds:    c.blockMetadata.ConsenterIds,
+		NextConsenterId: c.blockMetadata.NextConsenterId,
+	})
+	isConfig := cc != nil || isConfigBlock(block)
+	if isConfig {
+		c.support.WriteConfigBlock(block, metadata)
+		c.updateMembers()
+	} else {
+		c.support.WriteBlock(block, metadata)
+	}
+	logger.Debugf("Raft node %d wrote block [%d] of channel %s", c.id, number, c.channel)
+	c.lastBlockNumber = number
+	c.lastBlockHash = block.Header.Hash()
+}
+
+// updatedBlockMetadata returns the node IDs of the consenters of the config block of a membership change.
+// The consenter added is given the node ID of the change, while the others keep their IDs
+func (c *chain) updatedBlockMetadata(block *cb.Block, cc *etcdraftpb.ConfChange) (*raftpb.BlockMetadata, error) {
+	env, err := utils.ExtractEnvelope(block, 0)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := consensusMetadata(env)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, errors.New("the block is not a config block")
+	}
+	current, err := unmarshalConfigMetadata(c.support.SharedConfig().ConsensusMetadata())
+	if err != nil {
+		return nil, err
+	}
+	blockMetadata := &raftpb.BlockMetadata{NextConsenterId: c.blockMetadata.NextConsenterId}
+	for _, consenter := range updated.Consenters {
+		id := cc.NodeID
+		for i := range current.Consenters {
+			if proto.Equal(consenter, current.Consenters[i]) {
+				id = c.blockMetadata.ConsenterIds[i]
+				break
+			}
+		}
+		blockMetadata.ConsenterIds = append(blockMetadata.ConsenterIds, id)
+	}
+	if cc.NodeID >= blockMetadata.NextConsenterId {
+		blockMetadata.NextConsenterId = cc.NodeID + 1
+	}
+	return blockMetadata, nil
+}
+
+// updateMembers refreshes the consenters of the channel from the current config, and starts
+// or stops the senders of their messages accordingly
+func (c *chain) updateMembers() {
+	configMetadata, err := unmarshalConfigMetadata(c.support.SharedConfig().ConsensusMetadata())
+	if err != nil {
+		logger.Panicf("Invalid consensus metadata of channel %s: %s", c.channel, err)
+	}
+	members, err := consenterMembers(configMetadata, c.blockMetadata)
+	if err != nil {
+		logger.Panicf("Invalid consenters of channel %s: %s", c.channel, err)
+	}
+	c.membersLock.Lock()
+	c.members = members
+	c.membersLock.Unlock()
+	c.updateSenders()
+}
+
+// consenterMembers maps the node IDs of the consenters of the channel to their endpoints and certificates
+func consenterMembers(configMetadata *raftpb.ConfigMetadata, blockMetadata *raftpb.BlockMetadata) (map[uint64]*member, error) {
+	if len(blockMetadata.ConsenterIds) != len(configMetadata.Consenters) {
+		return nil, errors.Errorf("the channel has %d consenters, but %d raft node IDs",
+			len(configMetadata.Consenters), len(blockMetadata.ConsenterIds))
+	}
+	members := make(map[uint64]*member)
+	for i, consenter := range configMetadata.Consenters {
+		// the certificates were validated along with the metadata
+		hash, _ := certHash(consenter.ClientTlsCert)
+		members[blockMetadata.ConsenterIds[i]] = &member{
+			endpoint: fmt.Sprintf("%s:%d", consenter.Host, consenter.Port),
+			certHash: hash,
+		}
+	}
+	return members, nil
+}
+
+func (c *chain) updateSenders() {
+	c.membersLock.RLock()
+	defer c.membersLock.RUnlock()
+	for id, s := range c.senders {
+		if _, ok := c.members[id]; !ok {
+			close(s.stopC)
+			delete(c.senders, id)
+		}
+	}
+	for id, m := range c.members {
+		if _, ok := c.senders[id]; ok || id == c.id {
+			continue
+		}
+		s := &sender{endpoint: m.endpoint, sendC: make(chan *etcdraftpb.Message, sendBufferSize), stopC: make(chan struct{})}
+		c.senders[id] = s
+		go c.sendLoop(id, s)
+	}
+}
+
+// catchUp brings the ledger up to the block recorded by a snapshot received from the leader, by pulling the
+// blocks it lacks from the other consenters. It returns false if the chain is halted before they are pulled
+func (c *chain) catchUp(snapshot etcdraftpb.Snapshot) bool {
+	data := &raftpb.SnapshotData{}
+	if err := proto.Unmarshal(snapshot.Data, data); err != nil {
+		logger.Panicf("Failed to unmarshal the raft snapshot data of channel %s: %s", c.channel, err)
+	}
+	c.applied = snapshot.Metadata.Index
+	c.confState = snapshot.Metadata.ConfState
+	c.lastSnapshotBlock = data.BlockNumber
+	c.written = nil
+	if data.BlockNumber <= c.lastBlockNumber {
+		return true
+	}
+
+	start, end := c.lastBlockNumber+1, data.BlockNumber+1
+	logger.Infof("Raft node %d received a snapshot of channel %s at block [%d], pulling blocks [%d, %d)",
+		c.id, c.channel, data.BlockNumber, start, end)
+	var blocks []*cb.Block
+	for {
+		var err error
+		blocks, err = c.pullBlocks(start, end)
+		if err == nil {
+			err = verifyBlocks(blocks, start, c.lastBlockHash, data.BlockHash)
+		}
+		if err == nil {
+			break
+		}
+		logger.Warningf("Raft node %d failed to pull blocks [%d, %d) of channel %s: %s", c.id, start, end, c.channel, err)
+		select {
+		case <-time.After(pullRetryInterval):
+		case <-c.haltC:
+			return false
+		}
+	}
+
+	for _, block := range blocks {
+		metadata, err := utils.GetMetadataFromBlock(block, cb.BlockMetadataIndex_ORDERER)
+		if err != nil {
+			logger.Panicf("Failed to read the metadata of block [%d] of channel %s: %s", block.Header.Number, c.channel, err)
+		}
+		blockMetadata := &raftpb.BlockMetadata{}
+		if err := proto.Unmarshal(metadata.Value, blockMetadata); err != nil {
+			logger.Panicf("Failed to unmarshal the raft metadata of block [%d] of channel %s: %s", block.Header.Number, c.channel, err)
+		}
+		c.blockMetadata = blockMetadata
+		if isConfigBlock(block) {
+			c.support.WriteConfigBlock(block, metadata.Value)
+			c.updateMembers()
+		} else {
+			c.support.WriteBlock(block, metadata.Value)
+		}
+	}
+	c.lastBlockNumber = data.BlockNumber
+	c.lastBlockHash = data.BlockHash
+	return true
+}
+
+func (c *chain) pullBlocks(start, end uint64) ([]*cb.Block, error) {
+	c.membersLock.RLock()
+	var endpoints []string
+	for id, m := range c.members {
+		if id != c.id {
+			endpoints = append(endpoints, m.endpoint)
+		}
+	}
+	c.membersLock.RUnlock()
+	return c.puller.PullBlocks(c.channel, endpoints, start, end)
+}
+
+// verifyBlocks checks that the blocks pulled are consecutive from the given start, and chained by their hashes
+// from the block of previousHash up to the block of lastHash
+func verifyBlocks(blocks []*cb.Block, start uint64, previousHash, lastHash []byte) error {
+	for i, block := range blocks {
+		number := start + uint64(i)
+		if block == nil || block.Header == nil || block.Data == nil {
+			return errors.Errorf("block [%d] is missing its header or data", number)
+		}
+		if block.Header.Number != number {
+			return errors.Errorf("expected block [%d], but got block [%d]", number, block.Header.Number)
+		}
+		if !bytes.Equal(block.Header.DataHash, block.Data.Hash()) {
+			return errors.Errorf("the data hash of block [%d] does not match its data", number)
+		}
+		if !bytes.Equal(block.Header.PreviousHash, previousHash) {
+			return errors.Errorf("the previous hash of block [%d] does not match the hash of block [%d]", number, number-1)
+		}
+		previousHash = block.Header.Hash()
+	}
+	if !bytes.Equal(previousHash, lastHash) {
+		return errors.Errorf("the hash of block [%d] does not match the hash recorded by the snapshot", start+uint64(len(blocks))-1)
+	}
+	return nil
+}
+
+// maybeSnapshot takes a snapshot of the raft log once snapshotInterval blocks have been committed to the ledger
+// since the last snapshot, and compacts the log. As the ledger commits the blocks asynchronously, the snapshot
+// records the last block which is committed already
+func (c *chain) maybeSnapshot() {
+	height := c.support.Height()
+	i := -1
+	for j := range c.written {
+		if c.written[j].number >= height {
+			break
+		}
+		i = j
+	}
+	if i < 0 {
+		return
+	}
+	c.written = c.written[i:]
+	last := c.written[0]
+	if last.number < c.lastSnapshotBlock+c.snapshotInterval {
+		return
+	}
+	data := utils.MarshalOrPanic(&raftpb.SnapshotData{BlockNumber: last.number, BlockHash: last.hash})
+	if err := c.storage.takeSnapshot(last.index, last.confState, data); err != nil {
+		logger.Errorf("Raft node %d failed to take a snapshot of channel %s at block [%d]: %s", c.id, c.channel, last.number, err)
+		return
+	}
+	logger.Infof("Raft node %d took a snapshot of channel %s at block [%d], compacting the raft log up to entry [%d]",
+		c.id, c.channel, last.number, last.index)
+	c.lastSnapshotBlock = last.number
+	c.written = c.written[1:]
+}
+
+func (c *chain) send(m *etcdraftpb.Message) {
+	s, ok := c.senders[m.To]
+	if ok {
+		select {
+		case s.sendC <- m:
+			return
+		default:
+			logger.Debugf("Dropping a raft message of channel %s to node %d, as its send buffer is full", c.channel, m.To)
+		}
+	} else {
+		logger.Debugf("Dropping a raft message of channel %s to node %d, which is not a consenter", c.channel, m.To)
+	}
+	if m.Type == etcdraftpb.MsgSnap {
+		c.node.ReportSnapshot(m.To, etcdraft.SnapshotFailure)
+	}
+}
+
+func (c *chain) sendLoop(to uint64, s *sender) {
+	defer func() {
+		for {
+			select {
+			case m := <-s.sendC:
+				c.step(s.endpoint, m)
+			default:
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case m := <-s.sendC:
+			err := c.step(s.endpoint, m)
+			if err != nil {
+				logger.Debugf("Failed to send a raft message of channel %s to node %d: %s", c.channel, to, err)
+			}
+			if err == nil && m.Type != etcdraftpb.MsgSnap {
+				continue
+			}
+			select {
+			case c.reportC <- peerReport{to: to, snapshot: m.Type == etcdraftpb.MsgSnap, failed: err != nil}:
+			case <-s.stopC:
+				return
+			case <-c.haltC:
+				return
+			}
+		case <-s.stopC:
+			return
+		case <-c.haltC:
+			return
+		}
+	}
+}
+
+func (c *chain) step(endpoint string, m *etcdraftpb.Message) error {
+	msgBytes, err := m.Marshal()
+	if err != nil {
+		return err
+	}
+	return c.communicator.Step(endpoint, &raftpb.StepRequest{Channel: c.channel, Message: msgBytes})
+}
+
+// checkConfig rejects the config updates that change the consensus type, or that add or remove more
+// than one consenter at a time, as a raft cluster can only change its membership one node at a time
+func (c *chain) checkConfig(config *cb.Envelope) error {
+	updated, err := consensusMetadata(config)
+	if err != nil {
+		return err
+	}
+	if updated == nil {
+		return nil
+	}
+	current, err := unmarshalConfigMetadata(c.support.SharedConfig().ConsensusMetadata())
+	if err != nil {
+		return err
+	}
+	added, removed := diffConsenters(current.Consenters, updated.Consenters)
+	if len(added)+len(removed) > 1 {
+		return errors.Errorf("only one consenter can be added or removed at a time, but the update of channel %s "+
+			"adds %d and removes %d", c.channel, len(added), len(removed))
+	}
+	return nil
+}
+
+// confChange returns the raft membership change made by a config update, or nil if it does not change the consenters
+func (c *chain) confChange(config *cb.Envelope) (*etcdraftpb.ConfChange, error) {
+	updated, err := consensusMetadata(config)
+	if err != nil || updated == nil {
+		return nil, err
+	}
+	current, err := unmarshalConfigMetadata(c.support.SharedConfig().ConsensusMetadata())
+	if err != nil {
+		return nil, err
+	}
+	added, removed := diffConsenters(current.Consenters, updated.Consenters)
+	switch {
+	case len(added) == 1:
+		return &etcdraftpb.ConfChange{Type: etcdraftpb.ConfChangeAddNode, NodeID: c.blockMetadata.NextConsenterId}, nil
+	case len(removed) == 1:
+		return &etcdraftpb.ConfChange{Type: etcdraftpb.ConfChangeRemoveNode, NodeID: c.blockMetadata.ConsenterIds[removed[0]]}, nil
+	}
+	return nil, nil
+}
+
+// diffConsenters returns the indices of the updated consenters which are added, and of the
+// current consenters which are removed
+func diffConsenters(current, updated []*raftpb.Consenter) (added, removed []int) {
+	contains := func(consenters []*raftpb.Consenter, consenter *raftpb.Consenter) bool {
+		for _, other := range consenters {
+			if proto.Equal(consenter, other) {
+				return true
+			}
+		}
+		return false
+	}
+	for i, consenter := range updated {
+		if !contains(current, consenter) {
+			added = append(added, i)
+		}
+	}
+	for i, consenter := range current {
+		if !contains(updated, consenter) {
+			removed = append(removed, i)
+		}
+	}
+	return added, removed
+}
+
+// consensusMetadata returns the raft config metadata of a config update, or nil if the update is
+// not a config update of the channel, e.g. the creation of a new channel on the system channel
+func consensusMetadata(config *cb.Envelope) (*raftpb.ConfigMetadata, error) {
+	payload, err := utils.UnmarshalPayload(config.Payload)
+	if err != nil {
+		return nil, err
+	}
+	if payload.Header == nil {
+		return nil, errors.New("config message is missing the payload header")
+	}
+	chdr, err := utils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return nil, err
+	}
+	if chdr.Type != int32(cb.HeaderType_CONFIG) {
+		return nil, nil
+	}
+	configEnv, err := configtx.UnmarshalConfigEnvelope(payload.Data)
+	if err != nil {
+		return nil, err
+	}
+	if configEnv.Config == nil || configEnv.Config.ChannelGroup == nil {
+		return nil, errors.New("config envelope is missing the channel group")
+	}
+	ordererGroup, ok := configEnv.Config.ChannelGroup.Groups[channelconfig.OrdererGroupKey]
+	if !ok {
+		return nil, errors.New("config is missing the orderer group")
+	}
+	consensusTypeValue, ok := ordererGroup.Values[channelconfig.ConsensusTypeKey]
+	if !ok {
+		return nil, errors.New("config is missing the consensus type")
+	}
+	consensusType := &ab.ConsensusType{}
+	if err := proto.Unmarshal(consensusTypeValue.Value, consensusType); err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling consensus type")
+	}
+	if consensusType.Type != consensusTypeRaft {
+		return nil, errors.Errorf("changing the consensus type to %s is not supported", consensusType.Type)
+	}
+	return unmarshalConfigMetadata(consensusType.Metadata)
+}
+
+// isConfigBlock tells whether a block carries a config transaction, which either updates the config
+// of the channel or, on the system channel, creates a new channel
+func isConfigBlock(block *cb.Block) bool {
+	if block.Data == nil || len(block.Data.Data) != 1 {
+		return false
+	}
+	env, err := utils.ExtractEnvelope(block, 0)
+	if err != nil {
+		return false
+	}
+	chdr, err := utils.ChannelHeader(env)
+	if err != nil {
+		return false
+	}
+	return chdr.Type == int32(cb.HeaderType_CONFIG) || chdr.Type == int32(cb.HeaderType_ORDERER_TRANSACTION)
+}
@@ -0,0 +1,769 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package raft
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	etcdraftpb "github.com/coreos/etcd/raft/raftpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	"github.com/hyperledger/fabric/common/configtx"
+	"github.com/hyperledger/fabric/common/flogging"
+	mockconfig "github.com/hyperledger/fabric/common/mocks/config"
+	"github.com/hyperledger/fabric/orderer/common/blockcutter"
+	localconfig "github.com/hyperledger/fabric/orderer/common/localconfig"
+	mockmultichannel "github.com/hyperledger/fabric/orderer/mocks/common/multichannel"
+	mockutil "github.com/hyperledger/fabric/orderer/mocks/util"
+	cb "github.com/hyperledger/fabric/protos/common"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	raftpb "github.com/hyperledger/fabric/protos/orderer/raft"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	testChannel = "testchannel"
+	testTimeout = 10 * time.Second
+)
+
+func init() {
+	flogging.SetModuleLevel(pkgLogID, "DEBUG")
+}
+
+func testMessage(i int) *cb.Envelope {
+	return &cb.Envelope{
+		Payload: utils.MarshalOrPanic(&cb.Payload{
+			Header: &cb.Header{ChannelHeader: utils.MarshalOrPanic(&cb.ChannelHeader{ChannelId: testChannel})},
+			Data:   []byte(fmt.Sprintf("TEST_MESSAGE_%d", i)),
+		}),
+	}
+}
+
+func testConfigMessage(metadata []byte) *cb.Envelope {
+	consensusType := utils.MarshalOrPanic(&ab.ConsensusType{Type: consensusTypeRaft, Metadata: metadata})
+	configEnv := &cb.ConfigEnvelope{
+		Config: &cb.Config{
+			ChannelGroup: &cb.ConfigGroup{
+				Groups: map[string]*cb.ConfigGroup{
+					channelconfig.OrdererGroupKey: {
+						Values: map[string]*cb.ConfigValue{
+							channelconfig.ConsensusTypeKey: {Value: consensusType},
+						},
+					},
+				},
+			},
+		},
+	}
+	return &cb.Envelope{
+		Payload: utils.MarshalOrPanic(&cb.Payload{
+			Header: &cb.Header{ChannelHeader: utils.MarshalOrPanic(&cb.ChannelHeader{
+				Type:      int32(cb.HeaderType_CONFIG),
+				ChannelId: testChannel,
+			})},
+			Data: utils.MarshalOrPanic(configEnv),
+		}),
+	}
+}
+
+func testConfigMetadata(endpoints ...string) []byte {
+	return testConfigMetadataWithOptions(testOptions(), endpoints...)
+}
+
+func testOptions() *raftpb.Options {
+	return &raftpb.Options{TickInterval: 10, ElectionTick: 10, HeartbeatTick: 1, MaxSizePerMsg: 64 * 1024, SnapshotInterval: 100}
+}
+
+// testConfigMetadataWithOptions lists the consenters at the given endpoints, which are assigned if left empty
+func testConfigMetadataWithOptions(opts *raftpb.Options, endpoints ...string) []byte {
+	metadata := &raftpb.ConfigMetadata{Options: opts}
+	for i := range endpoints {
+		if endpoints[i] == "" {
+			endpoints[i] = fmt.Sprintf("raft.example.com:%d", 7050+i)
+		}
+		var port uint32
+		fmt.Sscanf(endpoints[i], "raft.example.com:%d", &port)
+		metadata.Consenters = append(metadata.Consenters, &raftpb.Consenter{
+			Host:          "raft.example.com",
+			Port:          port,
+			ClientTlsCert: testCert(endpoints[i]),
+		})
+	}
+	return utils.MarshalOrPanic(metadata)
+}
+
+// testConfigMetadataOf returns the raft config metadata of a config block
+func testConfigMetadataOf(block *cb.Block) []byte {
+	env := utils.UnmarshalEnvelopeOrPanic(block.Data.Data[0])
+	payload := utils.UnmarshalPayloadOrPanic(env.Payload)
+	config := configtx.UnmarshalConfigEnvelopeOrPanic(payload.Data)
+	consensusType := &ab.ConsensusType{}
+	if err := proto.Unmarshal(config.Config.ChannelGroup.Groups[channelconfig.OrdererGroupKey].Values[channelconfig.ConsensusTypeKey].Value, consensusType); err != nil {
+		panic(err)
+	}
+	return consensusType.Metadata
+}
+
+// testStepMessage returns a marshaled raft message from the given node
+func testStepMessage(from uint64) []byte {
+	m := &etcdraftpb.Message{Type: etcdraftpb.MsgHeartbeat, From: from, To: 1}
+	msgBytes, err := m.Marshal()
+	if err != nil {
+		panic(err)
+	}
+	return msgBytes
+}
+
+var testCerts = struct {
+	sync.Mutex
+	certs map[string][]byte
+}{certs: make(map[string][]byte)}
+
+// testCert returns the PEM encoded client TLS certificate of the consenter at the given endpoint,
+// which is generated the first time it is needed
+func testCert(endpoint string) []byte {
+	testCerts.Lock()
+	defer testCerts.Unlock()
+	if cert, ok := testCerts.certs[endpoint]; ok {
+		return cert
+	}
+	cert, _, err := mockutil.GenerateMockPublicPrivateKeyPairPEM(false)
+	if err != nil {
+		panic(err)
+	}
+	testCerts.certs[endpoint] = []byte(cert)
+	return []byte(cert)
+}
+
+// testCallerContext returns the context of a call made over mutual TLS by the consenter at the given endpoint
+func testCallerContext(endpoint string) context.Context {
+	block, _ := pem.Decode(testCert(endpoint))
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		panic(err)
+	}
+	return peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}},
+	})
+}
+
+// testLedger is a thread-safe in-memory ledger that outlives the restarts of a consenter
+type testLedger struct {
+	sync.Mutex
+	blocks []*cb.Block
+}
+
+func newTestLedger() *testLedger {
+	genesis := cb.NewBlock(0, nil)
+	genesis.Header.DataHash = genesis.Data.Hash()
+	return &testLedger{blocks: []*cb.Block{genesis}}
+}
+
+func (l *testLedger) height() uint64 {
+	l.Lock()
+	defer l.Unlock()
+	return uint64(len(l.blocks))
+}
+
+// clone returns a copy of the ledger up to the given height, as pulled by a consenter that joins the channel
+func (l *testLedger) clone(height uint64) *testLedger {
+	clone := &testLedger{}
+	for i := uint64(0); i < height; i++ {
+		clone.blocks = append(clone.blocks, proto.Clone(l.block(i)).(*cb.Block))
+	}
+	return clone
+}
+
+// configMetadata returns the raft config metadata of the last config block of the ledger
+func (l *testLedger) configMetadata() []byte {
+	for i := l.height() - 1; i > 0; i-- {
+		if block := l.block(i); isConfigBlock(block) {
+			return testConfigMetadataOf(block)
+		}
+	}
+	return nil
+}
+
+func (l *testLedger) block(number uint64) *cb.Block {
+	l.Lock()
+	defer l.Unlock()
+	if number >= uint64(len(l.blocks)) {
+		return nil
+	}
+	return l.blocks[number]
+}
+
+// testSupport implements consensus.ConsenterSupport over a testLedger, with a real blockcutter.
+// The config blocks written update the consenters of the shared config
+type testSupport struct {
+	*mockmultichannel.ConsenterSupport
+	blockCutter  blockcutter.Receiver
+	ledger       *testLedger
+	configLock   sync.Mutex
+	sharedConfig *mockconfig.Orderer
+}
+
+func newTestSupport(ledger *testLedger, metadata []byte) *testSupport {
+	sharedConfig := &mockconfig.Orderer{
+		ConsensusTypeVal:     consensusTypeRaft,
+		ConsensusMetadataVal: metadata,
+		BatchTimeoutVal:      200 * time.Millisecond,
+		BatchSizeVal: &ab.BatchSize{
+			MaxMessageCount:   2,
+			AbsoluteMaxBytes:  1024 * 1024,
+			PreferredMaxBytes: 1024 * 1024,
+		},
+	}
+	return &testSupport{
+		ConsenterSupport: &mockmultichannel.ConsenterSupport{
+			SharedConfigVal: sharedConfig,
+			ChainIDVal:      testChannel,
+		},
+		blockCutter:  blockcutter.NewReceiverImpl(sharedConfig),
+		ledger:       ledger,
+		sharedConfig: sharedConfig,
+	}
+}
+
+func (ts *testSupport) SharedConfig() channelconfig.Orderer {
+	ts.configLock.Lock()
+	defer ts.configLock.Unlock()
+	sharedConfig := *ts.sharedConfig
+	return &sharedConfig
+}
+
+func (ts *testSupport) BlockCutter() blockcutter.Receiver {
+	return ts.blockCutter
+}
+
+func (ts *testSupport) CreateNextBlock(messages []*cb.Envelope) *cb.Block {
+	last := ts.ledger.block(ts.ledger.height() - 1)
+	data := &cb.BlockData{}
+	for _, msg := range messages {
+		data.Data = append(data.Data, utils.MarshalOrPanic(msg))
+	}
+	block := cb.NewBlock(last.Header.Number+1, last.Header.Hash())
+	block.Header.DataHash = data.Hash()
+	block.Data = data
+	return block
+}
+
+func (ts *testSupport) WriteBlock(block *cb.Block, encodedMetadataValue []byte) {
+	block.Metadata.Metadata[cb.BlockMetadataIndex_ORDERER] = utils.MarshalOrPanic(&cb.Metadata{Value: encodedMetadataValue})
+	ts.ledger.Lock()
+	defer ts.ledger.Unlock()
+	last := ts.ledger.blocks[len(ts.ledger.blocks)-1]
+	if block.Header.Number != last.Header.Number+1 || !proto.Equal(block.Header, &cb.BlockHeader{
+		Number: block.Header.Number, PreviousHash: last.Header.Hash(), DataHash: block.Header.DataHash}) {
+		panic(fmt.Sprintf("block [%d] does not follow block [%d]", block.Header.Number, last.Header.Number))
+	}
+	ts.ledger.blocks = append(ts.ledger.blocks, block)
+}
+
+func (ts *testSupport) WriteConfigBlock(block *cb.Block, encodedMetadataValue []byte) {
+	ts.WriteBlock(block, encodedMetadataValue)
+	ts.configLock.Lock()
+	defer ts.configLock.Unlock()
+	ts.sharedConfig.ConsensusMetadataVal = testConfigMetadataOf(block)
+}
+
+func (ts *testSupport) Height() uint64 {
+	return ts.ledger.height()
+}
+
+func (ts *testSupport) Block(number uint64) *cb.Block {
+	return ts.ledger.block(number)
+}
+
+// testNetwork connects the consenters of a test in-process
+type testNetwork struct {
+	sync.RWMutex
+	t         *testing.T
+	dir       string
+	metadata  []byte
+	endpoints []string
+	pulls     uint32 // accessed atomically
+	connected map[string]*Consenter
+	nodes     map[string]*testNode
+}
+
+type testNode struct {
+	endpoint  string
+	consenter *Consenter
+	support   *testSupport
+	ledger    *testLedger
+	chain     *chain
+}
+
+// testCommunicator delivers the requests of a consenter to the other connected consenters
+type testCommunicator struct {
+	from    string
+	network *testNetwork
+}
+
+func (tc *testCommunicator) Step(endpoint string, req *raftpb.StepRequest) error {
+	c, err := tc.network.route(tc.from, endpoint)
+	if err != nil {
+		return err
+	}
+	_, err = c.Step(testCallerContext(tc.from), proto.Clone(req).(*raftpb.StepRequest))
+	return err
+}
+
+func (tc *testCommunicator) Submit(endpoint string, req *raftpb.SubmitRequest) error {
+	c, err := tc.network.route(tc.from, endpoint)
+	if err != nil {
+		return err
+	}
+	_, err = c.Submit(testCallerContext(tc.from), proto.Clone(req).(*raftpb.SubmitRequest))
+	return err
+}
+
+// testPuller pulls the blocks of a consenter from the ledgers of the other connected consenters
+type testPuller struct {
+	network *testNetwork
+}
+
+func (tp *testPuller) PullBlocks(channelID string, endpoints []string, start, end uint64) ([]*cb.Block, error) {
+	atomic.AddUint32(&tp.network.pulls, 1)
+	tp.network.RLock()
+	defer tp.network.RUnlock()
+	for _, endpoint := range endpoints {
+		node, ok := tp.network.nodes[endpoint]
+		if !ok || node.ledger.height() < end {
+			continue
+		}
+		var blocks []*cb.Block
+		for i := start; i < end; i++ {
+			blocks = append(blocks, proto.Clone(node.ledger.block(i)).(*cb.Block))
+		}
+		return blocks, nil
+	}
+	return nil, errors.Errorf("no consenter serves blocks [%d, %d) of channel %s", start, end, channelID)
+}
+
+func newTestNetwork(t *testing.T, size int) *testNetwork {
+	return newTestNetworkWithOptions(t, size, testOptions())
+}
+
+func newTestNetworkWithOptions(t *testing.T, size int, opts *raftpb.Options) *testNetwork {
+	dir, err := ioutil.TempDir("", "raftconsenter")
+	assert.NoError(t, err)
+	endpoints := make([]string, size)
+	metadata := testConfigMetadataWithOptions(opts, endpoints...)
+	network := &testNetwork{
+		t:         t,
+		dir:       dir,
+		metadata:  metadata,
+		endpoints: endpoints,
+		connected: make(map[string]*Consenter),
+		nodes:     make(map[string]*testNode),
+	}
+	for _, endpoint := range endpoints {
+		network.start(endpoint, newTestLedger())
+	}
+	return network
+}
+
+func (n *testNetwork) route(from, to string) (*Consenter, error) {
+	n.RLock()
+	defer n.RUnlock()
+	if _, ok := n.connected[from]; !ok {
+		return nil, errors.Errorf("%s is disconnected", from)
+	}
+	c, ok := n.connected[to]
+	if !ok {
+		return nil, errors.Errorf("%s is unreachable", to)
+	}
+	return c, nil
+}
+
+// start starts a consenter, which resumes from its raft log if it has been started before
+func (n *testNetwork) start(endpoint string, ledger *testLedger) *testNode {
+	consenter := newConsenter(localconfig.Raft{WALDir: filepath.Join(n.dir, endpoint), Endpoint: endpoint},
+		&testCommunicator{from: endpoint, network: n}, &testPuller{network: n})
+	metadata := ledger.configMetadata()
+	if metadata == nil {
+		metadata = n.metadata
+	}
+	support := newTestSupport(ledger, metadata)
+	support.ProcessConfigMsgVal = testConfigMessage(n.metadata)
+	lastBlock := ledger.block(ledger.height() - 1)
+	blockMetadata, err := utils.GetMetadataFromBlock(lastBlock, cb.BlockMetadataIndex_ORDERER)
+	assert.NoError(n.t, err)
+	ch, err := consenter.HandleChain(support, blockMetadata)
+	assert.NoError(n.t, err)
+
+	node := &testNode{endpoint: endpoint, consenter: consenter, support: support, ledger: ledger, chain: ch.(*chain)}
+	n.Lock()
+	n.connected[endpoint] = consenter
+	n.nodes[endpoint] = node
+	n.Unlock()
+	ch.Start()
+	return node
+}
+
+// stop disconnects and halts a consenter
+func (n *testNetwork) stop(endpoint string) *testNode {
+	n.Lock()
+	node := n.nodes[endpoint]
+	delete(n.connected, endpoint)
+	delete(n.nodes, endpoint)
+	n.Unlock()
+	node.chain.Halt()
+	node.consenter.walProvider.Close()
+	return node
+}
+
+func (n *testNetwork) stopAll() {
+	for _, endpoint := range n.endpoints {
+		if _, ok := n.nodes[endpoint]; ok {
+			n.stop(endpoint)
+		}
+	}
+	os.RemoveAll(n.dir)
+}
+
+// node returns the running consenter with the given raft node ID
+func (n *testNetwork) node(id uint64) (*testNode, bool) {
+	n.RLock()
+	defer n.RUnlock()
+	for _, node := range n.nodes {
+		if node.chain.id == id {
+			return node, true
+		}
+	}
+	return nil, false
+}
+
+// leader waits until all the running consenters agree on a leader, and returns it
+func (n *testNetwork) leader() *testNode {
+	deadline := time.Now().Add(testTimeout)
+	for time.Now().Before(deadline) {
+		var lead uint64
+		agreed := true
+		n.RLock()
+		for _, node := range n.nodes {
+			l := node.chain.leaderID()
+			if l == 0 || (lead != 0 && l != lead) {
+				agreed = false
+				break
+			}
+			lead = l
+		}
+		n.RUnlock()
+		if agreed {
+			if node, ok := n.node(lead); ok {
+				return node
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	n.t.Fatal("Consenters did not agree on a leader in time")
+	return nil
+}
+
+// follower returns a running consenter other than the given leader
+func (n *testNetwork) follower(lead *testNode) *testNode {
+	for _, endpoint := range n.endpoints {
+		if node, ok := n.nodes[endpoint]; ok && node != lead {
+			return node
+		}
+	}
+	return nil
+}
+
+// waitForHeight waits until the ledgers of all the running consenters reach the given height
+// and checks that they contain the same blocks
+func (n *testNetwork) waitForHeight(height uint64) {
+	deadline := time.Now().Add(testTimeout)
+	for _, node := range n.nodes {
+		for node.ledger.height() < height {
+			if time.Now().After(deadline) {
+				n.t.Fatalf("Ledger of %s did not reach height %d in time, height is %d",
+					node.endpoint, height, node.ledger.height())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		assert.Equal(n.t, height, node.ledger.height(), "ledger of %s", node.endpoint)
+	}
+	var reference *testNode
+	for _, node := range n.nodes {
+		if reference == nil {
+			reference = node
+			continue
+		}
+		for i := uint64(0); i < height; i++ {
+			assert.Equal(n.t, reference.ledger.block(i).Header, node.ledger.block(i).Header,
+				"block [%d] of %s and %s", i, reference.endpoint, node.endpoint)
+		}
+	}
+}
+
+func (c *chain) leaderID() uint64 {
+	select {
+	case <-c.haltC:
+		return 0
+	default:
+		return atomic.LoadUint64(&c.leader)
+	}
+}
+
+func TestSingleConsenter(t *testing.T) {
+	network := newTestNetwork(t, 1)
+	defer network.stopAll()
+	node := network.leader()
+
+	assert.NoError(t, node.chain.Order(testMessage(1), 0))
+	assert.NoError(t, node.chain.Order(testMessage(2), 0))
+	network.waitForHeight(2)
+	block := node.ledger.block(1)
+	assert.Len(t, block.Data.Data, 2)
+	metadata := &raftpb.BlockMetadata{}
+	assert.NoError(t, proto.Unmarshal(utils.GetMetadataFromBlockOrPanic(block, cb.BlockMetadataIndex_ORDERER).Value, metadata))
+	// the log starts with the membership change that bootstraps the raft cluster, and the empty entry of the leader
+	assert.Equal(t, &raftpb.BlockMetadata{Term: 2, Index: 3, ConsenterIds: []uint64{1}, NextConsenterId: 2}, metadata)
+
+	// a partial batch is cut by the batch timer
+	assert.NoError(t, node.chain.Order(testMessage(3), 0))
+	network.waitForHeight(3)
+	assert.Len(t, node.ledger.block(2).Data.Data, 1)
+}
+
+func TestReplicationAmongConsenters(t *testing.T) {
+	network := newTestNetwork(t, 3)
+	defer network.stopAll()
+	lead := network.leader()
+	follower := network.follower(lead)
+
+	// messages submitted to a follower are forwarded to the leader
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, follower.chain.Order(testMessage(i), 0))
+	}
+	network.waitForHeight(3)
+	for i := 4; i < 10; i++ {
+		assert.NoError(t, lead.chain.Order(testMessage(i), 0))
+	}
+	network.waitForHeight(6)
+
+	// the blocks carry the messages in the order they were submitted
+	for i := 0; i < 10; i++ {
+		block := follower.ledger.block(uint64(i/2 + 1))
+		assert.Equal(t, utils.MarshalOrPanic(testMessage(i)), block.Data.Data[i%2])
+	}
+}
+
+func TestConfigUpdates(t *testing.T) {
+	network := newTestNetwork(t, 3)
+	defer network.stopAll()
+	lead := network.leader()
+	follower := network.follower(lead)
+
+	// a pending batch is cut before the config block
+	assert.NoError(t, lead.chain.Order(testMessage(1), 0))
+	assert.NoError(t, follower.chain.Configure(testConfigMessage(network.metadata), 0))
+	network.waitForHeight(3)
+	assert.Len(t, lead.ledger.block(1).Data.Data, 1)
+	assert.Equal(t, utils.MarshalOrPanic(testConfigMessage(network.metadata)), lead.ledger.block(2).Data.Data[0])
+
+	// the consenters can only be added or removed one at a time
+	err := lead.chain.Configure(testConfigMessage(testConfigMetadata(make([]string, 1)...)), 0)
+	assert.EqualError(t, err, "only one consenter can be added or removed at a time, but the update of channel testchannel adds 0 and removes 2")
+	consensusType := utils.MarshalOrPanic(&ab.ConsensusType{Type: "solo"})
+	configEnv := testConfigMessage(nil)
+	payload := utils.UnmarshalPayloadOrPanic(configEnv.Payload)
+	config := configtx.UnmarshalConfigEnvelopeOrPanic(payload.Data)
+	config.Config.ChannelGroup.Groups[channelconfig.OrdererGroupKey].Values[channelconfig.ConsensusTypeKey].Value = consensusType
+	payload.Data = utils.MarshalOrPanic(config)
+	configEnv.Payload = utils.MarshalOrPanic(payload)
+	err = lead.chain.Configure(configEnv, 0)
+	assert.EqualError(t, err, "changing the consensus type to solo is not supported")
+}
+
+func TestLeaderFailover(t *testing.T) {
+	network := newTestNetwork(t, 3)
+	defer network.stopAll()
+	lead := network.leader()
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, lead.chain.Order(testMessage(i), 0))
+	}
+	network.waitForHeight(3)
+
+	// the remaining consenters elect a new leader and keep ordering
+	stopped := network.stop(lead.endpoint)
+	newLead := network.leader()
+	assert.NotEqual(t, lead.endpoint, newLead.endpoint)
+	for i := 4; i < 8; i++ {
+		assert.NoError(t, newLead.chain.Order(testMessage(i), 0))
+	}
+	network.waitForHeight(5)
+	assert.Equal(t, uint64(3), stopped.ledger.height())
+
+	// the restarted consenter resumes from its raft log and catches up
+	network.start(stopped.endpoint, stopped.ledger)
+	network.leader()
+	network.waitForHeight(5)
+	assert.NoError(t, network.leader().chain.Order(testMessage(8), 0))
+	network.waitForHeight(6)
+}
+
+func TestRestartAllConsenters(t *testing.T) {
+	network := newTestNetwork(t, 3)
+	defer network.stopAll()
+	lead := network.leader()
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, lead.chain.Order(testMessage(i), 0))
+	}
+	network.waitForHeight(3)
+
+	var ledgers []*testLedger
+	for _, endpoint := range network.endpoints {
+		ledgers = append(ledgers, network.stop(endpoint).ledger)
+	}
+	for i, endpoint := range network.endpoints {
+		network.start(endpoint, ledgers[i])
+	}
+	// the blocks already in the ledgers are not written again
+	lead = network.leader()
+	assert.NoError(t, lead.chain.Order(testMessage(4), 0))
+	assert.NoError(t, lead.chain.Order(testMessage(5), 0))
+	network.waitForHeight(4)
+}
+
+func TestNoLeader(t *testing.T) {
+	network := newTestNetwork(t, 3)
+	defer network.stopAll()
+	lead := network.leader()
+	network.stop(lead.endpoint)
+	network.stop(network.follower(lead).endpoint)
+
+	// without a quorum, the remaining consenter cannot elect a leader
+	node := network.follower(nil)
+	deadline := time.Now().Add(testTimeout)
+	for node.chain.leaderID() != 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.EqualError(t, node.chain.Order(testMessage(1), 0), "no raft leader is elected for channel testchannel")
+
+	node.chain.Halt()
+	select {
+	case <-node.chain.Errored():
+	default:
+		t.Fatal("Errored channel should be closed after halting")
+	}
+	assert.EqualError(t, node.chain.Order(testMessage(1), 0), "raft node for channel testchannel is halted")
+	assert.NoError(t, node.chain.WaitReady())
+}
+
+func TestSnapshotCatchUp(t *testing.T) {
+	opts := testOptions()
+	opts.SnapshotInterval = 2
+	network := newTestNetworkWithOptions(t, 3, opts)
+	defer network.stopAll()
+	lead := network.leader()
+	stopped := network.stop(network.follower(lead).endpoint)
+
+	// the leader compacts its log past the entries the stopped consenter lacks
+	for i := 0; i < 12; i++ {
+		assert.NoError(t, lead.chain.Order(testMessage(i), 0))
+	}
+	network.waitForHeight(7)
+	deadline := time.Now().Add(testTimeout)
+	for lead.chain.storage.snapshot().Metadata.Index <= stopped.chain.storage.lastIndex() {
+		if time.Now().After(deadline) {
+			t.Fatal("Leader did not compact its raft log in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the restarted consenter receives a snapshot, and pulls the blocks it lacks
+	network.start(stopped.endpoint, stopped.ledger)
+	network.waitForHeight(7)
+	assert.NotEqual(t, uint32(0), atomic.LoadUint32(&network.pulls))
+	assert.NoError(t, network.leader().chain.Order(testMessage(12), 0))
+	assert.NoError(t, network.leader().chain.Order(testMessage(13), 0))
+	network.waitForHeight(8)
+
+	// the consenters resume from their snapshots after a restart
+	var ledgers []*testLedger
+	for _, endpoint := range network.endpoints {
+		ledgers = append(ledgers, network.stop(endpoint).ledger)
+	}
+	for i, endpoint := range network.endpoints {
+		network.start(endpoint, ledgers[i])
+	}
+	assert.NoError(t, network.leader().chain.Order(testMessage(14), 0))
+	assert.NoError(t, network.leader().chain.Order(testMessage(15), 0))
+	network.waitForHeight(9)
+}
+
+func TestMembershipChanges(t *testing.T) {
+	network := newTestNetwork(t, 3)
+	defer network.stopAll()
+	lead := network.leader()
+	assert.NoError(t, lead.chain.Order(testMessage(0), 0))
+	assert.NoError(t, lead.chain.Order(testMessage(1), 0))
+	network.waitForHeight(2)
+
+	// a fourth consenter is added, and joins the channel from the config block which adds it
+	endpoints := append(append([]string{}, network.endpoints...), "")
+	added := testConfigMetadata(endpoints...)
+	assert.NoError(t, lead.chain.Configure(testConfigMessage(added), 0))
+	network.waitForHeight(3)
+	blockMetadata := &raftpb.BlockMetadata{}
+	assert.NoError(t, proto.Unmarshal(utils.GetMetadataFromBlockOrPanic(lead.ledger.block(2), cb.BlockMetadataIndex_ORDERER).Value, blockMetadata))
+	assert.Equal(t, []uint64{1, 2, 3, 4}, blockMetadata.ConsenterIds)
+	assert.Equal(t, uint64(5), blockMetadata.NextConsenterId)
+
+	network.endpoints = endpoints
+	joined := network.start(endpoints[3], lead.ledger.clone(3))
+	assert.Equal(t, uint64(4), joined.chain.id)
+	for i := 2; i < 6; i++ {
+		assert.NoError(t, network.leader().chain.Order(testMessage(i), 0))
+	}
+	network.waitForHeight(5)
+
+	// a follower is removed, and halts once the config block which removes it is committed
+	lead = network.leader()
+	removed := network.follower(lead)
+	var remaining []string
+	for _, endpoint := range endpoints {
+		if endpoint != removed.endpoint {
+			remaining = append(remaining, endpoint)
+		}
+	}
+	assert.NoError(t, lead.chain.Configure(testConfigMessage(testConfigMetadata(remaining...)), 0))
+	select {
+	case <-removed.chain.Errored():
+	case <-time.After(testTimeout):
+		t.Fatal("Removed consenter did not halt in time")
+	}
+	network.stop(removed.endpoint)
+	network.endpoints = remaining
+	network.waitForHeight(6)
+
+	// the remaining consenters keep ordering, and no longer serve the removed one
+	assert.NoError(t, network.leader().chain.Order(testMessage(6), 0))
+	assert.NoError(t, network.leader().chain.Order(testMessage(7), 0))
+	network.waitForHeight(7)
+	_, err := lead.consenter.Step(testCallerContext(removed.endpoint), &raftpb.StepRequest{Channel: testChannel, Message: testStepMessage(removed.chain.id)})
+	assert.EqualError(t, err, "the caller is not a consenter of channel testchannel")
+}
@@ -0,0 +1,264 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package raft
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sync"
+
+	etcdraftpb "github.com/coreos/etcd/raft/raftpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/flogging"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/core/comm"
+	localconfig "github.com/hyperledger/fabric/orderer/common/localconfig"
+	"github.com/hyperledger/fabric/orderer/consensus"
+	cb "github.com/hyperledger/fabric/protos/common"
+	raftpb "github.com/hyperledger/fabric/protos/orderer/raft"
+	"github.com/op/go-logging"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+const pkgLogID = "orderer/consensus/raft"
+
+const consensusTypeRaft = "raft"
+
+const (
+	defaultTickInterval     = 100 // milliseconds
+	defaultElectionTick     = 10
+	defaultHeartbeatTick    = 1
+	defaultMaxSizePerMsg    = 1024 * 1024
+	defaultSnapshotInterval = 100 // blocks
+)
+
+var logger *logging.Logger
+
+func init() {
+	logger = flogging.MustGetLogger(pkgLogID)
+}
+
+// BlockPuller pulls the blocks of a channel from the other consenters, for a consenter to catch up with a
+// snapshot of the raft log
+type BlockPuller interface {
+	// PullBlocks returns the blocks of the given channel numbered from start up to, but excluding, end,
+	// pulled from the first of the given orderer endpoints which serves all of them
+	PullBlocks(channelID string, endpoints []string, start, end uint64) ([]*cb.Block, error)
+}
+
+// Consenter implements the raft consensus scheme. The consenters of a channel, which are listed
+// in the consensus metadata of the channel config, replicate the blocks of the channel among
+// themselves, so that the channel remains available as long as a majority of them are up.
+// Consenter also serves the Cluster service, over which the consenters communicate with each other.
+// The Cluster service only serves the consenters of a channel, which are authenticated by the client
+// TLS certificates listed in the consensus metadata, and hence requires mutual TLS
+type Consenter struct {
+	sync.RWMutex
+	walDir       string
+	endpoint     string
+	communicator Communicator
+	puller       BlockPuller
+	walProvider  *leveldbhelper.Provider
+	chains       map[string]*chain
+}
+
+// New creates a raft consenter. Called by orderer's main.go.
+func New(conf localconfig.Raft, clientConfig comm.ClientConfig, puller BlockPuller) *Consenter {
+	communicator, err := NewCommunicator(clientConfig)
+	if err != nil {
+		logger.Panicf("Failed to initialize raft consenter: %s", err)
+	}
+	return newConsenter(conf, communicator, puller)
+}
+
+func newConsenter(conf localconfig.Raft, communicator Communicator, puller BlockPuller) *Consenter {
+	return &Consenter{
+		walDir:       conf.WALDir,
+		endpoint:     conf.Endpoint,
+		communicator: communicator,
+		puller:       puller,
+		chains:       make(map[string]*chain),
+	}
+}
+
+// HandleChain creates a raft node for the channel of the given support. The metadata of the last block
+// in the ledger assigns the raft node IDs of the consenters; the consenters of the genesis block are
+// numbered from 1 in the order they are listed
+func (c *Consenter) HandleChain(support consensus.ConsenterSupport, metadata *cb.Metadata) (consensus.Chain, error) {
+	configMetadata, err := unmarshalConfigMetadata(support.SharedConfig().ConsensusMetadata())
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("invalid consensus metadata for channel %s", support.ChainID()))
+	}
+	blockMetadata := &raftpb.BlockMetadata{}
+	if metadata != nil && len(metadata.Value) > 0 {
+		if err := proto.Unmarshal(metadata.Value, blockMetadata); err != nil {
+			return nil, errors.Wrap(err, "error unmarshaling raft block metadata")
+		}
+	} else {
+		for i := range configMetadata.Consenters {
+			blockMetadata.ConsenterIds = append(blockMetadata.ConsenterIds, uint64(i+1))
+		}
+		blockMetadata.NextConsenterId = uint64(len(configMetadata.Consenters) + 1)
+	}
+	members, err := consenterMembers(configMetadata, blockMetadata)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("invalid raft block metadata for channel %s", support.ChainID()))
+	}
+	var id uint64
+	for memberID, m := range members {
+		if m.endpoint == c.endpoint {
+			id = memberID
+		}
+	}
+	if id == 0 {
+		return nil, errors.Errorf("this orderer (%s) is not a consenter of channel %s", c.endpoint, support.ChainID())
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	if c.walProvider == nil {
+		c.walProvider = leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: c.walDir})
+	}
+	s, err := newRaftStorage(c.walProvider.GetDBHandle(support.ChainID()))
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("failed to open the raft log of channel %s", support.ChainID()))
+	}
+	logger.Infof("Creating raft node %d of channel %s, last raft entry is [%d]", id, support.ChainID(), s.lastIndex())
+	ch, err := newChain(support, id, blockMetadata, members, c.communicator, c.puller, s, configMetadata.Options)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("failed to create the raft node of channel %s", support.ChainID()))
+	}
+	c.chains[support.ChainID()] = ch
+	return ch, nil
+}
+
+// RemoveChain forgets the halted raft node of the channel and deletes its raft log
+func (c *Consenter) RemoveChain(chainID string) error {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.chains, chainID)
+	if c.walProvider == nil {
+		return nil
+	}
+	return c.walProvider.GetDBHandle(chainID).DeleteAll()
+}
+
+// Step passes a raft protocol message to the raft node of the channel. The message must be sent
+// by the consenter it is from
+func (c *Consenter) Step(ctx context.Context, req *raftpb.StepRequest) (*raftpb.StepResponse, error) {
+	ch, err := c.chain(req.Channel)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Message) == 0 {
+		return nil, errors.New("step request is missing the message")
+	}
+	m := &etcdraftpb.Message{}
+	if err := m.Unmarshal(req.Message); err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling raft message")
+	}
+	ids, err := ch.callerIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := ids[m.From]; !ok {
+		return nil, errors.Errorf("the caller is not consenter %d of channel %s, from which the message is", m.From, req.Channel)
+	}
+	if err := ch.handleStep(m); err != nil {
+		return nil, err
+	}
+	return &raftpb.StepResponse{}, nil
+}
+
+// Submit accepts a message forwarded by another consenter of the channel
+func (c *Consenter) Submit(ctx context.Context, req *raftpb.SubmitRequest) (*raftpb.SubmitResponse, error) {
+	ch, err := c.chain(req.Channel)
+	if err != nil {
+		return nil, err
+	}
+	if req.Content == nil {
+		return nil, errors.New("submit request is missing the content")
+	}
+	if _, err := ch.callerIDs(ctx); err != nil {
+		return nil, err
+	}
+	if err := ch.handleSubmit(req); err != nil {
+		return nil, err
+	}
+	return &raftpb.SubmitResponse{}, nil
+}
+
+func (c *Consenter) chain(channel string) (*chain, error) {
+	c.RLock()
+	defer c.RUnlock()
+	ch, ok := c.chains[channel]
+	if !ok {
+		return nil, errors.Errorf("channel %s is not served by this raft consenter", channel)
+	}
+	return ch, nil
+}
+
+// certHash returns the hash of the DER of the given PEM encoded certificate, which is the hash
+// under which the TLS certificate of a caller is extracted from its context
+func certHash(certPEM []byte) ([]byte, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, errors.New("the certificate is not PEM encoded")
+	}
+	if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+		return nil, errors.Wrap(err, "error parsing the certificate")
+	}
+	return util.ComputeSHA256(block.Bytes), nil
+}
+
+func unmarshalConfigMetadata(metadataBytes []byte) (*raftpb.ConfigMetadata, error) {
+	configMetadata := &raftpb.ConfigMetadata{}
+	if err := proto.Unmarshal(metadataBytes, configMetadata); err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling raft config metadata")
+	}
+	if len(configMetadata.Consenters) == 0 {
+		return nil, errors.New("raft config metadata contains no consenters")
+	}
+	endpoints := make(map[string]struct{})
+	for _, consenter := range configMetadata.Consenters {
+		endpoint := fmt.Sprintf("%s:%d", consenter.Host, consenter.Port)
+		if _, ok := endpoints[endpoint]; ok {
+			return nil, errors.Errorf("consenter %s is listed more than once", endpoint)
+		}
+		endpoints[endpoint] = struct{}{}
+		if _, err := certHash(consenter.ClientTlsCert); err != nil {
+			return nil, errors.WithMessage(err, fmt.Sprintf("invalid client TLS certificate of consenter %s:%d", consenter.Host, consenter.Port))
+		}
+	}
+	if configMetadata.Options == nil {
+		configMetadata.Options = &raftpb.Options{}
+	}
+	opts := configMetadata.Options
+	if opts.TickInterval == 0 {
+		opts.TickInterval = defaultTickInterval
+	}
+	if opts.ElectionTick == 0 {
+		opts.ElectionTick = defaultElectionTick
+	}
+	if opts.HeartbeatTick == 0 {
+		opts.HeartbeatTick = defaultHeartbeatTick
+	}
+	if opts.MaxSizePerMsg == 0 {
+		opts.MaxSizePerMsg = defaultMaxSizePerMsg
+	}
+	if opts.SnapshotInterval == 0 {
+		opts.SnapshotInterval = defaultSnapshotInterval
+	}
+	if opts.HeartbeatTick >= opts.ElectionTick {
+		return nil, errors.Errorf("heartbeat tick (%d) must be less than election tick (%d)",
+			opts.HeartbeatTick, opts.ElectionTick)
+	}
+	return configMetadata, nil
+}
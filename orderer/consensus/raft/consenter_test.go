@@ -0,0 +1,160 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package raft
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	localconfig "github.com/hyperledger/fabric/orderer/common/localconfig"
+	cb "github.com/hyperledger/fabric/protos/common"
+	raftpb "github.com/hyperledger/fabric/protos/orderer/raft"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestHandleChainErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "raftconsenter")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	consenter := newConsenter(localconfig.Raft{WALDir: dir, Endpoint: "orderer.example.com:7050"}, nil, nil)
+
+	_, err = consenter.HandleChain(newTestSupport(newTestLedger(), testConfigMetadata(make([]string, 3)...)), nil)
+	assert.EqualError(t, err, "this orderer (orderer.example.com:7050) is not a consenter of channel testchannel")
+
+	_, err = consenter.HandleChain(newTestSupport(newTestLedger(), []byte("garbage")), nil)
+	assert.Contains(t, err.Error(), "invalid consensus metadata for channel testchannel")
+
+	_, err = consenter.HandleChain(newTestSupport(newTestLedger(), utils.MarshalOrPanic(&raftpb.ConfigMetadata{
+		Consenters: []*raftpb.Consenter{{Host: "orderer.example.com", Port: 7050, ClientTlsCert: testCert("orderer.example.com:7050")}},
+		Options:    &raftpb.Options{ElectionTick: 2, HeartbeatTick: 2},
+	})), nil)
+	assert.EqualError(t, err, "invalid consensus metadata for channel testchannel: heartbeat tick (2) must be less than election tick (2)")
+
+	_, err = consenter.HandleChain(newTestSupport(newTestLedger(), utils.MarshalOrPanic(&raftpb.ConfigMetadata{
+		Consenters: []*raftpb.Consenter{{Host: "orderer.example.com", Port: 7050}},
+	})), nil)
+	assert.EqualError(t, err, "invalid consensus metadata for channel testchannel: invalid client TLS certificate of consenter orderer.example.com:7050: the certificate is not PEM encoded")
+
+	endpoints := make([]string, 1)
+	metadata := testConfigMetadata(endpoints...)
+	consenter.endpoint = endpoints[0]
+	_, err = consenter.HandleChain(newTestSupport(newTestLedger(), metadata), &cb.Metadata{Value: []byte("garbage")})
+	assert.Contains(t, err.Error(), "error unmarshaling raft block metadata")
+
+	_, err = consenter.HandleChain(newTestSupport(newTestLedger(), metadata), &cb.Metadata{Value: utils.MarshalOrPanic(&raftpb.BlockMetadata{
+		ConsenterIds: []uint64{1, 2},
+	})})
+	assert.EqualError(t, err, "invalid raft block metadata for channel testchannel: the channel has 1 consenters, but 2 raft node IDs")
+}
+
+func TestUnmarshalConfigMetadata(t *testing.T) {
+	_, err := unmarshalConfigMetadata(nil)
+	assert.EqualError(t, err, "raft config metadata contains no consenters")
+
+	_, err = unmarshalConfigMetadata(utils.MarshalOrPanic(&raftpb.ConfigMetadata{
+		Consenters: []*raftpb.Consenter{{Host: "orderer.example.com", Port: 7050, ClientTlsCert: []byte("-----BEGIN CERTIFICATE-----\nZ2FyYmFnZQ==\n-----END CERTIFICATE-----\n")}},
+	}))
+	assert.Contains(t, err.Error(), "invalid client TLS certificate of consenter orderer.example.com:7050: error parsing the certificate")
+
+	_, err = unmarshalConfigMetadata(utils.MarshalOrPanic(&raftpb.ConfigMetadata{
+		Consenters: []*raftpb.Consenter{
+			{Host: "orderer.example.com", Port: 7050, ClientTlsCert: testCert("orderer.example.com:7050")},
+			{Host: "orderer.example.com", Port: 7050, ClientTlsCert: testCert("orderer.example.com:7050")},
+		},
+	}))
+	assert.EqualError(t, err, "consenter orderer.example.com:7050 is listed more than once")
+
+	metadata, err := unmarshalConfigMetadata(utils.MarshalOrPanic(&raftpb.ConfigMetadata{
+		Consenters: []*raftpb.Consenter{{Host: "orderer.example.com", Port: 7050, ClientTlsCert: testCert("orderer.example.com:7050")}},
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, &raftpb.Options{
+		TickInterval:     defaultTickInterval,
+		ElectionTick:     defaultElectionTick,
+		HeartbeatTick:    defaultHeartbeatTick,
+		MaxSizePerMsg:    defaultMaxSizePerMsg,
+		SnapshotInterval: defaultSnapshotInterval,
+	}, metadata.Options)
+}
+
+func TestClusterServiceErrors(t *testing.T) {
+	network := newTestNetwork(t, 1)
+	defer network.stopAll()
+	endpoint := network.endpoints[0]
+	consenter := network.nodes[endpoint].consenter
+	ctx := testCallerContext(endpoint)
+
+	_, err := consenter.Step(ctx, &raftpb.StepRequest{Channel: "foo", Message: testStepMessage(1)})
+	assert.EqualError(t, err, "channel foo is not served by this raft consenter")
+	_, err = consenter.Step(ctx, &raftpb.StepRequest{Channel: testChannel})
+	assert.EqualError(t, err, "step request is missing the message")
+	_, err = consenter.Step(ctx, &raftpb.StepRequest{Channel: testChannel, Message: []byte("garbage")})
+	assert.Contains(t, err.Error(), "error unmarshaling raft message")
+	_, err = consenter.Submit(ctx, &raftpb.SubmitRequest{Channel: "foo", Content: testMessage(1)})
+	assert.EqualError(t, err, "channel foo is not served by this raft consenter")
+	_, err = consenter.Submit(ctx, &raftpb.SubmitRequest{Channel: testChannel})
+	assert.EqualError(t, err, "submit request is missing the content")
+
+	network.leader()
+	_, err = consenter.Submit(ctx, &raftpb.SubmitRequest{Channel: testChannel, Content: testMessage(1)})
+	assert.NoError(t, err)
+	_, err = consenter.Submit(ctx, &raftpb.SubmitRequest{Channel: testChannel, Content: testMessage(2)})
+	assert.NoError(t, err)
+	network.waitForHeight(2)
+}
+
+func TestClusterServiceAuthentication(t *testing.T) {
+	network := newTestNetwork(t, 1)
+	defer network.stopAll()
+	endpoint := network.endpoints[0]
+	consenter := network.nodes[endpoint].consenter
+	network.leader()
+
+	t.Run("NoCertificate", func(t *testing.T) {
+		_, err := consenter.Step(context.Background(), &raftpb.StepRequest{Channel: testChannel, Message: testStepMessage(1)})
+		assert.EqualError(t, err, "the caller did not present a TLS certificate")
+		_, err = consenter.Submit(context.Background(), &raftpb.SubmitRequest{Channel: testChannel, Content: testMessage(1)})
+		assert.EqualError(t, err, "the caller did not present a TLS certificate")
+	})
+
+	t.Run("NotAConsenter", func(t *testing.T) {
+		ctx := testCallerContext("stranger.example.com:7050")
+		_, err := consenter.Step(ctx, &raftpb.StepRequest{Channel: testChannel, Message: testStepMessage(1)})
+		assert.EqualError(t, err, "the caller is not a consenter of channel testchannel")
+		_, err = consenter.Submit(ctx, &raftpb.SubmitRequest{Channel: testChannel, Content: testMessage(1)})
+		assert.EqualError(t, err, "the caller is not a consenter of channel testchannel")
+	})
+
+	t.Run("ForgedSender", func(t *testing.T) {
+		_, err := consenter.Step(testCallerContext(endpoint), &raftpb.StepRequest{Channel: testChannel, Message: testStepMessage(2)})
+		assert.EqualError(t, err, "the caller is not consenter 2 of channel testchannel, from which the message is")
+	})
+
+	assert.Equal(t, uint64(1), network.nodes[endpoint].ledger.height(), "No message of an unauthenticated caller should have been ordered")
+}
+
+func TestRemoveChain(t *testing.T) {
+	network := newTestNetwork(t, 1)
+	defer network.stopAll()
+	node := network.nodes[network.endpoints[0]]
+
+	network.leader()
+	_, err := node.consenter.Submit(testCallerContext(node.endpoint), &raftpb.SubmitRequest{Channel: testChannel, Content: testMessage(1)})
+	assert.NoError(t, err)
+	network.waitForHeight(2)
+
+	node.chain.Halt()
+	assert.NoError(t, node.consenter.RemoveChain(testChannel))
+	_, err = node.consenter.Step(testCallerContext(node.endpoint), &raftpb.StepRequest{Channel: testChannel, Message: testStepMessage(1)})
+	assert.EqualError(t, err, "channel testchannel is not served by this raft consenter")
+	itr := node.consenter.walProvider.GetDBHandle(testChannel).GetIterator(nil, nil)
+	defer itr.Release()
+	assert.False(t, itr.Next(), "Expected the raft log of the removed chain to be deleted")
+}
@@ -0,0 +1,186 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package raft
+
+import (
+	"math"
+
+	etcdraft "github.com/coreos/etcd/raft"
+	etcdraftpb "github.com/coreos/etcd/raft/raftpb"
+	"github.com/hyperledger/fabric/common/ledger/util"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/pkg/errors"
+)
+
+var (
+	hardStateKey   = []byte("hs")
+	snapshotKey    = []byte("snap")
+	entryKeyPrefix = []byte("e")
+)
+
+// raftStorage persists the raft log, the hard state and the last snapshot of a channel in a
+// leveldb handle that is dedicated to the channel, and mirrors them in the MemoryStorage that
+// the raft node reads from. All the writes are synced to the disk before they return, so that
+// the messages sent after a write never reflect an unpersisted state
+type raftStorage struct {
+	db  *leveldbhelper.DBHandle
+	ram *etcdraft.MemoryStorage
+}
+
+// newRaftStorage loads the raft log of a channel, which is empty if the channel is new
+func newRaftStorage(db *leveldbhelper.DBHandle) (*raftStorage, error) {
+	s := &raftStorage{db: db, ram: etcdraft.NewMemoryStorage()}
+
+	snapBytes, err := db.Get(snapshotKey)
+	if err != nil {
+		return nil, err
+	}
+	if snapBytes != nil {
+		snapshot := etcdraftpb.Snapshot{}
+		if err := snapshot.Unmarshal(snapBytes); err != nil {
+			return nil, errors.Wrap(err, "error unmarshaling raft snapshot")
+		}
+		if err := s.ram.ApplySnapshot(snapshot); err != nil {
+			return nil, errors.Wrap(err, "error applying raft snapshot")
+		}
+	}
+
+	itr := db.GetIterator(entryKey(s.firstIndex()), entryKey(math.MaxUint64))
+	defer itr.Release()
+	var entries []etcdraftpb.Entry
+	for itr.Next() {
+		e := etcdraftpb.Entry{}
+		if err := e.Unmarshal(itr.Value()); err != nil {
+			return nil, errors.Wrap(err, "error unmarshaling raft entry")
+		}
+		entries = append(entries, e)
+	}
+	if err := itr.Error(); err != nil {
+		return nil, err
+	}
+	if err := s.ram.Append(entries); err != nil {
+		return nil, errors.Wrap(err, "error loading raft entries")
+	}
+
+	hsBytes, err := db.Get(hardStateKey)
+	if err != nil {
+		return nil, err
+	}
+	if hsBytes != nil {
+		hs := etcdraftpb.HardState{}
+		if err := hs.Unmarshal(hsBytes); err != nil {
+			return nil, errors.Wrap(err, "error unmarshaling raft hard state")
+		}
+		if err := s.ram.SetHardState(hs); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// isEmpty tells whether the raft log has never been written to
+func (s *raftStorage) isEmpty() bool {
+	hs, _, _ := s.ram.InitialState()
+	return etcdraft.IsEmptyHardState(hs) && s.lastIndex() == 0
+}
+
+func (s *raftStorage) firstIndex() uint64 {
+	index, _ := s.ram.FirstIndex()
+	return index
+}
+
+func (s *raftStorage) lastIndex() uint64 {
+	index, _ := s.ram.LastIndex()
+	return index
+}
+
+// snapshot returns the last snapshot of the raft log, which is empty if none was taken
+func (s *raftStorage) snapshot() etcdraftpb.Snapshot {
+	snapshot, _ := s.ram.Snapshot()
+	return snapshot
+}
+
+// store persists the state of a raft Ready: the snapshot received from the leader, which
+// replaces the log, the entries to append, which overwrite the conflicting entries of the
+// log, and the hard state
+func (s *raftStorage) store(snapshot etcdraftpb.Snapshot, entries []etcdraftpb.Entry, hs etcdraftpb.HardState) error {
+	batch := leveldbhelper.NewUpdateBatch()
+	lastIndex := s.lastIndex()
+	if !etcdraft.IsEmptySnap(snapshot) {
+		snapBytes, err := snapshot.Marshal()
+		if err != nil {
+			return err
+		}
+		batch.Put(snapshotKey, snapBytes)
+		for i := s.firstIndex(); i <= lastIndex; i++ {
+			batch.Delete(entryKey(i))
+		}
+		lastIndex = snapshot.Metadata.Index
+	}
+	if len(entries) > 0 {
+		for i := entries[0].Index; i <= lastIndex; i++ {
+			batch.Delete(entryKey(i))
+		}
+		for _, e := range entries {
+			entryBytes, err := e.Marshal()
+			if err != nil {
+				return err
+			}
+			batch.Put(entryKey(e.Index), entryBytes)
+		}
+	}
+	if !etcdraft.IsEmptyHardState(hs) {
+		hsBytes, err := hs.Marshal()
+		if err != nil {
+			return err
+		}
+		batch.Put(hardStateKey, hsBytes)
+	}
+	if err := s.db.WriteBatch(batch, true); err != nil {
+		return err
+	}
+
+	if !etcdraft.IsEmptySnap(snapshot) {
+		if err := s.ram.ApplySnapshot(snapshot); err != nil {
+			return err
+		}
+	}
+	if err := s.ram.Append(entries); err != nil {
+		return err
+	}
+	if !etcdraft.IsEmptyHardState(hs) {
+		return s.ram.SetHardState(hs)
+	}
+	return nil
+}
+
+// takeSnapshot takes a snapshot of the raft log at the given applied index, and compacts
+// the log up to that index
+func (s *raftStorage) takeSnapshot(index uint64, cs etcdraftpb.ConfState, data []byte) error {
+	firstIndex := s.firstIndex()
+	snapshot, err := s.ram.CreateSnapshot(index, &cs, data)
+	if err != nil {
+		return err
+	}
+	snapBytes, err := snapshot.Marshal()
+	if err != nil {
+		return err
+	}
+	batch := leveldbhelper.NewUpdateBatch()
+	batch.Put(snapshotKey, snapBytes)
+	for i := firstIndex; i <= index; i++ {
+		batch.Delete(entryKey(i))
+	}
+	if err := s.db.WriteBatch(batch, true); err != nil {
+		return err
+	}
+	return s.ram.Compact(index)
+}
+
+func entryKey(index uint64) []byte {
+	return append(append([]byte{}, entryKeyPrefix...), util.EncodeOrderPreservingVarUint64(index)...)
+}
@@ -0,0 +1,88 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package raft
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	etcdraftpb "github.com/coreos/etcd/raft/raftpb"
+	"github.com/hyperledger/fabric/common/ledger/util/leveldbhelper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRaftStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "raftwal")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	provider := leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dir})
+	s, err := newRaftStorage(provider.GetDBHandle("testchannel"))
+	assert.NoError(t, err)
+	assert.True(t, s.isEmpty())
+	assert.Equal(t, uint64(0), s.lastIndex())
+
+	entries := []etcdraftpb.Entry{
+		{Term: 1, Index: 1, Type: etcdraftpb.EntryConfChange, Data: []byte("conf change")},
+		{Term: 2, Index: 2},
+		{Term: 2, Index: 3, Data: []byte("block 1")},
+		{Term: 2, Index: 4, Data: []byte("block 2")},
+	}
+	assert.NoError(t, s.store(etcdraftpb.Snapshot{}, entries, etcdraftpb.HardState{Term: 2, Vote: 1, Commit: 3}))
+	assert.False(t, s.isEmpty())
+	assert.Equal(t, uint64(4), s.lastIndex())
+
+	// appending a conflicting entry truncates the log
+	assert.NoError(t, s.store(etcdraftpb.Snapshot{}, []etcdraftpb.Entry{{Term: 3, Index: 4, Data: []byte("block 2'")}}, etcdraftpb.HardState{}))
+	assert.Equal(t, uint64(4), s.lastIndex())
+	assert.NoError(t, s.store(etcdraftpb.Snapshot{}, []etcdraftpb.Entry{{Term: 3, Index: 3, Data: []byte("block 1'")}}, etcdraftpb.HardState{}))
+	assert.Equal(t, uint64(3), s.lastIndex())
+	provider.Close()
+
+	// the log and the hard state survive a restart
+	provider = leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dir})
+	s, err = newRaftStorage(provider.GetDBHandle("testchannel"))
+	assert.NoError(t, err)
+	hs, _, err := s.ram.InitialState()
+	assert.NoError(t, err)
+	assert.Equal(t, etcdraftpb.HardState{Term: 2, Vote: 1, Commit: 3}, hs)
+	retrieved, err := s.ram.Entries(1, 4, 1<<20)
+	assert.NoError(t, err)
+	assert.Equal(t, []etcdraftpb.Entry{entries[0], entries[1], {Term: 3, Index: 3, Data: []byte("block 1'")}}, retrieved)
+
+	// a snapshot compacts the log
+	cs := etcdraftpb.ConfState{Nodes: []uint64{1, 2, 3}}
+	assert.NoError(t, s.takeSnapshot(2, cs, []byte("snapshot")))
+	assert.Equal(t, uint64(3), s.firstIndex())
+	assert.Equal(t, uint64(3), s.lastIndex())
+	assert.Error(t, s.takeSnapshot(1, cs, nil))
+	provider.Close()
+
+	provider = leveldbhelper.NewProvider(&leveldbhelper.Conf{DBPath: dir})
+	defer provider.Close()
+	s, err = newRaftStorage(provider.GetDBHandle("testchannel"))
+	assert.NoError(t, err)
+	snapshot := s.snapshot()
+	assert.Equal(t, etcdraftpb.SnapshotMetadata{Index: 2, Term: 2, ConfState: cs}, snapshot.Metadata)
+	assert.Equal(t, []byte("snapshot"), snapshot.Data)
+	assert.Equal(t, uint64(3), s.firstIndex())
+	assert.Equal(t, uint64(3), s.lastIndex())
+
+	// a snapshot received from the leader replaces the log
+	received := etcdraftpb.Snapshot{Data: []byte("received"), Metadata: etcdraftpb.SnapshotMetadata{Index: 10, Term: 4, ConfState: cs}}
+	assert.NoError(t, s.store(received, []etcdraftpb.Entry{{Term: 4, Index: 11}}, etcdraftpb.HardState{Term: 4, Commit: 10}))
+	assert.Equal(t, uint64(11), s.firstIndex())
+	assert.Equal(t, uint64(11), s.lastIndex())
+	_, err = s.ram.Term(3)
+	assert.Error(t, err)
+
+	// the logs of different channels are independent
+	other, err := newRaftStorage(provider.GetDBHandle("otherchannel"))
+	assert.NoError(t, err)
+	assert.True(t, other.isEmpty())
+}
@@ -9,6 +9,7 @@ package multichannel
 import (
 	"github.com/hyperledger/fabric/common/channelconfig"
 	mockconfig "github.com/hyperledger/fabric/common/mocks/config"
+	"github.com/hyperledger/fabric/msp"
 	"github.com/hyperledger/fabric/orderer/common/blockcutter"
 	"github.com/hyperledger/fabric/orderer/common/msgprocessor"
 	mockblockcutter "github.com/hyperledger/fabric/orderer/mocks/common/blockcutter"
@@ -34,6 +35,9 @@ type ConsenterSupport struct {
 	// HeightVal is the value returned by Height()
 	HeightVal uint64
 
+	// BlockByNumberVal contains the values returned by Block()
+	BlockByNumberVal map[uint64]*cb.Block
+
 	// NextBlockVal stores the block created by the most recent CreateNextBlock() call
 	NextBlockVal *cb.Block
 
@@ -60,6 +64,9 @@ type ConsenterSupport struct {
 
 	// SequenceVal is returned by Sequence
 	SequenceVal uint64
+
+	// MSPManagerVal is returned by MSPManager
+	MSPManagerVal msp.MSPManager
 }
 
 // BlockCutter returns BlockCutterVal
@@ -108,6 +115,11 @@ func (mcs *ConsenterSupport) Height() uint64 {
 	return mcs.HeightVal
 }
 
+// Block returns the value in BlockByNumberVal for the given number
+func (mcs *ConsenterSupport) Block(number uint64) *cb.Block {
+	return mcs.BlockByNumberVal[number]
+}
+
 // Sign returns the bytes passed in
 func (mcs *ConsenterSupport) Sign(message []byte) ([]byte, error) {
 	return message, nil
@@ -142,3 +154,8 @@ func (mcs *ConsenterSupport) ProcessConfigMsg(env *cb.Envelope) (*cb.Envelope, u
 func (mcs *ConsenterSupport) Sequence() uint64 {
 	return mcs.SequenceVal
 }
+
+// MSPManager returns MSPManagerVal
+func (mcs *ConsenterSupport) MSPManager() msp.MSPManager {
+	return mcs.MSPManagerVal
+}
@@ -163,6 +163,7 @@ type collectionConfigJson struct {
 	Policy        string `json:"policy"`
 	RequiredCount int32  `json:"requiredPeerCount"`
 	MaxPeerCount  int32  `json:"maxPeerCount"`
+	BlockToLive   uint64 `json:"blockToLive"`
 }
 
 // getCollectionConfig retrieves the collection configuration
@@ -207,6 +208,7 @@ func getCollectionConfigFromBytes(cconfBytes []byte) ([]byte, error) {
 					MemberOrgsPolicy:  cpc,
 					RequiredPeerCount: cconfitem.RequiredCount,
 					MaximumPeerCount:  cconfitem.MaxPeerCount,
+					BlockToLive:       cconfitem.BlockToLive,
 				},
 			},
 		}
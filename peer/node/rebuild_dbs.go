@@ -0,0 +1,41 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package node
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/ledger/ledgermgmt"
+	"github.com/hyperledger/fabric/core/peer"
+	"github.com/spf13/cobra"
+)
+
+func rebuildDBsCmd() *cobra.Command {
+	return nodeRebuildDBsCmd
+}
+
+var nodeRebuildDBsCmd = &cobra.Command{
+	Use:   "rebuild-dbs",
+	Short: "Rebuilds the databases of all the channel ledgers.",
+	Long: `Drops the state database, the history database and the block index of all the channel ledgers and ` +
+		`rebuilds these from the blocks. This can be used, for instance, after switching the state database or ` +
+		`enabling the history database. If the rebuild is interrupted, executing this command again (or starting ` +
+		`the peer) resumes it. The peer should be stopped when this command is executed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return rebuildDBs()
+	},
+}
+
+func rebuildDBs() error {
+	ledgermgmt.Initialize(&ledgermgmt.Initializer{CustomTxProcessors: peer.ConfigTxProcessors})
+	defer ledgermgmt.Close()
+	if err := ledgermgmt.RebuildLedgers(); err != nil {
+		return fmt.Errorf("Error while rebuilding the databases: %s", err)
+	}
+	logger.Info("Rebuilt the databases of all the ledgers")
+	return nil
+}
@@ -0,0 +1,40 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package node
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/ledger/ledgermgmt"
+	"github.com/hyperledger/fabric/core/peer"
+	"github.com/spf13/cobra"
+)
+
+func resetCmd() *cobra.Command {
+	return nodeResetCmd
+}
+
+var nodeResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Resets all the channel ledgers to the genesis block.",
+	Long: `Removes all the blocks after the genesis block from all the channel ledgers. The state database and ` +
+		`the history database of the channels are rebuilt when the peer is started next, and the removed blocks ` +
+		`are pulled again from the orderer. The peer should be stopped when this command is executed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return resetLedgers()
+	},
+}
+
+func resetLedgers() error {
+	ledgermgmt.Initialize(&ledgermgmt.Initializer{CustomTxProcessors: peer.ConfigTxProcessors})
+	defer ledgermgmt.Close()
+	if err := ledgermgmt.ResetLedgers(); err != nil {
+		return fmt.Errorf("Error while resetting the ledgers: %s", err)
+	}
+	logger.Info("Reset all the ledgers to the genesis block")
+	return nil
+}
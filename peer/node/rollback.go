@@ -0,0 +1,54 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package node
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/ledger/ledgermgmt"
+	"github.com/hyperledger/fabric/core/peer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rollbackChannelID   string
+	rollbackBlockNumber uint64
+)
+
+func rollbackCmd() *cobra.Command {
+	flags := nodeRollbackCmd.Flags()
+	flags.StringVarP(&rollbackChannelID, "channelID", "c", "", "Channel whose ledger is to be rolled back")
+	flags.Uint64VarP(&rollbackBlockNumber, "blockNumber", "b", 0, "Block number to which the ledger is to be rolled back")
+	return nodeRollbackCmd
+}
+
+var nodeRollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Rolls back a channel ledger to a block number.",
+	Long: `Removes all the blocks after the given block number from a channel ledger. The state database and ` +
+		`the history database of the channel are rebuilt from the retained blocks when the peer is started next. ` +
+		`The peer should be stopped when this command is executed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !cmd.Flags().Changed("blockNumber") {
+			return fmt.Errorf("Must supply the block number")
+		}
+		return rollbackLedger(rollbackChannelID, rollbackBlockNumber)
+	},
+}
+
+func rollbackLedger(channelID string, blockNumber uint64) error {
+	if channelID == "" {
+		return fmt.Errorf("Must supply channel ID")
+	}
+	ledgermgmt.Initialize(&ledgermgmt.Initializer{CustomTxProcessors: peer.ConfigTxProcessors})
+	defer ledgermgmt.Close()
+	if err := ledgermgmt.RollbackLedger(channelID, blockNumber); err != nil {
+		return fmt.Errorf("Error while rolling back the ledger for channel [%s] to block [%d]: %s", channelID, blockNumber, err)
+	}
+	logger.Infof("Rolled back the ledger for channel [%s] to block [%d]", channelID, blockNumber)
+	return nil
+}
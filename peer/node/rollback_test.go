@@ -0,0 +1,28 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollbackCmdFlags(t *testing.T) {
+	cmd := rollbackCmd()
+	for _, flag := range []string{"channelID", "blockNumber"} {
+		assert.NotNil(t, cmd.Flags().Lookup(flag), "flag [%s] should be defined", flag)
+	}
+
+	// the block number has no meaningful default, hence it must be supplied explicitly
+	cmd.SetArgs([]string{"-c", "mychannel"})
+	assert.EqualError(t, cmd.Execute(), "Must supply the block number")
+}
+
+func TestRollbackLedgerMissingArgs(t *testing.T) {
+	assert.EqualError(t, rollbackLedger("", 10), "Must supply channel ID")
+}
@@ -0,0 +1,74 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package node
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric/core/ledger/ledgermgmt"
+	"github.com/hyperledger/fabric/core/peer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	snapshotChannelID string
+	snapshotOutputDir string
+	snapshotImportDir string
+)
+
+func snapshotCmd() *cobra.Command {
+	flags := nodeSnapshotCmd.Flags()
+	flags.StringVarP(&snapshotChannelID, "channelID", "c", "", "Channel whose ledger is to be exported")
+	flags.StringVarP(&snapshotOutputDir, "outputDir", "o", "", "Directory to export the snapshot to")
+	flags.StringVarP(&snapshotImportDir, "importDir", "i", "",
+		"Directory containing a snapshot to create the ledger from (instead of exporting a snapshot)")
+	return nodeSnapshotCmd
+}
+
+var nodeSnapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Exports a snapshot of a channel ledger or creates a channel ledger from a snapshot.",
+	Long: `Exports the state database, the savepoint, the last block, and the config block of a channel ledger ` +
+		`to a hash-verified set of files. Alternatively, with the --importDir flag, creates a channel ledger ` +
+		`from such a snapshot. The peer should be stopped when this command is executed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if snapshotImportDir != "" {
+			return importSnapshot(snapshotImportDir)
+		}
+		return exportSnapshot(snapshotChannelID, snapshotOutputDir)
+	},
+}
+
+func exportSnapshot(channelID, outputDir string) error {
+	if channelID == "" {
+		return fmt.Errorf("Must supply channel ID")
+	}
+	if outputDir == "" {
+		return fmt.Errorf("Must supply the output directory for the snapshot")
+	}
+	ledgermgmt.Initialize(&ledgermgmt.Initializer{CustomTxProcessors: peer.ConfigTxProcessors})
+	defer ledgermgmt.Close()
+	l, err := ledgermgmt.OpenLedger(channelID)
+	if err != nil {
+		return fmt.Errorf("Error while opening the ledger for channel [%s]: %s", channelID, err)
+	}
+	if err := l.ExportSnapshot(outputDir); err != nil {
+		return fmt.Errorf("Error while exporting the snapshot for channel [%s]: %s", channelID, err)
+	}
+	logger.Infof("Exported the snapshot for channel [%s] to [%s]", channelID, outputDir)
+	return nil
+}
+
+func importSnapshot(snapshotDir string) error {
+	ledgermgmt.Initialize(&ledgermgmt.Initializer{CustomTxProcessors: peer.ConfigTxProcessors})
+	defer ledgermgmt.Close()
+	if _, err := ledgermgmt.CreateLedgerFromSnapshot(snapshotDir); err != nil {
+		return fmt.Errorf("Error while creating the ledger from the snapshot [%s]: %s", snapshotDir, err)
+	}
+	logger.Infof("Created the ledger from the snapshot [%s]", snapshotDir)
+	return nil
+}
@@ -0,0 +1,27 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotCmdFlags(t *testing.T) {
+	cmd := snapshotCmd()
+	for _, flag := range []string{"channelID", "outputDir", "importDir"} {
+		assert.NotNil(t, cmd.Flags().Lookup(flag), "flag [%s] should be defined", flag)
+	}
+}
+
+func TestExportSnapshotMissingArgs(t *testing.T) {
+	err := exportSnapshot("", "/tmp/snapshot")
+	assert.EqualError(t, err, "Must supply channel ID")
+	err = exportSnapshot("mychannel", "")
+	assert.EqualError(t, err, "Must supply the output directory for the snapshot")
+}
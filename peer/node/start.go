@@ -21,6 +21,7 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/common/flogging"
 	"github.com/hyperledger/fabric/common/localmsp"
+	"github.com/hyperledger/fabric/common/metrics"
 	"github.com/hyperledger/fabric/common/viperutil"
 	"github.com/hyperledger/fabric/core"
 	"github.com/hyperledger/fabric/core/aclmgmt"
@@ -110,13 +111,27 @@ func serve(args []string) error {
 
 	logger.Infof("Starting %s", version.GetInfo())
 
+	// the metrics root scope must be initialized before the components
+	// which emit metrics are created
+	if err := metrics.Init(metrics.NewOpts()); err != nil {
+		return errors.WithMessage(err, "failed to initialize metrics")
+	}
+	if viper.GetBool("metrics.enabled") {
+		go func() {
+			logger.Infof("Starting %s metrics reporter", viper.GetString("metrics.reporter"))
+			if err := metrics.Start(); err != nil && err != http.ErrServerClosed {
+				logger.Errorf("Error starting metrics reporter: %s", err)
+			}
+		}()
+	}
+
 	//aclmgmt initializes a proxy Processor that will be redirected to RSCC provider
 	//or default ACL Provider (for 1.0 behavior if RSCC is not enabled or available)
 
 	// TODO RSCC-cleanup: remove the commented code (?)
 	// aclmgmt.GetConfigTxProcessor()
 	// txprocessors := customtx.Processors{cb.HeaderType_CONFIG: aclmgmt.GetConfigTxProcessor()}
-	ledgermgmt.Initialize(peer.ConfigTxProcessors)
+	ledgermgmt.Initialize(&ledgermgmt.Initializer{CustomTxProcessors: peer.ConfigTxProcessors})
 
 	// Parameter overrides must be processed before any parameters are
 	// cached. Failures to cache cause the server to terminate immediately.
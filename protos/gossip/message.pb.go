@@ -1073,6 +1073,7 @@ func (m *StateInfo) GetProperties() *Properties {
 type Properties struct {
 	LedgerHeight uint64 `protobuf:"varint,1,opt,name=ledger_height,json=ledgerHeight" json:"ledger_height,omitempty"`
 	LeftChannel  bool   `protobuf:"varint,2,opt,name=left_channel,json=leftChannel" json:"left_channel,omitempty"`
+	CommitHash   []byte `protobuf:"bytes,3,opt,name=commit_hash,json=commitHash,proto3" json:"commit_hash,omitempty"`
 }
 
 func (m *Properties) Reset()                    { *m = Properties{} }
@@ -1094,6 +1095,13 @@ func (m *Properties) GetLeftChannel() bool {
 	return false
 }
 
+func (m *Properties) GetCommitHash() []byte {
+	if m != nil {
+		return m.CommitHash
+	}
+	return nil
+}
+
 // StateInfoSnapshot is an aggregation of StateInfo messages
 type StateInfoSnapshot struct {
 	Elements []*Envelope `protobuf:"bytes,1,rep,name=elements" json:"elements,omitempty"`
@@ -2018,116 +2026,117 @@ var _Gossip_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("gossip/message.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 1773 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x58, 0xdb, 0x6e, 0xe3, 0xc6,
-	0x19, 0x16, 0xad, 0x23, 0x7f, 0x1d, 0x2c, 0x8f, 0xbd, 0xbb, 0x8c, 0x93, 0xa6, 0x0e, 0xdb, 0x4d,
-	0xb6, 0x75, 0x62, 0x2f, 0x9c, 0x16, 0x0d, 0x90, 0xb6, 0x0b, 0xdb, 0x52, 0x2c, 0x21, 0x2b, 0xaf,
-	0x4b, 0x7b, 0xd1, 0xba, 0x37, 0xc4, 0x98, 0x1c, 0x53, 0xac, 0xc9, 0x21, 0xcd, 0x19, 0x6f, 0xec,
-	0xcb, 0xa2, 0x17, 0x05, 0x7a, 0xd7, 0x47, 0xe8, 0xb3, 0xf4, 0xc5, 0x8a, 0x99, 0xe1, 0x51, 0xb2,
-	0x16, 0xd8, 0x05, 0x7a, 0xa7, 0xff, 0x3c, 0xf3, 0xcd, 0x7f, 0xa2, 0x60, 0xcb, 0x8b, 0x18, 0xf3,
-	0xe3, 0xfd, 0x90, 0x30, 0x86, 0x3d, 0xb2, 0x17, 0x27, 0x11, 0x8f, 0x50, 0x4b, 0x71, 0xcd, 0x7f,
-	0x68, 0xd0, 0x19, 0xd3, 0x77, 0x24, 0x88, 0x62, 0x82, 0x0c, 0x68, 0xc7, 0xf8, 0x21, 0x88, 0xb0,
-	0x6b, 0x68, 0x3b, 0xda, 0x8b, 0x9e, 0x95, 0x91, 0xe8, 0x33, 0xd0, 0x99, 0xef, 0x51, 0xcc, 0xef,
-	0x12, 0x62, 0xac, 0x49, 0x59, 0xc1, 0x40, 0xaf, 0x60, 0x9d, 0x11, 0x27, 0x21, 0xdc, 0x26, 0xa9,
-	0x2b, 0xa3, 0xbe, 0xa3, 0xbd, 0xe8, 0x1e, 0x3c, 0xdd, 0x53, 0x61, 0xf6, 0xce, 0xa5, 0x38, 0x0b,
-	0x64, 0x0d, 0x58, 0x85, 0x36, 0x27, 0x30, 0xa8, 0x6a, 0x7c, 0xec, 0x51, 0xcc, 0x43, 0x68, 0x29,
-	0x4f, 0xe8, 0x6b, 0x18, 0xfa, 0x94, 0x93, 0x84, 0xe2, 0x60, 0x4c, 0xdd, 0x38, 0xf2, 0x29, 0x97,
-	0xae, 0xf4, 0x49, 0xcd, 0x5a, 0x92, 0x1c, 0xe9, 0xd0, 0x76, 0x22, 0xca, 0x09, 0xe5, 0xe6, 0x3f,
-	0xbb, 0xd0, 0x3f, 0x91, 0xc7, 0x9e, 0x29, 0xc8, 0xd0, 0x16, 0x34, 0x69, 0x44, 0x1d, 0x22, 0xed,
-	0x1b, 0x96, 0x22, 0xc4, 0x11, 0x9d, 0x39, 0xa6, 0x94, 0x04, 0xe9, 0x31, 0x32, 0x12, 0xed, 0x42,
-	0x9d, 0x63, 0x4f, 0x62, 0x30, 0x38, 0xf8, 0x24, 0xc3, 0xa0, 0xe2, 0x73, 0xef, 0x02, 0x7b, 0x96,
-	0xd0, 0x42, 0xdf, 0x82, 0x8e, 0x03, 0xff, 0x1d, 0xb1, 0x43, 0xe6, 0x19, 0x4d, 0x09, 0xdb, 0x56,
-	0x66, 0x72, 0x28, 0x04, 0xa9, 0xc5, 0xa4, 0x66, 0x75, 0xa4, 0xe2, 0x8c, 0x79, 0xe8, 0x37, 0xd0,
-	0x0e, 0x49, 0x68, 0x27, 0xe4, 0xd6, 0x68, 0x49, 0x93, 0x3c, 0xca, 0x8c, 0x84, 0x57, 0x24, 0x61,
-	0x73, 0x3f, 0xb6, 0xc8, 0xed, 0x1d, 0x61, 0x7c, 0x52, 0xb3, 0x5a, 0x21, 0x09, 0x2d, 0x72, 0x8b,
-	0x7e, 0x9b, 0x59, 0x31, 0xa3, 0x2d, 0xad, 0xb6, 0x1f, 0xb3, 0x62, 0x71, 0x44, 0x19, 0xc9, 0xcd,
-	0x18, 0x7a, 0x09, 0x1d, 0x17, 0x73, 0x2c, 0x0f, 0xd8, 0x91, 0x76, 0x9b, 0x99, 0xdd, 0x08, 0x73,
-	0x5c, 0x9c, 0xaf, 0x2d, 0xd4, 0xc4, 0xf1, 0x76, 0xa1, 0x39, 0x27, 0x41, 0x10, 0x19, 0x7a, 0x55,
-	0x5d, 0x41, 0x30, 0x11, 0xa2, 0x49, 0xcd, 0x52, 0x3a, 0x68, 0x3f, 0x75, 0xef, 0xfa, 0x9e, 0x01,
-	0x52, 0x1f, 0x95, 0xdd, 0x8f, 0x7c, 0x4f, 0xdd, 0x42, 0x7a, 0x1f, 0xf9, 0x5e, 0x7e, 0x1e, 0x71,
-	0xfb, 0xee, 0xf2, 0x79, 0x8a, 0x7b, 0x4b, 0x0b, 0x75, 0xf1, 0xae, 0xb4, 0xb8, 0x8b, 0x5d, 0xcc,
-	0x89, 0xd1, 0x5b, 0x8e, 0xf2, 0x56, 0x4a, 0x26, 0x35, 0x0b, 0xdc, 0x9c, 0x42, 0xcf, 0xa1, 0x49,
-	0xc2, 0x98, 0x3f, 0x18, 0x7d, 0x69, 0xd0, 0xcf, 0x0c, 0xc6, 0x82, 0x29, 0x2e, 0x20, 0xa5, 0x68,
-	0x17, 0x1a, 0x4e, 0x44, 0xa9, 0x31, 0x90, 0x5a, 0x4f, 0x32, 0xad, 0xe3, 0x88, 0xd2, 0x31, 0xe3,
-	0xf8, 0x2a, 0xf0, 0xd9, 0x7c, 0x52, 0xb3, 0xa4, 0x12, 0x3a, 0x00, 0x60, 0x1c, 0x73, 0x62, 0xfb,
-	0xf4, 0x3a, 0x32, 0xd6, 0xa5, 0xc9, 0x46, 0x5e, 0x26, 0x42, 0x32, 0xa5, 0xd7, 0x02, 0x1d, 0x9d,
-	0x65, 0x04, 0x3a, 0x82, 0x81, 0xb2, 0x61, 0x14, 0xc7, 0x6c, 0x1e, 0x71, 0x63, 0x58, 0x7d, 0xf4,
-	0xdc, 0xee, 0x3c, 0x55, 0x98, 0xd4, 0xac, 0xbe, 0x34, 0xc9, 0x18, 0x68, 0x06, 0x9b, 0x45, 0x5c,
-	0x3b, 0xbe, 0x0b, 0x02, 0x89, 0xdf, 0x86, 0x74, 0xf4, 0xd9, 0x92, 0xa3, 0xb3, 0xbb, 0x20, 0x28,
-	0x80, 0x1c, 0xb2, 0x05, 0x3e, 0x3a, 0x04, 0xe5, 0x5f, 0x38, 0x11, 0x4a, 0x06, 0xaa, 0x26, 0x94,
-	0x45, 0xc2, 0x88, 0x13, 0xe9, 0xae, 0x70, 0xd3, 0x63, 0x25, 0x1a, 0x8d, 0xb2, 0x5b, 0x25, 0x69,
-	0xca, 0x19, 0x9b, 0xd2, 0xc7, 0xa7, 0x8f, 0xfa, 0xc8, 0xb3, 0xb2, 0xcf, 0xca, 0x0c, 0x81, 0x4d,
-	0x40, 0xb0, 0xab, 0x92, 0x57, 0xa6, 0xe8, 0x56, 0x15, 0x9b, 0xd7, 0xb9, 0xb4, 0x48, 0xd4, 0x7e,
-	0x61, 0x22, 0xd2, 0xf5, 0x7b, 0xe8, 0xc7, 0x84, 0x24, 0xb6, 0xef, 0x12, 0xca, 0x7d, 0xfe, 0x60,
-	0x3c, 0xa9, 0x96, 0xe1, 0x19, 0x21, 0xc9, 0x34, 0x95, 0x89, 0x6b, 0xc4, 0x25, 0x5a, 0x14, 0x3b,
-	0x76, 0x6e, 0x8c, 0xa7, 0xd2, 0xe4, 0x59, 0x5e, 0xb9, 0xce, 0x0d, 0x8d, 0x7e, 0x0a, 0x88, 0xeb,
-	0x91, 0x90, 0x50, 0x71, 0x79, 0xa1, 0x85, 0xfe, 0x08, 0x10, 0x27, 0xfe, 0x3b, 0x85, 0x82, 0xf1,
-	0xac, 0x0a, 0xbe, 0xba, 0xef, 0xd9, 0x3b, 0x5e, 0xcd, 0xe2, 0x92, 0x05, 0x7a, 0x55, 0xb2, 0x67,
-	0x86, 0x21, 0xed, 0x7f, 0xb6, 0xc2, 0x3e, 0x47, 0xac, 0x64, 0x82, 0x5e, 0x41, 0x2f, 0xa5, 0x6c,
-	0x91, 0xe8, 0xc6, 0x27, 0xd5, 0x67, 0x3b, 0x53, 0xb2, 0x6a, 0x59, 0x77, 0xe3, 0x82, 0x6b, 0xda,
-	0x50, 0xbf, 0xc0, 0x1e, 0xea, 0x83, 0xfe, 0xf6, 0x74, 0x34, 0xfe, 0x61, 0x7a, 0x3a, 0x1e, 0x0d,
-	0x6b, 0x48, 0x87, 0xe6, 0x78, 0x76, 0x76, 0x71, 0x39, 0xd4, 0x50, 0x0f, 0x3a, 0x6f, 0xac, 0x13,
-	0xfb, 0xcd, 0xe9, 0xeb, 0xcb, 0xe1, 0x9a, 0xd0, 0x3b, 0x9e, 0x1c, 0x9e, 0x2a, 0xb2, 0x8e, 0x86,
-	0xd0, 0x93, 0xe4, 0xe1, 0xe9, 0xc8, 0x7e, 0x63, 0x9d, 0x0c, 0x1b, 0x68, 0x1d, 0xba, 0x4a, 0xc1,
-	0x92, 0x8c, 0x66, 0xb9, 0x13, 0xff, 0x57, 0x03, 0x3d, 0xcf, 0x48, 0xb4, 0x0d, 0x9d, 0x90, 0x70,
-	0x2c, 0x8f, 0xad, 0x66, 0x42, 0x4e, 0xa3, 0x3d, 0xd0, 0xb9, 0x1f, 0x12, 0xc6, 0x71, 0x18, 0xcb,
-	0x6e, 0xdc, 0x3d, 0x18, 0x96, 0x5f, 0xef, 0xc2, 0x0f, 0x89, 0x55, 0xa8, 0xa0, 0x27, 0xd0, 0x8a,
-	0x6f, 0x7c, 0xdb, 0x77, 0x65, 0x93, 0xee, 0x59, 0xcd, 0xf8, 0xc6, 0x9f, 0xba, 0xe8, 0xe7, 0xd0,
-	0x4d, 0x7b, 0xb8, 0x3d, 0x3b, 0x3c, 0x36, 0x1a, 0x52, 0x06, 0x29, 0x6b, 0x76, 0x78, 0x2c, 0xaa,
-	0x37, 0x4e, 0xa2, 0x98, 0x24, 0xdc, 0x27, 0x2c, 0xed, 0xd6, 0xa8, 0x00, 0x2f, 0x93, 0x58, 0x25,
-	0x2d, 0xf3, 0x02, 0xa0, 0x90, 0xa0, 0x5f, 0x40, 0x5f, 0x66, 0x45, 0x62, 0xcf, 0x89, 0xef, 0xcd,
-	0x79, 0x3a, 0x53, 0x7a, 0x8a, 0x39, 0x91, 0x3c, 0xf4, 0x05, 0xf4, 0x02, 0x72, 0xcd, 0xed, 0xf2,
-	0x7c, 0xe9, 0x58, 0x5d, 0xc1, 0x3b, 0x56, 0x2c, 0xf3, 0x10, 0x36, 0x96, 0xaa, 0x1e, 0x7d, 0x0d,
-	0x1d, 0x12, 0xc8, 0x84, 0x63, 0x86, 0xb6, 0x53, 0x2f, 0xa3, 0x90, 0xcf, 0xde, 0x5c, 0xc3, 0xfc,
-	0x1d, 0x6c, 0x3d, 0x56, 0xef, 0x8b, 0x28, 0x68, 0x8b, 0x28, 0x98, 0xd7, 0xd0, 0xaf, 0x34, 0xb7,
-	0x12, 0x9c, 0x5a, 0x19, 0xce, 0x6d, 0xe8, 0xe4, 0x25, 0xa5, 0x46, 0x64, 0x4e, 0x23, 0x13, 0xfa,
-	0x3c, 0x60, 0xb6, 0x43, 0x12, 0x6e, 0xcf, 0x31, 0x9b, 0xa7, 0x0f, 0xd1, 0xe5, 0x01, 0x3b, 0x26,
-	0x09, 0x9f, 0x60, 0x36, 0x37, 0xdf, 0x42, 0xaf, 0x5c, 0x7a, 0xab, 0xc2, 0x20, 0x68, 0x08, 0x37,
-	0x69, 0x08, 0xf9, 0xbb, 0x92, 0x2c, 0xf5, 0x6a, 0xb2, 0x98, 0x21, 0x74, 0x4b, 0x15, 0xb6, 0x7a,
-	0xba, 0xbb, 0x72, 0xf2, 0x30, 0x63, 0x6d, 0xa7, 0xfe, 0x42, 0xb7, 0x32, 0x12, 0xed, 0x41, 0x27,
-	0x64, 0x9e, 0xcd, 0x1f, 0xd2, 0x35, 0x67, 0x50, 0x8c, 0x1f, 0x81, 0xe2, 0x8c, 0x79, 0x17, 0x0f,
-	0x31, 0xb1, 0xda, 0xa1, 0xfa, 0x61, 0x46, 0xd0, 0x2d, 0xcd, 0xbd, 0x15, 0xe1, 0xca, 0xe7, 0x5d,
-	0x5b, 0x4a, 0xee, 0x0f, 0x0b, 0x78, 0x0f, 0x50, 0x8c, 0xb4, 0x15, 0xf1, 0x7e, 0x09, 0x8d, 0x34,
-	0xd6, 0xe3, 0x59, 0xd2, 0xf8, 0xa8, 0xc8, 0x81, 0x8a, 0xac, 0x46, 0xf6, 0xff, 0x1d, 0xd8, 0xef,
-	0xd4, 0x3b, 0x66, 0x5b, 0xda, 0xaf, 0xaa, 0x2b, 0x63, 0xf7, 0x60, 0x3d, 0xb7, 0x56, 0xec, 0x7c,
-	0x87, 0x34, 0x7f, 0x00, 0xb4, 0xdc, 0xe9, 0xd0, 0xcb, 0x45, 0x07, 0x4f, 0x17, 0xda, 0xe2, 0x92,
-	0x9f, 0x4b, 0x68, 0xa7, 0x3c, 0xf4, 0x0c, 0xda, 0x8c, 0xdc, 0xda, 0xf4, 0x2e, 0x4c, 0xaf, 0xdb,
-	0x62, 0xe4, 0xf6, 0xf4, 0x2e, 0x14, 0xd9, 0x59, 0x7a, 0x55, 0x85, 0xeb, 0x17, 0x0b, 0x5d, 0xb8,
-	0xbe, 0x53, 0x17, 0xb9, 0x5f, 0xee, 0xb3, 0xff, 0xd6, 0x60, 0x50, 0x0d, 0x8b, 0xbe, 0x82, 0x75,
-	0x27, 0x0a, 0x02, 0xe2, 0x70, 0x3f, 0xa2, 0x36, 0xc5, 0xa1, 0x42, 0x56, 0xb7, 0x06, 0x05, 0xfb,
-	0x14, 0x87, 0x44, 0xac, 0xc8, 0x42, 0xca, 0x62, 0xec, 0xa8, 0x15, 0x59, 0xb7, 0x0a, 0x06, 0xda,
-	0x84, 0x26, 0xbf, 0xcf, 0x5a, 0x9f, 0x6e, 0x35, 0xf8, 0xfd, 0xd4, 0x15, 0x6d, 0x29, 0x3b, 0x51,
-	0xf2, 0x13, 0x23, 0x3c, 0xed, 0x7d, 0xd9, 0x31, 0x2d, 0xc1, 0x33, 0xff, 0xa5, 0x41, 0xaf, 0xbc,
-	0x92, 0xa2, 0x3d, 0x80, 0x30, 0xdf, 0x1c, 0x53, 0xd0, 0x06, 0xd5, 0x9d, 0xd2, 0x2a, 0x69, 0x7c,
-	0x70, 0x9b, 0x2e, 0x37, 0x90, 0x46, 0xb5, 0x81, 0x98, 0x7f, 0xd7, 0x60, 0x63, 0x69, 0xb6, 0xaf,
-	0x6a, 0x11, 0x1f, 0x1a, 0xf8, 0x39, 0x0c, 0x7c, 0x66, 0xbb, 0xc4, 0x09, 0x70, 0x82, 0x05, 0xae,
-	0x12, 0xac, 0x8e, 0xd5, 0xf7, 0xd9, 0xa8, 0x60, 0x9a, 0xbf, 0x87, 0x4e, 0x66, 0x2d, 0x12, 0xc0,
-	0xa7, 0x4e, 0x39, 0x01, 0x7c, 0xea, 0x88, 0x04, 0x28, 0x65, 0xc6, 0x5a, 0x39, 0x33, 0xcc, 0x6b,
-	0xd8, 0x58, 0xda, 0xd6, 0xd1, 0xf7, 0x30, 0x64, 0x24, 0xb8, 0x96, 0x6b, 0x5a, 0x12, 0xaa, 0xd8,
-	0x5a, 0xf5, 0xc0, 0x79, 0x91, 0xae, 0x0b, 0xcd, 0x69, 0xa1, 0x28, 0x2a, 0x4e, 0xac, 0x1d, 0x54,
-	0x56, 0x56, 0xcf, 0x52, 0x84, 0x79, 0x05, 0x68, 0x79, 0xbf, 0x47, 0x5f, 0x42, 0x53, 0x7e, 0x4e,
-	0xac, 0x1c, 0x14, 0x4a, 0x2c, 0x3b, 0x05, 0xc1, 0xee, 0x7b, 0x3a, 0x05, 0xc1, 0xae, 0xf9, 0x67,
-	0x68, 0xa9, 0x18, 0xe2, 0xcd, 0x48, 0xe5, 0x7b, 0xcb, 0xca, 0xe9, 0xf7, 0x76, 0xb9, 0xc7, 0x47,
-	0xb2, 0xd9, 0x86, 0xa6, 0x5c, 0xb7, 0xcd, 0xbf, 0x00, 0x5a, 0x5e, 0x2a, 0xc5, 0x18, 0x61, 0x1c,
-	0x27, 0xdc, 0xae, 0x16, 0x5f, 0x57, 0x32, 0xcf, 0x55, 0x05, 0x7e, 0x0e, 0x5d, 0x42, 0x5d, 0xbb,
-	0xfa, 0x08, 0x3a, 0xa1, 0xae, 0x92, 0x9b, 0x47, 0xb0, 0xf9, 0xc8, 0xaa, 0x89, 0x76, 0xa1, 0x93,
-	0xd6, 0x79, 0x36, 0x4c, 0x97, 0x1a, 0x4a, 0xae, 0x60, 0x9e, 0xc0, 0xd6, 0x63, 0xeb, 0x1b, 0xda,
-	0x2f, 0xba, 0x9d, 0xf2, 0x91, 0x7f, 0x1e, 0xa4, 0x8a, 0xaa, 0x57, 0xe6, 0x4d, 0xd0, 0xfc, 0x8f,
-	0x06, 0xfd, 0x8a, 0xa8, 0xa8, 0x57, 0xad, 0x54, 0xaf, 0xef, 0x2f, 0xf1, 0xcf, 0x01, 0x8a, 0x96,
-	0x90, 0xd6, 0x79, 0x89, 0x83, 0x3e, 0x05, 0xfd, 0x2a, 0x88, 0x9c, 0x1b, 0x81, 0x89, 0x2c, 0xac,
-	0x86, 0xd5, 0x91, 0x8c, 0x73, 0x72, 0x8b, 0x76, 0xa0, 0x27, 0xa0, 0xf2, 0xa9, 0x2d, 0x59, 0x72,
-	0xcb, 0x69, 0x58, 0xc0, 0xc8, 0xed, 0x94, 0x1e, 0x09, 0x8e, 0xf9, 0x23, 0x3c, 0x79, 0x74, 0xd7,
-	0x44, 0x07, 0x4b, 0xfb, 0xc7, 0xd3, 0x85, 0xeb, 0x8e, 0x95, 0xb8, 0xb4, 0x85, 0x5c, 0xc2, 0xa0,
-	0x2a, 0x43, 0xdf, 0x40, 0x4b, 0xa1, 0x91, 0x26, 0xfe, 0x0a, 0xc8, 0x52, 0xa5, 0xf2, 0x5f, 0x05,
-	0x2a, 0xed, 0xf3, 0xf6, 0xfc, 0xa7, 0xdc, 0x75, 0xd6, 0x42, 0x9f, 0xc3, 0x3a, 0xbf, 0xb7, 0x2b,
-	0xd7, 0x4b, 0xf7, 0x2f, 0x7e, 0x7f, 0x9e, 0x5f, 0xb0, 0xea, 0xb2, 0xfc, 0xef, 0x83, 0xf9, 0x15,
-	0xac, 0x2f, 0xac, 0xf6, 0xa2, 0xe8, 0x48, 0x92, 0x44, 0x49, 0xfa, 0x3e, 0x8a, 0xf8, 0xf5, 0x1f,
-	0xa0, 0x5b, 0x1a, 0x5a, 0x8b, 0xfb, 0x72, 0x1f, 0xf4, 0xa3, 0xd7, 0x6f, 0x8e, 0x7f, 0xb4, 0x67,
-	0xe7, 0x27, 0x43, 0x4d, 0xac, 0xc5, 0xd3, 0xd1, 0xf8, 0xf4, 0x62, 0x7a, 0x71, 0x29, 0x39, 0x6b,
-	0x07, 0x7f, 0x83, 0x96, 0x5a, 0x1a, 0xd0, 0x77, 0xd0, 0x53, 0xbf, 0xce, 0x79, 0x42, 0x70, 0x88,
-	0x96, 0x2a, 0x70, 0x7b, 0x89, 0x63, 0xd6, 0x5e, 0x68, 0x2f, 0x35, 0xf4, 0x25, 0x34, 0xce, 0x7c,
-	0xea, 0xa1, 0xea, 0x77, 0xeb, 0x76, 0x95, 0x34, 0x6b, 0x47, 0xdf, 0xfc, 0x75, 0xd7, 0xf3, 0xf9,
-	0xfc, 0xee, 0x6a, 0xcf, 0x89, 0xc2, 0xfd, 0xf9, 0x43, 0x4c, 0x12, 0xb5, 0x8d, 0xee, 0x5f, 0xe3,
-	0xab, 0xc4, 0x77, 0xf6, 0xe5, 0x5f, 0x46, 0x6c, 0x5f, 0x99, 0x5d, 0xb5, 0x24, 0xf9, 0xed, 0xff,
-	0x02, 0x00, 0x00, 0xff, 0xff, 0xb4, 0xb4, 0x9a, 0xc5, 0x59, 0x12, 0x00, 0x00,
+	// 1781 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x58, 0x5f, 0x6f, 0xe3, 0xc6,
+	0x11, 0x17, 0xad, 0xff, 0x43, 0x49, 0x96, 0xd7, 0xbe, 0x3b, 0xc6, 0x49, 0x53, 0x97, 0xed, 0x25,
+	0xd7, 0x3a, 0xb1, 0x0f, 0x4e, 0x8b, 0x06, 0x48, 0xdb, 0x83, 0x6d, 0x29, 0x96, 0x90, 0x93, 0xcf,
+	0xa5, 0x7d, 0x68, 0xdd, 0x17, 0x62, 0x4d, 0xae, 0x29, 0xd6, 0xe4, 0x92, 0xe6, 0xae, 0x2e, 0xf6,
+	0x63, 0xd1, 0x87, 0x02, 0x7d, 0xeb, 0x47, 0xe8, 0x67, 0xe9, 0x17, 0x2b, 0x76, 0x97, 0x7f, 0x2d,
+	0xf9, 0x80, 0x3b, 0x20, 0x6f, 0x9a, 0xbf, 0xbb, 0x33, 0x3b, 0xf3, 0x9b, 0xa1, 0x60, 0xcb, 0x8b,
+	0x18, 0xf3, 0xe3, 0xfd, 0x90, 0x30, 0x86, 0x3d, 0xb2, 0x17, 0x27, 0x11, 0x8f, 0x50, 0x4b, 0x71,
+	0xcd, 0x7f, 0x6a, 0xd0, 0x19, 0xd3, 0x77, 0x24, 0x88, 0x62, 0x82, 0x0c, 0x68, 0xc7, 0xf8, 0x3e,
+	0x88, 0xb0, 0x6b, 0x68, 0x3b, 0xda, 0x8b, 0x9e, 0x95, 0x91, 0xe8, 0x33, 0xe8, 0x32, 0xdf, 0xa3,
+	0x98, 0x2f, 0x12, 0x62, 0xac, 0x49, 0x59, 0xc1, 0x40, 0xaf, 0x60, 0x9d, 0x11, 0x27, 0x21, 0xdc,
+	0x26, 0xa9, 0x2b, 0xa3, 0xbe, 0xa3, 0xbd, 0xd0, 0x0f, 0x9e, 0xee, 0xa9, 0x63, 0xf6, 0xce, 0xa5,
+	0x38, 0x3b, 0xc8, 0x1a, 0xb0, 0x0a, 0x6d, 0x4e, 0x60, 0x50, 0xd5, 0xf8, 0xd8, 0xab, 0x98, 0x87,
+	0xd0, 0x52, 0x9e, 0xd0, 0x57, 0x30, 0xf4, 0x29, 0x27, 0x09, 0xc5, 0xc1, 0x98, 0xba, 0x71, 0xe4,
+	0x53, 0x2e, 0x5d, 0x75, 0x27, 0x35, 0x6b, 0x49, 0x72, 0xd4, 0x85, 0xb6, 0x13, 0x51, 0x4e, 0x28,
+	0x37, 0xff, 0xa5, 0x43, 0xff, 0x44, 0x5e, 0x7b, 0xa6, 0x52, 0x86, 0xb6, 0xa0, 0x49, 0x23, 0xea,
+	0x10, 0x69, 0xdf, 0xb0, 0x14, 0x21, 0xae, 0xe8, 0xcc, 0x31, 0xa5, 0x24, 0x48, 0xaf, 0x91, 0x91,
+	0x68, 0x17, 0xea, 0x1c, 0x7b, 0x32, 0x07, 0x83, 0x83, 0x4f, 0xb2, 0x1c, 0x54, 0x7c, 0xee, 0x5d,
+	0x60, 0xcf, 0x12, 0x5a, 0xe8, 0x1b, 0xe8, 0xe2, 0xc0, 0x7f, 0x47, 0xec, 0x90, 0x79, 0x46, 0x53,
+	0xa6, 0x6d, 0x2b, 0x33, 0x39, 0x14, 0x82, 0xd4, 0x62, 0x52, 0xb3, 0x3a, 0x52, 0x71, 0xc6, 0x3c,
+	0xf4, 0x5b, 0x68, 0x87, 0x24, 0xb4, 0x13, 0x72, 0x6b, 0xb4, 0xa4, 0x49, 0x7e, 0xca, 0x8c, 0x84,
+	0x57, 0x24, 0x61, 0x73, 0x3f, 0xb6, 0xc8, 0xed, 0x82, 0x30, 0x3e, 0xa9, 0x59, 0xad, 0x90, 0x84,
+	0x16, 0xb9, 0x45, 0xbf, 0xcb, 0xac, 0x98, 0xd1, 0x96, 0x56, 0xdb, 0xab, 0xac, 0x58, 0x1c, 0x51,
+	0x46, 0x72, 0x33, 0x86, 0x5e, 0x42, 0xc7, 0xc5, 0x1c, 0xcb, 0x0b, 0x76, 0xa4, 0xdd, 0x66, 0x66,
+	0x37, 0xc2, 0x1c, 0x17, 0xf7, 0x6b, 0x0b, 0x35, 0x71, 0xbd, 0x5d, 0x68, 0xce, 0x49, 0x10, 0x44,
+	0x46, 0xb7, 0xaa, 0xae, 0x52, 0x30, 0x11, 0xa2, 0x49, 0xcd, 0x52, 0x3a, 0x68, 0x3f, 0x75, 0xef,
+	0xfa, 0x9e, 0x01, 0x52, 0x1f, 0x95, 0xdd, 0x8f, 0x7c, 0x4f, 0x45, 0x21, 0xbd, 0x8f, 0x7c, 0x2f,
+	0xbf, 0x8f, 0x88, 0x5e, 0x5f, 0xbe, 0x4f, 0x11, 0xb7, 0xb4, 0x50, 0x81, 0xeb, 0xd2, 0x62, 0x11,
+	0xbb, 0x98, 0x13, 0xa3, 0xb7, 0x7c, 0xca, 0x5b, 0x29, 0x99, 0xd4, 0x2c, 0x70, 0x73, 0x0a, 0x3d,
+	0x87, 0x26, 0x09, 0x63, 0x7e, 0x6f, 0xf4, 0xa5, 0x41, 0x3f, 0x33, 0x18, 0x0b, 0xa6, 0x08, 0x40,
+	0x4a, 0xd1, 0x2e, 0x34, 0x9c, 0x88, 0x52, 0x63, 0x20, 0xb5, 0x9e, 0x64, 0x5a, 0xc7, 0x11, 0xa5,
+	0x63, 0xc6, 0xf1, 0x55, 0xe0, 0xb3, 0xf9, 0xa4, 0x66, 0x49, 0x25, 0x74, 0x00, 0xc0, 0x38, 0xe6,
+	0xc4, 0xf6, 0xe9, 0x75, 0x64, 0xac, 0x4b, 0x93, 0x8d, 0xbc, 0x4d, 0x84, 0x64, 0x4a, 0xaf, 0x45,
+	0x76, 0xba, 0x2c, 0x23, 0xd0, 0x11, 0x0c, 0x94, 0x0d, 0xa3, 0x38, 0x66, 0xf3, 0x88, 0x1b, 0xc3,
+	0xea, 0xa3, 0xe7, 0x76, 0xe7, 0xa9, 0xc2, 0xa4, 0x66, 0xf5, 0xa5, 0x49, 0xc6, 0x40, 0x33, 0xd8,
+	0x2c, 0xce, 0xb5, 0xe3, 0x45, 0x10, 0xc8, 0xfc, 0x6d, 0x48, 0x47, 0x9f, 0x2d, 0x39, 0x3a, 0x5b,
+	0x04, 0x41, 0x91, 0xc8, 0x21, 0x7b, 0xc0, 0x47, 0x87, 0xa0, 0xfc, 0xdb, 0x89, 0x52, 0x32, 0x50,
+	0xb5, 0xa0, 0x2c, 0x12, 0x46, 0x9c, 0x48, 0x77, 0x85, 0x9b, 0x1e, 0x2b, 0xd1, 0x68, 0x94, 0x45,
+	0x95, 0xa4, 0x25, 0x67, 0x6c, 0x4a, 0x1f, 0x9f, 0xae, 0xf4, 0x91, 0x57, 0x65, 0x9f, 0x95, 0x19,
+	0x22, 0x37, 0x01, 0xc1, 0xae, 0x2a, 0x5e, 0x59, 0xa2, 0x5b, 0xd5, 0xdc, 0xbc, 0xce, 0xa5, 0x45,
+	0xa1, 0xf6, 0x0b, 0x13, 0x51, 0xae, 0xdf, 0x41, 0x3f, 0x26, 0x24, 0xb1, 0x7d, 0x97, 0x50, 0xee,
+	0xf3, 0x7b, 0xe3, 0x49, 0xb5, 0x0d, 0xcf, 0x08, 0x49, 0xa6, 0xa9, 0x4c, 0x84, 0x11, 0x97, 0x68,
+	0xd1, 0xec, 0xd8, 0xb9, 0x31, 0x9e, 0x4a, 0x93, 0x67, 0x79, 0xe7, 0x3a, 0x37, 0x34, 0xfa, 0x31,
+	0x20, 0xae, 0x47, 0x42, 0x42, 0x45, 0xf0, 0x42, 0x0b, 0xfd, 0x09, 0x20, 0x4e, 0xfc, 0x77, 0x2a,
+	0x0b, 0xc6, 0xb3, 0x6a, 0xf2, 0x55, 0xbc, 0x67, 0xef, 0x78, 0xb5, 0x8a, 0x4b, 0x16, 0xe8, 0x55,
+	0xc9, 0x9e, 0x19, 0x86, 0xb4, 0xff, 0xd9, 0x23, 0xf6, 0x79, 0xc6, 0x4a, 0x26, 0xe8, 0x15, 0xf4,
+	0x52, 0xca, 0x16, 0x85, 0x6e, 0x7c, 0x52, 0x7d, 0xb6, 0x33, 0x25, 0xab, 0xb6, 0xb5, 0x1e, 0x17,
+	0x5c, 0xd3, 0x86, 0xfa, 0x05, 0xf6, 0x50, 0x1f, 0xba, 0x6f, 0x4f, 0x47, 0xe3, 0xef, 0xa7, 0xa7,
+	0xe3, 0xd1, 0xb0, 0x86, 0xba, 0xd0, 0x1c, 0xcf, 0xce, 0x2e, 0x2e, 0x87, 0x1a, 0xea, 0x41, 0xe7,
+	0x8d, 0x75, 0x62, 0xbf, 0x39, 0x7d, 0x7d, 0x39, 0x5c, 0x13, 0x7a, 0xc7, 0x93, 0xc3, 0x53, 0x45,
+	0xd6, 0xd1, 0x10, 0x7a, 0x92, 0x3c, 0x3c, 0x1d, 0xd9, 0x6f, 0xac, 0x93, 0x61, 0x03, 0xad, 0x83,
+	0xae, 0x14, 0x2c, 0xc9, 0x68, 0x96, 0x91, 0xf8, 0x7f, 0x1a, 0x74, 0xf3, 0x8a, 0x44, 0xdb, 0xd0,
+	0x09, 0x09, 0xc7, 0xf2, 0xda, 0x6a, 0x26, 0xe4, 0x34, 0xda, 0x83, 0x2e, 0xf7, 0x43, 0xc2, 0x38,
+	0x0e, 0x63, 0x89, 0xc6, 0xfa, 0xc1, 0xb0, 0xfc, 0x7a, 0x17, 0x7e, 0x48, 0xac, 0x42, 0x05, 0x3d,
+	0x81, 0x56, 0x7c, 0xe3, 0xdb, 0xbe, 0x2b, 0x41, 0xba, 0x67, 0x35, 0xe3, 0x1b, 0x7f, 0xea, 0xa2,
+	0x9f, 0x83, 0x9e, 0x62, 0xb8, 0x3d, 0x3b, 0x3c, 0x36, 0x1a, 0x52, 0x06, 0x29, 0x6b, 0x76, 0x78,
+	0x2c, 0xba, 0x37, 0x4e, 0xa2, 0x98, 0x24, 0xdc, 0x27, 0xcc, 0x68, 0x56, 0x71, 0xe4, 0x2c, 0x97,
+	0x58, 0x25, 0x2d, 0x73, 0x01, 0x50, 0x48, 0xd0, 0x2f, 0xa1, 0x2f, 0xab, 0x22, 0xb1, 0xe7, 0xc4,
+	0xf7, 0xe6, 0x3c, 0x9d, 0x29, 0x3d, 0xc5, 0x9c, 0x48, 0x1e, 0xfa, 0x05, 0xf4, 0x02, 0x72, 0xcd,
+	0xed, 0xf2, 0x7c, 0xe9, 0x58, 0xba, 0xe0, 0x1d, 0x2b, 0x96, 0xbc, 0x6a, 0x14, 0x86, 0x3e, 0xb7,
+	0xe7, 0x98, 0xcd, 0xd3, 0x30, 0x40, 0xb1, 0x26, 0x98, 0xcd, 0xcd, 0x43, 0xd8, 0x58, 0x82, 0x05,
+	0xf4, 0x15, 0x74, 0x48, 0x20, 0x2b, 0x92, 0x19, 0xda, 0x4e, 0xbd, 0x9c, 0xa6, 0x7c, 0x38, 0xe7,
+	0x1a, 0xe6, 0xef, 0x61, 0x6b, 0x15, 0x20, 0x3c, 0x4c, 0x93, 0xf6, 0x30, 0x4d, 0xe6, 0x35, 0xf4,
+	0x2b, 0xe8, 0x57, 0xca, 0xb7, 0x56, 0xce, 0xf7, 0x36, 0x74, 0xf2, 0x9e, 0x53, 0x33, 0x34, 0xa7,
+	0x91, 0x09, 0x7d, 0x1e, 0x30, 0xdb, 0x21, 0x49, 0x25, 0x44, 0x9d, 0x07, 0xec, 0x98, 0x24, 0x2a,
+	0xc6, 0xb7, 0xd0, 0x2b, 0xf7, 0xe6, 0x63, 0xc7, 0x20, 0x68, 0x08, 0x37, 0xe9, 0x11, 0xf2, 0x77,
+	0xa5, 0x9a, 0xea, 0xd5, 0x6a, 0x32, 0x43, 0xd0, 0x4b, 0x2d, 0xf8, 0xf8, 0xf8, 0x77, 0xe5, 0x68,
+	0x62, 0xc6, 0xda, 0x4e, 0xfd, 0x45, 0xd7, 0xca, 0x48, 0xb4, 0x07, 0x9d, 0x90, 0x79, 0x36, 0xbf,
+	0x4f, 0xf7, 0xa0, 0x41, 0x31, 0x9f, 0x44, 0x16, 0x67, 0xcc, 0xbb, 0xb8, 0x8f, 0x89, 0xd5, 0x0e,
+	0xd5, 0x0f, 0x33, 0x02, 0xbd, 0x34, 0x18, 0x1f, 0x39, 0xae, 0x7c, 0xdf, 0xb5, 0xa5, 0xea, 0xff,
+	0xb0, 0x03, 0xef, 0x00, 0x8a, 0x99, 0xf7, 0xc8, 0x79, 0xbf, 0x82, 0x46, 0x7a, 0xd6, 0xea, 0x2a,
+	0x69, 0x7c, 0xd4, 0xc9, 0x01, 0x40, 0x31, 0xd3, 0x7f, 0xf2, 0xc4, 0x7e, 0x0b, 0x7a, 0x09, 0xc9,
+	0xd0, 0xaf, 0xab, 0x3b, 0xa5, 0x7e, 0xb0, 0x9e, 0x5b, 0x2b, 0x76, 0xbe, 0x64, 0x9a, 0xdf, 0x03,
+	0x5a, 0x86, 0x42, 0xf4, 0xf2, 0xa1, 0x83, 0xa7, 0x0f, 0x70, 0x73, 0xc9, 0xcf, 0x25, 0xb4, 0x53,
+	0x1e, 0x7a, 0x06, 0x6d, 0x46, 0x6e, 0x6d, 0xba, 0x08, 0xd3, 0x70, 0x5b, 0x8c, 0xdc, 0x9e, 0x2e,
+	0x42, 0x51, 0x9d, 0xa5, 0x57, 0x95, 0xbf, 0x05, 0x00, 0x54, 0x60, 0xba, 0xbe, 0x53, 0x17, 0xb5,
+	0x5f, 0x06, 0xe2, 0xff, 0x68, 0x30, 0xa8, 0x1e, 0x8b, 0xbe, 0x84, 0x75, 0x27, 0x0a, 0x02, 0xe2,
+	0x70, 0x3f, 0xa2, 0x36, 0xc5, 0xa1, 0xca, 0x6c, 0xd7, 0x1a, 0x14, 0xec, 0x53, 0x1c, 0x12, 0xb1,
+	0x43, 0x0b, 0x29, 0x8b, 0xb1, 0xa3, 0x76, 0xe8, 0xae, 0x55, 0x30, 0xd0, 0x26, 0x34, 0xf9, 0x5d,
+	0x86, 0x8d, 0x5d, 0xab, 0xc1, 0xef, 0xa6, 0xae, 0xc0, 0xad, 0xec, 0x46, 0xc9, 0x8f, 0x8c, 0xf0,
+	0x14, 0x1c, 0xb3, 0x6b, 0x5a, 0x82, 0x67, 0xfe, 0x5b, 0x83, 0x5e, 0x79, 0x67, 0x45, 0x7b, 0x00,
+	0x61, 0xbe, 0x5a, 0xa6, 0x49, 0x1b, 0x54, 0x97, 0x4e, 0xab, 0xa4, 0xf1, 0xc1, 0x38, 0x5e, 0x06,
+	0x90, 0x46, 0x15, 0x40, 0xcc, 0x7f, 0x68, 0xb0, 0xb1, 0x34, 0xfc, 0x1f, 0x83, 0x88, 0x0f, 0x3d,
+	0xf8, 0x39, 0x0c, 0x7c, 0x66, 0xbb, 0xc4, 0x09, 0x70, 0x82, 0x45, 0x5e, 0x65, 0xb2, 0x3a, 0x56,
+	0xdf, 0x67, 0xa3, 0x82, 0x69, 0xfe, 0x01, 0x3a, 0x99, 0xb5, 0x28, 0x00, 0x9f, 0x3a, 0xe5, 0x02,
+	0xf0, 0xa9, 0x23, 0x0a, 0xa0, 0x54, 0x19, 0x6b, 0xe5, 0xca, 0x30, 0xaf, 0x61, 0x63, 0x69, 0x9d,
+	0x47, 0xdf, 0xc1, 0x90, 0x91, 0xe0, 0x5a, 0xee, 0x71, 0x49, 0xa8, 0xce, 0xd6, 0x76, 0xb4, 0x95,
+	0x4d, 0xba, 0x2e, 0x34, 0xa7, 0x85, 0xa2, 0xe8, 0x38, 0xb1, 0x97, 0x50, 0xd9, 0x59, 0x3d, 0x4b,
+	0x11, 0xe6, 0x15, 0xa0, 0xe5, 0x0f, 0x00, 0xf4, 0x05, 0x34, 0xe5, 0xf7, 0xc6, 0xa3, 0x83, 0x42,
+	0x89, 0x25, 0x52, 0x10, 0xec, 0xbe, 0x07, 0x29, 0x08, 0x76, 0xcd, 0xbf, 0x40, 0x4b, 0x9d, 0x21,
+	0xde, 0x8c, 0x54, 0x3e, 0xc8, 0xac, 0x9c, 0x7e, 0x2f, 0xca, 0xad, 0x9e, 0xd9, 0x66, 0x1b, 0x9a,
+	0x72, 0x1f, 0x37, 0xff, 0x0a, 0x68, 0x79, 0xeb, 0x14, 0x63, 0x84, 0x71, 0x9c, 0x70, 0xbb, 0xda,
+	0x7c, 0xba, 0x64, 0x9e, 0xab, 0x0e, 0xfc, 0x1c, 0x74, 0x42, 0x5d, 0xbb, 0xfa, 0x08, 0x5d, 0x42,
+	0x5d, 0x25, 0x37, 0x8f, 0x60, 0x73, 0xc5, 0x2e, 0x8a, 0x76, 0xa1, 0x93, 0xf6, 0x79, 0x36, 0x4c,
+	0x97, 0x00, 0x25, 0x57, 0x30, 0x4f, 0x60, 0x6b, 0xd5, 0x7e, 0x87, 0xf6, 0x0b, 0xb4, 0x53, 0x3e,
+	0xf2, 0xef, 0x87, 0x54, 0x51, 0x61, 0x65, 0x0e, 0x82, 0xe6, 0x7f, 0x35, 0xe8, 0x57, 0x44, 0x45,
+	0xbf, 0x6a, 0xa5, 0x7e, 0x7d, 0x7f, 0x8b, 0x7f, 0x0e, 0x50, 0x40, 0x42, 0xda, 0xe7, 0x25, 0x0e,
+	0xfa, 0x14, 0xba, 0x57, 0x41, 0xe4, 0xdc, 0x88, 0x9c, 0xc8, 0xc6, 0x6a, 0x58, 0x1d, 0xc9, 0x38,
+	0x27, 0xb7, 0x68, 0x07, 0x7a, 0x22, 0x55, 0x3e, 0xb5, 0x25, 0x4b, 0xae, 0x41, 0x0d, 0x0b, 0x18,
+	0xb9, 0x9d, 0xd2, 0x23, 0xc1, 0x31, 0x7f, 0x80, 0x27, 0x2b, 0x97, 0x51, 0x74, 0xb0, 0xb4, 0x7f,
+	0x3c, 0x7d, 0x10, 0xee, 0x58, 0x89, 0x4b, 0x5b, 0xc8, 0x25, 0x0c, 0xaa, 0x32, 0xf4, 0x35, 0xb4,
+	0x54, 0x36, 0xd2, 0xc2, 0x7f, 0x24, 0x65, 0xa9, 0x52, 0xf9, 0xbf, 0x04, 0x55, 0xf6, 0x19, 0x69,
+	0xfe, 0x39, 0x77, 0x9d, 0x41, 0xe8, 0x73, 0x58, 0xe7, 0x77, 0x76, 0x25, 0xbc, 0x74, 0x41, 0xe3,
+	0x77, 0xe7, 0x79, 0x80, 0x55, 0x97, 0xe5, 0xbf, 0x27, 0xcc, 0x2f, 0x61, 0xfd, 0xc1, 0xee, 0x2f,
+	0x9a, 0x8e, 0x24, 0x49, 0x94, 0xa4, 0xef, 0xa3, 0x88, 0xdf, 0xfc, 0x11, 0xf4, 0xd2, 0xd0, 0x7a,
+	0xb8, 0x50, 0xf7, 0xa1, 0x7b, 0xf4, 0xfa, 0xcd, 0xf1, 0x0f, 0xf6, 0xec, 0xfc, 0x64, 0xa8, 0x89,
+	0xbd, 0x79, 0x3a, 0x1a, 0x9f, 0x5e, 0x4c, 0x2f, 0x2e, 0x25, 0x67, 0xed, 0xe0, 0xef, 0xd0, 0x52,
+	0x4b, 0x03, 0xfa, 0x16, 0x7a, 0xea, 0xd7, 0x39, 0x4f, 0x08, 0x0e, 0xd1, 0x52, 0x07, 0x6e, 0x2f,
+	0x71, 0xcc, 0xda, 0x0b, 0xed, 0xa5, 0x86, 0xbe, 0x80, 0xc6, 0x99, 0x4f, 0x3d, 0x54, 0xfd, 0xb0,
+	0xdd, 0xae, 0x92, 0x66, 0xed, 0xe8, 0xeb, 0xbf, 0xed, 0x7a, 0x3e, 0x9f, 0x2f, 0xae, 0xf6, 0x9c,
+	0x28, 0xdc, 0x9f, 0xdf, 0xc7, 0x24, 0x51, 0xeb, 0xea, 0xfe, 0x35, 0xbe, 0x4a, 0x7c, 0x67, 0x5f,
+	0xfe, 0xa7, 0xc4, 0xf6, 0x95, 0xd9, 0x55, 0x4b, 0x92, 0xdf, 0xfc, 0x7f, 0x00, 0x4a, 0x32, 0x5d,
+	0x80, 0x7a, 0x12, 0x00, 0x00,
 }
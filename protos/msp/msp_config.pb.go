@@ -220,6 +220,10 @@ type IdemixMSPConfig struct {
 	IPk []byte `protobuf:"bytes,2,opt,name=IPk,proto3" json:"IPk,omitempty"`
 	// signer may contain crypto material to configure a default signer
 	Signer *IdemixMSPSignerConfig `protobuf:"bytes,3,opt,name=signer" json:"signer,omitempty"`
+	// revocation_pk is the public key used for revocation of credentials
+	RevocationPk []byte `protobuf:"bytes,4,opt,name=revocation_pk,json=revocationPk,proto3" json:"revocation_pk,omitempty"`
+	// epoch represents the current epoch (time interval) used for revocation
+	Epoch int64 `protobuf:"varint,5,opt,name=epoch" json:"epoch,omitempty"`
 }
 
 func (m *IdemixMSPConfig) Reset()                    { *m = IdemixMSPConfig{} }
@@ -248,6 +252,20 @@ func (m *IdemixMSPConfig) GetSigner() *IdemixMSPSignerConfig {
 	return nil
 }
 
+func (m *IdemixMSPConfig) GetRevocationPk() []byte {
+	if m != nil {
+		return m.RevocationPk
+	}
+	return nil
+}
+
+func (m *IdemixMSPConfig) GetEpoch() int64 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
 // IdemixMSPSIgnerConfig contains the crypto material to set up an idemix signing identity
 type IdemixMSPSignerConfig struct {
 	// Cred represents the serialized idemix credential of the default signer
@@ -258,6 +276,9 @@ type IdemixMSPSignerConfig struct {
 	OrganizationalUnitIdentifier string `protobuf:"bytes,3,opt,name=organizational_unit_identifier,json=organizationalUnitIdentifier" json:"organizational_unit_identifier,omitempty"`
 	// is_admin defines whether the default signer is admin or not
 	IsAdmin bool `protobuf:"varint,4,opt,name=is_admin,json=isAdmin" json:"is_admin,omitempty"`
+	// credential_revocation_information contains a serialized CredentialRevocationInformation
+	// that allows the default signer to prove that its credential is not revoked
+	CredentialRevocationInformation []byte `protobuf:"bytes,5,opt,name=credential_revocation_information,json=credentialRevocationInformation,proto3" json:"credential_revocation_information,omitempty"`
 }
 
 func (m *IdemixMSPSignerConfig) Reset()                    { *m = IdemixMSPSignerConfig{} }
@@ -293,6 +314,13 @@ func (m *IdemixMSPSignerConfig) GetIsAdmin() bool {
 	return false
 }
 
+func (m *IdemixMSPSignerConfig) GetCredentialRevocationInformation() []byte {
+	if m != nil {
+		return m.CredentialRevocationInformation
+	}
+	return nil
+}
+
 // SigningIdentityInfo represents the configuration information
 // related to the signing identity the peer is to use for generating
 // endorsements
@@ -455,54 +483,58 @@ func init() {
 func init() { proto.RegisterFile("msp/msp_config.proto", fileDescriptor1) }
 
 var fileDescriptor1 = []byte{
-	// 778 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x55, 0xcb, 0x6e, 0xdb, 0x46,
-	0x14, 0x85, 0x1e, 0x96, 0xa5, 0x2b, 0x4a, 0x72, 0xc7, 0x8f, 0xb2, 0x45, 0xed, 0xca, 0x6c, 0x8b,
-	0x6a, 0x53, 0x09, 0x90, 0x0b, 0xb4, 0x8b, 0x6e, 0x6a, 0xb5, 0x4e, 0x88, 0xc4, 0xb1, 0x41, 0xc1,
-	0x9b, 0x6c, 0x88, 0x11, 0x39, 0xa2, 0x06, 0xe2, 0x0b, 0x33, 0x23, 0x23, 0x0a, 0xb2, 0xc8, 0x3f,
-	0x64, 0x9b, 0x6f, 0xc8, 0x37, 0x06, 0xf3, 0x90, 0x4c, 0xd9, 0x8a, 0x92, 0xdd, 0xcc, 0xbd, 0xe7,
-	0x9c, 0xb9, 0x3c, 0xf7, 0xce, 0x10, 0x8e, 0x12, 0x9e, 0x0f, 0x12, 0x9e, 0xfb, 0x41, 0x96, 0x4e,
-	0x69, 0xd4, 0xcf, 0x59, 0x26, 0x32, 0x54, 0x49, 0x78, 0xee, 0xfc, 0x05, 0x8d, 0xeb, 0xf1, 0xed,
-	0x48, 0xc5, 0x11, 0x82, 0xaa, 0x58, 0xe6, 0xc4, 0x2e, 0x75, 0x4b, 0xbd, 0x3d, 0x4f, 0xad, 0xd1,
-	0x09, 0xd4, 0x34, 0xcb, 0x2e, 0x77, 0x4b, 0x3d, 0xcb, 0x33, 0x3b, 0xe7, 0x53, 0x15, 0x3a, 0x57,
-	0x78, 0xc2, 0x68, 0xb0, 0xc1, 0x4f, 0x71, 0xa2, 0xf9, 0x0d, 0x4f, 0xad, 0xd1, 0x29, 0x00, 0xcb,
-	0x32, 0xe1, 0x07, 0x84, 0x09, 0x6e, 0x97, 0xbb, 0x95, 0x9e, 0xe5, 0x35, 0x64, 0x64, 0x24, 0x03,
-	0xe8, 0x0f, 0x40, 0x34, 0x15, 0x84, 0x25, 0x24, 0xa4, 0x58, 0x10, 0x03, 0xab, 0x28, 0xd8, 0x77,
-	0xc5, 0x8c, 0x86, 0x9f, 0x40, 0x0d, 0x87, 0x09, 0x4d, 0xb9, 0x5d, 0x55, 0x10, 0xb3, 0x43, 0xbf,
-	0x43, 0x87, 0x91, 0xfb, 0x2c, 0xc0, 0x82, 0x66, 0xa9, 0x1f, 0x53, 0x2e, 0xec, 0x3d, 0x05, 0x68,
-	0x3f, 0x84, 0x5f, 0x52, 0x2e, 0xd0, 0x08, 0x0e, 0x38, 0x8d, 0x52, 0x9a, 0x46, 0x3e, 0x0d, 0x49,
-	0x2a, 0xa8, 0x58, 0xda, 0xb5, 0x6e, 0xa9, 0xd7, 0x1c, 0xda, 0xfd, 0x84, 0xe7, 0xfd, 0xb1, 0x4e,
-	0xba, 0x26, 0xe7, 0xa6, 0xd3, 0xcc, 0xeb, 0xf0, 0xcd, 0x20, 0xf2, 0xe1, 0xe7, 0x8c, 0x45, 0x38,
-	0xa5, 0x6f, 0x95, 0x30, 0x8e, 0xfd, 0x45, 0x4a, 0x85, 0x11, 0x9c, 0x52, 0xc2, 0xb8, 0xbd, 0xdf,
-	0xad, 0xf4, 0x9a, 0xc3, 0xef, 0x95, 0xa6, 0xb6, 0xe9, 0xe6, 0xce, 0x5d, 0xe7, 0xbd, 0xd3, 0x4d,
-	0xfe, 0x5d, 0x4a, 0xc5, 0x43, 0x96, 0xa3, 0x7f, 0xa0, 0x15, 0xb0, 0x65, 0x2e, 0x32, 0xd3, 0x31,
-	0xbb, 0xae, 0x4a, 0x2c, 0xca, 0x8d, 0x54, 0x5e, 0x1b, 0xef, 0x59, 0x41, 0x61, 0x87, 0x7e, 0x85,
-	0xb6, 0x88, 0xb9, 0x5f, 0xb0, 0xbd, 0xa1, 0xbc, 0xb0, 0x44, 0xcc, 0xbd, 0xb5, 0xf3, 0x7f, 0xc2,
-	0x89, 0x44, 0x6d, 0x71, 0x1f, 0x14, 0xfa, 0x48, 0xc4, 0xdc, 0x7d, 0xd2, 0x80, 0xbf, 0xa1, 0xa5,
-	0xcf, 0x7f, 0x95, 0x85, 0xe4, 0xe6, 0x8e, 0xdb, 0x4d, 0x55, 0x19, 0x2a, 0x54, 0x66, 0x32, 0xde,
-	0x26, 0xd0, 0xf9, 0x50, 0x02, 0xf4, 0xb4, 0x74, 0x34, 0x84, 0x63, 0x69, 0x2f, 0x16, 0x0b, 0x46,
-	0xfc, 0x19, 0xe6, 0x33, 0x7f, 0x8a, 0x13, 0x1a, 0x2f, 0xcd, 0x10, 0x1d, 0xae, 0x93, 0xcf, 0x31,
-	0x9f, 0x5d, 0xa9, 0x14, 0x72, 0xe1, 0x7c, 0xd5, 0xbc, 0x82, 0xe9, 0x86, 0xbd, 0x48, 0x03, 0x69,
-	0xaa, 0x1a, 0xd7, 0x86, 0x77, 0xb6, 0x02, 0x3e, 0xd8, 0xab, 0x84, 0x0c, 0xca, 0x99, 0x43, 0xc7,
-	0x0d, 0x49, 0x42, 0xdf, 0xec, 0x9e, 0xe2, 0x03, 0xa8, 0xb8, 0xb7, 0x73, 0x73, 0x05, 0xe4, 0x12,
-	0x0d, 0xa1, 0x26, 0x4b, 0x23, 0xcc, 0xae, 0x28, 0x07, 0x7e, 0x54, 0x0e, 0xac, 0xb5, 0xc6, 0x2a,
-	0x67, 0xda, 0x63, 0x90, 0xce, 0xc7, 0x12, 0x1c, 0x6f, 0x45, 0xc8, 0x33, 0x47, 0x8c, 0x84, 0xea,
-	0x4c, 0xcb, 0x53, 0x6b, 0xd4, 0x86, 0xf2, 0x78, 0x75, 0x64, 0x79, 0x3c, 0x47, 0xff, 0xc1, 0xd9,
-	0xee, 0xa9, 0x53, 0x95, 0x34, 0xbc, 0x9f, 0x76, 0xcd, 0x16, 0xfa, 0x01, 0xea, 0x94, 0xfb, 0xea,
-	0xda, 0xd8, 0xd5, 0x6e, 0xa9, 0x57, 0xf7, 0xf6, 0x29, 0xff, 0x57, 0x6e, 0x9d, 0x0c, 0x0e, 0xb7,
-	0x8c, 0x3f, 0xfa, 0x05, 0x5a, 0xf9, 0x62, 0x12, 0xd3, 0xc0, 0x37, 0x1f, 0xac, 0x8b, 0xb4, 0x74,
-	0x50, 0x7f, 0x06, 0xba, 0x80, 0x76, 0xce, 0xe8, 0xbd, 0x1c, 0x22, 0x83, 0x2a, 0x2b, 0x5b, 0x2c,
-	0x65, 0xcb, 0x0b, 0xa2, 0x6f, 0x52, 0xcb, 0x60, 0x34, 0xc9, 0x19, 0xc3, 0xbe, 0xc9, 0xa0, 0xdf,
-	0xa0, 0x3d, 0x27, 0xc5, 0x6e, 0x1a, 0xfb, 0x5b, 0x73, 0x52, 0x68, 0x1d, 0x3a, 0x07, 0x4b, 0xc2,
-	0x12, 0x2c, 0x08, 0xa3, 0x38, 0x36, 0xee, 0x34, 0xe7, 0x64, 0x79, 0x6d, 0x42, 0xce, 0xbb, 0xd5,
-	0x98, 0x15, 0x2f, 0x1c, 0xea, 0x42, 0x53, 0x0e, 0x37, 0x9d, 0xd2, 0x00, 0x0b, 0x62, 0x3e, 0xa1,
-	0x18, 0xfa, 0x06, 0x7b, 0xcb, 0x5f, 0xb7, 0xd7, 0x79, 0x5f, 0x7e, 0x74, 0x41, 0xe4, 0x93, 0xf5,
-	0x7f, 0x8a, 0x27, 0xb1, 0x3e, 0xb4, 0xee, 0x99, 0x1d, 0x7a, 0x06, 0x28, 0x88, 0x29, 0x49, 0x45,
-	0xb1, 0x4e, 0xe3, 0xda, 0x17, 0xdf, 0x8d, 0x2d, 0x14, 0xf9, 0xa4, 0xe5, 0x84, 0xb0, 0x0d, 0x99,
-	0xca, 0x6e, 0x99, 0x27, 0x04, 0xe4, 0xc2, 0x61, 0xc6, 0x42, 0xc2, 0x1e, 0xe9, 0x54, 0x77, 0xeb,
-	0x6c, 0xe3, 0x5c, 0xfa, 0x70, 0x9e, 0xb1, 0xa8, 0x3f, 0x5b, 0xe6, 0x84, 0xc5, 0x24, 0x8c, 0x08,
-	0xeb, 0x4f, 0x15, 0x55, 0xff, 0x77, 0xb8, 0x14, 0xbb, 0x3c, 0xb8, 0xe6, 0xb9, 0x9e, 0xfd, 0x5b,
-	0x1c, 0xcc, 0x71, 0x44, 0x5e, 0xf7, 0x22, 0x2a, 0x66, 0x8b, 0x49, 0x3f, 0xc8, 0x92, 0x41, 0x81,
-	0x3b, 0xd0, 0xdc, 0x81, 0xe6, 0xca, 0xbf, 0xd8, 0xa4, 0xa6, 0xd6, 0x17, 0x9f, 0x03, 0x00, 0x00,
-	0xff, 0xff, 0x0c, 0x9d, 0x3e, 0x36, 0xd7, 0x06, 0x00, 0x00,
+	// 833 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x55, 0xcd, 0x6e, 0x23, 0x45,
+	0x10, 0xd6, 0xd8, 0x8e, 0x13, 0x97, 0xc7, 0x4e, 0xe8, 0x64, 0xc3, 0x80, 0xd8, 0x5d, 0x67, 0x00,
+	0xe1, 0x0b, 0x8e, 0x94, 0x45, 0x82, 0x03, 0x17, 0xd6, 0xb0, 0x30, 0x40, 0xd8, 0xa8, 0xad, 0x5c,
+	0xb8, 0x8c, 0xda, 0xe3, 0xb6, 0xdd, 0xf2, 0x4c, 0xf7, 0xa8, 0xbb, 0x1d, 0x61, 0xc4, 0x81, 0x77,
+	0xe0, 0x1d, 0x38, 0xf2, 0x5c, 0x3c, 0x06, 0xea, 0x9f, 0xd8, 0xe3, 0xd8, 0x32, 0x7b, 0xeb, 0xae,
+	0xfa, 0xbe, 0xcf, 0xd5, 0x5f, 0x55, 0x8d, 0xe1, 0xa2, 0x50, 0xe5, 0x75, 0xa1, 0xca, 0x34, 0x13,
+	0x7c, 0xca, 0x66, 0x83, 0x52, 0x0a, 0x2d, 0x50, 0xbd, 0x50, 0x65, 0xfc, 0x25, 0xb4, 0x6e, 0x47,
+	0x77, 0x43, 0x1b, 0x47, 0x08, 0x1a, 0x7a, 0x55, 0xd2, 0x28, 0xe8, 0x05, 0xfd, 0x23, 0x6c, 0xcf,
+	0xe8, 0x12, 0x9a, 0x8e, 0x15, 0xd5, 0x7a, 0x41, 0x3f, 0xc4, 0xfe, 0x16, 0xff, 0xd3, 0x80, 0xd3,
+	0x37, 0x64, 0x2c, 0x59, 0xb6, 0xc5, 0xe7, 0xa4, 0x70, 0xfc, 0x16, 0xb6, 0x67, 0xf4, 0x1c, 0x40,
+	0x0a, 0xa1, 0xd3, 0x8c, 0x4a, 0xad, 0xa2, 0x5a, 0xaf, 0xde, 0x0f, 0x71, 0xcb, 0x44, 0x86, 0x26,
+	0x80, 0x3e, 0x07, 0xc4, 0xb8, 0xa6, 0xb2, 0xa0, 0x13, 0x46, 0x34, 0xf5, 0xb0, 0xba, 0x85, 0xbd,
+	0x57, 0xcd, 0x38, 0xf8, 0x25, 0x34, 0xc9, 0xa4, 0x60, 0x5c, 0x45, 0x0d, 0x0b, 0xf1, 0x37, 0xf4,
+	0x19, 0x9c, 0x4a, 0xfa, 0x20, 0x32, 0xa2, 0x99, 0xe0, 0x69, 0xce, 0x94, 0x8e, 0x8e, 0x2c, 0xa0,
+	0xbb, 0x09, 0xff, 0xcc, 0x94, 0x46, 0x43, 0x38, 0x53, 0x6c, 0xc6, 0x19, 0x9f, 0xa5, 0x6c, 0x42,
+	0xb9, 0x66, 0x7a, 0x15, 0x35, 0x7b, 0x41, 0xbf, 0x7d, 0x13, 0x0d, 0x0a, 0x55, 0x0e, 0x46, 0x2e,
+	0x99, 0xf8, 0x5c, 0xc2, 0xa7, 0x02, 0x9f, 0xaa, 0xed, 0x20, 0x4a, 0xe1, 0xa5, 0x90, 0x33, 0xc2,
+	0xd9, 0xef, 0x56, 0x98, 0xe4, 0xe9, 0x92, 0x33, 0xed, 0x05, 0xa7, 0x8c, 0x4a, 0x15, 0x1d, 0xf7,
+	0xea, 0xfd, 0xf6, 0xcd, 0xfb, 0x56, 0xd3, 0xd9, 0xf4, 0xf6, 0x3e, 0x59, 0xe7, 0xf1, 0xf3, 0x6d,
+	0xfe, 0x3d, 0x67, 0x7a, 0x93, 0x55, 0xe8, 0x6b, 0xe8, 0x64, 0x72, 0x55, 0x6a, 0xe1, 0x3b, 0x16,
+	0x9d, 0xf4, 0x82, 0x27, 0x72, 0x43, 0x9b, 0x77, 0xc6, 0xe3, 0x30, 0xab, 0xdc, 0xd0, 0x27, 0xd0,
+	0xd5, 0xb9, 0x4a, 0x2b, 0xb6, 0xb7, 0xac, 0x17, 0xa1, 0xce, 0x15, 0x5e, 0x3b, 0xff, 0x05, 0x5c,
+	0x1a, 0xd4, 0x1e, 0xf7, 0xc1, 0xa2, 0x2f, 0x74, 0xae, 0x92, 0x9d, 0x06, 0x7c, 0x05, 0x1d, 0xf7,
+	0xfb, 0xbf, 0x88, 0x09, 0x7d, 0x7b, 0xaf, 0xa2, 0xb6, 0xad, 0x0c, 0x55, 0x2a, 0xf3, 0x19, 0xbc,
+	0x0d, 0x8c, 0xff, 0x0a, 0x00, 0xed, 0x96, 0x8e, 0x6e, 0xe0, 0x99, 0xb1, 0x97, 0xe8, 0xa5, 0xa4,
+	0xe9, 0x9c, 0xa8, 0x79, 0x3a, 0x25, 0x05, 0xcb, 0x57, 0x7e, 0x88, 0xce, 0xd7, 0xc9, 0x1f, 0x88,
+	0x9a, 0xbf, 0xb1, 0x29, 0x94, 0xc0, 0xd5, 0x63, 0xf3, 0x2a, 0xa6, 0x7b, 0xf6, 0x92, 0x67, 0xc6,
+	0x54, 0x3b, 0xae, 0x2d, 0xfc, 0xe2, 0x11, 0xb8, 0xb1, 0xd7, 0x0a, 0x79, 0x54, 0xfc, 0x77, 0x00,
+	0xa7, 0xc9, 0x84, 0x16, 0xec, 0xb7, 0xc3, 0x63, 0x7c, 0x06, 0xf5, 0xe4, 0x6e, 0xe1, 0x77, 0xc0,
+	0x1c, 0xd1, 0x0d, 0x34, 0x4d, 0x6d, 0x54, 0x46, 0x75, 0x6b, 0xc1, 0x87, 0xd6, 0x82, 0xb5, 0xd6,
+	0xc8, 0xe6, 0x7c, 0x7f, 0x3c, 0x12, 0x7d, 0x0c, 0x9d, 0xca, 0x98, 0x96, 0x8b, 0xa8, 0x61, 0xf5,
+	0xc2, 0x4d, 0xf0, 0x6e, 0x81, 0x2e, 0xe0, 0x88, 0x96, 0x22, 0x9b, 0x47, 0x47, 0xbd, 0xa0, 0x5f,
+	0xc7, 0xee, 0x12, 0xff, 0x1b, 0xc0, 0xb3, 0xbd, 0xe2, 0xa6, 0xdc, 0xa1, 0xa4, 0x13, 0x5b, 0x6e,
+	0x88, 0xed, 0x19, 0x75, 0xa1, 0x36, 0x7a, 0xac, 0xb6, 0x36, 0x5a, 0xa0, 0x6f, 0xe1, 0xc5, 0xe1,
+	0x89, 0xb5, 0x8f, 0x68, 0xe1, 0x8f, 0x0e, 0xcd, 0x25, 0xfa, 0x00, 0x4e, 0x98, 0x4a, 0xed, 0xca,
+	0xd9, 0xca, 0x4f, 0xf0, 0x31, 0x53, 0xdf, 0x98, 0x2b, 0xfa, 0x11, 0xae, 0x32, 0x49, 0x2d, 0x94,
+	0xe4, 0x69, 0xe5, 0x91, 0x8c, 0x4f, 0x85, 0x2c, 0xec, 0xd9, 0x3e, 0x28, 0xc4, 0x2f, 0x37, 0x40,
+	0xbc, 0xc6, 0x25, 0x1b, 0x58, 0x2c, 0xe0, 0x7c, 0xcf, 0x1a, 0x1a, 0xf3, 0xca, 0xe5, 0x38, 0x67,
+	0x59, 0xea, 0x7d, 0x77, 0x0f, 0x0e, 0x5d, 0xd0, 0x59, 0x82, 0x5e, 0x41, 0xb7, 0x94, 0xec, 0xc1,
+	0x0c, 0xb3, 0x47, 0xd5, 0x6c, 0x77, 0x42, 0xdb, 0x9d, 0x9f, 0xa8, 0xdb, 0xe8, 0x8e, 0xc7, 0x38,
+	0x52, 0x3c, 0x82, 0x63, 0x9f, 0x41, 0x9f, 0x42, 0x77, 0x41, 0xab, 0x53, 0xe5, 0xa7, 0xa0, 0xb3,
+	0xa0, 0x95, 0x11, 0x42, 0x57, 0x10, 0x1a, 0x58, 0x41, 0x34, 0x95, 0x8c, 0xe4, 0xde, 0xe9, 0xf6,
+	0x82, 0xae, 0x6e, 0x7d, 0x28, 0xfe, 0x03, 0xd0, 0xee, 0xe2, 0xa3, 0x1e, 0xb4, 0xcd, 0x92, 0xb1,
+	0x29, 0xcb, 0x88, 0xa6, 0xfe, 0x09, 0xd5, 0xd0, 0x3b, 0xb4, 0xaa, 0xf6, 0xff, 0xad, 0x8a, 0xff,
+	0xac, 0x3d, 0x59, 0x54, 0xf3, 0xe9, 0xfc, 0x8e, 0x93, 0x71, 0xee, 0x7e, 0xf4, 0x04, 0xfb, 0x1b,
+	0xfa, 0x1e, 0x50, 0x96, 0x33, 0xca, 0x75, 0xb5, 0xce, 0xa8, 0xb6, 0xf3, 0xc1, 0xa9, 0xa6, 0xf1,
+	0x1e, 0x8a, 0xf9, 0xb4, 0x96, 0x94, 0xca, 0x2d, 0x99, 0xfa, 0x61, 0x99, 0x1d, 0x02, 0x4a, 0xe0,
+	0x5c, 0xc8, 0x09, 0x95, 0x4f, 0x74, 0x1a, 0x87, 0x75, 0xf6, 0x71, 0x5e, 0xa7, 0x70, 0x25, 0xe4,
+	0x6c, 0x30, 0x5f, 0x95, 0x54, 0xe6, 0x74, 0x32, 0xa3, 0x72, 0x30, 0xb5, 0x54, 0xf7, 0xff, 0xa7,
+	0x8c, 0xd8, 0xeb, 0xb3, 0x5b, 0x55, 0xba, 0x3d, 0xba, 0x23, 0xd9, 0x82, 0xcc, 0xe8, 0xaf, 0xfd,
+	0x19, 0xd3, 0xf3, 0xe5, 0x78, 0x90, 0x89, 0xe2, 0xba, 0xc2, 0xbd, 0x76, 0xdc, 0x6b, 0xc7, 0x35,
+	0xff, 0xa6, 0xe3, 0xa6, 0x3d, 0xbf, 0xfa, 0x6f, 0x00, 0x0a, 0x70, 0x1d, 0x88, 0x5f, 0x07, 0x00,
+	0x00,
 }
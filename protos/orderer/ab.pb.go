@@ -22,6 +22,8 @@ It has these top-level messages:
 	BatchTimeout
 	KafkaBrokers
 	ChannelRestrictions
+	RateLimits
+	RateLimit
 	KafkaMessage
 	KafkaMessageRegular
 	KafkaMessageTimeToCut
@@ -77,6 +79,9 @@ type BroadcastResponse struct {
 	Status common.Status `protobuf:"varint,1,opt,name=status,enum=common.Status" json:"status,omitempty"`
 	// Info string which may contain additional information about the status returned
 	Info string `protobuf:"bytes,2,opt,name=info" json:"info,omitempty"`
+	// The number of milliseconds to wait before retrying, set when the message
+	// was rejected with SERVICE_UNAVAILABLE because its sender exceeded its rate
+	RetryAfterMs uint64 `protobuf:"varint,3,opt,name=retry_after_ms,json=retryAfterMs" json:"retry_after_ms,omitempty"`
 }
 
 func (m *BroadcastResponse) Reset()                    { *m = BroadcastResponse{} }
@@ -98,6 +103,13 @@ func (m *BroadcastResponse) GetInfo() string {
 	return ""
 }
 
+func (m *BroadcastResponse) GetRetryAfterMs() uint64 {
+	if m != nil {
+		return m.RetryAfterMs
+	}
+	return 0
+}
+
 type SeekNewest struct {
 }
 
@@ -625,37 +637,38 @@ var _AtomicBroadcast_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("orderer/ab.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 505 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x93, 0xdf, 0x6e, 0x12, 0x51,
-	0x10, 0xc6, 0x59, 0xa5, 0xb4, 0x8c, 0x2d, 0xa5, 0xa7, 0x69, 0x43, 0xb8, 0x30, 0xcd, 0x26, 0x55,
-	0x8c, 0xba, 0x6b, 0x30, 0xf1, 0x42, 0x4d, 0x0c, 0x6b, 0xdb, 0x40, 0x24, 0x60, 0x16, 0x7a, 0xa1,
-	0x37, 0x64, 0x77, 0x19, 0x60, 0xed, 0xb2, 0x67, 0x73, 0xce, 0x01, 0xd3, 0xa7, 0xf0, 0x45, 0x7c,
-	0x24, 0x1f, 0xc6, 0x9c, 0x3f, 0xbb, 0x48, 0xd5, 0x5e, 0xb1, 0x33, 0xf3, 0xfb, 0xce, 0x7c, 0x33,
-	0x19, 0xa0, 0x4e, 0xd9, 0x14, 0x19, 0x32, 0x37, 0x08, 0x9d, 0x8c, 0x51, 0x41, 0xc9, 0xae, 0xc9,
-	0x34, 0x8f, 0x23, 0xba, 0x5c, 0xd2, 0xd4, 0xd5, 0x3f, 0xba, 0x6a, 0x0f, 0xe1, 0xc8, 0x63, 0x34,
-	0x98, 0x46, 0x01, 0x17, 0x3e, 0xf2, 0x8c, 0xa6, 0x1c, 0xc9, 0x13, 0xa8, 0x70, 0x11, 0x88, 0x15,
-	0x6f, 0x58, 0x67, 0x56, 0xab, 0xd6, 0xae, 0x39, 0x46, 0x33, 0x52, 0x59, 0xdf, 0x54, 0x09, 0x81,
-	0x72, 0x9c, 0xce, 0x68, 0xe3, 0xc1, 0x99, 0xd5, 0xaa, 0xfa, 0xea, 0xdb, 0xde, 0x07, 0x18, 0x21,
-	0xde, 0x0c, 0xf0, 0x3b, 0x72, 0x91, 0x47, 0xc3, 0x64, 0x2a, 0xa3, 0xa7, 0x70, 0x20, 0xa3, 0x51,
-	0x86, 0x51, 0x3c, 0x8b, 0x71, 0x4a, 0x4e, 0xa1, 0x92, 0xae, 0x96, 0x21, 0x32, 0xd5, 0xa8, 0xec,
-	0x9b, 0xc8, 0xfe, 0x69, 0xc1, 0xbe, 0x24, 0x3f, 0x53, 0x1e, 0x8b, 0x98, 0xa6, 0xe4, 0x25, 0x54,
-	0x52, 0xf5, 0xa2, 0x02, 0x1f, 0xb5, 0x8f, 0x1d, 0x33, 0x95, 0xb3, 0x69, 0xd6, 0x2d, 0xf9, 0x06,
-	0x92, 0x38, 0x55, 0x2d, 0x95, 0xb5, 0xbb, 0xb8, 0x76, 0x23, 0x71, 0x0d, 0x91, 0x37, 0x50, 0xe5,
-	0xb9, 0xa7, 0xc6, 0x43, 0xa5, 0x38, 0xdd, 0x52, 0x14, 0x8e, 0xbb, 0x25, 0x7f, 0x83, 0x7a, 0x15,
-	0x28, 0x8f, 0x6f, 0x33, 0xb4, 0x7f, 0x59, 0xb0, 0x27, 0xb1, 0x5e, 0x3a, 0xa3, 0xe4, 0x39, 0xec,
-	0x70, 0x11, 0xb0, 0xdc, 0xe9, 0xc9, 0xd6, 0x43, 0xf9, 0x40, 0xbe, 0x66, 0xc8, 0x33, 0x28, 0x73,
-	0x41, 0x33, 0x63, 0xf3, 0x3f, 0xac, 0x42, 0xc8, 0x5b, 0xd8, 0x0b, 0x71, 0x11, 0xac, 0x63, 0xca,
-	0x94, 0xc7, 0x5a, 0xfb, 0xf1, 0x16, 0x2e, 0x9b, 0xab, 0x0f, 0xcf, 0x50, 0x7e, 0xc1, 0xdb, 0xef,
-	0xf5, 0x3a, 0xf3, 0x0a, 0x39, 0x81, 0x23, 0xaf, 0x3f, 0xfc, 0xf8, 0x69, 0x72, 0x3d, 0x18, 0xf7,
-	0xfa, 0x13, 0xff, 0xb2, 0x73, 0xf1, 0xa5, 0x5e, 0x92, 0xe9, 0xab, 0x4e, 0xaf, 0x3f, 0xe9, 0x5d,
-	0x4d, 0x06, 0xc3, 0xb1, 0x49, 0x5b, 0xf6, 0x37, 0x38, 0xbc, 0xc0, 0x24, 0x5e, 0x23, 0x2b, 0x2e,
-	0xa4, 0x75, 0xff, 0x85, 0xc8, 0xdd, 0x9a, 0x1b, 0x39, 0x87, 0x9d, 0x30, 0xa1, 0xd1, 0x8d, 0x19,
-	0xf1, 0x20, 0x07, 0x3d, 0x99, 0xec, 0x96, 0x7c, 0x5d, 0xcd, 0x57, 0xd9, 0xfe, 0x61, 0xc1, 0x61,
-	0x47, 0xd0, 0x65, 0x1c, 0x15, 0x67, 0x49, 0x3e, 0x40, 0x75, 0x13, 0xd4, 0xf3, 0x07, 0x2e, 0xd3,
-	0x35, 0x26, 0x34, 0xc3, 0x66, 0xb3, 0x58, 0xc3, 0x5f, 0x97, 0x6c, 0x97, 0x5a, 0xd6, 0x2b, 0x8b,
-	0xbc, 0x83, 0x5d, 0x33, 0xc0, 0x3f, 0xe4, 0x8d, 0x42, 0x7e, 0x67, 0x48, 0x2d, 0xf6, 0xae, 0xe1,
-	0x9c, 0xb2, 0xb9, 0xb3, 0xb8, 0xcd, 0x90, 0x25, 0x38, 0x9d, 0x23, 0x73, 0x66, 0x41, 0xc8, 0xe2,
-	0x48, 0xff, 0x83, 0x78, 0x2e, 0xff, 0xfa, 0x62, 0x1e, 0x8b, 0xc5, 0x2a, 0x94, 0x0d, 0xdc, 0x3f,
-	0x68, 0x57, 0xd3, 0xae, 0xa6, 0x5d, 0x43, 0x87, 0x15, 0x15, 0xbf, 0xfe, 0x1d, 0x00, 0x00, 0xff,
-	0xff, 0x4b, 0x88, 0xa4, 0x39, 0xb1, 0x03, 0x00, 0x00,
+	// 526 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x93, 0xdd, 0x6e, 0x12, 0x41,
+	0x14, 0xc7, 0x59, 0xa4, 0xb4, 0x9c, 0x52, 0x4a, 0xa7, 0x69, 0xb3, 0xe1, 0xc2, 0x90, 0x8d, 0x55,
+	0x8c, 0xba, 0x6b, 0x30, 0xf1, 0x42, 0x4d, 0x0c, 0x6b, 0xdb, 0x40, 0x44, 0x30, 0x03, 0xbd, 0xd0,
+	0x9b, 0xcd, 0xee, 0x32, 0xc0, 0x5a, 0xd8, 0xd9, 0xcc, 0x0c, 0x18, 0x9e, 0xc2, 0x17, 0xf1, 0x91,
+	0x7c, 0x18, 0x33, 0xb3, 0xb3, 0x4b, 0xd1, 0xa6, 0x57, 0x3b, 0xe7, 0x7f, 0x7e, 0xe7, 0x33, 0x67,
+	0xa1, 0x4e, 0xd9, 0x84, 0x30, 0xc2, 0x1c, 0x3f, 0xb0, 0x13, 0x46, 0x05, 0x45, 0xfb, 0x5a, 0x69,
+	0x9c, 0x86, 0x74, 0xb9, 0xa4, 0xb1, 0x93, 0x7e, 0x52, 0xaf, 0xb5, 0x82, 0x13, 0x97, 0x51, 0x7f,
+	0x12, 0xfa, 0x5c, 0x60, 0xc2, 0x13, 0x1a, 0x73, 0x82, 0x9e, 0x42, 0x99, 0x0b, 0x5f, 0xac, 0xb8,
+	0x69, 0x34, 0x8d, 0x56, 0xad, 0x5d, 0xb3, 0x75, 0xcc, 0x48, 0xa9, 0x58, 0x7b, 0x11, 0x82, 0x52,
+	0x14, 0x4f, 0xa9, 0x59, 0x6c, 0x1a, 0xad, 0x0a, 0x56, 0x6f, 0xf4, 0x04, 0x6a, 0x8c, 0x08, 0xb6,
+	0xf1, 0xfc, 0xa9, 0x20, 0xcc, 0x5b, 0x72, 0xf3, 0x51, 0xd3, 0x68, 0x95, 0x70, 0x55, 0xa9, 0x1d,
+	0x29, 0x7e, 0xe1, 0x56, 0x15, 0x60, 0x44, 0xc8, 0xed, 0x80, 0xfc, 0x24, 0x5c, 0x64, 0xd6, 0x70,
+	0x31, 0x91, 0xd6, 0x33, 0x38, 0x92, 0xd6, 0x28, 0x21, 0x61, 0x34, 0x8d, 0xc8, 0x04, 0x9d, 0x43,
+	0x39, 0x5e, 0x2d, 0x03, 0xc2, 0x54, 0x3b, 0x25, 0xac, 0x2d, 0xeb, 0xb7, 0x01, 0x55, 0x49, 0x7e,
+	0xa5, 0x3c, 0x12, 0x11, 0x8d, 0xd1, 0x2b, 0x28, 0xc7, 0x2a, 0xa3, 0x02, 0x0f, 0xdb, 0xa7, 0xb6,
+	0x9e, 0xdd, 0xde, 0x16, 0xeb, 0x16, 0xb0, 0x86, 0x24, 0x4e, 0x55, 0x49, 0xb3, 0x78, 0x0f, 0x9e,
+	0x76, 0x23, 0xf1, 0x14, 0x42, 0x6f, 0xa1, 0xc2, 0xb3, 0x9e, 0xd4, 0x50, 0x87, 0xed, 0xf3, 0x9d,
+	0x88, 0xbc, 0xe3, 0x6e, 0x01, 0x6f, 0x51, 0xb7, 0x0c, 0xa5, 0xf1, 0x26, 0x21, 0xd6, 0x1f, 0x03,
+	0x0e, 0x24, 0xd6, 0x93, 0x6b, 0x7a, 0x01, 0x7b, 0x5c, 0xf8, 0x2c, 0xeb, 0xf4, 0x6c, 0x27, 0x51,
+	0x36, 0x10, 0x4e, 0x19, 0xf4, 0x1c, 0x4a, 0x5c, 0xd0, 0xc4, 0x2c, 0x3e, 0xc4, 0x2a, 0x04, 0xbd,
+	0x83, 0x83, 0x80, 0xcc, 0xfd, 0x75, 0x44, 0x99, 0xea, 0xb1, 0xd6, 0x7e, 0xbc, 0x83, 0xcb, 0xe2,
+	0xea, 0xe1, 0x6a, 0x0a, 0xe7, 0xbc, 0xf5, 0x01, 0xaa, 0x77, 0x3d, 0xe8, 0x0c, 0x4e, 0xdc, 0xfe,
+	0xf0, 0xd3, 0x67, 0xef, 0x66, 0x30, 0xee, 0xf5, 0x3d, 0x7c, 0xd5, 0xb9, 0xfc, 0x56, 0x2f, 0x48,
+	0xf9, 0xba, 0xd3, 0xeb, 0x7b, 0xbd, 0x6b, 0x6f, 0x30, 0x1c, 0x6b, 0xd9, 0xb0, 0x7e, 0xc0, 0xf1,
+	0x25, 0x59, 0x44, 0x6b, 0xc2, 0xf2, 0x3b, 0x6a, 0x3d, 0x7c, 0x47, 0x72, 0xb7, 0xfa, 0x92, 0x2e,
+	0x60, 0x2f, 0x58, 0xd0, 0xf0, 0x56, 0x8f, 0x78, 0x94, 0x81, 0xae, 0x14, 0xbb, 0x05, 0x9c, 0x7a,
+	0xb3, 0x55, 0xb6, 0x7f, 0x19, 0x70, 0xdc, 0x11, 0x74, 0x19, 0x85, 0xf9, 0xf1, 0xa2, 0x8f, 0x50,
+	0xd9, 0x1a, 0xf5, 0x2c, 0xc1, 0x55, 0xbc, 0x26, 0x0b, 0x9a, 0x90, 0x46, 0x23, 0x5f, 0xc3, 0x7f,
+	0xf7, 0x6e, 0x15, 0x5a, 0xc6, 0x6b, 0x03, 0xbd, 0x87, 0x7d, 0x3d, 0xc0, 0x3d, 0xe1, 0x66, 0x1e,
+	0xfe, 0xcf, 0x90, 0x69, 0xb0, 0x7b, 0x03, 0x17, 0x94, 0xcd, 0xec, 0xf9, 0x26, 0x21, 0x6c, 0x41,
+	0x26, 0x33, 0xc2, 0xec, 0xa9, 0x1f, 0xb0, 0x28, 0x4c, 0xff, 0x33, 0x9e, 0x85, 0x7f, 0x7f, 0x39,
+	0x8b, 0xc4, 0x7c, 0x15, 0xc8, 0x02, 0xce, 0x1d, 0xda, 0x49, 0x69, 0x27, 0xa5, 0x1d, 0x4d, 0x07,
+	0x65, 0x65, 0xbf, 0xf9, 0x3b, 0x00, 0x27, 0xc9, 0x0c, 0x99, 0xd7, 0x03, 0x00, 0x00,
 }
@@ -0,0 +1,490 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: orderer/bft/bft.proto
+
+package bft
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+import common "github.com/hyperledger/fabric/protos/common"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+type MessageType int32
+
+const (
+	MessageType_REQUEST       MessageType = 0
+	MessageType_PRE_PREPARE   MessageType = 1
+	MessageType_PREPARE       MessageType = 2
+	MessageType_COMMIT        MessageType = 3
+	MessageType_VIEW_CHANGE   MessageType = 4
+	MessageType_NEW_VIEW      MessageType = 5
+	MessageType_SYNC_REQUEST  MessageType = 6
+	MessageType_SYNC_RESPONSE MessageType = 7
+)
+
+var MessageType_name = map[int32]string{
+	0: "REQUEST",
+	1: "PRE_PREPARE",
+	2: "PREPARE",
+	3: "COMMIT",
+	4: "VIEW_CHANGE",
+	5: "NEW_VIEW",
+	6: "SYNC_REQUEST",
+	7: "SYNC_RESPONSE",
+}
+var MessageType_value = map[string]int32{
+	"REQUEST":       0,
+	"PRE_PREPARE":   1,
+	"PREPARE":       2,
+	"COMMIT":        3,
+	"VIEW_CHANGE":   4,
+	"NEW_VIEW":      5,
+	"SYNC_REQUEST":  6,
+	"SYNC_RESPONSE": 7,
+}
+
+func (x MessageType) String() string {
+	return proto.EnumName(MessageType_name, int32(x))
+}
+func (MessageType) EnumDescriptor() ([]byte, []int) { return fileDescriptor1, []int{0} }
+
+type StepRequest struct {
+	Channel string         `protobuf:"bytes,1,opt,name=channel" json:"channel,omitempty"`
+	Message *SignedMessage `protobuf:"bytes,2,opt,name=message" json:"message,omitempty"`
+}
+
+func (m *StepRequest) Reset()                    { *m = StepRequest{} }
+func (m *StepRequest) String() string            { return proto.CompactTextString(m) }
+func (*StepRequest) ProtoMessage()               {}
+func (*StepRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{0} }
+
+func (m *StepRequest) GetChannel() string {
+	if m != nil {
+		return m.Channel
+	}
+	return ""
+}
+
+func (m *StepRequest) GetMessage() *SignedMessage {
+	if m != nil {
+		return m.Message
+	}
+	return nil
+}
+
+type StepResponse struct {
+}
+
+func (m *StepResponse) Reset()                    { *m = StepResponse{} }
+func (m *StepResponse) String() string            { return proto.CompactTextString(m) }
+func (*StepResponse) ProtoMessage()               {}
+func (*StepResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{1} }
+
+// SignedMessage carries a serialized Message along with the signature of the consenter that sent it
+type SignedMessage struct {
+	Message   []byte `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Signature []byte `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+}
+
+func (m *SignedMessage) Reset()                    { *m = SignedMessage{} }
+func (m *SignedMessage) String() string            { return proto.CompactTextString(m) }
+func (*SignedMessage) ProtoMessage()               {}
+func (*SignedMessage) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{2} }
+
+func (m *SignedMessage) GetMessage() []byte {
+	if m != nil {
+		return m.Message
+	}
+	return nil
+}
+
+func (m *SignedMessage) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// Message is a BFT protocol message
+type Message struct {
+	Type MessageType `protobuf:"varint,1,opt,name=type,enum=bft.MessageType" json:"type,omitempty"`
+	// The number of the consenter that sent the message
+	From uint64 `protobuf:"varint,2,opt,name=from" json:"from,omitempty"`
+	View uint64 `protobuf:"varint,3,opt,name=view" json:"view,omitempty"`
+	// The number of the block the message is about. For VIEW_CHANGE, the height
+	// of the ledger of the sender. For SYNC_REQUEST, the first block requested
+	Sequence uint64 `protobuf:"varint,4,opt,name=sequence" json:"sequence,omitempty"`
+	// For PREPARE and COMMIT, the hash of the header of the block
+	Digest []byte `protobuf:"bytes,5,opt,name=digest,proto3" json:"digest,omitempty"`
+	// For REQUEST, the transaction or config update to be ordered
+	Request *Request `protobuf:"bytes,6,opt,name=request" json:"request,omitempty"`
+	// For PRE_PREPARE, the proposed block. For VIEW_CHANGE, the last block
+	// in the ledger of the sender, stripped of its data
+	Block *common.Block `protobuf:"bytes,7,opt,name=block" json:"block,omitempty"`
+	// For COMMIT, the signature of the sender on the header of the block
+	BlockSignature *common.MetadataSignature `protobuf:"bytes,8,opt,name=block_signature,json=blockSignature" json:"block_signature,omitempty"`
+	// For VIEW_CHANGE, the block prepared by the sender at the height of its ledger, if any
+	Prepared *PreparedCertificate `protobuf:"bytes,9,opt,name=prepared" json:"prepared,omitempty"`
+	// For NEW_VIEW, the VIEW_CHANGE messages of a quorum of consenters. For SYNC_RESPONSE,
+	// the NEW_VIEW that installed the view of the sender, if the requester is in a lower view
+	ViewChanges []*SignedMessage `protobuf:"bytes,10,rep,name=view_changes,json=viewChanges" json:"view_changes,omitempty"`
+	// For SYNC_RESPONSE, the blocks requested
+	Blocks []*common.Block `protobuf:"bytes,11,rep,name=blocks" json:"blocks,omitempty"`
+}
+
+func (m *Message) Reset()                    { *m = Message{} }
+func (m *Message) String() string            { return proto.CompactTextString(m) }
+func (*Message) ProtoMessage()               {}
+func (*Message) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{3} }
+
+func (m *Message) GetType() MessageType {
+	if m != nil {
+		return m.Type
+	}
+	return MessageType_REQUEST
+}
+
+func (m *Message) GetFrom() uint64 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+func (m *Message) GetView() uint64 {
+	if m != nil {
+		return m.View
+	}
+	return 0
+}
+
+func (m *Message) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
+func (m *Message) GetDigest() []byte {
+	if m != nil {
+		return m.Digest
+	}
+	return nil
+}
+
+func (m *Message) GetRequest() *Request {
+	if m != nil {
+		return m.Request
+	}
+	return nil
+}
+
+func (m *Message) GetBlock() *common.Block {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+func (m *Message) GetBlockSignature() *common.MetadataSignature {
+	if m != nil {
+		return m.BlockSignature
+	}
+	return nil
+}
+
+func (m *Message) GetPrepared() *PreparedCertificate {
+	if m != nil {
+		return m.Prepared
+	}
+	return nil
+}
+
+func (m *Message) GetViewChanges() []*SignedMessage {
+	if m != nil {
+		return m.ViewChanges
+	}
+	return nil
+}
+
+func (m *Message) GetBlocks() []*common.Block {
+	if m != nil {
+		return m.Blocks
+	}
+	return nil
+}
+
+type Request struct {
+	// The config sequence at which the content was validated
+	ConfigSeq uint64           `protobuf:"varint,1,opt,name=config_seq,json=configSeq" json:"config_seq,omitempty"`
+	Content   *common.Envelope `protobuf:"bytes,2,opt,name=content" json:"content,omitempty"`
+	IsConfig  bool             `protobuf:"varint,3,opt,name=is_config,json=isConfig" json:"is_config,omitempty"`
+}
+
+func (m *Request) Reset()                    { *m = Request{} }
+func (m *Request) String() string            { return proto.CompactTextString(m) }
+func (*Request) ProtoMessage()               {}
+func (*Request) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{4} }
+
+func (m *Request) GetConfigSeq() uint64 {
+	if m != nil {
+		return m.ConfigSeq
+	}
+	return 0
+}
+
+func (m *Request) GetContent() *common.Envelope {
+	if m != nil {
+		return m.Content
+	}
+	return nil
+}
+
+func (m *Request) GetIsConfig() bool {
+	if m != nil {
+		return m.IsConfig
+	}
+	return false
+}
+
+// PreparedCertificate proves that a quorum of consenters prepared a block in a view
+type PreparedCertificate struct {
+	View  uint64        `protobuf:"varint,1,opt,name=view" json:"view,omitempty"`
+	Block *common.Block `protobuf:"bytes,2,opt,name=block" json:"block,omitempty"`
+	// The PREPARE messages of the quorum
+	Prepares []*SignedMessage `protobuf:"bytes,3,rep,name=prepares" json:"prepares,omitempty"`
+}
+
+func (m *PreparedCertificate) Reset()                    { *m = PreparedCertificate{} }
+func (m *PreparedCertificate) String() string            { return proto.CompactTextString(m) }
+func (*PreparedCertificate) ProtoMessage()               {}
+func (*PreparedCertificate) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{5} }
+
+func (m *PreparedCertificate) GetView() uint64 {
+	if m != nil {
+		return m.View
+	}
+	return 0
+}
+
+func (m *PreparedCertificate) GetBlock() *common.Block {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+func (m *PreparedCertificate) GetPrepares() []*SignedMessage {
+	if m != nil {
+		return m.Prepares
+	}
+	return nil
+}
+
+// ReplicaState is the protocol state that a consenter persists before sending the
+// messages which depend on it, and resumes from when it restarts
+type ReplicaState struct {
+	View uint64 `protobuf:"varint,1,opt,name=view" json:"view,omitempty"`
+	// Whether the consenter is changing to the view, rather than ordering in it
+	ViewChanging bool `protobuf:"varint,2,opt,name=view_changing,json=viewChanging" json:"view_changing,omitempty"`
+	// The NEW_VIEW that installed the view
+	NewView *SignedMessage `protobuf:"bytes,3,opt,name=new_view,json=newView" json:"new_view,omitempty"`
+	// The height of the ledger at which the consenter is ordering the following blocks
+	Sequence uint64 `protobuf:"varint,4,opt,name=sequence" json:"sequence,omitempty"`
+	// The block the consenter prepared in the view, if any
+	Proposal *common.Block `protobuf:"bytes,5,opt,name=proposal" json:"proposal,omitempty"`
+	// The block prepared by a quorum in the highest view, if any
+	Prepared *PreparedCertificate `protobuf:"bytes,6,opt,name=prepared" json:"prepared,omitempty"`
+}
+
+func (m *ReplicaState) Reset()                    { *m = ReplicaState{} }
+func (m *ReplicaState) String() string            { return proto.CompactTextString(m) }
+func (*ReplicaState) ProtoMessage()               {}
+func (*ReplicaState) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{6} }
+
+func (m *ReplicaState) GetView() uint64 {
+	if m != nil {
+		return m.View
+	}
+	return 0
+}
+
+func (m *ReplicaState) GetViewChanging() bool {
+	if m != nil {
+		return m.ViewChanging
+	}
+	return false
+}
+
+func (m *ReplicaState) GetNewView() *SignedMessage {
+	if m != nil {
+		return m.NewView
+	}
+	return nil
+}
+
+func (m *ReplicaState) GetSequence() uint64 {
+	if m != nil {
+		return m.Sequence
+	}
+	return 0
+}
+
+func (m *ReplicaState) GetProposal() *common.Block {
+	if m != nil {
+		return m.Proposal
+	}
+	return nil
+}
+
+func (m *ReplicaState) GetPrepared() *PreparedCertificate {
+	if m != nil {
+		return m.Prepared
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*StepRequest)(nil), "bft.StepRequest")
+	proto.RegisterType((*StepResponse)(nil), "bft.StepResponse")
+	proto.RegisterType((*SignedMessage)(nil), "bft.SignedMessage")
+	proto.RegisterType((*Message)(nil), "bft.Message")
+	proto.RegisterType((*Request)(nil), "bft.Request")
+	proto.RegisterType((*PreparedCertificate)(nil), "bft.PreparedCertificate")
+	proto.RegisterType((*ReplicaState)(nil), "bft.ReplicaState")
+	proto.RegisterEnum("bft.MessageType", MessageType_name, MessageType_value)
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for Cluster service
+
+type ClusterClient interface {
+	// Step passes a signed protocol message to the consenter
+	Step(ctx context.Context, in *StepRequest, opts ...grpc.CallOption) (*StepResponse, error)
+}
+
+type clusterClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewClusterClient(cc *grpc.ClientConn) ClusterClient {
+	return &clusterClient{cc}
+}
+
+func (c *clusterClient) Step(ctx context.Context, in *StepRequest, opts ...grpc.CallOption) (*StepResponse, error) {
+	out := new(StepResponse)
+	err := grpc.Invoke(ctx, "/bft.Cluster/Step", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Cluster service
+
+type ClusterServer interface {
+	// Step passes a signed protocol message to the consenter
+	Step(context.Context, *StepRequest) (*StepResponse, error)
+}
+
+func RegisterClusterServer(s *grpc.Server, srv ClusterServer) {
+	s.RegisterService(&_Cluster_serviceDesc, srv)
+}
+
+func _Cluster_Step_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StepRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServer).Step(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/bft.Cluster/Step",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServer).Step(ctx, req.(*StepRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Cluster_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "bft.Cluster",
+	HandlerType: (*ClusterServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Step",
+			Handler:    _Cluster_Step_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "orderer/bft/bft.proto",
+}
+
+func init() { proto.RegisterFile("orderer/bft/bft.proto", fileDescriptor1) }
+
+var fileDescriptor1 = []byte{
+	// 719 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x54, 0xdd, 0x4e, 0xdb, 0x4a,
+	0x10, 0x26, 0xe4, 0xc7, 0xce, 0xc4, 0x01, 0xb3, 0xe8, 0x1c, 0xf9, 0x70, 0xce, 0x91, 0xa2, 0xf4,
+	0x47, 0x80, 0x4a, 0x52, 0xd1, 0x56, 0xea, 0x2d, 0x44, 0x16, 0xe5, 0x22, 0x21, 0x5d, 0xf3, 0xa3,
+	0x56, 0xaa, 0x2c, 0xc7, 0x99, 0x18, 0xab, 0x89, 0xed, 0x78, 0x37, 0x20, 0x6e, 0x7a, 0xdd, 0xc7,
+	0xe9, 0xbb, 0xf5, 0x05, 0xaa, 0xdd, 0xb5, 0x4d, 0x50, 0x49, 0xdb, 0x8b, 0x28, 0x3b, 0xdf, 0x7c,
+	0x33, 0xbb, 0xf3, 0xcd, 0x78, 0xe0, 0xaf, 0x38, 0x1d, 0x63, 0x8a, 0x69, 0x77, 0x34, 0xe1, 0xe2,
+	0xd7, 0x49, 0xd2, 0x98, 0xc7, 0xa4, 0x3c, 0x9a, 0xf0, 0x9d, 0x6d, 0x3f, 0x9e, 0xcd, 0xe2, 0xa8,
+	0xab, 0xfe, 0x94, 0xa7, 0x7d, 0x01, 0x0d, 0x87, 0x63, 0x42, 0x71, 0xbe, 0x40, 0xc6, 0x89, 0x05,
+	0x9a, 0x7f, 0xed, 0x45, 0x11, 0x4e, 0xad, 0x52, 0xab, 0xb4, 0x5b, 0xa7, 0xb9, 0x49, 0x5e, 0x80,
+	0x36, 0x43, 0xc6, 0xbc, 0x00, 0xad, 0xf5, 0x56, 0x69, 0xb7, 0x71, 0x48, 0x3a, 0x22, 0xbf, 0x13,
+	0x06, 0x11, 0x8e, 0xfb, 0xca, 0x43, 0x73, 0x4a, 0x7b, 0x03, 0x0c, 0x95, 0x96, 0x25, 0x71, 0xc4,
+	0xb0, 0x7d, 0x02, 0xcd, 0x07, 0x4c, 0x71, 0x51, 0x9e, 0x4e, 0x5c, 0x64, 0x14, 0xa1, 0xe4, 0x3f,
+	0xa8, 0xb3, 0x30, 0x88, 0x3c, 0xbe, 0x48, 0xd5, 0x55, 0x06, 0xbd, 0x07, 0xda, 0xdf, 0xca, 0xa0,
+	0xe5, 0x39, 0x9e, 0x42, 0x85, 0xdf, 0x25, 0x2a, 0xc1, 0xc6, 0xa1, 0x29, 0xdf, 0x93, 0xf9, 0xce,
+	0xef, 0x12, 0xa4, 0xd2, 0x4b, 0x08, 0x54, 0x26, 0x69, 0x3c, 0x93, 0xa9, 0x2a, 0x54, 0x9e, 0x05,
+	0x76, 0x13, 0xe2, 0xad, 0x55, 0x56, 0x98, 0x38, 0x93, 0x1d, 0xd0, 0x99, 0x50, 0x21, 0xf2, 0xd1,
+	0xaa, 0x48, 0xbc, 0xb0, 0xc9, 0xdf, 0x50, 0x1b, 0x87, 0x01, 0x32, 0x6e, 0x55, 0xe5, 0x83, 0x32,
+	0x8b, 0x3c, 0x07, 0x2d, 0x55, 0xca, 0x59, 0x35, 0x29, 0x8a, 0x21, 0x1f, 0x91, 0xa9, 0x49, 0x73,
+	0x27, 0x79, 0x02, 0xd5, 0xd1, 0x34, 0xf6, 0x3f, 0x5b, 0x9a, 0x64, 0x35, 0x3b, 0x59, 0x0f, 0x8e,
+	0x05, 0x48, 0x95, 0x8f, 0x1c, 0xc3, 0xa6, 0x3c, 0xb8, 0xf7, 0xe5, 0xeb, 0x92, 0xfe, 0x4f, 0x4e,
+	0xef, 0x23, 0xf7, 0xc6, 0x1e, 0xf7, 0x9c, 0x9c, 0x40, 0x37, 0x64, 0x44, 0x61, 0x93, 0xd7, 0xa0,
+	0x27, 0x29, 0x26, 0x5e, 0x8a, 0x63, 0xab, 0x2e, 0x83, 0x2d, 0xf9, 0xa2, 0x61, 0x06, 0xf6, 0x30,
+	0xe5, 0xe1, 0x24, 0xf4, 0x3d, 0x8e, 0xb4, 0x60, 0x92, 0x37, 0x60, 0x08, 0x09, 0x5c, 0xd1, 0xeb,
+	0x00, 0x99, 0x05, 0xad, 0xf2, 0x8a, 0x06, 0x37, 0x04, 0xaf, 0xa7, 0x68, 0xe4, 0x19, 0xd4, 0xe4,
+	0xf5, 0xcc, 0x6a, 0xb4, 0xca, 0x3f, 0x97, 0x95, 0x39, 0xdb, 0x73, 0xd0, 0xf2, 0xf1, 0xfa, 0x1f,
+	0xc0, 0x8f, 0xa3, 0x49, 0x18, 0xb8, 0x0c, 0xe7, 0xb2, 0x6f, 0x15, 0x5a, 0x57, 0x88, 0x83, 0x73,
+	0xb2, 0x0f, 0x9a, 0x1f, 0x47, 0x1c, 0x23, 0x9e, 0xcd, 0x98, 0x99, 0x67, 0xb4, 0xa3, 0x1b, 0x9c,
+	0xc6, 0x09, 0xd2, 0x9c, 0x40, 0xfe, 0x85, 0x7a, 0xc8, 0x5c, 0x15, 0x2b, 0xfb, 0xa8, 0x53, 0x3d,
+	0x64, 0x3d, 0x69, 0xb7, 0xbf, 0xc0, 0xf6, 0x23, 0x15, 0x17, 0x6d, 0x2f, 0x2d, 0xb5, 0xbd, 0x68,
+	0xcd, 0xfa, 0x2f, 0x5a, 0xd3, 0x29, 0x64, 0x65, 0x56, 0x79, 0xa5, 0x38, 0x05, 0xa7, 0xfd, 0xbd,
+	0x04, 0x06, 0xc5, 0x64, 0x1a, 0xfa, 0x9e, 0xc3, 0x57, 0xdf, 0xdc, 0xbc, 0x57, 0x3d, 0x8c, 0x02,
+	0xf9, 0x02, 0x9d, 0x1a, 0x85, 0xc4, 0x61, 0x14, 0x90, 0x03, 0xd0, 0x23, 0xbc, 0x75, 0x8b, 0x69,
+	0x5d, 0xf1, 0xdd, 0x45, 0x78, 0x7b, 0xf9, 0xbb, 0x21, 0xde, 0x13, 0x45, 0xc4, 0x49, 0xcc, 0xbc,
+	0xa9, 0x55, 0x7d, 0xac, 0xd8, 0xc2, 0xfd, 0x60, 0x8c, 0x6a, 0x7f, 0x3a, 0x46, 0xfb, 0x5f, 0x4b,
+	0xd0, 0x58, 0xfa, 0xfe, 0x48, 0x03, 0x34, 0x6a, 0xbf, 0xbf, 0xb0, 0x9d, 0x73, 0x73, 0x8d, 0x6c,
+	0x42, 0x63, 0x48, 0x6d, 0x77, 0x48, 0xed, 0xe1, 0x11, 0xb5, 0xcd, 0x92, 0xf0, 0xe6, 0xc6, 0x3a,
+	0x01, 0xa8, 0xf5, 0xce, 0xfa, 0xfd, 0xd3, 0x73, 0xb3, 0x2c, 0x98, 0x97, 0xa7, 0xf6, 0x95, 0xdb,
+	0x7b, 0x77, 0x34, 0x38, 0xb1, 0xcd, 0x0a, 0x31, 0x40, 0x1f, 0xd8, 0x57, 0xae, 0x00, 0xcd, 0x2a,
+	0x31, 0xc1, 0x70, 0x3e, 0x0c, 0x7a, 0x6e, 0x9e, 0xba, 0x46, 0xb6, 0xa0, 0x99, 0x21, 0xce, 0xf0,
+	0x6c, 0xe0, 0xd8, 0xa6, 0x76, 0xf8, 0x16, 0xb4, 0xde, 0x74, 0xc1, 0x38, 0xa6, 0xe4, 0x00, 0x2a,
+	0x62, 0x15, 0x11, 0xb5, 0x1f, 0x96, 0x96, 0xdd, 0xce, 0xd6, 0x12, 0x92, 0xed, 0xa9, 0xb5, 0xe3,
+	0x4f, 0xb0, 0x17, 0xa7, 0x41, 0xe7, 0xfa, 0x2e, 0xc1, 0x74, 0x8a, 0xe3, 0x00, 0xd3, 0xce, 0xc4,
+	0x1b, 0xa5, 0xa1, 0xaf, 0x16, 0x26, 0xeb, 0x64, 0x1b, 0x56, 0xc4, 0x7e, 0x7c, 0x19, 0x84, 0xfc,
+	0x7a, 0x31, 0x12, 0x32, 0x76, 0x97, 0x22, 0xba, 0x2a, 0xa2, 0xab, 0x22, 0xba, 0x4b, 0x3b, 0x79,
+	0x54, 0x93, 0xd8, 0xab, 0x1f, 0x03, 0x00, 0x7b, 0x17, 0x9a, 0x06, 0xa9, 0x05, 0x00, 0x00,
+}
@@ -0,0 +1,177 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: orderer/bft/configuration.proto
+
+/*
+Package bft is a generated protocol buffer package.
+
+It is generated from these files:
+	orderer/bft/configuration.proto
+	orderer/bft/bft.proto
+
+It has these top-level messages:
+	ConfigMetadata
+	Consenter
+	Options
+	BlockMetadata
+	StepRequest
+	StepResponse
+	SignedMessage
+	Message
+	Request
+	PreparedCertificate
+	ReplicaState
+*/
+package bft
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+// ConfigMetadata is serialized and set as the value of ConsensusType.Metadata in
+// a channel configuration when the ConsensusType.Type is set to "bft".
+type ConfigMetadata struct {
+	Consenters []*Consenter `protobuf:"bytes,1,rep,name=consenters" json:"consenters,omitempty"`
+	Options    *Options     `protobuf:"bytes,2,opt,name=options" json:"options,omitempty"`
+}
+
+func (m *ConfigMetadata) Reset()                    { *m = ConfigMetadata{} }
+func (m *ConfigMetadata) String() string            { return proto.CompactTextString(m) }
+func (*ConfigMetadata) ProtoMessage()               {}
+func (*ConfigMetadata) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+func (m *ConfigMetadata) GetConsenters() []*Consenter {
+	if m != nil {
+		return m.Consenters
+	}
+	return nil
+}
+
+func (m *ConfigMetadata) GetOptions() *Options {
+	if m != nil {
+		return m.Options
+	}
+	return nil
+}
+
+// Consenter represents a consenting orderer node. The consenters are numbered
+// according to their order (starting from 1), which determines the primary of each view
+type Consenter struct {
+	Host string `protobuf:"bytes,1,opt,name=host" json:"host,omitempty"`
+	Port uint32 `protobuf:"varint,2,opt,name=port" json:"port,omitempty"`
+	// The serialized MSP identity with which the consenter signs its protocol messages and the blocks
+	Identity []byte `protobuf:"bytes,3,opt,name=identity,proto3" json:"identity,omitempty"`
+}
+
+func (m *Consenter) Reset()                    { *m = Consenter{} }
+func (m *Consenter) String() string            { return proto.CompactTextString(m) }
+func (*Consenter) ProtoMessage()               {}
+func (*Consenter) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *Consenter) GetHost() string {
+	if m != nil {
+		return m.Host
+	}
+	return ""
+}
+
+func (m *Consenter) GetPort() uint32 {
+	if m != nil {
+		return m.Port
+	}
+	return 0
+}
+
+func (m *Consenter) GetIdentity() []byte {
+	if m != nil {
+		return m.Identity
+	}
+	return nil
+}
+
+// Options to be specified for all the BFT nodes of a channel
+type Options struct {
+	// The time, in milliseconds, that a request may wait to be ordered before the consenters replace the primary
+	RequestTimeout uint64 `protobuf:"varint,1,opt,name=request_timeout,json=requestTimeout" json:"request_timeout,omitempty"`
+	// The time, in milliseconds, that a view change may take before the consenters move on to the next view
+	ViewChangeTimeout uint64 `protobuf:"varint,2,opt,name=view_change_timeout,json=viewChangeTimeout" json:"view_change_timeout,omitempty"`
+}
+
+func (m *Options) Reset()                    { *m = Options{} }
+func (m *Options) String() string            { return proto.CompactTextString(m) }
+func (*Options) ProtoMessage()               {}
+func (*Options) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *Options) GetRequestTimeout() uint64 {
+	if m != nil {
+		return m.RequestTimeout
+	}
+	return 0
+}
+
+func (m *Options) GetViewChangeTimeout() uint64 {
+	if m != nil {
+		return m.ViewChangeTimeout
+	}
+	return 0
+}
+
+// BlockMetadata is stored as the value of the ORDERER metadata slot of the blocks
+// written by the BFT consenter. It records the view in which the block was committed
+type BlockMetadata struct {
+	View uint64 `protobuf:"varint,1,opt,name=view" json:"view,omitempty"`
+}
+
+func (m *BlockMetadata) Reset()                    { *m = BlockMetadata{} }
+func (m *BlockMetadata) String() string            { return proto.CompactTextString(m) }
+func (*BlockMetadata) ProtoMessage()               {}
+func (*BlockMetadata) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *BlockMetadata) GetView() uint64 {
+	if m != nil {
+		return m.View
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*ConfigMetadata)(nil), "bft.ConfigMetadata")
+	proto.RegisterType((*Consenter)(nil), "bft.Consenter")
+	proto.RegisterType((*Options)(nil), "bft.Options")
+	proto.RegisterType((*BlockMetadata)(nil), "bft.BlockMetadata")
+}
+
+func init() { proto.RegisterFile("orderer/bft/configuration.proto", fileDescriptor0) }
+
+var fileDescriptor0 = []byte{
+	// 304 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x4c, 0x91, 0x4f, 0x4b, 0xc3, 0x40,
+	0x10, 0xc5, 0x49, 0x5b, 0xac, 0x9d, 0xfe, 0x11, 0xd7, 0x4b, 0xf0, 0x62, 0x88, 0xa0, 0xf1, 0xb2,
+	0x91, 0xfa, 0x0d, 0xda, 0xb3, 0x14, 0x82, 0x27, 0x41, 0x4a, 0x36, 0x99, 0x24, 0x8b, 0x6d, 0x26,
+	0x6e, 0x26, 0x4a, 0xbf, 0xbd, 0x64, 0x93, 0x86, 0xde, 0x66, 0xdf, 0xfb, 0xcd, 0x5b, 0x78, 0x03,
+	0x0f, 0x64, 0x52, 0x34, 0x68, 0x42, 0x95, 0x71, 0x98, 0x50, 0x99, 0xe9, 0xbc, 0x31, 0x31, 0x6b,
+	0x2a, 0x65, 0x65, 0x88, 0x49, 0x8c, 0x55, 0xc6, 0x7e, 0x01, 0xab, 0xad, 0xf5, 0xde, 0x91, 0xe3,
+	0x34, 0xe6, 0x58, 0x48, 0x80, 0x84, 0xca, 0x1a, 0x4b, 0x46, 0x53, 0xbb, 0x8e, 0x37, 0x0e, 0xe6,
+	0xeb, 0x95, 0x54, 0x19, 0xcb, 0xed, 0x59, 0x8e, 0x2e, 0x08, 0xf1, 0x04, 0x53, 0xaa, 0xda, 0xd8,
+	0xda, 0x1d, 0x79, 0x4e, 0x30, 0x5f, 0x2f, 0x2c, 0xbc, 0xeb, 0xb4, 0xe8, 0x6c, 0xfa, 0x3b, 0x98,
+	0x0d, 0x01, 0x42, 0xc0, 0xa4, 0xa0, 0x9a, 0x5d, 0xc7, 0x73, 0x82, 0x59, 0x64, 0xe7, 0x56, 0xab,
+	0xc8, 0xb0, 0x4d, 0x59, 0x46, 0x76, 0x16, 0xf7, 0x70, 0xad, 0x53, 0x2c, 0x59, 0xf3, 0xc9, 0x1d,
+	0x7b, 0x4e, 0xb0, 0x88, 0x86, 0xb7, 0xaf, 0x60, 0xda, 0x7f, 0x22, 0x9e, 0xe1, 0xc6, 0xe0, 0x4f,
+	0x83, 0x35, 0xef, 0x59, 0x1f, 0x91, 0x9a, 0x2e, 0x79, 0x12, 0xad, 0x7a, 0xf9, 0xa3, 0x53, 0x85,
+	0x84, 0xbb, 0x5f, 0x8d, 0x7f, 0xfb, 0xa4, 0x88, 0xcb, 0x1c, 0x07, 0x78, 0x64, 0xe1, 0xdb, 0xd6,
+	0xda, 0x5a, 0xa7, 0xe7, 0xfd, 0x47, 0x58, 0x6e, 0x0e, 0x94, 0x7c, 0x0f, 0xed, 0x08, 0x98, 0xb4,
+	0x54, 0x1f, 0x6f, 0xe7, 0xcd, 0x17, 0xbc, 0x90, 0xc9, 0x65, 0x71, 0xaa, 0xd0, 0x1c, 0x30, 0xcd,
+	0xd1, 0xc8, 0x2c, 0x56, 0x46, 0x27, 0x5d, 0xd1, 0xb5, 0xec, 0x2f, 0xd1, 0xf6, 0xf2, 0xf9, 0x9a,
+	0x6b, 0x2e, 0x1a, 0x25, 0x13, 0x3a, 0x86, 0x17, 0x1b, 0x61, 0xb7, 0x11, 0x76, 0x1b, 0xe1, 0xc5,
+	0xed, 0xd4, 0x95, 0xd5, 0xde, 0xfe, 0x07, 0x00, 0xd7, 0x02, 0xb9, 0xec, 0xd1, 0x01, 0x00, 0x00,
+}
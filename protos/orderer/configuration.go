@@ -109,6 +109,8 @@ func (docv *DynamicOrdererConfigValue) VariablyOpaqueFieldProto(name string) (pr
 		return &KafkaBrokers{}, nil
 	case "ChannelRestrictions":
 		return &ChannelRestrictions{}, nil
+	case "RateLimits":
+		return &RateLimits{}, nil
 	case "Capabilities":
 		return &common.Capabilities{}, nil
 	default:
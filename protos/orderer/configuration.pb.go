@@ -14,6 +14,9 @@ var _ = math.Inf
 
 type ConsensusType struct {
 	Type string `protobuf:"bytes,1,opt,name=type" json:"type,omitempty"`
+	// Opaque metadata, dependent on the consensus type. For example, the "raft"
+	// consensus type expects a serialized raft.ConfigMetadata message
+	Metadata []byte `protobuf:"bytes,2,opt,name=metadata,proto3" json:"metadata,omitempty"`
 }
 
 func (m *ConsensusType) Reset()                    { *m = ConsensusType{} }
@@ -28,6 +31,13 @@ func (m *ConsensusType) GetType() string {
 	return ""
 }
 
+func (m *ConsensusType) GetMetadata() []byte {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
 type BatchSize struct {
 	// Simply specified as number of messages for now, in the future
 	// we may want to allow this to be specified by size in bytes
@@ -121,36 +131,115 @@ func (m *ChannelRestrictions) GetMaxCount() uint64 {
 	return 0
 }
 
+// RateLimits is the message which conveys the rate limits applied to the messages
+// broadcast to a channel by each org and by each of its clients
+type RateLimits struct {
+	Limits []*RateLimit `protobuf:"bytes,1,rep,name=limits" json:"limits,omitempty"`
+}
+
+func (m *RateLimits) Reset()                    { *m = RateLimits{} }
+func (m *RateLimits) String() string            { return proto.CompactTextString(m) }
+func (*RateLimits) ProtoMessage()               {}
+func (*RateLimits) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{5} }
+
+func (m *RateLimits) GetLimits() []*RateLimit {
+	if m != nil {
+		return m.Limits
+	}
+	return nil
+}
+
+// RateLimit conveys the token bucket settings applied to the messages of an org, and
+// to the messages of each of its clients. The buckets are refilled with rate (or
+// client_rate) messages per second and hold at most burst (or client_burst) messages.
+// A rate of 0 indicates no limit, and a burst of 0 a burst of one second of messages.
+type RateLimit struct {
+	MspId       string  `protobuf:"bytes,1,opt,name=msp_id,json=mspId" json:"msp_id,omitempty"`
+	Rate        float64 `protobuf:"fixed64,2,opt,name=rate" json:"rate,omitempty"`
+	Burst       uint32  `protobuf:"varint,3,opt,name=burst" json:"burst,omitempty"`
+	ClientRate  float64 `protobuf:"fixed64,4,opt,name=client_rate,json=clientRate" json:"client_rate,omitempty"`
+	ClientBurst uint32  `protobuf:"varint,5,opt,name=client_burst,json=clientBurst" json:"client_burst,omitempty"`
+}
+
+func (m *RateLimit) Reset()                    { *m = RateLimit{} }
+func (m *RateLimit) String() string            { return proto.CompactTextString(m) }
+func (*RateLimit) ProtoMessage()               {}
+func (*RateLimit) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{6} }
+
+func (m *RateLimit) GetMspId() string {
+	if m != nil {
+		return m.MspId
+	}
+	return ""
+}
+
+func (m *RateLimit) GetRate() float64 {
+	if m != nil {
+		return m.Rate
+	}
+	return 0
+}
+
+func (m *RateLimit) GetBurst() uint32 {
+	if m != nil {
+		return m.Burst
+	}
+	return 0
+}
+
+func (m *RateLimit) GetClientRate() float64 {
+	if m != nil {
+		return m.ClientRate
+	}
+	return 0
+}
+
+func (m *RateLimit) GetClientBurst() uint32 {
+	if m != nil {
+		return m.ClientBurst
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*ConsensusType)(nil), "orderer.ConsensusType")
 	proto.RegisterType((*BatchSize)(nil), "orderer.BatchSize")
 	proto.RegisterType((*BatchTimeout)(nil), "orderer.BatchTimeout")
 	proto.RegisterType((*KafkaBrokers)(nil), "orderer.KafkaBrokers")
 	proto.RegisterType((*ChannelRestrictions)(nil), "orderer.ChannelRestrictions")
+	proto.RegisterType((*RateLimits)(nil), "orderer.RateLimits")
+	proto.RegisterType((*RateLimit)(nil), "orderer.RateLimit")
 }
 
 func init() { proto.RegisterFile("orderer/configuration.proto", fileDescriptor1) }
 
 var fileDescriptor1 = []byte{
-	// 313 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x4c, 0xd0, 0xcd, 0x4a, 0xc3, 0x40,
-	0x10, 0x07, 0x70, 0x62, 0x8b, 0xb5, 0x8b, 0x45, 0xbb, 0xbd, 0x04, 0x7a, 0x29, 0x11, 0xa1, 0x48,
-	0x49, 0x40, 0xdf, 0x20, 0x3d, 0x4a, 0x2f, 0xb1, 0x5e, 0xbc, 0x94, 0x4d, 0x3a, 0x49, 0x96, 0x36,
-	0x3b, 0x61, 0x76, 0x03, 0x89, 0xef, 0xe1, 0xfb, 0xca, 0x6e, 0x52, 0xed, 0x6d, 0x3e, 0x7e, 0x0b,
-	0xb3, 0x7f, 0xb6, 0x44, 0x3a, 0x02, 0x01, 0x45, 0x19, 0xaa, 0x5c, 0x16, 0x0d, 0x09, 0x23, 0x51,
-	0x85, 0x35, 0xa1, 0x41, 0x3e, 0x19, 0x96, 0xc1, 0x13, 0x9b, 0x6d, 0x51, 0x69, 0x50, 0xba, 0xd1,
-	0xfb, 0xae, 0x06, 0xce, 0xd9, 0xd8, 0x74, 0x35, 0xf8, 0xde, 0xca, 0x5b, 0x4f, 0x13, 0x57, 0x07,
-	0x3f, 0x1e, 0x9b, 0xc6, 0xc2, 0x64, 0xe5, 0x87, 0xfc, 0x06, 0xfe, 0xc2, 0xe6, 0x95, 0x68, 0x0f,
-	0x15, 0x68, 0x2d, 0x0a, 0x38, 0x64, 0xd8, 0x28, 0xe3, 0xf8, 0x2c, 0x79, 0xa8, 0x44, 0xbb, 0xeb,
-	0xe7, 0x5b, 0x3b, 0xe6, 0x1b, 0xc6, 0x45, 0xaa, 0xf1, 0xdc, 0x18, 0x38, 0xd8, 0x47, 0x69, 0x67,
-	0x40, 0xfb, 0x37, 0x0e, 0x3f, 0x5e, 0x36, 0x3b, 0xd1, 0xc6, 0x76, 0xce, 0x43, 0xb6, 0xa8, 0x09,
-	0x72, 0x20, 0x82, 0xe3, 0x15, 0x1f, 0x39, 0x3e, 0xff, 0x5b, 0x5d, 0x7c, 0xb0, 0x66, 0xf7, 0xee,
-	0xac, 0xbd, 0xac, 0x00, 0x1b, 0xc3, 0x7d, 0x36, 0x31, 0x7d, 0x39, 0x9c, 0x7f, 0x69, 0xad, 0x7c,
-	0x17, 0xf9, 0x49, 0xc4, 0x84, 0x27, 0x20, 0x6d, 0x65, 0xda, 0x97, 0xbe, 0xb7, 0x1a, 0x59, 0x39,
-	0xb4, 0xc1, 0x2b, 0x5b, 0x6c, 0x4b, 0xa1, 0x14, 0x9c, 0x13, 0xd0, 0x86, 0x64, 0x66, 0x53, 0xd3,
-	0x7c, 0xc9, 0xa6, 0xf6, 0xa0, 0xff, 0xcf, 0x8e, 0x93, 0xbb, 0x4a, 0xb4, 0xee, 0x97, 0xf1, 0x27,
-	0x7b, 0x46, 0x2a, 0xc2, 0xb2, 0xab, 0x81, 0xce, 0x70, 0x2c, 0x80, 0xc2, 0x5c, 0xa4, 0x24, 0xb3,
-	0x3e, 0x6d, 0x1d, 0x0e, 0x69, 0x7f, 0x6d, 0x0a, 0x69, 0xca, 0x26, 0x0d, 0x33, 0xac, 0xa2, 0x2b,
-	0x1d, 0xf5, 0x3a, 0xea, 0x75, 0x34, 0xe8, 0xf4, 0xd6, 0xf5, 0x6f, 0xbf, 0x01, 0x00, 0x00, 0xff,
-	0xff, 0x0c, 0x47, 0xa2, 0x55, 0xca, 0x01, 0x00, 0x00,
+	// 431 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x4c, 0x92, 0xcf, 0x6a, 0xdb, 0x40,
+	0x10, 0xc6, 0x51, 0x63, 0x3b, 0xf1, 0xc4, 0xa6, 0xcd, 0xa6, 0x05, 0xd3, 0x1c, 0xea, 0x0a, 0x0a,
+	0x26, 0x04, 0x19, 0xd2, 0x4b, 0x6f, 0x05, 0xf9, 0x54, 0xda, 0x5c, 0xb6, 0xe9, 0xa5, 0x17, 0xb1,
+	0x92, 0xc6, 0xf2, 0x12, 0xad, 0x56, 0xec, 0x8e, 0xc0, 0xee, 0x53, 0xf4, 0xd2, 0xf7, 0x2d, 0xfb,
+	0xc7, 0x4e, 0x6e, 0x33, 0xdf, 0xfc, 0xbe, 0xd1, 0xcc, 0xac, 0xe0, 0x46, 0x9b, 0x1a, 0x0d, 0x9a,
+	0x75, 0xa5, 0xbb, 0xad, 0x6c, 0x06, 0x23, 0x48, 0xea, 0x2e, 0xeb, 0x8d, 0x26, 0xcd, 0xce, 0x63,
+	0x31, 0xfd, 0x0a, 0xf3, 0x8d, 0xee, 0x2c, 0x76, 0x76, 0xb0, 0x8f, 0x87, 0x1e, 0x19, 0x83, 0x11,
+	0x1d, 0x7a, 0x5c, 0x24, 0xcb, 0x64, 0x35, 0xe5, 0x3e, 0x66, 0xef, 0xe1, 0x42, 0x21, 0x89, 0x5a,
+	0x90, 0x58, 0xbc, 0x5a, 0x26, 0xab, 0x19, 0x3f, 0xe5, 0xe9, 0xbf, 0x04, 0xa6, 0xb9, 0xa0, 0x6a,
+	0xf7, 0x53, 0xfe, 0x41, 0x76, 0x0b, 0x57, 0x4a, 0xec, 0x0b, 0x85, 0xd6, 0x8a, 0x06, 0x8b, 0x4a,
+	0x0f, 0x1d, 0xf9, 0x56, 0x73, 0xfe, 0x5a, 0x89, 0xfd, 0x43, 0xd0, 0x37, 0x4e, 0x66, 0x77, 0xc0,
+	0x44, 0x69, 0x75, 0x3b, 0x10, 0x16, 0xce, 0x54, 0x1e, 0x08, 0xad, 0xef, 0x3f, 0xe7, 0x6f, 0x8e,
+	0x95, 0x07, 0xb1, 0xcf, 0x9d, 0xce, 0x32, 0xb8, 0xee, 0x0d, 0x6e, 0xd1, 0x18, 0xac, 0x5f, 0xe0,
+	0x67, 0x1e, 0xbf, 0x3a, 0x95, 0x8e, 0x7c, 0xba, 0x82, 0x99, 0x1f, 0xeb, 0x51, 0x2a, 0xd4, 0x03,
+	0xb1, 0x05, 0x9c, 0x53, 0x08, 0xe3, 0x6a, 0xc7, 0xd4, 0x91, 0xdf, 0xc5, 0xf6, 0x49, 0xe4, 0x46,
+	0x3f, 0xa1, 0xb1, 0x8e, 0x2c, 0x43, 0xb8, 0x48, 0x96, 0x67, 0x8e, 0x8c, 0x69, 0x7a, 0x0f, 0xd7,
+	0x9b, 0x9d, 0xe8, 0x3a, 0x6c, 0x39, 0x5a, 0x32, 0xb2, 0x72, 0x17, 0xb5, 0xec, 0x06, 0xa6, 0x6e,
+	0xa0, 0xe7, 0x65, 0x47, 0xfc, 0x42, 0x89, 0xbd, 0xdf, 0x32, 0xfd, 0x02, 0xc0, 0x05, 0xe1, 0x0f,
+	0xa9, 0x24, 0x59, 0x76, 0x0b, 0x93, 0xd6, 0x47, 0xbe, 0xf5, 0xe5, 0x3d, 0xcb, 0xe2, 0x43, 0x64,
+	0x27, 0x88, 0x47, 0x22, 0xfd, 0x9b, 0xc0, 0xf4, 0xa4, 0xb2, 0x77, 0x30, 0x51, 0xb6, 0x2f, 0x64,
+	0x1d, 0xc7, 0x1f, 0x2b, 0xdb, 0x7f, 0xab, 0xdd, 0x73, 0x19, 0x41, 0xe8, 0xcf, 0x96, 0x70, 0x1f,
+	0xb3, 0xb7, 0x30, 0x2e, 0x07, 0x63, 0x29, 0x1e, 0x27, 0x24, 0xec, 0x03, 0x5c, 0x56, 0xad, 0xc4,
+	0x8e, 0x0a, 0x6f, 0x18, 0x79, 0x03, 0x04, 0xc9, 0x7d, 0x86, 0x7d, 0x84, 0x59, 0x04, 0x82, 0x7b,
+	0xec, 0xdd, 0xd1, 0x94, 0x3b, 0x29, 0xff, 0x05, 0x9f, 0xb4, 0x69, 0xb2, 0xdd, 0xa1, 0x47, 0xd3,
+	0x62, 0xdd, 0xa0, 0xc9, 0xb6, 0xa2, 0x34, 0xb2, 0x0a, 0xbf, 0x95, 0x3d, 0x6e, 0xf3, 0xfb, 0xae,
+	0x91, 0xb4, 0x1b, 0xca, 0xac, 0xd2, 0x6a, 0xfd, 0x82, 0x5e, 0x07, 0x7a, 0x1d, 0xe8, 0x75, 0xa4,
+	0xcb, 0x89, 0xcf, 0x3f, 0xff, 0x1f, 0x00, 0x26, 0xaa, 0x01, 0x6d, 0xb3, 0x02, 0x00, 0x00,
 }
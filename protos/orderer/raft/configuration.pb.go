@@ -0,0 +1,242 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: orderer/raft/configuration.proto
+
+/*
+Package raft is a generated protocol buffer package.
+
+It is generated from these files:
+	orderer/raft/configuration.proto
+	orderer/raft/raft.proto
+
+It has these top-level messages:
+	ConfigMetadata
+	Consenter
+	Options
+	BlockMetadata
+	StepRequest
+	StepResponse
+	SubmitRequest
+	SubmitResponse
+	SnapshotData
+*/
+package raft
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+// ConfigMetadata is serialized and set as the value of ConsensusType.Metadata in
+// a channel configuration when the ConsensusType.Type is set to "raft".
+type ConfigMetadata struct {
+	Consenters []*Consenter `protobuf:"bytes,1,rep,name=consenters" json:"consenters,omitempty"`
+	Options    *Options     `protobuf:"bytes,2,opt,name=options" json:"options,omitempty"`
+}
+
+func (m *ConfigMetadata) Reset()                    { *m = ConfigMetadata{} }
+func (m *ConfigMetadata) String() string            { return proto.CompactTextString(m) }
+func (*ConfigMetadata) ProtoMessage()               {}
+func (*ConfigMetadata) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{0} }
+
+func (m *ConfigMetadata) GetConsenters() []*Consenter {
+	if m != nil {
+		return m.Consenters
+	}
+	return nil
+}
+
+func (m *ConfigMetadata) GetOptions() *Options {
+	if m != nil {
+		return m.Options
+	}
+	return nil
+}
+
+// Consenter represents a consenting orderer node. The consenters of the genesis block
+// are assigned the raft node IDs 1 to n in order, and the consenters added later on
+// are assigned the following IDs, which are recorded in the BlockMetadata
+type Consenter struct {
+	Host string `protobuf:"bytes,1,opt,name=host" json:"host,omitempty"`
+	Port uint32 `protobuf:"varint,2,opt,name=port" json:"port,omitempty"`
+	// The PEM encoded TLS certificate with which the consenter connects to the
+	// other consenters. The Cluster service only serves the consenters of the
+	// channel, authenticated by these certificates
+	ClientTlsCert []byte `protobuf:"bytes,3,opt,name=client_tls_cert,json=clientTlsCert,proto3" json:"client_tls_cert,omitempty"`
+}
+
+func (m *Consenter) Reset()                    { *m = Consenter{} }
+func (m *Consenter) String() string            { return proto.CompactTextString(m) }
+func (*Consenter) ProtoMessage()               {}
+func (*Consenter) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *Consenter) GetHost() string {
+	if m != nil {
+		return m.Host
+	}
+	return ""
+}
+
+func (m *Consenter) GetPort() uint32 {
+	if m != nil {
+		return m.Port
+	}
+	return 0
+}
+
+func (m *Consenter) GetClientTlsCert() []byte {
+	if m != nil {
+		return m.ClientTlsCert
+	}
+	return nil
+}
+
+// Options to be specified for all the raft nodes of a channel
+type Options struct {
+	// The interval between two raft ticks, in milliseconds
+	TickInterval uint64 `protobuf:"varint,1,opt,name=tick_interval,json=tickInterval" json:"tick_interval,omitempty"`
+	// The number of ticks that must pass without hearing from the leader before a follower starts an election
+	ElectionTick uint32 `protobuf:"varint,2,opt,name=election_tick,json=electionTick" json:"election_tick,omitempty"`
+	// The number of ticks between two heartbeats sent by the leader
+	HeartbeatTick uint32 `protobuf:"varint,3,opt,name=heartbeat_tick,json=heartbeatTick" json:"heartbeat_tick,omitempty"`
+	// The maximum size of the log entries that are sent in a single append message, in bytes.
+	// An entry larger than this is sent on its own
+	MaxSizePerMsg uint32 `protobuf:"varint,4,opt,name=max_size_per_msg,json=maxSizePerMsg" json:"max_size_per_msg,omitempty"`
+	// The number of blocks written between two snapshots of the raft log, which is compacted
+	// up to each snapshot
+	SnapshotInterval uint32 `protobuf:"varint,5,opt,name=snapshot_interval,json=snapshotInterval" json:"snapshot_interval,omitempty"`
+}
+
+func (m *Options) Reset()                    { *m = Options{} }
+func (m *Options) String() string            { return proto.CompactTextString(m) }
+func (*Options) ProtoMessage()               {}
+func (*Options) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *Options) GetTickInterval() uint64 {
+	if m != nil {
+		return m.TickInterval
+	}
+	return 0
+}
+
+func (m *Options) GetElectionTick() uint32 {
+	if m != nil {
+		return m.ElectionTick
+	}
+	return 0
+}
+
+func (m *Options) GetHeartbeatTick() uint32 {
+	if m != nil {
+		return m.HeartbeatTick
+	}
+	return 0
+}
+
+func (m *Options) GetMaxSizePerMsg() uint32 {
+	if m != nil {
+		return m.MaxSizePerMsg
+	}
+	return 0
+}
+
+func (m *Options) GetSnapshotInterval() uint32 {
+	if m != nil {
+		return m.SnapshotInterval
+	}
+	return 0
+}
+
+// BlockMetadata is stored as the value of the ORDERER metadata slot of the blocks
+// written by the raft consenter. It records the raft log entry that carried the block,
+// and the raft node IDs of the consenters as of the block
+type BlockMetadata struct {
+	Term  uint64 `protobuf:"varint,1,opt,name=term" json:"term,omitempty"`
+	Index uint64 `protobuf:"varint,2,opt,name=index" json:"index,omitempty"`
+	// The node IDs of the consenters of the channel config, in the same order
+	ConsenterIds []uint64 `protobuf:"varint,3,rep,packed,name=consenter_ids,json=consenterIds" json:"consenter_ids,omitempty"`
+	// The node ID to be assigned to the next consenter added to the channel
+	NextConsenterId uint64 `protobuf:"varint,4,opt,name=next_consenter_id,json=nextConsenterId" json:"next_consenter_id,omitempty"`
+}
+
+func (m *BlockMetadata) Reset()                    { *m = BlockMetadata{} }
+func (m *BlockMetadata) String() string            { return proto.CompactTextString(m) }
+func (*BlockMetadata) ProtoMessage()               {}
+func (*BlockMetadata) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *BlockMetadata) GetTerm() uint64 {
+	if m != nil {
+		return m.Term
+	}
+	return 0
+}
+
+func (m *BlockMetadata) GetIndex() uint64 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *BlockMetadata) GetConsenterIds() []uint64 {
+	if m != nil {
+		return m.ConsenterIds
+	}
+	return nil
+}
+
+func (m *BlockMetadata) GetNextConsenterId() uint64 {
+	if m != nil {
+		return m.NextConsenterId
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterType((*ConfigMetadata)(nil), "raft.ConfigMetadata")
+	proto.RegisterType((*Consenter)(nil), "raft.Consenter")
+	proto.RegisterType((*Options)(nil), "raft.Options")
+	proto.RegisterType((*BlockMetadata)(nil), "raft.BlockMetadata")
+}
+
+func init() { proto.RegisterFile("orderer/raft/configuration.proto", fileDescriptor0) }
+
+var fileDescriptor0 = []byte{
+	// 426 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x4c, 0x92, 0xcd, 0x6e, 0xd3, 0x40,
+	0x10, 0xc7, 0x65, 0xec, 0x52, 0x75, 0x1b, 0x37, 0xed, 0x8a, 0x83, 0x8f, 0x56, 0x10, 0x34, 0x2a,
+	0x92, 0x2d, 0xca, 0x1b, 0x34, 0xa7, 0x1e, 0x2a, 0x90, 0xe9, 0x09, 0x0e, 0xab, 0xcd, 0x7a, 0x62,
+	0x2f, 0xb1, 0xbd, 0xd6, 0xec, 0x14, 0x85, 0x3e, 0x02, 0x4f, 0xc7, 0x23, 0xa1, 0x5d, 0x7f, 0x24,
+	0xb7, 0xf1, 0xff, 0xff, 0x9b, 0x8f, 0xf5, 0x0c, 0x4b, 0x0d, 0x96, 0x80, 0x80, 0x39, 0xca, 0x1d,
+	0xe5, 0xca, 0x74, 0x3b, 0x5d, 0xbd, 0xa0, 0x24, 0x6d, 0xba, 0xac, 0x47, 0x43, 0x86, 0x47, 0xce,
+	0x59, 0xfd, 0x62, 0x57, 0x1b, 0x6f, 0x3e, 0x01, 0xc9, 0x52, 0x92, 0xe4, 0x39, 0x63, 0xca, 0x74,
+	0x16, 0x3a, 0x02, 0xb4, 0x49, 0x90, 0x86, 0xeb, 0xcb, 0xfb, 0x65, 0xe6, 0xe0, 0x6c, 0x33, 0xe9,
+	0xc5, 0x09, 0xc2, 0x6f, 0xd9, 0xb9, 0xe9, 0x5d, 0x61, 0x9b, 0xbc, 0x49, 0x83, 0xf5, 0xe5, 0x7d,
+	0x3c, 0xd0, 0x5f, 0x07, 0xb1, 0x98, 0xdc, 0xd5, 0x4f, 0x76, 0x31, 0x57, 0xe0, 0x9c, 0x45, 0xb5,
+	0xb1, 0x94, 0x04, 0x69, 0xb0, 0xbe, 0x28, 0x7c, 0xec, 0xb4, 0xde, 0x20, 0xf9, 0x32, 0x71, 0xe1,
+	0x63, 0xfe, 0x91, 0x2d, 0x55, 0xa3, 0xa1, 0x23, 0x41, 0x8d, 0x15, 0x0a, 0x90, 0x92, 0x30, 0x0d,
+	0xd6, 0x8b, 0x22, 0x1e, 0xe4, 0xe7, 0xc6, 0x6e, 0x00, 0x69, 0xf5, 0x2f, 0x60, 0xe7, 0x63, 0x47,
+	0xfe, 0x9e, 0xc5, 0xa4, 0xd5, 0x5e, 0x68, 0xd7, 0xe9, 0xb7, 0x6c, 0x7c, 0x93, 0xa8, 0x58, 0x38,
+	0xf1, 0x71, 0xd4, 0x1c, 0x04, 0x0d, 0x28, 0x97, 0x21, 0x9c, 0x31, 0x76, 0x5d, 0x4c, 0xe2, 0xb3,
+	0x56, 0x7b, 0xfe, 0x81, 0x5d, 0xd5, 0x20, 0x91, 0xb6, 0x20, 0x69, 0xa0, 0x42, 0x4f, 0xc5, 0xb3,
+	0xea, 0xb1, 0x5b, 0x76, 0xdd, 0xca, 0x83, 0xb0, 0xfa, 0x15, 0x44, 0x0f, 0x28, 0x5a, 0x5b, 0x25,
+	0xd1, 0x00, 0xb6, 0xf2, 0xf0, 0x5d, 0xbf, 0xc2, 0x37, 0xc0, 0x27, 0x5b, 0xf1, 0x4f, 0xec, 0xc6,
+	0x76, 0xb2, 0xb7, 0xb5, 0xa1, 0xe3, 0x74, 0x67, 0x9e, 0xbc, 0x9e, 0x8c, 0x69, 0xc2, 0xd5, 0xdf,
+	0x80, 0xc5, 0x0f, 0x8d, 0x51, 0xfb, 0x79, 0x37, 0x9c, 0x45, 0x04, 0xd8, 0x8e, 0xef, 0xf1, 0x31,
+	0x7f, 0xc7, 0xce, 0x74, 0x57, 0xc2, 0xc1, 0xcf, 0x1f, 0x15, 0xc3, 0x87, 0x7b, 0xdd, 0xbc, 0x22,
+	0xa1, 0x4b, 0x9b, 0x84, 0x69, 0xe8, 0x7e, 0xc1, 0x2c, 0x3e, 0x96, 0x96, 0xdf, 0xb1, 0x9b, 0x0e,
+	0x0e, 0x24, 0x4e, 0x49, 0x3f, 0x77, 0x54, 0x2c, 0x9d, 0xb1, 0x39, 0xc2, 0x0f, 0x82, 0xdd, 0x19,
+	0xac, 0xb2, 0xfa, 0x4f, 0x0f, 0xd8, 0x40, 0x59, 0x01, 0x66, 0x3b, 0xb9, 0x45, 0xad, 0x86, 0x73,
+	0xb2, 0xd9, 0x78, 0x70, 0x7e, 0xf7, 0x3f, 0x3e, 0x57, 0x9a, 0xea, 0x97, 0x6d, 0xa6, 0x4c, 0x9b,
+	0x9f, 0xa4, 0xe4, 0x43, 0x4a, 0x3e, 0xa4, 0xe4, 0xa7, 0x37, 0xba, 0x7d, 0xeb, 0xc5, 0x2f, 0xff,
+	0x07, 0x00, 0xc1, 0x50, 0x50, 0xaa, 0xba, 0x02, 0x00, 0x00,
+}
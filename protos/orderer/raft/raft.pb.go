@@ -0,0 +1,274 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: orderer/raft/raft.proto
+
+package raft
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+import common "github.com/hyperledger/fabric/protos/common"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+type StepRequest struct {
+	Channel string `protobuf:"bytes,1,opt,name=channel" json:"channel,omitempty"`
+	// A marshaled raftpb.Message of etcd/raft
+	Message []byte `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (m *StepRequest) Reset()                    { *m = StepRequest{} }
+func (m *StepRequest) String() string            { return proto.CompactTextString(m) }
+func (*StepRequest) ProtoMessage()               {}
+func (*StepRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{0} }
+
+func (m *StepRequest) GetChannel() string {
+	if m != nil {
+		return m.Channel
+	}
+	return ""
+}
+
+func (m *StepRequest) GetMessage() []byte {
+	if m != nil {
+		return m.Message
+	}
+	return nil
+}
+
+type StepResponse struct {
+}
+
+func (m *StepResponse) Reset()                    { *m = StepResponse{} }
+func (m *StepResponse) String() string            { return proto.CompactTextString(m) }
+func (*StepResponse) ProtoMessage()               {}
+func (*StepResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{1} }
+
+type SubmitRequest struct {
+	Channel string `protobuf:"bytes,1,opt,name=channel" json:"channel,omitempty"`
+	// The config sequence at which the content was validated
+	ConfigSeq uint64           `protobuf:"varint,2,opt,name=config_seq,json=configSeq" json:"config_seq,omitempty"`
+	Content   *common.Envelope `protobuf:"bytes,3,opt,name=content" json:"content,omitempty"`
+	IsConfig  bool             `protobuf:"varint,4,opt,name=is_config,json=isConfig" json:"is_config,omitempty"`
+}
+
+func (m *SubmitRequest) Reset()                    { *m = SubmitRequest{} }
+func (m *SubmitRequest) String() string            { return proto.CompactTextString(m) }
+func (*SubmitRequest) ProtoMessage()               {}
+func (*SubmitRequest) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{2} }
+
+func (m *SubmitRequest) GetChannel() string {
+	if m != nil {
+		return m.Channel
+	}
+	return ""
+}
+
+func (m *SubmitRequest) GetConfigSeq() uint64 {
+	if m != nil {
+		return m.ConfigSeq
+	}
+	return 0
+}
+
+func (m *SubmitRequest) GetContent() *common.Envelope {
+	if m != nil {
+		return m.Content
+	}
+	return nil
+}
+
+func (m *SubmitRequest) GetIsConfig() bool {
+	if m != nil {
+		return m.IsConfig
+	}
+	return false
+}
+
+type SubmitResponse struct {
+}
+
+func (m *SubmitResponse) Reset()                    { *m = SubmitResponse{} }
+func (m *SubmitResponse) String() string            { return proto.CompactTextString(m) }
+func (*SubmitResponse) ProtoMessage()               {}
+func (*SubmitResponse) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{3} }
+
+// SnapshotData is the data of the snapshots of the raft log, which record the last block
+// written to the ledger before the log was compacted. A consenter which receives a snapshot
+// beyond its ledger pulls the blocks up to that block from the other consenters
+type SnapshotData struct {
+	BlockNumber uint64 `protobuf:"varint,1,opt,name=block_number,json=blockNumber" json:"block_number,omitempty"`
+	BlockHash   []byte `protobuf:"bytes,2,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+}
+
+func (m *SnapshotData) Reset()                    { *m = SnapshotData{} }
+func (m *SnapshotData) String() string            { return proto.CompactTextString(m) }
+func (*SnapshotData) ProtoMessage()               {}
+func (*SnapshotData) Descriptor() ([]byte, []int) { return fileDescriptor1, []int{4} }
+
+func (m *SnapshotData) GetBlockNumber() uint64 {
+	if m != nil {
+		return m.BlockNumber
+	}
+	return 0
+}
+
+func (m *SnapshotData) GetBlockHash() []byte {
+	if m != nil {
+		return m.BlockHash
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*StepRequest)(nil), "raft.StepRequest")
+	proto.RegisterType((*StepResponse)(nil), "raft.StepResponse")
+	proto.RegisterType((*SubmitRequest)(nil), "raft.SubmitRequest")
+	proto.RegisterType((*SubmitResponse)(nil), "raft.SubmitResponse")
+	proto.RegisterType((*SnapshotData)(nil), "raft.SnapshotData")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for Cluster service
+
+type ClusterClient interface {
+	// Step passes a raft protocol message to the consenter
+	Step(ctx context.Context, in *StepRequest, opts ...grpc.CallOption) (*StepResponse, error)
+	// Submit forwards a transaction or a config update to the consenter that is the raft leader
+	Submit(ctx context.Context, in *SubmitRequest, opts ...grpc.CallOption) (*SubmitResponse, error)
+}
+
+type clusterClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewClusterClient(cc *grpc.ClientConn) ClusterClient {
+	return &clusterClient{cc}
+}
+
+func (c *clusterClient) Step(ctx context.Context, in *StepRequest, opts ...grpc.CallOption) (*StepResponse, error) {
+	out := new(StepResponse)
+	err := grpc.Invoke(ctx, "/raft.Cluster/Step", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *clusterClient) Submit(ctx context.Context, in *SubmitRequest, opts ...grpc.CallOption) (*SubmitResponse, error) {
+	out := new(SubmitResponse)
+	err := grpc.Invoke(ctx, "/raft.Cluster/Submit", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Cluster service
+
+type ClusterServer interface {
+	// Step passes a raft protocol message to the consenter
+	Step(context.Context, *StepRequest) (*StepResponse, error)
+	// Submit forwards a transaction or a config update to the consenter that is the raft leader
+	Submit(context.Context, *SubmitRequest) (*SubmitResponse, error)
+}
+
+func RegisterClusterServer(s *grpc.Server, srv ClusterServer) {
+	s.RegisterService(&_Cluster_serviceDesc, srv)
+}
+
+func _Cluster_Step_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StepRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServer).Step(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/raft.Cluster/Step",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServer).Step(ctx, req.(*StepRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Cluster_Submit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ClusterServer).Submit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/raft.Cluster/Submit",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ClusterServer).Submit(ctx, req.(*SubmitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Cluster_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "raft.Cluster",
+	HandlerType: (*ClusterServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Step",
+			Handler:    _Cluster_Step_Handler,
+		},
+		{
+			MethodName: "Submit",
+			Handler:    _Cluster_Submit_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "orderer/raft/raft.proto",
+}
+
+func init() { proto.RegisterFile("orderer/raft/raft.proto", fileDescriptor1) }
+
+var fileDescriptor1 = []byte{
+	// 365 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x92, 0xc1, 0x8e, 0x9b, 0x30,
+	0x10, 0x86, 0x4b, 0x8b, 0x92, 0x30, 0xa1, 0x51, 0xea, 0x54, 0x2a, 0x4a, 0x55, 0x89, 0x72, 0x42,
+	0x39, 0x80, 0x9a, 0xaa, 0x0f, 0xd0, 0xa6, 0x95, 0x7a, 0xaa, 0x2a, 0xb8, 0xed, 0x05, 0x19, 0x32,
+	0x01, 0xb4, 0x60, 0x83, 0x6d, 0x56, 0xda, 0xc7, 0xd8, 0x37, 0x5e, 0x61, 0x83, 0xc4, 0x9e, 0xf6,
+	0x02, 0x9e, 0x7f, 0xfc, 0x8f, 0x3e, 0xfd, 0x1e, 0xf8, 0xc4, 0xc5, 0x15, 0x05, 0x8a, 0x58, 0xd0,
+	0x9b, 0xd2, 0x9f, 0xa8, 0x13, 0x5c, 0x71, 0x62, 0x8f, 0xe7, 0xe3, 0xa1, 0xe0, 0x6d, 0xcb, 0x59,
+	0x6c, 0x7e, 0xa6, 0x15, 0xfc, 0x84, 0x6d, 0xaa, 0xb0, 0x4b, 0xb0, 0x1f, 0x50, 0x2a, 0xe2, 0xc1,
+	0xba, 0xa8, 0x28, 0x63, 0xd8, 0x78, 0x96, 0x6f, 0x85, 0x4e, 0x32, 0x97, 0x63, 0xa7, 0x45, 0x29,
+	0x69, 0x89, 0xde, 0x5b, 0xdf, 0x0a, 0xdd, 0x64, 0x2e, 0x83, 0x1d, 0xb8, 0x66, 0x84, 0xec, 0x38,
+	0x93, 0x18, 0x3c, 0x59, 0xf0, 0x3e, 0x1d, 0xf2, 0xb6, 0x56, 0xaf, 0x4f, 0xfd, 0x02, 0x50, 0x70,
+	0x76, 0xab, 0xcb, 0x4c, 0x62, 0xaf, 0x07, 0xdb, 0x89, 0x63, 0x94, 0x14, 0x7b, 0x72, 0x82, 0x75,
+	0xc1, 0x99, 0x42, 0xa6, 0xbc, 0x77, 0xbe, 0x15, 0x6e, 0xcf, 0xfb, 0x68, 0xa2, 0xff, 0xc3, 0x1e,
+	0xb0, 0xe1, 0x1d, 0x26, 0xf3, 0x05, 0xf2, 0x19, 0x9c, 0x5a, 0x66, 0xc6, 0xeb, 0xd9, 0xbe, 0x15,
+	0x6e, 0x92, 0x4d, 0x2d, 0x2f, 0xba, 0x0e, 0xf6, 0xb0, 0x9b, 0x91, 0x26, 0xca, 0xff, 0xe0, 0xa6,
+	0x8c, 0x76, 0xb2, 0xe2, 0xea, 0x37, 0x55, 0x94, 0x7c, 0x05, 0x37, 0x6f, 0x78, 0x71, 0x9f, 0xb1,
+	0xa1, 0xcd, 0x51, 0x68, 0x50, 0x3b, 0xd9, 0x6a, 0xed, 0x9f, 0x96, 0x46, 0x58, 0x73, 0xa5, 0xa2,
+	0xb2, 0x9a, 0x52, 0x70, 0xb4, 0xf2, 0x97, 0xca, 0xea, 0xdc, 0xc3, 0xfa, 0xd2, 0x0c, 0x52, 0xa1,
+	0x20, 0x31, 0xd8, 0x63, 0x24, 0xe4, 0x43, 0xa4, 0x5f, 0x61, 0x91, 0xf0, 0x91, 0x2c, 0xa5, 0x89,
+	0xe5, 0x0d, 0xf9, 0x01, 0x2b, 0xc3, 0x47, 0x0e, 0x53, 0x7f, 0x19, 0xe0, 0xf1, 0xe3, 0x4b, 0x71,
+	0xb6, 0xfd, 0xca, 0xe0, 0xc4, 0x45, 0x19, 0x55, 0x8f, 0x1d, 0x8a, 0x06, 0xaf, 0x25, 0x8a, 0xe8,
+	0x46, 0x73, 0x51, 0x17, 0xe6, 0x75, 0x65, 0x34, 0x6d, 0x84, 0xb6, 0xdf, 0x7d, 0x2b, 0x6b, 0x55,
+	0x0d, 0xf9, 0x18, 0x61, 0xbc, 0xb0, 0xc4, 0xc6, 0x12, 0x1b, 0x4b, 0xbc, 0x5c, 0xa2, 0x7c, 0xa5,
+	0xc5, 0xef, 0xcf, 0x03, 0x00, 0xd5, 0x6d, 0xd6, 0xe3, 0x5b, 0x02, 0x00, 0x00,
+}
@@ -36,6 +36,7 @@ It has these top-level messages:
 	GetStateByRange
 	GetQueryResult
 	GetHistoryForKey
+	HistoryQueryMetadata
 	QueryStateNext
 	QueryStateClose
 	QueryResultBytes
@@ -18,6 +18,26 @@ var _ = proto.Marshal
 var _ = fmt.Errorf
 var _ = math.Inf
 
+// MetaDataKeys are the names of the entries in the metadata of a key which are
+// known to the peer
+type MetaDataKeys int32
+
+const (
+	MetaDataKeys_VALIDATION_PARAMETER MetaDataKeys = 0
+)
+
+var MetaDataKeys_name = map[int32]string{
+	0: "VALIDATION_PARAMETER",
+}
+var MetaDataKeys_value = map[string]int32{
+	"VALIDATION_PARAMETER": 0,
+}
+
+func (x MetaDataKeys) String() string {
+	return proto.EnumName(MetaDataKeys_name, int32(x))
+}
+func (MetaDataKeys) EnumDescriptor() ([]byte, []int) { return fileDescriptor3, []int{0} }
+
 type ChaincodeMessage_Type int32
 
 const (
@@ -40,6 +60,8 @@ const (
 	ChaincodeMessage_QUERY_STATE_CLOSE   ChaincodeMessage_Type = 17
 	ChaincodeMessage_KEEPALIVE           ChaincodeMessage_Type = 18
 	ChaincodeMessage_GET_HISTORY_FOR_KEY ChaincodeMessage_Type = 19
+	ChaincodeMessage_GET_STATE_METADATA  ChaincodeMessage_Type = 20
+	ChaincodeMessage_PUT_STATE_METADATA  ChaincodeMessage_Type = 21
 )
 
 var ChaincodeMessage_Type_name = map[int32]string{
@@ -62,6 +84,8 @@ var ChaincodeMessage_Type_name = map[int32]string{
 	17: "QUERY_STATE_CLOSE",
 	18: "KEEPALIVE",
 	19: "GET_HISTORY_FOR_KEY",
+	20: "GET_STATE_METADATA",
+	21: "PUT_STATE_METADATA",
 }
 var ChaincodeMessage_Type_value = map[string]int32{
 	"UNDEFINED":           0,
@@ -83,6 +107,8 @@ var ChaincodeMessage_Type_value = map[string]int32{
 	"QUERY_STATE_CLOSE":   17,
 	"KEEPALIVE":           18,
 	"GET_HISTORY_FOR_KEY": 19,
+	"GET_STATE_METADATA":  20,
+	"PUT_STATE_METADATA":  21,
 }
 
 func (x ChaincodeMessage_Type) String() string {
@@ -238,16 +264,122 @@ func (m *DelState) GetCollection() string {
 	return ""
 }
 
+// GetStateMetadata is the payload of a ChaincodeMessage. It requests the
+// metadata of a key, which is returned as a marshalled StateMetadataResult
+type GetStateMetadata struct {
+	Key        string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	Collection string `protobuf:"bytes,2,opt,name=collection" json:"collection,omitempty"`
+}
+
+func (m *GetStateMetadata) Reset()                    { *m = GetStateMetadata{} }
+func (m *GetStateMetadata) String() string            { return proto.CompactTextString(m) }
+func (*GetStateMetadata) ProtoMessage()               {}
+func (*GetStateMetadata) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{4} }
+
+func (m *GetStateMetadata) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *GetStateMetadata) GetCollection() string {
+	if m != nil {
+		return m.Collection
+	}
+	return ""
+}
+
+// PutStateMetadata is the payload of a ChaincodeMessage. It sets the value
+// of an entry in the metadata of a key
+type PutStateMetadata struct {
+	Key        string         `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	Collection string         `protobuf:"bytes,2,opt,name=collection" json:"collection,omitempty"`
+	Metadata   *StateMetadata `protobuf:"bytes,3,opt,name=metadata" json:"metadata,omitempty"`
+}
+
+func (m *PutStateMetadata) Reset()                    { *m = PutStateMetadata{} }
+func (m *PutStateMetadata) String() string            { return proto.CompactTextString(m) }
+func (*PutStateMetadata) ProtoMessage()               {}
+func (*PutStateMetadata) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{5} }
+
+func (m *PutStateMetadata) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *PutStateMetadata) GetCollection() string {
+	if m != nil {
+		return m.Collection
+	}
+	return ""
+}
+
+func (m *PutStateMetadata) GetMetadata() *StateMetadata {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+// StateMetadata is an entry in the metadata of a key, such as its validation parameter
+type StateMetadata struct {
+	Metakey string `protobuf:"bytes,1,opt,name=metakey" json:"metakey,omitempty"`
+	Value   []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *StateMetadata) Reset()                    { *m = StateMetadata{} }
+func (m *StateMetadata) String() string            { return proto.CompactTextString(m) }
+func (*StateMetadata) ProtoMessage()               {}
+func (*StateMetadata) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{6} }
+
+func (m *StateMetadata) GetMetakey() string {
+	if m != nil {
+		return m.Metakey
+	}
+	return ""
+}
+
+func (m *StateMetadata) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+// StateMetadataResult holds all the entries in the metadata of a key
+type StateMetadataResult struct {
+	Entries []*StateMetadata `protobuf:"bytes,1,rep,name=entries" json:"entries,omitempty"`
+}
+
+func (m *StateMetadataResult) Reset()                    { *m = StateMetadataResult{} }
+func (m *StateMetadataResult) String() string            { return proto.CompactTextString(m) }
+func (*StateMetadataResult) ProtoMessage()               {}
+func (*StateMetadataResult) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{7} }
+
+func (m *StateMetadataResult) GetEntries() []*StateMetadata {
+	if m != nil {
+		return m.Entries
+	}
+	return nil
+}
+
+// GetStateByRange is the payload of a ChaincodeMessage. It contains a start key and
+// a end key required to execute range query. If the metadata (a marshalled QueryMetadata)
+// is set, a single page of the results is returned
 type GetStateByRange struct {
 	StartKey   string `protobuf:"bytes,1,opt,name=startKey" json:"startKey,omitempty"`
 	EndKey     string `protobuf:"bytes,2,opt,name=endKey" json:"endKey,omitempty"`
 	Collection string `protobuf:"bytes,3,opt,name=collection" json:"collection,omitempty"`
+	Metadata   []byte `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`
 }
 
 func (m *GetStateByRange) Reset()                    { *m = GetStateByRange{} }
 func (m *GetStateByRange) String() string            { return proto.CompactTextString(m) }
 func (*GetStateByRange) ProtoMessage()               {}
-func (*GetStateByRange) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{4} }
+func (*GetStateByRange) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{8} }
 
 func (m *GetStateByRange) GetStartKey() string {
 	if m != nil {
@@ -270,15 +402,26 @@ func (m *GetStateByRange) GetCollection() string {
 	return ""
 }
 
+func (m *GetStateByRange) GetMetadata() []byte {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+// GetQueryResult is the payload of a ChaincodeMessage. It contains a query string
+// in the form that is supported by the underlying state database. If the metadata
+// (a marshalled QueryMetadata) is set, a single page of the results is returned
 type GetQueryResult struct {
 	Query      string `protobuf:"bytes,1,opt,name=query" json:"query,omitempty"`
 	Collection string `protobuf:"bytes,2,opt,name=collection" json:"collection,omitempty"`
+	Metadata   []byte `protobuf:"bytes,3,opt,name=metadata,proto3" json:"metadata,omitempty"`
 }
 
 func (m *GetQueryResult) Reset()                    { *m = GetQueryResult{} }
 func (m *GetQueryResult) String() string            { return proto.CompactTextString(m) }
 func (*GetQueryResult) ProtoMessage()               {}
-func (*GetQueryResult) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{5} }
+func (*GetQueryResult) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{9} }
 
 func (m *GetQueryResult) GetQuery() string {
 	if m != nil {
@@ -294,14 +437,53 @@ func (m *GetQueryResult) GetCollection() string {
 	return ""
 }
 
+func (m *GetQueryResult) GetMetadata() []byte {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+// QueryMetadata is the metadata of a GetStateByRange or a GetQueryResult request
+// which asks for a page of the results. The bookmark is the one returned in the
+// QueryResponseMetadata of the previous page, or empty for the first page
+type QueryMetadata struct {
+	PageSize int32  `protobuf:"varint,1,opt,name=pageSize" json:"pageSize,omitempty"`
+	Bookmark string `protobuf:"bytes,2,opt,name=bookmark" json:"bookmark,omitempty"`
+}
+
+func (m *QueryMetadata) Reset()                    { *m = QueryMetadata{} }
+func (m *QueryMetadata) String() string            { return proto.CompactTextString(m) }
+func (*QueryMetadata) ProtoMessage()               {}
+func (*QueryMetadata) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{10} }
+
+func (m *QueryMetadata) GetPageSize() int32 {
+	if m != nil {
+		return m.PageSize
+	}
+	return 0
+}
+
+func (m *QueryMetadata) GetBookmark() string {
+	if m != nil {
+		return m.Bookmark
+	}
+	return ""
+}
+
+// GetHistoryForKey is the payload of a ChaincodeMessage. It contains the key whose
+// history of modifications is requested. If the metadata (a marshalled
+// HistoryQueryMetadata) is set, the history is restricted, ordered and paginated
+// as requested
 type GetHistoryForKey struct {
-	Key string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	Key      string `protobuf:"bytes,1,opt,name=key" json:"key,omitempty"`
+	Metadata []byte `protobuf:"bytes,2,opt,name=metadata,proto3" json:"metadata,omitempty"`
 }
 
 func (m *GetHistoryForKey) Reset()                    { *m = GetHistoryForKey{} }
 func (m *GetHistoryForKey) String() string            { return proto.CompactTextString(m) }
 func (*GetHistoryForKey) ProtoMessage()               {}
-func (*GetHistoryForKey) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{6} }
+func (*GetHistoryForKey) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{11} }
 
 func (m *GetHistoryForKey) GetKey() string {
 	if m != nil {
@@ -310,6 +492,84 @@ func (m *GetHistoryForKey) GetKey() string {
 	return ""
 }
 
+func (m *GetHistoryForKey) GetMetadata() []byte {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+// HistoryQueryMetadata is the metadata of a GetHistoryForKey request. It restricts
+// the history to the modifications committed in the blocks [startBlock, endBlock)
+// by the transactions whose timestamps are in [startTime, endTime). A zero endBlock
+// and unset timestamps impose no bound. If descending is set, the most recent
+// modification is returned first. If the pageSize is greater than zero, a single
+// page of the history is returned; the bookmark is the one returned in the
+// QueryResponseMetadata of the previous page, or empty for the first page
+type HistoryQueryMetadata struct {
+	StartBlock uint64                      `protobuf:"varint,1,opt,name=startBlock" json:"startBlock,omitempty"`
+	EndBlock   uint64                      `protobuf:"varint,2,opt,name=endBlock" json:"endBlock,omitempty"`
+	StartTime  *google_protobuf1.Timestamp `protobuf:"bytes,3,opt,name=startTime" json:"startTime,omitempty"`
+	EndTime    *google_protobuf1.Timestamp `protobuf:"bytes,4,opt,name=endTime" json:"endTime,omitempty"`
+	Descending bool                        `protobuf:"varint,5,opt,name=descending" json:"descending,omitempty"`
+	PageSize   int32                       `protobuf:"varint,6,opt,name=pageSize" json:"pageSize,omitempty"`
+	Bookmark   string                      `protobuf:"bytes,7,opt,name=bookmark" json:"bookmark,omitempty"`
+}
+
+func (m *HistoryQueryMetadata) Reset()                    { *m = HistoryQueryMetadata{} }
+func (m *HistoryQueryMetadata) String() string            { return proto.CompactTextString(m) }
+func (*HistoryQueryMetadata) ProtoMessage()               {}
+func (*HistoryQueryMetadata) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{12} }
+
+func (m *HistoryQueryMetadata) GetStartBlock() uint64 {
+	if m != nil {
+		return m.StartBlock
+	}
+	return 0
+}
+
+func (m *HistoryQueryMetadata) GetEndBlock() uint64 {
+	if m != nil {
+		return m.EndBlock
+	}
+	return 0
+}
+
+func (m *HistoryQueryMetadata) GetStartTime() *google_protobuf1.Timestamp {
+	if m != nil {
+		return m.StartTime
+	}
+	return nil
+}
+
+func (m *HistoryQueryMetadata) GetEndTime() *google_protobuf1.Timestamp {
+	if m != nil {
+		return m.EndTime
+	}
+	return nil
+}
+
+func (m *HistoryQueryMetadata) GetDescending() bool {
+	if m != nil {
+		return m.Descending
+	}
+	return false
+}
+
+func (m *HistoryQueryMetadata) GetPageSize() int32 {
+	if m != nil {
+		return m.PageSize
+	}
+	return 0
+}
+
+func (m *HistoryQueryMetadata) GetBookmark() string {
+	if m != nil {
+		return m.Bookmark
+	}
+	return ""
+}
+
 type QueryStateNext struct {
 	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
 }
@@ -317,7 +577,7 @@ type QueryStateNext struct {
 func (m *QueryStateNext) Reset()                    { *m = QueryStateNext{} }
 func (m *QueryStateNext) String() string            { return proto.CompactTextString(m) }
 func (*QueryStateNext) ProtoMessage()               {}
-func (*QueryStateNext) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{7} }
+func (*QueryStateNext) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{13} }
 
 func (m *QueryStateNext) GetId() string {
 	if m != nil {
@@ -333,7 +593,7 @@ type QueryStateClose struct {
 func (m *QueryStateClose) Reset()                    { *m = QueryStateClose{} }
 func (m *QueryStateClose) String() string            { return proto.CompactTextString(m) }
 func (*QueryStateClose) ProtoMessage()               {}
-func (*QueryStateClose) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{8} }
+func (*QueryStateClose) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{14} }
 
 func (m *QueryStateClose) GetId() string {
 	if m != nil {
@@ -349,7 +609,7 @@ type QueryResultBytes struct {
 func (m *QueryResultBytes) Reset()                    { *m = QueryResultBytes{} }
 func (m *QueryResultBytes) String() string            { return proto.CompactTextString(m) }
 func (*QueryResultBytes) ProtoMessage()               {}
-func (*QueryResultBytes) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{9} }
+func (*QueryResultBytes) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{15} }
 
 func (m *QueryResultBytes) GetResultBytes() []byte {
 	if m != nil {
@@ -358,16 +618,20 @@ func (m *QueryResultBytes) GetResultBytes() []byte {
 	return nil
 }
 
+// QueryResponse is returned by the peer as a result of a GetStateByRange,
+// GetQueryResult, GetHistoryForKey or a QueryStateNext. The metadata (a marshalled
+// QueryResponseMetadata) is set in the response to a paginated query
 type QueryResponse struct {
-	Results []*QueryResultBytes `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
-	HasMore bool                `protobuf:"varint,2,opt,name=has_more,json=hasMore" json:"has_more,omitempty"`
-	Id      string              `protobuf:"bytes,3,opt,name=id" json:"id,omitempty"`
+	Results  []*QueryResultBytes `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
+	HasMore  bool                `protobuf:"varint,2,opt,name=has_more,json=hasMore" json:"has_more,omitempty"`
+	Id       string              `protobuf:"bytes,3,opt,name=id" json:"id,omitempty"`
+	Metadata []byte              `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`
 }
 
 func (m *QueryResponse) Reset()                    { *m = QueryResponse{} }
 func (m *QueryResponse) String() string            { return proto.CompactTextString(m) }
 func (*QueryResponse) ProtoMessage()               {}
-func (*QueryResponse) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{10} }
+func (*QueryResponse) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{16} }
 
 func (m *QueryResponse) GetResults() []*QueryResultBytes {
 	if m != nil {
@@ -390,18 +654,60 @@ func (m *QueryResponse) GetId() string {
 	return ""
 }
 
+func (m *QueryResponse) GetMetadata() []byte {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+// QueryResponseMetadata is the metadata of a page of query results. It holds the
+// number of the records fetched in the page and the bookmark to be used for
+// requesting the next page
+type QueryResponseMetadata struct {
+	FetchedRecordsCount int32  `protobuf:"varint,1,opt,name=fetched_records_count,json=fetchedRecordsCount" json:"fetched_records_count,omitempty"`
+	Bookmark            string `protobuf:"bytes,2,opt,name=bookmark" json:"bookmark,omitempty"`
+}
+
+func (m *QueryResponseMetadata) Reset()                    { *m = QueryResponseMetadata{} }
+func (m *QueryResponseMetadata) String() string            { return proto.CompactTextString(m) }
+func (*QueryResponseMetadata) ProtoMessage()               {}
+func (*QueryResponseMetadata) Descriptor() ([]byte, []int) { return fileDescriptor3, []int{17} }
+
+func (m *QueryResponseMetadata) GetFetchedRecordsCount() int32 {
+	if m != nil {
+		return m.FetchedRecordsCount
+	}
+	return 0
+}
+
+func (m *QueryResponseMetadata) GetBookmark() string {
+	if m != nil {
+		return m.Bookmark
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*ChaincodeMessage)(nil), "protos.ChaincodeMessage")
 	proto.RegisterType((*GetState)(nil), "protos.GetState")
 	proto.RegisterType((*PutState)(nil), "protos.PutState")
 	proto.RegisterType((*DelState)(nil), "protos.DelState")
+	proto.RegisterType((*GetStateMetadata)(nil), "protos.GetStateMetadata")
+	proto.RegisterType((*PutStateMetadata)(nil), "protos.PutStateMetadata")
+	proto.RegisterType((*StateMetadata)(nil), "protos.StateMetadata")
+	proto.RegisterType((*StateMetadataResult)(nil), "protos.StateMetadataResult")
 	proto.RegisterType((*GetStateByRange)(nil), "protos.GetStateByRange")
 	proto.RegisterType((*GetQueryResult)(nil), "protos.GetQueryResult")
+	proto.RegisterType((*QueryMetadata)(nil), "protos.QueryMetadata")
 	proto.RegisterType((*GetHistoryForKey)(nil), "protos.GetHistoryForKey")
+	proto.RegisterType((*HistoryQueryMetadata)(nil), "protos.HistoryQueryMetadata")
 	proto.RegisterType((*QueryStateNext)(nil), "protos.QueryStateNext")
 	proto.RegisterType((*QueryStateClose)(nil), "protos.QueryStateClose")
 	proto.RegisterType((*QueryResultBytes)(nil), "protos.QueryResultBytes")
 	proto.RegisterType((*QueryResponse)(nil), "protos.QueryResponse")
+	proto.RegisterType((*QueryResponseMetadata)(nil), "protos.QueryResponseMetadata")
+	proto.RegisterEnum("protos.MetaDataKeys", MetaDataKeys_name, MetaDataKeys_value)
 	proto.RegisterEnum("protos.ChaincodeMessage_Type", ChaincodeMessage_Type_name, ChaincodeMessage_Type_value)
 }
 
@@ -512,57 +818,76 @@ var _ChaincodeSupport_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("peer/chaincode_shim.proto", fileDescriptor3) }
 
 var fileDescriptor3 = []byte{
-	// 825 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x95, 0x51, 0x6f, 0xe2, 0x46,
-	0x10, 0xc7, 0x8f, 0x00, 0xc1, 0x0c, 0x09, 0xec, 0x6d, 0xae, 0xa9, 0x0f, 0xe9, 0x5a, 0x8a, 0xfa,
-	0x40, 0x5f, 0xa0, 0xa5, 0x7d, 0xe8, 0xc3, 0x49, 0x15, 0xc1, 0x1b, 0x62, 0x85, 0xd8, 0xdc, 0xda,
-	0x39, 0x1d, 0x7d, 0xb1, 0x1c, 0xbc, 0x67, 0xac, 0x1a, 0xaf, 0x6b, 0x2f, 0xa7, 0xf3, 0x67, 0xe8,
-	0x07, 0xeb, 0xd7, 0xaa, 0xd6, 0xc6, 0x84, 0x23, 0x8a, 0x4e, 0xea, 0x13, 0xfe, 0xcf, 0xfc, 0x66,
-	0xe6, 0x3f, 0xd6, 0xb2, 0x86, 0xd7, 0x31, 0x63, 0xc9, 0x68, 0xb5, 0x76, 0x83, 0x68, 0xc5, 0x3d,
-	0xe6, 0xa4, 0xeb, 0x60, 0x33, 0x8c, 0x13, 0x2e, 0x38, 0x3e, 0xcd, 0x7f, 0xd2, 0x6e, 0xf7, 0x08,
-	0x61, 0x9f, 0x58, 0x24, 0x0a, 0xa6, 0x7b, 0x91, 0xe7, 0xe2, 0x84, 0xc7, 0x3c, 0x75, 0xc3, 0x5d,
-	0xf0, 0x7b, 0x9f, 0x73, 0x3f, 0x64, 0xa3, 0x5c, 0x3d, 0x6c, 0x3f, 0x8e, 0x44, 0xb0, 0x61, 0xa9,
-	0x70, 0x37, 0x71, 0x01, 0xf4, 0xff, 0xa9, 0x03, 0x9a, 0x96, 0xfd, 0xee, 0x58, 0x9a, 0xba, 0x3e,
-	0xc3, 0xbf, 0x40, 0x4d, 0x64, 0x31, 0x53, 0x2b, 0xbd, 0xca, 0xa0, 0x3d, 0x7e, 0x53, 0xa0, 0xe9,
-	0xf0, 0x98, 0x1b, 0xda, 0x59, 0xcc, 0x68, 0x8e, 0xe2, 0xdf, 0xa1, 0xb9, 0x6f, 0xad, 0x9e, 0xf4,
-	0x2a, 0x83, 0xd6, 0xb8, 0x3b, 0x2c, 0x86, 0x0f, 0xcb, 0xe1, 0x43, 0xbb, 0x24, 0xe8, 0x23, 0x8c,
-	0x55, 0x68, 0xc4, 0x6e, 0x16, 0x72, 0xd7, 0x53, 0xab, 0xbd, 0xca, 0xe0, 0x8c, 0x96, 0x12, 0x63,
-	0xa8, 0x89, 0xcf, 0x81, 0xa7, 0xd6, 0x7a, 0x95, 0x41, 0x93, 0xe6, 0xcf, 0x78, 0x0c, 0x4a, 0xb9,
-	0xa2, 0x5a, 0xcf, 0xc7, 0x5c, 0x96, 0xf6, 0xac, 0xc0, 0x8f, 0x98, 0xb7, 0xd8, 0x65, 0xe9, 0x9e,
-	0xc3, 0x7f, 0x40, 0xe7, 0xe8, 0x95, 0xa9, 0xa7, 0x5f, 0x96, 0xee, 0x37, 0x23, 0x32, 0x4b, 0xdb,
-	0xab, 0x2f, 0x34, 0x7e, 0x03, 0xb0, 0x5a, 0xbb, 0x51, 0xc4, 0x42, 0x27, 0xf0, 0xd4, 0x46, 0x6e,
-	0xa7, 0xb9, 0x8b, 0xe8, 0x5e, 0xff, 0xdf, 0x13, 0xa8, 0xc9, 0x57, 0x81, 0xcf, 0xa1, 0x79, 0x6f,
-	0x68, 0xe4, 0x5a, 0x37, 0x88, 0x86, 0x5e, 0xe0, 0x33, 0x50, 0x28, 0x99, 0xe9, 0x96, 0x4d, 0x28,
-	0xaa, 0xe0, 0x36, 0x40, 0xa9, 0x88, 0x86, 0x4e, 0xb0, 0x02, 0x35, 0xdd, 0xd0, 0x6d, 0x54, 0xc5,
-	0x4d, 0xa8, 0x53, 0x32, 0xd1, 0x96, 0xa8, 0x86, 0x3b, 0xd0, 0xb2, 0xe9, 0xc4, 0xb0, 0x26, 0x53,
-	0x5b, 0x37, 0x0d, 0x54, 0x97, 0x2d, 0xa7, 0xe6, 0xdd, 0x62, 0x4e, 0x6c, 0xa2, 0xa1, 0x53, 0x89,
-	0x12, 0x4a, 0x4d, 0x8a, 0x1a, 0x32, 0x33, 0x23, 0xb6, 0x63, 0xd9, 0x13, 0x9b, 0x20, 0x45, 0xca,
-	0xc5, 0x7d, 0x29, 0x9b, 0x52, 0x6a, 0x64, 0xbe, 0x93, 0x80, 0x5f, 0x01, 0xd2, 0x8d, 0xf7, 0xe6,
-	0x2d, 0x71, 0xa6, 0x37, 0x13, 0xdd, 0x98, 0x9a, 0x1a, 0x41, 0xad, 0xc2, 0xa0, 0xb5, 0x30, 0x0d,
-	0x8b, 0xa0, 0x73, 0x7c, 0x09, 0x78, 0xdf, 0xd0, 0xb9, 0x5a, 0x3a, 0x74, 0x62, 0xcc, 0x08, 0x6a,
-	0xcb, 0x5a, 0x19, 0x7f, 0x77, 0x4f, 0xe8, 0xd2, 0xa1, 0xc4, 0xba, 0x9f, 0xdb, 0xa8, 0x23, 0xa3,
-	0x45, 0xa4, 0xe0, 0x0d, 0xf2, 0xc1, 0x46, 0x08, 0x7f, 0x03, 0x2f, 0x0f, 0xa3, 0xd3, 0xb9, 0x69,
-	0x11, 0xf4, 0x52, 0xba, 0xb9, 0x25, 0x64, 0x31, 0x99, 0xeb, 0xef, 0x09, 0xc2, 0xf8, 0x5b, 0xb8,
-	0x90, 0x1d, 0x6f, 0x74, 0xcb, 0x36, 0xe9, 0xd2, 0xb9, 0x36, 0xa9, 0x73, 0x4b, 0x96, 0xe8, 0xa2,
-	0xff, 0x16, 0x94, 0x19, 0x13, 0x96, 0x70, 0x05, 0xc3, 0x08, 0xaa, 0x7f, 0xb1, 0x2c, 0x3f, 0x83,
-	0x4d, 0x2a, 0x1f, 0xf1, 0x77, 0x00, 0x2b, 0x1e, 0x86, 0x6c, 0x25, 0x02, 0x1e, 0xe5, 0x87, 0xac,
-	0x49, 0x0f, 0x22, 0x7d, 0x0a, 0xca, 0x62, 0xfb, 0x6c, 0xf5, 0x2b, 0xa8, 0x7f, 0x72, 0xc3, 0x2d,
-	0xcb, 0x0b, 0xcf, 0x68, 0x21, 0x8e, 0x7a, 0x56, 0x9f, 0xf4, 0x7c, 0x0b, 0x8a, 0xc6, 0xc2, 0xff,
-	0xeb, 0x88, 0x41, 0xa7, 0xdc, 0xe7, 0x2a, 0xa3, 0x6e, 0xe4, 0x33, 0xdc, 0x05, 0x25, 0x15, 0x6e,
-	0x22, 0x6e, 0xf7, 0x9d, 0xf6, 0x1a, 0x5f, 0xc2, 0x29, 0x8b, 0x3c, 0x99, 0x29, 0x5a, 0xed, 0xd4,
-	0x57, 0x4d, 0x5e, 0x43, 0x7b, 0xc6, 0xc4, 0xbb, 0x2d, 0x4b, 0x32, 0xca, 0xd2, 0x6d, 0x28, 0xe4,
-	0xb2, 0x7f, 0x4b, 0xb9, 0x1b, 0x51, 0x88, 0xaf, 0xda, 0xfd, 0x11, 0xd0, 0x8c, 0x89, 0x9b, 0x20,
-	0x15, 0x3c, 0xc9, 0xae, 0x79, 0x22, 0x67, 0x3f, 0x59, 0xba, 0xdf, 0x83, 0x76, 0x3e, 0x2a, 0x5f,
-	0xcb, 0x60, 0x9f, 0x05, 0x6e, 0xc3, 0x49, 0xe0, 0xed, 0x90, 0x93, 0xc0, 0xeb, 0xff, 0x00, 0x9d,
-	0x47, 0x62, 0x1a, 0xf2, 0x94, 0x3d, 0x41, 0x7e, 0x03, 0x74, 0xe0, 0xf7, 0x2a, 0x13, 0x2c, 0xc5,
-	0x3d, 0x68, 0x25, 0x8f, 0x32, 0x87, 0xcf, 0xe8, 0x61, 0xa8, 0x1f, 0xc1, 0x79, 0x59, 0x15, 0xf3,
-	0x28, 0x65, 0x78, 0x0c, 0x8d, 0x22, 0x2f, 0xf1, 0xea, 0xa0, 0x35, 0x56, 0xcb, 0xbf, 0xf4, 0x71,
-	0x77, 0x5a, 0x82, 0xf8, 0x35, 0x28, 0x6b, 0x37, 0x75, 0x36, 0x3c, 0x29, 0xce, 0x82, 0x42, 0x1b,
-	0x6b, 0x37, 0xbd, 0xe3, 0x49, 0xe9, 0xb2, 0x5a, 0xba, 0x1c, 0x7f, 0x38, 0xb8, 0x1c, 0xad, 0x6d,
-	0x1c, 0xf3, 0x44, 0x60, 0x0d, 0x14, 0xca, 0xfc, 0x20, 0x15, 0x2c, 0xc1, 0xea, 0x73, 0x57, 0x63,
-	0xf7, 0xd9, 0x4c, 0xff, 0xc5, 0xa0, 0xf2, 0x73, 0xe5, 0xca, 0x84, 0x3e, 0x4f, 0xfc, 0xe1, 0x3a,
-	0x8b, 0x59, 0x12, 0x32, 0xcf, 0x67, 0xc9, 0xf0, 0xa3, 0xfb, 0x90, 0x04, 0xab, 0xb2, 0x4e, 0xde,
-	0xe6, 0x7f, 0xfe, 0xe4, 0x07, 0x62, 0xbd, 0x7d, 0x18, 0xae, 0xf8, 0x66, 0x74, 0x80, 0x8e, 0x0a,
-	0xb4, 0xb8, 0xd5, 0xd3, 0x91, 0x44, 0x1f, 0x8a, 0x4f, 0xc4, 0xaf, 0xff, 0x05, 0x00, 0x00, 0xff,
-	0xff, 0x18, 0x6a, 0x79, 0xe7, 0x46, 0x06, 0x00, 0x00,
+	// 1129 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x56, 0x5d, 0x53, 0xdb, 0x46,
+	0x17, 0x8e, 0xbf, 0xb0, 0x7c, 0x00, 0xb3, 0x59, 0x20, 0xaf, 0xe2, 0x99, 0xe4, 0x75, 0x7d, 0x45,
+	0x7b, 0x61, 0x37, 0x6e, 0x2e, 0x7a, 0x91, 0x99, 0x54, 0x58, 0x0b, 0xf1, 0x00, 0xb6, 0xb3, 0x16,
+	0x99, 0xd0, 0x1b, 0x8d, 0x90, 0x36, 0xb2, 0x06, 0x59, 0xab, 0x4a, 0xeb, 0x34, 0xee, 0x5d, 0x6f,
+	0xfb, 0x13, 0xfa, 0x57, 0xfa, 0xc3, 0x7a, 0xdb, 0x59, 0x7d, 0x61, 0x9b, 0x02, 0x33, 0xe9, 0x15,
+	0x7e, 0xce, 0x79, 0xce, 0x73, 0x3e, 0x74, 0x96, 0x5d, 0x78, 0x1e, 0x32, 0x16, 0xf5, 0xec, 0x99,
+	0xe5, 0x05, 0x36, 0x77, 0x98, 0x19, 0xcf, 0xbc, 0x79, 0x37, 0x8c, 0xb8, 0xe0, 0x78, 0x2b, 0xf9,
+	0x13, 0xb7, 0x5a, 0x1b, 0x14, 0xf6, 0x99, 0x05, 0x22, 0xe5, 0xb4, 0xf6, 0x13, 0x5f, 0x18, 0xf1,
+	0x90, 0xc7, 0x96, 0x9f, 0x19, 0xff, 0xef, 0x72, 0xee, 0xfa, 0xac, 0x97, 0xa0, 0xeb, 0xc5, 0xa7,
+	0x9e, 0xf0, 0xe6, 0x2c, 0x16, 0xd6, 0x3c, 0x4c, 0x09, 0x9d, 0xbf, 0x6a, 0x80, 0x06, 0xb9, 0xde,
+	0x05, 0x8b, 0x63, 0xcb, 0x65, 0xf8, 0x15, 0x54, 0xc5, 0x32, 0x64, 0x6a, 0xa9, 0x5d, 0x3a, 0x6a,
+	0xf6, 0x5f, 0xa4, 0xd4, 0xb8, 0xbb, 0xc9, 0xeb, 0x1a, 0xcb, 0x90, 0xd1, 0x84, 0x8a, 0x7f, 0x84,
+	0x46, 0x21, 0xad, 0x96, 0xdb, 0xa5, 0xa3, 0xed, 0x7e, 0xab, 0x9b, 0x26, 0xef, 0xe6, 0xc9, 0xbb,
+	0x46, 0xce, 0xa0, 0xb7, 0x64, 0xac, 0x42, 0x3d, 0xb4, 0x96, 0x3e, 0xb7, 0x1c, 0xb5, 0xd2, 0x2e,
+	0x1d, 0xed, 0xd0, 0x1c, 0x62, 0x0c, 0x55, 0xf1, 0xc5, 0x73, 0xd4, 0x6a, 0xbb, 0x74, 0xd4, 0xa0,
+	0xc9, 0x6f, 0xdc, 0x07, 0x25, 0x6f, 0x51, 0xad, 0x25, 0x69, 0x9e, 0xe5, 0xe5, 0x4d, 0x3d, 0x37,
+	0x60, 0xce, 0x24, 0xf3, 0xd2, 0x82, 0x87, 0xdf, 0xc2, 0xde, 0xc6, 0xc8, 0xd4, 0xad, 0xf5, 0xd0,
+	0xa2, 0x33, 0x22, 0xbd, 0xb4, 0x69, 0xaf, 0x61, 0xfc, 0x02, 0xc0, 0x9e, 0x59, 0x41, 0xc0, 0x7c,
+	0xd3, 0x73, 0xd4, 0x7a, 0x52, 0x4e, 0x23, 0xb3, 0x0c, 0x9d, 0xce, 0xdf, 0x65, 0xa8, 0xca, 0x51,
+	0xe0, 0x5d, 0x68, 0x5c, 0x8e, 0x74, 0x72, 0x32, 0x1c, 0x11, 0x1d, 0x3d, 0xc1, 0x3b, 0xa0, 0x50,
+	0x72, 0x3a, 0x9c, 0x1a, 0x84, 0xa2, 0x12, 0x6e, 0x02, 0xe4, 0x88, 0xe8, 0xa8, 0x8c, 0x15, 0xa8,
+	0x0e, 0x47, 0x43, 0x03, 0x55, 0x70, 0x03, 0x6a, 0x94, 0x68, 0xfa, 0x15, 0xaa, 0xe2, 0x3d, 0xd8,
+	0x36, 0xa8, 0x36, 0x9a, 0x6a, 0x03, 0x63, 0x38, 0x1e, 0xa1, 0x9a, 0x94, 0x1c, 0x8c, 0x2f, 0x26,
+	0xe7, 0xc4, 0x20, 0x3a, 0xda, 0x92, 0x54, 0x42, 0xe9, 0x98, 0xa2, 0xba, 0xf4, 0x9c, 0x12, 0xc3,
+	0x9c, 0x1a, 0x9a, 0x41, 0x90, 0x22, 0xe1, 0xe4, 0x32, 0x87, 0x0d, 0x09, 0x75, 0x72, 0x9e, 0x41,
+	0xc0, 0x07, 0x80, 0x86, 0xa3, 0x0f, 0xe3, 0x33, 0x62, 0x0e, 0xde, 0x69, 0xc3, 0xd1, 0x60, 0xac,
+	0x13, 0xb4, 0x9d, 0x16, 0x38, 0x9d, 0x8c, 0x47, 0x53, 0x82, 0x76, 0xf1, 0x33, 0xc0, 0x85, 0xa0,
+	0x79, 0x7c, 0x65, 0x52, 0x6d, 0x74, 0x4a, 0x50, 0x53, 0xc6, 0x4a, 0xfb, 0xfb, 0x4b, 0x42, 0xaf,
+	0x4c, 0x4a, 0xa6, 0x97, 0xe7, 0x06, 0xda, 0x93, 0xd6, 0xd4, 0x92, 0xf2, 0x47, 0xe4, 0xa3, 0x81,
+	0x10, 0x3e, 0x84, 0xa7, 0xab, 0xd6, 0xc1, 0xf9, 0x78, 0x4a, 0xd0, 0x53, 0x59, 0xcd, 0x19, 0x21,
+	0x13, 0xed, 0x7c, 0xf8, 0x81, 0x20, 0x8c, 0xff, 0x07, 0xfb, 0x52, 0xf1, 0xdd, 0x70, 0x6a, 0x8c,
+	0xe9, 0x95, 0x79, 0x32, 0xa6, 0xe6, 0x19, 0xb9, 0x42, 0xfb, 0xeb, 0x25, 0x5c, 0x10, 0x43, 0xd3,
+	0x35, 0x43, 0x43, 0x07, 0xd2, 0x3e, 0xb9, 0xbc, 0x63, 0x3f, 0xec, 0xbc, 0x01, 0xe5, 0x94, 0x89,
+	0xa9, 0xb0, 0x04, 0xc3, 0x08, 0x2a, 0x37, 0x6c, 0x99, 0xec, 0x6c, 0x83, 0xca, 0x9f, 0xf8, 0x25,
+	0x80, 0xcd, 0x7d, 0x9f, 0xd9, 0xc2, 0xe3, 0x41, 0xb2, 0x94, 0x0d, 0xba, 0x62, 0xe9, 0x50, 0x50,
+	0x26, 0x8b, 0x7b, 0xa3, 0x0f, 0xa0, 0xf6, 0xd9, 0xf2, 0x17, 0x2c, 0x09, 0xdc, 0xa1, 0x29, 0xd8,
+	0xd0, 0xac, 0xdc, 0xd1, 0x7c, 0x03, 0x8a, 0xce, 0xfc, 0xaf, 0xad, 0x48, 0x07, 0x94, 0xf7, 0x73,
+	0xc1, 0x84, 0xe5, 0x58, 0xc2, 0xfa, 0x0a, 0x95, 0x5f, 0x01, 0x4d, 0x16, 0xff, 0x55, 0x05, 0xbf,
+	0x02, 0x65, 0x9e, 0x45, 0x27, 0x7d, 0x6e, 0xf7, 0x0f, 0x8b, 0x93, 0xb6, 0x2a, 0x4d, 0x0b, 0x5a,
+	0xe7, 0x2d, 0xec, 0xae, 0x67, 0x55, 0xa1, 0x2e, 0x9d, 0xb7, 0x99, 0x73, 0xf8, 0xef, 0xd3, 0xed,
+	0x9c, 0xc0, 0xfe, 0xba, 0x36, 0x8b, 0x17, 0xbe, 0xc0, 0x3d, 0xa8, 0xb3, 0x40, 0x44, 0x1e, 0x8b,
+	0xd5, 0x52, 0xbb, 0x72, 0x7f, 0x25, 0x39, 0xab, 0xf3, 0x7b, 0x09, 0xf6, 0xf2, 0x41, 0x1e, 0x2f,
+	0xa9, 0x15, 0xb8, 0x0c, 0xb7, 0x40, 0x89, 0x85, 0x15, 0x89, 0xb3, 0xa2, 0x98, 0x02, 0xe3, 0x67,
+	0xb0, 0xc5, 0x02, 0x47, 0x7a, 0xd2, 0x39, 0x64, 0xe8, 0xb1, 0xaf, 0x2d, 0x35, 0x8b, 0x19, 0x55,
+	0x93, 0x46, 0x6e, 0x87, 0x71, 0x0d, 0xcd, 0x53, 0x26, 0xde, 0x2f, 0x58, 0xb4, 0xcc, 0xda, 0x38,
+	0x80, 0xda, 0x2f, 0x12, 0x66, 0xe9, 0x53, 0xf0, 0xe8, 0x77, 0x68, 0x6d, 0x7c, 0x87, 0xd5, 0x1c,
+	0xa7, 0xb0, 0x9b, 0x24, 0x28, 0x06, 0xde, 0x02, 0x25, 0xb4, 0x5c, 0x36, 0xf5, 0x7e, 0x4b, 0xff,
+	0x7b, 0xd7, 0x68, 0x81, 0xa5, 0xef, 0x9a, 0xf3, 0x9b, 0xb9, 0x15, 0xdd, 0x64, 0x69, 0x0a, 0xdc,
+	0xf9, 0x29, 0x59, 0xbc, 0x77, 0x5e, 0x2c, 0x78, 0xb4, 0x3c, 0xe1, 0x91, 0x6c, 0xfe, 0xee, 0xca,
+	0xac, 0x96, 0x52, 0xde, 0x28, 0xe5, 0xcf, 0x32, 0x1c, 0x64, 0xf1, 0xeb, 0x25, 0xbd, 0x04, 0x48,
+	0xe6, 0x7c, 0xec, 0x73, 0xfb, 0x26, 0x51, 0xab, 0xd2, 0x15, 0x8b, 0x14, 0x65, 0x81, 0x93, 0x7a,
+	0xcb, 0x89, 0xb7, 0xc0, 0xf2, 0x56, 0x49, 0x98, 0xf2, 0xe2, 0x50, 0x2b, 0x8f, 0xdf, 0x2a, 0x05,
+	0x19, 0xbf, 0x96, 0x2b, 0xe3, 0x24, 0x71, 0xd5, 0x47, 0xe3, 0x72, 0xaa, 0xac, 0xd5, 0x61, 0xb1,
+	0xcd, 0x02, 0xc7, 0x0b, 0xdc, 0xe4, 0x7e, 0x51, 0xe8, 0x8a, 0x65, 0x6d, 0xbc, 0x5b, 0x0f, 0x8c,
+	0xb7, 0xbe, 0x31, 0xde, 0x36, 0x34, 0x93, 0xa1, 0x24, 0x0b, 0x39, 0x62, 0x5f, 0x04, 0x6e, 0x42,
+	0xd9, 0x73, 0xb2, 0xd9, 0x96, 0x3d, 0xa7, 0xf3, 0x0d, 0xec, 0xdd, 0x32, 0x06, 0x3e, 0x8f, 0xd9,
+	0x1d, 0xca, 0x6b, 0x40, 0x2b, 0xdb, 0x74, 0xbc, 0x14, 0x2c, 0xc6, 0x6d, 0xd8, 0x8e, 0x6e, 0x61,
+	0x42, 0xde, 0xa1, 0xab, 0xa6, 0xce, 0x1f, 0xa5, 0x6c, 0x47, 0x28, 0x8b, 0x43, 0x1e, 0xc4, 0x0c,
+	0xf7, 0xa1, 0x9e, 0x12, 0xf2, 0xd3, 0xa4, 0xe6, 0xa7, 0x69, 0x53, 0x9e, 0xe6, 0x44, 0xfc, 0x1c,
+	0x94, 0x99, 0x15, 0x9b, 0x73, 0x1e, 0xa5, 0x27, 0x56, 0xa1, 0xf5, 0x99, 0x15, 0x5f, 0xf0, 0x28,
+	0x2f, 0xb3, 0x92, 0x97, 0xf9, 0xe0, 0x99, 0x70, 0xe1, 0x70, 0xad, 0x96, 0x62, 0x49, 0xfa, 0x70,
+	0xf8, 0x89, 0x09, 0x7b, 0xc6, 0x1c, 0x33, 0x62, 0x36, 0x8f, 0x9c, 0xd8, 0xb4, 0xf9, 0x22, 0x10,
+	0xd9, 0x12, 0xef, 0x67, 0x4e, 0x9a, 0xfa, 0x06, 0xd2, 0xf5, 0xd0, 0x3e, 0x7f, 0x77, 0x04, 0x3b,
+	0x52, 0x5b, 0xb7, 0x84, 0x75, 0xc6, 0x96, 0x31, 0x56, 0xe1, 0xe0, 0x83, 0x76, 0x3e, 0xd4, 0x35,
+	0x79, 0xad, 0x9a, 0x13, 0x8d, 0x6a, 0x17, 0x44, 0x5e, 0xcb, 0x4f, 0xfa, 0x1f, 0x57, 0xde, 0x3f,
+	0xd3, 0x45, 0x18, 0xf2, 0x48, 0x60, 0x1d, 0x14, 0xca, 0x5c, 0x2f, 0x16, 0x2c, 0xc2, 0xea, 0x7d,
+	0xaf, 0x9f, 0xd6, 0xbd, 0x9e, 0xce, 0x93, 0xa3, 0xd2, 0xf7, 0xa5, 0xe3, 0x31, 0x74, 0x78, 0xe4,
+	0x76, 0x67, 0xcb, 0x90, 0x45, 0x3e, 0x73, 0x5c, 0x16, 0x75, 0x3f, 0x59, 0xd7, 0x91, 0x67, 0xe7,
+	0x71, 0xf2, 0xc1, 0xf6, 0xf3, 0xb7, 0xae, 0x27, 0x66, 0x8b, 0xeb, 0xae, 0xcd, 0xe7, 0xbd, 0x15,
+	0x6a, 0x2f, 0xa5, 0xa6, 0x0f, 0xb7, 0xb8, 0x27, 0xa9, 0xd7, 0xe9, 0x2b, 0xf0, 0x87, 0x7f, 0x06,
+	0x00, 0xd0, 0xaf, 0xd9, 0x77, 0x29, 0x0a, 0x00, 0x00,
 }
@@ -101,6 +101,19 @@ func GetLastConfigIndexFromBlockOrPanic(block *cb.Block) uint64 {
 	return index
 }
 
+// GetCommitHashFromBlock retrieves the commit hash, as computed by the peer that committed the block and encoded
+// in the block metadata. A nil commit hash is returned if the block does not carry a commit hash
+func GetCommitHashFromBlock(block *cb.Block) ([]byte, error) {
+	if block.Metadata == nil || len(block.Metadata.Metadata) <= int(cb.BlockMetadataIndex_COMMIT_HASH) {
+		return nil, nil
+	}
+	md, err := GetMetadataFromBlock(block, cb.BlockMetadataIndex_COMMIT_HASH)
+	if err != nil {
+		return nil, err
+	}
+	return md.Value, nil
+}
+
 // GetBlockFromBlockBytes marshals the bytes into Block
 func GetBlockFromBlockBytes(blockBytes []byte) (*cb.Block, error) {
 	block := &cb.Block{}
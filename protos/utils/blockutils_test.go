@@ -215,3 +215,30 @@ func TestGetLastConfigIndexFromBlock(t *testing.T) {
 		_ = utils.GetLastConfigIndexFromBlockOrPanic(block)
 	}, "Expected panic with malformed last config metadata")
 }
+
+func TestGetCommitHashFromBlock(t *testing.T) {
+	// block without the commit hash entry in the metadata
+	block := &cb.Block{}
+	utils.InitBlockMetadata(block)
+	commitHash, err := utils.GetCommitHashFromBlock(block)
+	assert.NoError(t, err, "Unexpected error returning commit hash")
+	assert.Nil(t, commitHash, "Expected no commit hash for a block without the commit hash metadata")
+
+	block = common.NewBlock(0, nil)
+	commitHash, err = utils.GetCommitHashFromBlock(block)
+	assert.NoError(t, err, "Unexpected error returning commit hash")
+	assert.Nil(t, commitHash, "Expected no commit hash for a new block")
+
+	metadata, _ := proto.Marshal(&cb.Metadata{
+		Value: []byte("commit hash"),
+	})
+	block.Metadata.Metadata[cb.BlockMetadataIndex_COMMIT_HASH] = metadata
+	commitHash, err = utils.GetCommitHashFromBlock(block)
+	assert.NoError(t, err, "Unexpected error returning commit hash")
+	assert.Equal(t, []byte("commit hash"), commitHash, "Unexpected commit hash returned from block")
+
+	// malformed metadata
+	block.Metadata.Metadata[cb.BlockMetadataIndex_COMMIT_HASH] = []byte("bad metadata")
+	_, err = utils.GetCommitHashFromBlock(block)
+	assert.Error(t, err, "Expected error with malformed metadata")
+}